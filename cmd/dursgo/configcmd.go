@@ -0,0 +1,211 @@
+package main
+
+import (
+	"Dursgo/internal/config"
+	"Dursgo/internal/control"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/metrics"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/reporter"
+	"Dursgo/internal/scanner"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultConfigFile is the configuration file loaded unless -config names another one.
+const defaultConfigFile = "config.yaml"
+
+// configFileArgs returns the values of the -config, -profile and -policy flags in args, which
+// are needed before the other flags can be defined. A missing -config returns defaultConfigFile.
+func configFileArgs(args []string) (file, profile, policy string) {
+	file = defaultConfigFile
+	for i := 0; i < len(args); i++ {
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[i], "-"), "=")
+		if !strings.HasPrefix(args[i], "-") || (name != "config" && name != "profile" && name != "policy") {
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				continue // flag.Parse reports the missing value.
+			}
+			i++
+			value = args[i]
+		}
+		switch name {
+		case "config":
+			file = value
+		case "profile":
+			profile = value
+		default:
+			policy = value
+		}
+	}
+	return file, profile, policy
+}
+
+// runConfigCommand runs "dursgo config validate" or "dursgo config init" and returns the exit
+// status.
+func runConfigCommand(log *logger.Logger, args []string) int {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  %s config validate [-config file] [-profile name]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s config init [-force] [file]\n", os.Args[0])
+	}
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+	switch args[0] {
+	case "validate":
+		return validateConfigFile(log, args[1:])
+	case "init":
+		return writeConfigTemplate(log, args[1:])
+	default:
+		log.Error("Unknown config command %q.", args[0])
+		usage()
+		return 2
+	}
+}
+
+// validateConfigFile checks a configuration file: unknown keys and invalid values of the file and
+// of each of its profiles (or only of -profile), scanner selections and options, and payload
+// files.
+func validateConfigFile(log *logger.Logger, args []string) int {
+	flags := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	file := flags.String("config", defaultConfigFile, "Configuration file to check")
+	profile := flags.String("profile", "", "Check only this profile")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if _, err := os.Stat(*file); err != nil {
+		log.Error("%v", err)
+		return 1
+	}
+
+	profiles := []string{*profile}
+	if *profile == "" {
+		names, err := config.ProfileNames(*file)
+		if err == nil {
+			profiles = append(profiles, names...)
+		}
+	}
+	valid := true
+	for _, name := range profiles {
+		label := *file
+		if name != "" {
+			label = fmt.Sprintf("%s (profile %s)", *file, name)
+		}
+		cfg, err := config.Load(*file, name)
+		if err == nil {
+			err = checkConfigValues(cfg)
+		}
+		if err != nil {
+			valid = false
+			for _, line := range strings.Split(err.Error(), "\n") {
+				log.Error("%s: %s", label, line)
+			}
+			if name == "" && cfg == nil {
+				break // Unknown keys are reported once, for all profiles.
+			}
+		}
+	}
+	if !valid {
+		return 1
+	}
+	switch {
+	case *profile != "":
+		log.Success("%s is valid with profile %s.", *file, *profile)
+	case len(profiles) > 1:
+		log.Success("%s is valid, including profile(s) %s.", *file, strings.Join(profiles[1:], ", "))
+	default:
+		log.Success("%s is valid.", *file)
+	}
+	return 0
+}
+
+// checkConfigValues checks the values of cfg that config.Validate leaves to the packages using
+// them.
+func checkConfigValues(cfg *config.Config) error {
+	var errs []error
+	if _, err := scanner.Resolve(scanner.Selection{
+		Base:     cfg.Scanners,
+		Enable:   cfg.EnableScanners,
+		Disable:  cfg.DisableScanners,
+		Settings: cfg.ScannerSettings,
+	}, scanner.Env{OAST: cfg.OAST, Renderer: cfg.RenderJS}); err != nil {
+		errs = append(errs, fmt.Errorf("scanners: %v", err))
+	}
+	if _, err := httpclient.ParseTLSVersion(cfg.TLS.MinVersion); err != nil {
+		errs = append(errs, fmt.Errorf("tls.min_version: %v", err))
+	}
+	if _, _, err := reporter.ParseFailOn(cfg.FailOn); err != nil {
+		errs = append(errs, fmt.Errorf("fail_on: %v", err))
+	}
+	if cfg.FailOnNew != "" {
+		if _, err := reporter.ParseSeverity(cfg.FailOnNew); err != nil {
+			errs = append(errs, fmt.Errorf("fail_on_new: %v", err))
+		}
+	}
+	if cfg.Notifications.MinSeverity != "" {
+		if _, err := reporter.ParseSeverity(cfg.Notifications.MinSeverity); err != nil {
+			errs = append(errs, fmt.Errorf("notifications.min_severity: %v", err))
+		}
+	}
+	if cfg.Suppressions != "" {
+		if _, err := reporter.LoadSuppressions(cfg.Suppressions); err != nil {
+			errs = append(errs, fmt.Errorf("suppressions: %v", err))
+		}
+	}
+	if cfg.Control != "" {
+		if _, _, err := control.ParseAddr(cfg.Control); err != nil {
+			errs = append(errs, fmt.Errorf("control: %v", err))
+		}
+	}
+	if cfg.MetricsAddr != "" {
+		if err := metrics.CheckAddr(cfg.MetricsAddr); err != nil {
+			errs = append(errs, fmt.Errorf("metrics_addr: %v", err))
+		}
+	}
+	for name, file := range cfg.PayloadFiles {
+		if err := payloads.CheckPayloadFile(name, file); err != nil {
+			errs = append(errs, fmt.Errorf("payload_files.%s: %v", name, err))
+		}
+	}
+	for i, file := range cfg.PayloadSets {
+		if err := payloads.CheckPayloadSet(file); err != nil {
+			// One error per line, each naming the file.
+			prefix := fmt.Sprintf("payload_sets.%d: ", i)
+			errs = append(errs, errors.New(prefix+strings.ReplaceAll(err.Error(), "\n", "\n"+prefix)))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// writeConfigTemplate writes the commented configuration template to the file given in args, or
+// to stdout. An existing file is only overwritten with -force.
+func writeConfigTemplate(log *logger.Logger, args []string) int {
+	flags := flag.NewFlagSet("config init", flag.ContinueOnError)
+	force := flags.Bool("force", false, "Overwrite an existing file")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		os.Stdout.Write(config.Template)
+		return 0
+	}
+	file := flags.Arg(0)
+	if _, err := os.Stat(file); err == nil && !*force {
+		log.Error("%s already exists; use -force to overwrite it.", file)
+		return 1
+	}
+	if err := os.WriteFile(file, config.Template, 0o644); err != nil {
+		log.Error("Failed to write %s: %v", file, err)
+		return 1
+	}
+	log.Success("Wrote a configuration template to %s.", file)
+	return 0
+}
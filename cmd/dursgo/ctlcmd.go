@@ -0,0 +1,104 @@
+package main
+
+import (
+	"Dursgo/internal/config"
+	"Dursgo/internal/control"
+	"Dursgo/internal/logger"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// runCtlCommand runs "dursgo ctl": it sends a command to the control interface of a scan running
+// with -control, and prints the answer.
+func runCtlCommand(log *logger.Logger, args []string) int {
+	flags := flag.NewFlagSet("ctl", flag.ContinueOnError)
+	addr := flags.String("addr", "", "Address of the control interface (default: control in config.yaml, else "+control.DefaultAddr+")")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s ctl [-addr address] command\n\nCommands:\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  status           Show the progress of the scan and the state set through ctl\n")
+		fmt.Fprintf(os.Stderr, "  pause            Hold every request until resume; requests in flight complete\n")
+		fmt.Fprintf(os.Stderr, "  resume           Resume a paused scan\n")
+		fmt.Fprintf(os.Stderr, "  skip-host [host] Give up a host, by default the one of the last request sent\n")
+		fmt.Fprintf(os.Stderr, "  rate rps         Set the rate limit in requests per second (0 = unlimited)\n\n")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *addr == "" {
+		*addr = control.DefaultAddr
+		if cfg, err := config.Load(defaultConfigFile, ""); err == nil && cfg.Control != "" {
+			*addr = cfg.Control
+		}
+	}
+
+	var path string
+	var form url.Values
+	switch command := flags.Arg(0); {
+	case command == "status" && flags.NArg() == 1:
+		path = "/status"
+	case (command == "pause" || command == "resume") && flags.NArg() == 1:
+		path, form = "/"+command, url.Values{}
+	case command == "skip-host" && flags.NArg() <= 2:
+		path, form = "/skip-host", url.Values{}
+		if host := flags.Arg(1); host != "" {
+			form.Set("host", host)
+		}
+	case command == "rate" && flags.NArg() == 2:
+		path, form = "/rate", url.Values{"rps": {flags.Arg(1)}}
+	default:
+		flags.Usage()
+		return 2
+	}
+
+	data, err := control.Call(context.Background(), *addr, path, form)
+	if err != nil {
+		log.Error("%v", err)
+		return 1
+	}
+	if form != nil {
+		fmt.Print(string(data))
+		return 0
+	}
+	var status control.Status
+	if err := json.Unmarshal(data, &status); err != nil {
+		log.Error("Invalid status from %s: %v", *addr, err)
+		return 1
+	}
+	printControlStatus(status)
+	return 0
+}
+
+// printControlStatus prints the status of a scan for "dursgo ctl status".
+func printControlStatus(s control.Status) {
+	state := "running"
+	if s.Paused {
+		state = "paused"
+	}
+	limit := "unlimited"
+	if s.RateLimit > 0 {
+		limit = fmt.Sprintf("%.2f req/s", s.RateLimit)
+	}
+	fmt.Printf("State:      %s\n", state)
+	fmt.Printf("Progress:   %d%% (%d/%d), ETA %s\n", s.Percent, s.Completed, s.Total, s.ETA)
+	fmt.Printf("Requests:   %d (%.1f req/s, limit %s)\n", s.Requests, s.Rate, limit)
+	fmt.Printf("Findings:   %d\n", s.Findings)
+	if s.CurrentHost != "" {
+		fmt.Printf("Last host:  %s\n", s.CurrentHost)
+	}
+	if len(s.SkippedHosts) > 0 {
+		fmt.Printf("Skipped:    %s\n", strings.Join(s.SkippedHosts, ", "))
+	}
+	for _, a := range s.Actions {
+		line := a.Time.Local().Format("15:04:05") + " " + a.Action
+		if a.Detail != "" {
+			line += " (" + a.Detail + ")"
+		}
+		fmt.Printf("Action:     %s\n", line)
+	}
+}
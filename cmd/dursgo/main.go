@@ -5,57 +5,108 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"Dursgo/internal/ai" // Import the new AI package
 	"Dursgo/internal/config"
+	"Dursgo/internal/control"
 	"Dursgo/internal/crawler"
 	"Dursgo/internal/discovery"
 	"Dursgo/internal/enrichment"
 	"Dursgo/internal/fingerprint"
 	"Dursgo/internal/httpclient"
 	"Dursgo/internal/logger"
+	"Dursgo/internal/metrics"
+	"Dursgo/internal/notify"
+	"Dursgo/internal/oob"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/progress"
 	"Dursgo/internal/renderer"
 	"Dursgo/internal/reporter"
 	"Dursgo/internal/scanner"
-	"Dursgo/internal/scanner/blindssrf"
-	"Dursgo/internal/scanner/bola"
-	"Dursgo/internal/scanner/cmdinjection"
-	"Dursgo/internal/scanner/cors"
-	"Dursgo/internal/scanner/csrf"
-	"Dursgo/internal/scanner/domxss"
-	"Dursgo/internal/scanner/exposed"
-	"Dursgo/internal/scanner/fileupload"
-	"Dursgo/internal/scanner/graphql"
-	"Dursgo/internal/scanner/idor"
-	"Dursgo/internal/scanner/lfi"
-	"Dursgo/internal/scanner/massassignment"
-	"Dursgo/internal/scanner/openredirect"
-	"Dursgo/internal/scanner/securityheaders"
-	"Dursgo/internal/scanner/sqli"
-	"Dursgo/internal/scanner/ssrf"
-	"Dursgo/internal/scanner/ssti"
+	// The scanner packages register their scanners with the scanner registry.
+	_ "Dursgo/internal/scanner/backupfiles"
+	_ "Dursgo/internal/scanner/blindssrf"
+	_ "Dursgo/internal/scanner/bola"
+	_ "Dursgo/internal/scanner/bruteforce"
+	_ "Dursgo/internal/scanner/cachepoisoning"
+	_ "Dursgo/internal/scanner/cmdinjection"
+	_ "Dursgo/internal/scanner/cms"
+	_ "Dursgo/internal/scanner/cookies"
+	_ "Dursgo/internal/scanner/cors"
+	_ "Dursgo/internal/scanner/crlf"
+	_ "Dursgo/internal/scanner/csrf"
+	_ "Dursgo/internal/scanner/deserialization"
+	_ "Dursgo/internal/scanner/domxss"
+	"Dursgo/internal/scanner/errorpages"
+	_ "Dursgo/internal/scanner/exposed"
+	_ "Dursgo/internal/scanner/fileupload"
+	_ "Dursgo/internal/scanner/graphql"
+	_ "Dursgo/internal/scanner/hostheader"
+	_ "Dursgo/internal/scanner/idor"
+	_ "Dursgo/internal/scanner/jwt"
+	_ "Dursgo/internal/scanner/lfi"
+	_ "Dursgo/internal/scanner/massassignment"
+	_ "Dursgo/internal/scanner/methodtampering"
+	_ "Dursgo/internal/scanner/misconfig"
+	_ "Dursgo/internal/scanner/nosqli"
+	_ "Dursgo/internal/scanner/openredirect"
+	_ "Dursgo/internal/scanner/prototypepollution"
+	_ "Dursgo/internal/scanner/secrets"
+	_ "Dursgo/internal/scanner/securityheaders"
+	_ "Dursgo/internal/scanner/sqli"
+	_ "Dursgo/internal/scanner/ssrf"
+	_ "Dursgo/internal/scanner/ssti"
+	_ "Dursgo/internal/scanner/takeover"
+	"Dursgo/internal/scanner/timing"
 	"Dursgo/internal/scanner/xss"
-	"regexp"
-
-	"github.com/projectdiscovery/interactsh/pkg/client"
-	"github.com/projectdiscovery/interactsh/pkg/server"
+	_ "Dursgo/internal/scanner/xxe"
+	"Dursgo/internal/soft404"
+	"Dursgo/internal/state"
 )
 
+// version is the version of the Dursgo build, set with -ldflags "-X main.version=...".
+var version = "dev"
+
 // main is the entry point of the Dursgo application.
 func main() {
 	// Initialize logger with INFO level.
 	log := logger.NewLogger(logger.INFO)
 	startTime := time.Now()
 
-	// Load configuration from config.yaml.
-	cfg, err := config.LoadConfig("config.yaml")
+	// "dursgo config validate|init" checks or writes a configuration file without scanning.
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(log, os.Args[2:]))
+	}
+	// "dursgo verify" replays the findings of a report.
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		os.Exit(runVerifyCommand(log, os.Args[2:]))
+	}
+	// "dursgo ctl" controls a scan running with -control.
+	if len(os.Args) > 1 && os.Args[1] == "ctl" {
+		os.Exit(runCtlCommand(log, os.Args[2:]))
+	}
+
+	// Load the configuration file (config.yaml unless -config is given) and the -profile in it,
+	// before flags are defined: their defaults are the values of the file.
+	configFile, profile, policy := configFileArgs(os.Args[1:])
+	if configFile != defaultConfigFile {
+		if _, statErr := os.Stat(configFile); statErr != nil {
+			log.Error("Failed to load config: %v", statErr)
+			os.Exit(1)
+		}
+	}
+	cfg, err := config.Load(configFile, profile)
 	if err != nil {
 		log.Error("Failed to load config: %v", err)
 	} else {
@@ -67,20 +118,26 @@ func main() {
 		}
 	}
 	if err != nil {
-		log.Error("Failed to load config.yaml: %v", err)
 		os.Exit(1)
 	}
-
-	// Handle old authentication config format for backward compatibility.
-	if cfg.Authentication.Type == "header" && cfg.Authentication.HeaderName != "" && cfg.Authentication.Value != "" {
-		log.Info("Old authentication config format detected. Converting to new 'headers' format.")
-		if cfg.Authentication.Headers == nil {
-			cfg.Authentication.Headers = make(map[string]string)
+	if profile != "" {
+		log.Info("Using profile %s of %s.", profile, configFile)
+	}
+	// A scan policy (-policy, or policy: in the file) overrides the file and is itself
+	// overridden by the flags set with it.
+	if policy == "" {
+		policy = cfg.Policy
+	}
+	if policy != "" {
+		if err := cfg.ApplyPolicy(policy); err != nil {
+			log.Error("%v", err)
+			os.Exit(1)
 		}
-		cfg.Authentication.Headers[cfg.Authentication.HeaderName] = cfg.Authentication.Value
-		log.Debug("Converted old auth config to: Headers[%s] = %s", cfg.Authentication.HeaderName, "token_value")
+		log.Info("Using scan policy %s.", cfg.Policy)
 	}
 
+	convertLegacyAuthentication(log, cfg)
+
 	// Parse command-line arguments.
 	args := os.Args[1:]
 	for i, arg := range args {
@@ -109,24 +166,131 @@ func main() {
 	// --- Custom Flag Definitions & Help Screen ---
 
 	// Define command-line flags.
-	var targetURLStr, scannersToRunStr, jsonOutputFile string
-	var concurrency, maxRetries, delay, maxDepth int
-	var verbose, trace, oast, enableEnrichment, updateKEV, renderJS, enableAI bool
+	var retestFile, metricsAddr string
+	var targetURLStr, scannersToRunStr, enableScannersStr, disableScannersStr, jsonOutputFile, outputFormat, outputFile, oobListen, oobURL, similarityMode, crawlModeStr, apiSpecFile, stateFile, sortFindings, baselineFile, failOnNew, failOn, suppressionsFile, harOutput, controlAddr, proxyURL, caCertFile, defaultCookies, clientCertFile, clientKeyFile, serverName, minTLSVersion, httpVersion, dnsResolver, logFormat, scannerLogLevels, paramWordlist, payloadTierStr, evasionStr, minConfidence string
+	var similarityThreshold, requestsPerSecond, minCVSS float64
+	var targetsFile string
+	var flagTargets []string
+	var parallelTargets int
+	var concurrency, maxRetries, delay, maxDepth, maxRequestsPerParam, maxProbesPerHost, paramChunkSize, maxParamProbes, dedupRepresentatives, maxPagesPerHost, crawlDelay, perHostConcurrency, maxEvidenceBytes, retryBackoff, bodyReadTimeout, harMaxBodyBytes, timeConfirmations, oastWait int
+	var maxResponseBytes int64
+	var verbose, trace, oast, enableEnrichment, updateKEV, renderJS, enableAI, injectHeaders, pocExtraction, skipInertParams, discoverContent, forcePrototypePollution, resume, excludeRaw, noCollapseFindings, recordCoverage, rotateUserAgent, noBlockDetection, insecureSkipVerify, quiet bool
 
 	flag.StringVar(&targetURLStr, "u", cfg.Target, "Target URL for scanning")
+	flag.Func("target", "Additional target URL, scanned separately with the same settings (repeatable)", func(target string) error {
+		if err := checkTarget(target); err != nil {
+			return err
+		}
+		flagTargets = append(flagTargets, target)
+		return nil
+	})
+	flag.StringVar(&targetsFile, "targets-file", cfg.TargetsFile, "File of target URLs to scan, one per line")
+	flag.IntVar(&parallelTargets, "parallel-targets", cfg.ParallelTargets, "Number of targets scanned concurrently (default 1)")
 	flag.StringVar(&scannersToRunStr, "s", cfg.Scanners, "Comma-separated list of scanners to run (e.g., xss,sqli)")
+	flag.StringVar(&enableScannersStr, "enable-scanners", strings.Join(cfg.EnableScanners, ","), "Comma-separated scanners to add to the -s selection")
+	flag.StringVar(&disableScannersStr, "disable-scanners", strings.Join(cfg.DisableScanners, ","), "Comma-separated scanners to remove from the -s selection")
 	flag.IntVar(&concurrency, "c", cfg.Concurrency, "Number of concurrent workers/threads")
+	flag.IntVar(&concurrency, "concurrency", cfg.Concurrency, "Number of concurrent workers/threads (same as -c)")
+	flag.IntVar(&perHostConcurrency, "per-host-concurrency", cfg.PerHostConcurrency, "Maximum concurrent requests to one host (0 = unlimited)")
 	flag.IntVar(&maxDepth, "d", cfg.MaxDepth, "Maximum crawling depth")
 	flag.IntVar(&delay, "delay", cfg.Delay, "Delay between requests in milliseconds (ms)")
+	flag.IntVar(&maxPagesPerHost, "max-pages-per-host", cfg.MaxPagesPerHost, "Maximum pages crawled per host (0 = unlimited)")
+	flag.IntVar(&crawlDelay, "crawl-delay", cfg.CrawlDelay, "Minimum delay between crawler requests to the same host in milliseconds")
 	flag.IntVar(&maxRetries, "r", cfg.MaxRetries, "Maximum number of retries for failed requests")
+	flag.Int64Var(&maxResponseBytes, "max-response-bytes", cfg.MaxResponseBytes, "Size response bodies are cut off at in bytes (0 = 5 MiB, negative = unlimited)")
+	flag.IntVar(&bodyReadTimeout, "body-read-timeout", cfg.BodyReadTimeout, "Time allowed for reading a response body in seconds (0 = 10, negative = none)")
+	flag.IntVar(&retryBackoff, "retry-backoff", cfg.RetryBackoff, "Wait before the first retry of a transient failure in milliseconds, doubled for each further retry (0 = 1000)")
+	flag.StringVar(&proxyURL, "proxy", cfg.Proxy, "Upstream proxy for all requests: http://, https:// or socks5:// (e.g., http://127.0.0.1:8080)")
+	flag.StringVar(&caCertFile, "ca-cert", cfg.CACert, "PEM bundle of extra CAs to trust, e.g. the CA of an intercepting proxy")
+	flag.StringVar(&clientCertFile, "client-cert", cfg.TLS.ClientCert, "PEM client certificate for mTLS-protected targets")
+	flag.StringVar(&clientKeyFile, "client-key", cfg.TLS.ClientKey, "PEM private key of the client certificate")
+	flag.BoolVar(&insecureSkipVerify, "insecure", cfg.TLS.InsecureSkipVerify, "Skip TLS certificate verification (unsafe)")
+	flag.StringVar(&serverName, "sni", cfg.TLS.ServerName, "TLS server name (SNI) sent and verified instead of the target host")
+	flag.StringVar(&minTLSVersion, "min-tls", cfg.TLS.MinVersion, "Minimum TLS version: 1.0, 1.1, 1.2 or 1.3")
+	flag.StringVar(&httpVersion, "http-version", cfg.HTTPVersion, "HTTP version: 1.1 or 2 (HTTP/2 over TLS)")
+	flag.StringVar(&dnsResolver, "resolver", cfg.DNS.Resolver, "DNS server (host or host:port) used instead of the system resolver")
+	// -resolve may be repeated; it adds to the overrides of config.yaml.
+	hostOverrides := append([]string(nil), cfg.DNS.Resolve...)
+	flag.Func("resolve", "Connect to an address instead of resolving a host, as \"host:port:address\" (repeatable)", func(entry string) error {
+		if _, err := httpclient.ParseResolve(entry); err != nil {
+			return err
+		}
+		hostOverrides = append(hostOverrides, entry)
+		return nil
+	})
+	// -H may be repeated; it adds to and overrides the headers of config.yaml.
+	defaultHeaders := make(map[string]string, len(cfg.Headers))
+	for name, value := range cfg.Headers {
+		defaultHeaders[http.CanonicalHeaderKey(name)] = value
+	}
+	flag.Func("H", "Header sent with every request, as \"Name: value\" (repeatable)", func(line string) error {
+		name, value, err := httpclient.ParseHeader(line)
+		if err == nil {
+			defaultHeaders[name] = value
+		}
+		return err
+	})
+	flag.StringVar(&defaultCookies, "cookie", cfg.Cookies, "Cookies sent with every request (e.g., \"a=1; b=2\")")
+	flag.BoolVar(&rotateUserAgent, "rotate-user-agent", cfg.RotateUserAgent, "Pick the User-Agent of each request at random from a list")
+	flag.BoolVar(&noBlockDetection, "no-block-detection", cfg.BlockDetection.Disabled, "Don't detect WAF blocking and rate limiting")
+	flag.Float64Var(&requestsPerSecond, "rps", cfg.RequestsPerSecond, "Maximum requests per second shared by all scanners (0 = unlimited)")
+	flag.IntVar(&maxRequestsPerParam, "max-requests-per-param", cfg.MaxRequestsPerParam, "Request budget per parameter for SQLi tests (0 = unlimited)")
+	flag.StringVar(&payloadTierStr, "payload-tier", cfg.PayloadTier, "Payloads sent from each list: minimal, standard or full (default)")
+	flag.StringVar(&evasionStr, "evasion", cfg.Evasion, "Encoded payload variants for SQLi, XSS and command injection: none (default), low, high or a list of transformations")
+	flag.IntVar(&timeConfirmations, "time-confirmations", cfg.TimeConfirmations, "Increasing delays a time-based finding must be reproduced with (0 = 2)")
+	flag.BoolVar(&discoverContent, "discover", cfg.ContentDiscovery, "Brute-force common paths under discovered directories after crawling")
+	flag.IntVar(&maxProbesPerHost, "max-probes-per-host", cfg.MaxProbesPerHost, "Cap on content discovery requests per host (0 = unlimited)")
+	flag.StringVar(&paramWordlist, "param-wordlist", "", "File of parameter names for parameter discovery, one per line")
+	flag.IntVar(&paramChunkSize, "param-chunk-size", cfg.ParamChunkSize, "Parameter names sent per parameter discovery request (0 = 30)")
+	flag.IntVar(&maxParamProbes, "max-param-probes", cfg.MaxParamProbesPerURL, "Parameter discovery requests per endpoint (0 = unlimited)")
+	flag.IntVar(&dedupRepresentatives, "dedup-representatives", cfg.DedupRepresentatives, "Requests scanned per group of structurally identical requests (0 = 2, -1 = all)")
 	flag.BoolVar(&oast, "oast", cfg.OAST, "Enable OAST (Out-of-Band) for blind vulnerabilities")
+	flag.StringVar(&oobListen, "oob-listen", cfg.OOBListen, "Run a local OOB HTTP listener on this address instead of Interactsh (e.g., :8880)")
+	flag.StringVar(&oobURL, "oob-url", cfg.OOBURL, "Public URL targets use to reach the local OOB listener")
+	flag.IntVar(&oastWait, "oast-wait", cfg.OASTWait, "Seconds the collaborator is still polled for callbacks after the scan")
 	flag.StringVar(&jsonOutputFile, "output-json", "", "Path to save the report file in JSON format")
+	flag.StringVar(&outputFormat, "output-format", cfg.Output.Format, "Findings file format: text (none), json, jsonl or html")
+	flag.StringVar(&outputFile, "output", cfg.Output.FindingsFile, "Path of the findings file for -output-format json, jsonl or html")
+	flag.IntVar(&maxEvidenceBytes, "max-evidence-bytes", cfg.Output.MaxEvidenceBytes, "Evidence size in the findings file (0 = 4096, -1 = unlimited)")
+	flag.BoolVar(&excludeRaw, "exclude-raw", cfg.Output.ExcludeRaw, "Leave raw request/response dumps out of the findings file")
+	flag.Float64Var(&minCVSS, "min-cvss", cfg.Output.MinCVSS, "Leave findings with a lower CVSS v3.1 score out of the reports")
+	flag.StringVar(&minConfidence, "min-confidence", cfg.Output.MinConfidence, "Leave less certain findings out of the reports: certain, firm or tentative")
+	flag.StringVar(&sortFindings, "sort-findings", cfg.Output.SortFindings, "Order of the reported findings: found or cvss")
+	flag.BoolVar(&noCollapseFindings, "no-collapse-findings", cfg.Output.NoCollapseFindings, "Report a finding once per URL instead of once per fingerprint")
+	flag.BoolVar(&recordCoverage, "coverage", cfg.Output.Coverage, "List whether each parameter was tested by each scanner, or why not, in the reports")
+	flag.StringVar(&stateFile, "state-file", cfg.StateFile, "File the scan progress is saved to, to resume an interrupted scan")
+	flag.StringVar(&harOutput, "har-output", cfg.HAROutput, "HAR 1.2 file every request and response is recorded to")
+	flag.IntVar(&harMaxBodyBytes, "har-max-body-bytes", cfg.HARMaxBodyBytes, "Size bodies are truncated to in the HAR file in bytes (0 = 1 MiB, negative = whole bodies)")
+	flag.StringVar(&controlAddr, "control", cfg.Control, "Serve the control interface of the scan (dursgo ctl) on this loopback host:port or unix:/path")
+	flag.StringVar(&metricsAddr, "metrics-addr", cfg.MetricsAddr, "Serve Prometheus metrics of the running scan at /metrics on this loopback host:port")
+	flag.BoolVar(&resume, "resume", false, "Resume the interrupted scan saved in the state file")
+	flag.StringVar(&baselineFile, "baseline", cfg.Baseline, "Findings document or JSON report of a previous scan to compare findings with")
+	flag.StringVar(&retestFile, "retest", "", "Findings document or JSON report whose findings are re-tested, without crawling")
+	flag.StringVar(&failOnNew, "fail-on-new", cfg.FailOnNew, "Exit with status 3 when a new finding of at least this severity is reported")
+	flag.StringVar(&failOn, "fail-on", cfg.FailOn, "Exit with status 3 when a finding of at least this severity, and optionally confidence, is reported (none, low, medium, high, critical; e.g. high:firm)")
+	flag.StringVar(&suppressionsFile, "suppressions", cfg.Suppressions, "Suppression file (YAML) of known-accepted findings")
 	flag.BoolVar(&renderJS, "render-js", cfg.RenderJS, "Enable JavaScript rendering for crawling SPAs")
+	flag.StringVar(&crawlModeStr, "crawl-mode", cfg.CrawlMode, "Crawl mode: static, rendered or hybrid")
+	flag.StringVar(&apiSpecFile, "api-spec", cfg.APISpec, "OpenAPI/Swagger file to scan instead of crawling HTML pages")
+	flag.Float64Var(&similarityThreshold, "similarity-threshold", cfg.SimilarityThreshold, "Similarity (0-1) below which responses count as different (default 0.95)")
+	flag.StringVar(&similarityMode, "similarity-mode", cfg.SimilarityMode, "Response comparison mode: levenshtein, structure or words")
+	flag.BoolVar(&injectHeaders, "inject-headers", cfg.InjectHeaders, "Also inject payloads into headers and cookies (SQLi)")
+	flag.BoolVar(&pocExtraction, "poc-extraction", cfg.PoCExtraction, "Exploit confirmed blind SQL injections to read the database version or user as proof (active exploitation)")
+	flag.BoolVar(&skipInertParams, "skip-inert-params", cfg.SkipInertParams, "Skip the parameters a pre-flight finds to have no effect on the response")
+	flag.BoolVar(&forcePrototypePollution, "force-prototype-pollution", cfg.ForcePrototypePollution, "Test prototype pollution on targets not fingerprinted as Node.js")
 	flag.BoolVar(&enableEnrichment, "enrich", false, "Enable vulnerability enrichment with CISA KEV data")
 	flag.BoolVar(&enableAI, "enable-ai", cfg.AI.Enabled, "Enable AI-powered vulnerability analysis")
 	flag.BoolVar(&updateKEV, "update-kev", false, "Force update CISA KEV catalog and exit")
 	flag.BoolVar(&verbose, "v", cfg.Output.Verbose, "Enable verbose output (DEBUG level)")
 	flag.BoolVar(&trace, "vv", false, "Enable trace-level output (highly verbose)")
+	flag.BoolVar(&quiet, "quiet", cfg.Output.Quiet, "Show only the progress line, findings and errors")
+	flag.StringVar(&logFormat, "log-format", cfg.Logging.Format, "Log format: text or json")
+	flag.StringVar(&scannerLogLevels, "scanner-log-level", "", "Log level per scanner, e.g. sqli=debug,xss=trace")
+	// -config and -profile are read before the flags are parsed; they are defined so that the
+	// parser accepts them.
+	flag.String("config", defaultConfigFile, "Configuration file")
+	flag.String("profile", "", "Profile of the configuration file to apply")
+	flag.String("policy", "", "Scan policy: "+strings.Join(config.PolicyNames(), ", "))
 
 	// Custom Usage function
 	flag.Usage = func() {
@@ -135,29 +299,104 @@ func main() {
 
 		fmt.Fprintf(os.Stderr, "TARGET:\n")
 		fmt.Fprintf(os.Stderr, "  -u string\n    \tTarget URL for scanning (e.g., \"http://example.com\")\n")
+		fmt.Fprintf(os.Stderr, "  -target string\n    \tFurther target URL (repeatable); each target is scanned with its own crawl scope, session and rate limits\n")
+		fmt.Fprintf(os.Stderr, "  -targets-file string\n    \tFile of target URLs to scan, one per line (# starts a comment)\n")
+		fmt.Fprintf(os.Stderr, "  -parallel-targets int\n    \tNumber of targets scanned concurrently (default: 1)\n")
 
 		fmt.Fprintf(os.Stderr, "\nSCANNERS:\n")
 		fmt.Fprintf(os.Stderr, "  -s string\n")
 		fmt.Fprintf(os.Stderr, "    \tScanners to run, comma-separated (e.g., xss,sqli,idor).\n")
 		fmt.Fprintf(os.Stderr, "    \tUse 'all' to run all scanners, 'none' for crawling only.\n")
-		fmt.Fprintf(os.Stderr, "    \tAvailable: none,xss,sqli,lfi,openredirect,ssrf,exposed,idor,csrf,cmdinjection,ssti,securityheaders,cors,fileupload,bola,massassignment,graphql,blindssrf,domxss\n")
+		fmt.Fprintf(os.Stderr, "    \tAvailable: none,%s\n", strings.Join(scanner.AvailableScanners(), ","))
+		fmt.Fprintf(os.Stderr, "  -enable-scanners string\n    \tScanners to add to the -s selection, comma-separated (e.g., -s sqli -enable-scanners xss,lfi)\n")
+		fmt.Fprintf(os.Stderr, "  -disable-scanners string\n    \tScanners to remove from the -s selection, comma-separated (e.g., -s all -disable-scanners fileupload,bola)\n")
+		fmt.Fprintf(os.Stderr, "    \tPer-scanner options and ordering are set in the 'scanners' section of config.yaml.\n")
+		fmt.Fprintf(os.Stderr, "  -policy string\n    \tScan policy bundling scanners, payload tier, time-based confirmations and delay, request budget and crawl depth:\n")
+		fmt.Fprintf(os.Stderr, "    \tquick (CI smoke test), balanced or thorough (full pentest, OAST). Other flags override its values\n")
+		fmt.Fprintf(os.Stderr, "  -payload-tier string\n    \tPayloads sent from each list: minimal, standard or full (default: full)\n")
+		fmt.Fprintf(os.Stderr, "  -evasion string\n    \tAlso send SQLi, XSS and command injection payloads encoded to get past filters: none (default), low (url, sql-comment, case),\n")
+		fmt.Fprintf(os.Stderr, "    \thigh (every transformation) or a comma-separated list of url, sql-comment, case, double-url, unicode, null-byte. Multiplies requests\n")
+		fmt.Fprintf(os.Stderr, "  -time-confirmations int\n    \tIncreasing delays a time-based SQLi or command injection finding must be reproduced with (default: 2)\n")
 
 		fmt.Fprintf(os.Stderr, "\nCRAWLING & PERFORMANCE:\n")
-		fmt.Fprintf(os.Stderr, "  -c int\n    \tNumber of concurrent workers (default: %d)\n", cfg.Concurrency)
+		fmt.Fprintf(os.Stderr, "  -c, -concurrency int\n    \tNumber of concurrent workers; scanners run on scanner/request pairs in parallel (default: %d)\n", cfg.Concurrency)
+		fmt.Fprintf(os.Stderr, "  -per-host-concurrency int\n    \tMaximum concurrent requests to one host across all workers (default: unlimited)\n")
 		fmt.Fprintf(os.Stderr, "  -d int\n    \tMaximum crawling depth (default: %d)\n", cfg.MaxDepth)
 		fmt.Fprintf(os.Stderr, "  -delay int\n    \tDelay between requests in milliseconds (ms) (default: %d)\n", cfg.Delay)
-		fmt.Fprintf(os.Stderr, "  -r int\n    \tMaximum number of retries for failed requests (default: %d)\n", cfg.MaxRetries)
+		fmt.Fprintf(os.Stderr, "  -max-pages-per-host int\n    \tMaximum pages crawled per host (default: unlimited)\n")
+		fmt.Fprintf(os.Stderr, "  -crawl-delay int\n    \tMinimum delay between crawler requests to the same host in milliseconds (politeness delay)\n")
+		fmt.Fprintf(os.Stderr, "  -r int\n    \tMaximum number of retries of transient failures: timeouts, connection resets, 429, 502, 503, 504 (default: %d)\n", cfg.MaxRetries)
+		fmt.Fprintf(os.Stderr, "  -retry-backoff int\n    \tWait before the first retry in milliseconds, doubled for each further retry, with jitter (default: 1000)\n")
+		fmt.Fprintf(os.Stderr, "  -max-response-bytes int\n    \tSize response bodies are cut off at in bytes, so huge downloads and endless streams cannot exhaust memory (default: 5 MiB, -1 = unlimited)\n")
+		fmt.Fprintf(os.Stderr, "  -body-read-timeout int\n    \tTime allowed for reading a response body once its headers arrived, in seconds (default: 10, -1 = none)\n")
+		fmt.Fprintf(os.Stderr, "  -no-block-detection\n    \tDon't detect WAF block pages and 403/429 streaks, which otherwise pause, throttle and finally give up the blocking host\n")
+		fmt.Fprintf(os.Stderr, "  -proxy string\n    \tRoute all requests through an upstream proxy: http://host:port (e.g., Burp), https:// or socks5://[user:password@]host:port\n")
+		fmt.Fprintf(os.Stderr, "  -ca-cert string\n    \tPEM bundle of extra CAs to trust, so an intercepting proxy does not break TLS\n")
+		fmt.Fprintf(os.Stderr, "  -client-cert string\n    \tPEM client certificate for mTLS-protected targets; may also hold the key\n")
+		fmt.Fprintf(os.Stderr, "  -client-key string\n    \tPEM private key of the client certificate (default: read from -client-cert)\n")
+		fmt.Fprintf(os.Stderr, "  -insecure\n    \tSkip TLS certificate verification; connections can be intercepted (unsafe)\n")
+		fmt.Fprintf(os.Stderr, "  -sni string\n    \tTLS server name (SNI) sent and verified instead of the target host\n")
+		fmt.Fprintf(os.Stderr, "  -min-tls string\n    \tMinimum TLS version: 1.0, 1.1, 1.2 or 1.3 (default: 1.2; use 1.0 for legacy servers)\n")
+		fmt.Fprintf(os.Stderr, "  -http-version string\n    \tHTTP version: 1.1 or 2, negotiated over TLS with fallback to HTTP/1.1 (default: 1.1)\n")
+		fmt.Fprintf(os.Stderr, "  -resolve string\n    \tConnect to an address instead of resolving a host, keeping the Host header and SNI, as \"host:port:address\" (repeatable, e.g., -resolve app.example.com:443:10.0.0.5; port * matches every port)\n")
+		fmt.Fprintf(os.Stderr, "  -resolver string\n    \tDNS server resolving host names instead of the system resolver, as host or host:port (e.g., 10.0.0.2:53)\n")
+		fmt.Fprintf(os.Stderr, "  -H string\n    \tHeader sent with every request, as \"Name: value\" (repeatable, e.g., -H \"X-API-Key: abc\")\n")
+		fmt.Fprintf(os.Stderr, "  -cookie string\n    \tCookies sent with every request (e.g., \"lang=en; tenant=acme\")\n")
+		fmt.Fprintf(os.Stderr, "  -rotate-user-agent\n    \tPick the User-Agent of each request at random (user_agents in config.yaml, or common browsers)\n")
+		fmt.Fprintf(os.Stderr, "  -rps float\n    \tMaximum requests per second shared by all scanners (default: unlimited)\n")
+		fmt.Fprintf(os.Stderr, "  -max-requests-per-param int\n    \tRequest budget per parameter for SQLi tests; remaining payloads are skipped (default: unlimited)\n")
+		fmt.Fprintf(os.Stderr, "  -discover\n    \tBrute-force common paths (/admin, /.env, /backup.zip, ...) under discovered directories and crawl what is found\n")
+		fmt.Fprintf(os.Stderr, "  -max-probes-per-host int\n    \tCap on content discovery requests per host (default: unlimited)\n")
+		fmt.Fprintf(os.Stderr, "  -param-wordlist string\n    \tFile of parameter names (one per line) probed for hidden parameters instead of the built-in list\n")
+		fmt.Fprintf(os.Stderr, "  -param-chunk-size int\n    \tParameter names sent per parameter discovery request (default: 30)\n")
+		fmt.Fprintf(os.Stderr, "  -max-param-probes int\n    \tParameter discovery requests per endpoint, baselines included (default: unlimited)\n")
+		fmt.Fprintf(os.Stderr, "  -dedup-representatives int\n    \tRequests scanned per group of requests differing only in IDs, e.g. /product/1 ... /product/9000 (default: 2, -1 scans all)\n")
+		fmt.Fprintf(os.Stderr, "  -api-spec string\n    \tScan the operations of an OpenAPI/Swagger file (JSON or YAML) instead of crawling HTML pages\n")
+		fmt.Fprintf(os.Stderr, "  -crawl-mode string\n    \tstatic (HTTP only), rendered (headless browser, captures XHR/fetch requests) or hybrid (both) (default: static, rendered with -render-js)\n")
 
 		fmt.Fprintf(os.Stderr, "\nDETECTION:\n")
 		fmt.Fprintf(os.Stderr, "  -oast\n    \tEnable OAST for blind vulnerabilities (e.g., Blind SSRF, Blind Command Injection)\n")
+		fmt.Fprintf(os.Stderr, "  -oob-listen string\n    \tRun a local OOB HTTP listener on this address instead of Interactsh (implies -oast)\n")
+		fmt.Fprintf(os.Stderr, "  -oob-url string\n    \tPublic URL targets use to reach the local OOB listener (e.g., http://oob.example.com:8880)\n")
+		fmt.Fprintf(os.Stderr, "  -oast-wait int\n    \tSeconds the collaborator is still polled for callbacks after the scan, e.g. for blind XSS (default 10)\n")
 		fmt.Fprintf(os.Stderr, "  -render-js\n    \tEnable JavaScript rendering via headless browser (required for 'domxss' scanner)\n")
+		fmt.Fprintf(os.Stderr, "  -similarity-threshold float\n    \tSimilarity (0-1) below which responses count as different in differential tests (default: 0.95)\n")
+		fmt.Fprintf(os.Stderr, "  -similarity-mode string\n    \tResponse comparison mode: levenshtein, structure (HTML tags only) or words (default: levenshtein)\n")
+		fmt.Fprintf(os.Stderr, "  -inject-headers\n    \tAlso inject SQLi payloads into User-Agent, Referer, X-Forwarded-For and cookies (more requests)\n")
+		fmt.Fprintf(os.Stderr, "  -poc-extraction\n    \tExploit confirmed boolean- and time-based SQL injections to read a short proof value (database version or user) into the evidence. Active exploitation; off by default\n")
+		fmt.Fprintf(os.Stderr, "  -skip-inert-params\n    \tProbe each parameter without it and with a random value first, and skip those that change nothing (blind sinks may be missed; sqli out-of-band and blindssrf still test them)\n")
+		fmt.Fprintf(os.Stderr, "  -force-prototype-pollution\n    \tRun the 'prototypepollution' scanner even if the target is not fingerprinted as Node.js\n")
 		fmt.Fprintf(os.Stderr, "  -enrich\n    \tEnable vulnerability enrichment with CISA KEV data\n")
 		fmt.Fprintf(os.Stderr, "  --enable-ai\n    \tEnable AI-powered analysis for found vulnerabilities\n")
 
 		fmt.Fprintf(os.Stderr, "\nOUTPUT & REPORTING:\n")
 		fmt.Fprintf(os.Stderr, "  -output-json string\n    \tPath to save the report file in JSON format (e.g., report.json)\n")
+		fmt.Fprintf(os.Stderr, "  -output-format string\n    \tFindings file format: text (log lines only), json (one document with scan metadata), jsonl (one finding per line, written live) or html (self-contained report)\n")
+		fmt.Fprintf(os.Stderr, "  -output string\n    \tPath of the findings file for -output-format json, jsonl or html (e.g., findings.json)\n")
+		fmt.Fprintf(os.Stderr, "  -max-evidence-bytes int\n    \tSize evidence is truncated to in the findings file (default: 4096, -1 = unlimited)\n")
+		fmt.Fprintf(os.Stderr, "  -exclude-raw\n    \tLeave raw request/response dumps out of the findings file\n")
+		fmt.Fprintf(os.Stderr, "  -min-cvss float\n    \tLeave findings with a lower CVSS v3.1 score out of the reports and findings file (e.g., 7.0)\n")
+		fmt.Fprintf(os.Stderr, "  -min-confidence string\n    \tLeave less certain findings out of the reports and findings file: certain, firm or tentative (default: keep all)\n")
+		fmt.Fprintf(os.Stderr, "  -coverage\n    \tList the outcome of every scanner/parameter test (tested, skipped with the reason, or failed with the error) in the reports\n")
+		fmt.Fprintf(os.Stderr, "  -sort-findings string\n    \tOrder of the reported findings: found (default, as reported by the scanners) or cvss (highest score first)\n")
+		fmt.Fprintf(os.Stderr, "  -no-collapse-findings\n    \tReport a finding once per URL instead of collapsing the URLs that share its type, path template and parameter\n")
+		fmt.Fprintf(os.Stderr, "  -state-file string\n    \tSave the scan progress (crawl frontier, tested requests, findings) to this file periodically\n")
+		fmt.Fprintf(os.Stderr, "  -har-output string\n    \tRecord every request sent (crawler and scanners) and its response to this HAR 1.2 file, tagged with the phase and scanner\n")
+		fmt.Fprintf(os.Stderr, "  -har-max-body-bytes int\n    \tSize bodies are truncated to in the HAR file in bytes (default: 1 MiB, -1 = whole bodies)\n")
+		fmt.Fprintf(os.Stderr, "  -control string\n    \tServe a control interface on this loopback host:port or unix:/path to pause, resume, skip hosts and adjust the rate limit of the running scan with 'dursgo ctl'\n")
+		fmt.Fprintf(os.Stderr, "  -metrics-addr string\n    \tServe the statistics of the running scan (requests, response times, scanner and phase timings, findings) in the Prometheus format at /metrics on this loopback host:port\n")
+		fmt.Fprintf(os.Stderr, "  -resume\n    \tResume the interrupted scan saved in the state file, skipping completed work\n")
+		fmt.Fprintf(os.Stderr, "  -baseline string\n    \tFindings document (-output-format json) or JSON report of a previous scan; findings are marked new, existing or resolved\n")
+		fmt.Fprintf(os.Stderr, "  -retest string\n    \tRe-test the findings of a findings document or JSON report instead of crawling: only the scanner of each finding runs, against its parameter, and each is reported still vulnerable, remediated, endpoint gone (404/410) or not re-tested\n")
+		fmt.Fprintf(os.Stderr, "  -fail-on string\n    \tExit with status 3 when a finding of at least this severity (none, critical, high, medium, low, info) is reported (default: none).\n")
+		fmt.Fprintf(os.Stderr, "    \tAppend a minimum confidence to count only surer findings, e.g. high:firm (certain, firm, tentative)\n")
+		fmt.Fprintf(os.Stderr, "  -fail-on-new string\n    \tExit with status 3 when a new finding of at least this severity (critical, high, medium, low, info) is reported\n")
+		fmt.Fprintf(os.Stderr, "  -suppressions string\n    \tSuppression file (YAML) of known-accepted findings: fingerprints, or type, url regex and parameter; matching findings are reported apart and ignored by the exit status\n")
 		fmt.Fprintf(os.Stderr, "  -v\n    \tEnable verbose output (DEBUG level)\n")
 		fmt.Fprintf(os.Stderr, "  -vv\n    \tEnable trace-level output (highly verbose)\n")
+		fmt.Fprintf(os.Stderr, "  -quiet\n    \tShow only the scan progress line, findings and errors\n")
+		fmt.Fprintf(os.Stderr, "  -log-format string\n    \tLog format: text (default) or json, one object per line with scanner, url and param fields, e.g. for ELK\n")
+		fmt.Fprintf(os.Stderr, "  -scanner-log-level string\n    \tLog level per scanner, overriding -v for it (e.g., sqli=debug keeps the other scanners at info)\n")
 
 		fmt.Fprintf(os.Stderr, "\nUTILITIES:\n")
 		fmt.Fprintf(os.Stderr, "  -update-kev\n    \tForce update CISA KEV catalog and exit\n")
@@ -165,6 +404,12 @@ func main() {
 		fmt.Fprintf(os.Stderr, "\nCONFIGURATION:\n")
 		fmt.Fprintf(os.Stderr, "  DursGo automatically loads 'config.yaml' from the current directory.\n")
 		fmt.Fprintf(os.Stderr, "  Command-line flags will override settings from the configuration file.\n")
+		fmt.Fprintf(os.Stderr, "  -config string\n    \tConfiguration file to load instead of config.yaml\n")
+		fmt.Fprintf(os.Stderr, "  -profile string\n    \tNamed profile of the configuration file's 'profiles' section to apply on top of it\n")
+		fmt.Fprintf(os.Stderr, "  %s config validate [-config file] [-profile name]\n    \tCheck a configuration file (unknown keys, invalid values) without scanning\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s config init [-force] [file]\n    \tWrite a commented configuration template to file, or to stdout\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s verify [-config file] [-profile name] [-finding id] findings.json\n    \tReplay the findings of a report with the configured session and re-run their detection checks\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s ctl [-addr address] status|pause|resume|skip-host [host]|rate rps\n    \tControl a scan running with -control\n", os.Args[0])
 
 		fmt.Fprintf(os.Stderr, "\nEXAMPLES:\n")
 		fmt.Fprintf(os.Stderr, "  # Basic scan for XSS and SQLi\n")
@@ -173,6 +418,15 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  dursgo -u http://example.com -s blindssrf -oast\n\n")
 		fmt.Fprintf(os.Stderr, "  # Crawl a Single-Page Application and save the report\n")
 		fmt.Fprintf(os.Stderr, "  dursgo -u http://spa.example.com -s all -render-js -output-json report.json\n\n")
+		fmt.Fprintf(os.Stderr, "  # Stream findings to a JSONL file for a pipeline\n")
+		fmt.Fprintf(os.Stderr, "  dursgo -u http://example.com -s all -output-format jsonl -output findings.jsonl\n\n")
+
+		fmt.Fprintf(os.Stderr, "EXIT STATUS:\n")
+		fmt.Fprintf(os.Stderr, "  0  Scan completed; no finding met -fail-on or -fail-on-new\n")
+		fmt.Fprintf(os.Stderr, "  1  Invalid options or configuration (2 for unknown flags)\n")
+		fmt.Fprintf(os.Stderr, "  3  Findings met -fail-on or -fail-on-new, or a -retest finding is still vulnerable\n")
+		fmt.Fprintf(os.Stderr, "  4  Scan error: target unreachable, login or session verification failed, or scanner errors\n")
+		fmt.Fprintf(os.Stderr, "  5  Partial scan: interrupted, given up on a blocking host, request budgets exhausted or session lost\n")
 	}
 
 	// Parse all defined flags.
@@ -186,10 +440,19 @@ func main() {
 
 	// Determine if the run is command-line driven (-u flag is present).
 	// If not, and no output file is specified via flags, use the one from config.yaml.
-	uFlagProvided := false
+	// The flags overriding values of the scan policy are recorded in the report metadata.
+	uFlagProvided, targetFlagsProvided := false, false
+	var policyOverrides []string
 	flag.Visit(func(f *flag.Flag) {
-		if f.Name == "u" {
+		switch f.Name {
+		case "u":
 			uFlagProvided = true
+		case "target", "targets-file":
+			targetFlagsProvided = true
+		case "s", "enable-scanners", "disable-scanners", "payload-tier", "time-confirmations", "max-requests-per-param", "d", "oast", "inject-headers":
+			if cfg.Policy != "" {
+				policyOverrides = append(policyOverrides, f.Name)
+			}
 		}
 	})
 
@@ -198,6 +461,40 @@ func main() {
 		log.Debug("Using output file from config.yaml: %s", jsonOutputFile)
 	}
 
+	// Configure the log format, per-scanner levels and the values never to be logged.
+	switch logger.Format(strings.ToLower(strings.TrimSpace(logFormat))) {
+	case "", logger.FormatText:
+	case logger.FormatJSON:
+		log.SetFormat(logger.FormatJSON)
+	default:
+		log.Error("Invalid log format %q; use text or json.", logFormat)
+		os.Exit(1)
+	}
+	scannerLevels := make(map[string]logger.LogLevel)
+	for scanner, name := range cfg.Logging.ScannerLevels {
+		level, err := logger.ParseLevel(name)
+		if err != nil {
+			log.Error("Invalid log level for scanner %s in config.yaml: %v", scanner, err)
+			os.Exit(1)
+		}
+		scannerLevels[scanner] = level
+	}
+	flagLevels, err := logger.ParseScannerLevels(scannerLogLevels)
+	if err != nil {
+		log.Error("Invalid -scanner-log-level: %v", err)
+		os.Exit(1)
+	}
+	for scanner, level := range flagLevels {
+		scannerLevels[scanner] = level
+	}
+	for scanner, level := range scannerLevels {
+		log.SetScannerLevel(scanner, level)
+	}
+	if len(cfg.Logging.RedactKeys) > 0 {
+		log.SetRedactKeys(cfg.Logging.RedactKeys)
+	}
+	log.AddSecrets(loggedSecrets(cfg, defaultHeaders, defaultCookies)...)
+
 	// Adjust log level based on verbosity flags.
 	if trace {
 		log.SetMinLevel(logger.TRACE)
@@ -206,6 +503,155 @@ func main() {
 		log.SetMinLevel(logger.DEBUG)
 		log.Info("Debug logging enabled (-v).")
 	}
+	if quiet {
+		log.SetMinLevel(logger.ERROR) // Findings are logged at SUCCESS, above ERROR.
+	}
+
+	// Validate the findings file settings.
+	outputFormat = strings.ToLower(strings.TrimSpace(outputFormat))
+	switch outputFormat {
+	case "", reporter.FormatText:
+		outputFormat = reporter.FormatText
+	case reporter.FormatJSON, reporter.FormatJSONL, reporter.FormatHTML:
+		if outputFile == "" {
+			log.Error("-output-format %s requires a findings file (-output).", outputFormat)
+			os.Exit(1)
+		}
+	default:
+		log.Error("Unknown output format '%s'. Use text, json, jsonl or html.", outputFormat)
+		os.Exit(1)
+	}
+	sortFindings = strings.ToLower(strings.TrimSpace(sortFindings))
+	if sortFindings != "" && sortFindings != "found" && sortFindings != "cvss" {
+		log.Error("Unknown finding order '%s'. Use found or cvss.", sortFindings)
+		os.Exit(1)
+	}
+	if failOnNew != "" {
+		if failOnNew, err = reporter.ParseSeverity(failOnNew); err != nil {
+			log.Error("Invalid -fail-on-new: %v", err)
+			os.Exit(1)
+		}
+	}
+	var failOnConfidence string
+	if failOn, failOnConfidence, err = reporter.ParseFailOn(failOn); err != nil {
+		log.Error("Invalid -fail-on: %v", err)
+		os.Exit(1)
+	}
+	if minConfidence != "" {
+		if minConfidence, err = scanner.ParseConfidence(minConfidence); err != nil {
+			log.Error("Invalid -min-confidence: %v", err)
+			os.Exit(1)
+		}
+	}
+	var baseline *reporter.Baseline
+	if baselineFile != "" {
+		if baseline, err = reporter.LoadBaseline(baselineFile); err != nil {
+			log.Error("Failed to load baseline: %v", err)
+			os.Exit(1)
+		}
+		if os.Getenv(targetChildEnv) != "" {
+			// A baseline of a scan of several targets holds the findings of all of them.
+			baseline = baseline.ForTarget(targetURLStr)
+		}
+		log.Info("Comparing findings with %d finding(s) of the baseline %s.", baseline.Len(), baselineFile)
+	}
+	var suppressions *reporter.Suppressions
+	if suppressionsFile != "" {
+		if suppressions, err = reporter.LoadSuppressions(suppressionsFile); err != nil {
+			log.Error("Failed to load suppressions: %v", err)
+			os.Exit(1)
+		}
+		log.Info("Suppressing findings matching %d rule(s) of %s.", suppressions.Len(), suppressionsFile)
+	}
+	severityOverrides := make([]scanner.Override, 0, len(cfg.SeverityOverrides))
+	for _, o := range cfg.SeverityOverrides {
+		severityOverrides = append(severityOverrides, scanner.Override{Type: o.Type, URL: o.URL, Severity: o.Severity, Remediation: o.Remediation, RemediationURL: o.RemediationURL})
+	}
+	overrides, err := scanner.NewOverrides(severityOverrides)
+	if err != nil {
+		log.Error("Invalid severity_overrides configuration: %v", err)
+		os.Exit(1)
+	}
+	if overrides.Len() > 0 {
+		log.Info("Overriding the severity or remediation of findings matching %d rule(s) of severity_overrides.", overrides.Len())
+	}
+	if pocExtraction {
+		log.Warn("!!! PoC EXTRACTION IS ENABLED (-poc-extraction). Confirmed blind SQL injections are exploited to read the database version or user (sqli.poc_value) into the evidence. Use only with permission to exploit the target.")
+	}
+	findingOpts := reporter.FindingOptions{MaxEvidenceBytes: maxEvidenceBytes, ExcludeRaw: excludeRaw, MinCVSSScore: minCVSS, MinConfidence: minConfidence, NoCollapse: noCollapseFindings}
+
+	// A retest replaces the crawl and the scan; its target is that of the report unless -u
+	// gives one.
+	var retestFindings []reporter.Finding
+	if retestFile != "" {
+		if outputFormat == reporter.FormatJSONL {
+			log.Error("-retest writes a json or html findings file, not jsonl.")
+			os.Exit(1)
+		}
+		if retestFindings, err = reporter.LoadFindings(retestFile); err != nil {
+			log.Error("Failed to load the report to re-test: %v", err)
+			os.Exit(1)
+		}
+		if !uFlagProvided {
+			targetURLStr = retestTarget(retestFindings)
+		}
+		scannersToRunStr = strings.Join(scanner.AvailableScanners(), ",")
+		enableScannersStr, disableScannersStr = "", ""
+	}
+
+	// Targets given on the command line replace those of the configuration file. Several targets
+	// are scanned by one dursgo process each (runTargets); a process scanning one of them has
+	// its target in -u.
+	if os.Getenv(targetChildEnv) == "" && retestFile == "" {
+		var targets []string
+		if uFlagProvided || targetFlagsProvided {
+			if uFlagProvided {
+				targets = append(targets, targetURLStr)
+			}
+			targets = append(targets, flagTargets...)
+		} else {
+			targets = append([]string{targetURLStr}, cfg.Targets...)
+		}
+		if targetsFile != "" {
+			fileTargets, err := readTargetsFile(targetsFile)
+			if err != nil {
+				log.Error("Failed to read targets file: %v", err)
+				os.Exit(1)
+			}
+			targets = append(targets, fileTargets...)
+		}
+		if targets = uniqueTargets(targets); len(targets) > 1 {
+			if resume && stateFile == "" {
+				log.Error("-resume requires a state file (-state-file or state_file in config.yaml).")
+				os.Exit(1)
+			}
+			if oobListen != "" && parallelTargets > 1 {
+				log.Error("-oob-listen cannot be shared by targets scanned concurrently; use -parallel-targets 1 or Interactsh.")
+				os.Exit(1)
+			}
+			if controlAddr != "" && parallelTargets > 1 {
+				log.Error("-control cannot be shared by targets scanned concurrently; use -parallel-targets 1.")
+				os.Exit(1)
+			}
+			if metricsAddr != "" && parallelTargets > 1 {
+				log.Error("-metrics-addr cannot be shared by targets scanned concurrently; use -parallel-targets 1.")
+				os.Exit(1)
+			}
+			os.Exit(runTargets(log, targetScanOptions{
+				Targets:        targets,
+				Parallel:       parallelTargets,
+				OutputFormat:   outputFormat,
+				OutputFile:     outputFile,
+				JSONReportFile: jsonOutputFile,
+				StateFile:      stateFile,
+				HARFile:        harOutput,
+				LogFormat:      logger.Format(strings.ToLower(strings.TrimSpace(logFormat))),
+			}))
+		}
+		if len(targets) == 1 {
+			targetURLStr = targets[0]
+		}
+	}
 
 	// Validate target URL.
 	if targetURLStr == "" {
@@ -233,6 +679,18 @@ func main() {
 	log.Info("Starting Dursgo scan...")
 	log.Info("Target URL: %s", targetBaseURL)
 
+	crawlMode, err := crawler.ParseCrawlMode(crawlModeStr)
+	if err != nil {
+		log.Error("Invalid crawl mode: %v", err)
+		os.Exit(1)
+	}
+	if crawlModeStr == "" && renderJS {
+		crawlMode = crawler.CrawlModeRendered // -render-js alone keeps its original meaning.
+	}
+	if crawlMode != crawler.CrawlModeStatic {
+		renderJS = true
+	}
+
 	// Initialize headless browser renderer if JavaScript rendering is enabled.
 	var rend *renderer.Renderer
 	if renderJS {
@@ -247,98 +705,313 @@ func main() {
 	}
 
 	// Declare variables for OAST (Out-of-Band Application Security Testing) functionality.
-	var interactshClient *client.Client
-	var oastDomain string
-	var oastInteractions []*server.Interaction
-	var oastMu sync.Mutex
+	var collaborator *oob.Collaborator
+	var oastDomain, oobCollaboratorURL string
 	var oastCorrelationMap sync.Map
 
-	// Initialize Interactsh client if OAST is enabled.
+	// A local OOB listener implies OAST mode.
+	if oobListen != "" {
+		oast = true
+	}
+
+	// Initialize the OOB collaborator (local listener or Interactsh) if OAST is enabled.
 	if oast {
-		interactshClient, err = client.New(client.DefaultOptions)
+		if oobListen != "" {
+			collaborator, err = oob.NewLocalCollaborator(oobListen, oobURL)
+		} else {
+			// Interactsh is polled every 5 seconds for the whole scan.
+			collaborator, err = oob.NewInteractshCollaborator(5 * time.Second)
+		}
 		if err != nil {
-			log.Error("Could not create interactsh client: %v. Disabling OAST.", err)
+			log.Error("Could not start OOB collaborator: %v. Disabling OAST.", err)
 			oast = false
 		} else {
-			defer interactshClient.Close() // Ensure client is closed on exit.
-			oastDomain = interactshClient.URL()
+			defer collaborator.Close() // Ensure polling/listening stops on exit.
+			oastDomain = collaborator.Domain()
+			oobCollaboratorURL = collaborator.URL()
 			log.Info("OAST domain for this session: %s", oastDomain)
-			// Start polling for OAST interactions.
-			interactshClient.StartPolling(5*time.Second, func(interaction *server.Interaction) {
-				oastMu.Lock()
-				oastInteractions = append(oastInteractions, interaction)
-				oastMu.Unlock()
-			})
-			defer interactshClient.StopPolling() // Stop polling on exit.
 		}
 	}
 
+	// Record the traffic of every client, the login included.
+	var harRecorder *httpclient.HARRecorder
+	if harOutput != "" {
+		if harRecorder, err = httpclient.NewHARRecorder(harOutput, version, harMaxBodyBytes); err != nil {
+			log.Error("Failed to create HAR file: %v", err)
+			os.Exit(1)
+		}
+		log.Info("Recording requests and responses to %s.", harOutput)
+	}
+
+	// Verbose error pages are looked for in every response received, the crawl's included.
+	errorPages := errorpages.NewAnalyzer(log)
+	// Every request is timed for the scan statistics, by phase, scanner and host.
+	scanMetrics := metrics.NewCollector()
+	scanMetrics.StartPhase("setup")
+
 	// Configure HTTP client options.
 	clientOpts := httpclient.ClientOptions{
-		Timeout:         15 * time.Second,
-		UserAgent:       cfg.UserAgent,
-		FollowRedirects: true,
-		MaxRetries:      maxRetries,
-		RequestDelay:    time.Duration(delay) * time.Millisecond,
-		TargetBaseURL:   targetBaseURL,
+		Timeout:            15 * time.Second,
+		UserAgent:          cfg.UserAgent,
+		FollowRedirects:    true,
+		MaxRetries:         maxRetries,
+		RequestDelay:       time.Duration(delay) * time.Millisecond,
+		RetryBackoff:       time.Duration(retryBackoff) * time.Millisecond,
+		TargetBaseURL:      targetBaseURL,
+		ProxyURL:           proxyURL,
+		CACertFile:         caCertFile,
+		InsecureSkipVerify: insecureSkipVerify,
+		ClientCertFile:     clientCertFile,
+		ClientKeyFile:      clientKeyFile,
+		ServerName:         serverName,
+		MinTLSVersion:      minTLSVersion,
+		HTTPVersion:        httpVersion,
+		Resolver:           dnsResolver,
+		Resolve:            hostOverrides,
+		Headers:            defaultHeaders,
+		Cookies:            defaultCookies,
+		MaxResponseBytes:   maxResponseBytes,
+		BodyReadTimeout:    time.Duration(bodyReadTimeout) * time.Second,
+		HAR:                harRecorder,
+		Observe:            errorPages.Observe,
+		Metrics:            scanMetrics.ObserveRequest,
+	}
+	if rotateUserAgent {
+		clientOpts.UserAgents = cfg.UserAgents
+		if len(clientOpts.UserAgents) == 0 {
+			clientOpts.UserAgents = httpclient.DefaultUserAgents
+		}
+		log.Info("Rotating between %d User-Agent(s).", len(clientOpts.UserAgents))
+	}
+	// A bad proxy, CA bundle or TLS setting would fail every request; stop before the scan starts.
+	if err := httpclient.CheckTransport(clientOpts, 10*time.Second); err != nil {
+		log.Error("Invalid network settings: %v", err)
+		os.Exit(1)
+	}
+	if proxyURL != "" {
+		proxy, _ := httpclient.ParseProxyURL(proxyURL)
+		log.Info("Routing requests through proxy %s.", proxy.Redacted())
+	}
+	for _, entry := range hostOverrides {
+		override, _ := httpclient.ParseResolve(entry)
+		log.Info("Resolving %s:%s to %s.", override.Host, override.Port, override.Addr)
+	}
+	if dnsResolver != "" {
+		log.Info("Resolving host names with DNS server %s.", dnsResolver)
+	}
+	if insecureSkipVerify {
+		log.Warn("!!! TLS CERTIFICATE VERIFICATION IS DISABLED (-insecure). Any server certificate is accepted, so traffic and credentials can be intercepted. Use only against hosts you trust.")
+	}
+	if clientCertFile != "" {
+		log.Info("Presenting client certificate %s for mTLS.", clientCertFile)
+	}
+
+	// Resolve the scanners to run and their options. Unknown scanners or options are fatal so
+	// that a typo does not silently skip a test.
+	selectedScanners, err := scanner.Resolve(scanner.Selection{
+		Base:     scannersToRunStr,
+		Enable:   strings.Split(enableScannersStr, ","),
+		Disable:  strings.Split(disableScannersStr, ","),
+		Settings: cfg.ScannerSettings,
+	}, scanner.Env{OAST: oast, Renderer: renderJS})
+	if err != nil {
+		log.Error("Invalid scanner selection: %v", err)
+		os.Exit(1)
+	}
+	skippedScanners := make([]string, 0, len(selectedScanners.Skipped))
+	for name := range selectedScanners.Skipped {
+		skippedScanners = append(skippedScanners, name)
+	}
+	sort.Strings(skippedScanners)
+	for _, name := range skippedScanners {
+		log.Info("Skipping scanner '%s': %s.", name, selectedScanners.Skipped[name])
+	}
+	moduleOptions := selectedScanners.ModuleOptions()
+	payloadTier, err := scanner.ParsePayloadTier(payloadTierStr)
+	if err != nil {
+		log.Error("%v", err)
+		os.Exit(1)
+	}
+	evasion, err := scanner.ParseEvasion(evasionStr)
+	if err != nil {
+		log.Error("%v", err)
+		os.Exit(1)
+	}
+	if evasion.Enabled() {
+		var names []string
+		for _, t := range evasion.Transformations(payloads.PayloadSQL) {
+			if t.Name != "" {
+				names = append(names, t.Name)
+			}
+		}
+		log.Info("Evasion '%s': SQLi, XSS and command injection payloads are also sent transformed with %s.", evasion, strings.Join(names, ", "))
+	}
+	if timeConfirmations < 0 {
+		log.Error("-time-confirmations must not be negative.")
+		os.Exit(1)
+	}
+	if oastWait < 0 {
+		log.Error("-oast-wait must not be negative.")
+		os.Exit(1)
 	}
 
 	// Determine if scanning is enabled.
-	willScan := scannersToRunStr != "none"
+	willScan := len(selectedScanners.Modules) > 0
 
 	// Handle authentication based on configuration.
+	var authInfo reporter.AuthInfo
 	if cfg.Authentication.Enabled {
 		// Dynamic login via form.
 		if willScan && cfg.Authentication.LoginURL != "" {
 			log.Info("Authentication (Login Action) is enabled. Attempting to log in...")
-			tempLoginClient := httpclient.NewClient(log, clientOpts)
-			loginReqBody := strings.NewReader(cfg.Authentication.LoginData)
-			loginResp, err := tempLoginClient.Post(cfg.Authentication.LoginURL, "application/x-www-form-urlencoded", loginReqBody)
+			finalCookieHeader, err := loginAndCaptureCookie(log, clientOpts, cfg.Authentication.LoginURL, cfg.Authentication.LoginData, cfg.Authentication.LoginCheckKeyword)
 			if err != nil {
-				log.Error("Login request failed: %v", err)
-				os.Exit(1)
-			}
-			bodyBytes, _ := io.ReadAll(loginResp.Body)
-			loginResp.Body.Close()
-			// Verify login success using a keyword.
-			if cfg.Authentication.LoginCheckKeyword != "" && !strings.Contains(string(bodyBytes), cfg.Authentication.LoginCheckKeyword) {
-				log.Error("Login check failed. Keyword '%s' not found.", cfg.Authentication.LoginCheckKeyword)
-				os.Exit(1)
+				log.Error("%v", err)
+				log.Error("Exit status %d: login failed.", reporter.ExitScanError)
+				harRecorder.Close() // Keep the login exchange for troubleshooting.
+				os.Exit(reporter.ExitScanError)
 			}
-			// Capture session cookies after successful login.
-			loginURL, _ := url.Parse(cfg.Authentication.LoginURL)
-			loginCookies := tempLoginClient.GetClient().Jar.Cookies(loginURL)
-			if len(loginCookies) == 0 {
-				log.Error("Login successful, but no session cookies were returned.")
-				os.Exit(1)
-			}
-			var cookieStrings []string
-			for _, cookie := range loginCookies {
-				cookieStrings = append(cookieStrings, cookie.String())
-			}
-			finalCookieHeader := strings.Join(cookieStrings, "; ")
 			log.Success("Login successful. Session cookie captured and will be used for scanning.")
+			log.AddSecrets(cookieValues(finalCookieHeader)...)
 			clientOpts.AuthCookie = finalCookieHeader
+			authInfo.Authenticated, authInfo.Method = true, "login"
 		} else if cfg.Authentication.Cookie != "" || len(cfg.Authentication.Headers) > 0 {
 			// Static authentication via cookie or headers.
 			log.Info("Static authentication is enabled and will be used for scanning.")
 			clientOpts.AuthCookie = cfg.Authentication.Cookie
 			clientOpts.AuthHeaders = cfg.Authentication.Headers
+			authInfo.Authenticated, authInfo.Method = true, "static"
 		}
 	} else {
 		log.Info("Authentication is disabled.")
 	}
 
+	// Authenticate the second user (user B) for cross-session IDOR checks.
+	var secondSessionCookie string
+	var secondSessionHeaders map[string]string
+	if second := cfg.Authentication.SecondSession; cfg.Authentication.Enabled && willScan {
+		if second.LoginURL != "" {
+			log.Info("Second session (user B) login is configured. Attempting to log in...")
+			// The login must not carry user A's session.
+			loginOpts := clientOpts
+			loginOpts.AuthCookie, loginOpts.AuthHeaders = "", nil
+			cookie, err := loginAndCaptureCookie(log, loginOpts, second.LoginURL, second.LoginData, second.LoginCheckKeyword)
+			if err != nil {
+				log.Warn("Second session login failed, cross-session IDOR checks are disabled: %v", err)
+			} else {
+				log.Success("Second session (user B) login successful.")
+				log.AddSecrets(cookieValues(cookie)...)
+				secondSessionCookie = cookie
+			}
+		} else if second.Cookie != "" || len(second.Headers) > 0 {
+			log.Info("Static second session (user B) is configured for cross-session IDOR checks.")
+			secondSessionCookie, secondSessionHeaders = second.Cookie, second.Headers
+		}
+	}
+
 	// Create the main HTTP client with configured options.
 	httpClient := httpclient.NewClient(log, clientOpts)
+	// The per-host cap is shared by every client derived from the main one (crawler, scanners).
+	httpClient.SetPerHostConcurrency(perHostConcurrency)
+	if perHostConcurrency > 0 {
+		log.Info("Limiting requests to %d concurrent request(s) per host.", perHostConcurrency)
+	}
+	// The control interface pauses and steers every client derived from the main one.
+	if controlAddr != "" {
+		httpClient.EnableControl()
+	}
+	// Custom WAF block pages must be known before the first request is sent.
+	customWAFFingerprints := make([]payloads.WAFFingerprint, 0, len(cfg.WAFFingerprints))
+	for _, f := range cfg.WAFFingerprints {
+		customWAFFingerprints = append(customWAFFingerprints, payloads.WAFFingerprint{Name: f.Name, Statuses: f.Statuses, Pattern: f.Pattern, Headers: f.Headers})
+	}
+	if err := payloads.AddWAFFingerprints(customWAFFingerprints); err != nil {
+		log.Warn("Ignoring invalid custom WAF fingerprint(s): %v", err)
+	}
+	if !noBlockDetection {
+		httpClient.SetBlockDetection(httpclient.BlockDetectionOptions{
+			Streak:     cfg.BlockDetection.Streak,
+			Pause:      time.Duration(cfg.BlockDetection.Pause) * time.Second,
+			MaxStrikes: cfg.BlockDetection.MaxStrikes,
+		})
+	}
+	// Verify that the scan runs authenticated before it starts, and keep verifying it while it
+	// runs, so that a failed login never yields a scan of the logged-out site.
+	if verify := cfg.Authentication.Verify; authInfo.Authenticated && verify.URL != "" {
+		verifyURL, err := parsedURL.Parse(verify.URL)
+		if err != nil {
+			log.Error("Invalid authentication.verify.url %q: %v", verify.URL, err)
+			os.Exit(reporter.ExitUsage)
+		}
+		guardOpts := httpclient.SessionGuardOptions{
+			VerifyURL:            verifyURL.String(),
+			VerifyStatus:         verify.Status,
+			RecheckEvery:         verify.RecheckEvery,
+			MaxReauthentications: verify.MaxReauth,
+			TargetBaseURL:        targetBaseURL,
+		}
+		// The patterns were checked by config.Validate.
+		if verify.Pattern != "" {
+			guardOpts.VerifyPattern = regexp.MustCompile(verify.Pattern)
+		}
+		if verify.LoggedOutPattern != "" {
+			guardOpts.LoggedOut = regexp.MustCompile(verify.LoggedOutPattern)
+		}
+		if authInfo.Method == "login" && !strings.EqualFold(strings.TrimSpace(verify.OnLoss), "abort") {
+			// The new login must not carry the lost session.
+			loginOpts := clientOpts
+			loginOpts.AuthCookie = ""
+			guardOpts.Relogin = func() (string, error) {
+				log.Info("Logging in again at %s...", cfg.Authentication.LoginURL)
+				cookie, err := loginAndCaptureCookie(log, loginOpts, cfg.Authentication.LoginURL, cfg.Authentication.LoginData, cfg.Authentication.LoginCheckKeyword)
+				if err == nil {
+					log.AddSecrets(cookieValues(cookie)...)
+				}
+				return cookie, err
+			}
+		}
+		httpClient.SetSessionGuard(guardOpts)
+		if err := httpClient.VerifySession(context.Background()); err != nil {
+			log.Error("Authentication verification failed: %v", err)
+			log.Error("Exit status %d: the session is not authenticated; check the credentials and login settings.", reporter.ExitScanError)
+			harRecorder.Close() // Keep the login and verification exchanges for troubleshooting.
+			os.Exit(reporter.ExitScanError)
+		}
+		log.Success("Authenticated session verified at %s.", verifyURL)
+	} else if authInfo.Authenticated {
+		log.Info("The authenticated session is not verified; set authentication.verify.url to fail the scan when the login does not work.")
+	}
 
 	// Start technology fingerprinting to identify web technologies used by the target.
+	customTechnologyRules := make([]payloads.TechnologyRule, 0, len(cfg.TechnologyRules))
+	for _, r := range cfg.TechnologyRules {
+		customTechnologyRules = append(customTechnologyRules, payloads.TechnologyRule{Name: r.Name, Category: r.Category, Headers: r.Headers, Cookies: r.Cookies, Body: r.Body, Favicons: r.Favicons, Implies: r.Implies})
+	}
+	if err := payloads.AddTechnologyRules(customTechnologyRules); err != nil {
+		log.Warn("Ignoring invalid custom technology rule(s): %v", err)
+	}
+	scanMetrics.StartPhase("fingerprint")
 	log.Info("Starting technology fingerprinting...")
-	fp := fingerprint.NewFingerprinter(httpClient, log)
-	fingerprintResult := fp.Analyze(targetBaseURL)
-	if len(fingerprintResult) > 0 {
+	fp := fingerprint.NewFingerprinter(httpClient.WithSource("fingerprint", ""), log)
+	fingerprintAnalysis, err := fp.Analyze(targetBaseURL)
+	if err != nil {
+		log.Error("Target %s is unreachable: %v", targetBaseURL, err)
+		log.Error("Exit status %d: target unreachable.", reporter.ExitScanError)
+		harRecorder.Close()
+		os.Exit(reporter.ExitScanError)
+	}
+	fingerprintResult, technologies := fingerprintAnalysis.Fingerprint, fingerprintAnalysis.Technologies
+	if len(technologies) > 0 {
+		log.Info("Technologies Detected: %s", technologies)
+	} else if len(fingerprintResult) > 0 {
 		log.Info("Technologies Detected: %v", fingerprintResult)
 	}
+	findingOpts.Technologies = technologies.Names()
+
+	// Fingerprint the page the target answers unknown paths with, so that discovery and the
+	// scanners do not take a soft 404 for content. Other hosts are fingerprinted on first use.
+	soft404s := soft404.New(httpClient.WithSource("soft404", ""), log)
+	soft404s.Probe(context.Background(), targetBaseURL)
 
 	// Determine the current user ID for IDOR scanning if authentication is enabled.
 	var currentUserID int
@@ -348,33 +1021,310 @@ func main() {
 
 	// Discover GraphQL endpoint.
 	graphQLEndpoint := ""
+	graphQLFinder := discovery.NewGraphQLFinder(httpClient.WithSource("graphql-discovery", ""), log)
 	// Check if the target URL itself is a GraphQL endpoint.
 	if strings.Contains(targetURLStr, "/graphql") || strings.Contains(targetURLStr, "/gql") {
 		graphQLEndpoint = targetURLStr
 	} else {
 		// Otherwise, use the GraphQL finder to discover it.
-		finder := discovery.NewGraphQLFinder(httpClient, log)
-		graphQLEndpoint = finder.FindEndpoint(targetBaseURL)
+		graphQLEndpoint = graphQLFinder.FindEndpoint(targetBaseURL)
+	}
+
+	// Validate custom SQLi error patterns up front; a bad pattern must not crash a scan worker.
+	if err := payloads.AddSQLiErrorPatterns(cfg.SQLiErrorPatterns); err != nil {
+		log.Warn("Ignoring invalid custom SQLi error pattern(s): %v", err)
+	}
+	customSecretPatterns := make([]payloads.SecretPattern, 0, len(cfg.SecretPatterns))
+	for _, p := range cfg.SecretPatterns {
+		customSecretPatterns = append(customSecretPatterns, payloads.SecretPattern{Name: p.Name, Pattern: p.Pattern, Severity: p.Severity, Verbatim: p.Verbatim})
+	}
+	if err := payloads.AddSecretPatterns(customSecretPatterns); err != nil {
+		log.Warn("Ignoring invalid custom secret pattern(s): %v", err)
+	}
+	customTakeoverFingerprints := make([]payloads.TakeoverFingerprint, 0, len(cfg.TakeoverFingerprints))
+	for _, f := range cfg.TakeoverFingerprints {
+		customTakeoverFingerprints = append(customTakeoverFingerprints, payloads.TakeoverFingerprint{Service: f.Service, CNAMEs: f.CNAMEs, Pattern: f.Pattern, NXDomain: f.NXDomain})
+	}
+	if err := payloads.AddTakeoverFingerprints(customTakeoverFingerprints); err != nil {
+		log.Warn("Ignoring invalid custom takeover fingerprint(s): %v", err)
+	}
+	// Payload files replace or extend built-in payload lists; a scan with a broken one would test
+	// less than asked for.
+	if paramWordlist != "" {
+		if cfg.PayloadFiles == nil {
+			cfg.PayloadFiles = make(map[string]string)
+		}
+		cfg.PayloadFiles["parameters"] = paramWordlist
+	}
+	payloadLists := make([]string, 0, len(cfg.PayloadFiles))
+	for name := range cfg.PayloadFiles {
+		payloadLists = append(payloadLists, name)
+	}
+	sort.Strings(payloadLists)
+	for _, name := range payloadLists {
+		file, err := payloads.LoadPayloadFile(name, cfg.PayloadFiles[name])
+		if err != nil {
+			log.Error("Invalid payload file for %s: %v", name, err)
+			os.Exit(1)
+		}
+		log.Info("Loaded %d %s payload(s) from %s.", file.Categories[0].Count, name, file.Path)
+	}
+	for _, path := range cfg.PayloadSets {
+		file, err := payloads.LoadPayloadSet(path)
+		if err != nil {
+			log.Error("Invalid payload set file:\n%v", err)
+			os.Exit(1)
+		}
+		for _, category := range file.Categories {
+			log.Info("Loaded %d %s payload(s) from %s (%s).", category.Count, category.Name, file.Path, category.Mode)
+		}
 	}
 
 	// Initialize scanner options with collected information.
+	// Build the scope shared by the crawler and the scanners.
+	scope, err := crawler.NewScope(targetBaseURL, crawler.ScopeOptions{
+		IncludePatterns: cfg.Scope.IncludePatterns,
+		ExcludePatterns: cfg.Scope.ExcludePatterns,
+		SubdomainPolicy: crawler.SubdomainPolicy(cfg.Scope.Subdomains),
+		AllowedHosts:    cfg.Scope.AllowedHosts,
+	})
+	if err != nil {
+		log.Error("Invalid scope configuration: %v", err)
+		os.Exit(1)
+	}
+
+	csrfTokens := scanner.NewTokenRefresher(cfg.CSRFTokenFields)
+	var extraSkipRules []scanner.SkipRule
+	for _, rule := range cfg.SkipRules.Rules {
+		extraSkipRules = append(extraSkipRules, scanner.SkipRule{Param: rule.Param, Path: rule.Path, Scanners: rule.Scanners})
+	}
+	effectiveSkipRules, err := scanner.BuildSkipRules(cfg.SkipRules.NoDefaults, cfg.SkipRules.Remove, extraSkipRules)
+	if err != nil {
+		log.Error("Invalid skip_rules configuration: %v", err)
+		os.Exit(1)
+	}
+	skipRules := scanner.NewSkipRules(effectiveSkipRules)
 	scannerOptions := scanner.ScannerOptions{
-		Concurrency:        concurrency,         // Number of concurrent scan workers.
-		OASTDomain:         oastDomain,          // Domain for OAST interactions.
-		OASTCorrelationMap: &oastCorrelationMap, // Map to correlate OAST interactions.
-		Fingerprint:        fingerprintResult,   // Detected technologies.
-		UserID:             currentUserID,       // User ID for IDOR scanning.
-		Renderer:           rend,                // Headless browser renderer.
-		Client:             httpClient,          // HTTP client for requests.
-		GraphQLEndpoint:    graphQLEndpoint,     // Discovered GraphQL endpoint.
+		Concurrency:              concurrency,             // Number of concurrent scan workers.
+		OASTDomain:               oastDomain,              // Domain for OAST interactions.
+		OASTCorrelationMap:       &oastCorrelationMap,     // Map to correlate OAST interactions.
+		Fingerprint:              fingerprintResult,       // Detected technologies.
+		Technologies:             technologies,            // Stack identified by the technology rules.
+		UserID:                   currentUserID,           // User ID for IDOR scanning.
+		Renderer:                 rend,                    // Headless browser renderer.
+		Client:                   httpClient,              // HTTP client for requests.
+		GraphQLEndpoint:          graphQLEndpoint,         // Discovered GraphQL endpoint.
+		TimeBasedBaselineSamples: cfg.TimeBasedSamples,    // Baseline samples for time-based SQLi tests.
+		InjectHeaders:            injectHeaders,           // Header and cookie injection points.
+		PoCExtraction:            pocExtraction,           // Proof values read through blind SQL injections.
+		OOBCollaboratorURL:       oobCollaboratorURL,      // Base URL for out-of-band payloads.
+		SimilarityThreshold:      similarityThreshold,     // Threshold for differential response comparison.
+		SimilarityMode:           similarityMode,          // Response comparison mode.
+		MaxRequestsPerParam:      maxRequestsPerParam,     // Request budget per tested parameter.
+		RequestsPerSecond:        requestsPerSecond,       // Shared scan rate limit.
+		MaxRawResponseBytes:      cfg.RawResponseMaxBytes, // Truncation of raw responses in findings.
+		SecondSessionCookie:      secondSessionCookie,     // Session of user B for IDOR checks.
+		SecondSessionHeaders:     secondSessionHeaders,    // Auth headers of user B for IDOR checks.
+		ForcePrototypePollution:  forcePrototypePollution, // Prototype pollution tests on non-Node.js targets.
+		Scope:                    scope,                   // URLs scanners may send requests to.
+		CSRFTokens:               csrfTokens,              // Fresh anti-CSRF tokens for form submissions.
+		SkipRules:                skipRules,               // Parameters and paths left untested.
+		Soft404:                  soft404s,                // Not-found pages answered with 200 or a redirect.
+		Coverage:                 scanner.NewCoverage(),   // Outcome of every parameter test.
+		Overrides:                overrides,               // Severities and remediation of the organization.
+		Timings:                  scanMetrics,             // Time and findings of each test, for the statistics.
+		ModuleOptions:            moduleOptions,           // Options of each selected scanner.
+		PayloadTier:              payloadTier,             // Share of each payload list sent.
+		Evasion:                  evasion,                 // Encoded variants of the payloads.
+		TimeConfirmations:        timeConfirmations,       // Delays confirming time-based findings.
+	}
+
+	if retestFile != "" {
+		status := runRetest(log, retestOptions{
+			Source:        retestFile,
+			Findings:      retestFindings,
+			Client:        httpClient,
+			ClientOptions: clientOpts,
+			ScannerOpts:   scannerOptions,
+			Settings:      cfg.ScannerSettings,
+			OAST:          oast,
+			Renderer:      renderJS,
+			Target:        targetURLStr,
+			StartTime:     startTime,
+			OutputFormat:  outputFormat,
+			OutputFile:    outputFile,
+			FindingOpts:   findingOpts,
+		})
+		harRecorder.Close()
+		os.Exit(status)
+	}
+
+	// The stored XSS module submits markers to writable parameters during the active phase and
+	// looks for them on the pages of the target once it is over.
+	if scannerOptions.BoolOption(xss.StoredModuleName, "markers", false) {
+		scannerOptions.StoredMarkers = scanner.NewStoredMarkers()
 	}
 
 	// Initialize the crawler with the authenticated HTTP client.
-	dursGoCrawler, err := crawler.NewCrawler(httpClient, log, targetBaseURL, concurrency, maxDepth, rend)
+	dursGoCrawler, err := crawler.NewCrawler(httpClient.WithSource("crawl", ""), log, targetBaseURL, concurrency, maxDepth, rend)
 	if err != nil {
 		log.Error("Failed to initialize crawler: %v", err)
 		os.Exit(1)
 	}
+	dursGoCrawler.SetScope(scope)
+	dursGoCrawler.SetFormFieldDefaults(cfg.FormDefaults)
+	dursGoCrawler.SetLimits(crawler.CrawlLimits{
+		MaxPagesPerHost:      maxPagesPerHost,
+		MaxParamsPerURL:      cfg.MaxParamsPerURL,
+		PolitenessDelay:      time.Duration(crawlDelay) * time.Millisecond,
+		InfiniteURLThreshold: cfg.InfiniteURLThreshold,
+		PaginationPages:      cfg.PaginationPages,
+		CursorPages:          cfg.CursorPages,
+	})
+	dursGoCrawler.SetCrawlMode(crawlMode) // Falls back to static crawling without a browser.
+	log.Info("Crawl mode: %s", dursGoCrawler.CrawlMode())
+
+	// Save the progress of the scan to the state file; -resume continues the scan saved there.
+	if resume && stateFile == "" {
+		log.Error("-resume requires a state file (-state-file or state_file in config.yaml).")
+		os.Exit(1)
+	}
+	var resumed *state.State
+	var previousFindings []scanner.VulnerabilityResult // Active scan findings of the interrupted run(s).
+	if resume {
+		resumed, err = state.Load(stateFile)
+		if err != nil {
+			log.Error("Cannot resume the scan: %v", err)
+			os.Exit(1)
+		}
+		if resumed.Target != targetURLStr {
+			log.Error("Cannot resume the scan: %s was saved for target %s, not %s.", stateFile, resumed.Target, targetURLStr)
+			os.Exit(1)
+		}
+		previousFindings = append(previousFindings, resumed.Findings...)
+		log.Info("Resuming the scan started at %s: %d URL(s) visited, %d scanner/request pair(s) tested, %d finding(s) so far.",
+			resumed.StartedAt.Format(time.RFC3339), len(resumed.Crawl.Visited), len(resumed.Tested), len(resumed.Findings)+len(resumed.PassiveFindings))
+		dursGoCrawler.Restore(resumed.Crawl)
+	}
+	// Blind XSS injections of the interrupted run(s) whose callback has not arrived yet. They can
+	// only be confirmed by the collaborator URL they were injected with.
+	var unmatchedPending []state.PendingCallback
+	if resumed != nil && len(resumed.PendingCallbacks) > 0 {
+		restored := 0
+		for _, pending := range resumed.PendingCallbacks {
+			if oast && pending.CollaboratorURL == oobCollaboratorURL {
+				scannerOptions.OASTCorrelationMap.Store(pending.CorrelationID, pending.Finding)
+				restored++
+			} else {
+				unmatchedPending = append(unmatchedPending, pending)
+			}
+		}
+		if restored > 0 {
+			log.Info("Waiting for the callbacks of %d blind XSS injection(s) of the interrupted run(s).", restored)
+		}
+		if len(unmatchedPending) > 0 {
+			log.Warn("%d pending blind XSS injection(s) were made with another collaborator URL and cannot be confirmed by this run; use -oob-listen with the same -oob-url to keep waiting for them.", len(unmatchedPending))
+		}
+	}
+	crawlDone := resumed != nil && resumed.CrawlComplete
+	var stateStore *state.Store
+	if stateFile != "" {
+		st := resumed
+		if st == nil {
+			st = &state.State{Target: targetURLStr, StartedAt: startTime}
+		}
+		stateStore = state.NewStore(stateFile, st)
+		if !crawlDone {
+			stateStore.TrackCrawler(dursGoCrawler)
+		}
+		if scannerOptions.StoredMarkers != nil {
+			if resumed != nil {
+				scannerOptions.StoredMarkers.Restore(resumed.StoredMarkers)
+			}
+			stateStore.TrackStoredMarkers(scannerOptions.StoredMarkers)
+		}
+		stateStore.Start(time.Duration(cfg.CheckpointInterval)*time.Second, log)
+		scannerOptions.Progress = stateStore
+		log.Info("Saving scan progress to %s.", stateFile)
+	}
+
+	// Stream findings to the JSONL findings file as soon as the scanners report them.
+	var findingsStream *reporter.JSONLWriter
+	if outputFormat == reporter.FormatJSONL {
+		findingsStream, err = reporter.NewJSONLWriter(outputFile, findingOpts)
+		if err != nil {
+			log.Error("Failed to create findings file %s: %v", outputFile, err)
+			os.Exit(1)
+		}
+		log.Info("Streaming findings to %s.", outputFile)
+		// Findings of an interrupted run are streamed again, as the file is rewritten.
+		if resumed != nil {
+			findingsStream.Emit(resumed.PassiveFindings)
+			findingsStream.Emit(previousFindings)
+		}
+	}
+
+	// Post findings to the webhooks named by the environment as they are found. Findings of an
+	// interrupted run were notified by that run and are not sent again.
+	notifier, err := notify.NewNotifier(notify.Options{
+		WebhookURL:      notify.URLFromEnv(cfg.Notifications.WebhookURLEnv, notify.DefaultWebhookURLEnv),
+		SlackWebhookURL: notify.URLFromEnv(cfg.Notifications.SlackWebhookURLEnv, notify.DefaultSlackWebhookURLEnv),
+		Target:          targetURLStr,
+		MinSeverity:     cfg.Notifications.MinSeverity,
+		Digest:          cfg.Notifications.Digest,
+		DigestInterval:  time.Duration(cfg.Notifications.DigestInterval) * time.Second,
+	}, log)
+	if err != nil {
+		log.Error("Invalid notifications.min_severity: %v", err)
+		os.Exit(1)
+	}
+	if notifier != nil {
+		log.Info("Sending notifications of findings to %s.", strings.Join(notifier.Endpoints(), ", "))
+	}
+
+	var findingSinks scanner.FindingSinks
+	if findingsStream != nil {
+		findingSinks = append(findingSinks, suppressions.Sink(findingsStream))
+	}
+	if notifier != nil {
+		findingSinks = append(findingSinks, suppressions.Sink(notifier))
+	}
+	if len(findingSinks) > 0 {
+		scannerOptions.Findings = findingSinks
+	}
+
+	// Show the progress of the scan as a status line, unless the output is a file or a pipe, or
+	// log lines are JSON for a log shipper. Log lines are written above the status line.
+	var scanStatus *progress.Reporter
+	if willScan && progress.IsTerminal(os.Stdout) && logger.Format(strings.ToLower(strings.TrimSpace(logFormat))) != logger.FormatJSON {
+		scanStatus = progress.New(os.Stdout, httpClient.RequestsSent)
+		log.SetOutput(scanStatus.Writer(os.Stdout), scanStatus.Writer(os.Stderr))
+		scannerOptions.Status = scanStatus
+	}
+
+	// Serve the control interface ("dursgo ctl") until the scan is done. Its status endpoint
+	// reports the progress shown on the status line, which is kept even without a terminal.
+	var controlServer *control.Server
+	if controlAddr != "" {
+		if scanStatus == nil && willScan {
+			scanStatus = progress.New(io.Discard, httpClient.RequestsSent)
+			scannerOptions.Status = scanStatus
+		}
+		controlServer = control.NewServer(httpClient, scanStatus, log)
+		if err := controlServer.Listen(controlAddr); err != nil {
+			log.Error("Failed to start the control interface: %v", err)
+			os.Exit(1)
+		}
+		log.Info("Control interface listening on %s (dursgo ctl -addr %s).", controlServer.Addr(), controlServer.Addr())
+	}
+	var metricsServer *metrics.Server
+	if metricsAddr != "" {
+		if metricsServer, err = metrics.Serve(scanMetrics, metricsAddr); err != nil {
+			log.Error("Failed to serve metrics: %v", err)
+			os.Exit(1)
+		}
+		log.Info("Serving scan metrics at http://%s/metrics.", metricsServer.Addr())
+	}
 
 	// Prepare entry points for crawling.
 	entryPoints := []string{targetURLStr}
@@ -393,17 +1343,54 @@ func main() {
 		}
 	}
 
-	// Start the crawling process.
-	log.Info("Starting crawling from %d unique entry points...", len(finalEntryPoints))
-	resultsChan := dursGoCrawler.Crawl(finalEntryPoints, 0)
-	// Consume results from the crawling channel to ensure completion.
-	for range resultsChan {
+	scanMetrics.StartPhase("crawl")
+	if crawlDone {
+		log.Info("Crawling was completed before the scan was interrupted. Skipping it.")
+	} else if apiSpecFile != "" {
+		// Scan the operations of the API specification instead of crawling.
+		specData, err := os.ReadFile(apiSpecFile)
+		if err != nil {
+			log.Error("Failed to read API specification: %v", err)
+			os.Exit(1)
+		}
+		operations, err := dursGoCrawler.ImportAPISpec(specData)
+		if err != nil {
+			log.Error("Failed to parse API specification %s: %v", apiSpecFile, err)
+			os.Exit(1)
+		}
+		log.Info("Imported %d operation(s) from %s. Skipping HTML crawling.", operations, apiSpecFile)
+	} else {
+		// Start the crawling process.
+		log.Info("Starting crawling from %d unique entry points...", len(finalEntryPoints))
+		resultsChan := dursGoCrawler.Crawl(finalEntryPoints, 0)
+		// Consume results from the crawling channel to ensure completion.
+		for range resultsChan {
+		}
+	}
+
+	// Brute-force common paths under the crawled directories, then crawl the hits so their
+	// links, forms and parameters become scan targets as well.
+	if discoverContent && apiSpecFile == "" && !crawlDone {
+		contentDiscoverer := discovery.NewContentDiscoverer(httpClient.WithSource("content-discovery", ""), log, concurrency, maxProbesPerHost)
+		contentDiscoverer.SetSoft404(soft404s)
+		discoveredContent := contentDiscoverer.Discover(context.Background(), dursGoCrawler.GetDiscoveredURLs(), fingerprintResult)
+		if len(discoveredContent) > 0 {
+			log.Info("Crawling %d paths found by content discovery...", len(discoveredContent))
+			for range dursGoCrawler.CrawlDiscovered(discoveredContent) {
+			}
+		}
 	}
 
 	// Retrieve discovered parameterized requests and all discovered URLs from the crawler.
 	parameterizedRequestsForScan := dursGoCrawler.GetParameterizedRequestsForScanning()
 	allDiscoveredURLs := dursGoCrawler.GetDiscoveredURLs()
 
+	// GraphQL endpoints outside the common paths are recognized by the queries sent to them.
+	if graphQLEndpoint == "" {
+		graphQLEndpoint = graphQLFinder.FindInRequests(parameterizedRequestsForScan)
+		scannerOptions.GraphQLEndpoint = graphQLEndpoint
+	}
+
 	// Prepare initial scan requests, merging parameters for the same path to avoid data loss.
 	mergedRequests := make(map[string]*crawler.ParameterizedRequest)
 
@@ -445,6 +1432,7 @@ func main() {
 				Path:           parsedU.Path,
 				ParamLocations: []string{"query"},
 				ParamNames:     []string{},
+				PathParams:     crawler.PathParamsOf(parsedU.Path),
 			}
 		}
 	}
@@ -455,14 +1443,58 @@ func main() {
 		initialScanRequests = append(initialScanRequests, *req)
 	}
 
+	// Scan a few representatives of requests that differ only in IDs (e.g., /product/1 ... /product/9000).
+	sort.Slice(initialScanRequests, func(i, j int) bool {
+		return initialScanRequests[i].Method+" "+initialScanRequests[i].URL < initialScanRequests[j].Method+" "+initialScanRequests[j].URL
+	})
+	var duplicateGroups []crawler.DuplicateGroup
+	var duplicateRequests []crawler.ParameterizedRequest
+	collapsedDuplicates := 0
+	initialScanRequests, duplicateRequests, duplicateGroups = crawler.PartitionDuplicates(initialScanRequests, dedupRepresentatives)
+	for _, group := range duplicateGroups {
+		collapsedDuplicates += group.Total - group.Representatives
+	}
+
 	// Discover additional parameters if scanning is enabled.
+	scanMetrics.StartPhase("discovery")
 	var enrichedScanRequests []crawler.ParameterizedRequest
-	if willScan {
-		enrichedScanRequests = dursGoCrawler.DiscoverParameters(initialScanRequests)
+	if crawlDone {
+		// Reuse the requests of the interrupted scan; discovering them again would send requests.
+		enrichedScanRequests = resumed.ScanRequests
+	} else if willScan {
+		paramDiscoverer := discovery.NewParameterDiscoverer(httpClient.WithSource("parameter-discovery", ""), log, discovery.ParameterDiscoveryOptions{
+			Concurrency:       concurrency,
+			ChunkSize:         paramChunkSize,
+			MaxRequestsPerURL: maxParamProbes,
+		})
+		enrichedScanRequests = paramDiscoverer.Discover(context.Background(), initialScanRequests)
 	} else {
 		enrichedScanRequests = initialScanRequests
 	}
 
+	// Turn GraphQL lookups by ID into scan requests, so injection and IDOR scanners test their
+	// variables like any other parameter.
+	if willScan && graphQLEndpoint != "" && !crawlDone {
+		if schema, _, err := graphQLFinder.Introspect(graphQLEndpoint); err != nil {
+			log.Debug("GraphQL introspection of %s failed, no GraphQL requests generated: %v", graphQLEndpoint, err)
+		} else {
+			graphQLRequests := schema.IDLookupRequests(graphQLEndpoint)
+			log.Info("Generated %d GraphQL request(s) from the schema of %s.", len(graphQLRequests), graphQLEndpoint)
+			enrichedScanRequests = append(enrichedScanRequests, graphQLRequests...)
+		}
+	}
+
+	// Find the parameters the server never reads, so that scanners skip them. Off by default:
+	// a parameter reaching a blind sink looks just as inert.
+	if willScan && skipInertParams {
+		inertDetector := discovery.NewInertParamDetector(httpClient.WithSource("inert-params", ""), log, concurrency)
+		scannerOptions.InertParams = inertDetector.Detect(context.Background(), enrichedScanRequests)
+	}
+
+	if err := stateStore.CompleteCrawl(enrichedScanRequests); err != nil {
+		log.Warn("Failed to save scan state to %s: %v", stateFile, err)
+	}
+
 	// Log crawler results.
 	log.Info("\n--- Crawler Results ---")
 	log.Info("Total unique URLs discovered: %d", len(allDiscoveredURLs))
@@ -475,6 +1507,43 @@ func main() {
 			log.Info("- %s", u)
 		}
 	}
+	if excluded := scope.ExcludedByReason(); len(excluded) > 0 {
+		log.Info("URLs excluded by scope: %d", scope.ExcludedCount())
+		reasons := make([]string, 0, len(excluded))
+		for reason := range excluded {
+			reasons = append(reasons, reason)
+		}
+		sort.Strings(reasons)
+		for _, reason := range reasons {
+			log.Info("- %s: %d", reason, excluded[reason])
+		}
+	}
+	if dropped := dursGoCrawler.DroppedByLimit(); len(dropped) > 0 {
+		limits := make([]string, 0, len(dropped))
+		for limit := range dropped {
+			limits = append(limits, limit)
+		}
+		sort.Strings(limits)
+		for _, limit := range limits {
+			log.Info("URLs not crawled because of the %s limit: %d", limit, dropped[limit])
+		}
+	}
+	if branches := dursGoCrawler.PrunedPagination(); len(branches) > 0 {
+		log.Info("Paginated listings sampled: %d", len(branches))
+		for _, b := range branches {
+			if b.Loop {
+				log.Info("- %s (%s pagination with '%s'): %d page(s) crawled, %d pruned after a page repeated an earlier one", b.Listing, b.Kind, b.Param, b.Crawled, b.Pruned)
+			} else {
+				log.Info("- %s (%s pagination with '%s'): %d page(s) crawled, %d pruned", b.Listing, b.Kind, b.Param, b.Crawled, b.Pruned)
+			}
+		}
+	}
+	if collapsedDuplicates > 0 {
+		log.Info("Structurally identical requests collapsed: %d (%d group(s))", collapsedDuplicates, len(duplicateGroups))
+		for _, group := range duplicateGroups {
+			log.Info("- %s: %d of %d scanned", group.Template, group.Representatives, group.Total)
+		}
+	}
 	log.Info("Found %d unique parameterized requests for vulnerability scanning.", len(enrichedScanRequests))
 	if len(enrichedScanRequests) > 0 {
 		var getRequests, postRequests []crawler.ParameterizedRequest
@@ -508,179 +1577,301 @@ func main() {
 
 	// Declare a slice to store all discovered vulnerabilities.
 	var allVulnerabilities []scanner.VulnerabilityResult
-
-	// Proceed with scanning if 'scanners_to_run' is not set to "none".
+	var requestsByScanner map[string]int64 // Requests sent per scanner, for the report summary.
+	var scanErrors map[string]int64        // Failed scanner/request pairs per scanner, for the exit status.
+
+	// Cancel in-flight scans on Ctrl-C/SIGTERM and report what was found so far.
+	// A second signal restores the default behavior and exits immediately.
+	scanCtx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	go func() {
+		<-scanCtx.Done()
+		stopSignals()
+	}()
+
+	// Proceed with scanning if any scanner is selected. Scanners whose packages are imported
+	// above register themselves; selectedScanners holds the ones chosen for this scan.
 	if willScan {
-		// Determine which scanners to run based on command-line flag or config.
-		scannersToRun := make(map[string]bool)
-		if scannersToRunStr == "all" {
-			// Register all available scanners.
-			for _, s := range []string{"xss", "sqli", "lfi", "openredirect", "ssrf", "exposed", "idor", "csrf", "cmdinjection", "ssti", "securityheaders", "cors", "fileupload", "bola", "massassignment", "graphql", "domxss"} {
-				scannersToRun[s] = true
-			}
-			// Conditionally enable blind SSRF if OAST is active.
-			if oast {
-				scannersToRun["blindssrf"] = true
-			}
-			// Conditionally enable DOM XSS if JavaScript rendering is active.
-			if renderJS {
-				scannersToRun["domxss"] = true
-			}
-		} else {
-			// Register specific scanners listed in the flag.
-			for _, s := range strings.Split(strings.ToLower(scannersToRunStr), ",") {
-				scannersToRun[strings.TrimSpace(s)] = true
+		log.Info("\n--- Initiating Vulnerability Scans ---")
+		scannerManager := scanner.NewManager(httpClient, log, scannerOptions)
+
+		// Candidates of some scanners (e.g., takeover) are served by third parties, so they get
+		// a client without the scan's credentials.
+		anonymousClientOpts := clientOpts
+		anonymousClientOpts.AuthCookie, anonymousClientOpts.AuthHeaders = "", nil
+		scannerEnv := scanner.Env{
+			TargetBaseURL:   targetBaseURL,
+			AnonymousClient: httpclient.NewClient(log, anonymousClientOpts),
+			OAST:            oast,
+			Renderer:        renderJS,
+			LoginUsernames:  loginUsernames(cfg),
+		}
+		for _, module := range selectedScanners.Modules {
+			if module.New != nil {
+				scannerManager.RegisterModule(module.Name, module.New(scannerEnv))
+			} else {
+				scannerManager.RegisterPassiveModule(module.Name, module.NewPassive(scannerEnv))
 			}
 		}
 
-		// If any scanners are selected, initialize and run them.
-		if len(scannersToRun) > 0 {
-			log.Info("\n--- Initiating Vulnerability Scans ---")
-			scannerManager := scanner.NewManager(httpClient, log, scannerOptions)
-
-			// Register individual scanners based on the 'scannersToRun' map.
-			if scannersToRun["all"] || scannersToRun["xss"] || scannersToRun["xss-reflected"] {
-				scannerManager.RegisterScanner(xss.NewReflectedXSSScanner())
-			}
-			if scannersToRun["all"] || scannersToRun["xss"] || scannersToRun["xss-stored"] {
-				scannerManager.RegisterScanner(xss.NewStoredXSSScanner())
-			}
-			if scannersToRun["sqli"] {
-				scannerManager.RegisterScanner(sqli.NewSQLiScanner())
-			}
-			if scannersToRun["lfi"] {
-				scannerManager.RegisterScanner(lfi.NewLFIScanner())
-			}
-			if scannersToRun["openredirect"] {
-				scannerManager.RegisterScanner(openredirect.NewOpenRedirectScanner())
-			}
-			if scannersToRun["ssrf"] {
-				scannerManager.RegisterScanner(ssrf.NewSSRFScanner())
-			}
-			if scannersToRun["idor"] {
-				scannerManager.RegisterScanner(idor.NewIDORScanner())
-			}
-			if scannersToRun["csrf"] {
-				scannerManager.RegisterScanner(csrf.NewCSRFScanner())
-			}
-			if scannersToRun["cmdinjection"] {
-				scannerManager.RegisterScanner(cmdinjection.NewCommandInjectionScanner())
-			}
-			if scannersToRun["ssti"] {
-				scannerManager.RegisterScanner(ssti.NewSSTIScanner())
-			}
-			if scannersToRun["fileupload"] {
-				scannerManager.RegisterScanner(fileupload.NewFileUploadScanner())
-			}
-			if scannersToRun["securityheaders"] {
-				scannerManager.RegisterScanner(securityheaders.NewSecurityHeadersScanner())
-			}
-			if scannersToRun["cors"] {
-				scannerManager.RegisterScanner(cors.NewCORSScanner())
-			}
-			if scannersToRun["exposed"] {
-				scannerManager.RegisterScanner(exposed.NewExposedScanner())
-			}
-			if scannersToRun["bola"] {
-				scannerManager.RegisterScanner(bola.NewBOLAScanner())
-			}
-			if scannersToRun["massassignment"] {
-				scannerManager.RegisterScanner(massassignment.NewMassAssignmentScanner())
-			}
-			if scannersToRun["blindssrf"] && oast {
-				scannerManager.RegisterScanner(blindssrf.NewBlindSSRFScanner())
+		// Passive scanners analyze the responses fetched while crawling and send no requests.
+		if len(scannerManager.GetPassiveScanners()) > 0 {
+			if resumed != nil && resumed.PassiveComplete {
+				log.Info("Passive scans were completed before the scan was interrupted. Reusing their findings.")
+				allVulnerabilities = append(allVulnerabilities, resumed.PassiveFindings...)
+			} else {
+				scanMetrics.StartPhase("passive")
+				vulns := scannerManager.RunPassiveScans(dursGoCrawler.GetCrawledResponses())
+				stateStore.CompletePassive(vulns)
+				allVulnerabilities = append(allVulnerabilities, vulns...)
 			}
-			if scannersToRun["domxss"] && renderJS {
-				scannerManager.RegisterScanner(domxss.NewDOMXSSScanner())
+		}
+
+		// Run scans if there are registered scanners and discovered requests.
+		if len(scannerManager.GetRegisteredScanners()) > 0 && len(enrichedScanRequests) > 0 {
+			log.Info("Running scanners on %d unique targets (including proactively discovered params)...", len(enrichedScanRequests))
+			scannerManager.SkipRequests(duplicateRequests, scanner.SkipReasonDuplicate)
+			scanMetrics.StartPhase("active")
+			scanStatus.Start()
+			vulns := scannerManager.RunScans(scanCtx, enrichedScanRequests)
+			scanStatus.Stop()
+			allVulnerabilities = append(allVulnerabilities, vulns...)
+			// Merge the findings of the requests tested before the interruption.
+			if len(previousFindings) > 0 {
+				log.Info("Merging %d finding(s) of the interrupted scan.", len(previousFindings))
+				allVulnerabilities = append(allVulnerabilities, previousFindings...)
 			}
-			if scannersToRun["graphql"] {
-				scannerManager.RegisterScanner(graphql.NewGraphQLScanner())
+
+			// Look for the stored XSS markers on the pages of the target.
+			if len(scannerOptions.StoredMarkers.Markers()) > 0 && scanCtx.Err() == nil {
+				scanMetrics.StartPhase("stored")
+				log.Info("Looking for %d stored XSS marker(s) on the pages of the target...", len(scannerOptions.StoredMarkers.Markers()))
+				storedVulns := xss.RetrieveStored(scanCtx, dursGoCrawler.GetCrawledResponses(), httpClient, log, scannerOptions)
+				scanner.Classify(storedVulns)
+				overrides.Apply(storedVulns)
+				findingSinks.Emit(storedVulns)
+				allVulnerabilities = append(allVulnerabilities, storedVulns...)
 			}
 
-			// Run scans if there are registered scanners and discovered requests.
-			if len(scannerManager.GetRegisteredScanners()) > 0 && len(enrichedScanRequests) > 0 {
-				log.Info("Running scanners on %d unique targets (including proactively discovered params)...", len(enrichedScanRequests))
-				vulns := scannerManager.RunScans(enrichedScanRequests)
-				allVulnerabilities = append(allVulnerabilities, vulns...)
+			// Report how many requests each scanner consumed to help tune the budgets.
+			requestsByScanner = scannerManager.RequestCounts()
+			scanErrors = scannerManager.ErrorCounts()
+			for _, s := range scannerManager.GetRegisteredScanners() {
+				log.Info("Requests sent by %s scanner: %d", s.Name(), requestsByScanner[s.Name()])
 			}
 		}
 	} else {
 		log.Info("\nOnly crawling requested. Skipping vulnerability scan.")
 	}
+	// The content the stored XSS markers and payloads created on the target, for its owners.
+	storedContent := scannerOptions.StoredMarkers.Content()
+	if len(storedContent) > 0 {
+		log.Warn("The scan submitted %d marker(s) and payload(s) to writable parameters; the content they created is listed in the report (stored_content) for cleanup.", len(storedContent))
+	}
+
+	if vulns := errorPages.Findings(); len(vulns) > 0 {
+		scanner.Classify(vulns)
+		overrides.Apply(vulns)
+		findingSinks.Emit(vulns)
+		allVulnerabilities = append(allVulnerabilities, vulns...)
+	}
+
+	// Stop the control interface; the actions taken through it are reported.
+	var controlActions []control.Action
+	if controlServer != nil {
+		controlServer.Close()
+		controlActions = controlServer.Actions()
+	}
+
+	// The parameters found inert, and the tests skipped because of them (-skip-inert-params).
+	var inertParams *scanner.InertSummary
+	if scannerOptions.InertParams != nil {
+		summary := scannerOptions.InertParams.Summary()
+		inertParams = &summary
+	}
+
+	// Whether each parameter was tested by each scanner; every test is listed with -coverage.
+	var coverage *scanner.CoverageSummary
+	if willScan {
+		summary := scannerOptions.Coverage.Summary(recordCoverage)
+		coverage = &summary
+	}
+
+	// Transient failures mean the target was flaky; requests that still failed were skipped.
+	retryStats := httpClient.RetryStats()
+	if retryStats.Failed > 0 {
+		log.Warn("%d request(s) failed with transient errors after all retries; results may be incomplete (%d retries, %d request(s) recovered).", retryStats.Failed, retryStats.Retries, retryStats.Recovered)
+	} else if retryStats.Retries > 0 {
+		log.Info("%d retries of transient failures; %d request(s) recovered.", retryStats.Retries, retryStats.Recovered)
+	}
+	// A session lost during the scan leaves every later test untested.
+	authInfo.SessionStats = httpClient.SessionStats()
+	if authInfo.Lost {
+		log.Warn("!!! RESULTS ARE INCOMPLETE: the authenticated session was lost and the scan was ended (%s).", authInfo.Reason)
+	} else if authInfo.Reauthentications > 0 {
+		log.Info("The authenticated session was lost and re-established %d time(s).", authInfo.Reauthentications)
+	}
+	// Hosts that blocked the scan answered some tests with block pages, or none at all.
+	blockedHosts := httpClient.BlockedHosts()
+	for _, h := range blockedHosts {
+		if h.Aborted {
+			log.Warn("!!! RESULTS ARE INCOMPLETE: %s blocked the scan and was given up (%s); %d blocked response(s) discarded.", h.Host, h.Reason, h.BlockedResponses)
+		} else {
+			log.Warn("RESULTS MAY BE INCOMPLETE: %s blocked the scan %d time(s) (%s); %d blocked response(s) discarded.", h.Host, h.Strikes, h.Reason, h.BlockedResponses)
+		}
+	}
 
 	// Handle OAST (Out-of-Band Application Security Testing) interactions.
 	if oast {
-		log.Info("Waiting for final OAST interactions (10 seconds)...")
-		time.Sleep(10 * time.Second) // Wait for any pending OAST interactions.
-		interactshClient.StopPolling()
-		oastMu.Lock()
-		defer oastMu.Unlock()
-		if len(oastInteractions) > 0 {
+		scanMetrics.StartPhase("oast")
+		if scanCtx.Err() == nil && oastWait > 0 {
+			log.Info("Waiting for final OAST interactions (%d seconds)...", oastWait)
+			select { // Wait for any pending OAST interactions; an interrupt ends the wait.
+			case <-time.After(time.Duration(oastWait) * time.Second):
+			case <-scanCtx.Done():
+			}
+		}
+		if len(collaborator.Interactions()) > 0 {
 			log.Success("--- OAST Interaction(s) Detected! Correlating results... ---")
 			var confirmedOASTFindings []scanner.VulnerabilityResult
 			// Iterate through potential vulnerabilities and correlate with OAST interactions.
 			scannerOptions.OASTCorrelationMap.Range(func(key, value interface{}) bool {
 				correlationID := key.(string)
 				potentialVuln := value.(scanner.VulnerabilityResult)
-				for _, interaction := range oastInteractions {
-					if strings.Contains(interaction.FullId, correlationID) {
-						potentialVuln.Details += fmt.Sprintf(" Confirmed via %s interaction from %s.", interaction.Protocol, interaction.RemoteAddress)
-						confirmedOASTFindings = append(confirmedOASTFindings, potentialVuln)
-						scannerOptions.OASTCorrelationMap.Delete(key) // Remove correlated vulnerability from map.
-						return false                                  // Stop iterating for this key.
-					}
+				if interaction, ok := collaborator.Find(correlationID); ok {
+					potentialVuln.Details += fmt.Sprintf(" Confirmed via %s interaction from %s.", interaction.Protocol, interaction.RemoteAddress)
+					potentialVuln.Evidence = strings.TrimSpace(potentialVuln.Evidence + fmt.Sprintf(" %s interaction received at %s from %s.", strings.ToUpper(interaction.Protocol), interaction.Timestamp.Format(time.RFC3339), interaction.RemoteAddress))
+					confirmedOASTFindings = append(confirmedOASTFindings, potentialVuln)
+					scannerOptions.OASTCorrelationMap.Delete(key) // Remove correlated vulnerability from map.
 				}
 				return true // Continue iterating.
 			})
+			scanner.Classify(confirmedOASTFindings)
+			overrides.Apply(confirmedOASTFindings)
+			findingSinks.Emit(confirmedOASTFindings)
 			allVulnerabilities = append(allVulnerabilities, confirmedOASTFindings...)
 		} else {
 			log.Info("No OAST interactions detected.")
 		}
+		// Stored input may be viewed long after the scan: keep the blind XSS injections that have
+		// not called back in the state file, so that a run with -resume keeps waiting for them.
+		if stateStore != nil && scannerOptions.BoolOption(xss.BlindModuleName, "save_pending", true) {
+			pending := append([]state.PendingCallback{}, unmatchedPending...)
+			scannerOptions.OASTCorrelationMap.Range(func(key, value interface{}) bool {
+				if finding := value.(scanner.VulnerabilityResult); finding.ScannerName == xss.BlindScannerName {
+					pending = append(pending, state.PendingCallback{CollaboratorURL: oobCollaboratorURL, CorrelationID: key.(string), Finding: finding})
+				}
+				return true
+			})
+			stateStore.SetPendingCallbacks(pending)
+			if len(pending) > 0 {
+				log.Info("%d blind XSS injection(s) still pending saved to %s; run again with -resume (same -oob-url) to keep waiting for them.", len(pending), stateFile)
+			}
+		}
 	}
 
+	scanMetrics.Finish()
+	metricsServer.Close()
+	statistics := scanMetrics.Summary()
+
 	// Display scan results.
 	log.Info("\n--- Scan Results ---")
-	var finalReportVulns []scanner.VulnerabilityResult
-	if len(allVulnerabilities) > 0 {
-		reportedVulnerabilities := make(map[string]scanner.VulnerabilityResult)
-		// Deduplicate and log vulnerabilities.
-		for _, vuln := range allVulnerabilities {
-			parsedVulnURL, err := url.Parse(vuln.URL)
-			var reportKey string
-			if err == nil {
-				// Normalize path for deduplication (e.g., /product/1 and /product/2 become /product/{ID}).
-				normalizedPath := normalizePathForDeduplication(parsedVulnURL.Path)
-				reportKey = fmt.Sprintf("%s|%s|%s", vuln.VulnerabilityType, normalizedPath, vuln.Parameter)
-			} else {
-				reportKey = fmt.Sprintf("%s|%s|%s", vuln.VulnerabilityType, vuln.URL, vuln.Parameter)
+	// Findings of an interrupted scan may predate the CVSS scores and the severity overrides.
+	scanner.Classify(allVulnerabilities)
+	overrides.Apply(allVulnerabilities)
+	// Findings with the same type, host, path template (e.g., /product/{id}), parameter and
+	// location are collapsed into one listing every affected URL.
+	finalReportVulns := reporter.Aggregate(allVulnerabilities, !noCollapseFindings)
+	if filtered := reporter.FilterByCVSS(finalReportVulns, minCVSS); len(filtered) < len(finalReportVulns) {
+		log.Info("Leaving %d finding(s) with a CVSS score below %.1f out of the reports.", len(finalReportVulns)-len(filtered), minCVSS)
+		finalReportVulns = filtered
+	}
+	if filtered := reporter.FilterByConfidence(finalReportVulns, minConfidence); len(filtered) < len(finalReportVulns) {
+		log.Info("Leaving %d finding(s) with a confidence below %s out of the reports.", len(finalReportVulns)-len(filtered), minConfidence)
+		finalReportVulns = filtered
+	}
+	if sortFindings == "cvss" {
+		reporter.SortByCVSS(finalReportVulns)
+	}
+	var resolvedFindings []reporter.Finding
+	var diffSummary *reporter.DiffSummary
+	if baseline != nil {
+		var summary reporter.DiffSummary
+		resolvedFindings, summary = baseline.Diff(finalReportVulns)
+		diffSummary = &summary
+		if scanCtx.Err() != nil && summary.Resolved > 0 {
+			log.Warn("The scan was interrupted; some of the resolved findings may not have been tested again.")
+		}
+	}
+	// Suppressed findings are compared with the baseline like the others, so accepting a
+	// finding does not list it as resolved.
+	finalReportVulns, suppressedVulns := suppressions.Apply(finalReportVulns)
+	if len(finalReportVulns) > 0 {
+		// Log the vulnerabilities.
+		for _, vuln := range finalReportVulns {
+			log.Success("--------------------------------------------------")
+			log.Success("Vulnerability Found: %s", vuln.VulnerabilityType)
+			log.Success("  URL: %s", vuln.URL)
+			if vuln.Occurrences > 1 {
+				log.Success("  Also found at %d other URL(s): %s", vuln.Occurrences-1, strings.Join(vuln.AffectedURLs[1:], ", "))
 			}
-
-			if _, exists := reportedVulnerabilities[reportKey]; !exists {
-				reportedVulnerabilities[reportKey] = vuln
-				finalReportVulns = append(finalReportVulns, vuln)
-				log.Success("--------------------------------------------------")
-				log.Success("Vulnerability Found: %s", vuln.VulnerabilityType)
-				log.Success("  URL: %s", vuln.URL)
-				if vuln.Parameter != "" {
-					log.Success("  Parameter: %s", vuln.Parameter)
-				}
-				if vuln.Location != "" {
-					log.Success("  Location: %s", vuln.Location)
-				}
-				if vuln.Payload != "" {
-					log.Success("  Payload/Info: %s", vuln.Payload)
-				}
-				if vuln.Severity != "" {
-					log.Success("  Severity: %s", vuln.Severity)
-				}
-				log.Success("  Details: %s", vuln.Details)
+			if vuln.Parameter != "" {
+				log.Success("  Parameter: %s", vuln.Parameter)
+			}
+			if vuln.Location != "" {
+				log.Success("  Location: %s", vuln.Location)
+			}
+			if vuln.Payload != "" {
+				log.Success("  Payload/Info: %s", vuln.Payload)
+			}
+			switch {
+			case vuln.OriginalSeverity != "":
+				log.Success("  Severity: %s (overridden from %s)", vuln.Severity, vuln.OriginalSeverity)
+			case vuln.Severity != "":
+				log.Success("  Severity: %s", vuln.Severity)
+			}
+			if vuln.Confidence != "" {
+				log.Success("  Confidence: %s", vuln.Confidence)
+			}
+			if vuln.CVSSVector != "" {
+				log.Success("  CVSS: %.1f (%s)", vuln.CVSSScore, vuln.CVSSVector)
+			}
+			if vuln.CWE != "" {
+				log.Success("  CWE: %s", vuln.CWE)
+			}
+			log.Success("  Fingerprint: %s", vuln.Fingerprint)
+			if vuln.DiffStatus != "" {
+				log.Success("  Baseline: %s", vuln.DiffStatus)
+			}
+			log.Success("  Details: %s", vuln.Details)
+			if vuln.Transformation != "" {
+				log.Success("  Evasion: %s", vuln.Transformation)
+			}
+			if vuln.ActiveExploitation != "" {
+				log.Success("  Active exploitation: %s", vuln.ActiveExploitation)
 			}
 		}
 		log.Success("--------------------------------------------------")
-		log.Info("Total unique vulnerabilities reported: %d", len(reportedVulnerabilities))
+		log.Info("Total unique vulnerabilities reported: %d", len(finalReportVulns))
 	} else if willScan {
 		log.Info("No vulnerabilities found.")
 	}
+	for _, vuln := range suppressedVulns {
+		log.Info("Suppressed: %s at %s (rule %s)", vuln.VulnerabilityType, vuln.URL, vuln.SuppressedBy)
+	}
+	if suppressions != nil {
+		log.Info("%d finding(s) suppressed by %s.", len(suppressedVulns), suppressionsFile)
+		for _, rule := range suppressions.Unused() {
+			log.Warn("Suppression rule %s matched no finding; remove it from %s if it is stale.", rule, suppressionsFile)
+		}
+	}
+	if diffSummary != nil {
+		for _, f := range resolvedFindings {
+			log.Info("Resolved since the baseline: %s at %s", f.Type, f.URL)
+		}
+		log.Info("Compared with the baseline: %d new, %d existing, %d resolved finding(s).", diffSummary.New, diffSummary.Existing, diffSummary.Resolved)
+	}
+	logStatistics(log, statistics)
 
 	// Generate JSON report if output file is specified.
 	// Manual check for -output-json as a fallback for potential flag parsing issues.
@@ -712,22 +1903,13 @@ func main() {
 		} else {
 			log.Info("Generating JSON report to %s...", fullReportPath)
 
-			activeScannersList := make([]string, 0)
-			if willScan {
-				scannersToRun := make(map[string]bool)
-				if scannersToRunStr == "all" {
-					for _, s := range []string{"xss", "sqli", "lfi", "openredirect", "ssrf", "exposed", "idor", "csrf", "cmdinjection", "ssti", "securityheaders", "cors", "fileupload", "bola", "massassignment", "graphql", "domxss"} {
-						scannersToRun[s] = true
-					}
-				} else {
-					for _, s := range strings.Split(strings.ToLower(scannersToRunStr), ",") {
-						scannersToRun[strings.TrimSpace(s)] = true
-					}
-				}
-				for scannerName := range scannersToRun {
-					activeScannersList = append(activeScannersList, scannerName)
+			// Record the scanners that ran and the options of those that take any.
+			activeScannersList := selectedScanners.Names()
+			scannerOptionsForReport := make(map[string]map[string]interface{})
+			for name, options := range moduleOptions {
+				if len(options) > 0 {
+					scannerOptionsForReport[name] = options
 				}
-				sort.Strings(activeScannersList)
 			}
 
 			var paramRequestsForReport []crawler.ParameterizedRequest
@@ -793,6 +1975,30 @@ func main() {
 			// Finalize and write the report.
 			reportData := reporter.NewReport(targetURLStr, startTime)
 			reportData.Finalize(time.Now(), startTime, enrichedVulns, activeScannersList, fingerprintResult, len(allDiscoveredURLs), paramRequestsForReport)
+			reportData.ScanSummary.RequestsByScanner = requestsByScanner
+			reportData.ScanSummary.ExcludedByScope = scope.ExcludedByReason()
+			reportData.ScanSummary.CollapsedDuplicates = collapsedDuplicates
+			reportData.ScanSummary.DroppedByCrawlLimit = dursGoCrawler.DroppedByLimit()
+			reportData.ScanSummary.PrunedPagination = dursGoCrawler.PrunedPagination()
+			reportData.ScanSummary.RepresentativeCoverage = duplicateGroups
+			reportData.ScanSummary.ScannerOptions = scannerOptionsForReport
+			reportData.ScanSummary.Baseline = diffSummary
+			reportData.ScanSummary.Retries = &retryStats
+			reportData.ScanSummary.Authentication = &authInfo
+			reportData.ScanSummary.BlockedHosts = blockedHosts
+			reportData.ScanSummary.PayloadFiles = payloads.LoadedPayloadFiles()
+			reportData.ScanSummary.StoredContent = storedContent
+			reportData.ScanSummary.Technologies = technologies
+			reportData.ScanSummary.Suppressions = suppressions.Rules()
+			reportData.ScanSummary.ControlActions = controlActions
+			reportData.ScanSummary.InertParams = inertParams
+			reportData.ScanSummary.Coverage = coverage
+			reportData.ScanSummary.Statistics = &statistics
+			reportData.SuppressedVulnerabilities = suppressedVulns
+			if resumed != nil {
+				reportData.ScanSummary.ResumedFrom = resumed.StartedAt.Format(time.RFC3339)
+				reportData.ScanSummary.ResumedFindings = len(previousFindings) + len(resumed.PassiveFindings)
+			}
 
 			reportErr := reporter.WriteJSONReport(reportData, fullReportPath)
 			if reportErr != nil {
@@ -803,16 +2009,280 @@ func main() {
 		}
 	}
 
+	// Write the findings file.
+	switch outputFormat {
+	case reporter.FormatJSON, reporter.FormatHTML:
+		metadata := reporter.Metadata{
+			ToolVersion:       version,
+			Target:            targetURLStr,
+			StartTime:         startTime.UTC(),
+			EndTime:           time.Now().UTC(),
+			Interrupted:       scanCtx.Err() != nil,
+			URLsDiscovered:    len(allDiscoveredURLs),
+			RequestsByScanner: requestsByScanner,
+			Retries:           retryStats,
+			Baseline:          diffSummary,
+			Authentication:    authInfo,
+			BlockedHosts:      blockedHosts,
+			PayloadFiles:      payloads.LoadedPayloadFiles(),
+			StoredContent:     storedContent,
+			Technologies:      technologies,
+			SkipRules:         skipRules.Rules(),
+			Suppressions:      suppressions.Rules(),
+			ControlActions:    controlActions,
+			InertParams:       inertParams,
+			Coverage:          coverage,
+			Statistics:        &statistics,
+		}
+		if willScan {
+			metadata.RequestsScanned = len(enrichedScanRequests)
+		}
+		for _, module := range selectedScanners.Modules {
+			info := reporter.ScannerInfo{Name: module.Name, Version: module.Version}
+			if len(module.Options) > 0 {
+				info.Options = module.Options
+			}
+			metadata.Scanners = append(metadata.Scanners, info)
+		}
+		metadata.Policy = policyInfo(cfg.Policy, policyOverrides, selectedScanners.Modules, scannerOptions, maxDepth)
+		metadata.Scope = &reporter.ScopeInfo{
+			Subdomains:      cfg.Scope.Subdomains,
+			AllowedHosts:    cfg.Scope.AllowedHosts,
+			IncludePatterns: cfg.Scope.IncludePatterns,
+			ExcludePatterns: cfg.Scope.ExcludePatterns,
+			ExcludedURLs:    scope.ExcludedCount(),
+		}
+		if metadata.Scope.Subdomains == "" {
+			metadata.Scope.Subdomains = "same-host"
+		}
+		doc := reporter.NewDocument(metadata, finalReportVulns, findingOpts)
+		doc.Resolved = resolvedFindings
+		for _, v := range suppressedVulns {
+			doc.Suppressed = append(doc.Suppressed, reporter.NewFinding(v, findingOpts))
+		}
+		write := reporter.WriteDocument
+		if outputFormat == reporter.FormatHTML {
+			write = reporter.WriteHTML
+		}
+		if err := write(doc, outputFile); err != nil {
+			log.Error("Failed to write findings file %s: %v", outputFile, err)
+		} else {
+			log.Success("%d finding(s) saved to %s.", len(doc.Findings), outputFile)
+		}
+	case reporter.FormatJSONL:
+		written := findingsStream.Count()
+		if err := findingsStream.Close(); err != nil {
+			log.Error("Failed to write findings file %s: %v", outputFile, err)
+		} else {
+			log.Success("%d finding(s) streamed to %s.", written, outputFile)
+		}
+	}
+
+	if stateStore != nil {
+		if err := stateStore.Close(); err != nil {
+			log.Error("Failed to save scan state to %s: %v", stateFile, err)
+		} else if scanCtx.Err() != nil {
+			log.Info("Scan progress saved to %s. Run again with -resume to continue.", stateFile)
+		}
+	}
+
+	notifier.Close()
+
+	if harRecorder != nil {
+		if err := harRecorder.Close(); err != nil {
+			log.Error("Failed to write HAR file %s: %v", harOutput, err)
+		} else {
+			log.Success("%d request(s) recorded to %s.", harRecorder.Entries(), harOutput)
+		}
+	}
+
 	log.Info("Dursgo scan completed.")
+
+	// The exit status tells CI pipelines whether findings met the thresholds and whether the
+	// results are complete.
+	outcome := reporter.ScanOutcome{
+		Findings:         finalReportVulns,
+		FailOn:           failOn,
+		FailOnConfidence: failOnConfidence,
+		FailOnNew:        failOnNew,
+		ScanErrors:       scanErrors,
+		Interrupted:      scanCtx.Err() != nil,
+		BudgetSkipped:    httpClient.BudgetSkippedRequests(),
+	}
+	if authInfo.Lost {
+		outcome.SessionLost = authInfo.Reason
+	}
+	for _, h := range blockedHosts {
+		if h.Aborted {
+			outcome.AbortedHosts = append(outcome.AbortedHosts, h.Host)
+		}
+	}
+	status, reason := outcome.ExitStatus()
+	if status == reporter.ExitOK {
+		log.Success("Exit status %d: %s.", status, reason)
+		return
+	}
+	log.Error("Exit status %d: %s.", status, reason)
+	os.Exit(status)
+}
+
+// loggedSecrets returns the configured credentials that must never appear in log output: the
+// values of the static session cookies and authentication headers of both users, and of the
+// default cookies and default headers with a sensitive name (e.g., "Authorization").
+func loggedSecrets(cfg *config.Config, defaultHeaders map[string]string, defaultCookies string) []string {
+	redactKeys := cfg.Logging.RedactKeys
+	if len(redactKeys) == 0 {
+		redactKeys = logger.DefaultRedactKeys
+	}
+	var secrets []string
+	for _, cookie := range []string{cfg.Authentication.Cookie, cfg.Authentication.SecondSession.Cookie, defaultCookies} {
+		secrets = append(secrets, cookieValues(cookie)...)
+	}
+	for _, headers := range []map[string]string{cfg.Authentication.Headers, cfg.Authentication.SecondSession.Headers} {
+		for _, value := range headers {
+			secrets = append(secrets, value)
+		}
+	}
+	for name, value := range defaultHeaders {
+		lower := strings.ToLower(name)
+		for _, word := range redactKeys {
+			if word != "" && strings.Contains(lower, strings.ToLower(word)) {
+				secrets = append(secrets, value)
+				break
+			}
+		}
+	}
+	return secrets
+}
+
+// cookieValues returns the values of the cookies of a "Cookie" header value ("a=1; b=2").
+func cookieValues(header string) []string {
+	var values []string
+	for _, part := range strings.Split(header, ";") {
+		if _, value, ok := strings.Cut(strings.TrimSpace(part), "="); ok && value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// loginUsernames returns the user names in the login data of both configured users.
+func loginUsernames(cfg *config.Config) []string {
+	var names []string
+	for _, data := range []string{cfg.Authentication.LoginData, cfg.Authentication.SecondSession.LoginData} {
+		values, err := url.ParseQuery(data)
+		if err != nil {
+			continue
+		}
+		for field := range values {
+			if payloads.LoginUserParams[strings.ToLower(field)] && values.Get(field) != "" {
+				names = append(names, values.Get(field))
+			}
+		}
+	}
+	return names
+}
+
+// convertLegacyAuthentication converts the old authentication format (type "header" with
+// header_name and value) to the headers map.
+func convertLegacyAuthentication(log *logger.Logger, cfg *config.Config) {
+	if cfg.Authentication.Type != "header" || cfg.Authentication.HeaderName == "" || cfg.Authentication.Value == "" {
+		return
+	}
+	log.Info("Old authentication config format detected. Converting to new 'headers' format.")
+	if cfg.Authentication.Headers == nil {
+		cfg.Authentication.Headers = make(map[string]string)
+	}
+	cfg.Authentication.Headers[cfg.Authentication.HeaderName] = cfg.Authentication.Value
+	log.Debug("Converted old auth config to: Headers[%s] = %s", cfg.Authentication.HeaderName, "token_value")
+}
+
+// policyInfo returns the effective scan policy for the report metadata: the values the scan ran
+// with, under the name of the applied policy ("custom" without one) and the flags that
+// overrode it.
+func policyInfo(name string, overrides []string, modules []scanner.SelectedModule, opts scanner.ScannerOptions, maxDepth int) *reporter.PolicyInfo {
+	info := &reporter.PolicyInfo{
+		Name:                name,
+		Scanners:            []string{},
+		PayloadTier:         string(opts.PayloadTier),
+		TimeConfirmations:   len(timing.Delays(0, opts.TimeConfirmations)),
+		MaxRequestsPerParam: opts.MaxRequestsPerParam,
+		MaxDepth:            maxDepth,
+		OAST:                opts.OASTDomain != "" || opts.OOBCollaboratorURL != "",
+		InjectHeaders:       opts.InjectHeaders,
+		PoCExtraction:       opts.PoCExtraction,
+		Overrides:           overrides,
+	}
+	if opts.Evasion.Enabled() {
+		info.Evasion = opts.Evasion.String()
+	}
+	if info.Name == "" {
+		info.Name = "custom"
+	}
+	for _, module := range modules {
+		info.Scanners = append(info.Scanners, module.Name)
+		if delay, ok := module.Options["time_delay"].(int); ok && info.TimeDelay == 0 {
+			info.TimeDelay = delay
+		}
+	}
+	return info
+}
+
+// statisticsLines is the number of scanners, hosts and slow URLs logged by logStatistics; the
+// findings document lists them all.
+const statisticsLines = 5
+
+// logStatistics logs where the time of the scan went: the duration of each phase, and the
+// scanners that took the longest, the hosts that got the most requests and the slowest URLs.
+func logStatistics(log *logger.Logger, stats metrics.Summary) {
+	log.Info("\n--- Scan Statistics ---")
+	for _, p := range stats.Phases {
+		log.Info("Phase %s: %s, %d request(s).", p.Name, time.Duration(p.DurationSeconds*float64(time.Second)).Round(time.Millisecond), p.Requests)
+	}
+	for _, s := range stats.Scanners[:min(len(stats.Scanners), statisticsLines)] {
+		log.Info("Scanner %s: %.1fs in %d test(s), %d request(s) (%d failed, %.0f ms average response), %d finding(s).", s.Name, s.TimeSeconds, s.Tests, s.Requests, s.Errors, s.AverageResponseMilli, s.Findings)
+	}
+	for _, h := range stats.Hosts[:min(len(stats.Hosts), statisticsLines)] {
+		log.Info("Host %s: %d request(s) (%d failed), %.0f ms average response.", h.Host, h.Requests, h.Errors, h.AverageResponseMilli)
+	}
+	for _, u := range stats.Slowest[:min(len(stats.Slowest), statisticsLines)] {
+		source := u.Phase
+		if u.Scanner != "" {
+			source = u.Scanner
+		}
+		if source != "" {
+			source = " (" + source + ")"
+		}
+		log.Info("Slow URL: %s %s answered %d in %.0f ms%s.", u.Method, u.URL, u.Status, u.ResponseMilli, source)
+	}
 }
 
-// normalizePathForDeduplication replaces numeric parts of a URL path with a placeholder
-// to group vulnerabilities found in rewritten URLs (e.g., /product/1, /product/2).
-func normalizePathForDeduplication(path string) string {
-	// This regex finds any sequence of one or more digits.
-	re := regexp.MustCompile(`\d+`)
-	// Replace all numeric sequences with a static placeholder.
-	return re.ReplaceAllString(path, "{ID}")
+// loginAndCaptureCookie submits loginData to loginURL with a fresh client and returns the
+// session cookies it received as a "Cookie" header value. If checkKeyword is set, the login
+// response must contain it.
+func loginAndCaptureCookie(log *logger.Logger, clientOpts httpclient.ClientOptions, loginURL, loginData, checkKeyword string) (string, error) {
+	tempLoginClient := httpclient.NewClient(log, clientOpts).WithSource("login", "")
+	loginResp, err := tempLoginClient.Post(loginURL, "application/x-www-form-urlencoded", strings.NewReader(loginData))
+	if err != nil {
+		return "", fmt.Errorf("login request failed: %w", err)
+	}
+	bodyBytes, _ := io.ReadAll(loginResp.Body)
+	loginResp.Body.Close()
+	// Verify login success using a keyword.
+	if checkKeyword != "" && !strings.Contains(string(bodyBytes), checkKeyword) {
+		return "", fmt.Errorf("login check failed, keyword '%s' not found", checkKeyword)
+	}
+	// Capture session cookies after successful login.
+	parsedLoginURL, _ := url.Parse(loginURL)
+	loginCookies := tempLoginClient.GetClient().Jar.Cookies(parsedLoginURL)
+	if len(loginCookies) == 0 {
+		return "", fmt.Errorf("login successful, but no session cookies were returned")
+	}
+	var cookieStrings []string
+	for _, cookie := range loginCookies {
+		cookieStrings = append(cookieStrings, cookie.String())
+	}
+	return strings.Join(cookieStrings, "; "), nil
 }
 
 // convertToEnrichmentVulnerability converts a vulnerability from the scanner format to the enrichment format.
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/reporter"
+	"Dursgo/internal/retest"
+	"Dursgo/internal/scanner"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// retestOptions configures runRetest. The client and scanner options are those of a scan, so
+// the findings are re-tested with its authentication, rate limit and network settings.
+type retestOptions struct {
+	Source        string             // Report whose findings are re-tested (-retest).
+	Findings      []reporter.Finding // Findings of Source.
+	Client        *httpclient.Client
+	ClientOptions httpclient.ClientOptions // Options of Client, for the client without credentials.
+	ScannerOpts   scanner.ScannerOptions
+	Settings      map[string]map[string]interface{} // Options per module (scanners in config.yaml).
+	OAST          bool
+	Renderer      bool
+	Target        string
+	StartTime     time.Time
+	OutputFormat  string
+	OutputFile    string
+	FindingOpts   reporter.FindingOptions
+}
+
+// retestTarget returns the scheme and host of the first finding with a URL, the target of a
+// retest run without -u.
+func retestTarget(findings []reporter.Finding) string {
+	for _, f := range findings {
+		if u, err := url.Parse(f.URL); err == nil && u.IsAbs() {
+			return u.Scheme + "://" + u.Host
+		}
+	}
+	return ""
+}
+
+// runRetest re-tests the findings of a report (-retest) instead of crawling and scanning: each
+// finding's request is rebuilt and only the scanner that reported it runs, against its
+// parameter. The findings are logged as still vulnerable, remediated, on an endpoint that is
+// gone or not re-tested, and written to the findings file with -output-format json or html. The
+// exit status is reporter.ExitFindings when a finding is still vulnerable.
+func runRetest(log *logger.Logger, opts retestOptions) int {
+	// Every module may have reported a finding of the report; the ones the scan environment
+	// cannot run are reported per finding.
+	resolution, err := scanner.Resolve(scanner.Selection{Base: "all", Enable: scanner.AvailableScanners(), Settings: opts.Settings}, scanner.Env{OAST: true, Renderer: true})
+	if err != nil {
+		log.Error("Invalid scanner selection: %v", err)
+		return reporter.ExitUsage
+	}
+	anonymousClientOpts := opts.ClientOptions
+	anonymousClientOpts.AuthCookie, anonymousClientOpts.AuthHeaders = "", nil
+	env := scanner.Env{
+		TargetBaseURL:   opts.ClientOptions.TargetBaseURL,
+		AnonymousClient: httpclient.NewClient(log, anonymousClientOpts),
+		OAST:            opts.OAST,
+		Renderer:        opts.Renderer,
+	}
+	retester := retest.NewRetester(opts.Client, log, opts.ScannerOpts, resolution.Modules, env)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	log.Info("\n--- Re-testing %d finding(s) of %s ---", len(opts.Findings), opts.Source)
+	results := make([]retest.Result, 0, len(opts.Findings))
+	for _, f := range opts.Findings {
+		var result retest.Result
+		if ctx.Err() != nil {
+			result = retest.Result{Status: reporter.RetestNotRetested, Reason: "the retest was interrupted"}
+		} else {
+			result = retester.Retest(ctx, f)
+		}
+		results = append(results, result)
+
+		location := f.URL
+		if f.Parameter != "" {
+			location = fmt.Sprintf("%s (%s)", f.URL, f.Parameter)
+		}
+		switch result.Status {
+		case reporter.RetestStillVulnerable:
+			log.Success("Retest: %s at %s is still vulnerable: %s", f.Type, location, result.Fresh.Evidence)
+		case reporter.RetestRemediated:
+			log.Info("Retest: %s at %s is remediated.", f.Type, location)
+		case reporter.RetestEndpointGone:
+			log.Info("Retest: %s at %s: endpoint gone (%s).", f.Type, location, result.Reason)
+		default:
+			log.Warn("Retest: %s at %s was not re-tested: %s", f.Type, location, result.Reason)
+		}
+	}
+
+	metadata := reporter.Metadata{
+		ToolVersion:       version,
+		Target:            opts.Target,
+		StartTime:         opts.StartTime.UTC(),
+		EndTime:           time.Now().UTC(),
+		Interrupted:       ctx.Err() != nil,
+		RequestsByScanner: map[string]int64{"retest": opts.Client.RequestsSent()},
+		Retries:           opts.Client.RetryStats(),
+	}
+	doc := retest.Document(metadata, opts.Source, opts.Findings, results, opts.FindingOpts)
+	summary := doc.Metadata.Retest
+	log.Info("Retest: %d still vulnerable, %d remediated, %d endpoint(s) gone, %d not re-tested.", summary.StillVulnerable, summary.Remediated, summary.EndpointGone, summary.NotRetested)
+
+	switch opts.OutputFormat {
+	case reporter.FormatJSON, reporter.FormatHTML:
+		write := reporter.WriteDocument
+		if opts.OutputFormat == reporter.FormatHTML {
+			write = reporter.WriteHTML
+		}
+		if err := write(doc, opts.OutputFile); err != nil {
+			log.Error("Failed to write findings file %s: %v", opts.OutputFile, err)
+			return reporter.ExitScanError
+		}
+		log.Success("Retest of %d finding(s) saved to %s.", len(opts.Findings), opts.OutputFile)
+	}
+	if summary.StillVulnerable > 0 {
+		return reporter.ExitFindings
+	}
+	return reporter.ExitOK
+}
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"Dursgo/internal/logger"
+	"Dursgo/internal/reporter"
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// targetChildEnv marks the process scanning one target of a scan of several targets; it scans
+// its -u target only and ignores the targets of the flags and the configuration file.
+const targetChildEnv = "DURSGO_TARGET_CHILD"
+
+// targetScanOptions are the settings of a scan of several targets that the parent process
+// handles itself; every other flag is passed to the scan of each target unchanged.
+type targetScanOptions struct {
+	Targets        []string
+	Parallel       int    // Targets scanned concurrently.
+	OutputFormat   string // Format of the combined findings file.
+	OutputFile     string // Combined findings file; per-target documents are written next to it.
+	JSONReportFile string // -output-json; one report per target.
+	StateFile      string // -state-file; one state file per target.
+	HARFile        string // -har-output; one HAR file per target.
+	LogFormat      logger.Format
+}
+
+// readTargetsFile reads the targets of a -targets-file: one URL per line, blank lines and lines
+// starting with # ignored.
+func readTargetsFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var targets []string
+	lines := bufio.NewScanner(file)
+	for n := 1; lines.Scan(); n++ {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if err := checkTarget(line); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, n, err)
+		}
+		targets = append(targets, line)
+	}
+	return targets, lines.Err()
+}
+
+// checkTarget checks that target is an absolute URL, as -u requires.
+func checkTarget(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || !u.IsAbs() || u.Host == "" {
+		return fmt.Errorf("%q is not an absolute URL", target)
+	}
+	return nil
+}
+
+// uniqueTargets returns targets without empty and repeated entries, in their order.
+func uniqueTargets(targets []string) []string {
+	seen := make(map[string]bool)
+	var unique []string
+	for _, t := range targets {
+		if t = strings.TrimSpace(t); t != "" && !seen[t] {
+			seen[t] = true
+			unique = append(unique, t)
+		}
+	}
+	return unique
+}
+
+// targetFileName returns the file of the index-th target for a file of the whole scan, e.g.
+// scan-2-shop.example.com.json for scan.json; ext replaces the extension of path unless empty.
+func targetFileName(path string, index int, target, ext string) string {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	if ext == "" {
+		ext = filepath.Ext(path)
+	}
+	host := "target"
+	if u, err := url.Parse(target); err == nil && u.Host != "" {
+		host = strings.NewReplacer(":", "_", "[", "", "]", "").Replace(u.Host)
+	}
+	return fmt.Sprintf("%s-%d-%s%s", base, index+1, host, ext)
+}
+
+// runTargets scans each target in a dursgo process of its own, so that targets share the
+// configuration but not their crawl scope, session, cookies or rate limits, and combines their
+// findings documents. A target that fails (unreachable, failed login) is reported and the other
+// targets are scanned regardless. It returns the exit status of the whole scan.
+func runTargets(log *logger.Logger, opts targetScanOptions) int {
+	executable, err := os.Executable()
+	if err != nil {
+		log.Error("Cannot scan several targets: %v", err)
+		return reporter.ExitUsage
+	}
+	// The findings documents of the targets are kept next to the combined findings file; without
+	// one they are only needed until they are combined.
+	docDir := ""
+	if opts.OutputFile == "" {
+		if docDir, err = os.MkdirTemp("", "dursgo-targets-"); err != nil {
+			log.Error("Cannot scan several targets: %v", err)
+			return reporter.ExitUsage
+		}
+		defer os.RemoveAll(docDir)
+	}
+	if opts.Parallel < 1 {
+		opts.Parallel = 1
+	}
+	log.Info("Scanning %d targets, %d at a time.", len(opts.Targets), opts.Parallel)
+
+	// Ctrl-C reaches the scans of the targets as well; they stop and write what they found. No
+	// further target is started.
+	ctx, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	go func() {
+		<-ctx.Done()
+		stopSignals()
+	}()
+
+	infos := make([]reporter.TargetInfo, len(opts.Targets))
+	docFiles := make([]string, len(opts.Targets))
+	var outputMu sync.Mutex // Keeps the lines of concurrent scans apart.
+	slots := make(chan struct{}, opts.Parallel)
+	var wg sync.WaitGroup
+	for i, target := range opts.Targets {
+		infos[i].Target = target
+		if opts.OutputFile != "" {
+			docFiles[i] = targetFileName(opts.OutputFile, i, target, ".json")
+			infos[i].FindingsFile = docFiles[i]
+		} else {
+			docFiles[i] = targetFileName(filepath.Join(docDir, "findings.json"), i, target, "")
+		}
+
+		slots <- struct{}{}
+		if ctx.Err() != nil {
+			<-slots
+			infos[i].ExitStatus, infos[i].Status = reporter.ExitPartial, "not scanned: the scan was interrupted"
+			continue
+		}
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			args := append(os.Args[1:len(os.Args):len(os.Args)], "-u", target, "-output-format", reporter.FormatJSON, "-output", docFiles[i])
+			if opts.JSONReportFile != "" {
+				args = append(args, "-output-json", targetFileName(opts.JSONReportFile, i, target, ""))
+			}
+			if opts.StateFile != "" {
+				args = append(args, "-state-file", targetFileName(opts.StateFile, i, target, ""))
+			}
+			if opts.HARFile != "" {
+				args = append(args, "-har-output", targetFileName(opts.HARFile, i, target, ""))
+			}
+			cmd := exec.Command(executable, args...)
+			cmd.Env = append(os.Environ(), targetChildEnv+"=1")
+			prefix := ""
+			if opts.LogFormat != logger.FormatJSON {
+				prefix = "[" + target + "] "
+			}
+			stdout := &linePrefixWriter{prefix: prefix, out: os.Stdout, mu: &outputMu}
+			stderr := &linePrefixWriter{prefix: prefix, out: os.Stderr, mu: &outputMu}
+			cmd.Stdout, cmd.Stderr = stdout, stderr
+
+			log.Info("Starting the scan of %s.", target)
+			started := time.Now()
+			err := cmd.Run()
+			stdout.Flush()
+			stderr.Flush()
+
+			var exitErr *exec.ExitError
+			switch {
+			case err == nil:
+				infos[i].ExitStatus = reporter.ExitOK
+			case errors.As(err, &exitErr) && exitErr.ExitCode() > 0:
+				infos[i].ExitStatus = exitErr.ExitCode()
+			default:
+				infos[i].ExitStatus, infos[i].Status = reporter.ExitScanError, fmt.Sprintf("failed: %v", err)
+			}
+			if infos[i].Status == "" {
+				infos[i].Status = exitStatusText(infos[i].ExitStatus)
+			}
+			if infos[i].ExitStatus == reporter.ExitScanError || infos[i].ExitStatus == reporter.ExitUsage {
+				log.Error("Scan of %s failed after %s (exit status %d: %s); continuing with the other targets.", target, time.Since(started).Round(time.Second), infos[i].ExitStatus, infos[i].Status)
+			} else {
+				log.Info("Scan of %s finished after %s (exit status %d: %s).", target, time.Since(started).Round(time.Second), infos[i].ExitStatus, infos[i].Status)
+			}
+		}(i, target)
+	}
+	wg.Wait()
+
+	// Combine the findings documents; a target that failed before writing one has no findings.
+	docs := make([]*reporter.Document, len(opts.Targets))
+	for i, path := range docFiles {
+		doc, err := reporter.ReadDocument(path)
+		if err != nil {
+			if !errors.Is(err, os.ErrNotExist) {
+				log.Warn("Ignoring the findings of %s: %v", opts.Targets[i], err)
+			}
+			infos[i].FindingsFile = ""
+			continue
+		}
+		docs[i] = doc
+	}
+	combined := reporter.CombineDocuments(infos, docs)
+	if combined.Metadata.ToolVersion == "" {
+		combined.Metadata.ToolVersion = version
+	}
+
+	log.Info("\n--- Results per Target ---")
+	for _, t := range combined.Metadata.Targets {
+		log.Info("- %s: %d finding(s), exit status %d (%s)", t.Target, t.Findings, t.ExitStatus, t.Status)
+	}
+	log.Info("Total unique vulnerabilities reported: %d on %d target(s)", len(combined.Findings), len(opts.Targets))
+
+	var write func(*reporter.Document, string) error
+	switch opts.OutputFormat {
+	case reporter.FormatJSON:
+		write = reporter.WriteDocument
+	case reporter.FormatJSONL:
+		write = reporter.WriteJSONL
+	case reporter.FormatHTML:
+		write = reporter.WriteHTML
+	}
+	if write != nil {
+		if err := write(combined, opts.OutputFile); err != nil {
+			log.Error("Failed to write findings file %s: %v", opts.OutputFile, err)
+		} else {
+			log.Success("%d finding(s) of %d target(s) saved to %s.", len(combined.Findings), len(opts.Targets), opts.OutputFile)
+		}
+	}
+
+	status, reason := reporter.TargetsExitStatus(combined.Metadata.Targets)
+	if status == reporter.ExitOK {
+		log.Success("Exit status %d: %s.", status, reason)
+	} else {
+		log.Error("Exit status %d: %s.", status, reason)
+	}
+	return status
+}
+
+// exitStatusText describes an exit status of the scan of one target.
+func exitStatusText(status int) string {
+	switch status {
+	case reporter.ExitOK:
+		return "scan completed"
+	case reporter.ExitUsage, 2:
+		return "invalid options or configuration"
+	case reporter.ExitFindings:
+		return "findings met the failure threshold"
+	case reporter.ExitScanError:
+		return "scan error: target unreachable, login failed or scanner errors"
+	case reporter.ExitPartial:
+		return "partial scan"
+	}
+	return fmt.Sprintf("exit status %d", status)
+}
+
+// linePrefixWriter writes the output of the scan of one target line by line, each line with
+// the prefix naming the target, so that the lines of concurrent scans do not mix.
+type linePrefixWriter struct {
+	prefix  string
+	out     io.Writer
+	mu      *sync.Mutex
+	pending []byte // Start of a line whose end has not been written yet.
+}
+
+func (w *linePrefixWriter) Write(p []byte) (int, error) {
+	w.pending = append(w.pending, p...)
+	for {
+		end := bytes.IndexByte(w.pending, '\n')
+		if end < 0 {
+			return len(p), nil
+		}
+		w.writeLine(w.pending[:end+1])
+		w.pending = w.pending[end+1:]
+	}
+}
+
+// Flush writes the last line if the output did not end with a newline.
+func (w *linePrefixWriter) Flush() {
+	if len(w.pending) > 0 {
+		w.writeLine(append(w.pending, '\n'))
+		w.pending = nil
+	}
+}
+
+func (w *linePrefixWriter) writeLine(line []byte) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	io.WriteString(w.out, w.prefix)
+	w.out.Write(line)
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"Dursgo/internal/config"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/reporter"
+	"Dursgo/internal/verify"
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+)
+
+// runVerifyCommand runs "dursgo verify": it replays the findings of a report (or the one chosen
+// with -finding), re-runs the checks that detected them and prints PASS or FAIL with fresh
+// evidence. The exit status is reporter.ExitFindings when a finding failed to verify.
+func runVerifyCommand(log *logger.Logger, args []string) int {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	file := flags.String("config", defaultConfigFile, "Configuration file with the authentication and network settings")
+	profile := flags.String("profile", "", "Profile of the configuration file to use")
+	key := flags.String("finding", "", "Finding to verify: its ID, its fingerprint or its 1-based index in the report (default all)")
+	baselineSamples := flags.Int("baseline-samples", 0, "Requests measuring the normal response time of timing checks (0 = default)")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s verify [-config file] [-profile name] [-finding id] findings.json\n", os.Args[0])
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return 2
+	}
+
+	if *file != defaultConfigFile {
+		if _, err := os.Stat(*file); err != nil {
+			log.Error("Failed to load config: %v", err)
+			return 1
+		}
+	}
+	cfg, err := config.Load(*file, *profile)
+	if err != nil {
+		log.Error("Failed to load config: %v", err)
+		return 1
+	}
+	convertLegacyAuthentication(log, cfg)
+
+	findings, err := reporter.LoadFindings(flags.Arg(0))
+	if err != nil {
+		log.Error("%v", err)
+		return 1
+	}
+	selected, err := verify.Select(findings, *key)
+	if err != nil {
+		log.Error("%v", err)
+		return 1
+	}
+	if len(selected) == 0 {
+		log.Info("%s has no findings to verify.", flags.Arg(0))
+		return reporter.ExitOK
+	}
+
+	// Timing checks wait for the injected sleep on top of the normal response time.
+	maxDelay := 0
+	for _, f := range selected {
+		if f.Reproduction != nil && f.Reproduction.Delay > maxDelay {
+			maxDelay = f.Reproduction.Delay
+		}
+	}
+	clientOpts := httpclient.ClientOptions{
+		Timeout:            15*time.Second + time.Duration(maxDelay)*time.Second,
+		UserAgent:          cfg.UserAgent,
+		FollowRedirects:    true,
+		MaxRetries:         cfg.MaxRetries,
+		ProxyURL:           cfg.Proxy,
+		CACertFile:         cfg.CACert,
+		InsecureSkipVerify: cfg.TLS.InsecureSkipVerify,
+		ClientCertFile:     cfg.TLS.ClientCert,
+		ClientKeyFile:      cfg.TLS.ClientKey,
+		ServerName:         cfg.TLS.ServerName,
+		MinTLSVersion:      cfg.TLS.MinVersion,
+		HTTPVersion:        cfg.HTTPVersion,
+		Resolver:           cfg.DNS.Resolver,
+		Resolve:            cfg.DNS.Resolve,
+		Headers:            cfg.Headers,
+		Cookies:            cfg.Cookies,
+		MaxResponseBytes:   cfg.MaxResponseBytes,
+		BodyReadTimeout:    time.Duration(cfg.BodyReadTimeout) * time.Second,
+	}
+	if err := httpclient.CheckTransport(clientOpts, 10*time.Second); err != nil {
+		log.Error("Invalid network settings: %v", err)
+		return 1
+	}
+	if cfg.Authentication.Enabled {
+		if cfg.Authentication.LoginURL != "" {
+			log.Info("Authentication (Login Action) is enabled. Attempting to log in...")
+			cookie, err := loginAndCaptureCookie(log, clientOpts, cfg.Authentication.LoginURL, cfg.Authentication.LoginData, cfg.Authentication.LoginCheckKeyword)
+			if err != nil {
+				log.Error("%v", err)
+				return reporter.ExitScanError
+			}
+			log.Success("Login successful. Session cookie captured and will be used for the replays.")
+			log.AddSecrets(cookieValues(cookie)...)
+			clientOpts.AuthCookie = cookie
+		} else {
+			clientOpts.AuthCookie = cfg.Authentication.Cookie
+			clientOpts.AuthHeaders = cfg.Authentication.Headers
+		}
+	}
+
+	// The session is only sent to the target of each finding, as during the scan.
+	verifiers := make(map[string]*verify.Verifier)
+	verifierFor := func(rawURL string) *verify.Verifier {
+		base := rawURL
+		if u, err := url.Parse(rawURL); err == nil {
+			base = u.Scheme + "://" + u.Host
+		}
+		if v, ok := verifiers[base]; ok {
+			return v
+		}
+		opts := clientOpts
+		opts.TargetBaseURL = base
+		v := verify.NewVerifier(httpclient.NewClient(log, opts), log)
+		v.BaselineSamples = *baselineSamples
+		verifiers[base] = v
+		return v
+	}
+
+	failed := 0
+	for _, f := range selected {
+		target := f.URL
+		if f.Reproduction != nil {
+			target = f.Reproduction.Request.URL
+		}
+		result := verifierFor(target).Verify(context.Background(), f)
+		if result.Status == verify.Fail {
+			failed++
+		}
+		location := f.URL
+		if f.Parameter != "" {
+			location = fmt.Sprintf("%s (%s)", f.URL, f.Parameter)
+		}
+		fmt.Printf("[%s] %s %s at %s, %s check: %s\n", result.Status, f.ID, f.Type, location, result.Check, result.Evidence)
+	}
+	if failed > 0 {
+		log.Warn("%d of %d finding(s) could not be reproduced.", failed, len(selected))
+		return reporter.ExitFindings
+	}
+	return reporter.ExitOK
+}
@@ -1,16 +1,36 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"reflect"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
 
 // OutputConfig holds configuration settings related to output and logging.
 type OutputConfig struct {
-	Format     string `yaml:"format"`      // Output format (e.g., "text", "json").
-	OutputFile string `yaml:"output_file"` // Path to save the output file.
-	Verbose    bool   `yaml:"verbose"`     // Enable verbose logging.
+	Format             string  `yaml:"format"`               // Findings file format: "text" (none), "json", "jsonl" or "html".
+	FindingsFile       string  `yaml:"findings_file"`        // Path of the findings file for the "json", "jsonl" and "html" formats.
+	MaxEvidenceBytes   int     `yaml:"max_evidence_bytes"`   // Evidence size in the findings file (0 = 4096, -1 = unlimited).
+	ExcludeRaw         bool    `yaml:"exclude_raw"`          // Leave raw request/response dumps out of the findings file.
+	MinCVSS            float64 `yaml:"min_cvss"`             // Findings with a lower CVSS score are left out of the reports.
+	MinConfidence      string  `yaml:"min_confidence"`       // Less certain findings are left out of the reports: "certain", "firm" or "tentative".
+	SortFindings       string  `yaml:"sort_findings"`        // Order of the reported findings: "found" (default) or "cvss".
+	NoCollapseFindings bool    `yaml:"no_collapse_findings"` // Report a finding once per URL instead of once per fingerprint.
+	Coverage           bool    `yaml:"coverage"`             // List the outcome of every scanner/parameter test in the reports.
+	OutputFile         string  `yaml:"output_file"`          // Path to save the output file.
+	Verbose            bool    `yaml:"verbose"`              // Enable verbose logging.
+	Quiet              bool    `yaml:"quiet"`                // Show only the progress line, findings and errors.
+}
+
+// LoggingConfig configures the log output.
+type LoggingConfig struct {
+	Format        string            `yaml:"format"`         // "text" (default) or "json" (one object per line, e.g. for ELK).
+	ScannerLevels map[string]string `yaml:"scanner_levels"` // Log level per scanner module, e.g. sqli: debug.
+	RedactKeys    []string          `yaml:"redact_keys"`    // Words marking log fields whose values are redacted (default: auth, cookie, token, ...).
 }
 
 // AIConfig holds configuration for LLM integration.
@@ -21,6 +41,131 @@ type AIConfig struct {
 	Model    string `yaml:"model"`    // The specific model to use (e.g., "gpt-4-turbo").
 }
 
+// NotificationConfig configures the webhooks findings are posted to during the scan. The webhook
+// URLs are secrets and are read from environment variables, never from this file.
+type NotificationConfig struct {
+	WebhookURLEnv      string `yaml:"webhook_url_env"`       // Variable holding the generic JSON webhook URL (default: DURSGO_WEBHOOK_URL).
+	SlackWebhookURLEnv string `yaml:"slack_webhook_url_env"` // Variable holding the Slack incoming webhook URL (default: DURSGO_SLACK_WEBHOOK_URL).
+	MinSeverity        string `yaml:"min_severity"`          // Lowest severity notified (default: "high").
+	Digest             bool   `yaml:"digest"`                // Send batched digests instead of one message per finding.
+	DigestInterval     int    `yaml:"digest_interval"`       // Seconds between digests (0 = 300).
+}
+
+// TLSConfig configures the TLS connections of the HTTP client.
+type TLSConfig struct {
+	ClientCert         string `yaml:"client_cert"`          // PEM client certificate for mTLS-protected targets.
+	ClientKey          string `yaml:"client_key"`           // PEM private key of the client certificate (default: read from client_cert).
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"` // Accept any server certificate (unsafe; e.g., self-signed staging hosts).
+	ServerName         string `yaml:"server_name"`          // SNI server name sent and verified instead of the target host.
+	MinVersion         string `yaml:"min_version"`          // Minimum TLS version: "1.0", "1.1", "1.2" (default) or "1.3".
+}
+
+// DNSConfig configures how the HTTP client resolves host names.
+type DNSConfig struct {
+	Resolver string   `yaml:"resolver"` // DNS server (host or host:port, default port 53) used instead of the system resolver.
+	Resolve  []string `yaml:"resolve"`  // Host overrides "host:port:address" (port may be "*"), like curl's --resolve.
+}
+
+// SessionConfig holds the credentials of an additional user session, either a login
+// (LoginURL and LoginData) or a static cookie and headers.
+type SessionConfig struct {
+	LoginURL          string            `yaml:"login_url"`           // URL for dynamic login.
+	LoginData         string            `yaml:"login_data"`          // POST data for login form.
+	LoginCheckKeyword string            `yaml:"login_check_keyword"` // Keyword to verify successful login.
+	Cookie            string            `yaml:"cookie"`              // Static session cookie.
+	Headers           map[string]string `yaml:"headers"`             // Static authentication headers.
+}
+
+// SessionVerifyConfig verifies that a scan runs authenticated: after the login, URL must show
+// an authenticated session, or the scan fails; during the scan it is checked again, and a lost
+// session is re-established with a new login or ends the scan.
+type SessionVerifyConfig struct {
+	URL              string `yaml:"url"`                // Page only an authenticated user can see (absolute, or relative to the target); empty disables verification.
+	Pattern          string `yaml:"pattern"`            // Regex the page must match while authenticated (e.g., "Logout").
+	Status           int    `yaml:"status"`             // Status the page must answer (0 = any 2xx); redirects are not followed.
+	LoggedOutPattern string `yaml:"logged_out_pattern"` // Regex of responses served to logged-out users (e.g., the login form), checked on every response.
+	RecheckEvery     int    `yaml:"recheck_every"`      // Requests between verifications during the scan (0 = 200, negative = never).
+	OnLoss           string `yaml:"on_loss"`            // "reauth" (default with login_url) logs in again; "abort" ends the scan.
+	MaxReauth        int    `yaml:"max_reauth"`         // Re-logins allowed before the scan is ended (0 = 5).
+}
+
+// SecretPatternConfig defines a user-supplied pattern for the secrets scanner.
+type SecretPatternConfig struct {
+	Name     string `yaml:"name"`     // Kind of secret shown in findings.
+	Pattern  string `yaml:"pattern"`  // Regular expression matching the secret.
+	Severity string `yaml:"severity"` // Severity of findings (default: "Medium").
+	Verbatim bool   `yaml:"verbatim"` // Show the match unmasked (for non-credentials).
+}
+
+// TakeoverFingerprintConfig defines a user-supplied hosting service for the takeover scanner.
+type TakeoverFingerprintConfig struct {
+	Service  string   `yaml:"service"`  // Hosting service shown in findings.
+	CNAMEs   []string `yaml:"cnames"`   // Domain suffixes of the service's host names.
+	Pattern  string   `yaml:"pattern"`  // Regular expression matching the unclaimed resource page.
+	NXDomain bool     `yaml:"nxdomain"` // Unclaimed resources do not resolve (instead of pattern).
+}
+
+// TechnologyRuleConfig defines a user-supplied technology fingerprinting rule; it matches when
+// any of its conditions does.
+type TechnologyRuleConfig struct {
+	Name     string            `yaml:"name"`     // Technology shown in the scan metadata and reports.
+	Category string            `yaml:"category"` // E.g. "CMS", "Framework", "Language".
+	Headers  map[string]string `yaml:"headers"`  // Regular expressions matching response header values.
+	Cookies  []string          `yaml:"cookies"`  // Regular expressions matching cookie names.
+	Body     []string          `yaml:"body"`     // Regular expressions matching the page body.
+	Favicons []int32           `yaml:"favicons"` // Shodan-style favicon hashes (http.favicon.hash).
+	Implies  []string          `yaml:"implies"`  // Technologies it runs on, e.g. "PHP".
+}
+
+// WAFFingerprintConfig defines a user-supplied WAF block page for block detection.
+type WAFFingerprintConfig struct {
+	Name     string            `yaml:"name"`     // WAF shown in warnings and the report.
+	Statuses []int             `yaml:"statuses"` // Status codes of the block page (default: any 4xx or 5xx).
+	Pattern  string            `yaml:"pattern"`  // Regular expression matching the block page body.
+	Headers  map[string]string `yaml:"headers"`  // Regular expressions matching response header values.
+}
+
+// BlockDetectionConfig configures the detection of WAF blocking and rate limiting.
+type BlockDetectionConfig struct {
+	Disabled   bool `yaml:"disabled"`    // Don't detect blocking; block pages are passed to scanners.
+	Streak     int  `yaml:"streak"`      // Consecutive 403/429 responses that mean a host blocks (0 = 20).
+	Pause      int  `yaml:"pause"`       // Seconds a blocking host is paused (0 = 30).
+	MaxStrikes int  `yaml:"max_strikes"` // Times a host may block before it is given up (0 = 5).
+}
+
+// ScopeConfig restricts the URLs that are crawled and scanned.
+type ScopeConfig struct {
+	IncludePatterns []string `yaml:"include_patterns"` // Regexes on the full URL; when set, one must match.
+	ExcludePatterns []string `yaml:"exclude_patterns"` // Regexes on the full URL that are never crawled or scanned.
+	Subdomains      string   `yaml:"subdomains"`       // "same-host" (default), "same-domain" or "allowlist".
+	AllowedHosts    []string `yaml:"allowed_hosts"`    // Extra hosts for "allowlist" (e.g., "api.example.com", "*.example.com").
+}
+
+// SkipRulesConfig adjusts the parameters and paths scanners leave untested (skip_rules).
+type SkipRulesConfig struct {
+	NoDefaults bool             `yaml:"no_defaults"` // Drop every built-in rule.
+	Remove     []string         `yaml:"remove"`      // Parameters or paths of built-in rules to drop.
+	Rules      []SkipRuleConfig `yaml:"rules"`       // Rules added to the built-in ones.
+}
+
+// SkipRuleConfig excludes a parameter (by name, case-insensitive) or the URLs whose path
+// contains Path from the tests of the listed scanners, or of all scanners.
+type SkipRuleConfig struct {
+	Param    string   `yaml:"param"`
+	Path     string   `yaml:"path"`
+	Scanners []string `yaml:"scanners"`
+}
+
+// SeverityOverrideConfig changes the severity of the findings of a vulnerability type, optionally
+// only at the URLs matching a regex, and appends the organization's remediation guidance.
+type SeverityOverrideConfig struct {
+	Type           string `yaml:"type"`            // Vulnerability type, e.g. "Reflected XSS".
+	URL            string `yaml:"url"`             // Regex on the URL of the finding; empty matches all.
+	Severity       string `yaml:"severity"`        // critical, high, medium, low or info; empty keeps it.
+	Remediation    string `yaml:"remediation"`     // Text appended to the remediation.
+	RemediationURL string `yaml:"remediation_url"` // Link appended to the remediation, e.g. a wiki page.
+}
+
 // Config is the main struct to hold all configuration data from the YAML file.
 type Config struct {
 	Target      string   `yaml:"target"`          // Target URL for scanning.
@@ -32,9 +177,179 @@ type Config struct {
 	OAST        bool     `yaml:"oast"`            // Enable Out-of-Band Application Security Testing.
 	RenderJS    bool     `yaml:"render_js"`       // Enable JavaScript rendering via headless browser.
 	SeedURLs    []string `yaml:"seed_urls"`       // Additional URLs to start crawling from.
+	// Targets and TargetsFile (one URL per line) are further targets scanned with this
+	// configuration, each with its own crawl scope, session and rate limits.
+	Targets     []string `yaml:"targets"`
+	TargetsFile string   `yaml:"targets_file"`
+	// ParallelTargets is the number of targets scanned concurrently (0 = 1).
+	ParallelTargets int `yaml:"parallel_targets"`
+	// PerHostConcurrency caps the concurrent requests to one host, shared by the crawler and all
+	// scanners (0 = unlimited).
+	PerHostConcurrency int `yaml:"per_host_concurrency"`
+	// EnableScanners and DisableScanners add scanners to and remove them from scanners_to_run.
+	EnableScanners  []string `yaml:"enable_scanners"`
+	DisableScanners []string `yaml:"disable_scanners"`
+	// ScannerSettings holds the options of each scanner (scanners.<name>.<option>). The
+	// "enabled" and "order" keys of every scanner select it and set its position in the scan.
+	ScannerSettings map[string]map[string]interface{} `yaml:"scanners"`
+	// MaxPagesPerHost caps the pages crawled per host (0 = unlimited).
+	MaxPagesPerHost int `yaml:"max_pages_per_host"`
+	// MaxParamsPerURL drops crawled URLs with more query parameters (0 = unlimited).
+	MaxParamsPerURL int `yaml:"max_params_per_url"`
+	// CrawlDelay is the minimum delay between crawler requests to the same host, in milliseconds.
+	CrawlDelay int `yaml:"crawl_delay"`
+	// InfiniteURLThreshold is the number of query value variants of one path crawled before it is
+	// pruned as an infinite URL pattern (0 = 25, negative = never prune).
+	InfiniteURLThreshold int `yaml:"infinite_url_threshold"`
+	// PaginationPages is the number of pages of a listing paginated by page number or offset that
+	// are crawled (0 = 5, negative = every page).
+	PaginationPages int `yaml:"pagination_pages"`
+	// CursorPages is the number of pages of a cursor-paginated listing that are followed (0 = 20,
+	// negative = every page).
+	CursorPages int `yaml:"cursor_pages"`
+	// CrawlMode selects static, rendered or hybrid crawling (default: static, rendered with render_js).
+	CrawlMode string `yaml:"crawl_mode"`
+	// Scope restricts the URLs that are crawled and scanned.
+	Scope ScopeConfig `yaml:"scope"`
+	// APISpec is an OpenAPI/Swagger file whose operations are scanned instead of crawling.
+	APISpec string `yaml:"api_spec"`
+	// FormDefaults are the values submitted for empty form fields, by field name.
+	FormDefaults map[string]string `yaml:"form_defaults"`
+
+	// TimeBasedSamples is the number of baseline requests used before time-based tests.
+	TimeBasedSamples int `yaml:"time_based_samples"`
+	// InjectHeaders enables header and cookie injection points for supported scanners.
+	InjectHeaders bool `yaml:"inject_headers"`
+	// PoCExtraction exploits confirmed blind SQL injections to read a short proof value.
+	PoCExtraction bool `yaml:"poc_extraction"`
+	// ForcePrototypePollution tests prototype pollution on targets not fingerprinted as Node.js.
+	ForcePrototypePollution bool `yaml:"force_prototype_pollution"`
+	// SkipInertParams skips the parameters a pre-flight finds to have no effect on the response.
+	SkipInertParams bool `yaml:"skip_inert_params"`
+	// CSRFTokenFields are the anti-CSRF form fields refreshed before each test request (default:
+	// common token names).
+	CSRFTokenFields []string `yaml:"csrf_token_fields"`
+	// SkipRules extends, shrinks or clears the built-in rules of untested parameters and paths.
+	SkipRules SkipRulesConfig `yaml:"skip_rules"`
+	// SQLiErrorPatterns are additional regexes recognizing database errors (e.g., custom ORMs).
+	SQLiErrorPatterns []string `yaml:"sqli_error_patterns"`
+	// SecretPatterns are additional patterns the secrets scanner looks for in crawled responses.
+	SecretPatterns []SecretPatternConfig `yaml:"secret_patterns"`
+	// PayloadFiles replace built-in payload lists with the payloads of a file, one per line, by
+	// list name (e.g., sqli: "sqli-payloads.txt").
+	PayloadFiles map[string]string `yaml:"payload_files"`
+	// PayloadSets are YAML or JSON files of payloads merged with or replacing the built-in ones
+	// per category, including structured ones such as boolean and time-based SQLi tests.
+	PayloadSets []string `yaml:"payload_sets"`
+	// TakeoverFingerprints are additional hosting services the takeover scanner recognizes.
+	TakeoverFingerprints []TakeoverFingerprintConfig `yaml:"takeover_fingerprints"`
+	// WAFFingerprints are additional WAF block pages recognized by block detection.
+	WAFFingerprints []WAFFingerprintConfig `yaml:"waf_fingerprints"`
+	// TechnologyRules are additional rules of technology fingerprinting.
+	TechnologyRules []TechnologyRuleConfig `yaml:"technology_rules"`
+	// SimilarityThreshold is the similarity (0-1) below which responses count as different.
+	SimilarityThreshold float64 `yaml:"similarity_threshold"`
+	// SimilarityMode selects the response comparison mode ("levenshtein", "structure", "words").
+	SimilarityMode string `yaml:"similarity_mode"`
+	// RequestsPerSecond limits the scan request rate shared by all scanners (0 = unlimited).
+	RequestsPerSecond float64 `yaml:"requests_per_second"`
+	// MaxRequestsPerParam caps the requests sent while testing one parameter (0 = unlimited).
+	MaxRequestsPerParam int `yaml:"max_requests_per_param"`
+	// Policy is a built-in scan policy (quick, balanced or thorough) applied on top of the file
+	// and profile; see ApplyPolicy.
+	Policy string `yaml:"policy"`
+	// PayloadTier is how much of each payload list is sent: "minimal", "standard" or "full"
+	// (default).
+	PayloadTier string `yaml:"payload_tier"`
+	// Evasion expands the SQLi, XSS and command injection payloads with encoded variants: "none"
+	// (default), "low", "high" or a comma-separated list of transformations.
+	Evasion string `yaml:"evasion"`
+	// TimeConfirmations is the number of increasing delays a time-based finding must be
+	// reproduced with (0 = 2).
+	TimeConfirmations int `yaml:"time_confirmations"`
+	// ContentDiscovery brute-forces common paths under crawled directories after crawling.
+	ContentDiscovery bool `yaml:"content_discovery"`
+	// MaxProbesPerHost caps the content discovery requests sent to one host (0 = unlimited).
+	MaxProbesPerHost int `yaml:"max_probes_per_host"`
+	// ParamChunkSize is the number of parameter names sent per parameter discovery request (0 = 30).
+	ParamChunkSize int `yaml:"param_chunk_size"`
+	// MaxParamProbesPerURL caps the parameter discovery requests sent to one endpoint (0 = unlimited).
+	MaxParamProbesPerURL int `yaml:"max_param_probes_per_url"`
+	// DedupRepresentatives is the number of requests scanned per group of structurally identical
+	// requests (0 = 2, negative = scan every request).
+	DedupRepresentatives int `yaml:"dedup_representatives"`
+	// RawResponseMaxBytes is the size raw responses in findings are truncated to (0 = 8192,
+	// negative = don't capture raw exchanges).
+	RawResponseMaxBytes int `yaml:"raw_response_max_bytes"`
+	// StateFile is the file the progress of the scan is saved to, to resume it with -resume.
+	StateFile string `yaml:"state_file"`
+	// HAROutput is a HAR 1.2 file every request sent (crawler and scanners) and its response are
+	// recorded to.
+	HAROutput string `yaml:"har_output"`
+	// HARMaxBodyBytes is the size bodies are truncated to in the HAR file (0 = 1 MiB, negative =
+	// whole bodies).
+	HARMaxBodyBytes int `yaml:"har_max_body_bytes"`
+	// Control is the address of the control interface of the scan ("dursgo ctl"): a loopback
+	// host:port or unix:/path/to/socket. Empty disables it.
+	Control string `yaml:"control"`
+	// MetricsAddr is the loopback host:port the statistics of the running scan are served on at
+	// /metrics, in the Prometheus format. Empty disables it.
+	MetricsAddr string `yaml:"metrics_addr"`
+	// RetryBackoff is the wait before the first retry of a transient failure, in milliseconds
+	// (0 = 1000); each further retry of the request waits twice as long.
+	RetryBackoff int `yaml:"retry_backoff"`
+	// MaxResponseBytes is the size response bodies are cut off at (0 = 5 MiB, negative =
+	// unlimited), so huge downloads and endless streams cannot exhaust memory.
+	MaxResponseBytes int64 `yaml:"max_response_bytes"`
+	// BodyReadTimeout is the time allowed for reading a response body once its headers arrived,
+	// in seconds (0 = 10, negative = none).
+	BodyReadTimeout int `yaml:"body_read_timeout"`
+	// CheckpointInterval is how often the state file is saved, in seconds (0 = 30).
+	CheckpointInterval int `yaml:"checkpoint_interval"`
+	// Baseline is a findings document or JSON report of a previous scan; findings are marked
+	// new, existing or resolved compared with it.
+	Baseline string `yaml:"baseline"`
+	// FailOnNew makes the scan exit with status 3 when a new finding of at least this severity
+	// (critical, high, medium, low or info) is reported.
+	FailOnNew string `yaml:"fail_on_new"`
+	// FailOn makes the scan exit with status 3 when any finding of at least this severity is
+	// reported ("none" or empty never fails).
+	FailOn string `yaml:"fail_on"`
+	// Suppressions is a suppression file (YAML) of known-accepted findings, which are reported
+	// apart and do not affect the exit status.
+	Suppressions string `yaml:"suppressions"`
+	// SeverityOverrides re-rate findings and append remediation guidance by vulnerability type
+	// (and URL). The first matching override applies.
+	SeverityOverrides []SeverityOverrideConfig `yaml:"severity_overrides"`
+	// OOBListen runs a local OOB HTTP listener on this address instead of using Interactsh.
+	OOBListen string `yaml:"oob_listen"`
+	// OOBURL is the public URL targets use to reach the local OOB listener.
+	OOBURL string `yaml:"oob_url"`
+	// OASTWait is how long the collaborator is still polled for callbacks after the last test,
+	// in seconds (default 10; 0 does not wait). Blind XSS payloads only call back once someone views the stored input.
+	OASTWait int `yaml:"oast_wait"`
 
 	// UserAgent field allows specifying a custom User-Agent header.
 	UserAgent string `yaml:"user_agent"`
+	// Proxy routes all requests through an upstream proxy: http://, https:// or socks5://, with
+	// optional user:password@ (e.g., "http://127.0.0.1:8080" for Burp).
+	Proxy string `yaml:"proxy"`
+	// CACert is a PEM bundle of extra CAs to trust, e.g. the CA of an intercepting proxy.
+	CACert string `yaml:"ca_cert"`
+	// TLS configures client certificates, certificate verification, SNI and the minimum version.
+	TLS TLSConfig `yaml:"tls"`
+	// HTTPVersion is "1.1" (default) or "2" to negotiate HTTP/2 with targets served over TLS.
+	HTTPVersion string `yaml:"http_version"`
+	// DNS sets a custom DNS resolver and static host overrides applied when connecting.
+	DNS DNSConfig `yaml:"dns"`
+	// Headers are sent with every request (e.g., X-API-Key), unless a scanner sets them itself.
+	Headers map[string]string `yaml:"headers"`
+	// Cookies are sent with every request ("a=1; b=2"), unless the session already has them.
+	Cookies string `yaml:"cookies"`
+	// RotateUserAgent picks the User-Agent of each request at random from UserAgents, or from
+	// common browser User-Agents when UserAgents is empty.
+	RotateUserAgent bool     `yaml:"rotate_user_agent"`
+	UserAgents      []string `yaml:"user_agents"`
 
 	// AI configuration settings.
 	AI AIConfig `yaml:"ai"`
@@ -42,6 +357,15 @@ type Config struct {
 	// Output configuration settings.
 	Output OutputConfig `yaml:"output"`
 
+	// Logging configures the log format, per-scanner log levels and redaction.
+	Logging LoggingConfig `yaml:"logging"`
+
+	// BlockDetection configures how blocking by a WAF or rate limiting is detected and handled.
+	BlockDetection BlockDetectionConfig `yaml:"block_detection"`
+
+	// Notifications configures webhook and Slack notifications of findings.
+	Notifications NotificationConfig `yaml:"notifications"`
+
 	// Authentication configuration settings.
 	Authentication struct {
 		Enabled           bool   `yaml:"enabled"`             // Enable authentication.
@@ -56,6 +380,12 @@ type Config struct {
 		Headers map[string]string `yaml:"headers"`
 		// ScanIDOR is the authenticated user's ID for IDOR scanning.
 		ScanIDOR int `yaml:"scan_idor"`
+		// SecondSession authenticates a second user (user B). The IDOR scanner replays
+		// requests made as the primary user (user A) with this session.
+		SecondSession SessionConfig `yaml:"second_session"`
+		// Verify checks that the session of the primary user is authenticated before and
+		// during the scan.
+		Verify SessionVerifyConfig `yaml:"verify"`
 
 		// Fields for backward compatibility with old config format.
 		Type       string `yaml:"type,omitempty"`        // Old authentication type (e.g., "header").
@@ -67,29 +397,113 @@ type Config struct {
 // LoadConfig reads the configuration from a YAML file and returns a Config struct.
 // It sets default values if the file does not exist or is empty.
 func LoadConfig(filePath string) (*Config, error) {
+	return Load(filePath, "")
+}
+
+// Load reads the configuration from a YAML file and applies the named profile of its profiles
+// section on top of it; an empty profile applies none. A profile sets only the keys it lists:
+// maps are merged key by key and lists replace the base list. Unknown keys, in the base
+// configuration and in every profile, are errors naming the nearest valid key, and the result
+// is checked with Validate. A file that does not exist yields the default configuration.
+func Load(filePath, profile string) (*Config, error) {
 	// Set default configuration values.
 	config := &Config{
 		Output: OutputConfig{
 			Format:  "text",
 			Verbose: false,
 		},
+		OASTWait: 10,
 	}
 
 	// Read the YAML file.
 	yamlFile, err := os.ReadFile(filePath)
 	if err != nil {
 		// If the file does not exist, return default config without error.
-		if os.IsNotExist(err) {
+		if os.IsNotExist(err) && profile == "" {
 			return config, nil
 		}
 		return nil, err // Return error for other file reading issues.
 	}
 
-	// Unmarshal YAML data into the Config struct.
-	err = yaml.Unmarshal(yamlFile, &config)
-	if err != nil {
-		return nil, err // Return error if YAML unmarshaling fails.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(yamlFile, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 { // Empty file.
+		if profile != "" {
+			return nil, fmt.Errorf("unknown profile %q: %s has no profiles", profile, filePath)
+		}
+		return config, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: the configuration must be a mapping of keys to values", root.Line)
+	}
+
+	// Split off the profiles; each one is a partial configuration.
+	base := &yaml.Node{Kind: yaml.MappingNode, Tag: root.Tag}
+	var profiles *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == profilesKey {
+			profiles = root.Content[i+1]
+			continue
+		}
+		base.Content = append(base.Content, root.Content[i], root.Content[i+1])
+	}
+	configType := reflect.TypeOf(Config{})
+	errs := checkKeys(base, configType, "")
+	profileNodes := make(map[string]*yaml.Node)
+	var profileNames []string
+	if profiles != nil {
+		if profiles.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("line %d: %s must map profile names to configurations", profiles.Line, profilesKey)
+		}
+		for i := 0; i+1 < len(profiles.Content); i += 2 {
+			name, node := profiles.Content[i].Value, profiles.Content[i+1]
+			profileNodes[name] = node
+			profileNames = append(profileNames, name)
+			errs = append(errs, checkKeys(node, configType, profilesKey+"."+name+".")...)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	// Unmarshal YAML data into the Config struct, then the profile on top of it.
+	if err := base.Decode(config); err != nil {
+		return nil, err
+	}
+	if profile != "" {
+		node, ok := profileNodes[profile]
+		if !ok {
+			if len(profileNames) == 0 {
+				return nil, fmt.Errorf("unknown profile %q: %s has no profiles", profile, filePath)
+			}
+			return nil, fmt.Errorf("unknown profile %q; profiles in %s: %s", profile, filePath, strings.Join(profileNames, ", "))
+		}
+		if err := node.Decode(config); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", profile, err)
+		}
 	}
 
-	return config, nil // Return the loaded configuration.
+	return config, config.Validate() // Return the loaded configuration.
+}
+
+// ProfileNames returns the names of the profiles of a configuration file, in file order.
+func ProfileNames(filePath string) ([]string, error) {
+	yamlFile, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Profiles yaml.Node `yaml:"profiles"`
+	}
+	if err := yaml.Unmarshal(yamlFile, &file); err != nil {
+		return nil, err
+	}
+	var names []string
+	for i := 0; i+1 < len(file.Profiles.Content); i += 2 {
+		names = append(names, file.Profiles.Content[i].Value)
+	}
+	return names, nil
 }
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadAppliesProfile(t *testing.T) {
+	path := writeConfig(t, `
+target: "https://example.com/"
+concurrency: 5
+headers:
+  X-Team: "red"
+output:
+  format: "json"
+  findings_file: "out.json"
+profiles:
+  fast:
+    concurrency: 50
+    headers:
+      X-Fast: "1"
+    output:
+      sort_findings: "cvss"
+`)
+
+	cfg, err := Load(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, 5, cfg.Concurrency)
+	assert.Empty(t, cfg.Output.SortFindings)
+
+	cfg, err = Load(path, "fast")
+	require.NoError(t, err)
+	assert.Equal(t, 50, cfg.Concurrency)
+	assert.Equal(t, "https://example.com/", cfg.Target)
+	// Nested structs and maps are merged with the base configuration.
+	assert.Equal(t, map[string]string{"X-Team": "red", "X-Fast": "1"}, cfg.Headers)
+	assert.Equal(t, "json", cfg.Output.Format)
+	assert.Equal(t, "cvss", cfg.Output.SortFindings)
+
+	_, err = Load(path, "slow")
+	assert.ErrorContains(t, err, `unknown profile "slow"; profiles in`)
+	names, err := ProfileNames(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"fast"}, names)
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	path := writeConfig(t, `
+concurency: 5
+output:
+  formt: "json"
+authentication:
+  second_session:
+    cookei: "a=1"
+waf_fingerprints:
+  - name: "WAF"
+    patern: "blocked"
+scanners:
+  sqli:
+    time_delay: 5
+profiles:
+  quick:
+    zzz: 1
+`)
+
+	_, err := Load(path, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `line 2: unknown key "concurency"; did you mean "concurrency"?`)
+	assert.Contains(t, err.Error(), `line 4: unknown key "output.formt"; did you mean "output.format"?`)
+	assert.Contains(t, err.Error(), `unknown key "authentication.second_session.cookei"; did you mean "authentication.second_session.cookie"?`)
+	assert.Contains(t, err.Error(), `unknown key "waf_fingerprints.0.patern"; did you mean "waf_fingerprints.0.pattern"?`)
+	// Far from any key: no suggestion.
+	assert.Contains(t, err.Error(), `line 16: unknown key "profiles.quick.zzz"`)
+	assert.NotContains(t, err.Error(), "zzz\"; did you mean")
+	// Scanner options are free-form here; scanner.Resolve checks them.
+	assert.NotContains(t, err.Error(), "time_delay")
+}
+
+func TestValidate(t *testing.T) {
+	path := writeConfig(t, `
+target: "example.com"
+concurrency: -1
+similarity_threshold: 2
+scope:
+  exclude_patterns: ["("]
+output:
+  format: "xml"
+logging:
+  scanner_levels:
+    sqli: loud
+skip_rules:
+  rules:
+    - param: "api_key"
+    - param: "token"
+      path: "/login"
+severity_overrides:
+  - type: "Missing Security Header"
+    severity: "informational"
+  - url: "^https://www\\.example\\.com/"
+    remediation_url: "https://wiki.example.com/xss"
+authentication:
+  verify:
+    logged_out_pattern: "name=\"password\""
+    on_loss: "retry"
+`)
+
+	_, err := Load(path, "")
+	require.Error(t, err)
+	for _, msg := range []string{
+		`target: "example.com" is not an http:// or https:// URL`,
+		"concurrency must not be negative",
+		"similarity_threshold must be between 0 and 1",
+		`scope.exclude_patterns: invalid pattern "("`,
+		`output.format: invalid value "xml"; use text, json, jsonl, html`,
+		`logging.scanner_levels.sqli: unknown log level "loud"`,
+		"skip_rules.rules.1: set exactly one of param and path",
+		`severity_overrides.0.severity: invalid value "informational"; use critical, high, medium, low, info`,
+		"severity_overrides.1: type is required",
+		"authentication.verify.url is required to verify the session",
+		`authentication.verify.on_loss: invalid value "retry"; use reauth, abort`,
+	} {
+		assert.Contains(t, err.Error(), msg)
+	}
+}
+
+func TestApplyPolicy(t *testing.T) {
+	path := writeConfig(t, `
+max_depth: 10
+policy: "quick"
+scanners:
+  sqli:
+    time_delay: 7
+`)
+	cfg, err := Load(path, "")
+	require.NoError(t, err)
+	require.NoError(t, cfg.ApplyPolicy(cfg.Policy))
+	assert.Equal(t, "sqli,xss-reflected", cfg.Scanners)
+	assert.Equal(t, "minimal", cfg.PayloadTier)
+	assert.Equal(t, 2, cfg.MaxDepth, "the policy overrides the file")
+	assert.Equal(t, 30, cfg.MaxRequestsPerParam)
+	// Scanner options of the file are kept; missing ones are set.
+	assert.Equal(t, map[string]interface{}{"time_delay": 7, "techniques": "error"}, cfg.ScannerSettings["sqli"])
+	assert.Equal(t, map[string]interface{}{"time_delay": 3}, cfg.ScannerSettings["cmdinjection"])
+
+	require.NoError(t, cfg.ApplyPolicy("Thorough"))
+	assert.Equal(t, "thorough", cfg.Policy)
+	assert.True(t, cfg.OAST)
+	assert.Equal(t, 3, cfg.TimeConfirmations)
+
+	assert.EqualError(t, cfg.ApplyPolicy("paranoid"), `unknown policy "paranoid"; use quick, balanced, thorough`)
+	_, err = Load(writeConfig(t, "policy: paranoid\npayload_tier: huge\n"), "")
+	assert.ErrorContains(t, err, `policy: invalid value "paranoid"`)
+	assert.ErrorContains(t, err, `payload_tier: invalid value "huge"; use minimal, standard, full`)
+}
+
+func TestLoadMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	cfg, err := Load(path, "")
+	require.NoError(t, err)
+	assert.Equal(t, "text", cfg.Output.Format)
+
+	_, err = Load(path, "fast")
+	assert.Error(t, err)
+}
+
+func TestTemplateIsValid(t *testing.T) {
+	cfg, err := Load(writeConfig(t, string(Template)), "")
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/", cfg.Target)
+}
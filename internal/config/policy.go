@@ -0,0 +1,134 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Policy is a named preset of scan depth (-policy, policy:) bundling the scanners run, the
+// payload tier, time-based confirmations and delay, the request budget per parameter and the
+// crawl depth. Applying a policy overwrites these values of a configuration; individual flags
+// still override them.
+type Policy struct {
+	Name        string
+	Description string
+	// Scanners is the scanners_to_run selection.
+	Scanners string
+	// PayloadTier is "minimal", "standard" or "full".
+	PayloadTier string
+	// TimeConfirmations is the number of delays a time-based finding is confirmed with.
+	TimeConfirmations int
+	// TimeDelay is the time_delay option of the time-based scanners, in seconds.
+	TimeDelay           int
+	MaxRequestsPerParam int
+	MaxDepth            int
+	OAST                bool
+	InjectHeaders       bool
+	// ScannerSettings are scanner options set unless the scanners section sets them itself.
+	ScannerSettings map[string]map[string]interface{}
+}
+
+// timeBasedScanners are the scanners with a time_delay option set by a policy.
+var timeBasedScanners = []string{"sqli", "cmdinjection"}
+
+// policies are the built-in policies, from the cheapest to the most thorough.
+var policies = []Policy{
+	{
+		Name:                "quick",
+		Description:         "CI smoke test: error-based SQLi and reflected XSS with minimal payloads",
+		Scanners:            "sqli,xss-reflected",
+		PayloadTier:         "minimal",
+		TimeConfirmations:   2,
+		TimeDelay:           3,
+		MaxRequestsPerParam: 30,
+		MaxDepth:            2,
+		ScannerSettings:     map[string]map[string]interface{}{"sqli": {"techniques": "error"}},
+	},
+	{
+		Name:                "balanced",
+		Description:         "Every scanner with the common payloads and a request budget per parameter",
+		Scanners:            "all",
+		PayloadTier:         "standard",
+		TimeConfirmations:   2,
+		TimeDelay:           5,
+		MaxRequestsPerParam: 150,
+		MaxDepth:            3,
+	},
+	{
+		Name:              "thorough",
+		Description:       "Full pentest: every scanner and payload, out-of-band and header injection",
+		Scanners:          "all",
+		PayloadTier:       "full",
+		TimeConfirmations: 3,
+		TimeDelay:         5,
+		MaxDepth:          6,
+		OAST:              true,
+		InjectHeaders:     true,
+	},
+}
+
+// payloadTiers are the valid payload_tier values.
+var payloadTiers = []string{"minimal", "standard", "full"}
+
+// evasionLevels are the evasion levels; evasion may also list transformations.
+var evasionLevels = []string{"none", "low", "high"}
+
+// PolicyNames returns the names of the built-in policies.
+func PolicyNames() []string {
+	names := make([]string, len(policies))
+	for i, p := range policies {
+		names[i] = p.Name
+	}
+	return names
+}
+
+// LookupPolicy returns the built-in policy of a name (case-insensitive).
+func LookupPolicy(name string) (Policy, error) {
+	for _, p := range policies {
+		if strings.EqualFold(p.Name, strings.TrimSpace(name)) {
+			return p, nil
+		}
+	}
+	return Policy{}, fmt.Errorf("unknown policy %q; use %s", name, strings.Join(PolicyNames(), ", "))
+}
+
+// ApplyPolicy sets the values of the named policy in c and records it in c.Policy. The time
+// delay and scanner options of the policy are only set for scanners whose options in the
+// scanners section do not set them.
+func (c *Config) ApplyPolicy(name string) error {
+	p, err := LookupPolicy(name)
+	if err != nil {
+		return err
+	}
+	c.Policy = p.Name
+	c.Scanners = p.Scanners
+	c.PayloadTier = p.PayloadTier
+	c.TimeConfirmations = p.TimeConfirmations
+	c.MaxRequestsPerParam = p.MaxRequestsPerParam
+	c.MaxDepth = p.MaxDepth
+	c.OAST = p.OAST
+	c.InjectHeaders = p.InjectHeaders
+	for _, scanner := range timeBasedScanners {
+		c.setScannerDefault(scanner, "time_delay", p.TimeDelay)
+	}
+	for scanner, options := range p.ScannerSettings {
+		for option, value := range options {
+			c.setScannerDefault(scanner, option, value)
+		}
+	}
+	return nil
+}
+
+// setScannerDefault sets an option of a scanner in c.ScannerSettings unless it is set already.
+func (c *Config) setScannerDefault(scanner, option string, value interface{}) {
+	if _, ok := c.ScannerSettings[scanner][option]; ok {
+		return
+	}
+	if c.ScannerSettings == nil {
+		c.ScannerSettings = make(map[string]map[string]interface{})
+	}
+	if c.ScannerSettings[scanner] == nil {
+		c.ScannerSettings[scanner] = make(map[string]interface{})
+	}
+	c.ScannerSettings[scanner][option] = value
+}
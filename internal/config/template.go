@@ -0,0 +1,9 @@
+package config
+
+import _ "embed"
+
+// Template is a commented configuration file covering the main settings and profiles, written
+// by "dursgo config init".
+//
+//go:embed template.yaml
+var Template []byte
@@ -0,0 +1,254 @@
+package config
+
+import (
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// profilesKey is the top-level key holding the named profiles of a config file.
+const profilesKey = "profiles"
+
+// checkKeys reports the keys of node that are not fields of t, recursing into nested structs,
+// lists and maps. path is the dotted key path of node, e.g. "output.".
+func checkKeys(node *yaml.Node, t reflect.Type, path string) []error {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var errs []error
+	switch {
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Struct:
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" { // Merge key: the merged mapping must fit t as well.
+				errs = append(errs, checkKeys(value, t, path)...)
+				continue
+			}
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				errs = append(errs, unknownKeyError(key, path, fields))
+				continue
+			}
+			errs = append(errs, checkKeys(value, fieldType, path+key.Value+".")...)
+		}
+	case node.Kind == yaml.MappingNode && t.Kind() == reflect.Map:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, checkKeys(node.Content[i+1], t.Elem(), path+node.Content[i].Value+".")...)
+		}
+	case node.Kind == yaml.SequenceNode && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array):
+		for i, item := range node.Content {
+			errs = append(errs, checkKeys(item, t.Elem(), fmt.Sprintf("%s%d.", path, i))...)
+		}
+	}
+	return errs
+}
+
+// yamlFields returns the types of the fields of struct t by YAML key.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		switch {
+		case name == "-":
+			continue
+		case strings.Contains(opts, "inline"):
+			for key, fieldType := range yamlFields(field.Type) {
+				fields[key] = fieldType
+			}
+			continue
+		case name == "":
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+	return fields
+}
+
+// unknownKeyError reports an unknown key, suggesting the nearest valid key of fields.
+func unknownKeyError(key *yaml.Node, path string, fields map[string]reflect.Type) error {
+	candidates := make([]string, 0, len(fields))
+	for name := range fields {
+		candidates = append(candidates, name)
+	}
+	sort.Strings(candidates)
+	msg := fmt.Sprintf("line %d: unknown key %q", key.Line, path+key.Value)
+	if suggestion := nearest(key.Value, candidates); suggestion != "" {
+		msg += fmt.Sprintf("; did you mean %q?", path+suggestion)
+	}
+	return errors.New(msg)
+}
+
+// nearest returns the candidate closest to name by edit distance, or "" if none is close enough
+// to be a likely typo.
+func nearest(name string, candidates []string) string {
+	best, bestDistance := "", -1
+	for _, candidate := range candidates {
+		d := editDistance(strings.ToLower(name), candidate)
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if bestDistance < 0 || bestDistance > max(2, len(name)/3) {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// Validate checks the values of the configuration that can be checked without the rest of the
+// scanner, e.g. formats, URLs, regular expressions and negative limits. Values checked when they
+// are used (scanner names and options, TLS versions, secret pattern severities) are left to their packages.
+func (c *Config) Validate() error {
+	var errs []error
+	oneOf := func(key, value string, allowed ...string) {
+		if v := strings.ToLower(strings.TrimSpace(value)); v != "" && !slices.Contains(allowed, v) {
+			errs = append(errs, fmt.Errorf("%s: invalid value %q; use %s", key, value, strings.Join(allowed, ", ")))
+		}
+	}
+	nonNegative := func(key string, value float64) {
+		if value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", key))
+		}
+	}
+	checkURL := func(key, value string) {
+		if value == "" {
+			return
+		}
+		u, err := url.Parse(value)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			errs = append(errs, fmt.Errorf("%s: %q is not an http:// or https:// URL", key, value))
+		}
+	}
+
+	checkURL("target", c.Target)
+	for i, seed := range c.SeedURLs {
+		checkURL(fmt.Sprintf("seed_urls.%d", i), seed)
+	}
+	for i, target := range c.Targets {
+		checkURL(fmt.Sprintf("targets.%d", i), target)
+	}
+	nonNegative("parallel_targets", float64(c.ParallelTargets))
+	nonNegative("concurrency", float64(c.Concurrency))
+	nonNegative("per_host_concurrency", float64(c.PerHostConcurrency))
+	nonNegative("max_retries", float64(c.MaxRetries))
+	nonNegative("delay", float64(c.Delay))
+	nonNegative("max_depth", float64(c.MaxDepth))
+	nonNegative("requests_per_second", c.RequestsPerSecond)
+	nonNegative("max_requests_per_param", float64(c.MaxRequestsPerParam))
+	nonNegative("oast_wait", float64(c.OASTWait))
+	nonNegative("param_chunk_size", float64(c.ParamChunkSize))
+	nonNegative("time_confirmations", float64(c.TimeConfirmations))
+	nonNegative("max_param_probes_per_url", float64(c.MaxParamProbesPerURL))
+	if c.SimilarityThreshold < 0 || c.SimilarityThreshold > 1 {
+		errs = append(errs, fmt.Errorf("similarity_threshold must be between 0 and 1"))
+	}
+	oneOf("crawl_mode", c.CrawlMode, "static", "rendered", "hybrid")
+	oneOf("policy", c.Policy, PolicyNames()...)
+	oneOf("payload_tier", c.PayloadTier, payloadTiers...)
+	if level := strings.ToLower(strings.TrimSpace(c.Evasion)); level != "" && !slices.Contains(evasionLevels, level) {
+		for _, name := range strings.Split(level, ",") {
+			if _, ok := payloads.TransformationByName(strings.TrimSpace(name)); !ok {
+				errs = append(errs, fmt.Errorf("evasion: invalid level or transformation %q; use %s or a list of transformations", name, strings.Join(evasionLevels, ", ")))
+			}
+		}
+	}
+	oneOf("scope.subdomains", c.Scope.Subdomains, "same-host", "same-domain", "allowlist")
+	verify := c.Authentication.Verify
+	oneOf("authentication.verify.on_loss", verify.OnLoss, "reauth", "abort")
+	if verify.URL == "" && (verify.Pattern != "" || verify.LoggedOutPattern != "") {
+		errs = append(errs, errors.New("authentication.verify.url is required to verify the session"))
+	}
+	if verify.Status != 0 && (verify.Status < 100 || verify.Status > 599) {
+		errs = append(errs, fmt.Errorf("authentication.verify.status: invalid HTTP status %d", verify.Status))
+	}
+	nonNegative("authentication.verify.max_reauth", float64(verify.MaxReauth))
+	for key, patterns := range map[string][]string{
+		"scope.include_patterns":                   c.Scope.IncludePatterns,
+		"scope.exclude_patterns":                   c.Scope.ExcludePatterns,
+		"sqli_error_patterns":                      c.SQLiErrorPatterns,
+		"authentication.verify.pattern":            {verify.Pattern},
+		"authentication.verify.logged_out_pattern": {verify.LoggedOutPattern},
+	} {
+		for _, pattern := range patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				errs = append(errs, fmt.Errorf("%s: invalid pattern %q: %v", key, pattern, err))
+			}
+		}
+	}
+	oneOf("output.format", c.Output.Format, "text", "json", "jsonl", "html")
+	oneOf("output.sort_findings", c.Output.SortFindings, "found", "cvss")
+	oneOf("output.min_confidence", c.Output.MinConfidence, "certain", "firm", "tentative")
+	oneOf("logging.format", c.Logging.Format, "text", "json")
+	for scanner, level := range c.Logging.ScannerLevels {
+		if _, err := logger.ParseLevel(level); err != nil {
+			errs = append(errs, fmt.Errorf("logging.scanner_levels.%s: %v", scanner, err))
+		}
+	}
+	for i, rule := range c.SkipRules.Rules {
+		if (strings.TrimSpace(rule.Param) == "") == (strings.TrimSpace(rule.Path) == "") {
+			errs = append(errs, fmt.Errorf("skip_rules.rules.%d: set exactly one of param and path", i))
+		}
+	}
+	for i, override := range c.SeverityOverrides {
+		key := fmt.Sprintf("severity_overrides.%d", i)
+		if strings.TrimSpace(override.Type) == "" {
+			errs = append(errs, fmt.Errorf("%s: type is required", key))
+		}
+		if override.Severity == "" && override.Remediation == "" && override.RemediationURL == "" {
+			errs = append(errs, fmt.Errorf("%s: set a severity, remediation or remediation_url", key))
+		}
+		oneOf(key+".severity", override.Severity, "critical", "high", "medium", "low", "info")
+		if _, err := regexp.Compile(override.URL); err != nil {
+			errs = append(errs, fmt.Errorf("%s.url: invalid pattern %q: %v", key, override.URL, err))
+		}
+	}
+	for name, file := range c.PayloadFiles {
+		if strings.TrimSpace(file) == "" {
+			errs = append(errs, fmt.Errorf("payload_files.%s: no file given", name))
+		}
+	}
+	for i, file := range c.PayloadSets {
+		if strings.TrimSpace(file) == "" {
+			errs = append(errs, fmt.Errorf("payload_sets.%d: no file given", i))
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
+}
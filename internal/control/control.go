@@ -0,0 +1,305 @@
+// Package control serves a local interface that pauses, resumes and steers a running scan, and
+// calls it for "dursgo ctl".
+package control
+
+import (
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/progress"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultAddr is the address "dursgo ctl" calls unless told otherwise.
+const DefaultAddr = "127.0.0.1:9797"
+
+// unixPrefix marks an address as the path of a unix socket, e.g. "unix:/tmp/dursgo.sock".
+const unixPrefix = "unix:"
+
+// Actions of the control interface, as recorded in Action.Action.
+const (
+	ActionPause    = "pause"
+	ActionResume   = "resume"
+	ActionSkipHost = "skip-host"
+	ActionRate     = "rate"
+)
+
+// Action is a control action taken during a scan, recorded in the report.
+type Action struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`           // One of the Action* constants.
+	Detail string    `json:"detail,omitempty"` // Host skipped or new rate limit.
+}
+
+// Status is the state of a running scan returned by the status endpoint: the data of the
+// progress display, and the state set through the control interface.
+type Status struct {
+	progress.Snapshot
+	Paused       bool     `json:"paused"`
+	RateLimit    float64  `json:"rate_limit"`             // Requests per second; 0 = unlimited.
+	CurrentHost  string   `json:"current_host,omitempty"` // Host of the last request, skipped by a bare skip-host.
+	SkippedHosts []string `json:"skipped_hosts,omitempty"`
+	Actions      []Action `json:"actions,omitempty"`
+}
+
+// Server serves the control interface of a scan over HTTP, on a loopback address or a unix
+// socket. It acts on a client made controllable with httpclient.Client.EnableControl.
+type Server struct {
+	client   *httpclient.Client
+	status   *progress.Reporter
+	log      *logger.Logger
+	server   *http.Server
+	listener net.Listener
+	socket   string // Path of the unix socket, removed by Close.
+
+	mu      sync.Mutex
+	actions []Action
+}
+
+// NewServer returns a server controlling client. status provides the progress of the scan; it
+// may be nil.
+func NewServer(client *httpclient.Client, status *progress.Reporter, log *logger.Logger) *Server {
+	return &Server{client: client, status: status, log: log}
+}
+
+// ParseAddr returns the network and address to listen on or dial for addr: "unix:" followed by
+// the path of a unix socket, or a host:port on the loopback interface. Other hosts are refused,
+// as the interface has no authentication.
+func ParseAddr(addr string) (network, address string, err error) {
+	if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+		if path == "" {
+			return "", "", fmt.Errorf("control address %q: missing socket path", addr)
+		}
+		return "unix", path, nil
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", "", fmt.Errorf("control address %q: %v", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return "", "", fmt.Errorf("control address %q: only loopback addresses or unix:/path are allowed", addr)
+	}
+	return "tcp", addr, nil
+}
+
+// Listen starts serving the control interface on addr (see ParseAddr) in the background. A unix
+// socket is created readable and writable by the owner only.
+func (s *Server) Listen(addr string) error {
+	network, address, err := ParseAddr(addr)
+	if err != nil {
+		return err
+	}
+	if network == "unix" {
+		// A socket left behind by a scan that crashed is replaced; one still served is not.
+		if conn, err := net.Dial(network, address); err == nil {
+			conn.Close()
+			return fmt.Errorf("control socket %s is in use by another scan", address)
+		}
+		if info, err := os.Lstat(address); err == nil && info.Mode()&os.ModeSocket != 0 {
+			os.Remove(address)
+		}
+	}
+	listener, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	if network == "unix" {
+		if err := os.Chmod(address, 0600); err != nil {
+			listener.Close()
+			return err
+		}
+		s.socket = address
+	}
+	s.listener = listener
+	s.server = &http.Server{Handler: s.handler(), ReadHeaderTimeout: 10 * time.Second}
+	go s.server.Serve(listener)
+	return nil
+}
+
+// Addr returns the address the server listens on, in the form ParseAddr accepts.
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return ""
+	}
+	if s.socket != "" {
+		return unixPrefix + s.socket
+	}
+	return s.listener.Addr().String()
+}
+
+// Close stops the server. A paused scan is resumed, so it can finish.
+func (s *Server) Close() error {
+	if s.client.Resume() {
+		s.record(ActionResume, "control interface closed")
+	}
+	if s.server == nil {
+		return nil
+	}
+	err := s.server.Close()
+	if s.socket != "" {
+		os.Remove(s.socket)
+	}
+	return err
+}
+
+// Actions returns the control actions taken so far, oldest first.
+func (s *Server) Actions() []Action {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Action(nil), s.actions...)
+}
+
+// Status returns the state of the scan.
+func (s *Server) Status() Status {
+	return Status{
+		Snapshot:     s.status.Snapshot(),
+		Paused:       s.client.Paused(),
+		RateLimit:    s.client.RateLimit(),
+		CurrentHost:  s.client.CurrentHost(),
+		SkippedHosts: s.client.SkippedHosts(),
+		Actions:      s.Actions(),
+	}
+}
+
+// record logs a control action and keeps it for the report.
+func (s *Server) record(action, detail string) {
+	s.mu.Lock()
+	s.actions = append(s.actions, Action{Time: time.Now().UTC(), Action: action, Detail: detail})
+	s.mu.Unlock()
+	if detail != "" {
+		s.log.Info("Control: %s (%s)", action, detail)
+	} else {
+		s.log.Info("Control: %s", action)
+	}
+}
+
+// handler returns the HTTP handler of the control interface:
+//
+//	GET  /status                 the Status of the scan, as JSON
+//	POST /pause                  hold every request until /resume
+//	POST /resume
+//	POST /skip-host [host=name]  give up a host, by default the current one
+//	POST /rate rps=n             set the rate limit (0 = unlimited)
+//
+// Actions answer with a line of text; failed actions with a 4xx status.
+func (s *Server) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "use GET", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(s.Status())
+	})
+	mux.HandleFunc("/pause", s.action(func(r *http.Request) (string, error) {
+		if !s.client.Pause() {
+			return "", errors.New("the scan is already paused")
+		}
+		s.record(ActionPause, "")
+		return "Scan paused; requests in flight complete.", nil
+	}))
+	mux.HandleFunc("/resume", s.action(func(r *http.Request) (string, error) {
+		if !s.client.Resume() {
+			return "", errors.New("the scan is not paused")
+		}
+		s.record(ActionResume, "")
+		return "Scan resumed.", nil
+	}))
+	mux.HandleFunc("/skip-host", s.action(func(r *http.Request) (string, error) {
+		host, err := s.client.SkipHost(strings.TrimSpace(r.FormValue("host")))
+		if err != nil {
+			return "", err
+		}
+		s.record(ActionSkipHost, host)
+		return fmt.Sprintf("Skipping %s; its remaining tests are left untested.", host), nil
+	}))
+	mux.HandleFunc("/rate", s.action(func(r *http.Request) (string, error) {
+		rps, err := strconv.ParseFloat(strings.TrimSpace(r.FormValue("rps")), 64)
+		if err != nil || rps < 0 || math.IsNaN(rps) || math.IsInf(rps, 0) {
+			return "", fmt.Errorf("invalid rate %q: want requests per second, 0 for unlimited", r.FormValue("rps"))
+		}
+		s.client.SetRateLimit(rps)
+		detail := "unlimited"
+		if rps > 0 {
+			detail = fmt.Sprintf("%.2f requests per second", rps)
+		}
+		s.record(ActionRate, detail)
+		return "Rate limit set to " + detail + ".", nil
+	}))
+	return mux
+}
+
+// action returns a handler running act for POST requests and answering with its message.
+func (s *Server) action(act func(*http.Request) (string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "use POST", http.StatusMethodNotAllowed)
+			return
+		}
+		msg, err := act(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		fmt.Fprintln(w, msg)
+	}
+}
+
+// Call sends a request to the control interface of the scan listening on addr: a GET if form
+// is nil, else a POST of form. It returns the body of the answer, or an error with the message
+// of a failed action.
+func Call(ctx context.Context, addr, path string, form url.Values) ([]byte, error) {
+	network, address, err := ParseAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, network, address)
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+
+	method, body := http.MethodGet, io.Reader(nil)
+	if form != nil {
+		method, body = http.MethodPost, strings.NewReader(form.Encode())
+	}
+	// The host of the URL is not used to connect; the dialer connects to address.
+	req, err := http.NewRequestWithContext(ctx, method, "http://dursgo"+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("no scan is listening on %s: %w", addr, err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
@@ -0,0 +1,128 @@
+package control
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/progress"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newControlledServer returns a control server listening on a unix socket, its controlled client
+// and the URL of a target answering every request.
+func newControlledServer(t *testing.T) (*Server, *httpclient.Client, string) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	t.Cleanup(target.Close)
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{})
+	client.EnableControl()
+	status := progress.New(nil, client.RequestsSent)
+	status.AddWork(4)
+	status.CompleteWork(1)
+
+	s := NewServer(client, status, log)
+	require.NoError(t, s.Listen("unix:"+filepath.Join(t.TempDir(), "ctl.sock")))
+	t.Cleanup(func() { s.Close() })
+	return s, client, target.URL
+}
+
+func TestPauseHoldsRequests(t *testing.T) {
+	s, client, target := newControlledServer(t)
+	scanner := client.WithSource("scan", "sqli") // Derived before the pause, like scanner clients.
+	ctx := context.Background()
+
+	_, err := Call(ctx, s.Addr(), "/pause", url.Values{})
+	require.NoError(t, err)
+	_, err = Call(ctx, s.Addr(), "/pause", url.Values{})
+	assert.EqualError(t, err, "the scan is already paused")
+
+	done := make(chan error, 1)
+	go func() {
+		resp, err := scanner.Get(target + "/item")
+		if err == nil {
+			resp.Body.Close()
+		}
+		done <- err
+	}()
+	select {
+	case <-done:
+		t.Fatal("a request was sent while the scan was paused")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	data, err := Call(ctx, s.Addr(), "/status", nil)
+	require.NoError(t, err)
+	var status Status
+	require.NoError(t, json.Unmarshal(data, &status))
+	assert.True(t, status.Paused)
+	assert.Equal(t, 25, status.Percent, "the status carries the progress of the scan")
+
+	msg, err := Call(ctx, s.Addr(), "/resume", url.Values{})
+	require.NoError(t, err)
+	assert.Equal(t, "Scan resumed.\n", string(msg))
+	require.NoError(t, <-done)
+}
+
+func TestSkipHostAndRate(t *testing.T) {
+	s, client, target := newControlledServer(t)
+	ctx := context.Background()
+
+	_, err := Call(ctx, s.Addr(), "/skip-host", url.Values{})
+	assert.EqualError(t, err, "no request has been sent yet")
+
+	resp, err := client.Get(target)
+	require.NoError(t, err)
+	resp.Body.Close()
+	host := strings.TrimPrefix(target, "http://")
+	msg, err := Call(ctx, s.Addr(), "/skip-host", url.Values{})
+	require.NoError(t, err)
+	assert.Contains(t, string(msg), "Skipping "+host)
+	_, err = client.Get(target + "/next")
+	assert.True(t, errors.Is(err, httpclient.ErrHostSkipped))
+	assert.True(t, client.HostSkipped(target+"/other"))
+
+	_, err = Call(ctx, s.Addr(), "/rate", url.Values{"rps": {"fast"}})
+	assert.ErrorContains(t, err, `invalid rate "fast"`)
+	_, err = Call(ctx, s.Addr(), "/rate", url.Values{"rps": {"2.5"}})
+	require.NoError(t, err)
+	assert.Equal(t, 2.5, client.RateLimit())
+
+	actions := s.Actions()
+	require.Len(t, actions, 2)
+	assert.Equal(t, ActionSkipHost, actions[0].Action)
+	assert.Equal(t, host, actions[0].Detail)
+	assert.Equal(t, "2.50 requests per second", actions[1].Detail)
+	assert.False(t, actions[1].Time.IsZero())
+
+	_, err = Call(ctx, s.Addr(), "/status", url.Values{})
+	assert.EqualError(t, err, "use GET")
+}
+
+func TestParseAddr(t *testing.T) {
+	network, address, err := ParseAddr("unix:/tmp/dursgo.sock")
+	require.NoError(t, err)
+	assert.Equal(t, "unix", network)
+	assert.Equal(t, "/tmp/dursgo.sock", address)
+
+	for _, addr := range []string{"127.0.0.1:9797", "localhost:9797", "[::1]:9797"} {
+		network, _, err = ParseAddr(addr)
+		require.NoError(t, err, addr)
+		assert.Equal(t, "tcp", network)
+	}
+	_, _, err = ParseAddr("0.0.0.0:9797")
+	assert.ErrorContains(t, err, "only loopback addresses")
+	_, _, err = ParseAddr("unix:")
+	assert.Error(t, err)
+}
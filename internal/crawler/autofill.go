@@ -0,0 +1,161 @@
+package crawler
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// formTypeDefaults are the values submitted for empty fields of an input type.
+var formTypeDefaults = map[string]string{
+	"email":          "test@example.com",
+	"tel":            "5555555555",
+	"number":         "1",
+	"range":          "1",
+	"date":           "2024-01-01",
+	"datetime-local": "2024-01-01T12:00",
+	"time":           "12:00",
+	"month":          "2024-01",
+	"week":           "2024-W01",
+	"url":            "https://example.com/",
+	"color":          "#000000",
+	"password":       "Passw0rd!",
+	"search":         "test",
+}
+
+// formNameDefaults are the values submitted for empty text fields whose name contains the key,
+// checked in order.
+var formNameDefaults = []struct{ key, value string }{
+	{"email", "test@example.com"},
+	{"mail", "test@example.com"},
+	{"phone", "5555555555"},
+	{"mobile", "5555555555"},
+	{"tel", "5555555555"},
+	{"zip", "12345"},
+	{"postal", "12345"},
+	{"postcode", "12345"},
+	{"birth", "2000-01-01"},
+	{"date", "2024-01-01"},
+	{"url", "https://example.com/"},
+	{"website", "https://example.com/"},
+	{"age", "30"},
+	{"qty", "1"},
+	{"quantity", "1"},
+	{"amount", "1"},
+	{"count", "1"},
+	{"price", "1"},
+	{"year", "2024"},
+	{"name", "test"},
+}
+
+// SetFormFieldDefaults sets the values submitted for empty form fields by field name
+// (case-insensitive), overriding the built-in heuristics. Values captured from the page still
+// take precedence. It must be called before Crawl.
+func (c *Crawler) SetFormFieldDefaults(defaults map[string]string) {
+	c.formDefaults = make(map[string]string, len(defaults))
+	for name, value := range defaults {
+		c.formDefaults[strings.ToLower(name)] = value
+	}
+}
+
+// formFieldValue returns the value submitted for a form field, so that required fields pass the
+// server's validation and injected payloads reach the code behind them. value is the value
+// captured from the page and wins when present; hidden and file fields are never filled in.
+func (c *Crawler) formFieldValue(node *html.Node, name, elemType, value string) string {
+	switch node.Data {
+	case "select":
+		return selectedOption(node)
+	case "textarea":
+		if value == "" {
+			value = textContent(node)
+		}
+	}
+	if value != "" || elemType == "hidden" || elemType == "file" {
+		return value
+	}
+	if override, ok := c.formDefaults[strings.ToLower(name)]; ok {
+		return override
+	}
+	switch elemType {
+	case "checkbox", "radio":
+		return "on" // What browsers submit for checked fields without a value.
+	case "number", "range":
+		if min := attrValue(node, "min"); min != "" {
+			return min
+		}
+	case "submit", "reset", "button", "image":
+		return value
+	}
+	if def, ok := formTypeDefaults[elemType]; ok {
+		return def
+	}
+	lowerName := strings.ToLower(name)
+	for _, def := range formNameDefaults {
+		if strings.Contains(lowerName, def.key) {
+			return def.value
+		}
+	}
+	return "test"
+}
+
+// selectedOption returns the value of the selected option of a <select>, or of its first option.
+func selectedOption(node *html.Node) string {
+	var first, selected *html.Node
+	var find func(*html.Node)
+	find = func(n *html.Node) {
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type == html.ElementNode && child.Data == "option" {
+				if first == nil {
+					first = child
+				}
+				if selected == nil && hasAttr(child, "selected") {
+					selected = child
+				}
+				continue
+			}
+			find(child) // <optgroup>
+		}
+	}
+	find(node)
+	if selected == nil {
+		selected = first
+	}
+	if selected == nil {
+		return ""
+	}
+	if hasAttr(selected, "value") {
+		return attrValue(selected, "value")
+	}
+	return strings.TrimSpace(textContent(selected))
+}
+
+// textContent returns the text inside node.
+func textContent(node *html.Node) string {
+	var sb strings.Builder
+	for child := node.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode {
+			sb.WriteString(child.Data)
+		} else {
+			sb.WriteString(textContent(child))
+		}
+	}
+	return sb.String()
+}
+
+func attrValue(node *html.Node, key string) string {
+	for _, a := range node.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func hasAttr(node *html.Node, key string) bool {
+	for _, a := range node.Attr {
+		if strings.EqualFold(a.Key, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package crawler
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+const autofillPage = `<html><body>
+<form action="/register" method="post">
+  <input type="hidden" name="csrf" value="">
+  <input type="email" name="contact">
+  <input type="tel" name="mobile">
+  <input type="number" name="seats" min="2">
+  <input type="date" name="start">
+  <input type="text" name="zip_code">
+  <input type="text" name="nickname" value="bob">
+  <input type="text" name="coupon">
+  <select name="country"><option value="">Choose</option><option value="id" selected>Indonesia</option></select>
+  <select name="plan"><optgroup label="Plans"><option>Basic</option><option>Pro</option></optgroup></select>
+  <textarea name="bio"></textarea>
+  <input type="checkbox" name="terms">
+  <input type="radio" name="size" value="s">
+  <input type="radio" name="size" value="m" checked>
+  <input type="file" name="avatar">
+  <button type="submit">Register</button>
+</form>
+<form action="/search?lang=en">
+  <input type="search" name="q">
+  <input type="text" name="email_address">
+</form>
+</body></html>`
+
+func TestFormAutofill(t *testing.T) {
+	c := newTestCrawler(t, "https://shop.example.com", nil, CrawlModeStatic)
+	c.SetFormFieldDefaults(map[string]string{"Coupon": "WELCOME10", "nickname": "ignored"})
+	doc, err := html.Parse(strings.NewReader(autofillPage))
+	require.NoError(t, err)
+
+	_, forms := c.extractLinksAndForms(doc, "https://shop.example.com/signup")
+	require.Len(t, forms, 2)
+
+	register, err := url.ParseQuery(forms[0].FormPostData)
+	require.NoError(t, err)
+	assert.Equal(t, url.Values{
+		"csrf":     {""},
+		"contact":  {"test@example.com"},
+		"mobile":   {"5555555555"},
+		"seats":    {"2"},
+		"start":    {"2024-01-01"},
+		"zip_code": {"12345"},
+		"nickname": {"bob"},
+		"coupon":   {"WELCOME10"},
+		"country":  {"id"},
+		"plan":     {"Basic"},
+		"bio":      {"test"},
+		"terms":    {"on"},
+		"size":     {"m"},
+		"avatar":   {""},
+	}, register)
+	assert.Equal(t, []string{"csrf", "contact", "mobile", "seats", "start", "zip_code", "nickname", "coupon", "country", "plan", "bio", "terms", "size", "avatar"}, forms[0].ParamNames)
+
+	search := forms[1]
+	assert.Equal(t, "GET", search.Method)
+	assert.Equal(t, "https://shop.example.com/search?email_address=test%40example.com&lang=en&q=test", search.URL)
+	assert.Equal(t, []string{"q", "email_address"}, search.ParamNames)
+	assert.Empty(t, search.FormPostData)
+}
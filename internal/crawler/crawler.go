@@ -1,17 +1,16 @@
 package crawler
 
 import (
-	"crypto/sha1"
-	"crypto/sha256"
 	"Dursgo/internal/httpclient"
 	"Dursgo/internal/logger"
 	"Dursgo/internal/renderer"
+	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
-	"math/rand"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -21,7 +20,6 @@ import (
 	"time"
 
 	"golang.org/x/net/html"
-	"gopkg.in/yaml.v3"
 )
 
 // excludedExtensions defines file types that are not relevant for vulnerability scanning.
@@ -40,16 +38,6 @@ var excludedExtensions = []string{
 	".mp3", ".mp4", ".avi", ".mov", ".flv", ".wmv",
 }
 
-// OpenAPIv3 represents the structure for parsing OpenAPI/Swagger files.
-type OpenAPIv3 struct {
-	Paths map[string]interface{} `json:"paths" yaml:"paths"`
-}
-
-// SourceMap represents the structure of a .map file, used for JavaScript source map analysis.
-type SourceMap struct {
-	Sources []string `json:"sources"`
-}
-
 // commonAPISpecPaths holds common names for API specification files to discover.
 var commonAPISpecPaths = []string{
 	"openapi.json", "swagger.json", "api.json",
@@ -59,41 +47,15 @@ var commonAPISpecPaths = []string{
 	"docs/openapi.yaml", "docs/swagger.yaml",
 	"api/openapi.json", "api/swagger.json",
 	"api/openapi.yaml", "api/swagger.yaml",
-}
-
-// jsPathRegexes are regular expressions used to extract potential paths from JavaScript files.
-var jsPathRegexes []*regexp.Regexp
-
-// init initializes jsPathRegexes once for efficiency.
-func init() {
-	patterns := []string{
-		`(?:"|')((?:/[a-zA-Z0-9_.-]+)+/?)(?:"|')`,          // Absolute paths like "/api/users"
-		`(?:"|')(\.\./(?:[a-zA-Z0-9_.-]+/)*[a-zA-Z0-9_.-]+)(?:"|')`, // Relative paths like "../data/config.json"
-		`(?:"|')([a-zA-Z0-9_.-]+(?:/[a-zA-Z0-9_.-]+)+)(?:"|')`,      // Paths without leading slash like "assets/js/main.js"
-	}
-	for _, pattern := range patterns {
-		jsPathRegexes = append(jsPathRegexes, regexp.MustCompile(pattern))
-	}
-}
-
-// commonParameters lists common parameter names to proactively test for reflection.
-var commonParameters = []string{
-	"id", "q", "search", "query", "keyword", "s",
-	"lang", "language", "locale",
-	"redirect", "url", "next", "return", "returnTo", "goto", "dest", "destination",
-	"view", "page", "p", "pg",
-	"file", "path", "document", "folder", "dir",
-	"name", "user", "username", "account",
-	"message", "msg", "error", "alert", "text",
-	"input", "data", "json", "val", "value",
-	"item", "category", "product", "type",
-	"callback", "jsonp",
-	"token", "key", "api_key",
+	"v2/api-docs", "v3/api-docs", "api-docs", "swagger/v1/swagger.json",
 }
 
 // MaxPathSegments defines the maximum number of path segments to crawl to prevent infinite loops.
 const MaxPathSegments = 15
 
+// MaxRetainedBodyBytes bounds the body kept per crawled response for passive scanners.
+const MaxRetainedBodyBytes = 2 << 20
+
 // SitemapIndex represents the structure of a sitemap index file.
 type SitemapIndex struct {
 	XMLName  xml.Name  `xml:"sitemapindex"`
@@ -124,13 +86,53 @@ type URL struct {
 
 // ParameterizedRequest holds details of a request with identifiable parameters, suitable for scanning.
 type ParameterizedRequest struct {
-	Method         string   // HTTP method (GET, POST, etc.)
-	URL            string   // Full URL of the request.
-	Path           string   // URL path.
-	ParamNames     []string // Names of parameters found.
-	ParamLocations []string // Locations of parameters (e.g., "query", "body").
-	FormPostData   string   // Raw POST data for form submissions.
-	SourceURL      string   // URL of the page where the form was discovered.
+	Method          string            // HTTP method (GET, POST, etc.)
+	URL             string            // Full URL of the request.
+	Path            string            // URL path.
+	ParamNames      []string          // Names of parameters found.
+	ParamLocations  []string          // Locations of parameters (e.g., "query", "body").
+	FormPostData    string            // Raw POST data for form submissions.
+	SourceURL       string            // URL of the page where the form was discovered.
+	ContentType     string            // Content type of the request body (e.g., "application/json"). Empty means form-encoded.
+	RawBody         string            // Raw request body for non-form payloads such as JSON.
+	BodyEncoding    string            // Structure of RawBody beyond its content type: BodyEncodingGraphQL, or empty.
+	Headers         map[string]string // Injectable request headers and their original values (opt-in).
+	Cookies         map[string]string // Injectable cookies and their original values (opt-in).
+	PathParams      []PathParam       // Path segments holding values (e.g., the 123 of /users/123/orders).
+	MultipartFields []MultipartField  // Fields of a multipart/form-data body, in order.
+}
+
+// IsJSON reports whether the request carries a JSON body.
+func (r ParameterizedRequest) IsJSON() bool {
+	return r.Method != "GET" && strings.Contains(strings.ToLower(r.ContentType), "json")
+}
+
+// IsGraphQL reports whether the request is a GraphQL operation. RawBody then holds the JSON
+// envelope {"query": ..., "variables": {...}} and only the variables are injectable.
+func (r ParameterizedRequest) IsGraphQL() bool {
+	return r.BodyEncoding == BodyEncodingGraphQL && r.IsJSON()
+}
+
+// IsMultipart reports whether the request carries a multipart/form-data body, described by
+// MultipartFields.
+func (r ParameterizedRequest) IsMultipart() bool {
+	return r.Method != "GET" && strings.HasPrefix(strings.ToLower(r.ContentType), MultipartContentType)
+}
+
+// IsXML reports whether the request carries an XML body (text/xml, application/xml or +xml types).
+func (r ParameterizedRequest) IsXML() bool {
+	return r.Method != "GET" && strings.Contains(strings.ToLower(r.ContentType), "xml")
+}
+
+// CrawledResponse is a response fetched while crawling, retained for passive scanners.
+type CrawledResponse struct {
+	URL        string      // URL that was requested.
+	StatusCode int         // HTTP status code of the response.
+	Header     http.Header // Response headers.
+	Body       string      // Response body, truncated to MaxRetainedBodyBytes.
+	// Scripts are the in-scope URLs of the <script src> elements of an HTML page, in document
+	// order. Their bodies are retained as responses of their own once crawled.
+	Scripts []string `json:",omitempty"`
 }
 
 // CrawlJob represents a single unit of work for the crawler.
@@ -169,22 +171,28 @@ func getURLHash(url string) string {
 
 // Crawler represents the main crawling engine.
 type Crawler struct {
-	httpClient            *httpclient.Client          // HTTP client for making requests.
-	logger                *logger.Logger              // Logger for outputting messages.
-	visitedURLHashes      map[string]bool             // Set of visited URL hashes to prevent redundant crawling.
-	urlDepths             map[string]int              // Map to store the depth at which each URL was discovered.
-	mu                    sync.Mutex                  // Mutex for protecting concurrent access to shared resources.
-	targetDomain          string                      // The base domain of the target application.
-	resultsChan           chan string                 // Channel to send discovered URLs to.
-	wg                    sync.WaitGroup              // WaitGroup to manage goroutines for crawling.
-	maxConcurrency        int                         // Maximum number of concurrent crawling workers.
-	queue                 chan CrawlJob               // Channel for distributing crawl jobs to workers.
-	robotsDisallowed      map[string]bool             // Map of paths disallowed by robots.txt.
-	maxDepth              int                         // Maximum crawling depth.
+	httpClient            *httpclient.Client              // HTTP client for making requests.
+	logger                *logger.Logger                  // Logger for outputting messages.
+	visitedURLHashes      map[string]bool                 // Set of visited URL hashes to prevent redundant crawling.
+	urlDepths             map[string]int                  // Map to store the depth at which each URL was discovered.
+	mu                    sync.Mutex                      // Mutex for protecting concurrent access to shared resources.
+	targetDomain          string                          // The base domain of the target application.
+	scope                 *Scope                          // URLs the crawler may follow and record.
+	resultsChan           chan string                     // Channel to send discovered URLs to.
+	wg                    sync.WaitGroup                  // WaitGroup to manage goroutines for crawling.
+	maxConcurrency        int                             // Maximum number of concurrent crawling workers.
+	queue                 chan CrawlJob                   // Channel for distributing crawl jobs to workers.
+	maxDepth              int                             // Maximum crawling depth.
 	parameterizedRequests map[string]ParameterizedRequest // Map to store unique parameterized requests for scanning.
-	renderer              *renderer.Renderer          // Headless browser renderer for JavaScript-heavy pages.
-	detectedFramework     FrameworkType               // Detected JavaScript framework.
-	frameworkChecked      bool                        // Flag to ensure framework detection runs only once.
+	responses             map[string]CrawledResponse      // Responses fetched while crawling, keyed by URL.
+	renderer              PageRenderer                    // Headless browser renderer for JavaScript-heavy pages.
+	crawlMode             CrawlMode                       // How pages are fetched: static, rendered or hybrid.
+	formDefaults          map[string]string               // Values for empty form fields by lower-cased name.
+	limiter               *crawlLimiter                   // Page limits and politeness delay.
+	pending               map[string]int                  // Queued URLs not crawled yet, with their depth.
+	resumeFrontier        []CrawlJob                      // Jobs of a restored crawl, queued by Crawl.
+	detectedFramework     FrameworkType                   // Detected JavaScript framework.
+	frameworkChecked      bool                            // Flag to ensure framework detection runs only once.
 }
 
 // NewCrawler creates and initializes a new Crawler instance.
@@ -202,121 +210,113 @@ func NewCrawler(httpClient *httpclient.Client, log *logger.Logger, targetURL str
 	if maxDepth < 0 {
 		maxDepth = 0
 	}
-	return &Crawler{
+	scope, err := NewScope(targetURL, ScopeOptions{})
+	if err != nil {
+		return nil, err
+	}
+	c := &Crawler{
 		httpClient:            httpClient,
 		logger:                log,
 		visitedURLHashes:      make(map[string]bool),
 		urlDepths:             make(map[string]int),
 		targetDomain:          targetDomain,
+		scope:                 scope,
 		resultsChan:           make(chan string, 100), // Buffered channel for results.
 		maxConcurrency:        maxConcurrency,
 		queue:                 make(chan CrawlJob, maxConcurrency*2), // Buffered channel for crawl jobs.
-		robotsDisallowed:      make(map[string]bool),
 		maxDepth:              maxDepth,
 		parameterizedRequests: make(map[string]ParameterizedRequest),
-		renderer:              rend,
-	}, nil
+		responses:             make(map[string]CrawledResponse),
+		crawlMode:             CrawlModeStatic,
+		limiter:               newCrawlLimiter(CrawlLimits{}),
+		pending:               make(map[string]int),
+	}
+	// Keep the interface nil when no renderer is given. Passing a renderer selects
+	// CrawlModeRendered unless SetCrawlMode chooses otherwise.
+	if rend != nil {
+		c.renderer = rend
+		c.crawlMode = CrawlModeRendered
+	}
+	return c, nil
 }
 
 // addToQueue adds a new URL to the crawling queue if it meets the criteria.
 func (c *Crawler) addToQueue(newURL string, currentDepth int) {
-	// Check if the URL should be crawled and is not disallowed by robots.txt.
-	if c.shouldCrawl(newURL) && !c.isDisallowedByRobots(newURL) {
+	// Check if the URL should be crawled.
+	if c.shouldCrawl(newURL) && c.withinLimits(newURL) {
 		c.markAsVisited(newURL, currentDepth) // Mark URL as visited.
-		c.wg.Add(1)                           // Increment WaitGroup counter.
-		// Add the crawl job to the queue in a new goroutine to avoid blocking.
-		go func() { c.queue <- CrawlJob{URL: newURL, Depth: currentDepth} }()
+		c.enqueue(CrawlJob{URL: newURL, Depth: currentDepth})
 		c.resultsChan <- newURL // Send the new URL to the results channel.
 	}
 }
 
-// processJSFile extracts and processes potential endpoints from JavaScript content.
-func (c *Crawler) processJSFile(jsContent string, baseURL string, currentDepth int) {
-	go func() {
-		// Extract source map URL if present.
-		sourceMapRegex := regexp.MustCompile(`//# sourceMappingURL=(.+)`)
-		matches := sourceMapRegex.FindStringSubmatch(jsContent)
-		if len(matches) > 1 {
-			sourceMapPath := strings.TrimSpace(matches[1])
-			sourceMapURL := c.resolveURL(baseURL, sourceMapPath)
-			if sourceMapURL != "" {
-				c.fetchAndParseSourceMap(sourceMapURL, currentDepth) // Fetch and parse the source map.
-			}
-		}
-	}()
-	c.logger.Debug("JS Extractor: Analyzing JS content from %s", baseURL)
-	foundEndpoints := make(map[string]bool)
-	// Iterate through regexes to find potential endpoints.
-	for _, re := range jsPathRegexes {
-		matches := re.FindAllStringSubmatch(jsContent, -1)
-		for _, match := range matches {
-			if len(match) > 1 {
-				endpoint := strings.Trim(match[1], `"' `)
-				// Add valid, non-absolute endpoints to the foundEndpoints map.
-				if len(endpoint) > 1 && !strings.HasPrefix(endpoint, "http") {
-					foundEndpoints[endpoint] = true
-				}
-			}
-		}
-	}
-	// Add discovered endpoints to the crawling queue.
-	if len(foundEndpoints) > 0 {
-		c.logger.Success("JS Extractor: Found %d potential endpoints in %s", len(foundEndpoints), baseURL)
-		for endpoint := range foundEndpoints {
-			resolvedURL := c.resolveURL(baseURL, endpoint)
-			if resolvedURL != "" {
-				c.logger.Debug("JS Extractor: Adding resolved endpoint to queue: %s", resolvedURL)
-				c.addToQueue(resolvedURL, currentDepth)
-			}
-		}
+// addEntryPoint adds an entry point to the crawling queue. Unlike addToQueue, it does not require
+// the URL to match the include patterns of the scope, only to be on an allowed host.
+func (c *Crawler) addEntryPoint(entryURL string, depth int) {
+	c.mu.Lock()
+	visited := c.visitedURLHashes[getURLHash(entryURL)]
+	c.mu.Unlock()
+	if visited || !c.scope.allowsEntryPoint(entryURL) {
+		return
 	}
+	c.markAsVisited(entryURL, depth)
+	c.enqueue(CrawlJob{URL: entryURL, Depth: depth})
+	c.resultsChan <- entryURL
 }
 
-// fetchAndParseSourceMap fetches and parses a JavaScript source map file to discover original source paths.
-func (c *Crawler) fetchAndParseSourceMap(sourceMapURL string, currentDepth int) {
-	c.logger.Debug("Source Map: Attempting to fetch and parse %s", sourceMapURL)
-	resp, err := c.httpClient.Get(sourceMapURL)
-	if err != nil {
-		c.logger.Warn("Source Map: Failed to fetch %s: %v", sourceMapURL, err)
-		return
-	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return
-	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return
-	}
-	var sm SourceMap
-	if err := json.Unmarshal(body, &sm); err != nil {
-		c.logger.Warn("Source Map: Failed to parse JSON from %s: %v", sourceMapURL, err)
-		return
-	}
-	if len(sm.Sources) > 0 {
-		c.logger.Success("Source Map: Found %d source files in %s", len(sm.Sources), sourceMapURL)
-		for _, sourcePath := range sm.Sources {
-			// Clean up webpack/source-map specific prefixes.
-			cleanedPath := regexp.MustCompile(`^(webpack|source-map):///`).ReplaceAllString(sourcePath, "")
-			cleanedPath = strings.TrimPrefix(cleanedPath, ".") // Remove leading dot if present.
-			resolvedURL := c.resolveURL(sourceMapURL, cleanedPath)
-			if resolvedURL != "" {
-				c.logger.Debug("Source Map: Adding discovered source path to queue: %s", resolvedURL)
-				c.addToQueue(resolvedURL, currentDepth) // Add resolved source path to the queue.
-			}
-		}
-	}
+// enqueue schedules a crawl job. The job stays in the frontier saved by Snapshot until it has
+// been crawled.
+func (c *Crawler) enqueue(job CrawlJob) {
+	c.mu.Lock()
+	c.pending[job.URL] = job.Depth
+	c.mu.Unlock()
+	c.wg.Add(1) // Increment WaitGroup counter.
+	// Add the crawl job to the queue in a new goroutine to avoid blocking.
+	go func() { c.queue <- job }()
 }
 
 // Crawl starts the crawling process from the given entry points.
 // It returns a channel of discovered URLs.
 func (c *Crawler) Crawl(entryPoints []string, initialDepth int) chan string {
-	c.fetchAndParseRobotsTxt() // Fetch and parse robots.txt.
+	// Bootstrap the queue from robots.txt, sitemaps and API specifications in the background.
+	// The WaitGroup keeps the crawl running until they are processed.
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		sitemaps := c.fetchAndParseRobotsTxt(initialDepth) // Queue the paths listed in robots.txt.
+		c.fetchAndParseSitemaps(sitemaps, initialDepth)    // Queue the pages listed in sitemaps.
+		c.fetchAndParseAPISpecs(initialDepth)              // Discover and parse API specifications.
+	}()
 	for _, baseURL := range entryPoints {
-		go c.fetchAndParseAPISpecs(baseURL) // Discover and parse API specifications.
-		c.fetchAndParseSitemap(baseURL)     // Discover and parse sitemaps.
-		c.addToQueue(baseURL, initialDepth) // Add initial entry points to the queue.
+		c.addEntryPoint(baseURL, initialDepth) // Add initial entry points to the queue.
+	}
+	// Continue a restored crawl where it stopped; its URLs are already marked as visited.
+	for _, job := range c.resumeFrontier {
+		c.enqueue(job)
 	}
+	c.resumeFrontier = nil
+	return c.run()
+}
+
+// CrawlDiscovered crawls URLs found outside the crawler (e.g., by content discovery) after a
+// previous Crawl has finished, so that their links, forms and parameters join the requests
+// returned by GetParameterizedRequestsForScanning. URLs already visited are skipped.
+func (c *Crawler) CrawlDiscovered(urls []string) chan string {
+	c.queue = make(chan CrawlJob, c.maxConcurrency*2)
+	c.resultsChan = make(chan string, 100)
+	// Hold the WaitGroup while queueing so the run cannot finish before the URLs are added.
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		for _, u := range urls {
+			c.addToQueue(u, 0)
+		}
+	}()
+	return c.run()
+}
+
+// run starts the workers and closes the channels once every queued job is done.
+func (c *Crawler) run() chan string {
 	// Start worker goroutines for concurrent crawling.
 	for i := 0; i < c.maxConcurrency; i++ {
 		go c.worker()
@@ -343,9 +343,9 @@ func (c *Crawler) Crawl(entryPoints []string, initialDepth int) chan string {
 
 	// Goroutine to close channels once all crawling jobs are done.
 	go func() {
-		c.wg.Wait()         // Wait for all worker goroutines to finish.
-		done <- true        // Stop the spinner
-		close(c.queue)      // Close the job queue.
+		c.wg.Wait()          // Wait for all worker goroutines to finish.
+		done <- true         // Stop the spinner
+		close(c.queue)       // Close the job queue.
 		close(c.resultsChan) // Close the results channel.
 	}()
 	return c.resultsChan // Return the channel for consuming discovered URLs.
@@ -361,6 +361,11 @@ func (c *Crawler) worker() {
 // crawl performs the actual crawling of a given URL.
 func (c *Crawler) crawl(currentURL string, currentDepth int) {
 	defer c.wg.Done() // Decrement WaitGroup counter when the function exits.
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, currentURL) // Leave the frontier before the WaitGroup is released.
+		c.mu.Unlock()
+	}()
 
 	parsedCurrentURL, err := url.Parse(currentURL)
 	if err != nil {
@@ -375,79 +380,55 @@ func (c *Crawler) crawl(currentURL string, currentDepth int) {
 	// Skip URL if max crawling depth is exceeded.
 	if c.maxDepth > 0 && currentDepth >= c.maxDepth {
 		c.logger.Debug("Crawler: Skipping %s due to exceeding max crawl depth.", currentURL)
+		c.dropDepth(currentURL)
 		return
 	}
 
 	c.logger.Debug("Crawling: %s (Depth: %d)", currentURL, currentDepth)
 
-	var bodyString string
-	var crawlErr error
-
-	// Use headless browser renderer if enabled.
-	if c.renderer != nil {
-		c.logger.Debug("Renderer: Using headless browser for %s", currentURL)
-		bodyString, crawlErr = c.renderer.GetRenderedHTML(currentURL, 30*time.Second)
-	} else {
-		// Otherwise, use standard HTTP client.
-		resp, httpErr := c.httpClient.Get(currentURL)
-		if httpErr != nil {
-			return // Skip if HTTP request fails.
+	// Collect the HTML documents of the page: the static response, the rendered DOM, or both.
+	var documents []string
+	if c.crawlMode != CrawlModeRendered {
+		bodyString, ok := c.fetchStatic(currentURL, currentDepth)
+		if !ok {
+			return // Not an HTML page worth parsing (error status, JS file, ...).
 		}
-		defer resp.Body.Close() // Ensure response body is closed.
-		if resp.StatusCode != http.StatusOK {
-			return // Skip if response status is not OK.
+		documents = append(documents, bodyString)
+	}
+	// In hybrid mode, pages without scripts are not rendered: the browser would see the same DOM.
+	if c.crawlMode == CrawlModeRendered || (c.crawlMode == CrawlModeHybrid && strings.Contains(strings.ToLower(documents[0]), "<script")) {
+		bodyString, renderErr := c.renderPage(currentURL)
+		if renderErr != nil {
+			c.logger.Warn("Failed to get content for %s: %v", currentURL, renderErr)
+		} else {
+			documents = append(documents, bodyString)
 		}
+	}
 
-		contentType := resp.Header.Get("Content-Type")
-		bodyBytes, readErr := io.ReadAll(resp.Body)
-		if readErr != nil {
-			return // Skip if reading response body fails.
+	for _, bodyString := range documents {
+		// Parse HTML document.
+		doc, err := html.Parse(strings.NewReader(bodyString))
+		if err != nil {
+			continue // Skip if HTML parsing fails.
 		}
-		bodyString = string(bodyBytes)
-		crawlErr = nil // No error if successful.
 
-		// Detect and analyze framework if not already checked.
-		c.mu.Lock()
-		if !c.frameworkChecked {
-			c.detectedFramework = c.detectFramework(string(bodyBytes), resp.Header)
-			if c.detectedFramework != FrameworkUnknown {
-				c.analyzeFrameworkConfig(currentURL)
-			}
-			c.frameworkChecked = true
-		}
-		c.mu.Unlock()
+		// Extract links and forms from the HTML document.
+		newLinks, newForms := c.extractLinksAndForms(doc, currentURL)
+		c.recordScripts(currentURL, c.scriptSources(doc, currentURL))
 
-		// Process JavaScript files.
-		if strings.Contains(contentType, "javascript") || strings.HasSuffix(currentURL, ".js") {
-			c.processJSFile(bodyString, currentURL, currentDepth)
-			return // Stop crawling this URL if it's a JS file.
+		// Add new links to the queue.
+		for _, newURL := range newLinks {
+			c.addToQueue(newURL, currentDepth+1)
+		}
+		// Add new forms as parameterized requests.
+		for _, formReq := range newForms {
+			c.addParameterizedRequest(formReq)
 		}
 	}
-
-	// Handle crawling errors.
-	if crawlErr != nil {
-		c.logger.Warn("Failed to get content for %s: %v", currentURL, crawlErr)
+	if len(documents) == 0 {
 		return
 	}
 
-	// Parse HTML document.
-	doc, err := html.Parse(strings.NewReader(bodyString))
-	if err != nil {
-		return // Skip if HTML parsing fails.
-	}
-
-	// Extract links and forms from the HTML document.
-	newLinks, newForms := c.extractLinksAndForms(doc, currentURL)
-
-	// Add new links to the queue.
-	for _, newURL := range newLinks {
-		c.addToQueue(newURL, currentDepth+1)
-	}
-	// Add new forms as parameterized requests.
-	for _, formReq := range newForms {
-		c.addParameterizedRequest(formReq)
-	}
-
 	// Add requests with common parameters.
 	commonParamRequests := c.addCommonParameters(currentURL)
 	for _, req := range commonParamRequests {
@@ -469,6 +450,61 @@ func (c *Crawler) crawl(currentURL string, currentDepth int) {
 	}
 }
 
+// fetchStatic fetches currentURL with the HTTP client and returns its body. It returns false for
+// responses that are not parsed as HTML: failed requests, non-200 statuses and JavaScript files,
+// which are mined for endpoints instead.
+func (c *Crawler) fetchStatic(currentURL string, currentDepth int) (string, bool) {
+	c.waitPoliteness(currentURL)
+	resp, httpErr := c.httpClient.Get(currentURL)
+	if httpErr != nil {
+		return "", false // Skip if HTTP request fails.
+	}
+	defer resp.Body.Close() // Ensure response body is closed.
+
+	contentType := resp.Header.Get("Content-Type")
+	isJS := strings.Contains(contentType, "javascript") || isJSURL(currentURL)
+	var body io.Reader = resp.Body
+	if isJS {
+		body = io.LimitReader(resp.Body, maxJSFileBytes+1) // Only the start of huge bundles is analyzed.
+	}
+	bodyBytes, readErr := io.ReadAll(body)
+	if readErr != nil {
+		return "", false // Skip if reading response body fails.
+	}
+	// Error pages are retained too: passive scanners look for stack traces in them.
+	c.recordResponse(currentURL, resp, bodyBytes)
+	if resp.StatusCode != http.StatusOK {
+		return "", false // Skip if response status is not OK.
+	}
+	if c.repeatsPage(currentURL, bodyBytes) {
+		return "", false // Same links as an earlier page of the listing.
+	}
+	// Follow the next page of API listings (Link header, next links and cursors in JSON) at the
+	// same depth: it continues the listing rather than going deeper. Pagination limits the pages.
+	for _, next := range nextPageLinks(currentURL, resp.Header, bodyBytes) {
+		c.addToQueue(next, currentDepth)
+	}
+	bodyString := string(bodyBytes)
+
+	// Detect and analyze framework if not already checked.
+	c.mu.Lock()
+	if !c.frameworkChecked {
+		c.detectedFramework = c.detectFramework(bodyString, resp.Header)
+		if c.detectedFramework != FrameworkUnknown {
+			c.analyzeFrameworkConfig(currentURL)
+		}
+		c.frameworkChecked = true
+	}
+	c.mu.Unlock()
+
+	// Process JavaScript files.
+	if isJS {
+		c.processJSFile(bodyString, currentURL, currentDepth)
+		return "", false // Stop crawling this URL if it's a JS file.
+	}
+	return bodyString, true
+}
+
 // extractLinksAndForms extracts links (a, link, script, img) and forms from an HTML document.
 func (c *Crawler) extractLinksAndForms(doc *html.Node, baseURL string) ([]string, []ParameterizedRequest) {
 	var links []string
@@ -490,7 +526,7 @@ func (c *Crawler) extractLinksAndForms(doc *html.Node, baseURL string) ([]string
 					if a.Key == attrKey {
 						resolvedURL := c.resolveURL(baseURL, a.Val)
 						// Add resolved URL to links if it's within the target domain.
-						if resolvedURL != "" && strings.HasPrefix(resolvedURL, c.targetDomain) {
+						if resolvedURL != "" && c.scope.Allows(resolvedURL) {
 							links = append(links, resolvedURL)
 						}
 						break // Move to the next tag after finding the relevant attribute.
@@ -502,7 +538,7 @@ func (c *Crawler) extractLinksAndForms(doc *html.Node, baseURL string) ([]string
 				for _, a := range n.Attr {
 					if a.Key == "src" {
 						resolvedURL := c.resolveURL(baseURL, a.Val)
-						if resolvedURL != "" && strings.HasPrefix(resolvedURL, c.targetDomain) {
+						if resolvedURL != "" && c.scope.Allows(resolvedURL) {
 							links = append(links, resolvedURL)
 						}
 						break
@@ -525,7 +561,7 @@ func (c *Crawler) extractLinksAndForms(doc *html.Node, baseURL string) ([]string
 				formURL := c.resolveURL(baseURL, action)
 				c.logger.Debug("Crawler: Found <form> tag. Raw action='%s', Resolved URL='%s'", action, formURL)
 				// Skip form if its URL is empty or out of scope.
-				if formURL == "" || !strings.HasPrefix(formURL, c.targetDomain) {
+				if formURL == "" || !c.scope.Allows(formURL) {
 					c.logger.Debug("Crawler: Skipping form, URL is out of scope.")
 					return // Return from this recursive call, not the main function.
 				}
@@ -535,6 +571,7 @@ func (c *Crawler) extractLinksAndForms(doc *html.Node, baseURL string) ([]string
 				isMultipart := enctype == "multipart/form-data"
 				var formParamNames []string
 				formInitialValues := url.Values{}
+				fileFields := make(map[string]bool)
 				var findInputs func(*html.Node) // Recursive function to find input elements within the form.
 				findInputs = func(node *html.Node) {
 					if node.Type == html.ElementNode {
@@ -560,6 +597,17 @@ func (c *Crawler) extractLinksAndForms(doc *html.Node, baseURL string) ([]string
 								if node.Data == "button" && value == "" && node.FirstChild != nil && node.FirstChild.Type == html.TextNode {
 									value = node.FirstChild.Data
 								}
+								value = c.formFieldValue(node, name, elemType, value)
+								// A radio group submits one value: the checked option, or else the first.
+								if elemType == "radio" && formInitialValues.Has(name) {
+									if hasAttr(node, "checked") {
+										formInitialValues.Set(name, value)
+									}
+									return
+								}
+								if elemType == "file" {
+									fileFields[name] = true
+								}
 								formParamNames = append(formParamNames, name)
 								formInitialValues.Add(name, value)
 							}
@@ -580,10 +628,18 @@ func (c *Crawler) extractLinksAndForms(doc *html.Node, baseURL string) ([]string
 						paramLocations = []string{"query"} // Use query for GET forms.
 					}
 					postData := ""
-					if !isMultipart {
+					if method == "GET" {
+						// GET forms carry their values in the query string, where scanners read them.
+						query := parsedFormActionURL.Query()
+						for name, values := range formInitialValues {
+							query[name] = values
+						}
+						parsedFormActionURL.RawQuery = query.Encode()
+						formURL = parsedFormActionURL.String()
+					} else if !isMultipart {
 						postData = formInitialValues.Encode() // Encode form data for non-multipart forms.
 					}
-					forms = append(forms, ParameterizedRequest{
+					form := ParameterizedRequest{
 						Method:         method,
 						URL:            formURL,
 						Path:           parsedFormActionURL.Path,
@@ -591,7 +647,12 @@ func (c *Crawler) extractLinksAndForms(doc *html.Node, baseURL string) ([]string
 						ParamLocations: paramLocations,
 						FormPostData:   postData,
 						SourceURL:      baseURL, // Store the URL of the page where the form was found.
-					})
+					}
+					if isMultipart && method != "GET" {
+						form.ContentType = MultipartContentType
+						form.MultipartFields = multipartFields(formParamNames, formInitialValues, fileFields)
+					}
+					forms = append(forms, form)
 				} else {
 					c.logger.Debug("Crawler: Skipping form because no named parameters were found inside.")
 				}
@@ -736,8 +797,8 @@ func (c *Crawler) shouldCrawl(u string) bool {
 	if visited {
 		return false // Skip if already visited.
 	}
-	if !strings.HasPrefix(u, c.targetDomain) {
-		return false // Skip if outside the target domain.
+	if !c.scope.Allows(u) {
+		return false // Skip if outside the scope.
 	}
 
 	// Check for excluded file extensions to avoid scanning irrelevant files.
@@ -778,6 +839,17 @@ func (c *Crawler) markAsVisited(u string, depth int) {
 	}
 }
 
+// SetScope replaces the default scope (the target's host) of the crawler. It must be called
+// before Crawl.
+func (c *Crawler) SetScope(scope *Scope) {
+	c.scope = scope
+}
+
+// Scope returns the scope of the crawler.
+func (c *Crawler) Scope() *Scope {
+	return c.scope
+}
+
 // GetDiscoveredURLs returns a list of all unique URLs discovered by the crawler.
 func (c *Crawler) GetDiscoveredURLs() []string {
 	c.mu.Lock()
@@ -789,8 +861,90 @@ func (c *Crawler) GetDiscoveredURLs() []string {
 	return urls
 }
 
+// recordResponse retains a crawled response for passive scanners, with the body truncated to
+// MaxRetainedBodyBytes.
+func (c *Crawler) recordResponse(u string, resp *http.Response, body []byte) {
+	if len(body) > MaxRetainedBodyBytes {
+		body = body[:MaxRetainedBodyBytes]
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responses[u] = CrawledResponse{URL: u, StatusCode: resp.StatusCode, Header: resp.Header.Clone(), Body: string(body)}
+}
+
+// scriptSources returns the in-scope URLs of the <script src> elements of doc, in document order.
+func (c *Crawler) scriptSources(doc *html.Node, baseURL string) []string {
+	var scripts []string
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "script" {
+			for _, a := range n.Attr {
+				if a.Key == "src" {
+					if resolvedURL := c.resolveURL(baseURL, a.Val); resolvedURL != "" && c.scope.Allows(resolvedURL) {
+						scripts = append(scripts, resolvedURL)
+					}
+					break
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			f(child)
+		}
+	}
+	f(doc)
+	return scripts
+}
+
+// recordScripts adds scripts to the retained response of page u, so that passive scanners can
+// analyze the JavaScript each page runs. The rendered DOM of a page adds the scripts it inserted.
+func (c *Crawler) recordScripts(u string, scripts []string) {
+	if len(scripts) == 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	resp, ok := c.responses[u]
+	if !ok {
+		return // Rendered pages are not retained.
+	}
+	known := make(map[string]bool, len(resp.Scripts))
+	for _, script := range resp.Scripts {
+		known[script] = true
+	}
+	for _, script := range scripts {
+		if !known[script] {
+			known[script] = true
+			resp.Scripts = append(resp.Scripts, script)
+		}
+	}
+	c.responses[u] = resp
+}
+
+// GetCrawledResponses returns the responses fetched while crawling, sorted by URL. Pages crawled
+// through the headless renderer are not included, as their headers are not available.
+func (c *Crawler) GetCrawledResponses() []CrawledResponse {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	urls := make([]string, 0, len(c.responses))
+	for u := range c.responses {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	result := make([]CrawledResponse, 0, len(urls))
+	for _, u := range urls {
+		result = append(result, c.responses[u])
+	}
+	return result
+}
+
 // addParameterizedRequest adds a new parameterized request to the crawler's collection, handling deduplication.
 func (c *Crawler) addParameterizedRequest(newReq ParameterizedRequest) {
+	if !c.scope.Allows(newReq.URL) {
+		return // Skip requests built from out-of-scope URLs (e.g., API specs listing other hosts).
+	}
+	if newReq.PathParams == nil && !newReq.IsGraphQL() {
+		newReq.PathParams = PathParamsOf(newReq.Path)
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	sort.Strings(newReq.ParamNames) // Sort parameter names for consistent hashing.
@@ -822,220 +976,55 @@ func (c *Crawler) GetParameterizedRequestsForScanning() []ParameterizedRequest {
 	return result
 }
 
-// DiscoverParameters proactively discovers additional hidden parameters by injecting common parameter names.
-func (c *Crawler) DiscoverParameters(requests []ParameterizedRequest) []ParameterizedRequest {
-	c.logger.Info("Starting proactive parameter discovery to find hidden parameters...")
-	rand.Seed(time.Now().UnixNano()) // Seed random number generator.
-	searchParam := "search"          // Example common parameter.
-	c.logger.Debug("Testing for parameter: %s and %d other common parameters", searchParam, len(commonParameters)-1)
-
-	type probeJob struct {
-		request ParameterizedRequest
-		param   string
-	}
-	type foundParam struct {
-		requestKey string
-		paramName  string
-	}
-
-	jobsChan := make(chan probeJob)    // Channel for probe jobs.
-	foundChan := make(chan foundParam) // Channel for found parameters.
-	var wg sync.WaitGroup              // WaitGroup for probe workers.
-
-	// --- Start Spinner ---
-	done := make(chan bool)
-	go func() {
-		spinner := []string{"/", "-", "\\", "|"}
-		i := 0
-		for {
-			select {
-			case <-done:
-				fmt.Print("\r") // Clear the spinner line
-				return
-			default:
-				fmt.Printf("\rParameterDiscovery... %s ", spinner[i])
-				i = (i + 1) % len(spinner)
-				time.Sleep(100 * time.Millisecond)
-			}
-		}
-	}()
-	// --- End Spinner ---
-
-	// Start probe worker goroutines.
-	for i := 0; i < c.maxConcurrency; i++ {
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			for job := range jobsChan {
-				reflectionString := fmt.Sprintf("dursgoReflect%d", rand.Intn(1e9)) // Unique reflection string.
-				base, err := url.Parse(c.targetDomain)
-				if err != nil {
-					continue
-				}
-				base.Path = job.request.Path
-				query := url.Values{}
-				query.Set(job.param, reflectionString)
-				base.RawQuery = query.Encode()
-				testURLStr := base.String()
-
-				c.logger.Debug("Probing parameter '%s' on %s", job.param, job.request.Path)
-				c.logger.Debug("Probing URL: %s", testURLStr)
-
-				// Set standard headers for the probe request.
-				headers := map[string]string{
-					"User-Agent": "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
-					"Accept":     "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8",
-				}
-				client := &http.Client{
-					Timeout: 10 * time.Second, // Set a timeout for the probe request.
-				}
-				req, err := http.NewRequest("GET", testURLStr, nil)
-				if err != nil {
-					c.logger.Debug("Failed to create request: %v", err)
-					continue
-				}
-				for k, v := range headers {
-					req.Header.Set(k, v)
-				}
-
-				resp, err := client.Do(req)
-				if err != nil {
-					c.logger.Debug("Probe request for %s failed: %v", testURLStr, err)
-					continue
-				}
-				defer resp.Body.Close()
-				bodyBytes, readErr := io.ReadAll(resp.Body)
-				if readErr != nil {
-					c.logger.Debug("Failed to read response body: %v", readErr)
-					continue
-				}
-				bodyStr := string(bodyBytes)
-				// Log a preview of the response body.
-				previewLen := 300
-				if len(bodyStr) < previewLen {
-					previewLen = len(bodyStr)
-				}
-				c.logger.Debug("Response Body Preview (for %s on %s): %s", job.param, job.request.Path, strings.ReplaceAll(bodyStr[:previewLen], "\n", " "))
-
-				// If the reflection string is found in the response, a new parameter is discovered.
-				if strings.Contains(bodyStr, reflectionString) {
-					foundChan <- foundParam{
-						requestKey: job.request.Method + " " + job.request.Path,
-						paramName:  job.param,
-					}
-				}
-			}
-		}()
-	}
-
-	// Distribute probe jobs.
-	go func() {
-		for _, req := range requests {
-			currentReq := req
-			if currentReq.Method != "GET" { // Only probe GET requests for reflection.
-				continue
-			}
-			existingParams := make(map[string]bool)
-			for _, pName := range currentReq.ParamNames {
-				existingParams[pName] = true
-			}
-			for _, paramToTest := range commonParameters {
-				if existingParams[paramToTest] {
-					continue // Skip if parameter already exists.
-				}
-				jobsChan <- probeJob{request: currentReq, param: paramToTest}
-			}
+// fetchAndParseAPISpecs fetches common API specification files (OpenAPI/Swagger) from the
+// target. The operations they describe become scan targets and their GET endpoints are crawled.
+func (c *Crawler) fetchAndParseAPISpecs(depth int) {
+	for _, specPath := range commonAPISpecPaths {
+		specURL := c.targetDomain + "/" + specPath
+		body, ok := c.fetchBootstrapFile(specURL)
+		if !ok {
+			continue
 		}
-		close(jobsChan) // Close the jobs channel when all jobs are distributed.
-	}()
-
-	var collectionWg sync.WaitGroup
-	collectionWg.Add(1)
-	// Goroutine to wait for all probes to finish and close the found channel.
-	go func() {
-		wg.Wait()        // Wait for all probe workers to finish.
-		done <- true     // Stop the spinner
-		close(foundChan) // Close the channel of found parameters.
-		collectionWg.Done()
-	}()
-
-	// Collect found parameters and update requests.
-	requestMap := make(map[string]ParameterizedRequest)
-	for _, req := range requests {
-		requestMap[req.Method+" "+req.Path] = req
-	}
-	for found := range foundChan {
-		c.logger.Success("Found new reflected parameter '%s' on %s", found.paramName, found.requestKey)
-		if req, ok := requestMap[found.requestKey]; ok {
-			req.ParamNames = append(req.ParamNames, found.paramName)
-			requestMap[found.requestKey] = req
+		requests, err := ParseAPISpec(body, c.targetDomain)
+		if err != nil {
+			c.logger.Debug("API Spec: %s is not an API specification: %v", specURL, err)
+			continue
 		}
+		c.logger.Success("API Spec: Parsed %d operation(s) from %s", len(requests), specURL)
+		c.addAPISpecRequests(requests, func(u string) { c.addToQueue(u, depth) })
 	}
-	collectionWg.Wait() // Wait for all found parameters to be collected.
-
-	// Convert map back to slice for final requests.
-	finalRequests := make([]ParameterizedRequest, 0, len(requestMap))
-	for _, req := range requestMap {
-		req.ParamNames = mergeUnique(req.ParamNames, []string{}) // Ensure unique and sorted parameter names.
-		sort.Strings(req.ParamNames)
-		finalRequests = append(finalRequests, req)
-	}
-	c.logger.Info("Proactive parameter discovery finished.")
-	return finalRequests
 }
 
-// fetchAndParseAPISpecs fetches and parses common API specification files (OpenAPI/Swagger).
-func (c *Crawler) fetchAndParseAPISpecs(baseURL string) {
-	parsedBase, err := url.Parse(baseURL)
+// ImportAPISpec adds the operations of an OpenAPI/Swagger document as scan targets without
+// crawling, e.g. for a specification read from disk. Their URLs are recorded as discovered. It
+// returns the number of operations in scope.
+func (c *Crawler) ImportAPISpec(data []byte) (int, error) {
+	requests, err := ParseAPISpec(data, c.targetDomain)
 	if err != nil {
-		return
+		return 0, err
 	}
-	for _, specPath := range commonAPISpecPaths {
-		specURL := fmt.Sprintf("%s://%s/%s", parsedBase.Scheme, parsedBase.Host, strings.TrimLeft(specPath, "/"))
-		resp, err := c.httpClient.Get(specURL)
-		if err != nil || resp.StatusCode != http.StatusOK {
-			continue
-		}
-		defer resp.Body.Close()
-		c.logger.Success("API Spec: Found potential spec file at %s", specURL)
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
+	return c.addAPISpecRequests(requests, func(u string) { c.markAsVisited(u, 0) }), nil
+}
+
+// addAPISpecRequests records the operations of an API specification that are in scope: those with
+// parameters become scan targets, and visit is called with the URL of every GET operation.
+func (c *Crawler) addAPISpecRequests(requests []ParameterizedRequest, visit func(string)) int {
+	added := 0
+	for _, req := range requests {
+		if !c.scope.Allows(req.URL) {
 			continue
 		}
-		var spec OpenAPIv3
-		// Try parsing as YAML, then as JSON.
-		if yaml.Unmarshal(body, &spec) != nil {
-			if json.Unmarshal(body, &spec) != nil {
-				c.logger.Warn("API Spec: Failed to parse %s as JSON or YAML", specURL)
-				continue
-			}
+		added++
+		if len(req.ParamNames) > 0 {
+			c.addParameterizedRequest(req)
 		}
-		// Add discovered API paths to the crawling queue.
-		if len(spec.Paths) > 0 {
-			c.logger.Success("API Spec: Parsed %d paths from %s", len(spec.Paths), specURL)
-			for apiPath := range spec.Paths {
-				resolvedPath := c.resolveURL(specURL, apiPath)
-				if resolvedPath != "" {
-					c.logger.Debug("API Spec: Adding path %s to queue", resolvedPath)
-					c.addToQueue(resolvedPath, 0)
-				}
-			}
+		if req.Method == "GET" {
+			visit(req.URL)
 		}
 	}
+	return added
 }
 
-// fetchAndParseRobotsTxt is a placeholder for fetching and parsing robots.txt.
-func (c *Crawler) fetchAndParseRobotsTxt() {}
-
-// isDisallowedByRobots is a placeholder for checking if a URL is disallowed by robots.txt.
-func (c *Crawler) isDisallowedByRobots(u string) bool { return false }
-
-// fetchAndParseSitemap is a placeholder for fetching and parsing sitemap files.
-func (c *Crawler) fetchAndParseSitemap(b string) {}
-
-// fetchSitemapURL is a placeholder for fetching a URL from a sitemap.
-func (c *Crawler) fetchSitemapURL(s string) bool { return false }
-
 // getKeys returns the keys of a url.Values map as a sorted string slice.
 func getKeys(m url.Values) []string {
 	keys := make([]string, 0, len(m))
@@ -1044,20 +1033,3 @@ func getKeys(m url.Values) []string {
 	}
 	return keys
 }
-
-// mergeUnique merges two string slices and returns a new slice with unique elements, sorted.
-func mergeUnique(s1, s2 []string) []string {
-	m := make(map[string]bool)
-	for _, item := range s1 {
-		m[item] = true
-	}
-	for _, item := range s2 {
-		m[item] = true
-	}
-	result := make([]string, 0, len(m))
-	for item := range m {
-		result = append(result, item)
-	}
-	sort.Strings(result) // Ensure the result is sorted.
-	return result
-}
@@ -0,0 +1,103 @@
+package crawler
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultRepresentativesPerGroup is the number of structurally identical requests scanned per group.
+const DefaultRepresentativesPerGroup = 2
+
+// idSegmentRegex matches path segments that are identifiers rather than names: numbers, UUIDs,
+// hex digests and long tokens mixing letters and digits.
+var idSegmentRegex = regexp.MustCompile(`^(?:\d+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}|[0-9a-fA-F]{16,}|[A-Za-z0-9_-]*\d[A-Za-z0-9_-]*[A-Za-z][A-Za-z0-9_-]{18,})$`)
+
+// DuplicateGroup describes a group of structurally identical requests of which only
+// representatives were scanned.
+type DuplicateGroup struct {
+	Template        string `json:"template"`        // Method, path template and parameter names, e.g. "GET /product/{id} [id]".
+	Representatives int    `json:"representatives"` // Requests of the group that were scanned.
+	Total           int    `json:"total"`           // Requests in the group.
+}
+
+// PathTemplate replaces the identifier segments of a URL path with "{id}", so /product/42/reviews
+// and /product/43/reviews share the template /product/{id}/reviews.
+func PathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if idSegmentRegex.MatchString(segment) {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// DeduplicateRequests groups requests by method, host, path template and parameter names and
+// keeps the first perGroup requests of every group (DefaultRepresentativesPerGroup when perGroup
+// is 0; a negative perGroup disables deduplication). It returns the requests to scan, in input
+// order, and the groups that were collapsed. GraphQL requests are never collapsed, as requests to
+// the same endpoint run different operations.
+func DeduplicateRequests(requests []ParameterizedRequest, perGroup int) ([]ParameterizedRequest, []DuplicateGroup) {
+	kept, _, collapsed := PartitionDuplicates(requests, perGroup)
+	return kept, collapsed
+}
+
+// PartitionDuplicates is DeduplicateRequests that also returns the requests left out, in input
+// order.
+func PartitionDuplicates(requests []ParameterizedRequest, perGroup int) (kept, dropped []ParameterizedRequest, collapsed []DuplicateGroup) {
+	if perGroup < 0 {
+		return requests, nil, nil
+	}
+	if perGroup == 0 {
+		perGroup = DefaultRepresentativesPerGroup
+	}
+	groups := make(map[string]*DuplicateGroup)
+	kept = make([]ParameterizedRequest, 0, len(requests))
+	for _, req := range requests {
+		if req.IsGraphQL() {
+			kept = append(kept, req)
+			continue
+		}
+		key, template := requestTemplate(req)
+		group, ok := groups[key]
+		if !ok {
+			group = &DuplicateGroup{Template: template}
+			groups[key] = group
+		}
+		group.Total++
+		if group.Representatives < perGroup {
+			group.Representatives++
+			kept = append(kept, req)
+		} else {
+			dropped = append(dropped, req)
+		}
+	}
+
+	for _, group := range groups {
+		if group.Total > group.Representatives {
+			collapsed = append(collapsed, *group)
+		}
+	}
+	sort.Slice(collapsed, func(i, j int) bool { return collapsed[i].Template < collapsed[j].Template })
+	return kept, dropped, collapsed
+}
+
+// requestTemplate returns the grouping key of a request and its human-readable template.
+func requestTemplate(req ParameterizedRequest) (string, string) {
+	host, path := "", req.Path
+	if parsedURL, err := url.Parse(req.URL); err == nil {
+		host = parsedURL.Host
+		if path == "" {
+			path = parsedURL.Path
+		}
+	}
+	names := append([]string(nil), req.ParamNames...)
+	sort.Strings(names)
+	template := req.Method + " " + PathTemplate(path)
+	if len(names) > 0 {
+		template += " [" + strings.Join(names, ",") + "]"
+	}
+	return host + " " + template + " " + strings.Join(req.ParamLocations, ","), template
+}
@@ -0,0 +1,55 @@
+package crawler
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathTemplate(t *testing.T) {
+	for path, want := range map[string]string{
+		"/product/42/reviews":                         "/product/{id}/reviews",
+		"/users/3f2b8c1e-9a4d-4e6b-8c7a-1d2e3f4a5b6c": "/users/{id}",
+		"/files/d41d8cd98f00b204e9800998ecf8427e":     "/files/{id}",
+		"/api/v2/orders":                              "/api/v2/orders",
+		"/blog/2024-release-notes":                    "/blog/2024-release-notes",
+		"/share/a8Kd93jfLq0pZx7Vn2MbQw4":              "/share/{id}",
+		"/":                                           "/",
+	} {
+		assert.Equal(t, want, PathTemplate(path), path)
+	}
+}
+
+func TestDeduplicateRequests(t *testing.T) {
+	var requests []ParameterizedRequest
+	for i := 1; i <= 50; i++ {
+		requests = append(requests, ParameterizedRequest{
+			Method:         "GET",
+			URL:            fmt.Sprintf("https://shop.example.com/product/%d?ref=home", i),
+			Path:           fmt.Sprintf("/product/%d", i),
+			ParamNames:     []string{"ref"},
+			ParamLocations: []string{"query"},
+		})
+	}
+	requests = append(requests,
+		ParameterizedRequest{Method: "GET", URL: "https://shop.example.com/product/7?ref=home&sort=asc", Path: "/product/7", ParamNames: []string{"sort", "ref"}, ParamLocations: []string{"query"}},
+		ParameterizedRequest{Method: "POST", URL: "https://shop.example.com/product/7", Path: "/product/7", ParamNames: []string{"qty"}, ParamLocations: []string{"body"}},
+		ParameterizedRequest{Method: "POST", URL: "https://shop.example.com/graphql", Path: "/graphql", ContentType: "application/json", BodyEncoding: BodyEncodingGraphQL, RawBody: `{"query":"query { a }"}`},
+		ParameterizedRequest{Method: "POST", URL: "https://shop.example.com/graphql", Path: "/graphql", ContentType: "application/json", BodyEncoding: BodyEncodingGraphQL, RawBody: `{"query":"query { b }"}`},
+	)
+
+	kept, collapsed := DeduplicateRequests(requests, 0)
+	assert.Len(t, kept, DefaultRepresentativesPerGroup+4)
+	assert.Equal(t, "https://shop.example.com/product/1?ref=home", kept[0].URL)
+	assert.Equal(t, "https://shop.example.com/product/2?ref=home", kept[1].URL)
+	assert.Equal(t, []DuplicateGroup{{Template: "GET /product/{id} [ref]", Representatives: 2, Total: 50}}, collapsed)
+
+	kept, collapsed = DeduplicateRequests(requests, 5)
+	assert.Len(t, kept, 9)
+	assert.Equal(t, 5, collapsed[0].Representatives)
+
+	kept, collapsed = DeduplicateRequests(requests, -1)
+	assert.Len(t, kept, len(requests))
+	assert.Empty(t, collapsed)
+}
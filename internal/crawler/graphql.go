@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// BodyEncodingGraphQL marks a request whose RawBody is a GraphQL envelope.
+const BodyEncodingGraphQL = "graphql"
+
+// graphQLEnvelope is the JSON body of a GraphQL request over HTTP.
+type graphQLEnvelope struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName,omitempty"`
+	Variables     map[string]interface{} `json:"variables,omitempty"`
+}
+
+// NewGraphQLRequest returns a POST request sending query with variables to endpoint. Every
+// variable becomes a parameter named "variables.<name>", so scanners that inject into JSON paths
+// only touch the variables and never the query document.
+func NewGraphQLRequest(endpoint, query string, variables map[string]interface{}) (ParameterizedRequest, error) {
+	body, err := EncodeGraphQLBody(query, variables)
+	if err != nil {
+		return ParameterizedRequest{}, err
+	}
+	parsedURL, err := url.Parse(endpoint)
+	if err != nil {
+		return ParameterizedRequest{}, err
+	}
+
+	names := make([]string, 0, len(variables))
+	for name := range variables {
+		names = append(names, "variables."+name)
+	}
+	sort.Strings(names)
+	locations := make([]string, len(names))
+	for i := range locations {
+		locations[i] = "graphql"
+	}
+
+	return ParameterizedRequest{
+		Method:         "POST",
+		URL:            endpoint,
+		Path:           parsedURL.Path,
+		ParamNames:     names,
+		ParamLocations: locations,
+		ContentType:    "application/json",
+		RawBody:        body,
+		BodyEncoding:   BodyEncodingGraphQL,
+	}, nil
+}
+
+// EncodeGraphQLBody encodes a GraphQL envelope for query and variables.
+func EncodeGraphQLBody(query string, variables map[string]interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // Keep payload characters such as '<' and '&' intact.
+	if err := enc.Encode(graphQLEnvelope{Query: query, Variables: variables}); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// GraphQLQueryOf returns the GraphQL document of a JSON request body that looks like a GraphQL
+// envelope (a "query" string containing a selection set), and whether one was found.
+func GraphQLQueryOf(rawBody string) (string, bool) {
+	var envelope graphQLEnvelope
+	if err := json.Unmarshal([]byte(rawBody), &envelope); err != nil {
+		return "", false
+	}
+	query := strings.TrimSpace(envelope.Query)
+	return query, strings.Contains(query, "{") && strings.Contains(query, "}")
+}
@@ -0,0 +1,243 @@
+package crawler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// maxJSFileBytes bounds the JavaScript (and source map) content analyzed per file; the rest of
+// larger bundles is ignored.
+const maxJSFileBytes = 5 << 20
+
+// SourceMap represents the structure of a .map file, used for JavaScript source map analysis.
+type SourceMap struct {
+	Sources        []string `json:"sources"`
+	SourcesContent []string `json:"sourcesContent"`
+}
+
+var (
+	// jsStringRegex matches string literals: double- and single-quoted strings without escapes and
+	// template literals.
+	jsStringRegex = regexp.MustCompile(`"([^"\\\n]{2,300})"|'([^'\\\n]{2,300})'|` + "`([^`\\\\]{2,300})`")
+	// jsTemplateSlotRegex matches the ${...} slots of template literals; jsLeadingSlotRegex one at the start.
+	jsTemplateSlotRegex = regexp.MustCompile(`\$\{[^{}]*\}`)
+	jsLeadingSlotRegex  = regexp.MustCompile(`^\$\{[^{}]*\}`)
+	// jsPathRegex matches strings that look like URL paths, absolute or relative, or like http(s) URLs.
+	jsPathRegex = regexp.MustCompile(`^(?:https?://[A-Za-z0-9.-]+(?::\d+)?)?(?:\.{1,2}/|/)?[A-Za-z0-9_.~%@+-]+(?:/[A-Za-z0-9_.~%@+-]*)*$`)
+	// jsQueryParamRegex matches the parameter names of a query string.
+	jsQueryParamRegex = regexp.MustCompile(`(?:^|&)([A-Za-z_][A-Za-z0-9_.\[\]-]*)=`)
+	// jsMIMETypeRegex matches MIME types, which look like relative paths.
+	jsMIMETypeRegex = regexp.MustCompile(`^(?:application|audio|font|image|model|multipart|text|video)/`)
+	// sourceMappingURLRegex matches the source map comment of a JavaScript file.
+	sourceMappingURLRegex = regexp.MustCompile(`//[#@] sourceMappingURL=(\S+)`)
+	// sourceMapPrefixRegex matches the webpack/source-map prefixes of source map entries.
+	sourceMapPrefixRegex = regexp.MustCompile(`^(webpack|source-map):///`)
+)
+
+// jsEndpoint is an endpoint referenced by JavaScript code.
+type jsEndpoint struct {
+	Path   string   // Path or URL, relative ones as written in the code.
+	Params []string // Query parameter names, sorted.
+}
+
+// extractJSEndpoints returns the endpoints referenced by string literals in JavaScript code, such
+// as fetch('/api/v1/users?role=' + r) or axios.post(`/api/orders/${id}/items`). Template literal
+// slots in the path are filled in with "1"; query parameter names are kept without values.
+func extractJSEndpoints(js string) []jsEndpoint {
+	params := make(map[string]map[string]bool)
+	var order []string
+	lastQueryPath := "" // Path of the last literal with a query, continued by "&name=" literals.
+	for _, match := range jsStringRegex.FindAllStringSubmatch(js, -1) {
+		literal := match[1] + match[2] + match[3]
+		if strings.HasPrefix(literal, "&") && lastQueryPath != "" {
+			addQueryParamNames(params[lastQueryPath], literal[1:])
+			continue
+		}
+		path, query, hasQuery := strings.Cut(literal, "?")
+		// A leading slot is a base URL (`${API_ROOT}/users`); other slots are IDs.
+		path = jsLeadingSlotRegex.ReplaceAllString(path, "")
+		path = jsTemplateSlotRegex.ReplaceAllString(path, "1")
+		if !isJSPath(path) {
+			continue
+		}
+		if _, seen := params[path]; !seen {
+			params[path] = make(map[string]bool)
+			order = append(order, path)
+		}
+		addQueryParamNames(params[path], query)
+		if hasQuery {
+			lastQueryPath = path
+		}
+	}
+
+	endpoints := make([]jsEndpoint, 0, len(order))
+	for _, path := range order {
+		endpoint := jsEndpoint{Path: path}
+		for name := range params[path] {
+			endpoint.Params = append(endpoint.Params, name)
+		}
+		sort.Strings(endpoint.Params)
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints
+}
+
+// addQueryParamNames adds the parameter names of a (template literal) query string to names.
+func addQueryParamNames(names map[string]bool, query string) {
+	for _, match := range jsQueryParamRegex.FindAllStringSubmatch(jsTemplateSlotRegex.ReplaceAllString(query, ""), -1) {
+		names[match[1]] = true
+	}
+}
+
+// isJSPath reports whether a string literal looks like a URL path rather than text, a MIME type or
+// a date.
+func isJSPath(s string) bool {
+	if !strings.Contains(s, "/") || strings.HasPrefix(s, "//") || !jsPathRegex.MatchString(s) {
+		return false
+	}
+	if jsMIMETypeRegex.MatchString(s) {
+		return false
+	}
+	return strings.IndexFunc(s, func(r rune) bool { return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' }) >= 0
+}
+
+// processJSFile extracts endpoints and query parameter names from JavaScript content and its
+// source map. Endpoints are queued for crawling; endpoints with query parameters also become GET
+// requests for scanning, with empty parameter values.
+func (c *Crawler) processJSFile(jsContent string, jsURL string, currentDepth int) {
+	if len(jsContent) > maxJSFileBytes {
+		c.logger.Debug("JS Extractor: Analyzing only the first %d bytes of %s", maxJSFileBytes, jsURL)
+		jsContent = jsContent[:maxJSFileBytes]
+	}
+	if matches := sourceMappingURLRegex.FindStringSubmatch(jsContent); len(matches) > 1 {
+		c.processSourceMap(strings.TrimSpace(matches[1]), jsURL, currentDepth)
+	}
+	c.logger.Debug("JS Extractor: Analyzing JS content from %s", jsURL)
+	c.queueJSEndpoints(jsContent, jsURL, currentDepth)
+}
+
+// queueJSEndpoints queues the endpoints referenced by JavaScript code found at sourceURL. Paths
+// starting with "./" or "../" are resolved against sourceURL; other relative paths against the
+// root of the target, as bundles mostly build them for the page's origin.
+func (c *Crawler) queueJSEndpoints(jsContent, sourceURL string, currentDepth int) {
+	endpoints := extractJSEndpoints(jsContent)
+	if len(endpoints) == 0 {
+		return
+	}
+	c.logger.Success("JS Extractor: Found %d potential endpoints in %s", len(endpoints), sourceURL)
+	for _, endpoint := range endpoints {
+		base := c.targetDomain + "/"
+		if strings.HasPrefix(endpoint.Path, ".") {
+			base = sourceURL
+		}
+		resolvedURL := c.resolveURL(base, endpoint.Path)
+		if resolvedURL == "" {
+			continue
+		}
+		parsedURL, err := url.Parse(resolvedURL)
+		if err != nil {
+			continue
+		}
+		if len(endpoint.Params) > 0 {
+			query := parsedURL.Query()
+			for _, name := range endpoint.Params {
+				query.Set(name, "")
+			}
+			parsedURL.RawQuery = query.Encode()
+			resolvedURL = parsedURL.String()
+			c.addParameterizedRequest(ParameterizedRequest{
+				Method:         "GET",
+				URL:            resolvedURL,
+				Path:           parsedURL.Path,
+				ParamNames:     append([]string(nil), endpoint.Params...),
+				ParamLocations: []string{"query"},
+				SourceURL:      sourceURL,
+			})
+		}
+		c.logger.Debug("JS Extractor: Adding resolved endpoint to queue: %s", resolvedURL)
+		c.addToQueue(resolvedURL, currentDepth)
+	}
+}
+
+// processSourceMap reads the source map of a JavaScript file, inline (data: URI) or fetched from
+// sourceMapRef resolved against jsURL. The original sources it embeds are mined for endpoints and
+// the paths of its source files are queued.
+func (c *Crawler) processSourceMap(sourceMapRef, jsURL string, currentDepth int) {
+	var body []byte
+	sourceMapURL := jsURL
+	if strings.HasPrefix(sourceMapRef, "data:") {
+		_, encoded, found := strings.Cut(sourceMapRef, ";base64,")
+		if !found {
+			return
+		}
+		decoded, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			c.logger.Debug("Source Map: Failed to decode inline source map of %s: %v", jsURL, err)
+			return
+		}
+		body = decoded
+	} else {
+		if sourceMapURL = c.resolveURL(jsURL, sourceMapRef); sourceMapURL == "" || !c.scope.Allows(sourceMapURL) {
+			return
+		}
+		var ok bool
+		if body, ok = c.fetchSourceMap(sourceMapURL); !ok {
+			return
+		}
+	}
+
+	var sm SourceMap
+	if err := json.Unmarshal(body, &sm); err != nil {
+		c.logger.Warn("Source Map: Failed to parse JSON from %s: %v", sourceMapURL, err)
+		return
+	}
+	if len(sm.Sources) > 0 {
+		c.logger.Success("Source Map: Found %d source files in %s", len(sm.Sources), sourceMapURL)
+		for _, sourcePath := range sm.Sources {
+			// Clean up webpack/source-map specific prefixes.
+			cleanedPath := sourceMapPrefixRegex.ReplaceAllString(sourcePath, "")
+			cleanedPath = strings.TrimPrefix(cleanedPath, ".") // Remove leading dot if present.
+			resolvedURL := c.resolveURL(sourceMapURL, cleanedPath)
+			if resolvedURL != "" {
+				c.logger.Debug("Source Map: Adding discovered source path to queue: %s", resolvedURL)
+				c.addToQueue(resolvedURL, currentDepth) // Add resolved source path to the queue.
+			}
+		}
+	}
+	// Original sources are not minified: endpoints split across modules are easier to find there.
+	for _, content := range sm.SourcesContent {
+		c.queueJSEndpoints(content, sourceMapURL, currentDepth)
+	}
+}
+
+// fetchSourceMap fetches a source map file, reading at most maxJSFileBytes.
+func (c *Crawler) fetchSourceMap(sourceMapURL string) ([]byte, bool) {
+	c.logger.Debug("Source Map: Attempting to fetch and parse %s", sourceMapURL)
+	c.waitPoliteness(sourceMapURL)
+	resp, err := c.httpClient.Get(sourceMapURL)
+	if err != nil {
+		c.logger.Warn("Source Map: Failed to fetch %s: %v", sourceMapURL, err)
+		return nil, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxJSFileBytes))
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+// isJSURL reports whether a URL names a JavaScript file, ignoring its query string (app.js?v=3).
+func isJSURL(u string) bool {
+	path, _, _ := strings.Cut(u, "?")
+	return strings.HasSuffix(strings.ToLower(path), ".js")
+}
@@ -0,0 +1,100 @@
+package crawler
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractJSEndpoints(t *testing.T) {
+	js := `const r=e.role;fetch("/api/v1/users?role="+r+"&page=1");` +
+		"axios.post(`/api/orders/${order.id}/items?sku=${s}&qty=${q}`);" +
+		"fetch(`${API_ROOT}/api/v1/profile`);" +
+		`n.get('../data/config.json');x.open("GET","/api/v1/users?sort=asc");` +
+		`h["Content-Type"]="application/json";d="12/31/2024";t="and/or";s="//cdn.example.com/lib.js";` +
+		`location.href="https://shop.example.com/account?tab=orders";m="Hello world/again"`
+
+	assert.Equal(t, []jsEndpoint{
+		{Path: "/api/v1/users", Params: []string{"page", "role", "sort"}},
+		{Path: "/api/orders/1/items", Params: []string{"qty", "sku"}},
+		{Path: "/api/v1/profile"},
+		{Path: "../data/config.json"},
+		{Path: "and/or"},
+		{Path: "https://shop.example.com/account", Params: []string{"tab"}},
+	}, extractJSEndpoints(js))
+}
+
+func TestCrawlJavaScriptEndpoints(t *testing.T) {
+	sourceMap := `{"version":3,"sources":["webpack:///./src/api.ts"],"sourcesContent":["export const search = (q) => http.get('/api/search?q=' + q + '&limit=10')"]}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body><script src="/static/app.js?v=3"></script></body></html>`))
+		case "/static/app.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			w.Write([]byte("fetch(`/api/v1/users/${id}?role=${r}`)\n//# sourceMappingURL=data:application/json;base64," + base64.StdEncoding.EncodeToString([]byte(sourceMap))))
+		default:
+			w.Write([]byte(`{}`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newTestCrawler(t, server.URL, nil, CrawlModeStatic)
+	var discovered []string
+	for u := range c.Crawl([]string{server.URL + "/"}, 0) {
+		discovered = append(discovered, u)
+	}
+	assert.Contains(t, discovered, server.URL+"/api/v1/users/1?role=")
+	assert.Contains(t, discovered, server.URL+"/api/search?limit=&q=")
+
+	requests := c.GetParameterizedRequestsForScanning()
+	users, ok := findRequest(requests, "GET", "/api/v1/users/1")
+	require.True(t, ok)
+	assert.Equal(t, []string{"role"}, users.ParamNames)
+	assert.True(t, strings.HasPrefix(users.SourceURL, server.URL+"/static/app.js"))
+	search, ok := findRequest(requests, "GET", "/api/search")
+	require.True(t, ok)
+	assert.Equal(t, []string{"limit", "q"}, search.ParamNames)
+}
+
+func TestCrawlRetainsPageScripts(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><head><script src="/js/app.js"></script><script src="https://cdn.example.net/lib.js"></script></head>` +
+				`<body><script>init()</script><script src="/js/app.js"></script></body></html>`))
+		case "/js/app.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			w.Write([]byte("function init(){document.body.innerHTML=location.hash}"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := newTestCrawler(t, server.URL, nil, CrawlModeStatic)
+	for range c.Crawl([]string{server.URL + "/"}, 0) {
+	}
+
+	responses := map[string]CrawledResponse{}
+	for _, resp := range c.GetCrawledResponses() {
+		responses[resp.URL] = resp
+	}
+	require.Contains(t, responses, server.URL+"/")
+	assert.Equal(t, []string{server.URL + "/js/app.js"}, responses[server.URL+"/"].Scripts, "out-of-scope and repeated scripts are left out")
+	require.Contains(t, responses, server.URL+"/js/app.js")
+	assert.Contains(t, responses[server.URL+"/js/app.js"].Body, "innerHTML")
+}
+
+func TestIsJSURL(t *testing.T) {
+	assert.True(t, isJSURL("https://example.com/static/app.js?v=3"))
+	assert.True(t, isJSURL("https://example.com/MAIN.JS"))
+	assert.False(t, isJSURL("https://example.com/app.json"))
+}
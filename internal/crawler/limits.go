@@ -0,0 +1,184 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultInfiniteURLThreshold is the number of variants of a URL pattern crawled before the
+// pattern is pruned as infinite.
+const DefaultInfiniteURLThreshold = 25
+
+// Limits reported by DroppedByLimit.
+const (
+	LimitMaxDepth        = "max_depth"
+	LimitMaxPagesPerHost = "max_pages_per_host"
+	LimitMaxParamsPerURL = "max_params_per_url"
+	LimitInfinitePattern = "infinite_pattern"
+	LimitPagination      = "pagination"      // Pages of a listing past the sample.
+	LimitPaginationLoop  = "pagination_loop" // Pages of a listing that repeated an earlier page, and the pages after.
+)
+
+// CrawlLimits bounds the pages the crawler visits. Zero values mean no limit.
+type CrawlLimits struct {
+	MaxPagesPerHost int           // Pages queued per host.
+	MaxParamsPerURL int           // Query parameters of a URL; URLs with more are dropped.
+	PolitenessDelay time.Duration // Minimum time between two requests to the same host.
+	// InfiniteURLThreshold is the number of variants (different query values) of one path and
+	// parameter name set that are crawled before the pattern is pruned, e.g. the ever-advancing
+	// ?month= links of a calendar. 0 means DefaultInfiniteURLThreshold; negative disables pruning.
+	InfiniteURLThreshold int
+	// PaginationPages is the number of pages of a listing paginated by page number or offset
+	// (?page=, ?offset=) that are crawled. 0 means DefaultPaginationPages; negative crawls every
+	// page.
+	PaginationPages int
+	// CursorPages is the number of pages of a cursor-paginated listing (?cursor=, ?after=, next
+	// links of API responses) that are followed. 0 means DefaultCursorPages; negative follows
+	// every page.
+	CursorPages int
+}
+
+// crawlLimiter enforces CrawlLimits. Its maps are protected by the crawler's mutex.
+type crawlLimiter struct {
+	limits          CrawlLimits
+	pagesPerHost    map[string]int
+	patternVariants map[string]int
+	prunedPatterns  map[string]bool
+	droppedURLs     map[string]bool
+	dropped         map[string]int
+	nextRequest     map[string]time.Time
+	pagination      map[string]*paginationBranch // By listing and pagination parameter.
+}
+
+func newCrawlLimiter(limits CrawlLimits) *crawlLimiter {
+	if limits.InfiniteURLThreshold == 0 {
+		limits.InfiniteURLThreshold = DefaultInfiniteURLThreshold
+	}
+	if limits.PaginationPages == 0 {
+		limits.PaginationPages = DefaultPaginationPages
+	}
+	if limits.CursorPages == 0 {
+		limits.CursorPages = DefaultCursorPages
+	}
+	return &crawlLimiter{
+		limits:          limits,
+		pagesPerHost:    make(map[string]int),
+		patternVariants: make(map[string]int),
+		prunedPatterns:  make(map[string]bool),
+		droppedURLs:     make(map[string]bool),
+		dropped:         make(map[string]int),
+		nextRequest:     make(map[string]time.Time),
+		pagination:      make(map[string]*paginationBranch),
+	}
+}
+
+// SetLimits sets the page limits and politeness delay of the crawler. It must be called before
+// Crawl.
+func (c *Crawler) SetLimits(limits CrawlLimits) {
+	c.limiter = newCrawlLimiter(limits)
+}
+
+// DroppedByLimit returns the number of distinct URLs that were not crawled, per limit
+// (LimitMaxDepth, LimitMaxPagesPerHost, LimitMaxParamsPerURL, LimitInfinitePattern,
+// LimitPagination, LimitPaginationLoop).
+func (c *Crawler) DroppedByLimit() map[string]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result := make(map[string]int, len(c.limiter.dropped))
+	for limit, count := range c.limiter.dropped {
+		result[limit] = count
+	}
+	return result
+}
+
+// withinLimits reports whether a URL about to be queued is within the page limits, and counts it
+// against them if so.
+func (c *Crawler) withinLimits(u string) bool {
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	query := parsedURL.Query()
+	l := c.limiter
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if l.droppedURLs[u] {
+		return false
+	}
+	if l.limits.MaxParamsPerURL > 0 && len(query) > l.limits.MaxParamsPerURL {
+		c.dropLocked(u, LimitMaxParamsPerURL)
+		return false
+	}
+	if l.limits.MaxPagesPerHost > 0 && l.pagesPerHost[parsedURL.Host] >= l.limits.MaxPagesPerHost {
+		if l.dropped[LimitMaxPagesPerHost] == 0 {
+			c.logger.Warn("Crawler: Reached the limit of %d pages for %s; further pages are dropped.", l.limits.MaxPagesPerHost, parsedURL.Host)
+		}
+		c.dropLocked(u, LimitMaxPagesPerHost)
+		return false
+	}
+	if !c.withinPaginationLocked(u, parsedURL, query) {
+		return false
+	}
+	if len(query) > 0 && l.limits.InfiniteURLThreshold > 0 {
+		pattern := urlPattern(parsedURL, query)
+		if l.prunedPatterns[pattern] {
+			c.dropLocked(u, LimitInfinitePattern)
+			return false
+		}
+		l.patternVariants[pattern]++
+		if l.patternVariants[pattern] > l.limits.InfiniteURLThreshold {
+			l.prunedPatterns[pattern] = true
+			c.logger.Warn("Crawler: Pruning %s after %d variants (infinite URL pattern).", pattern, l.limits.InfiniteURLThreshold)
+			c.dropLocked(u, LimitInfinitePattern)
+			return false
+		}
+	}
+	l.pagesPerHost[parsedURL.Host]++
+	return true
+}
+
+// dropDepth records a URL that was not crawled because it exceeded the maximum depth.
+func (c *Crawler) dropDepth(u string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dropLocked(u, LimitMaxDepth)
+}
+
+// dropLocked records a URL dropped by a limit. c.mu must be held.
+func (c *Crawler) dropLocked(u, limit string) {
+	c.limiter.droppedURLs[u] = true
+	c.limiter.dropped[limit]++
+	c.logger.Debug("Crawler: Dropping %s (%s)", u, limit)
+}
+
+// urlPattern returns the host, path and sorted query parameter names of a URL.
+func urlPattern(u *url.URL, query url.Values) string {
+	names := getKeys(query)
+	sort.Strings(names)
+	return u.Host + u.Path + "?" + strings.Join(names, "&")
+}
+
+// waitPoliteness blocks until the politeness delay since the last request to the host of u has
+// passed. Concurrent workers are spaced out by reserving consecutive slots.
+func (c *Crawler) waitPoliteness(u string) {
+	delay := c.limiter.limits.PolitenessDelay
+	if delay <= 0 {
+		return
+	}
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	now := time.Now()
+	slot := c.limiter.nextRequest[parsedURL.Host]
+	if slot.Before(now) {
+		slot = now
+	}
+	c.limiter.nextRequest[parsedURL.Host] = slot.Add(delay)
+	c.mu.Unlock()
+	time.Sleep(time.Until(slot))
+}
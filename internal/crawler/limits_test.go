@@ -0,0 +1,77 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrawlLimits(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body><a href="/calendar?month=1">Calendar</a><a href="/search?a=1&b=2&c=3">Search</a></body></html>`))
+		case "/calendar":
+			// Every month links to the next one, forever.
+			var month int
+			fmt.Sscan(r.URL.Query().Get("month"), &month)
+			fmt.Fprintf(w, `<html><body><a href="/calendar?month=%d">Next</a></body></html>`, month+1)
+		default:
+			w.Write([]byte(`<html><body>page</body></html>`))
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newTestCrawler(t, server.URL, nil, CrawlModeStatic)
+	c.maxDepth = 0 // Unlimited: the calendar must be stopped by pattern pruning.
+	c.SetLimits(CrawlLimits{MaxParamsPerURL: 2, InfiniteURLThreshold: 5})
+	var calendarPages int
+	for u := range c.Crawl([]string{server.URL + "/"}, 0) {
+		if strings.Contains(u, "/calendar") {
+			calendarPages++
+		}
+		assert.NotContains(t, u, "/search")
+	}
+	assert.Equal(t, 5, calendarPages)
+	assert.Equal(t, map[string]int{LimitMaxParamsPerURL: 1, LimitInfinitePattern: 1}, c.DroppedByLimit())
+}
+
+func TestCrawlLimitsPagesPerHost(t *testing.T) {
+	c := newTestCrawler(t, "https://shop.example.com", nil, CrawlModeStatic)
+	c.SetLimits(CrawlLimits{MaxPagesPerHost: 2})
+	assert.True(t, c.withinLimits("https://shop.example.com/a"))
+	assert.True(t, c.withinLimits("https://shop.example.com/b?x=1"))
+	assert.False(t, c.withinLimits("https://shop.example.com/c"))
+	assert.False(t, c.withinLimits("https://shop.example.com/c"))
+	assert.True(t, c.withinLimits("https://api.example.com/c"))
+	assert.Equal(t, map[string]int{LimitMaxPagesPerHost: 1}, c.DroppedByLimit())
+}
+
+func TestWaitPoliteness(t *testing.T) {
+	c := newTestCrawler(t, "https://shop.example.com", nil, CrawlModeStatic)
+	c.SetLimits(CrawlLimits{PolitenessDelay: 20 * time.Millisecond})
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.waitPoliteness("https://shop.example.com/page")
+		}()
+	}
+	wg.Wait()
+	require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	start = time.Now()
+	c.waitPoliteness("https://other.example.com/")
+	assert.Less(t, time.Since(start), 20*time.Millisecond)
+}
@@ -0,0 +1,355 @@
+package crawler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxSchemaDepth bounds the nesting of example bodies generated from (possibly recursive) schemas.
+const maxSchemaDepth = 6
+
+// apiSpecMethods are the operations read from a path item, in output order.
+var apiSpecMethods = []string{"get", "post", "put", "patch", "delete"}
+
+// serverVariableRegex matches the {variables} of OpenAPI server URLs and paths.
+var serverVariableRegex = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// apiSpec is a decoded OpenAPI 3 or Swagger 2 document.
+type apiSpec struct {
+	doc     map[string]interface{}
+	baseURL *url.URL
+}
+
+// ParseAPISpec parses an OpenAPI 3 or Swagger 2 document (JSON or YAML) and returns a request for
+// every operation, with path parameters filled in with example values, query parameters in the URL
+// and an example JSON or form body built from the request body schema. ParamNames lists the query
+// parameters, or the body fields for operations with a body. Server URLs that are relative or
+// missing are resolved against baseURL.
+func ParseAPISpec(data []byte, baseURL string) ([]ParameterizedRequest, error) {
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		if jsonErr := json.Unmarshal(data, &doc); jsonErr != nil {
+			return nil, fmt.Errorf("not a JSON or YAML document: %w", err)
+		}
+	}
+	if doc["openapi"] == nil && doc["swagger"] == nil {
+		return nil, errors.New("not an OpenAPI or Swagger document")
+	}
+	paths, ok := doc["paths"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("API specification has no paths")
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	spec := &apiSpec{doc: doc, baseURL: base}
+	server := spec.serverURL()
+
+	pathNames := make([]string, 0, len(paths))
+	for name := range paths {
+		pathNames = append(pathNames, name)
+	}
+	sort.Strings(pathNames)
+
+	var requests []ParameterizedRequest
+	for _, pathName := range pathNames {
+		pathItem, ok := spec.resolve(paths[pathName]).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, method := range apiSpecMethods {
+			operation, ok := pathItem[method].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			requests = append(requests, spec.request(server, pathName, strings.ToUpper(method), pathItem, operation))
+		}
+	}
+	return requests, nil
+}
+
+// serverURL returns the base URL of the API: the first server of OpenAPI 3 documents, or the
+// scheme, host and basePath of Swagger 2 documents.
+func (s *apiSpec) serverURL() string {
+	if servers, ok := s.doc["servers"].([]interface{}); ok && len(servers) > 0 {
+		if server, ok := servers[0].(map[string]interface{}); ok {
+			rawURL, _ := server["url"].(string)
+			variables, _ := server["variables"].(map[string]interface{})
+			rawURL = serverVariableRegex.ReplaceAllStringFunc(rawURL, func(match string) string {
+				variable, _ := variables[strings.Trim(match, "{}")].(map[string]interface{})
+				return fmt.Sprint(variable["default"])
+			})
+			if ref, err := url.Parse(rawURL); err == nil {
+				return strings.TrimSuffix(s.baseURL.ResolveReference(ref).String(), "/")
+			}
+		}
+	}
+	scheme, host := s.baseURL.Scheme, s.baseURL.Host
+	if schemes, ok := s.doc["schemes"].([]interface{}); ok && len(schemes) > 0 {
+		if first, ok := schemes[0].(string); ok && (first == "http" || first == "https") {
+			scheme = first
+		}
+	}
+	if specHost, ok := s.doc["host"].(string); ok && specHost != "" {
+		host = specHost
+	}
+	basePath, _ := s.doc["basePath"].(string)
+	return scheme + "://" + host + strings.TrimSuffix(basePath, "/")
+}
+
+// request builds the request of one operation.
+func (s *apiSpec) request(server, pathName, method string, pathItem, operation map[string]interface{}) ParameterizedRequest {
+	query := url.Values{}
+	form := url.Values{}
+	var body interface{}
+	path := pathName
+
+	for _, param := range s.parameters(pathItem, operation) {
+		name, _ := param["name"].(string)
+		value := s.parameterExample(param)
+		switch param["in"] {
+		case "path":
+			if value == "test" {
+				value = "1" // Untyped string path parameters are mostly IDs.
+			}
+			path = strings.ReplaceAll(path, "{"+name+"}", url.PathEscape(value))
+		case "query":
+			query.Set(name, value)
+		case "formData": // Swagger 2
+			form.Set(name, value)
+		case "body": // Swagger 2
+			body = s.example(param["schema"], 0)
+		}
+	}
+	contentType := ""
+	if requestBody, ok := s.resolve(operation["requestBody"]).(map[string]interface{}); ok {
+		contentType, body, form = s.requestBodyExample(requestBody, form)
+	}
+
+	endpoint := server + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req := ParameterizedRequest{Method: method, URL: endpoint, Path: path}
+	if parsedURL, err := url.Parse(endpoint); err == nil {
+		req.Path = parsedURL.Path
+	}
+	if serverURL, err := url.Parse(server); err == nil {
+		req.PathParams = PathParamsOfTemplate(strings.TrimSuffix(serverURL.Path, "/") + pathName)
+	}
+
+	switch fields, isObject := body.(map[string]interface{}); {
+	case body != nil && method != "GET":
+		raw, err := json.Marshal(body)
+		if err != nil {
+			break
+		}
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		req.ContentType = contentType
+		req.RawBody = string(raw)
+		if isObject {
+			for field := range fields {
+				req.ParamNames = append(req.ParamNames, field)
+			}
+			sort.Strings(req.ParamNames)
+		}
+		req.ParamLocations = []string{"json"}
+		return req
+	case len(form) > 0 && method != "GET":
+		req.FormPostData = form.Encode()
+		req.ParamNames = getKeys(form)
+		sort.Strings(req.ParamNames)
+		req.ParamLocations = []string{"body"}
+		return req
+	}
+	if len(query) > 0 {
+		req.ParamNames = getKeys(query)
+		sort.Strings(req.ParamNames)
+		req.ParamLocations = []string{"query"}
+	}
+	return req
+}
+
+// parameters returns the parameters of an operation, including the ones shared by its path item.
+// Operation parameters override path item parameters with the same name and location.
+func (s *apiSpec) parameters(pathItem, operation map[string]interface{}) []map[string]interface{} {
+	var params []map[string]interface{}
+	index := make(map[string]int)
+	for _, list := range []interface{}{pathItem["parameters"], operation["parameters"]} {
+		items, _ := list.([]interface{})
+		for _, item := range items {
+			param, ok := s.resolve(item).(map[string]interface{})
+			if !ok {
+				continue
+			}
+			key := fmt.Sprint(param["in"], ":", param["name"])
+			if i, exists := index[key]; exists {
+				params[i] = param
+				continue
+			}
+			index[key] = len(params)
+			params = append(params, param)
+		}
+	}
+	return params
+}
+
+// requestBodyExample returns the content type and example body of an OpenAPI 3 request body.
+// JSON bodies are preferred; form bodies are added to form.
+func (s *apiSpec) requestBodyExample(requestBody map[string]interface{}, form url.Values) (string, interface{}, url.Values) {
+	content, _ := requestBody["content"].(map[string]interface{})
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+	for _, mediaType := range mediaTypes {
+		if !strings.Contains(mediaType, "json") {
+			continue
+		}
+		media, _ := content[mediaType].(map[string]interface{})
+		if example, ok := media["example"]; ok {
+			return mediaType, example, form
+		}
+		return mediaType, s.example(media["schema"], 0), form
+	}
+	if media, ok := content["application/x-www-form-urlencoded"].(map[string]interface{}); ok {
+		if fields, ok := s.example(media["schema"], 0).(map[string]interface{}); ok {
+			for name, value := range fields {
+				form.Set(name, scalarString(value))
+			}
+		}
+	}
+	return "", nil, form
+}
+
+// parameterExample returns an example value for a parameter.
+func (s *apiSpec) parameterExample(param map[string]interface{}) string {
+	if example, ok := param["example"]; ok {
+		return scalarString(example)
+	}
+	if example, ok := param["x-example"]; ok {
+		return scalarString(example)
+	}
+	if schema, ok := param["schema"]; ok { // OpenAPI 3
+		return scalarString(s.example(schema, 0))
+	}
+	return scalarString(s.example(param, 0)) // Swagger 2 keeps the type on the parameter.
+}
+
+// example generates an example value for a schema.
+func (s *apiSpec) example(rawSchema interface{}, depth int) interface{} {
+	schema, ok := s.resolve(rawSchema).(map[string]interface{})
+	if !ok || depth > maxSchemaDepth {
+		return nil
+	}
+	for _, key := range []string{"example", "default"} {
+		if value, ok := schema[key]; ok {
+			return value
+		}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		merged := make(map[string]interface{})
+		for _, part := range allOf {
+			if fields, ok := s.example(part, depth+1).(map[string]interface{}); ok {
+				for name, value := range fields {
+					merged[name] = value
+				}
+			}
+		}
+		return merged
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		if options, ok := schema[key].([]interface{}); ok && len(options) > 0 {
+			return s.example(options[0], depth+1)
+		}
+	}
+
+	schemaType, _ := schema["type"].(string)
+	properties, hasProperties := schema["properties"].(map[string]interface{})
+	switch {
+	case schemaType == "object" || hasProperties:
+		object := make(map[string]interface{}, len(properties))
+		for name, property := range properties {
+			if value := s.example(property, depth+1); value != nil {
+				object[name] = value
+			}
+		}
+		return object
+	case schemaType == "array":
+		if item := s.example(schema["items"], depth+1); item != nil {
+			return []interface{}{item}
+		}
+		return []interface{}{}
+	case schemaType == "integer" || schemaType == "number":
+		return 1
+	case schemaType == "boolean":
+		return true
+	}
+	format, _ := schema["format"].(string)
+	switch format {
+	case "email":
+		return "test@example.com"
+	case "date":
+		return "2024-01-01"
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000001"
+	case "uri", "url":
+		return "https://example.com/"
+	}
+	return "test"
+}
+
+// resolve follows local $ref pointers (e.g., "#/components/schemas/User").
+func (s *apiSpec) resolve(node interface{}) interface{} {
+	for i := 0; i < maxSchemaDepth; i++ {
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return node
+		}
+		ref, ok := object["$ref"].(string)
+		if !ok || !strings.HasPrefix(ref, "#/") {
+			return node
+		}
+		var target interface{} = s.doc
+		for _, part := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			parent, ok := target.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			target = parent[part]
+		}
+		node = target
+	}
+	return nil
+}
+
+// scalarString formats an example value for a URL.
+func scalarString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "1"
+	case string:
+		return v
+	case map[string]interface{}, []interface{}:
+		raw, _ := json.Marshal(v)
+		return string(raw)
+	}
+	return fmt.Sprint(value)
+}
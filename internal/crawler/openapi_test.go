@@ -0,0 +1,139 @@
+package crawler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const openAPI3Spec = `
+openapi: 3.0.1
+servers:
+  - url: /api/{version}
+    variables:
+      version:
+        default: v1
+paths:
+  /users/{userId}:
+    parameters:
+      - name: userId
+        in: path
+        required: true
+        schema: {type: integer}
+    get:
+      parameters:
+        - name: fields
+          in: query
+          schema: {type: string, enum: [name, email]}
+    put:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/User'
+  /search:
+    post:
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              properties:
+                q: {type: string, example: shoes}
+                page: {type: integer}
+  /health:
+    get: {}
+components:
+  schemas:
+    User:
+      allOf:
+        - type: object
+          properties:
+            name: {type: string}
+            email: {type: string, format: email}
+        - type: object
+          properties:
+            tags:
+              type: array
+              items: {type: string}
+            manager:
+              $ref: '#/components/schemas/User'
+`
+
+const swagger2Spec = `{
+  "swagger": "2.0",
+  "host": "api.example.com",
+  "basePath": "/v2",
+  "schemes": ["https"],
+  "paths": {
+    "/orders/{id}": {
+      "delete": {"parameters": [{"name": "id", "in": "path", "type": "string"}]}
+    },
+    "/orders": {
+      "post": {"parameters": [{"name": "body", "in": "body", "schema": {"$ref": "#/definitions/Order"}}]},
+      "get": {"parameters": [{"name": "status", "in": "query", "type": "string", "x-example": "open"}]}
+    }
+  },
+  "definitions": {
+    "Order": {"type": "object", "properties": {"item": {"type": "string"}, "qty": {"type": "integer", "default": 2}}}
+  }
+}`
+
+func TestParseAPISpecOpenAPI3(t *testing.T) {
+	requests, err := ParseAPISpec([]byte(openAPI3Spec), "https://shop.example.com/app")
+	require.NoError(t, err)
+	require.Len(t, requests, 4) // Sorted by path, then method.
+
+	health := requests[0]
+	assert.Equal(t, "GET", health.Method)
+	assert.Equal(t, "https://shop.example.com/api/v1/health", health.URL)
+	assert.Empty(t, health.ParamNames)
+
+	search := requests[1]
+	assert.Equal(t, "POST", search.Method)
+	assert.Equal(t, "/api/v1/search", search.Path)
+	assert.Equal(t, "page=1&q=shoes", search.FormPostData)
+	assert.Equal(t, []string{"page", "q"}, search.ParamNames)
+	assert.Equal(t, []string{"body"}, search.ParamLocations)
+
+	getUser := requests[2]
+	assert.Equal(t, "GET", getUser.Method)
+	assert.Equal(t, "https://shop.example.com/api/v1/users/1?fields=name", getUser.URL)
+	assert.Equal(t, "/api/v1/users/1", getUser.Path)
+	assert.Equal(t, []string{"fields"}, getUser.ParamNames)
+	assert.Equal(t, []string{"query"}, getUser.ParamLocations)
+
+	putUser := requests[3]
+	assert.Equal(t, "PUT", putUser.Method)
+	assert.Equal(t, "https://shop.example.com/api/v1/users/1", putUser.URL)
+	assert.True(t, putUser.IsJSON())
+	assert.Equal(t, []string{"email", "manager", "name", "tags"}, putUser.ParamNames)
+	assert.Contains(t, putUser.RawBody, `"email":"test@example.com"`)
+	assert.Contains(t, putUser.RawBody, `"tags":["test"]`)
+	assert.Equal(t, []string{"json"}, putUser.ParamLocations)
+}
+
+func TestParseAPISpecSwagger2(t *testing.T) {
+	requests, err := ParseAPISpec([]byte(swagger2Spec), "http://www.example.com")
+	require.NoError(t, err)
+	require.Len(t, requests, 3)
+
+	assert.Equal(t, "GET", requests[0].Method)
+	assert.Equal(t, "https://api.example.com/v2/orders?status=open", requests[0].URL)
+
+	assert.Equal(t, "POST", requests[1].Method)
+	assert.JSONEq(t, `{"item":"test","qty":2}`, requests[1].RawBody)
+	assert.Equal(t, "application/json", requests[1].ContentType)
+	assert.Equal(t, []string{"item", "qty"}, requests[1].ParamNames)
+
+	assert.Equal(t, "DELETE", requests[2].Method)
+	assert.Equal(t, "https://api.example.com/v2/orders/1", requests[2].URL)
+}
+
+func TestParseAPISpecErrors(t *testing.T) {
+	_, err := ParseAPISpec([]byte("<html><body>Not found</body></html>"), "https://example.com")
+	assert.Error(t, err)
+	_, err = ParseAPISpec([]byte(`{"name": "package.json"}`), "https://example.com")
+	assert.Error(t, err)
+}
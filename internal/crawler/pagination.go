@@ -0,0 +1,289 @@
+package crawler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Defaults of CrawlLimits.PaginationPages and CrawlLimits.CursorPages.
+const (
+	DefaultPaginationPages = 5
+	DefaultCursorPages     = 20
+)
+
+// Pagination kinds, by how the pagination parameter addresses a page.
+const (
+	PaginationPage   = "page"   // Page number, e.g. ?page=2.
+	PaginationOffset = "offset" // Index of the first item, e.g. ?offset=40.
+	PaginationCursor = "cursor" // Opaque position returned by the previous page, e.g. ?cursor=eyJpZCI6NDJ9.
+)
+
+// paginationParams are the query parameters paginating a listing, by lower-cased name. Names
+// that also address single resources (e.g., WordPress's ?p=) or redirect targets (?next=) are
+// left out.
+var paginationParams = map[string]string{
+	"page": PaginationPage, "pg": PaginationPage, "paged": PaginationPage, "pagenum": PaginationPage,
+	"page_num": PaginationPage, "pagenumber": PaginationPage, "page_number": PaginationPage,
+	"pageno": PaginationPage, "pageindex": PaginationPage, "page_index": PaginationPage,
+	"offset": PaginationOffset, "start": PaginationOffset, "skip": PaginationOffset,
+	"startindex": PaginationOffset, "start_index": PaginationOffset,
+	"cursor": PaginationCursor, "after": PaginationCursor, "before": PaginationCursor,
+	"next_cursor": PaginationCursor, "nextcursor": PaginationCursor, "continuation": PaginationCursor,
+	"continuation_token": PaginationCursor, "continuationtoken": PaginationCursor,
+	"page_token": PaginationCursor, "pagetoken": PaginationCursor, "next_token": PaginationCursor,
+	"nexttoken": PaginationCursor, "since_id": PaginationCursor, "max_id": PaginationCursor,
+	"starting_after": PaginationCursor, "ending_before": PaginationCursor,
+}
+
+// nextLinkKeys are the JSON keys holding the URL of the next page of an API listing.
+var nextLinkKeys = []string{"next", "next_page", "nextPage", "next_url", "nextUrl", "next_link", "nextLink", "@odata.nextLink"}
+
+// nextCursorKeys are the JSON keys holding the cursor of the next page, with the query parameter
+// it is sent in when the current URL has no cursor parameter yet.
+var nextCursorKeys = []struct{ key, param string }{
+	{"next_cursor", "cursor"}, {"nextCursor", "cursor"}, {"next_page_token", "page_token"},
+	{"nextPageToken", "pageToken"}, {"continuation_token", "continuation_token"}, {"continuationToken", "continuationToken"},
+}
+
+// paginationEnvelopes are the JSON objects next links and cursors are nested in besides the top
+// level, e.g. {"links": {"next": "..."}}.
+var paginationEnvelopes = []string{"links", "_links", "meta", "pagination", "paging", "page_info", "pageInfo"}
+
+var linkNextPattern = regexp.MustCompile(`<([^>]+)>\s*;[^,]*rel="?next"?`)
+
+// PaginationBranch is a paginated listing whose pages were sampled: the listing URL without the
+// pagination parameter, how many of its pages were crawled and how many were pruned.
+type PaginationBranch struct {
+	Listing string `json:"listing"` // Host, path and other query parameters of the listing.
+	Param   string `json:"param"`   // Pagination parameter, e.g. "page".
+	Kind    string `json:"kind"`    // PaginationPage, PaginationOffset or PaginationCursor.
+	Crawled int    `json:"crawled"` // Distinct pages crawled.
+	Pruned  int    `json:"pruned"`  // Page URLs not crawled.
+	// Loop is set when a page repeated the content of an earlier one (e.g., every page past the
+	// last showing the last), which stopped the pagination.
+	Loop bool `json:"loop,omitempty"`
+}
+
+// paginationBranch is the sampling state of a listing. It is protected by the crawler's mutex.
+type paginationBranch struct {
+	PaginationBranch
+	values  map[string]bool   // Pagination values crawled.
+	hashes  map[string]string // Hashes of the pages crawled, to their pagination value.
+	stopped bool              // A loop was detected; further pages are pruned.
+}
+
+// pagination returns the pagination parameter of a query and its kind. Page numbers and offsets
+// must be numeric.
+func pagination(query url.Values) (string, string, bool) {
+	names := getKeys(query)
+	sort.Strings(names)
+	for _, name := range names {
+		kind, ok := paginationParams[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+		if kind != PaginationCursor {
+			if _, err := strconv.Atoi(query.Get(name)); err != nil {
+				continue
+			}
+		}
+		return name, kind, true
+	}
+	return "", "", false
+}
+
+// paginationBranchLocked returns the branch of a paginated URL, adding it if needed. c.mu must be
+// held.
+func (c *Crawler) paginationBranchLocked(u *url.URL, query url.Values, param, kind string) *paginationBranch {
+	rest := url.Values{}
+	for name, values := range query {
+		if name != param {
+			rest[name] = values
+		}
+	}
+	listing := u.Host + u.Path
+	if len(rest) > 0 {
+		listing += "?" + rest.Encode()
+	}
+	key := listing + "#" + param
+	b := c.limiter.pagination[key]
+	if b == nil {
+		b = &paginationBranch{
+			PaginationBranch: PaginationBranch{Listing: listing, Param: param, Kind: kind},
+			values:           make(map[string]bool),
+			hashes:           make(map[string]string),
+		}
+		c.limiter.pagination[key] = b
+	}
+	return b
+}
+
+// withinPaginationLocked reports whether a URL about to be queued is one of the sampled pages of
+// its listing, counting it if so: the first PaginationPages page numbers or offsets, or the first
+// CursorPages cursors. URLs without a pagination parameter are always within. c.mu must be held.
+func (c *Crawler) withinPaginationLocked(u string, parsedURL *url.URL, query url.Values) bool {
+	param, kind, ok := pagination(query)
+	if !ok {
+		return true
+	}
+	b := c.paginationBranchLocked(parsedURL, query, param, kind)
+	value := query.Get(param)
+	if b.values[value] {
+		return true
+	}
+	limit := c.limiter.limits.PaginationPages
+	if kind == PaginationCursor {
+		limit = c.limiter.limits.CursorPages
+	}
+	pruned := b.stopped
+	if limit > 0 && !pruned {
+		number, _ := strconv.Atoi(value)
+		// Page numbers past the sample are pruned even before the sample is complete, e.g. the
+		// link to the last of 5000 pages.
+		pruned = len(b.values) >= limit || (kind == PaginationPage && number > limit)
+	}
+	if pruned {
+		if b.Pruned == 0 && !b.stopped {
+			c.logger.Info("Crawler: Sampling the first %d pages of %s (%s pagination with '%s').", limit, b.Listing, kind, param)
+		}
+		b.Pruned++
+		if b.stopped {
+			c.dropLocked(u, LimitPaginationLoop)
+		} else {
+			c.dropLocked(u, LimitPagination)
+		}
+		return false
+	}
+	b.values[value] = true
+	b.Crawled++
+	return true
+}
+
+// repeatsPage reports whether the page at u, a page of a paginated listing, has the same content
+// as another page of the listing: the same body, or the same body once each page's own
+// pagination parameter (e.g. "page=7" in a canonical link) is removed. A repeated page stops the
+// pagination of its listing: its links are those of the earlier page, and further pages would
+// repeat it too.
+func (c *Crawler) repeatsPage(u string, body []byte) bool {
+	parsedURL, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	query := parsedURL.Query()
+	param, kind, ok := pagination(query)
+	if !ok {
+		return false
+	}
+	value := query.Get(param)
+	var hashes []string
+	for _, content := range []string{string(body), strings.ReplaceAll(string(body), param+"="+url.QueryEscape(value), "")} {
+		sum := sha256.Sum256([]byte(content))
+		hashes = append(hashes, hex.EncodeToString(sum[:]))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	b := c.paginationBranchLocked(parsedURL, query, param, kind)
+	previous, seen := "", false
+	for _, hash := range hashes {
+		if v, ok := b.hashes[hash]; ok && v != value {
+			previous, seen = v, true
+		}
+	}
+	if !seen {
+		for _, hash := range hashes {
+			b.hashes[hash] = value
+		}
+		return false
+	}
+	if !b.stopped {
+		c.logger.Info("Crawler: %s repeats the page with %s=%s; stopping its pagination.", u, param, previous)
+	}
+	b.stopped, b.Loop = true, true
+	b.Pruned++
+	c.limiter.dropped[LimitPaginationLoop]++
+	return true
+}
+
+// PrunedPagination returns the paginated listings whose pagination was cut short by the sample
+// size or a repeated page, sorted by listing.
+func (c *Crawler) PrunedPagination() []PaginationBranch {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var branches []PaginationBranch
+	for _, b := range c.limiter.pagination {
+		if b.Pruned > 0 {
+			branches = append(branches, b.PaginationBranch)
+		}
+	}
+	sort.Slice(branches, func(i, j int) bool {
+		if branches[i].Listing != branches[j].Listing {
+			return branches[i].Listing < branches[j].Listing
+		}
+		return branches[i].Param < branches[j].Param
+	})
+	return branches
+}
+
+// nextPageLinks returns the links to the next page of a response: the rel="next" Link header and,
+// for JSON bodies, the next page URLs and cursors of common API listing formats. A cursor is set
+// on currentURL, in its cursor parameter if it has one.
+func nextPageLinks(currentURL string, header http.Header, body []byte) []string {
+	base, err := url.Parse(currentURL)
+	if err != nil {
+		return nil
+	}
+	var links []string
+	add := func(ref string) {
+		if u, err := base.Parse(strings.TrimSpace(ref)); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			links = append(links, u.String())
+		}
+	}
+	for _, link := range header.Values("Link") {
+		for _, m := range linkNextPattern.FindAllStringSubmatch(link, -1) {
+			add(m[1])
+		}
+	}
+	if !strings.Contains(strings.ToLower(header.Get("Content-Type")), "json") {
+		return links
+	}
+	var document map[string]interface{}
+	if json.Unmarshal(body, &document) != nil {
+		return links
+	}
+	objects := []map[string]interface{}{document}
+	for _, name := range paginationEnvelopes {
+		if object, ok := document[name].(map[string]interface{}); ok {
+			objects = append(objects, object)
+		}
+	}
+	for _, object := range objects {
+		for _, key := range nextLinkKeys {
+			if ref, ok := object[key].(string); ok && ref != "" {
+				add(ref)
+			}
+		}
+		for _, k := range nextCursorKeys {
+			cursor, ok := object[k.key].(string)
+			if !ok || cursor == "" {
+				continue
+			}
+			param, query := k.param, base.Query()
+			if name, kind, ok := pagination(query); ok && kind == PaginationCursor {
+				param = name
+			}
+			query.Set(param, cursor)
+			next := *base
+			next.RawQuery = query.Encode()
+			links = append(links, next.String())
+		}
+	}
+	return links
+}
@@ -0,0 +1,113 @@
+package crawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPagination(t *testing.T) {
+	for query, want := range map[string]string{
+		"page=2":           "page",
+		"category=a&pg=3":  "pg",
+		"offset=40":        "offset",
+		"after=eyJpZCI6NH": "after",
+		"p=2":              "",
+		"page=last":        "",
+		"next=/account":    "",
+	} {
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		param, _, ok := pagination(values)
+		assert.Equal(t, want != "", ok, query)
+		assert.Equal(t, want, param, query)
+	}
+}
+
+func TestCrawlPaginationSampling(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><a href="/products?page=1">Products</a><a href="/feed?offset=0">Feed</a></body></html>`))
+	})
+	mux.HandleFunc("/products", func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		fmt.Fprintf(w, `<html><body><a href="/item/%d">A</a><a href="/item/%d">B</a><a href="/products?page=%d">Next</a><a href="/products?page=5000">Last</a></body></html>`, page*10, page*10+1, page+1)
+	})
+	mux.HandleFunc("/feed", func(w http.ResponseWriter, r *http.Request) {
+		// Offsets past the end keep showing the last page.
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		fmt.Fprintf(w, `<html><body><a href="/post/%d">Post</a><a href="/feed?offset=%d">Older</a></body></html>`, min(offset, 10), min(offset, 10)+10)
+	})
+	mux.HandleFunc("/item/", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`<html><body>item</body></html>`)) })
+	mux.HandleFunc("/post/", func(w http.ResponseWriter, r *http.Request) { w.Write([]byte(`<html><body>post</body></html>`)) })
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newTestCrawler(t, server.URL, nil, CrawlModeStatic)
+	c.maxDepth = 0 // Unlimited: the listings must be stopped by pagination.
+	c.SetLimits(CrawlLimits{PaginationPages: 3, InfiniteURLThreshold: -1})
+	crawled := make(map[string]bool)
+	for u := range c.Crawl([]string{server.URL + "/"}, 0) {
+		crawled[strings.TrimPrefix(u, server.URL)] = true
+	}
+
+	for _, page := range []string{"/products?page=1", "/products?page=2", "/products?page=3"} {
+		assert.True(t, crawled[page], page)
+	}
+	assert.False(t, crawled["/products?page=4"])
+	assert.False(t, crawled["/products?page=5000"], "pages past the sample are pruned")
+	for _, item := range []string{"/item/10", "/item/11", "/item/20", "/item/21", "/item/30", "/item/31"} {
+		assert.True(t, crawled[item], "sampled pages yield their links: %s", item)
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	branches := c.PrunedPagination()
+	require.Len(t, branches, 2)
+	assert.Equal(t, PaginationBranch{Listing: host + "/feed", Param: "offset", Kind: PaginationOffset, Crawled: 3, Pruned: 1, Loop: true}, branches[0])
+	assert.Equal(t, host+"/products", branches[1].Listing)
+	assert.Equal(t, 3, branches[1].Crawled)
+	assert.False(t, branches[1].Loop)
+	assert.Equal(t, 1, c.DroppedByLimit()[LimitPaginationLoop], "offset=20 repeats offset=10")
+}
+
+func TestCrawlCursorPagination(t *testing.T) {
+	var requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><a href="/api/items">Items</a></body></html>`))
+	})
+	mux.HandleFunc("/api/items", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		cursor, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Query().Get("cursor"), "c"))
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"items": [{"id": %d}], "next_cursor": "c%d"}`, cursor, cursor+1)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	c := newTestCrawler(t, server.URL, nil, CrawlModeStatic)
+	c.SetLimits(CrawlLimits{CursorPages: 4})
+	for range c.Crawl([]string{server.URL + "/"}, 0) {
+	}
+	assert.Equal(t, 5, requests, "the listing and 4 cursors")
+	require.Len(t, c.PrunedPagination(), 1)
+	assert.Equal(t, PaginationCursor, c.PrunedPagination()[0].Kind)
+}
+
+func TestNextPageLinks(t *testing.T) {
+	header := http.Header{}
+	header.Set("Link", `<https://api.example.com/items?page=3>; rel="next", <https://api.example.com/items?page=9>; rel="last"`)
+	assert.Equal(t, []string{"https://api.example.com/items?page=3"}, nextPageLinks("https://api.example.com/items?page=2", header, nil))
+
+	header = http.Header{}
+	header.Set("Content-Type", "application/json")
+	body := []byte(`{"data": [], "links": {"next": "/items?after=b"}, "meta": {"nextCursor": "b"}}`)
+	assert.Equal(t, []string{"https://api.example.com/items?after=b", "https://api.example.com/items?after=b"}, nextPageLinks("https://api.example.com/items?after=a", header, body))
+}
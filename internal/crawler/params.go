@@ -0,0 +1,76 @@
+package crawler
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// MultipartContentType is the ContentType of requests with a multipart/form-data body.
+const MultipartContentType = "multipart/form-data"
+
+// PathParam is a path segment holding a value rather than a route name, e.g. the 123 of
+// /users/123/orders or the {id} of a /users/{id} route template.
+type PathParam struct {
+	Name  string // Name of the parameter in the route template; "id", "id2", ... for guessed ones.
+	Index int    // Index of the segment in the path, counting from 0 after the leading slash.
+}
+
+// MultipartField is a field of a multipart/form-data body.
+type MultipartField struct {
+	Name   string
+	Value  string // Value of the field; the file name of file fields.
+	IsFile bool   // The field uploads a file; its value is sent as the file name.
+
+	// Content and ContentType are the file of a file field built by a scanner, e.g. an upload
+	// test; crawled fields leave them empty and a placeholder text file is sent.
+	Content     []byte
+	ContentType string
+}
+
+// PathParamsOf returns the identifier segments of a URL path (numbers, UUIDs, hex digests and
+// long tokens, as in PathTemplate) as path parameters named "id", "id2", ...
+func PathParamsOf(path string) []PathParam {
+	var params []PathParam
+	for i, segment := range strings.Split(strings.TrimPrefix(path, "/"), "/") {
+		if !idSegmentRegex.MatchString(segment) {
+			continue
+		}
+		name := "id"
+		if len(params) > 0 {
+			name += strconv.Itoa(len(params) + 1)
+		}
+		params = append(params, PathParam{Name: name, Index: i})
+	}
+	return params
+}
+
+// PathParamsOfTemplate returns the {name} segments of a route template such as /users/{id}.
+func PathParamsOfTemplate(template string) []PathParam {
+	var params []PathParam
+	for i, segment := range strings.Split(strings.TrimPrefix(template, "/"), "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") && len(segment) > 2 {
+			params = append(params, PathParam{Name: segment[1 : len(segment)-1], Index: i})
+		}
+	}
+	return params
+}
+
+// multipartFields returns the fields of a multipart form in document order. File fields are
+// given a file name, as browsers send one for selected files.
+func multipartFields(names []string, values url.Values, fileFields map[string]bool) []MultipartField {
+	fields := make([]MultipartField, 0, len(names))
+	seen := make(map[string]int)
+	for _, name := range names {
+		field := MultipartField{Name: name, IsFile: fileFields[name]}
+		if i := seen[name]; i < len(values[name]) {
+			field.Value = values[name][i]
+		}
+		seen[name]++
+		if field.IsFile && field.Value == "" {
+			field.Value = "dursgo.txt"
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
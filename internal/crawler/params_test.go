@@ -0,0 +1,29 @@
+package crawler
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPathParamsOf(t *testing.T) {
+	assert.Equal(t, []PathParam{{Name: "id", Index: 1}}, PathParamsOf("/users/123/orders"))
+	assert.Equal(t, []PathParam{{Name: "id", Index: 1}, {Name: "id2", Index: 3}}, PathParamsOf("/shops/7/items/d41d8cd98f00b204e9800998ecf8427e"))
+	assert.Empty(t, PathParamsOf("/api/v2/orders"))
+	assert.Empty(t, PathParamsOf("/"))
+
+	assert.Equal(t, []PathParam{{Name: "userId", Index: 2}}, PathParamsOfTemplate("/api/users/{userId}/posts"))
+	assert.Empty(t, PathParamsOfTemplate("/api/{}/posts"))
+}
+
+func TestMultipartFields(t *testing.T) {
+	values := url.Values{"title": {"report"}, "tag": {"a", "b"}}
+	fields := multipartFields([]string{"title", "tag", "tag", "attachment"}, values, map[string]bool{"attachment": true})
+	assert.Equal(t, []MultipartField{
+		{Name: "title", Value: "report"},
+		{Name: "tag", Value: "a"},
+		{Name: "tag", Value: "b"},
+		{Name: "attachment", Value: "dursgo.txt", IsFile: true},
+	}, fields)
+}
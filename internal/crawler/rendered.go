@@ -0,0 +1,137 @@
+package crawler
+
+import (
+	"Dursgo/internal/renderer"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CrawlMode selects how the crawler fetches pages.
+type CrawlMode string
+
+const (
+	// CrawlModeStatic fetches pages with the HTTP client only.
+	CrawlModeStatic CrawlMode = "static"
+	// CrawlModeRendered loads pages in the headless browser, so links and forms built by
+	// JavaScript are found and the XHR/fetch requests of the page become scan targets.
+	CrawlModeRendered CrawlMode = "rendered"
+	// CrawlModeHybrid fetches pages with the HTTP client and also renders the HTML ones,
+	// combining what both find.
+	CrawlModeHybrid CrawlMode = "hybrid"
+)
+
+// renderTimeout bounds the rendering of one page.
+const renderTimeout = 30 * time.Second
+
+// ParseCrawlMode parses a crawl mode name. An empty name is CrawlModeStatic.
+func ParseCrawlMode(name string) (CrawlMode, error) {
+	switch mode := CrawlMode(strings.ToLower(strings.TrimSpace(name))); mode {
+	case "":
+		return CrawlModeStatic, nil
+	case CrawlModeStatic, CrawlModeRendered, CrawlModeHybrid:
+		return mode, nil
+	}
+	return "", fmt.Errorf("unknown crawl mode %q (expected static, rendered or hybrid)", name)
+}
+
+// PageRenderer loads pages in a browser. It is implemented by renderer.Renderer.
+type PageRenderer interface {
+	Render(url string, timeout time.Duration) (renderer.Page, error)
+}
+
+// SetCrawlMode selects the crawl mode. Modes that need a browser fall back to CrawlModeStatic
+// when the crawler has no renderer. It must be called before Start.
+func (c *Crawler) SetCrawlMode(mode CrawlMode) {
+	if mode != CrawlModeStatic && c.renderer == nil {
+		c.logger.Warn("Crawler: No headless browser available, falling back from %s to static crawling.", mode)
+		mode = CrawlModeStatic
+	}
+	c.crawlMode = mode
+}
+
+// CrawlMode returns the crawl mode in use.
+func (c *Crawler) CrawlMode() CrawlMode {
+	return c.crawlMode
+}
+
+// renderPage loads currentURL in the browser and turns the XHR/fetch requests it sent into
+// parameterized requests. It returns the rendered HTML.
+func (c *Crawler) renderPage(currentURL string) (string, error) {
+	c.logger.Debug("Renderer: Using headless browser for %s", currentURL)
+	c.waitPoliteness(currentURL)
+	page, err := c.renderer.Render(currentURL, renderTimeout)
+	if err != nil {
+		return "", err
+	}
+	for _, captured := range page.Requests {
+		if req, ok := c.capturedToParameterized(captured, currentURL); ok {
+			c.logger.Debug("Renderer: Captured %s %s", req.Method, req.URL)
+			c.addParameterizedRequest(req)
+		}
+	}
+	return page.HTML, nil
+}
+
+// capturedToParameterized converts a request sent by a rendered page into a parameterized
+// request. Requests that are out of scope or carry no parameters are skipped.
+func (c *Crawler) capturedToParameterized(captured renderer.CapturedRequest, sourceURL string) (ParameterizedRequest, bool) {
+	if !c.scope.Allows(captured.URL) {
+		return ParameterizedRequest{}, false
+	}
+	parsedURL, err := url.Parse(captured.URL)
+	if err != nil {
+		return ParameterizedRequest{}, false
+	}
+	req := ParameterizedRequest{
+		Method:    strings.ToUpper(captured.Method),
+		URL:       captured.URL,
+		Path:      parsedURL.Path,
+		SourceURL: sourceURL,
+	}
+	if req.Method == "" {
+		req.Method = "GET"
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(captured.ContentType)
+	switch {
+	case req.Method == "GET" || captured.Body == "":
+		req.ParamNames = getKeys(parsedURL.Query())
+		req.ParamLocations = []string{"query"}
+	case strings.Contains(mediaType, "json"):
+		if query, ok := GraphQLQueryOf(captured.Body); ok {
+			// Kept even without variables, so the GraphQL finder sees the endpoint.
+			var envelope graphQLEnvelope
+			json.Unmarshal([]byte(captured.Body), &envelope)
+			gqlReq, err := NewGraphQLRequest(captured.URL, query, envelope.Variables)
+			gqlReq.SourceURL = sourceURL
+			return gqlReq, err == nil
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal([]byte(captured.Body), &body); err != nil {
+			return ParameterizedRequest{}, false
+		}
+		for key := range body {
+			req.ParamNames = append(req.ParamNames, key)
+		}
+		sort.Strings(req.ParamNames)
+		req.ParamLocations = []string{"json"}
+		req.ContentType = captured.ContentType
+		req.RawBody = captured.Body
+	case mediaType == "application/x-www-form-urlencoded" || mediaType == "":
+		values, err := url.ParseQuery(captured.Body)
+		if err != nil {
+			return ParameterizedRequest{}, false
+		}
+		req.ParamNames = getKeys(values)
+		req.ParamLocations = []string{"body"}
+		req.FormPostData = captured.Body
+	default:
+		return ParameterizedRequest{}, false // Multipart and other bodies cannot be replayed.
+	}
+	return req, len(req.ParamNames) > 0
+}
@@ -0,0 +1,135 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/renderer"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRenderer serves fixed pages and records the URLs it rendered.
+type fakeRenderer struct {
+	mu       sync.Mutex
+	pages    map[string]renderer.Page
+	rendered []string
+}
+
+func (r *fakeRenderer) Render(url string, _ time.Duration) (renderer.Page, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rendered = append(r.rendered, url)
+	return r.pages[url], nil
+}
+
+func newTestCrawler(t *testing.T, serverURL string, rend PageRenderer, mode CrawlMode) *Crawler {
+	log := logger.NewLogger(logger.ERROR)
+	c, err := NewCrawler(httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: serverURL}), log, serverURL, 2, 3, nil)
+	require.NoError(t, err)
+	c.renderer = rend
+	c.SetCrawlMode(mode)
+	return c
+}
+
+func findRequest(requests []ParameterizedRequest, method, path string) (ParameterizedRequest, bool) {
+	for _, req := range requests {
+		if req.Method == method && req.Path == path {
+			return req, true
+		}
+	}
+	return ParameterizedRequest{}, false
+}
+
+func TestParseCrawlMode(t *testing.T) {
+	for name, want := range map[string]CrawlMode{"": CrawlModeStatic, "static": CrawlModeStatic, " Rendered ": CrawlModeRendered, "hybrid": CrawlModeHybrid} {
+		mode, err := ParseCrawlMode(name)
+		require.NoError(t, err)
+		assert.Equal(t, want, mode)
+	}
+	_, err := ParseCrawlMode("browser")
+	assert.Error(t, err)
+}
+
+func TestHybridCrawl(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body><div id="app"></div><a href="/about">About</a><script src="/app.js"></script></body></html>`))
+		case "/about":
+			w.Write([]byte(`<html><body>No scripts here.</body></html>`))
+		case "/app.js":
+			w.Header().Set("Content-Type", "application/javascript")
+			w.Write([]byte(`mount("#app")`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	rend := &fakeRenderer{pages: map[string]renderer.Page{
+		server.URL + "/": {
+			HTML: `<html><body><a href="/products">Products</a><form method="post" action="/login"><input name="user"></form></body></html>`,
+			Requests: []renderer.CapturedRequest{
+				{Method: "POST", URL: server.URL + "/api/search", ContentType: "application/json", Body: `{"query":"shoes","filters":{"size":42}}`},
+				{Method: "GET", URL: server.URL + "/api/items?id=3&sort=asc"},
+				{Method: "GET", URL: server.URL + "/api/config"},
+				{Method: "POST", URL: server.URL + "/graphql", ContentType: "application/json", Body: `{"query":"query Me($id: ID!) { user(id: $id) { name } }","variables":{"id":"7"}}`},
+				{Method: "POST", URL: server.URL + "/api/upload", ContentType: "multipart/form-data; boundary=x", Body: "--x--"},
+				{Method: "GET", URL: "https://analytics.example.com/collect?event=view"},
+			},
+		},
+	}}
+	c := newTestCrawler(t, server.URL, rend, CrawlModeHybrid)
+	assert.Equal(t, CrawlModeHybrid, c.CrawlMode())
+
+	var discovered []string
+	for u := range c.Crawl([]string{server.URL + "/"}, 0) {
+		discovered = append(discovered, u)
+	}
+	assert.Contains(t, discovered, server.URL+"/about", "link from the static HTML")
+	assert.Contains(t, discovered, server.URL+"/products", "link from the rendered DOM")
+
+	// Only the page with scripts is rendered.
+	assert.Equal(t, []string{server.URL + "/"}, rend.rendered)
+
+	requests := c.GetParameterizedRequestsForScanning()
+
+	search, ok := findRequest(requests, "POST", "/api/search")
+	require.True(t, ok)
+	assert.Equal(t, []string{"filters", "query"}, search.ParamNames)
+	assert.True(t, search.IsJSON())
+	assert.Equal(t, `{"query":"shoes","filters":{"size":42}}`, search.RawBody)
+	assert.Equal(t, server.URL+"/", search.SourceURL)
+
+	items, ok := findRequest(requests, "GET", "/api/items")
+	require.True(t, ok)
+	assert.ElementsMatch(t, []string{"id", "sort"}, items.ParamNames)
+
+	gql, ok := findRequest(requests, "POST", "/graphql")
+	require.True(t, ok)
+	assert.True(t, gql.IsGraphQL())
+	assert.Equal(t, []string{"variables.id"}, gql.ParamNames)
+
+	_, ok = findRequest(requests, "POST", "/login")
+	assert.True(t, ok, "form from the rendered DOM")
+	_, ok = findRequest(requests, "GET", "/api/config")
+	assert.False(t, ok, "no parameters")
+	_, ok = findRequest(requests, "POST", "/api/upload")
+	assert.False(t, ok, "multipart bodies are not replayed")
+	for _, req := range requests {
+		assert.True(t, strings.HasPrefix(req.URL, server.URL), "out of scope: %s", req.URL)
+	}
+}
+
+func TestSetCrawlModeWithoutRenderer(t *testing.T) {
+	c := newTestCrawler(t, "http://example.com", nil, CrawlModeRendered)
+	assert.Equal(t, CrawlModeStatic, c.CrawlMode())
+}
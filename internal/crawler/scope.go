@@ -0,0 +1,217 @@
+package crawler
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// SubdomainPolicy selects which hosts besides the target's are in scope.
+type SubdomainPolicy string
+
+const (
+	// SubdomainSameHost keeps the crawl on the target's host (and port).
+	SubdomainSameHost SubdomainPolicy = "same-host"
+	// SubdomainSameDomain allows every host of the target's registrable domain, e.g.
+	// api.example.com and www.example.com for a target on example.com.
+	SubdomainSameDomain SubdomainPolicy = "same-domain"
+	// SubdomainAllowlist allows the target's host and the hosts listed in ScopeOptions.AllowedHosts.
+	SubdomainAllowlist SubdomainPolicy = "allowlist"
+)
+
+const (
+	// reasonInvalidURL is returned by Scope.check for URLs that are not absolute HTTP URLs.
+	reasonInvalidURL = "invalid URL"
+	// reasonNoIncludeMatch is returned by Scope.check for URLs no include pattern matches.
+	reasonNoIncludeMatch = "no include pattern matched"
+)
+
+// ScopeOptions configures a Scope.
+type ScopeOptions struct {
+	// IncludePatterns are regexes on the full URL; when set, a URL must match one of them.
+	IncludePatterns []string
+	// ExcludePatterns are regexes on the full URL; a URL matching any of them is out of scope.
+	ExcludePatterns []string
+	// SubdomainPolicy selects the allowed hosts. Empty means SubdomainSameHost.
+	SubdomainPolicy SubdomainPolicy
+	// AllowedHosts lists the extra hosts of SubdomainAllowlist. "*.example.com" allows every
+	// subdomain of example.com.
+	AllowedHosts []string
+}
+
+// Scope decides which URLs the crawler follows and the scanners send requests to. It counts the
+// URLs it excluded, by reason, for the scan summary. It is safe for concurrent use.
+type Scope struct {
+	targetHost   string
+	baseDomain   string
+	policy       SubdomainPolicy
+	allowedHosts []string
+	include      []*regexp.Regexp
+	exclude      []*regexp.Regexp
+
+	mu       sync.Mutex
+	excluded map[string]string // Excluded URL -> reason.
+}
+
+// NewScope creates the scope of a scan of targetURL. It returns an error if a pattern does not
+// compile or the subdomain policy is unknown.
+func NewScope(targetURL string, opts ScopeOptions) (*Scope, error) {
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scope{
+		targetHost: strings.ToLower(parsedURL.Host),
+		policy:     opts.SubdomainPolicy,
+		excluded:   make(map[string]string),
+	}
+	s.baseDomain, err = publicsuffix.EffectiveTLDPlusOne(strings.ToLower(parsedURL.Hostname()))
+	if err != nil {
+		s.baseDomain = strings.ToLower(parsedURL.Hostname()) // e.g. localhost or an IP address.
+	}
+	switch s.policy {
+	case "":
+		s.policy = SubdomainSameHost
+	case SubdomainSameHost, SubdomainSameDomain, SubdomainAllowlist:
+	default:
+		return nil, fmt.Errorf("unknown subdomain policy %q (expected same-host, same-domain or allowlist)", opts.SubdomainPolicy)
+	}
+	for _, host := range opts.AllowedHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			s.allowedHosts = append(s.allowedHosts, host)
+		}
+	}
+
+	var errs []error
+	s.include, errs = compilePatterns(opts.IncludePatterns, "include", errs)
+	s.exclude, errs = compilePatterns(opts.ExcludePatterns, "exclude", errs)
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return s, nil
+}
+
+func compilePatterns(patterns []string, kind string, errs []error) ([]*regexp.Regexp, []error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s pattern %q: %w", kind, pattern, err))
+			continue
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, errs
+}
+
+// Allows reports whether rawURL is in scope and records it as excluded otherwise. Links that are
+// not HTTP URLs (mailto:, javascript:, ...) are never in scope and not recorded.
+func (s *Scope) Allows(rawURL string) bool {
+	reason := s.check(rawURL)
+	if reason == "" {
+		return true
+	}
+	if reason == reasonInvalidURL {
+		return false
+	}
+	s.mu.Lock()
+	if _, seen := s.excluded[rawURL]; !seen {
+		s.excluded[rawURL] = reason
+	}
+	s.mu.Unlock()
+	return false
+}
+
+// allowsEntryPoint reports whether rawURL may start a crawl. Entry points only need to pass the
+// host and exclude checks, so that pages matching the include patterns can be reached from them.
+func (s *Scope) allowsEntryPoint(rawURL string) bool {
+	switch s.check(rawURL) {
+	case "", reasonNoIncludeMatch:
+		return true
+	}
+	return s.Allows(rawURL) // Records the exclusion.
+}
+
+// check returns why rawURL is out of scope, or "" if it is in scope.
+func (s *Scope) check(rawURL string) string {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") {
+		return reasonInvalidURL
+	}
+	if !s.allowsHost(strings.ToLower(parsedURL.Host), strings.ToLower(parsedURL.Hostname())) {
+		return "host"
+	}
+	for _, re := range s.exclude {
+		if re.MatchString(rawURL) {
+			return "exclude: " + re.String()
+		}
+	}
+	if len(s.include) == 0 {
+		return ""
+	}
+	for _, re := range s.include {
+		if re.MatchString(rawURL) {
+			return ""
+		}
+	}
+	return reasonNoIncludeMatch
+}
+
+// allowsHost applies the subdomain policy. host includes the port, hostname does not.
+func (s *Scope) allowsHost(host, hostname string) bool {
+	if host == s.targetHost {
+		return true
+	}
+	switch s.policy {
+	case SubdomainSameDomain:
+		return hostname == s.baseDomain || strings.HasSuffix(hostname, "."+s.baseDomain)
+	case SubdomainAllowlist:
+		for _, allowed := range s.allowedHosts {
+			if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+				if strings.HasSuffix(hostname, "."+suffix) {
+					return true
+				}
+			} else if allowed == host || allowed == hostname {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExcludedCount returns the number of distinct URLs excluded so far.
+func (s *Scope) ExcludedCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.excluded)
+}
+
+// ExcludedByReason returns the number of distinct excluded URLs per reason: "host",
+// "exclude: <pattern>" or "no include pattern matched".
+func (s *Scope) ExcludedByReason() map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[string]int)
+	for _, reason := range s.excluded {
+		counts[reason]++
+	}
+	return counts
+}
+
+// ExcludedURLs returns the distinct excluded URLs, sorted.
+func (s *Scope) ExcludedURLs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	urls := make([]string, 0, len(s.excluded))
+	for u := range s.excluded {
+		urls = append(urls, u)
+	}
+	sort.Strings(urls)
+	return urls
+}
@@ -0,0 +1,104 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScopeSubdomainPolicies(t *testing.T) {
+	tests := []struct {
+		policy  SubdomainPolicy
+		allowed []string
+		url     string
+		want    bool
+	}{
+		{"", nil, "https://www.example.com/a", true},
+		{"", nil, "http://www.example.com/a", true},
+		{"", nil, "https://api.example.com/a", false},
+		{"", nil, "https://www.example.com.evil.net/a", false},
+		{"", nil, "https://www.example.com:8443/a", false},
+		{SubdomainSameDomain, nil, "https://api.example.com/a", true},
+		{SubdomainSameDomain, nil, "https://example.com/a", true},
+		{SubdomainSameDomain, nil, "https://notexample.com/a", false},
+		{SubdomainAllowlist, []string{"api.example.com", "*.cdn.example.com"}, "https://api.example.com/a", true},
+		{SubdomainAllowlist, []string{"api.example.com", "*.cdn.example.com"}, "https://eu.cdn.example.com/a", true},
+		{SubdomainAllowlist, []string{"api.example.com", "*.cdn.example.com"}, "https://cdn.example.com/a", false},
+		{SubdomainAllowlist, []string{"api.example.com", "*.cdn.example.com"}, "https://blog.example.com/a", false},
+	}
+	for _, tt := range tests {
+		scope, err := NewScope("https://www.example.com", ScopeOptions{SubdomainPolicy: tt.policy, AllowedHosts: tt.allowed})
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, scope.Allows(tt.url), "%s with policy %q", tt.url, tt.policy)
+	}
+}
+
+func TestScopePatterns(t *testing.T) {
+	scope, err := NewScope("https://shop.example.com", ScopeOptions{
+		IncludePatterns: []string{`/api/`},
+		ExcludePatterns: []string{`/logout`, `/admin/delete`},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, scope.Allows("https://shop.example.com/api/items?id=1"))
+	assert.False(t, scope.Allows("https://shop.example.com/api/admin/delete?id=1"))
+	assert.False(t, scope.Allows("https://shop.example.com/cart"))
+	assert.False(t, scope.Allows("https://shop.example.com/logout"))
+	assert.False(t, scope.Allows("https://other.com/api/items"))
+	assert.False(t, scope.Allows("https://other.com/api/items"))
+	assert.False(t, scope.Allows("mailto:sales@example.com"))
+
+	assert.Equal(t, 4, scope.ExcludedCount())
+	assert.Equal(t, map[string]int{
+		"exclude: /admin/delete":     1,
+		"no include pattern matched": 1,
+		"exclude: /logout":           1,
+		"host":                       1,
+	}, scope.ExcludedByReason())
+	assert.Contains(t, scope.ExcludedURLs(), "https://other.com/api/items")
+
+	assert.True(t, scope.allowsEntryPoint("https://shop.example.com/"))
+	assert.False(t, scope.allowsEntryPoint("https://shop.example.com/logout"))
+}
+
+func TestNewScopeErrors(t *testing.T) {
+	_, err := NewScope("https://example.com", ScopeOptions{IncludePatterns: []string{"("}, ExcludePatterns: []string{"[a"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `invalid include pattern "("`)
+	assert.Contains(t, err.Error(), `invalid exclude pattern "[a"`)
+
+	_, err = NewScope("https://example.com", ScopeOptions{SubdomainPolicy: "any"})
+	assert.Error(t, err)
+}
+
+func TestCrawlRespectsScope(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>
+			<a href="/api/items?id=1">Items</a>
+			<a href="/api/admin/delete?id=1">Delete</a>
+			<a href="/blog?page=2">Blog</a>
+			<a href="/logout">Logout</a>
+			<a href="https://third-party.example.net/track?u=1">Tracker</a>
+		</body></html>`))
+	}))
+	defer server.Close()
+
+	c := newTestCrawler(t, server.URL, nil, CrawlModeStatic)
+	scope, err := NewScope(server.URL, ScopeOptions{IncludePatterns: []string{`/api/`}, ExcludePatterns: []string{`/delete`}})
+	require.NoError(t, err)
+	c.SetScope(scope)
+
+	var discovered []string
+	for u := range c.Crawl([]string{server.URL + "/"}, 0) {
+		discovered = append(discovered, u)
+	}
+	assert.ElementsMatch(t, []string{server.URL + "/", server.URL + "/api/items?id=1"}, discovered)
+	for _, req := range c.GetParameterizedRequestsForScanning() {
+		assert.Contains(t, req.URL, "/api/items")
+	}
+	assert.Contains(t, scope.ExcludedURLs(), server.URL+"/api/admin/delete?id=1")
+	assert.Contains(t, scope.ExcludedURLs(), "https://third-party.example.net/track?u=1")
+}
@@ -0,0 +1,159 @@
+package crawler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// maxSitemaps bounds the sitemap files fetched, including nested sitemap indexes.
+	maxSitemaps = 50
+	// maxSitemapURLs bounds the URLs queued from sitemaps.
+	maxSitemapURLs = 5000
+	// maxSitemapBytes bounds the (uncompressed) size of a sitemap file; the protocol allows 50 MB.
+	maxSitemapBytes = 50 << 20
+)
+
+// parseRobotsTxt returns the paths of the Allow and Disallow rules and the Sitemap URLs of a
+// robots.txt file. Wildcard rules are cut at the first wildcard; rules that reduce to "/" are
+// dropped.
+func parseRobotsTxt(body []byte) (paths, sitemaps []string) {
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		field, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(field)) {
+		case "allow", "disallow":
+			if i := strings.IndexAny(value, "*$"); i >= 0 {
+				value = value[:i]
+			}
+			if strings.HasPrefix(value, "/") && value != "/" && !seen[value] {
+				seen[value] = true
+				paths = append(paths, value)
+			}
+		case "sitemap":
+			if value != "" {
+				sitemaps = append(sitemaps, value)
+			}
+		}
+	}
+	return paths, sitemaps
+}
+
+// parseSitemap returns the page URLs of a sitemap, or the sitemap URLs of a sitemap index. body
+// may be gzip-compressed.
+func parseSitemap(body []byte) (pages, sitemaps []string, err error) {
+	if len(body) > 2 && body[0] == 0x1f && body[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, nil, err
+		}
+		defer reader.Close()
+		if body, err = io.ReadAll(io.LimitReader(reader, maxSitemapBytes)); err != nil {
+			return nil, nil, err
+		}
+	}
+	var index SitemapIndex
+	if xml.Unmarshal(body, &index) == nil {
+		for _, sitemap := range index.Sitemaps {
+			sitemaps = append(sitemaps, strings.TrimSpace(sitemap.Loc))
+		}
+		return nil, sitemaps, nil
+	}
+	var set URLSet
+	if err := xml.Unmarshal(body, &set); err != nil {
+		return nil, nil, err
+	}
+	for _, u := range set.URLs {
+		pages = append(pages, strings.TrimSpace(u.Loc))
+	}
+	return pages, nil, nil
+}
+
+// fetchAndParseRobotsTxt queues the paths listed in the target's robots.txt, allowed and
+// disallowed alike: disallowed paths often lead to admin areas and are worth scanning. It returns
+// the sitemaps robots.txt points to.
+func (c *Crawler) fetchAndParseRobotsTxt(depth int) []string {
+	robotsURL := c.targetDomain + "/robots.txt"
+	body, ok := c.fetchBootstrapFile(robotsURL)
+	if !ok {
+		return nil
+	}
+	paths, sitemaps := parseRobotsTxt(body)
+	c.logger.Info("Robots.txt: Found %d path(s) and %d sitemap(s) in %s", len(paths), len(sitemaps), robotsURL)
+	for _, path := range paths {
+		if resolvedURL := c.resolveURL(robotsURL, path); resolvedURL != "" {
+			c.addToQueue(resolvedURL, depth)
+		}
+	}
+	return sitemaps
+}
+
+// fetchAndParseSitemaps queues the pages listed in the target's /sitemap.xml and in the sitemaps
+// named by robots.txt, following sitemap indexes.
+func (c *Crawler) fetchAndParseSitemaps(robotsSitemaps []string, depth int) {
+	pending := append([]string{c.targetDomain + "/sitemap.xml"}, robotsSitemaps...)
+	fetched := make(map[string]bool)
+	queued := 0
+	for len(pending) > 0 && len(fetched) < maxSitemaps && queued < maxSitemapURLs {
+		sitemapURL := pending[0]
+		pending = pending[1:]
+		if fetched[sitemapURL] || !c.scope.Allows(sitemapURL) {
+			continue
+		}
+		fetched[sitemapURL] = true
+		body, ok := c.fetchBootstrapFile(sitemapURL)
+		if !ok {
+			continue
+		}
+		pages, nested, err := parseSitemap(body)
+		if err != nil {
+			c.logger.Debug("Sitemap: Could not parse %s: %v", sitemapURL, err)
+			continue
+		}
+		pending = append(pending, nested...)
+		if len(pages) > 0 {
+			c.logger.Info("Sitemap: Found %d URL(s) in %s", len(pages), sitemapURL)
+		}
+		for _, page := range pages {
+			if queued >= maxSitemapURLs {
+				c.logger.Warn("Sitemap: Stopping after %d URLs.", maxSitemapURLs)
+				break
+			}
+			c.addToQueue(page, depth)
+			queued++
+		}
+	}
+}
+
+// fetchBootstrapFile fetches robots.txt, a sitemap or an API specification. It returns false for
+// errors and non-200 responses.
+func (c *Crawler) fetchBootstrapFile(fileURL string) ([]byte, bool) {
+	c.waitPoliteness(fileURL)
+	resp, err := c.httpClient.Get(fileURL)
+	if err != nil {
+		return nil, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, false
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxSitemapBytes))
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
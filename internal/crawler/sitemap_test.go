@@ -0,0 +1,114 @@
+package crawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func gzipBytes(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	_, err := w.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestParseRobotsTxt(t *testing.T) {
+	paths, sitemaps := parseRobotsTxt([]byte(`# robots
+User-agent: *
+Disallow: /admin/   # staff only
+Disallow: /
+Allow: /public
+disallow: /*.php$
+Disallow: /tmp/*/cache
+Disallow: /admin/
+Sitemap: https://example.com/sitemap_index.xml
+`))
+	assert.Equal(t, []string{"/admin/", "/public", "/tmp/"}, paths)
+	assert.Equal(t, []string{"https://example.com/sitemap_index.xml"}, sitemaps)
+}
+
+func TestParseSitemap(t *testing.T) {
+	pages, nested, err := parseSitemap(gzipBytes(t, `<?xml version="1.0"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url><loc> https://example.com/a </loc></url>
+  <url><loc>https://example.com/b?id=2</loc></url>
+</urlset>`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"https://example.com/a", "https://example.com/b?id=2"}, pages)
+	assert.Empty(t, nested)
+
+	pages, nested, err = parseSitemap([]byte(`<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap><loc>https://example.com/posts.xml.gz</loc></sitemap>
+</sitemapindex>`))
+	require.NoError(t, err)
+	assert.Empty(t, pages)
+	assert.Equal(t, []string{"https://example.com/posts.xml.gz"}, nested)
+
+	_, _, err = parseSitemap([]byte("<html></html>"))
+	assert.Error(t, err)
+}
+
+func TestCrawlBootstrap(t *testing.T) {
+	var serverURL string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /backup/\nSitemap: " + serverURL + "/sitemap_index.xml\n"))
+	})
+	mux.HandleFunc("/sitemap_index.xml", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<sitemapindex><sitemap><loc>` + serverURL + `/posts.xml.gz</loc></sitemap></sitemapindex>`))
+	})
+	mux.HandleFunc("/posts.xml.gz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/gzip")
+		w.Write(gzipBytes(t, `<urlset><url><loc>`+serverURL+`/posts/hello</loc></url></urlset>`))
+	})
+	mux.HandleFunc("/v3/api-docs", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"openapi": "3.0.0", "paths": {"/api/orders": {"get": {"parameters": [{"name": "status", "in": "query", "schema": {"type": "string"}}]}}}}`))
+	})
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" && r.URL.Path != "/backup/" && r.URL.Path != "/posts/hello" && r.URL.Path != "/api/orders" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`<html><body>page</body></html>`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	serverURL = server.URL
+
+	c := newTestCrawler(t, server.URL, nil, CrawlModeStatic)
+	var discovered []string
+	for u := range c.Crawl([]string{server.URL + "/"}, 0) {
+		discovered = append(discovered, u)
+	}
+	assert.Contains(t, discovered, server.URL+"/backup/")
+	assert.Contains(t, discovered, server.URL+"/posts/hello")
+	assert.Contains(t, discovered, server.URL+"/api/orders?status=test")
+
+	orders, ok := findRequest(c.GetParameterizedRequestsForScanning(), "GET", "/api/orders")
+	require.True(t, ok)
+	assert.Equal(t, []string{"status"}, orders.ParamNames)
+}
+
+func TestImportAPISpec(t *testing.T) {
+	c := newTestCrawler(t, "https://shop.example.com", nil, CrawlModeStatic)
+	operations, err := c.ImportAPISpec([]byte(swagger2Spec))
+	require.NoError(t, err)
+	assert.Zero(t, operations, "spec host api.example.com is out of scope")
+
+	operations, err = c.ImportAPISpec([]byte(openAPI3Spec))
+	require.NoError(t, err)
+	assert.Equal(t, 4, operations)
+	assert.Len(t, c.GetParameterizedRequestsForScanning(), 3)
+	assert.ElementsMatch(t, []string{
+		"https://shop.example.com/api/v1/health",
+		"https://shop.example.com/api/v1/users/1?fields=name",
+	}, c.GetDiscoveredURLs())
+}
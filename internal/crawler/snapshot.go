@@ -0,0 +1,62 @@
+package crawler
+
+import (
+	"net/url"
+	"sort"
+)
+
+// CrawlSnapshot is the progress of a crawl: enough to resume it after an interruption.
+type CrawlSnapshot struct {
+	Visited   map[string]int         `json:"visited"`             // URLs queued or crawled, with their depth.
+	Frontier  []CrawlJob             `json:"frontier,omitempty"`  // Queued URLs not crawled yet.
+	Requests  []ParameterizedRequest `json:"requests,omitempty"`  // Parameterized requests found so far.
+	Responses []CrawledResponse      `json:"responses,omitempty"` // Responses retained for passive scanners.
+}
+
+// Snapshot returns the progress of the crawl. It may be called while crawling.
+func (c *Crawler) Snapshot() CrawlSnapshot {
+	requests := c.GetParameterizedRequestsForScanning()
+	responses := c.GetCrawledResponses()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	snapshot := CrawlSnapshot{
+		Visited:   make(map[string]int, len(c.urlDepths)),
+		Requests:  requests,
+		Responses: responses,
+	}
+	for u, depth := range c.urlDepths {
+		snapshot.Visited[u] = depth
+	}
+	for u, depth := range c.pending {
+		snapshot.Frontier = append(snapshot.Frontier, CrawlJob{URL: u, Depth: depth})
+	}
+	sort.Slice(snapshot.Frontier, func(i, j int) bool { return snapshot.Frontier[i].URL < snapshot.Frontier[j].URL })
+	return snapshot
+}
+
+// Restore loads the progress of an interrupted crawl. Visited URLs are not crawled again and the
+// next Crawl continues with the frontier of the snapshot. It must be called after SetScope and
+// SetLimits and before Crawl.
+func (c *Crawler) Restore(snapshot CrawlSnapshot) {
+	for u, depth := range snapshot.Visited {
+		c.markAsVisited(u, depth)
+		// Visited pages count against the page limit of their host, as in the original run.
+		// So do the sampled pages of paginated listings.
+		if parsedURL, err := url.Parse(u); err == nil {
+			c.mu.Lock()
+			c.limiter.pagesPerHost[parsedURL.Host]++
+			c.withinPaginationLocked(u, parsedURL, parsedURL.Query())
+			c.mu.Unlock()
+		}
+	}
+	for _, req := range snapshot.Requests {
+		c.addParameterizedRequest(req)
+	}
+	c.mu.Lock()
+	for _, resp := range snapshot.Responses {
+		c.responses[resp.URL] = resp
+	}
+	c.mu.Unlock()
+	c.resumeFrontier = append([]CrawlJob(nil), snapshot.Frontier...)
+}
@@ -0,0 +1,65 @@
+package crawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreResumesFromFrontier(t *testing.T) {
+	var mu sync.Mutex
+	var fetched []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		fetched = append(fetched, r.URL.Path)
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body><a href="/a">A</a><a href="/b">B</a></body></html>`))
+		case "/a", "/c":
+			w.Write([]byte(`<html><body>Leaf</body></html>`))
+		case "/b":
+			w.Write([]byte(`<html><body><a href="/c">C</a><form action="/search"><input name="q"></form></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := newTestCrawler(t, server.URL, nil, CrawlModeStatic)
+	c.Restore(CrawlSnapshot{
+		Visited:  map[string]int{server.URL + "/": 0, server.URL + "/a": 1, server.URL + "/b": 1},
+		Frontier: []CrawlJob{{URL: server.URL + "/b", Depth: 1}},
+		Requests: []ParameterizedRequest{{Method: "POST", URL: server.URL + "/login", Path: "/login", ParamNames: []string{"user"}, ParamLocations: []string{"body"}}},
+	})
+	for range c.Crawl([]string{server.URL + "/"}, 0) {
+	}
+
+	mu.Lock()
+	pages := map[string]bool{}
+	for _, path := range fetched {
+		pages[path] = true
+	}
+	mu.Unlock()
+	assert.True(t, pages["/b"])
+	assert.True(t, pages["/c"])
+	assert.False(t, pages["/"], "visited entry point crawled again")
+	assert.False(t, pages["/a"], "visited page crawled again")
+
+	snapshot := c.Snapshot()
+	assert.Empty(t, snapshot.Frontier)
+	visited := make([]string, 0, len(snapshot.Visited))
+	for u := range snapshot.Visited {
+		visited = append(visited, u)
+	}
+	sort.Strings(visited)
+	assert.Equal(t, []string{server.URL + "/", server.URL + "/a", server.URL + "/b", server.URL + "/c"}, visited)
+	_, restored := findRequest(snapshot.Requests, "POST", "/login")
+	assert.True(t, restored)
+	_, found := findRequest(snapshot.Requests, "GET", "/search")
+	assert.True(t, found)
+}
@@ -0,0 +1,276 @@
+package discovery
+
+import (
+	"Dursgo/internal/fingerprint"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"Dursgo/internal/soft404"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxBaselineBodyBytes bounds how much of a response is kept for soft-404 comparison.
+const maxBaselineBodyBytes = 64 * 1024
+
+// probeResponse is the part of a probe's response used to tell real content from a soft-404.
+type probeResponse struct {
+	status   int
+	location string // Location header with the requested path replaced by a placeholder.
+	body     string // Body with the requested path replaced by a placeholder.
+}
+
+// probeJob is a single wordlist entry to probe under a directory.
+type probeJob struct {
+	dir  *url.URL
+	word string
+}
+
+// ContentDiscoverer brute-forces common paths under discovered directories to find content
+// that is not linked from any crawled page.
+type ContentDiscoverer struct {
+	client      *httpclient.Client // HTTP client for making requests.
+	log         *logger.Logger     // Logger for outputting messages.
+	concurrency int                // Number of concurrent probe workers.
+	cmp         compare.Comparator // Compares probe responses with the soft-404 baseline.
+	soft404     *soft404.Detector  // Soft-404 page of each host, shared with the scanners.
+	// baselines are the soft-404 baselines of the directories; they also cap the probes per host.
+	baselines *soft404.Baselines[*probeResponse]
+}
+
+// NewContentDiscoverer creates a new instance of ContentDiscoverer.
+func NewContentDiscoverer(client *httpclient.Client, log *logger.Logger, concurrency, maxProbesPerHost int) *ContentDiscoverer {
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+	cmp := compare.New(scanner.ScannerOptions{}, log, "Content Discovery")
+	// Word sets are cheap on large pages and ignore the layout shifts a reflected path causes.
+	cmp.Mode = compare.Words
+	return &ContentDiscoverer{
+		client:      client,
+		log:         log,
+		concurrency: concurrency,
+		cmp:         cmp,
+		baselines:   soft404.NewBaselines[*probeResponse](log, "Content Discovery", maxProbesPerHost),
+	}
+}
+
+// SetSoft404 makes the discoverer also drop the probes answered with the soft-404 page of their
+// host, as fingerprinted by soft, on top of the baseline of each directory.
+func (d *ContentDiscoverer) SetSoft404(soft *soft404.Detector) {
+	d.soft404 = soft
+}
+
+// Discover probes the wordlist, plus the base names combined with the extensions of the detected
+// technologies, under every directory of the given URLs. It returns the URLs found, sorted.
+func (d *ContentDiscoverer) Discover(ctx context.Context, urls []string, fp fingerprint.Fingerprint) []string {
+	client := d.client.WithContext(ctx)
+	dirs := directoriesOf(urls)
+	words := wordlistFor(fp)
+	d.log.Info("Starting content discovery: %d paths under %d directories...", len(words), len(dirs))
+
+	// Redirects are kept as answers: "/admin" redirecting to "/admin/" is a hit, a redirect to
+	// the login or home page is compared with the baseline instead.
+	client = client.WithoutRedirects()
+
+	jobs := make(chan probeJob)
+	var (
+		found   []string
+		foundMu sync.Mutex
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < d.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if target, ok := d.probe(ctx, client, job); ok {
+					foundMu.Lock()
+					found = append(found, target)
+					foundMu.Unlock()
+				}
+			}
+		}()
+	}
+
+FeedLoop:
+	for _, dir := range dirs {
+		for _, word := range words {
+			select {
+			case jobs <- probeJob{dir: dir, word: word}:
+			case <-ctx.Done():
+				break FeedLoop
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.Strings(found)
+	d.log.Info("Content discovery finished: %d new paths found.", len(found))
+	return found
+}
+
+// probe requests one wordlist entry and reports whether it exists, i.e. it answered with a
+// status other than "not found" that differs from the soft-404 baseline of its directory.
+func (d *ContentDiscoverer) probe(ctx context.Context, client *httpclient.Client, job probeJob) (string, bool) {
+	target := job.dir.ResolveReference(&url.URL{Path: job.word}).String()
+	ext := extensionOf(job.word)
+
+	base := d.baselines.For(job.dir, ext, func(target string) (*probeResponse, error) {
+		return fetch(ctx, client, target)
+	})
+	if base == nil {
+		return "", false
+	}
+	if !d.baselines.Take(job.dir.Host) {
+		return "", false
+	}
+	resp, err := fetch(ctx, client, target)
+	if err != nil {
+		if !errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			d.log.Debug("Content Discovery: Probe of %s failed: %v", target, err)
+		}
+		return "", false
+	}
+	if !isExistingStatus(resp.status) || d.isSoft404(resp, base) || d.soft404.Match(ctx, target, resp.status, resp.location, resp.body) {
+		return "", false
+	}
+	d.log.Success("Content Discovery: Found %s (status %d)", target, resp.status)
+	return target, true
+}
+
+// isSoft404 reports whether resp looks like the response to a path that does not exist.
+func (d *ContentDiscoverer) isSoft404(resp, base *probeResponse) bool {
+	if resp.status != base.status {
+		return false
+	}
+	if resp.status >= 300 && resp.status < 400 {
+		return resp.location == base.location
+	}
+	return !d.cmp.IsDifferent(base.body, resp.body)
+}
+
+// fetch requests target and returns its status, Location header and body, with the requested
+// path replaced by a placeholder so that pages reflecting it compare equal.
+func fetch(ctx context.Context, client *httpclient.Client, target string) (*probeResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBaselineBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	replacer := strings.NewReplacer(req.URL.EscapedPath(), "{path}", req.URL.Path, "{path}")
+	return &probeResponse{
+		status:   resp.StatusCode,
+		location: replacer.Replace(resp.Header.Get("Location")),
+		body:     replacer.Replace(string(body)),
+	}, nil
+}
+
+// isExistingStatus reports whether status indicates the path exists. Authentication and
+// authorization errors count: they reveal protected content worth knowing about.
+func isExistingStatus(status int) bool {
+	return (status >= 200 && status < 400) || status == http.StatusUnauthorized || status == http.StatusForbidden
+}
+
+// directoriesOf returns every directory (with a trailing slash) leading to the given URLs,
+// deduplicated and sorted.
+func directoriesOf(urls []string) []*url.URL {
+	seen := make(map[string]*url.URL)
+	for _, raw := range urls {
+		parsed, err := url.Parse(raw)
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+		dir := parsed.Path
+		if !strings.HasSuffix(dir, "/") {
+			dir = path.Dir(dir)
+		}
+		for {
+			dir = strings.TrimSuffix(dir, "/") + "/"
+			u := &url.URL{Scheme: parsed.Scheme, Host: parsed.Host, Path: dir}
+			seen[u.String()] = u
+			if dir == "/" {
+				break
+			}
+			dir = path.Dir(strings.TrimSuffix(dir, "/"))
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	dirs := make([]*url.URL, 0, len(keys))
+	for _, k := range keys {
+		dirs = append(dirs, seen[k])
+	}
+	return dirs
+}
+
+// wordlistFor returns the common paths followed by the base names combined with the extensions
+// of every technology found in the fingerprint (its names and values, e.g. "X-Powered-By: PHP").
+func wordlistFor(fp fingerprint.Fingerprint) []string {
+	words := append([]string{}, payloads.GetContentDiscoveryPaths()...)
+	seen := make(map[string]bool)
+	for _, w := range words {
+		seen[w] = true
+	}
+
+	techs := make([]string, 0, len(payloads.ContentDiscoveryExtensions))
+	for tech := range payloads.ContentDiscoveryExtensions {
+		techs = append(techs, tech)
+	}
+	sort.Strings(techs)
+	for _, tech := range techs {
+		if !fingerprintMentions(fp, tech) {
+			continue
+		}
+		for _, ext := range payloads.ContentDiscoveryExtensions[tech] {
+			for _, name := range payloads.ContentDiscoveryBaseNames {
+				if word := name + ext; !seen[word] {
+					seen[word] = true
+					words = append(words, word)
+				}
+			}
+		}
+	}
+	return words
+}
+
+// fingerprintMentions reports whether a detected technology name or value contains tech.
+func fingerprintMentions(fp fingerprint.Fingerprint, tech string) bool {
+	for name, value := range fp {
+		if strings.Contains(strings.ToLower(name), tech) || strings.Contains(strings.ToLower(value), tech) {
+			return true
+		}
+	}
+	return false
+}
+
+// extensionOf returns the extension of the last segment of word, so that each extension gets its
+// own baseline (servers often route unknown ".php" paths to a different handler than others).
+func extensionOf(word string) string {
+	if strings.HasSuffix(word, "/") {
+		return "/"
+	}
+	return path.Ext(word)
+}
@@ -0,0 +1,111 @@
+package discovery
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Dursgo/internal/fingerprint"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// siteHandler serves a few real paths and answers everything else with notFound.
+func siteHandler(notFound http.HandlerFunc) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Write([]byte(`<a href="/shop/items">Shop</a>`))
+			return
+		}
+		notFound(w, r)
+	})
+	mux.HandleFunc("/admin", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/admin/", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/shop/.env", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("DB_PASSWORD=hunter2"))
+	})
+	mux.HandleFunc("/shop/search.php", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<form action="/shop/search.php"><input name="q"></form>`))
+	})
+	return mux
+}
+
+func TestDiscover(t *testing.T) {
+	tests := []struct {
+		name        string
+		notFound    http.HandlerFunc
+		fingerprint fingerprint.Fingerprint
+		want        []string
+	}{
+		{
+			name:     "Hard 404",
+			notFound: http.NotFound,
+			want:     []string{"/admin", "/shop/.env"},
+		},
+		{
+			name: "Soft 404 reflecting the path",
+			notFound: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("<html><body><h1>Sorry</h1><p>The page " + r.URL.Path + " could not be found. Try the search or go back home.</p></body></html>"))
+			},
+			want: []string{"/admin", "/shop/.env"},
+		},
+		{
+			name: "Soft 404 redirecting home",
+			notFound: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/", http.StatusFound)
+			},
+			want: []string{"/admin", "/shop/.env"},
+		},
+		{
+			name:        "Extension fuzzing for PHP",
+			notFound:    http.NotFound,
+			fingerprint: fingerprint.Fingerprint{"X-Powered-By": "PHP/8.2"},
+			want:        []string{"/admin", "/shop/.env", "/shop/search.php"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(siteHandler(tt.notFound))
+			defer server.Close()
+
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, FollowRedirects: true})
+			found := NewContentDiscoverer(client, log, 4, 0).Discover(context.Background(), []string{server.URL + "/shop/items"}, tt.fingerprint)
+
+			var want []string
+			for _, p := range tt.want {
+				want = append(want, server.URL+p)
+			}
+			assert.Equal(t, want, found)
+		})
+	}
+}
+
+func TestDiscoverProbeLimit(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	NewContentDiscoverer(client, log, 1, 10).Discover(context.Background(), []string{server.URL + "/a/b"}, nil)
+
+	assert.Equal(t, 10, requests)
+}
+
+func TestDirectoriesOf(t *testing.T) {
+	var dirs []string
+	for _, dir := range directoriesOf([]string{"http://example.com/a/b/page.php?id=1", "http://example.com/a/", "https://example.com/x"}) {
+		dirs = append(dirs, dir.String())
+	}
+	assert.Equal(t, []string{"http://example.com/", "http://example.com/a/", "http://example.com/a/b/", "https://example.com/"}, dirs)
+}
@@ -1,6 +1,7 @@
 package discovery
 
 import (
+	"Dursgo/internal/crawler"
 	"Dursgo/internal/httpclient"
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
@@ -34,52 +35,98 @@ func (f *GraphQLFinder) FindEndpoint(targetDomain string) string {
 	for _, path := range payloads.CommonGraphQLPaths {
 		testURL := targetDomain + path
 		f.log.Debug("GraphQL Finder: Probing path: %s", testURL)
-
-		// Send an Introspection Query using a POST request.
-		req, err := http.NewRequest("POST", testURL, bytes.NewBufferString(payloads.GraphQLQueries.IntrospectionSimple))
-		if err != nil {
-			continue // Skip if request creation fails.
-		}
-		req.Header.Set("Content-Type", "application/json") // Set Content-Type for GraphQL.
-
-		resp, err := f.client.Do(req)
-		if err != nil {
-			// Close response body if it exists, even on error.
-			if resp != nil && resp.Body != nil {
-				resp.Body.Close()
-			}
-			continue // Skip if HTTP request fails.
+		if f.isGraphQLEndpoint(testURL) {
+			f.log.Success("GraphQL endpoint confirmed at: %s", testURL)
+			return testURL // Success! Return the discovered URL.
 		}
+	}
 
-		// --- Stricter GraphQL Endpoint Validation ---
+	f.log.Info("No GraphQL endpoint found for %s.", targetDomain)
+	return "" // No GraphQL endpoint found.
+}
 
-		// 1. Check for "application/json" Content-Type header.
-		contentType := resp.Header.Get("Content-Type")
-		if !strings.Contains(strings.ToLower(contentType), "application/json") {
-			resp.Body.Close()
-			continue // Not a JSON response, highly unlikely to be GraphQL.
+// FindInRequests sniffs the crawled JSON requests for GraphQL envelopes (a "query" key holding a
+// GraphQL document) and returns the first URL confirmed to answer like a GraphQL endpoint. It
+// finds endpoints served under paths not listed in payloads.CommonGraphQLPaths.
+func (f *GraphQLFinder) FindInRequests(requests []crawler.ParameterizedRequest) string {
+	probed := make(map[string]bool)
+	for _, req := range requests {
+		if !req.IsJSON() || probed[req.URL] {
+			continue
 		}
-
-		bodyBytes, err := io.ReadAll(resp.Body)
-		resp.Body.Close() // Close body after reading.
-		if err != nil {
+		if _, ok := crawler.GraphQLQueryOf(req.RawBody); !ok {
 			continue
 		}
+		probed[req.URL] = true
+		f.log.Debug("GraphQL Finder: Request body of %s looks like a GraphQL query", req.URL)
+		if f.isGraphQLEndpoint(req.URL) {
+			f.log.Success("GraphQL endpoint confirmed at: %s", req.URL)
+			return req.URL
+		}
+	}
+	return ""
+}
+
+// Introspect sends the full introspection query to endpoint and returns the parsed schema along
+// with the raw response body.
+func (f *GraphQLFinder) Introspect(endpoint string) (*GraphQLSchema, []byte, error) {
+	req, err := http.NewRequest("POST", endpoint, bytes.NewBufferString(payloads.GraphQLQueries.IntrospectionFull))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	schema, err := ParseGraphQLSchema(body)
+	if err != nil {
+		return nil, body, err
+	}
+	return schema, body, nil
+}
 
-		// 2. Check if the response body is valid JSON and contains characteristic keys.
-		var result map[string]interface{}
-		if err := json.Unmarshal(bodyBytes, &result); err == nil {
-			// 3. A valid GraphQL response will have either a "data" or "errors" key.
-			_, hasData := result["data"]
-			_, hasErrors := result["errors"]
+// isGraphQLEndpoint sends a minimal query to testURL and reports whether the response is a JSON
+// document with a "data" or "errors" key, as every GraphQL response is.
+func (f *GraphQLFinder) isGraphQLEndpoint(testURL string) bool {
+	// Send an Introspection Query using a POST request.
+	req, err := http.NewRequest("POST", testURL, bytes.NewBufferString(payloads.GraphQLQueries.IntrospectionSimple))
+	if err != nil {
+		return false
+	}
+	req.Header.Set("Content-Type", "application/json") // Set Content-Type for GraphQL.
 
-			if hasData || hasErrors {
-				f.log.Success("GraphQL endpoint confirmed at: %s", testURL)
-				return testURL // Success! Return the discovered URL.
-			}
-		}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return false
 	}
+	defer resp.Body.Close()
 
-	f.log.Info("No GraphQL endpoint found for %s.", targetDomain)
-	return "" // No GraphQL endpoint found.
+	// --- Stricter GraphQL Endpoint Validation ---
+
+	// 1. Check for "application/json" Content-Type header.
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.Contains(strings.ToLower(contentType), "application/json") {
+		return false // Not a JSON response, highly unlikely to be GraphQL.
+	}
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false
+	}
+
+	// 2. Check if the response body is valid JSON and contains characteristic keys.
+	var result map[string]interface{}
+	if err := json.Unmarshal(bodyBytes, &result); err != nil {
+		return false
+	}
+	// 3. A valid GraphQL response will have either a "data" or "errors" key.
+	_, hasData := result["data"]
+	_, hasErrors := result["errors"]
+	return hasData || hasErrors
 }
@@ -0,0 +1,250 @@
+package discovery
+
+import (
+	"Dursgo/internal/crawler"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSelectedFields bounds the scalar fields selected from an object returned by a generated query.
+const maxSelectedFields = 10
+
+// maxSummaryFields bounds the root fields listed in a schema summary.
+const maxSummaryFields = 15
+
+// GraphQLSchema is the part of an introspection result used to summarize an API and generate
+// scan requests.
+type GraphQLSchema struct {
+	QueryType    string
+	MutationType string
+	Types        map[string]GraphQLType
+}
+
+// GraphQLType is a named type of the schema.
+type GraphQLType struct {
+	Kind   string         `json:"kind"`
+	Name   string         `json:"name"`
+	Fields []GraphQLField `json:"fields"`
+}
+
+// GraphQLField is a field of an object or interface type.
+type GraphQLField struct {
+	Name string            `json:"name"`
+	Args []GraphQLArgument `json:"args"`
+	Type GraphQLTypeRef    `json:"type"`
+}
+
+// GraphQLArgument is an argument of a field.
+type GraphQLArgument struct {
+	Name string         `json:"name"`
+	Type GraphQLTypeRef `json:"type"`
+}
+
+// GraphQLTypeRef references a type, possibly wrapped in NON_NULL and LIST.
+type GraphQLTypeRef struct {
+	Kind   string          `json:"kind"`
+	Name   string          `json:"name"`
+	OfType *GraphQLTypeRef `json:"ofType"`
+}
+
+// Named returns the innermost named type.
+func (t GraphQLTypeRef) Named() GraphQLTypeRef {
+	for t.OfType != nil && t.Name == "" {
+		t = *t.OfType
+	}
+	return t
+}
+
+// String renders the type in GraphQL syntax, e.g. "[ID!]!".
+func (t GraphQLTypeRef) String() string {
+	switch {
+	case t.Kind == "NON_NULL" && t.OfType != nil:
+		return t.OfType.String() + "!"
+	case t.Kind == "LIST" && t.OfType != nil:
+		return "[" + t.OfType.String() + "]"
+	}
+	return t.Name
+}
+
+// ParseGraphQLSchema decodes the response to an introspection query.
+func ParseGraphQLSchema(body []byte) (*GraphQLSchema, error) {
+	var result struct {
+		Data struct {
+			Schema *struct {
+				QueryType    *struct{ Name string } `json:"queryType"`
+				MutationType *struct{ Name string } `json:"mutationType"`
+				Types        []GraphQLType          `json:"types"`
+			} `json:"__schema"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	if result.Data.Schema == nil || len(result.Data.Schema.Types) == 0 {
+		return nil, errors.New("response does not contain a GraphQL schema")
+	}
+
+	schema := &GraphQLSchema{QueryType: "Query", Types: make(map[string]GraphQLType)}
+	if result.Data.Schema.QueryType != nil {
+		schema.QueryType = result.Data.Schema.QueryType.Name
+	}
+	if result.Data.Schema.MutationType != nil {
+		schema.MutationType = result.Data.Schema.MutationType.Name
+	}
+	for _, t := range result.Data.Schema.Types {
+		schema.Types[t.Name] = t
+	}
+	return schema, nil
+}
+
+// Summary describes the root operations and the size of the schema.
+func (s *GraphQLSchema) Summary() string {
+	var userTypes int
+	for name := range s.Types {
+		if !strings.HasPrefix(name, "__") && !isBuiltinScalar(name) {
+			userTypes++
+		}
+	}
+	parts := []string{fmt.Sprintf("%d types", userTypes)}
+	if summary := s.rootSummary("queries", s.QueryType); summary != "" {
+		parts = append(parts, summary)
+	}
+	if summary := s.rootSummary("mutations", s.MutationType); summary != "" {
+		parts = append(parts, summary)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// rootSummary lists the fields of a root operation type.
+func (s *GraphQLSchema) rootSummary(label, typeName string) string {
+	root, ok := s.Types[typeName]
+	if !ok || len(root.Fields) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(root.Fields))
+	for _, field := range root.Fields {
+		names = append(names, field.Name)
+	}
+	sort.Strings(names)
+	more := ""
+	if len(names) > maxSummaryFields {
+		more = fmt.Sprintf(", ... (%d more)", len(names)-maxSummaryFields)
+		names = names[:maxSummaryFields]
+	}
+	return fmt.Sprintf("%d %s: %s%s", len(root.Fields), label, strings.Join(names, ", "), more)
+}
+
+// IDLookupRequests returns a scan request for every query field that takes an ID-like argument
+// (type ID, or an Int or String argument named like an ID), such as user(id: ID!). The argument
+// is passed as a variable so SQLi, NoSQLi and IDOR scanners can inject into it. Mutations are
+// left out, since replaying them changes data.
+func (s *GraphQLSchema) IDLookupRequests(endpoint string) []crawler.ParameterizedRequest {
+	root, ok := s.Types[s.QueryType]
+	if !ok {
+		return nil
+	}
+	var requests []crawler.ParameterizedRequest
+	for _, field := range root.Fields {
+		query, variables, ok := s.lookupQuery(field)
+		if !ok {
+			continue
+		}
+		req, err := crawler.NewGraphQLRequest(endpoint, query, variables)
+		if err != nil {
+			continue
+		}
+		requests = append(requests, req)
+	}
+	return requests
+}
+
+// lookupQuery builds the query document and variables for a field with an ID-like argument.
+// Fields with other required arguments are skipped, since no meaningful value is known for them.
+func (s *GraphQLSchema) lookupQuery(field GraphQLField) (string, map[string]interface{}, bool) {
+	var idArg *GraphQLArgument
+	for i, arg := range field.Args {
+		if idArg == nil && isIDArgument(arg) {
+			idArg = &field.Args[i]
+		} else if arg.Type.Kind == "NON_NULL" {
+			return "", nil, false
+		}
+	}
+	if idArg == nil {
+		return "", nil, false
+	}
+
+	var sample interface{} = "1"
+	if idArg.Type.Named().Name == "Int" {
+		sample = 1
+	}
+	query := fmt.Sprintf("query DursgoLookup($%s: %s) { %s(%s: $%s)%s }", idArg.Name, idArg.Type, field.Name, idArg.Name, idArg.Name, s.selectionSet(field.Type))
+	return query, map[string]interface{}{idArg.Name: sample}, true
+}
+
+// selectionSet returns the fields selected from a value of type t: up to maxSelectedFields scalar
+// fields of an object, __typename when it has none, and nothing for scalars.
+func (s *GraphQLSchema) selectionSet(t GraphQLTypeRef) string {
+	named := s.Types[t.Named().Name]
+	switch named.Kind {
+	case "OBJECT", "INTERFACE":
+	case "UNION":
+		return " { __typename }"
+	default:
+		return ""
+	}
+	var selected []string
+	for _, field := range named.Fields {
+		if len(selected) == maxSelectedFields {
+			break
+		}
+		if hasRequiredArgs(field) {
+			continue
+		}
+		if kind := s.Types[field.Type.Named().Name].Kind; kind == "SCALAR" || kind == "ENUM" {
+			selected = append(selected, field.Name)
+		}
+	}
+	if len(selected) == 0 {
+		return " { __typename }"
+	}
+	return " { " + strings.Join(selected, " ") + " }"
+}
+
+// isIDArgument reports whether arg is a scalar object reference.
+func isIDArgument(arg GraphQLArgument) bool {
+	t := arg.Type
+	if t.Kind == "NON_NULL" && t.OfType != nil {
+		t = *t.OfType
+	}
+	if t.Kind != "SCALAR" {
+		return false // Lists of IDs are not lookups of a single object.
+	}
+	switch t.Name {
+	case "ID":
+		return true
+	case "Int", "String":
+		name := strings.ToLower(arg.Name)
+		return name == "id" || name == "uuid" || strings.HasSuffix(name, "_id") || strings.HasSuffix(arg.Name, "Id") || strings.HasSuffix(arg.Name, "ID")
+	}
+	return false
+}
+
+func hasRequiredArgs(field GraphQLField) bool {
+	for _, arg := range field.Args {
+		if arg.Type.Kind == "NON_NULL" {
+			return true
+		}
+	}
+	return false
+}
+
+func isBuiltinScalar(name string) bool {
+	switch name {
+	case "String", "Int", "Float", "Boolean", "ID":
+		return true
+	}
+	return false
+}
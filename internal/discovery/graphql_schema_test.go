@@ -0,0 +1,93 @@
+package discovery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// introspectionResponse is a trimmed introspection result of a shop API.
+const introspectionResponse = `{"data": {"__schema": {
+	"queryType": {"name": "Query"},
+	"mutationType": {"name": "Mutation"},
+	"types": [
+		{"kind": "OBJECT", "name": "Query", "fields": [
+			{"name": "user", "args": [{"name": "id", "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID"}}}], "type": {"kind": "OBJECT", "name": "User"}},
+			{"name": "order", "args": [{"name": "orderId", "type": {"kind": "SCALAR", "name": "Int"}}], "type": {"kind": "OBJECT", "name": "Order"}},
+			{"name": "search", "args": [{"name": "term", "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "String"}}}], "type": {"kind": "LIST", "name": null, "ofType": {"kind": "OBJECT", "name": "User"}}},
+			{"name": "usersByIds", "args": [{"name": "ids", "type": {"kind": "LIST", "name": null, "ofType": {"kind": "SCALAR", "name": "ID"}}}], "type": {"kind": "LIST", "name": null, "ofType": {"kind": "OBJECT", "name": "User"}}},
+			{"name": "version", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+		]},
+		{"kind": "OBJECT", "name": "Mutation", "fields": [
+			{"name": "deleteUser", "args": [{"name": "id", "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID"}}}], "type": {"kind": "SCALAR", "name": "Boolean"}}
+		]},
+		{"kind": "OBJECT", "name": "User", "fields": [
+			{"name": "id", "args": [], "type": {"kind": "NON_NULL", "name": null, "ofType": {"kind": "SCALAR", "name": "ID"}}},
+			{"name": "email", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+			{"name": "orders", "args": [], "type": {"kind": "LIST", "name": null, "ofType": {"kind": "OBJECT", "name": "Order"}}}
+		]},
+		{"kind": "OBJECT", "name": "Order", "fields": [
+			{"name": "total", "args": [], "type": {"kind": "SCALAR", "name": "Float"}}
+		]},
+		{"kind": "SCALAR", "name": "ID"},
+		{"kind": "SCALAR", "name": "Int"},
+		{"kind": "SCALAR", "name": "Float"},
+		{"kind": "SCALAR", "name": "String"},
+		{"kind": "SCALAR", "name": "Boolean"},
+		{"kind": "OBJECT", "name": "__Schema", "fields": []}
+	]
+}}}`
+
+func TestParseGraphQLSchema(t *testing.T) {
+	schema, err := ParseGraphQLSchema([]byte(introspectionResponse))
+	require.NoError(t, err)
+	assert.Equal(t, "4 types; 5 queries: order, search, user, usersByIds, version; 1 mutations: deleteUser", schema.Summary())
+
+	_, err = ParseGraphQLSchema([]byte(`{"errors": [{"message": "introspection is disabled"}]}`))
+	assert.Error(t, err)
+}
+
+func TestIDLookupRequests(t *testing.T) {
+	schema, err := ParseGraphQLSchema([]byte(introspectionResponse))
+	require.NoError(t, err)
+
+	requests := schema.IDLookupRequests("https://example.com/graphql")
+	require.Len(t, requests, 2)
+
+	user := requests[0]
+	assert.True(t, user.IsGraphQL())
+	assert.Equal(t, "POST", user.Method)
+	assert.Equal(t, []string{"variables.id"}, user.ParamNames)
+	assert.JSONEq(t, `{"query": "query DursgoLookup($id: ID!) { user(id: $id) { id email } }", "variables": {"id": "1"}}`, user.RawBody)
+
+	order := requests[1]
+	assert.Equal(t, []string{"variables.orderId"}, order.ParamNames)
+	assert.JSONEq(t, `{"query": "query DursgoLookup($orderId: Int) { order(orderId: $orderId) { total } }", "variables": {"orderId": 1}}`, order.RawBody)
+}
+
+func TestFindInRequests(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/internal/gateway" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": {"__typename": "Query"}}`))
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	finder := NewGraphQLFinder(httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL}), log)
+	requests := []crawler.ParameterizedRequest{
+		{Method: "POST", URL: server.URL + "/api/search", ContentType: "application/json", RawBody: `{"query": "shoes"}`},
+		{Method: "POST", URL: server.URL + "/internal/gateway", ContentType: "application/json", RawBody: `{"query": "query { me { id } }"}`},
+	}
+	assert.Equal(t, server.URL+"/internal/gateway", finder.FindInRequests(requests))
+}
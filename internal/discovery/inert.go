@@ -0,0 +1,183 @@
+package discovery
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// InertParamDetector finds the parameters of crawled requests that the server never reads, so
+// that scanners can skip them (-skip-inert-params). For each query or form parameter it sends
+// the request once without the parameter and once with a random value; if both responses are
+// identical to the baseline after normalizing dynamic content, the parameter is inert.
+//
+// Sinks without a visible effect (logging, asynchronous jobs) make a used parameter look inert,
+// which is why modules testing them declare Registration.TestsInertParams.
+type InertParamDetector struct {
+	client      *httpclient.Client
+	log         *logger.Logger
+	concurrency int
+}
+
+// NewInertParamDetector creates a new instance of InertParamDetector.
+func NewInertParamDetector(client *httpclient.Client, log *logger.Logger, concurrency int) *InertParamDetector {
+	if concurrency <= 0 {
+		concurrency = 5
+	}
+	return &InertParamDetector{client: client, log: log, concurrency: concurrency}
+}
+
+// inertResponse is the part of a response compared by the pre-flight.
+type inertResponse struct {
+	status   int
+	location string
+	body     string // Normalized with compare.Normalize.
+}
+
+// same reports whether r is identical to the baseline.
+func (r *inertResponse) same(base *inertResponse) bool {
+	return r.status == base.status && r.location == base.location && r.body == base.body
+}
+
+// Detect probes the parameters of GET requests and form-encoded POST requests and returns the
+// ones found inert. Parameters found by parameter discovery are not in the request as crawled
+// and are not probed; neither are JSON, XML and multipart bodies.
+func (d *InertParamDetector) Detect(ctx context.Context, requests []crawler.ParameterizedRequest) *scanner.InertParams {
+	inert := scanner.NewInertParams()
+	client := d.client.WithContext(ctx)
+
+	var targets []crawler.ParameterizedRequest
+	for _, req := range requests {
+		if _, ok := endpointKey(req); ok && len(req.ParamNames) > 0 {
+			targets = append(targets, req)
+		}
+	}
+	d.log.Info("Checking the parameters of %d request(s) for inert parameters...", len(targets))
+
+	// A parameter that redirects is compared by its Location header, not the page redirected to.
+	client = client.WithoutRedirects()
+
+	jobs := make(chan crawler.ParameterizedRequest)
+	var wg sync.WaitGroup
+	for i := 0; i < d.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range jobs {
+				d.detectRequest(ctx, client, req, inert)
+			}
+		}()
+	}
+FeedLoop:
+	for _, req := range targets {
+		select {
+		case jobs <- req:
+		case <-ctx.Done():
+			break FeedLoop
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	summary := inert.Summary()
+	d.log.Info("Inert parameter check finished: %d of %d parameter(s) inert.", len(summary.Inert), summary.Checked)
+	return inert
+}
+
+// detectRequest probes the parameters of req and marks the inert ones.
+func (d *InertParamDetector) detectRequest(ctx context.Context, client *httpclient.Client, req crawler.ParameterizedRequest, inert *scanner.InertParams) {
+	params, err := requestParams(req)
+	if err != nil {
+		return
+	}
+	base, err := d.send(ctx, client, req, params)
+	if err != nil {
+		d.log.Debug("Inert Params: Baseline request for %s %s failed: %v", req.Method, req.URL, err)
+		return
+	}
+
+	checked := 0
+	for _, name := range req.ParamNames {
+		if _, ok := params[name]; !ok || ctx.Err() != nil {
+			continue
+		}
+		checked++
+		removed := cloneValues(params)
+		removed.Del(name)
+		resp, err := d.send(ctx, client, req, removed)
+		if err != nil || !resp.same(base) {
+			continue
+		}
+		random := cloneValues(params)
+		random.Set(name, payloads.GenerateInertParamValue())
+		resp, err = d.send(ctx, client, req, random)
+		if err != nil || !resp.same(base) {
+			continue
+		}
+		d.log.Debug("Inert Params: Parameter '%s' of %s %s has no effect on the response", name, req.Method, req.URL)
+		inert.Mark(req, name)
+	}
+	inert.AddChecked(checked)
+}
+
+// requestParams returns the query parameters of a GET request or the form parameters of a POST
+// request.
+func requestParams(req crawler.ParameterizedRequest) (url.Values, error) {
+	if req.Method == "POST" {
+		return url.ParseQuery(req.FormPostData)
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	return u.Query(), nil
+}
+
+// cloneValues returns a copy of values that can be modified without changing values.
+func cloneValues(values url.Values) url.Values {
+	clone := make(url.Values, len(values))
+	for name, v := range values {
+		clone[name] = append([]string(nil), v...)
+	}
+	return clone
+}
+
+// send requests req with params as its query or form parameters.
+func (d *InertParamDetector) send(ctx context.Context, client *httpclient.Client, req crawler.ParameterizedRequest, params url.Values) (*inertResponse, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	var body io.Reader
+	if req.Method == "POST" {
+		body = strings.NewReader(params.Encode())
+	} else {
+		u.RawQuery = params.Encode()
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if req.Method == "POST" {
+		httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxBaselineBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	return &inertResponse{status: resp.StatusCode, location: resp.Header.Get("Location"), body: compare.Normalize(string(raw))}, nil
+}
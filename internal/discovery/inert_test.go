@@ -0,0 +1,51 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// inertSite serves a product page that reads id and echoes q, ignores the tracking parameter
+// utm, and logs a CSRF-looking token whose value is checked only when it is missing.
+func inertSite() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Method == http.MethodPost && r.PostForm.Get("token") == "" {
+			http.Error(w, "missing token", http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, `<html><body><h1>Product %s</h1><p>Search: %s</p><p>Rendered at %s</p></body></html>`,
+			r.Form.Get("id"), r.Form.Get("q"), time.Now().Format(time.RFC3339Nano))
+	})
+}
+
+func TestInertParamDetector(t *testing.T) {
+	server := httptest.NewServer(inertSite())
+	defer server.Close()
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+
+	get := crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/product?id=1&q=shoes&utm=mail", ParamNames: []string{"id", "q", "utm", "debug"}}
+	post := crawler.ParameterizedRequest{Method: "POST", URL: server.URL + "/cart", FormPostData: "token=abc&note=hi", ParamNames: []string{"token", "note"}}
+	json := crawler.ParameterizedRequest{Method: "POST", URL: server.URL + "/api", ContentType: "application/json", RawBody: `{"a":1}`, ParamNames: []string{"a"}}
+	inert := NewInertParamDetector(client, log, 2).Detect(context.Background(), []crawler.ParameterizedRequest{get, post, json})
+
+	assert.True(t, inert.IsInert(get, "utm"))
+	assert.False(t, inert.IsInert(get, "id"), "removing id changes the page")
+	assert.False(t, inert.IsInert(get, "q"), "a random q is reflected")
+	assert.False(t, inert.IsInert(get, "debug"), "parameters not in the request are not probed")
+	assert.True(t, inert.IsInert(post, "note"))
+	assert.False(t, inert.IsInert(post, "token"), "removing the token is refused")
+	assert.False(t, inert.IsInert(json, "a"), "JSON bodies are not probed")
+	assert.Equal(t, 5, inert.Summary().Checked)
+}
@@ -0,0 +1,351 @@
+package discovery
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultParamChunkSize is the number of candidate names sent in one parameter discovery request.
+const DefaultParamChunkSize = 30
+
+// errParamBudget stops the discovery of an endpoint whose request budget is spent.
+var errParamBudget = errors.New("parameter discovery budget reached")
+
+// ParameterDiscoveryOptions configures a ParameterDiscoverer.
+type ParameterDiscoveryOptions struct {
+	Concurrency       int // Endpoints probed at the same time.
+	ChunkSize         int // Candidate names per request (0 = DefaultParamChunkSize).
+	MaxRequestsPerURL int // Requests sent per endpoint, baselines included (0 = unlimited).
+}
+
+// ParameterDiscoverer finds the parameters an endpoint accepts although no crawled link or form
+// uses them (debug, admin, template, ...). It sends the names of payloads.ParameterNames in
+// chunks and narrows the chunks that change the response down to single names.
+type ParameterDiscoverer struct {
+	client *httpclient.Client
+	log    *logger.Logger
+	opts   ParameterDiscoveryOptions
+	cmp    compare.Comparator
+}
+
+// NewParameterDiscoverer creates a new instance of ParameterDiscoverer.
+func NewParameterDiscoverer(client *httpclient.Client, log *logger.Logger, opts ParameterDiscoveryOptions) *ParameterDiscoverer {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 5
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultParamChunkSize
+	}
+	cmp := compare.New(scanner.ScannerOptions{}, log, "Parameter Discovery")
+	cmp.Mode = compare.Words
+	return &ParameterDiscoverer{client: client, log: log, opts: opts, cmp: cmp}
+}
+
+// Discover probes every GET and form-encoded POST endpoint of requests and returns requests with
+// the parameters found appended to ParamNames, so that the active scanners test them. Requests
+// to the same endpoint (method, scheme, host and path) are probed once.
+func (d *ParameterDiscoverer) Discover(ctx context.Context, requests []crawler.ParameterizedRequest) []crawler.ParameterizedRequest {
+	client := d.client.WithContext(ctx)
+	words := payloads.GetParameterNames()
+
+	var endpoints []crawler.ParameterizedRequest
+	seen := make(map[string]bool)
+	for _, req := range requests {
+		key, ok := endpointKey(req)
+		if !ok || seen[key] {
+			continue
+		}
+		seen[key] = true
+		endpoints = append(endpoints, req)
+	}
+	d.log.Info("Starting parameter discovery: %d names on %d endpoints, %d per request...", len(words), len(endpoints), d.opts.ChunkSize)
+
+	// A parameter that redirects is found by its Location header, not the page redirected to.
+	client = client.WithoutRedirects()
+
+	jobs := make(chan crawler.ParameterizedRequest)
+	found := make(map[string][]string)
+	var (
+		foundMu sync.Mutex
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < d.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range jobs {
+				names := d.discoverEndpoint(ctx, client, req, words)
+				if len(names) == 0 {
+					continue
+				}
+				key, _ := endpointKey(req)
+				foundMu.Lock()
+				found[key] = names
+				foundMu.Unlock()
+			}
+		}()
+	}
+FeedLoop:
+	for _, req := range endpoints {
+		select {
+		case jobs <- req:
+		case <-ctx.Done():
+			break FeedLoop
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	enriched := make([]crawler.ParameterizedRequest, len(requests))
+	total := 0
+	for i, req := range requests {
+		key, _ := endpointKey(req)
+		if names := found[key]; len(names) > 0 {
+			req.ParamNames = mergeParamNames(req.ParamNames, names)
+			if len(req.ParamLocations) == 0 {
+				req.ParamLocations = []string{paramLocation(req)}
+			}
+		}
+		enriched[i] = req
+	}
+	for _, names := range found {
+		total += len(names)
+	}
+	d.log.Info("Parameter discovery finished: %d hidden parameter(s) found on %d endpoint(s).", total, len(found))
+	return enriched
+}
+
+// endpointKey returns the key requests to the same endpoint share, and whether parameters can
+// be discovered on the request: JSON, XML and GraphQL bodies are left out.
+func endpointKey(req crawler.ParameterizedRequest) (string, bool) {
+	if req.Method != "GET" && req.Method != "POST" {
+		return "", false
+	}
+	if req.Method == "POST" && (req.IsJSON() || req.IsXML() || req.RawBody != "" || strings.Contains(strings.ToLower(req.ContentType), "multipart")) {
+		return "", false
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil || u.Host == "" {
+		return "", false
+	}
+	return req.Method + " " + u.Scheme + "://" + u.Host + u.Path, true
+}
+
+// paramLocation returns where the discovered parameters of req are sent.
+func paramLocation(req crawler.ParameterizedRequest) string {
+	if req.Method == "POST" {
+		return "body"
+	}
+	return "query"
+}
+
+// mergeParamNames returns the names of existing followed by the new names of found, without
+// modifying existing.
+func mergeParamNames(existing, found []string) []string {
+	merged := slices.Clone(existing)
+	for _, name := range found {
+		if !slices.Contains(merged, name) {
+			merged = append(merged, name)
+		}
+	}
+	return merged
+}
+
+// paramResponse is a response to a parameter discovery request, with the parameters sent
+// removed from its body so that pages echoing their URL compare equal.
+type paramResponse struct {
+	status    int
+	location  string
+	body      string
+	reflected []string // Names whose value appears in the raw body.
+}
+
+// endpointProbe is the discovery state of one endpoint.
+type endpointProbe struct {
+	d        *ParameterDiscoverer
+	client   *httpclient.Client
+	req      crawler.ParameterizedRequest
+	token    string // Prefix of the values sent.
+	requests int
+
+	base        *paramResponse
+	bodyStable  bool // Two baselines with different random parameters have similar bodies.
+	reflectsAny bool // The endpoint echoes any parameter, so reflection proves nothing.
+}
+
+// discoverEndpoint returns the names of words that req's endpoint accepts, sorted.
+func (d *ParameterDiscoverer) discoverEndpoint(ctx context.Context, client *httpclient.Client, req crawler.ParameterizedRequest, words []string) []string {
+	p := &endpointProbe{d: d, client: client, req: req, token: payloads.GenerateParameterDiscoveryCanary()}
+	target, _ := endpointKey(req)
+
+	base1, err := p.send(ctx, []string{payloads.GenerateParameterDiscoveryCanary()})
+	if err != nil {
+		d.log.Debug("Parameter Discovery: Baseline request for %s failed: %v", target, err)
+		return nil
+	}
+	base2, err := p.send(ctx, []string{payloads.GenerateParameterDiscoveryCanary()})
+	if err != nil {
+		d.log.Debug("Parameter Discovery: Baseline request for %s failed: %v", target, err)
+		return nil
+	}
+	statusStable := base1.status == base2.status && base1.location == base2.location
+	p.base = base1
+	p.bodyStable = statusStable && !d.cmp.IsDifferent(base1.body, base2.body)
+	p.reflectsAny = len(base1.reflected) > 0 || len(base2.reflected) > 0
+	if !statusStable && p.reflectsAny {
+		d.log.Debug("Parameter Discovery: %s answers differently to identical requests, skipped", target)
+		return nil
+	}
+	if !p.bodyStable {
+		d.log.Debug("Parameter Discovery: Body of %s is unstable, only status changes and reflections are used", target)
+	}
+
+	candidates := make([]string, 0, len(words))
+	for _, word := range words {
+		if !slices.Contains(req.ParamNames, word) {
+			candidates = append(candidates, word)
+		}
+	}
+	found := make(map[string]string)
+	for start := 0; start < len(candidates); start += d.opts.ChunkSize {
+		chunk := candidates[start:min(start+d.opts.ChunkSize, len(candidates))]
+		if err := p.narrow(ctx, chunk, found); err != nil {
+			if errors.Is(err, errParamBudget) {
+				d.log.Debug("Parameter Discovery: Request budget of %d reached for %s", d.opts.MaxRequestsPerURL, target)
+			} else if !errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+				d.log.Debug("Parameter Discovery: Request to %s failed: %v", target, err)
+			}
+			break
+		}
+	}
+
+	names := make([]string, 0, len(found))
+	for name, reason := range found {
+		d.log.Success("Parameter Discovery: Found hidden parameter '%s' on %s (%s)", name, target, reason)
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// narrow sends names at once and, when the response changes, sends each half of them again
+// until the names changing it are isolated. Reflected names are found without narrowing.
+func (p *endpointProbe) narrow(ctx context.Context, names []string, found map[string]string) error {
+	resp, err := p.send(ctx, names)
+	if err != nil {
+		return err
+	}
+	if !p.reflectsAny {
+		for _, name := range resp.reflected {
+			found[name] = "value reflected"
+		}
+	}
+	reason := p.change(resp)
+	if reason == "" {
+		return nil
+	}
+	if len(names) == 1 {
+		if _, ok := found[names[0]]; !ok {
+			found[names[0]] = reason
+		}
+		return nil
+	}
+	mid := len(names) / 2
+	if err := p.narrow(ctx, names[:mid], found); err != nil {
+		return err
+	}
+	return p.narrow(ctx, names[mid:], found)
+}
+
+// change describes how resp differs from the baseline, or returns "" if it does not.
+func (p *endpointProbe) change(resp *paramResponse) string {
+	switch {
+	case resp.status != p.base.status:
+		return fmt.Sprintf("status %d instead of %d", resp.status, p.base.status)
+	case resp.location != p.base.location:
+		return "redirect changed"
+	case p.bodyStable && p.d.cmp.IsDifferent(p.base.body, resp.body):
+		return "response changed"
+	}
+	return ""
+}
+
+// value returns the value sent for the i-th name of a request.
+func (p *endpointProbe) value(i int) string {
+	return fmt.Sprintf("%sv%03d", p.token, i)
+}
+
+// send requests the endpoint with the given names added to its parameters.
+func (p *endpointProbe) send(ctx context.Context, names []string) (*paramResponse, error) {
+	if p.d.opts.MaxRequestsPerURL > 0 && p.requests >= p.d.opts.MaxRequestsPerURL {
+		return nil, errParamBudget
+	}
+	p.requests++
+
+	u, err := url.Parse(p.req.URL)
+	if err != nil {
+		return nil, err
+	}
+	var body io.Reader
+	params := u.Query()
+	if p.req.Method == "POST" {
+		params, _ = url.ParseQuery(p.req.FormPostData)
+	}
+	for i, name := range names {
+		params.Set(name, p.value(i))
+	}
+	if p.req.Method == "POST" {
+		body = strings.NewReader(params.Encode())
+	} else {
+		u.RawQuery = params.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, p.req.Method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	if p.req.Method == "POST" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, maxBaselineBodyBytes))
+	if err != nil {
+		return nil, err
+	}
+
+	result := &paramResponse{status: resp.StatusCode}
+	text, location := string(raw), resp.Header.Get("Location")
+	// Echoed "name=value" pairs (a canonical link, a pagination URL) are removed, then values
+	// echoed on their own are replaced by a placeholder.
+	pairs := make([]string, 0, 4*len(names))
+	values := make([]string, 0, 2*len(names))
+	for i, name := range names {
+		value := p.value(i)
+		if strings.Contains(text, value) {
+			result.reflected = append(result.reflected, name)
+		}
+		pairs = append(pairs, url.QueryEscape(name)+"="+value, "", name+"="+value, "")
+		values = append(values, value, "{value}")
+	}
+	pairReplacer, valueReplacer := strings.NewReplacer(pairs...), strings.NewReplacer(values...)
+	result.body = valueReplacer.Replace(pairReplacer.Replace(text))
+	result.location = valueReplacer.Replace(pairReplacer.Replace(location))
+	return result, nil
+}
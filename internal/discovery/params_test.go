@@ -0,0 +1,84 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// paramSite serves a page with a hidden debug switch, a reflected template parameter, a
+// redirecting next parameter, and a canonical link echoing every parameter.
+func paramSite(requests *int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		q := r.URL.Query()
+		if q.Get("next") != "" {
+			http.Redirect(w, r, "/login", http.StatusFound)
+			return
+		}
+		fmt.Fprintf(w, `<html><head><link rel="canonical" href="%s"></head><body><h1>Products</h1><p>Browse our catalogue of fine products, updated daily.</p>`, r.URL.String())
+		if q.Get("debug") != "" {
+			fmt.Fprint(w, `<pre>SQL: SELECT * FROM products WHERE visible = 1 ORDER BY name; cache miss; render time high</pre>`)
+		}
+		if t := q.Get("tpl"); t != "" {
+			fmt.Fprintf(w, `<div class="theme">%s</div>`, t)
+		}
+		fmt.Fprint(w, `</body></html>`)
+	})
+}
+
+func TestParameterDiscovery(t *testing.T) {
+	saved := payloads.ParameterNames
+	defer func() { payloads.ParameterNames = saved }()
+	payloads.ParameterNames = []string{"id", "debug", "lang", "page", "next", "sort", "q", "view", "tpl", "token", "limit", "mode"}
+
+	var requests int32
+	server := httptest.NewServer(paramSite(&requests))
+	defer server.Close()
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, FollowRedirects: true})
+
+	input := []crawler.ParameterizedRequest{
+		{Method: "GET", URL: server.URL + "/products?id=1", Path: "/products", ParamNames: []string{"id"}, ParamLocations: []string{"query"}},
+		{Method: "GET", URL: server.URL + "/products?id=2", Path: "/products", ParamNames: []string{"id"}, ParamLocations: []string{"query"}},
+		{Method: "POST", URL: server.URL + "/api", ContentType: "application/json", RawBody: `{"a":1}`, ParamNames: []string{"a"}},
+	}
+	d := NewParameterDiscoverer(client, log, ParameterDiscoveryOptions{Concurrency: 2, ChunkSize: 6})
+	got := d.Discover(context.Background(), input)
+
+	require.Len(t, got, 3)
+	assert.Equal(t, []string{"id", "debug", "next", "tpl"}, got[0].ParamNames)
+	assert.Equal(t, got[0].ParamNames, got[1].ParamNames, "requests to the same endpoint share its parameters")
+	assert.Equal(t, []string{"id"}, input[0].ParamNames, "the input requests are not modified")
+	assert.Equal(t, []string{"a"}, got[2].ParamNames, "JSON bodies are not probed")
+}
+
+func TestParameterDiscoveryBudget(t *testing.T) {
+	saved := payloads.ParameterNames
+	defer func() { payloads.ParameterNames = saved }()
+	payloads.ParameterNames = []string{"id", "debug", "lang", "page", "next", "sort", "q", "view", "tpl", "token", "limit", "mode"}
+
+	var requests int32
+	server := httptest.NewServer(paramSite(&requests))
+	defer server.Close()
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, FollowRedirects: true})
+
+	d := NewParameterDiscoverer(client, log, ParameterDiscoveryOptions{ChunkSize: 6, MaxRequestsPerURL: 3})
+	got := d.Discover(context.Background(), []crawler.ParameterizedRequest{{Method: "GET", URL: server.URL + "/products", Path: "/products"}})
+
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	require.Len(t, got, 1)
+	assert.Empty(t, got[0].ParamNames, "the first chunk changed the response but could not be narrowed down")
+}
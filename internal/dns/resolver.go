@@ -0,0 +1,153 @@
+package dns
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// DefaultServer is the name server used when none is configured in /etc/resolv.conf.
+const DefaultServer = "8.8.8.8:53"
+
+// Resolution is the answer of a recursive name server to an address lookup.
+type Resolution struct {
+	Host      string
+	CNAMEs    []string // CNAME chain in order: the target of Host, then the target of that name, ...
+	Addresses []string // IPv4 addresses of the last name in the chain.
+	NXDomain  bool     // The last name in the chain does not exist.
+}
+
+// Target returns the last name of the CNAME chain, or Host when there is none.
+func (r Resolution) Target() string {
+	if len(r.CNAMEs) == 0 {
+		return r.Host
+	}
+	return r.CNAMEs[len(r.CNAMEs)-1]
+}
+
+// Chain renders the CNAME chain starting at Host, e.g. "docs.example.com -> example.github.io".
+func (r Resolution) Chain() string {
+	return strings.Join(append([]string{r.Host}, r.CNAMEs...), " -> ")
+}
+
+// Resolver sends A queries to a recursive name server over UDP. Unlike net.Resolver it reports
+// the whole CNAME chain and whether its target exists, which is what dangling records are
+// recognized by.
+type Resolver struct {
+	Server  string        // Address of the name server, host:port.
+	Timeout time.Duration // Timeout of a single query.
+}
+
+// NewResolver creates a resolver querying server. An empty server uses the first name server of
+// /etc/resolv.conf, or DefaultServer.
+func NewResolver(server string) *Resolver {
+	if server == "" {
+		server = systemServer("/etc/resolv.conf")
+	}
+	return &Resolver{Server: server, Timeout: 5 * time.Second}
+}
+
+// Resolve looks up the IPv4 addresses of host, following CNAME records.
+func (r *Resolver) Resolve(ctx context.Context, host string) (Resolution, error) {
+	result := Resolution{Host: strings.TrimSuffix(strings.ToLower(host), ".")}
+	name, err := dnsmessage.NewName(result.Host + ".")
+	if err != nil {
+		return result, err
+	}
+	id := uint16(rand.Intn(1 << 16))
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: id, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET}},
+	}
+	packet, err := query.Pack()
+	if err != nil {
+		return result, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, r.Timeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", r.Server)
+	if err != nil {
+		return result, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	if _, err := conn.Write(packet); err != nil {
+		return result, err
+	}
+
+	buf := make([]byte, 4096)
+	var response dnsmessage.Message
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return result, err
+		}
+		if err := response.Unpack(buf[:n]); err != nil || response.Header.ID != id || !response.Header.Response {
+			continue // Not the answer to this query.
+		}
+		break
+	}
+
+	switch response.Header.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		result.NXDomain = true
+	default:
+		return result, fmt.Errorf("name server %s answered %s for %s", r.Server, response.Header.RCode, result.Host)
+	}
+
+	// Answers are followed by name, so a chain listed out of order is still rebuilt correctly.
+	current := result.Host
+	for range response.Answers {
+		next := ""
+		for _, answer := range response.Answers {
+			if cname, ok := answer.Body.(*dnsmessage.CNAMEResource); ok && trimName(answer.Header.Name) == current {
+				next = trimName(cname.CNAME)
+				break
+			}
+		}
+		if next == "" {
+			break
+		}
+		result.CNAMEs = append(result.CNAMEs, next)
+		current = next
+	}
+	for _, answer := range response.Answers {
+		if a, ok := answer.Body.(*dnsmessage.AResource); ok && trimName(answer.Header.Name) == current {
+			result.Addresses = append(result.Addresses, net.IP(a.A[:]).String())
+		}
+	}
+	return result, nil
+}
+
+// systemServer returns the first name server of a resolv.conf file, or DefaultServer.
+func systemServer(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return DefaultServer
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return net.JoinHostPort(strings.Split(fields[1], "%")[0], "53")
+		}
+	}
+	return DefaultServer
+}
+
+func trimName(name dnsmessage.Name) string {
+	return strings.TrimSuffix(strings.ToLower(name.String()), ".")
+}
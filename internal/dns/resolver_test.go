@@ -0,0 +1,95 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveDNS answers queries on a local UDP socket with the records of zone (name -> CNAME target
+// or IPv4 address), following CNAMEs like a recursive server. Unknown names are NXDOMAIN.
+func serveDNS(t *testing.T, zone map[string]string) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if err := query.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			response := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.Header.ID, Response: true, RecursionAvailable: true},
+				Questions: query.Questions,
+			}
+			name := query.Questions[0].Name.String()
+			for {
+				value, ok := zone[name]
+				if !ok {
+					response.Header.RCode = dnsmessage.RCodeNameError
+					break
+				}
+				header := dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Class: dnsmessage.ClassINET, TTL: 60}
+				if ip := net.ParseIP(value).To4(); ip != nil {
+					header.Type = dnsmessage.TypeA
+					response.Answers = append(response.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.AResource{A: [4]byte(ip)}})
+					break
+				}
+				header.Type = dnsmessage.TypeCNAME
+				response.Answers = append(response.Answers, dnsmessage.Resource{Header: header, Body: &dnsmessage.CNAMEResource{CNAME: dnsmessage.MustNewName(value)}})
+				name = value
+			}
+			packet, _ := response.Pack()
+			conn.WriteTo(packet, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestResolve(t *testing.T) {
+	server := serveDNS(t, map[string]string{
+		"www.example.com.":      "203.0.113.10",
+		"docs.example.com.":     "docs-cdn.example.net.",
+		"docs-cdn.example.net.": "example.github.io.",
+		"example.github.io.":    "185.199.108.153",
+		"old.example.com.":      "old-app.azurewebsites.net.",
+	})
+	resolver := NewResolver(server)
+
+	res, err := resolver.Resolve(context.Background(), "www.example.com")
+	require.NoError(t, err)
+	assert.Empty(t, res.CNAMEs)
+	assert.Equal(t, []string{"203.0.113.10"}, res.Addresses)
+	assert.False(t, res.NXDomain)
+
+	res, err = resolver.Resolve(context.Background(), "Docs.Example.com.")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"docs-cdn.example.net", "example.github.io"}, res.CNAMEs)
+	assert.Equal(t, []string{"185.199.108.153"}, res.Addresses)
+	assert.Equal(t, "docs.example.com -> docs-cdn.example.net -> example.github.io", res.Chain())
+
+	res, err = resolver.Resolve(context.Background(), "old.example.com")
+	require.NoError(t, err)
+	assert.True(t, res.NXDomain)
+	assert.Equal(t, "old-app.azurewebsites.net", res.Target())
+	assert.Empty(t, res.Addresses)
+}
+
+func TestSystemServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resolv.conf")
+	require.NoError(t, os.WriteFile(path, []byte("# generated\nsearch corp.local\nnameserver fe80::1%eth0\nnameserver 10.0.0.2\n"), 0o644))
+	assert.Equal(t, "[fe80::1]:53", systemServer(path))
+	assert.Equal(t, DefaultServer, systemServer(filepath.Join(t.TempDir(), "missing.conf")))
+}
@@ -327,6 +327,9 @@ func (c *Client) saveToCache(catalog *Catalog) error {
 
 // Close releases any resources used by the client
 func (c *Client) Close() error {
+	if c == nil {
+		return nil
+	}
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -1,12 +1,20 @@
 package cisa
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestNewClient(t *testing.T) {
+	// A directory below a regular file cannot be created, even when running as root.
+	blockingFile := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blockingFile, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
 	tests := []struct {
 		name    string
 		dir     string
@@ -19,7 +27,7 @@ func TestNewClient(t *testing.T) {
 		},
 		{
 			name:    "Invalid directory",
-			dir:     "/nonexistent/directory",
+			dir:     filepath.Join(blockingFile, "directory"),
 			wantErr: true,
 		},
 	}
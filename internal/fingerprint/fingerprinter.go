@@ -5,6 +5,7 @@ import (
 	"Dursgo/internal/logger"
 	"io"
 	"net/http"
+	"net/url"
 	"strings"
 
 	"golang.org/x/net/html"
@@ -27,33 +28,90 @@ func NewFingerprinter(client *httpclient.Client, log *logger.Logger) *Fingerprin
 	}
 }
 
-// Analyze runs an analysis on the target URL to identify technologies.
-func (f *Fingerprinter) Analyze(targetURL string) Fingerprint {
-	result := make(Fingerprint) // Initialize an empty Fingerprint map.
+// maxFaviconBytes bounds the size of a favicon read for hashing.
+const maxFaviconBytes = 1 << 20
+
+// Result is what Analyze learned about the target.
+type Result struct {
+	Fingerprint  Fingerprint  // Raw clues: Server, X-Powered-By, generator, ...
+	Technologies Technologies // Technologies identified by payloads.TechnologyRules.
+}
+
+// Analyze runs an analysis on the target URL to identify technologies. It returns an error if
+// the target URL could not be fetched.
+func (f *Fingerprinter) Analyze(targetURL string) (Result, error) {
+	result := Result{Fingerprint: make(Fingerprint)} // Initialize an empty Fingerprint map.
 
 	f.log.Debug("Fingerprinter: Starting analysis on %s", targetURL)
 
 	resp, err := f.client.Get(targetURL)
 	if err != nil {
-		f.log.Warn("Fingerprinter: Could not fetch target URL for analysis: %v", err)
-		return result // Return empty result on fetch error.
+		return result, err // The target is unreachable.
 	}
 	defer resp.Body.Close() // Ensure response body is closed.
 
 	// Analyze HTTP headers for technology clues.
-	f.analyzeHeaders(resp, result)
+	f.analyzeHeaders(resp, result.Fingerprint)
 
 	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		f.log.Warn("Fingerprinter: Could not read response body: %v", err)
-		return result // Return current result on body read error.
+		result.Technologies = Identify(Response{Header: resp.Header})
+		return result, nil // Return current result on body read error.
 	}
 	responseBody := string(bodyBytes)
 
 	// Analyze HTML content for technology clues.
-	f.analyzeHTMLContent(responseBody, result)
+	iconHref := f.analyzeHTMLContent(responseBody, result.Fingerprint)
+
+	// Apply the technology rules, favicon hash included.
+	target := Response{Header: resp.Header, Body: responseBody}
+	base := resp.Request.URL
+	if base == nil {
+		base, _ = url.Parse(targetURL)
+	}
+	target.FaviconHash, target.HasFavicon = f.faviconHash(base, iconHref)
+	result.Technologies = Identify(target)
+	for _, tech := range result.Technologies {
+		f.log.Debug("Fingerprint: Identified %s %s (%s)", tech.Name, tech.Version, tech.Evidence)
+	}
 
-	return result // Return the identified technologies.
+	return result, nil // Return the identified technologies.
+}
+
+// faviconHash fetches the favicon linked from the page (href, or /favicon.ico without one)
+// and returns its FaviconHash.
+func (f *Fingerprinter) faviconHash(base *url.URL, href string) (int32, bool) {
+	if base == nil {
+		return 0, false
+	}
+	if href == "" {
+		href = "/favicon.ico"
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return 0, false
+	}
+	iconURL := base.ResolveReference(ref)
+	if iconURL.Scheme != "http" && iconURL.Scheme != "https" {
+		return 0, false // data: URIs and the like.
+	}
+	resp, err := f.client.Get(iconURL.String())
+	if err != nil {
+		f.log.Debug("Fingerprint: Favicon request for %s failed: %v", iconURL, err)
+		return 0, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, false
+	}
+	icon, err := io.ReadAll(io.LimitReader(resp.Body, maxFaviconBytes))
+	if err != nil || len(icon) == 0 {
+		return 0, false
+	}
+	hash := FaviconHash(icon)
+	f.log.Debug("Fingerprint: Favicon %s hash %d", iconURL, hash)
+	return hash, true
 }
 
 // analyzeHeaders examines HTTP headers for technology clues.
@@ -86,8 +144,9 @@ func (f *Fingerprinter) analyzeHeaders(resp *http.Response, result Fingerprint)
 	}
 }
 
-// analyzeHTMLContent scans the HTML body for technology clues.
-func (f *Fingerprinter) analyzeHTMLContent(body string, result Fingerprint) {
+// analyzeHTMLContent scans the HTML body for technology clues. It returns the href of the
+// page's icon link, if any.
+func (f *Fingerprinter) analyzeHTMLContent(body string, result Fingerprint) (iconHref string) {
 	// Check for WordPress specific paths in HTML content.
 	if strings.Contains(body, "/wp-content/") || strings.Contains(body, "wp-emoji") {
 		if _, exists := result["WordPress"]; !exists { // Only add if not already detected.
@@ -99,7 +158,7 @@ func (f *Fingerprinter) analyzeHTMLContent(body string, result Fingerprint) {
 	// Parse HTML document to find meta tags.
 	doc, err := html.Parse(strings.NewReader(body))
 	if err != nil {
-		return // Return on HTML parsing error.
+		return "" // Return on HTML parsing error.
 	}
 	var findMeta func(*html.Node) // Recursive function to traverse HTML nodes.
 	findMeta = func(n *html.Node) {
@@ -119,10 +178,26 @@ func (f *Fingerprinter) analyzeHTMLContent(body string, result Fingerprint) {
 				f.log.Debug("Fingerprint: Found meta generator tag: %s", content)
 			}
 		}
+		// Remember the first icon link for favicon hashing.
+		if n.Type == html.ElementNode && n.Data == "link" && iconHref == "" {
+			var rel, href string
+			for _, a := range n.Attr {
+				switch a.Key {
+				case "rel":
+					rel = strings.ToLower(a.Val)
+				case "href":
+					href = a.Val
+				}
+			}
+			if (rel == "icon" || rel == "shortcut icon") && href != "" {
+				iconHref = href
+			}
+		}
 		// Recursively call for child nodes.
 		for c := n.FirstChild; c != nil; c = c.NextSibling {
 			findMeta(c)
 		}
 	}
 	findMeta(doc) // Start traversal from the document root.
+	return iconHref
 }
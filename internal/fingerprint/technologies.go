@@ -0,0 +1,234 @@
+package fingerprint
+
+import (
+	"Dursgo/internal/payloads"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Technology is a component of the target stack identified by a rule of
+// payloads.TechnologyRules.
+type Technology struct {
+	Name     string `json:"name"`
+	Category string `json:"category,omitempty"`
+	Version  string `json:"version,omitempty"`
+	// Evidence tells what identified the technology, e.g. "header Server: nginx/1.25.3".
+	Evidence string `json:"evidence,omitempty"`
+}
+
+// Technologies is the target stack, in the order of the rules that identified it.
+type Technologies []Technology
+
+// Has reports whether the technology with the given name (case-insensitive) was identified.
+func (t Technologies) Has(name string) bool {
+	for _, tech := range t {
+		if strings.EqualFold(tech.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAny reports whether any of the named technologies was identified.
+func (t Technologies) HasAny(names ...string) bool {
+	for _, name := range names {
+		if t.Has(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the names of the technologies, with their version when known.
+func (t Technologies) Names() []string {
+	names := make([]string, 0, len(t))
+	for _, tech := range t {
+		if tech.Version != "" {
+			names = append(names, tech.Name+" "+tech.Version)
+		} else {
+			names = append(names, tech.Name)
+		}
+	}
+	return names
+}
+
+// String lists the technologies for log lines.
+func (t Technologies) String() string {
+	return strings.Join(t.Names(), ", ")
+}
+
+// Response is what technology rules are applied to: the target's first response and the hash
+// of its favicon.
+type Response struct {
+	Header      http.Header
+	Body        string
+	FaviconHash int32
+	HasFavicon  bool // FaviconHash is set; 0 is a valid hash.
+}
+
+// Identify applies payloads.TechnologyRules to resp and returns the technologies it identifies,
+// followed by the technologies they imply.
+func Identify(resp Response) Technologies {
+	var techs Technologies
+	found := make(map[string]bool)
+	cookies := (&http.Response{Header: resp.Header}).Cookies()
+	for _, rule := range payloads.TechnologyRules {
+		if found[strings.ToLower(rule.Name)] {
+			continue
+		}
+		version, evidence, ok := matchRule(rule, resp, cookies)
+		if !ok {
+			continue
+		}
+		found[strings.ToLower(rule.Name)] = true
+		techs = append(techs, Technology{Name: rule.Name, Category: rule.Category, Version: version, Evidence: evidence})
+	}
+
+	// Implied technologies are added once, after those seen directly; implications chain
+	// (Next.js implies Node.js and React).
+	for i := 0; i < len(techs); i++ {
+		for _, implied := range impliedBy(techs[i].Name) {
+			if found[strings.ToLower(implied)] {
+				continue
+			}
+			found[strings.ToLower(implied)] = true
+			techs = append(techs, Technology{Name: implied, Category: categoryOf(implied), Evidence: "implied by " + techs[i].Name})
+		}
+	}
+	return techs
+}
+
+// matchRule returns the version and evidence of the first condition of rule matching resp.
+func matchRule(rule payloads.TechnologyRule, resp Response, cookies []*http.Cookie) (version, evidence string, ok bool) {
+	headerNames := make([]string, 0, len(rule.HeaderRegexes))
+	for name := range rule.HeaderRegexes {
+		headerNames = append(headerNames, name)
+	}
+	sort.Strings(headerNames)
+	for _, name := range headerNames {
+		value := resp.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		if m := rule.HeaderRegexes[name].FindStringSubmatch(value); m != nil {
+			return submatchVersion(m), fmt.Sprintf("header %s: %s", name, value), true
+		}
+	}
+	for _, re := range rule.CookieRegexes {
+		for _, c := range cookies {
+			if re.MatchString(c.Name) {
+				return "", "cookie " + c.Name, true
+			}
+		}
+	}
+	for _, re := range rule.BodyRegexes {
+		if m := re.FindStringSubmatch(resp.Body); m != nil {
+			return submatchVersion(m), fmt.Sprintf("body %q", truncate(m[0], 80)), true
+		}
+	}
+	if resp.HasFavicon {
+		for _, hash := range rule.Favicons {
+			if hash == resp.FaviconHash {
+				return "", fmt.Sprintf("favicon hash %d", hash), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// submatchVersion returns the first capture group of a match, or "" without one.
+func submatchVersion(m []string) string {
+	if len(m) > 1 {
+		return m[1]
+	}
+	return ""
+}
+
+// impliedBy returns the technologies the rules for name imply.
+func impliedBy(name string) []string {
+	var implied []string
+	for _, rule := range payloads.TechnologyRules {
+		if strings.EqualFold(rule.Name, name) {
+			implied = append(implied, rule.Implies...)
+		}
+	}
+	return implied
+}
+
+// categoryOf returns the category of the first rule for name that has one.
+func categoryOf(name string) string {
+	for _, rule := range payloads.TechnologyRules {
+		if strings.EqualFold(rule.Name, name) && rule.Category != "" {
+			return rule.Category
+		}
+	}
+	return ""
+}
+
+// truncate returns the first n bytes of s.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// FaviconHash returns the Shodan-style hash of a favicon: the signed 32-bit MurmurHash3 of its
+// base64 encoding, wrapped at 76 characters with a trailing newline (as Python's
+// base64.encodebytes produces), so hashes published for Shodan's http.favicon.hash work.
+func FaviconHash(icon []byte) int32 {
+	encoded := base64.StdEncoding.EncodeToString(icon)
+	var wrapped strings.Builder
+	for len(encoded) > 76 {
+		wrapped.WriteString(encoded[:76])
+		wrapped.WriteByte('\n')
+		encoded = encoded[76:]
+	}
+	wrapped.WriteString(encoded)
+	wrapped.WriteByte('\n')
+	return int32(murmur3(wrapped.String(), 0))
+}
+
+// murmur3 is MurmurHash3 x86_32.
+func murmur3(data string, seed uint32) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	h := seed
+	n := len(data)
+	for len(data) >= 4 {
+		k := binary.LittleEndian.Uint32([]byte(data[:4]))
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+		data = data[4:]
+	}
+	var k uint32
+	switch len(data) {
+	case 3:
+		k ^= uint32(data[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(data[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(data[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+	h ^= uint32(n)
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
@@ -0,0 +1,96 @@
+package fingerprint
+
+import (
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentify(t *testing.T) {
+	header := http.Header{}
+	header.Set("Server", "nginx/1.25.3")
+	header.Add("Set-Cookie", "wp-settings-1=abc; path=/")
+	body := `<html><head><meta name="generator" content="WordPress 6.4.2"></head><body></body></html>`
+
+	techs := Identify(Response{Header: header, Body: body})
+	require.Len(t, techs, 3)
+	assert.Equal(t, Technology{Name: "nginx", Category: "Web server", Version: "1.25.3", Evidence: "header Server: nginx/1.25.3"}, techs[0])
+	assert.Equal(t, "WordPress", techs[1].Name)
+	assert.Equal(t, "cookie wp-settings-1", techs[1].Evidence, "cookies are checked before the body")
+	assert.Equal(t, Technology{Name: "PHP", Category: "Language", Evidence: "implied by WordPress"}, techs[2])
+	assert.Equal(t, "nginx 1.25.3, WordPress, PHP", techs.String())
+	assert.True(t, techs.Has("php"))
+	assert.False(t, techs.HasAny("Java", "Python"))
+
+	// Implications chain, and a technology seen directly is not implied again.
+	header = http.Header{}
+	header.Set("X-Powered-By", "Next.js 14.1.0")
+	header.Add("Set-Cookie", "connect.sid=s%3Aabc; Path=/")
+	techs = Identify(Response{Header: header})
+	assert.Equal(t, []string{"Express", "Next.js 14.1.0", "Node.js", "React"}, techs.Names())
+
+	// Favicon hashes identify technologies without any other clue.
+	techs = Identify(Response{Header: http.Header{}, FaviconHash: 116323821, HasFavicon: true})
+	assert.Equal(t, []string{"Spring Boot", "Java"}, techs.Names())
+	assert.Empty(t, Identify(Response{Header: http.Header{}, FaviconHash: 116323821}))
+}
+
+func TestAddTechnologyRules(t *testing.T) {
+	saved := payloads.TechnologyRules
+	defer func() { payloads.TechnologyRules = saved }()
+
+	err := payloads.AddTechnologyRules([]payloads.TechnologyRule{
+		{Name: "Example CMS", Category: "CMS", Headers: map[string]string{"x-generator": `ExampleCMS ([\d.]+)`}, Implies: []string{"PHP"}},
+		{Name: "Broken", Body: []string{`(`}},
+		{Body: []string{`x`}},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `"Broken"`)
+	assert.Contains(t, err.Error(), "no name")
+	assert.Len(t, payloads.TechnologyRules, len(saved)+1)
+
+	header := http.Header{}
+	header.Set("X-Generator", "ExampleCMS 2.1")
+	techs := Identify(Response{Header: header})
+	assert.Equal(t, []string{"Example CMS 2.1", "PHP"}, techs.Names())
+}
+
+func TestMurmur3(t *testing.T) {
+	assert.Equal(t, uint32(0), murmur3("", 0))
+	assert.Equal(t, uint32(0x514e28b7), murmur3("", 1))
+	assert.Equal(t, uint32(0x248bfa47), murmur3("hello", 0))
+	assert.Equal(t, uint32(0x2e4ff723), murmur3("The quick brown fox jumps over the lazy dog", 0))
+}
+
+func TestAnalyzeFavicon(t *testing.T) {
+	icon := []byte("\x00\x00\x01\x00 example icon")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/static/app.ico":
+			w.Write(icon)
+		case "/":
+			w.Header().Set("Server", "gunicorn/21.2.0")
+			w.Write([]byte(`<html><head><link rel="icon" href="/static/app.ico"></head><body></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	saved := payloads.TechnologyRules
+	defer func() { payloads.TechnologyRules = saved }()
+	require.NoError(t, payloads.AddTechnologyRules([]payloads.TechnologyRule{{Name: "Example App", Favicons: []int32{FaviconHash(icon)}}}))
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, FollowRedirects: true})
+	result, err := NewFingerprinter(client, log).Analyze(server.URL + "/")
+	require.NoError(t, err)
+	assert.Equal(t, "gunicorn/21.2.0", result.Fingerprint["WebServer"])
+	assert.Equal(t, []string{"Gunicorn 21.2.0", "Example App", "Python"}, result.Technologies.Names())
+}
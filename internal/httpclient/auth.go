@@ -0,0 +1,252 @@
+package httpclient
+
+import (
+	"Dursgo/internal/logger"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sync"
+)
+
+// ErrSessionLost is returned by Do once the authenticated session was lost and could not be
+// re-established, for the request that found it lost and every later one, so that the scan
+// stops instead of testing the logged-out site.
+var ErrSessionLost = errors.New("authenticated session lost")
+
+// Session verification defaults, used when SessionGuardOptions leaves a setting unset.
+const (
+	DefaultSessionRecheck       = 200
+	DefaultMaxReauthentications = 5
+)
+
+// minSuspectGap is the number of requests after a verification during which logged-out
+// responses do not trigger another one, so that a login page served to many tests (e.g., failed
+// login bypasses) does not have the session verified after each of them.
+const minSuspectGap = 20
+
+// SessionGuardOptions configures how a client verifies that its session is still authenticated.
+type SessionGuardOptions struct {
+	VerifyURL     string         // Page only an authenticated user can see, fetched without following redirects.
+	VerifyPattern *regexp.Regexp // Must match the body of VerifyURL; nil accepts any body.
+	VerifyStatus  int            // Status VerifyURL must answer (0 = any 2xx).
+	// LoggedOut matches the responses served to a logged-out user (e.g., the login form); such
+	// a response has the session verified at once. nil verifies it every RecheckEvery requests only.
+	LoggedOut    *regexp.Regexp
+	RecheckEvery int // Requests between verifications (0 = DefaultSessionRecheck, negative = never).
+	// Relogin logs in again and returns the new session cookies as a "Cookie" header value. With
+	// nil (static credentials), a lost session is given up.
+	Relogin              func() (string, error)
+	MaxReauthentications int    // Re-logins allowed before the session is given up (0 = DefaultMaxReauthentications).
+	TargetBaseURL        string // Base URL the cookies returned by Relogin are scoped to.
+}
+
+// SessionStats describes the verification of the authenticated session of a scan.
+type SessionStats struct {
+	Verified          bool   `json:"verified"`          // VerifyURL confirmed the session before the scan.
+	Checks            int    `json:"checks"`            // Verifications during the scan.
+	Reauthentications int    `json:"reauthentications"` // Times the session was lost and re-established by logging in again.
+	Lost              bool   `json:"lost,omitempty"`    // The session was given up; later requests were not sent.
+	Reason            string `json:"reason,omitempty"`  // Why the session was last found lost.
+}
+
+// sessionGuard verifies the session of a client and every copy derived from it, and logs in
+// again when it is lost.
+type sessionGuard struct {
+	opts    SessionGuardOptions
+	log     *logger.Logger
+	checker *Client  // Sends the verification requests: the client without the guard, not following redirects.
+	session *Session // Jar the cookies of Relogin are stored in.
+	// mu is held while the session is re-established, which holds back every request.
+	mu         sync.Mutex
+	stats      SessionStats
+	generation int   // Incremented with every re-login.
+	sent       int64 // Requests sent since the guard was set.
+	lastCheck  int64 // Value of sent at the last verification.
+}
+
+// SetSessionGuard makes the client, and every copy derived from it afterwards except those
+// with a session of their own (WithSession, DoWithSession), verify that its session is still
+// authenticated: every opts.RecheckEvery requests and after a response matching opts.LoggedOut,
+// opts.VerifyURL is fetched and must answer opts.VerifyStatus and match opts.VerifyPattern. A
+// lost session is re-established with opts.Relogin and the request is sent again; once that
+// fails or opts.MaxReauthentications is reached, requests fail with ErrSessionLost. It must be
+// set after the other shared settings (e.g., SetBlockDetection), which the verification
+// requests use too.
+func (c *Client) SetSessionGuard(opts SessionGuardOptions) {
+	if opts.RecheckEvery == 0 {
+		opts.RecheckEvery = DefaultSessionRecheck
+	}
+	if opts.MaxReauthentications <= 0 {
+		opts.MaxReauthentications = DefaultMaxReauthentications
+	}
+	checker := *c
+	httpClient := *c.httpClient
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	checker.httpClient = &httpClient
+	checker.source = Source{Phase: "auth"}
+	checker.guard = nil
+	c.guard = &sessionGuard{opts: opts, log: c.logger, checker: &checker, session: c.session}
+}
+
+// VerifySession fetches the verification URL of SetSessionGuard and returns an error unless it
+// shows an authenticated session. It is meant to run once after the login, so that a scan never
+// silently tests the logged-out site.
+func (c *Client) VerifySession(ctx context.Context) error {
+	if c.guard == nil {
+		return errors.New("session verification is not configured")
+	}
+	lost, err := c.guard.verify(ctx)
+	if err != nil {
+		return err
+	}
+	if lost != "" {
+		return errors.New(lost)
+	}
+	c.guard.mu.Lock()
+	c.guard.stats.Verified = true
+	c.guard.mu.Unlock()
+	return nil
+}
+
+// SessionStats returns the verifications and re-authentications of the session so far.
+func (c *Client) SessionStats() SessionStats {
+	if c.guard == nil {
+		return SessionStats{}
+	}
+	c.guard.mu.Lock()
+	defer c.guard.mu.Unlock()
+	return c.guard.stats
+}
+
+// SessionLost reports whether the authenticated session was lost and given up.
+func (c *Client) SessionLost() bool {
+	return c.SessionStats().Lost
+}
+
+// do sends req with c, verifying the session after the response when it is due, and sends req
+// again once a lost session was re-established.
+func (g *sessionGuard) do(c *Client, req *http.Request) (*http.Response, error) {
+	g.mu.Lock()
+	if g.stats.Lost {
+		g.mu.Unlock()
+		return nil, fmt.Errorf("%w: %s", ErrSessionLost, g.stats.Reason)
+	}
+	generation := g.generation
+	g.mu.Unlock()
+
+	resp, err := c.send(req)
+	if err != nil {
+		return resp, err
+	}
+	suspect := g.opts.LoggedOut != nil && g.opts.LoggedOut.Match(peekBody(resp, blockPeekBytes))
+	if !g.due(suspect) {
+		return resp, nil
+	}
+	ctx := req.Context()
+	if c.ctx != nil && ctx == context.Background() {
+		ctx = c.ctx
+	}
+	lost, err := g.verify(ctx)
+	if err != nil {
+		// The session cannot be told lost from a failed verification request.
+		g.log.Debug("Session verification failed: %v", err)
+		return resp, nil
+	}
+	if lost == "" {
+		return resp, nil
+	}
+	resp.Body.Close()
+	if err := g.reestablish(ctx, generation, lost); err != nil {
+		return nil, err
+	}
+	return c.send(req)
+}
+
+// due counts a request and reports whether the session must be verified after it: every
+// RecheckEvery requests, and after a logged-out looking response unless the session was just
+// verified.
+func (g *sessionGuard) due(suspect bool) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sent++
+	since := g.sent - g.lastCheck
+	if (suspect && (g.stats.Checks == 0 || since >= minSuspectGap)) || (g.opts.RecheckEvery > 0 && since >= int64(g.opts.RecheckEvery)) {
+		g.lastCheck = g.sent
+		g.stats.Checks++
+		return true
+	}
+	return false
+}
+
+// verify fetches the verification URL. It returns why the session is not authenticated, or ""
+// if it is, and an error if the verification request failed.
+func (g *sessionGuard) verify(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.opts.VerifyURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid verification URL: %w", err)
+	}
+	resp, err := g.checker.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("verification request to %s failed: %w", g.opts.VerifyURL, err)
+	}
+	defer resp.Body.Close()
+	body, _, err := g.checker.ReadBody(resp)
+	if err != nil {
+		return "", fmt.Errorf("reading the response of %s failed: %w", g.opts.VerifyURL, err)
+	}
+	switch {
+	case g.opts.VerifyStatus != 0 && resp.StatusCode != g.opts.VerifyStatus:
+		return fmt.Sprintf("%s answered %s instead of %d", g.opts.VerifyURL, resp.Status, g.opts.VerifyStatus), nil
+	case g.opts.VerifyStatus == 0 && (resp.StatusCode < 200 || resp.StatusCode > 299):
+		return fmt.Sprintf("%s answered %s", g.opts.VerifyURL, resp.Status), nil
+	case g.opts.VerifyPattern != nil && !g.opts.VerifyPattern.Match(body):
+		return fmt.Sprintf("%s does not match %q", g.opts.VerifyURL, g.opts.VerifyPattern), nil
+	}
+	return "", nil
+}
+
+// reestablish logs in again after a request sent with the session of generation found it lost
+// for reason, unless another request did meanwhile. Requests are held back until it returns. It
+// gives the session up and returns an ErrSessionLost error when no re-login is configured, the
+// re-logins are used up, or the login fails or does not yield an authenticated session.
+func (g *sessionGuard) reestablish(ctx context.Context, generation int, reason string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	switch {
+	case g.stats.Lost:
+		return fmt.Errorf("%w: %s", ErrSessionLost, g.stats.Reason)
+	case g.generation != generation:
+		return nil // Another request logged in again meanwhile.
+	}
+	g.stats.Reason = reason
+	g.log.Warn("Authenticated session lost: %s.", reason)
+	if g.opts.Relogin == nil {
+		return g.giveUp(reason + "; no login is configured to re-authenticate")
+	}
+	if g.stats.Reauthentications >= g.opts.MaxReauthentications {
+		return g.giveUp(fmt.Sprintf("%s; already re-authenticated %d time(s)", reason, g.stats.Reauthentications))
+	}
+	cookie, err := g.opts.Relogin()
+	if err != nil {
+		return g.giveUp(fmt.Sprintf("%s; re-login failed: %v", reason, err))
+	}
+	setStaticCookie(g.session, g.opts.TargetBaseURL, cookie, g.log)
+	g.stats.Reauthentications++
+	g.generation++
+	if lost, _ := g.verify(ctx); lost != "" {
+		return g.giveUp(fmt.Sprintf("%s; still logged out after re-login: %s", reason, lost))
+	}
+	g.lastCheck = g.sent
+	g.log.Success("Re-authenticated; the scan continues (re-authentication %d of at most %d).", g.stats.Reauthentications, g.opts.MaxReauthentications)
+	return nil
+}
+
+// giveUp marks the session lost for reason, so that every later request fails. g.mu must be
+// held.
+func (g *sessionGuard) giveUp(reason string) error {
+	g.stats.Lost, g.stats.Reason = true, reason
+	g.log.Error("!!! Authenticated session lost and not re-established (%s); the remaining requests are not sent.", reason)
+	return fmt.Errorf("%w: %s", ErrSessionLost, reason)
+}
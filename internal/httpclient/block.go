@@ -0,0 +1,220 @@
+package httpclient
+
+import (
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrBlocked is returned by Do when the response is a WAF block page, when a host keeps
+// answering 403 or 429, and for every request to a host given up after repeated blocking. The
+// response is discarded, so findings are never based on block pages.
+var ErrBlocked = errors.New("blocked by WAF or rate limiting")
+
+// Block detection defaults, used when BlockDetectionOptions leaves a setting unset.
+const (
+	DefaultBlockStreak     = 20
+	DefaultBlockPause      = 30 * time.Second
+	DefaultBlockMaxStrikes = 5
+)
+
+// Limits of the reaction to blocking.
+const (
+	maxBlockPause    = 5 * time.Minute  // Cap of pauses, including those asked for with Retry-After.
+	minBlockThrottle = time.Second      // Delay before each request to a host after it first blocks.
+	maxBlockThrottle = 10 * time.Second // Cap of the delay, doubled each time the host blocks.
+	blockPeekBytes   = 64 << 10         // Body prefix matched against WAF fingerprints.
+)
+
+// BlockDetectionOptions configures how a client detects and reacts to blocking.
+type BlockDetectionOptions struct {
+	Streak     int           // Consecutive 403/429 responses from a host that mean it blocks (0 = DefaultBlockStreak).
+	Pause      time.Duration // Pause of a host each time it blocks (0 = DefaultBlockPause); longer if Retry-After asks.
+	MaxStrikes int           // Times a host may block before it is given up (0 = DefaultBlockMaxStrikes).
+}
+
+// BlockedHost describes a host that blocked the scan.
+type BlockedHost struct {
+	Host             string `json:"host"`
+	WAF              string `json:"waf,omitempty"`     // WAF recognized from its block page, if any.
+	Reason           string `json:"reason"`            // Last blocking signal.
+	Strikes          int    `json:"strikes"`           // Times the host was found blocking.
+	BlockedResponses int    `json:"blocked_responses"` // Responses discarded as block pages.
+	Aborted          bool   `json:"aborted"`           // The host was given up; later requests to it were not sent.
+}
+
+// hostBlock is the blocking state of a host.
+type hostBlock struct {
+	BlockedHost
+	streak      int           // Consecutive 403/429 or block page responses.
+	throttle    time.Duration // Delay before each request to the host.
+	pausedUntil time.Time
+}
+
+// blockDetector watches the responses of a client and every copy derived from it for blocking,
+// and throttles, pauses and finally gives up hosts that block.
+type blockDetector struct {
+	opts        BlockDetectionOptions
+	log         *logger.Logger
+	minThrottle time.Duration
+	mu          sync.Mutex
+	hosts       map[string]*hostBlock
+}
+
+// SetBlockDetection makes the client, and every copy derived from it afterwards, detect WAF
+// block pages (payloads.WAFFingerprints), streaks of 403/429 responses and Retry-After headers.
+// A host that blocks is paused and throttled, and given up after opts.MaxStrikes blockings;
+// block pages and requests to given-up hosts fail with ErrBlocked.
+func (c *Client) SetBlockDetection(opts BlockDetectionOptions) {
+	if opts.Streak <= 0 {
+		opts.Streak = DefaultBlockStreak
+	}
+	if opts.Pause <= 0 {
+		opts.Pause = DefaultBlockPause
+	}
+	if opts.MaxStrikes <= 0 {
+		opts.MaxStrikes = DefaultBlockMaxStrikes
+	}
+	c.blocks = &blockDetector{opts: opts, log: c.logger, minThrottle: minBlockThrottle, hosts: make(map[string]*hostBlock)}
+}
+
+// BlockedHosts returns the hosts that blocked the scan, sorted by host.
+func (c *Client) BlockedHosts() []BlockedHost {
+	if c.blocks == nil {
+		return nil
+	}
+	c.blocks.mu.Lock()
+	defer c.blocks.mu.Unlock()
+	var hosts []BlockedHost
+	for _, h := range c.blocks.hosts {
+		if h.Strikes > 0 {
+			hosts = append(hosts, h.BlockedHost)
+		}
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].Host < hosts[j].Host })
+	return hosts
+}
+
+// HostAborted reports whether the host of rawURL was given up after repeated blocking.
+func (c *Client) HostAborted(rawURL string) bool {
+	if c.blocks == nil {
+		return false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	c.blocks.mu.Lock()
+	defer c.blocks.mu.Unlock()
+	h := c.blocks.hosts[u.Host]
+	return h != nil && h.Aborted
+}
+
+// before waits out the pause and throttle of host before a request is sent to it, and fails
+// with ErrBlocked if the host was given up.
+func (d *blockDetector) before(ctx context.Context, host string) error {
+	d.mu.Lock()
+	h := d.hosts[host]
+	if h == nil {
+		d.mu.Unlock()
+		return nil
+	}
+	if h.Aborted {
+		d.mu.Unlock()
+		return fmt.Errorf("%w: %s was given up (%s)", ErrBlocked, host, h.Reason)
+	}
+	wait := time.Until(h.pausedUntil)
+	if wait < 0 {
+		wait = 0
+	}
+	wait += h.throttle
+	d.mu.Unlock()
+	return sleepContext(ctx, wait)
+}
+
+// after inspects a response from host for blocking. It returns an ErrBlocked error when the
+// response must be discarded: a WAF block page, a response of a 403/429 streak, or any response
+// once the host is given up.
+func (d *blockDetector) after(host string, resp *http.Response) error {
+	status := resp.StatusCode
+	waf, blockPage := "", false
+	if payloads.WAFStatus(status) {
+		waf, blockPage = payloads.MatchWAF(status, resp.Header, peekBody(resp, blockPeekBytes))
+	}
+	denied := status == http.StatusForbidden || status == http.StatusTooManyRequests
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	h := d.hosts[host]
+	if !blockPage && !denied {
+		if h != nil {
+			h.streak = 0
+		}
+		return nil
+	}
+	if h == nil {
+		h = &hostBlock{BlockedHost: BlockedHost{Host: host}}
+		d.hosts[host] = h
+	}
+	h.streak++
+
+	var signal string
+	pause := d.opts.Pause
+	switch wait := retryAfter(resp); {
+	case blockPage:
+		h.WAF = waf
+		signal = waf + " block page (" + resp.Status + ")"
+	case h.streak >= d.opts.Streak:
+		signal = fmt.Sprintf("%d consecutive 403/429 responses", h.streak)
+	case status == http.StatusTooManyRequests:
+		signal = "rate limited (429 Too Many Requests)"
+		if wait > pause {
+			pause = wait
+		}
+	}
+	// Signals of requests that were in flight when the host was paused do not count again.
+	if signal != "" && !h.Aborted && time.Now().After(h.pausedUntil) {
+		d.strike(h, signal, pause)
+	}
+
+	if blockPage || h.streak >= d.opts.Streak || h.Aborted {
+		h.BlockedResponses++
+		return fmt.Errorf("%w: %s (%s)", ErrBlocked, host, h.Reason)
+	}
+	return nil
+}
+
+// strike records a blocking of h: the host is paused and throttled, or given up after
+// MaxStrikes blockings.
+func (d *blockDetector) strike(h *hostBlock, signal string, pause time.Duration) {
+	h.Strikes++
+	h.Reason = signal
+	if h.Strikes >= d.opts.MaxStrikes {
+		h.Aborted = true
+		d.log.Warn("!!! Giving up %s after it blocked the scan %d times (%s). Results for this host are INCOMPLETE.", h.Host, h.Strikes, signal)
+		return
+	}
+	pause = min(pause, maxBlockPause)
+	h.pausedUntil = time.Now().Add(pause)
+	h.throttle = min(max(2*h.throttle, d.minThrottle), maxBlockThrottle)
+	d.log.Warn("%s is blocking the scan (%s). Pausing it for %v, then waiting %v before each request (strike %d of %d).", h.Host, signal, pause, h.throttle, h.Strikes, d.opts.MaxStrikes)
+}
+
+// peekBody returns up to limit bytes of the body of resp, leaving the body readable in full.
+func peekBody(resp *http.Response, limit int64) []byte {
+	prefix, _ := io.ReadAll(io.LimitReader(resp.Body, limit))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(prefix), resp.Body), resp.Body}
+	return prefix
+}
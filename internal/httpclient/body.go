@@ -0,0 +1,104 @@
+package httpclient
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultMaxResponseBytes is the size response bodies are cut off at when
+// ClientOptions.MaxResponseBytes is zero.
+const DefaultMaxResponseBytes = 5 << 20
+
+// DefaultBodyReadTimeout is the time allowed for reading a response body once its headers
+// arrived, when ClientOptions.BodyReadTimeout is zero.
+const DefaultBodyReadTimeout = 10 * time.Second
+
+// ErrBodyTruncated is returned by scanner helpers for response bodies cut off at the response
+// size limit or the body read timeout, where comparing them would compare partial content.
+var ErrBodyTruncated = errors.New("response body truncated at the size limit or read timeout")
+
+// limitedBody is a response body cut off after max bytes or when the body read timeout
+// expires, so that a huge download or an endless stream (e.g., server-sent events) can neither
+// exhaust memory nor hang a scanner. Reading it then ends with io.EOF and Truncated reports it.
+type limitedBody struct {
+	body      io.ReadCloser
+	limited   bool  // Whether remaining applies.
+	remaining int64 // Bytes left before the size limit.
+	timer     *time.Timer
+	timedOut  atomic.Bool
+	truncated bool
+	done      bool
+}
+
+// limitBody wraps the body of resp in the client's response size limit and body read timeout.
+func (c *Client) limitBody(resp *http.Response) {
+	if c.maxBodyBytes <= 0 && c.bodyTimeout <= 0 {
+		return
+	}
+	b := &limitedBody{body: resp.Body, limited: c.maxBodyBytes > 0, remaining: c.maxBodyBytes}
+	if c.bodyTimeout > 0 {
+		b.timer = time.AfterFunc(c.bodyTimeout, func() {
+			b.timedOut.Store(true)
+			b.body.Close() // Unblocks a pending Read.
+		})
+	}
+	resp.Body = b
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.done {
+		return 0, io.EOF
+	}
+	if b.limited && b.remaining == 0 {
+		// Find out whether the body goes on, without keeping more of it.
+		var probe [1]byte
+		if n, _ := io.ReadFull(b.body, probe[:]); n > 0 {
+			b.truncated = true
+		}
+		b.done = true
+		return 0, io.EOF
+	}
+	if b.limited && int64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.body.Read(p)
+	b.remaining -= int64(n)
+	if err != nil && b.timedOut.Load() {
+		b.truncated, b.done = true, true
+		return n, io.EOF
+	}
+	return n, err
+}
+
+func (b *limitedBody) Close() error {
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	return b.body.Close()
+}
+
+// Truncated reports whether the body of resp, a response of Do, was cut off at the response
+// size limit or the body read timeout. It is only known once the body was read to its end.
+// Comparisons of truncated bodies are unreliable, as they compare partial content.
+func Truncated(resp *http.Response) bool {
+	b, ok := resp.Body.(*limitedBody)
+	return ok && b.truncated
+}
+
+// ReadBody reads the body of resp, at most the client's response size limit, and reports
+// whether it was truncated. Bodies of responses from Do are already limited; ReadBody also
+// limits those of clients from GetClient and GetClientWithoutRedirects.
+func (c *Client) ReadBody(resp *http.Response) ([]byte, bool, error) {
+	if _, ok := resp.Body.(*limitedBody); ok || c.maxBodyBytes <= 0 {
+		body, err := io.ReadAll(resp.Body)
+		return body, Truncated(resp), err
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, c.maxBodyBytes+1))
+	if int64(len(body)) > c.maxBodyBytes {
+		return body[:c.maxBodyBytes], true, err
+	}
+	return body, false, err
+}
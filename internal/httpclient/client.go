@@ -3,23 +3,51 @@ package httpclient
 import (
 	"Dursgo/internal/logger"
 	"bytes"
-	"crypto/tls"
+	"context"
+	"crypto/x509"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
-	"net/http/cookiejar"
 	"net/url"
+	"os"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 // Client represents a custom HTTP client for Dursgo, encapsulating http.Client and custom behaviors.
 type Client struct {
-	httpClient   *http.Client      // The underlying standard HTTP client.
-	logger       *logger.Logger    // Logger for client-related messages.
-	userAgent    string            // Custom User-Agent header for requests.
-	maxRetries   int               // Maximum number of retries for failed requests.
-	requestDelay time.Duration     // Delay between retries.
-	authHeaders  map[string]string // Authentication headers to be added to requests.
+	httpClient   *http.Client              // The underlying standard HTTP client.
+	logger       *logger.Logger            // Logger for client-related messages.
+	userAgent    string                    // Custom User-Agent header for requests.
+	userAgents   []string                  // User-Agents rotated per request; empty means userAgent.
+	headers      map[string]string         // Default headers added unless a request sets them.
+	cookies      []*http.Cookie            // Default cookies added unless a request or the jar sends them.
+	maxRetries   int                       // Maximum number of retries for failed requests.
+	requestDelay time.Duration             // Delay added to the backoff before retries.
+	retryBackoff time.Duration             // Wait before the first retry, doubled for each further one.
+	retries      *retryCounters            // Shared retry counters, see RetryStats.
+	authHeaders  map[string]string         // Authentication headers to be added to requests.
+	overrides    map[string]string         // Headers bound with WithHeaders, replacing any other value.
+	ctx          context.Context           // Context bound with WithContext; nil means none.
+	limiter      *tokenBucket              // Shared rate limiter; nil means unlimited.
+	hostSlots    *hostSlots                // Shared per-host concurrency cap; nil means unlimited.
+	blocks       *blockDetector            // Shared WAF and rate limit block detection; nil means off.
+	maxBodyBytes int64                     // Response bodies are cut off after this many bytes; 0 means unlimited.
+	bodyTimeout  time.Duration             // Time allowed for reading a response body; 0 means none.
+	counter      *atomic.Int64             // Request counter bound with WithRequestCounter.
+	sent         *atomic.Int64             // Shared count of all requests sent, see RequestsSent.
+	budgetSkips  *atomic.Int64             // Shared count of requests refused by budgets, see BudgetSkippedRequests.
+	budget       *requestBudget            // Request budget bound with WithRequestBudget.
+	exempt       bool                      // Rate limit exemption bound with WithRateLimitExemption.
+	requestHook  func(*http.Request) error // Hook bound with WithRequestHook.
+	credentials  bool                      // Whether a static cookie or auth headers were configured.
+	source       Source                    // Tag of the requests in the HAR file, see WithSource.
+	control      *scanControl              // Shared pause switch and skipped hosts; nil means off.
+	session      *Session                  // Cookie jar of httpClient, shared with copies.
+	guard        *sessionGuard             // Shared session verification; nil means off.
+	admitted     func()                    // Called when a request is sent after the client's waits, see DoTimed.
 }
 
 // ClientOptions holds configuration parameters for initializing the HTTP Client.
@@ -29,10 +57,28 @@ type ClientOptions struct {
 	InsecureSkipVerify bool              // Whether to skip TLS certificate verification.
 	UserAgent          string            // Custom User-Agent string.
 	MaxRetries         int               // Maximum number of retries for requests.
-	RequestDelay       time.Duration     // Delay between retries.
+	RequestDelay       time.Duration     // Delay added to the backoff before retries.
+	RetryBackoff       time.Duration     // Wait before the first retry of a transient failure (DefaultRetryBackoff when not positive).
 	TargetBaseURL      string            // Base URL of the target, used for cookie scope.
 	AuthCookie         string            // Static cookie string for authentication.
 	AuthHeaders        map[string]string // Static headers for authentication.
+	ProxyURL           string            // Upstream proxy: http://, https:// or socks5://, with optional user:password@.
+	CACertFile         string            // PEM bundle of extra CAs to trust (e.g., the CA of an intercepting proxy).
+	ClientCertFile     string            // PEM client certificate for mTLS; may also hold the key.
+	ClientKeyFile      string            // PEM private key of ClientCertFile (default: read from ClientCertFile).
+	ServerName         string            // TLS server name (SNI) sent and verified instead of the host of the URL.
+	MinTLSVersion      string            // Minimum TLS version: "1.0", "1.1", "1.2" (default) or "1.3".
+	HTTPVersion        string            // "1.1" (default) or "2" to negotiate HTTP/2 over TLS.
+	Resolver           string            // DNS server (host or host:port) resolving host names instead of the system resolver.
+	Resolve            []string          // curl-style "host:port:address" overrides, see ParseResolve.
+	MaxResponseBytes   int64             // Size response bodies are cut off at (0 = DefaultMaxResponseBytes, negative = unlimited).
+	BodyReadTimeout    time.Duration     // Time allowed for reading a response body (0 = DefaultBodyReadTimeout, negative = none).
+	Headers            map[string]string // Default headers for every request (e.g., X-API-Key), unless the request sets them.
+	Cookies            string            // Default cookies for every request ("a=1; b=2"), unless the request or cookie jar sends them.
+	UserAgents         []string          // User-Agents picked at random per request instead of UserAgent.
+	HAR                *HARRecorder      // Records every request and response to a HAR file; nil records nothing.
+	Observe            ResponseObserver  // Called with every response and its body (e.g., passive analysis); nil means none.
+	Metrics            RequestObserver   // Called with the timing of every request (scan statistics); nil means none.
 }
 
 // NewClient creates and returns a new HTTP client instance with specified options.
@@ -49,47 +95,80 @@ func NewClient(log *logger.Logger, opts ClientOptions) *Client {
 	if opts.MaxRetries < 0 {
 		opts.MaxRetries = 0
 	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = DefaultRetryBackoff
+	}
+	if opts.MaxResponseBytes == 0 {
+		opts.MaxResponseBytes = DefaultMaxResponseBytes
+	}
+	if opts.BodyReadTimeout == 0 {
+		opts.BodyReadTimeout = DefaultBodyReadTimeout
+	}
 
-	// Initialize cookie jar for session management.
-	jar, _ := cookiejar.New(nil)
-	// Configure TLS transport, allowing insecure skip verify if specified.
-	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify},
+	// Initialize cookie jar for session management, with a separate jar per site.
+	jar := NewSession()
+	// Configure the transport: TLS, HTTP version and upstream proxy. Every request path (Do,
+	// DoWithSession, GetClientWithoutRedirects, sessions) shares it.
+	transport, err := newTransport(opts)
+	if err != nil {
+		// Never bypass a misconfigured proxy or TLS setting: fail every request instead.
+		// CheckTransport reports the error at startup.
+		log.Error("Invalid HTTP client transport options: %v", err)
+		transport = &http.Transport{Proxy: func(*http.Request) (*url.URL, error) { return nil, err }}
+	}
+	var roundTripper http.RoundTripper = transport
+	if opts.HAR != nil {
+		roundTripper = &harTransport{next: transport, recorder: opts.HAR}
+	}
+	if opts.Observe != nil {
+		roundTripper = &observeTransport{next: roundTripper, observe: opts.Observe}
+	}
+	if opts.Metrics != nil {
+		roundTripper = &metricsTransport{next: roundTripper, observe: opts.Metrics}
 	}
 
 	// Create the custom Client instance.
 	client := &Client{
 		httpClient: &http.Client{
 			Timeout:   opts.Timeout,
-			Transport: transport,
+			Transport: roundTripper,
 			Jar:       jar,
 		},
 		logger:       log,
 		userAgent:    opts.UserAgent,
+		userAgents:   opts.UserAgents,
+		headers:      canonicalHeaders(opts.Headers),
+		cookies:      parseCookies(opts.Cookies),
 		maxRetries:   opts.MaxRetries,
 		requestDelay: opts.RequestDelay,
+		retryBackoff: opts.RetryBackoff,
+		retries:      &retryCounters{},
+		sent:         new(atomic.Int64),
+		budgetSkips:  new(atomic.Int64),
+		maxBodyBytes: max(opts.MaxResponseBytes, 0),
+		bodyTimeout:  max(opts.BodyReadTimeout, 0),
 		authHeaders:  opts.AuthHeaders,
+		credentials:  opts.AuthCookie != "" || len(opts.AuthHeaders) > 0,
+		session:      jar,
 	}
 
 	// Set static authentication cookie if provided.
 	if opts.AuthCookie != "" {
 		log.Info("Static cookie authentication configured.")
-		targetURL, err := url.Parse(opts.TargetBaseURL)
-		if err != nil {
-			log.Error("Failed to parse target URL for setting cookie: %v", err)
-		} else {
-			header := http.Header{}
-			header.Add("Cookie", opts.AuthCookie)
-			request := http.Request{Header: header}
-			client.httpClient.Jar.SetCookies(targetURL, request.Cookies())
-			log.Debug("Static session cookie set for domain %s", targetURL.Host)
-		}
+		setStaticCookie(jar, opts.TargetBaseURL, opts.AuthCookie, log)
 	}
 
 	// Log if static header authentication is configured.
 	if len(opts.AuthHeaders) > 0 {
 		log.Info("Static header authentication configured.")
 	}
+	if len(client.headers) > 0 {
+		header := http.Header{}
+		for name, value := range client.headers {
+			header.Set(name, value)
+		}
+		log.Debug("Default headers: %s", formatHeaders(header))
+	}
 
 	// Configure redirect policy for the HTTP client.
 	client.httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
@@ -105,10 +184,236 @@ func NewClient(log *logger.Logger, opts ClientOptions) *Client {
 	return client // Return the initialized client.
 }
 
+// newTransport creates the transport of a client from the TLS, HTTP version, DNS and proxy
+// settings of opts. Proxy environment variables are ignored; only ProxyURL is used.
+func newTransport(opts ClientOptions) (*http.Transport, error) {
+	tlsConfig, err := newTLSConfig(opts)
+	if err != nil {
+		return nil, err
+	}
+	dial, err := newDialContext(opts)
+	if err != nil {
+		return nil, err
+	}
+	transport := &http.Transport{TLSClientConfig: tlsConfig, DialContext: dial}
+	if err := configureHTTPVersion(transport, opts.HTTPVersion); err != nil {
+		return nil, err
+	}
+	if opts.ProxyURL != "" {
+		proxyURL, err := ParseProxyURL(opts.ProxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return transport, nil
+}
+
+// loadCertPool returns the system certificate pool extended with the PEM certificates of path.
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("CA bundle %s holds no PEM certificate", path)
+	}
+	return pool, nil
+}
+
+// ParseProxyURL parses an upstream proxy URL. The scheme defaults to http:// (e.g.,
+// "127.0.0.1:8080" for Burp); http, https and socks5 are supported, with credentials given as
+// user:password@.
+func ParseProxyURL(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	proxyURL, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q; use http, https or socks5", proxyURL.Scheme)
+	}
+	if proxyURL.Hostname() == "" {
+		return nil, fmt.Errorf("proxy URL %s has no host", proxyURL.Redacted())
+	}
+	if proxyURL.Port() == "" {
+		port := map[string]string{"http": "80", "https": "443", "socks5": "1080"}[proxyURL.Scheme]
+		proxyURL.Host = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+	return proxyURL, nil
+}
+
+// CheckTransport validates the proxy, TLS, HTTP version and DNS settings of opts and, when a proxy is set, checks
+// that it accepts connections within timeout, so that a bad proxy fails the scan at startup
+// rather than every request.
+func CheckTransport(opts ClientOptions, timeout time.Duration) error {
+	if _, err := newTransport(opts); err != nil {
+		return err
+	}
+	if opts.ProxyURL == "" {
+		return nil
+	}
+	proxyURL, _ := ParseProxyURL(opts.ProxyURL)
+	conn, err := net.DialTimeout("tcp", proxyURL.Host, timeout)
+	if err != nil {
+		return fmt.Errorf("cannot connect to proxy %s: %w", proxyURL.Redacted(), err)
+	}
+	conn.Close()
+	return nil
+}
+
+// setStaticCookie stores the cookies of a "Cookie" header value in jar for targetBaseURL.
+func setStaticCookie(jar http.CookieJar, targetBaseURL, cookie string, log *logger.Logger) {
+	targetURL, err := url.Parse(targetBaseURL)
+	if err != nil {
+		log.Error("Failed to parse target URL for setting cookie: %v", err)
+		return
+	}
+	header := http.Header{}
+	header.Add("Cookie", cookie)
+	request := http.Request{Header: header}
+	jar.SetCookies(targetURL, request.Cookies())
+	log.Debug("Static session cookie set for domain %s", targetURL.Host)
+}
+
+// WithSession returns a copy of the client that authenticates as a different user: it has its
+// own cookie jar holding only authCookie (scoped to targetBaseURL) and sends authHeaders instead
+// of the original authentication headers. Empty credentials yield an unauthenticated client.
+// Transport, rate limit, request counter, budget and context are shared with the original; the
+// session verification of SetSessionGuard is not.
+func (c *Client) WithSession(targetBaseURL, authCookie string, authHeaders map[string]string) *Client {
+	jar := NewSession()
+	if authCookie != "" {
+		setStaticCookie(jar, targetBaseURL, authCookie, c.logger)
+	}
+	session := *c
+	session.httpClient = &http.Client{
+		Timeout:       c.httpClient.Timeout,
+		Transport:     c.httpClient.Transport,
+		CheckRedirect: c.httpClient.CheckRedirect,
+		Jar:           jar,
+	}
+	session.session = jar
+	session.guard = nil
+	session.authHeaders = authHeaders
+	session.credentials = authCookie != "" || len(authHeaders) > 0
+	return &session
+}
+
+// Authenticated reports whether the client was configured with a static cookie or
+// authentication headers (including a cookie captured from a login).
+func (c *Client) Authenticated() bool {
+	return c.credentials
+}
+
+// WithContext returns a shallow copy of the client whose requests are bound to ctx, so that
+// cancelling ctx aborts in-flight requests and pending retries. Requests that already carry
+// their own context (http.NewRequestWithContext) keep it.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	bound := *c
+	bound.ctx = ctx
+	return &bound
+}
+
+// WithRequestHook returns a shallow copy of the client that passes every request to hook before
+// sending it (once, not per retry). The hook may rewrite the request, e.g. to refresh anti-CSRF
+// tokens; an error aborts the request.
+func (c *Client) WithRequestHook(hook func(*http.Request) error) *Client {
+	hooked := *c
+	hooked.requestHook = hook
+	return &hooked
+}
+
+// WithHeaders returns a shallow copy of the client whose requests carry headers, replacing the
+// User-Agent, the default headers and the values set by the request itself (e.g., to inject a
+// header into requests built by shared helpers). Authentication headers are still set after
+// them, and the Host header cannot be replaced this way; see DoWithHost.
+func (c *Client) WithHeaders(headers map[string]string) *Client {
+	overridden := *c
+	overridden.overrides = make(map[string]string, len(c.overrides)+len(headers))
+	for name, value := range c.overrides {
+		overridden.overrides[name] = value
+	}
+	for name, value := range canonicalHeaders(headers) {
+		overridden.overrides[name] = value
+	}
+	return &overridden
+}
+
+// AuthHeaders returns a copy of the authentication headers the client sends.
+func (c *Client) AuthHeaders() map[string]string {
+	headers := make(map[string]string, len(c.authHeaders))
+	for name, value := range c.authHeaders {
+		headers[name] = value
+	}
+	return headers
+}
+
+// WithAuthHeaders returns a shallow copy of the client that sends headers instead of its
+// authentication headers, keeping its cookie jar (e.g., to send a tampered bearer token along
+// with the session cookies). Unlike WithSession, whether the client is Authenticated is kept.
+func (c *Client) WithAuthHeaders(headers map[string]string) *Client {
+	replaced := *c
+	replaced.authHeaders = headers
+	return &replaced
+}
+
+// WithFixedUserAgent returns a shallow copy of the client that sends the same User-Agent, one of
+// the rotated User-Agents, with every request, for tests comparing responses a cache may key on
+// the User-Agent.
+func (c *Client) WithFixedUserAgent() *Client {
+	fixed := *c
+	fixed.userAgent = c.nextUserAgent()
+	fixed.userAgents = nil
+	return &fixed
+}
+
 // Do performs an HTTP request, including setting headers, handling retries, and adaptive rate-limiting.
+// With SetSessionGuard, the session is verified after the response when it is due.
 func (c *Client) Do(req *http.Request) (*http.Response, error) {
-	// Set the User-Agent header for the request.
-	req.Header.Set("User-Agent", c.userAgent)
+	if c.guard != nil {
+		return c.guard.do(c, req)
+	}
+	return c.send(req)
+}
+
+// send performs req as Do, without session verification.
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	// The bound context is applied to the clones actually sent, so the caller's request keeps
+	// its rewound body for evidence capture.
+	ctx := req.Context()
+	if c.ctx != nil && ctx == context.Background() {
+		ctx = c.ctx
+	}
+	ctx = c.withSource(ctx)
+	if c.budget != nil && !c.budget.take() {
+		if c.budgetSkips != nil {
+			c.budgetSkips.Add(1)
+		}
+		return nil, ErrRequestBudgetExhausted
+	}
+	if c.requestHook != nil {
+		if err := c.requestHook(req); err != nil {
+			return nil, err
+		}
+	}
+
+	// Set the User-Agent, default headers and default cookies, unless the caller set them
+	// explicitly (e.g., scanners injecting payloads into the User-Agent header).
+	c.applyDefaults(req)
+
+	for key, value := range c.overrides {
+		req.Header.Set(key, value)
+	}
 
 	// Add any configured authentication headers.
 	if len(c.authHeaders) > 0 {
@@ -118,25 +423,19 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 	}
 
 	c.logger.Trace("Sending request: %s %s", req.Method, req.URL.String())
-	// Log cookies being sent from the cookie jar.
+	c.logger.Trace("  -> Headers: %s", formatHeaders(req.Header))
+	// Log the names of the cookies being sent from the cookie jar; their values are secrets.
 	if cookies := c.httpClient.Jar.Cookies(req.URL); len(cookies) > 0 {
-		var cookieStrings []string
+		var cookieNames []string
 		for _, cookie := range cookies {
-			cookieStrings = append(cookieStrings, cookie.Name+"="+cookie.Value)
+			cookieNames = append(cookieNames, cookie.Name)
 		}
-		c.logger.Trace("  -> Cookies from Jar to be sent: %s", strings.Join(cookieStrings, "; "))
+		c.logger.Trace("  -> Cookies from Jar to be sent: %s", strings.Join(cookieNames, ", "))
 	}
 
-	var resp *http.Response
-	var err error
-
-	// Implement retry logic with exponential backoff for rate limits.
-	for i := 0; i <= c.maxRetries; i++ {
-		if i > 0 {
-			// Apply a base delay for regular retries.
-			time.Sleep(c.requestDelay)
-		}
-
+	// Send the request, retrying transient failures (network errors, 429, 502, 503 and 504)
+	// with exponential backoff.
+	for attempt := 0; ; attempt++ {
 		// Clone the request to allow retrying with a fresh body.
 		var reqClone *http.Request
 		if req.Body != nil {
@@ -144,44 +443,113 @@ func (c *Client) Do(req *http.Request) (*http.Response, error) {
 			bodyBytes, _ := io.ReadAll(req.Body)
 			req.Body.Close()
 			req.Body = io.NopCloser(bytes.NewReader(bodyBytes))
-			reqClone = req.Clone(req.Context())
+			reqClone = req.Clone(ctx)
 			reqClone.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 		} else {
-			reqClone = req.Clone(req.Context())
+			reqClone = req.Clone(ctx)
 		}
 
-		// Execute the HTTP request.
+		// Wait while the scan is paused, wait out pauses of a blocking host, respect the global
+		// rate limit, then execute the HTTP request.
+		if c.control != nil {
+			if err := c.control.before(ctx, reqClone.URL.Host); err != nil {
+				return nil, err
+			}
+		}
+		if c.blocks != nil {
+			if err := c.blocks.before(ctx, reqClone.URL.Host); err != nil {
+				return nil, err
+			}
+		}
+		if c.limiter != nil && !c.exempt {
+			if err := c.limiter.Wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+		if c.counter != nil {
+			c.counter.Add(1)
+		}
+		if c.sent != nil {
+			c.sent.Add(1)
+		}
+		var resp *http.Response
+		var err error
+		if c.hostSlots != nil {
+			if err := c.hostSlots.acquire(ctx, reqClone.URL.Host); err != nil {
+				return nil, err
+			}
+		}
+		if c.admitted != nil {
+			c.admitted()
+		}
 		resp, err = c.httpClient.Do(reqClone)
+		if c.hostSlots != nil {
+			c.hostSlots.hold(reqClone.URL.Host, resp, err)
+		}
 
-		// --- Rate Limit and Server Error Handling Logic ---
-		if err == nil {
-			// Condition 1: Request successful (not 429 or 5xx).
-			if resp.StatusCode != http.StatusTooManyRequests && (resp.StatusCode < 500 || resp.StatusCode > 599) {
-				return resp, nil // Return successful response.
+		if err == nil && c.blocks != nil && !c.exempt {
+			if blockErr := c.blocks.after(reqClone.URL.Host, resp); blockErr != nil {
+				resp.Body.Close()
+				return nil, blockErr
 			}
+		}
 
-			// Condition 2: Rate limit detected (429 Too Many Requests).
-			if resp.StatusCode == http.StatusTooManyRequests {
-				// Wait longer before retrying for rate limits.
-				backoffDuration := 5 * time.Second // Significant wait time.
-				c.logger.Warn("Rate limit detected (429 Too Many Requests). Waiting for %v before retrying...", backoffDuration)
-				time.Sleep(backoffDuration)
-				// Close the current response body before retrying.
-				if resp != nil {
-					resp.Body.Close()
-				}
-				continue // Continue to the next retry iteration.
+		if err == nil {
+			c.limitBody(resp)
+		}
+
+		var reason string
+		switch {
+		case err == nil && !isTransientStatus(resp.StatusCode):
+			if attempt > 0 {
+				c.retries.recovered.Add(1)
 			}
+			return resp, nil
+		case err == nil:
+			reason = resp.Status
+		case ctx.Err() != nil:
+			// A cancelled request will not succeed on retry.
+			return nil, ctx.Err()
+		case IsTransient(err):
+			reason = err.Error()
+		default:
+			return nil, err
 		}
-		// --- End of Rate Limit and Server Error Handling ---
 
-		// If there's any other error or a 5xx status, close the body and retry.
+		// The last transient response is returned with its body intact, e.g. for error page
+		// analysis.
+		if attempt >= c.maxRetries {
+			c.retries.failed.Add(1)
+			return resp, err
+		}
+		delay := c.retryDelay(attempt+1, resp)
 		if resp != nil {
+			if resp.StatusCode == http.StatusTooManyRequests {
+				c.logger.Warn("Rate limit detected (429 Too Many Requests). Waiting for %v before retrying...", delay.Round(time.Millisecond))
+			}
 			resp.Body.Close()
 		}
+		c.logger.Debug("Retrying %s %s in %v (retry %d of %d): %s", req.Method, req.URL, delay.Round(time.Millisecond), attempt+1, c.maxRetries, reason)
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, err
+		}
+		c.retries.retries.Add(1)
 	}
+}
 
-	return resp, err // Return the last response and error after all retries.
+// sleepContext waits for d, returning early with ctx's error if ctx is cancelled first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
 }
 
 // Get performs an HTTP GET request using the custom client.
@@ -203,15 +571,11 @@ func (c *Client) Post(url, contentType string, body io.Reader) (*http.Response,
 	return c.Do(req)                            // Delegate to the Do method for request execution.
 }
 
-// GetWithCookies performs an HTTP GET request, adding the provided cookies to the request.
-func (c *Client) GetWithCookies(url string, cookies []*http.Cookie) (*http.Response, error) {
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, err
-	}
-	for _, cookie := range cookies {
-		req.AddCookie(cookie)
-	}
+// DoWithHost performs req like Do, but sends host as the Host header instead of the host
+// of req.URL. The connection still goes to the address in req.URL.
+func (c *Client) DoWithHost(req *http.Request, host string) (*http.Response, error) {
+	req.Host = host
+	c.logger.Trace("  -> Overriding Host header: %s", host)
 	return c.Do(req)
 }
 
@@ -234,8 +598,23 @@ func (c *Client) GetClientWithoutRedirects() *http.Client {
 	}
 }
 
+// WithoutRedirects returns a shallow copy of the client that never follows redirects, so that
+// the redirect responses themselves can be inspected. The client it was derived from, shared
+// with the other scanners, keeps its redirect policy; cookie jar, transport, rate limit and
+// counters are shared.
+func (c *Client) WithoutRedirects() *Client {
+	noRedirects := *c
+	httpClient := *c.httpClient
+	httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	noRedirects.httpClient = &httpClient
+	return &noRedirects
+}
+
 // TemporarilyDisableRedirects temporarily disables redirect following for the underlying http.Client.
 // It returns the original CheckRedirect function, which should be used to restore the behavior.
+// The change applies to every goroutine using the client; WithoutRedirects does not.
 func (c *Client) TemporarilyDisableRedirects() func(req *http.Request, via []*http.Request) error {
 	originalFunc := c.httpClient.CheckRedirect // Store the original function.
 	c.httpClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
@@ -0,0 +1,185 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// ErrHostSkipped is returned by Do for every request to a host skipped with SkipHost.
+var ErrHostSkipped = errors.New("host skipped")
+
+// scanControl is the pause switch and the skipped hosts of a client and every copy derived from
+// it, set from the control interface while a scan runs.
+type scanControl struct {
+	mu       sync.Mutex
+	resumed  chan struct{}   // Closed while the scan runs; open while it is paused.
+	skipped  map[string]bool // Hosts skipped with SkipHost.
+	lastHost string          // Host of the last request let through.
+}
+
+// EnableControl makes the client, and every copy derived from it afterwards, controllable while
+// a scan runs: Pause holds every request until Resume, SkipHost fails the requests to a host
+// with ErrHostSkipped and SetRateLimit adjusts the rate of requests already derived copies send.
+func (c *Client) EnableControl() {
+	resumed := make(chan struct{})
+	close(resumed)
+	c.control = &scanControl{resumed: resumed, skipped: make(map[string]bool)}
+	if c.limiter == nil {
+		c.limiter = newTokenBucket(0)
+	}
+}
+
+// Pause holds every request of the client and its copies until Resume is called; requests in
+// flight complete. It reports false if the client was already paused or control is not enabled.
+func (c *Client) Pause() bool {
+	if c.control == nil {
+		return false
+	}
+	c.control.mu.Lock()
+	defer c.control.mu.Unlock()
+	select {
+	case <-c.control.resumed:
+		c.control.resumed = make(chan struct{})
+		return true
+	default:
+		return false
+	}
+}
+
+// Resume lets the requests held by Pause through. It reports false if the client was not paused.
+func (c *Client) Resume() bool {
+	if c.control == nil {
+		return false
+	}
+	c.control.mu.Lock()
+	defer c.control.mu.Unlock()
+	select {
+	case <-c.control.resumed:
+		return false
+	default:
+		close(c.control.resumed)
+		return true
+	}
+}
+
+// Paused reports whether the client is paused.
+func (c *Client) Paused() bool {
+	if c.control == nil {
+		return false
+	}
+	c.control.mu.Lock()
+	defer c.control.mu.Unlock()
+	select {
+	case <-c.control.resumed:
+		return false
+	default:
+		return true
+	}
+}
+
+// WaitResumed blocks while the client is paused, or until ctx is cancelled. Workers call it
+// between tests so a pause also holds back work that sends no request.
+func (c *Client) WaitResumed(ctx context.Context) error {
+	if c.control == nil {
+		return ctx.Err()
+	}
+	c.control.mu.Lock()
+	resumed := c.control.resumed
+	c.control.mu.Unlock()
+	select {
+	case <-resumed:
+		return ctx.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SkipHost gives up host: its requests fail with ErrHostSkipped from now on. An empty host skips
+// the host of the last request sent. It returns the host skipped.
+func (c *Client) SkipHost(host string) (string, error) {
+	if c.control == nil {
+		return "", errors.New("scan control is not enabled")
+	}
+	c.control.mu.Lock()
+	defer c.control.mu.Unlock()
+	if host == "" {
+		if host = c.control.lastHost; host == "" {
+			return "", errors.New("no request has been sent yet")
+		}
+	}
+	c.control.skipped[host] = true
+	return host, nil
+}
+
+// CurrentHost returns the host of the last request sent, the host SkipHost("") skips.
+func (c *Client) CurrentHost() string {
+	if c.control == nil {
+		return ""
+	}
+	c.control.mu.Lock()
+	defer c.control.mu.Unlock()
+	return c.control.lastHost
+}
+
+// SkippedHosts returns the hosts skipped with SkipHost, sorted.
+func (c *Client) SkippedHosts() []string {
+	if c.control == nil {
+		return nil
+	}
+	c.control.mu.Lock()
+	defer c.control.mu.Unlock()
+	hosts := make([]string, 0, len(c.control.skipped))
+	for host := range c.control.skipped {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// HostSkipped reports whether the host of rawURL was skipped with SkipHost.
+func (c *Client) HostSkipped(rawURL string) bool {
+	if c.control == nil {
+		return false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	c.control.mu.Lock()
+	defer c.control.mu.Unlock()
+	return c.control.skipped[u.Host]
+}
+
+// before waits while the scan is paused before a request is sent to host, and fails with
+// ErrHostSkipped if the host was skipped.
+func (sc *scanControl) before(ctx context.Context, host string) error {
+	for {
+		sc.mu.Lock()
+		resumed := sc.resumed
+		sc.mu.Unlock()
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		sc.mu.Lock()
+		if sc.resumed != resumed {
+			sc.mu.Unlock()
+			continue // Paused again meanwhile.
+		}
+		skipped := sc.skipped[host]
+		if !skipped {
+			sc.lastHost = host
+		}
+		sc.mu.Unlock()
+		if skipped {
+			return fmt.Errorf("%w: %s", ErrHostSkipped, host)
+		}
+		return nil
+	}
+}
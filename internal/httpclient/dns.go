@@ -0,0 +1,109 @@
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HostOverride makes connections to a host and port go to a fixed address, like curl's
+// --resolve. The Host header and the TLS server name (SNI) stay those of the URL.
+type HostOverride struct {
+	Host string // Lowercase host name.
+	Port string // Port, or "*" for every port.
+	Addr string // IP address connected to instead.
+}
+
+// String returns the override in the form ParseResolve accepts.
+func (o HostOverride) String() string {
+	addr := o.Addr
+	if strings.Contains(addr, ":") {
+		addr = "[" + addr + "]"
+	}
+	return o.Host + ":" + o.Port + ":" + addr
+}
+
+// ParseResolve parses a curl-style override "host:port:address", e.g.
+// "app.example.com:443:10.0.0.5". The port may be "*" for every port and an IPv6 address may be
+// bracketed ("app.example.com:443:[::1]").
+func ParseResolve(entry string) (HostOverride, error) {
+	parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+	if len(parts) != 3 {
+		return HostOverride{}, fmt.Errorf("invalid host override %q: want host:port:address", entry)
+	}
+	host := strings.ToLower(strings.TrimSuffix(parts[0], "."))
+	port := parts[1]
+	addr := strings.TrimSuffix(strings.TrimPrefix(parts[2], "["), "]")
+	if host == "" {
+		return HostOverride{}, fmt.Errorf("invalid host override %q: missing host", entry)
+	}
+	if n, err := strconv.Atoi(port); port != "*" && (err != nil || n < 1 || n > 65535) {
+		return HostOverride{}, fmt.Errorf("invalid host override %q: port must be 1-65535 or *", entry)
+	}
+	if net.ParseIP(addr) == nil {
+		return HostOverride{}, fmt.Errorf("invalid host override %q: %q is not an IP address", entry, addr)
+	}
+	return HostOverride{Host: host, Port: port, Addr: addr}, nil
+}
+
+// ResolverAddr returns the host:port of a DNS server given as a host or host:port; the port
+// defaults to 53.
+func ResolverAddr(resolver string) (string, error) {
+	resolver = strings.TrimSpace(resolver)
+	if host, port, err := net.SplitHostPort(resolver); err == nil {
+		if host == "" || port == "" {
+			return "", fmt.Errorf("invalid DNS resolver %q", resolver)
+		}
+		return resolver, nil
+	}
+	host := strings.TrimSuffix(strings.TrimPrefix(resolver, "["), "]")
+	if host == "" || strings.ContainsAny(host, "/ ") {
+		return "", fmt.Errorf("invalid DNS resolver %q: want host or host:port", resolver)
+	}
+	return net.JoinHostPort(host, "53"), nil
+}
+
+// newDialContext returns the dial function of a transport applying the Resolve overrides and
+// the DNS Resolver of opts, or nil if neither is set. The overrides also apply to the address
+// of a proxy; host names reached through an HTTP proxy are resolved by the proxy.
+func newDialContext(opts ClientOptions) (func(ctx context.Context, network, addr string) (net.Conn, error), error) {
+	if len(opts.Resolve) == 0 && opts.Resolver == "" {
+		return nil, nil
+	}
+	overrides := make(map[string]string, len(opts.Resolve))
+	for _, entry := range opts.Resolve {
+		override, err := ParseResolve(entry)
+		if err != nil {
+			return nil, err
+		}
+		overrides[net.JoinHostPort(override.Host, override.Port)] = override.Addr
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if opts.Resolver != "" {
+		resolverAddr, err := ResolverAddr(opts.Resolver)
+		if err != nil {
+			return nil, err
+		}
+		dialer.Resolver = &net.Resolver{
+			PreferGo: true, // The cgo resolver would ask the system's servers.
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, resolverAddr)
+			},
+		}
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if host, port, err := net.SplitHostPort(addr); err == nil {
+			host = strings.ToLower(strings.TrimSuffix(host, "."))
+			if ip, ok := overrides[net.JoinHostPort(host, port)]; ok {
+				addr = net.JoinHostPort(ip, port)
+			} else if ip, ok := overrides[net.JoinHostPort(host, "*")]; ok {
+				addr = net.JoinHostPort(ip, port)
+			}
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}, nil
+}
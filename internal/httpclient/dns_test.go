@@ -0,0 +1,163 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func TestParseResolve(t *testing.T) {
+	override, err := ParseResolve("App.Example.com:443:10.0.0.5")
+	require.NoError(t, err)
+	assert.Equal(t, HostOverride{Host: "app.example.com", Port: "443", Addr: "10.0.0.5"}, override)
+
+	override, err = ParseResolve("app.example.com:*:[::1]")
+	require.NoError(t, err)
+	assert.Equal(t, HostOverride{Host: "app.example.com", Port: "*", Addr: "::1"}, override)
+	assert.Equal(t, "app.example.com:*:[::1]", override.String())
+
+	for _, entry := range []string{"", "app.example.com:443", ":443:10.0.0.5", "app.example.com:0:10.0.0.5", "app.example.com:http:10.0.0.5", "app.example.com:443:backend"} {
+		_, err := ParseResolve(entry)
+		assert.Error(t, err, entry)
+	}
+
+	addr, err := ResolverAddr("10.0.0.2")
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.2:53", addr)
+	addr, err = ResolverAddr("[::1]:5353")
+	require.NoError(t, err)
+	assert.Equal(t, "[::1]:5353", addr)
+	assert.Error(t, CheckTransport(ClientOptions{Resolve: []string{"app.example.com"}}, time.Second))
+}
+
+func TestResolveOverrideKeepsHostHeader(t *testing.T) {
+	var gotHost string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+	log := logger.NewLogger(logger.ERROR)
+
+	target := "http://app.internal.test:" + port + "/"
+	client := NewClient(log, ClientOptions{Resolve: []string{"app.internal.test:" + port + ":127.0.0.1"}})
+	resp, err := client.Get(target)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "app.internal.test:"+port, gotHost)
+
+	// An override for another port does not apply; a wildcard port does.
+	client = NewClient(log, ClientOptions{Resolve: []string{"app.internal.test:1:127.0.0.1"}})
+	_, err = client.Get(target)
+	assert.Error(t, err)
+	client = NewClient(log, ClientOptions{Resolve: []string{"APP.internal.test:*:127.0.0.1"}})
+	resp, err = client.Get(target)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestResolveOverrideKeepsServerName(t *testing.T) {
+	var mu sync.Mutex
+	var serverNames []string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	server.TLS = &tls.Config{GetConfigForClient: func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		serverNames = append(serverNames, hello.ServerName)
+		return nil, nil
+	}}
+	server.StartTLS()
+	defer server.Close()
+	_, port, err := net.SplitHostPort(server.Listener.Addr().String())
+	require.NoError(t, err)
+
+	// The test certificate is valid for example.com, so it verifies against the name of the URL
+	// rather than the address connected to.
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0644))
+	client := NewClient(logger.NewLogger(logger.ERROR), ClientOptions{
+		CACertFile: bundle,
+		Resolve:    []string{"example.com:" + port + ":127.0.0.1"},
+	})
+	resp, err := client.Get("https://example.com:" + port + "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"example.com"}, serverNames)
+}
+
+// serveDNS answers A queries for name with 127.0.0.1 on a UDP socket and returns its address.
+func serveDNS(t *testing.T, name string) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var query dnsmessage.Message
+			if query.Unpack(buf[:n]) != nil || len(query.Questions) != 1 {
+				continue
+			}
+			question := query.Questions[0]
+			reply := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: query.ID, Response: true, Authoritative: true},
+				Questions: query.Questions,
+			}
+			if question.Name.String() != name {
+				reply.RCode = dnsmessage.RCodeNameError
+			} else if question.Type == dnsmessage.TypeA {
+				reply.Answers = []dnsmessage.Resource{{
+					Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 60},
+					Body:   &dnsmessage.AResource{A: [4]byte{127, 0, 0, 1}},
+				}}
+			}
+			if packed, err := reply.Pack(); err == nil {
+				conn.WriteTo(packed, addr)
+			}
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestCustomResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	client := NewClient(logger.NewLogger(logger.ERROR), ClientOptions{Resolver: serveDNS(t, "app.internal.test.")})
+	target := "http://app.internal.test:" + serverURL.Port() + "/"
+	resp, err := client.Get(target)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _, err := client.ReadBody(resp)
+	require.NoError(t, err)
+	assert.Equal(t, "app.internal.test:"+serverURL.Port(), string(body))
+
+	_, err = client.Get("http://unknown.internal.test:" + serverURL.Port() + "/")
+	assert.Error(t, err, "names the resolver does not know are not resolved by the system")
+}
@@ -0,0 +1,416 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultHARMaxBodyBytes is the size request and response bodies are cut off at in the HAR
+// file when NewHARRecorder is given zero.
+const DefaultHARMaxBodyBytes = 1 << 20
+
+// harTimeFormat is the format of startedDateTime (ISO 8601 with milliseconds).
+const harTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// HARRecorder streams every request sent by the clients created with it (ClientOptions.HAR)
+// and its response to a HAR 1.2 file. Entries are written as their responses complete, so the
+// file never has to be held in memory; Close terminates the JSON document.
+type HARRecorder struct {
+	mu           sync.Mutex
+	file         *os.File
+	maxBodyBytes int
+	entries      int
+	err          error
+}
+
+// NewHARRecorder creates the HAR file at path. Bodies are cut off after maxBodyBytes bytes
+// (DefaultHARMaxBodyBytes when zero, whole when negative); version is the version of the
+// creator recorded in the file.
+func NewHARRecorder(path, version string, maxBodyBytes int) (*HARRecorder, error) {
+	if maxBodyBytes == 0 {
+		maxBodyBytes = DefaultHARMaxBodyBytes
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, err
+	}
+	creator, _ := json.Marshal(map[string]string{"name": "dursgo", "version": version})
+	if _, err := fmt.Fprintf(file, "{\"log\":{\"version\":\"1.2\",\"creator\":%s,\"entries\":[", creator); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &HARRecorder{file: file, maxBodyBytes: maxBodyBytes}, nil
+}
+
+// Entries returns the number of entries written.
+func (h *HARRecorder) Entries() int {
+	if h == nil {
+		return 0
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.entries
+}
+
+// Close terminates the document and closes the file. It returns the first write error, if any.
+// Responses completing afterwards are not recorded.
+func (h *HARRecorder) Close() error {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		return h.err
+	}
+	if _, err := h.file.WriteString("\n]}}\n"); err != nil && h.err == nil {
+		h.err = err
+	}
+	if err := h.file.Close(); err != nil && h.err == nil {
+		h.err = err
+	}
+	h.file = nil
+	return h.err
+}
+
+// write appends entry to the file with a single write.
+func (h *HARRecorder) write(entry *harEntry) {
+	data, err := json.Marshal(entry)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil || h.err != nil {
+		return
+	}
+	if err != nil {
+		h.err = err
+		return
+	}
+	separator := ",\n"
+	if h.entries == 0 {
+		separator = "\n"
+	}
+	if _, err := h.file.Write(append([]byte(separator), data...)); err != nil {
+		h.err = err
+		return
+	}
+	h.entries++
+}
+
+// capture returns the part of a body of size bytes kept in the HAR file: the first data bytes,
+// at most the size limit.
+func (h *HARRecorder) capture(data []byte) []byte {
+	if h.maxBodyBytes >= 0 && len(data) > h.maxBodyBytes {
+		return data[:h.maxBodyBytes]
+	}
+	return data
+}
+
+// body returns the HAR text of the captured part of a body of size bytes, base64-encoded
+// unless it is valid UTF-8, and whether it was cut off.
+func (h *HARRecorder) body(captured []byte, size int) (text, encoding string, truncated bool) {
+	truncated = len(captured) < size
+	if utf8.Valid(captured) {
+		return string(captured), "", truncated
+	}
+	// A cut may split the last character of a text body.
+	for cut := 1; truncated && cut < utf8.UTFMax && cut < len(captured); cut++ {
+		if text := captured[:len(captured)-cut]; utf8.Valid(text) {
+			return string(text), "", truncated
+		}
+	}
+	return base64.StdEncoding.EncodeToString(captured), "base64", truncated
+}
+
+// Source tags the requests of a client in the HAR file: the phase of the scan and, for the
+// scanning phase, the module that sent them.
+type Source struct {
+	Phase   string // e.g. "crawl", "fingerprint", "scan".
+	Scanner string // Module name, e.g. "sqli".
+}
+
+// sourceKey is the context key of the Source of a request.
+type sourceKey struct{}
+
+// WithSource returns a shallow copy of the client whose requests are tagged with phase and
+// scanner in the HAR file (ClientOptions.HAR).
+func (c *Client) WithSource(phase, scanner string) *Client {
+	tagged := *c
+	tagged.source = Source{Phase: phase, Scanner: scanner}
+	return &tagged
+}
+
+// harTransport records the requests of a client and their responses with a HARRecorder. As a
+// transport it also sees redirects, retries and the requests of GetClient users.
+type harTransport struct {
+	next     http.RoundTripper
+	recorder *HARRecorder
+}
+
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	started := time.Now()
+	var requestBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		requestBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		// The transport must not modify the caller's request; send a copy with a fresh body.
+		copied := *req
+		copied.Body = io.NopCloser(bytes.NewReader(requestBody))
+		req = &copied
+	}
+	entry := t.recorder.newEntry(req, requestBody, started)
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		entry.Error = err.Error()
+		entry.Time = milliseconds(time.Since(started))
+		entry.Timings.Wait = entry.Time
+		t.recorder.write(entry)
+		return nil, err
+	}
+	headersAt := time.Now()
+	entry.Timings.Wait = milliseconds(headersAt.Sub(started))
+	entry.Response = harResponse{
+		Status:      resp.StatusCode,
+		StatusText:  http.StatusText(resp.StatusCode),
+		HTTPVersion: resp.Proto,
+		Cookies:     harCookies(resp.Cookies()),
+		Headers:     harHeaders(resp.Header),
+		Content:     harContent{MimeType: resp.Header.Get("Content-Type")},
+		RedirectURL: resp.Header.Get("Location"),
+		HeadersSize: -1,
+	}
+	resp.Body = &harBody{body: resp.Body, recorder: t.recorder, entry: entry, started: started, headersAt: headersAt}
+	return resp, nil
+}
+
+// newEntry returns the entry of req, sent at started, without its response.
+func (h *HARRecorder) newEntry(req *http.Request, body []byte, started time.Time) *harEntry {
+	source, _ := req.Context().Value(sourceKey{}).(Source)
+	entry := &harEntry{
+		StartedDateTime: started.Format(harTimeFormat),
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: req.Proto,
+			Cookies:     harCookies(req.Cookies()),
+			Headers:     harHeaders(req.Header),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(body),
+		},
+		// The response of a request that failed stays empty, with status 0.
+		Response: harResponse{Cookies: []harCookie{}, Headers: []harNameValue{}, HeadersSize: -1, BodySize: -1},
+		Timings:  harTimings{Blocked: -1, DNS: -1, Connect: -1},
+		Phase:    source.Phase,
+		Scanner:  source.Scanner,
+	}
+	if entry.Request.HTTPVersion == "" {
+		entry.Request.HTTPVersion = "HTTP/1.1"
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		entry.Request.Headers = append(entry.Request.Headers, harNameValue{Name: "Host", Value: req.Host})
+	}
+	for name, values := range req.URL.Query() {
+		for _, value := range values {
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.Slice(entry.Request.QueryString, func(i, j int) bool { return entry.Request.QueryString[i].Name < entry.Request.QueryString[j].Name })
+	if len(body) > 0 {
+		text, encoding, truncated := h.body(h.capture(body), len(body))
+		entry.Request.PostData = &harPostData{MimeType: req.Header.Get("Content-Type"), Text: text, Encoding: encoding, Truncated: truncated}
+		if truncated {
+			entry.Request.PostData.Comment = truncatedComment(len(body))
+		}
+	}
+	return entry
+}
+
+// harBody records the response body as the caller reads it and writes the entry once the body
+// was read to its end or closed.
+// Close may be called while a Read is pending (see limitedBody), hence mu.
+type harBody struct {
+	body      io.ReadCloser
+	recorder  *HARRecorder
+	entry     *harEntry
+	started   time.Time
+	headersAt time.Time
+	mu        sync.Mutex
+	captured  []byte
+	size      int
+	done      bool
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.mu.Lock()
+	b.size += n
+	b.captured = b.recorder.capture(append(b.captured, p[:n]...))
+	b.mu.Unlock()
+	if err != nil {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	err := b.body.Close()
+	b.finish()
+	return err
+}
+
+// finish completes the entry with the body read so far and writes it.
+func (b *harBody) finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return
+	}
+	b.done = true
+	now := time.Now()
+	e := b.entry
+	e.Time = milliseconds(now.Sub(b.started))
+	e.Timings.Receive = milliseconds(now.Sub(b.headersAt))
+	e.Response.BodySize = b.size
+	e.Response.Content.Size = b.size
+	if b.size > 0 {
+		text, encoding, truncated := b.recorder.body(b.captured, b.size)
+		e.Response.Content.Text, e.Response.Content.Encoding = text, encoding
+		if truncated {
+			e.Response.Content.Truncated = true
+			e.Response.Content.Comment = truncatedComment(b.size)
+		}
+	}
+	b.recorder.write(e)
+}
+
+// truncatedComment is the marker of a body cut off in the HAR file.
+func truncatedComment(size int) string {
+	return fmt.Sprintf("Body truncated by dursgo; %d byte(s) were transferred.", size)
+}
+
+// milliseconds returns d in milliseconds, as HAR times are.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// harHeaders returns header as HAR name/value pairs, sorted by name.
+func harHeaders(header http.Header) []harNameValue {
+	pairs := []harNameValue{}
+	for name, values := range header {
+		for _, value := range values {
+			pairs = append(pairs, harNameValue{Name: name, Value: value})
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Name < pairs[j].Name })
+	return pairs
+}
+
+// harCookies returns cookies as HAR cookies.
+func harCookies(cookies []*http.Cookie) []harCookie {
+	list := []harCookie{}
+	for _, c := range cookies {
+		list = append(list, harCookie{Name: c.Name, Value: c.Value, Path: c.Path, Domain: c.Domain, HTTPOnly: c.HttpOnly, Secure: c.Secure})
+	}
+	return list
+}
+
+// withSource adds the Source of the client to ctx, for the HAR file.
+func (c *Client) withSource(ctx context.Context) context.Context {
+	if c.source == (Source{}) {
+		return ctx
+	}
+	return context.WithValue(ctx, sourceKey{}, c.source)
+}
+
+// harEntry is an entry of the HAR 1.2 format. Fields starting with an underscore are custom
+// fields, as the format allows.
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"` // Total time in milliseconds.
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+	Phase           string      `json:"_phase,omitempty"`   // Source.Phase.
+	Scanner         string      `json:"_scanner,omitempty"` // Source.Scanner.
+	Error           string      `json:"_error,omitempty"`   // Why no response was received (status 0).
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harCookie    `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harContent struct {
+	Size      int    `json:"size"` // Bytes transferred, before truncation.
+	MimeType  string `json:"mimeType"`
+	Text      string `json:"text,omitempty"`
+	Encoding  string `json:"encoding,omitempty"` // "base64" for binary bodies.
+	Truncated bool   `json:"_truncated,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+}
+
+type harPostData struct {
+	MimeType  string `json:"mimeType"`
+	Text      string `json:"text"`
+	Encoding  string `json:"_encoding,omitempty"` // "base64" for binary bodies.
+	Truncated bool   `json:"_truncated,omitempty"`
+	Comment   string `json:"comment,omitempty"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harCookie struct {
+	Name     string `json:"name"`
+	Value    string `json:"value"`
+	Path     string `json:"path,omitempty"`
+	Domain   string `json:"domain,omitempty"`
+	HTTPOnly bool   `json:"httpOnly,omitempty"`
+	Secure   bool   `json:"secure,omitempty"`
+}
+
+type harTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
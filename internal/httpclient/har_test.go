@@ -0,0 +1,125 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHARRecorderStreamsEntries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/large", http.StatusFound)
+		case "/large":
+			http.SetCookie(w, &http.Cookie{Name: "sid", Value: "abc"})
+			w.Header().Set("Content-Type", "text/plain")
+			io.WriteString(w, strings.Repeat("a", 100))
+		default:
+			body, _ := io.ReadAll(r.Body)
+			w.Write(body)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "scan.har")
+	recorder, err := NewHARRecorder(path, "1.2.3", 16)
+	require.NoError(t, err)
+	client := NewClient(logger.NewLogger(logger.ERROR), ClientOptions{FollowRedirects: true, HAR: recorder})
+
+	resp, err := client.WithSource("scan", "sqli").Post(srv.URL+"/echo?id=1", "application/x-www-form-urlencoded", strings.NewReader("name=x"))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "name=x", string(body), "the request body is still sent")
+
+	resp, err = client.WithSource("crawl", "").Get(srv.URL + "/redirect")
+	require.NoError(t, err)
+	io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, recorder.Close())
+	assert.Equal(t, 3, recorder.Entries(), "redirects are recorded")
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var har struct {
+		Log struct {
+			Version string `json:"version"`
+			Creator struct {
+				Name    string `json:"name"`
+				Version string `json:"version"`
+			} `json:"creator"`
+			Entries []harEntry `json:"entries"`
+		} `json:"log"`
+	}
+	require.NoError(t, json.Unmarshal(data, &har), "the file is one JSON document")
+	assert.Equal(t, "1.2", har.Log.Version)
+	assert.Equal(t, "1.2.3", har.Log.Creator.Version)
+	require.Len(t, har.Log.Entries, 3)
+
+	post := har.Log.Entries[0]
+	assert.Equal(t, "POST", post.Request.Method)
+	assert.Equal(t, []harNameValue{{Name: "id", Value: "1"}}, post.Request.QueryString)
+	require.NotNil(t, post.Request.PostData)
+	assert.Equal(t, "name=x", post.Request.PostData.Text)
+	assert.Equal(t, "scan", post.Phase)
+	assert.Equal(t, "sqli", post.Scanner)
+	assert.Equal(t, 200, post.Response.Status)
+	assert.NotEmpty(t, post.StartedDateTime)
+
+	redirect, large := har.Log.Entries[1], har.Log.Entries[2]
+	assert.Equal(t, 302, redirect.Response.Status)
+	assert.Equal(t, "/large", redirect.Response.RedirectURL)
+	assert.Equal(t, "crawl", large.Phase, "redirect hops keep the source of the request")
+	assert.Equal(t, 100, large.Response.Content.Size)
+	assert.Equal(t, strings.Repeat("a", 16), large.Response.Content.Text)
+	assert.True(t, large.Response.Content.Truncated)
+	assert.Contains(t, large.Response.Content.Comment, "100 byte(s)")
+	assert.Equal(t, []harCookie{{Name: "sid", Value: "abc"}}, large.Response.Cookies)
+}
+
+func TestHARRecorderRecordsFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.har")
+	recorder, err := NewHARRecorder(path, "dev", 0)
+	require.NoError(t, err)
+	client := NewClient(logger.NewLogger(logger.ERROR), ClientOptions{HAR: recorder})
+
+	_, err = client.Get("http://127.0.0.1:1/unreachable")
+	require.Error(t, err)
+	require.NoError(t, recorder.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var har struct {
+		Log struct {
+			Entries []harEntry `json:"entries"`
+		} `json:"log"`
+	}
+	require.NoError(t, json.Unmarshal(data, &har))
+	require.Len(t, har.Log.Entries, 1)
+	assert.Equal(t, 0, har.Log.Entries[0].Response.Status)
+	assert.NotEmpty(t, har.Log.Entries[0].Error)
+}
+
+func TestHARBodyEncoding(t *testing.T) {
+	h := &HARRecorder{maxBodyBytes: 4}
+	text, encoding, truncated := h.body(h.capture([]byte("abcé")), 5)
+	assert.Equal(t, "abc", text, "a character split by the cut is dropped")
+	assert.Empty(t, encoding)
+	assert.True(t, truncated)
+
+	text, encoding, truncated = h.body(h.capture([]byte{0xff, 0x00, 0x01}), 3)
+	assert.Equal(t, "/wAB", text)
+	assert.Equal(t, "base64", encoding)
+	assert.False(t, truncated)
+}
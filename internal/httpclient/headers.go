@@ -0,0 +1,130 @@
+package httpclient
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// DefaultUserAgents are common browser User-Agents rotated through when User-Agent rotation is
+// enabled without a list of its own.
+var DefaultUserAgents = []string{
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:125.0) Gecko/20100101 Firefox/125.0",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Safari/605.1.15",
+	"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36",
+	"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36 Edg/124.0.0.0",
+}
+
+// sensitiveHeaderWords mark header names whose values are redacted in logs.
+var sensitiveHeaderWords = []string{"auth", "cookie", "token", "key", "secret", "session", "password", "signature", "csrf", "xsrf"}
+
+// ParseHeader parses a "Name: value" header line, as given on the command line.
+func ParseHeader(line string) (string, string, error) {
+	name, value, ok := strings.Cut(line, ":")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("invalid header %q; use \"Name: value\"", line)
+	}
+	return http.CanonicalHeaderKey(name), strings.TrimSpace(value), nil
+}
+
+// canonicalHeaders returns headers with canonical names ("x-api-key" becomes "X-Api-Key").
+func canonicalHeaders(headers map[string]string) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	canonical := make(map[string]string, len(headers))
+	for name, value := range headers {
+		canonical[http.CanonicalHeaderKey(name)] = value
+	}
+	return canonical
+}
+
+// parseCookies parses a "Cookie" header value ("a=1; b=2").
+func parseCookies(cookie string) []*http.Cookie {
+	header := http.Header{}
+	header.Add("Cookie", cookie)
+	return (&http.Request{Header: header}).Cookies()
+}
+
+// IsSensitiveHeader reports whether the values of the named header may hold credentials
+// (Authorization, Cookie, X-API-Key, X-Auth-Token, ...).
+func IsSensitiveHeader(name string) bool {
+	name = strings.ToLower(name)
+	for _, word := range sensitiveHeaderWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHeader returns value for logging: values of sensitive headers are replaced with
+// "[REDACTED]", keeping the scheme of Authorization values ("Bearer [REDACTED]").
+func RedactHeader(name, value string) string {
+	if !IsSensitiveHeader(name) {
+		return value
+	}
+	if scheme, _, found := strings.Cut(value, " "); found && strings.Contains(strings.ToLower(name), "authorization") {
+		return scheme + " [REDACTED]"
+	}
+	return "[REDACTED]"
+}
+
+// formatHeaders formats header for logging, sorted by name, with sensitive values redacted.
+func formatHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		for _, value := range header[name] {
+			parts = append(parts, name+": "+RedactHeader(name, value))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// nextUserAgent returns the User-Agent of the next request: a random one of the rotated
+// User-Agents, or the configured one.
+func (c *Client) nextUserAgent() string {
+	if len(c.userAgents) > 0 {
+		return c.userAgents[rand.Intn(len(c.userAgents))]
+	}
+	return c.userAgent
+}
+
+// applyDefaults adds the default headers and cookies to req, except those the caller set
+// explicitly (e.g., a scanner's Content-Type or injected User-Agent) and cookies the cookie jar
+// will send.
+func (c *Client) applyDefaults(req *http.Request) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.nextUserAgent())
+	}
+	for name, value := range c.headers {
+		if len(req.Header.Values(name)) == 0 {
+			req.Header.Set(name, value)
+		}
+	}
+	if len(c.cookies) == 0 {
+		return
+	}
+	sent := make(map[string]bool)
+	for _, cookie := range req.Cookies() {
+		sent[cookie.Name] = true
+	}
+	for _, cookie := range c.httpClient.Jar.Cookies(req.URL) {
+		sent[cookie.Name] = true
+	}
+	for _, cookie := range c.cookies {
+		if !sent[cookie.Name] {
+			req.AddCookie(cookie)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package httpclient
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// observedBodyBytes is the part of each response body passed to a ResponseObserver.
+const observedBodyBytes = 1 << 20
+
+// ResponseObserver is called with every response received by the clients created with it
+// (ClientOptions.Observe) and the part of its body the caller read, at most 1 MiB, once the body
+// was read to its end or closed. resp.Request is the request as sent. It must be safe for
+// concurrent use, must not read resp.Body and must not keep body.
+type ResponseObserver func(resp *http.Response, body []byte)
+
+// observeTransport passes the responses of a client to a ResponseObserver. As a transport it
+// also sees redirects, retries and the requests of GetClient users.
+type observeTransport struct {
+	next    http.RoundTripper
+	observe ResponseObserver
+}
+
+func (t *observeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &observedBody{body: resp.Body, resp: resp, observe: t.observe}
+	return resp, nil
+}
+
+// observedBody keeps the response body as the caller reads it and passes it to the observer
+// once the body was read to its end or closed.
+// Close may be called while a Read is pending (see limitedBody), hence mu.
+type observedBody struct {
+	body     io.ReadCloser
+	resp     *http.Response
+	observe  ResponseObserver
+	mu       sync.Mutex
+	captured []byte
+	done     bool
+}
+
+func (b *observedBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	b.mu.Lock()
+	if room := observedBodyBytes - len(b.captured); room > 0 {
+		b.captured = append(b.captured, p[:min(n, room)]...)
+	}
+	b.mu.Unlock()
+	if err != nil {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *observedBody) Close() error {
+	err := b.body.Close()
+	b.finish()
+	return err
+}
+
+// finish passes the body read so far to the observer, once.
+func (b *observedBody) finish() {
+	b.mu.Lock()
+	if b.done {
+		b.mu.Unlock()
+		return
+	}
+	b.done = true
+	captured := b.captured
+	b.mu.Unlock()
+	b.observe(b.resp, captured)
+}
+
+// RequestMetric describes a request sent by a client, for a RequestObserver.
+type RequestMetric struct {
+	Source   Source // Phase and scanner of the client (WithSource).
+	Method   string
+	URL      string
+	Host     string
+	Status   int           // 0 when no response was received.
+	Duration time.Duration // Time until the response headers arrived, or the request failed.
+	Err      error         // Why no response was received.
+}
+
+// RequestObserver is called with every request sent by the clients created with it
+// (ClientOptions.Metrics), redirects and retries included, once its response headers arrived or
+// it failed. It must be safe for concurrent use.
+type RequestObserver func(RequestMetric)
+
+// metricsTransport times the requests of a client for a RequestObserver.
+type metricsTransport struct {
+	next    http.RoundTripper
+	observe RequestObserver
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	source, _ := req.Context().Value(sourceKey{}).(Source)
+	started := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	metric := RequestMetric{Source: source, Method: req.Method, URL: req.URL.String(), Host: req.URL.Host, Duration: time.Since(started), Err: err}
+	if resp != nil {
+		metric.Status = resp.StatusCode
+	}
+	t.observe(metric)
+	return resp, err
+}
@@ -0,0 +1,248 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrRequestBudgetExhausted is returned by Do when a client created with WithRequestBudget
+// has already sent its maximum number of requests.
+var ErrRequestBudgetExhausted = errors.New("request budget exhausted")
+
+// maxRateWait caps each wait for a token, so a rate raised with SetRateLimit takes effect
+// quickly for requests already waiting.
+const maxRateWait = time.Second
+
+// tokenBucket is a token-bucket rate limiter shared by every copy of a Client, so the
+// configured rate applies to all scanners together. A rate of zero or less lets every request
+// through.
+type tokenBucket struct {
+	mu       sync.Mutex
+	rate     float64   // Tokens added per second.
+	capacity float64   // Maximum burst size.
+	tokens   float64   // Tokens currently available.
+	last     time.Time // Last time tokens were added.
+}
+
+// newTokenBucket returns a limiter allowing rate requests per second with bursts of up to
+// one second's worth of requests (at least one).
+func newTokenBucket(rate float64) *tokenBucket {
+	b := &tokenBucket{last: time.Now()}
+	b.setRate(rate)
+	b.tokens = b.capacity
+	return b
+}
+
+// setRate changes the rate of the bucket; tokens above the new capacity are dropped.
+func (b *tokenBucket) setRate(rate float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.rate = rate
+	b.capacity = max(rate, 1)
+	b.tokens = min(b.tokens, b.capacity)
+}
+
+// limit returns the rate of the bucket; zero means unlimited.
+func (b *tokenBucket) limit() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return max(b.rate, 0)
+}
+
+// Wait blocks until a token is available or ctx is cancelled.
+func (b *tokenBucket) Wait(ctx context.Context) error {
+	for {
+		b.mu.Lock()
+		if b.rate <= 0 {
+			b.mu.Unlock()
+			return ctx.Err()
+		}
+		now := time.Now()
+		b.tokens += now.Sub(b.last).Seconds() * b.rate
+		if b.tokens > b.capacity {
+			b.tokens = b.capacity
+		}
+		b.last = now
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+		wait := min(time.Duration((1-b.tokens)/b.rate*float64(time.Second)), maxRateWait)
+		b.mu.Unlock()
+
+		if err := sleepContext(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// requestBudget caps the number of requests sent through a client copy.
+type requestBudget struct {
+	limit   int64
+	used    atomic.Int64
+	skipped atomic.Int64
+}
+
+// take reserves one request, reporting false (and counting a skipped request) once the
+// budget is spent.
+func (b *requestBudget) take() bool {
+	if b.used.Add(1) > b.limit {
+		b.skipped.Add(1)
+		return false
+	}
+	return true
+}
+
+// hostSlots caps the requests in flight to each host. It is shared by every copy of a Client,
+// so the cap applies to the crawler and all scanners together.
+type hostSlots struct {
+	mu    sync.Mutex
+	limit int
+	slots map[string]chan struct{} // Buffered to limit, keyed by host.
+}
+
+// acquire blocks until a request to host may be sent or ctx is cancelled.
+func (h *hostSlots) acquire(ctx context.Context, host string) error {
+	h.mu.Lock()
+	slots, ok := h.slots[host]
+	if !ok {
+		slots = make(chan struct{}, h.limit)
+		h.slots[host] = slots
+	}
+	h.mu.Unlock()
+	select {
+	case slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release frees the slot taken by acquire for host.
+func (h *hostSlots) release(host string) {
+	h.mu.Lock()
+	slots := h.slots[host]
+	h.mu.Unlock()
+	<-slots
+}
+
+// hold keeps the slot taken for host until the body of resp is closed, so that the cap covers
+// the transfer of the body too. The slot is released at once when the request failed, as the
+// body of a response returned with an error is already closed.
+func (h *hostSlots) hold(host string, resp *http.Response, err error) {
+	if err != nil || resp == nil {
+		h.release(host)
+		return
+	}
+	resp.Body = &slotBody{ReadCloser: resp.Body, release: func() { h.release(host) }}
+}
+
+// slotBody is a response body releasing its host slot when closed.
+type slotBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *slotBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// SetPerHostConcurrency limits the client, and every copy derived from it afterwards, to
+// maxRequests concurrent requests per host, counted from sending a request until its response
+// body is closed. Zero or a negative value removes the limit.
+func (c *Client) SetPerHostConcurrency(maxRequests int) {
+	if maxRequests <= 0 {
+		c.hostSlots = nil
+		return
+	}
+	c.hostSlots = &hostSlots{limit: maxRequests, slots: make(map[string]chan struct{})}
+}
+
+// SetRateLimit limits the client, and every copy derived from it, to requestsPerSecond
+// requests per second. Zero or a negative value removes the limit. Once the client has a
+// limiter, a new rate applies at once to the copies derived before the call too, so the rate
+// can be adjusted during a scan (see EnableControl).
+func (c *Client) SetRateLimit(requestsPerSecond float64) {
+	switch {
+	case c.limiter != nil:
+		c.limiter.setRate(requestsPerSecond)
+	case requestsPerSecond > 0:
+		c.limiter = newTokenBucket(requestsPerSecond)
+	}
+}
+
+// RateLimit returns the requests per second the client is limited to; zero means unlimited.
+func (c *Client) RateLimit() float64 {
+	if c.limiter == nil {
+		return 0
+	}
+	return c.limiter.limit()
+}
+
+// WithRequestCounter returns a shallow copy of the client that adds every request it sends
+// (including retries) to counter.
+func (c *Client) WithRequestCounter(counter *atomic.Int64) *Client {
+	counted := *c
+	counted.counter = counter
+	return &counted
+}
+
+// RequestsSent returns the number of requests (including retries) sent by the client and by
+// every copy derived from it, e.g. for a progress display.
+func (c *Client) RequestsSent() int64 {
+	if c.sent == nil {
+		return 0
+	}
+	return c.sent.Load()
+}
+
+// WithRequestBudget returns a shallow copy of the client that sends at most maxRequests
+// requests; further calls to Do fail with ErrRequestBudgetExhausted. Zero or a negative
+// value returns the client unchanged.
+func (c *Client) WithRequestBudget(maxRequests int) *Client {
+	if maxRequests <= 0 {
+		return c
+	}
+	budgeted := *c
+	budgeted.budget = &requestBudget{limit: int64(maxRequests)}
+	return &budgeted
+}
+
+// WithRateLimitExemption returns a shallow copy of the client for tests that need a burst of
+// requests, such as checking whether a login form throttles guessing. The copy does not wait
+// for the shared rate limit, sends each request once, and its 403 and 429 responses and block
+// pages are returned as they are rather than counted as the host blocking the scan. Pauses of
+// blocking hosts, the per-host concurrency cap and request budgets still apply.
+func (c *Client) WithRateLimitExemption() *Client {
+	exempt := *c
+	exempt.exempt = true
+	exempt.maxRetries = 0
+	return &exempt
+}
+
+// BudgetSkippedRequests returns how many requests the request budgets of the client and of every
+// copy derived from it refused. A nonzero count means some tests were cut short.
+func (c *Client) BudgetSkippedRequests() int64 {
+	if c.budgetSkips == nil {
+		return 0
+	}
+	return c.budgetSkips.Load()
+}
+
+// SkippedRequests returns how many requests were refused because the client's request
+// budget was exhausted.
+func (c *Client) SkippedRequests() int {
+	if c.budget == nil {
+		return 0
+	}
+	return int(c.budget.skipped.Load())
+}
@@ -0,0 +1,137 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// DefaultRetryBackoff is the wait before the first retry of a transient failure when
+// ClientOptions.RetryBackoff is not positive. Each further retry waits twice as long.
+const DefaultRetryBackoff = time.Second
+
+// maxRetryBackoff caps the wait before a retry, including waits requested with Retry-After.
+const maxRetryBackoff = 30 * time.Second
+
+// rateLimitBackoff is the minimum wait before retrying a 429 Too Many Requests response.
+const rateLimitBackoff = 5 * time.Second
+
+// RetryStats counts the retries of transient failures (network errors, 429, 502, 503 and 504)
+// of a client and every copy derived from it. Many retries or failures mean the target was
+// flaky and requests may have been skipped.
+type RetryStats struct {
+	Retries   int64 `json:"retries"`   // Retry attempts sent.
+	Recovered int64 `json:"recovered"` // Requests that succeeded after being retried.
+	Failed    int64 `json:"failed"`    // Requests that still failed transiently after all retries.
+}
+
+// retryCounters holds the RetryStats of a client, shared by its copies.
+type retryCounters struct {
+	retries, recovered, failed atomic.Int64
+}
+
+// RetryStats returns the retries of the client and of every copy derived from it.
+func (c *Client) RetryStats() RetryStats {
+	return RetryStats{
+		Retries:   c.retries.retries.Load(),
+		Recovered: c.retries.recovered.Load(),
+		Failed:    c.retries.failed.Load(),
+	}
+}
+
+// DoNoRetry performs req like Do, but sends it only once, whatever the outcome. Time-based
+// tests use it, as a retried request would distort the measured response time.
+func (c *Client) DoNoRetry(req *http.Request) (*http.Response, error) {
+	single := *c
+	single.maxRetries = 0
+	return single.Do(req)
+}
+
+// DoTimed performs req like DoNoRetry and also returns when the client sent it, after waiting
+// for a paused scan, a blocking host, the rate limit and a per-host slot. Measuring from there
+// keeps the client's own queueing out of the response time of time-based tests.
+func (c *Client) DoTimed(req *http.Request) (*http.Response, time.Time, error) {
+	var sentAt time.Time
+	single := *c
+	single.maxRetries = 0
+	single.admitted = func() { sentAt = time.Now() }
+	resp, err := single.Do(req)
+	return resp, sentAt, err
+}
+
+// IsTransient reports whether err is a network failure that may succeed on retry: timeouts,
+// connection resets, refused or aborted connections, and connections closed before the
+// response. Cancellations, DNS failures for unknown hosts and TLS errors are not transient.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTimeout || dnsErr.IsTemporary
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNABORTED) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isTransientStatus reports whether a response status is retried: rate limiting and the
+// gateway errors of overloaded or restarting servers. Other 5xx responses are often caused by
+// the payload itself and are returned as they are.
+func isTransientStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryDelay returns the wait before retry number attempt (1 for the first retry): exponential
+// backoff from the client's retry backoff with jitter, at least the Retry-After of resp and
+// rateLimitBackoff for 429 responses, plus the configured request delay.
+func (c *Client) retryDelay(attempt int, resp *http.Response) time.Duration {
+	backoff := c.retryBackoff << (attempt - 1)
+	if backoff > maxRetryBackoff || backoff <= 0 {
+		backoff = maxRetryBackoff
+	}
+	// Jitter in [backoff/2, backoff] keeps parallel workers from retrying in lockstep.
+	backoff = backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+	if resp != nil {
+		if resp.StatusCode == http.StatusTooManyRequests && backoff < rateLimitBackoff {
+			backoff = rateLimitBackoff
+		}
+		if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+			if wait := time.Duration(seconds) * time.Second; wait > backoff {
+				backoff = min(wait, maxRetryBackoff)
+			}
+		}
+	}
+	return backoff + c.requestDelay
+}
+
+// retryAfter returns the wait resp asks for with its Retry-After header, given in seconds or as
+// an HTTP date, or 0.
+func retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0)
+	}
+	return 0
+}
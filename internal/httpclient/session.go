@@ -0,0 +1,165 @@
+package httpclient
+
+import (
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// Session is the cookie jar of a client. It keeps a separate jar per site (registrable domain,
+// or host for IP addresses and single-label hosts), so that the session of one target never
+// reaches another, not even through cookies set for a shared parent domain. A Session can be
+// copied (SnapshotSession), put back (RestoreSession), cleared (ClearCookies) or used for single
+// requests instead of the client's jar (DoWithSession).
+type Session struct {
+	mu    sync.Mutex
+	sites map[string]*siteCookies
+}
+
+// siteCookies is the jar of one site, and the last cookie set under every name, domain and
+// path, which Clone replays into a fresh jar (a cookiejar.Jar cannot list its cookies).
+type siteCookies struct {
+	jar *cookiejar.Jar
+	set map[string]setCookie
+}
+
+// setCookie is a cookie and the URL of the response that set it.
+type setCookie struct {
+	url    *url.URL
+	cookie *http.Cookie
+}
+
+// NewSession creates an empty Session.
+func NewSession() *Session {
+	return &Session{sites: make(map[string]*siteCookies)}
+}
+
+// siteKey returns the site whose jar holds the cookies of host.
+func siteKey(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if site, err := publicsuffix.EffectiveTLDPlusOne(host); err == nil {
+		return site
+	}
+	return host
+}
+
+// site returns the jar of the site of u, creating it if create is set.
+func (s *Session) site(u *url.URL, create bool) *siteCookies {
+	key := siteKey(u.Hostname())
+	site := s.sites[key]
+	if site == nil && create {
+		jar, _ := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+		site = &siteCookies{jar: jar, set: make(map[string]setCookie)}
+		s.sites[key] = site
+	}
+	return site
+}
+
+// SetCookies stores the cookies set by a response from u, implementing http.CookieJar.
+func (s *Session) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	if len(cookies) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	site := s.site(u, true)
+	site.jar.SetCookies(u, cookies)
+	setURL := *u
+	for _, cookie := range cookies {
+		copied := *cookie
+		site.set[cookieKey(cookie)] = setCookie{url: &setURL, cookie: &copied}
+	}
+}
+
+// cookieKey identifies a cookie in a jar: a cookie replaces the one of the same name, domain
+// and path.
+func cookieKey(cookie *http.Cookie) string {
+	return cookie.Name + ";" + cookie.Domain + ";" + cookie.Path
+}
+
+// Cookies returns the cookies to send in a request to u, implementing http.CookieJar.
+func (s *Session) Cookies(u *url.URL) []*http.Cookie {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	site := s.site(u, false)
+	if site == nil {
+		return nil
+	}
+	return site.jar.Cookies(u)
+}
+
+// Clone returns a copy of the session that is independent of it. Expired cookies are left out;
+// cookies with a Max-Age get it again from the time of the copy.
+func (s *Session) Clone() *Session {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clone := NewSession()
+	for _, site := range s.sites {
+		for key, set := range site.set {
+			copied := clone.site(set.url, true)
+			copied.jar.SetCookies(set.url, []*http.Cookie{set.cookie})
+			copied.set[key] = set
+		}
+	}
+	return clone
+}
+
+// Clear removes the cookies of the site of host, or all cookies if host is empty.
+func (s *Session) Clear(host string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if host == "" {
+		s.sites = make(map[string]*siteCookies)
+		return
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	delete(s.sites, siteKey(strings.Trim(host, "[]")))
+}
+
+// restore replaces the cookies of the session with a copy of those of saved.
+func (s *Session) restore(saved *Session) {
+	clone := saved.Clone()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sites = clone.sites
+}
+
+// SnapshotSession returns a copy of the cookies of the client's jar, for RestoreSession after
+// a test that may change the session (e.g., a login bypass whose response sets a new session
+// cookie), or for DoWithSession.
+func (c *Client) SnapshotSession() *Session {
+	return c.session.Clone()
+}
+
+// RestoreSession replaces the cookies of the client's jar, shared with its copies, with those
+// of a snapshot taken with SnapshotSession.
+func (c *Client) RestoreSession(saved *Session) {
+	c.session.restore(saved)
+}
+
+// ClearCookies removes the cookies of the site of host from the client's jar, or all cookies
+// if host is empty.
+func (c *Client) ClearCookies(host string) {
+	c.session.Clear(host)
+}
+
+// DoWithSession performs req like Do, but with the cookies of session instead of the client's
+// jar: they are sent with req and its redirects, and the cookies set by the responses are stored
+// in session. The client's jar is left untouched, and the session is not verified
+// (SetSessionGuard).
+func (c *Client) DoWithSession(req *http.Request, session *Session) (*http.Response, error) {
+	isolated := *c
+	httpClient := *c.httpClient
+	httpClient.Jar = session
+	isolated.httpClient = &httpClient
+	isolated.session = session
+	isolated.guard = nil // The session of the client is not the one verified.
+	return isolated.Do(req)
+}
@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionIsolatesSites(t *testing.T) {
+	session := NewSession()
+	app, _ := url.Parse("https://app.example.com/")
+	sso, _ := url.Parse("https://sso.example.com/login")
+	other, _ := url.Parse("https://other.test/")
+
+	session.SetCookies(sso, []*http.Cookie{{Name: "sid", Value: "1", Domain: "example.com"}})
+	session.SetCookies(other, []*http.Cookie{{Name: "other", Value: "2"}})
+	require.Len(t, session.Cookies(app), 1, "cookies of a parent domain reach its subdomains")
+	assert.Equal(t, "sid", session.Cookies(app)[0].Name)
+	require.Len(t, session.Cookies(other), 1)
+	assert.Equal(t, "other", session.Cookies(other)[0].Name)
+
+	session.Clear("other.test:443")
+	assert.Empty(t, session.Cookies(other))
+	assert.Len(t, session.Cookies(app), 1)
+	session.Clear("")
+	assert.Empty(t, session.Cookies(app))
+}
+
+func TestSnapshotRestoreAndDoWithSession(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "admin", Path: "/"})
+			return
+		}
+		if cookie, err := r.Cookie("session"); err == nil {
+			w.Write([]byte(cookie.Value))
+		}
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := NewClient(log, ClientOptions{TargetBaseURL: server.URL, AuthCookie: "session=user"})
+	get := func(path string) string {
+		resp, err := client.Get(server.URL + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _, err := client.ReadBody(resp)
+		require.NoError(t, err)
+		return string(body)
+	}
+	assert.Equal(t, "user", get("/"))
+
+	saved := client.SnapshotSession()
+	get("/login")
+	assert.Equal(t, "admin", get("/"), "the login replaced the session cookie")
+	client.RestoreSession(saved)
+	assert.Equal(t, "user", get("/"))
+
+	// A request with its own session neither sends nor changes the cookies of the client.
+	session := NewSession()
+	req, err := http.NewRequest("GET", server.URL+"/login", nil)
+	require.NoError(t, err)
+	resp, err := client.DoWithSession(req, session)
+	require.NoError(t, err)
+	resp.Body.Close()
+	u, _ := url.Parse(server.URL)
+	require.Len(t, session.Cookies(u), 1)
+	assert.Equal(t, "admin", session.Cookies(u)[0].Value)
+	assert.Equal(t, "user", get("/"))
+
+	client.ClearCookies(u.Host)
+	assert.Equal(t, "", get("/"))
+}
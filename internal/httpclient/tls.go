@@ -0,0 +1,81 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// tlsVersions maps the TLS versions accepted by ParseTLSVersion to their crypto/tls constants.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion parses a TLS version ("1.0", "1.1", "1.2" or "1.3", optionally prefixed with
+// "TLS"). An empty version returns 0, the crypto/tls default (TLS 1.2).
+func ParseTLSVersion(version string) (uint16, error) {
+	version = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "tls")
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return 0, nil
+	}
+	v, ok := tlsVersions[version]
+	if !ok {
+		return 0, fmt.Errorf("unsupported TLS version %q; use 1.0, 1.1, 1.2 or 1.3", version)
+	}
+	return v, nil
+}
+
+// newTLSConfig creates the TLS configuration of a client from the TLS settings of opts.
+func newTLSConfig(opts ClientOptions) (*tls.Config, error) {
+	minVersion, err := ParseTLSVersion(opts.MinTLSVersion)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: opts.InsecureSkipVerify,
+		ServerName:         opts.ServerName,
+		MinVersion:         minVersion,
+	}
+	if opts.CACertFile != "" {
+		pool, err := loadCertPool(opts.CACertFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if opts.ClientCertFile != "" || opts.ClientKeyFile != "" {
+		if opts.ClientCertFile == "" {
+			return nil, fmt.Errorf("client key %s given without a client certificate", opts.ClientKeyFile)
+		}
+		keyFile := opts.ClientKeyFile
+		if keyFile == "" {
+			keyFile = opts.ClientCertFile // A PEM file holding both the certificate and its key.
+		}
+		cert, err := tls.LoadX509KeyPair(opts.ClientCertFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// configureHTTPVersion sets the protocols transport negotiates: HTTP/1.1 only for "" and "1.1",
+// and HTTP/2 over TLS (with ALPN, falling back to HTTP/1.1) for "2".
+func configureHTTPVersion(transport *http.Transport, version string) error {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "http/") {
+	case "", "1.1", "1":
+		// A non-nil empty map keeps the transport from ever upgrading to HTTP/2.
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	case "2", "2.0":
+		transport.ForceAttemptHTTP2 = true
+	default:
+		return fmt.Errorf("unsupported HTTP version %q; use 1.1 or 2", version)
+	}
+	return nil
+}
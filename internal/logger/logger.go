@@ -1,9 +1,14 @@
 package logger
 
 import (
-	"log"
+	"encoding/json"
+	"fmt"
+	"io"
 	"os"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 // LogLevel represents the severity of a log message.
@@ -21,75 +26,292 @@ const (
 	SUCCESS                 // 5 - Success messages (e.g., vulnerability found)
 )
 
-// Logger holds the loggers for different levels and a mutex for concurrent writes.
-type Logger struct {
-	infoLogger    *log.Logger
-	warnLogger    *log.Logger
-	errorLogger   *log.Logger
-	debugLogger   *log.Logger
-	traceLogger   *log.Logger
-	successLogger *log.Logger
+// levelNames are the names of the levels in log output and in ParseLevel.
+var levelNames = map[LogLevel]string{
+	TRACE:   "trace",
+	DEBUG:   "debug",
+	INFO:    "info",
+	WARN:    "warn",
+	ERROR:   "error",
+	SUCCESS: "success",
+}
+
+// String returns the name of the level (e.g., "debug").
+func (l LogLevel) String() string {
+	if name, ok := levelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLevel parses a level name: trace, debug, info, warn, error or success.
+func ParseLevel(name string) (LogLevel, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "warning" {
+		name = "warn"
+	}
+	for level, levelName := range levelNames {
+		if levelName == name {
+			return level, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown log level %q; use trace, debug, info, warn, error or success", name)
+}
+
+// ParseScannerLevels parses per-scanner levels given as "sqli=debug,xss=trace".
+func ParseScannerLevels(spec string) (map[string]LogLevel, error) {
+	levels := make(map[string]LogLevel)
+	for _, part := range strings.Split(spec, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		scanner, name, ok := strings.Cut(part, "=")
+		scanner = strings.TrimSpace(scanner)
+		if !ok || scanner == "" {
+			return nil, fmt.Errorf("invalid scanner log level %q; use scanner=level", part)
+		}
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		levels[scanner] = level
+	}
+	return levels, nil
+}
+
+// Format is the encoding of log lines.
+type Format string
+
+// Log formats.
+const (
+	FormatText Format = "text" // "[INFO] 2006/01/02 15:04:05 message key=value"
+	FormatJSON Format = "json" // One JSON object per line, for log shippers (e.g., ELK).
+)
+
+// ScannerField is the field that scopes a logger to a scanner, so that SetScannerLevel applies.
+const ScannerField = "scanner"
+
+// Fields are key/value pairs attached to every message of a logger derived with With.
+type Fields map[string]interface{}
+
+// DefaultRedactKeys mark field names whose values are redacted (e.g., "cookie", "Authorization").
+var DefaultRedactKeys = []string{"auth", "cookie", "token", "session", "password", "secret", "key", "csrf"}
+
+// redacted replaces sensitive values in log output.
+const redacted = "[REDACTED]"
+
+// output is the destination and configuration shared by a logger and every logger derived
+// from it with With.
+type output struct {
 	mu            sync.Mutex // Mutex to ensure thread-safe writes
 	minLevel      LogLevel
+	scannerLevels map[string]LogLevel
+	format        Format
+	stdout        io.Writer // INFO, DEBUG, TRACE and SUCCESS.
+	stderr        io.Writer // WARN and ERROR.
+	redactKeys    []string
+	secrets       []string
+}
+
+// Logger writes leveled messages. Messages are printf-style; structured fields are attached
+// with With.
+type Logger struct {
+	out     *output
+	fields  Fields
+	scanner string // Value of ScannerField, if any.
 }
 
 // NewLogger creates and returns a new Logger instance.
 func NewLogger(minLevel LogLevel) *Logger {
-	flags := log.Ldate | log.Ltime
-	return &Logger{
-		infoLogger:    log.New(os.Stdout, "[INFO] ", flags),
-		warnLogger:    log.New(os.Stderr, "[WARN] ", flags),
-		errorLogger:   log.New(os.Stderr, "[ERROR] ", flags),
-		debugLogger:   log.New(os.Stdout, "[DEBUG] ", flags),
-		traceLogger:   log.New(os.Stdout, "[TRACE] ", flags),
-		successLogger: log.New(os.Stdout, "[SUCCESS] ", flags),
-		minLevel:      minLevel,
+	return &Logger{out: &output{
+		minLevel:   minLevel,
+		format:     FormatText,
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
+		redactKeys: DefaultRedactKeys,
+	}}
+}
+
+// With returns a logger that attaches fields to every message, in addition to the fields of l.
+// A ScannerField scopes the logger to that scanner's level (SetScannerLevel). The returned
+// logger shares the output and settings of l.
+func (l *Logger) With(fields Fields) *Logger {
+	scoped := &Logger{out: l.out, fields: make(Fields, len(l.fields)+len(fields)), scanner: l.scanner}
+	for k, v := range l.fields {
+		scoped.fields[k] = v
+	}
+	for k, v := range fields {
+		scoped.fields[k] = v
+	}
+	if name, ok := fields[ScannerField].(string); ok {
+		scoped.scanner = name
 	}
+	return scoped
 }
 
-// log prints a message if its level is greater than or equal to the logger's minLevel.
-func (l *Logger) log(level LogLevel, logger *log.Logger, format string, v ...interface{}) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
+// enabled reports whether messages of level are written by l.
+func (l *Logger) enabled(level LogLevel) bool {
+	if l.scanner != "" {
+		if min, ok := l.out.scannerLevels[l.scanner]; ok {
+			return level >= min
+		}
+	}
+	return level >= l.out.minLevel
+}
+
+// log prints a message if its level is enabled for the logger.
+func (l *Logger) log(level LogLevel, format string, v ...interface{}) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+
+	if !l.enabled(level) {
+		return
+	}
+	w := l.out.stdout
+	if level == WARN || level == ERROR {
+		w = l.out.stderr
+	}
+	msg := l.out.redactSecrets(fmt.Sprintf(format, v...))
+	now := time.Now()
+
+	if l.out.format == FormatJSON {
+		entry := make(map[string]interface{}, len(l.fields)+3)
+		for k, v := range l.fields {
+			entry[k] = l.out.redactField(k, v)
+		}
+		entry["time"] = now.Format(time.RFC3339)
+		entry["level"] = level.String()
+		entry["msg"] = strings.TrimSpace(msg)
+		line, err := json.Marshal(entry)
+		if err != nil {
+			line, _ = json.Marshal(map[string]string{"time": now.Format(time.RFC3339), "level": level.String(), "msg": msg})
+		}
+		w.Write(append(line, '\n'))
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("[" + strings.ToUpper(level.String()) + "] ")
+	b.WriteString(now.Format("2006/01/02 15:04:05 "))
+	b.WriteString(strings.TrimSuffix(msg, "\n"))
+	keys := make([]string, 0, len(l.fields))
+	for k := range l.fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		value := fmt.Sprint(l.out.redactField(k, l.fields[k]))
+		if strings.ContainsAny(value, " \t\"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		b.WriteString(" " + k + "=" + value)
+	}
+	b.WriteByte('\n')
+	io.WriteString(w, b.String())
+}
+
+// redactField returns the value of field key for output: redacted if key is sensitive.
+func (o *output) redactField(key string, value interface{}) interface{} {
+	lower := strings.ToLower(key)
+	for _, word := range o.redactKeys {
+		if word != "" && strings.Contains(lower, strings.ToLower(word)) {
+			return redacted
+		}
+	}
+	if s, ok := value.(string); ok {
+		return o.redactSecrets(s)
+	}
+	return value
+}
 
-	if level >= l.minLevel {
-		logger.Printf(format, v...)
+// redactSecrets replaces the registered secret values in s.
+func (o *output) redactSecrets(s string) string {
+	for _, secret := range o.secrets {
+		s = strings.ReplaceAll(s, secret, redacted)
 	}
+	return s
 }
 
 // Info logs an informational message.
 func (l *Logger) Info(format string, v ...interface{}) {
-	l.log(INFO, l.infoLogger, format, v...)
+	l.log(INFO, format, v...)
 }
 
 // Warn logs a warning message.
 func (l *Logger) Warn(format string, v ...interface{}) {
-	l.log(WARN, l.warnLogger, format, v...)
+	l.log(WARN, format, v...)
 }
 
 // Error logs an error message.
 func (l *Logger) Error(format string, v ...interface{}) {
-	l.log(ERROR, l.errorLogger, format, v...)
+	l.log(ERROR, format, v...)
 }
 
 // Debug logs a debug message. Only active if minLevel is DEBUG.
 func (l *Logger) Debug(format string, v ...interface{}) {
-	l.log(DEBUG, l.debugLogger, format, v...)
+	l.log(DEBUG, format, v...)
 }
 
 // Trace logs a trace message. Only active if minLevel is TRACE.
 func (l *Logger) Trace(format string, v ...interface{}) {
-	l.log(TRACE, l.traceLogger, format, v...)
+	l.log(TRACE, format, v...)
 }
 
 // Success logs a success message, typically for found vulnerabilities.
 func (l *Logger) Success(format string, v ...interface{}) {
-	l.log(SUCCESS, l.successLogger, format, v...)
+	l.log(SUCCESS, format, v...)
 }
 
 // SetMinLevel sets the minimum logging level.
 func (l *Logger) SetMinLevel(level LogLevel) {
-	l.mu.Lock()
-	defer l.mu.Unlock()
-	l.minLevel = level
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.minLevel = level
+}
+
+// SetScannerLevel sets the minimum level of loggers scoped to scanner (With a ScannerField),
+// overriding the minimum level, e.g. DEBUG for "sqli" only.
+func (l *Logger) SetScannerLevel(scanner string, level LogLevel) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	if l.out.scannerLevels == nil {
+		l.out.scannerLevels = make(map[string]LogLevel)
+	}
+	l.out.scannerLevels[scanner] = level
+}
+
+// SetFormat sets the encoding of log lines.
+func (l *Logger) SetFormat(format Format) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.format = format
+}
+
+// SetOutput sets the writers of INFO, DEBUG, TRACE and SUCCESS messages (stdout) and of WARN and
+// ERROR messages (stderr).
+func (l *Logger) SetOutput(stdout, stderr io.Writer) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.stdout, l.out.stderr = stdout, stderr
+}
+
+// SetRedactKeys sets the words that mark sensitive field names, matched case-insensitively
+// within the name (default: DefaultRedactKeys). Their values are logged as "[REDACTED]".
+func (l *Logger) SetRedactKeys(words []string) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	l.out.redactKeys = words
+}
+
+// AddSecrets registers values, such as session cookies and authentication header values, that
+// are replaced with "[REDACTED]" wherever they appear in messages and fields. Values shorter
+// than 4 characters are ignored, as redacting them would mangle unrelated text.
+func (l *Logger) AddSecrets(values ...string) {
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	for _, value := range values {
+		if len(value) >= 4 {
+			l.out.secrets = append(l.out.secrets, value)
+		}
+	}
 }
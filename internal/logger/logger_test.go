@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestLogger(minLevel LogLevel) (*Logger, *bytes.Buffer) {
+	var buf bytes.Buffer
+	log := NewLogger(minLevel)
+	log.SetOutput(&buf, &buf)
+	return log, &buf
+}
+
+func TestWithAttachesFields(t *testing.T) {
+	log, buf := newTestLogger(INFO)
+	scoped := log.With(Fields{ScannerField: "sqli", "url": "http://example.com/?id=1"}).With(Fields{"param": "id"})
+
+	scoped.Info("Testing %s", "payload")
+	line := buf.String()
+	assert.True(t, strings.HasPrefix(line, "[INFO] "), line)
+	assert.Contains(t, line, "Testing payload param=id scanner=sqli url=\"http://example.com/?id=1\"")
+
+	// The parent logger is unchanged.
+	buf.Reset()
+	log.Info("plain")
+	assert.NotContains(t, buf.String(), "scanner=")
+}
+
+func TestJSONFormat(t *testing.T) {
+	log, buf := newTestLogger(INFO)
+	log.SetFormat(FormatJSON)
+
+	log.With(Fields{ScannerField: "sqli", "param": "id"}).Warn("Blocked: %d", 403)
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "warn", entry["level"])
+	assert.Equal(t, "Blocked: 403", entry["msg"])
+	assert.Equal(t, "sqli", entry["scanner"])
+	assert.Equal(t, "id", entry["param"])
+	assert.NotEmpty(t, entry["time"])
+}
+
+func TestScannerLevel(t *testing.T) {
+	log, buf := newTestLogger(INFO)
+	log.SetScannerLevel("sqli", DEBUG)
+	sqli := log.With(Fields{ScannerField: "sqli"})
+	xss := log.With(Fields{ScannerField: "xss"})
+
+	sqli.Debug("sqli debug")
+	xss.Debug("xss debug")
+	log.Debug("global debug")
+	assert.Contains(t, buf.String(), "sqli debug")
+	assert.NotContains(t, buf.String(), "xss debug")
+	assert.NotContains(t, buf.String(), "global debug")
+
+	// A scanner level can also be stricter than the minimum level.
+	log.SetScannerLevel("xss", ERROR)
+	buf.Reset()
+	xss.Info("xss info")
+	assert.Empty(t, buf.String())
+}
+
+func TestRedaction(t *testing.T) {
+	log, buf := newTestLogger(INFO)
+	log.AddSecrets("s3cr3t-session", "ab")
+
+	log.With(Fields{"Cookie": "PHPSESSID=abc", "url": "http://example.com/?sid=s3cr3t-session"}).Info("Sending s3cr3t-session to ab")
+	line := buf.String()
+	assert.NotContains(t, line, "abc")
+	assert.NotContains(t, line, "s3cr3t-session")
+	assert.Contains(t, line, "Sending [REDACTED] to ab")
+	assert.Contains(t, line, "Cookie=[REDACTED]")
+
+	log.SetRedactKeys([]string{"nonce"})
+	buf.Reset()
+	log.With(Fields{"Cookie": "visible", "X-Nonce": "hidden"}).Info("x")
+	assert.Contains(t, buf.String(), "Cookie=visible")
+	assert.NotContains(t, buf.String(), "hidden")
+}
+
+func TestParseScannerLevels(t *testing.T) {
+	levels, err := ParseScannerLevels("sqli=debug, xss=TRACE,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]LogLevel{"sqli": DEBUG, "xss": TRACE}, levels)
+
+	_, err = ParseScannerLevels("sqli")
+	assert.Error(t, err)
+	_, err = ParseScannerLevels("sqli=loud")
+	assert.Error(t, err)
+}
@@ -0,0 +1,275 @@
+// Package metrics accumulates the statistics of a scan: the wall-clock time of its phases, the
+// requests, response times and findings of each scanner, the response times of each host and
+// the slowest URLs. They are summarized at the end of the scan, in the terminal and the findings
+// document, and can be served in the Prometheus text format while the scan runs.
+package metrics
+
+import (
+	"Dursgo/internal/httpclient"
+	"math"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SlowestURLs is the number of URLs listed in Summary.Slowest.
+const SlowestURLs = 10
+
+// Summary is a snapshot of the statistics of a scan.
+type Summary struct {
+	Phases   []PhaseStats   `json:"phases,omitempty"`       // In the order they ran.
+	Scanners []ScannerStats `json:"scanners,omitempty"`     // Longest scan time first.
+	Hosts    []HostStats    `json:"hosts,omitempty"`        // Most requests first.
+	Slowest  []SlowURL      `json:"slowest_urls,omitempty"` // Slowest response first.
+}
+
+// PhaseStats is the wall-clock time of a phase of the scan and the requests sent during it.
+type PhaseStats struct {
+	Name            string  `json:"name"` // e.g. "crawl", "passive", "active".
+	DurationSeconds float64 `json:"duration_seconds"`
+	Requests        int64   `json:"requests"`
+}
+
+// ScannerStats are the statistics of a scanner module. TimeSeconds adds up the time of its
+// tests, which run concurrently, so it can exceed the duration of the active phase.
+type ScannerStats struct {
+	Name                 string  `json:"name"`                  // Module name, e.g. "sqli".
+	Requests             int64   `json:"requests"`              // Requests sent, including redirects and retries.
+	Errors               int64   `json:"errors"`                // Requests that got no response.
+	ResponseTimeSeconds  float64 `json:"response_time_seconds"` // Total time waiting for responses.
+	Tests                int64   `json:"tests"`                 // Requests tested (or passes over the crawled responses).
+	TimeSeconds          float64 `json:"time_seconds"`          // Total time of the tests.
+	Findings             int64   `json:"findings"`              // Findings reported by the tests.
+	AverageResponseMilli float64 `json:"average_response_ms"`   // ResponseTimeSeconds / requests that got a response.
+	responses            int64
+}
+
+// HostStats are the requests sent to a host and its response times.
+type HostStats struct {
+	Host                 string  `json:"host"`
+	Requests             int64   `json:"requests"`
+	Errors               int64   `json:"errors"`                // Requests that got no response.
+	ResponseTimeSeconds  float64 `json:"response_time_seconds"` // Total time waiting for responses.
+	AverageResponseMilli float64 `json:"average_response_ms"`
+	responses            int64
+}
+
+// SlowURL is a URL (without its query) among the slowest to respond, with its slowest request.
+type SlowURL struct {
+	Method        string  `json:"method"`
+	URL           string  `json:"url"`
+	Scanner       string  `json:"scanner,omitempty"` // Module that sent the request; empty for other phases.
+	Phase         string  `json:"phase"`
+	Status        int     `json:"status"`
+	ResponseMilli float64 `json:"response_ms"`
+	responseTime  time.Duration
+}
+
+// Collector accumulates the statistics of a scan. Its methods are safe for concurrent use; a nil
+// *Collector records nothing.
+type Collector struct {
+	mu         sync.Mutex
+	phases     []PhaseStats
+	phase      string // Running phase; empty before the first and after Finish.
+	phaseStart time.Time
+	scanners   map[string]*ScannerStats
+	hosts      map[string]*HostStats
+	slowest    []SlowURL
+}
+
+// NewCollector returns an empty Collector.
+func NewCollector() *Collector {
+	return &Collector{scanners: make(map[string]*ScannerStats), hosts: make(map[string]*HostStats)}
+}
+
+// StartPhase ends the running phase, if any, and starts the named one. Requests are counted in
+// the phase running when they complete.
+func (c *Collector) StartPhase(name string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.endPhase()
+	c.phases = append(c.phases, PhaseStats{Name: name})
+	c.phase, c.phaseStart = name, time.Now()
+}
+
+// Finish ends the running phase.
+func (c *Collector) Finish() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.endPhase()
+}
+
+// endPhase records the duration of the running phase. c.mu must be held.
+func (c *Collector) endPhase() {
+	if c.phase == "" {
+		return
+	}
+	c.phases[len(c.phases)-1].DurationSeconds = seconds(time.Since(c.phaseStart))
+	c.phase = ""
+}
+
+// ObserveRequest records a request sent by an HTTP client; it is an httpclient.RequestObserver.
+func (c *Collector) ObserveRequest(r httpclient.RequestMetric) {
+	if c == nil {
+		return
+	}
+	failed := r.Err != nil
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.phase != "" {
+		c.phases[len(c.phases)-1].Requests++
+	}
+
+	host := c.hosts[r.Host]
+	if host == nil {
+		host = &HostStats{Host: r.Host}
+		c.hosts[r.Host] = host
+	}
+	host.Requests++
+	if failed {
+		host.Errors++
+	} else {
+		host.responses++
+		host.ResponseTimeSeconds += r.Duration.Seconds()
+	}
+
+	if r.Source.Scanner != "" {
+		s := c.scanner(r.Source.Scanner)
+		s.Requests++
+		if failed {
+			s.Errors++
+		} else {
+			s.responses++
+			s.ResponseTimeSeconds += r.Duration.Seconds()
+		}
+	}
+	if !failed {
+		c.observeSlow(r)
+	}
+}
+
+// observeSlow keeps the URL of r among the slowest if it is. Each URL is listed once, with its
+// slowest request. c.mu must be held.
+func (c *Collector) observeSlow(r httpclient.RequestMetric) {
+	u := r.URL
+	if parsed, err := url.Parse(r.URL); err == nil {
+		parsed.RawQuery, parsed.Fragment, parsed.User = "", "", nil
+		u = parsed.String()
+	}
+	slow := SlowURL{Method: r.Method, URL: u, Scanner: r.Source.Scanner, Phase: r.Source.Phase, Status: r.Status, responseTime: r.Duration}
+	for i, listed := range c.slowest {
+		if listed.Method == slow.Method && listed.URL == slow.URL {
+			if slow.responseTime <= listed.responseTime {
+				return
+			}
+			c.slowest = append(c.slowest[:i], c.slowest[i+1:]...)
+			break
+		}
+	}
+	if len(c.slowest) == SlowestURLs && slow.responseTime <= c.slowest[len(c.slowest)-1].responseTime {
+		return
+	}
+	i := sort.Search(len(c.slowest), func(i int) bool { return c.slowest[i].responseTime < slow.responseTime })
+	c.slowest = append(c.slowest, SlowURL{})
+	copy(c.slowest[i+1:], c.slowest[i:])
+	c.slowest[i] = slow
+	if len(c.slowest) > SlowestURLs {
+		c.slowest = c.slowest[:SlowestURLs]
+	}
+}
+
+// ObserveScan records a test by the scanner module, how long it took and the findings it
+// reported; it implements scanner.ScanObserver.
+func (c *Collector) ObserveScan(module string, elapsed time.Duration, findings int) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s := c.scanner(module)
+	s.Tests++
+	s.TimeSeconds += elapsed.Seconds()
+	s.Findings += int64(findings)
+}
+
+// scanner returns the statistics of the module, adding them if needed. c.mu must be held.
+func (c *Collector) scanner(module string) *ScannerStats {
+	s := c.scanners[module]
+	if s == nil {
+		s = &ScannerStats{Name: module}
+		c.scanners[module] = s
+	}
+	return s
+}
+
+// Summary returns the statistics recorded so far. The running phase is reported with the time
+// it has run.
+func (c *Collector) Summary() Summary {
+	if c == nil {
+		return Summary{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var summary Summary
+	summary.Phases = append(summary.Phases, c.phases...)
+	if c.phase != "" {
+		summary.Phases[len(summary.Phases)-1].DurationSeconds = seconds(time.Since(c.phaseStart))
+	}
+	for _, s := range c.scanners {
+		stats := *s
+		stats.ResponseTimeSeconds = round(stats.ResponseTimeSeconds)
+		stats.TimeSeconds = round(stats.TimeSeconds)
+		stats.AverageResponseMilli = average(s.ResponseTimeSeconds, s.responses)
+		summary.Scanners = append(summary.Scanners, stats)
+	}
+	sort.Slice(summary.Scanners, func(i, j int) bool {
+		a, b := summary.Scanners[i], summary.Scanners[j]
+		if a.TimeSeconds != b.TimeSeconds {
+			return a.TimeSeconds > b.TimeSeconds
+		}
+		return a.Name < b.Name
+	})
+	for _, h := range c.hosts {
+		stats := *h
+		stats.ResponseTimeSeconds = round(stats.ResponseTimeSeconds)
+		stats.AverageResponseMilli = average(h.ResponseTimeSeconds, h.responses)
+		summary.Hosts = append(summary.Hosts, stats)
+	}
+	sort.Slice(summary.Hosts, func(i, j int) bool {
+		a, b := summary.Hosts[i], summary.Hosts[j]
+		if a.Requests != b.Requests {
+			return a.Requests > b.Requests
+		}
+		return a.Host < b.Host
+	})
+	for _, slow := range c.slowest {
+		slow.ResponseMilli = round(float64(slow.responseTime.Microseconds()) / 1000)
+		summary.Slowest = append(summary.Slowest, slow)
+	}
+	return summary
+}
+
+// seconds returns d in seconds, rounded to the millisecond.
+func seconds(d time.Duration) float64 {
+	return round(d.Seconds())
+}
+
+// average returns the average of n response times adding up to total seconds, in milliseconds.
+func average(total float64, n int64) float64 {
+	if n == 0 {
+		return 0
+	}
+	return round(total * 1000 / float64(n))
+}
+
+// round rounds f to three decimals.
+func round(f float64) float64 {
+	return math.Round(f*1000) / 1000
+}
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"Dursgo/internal/httpclient"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectorSummary(t *testing.T) {
+	c := NewCollector()
+	scan := httpclient.Source{Phase: "scan", Scanner: "sqli"}
+	c.ObserveRequest(httpclient.RequestMetric{Source: httpclient.Source{Phase: "fingerprint"}, Method: "GET", URL: "https://a.example/", Host: "a.example", Status: 200, Duration: 100 * time.Millisecond})
+	c.StartPhase("crawl")
+	c.ObserveRequest(httpclient.RequestMetric{Source: httpclient.Source{Phase: "crawl"}, Method: "GET", URL: "https://a.example/about", Host: "a.example", Status: 200, Duration: 300 * time.Millisecond})
+	c.StartPhase("active")
+	c.ObserveRequest(httpclient.RequestMetric{Source: scan, Method: "GET", URL: "https://a.example/item?id=1", Host: "a.example", Status: 200, Duration: 200 * time.Millisecond})
+	c.ObserveRequest(httpclient.RequestMetric{Source: scan, Method: "GET", URL: "https://a.example/item?id=1'", Host: "a.example", Status: 500, Duration: 5 * time.Second})
+	c.ObserveRequest(httpclient.RequestMetric{Source: scan, Method: "GET", URL: "https://b.example/", Host: "b.example", Err: errors.New("timeout"), Duration: 10 * time.Second})
+	c.ObserveScan("sqli", 6*time.Second, 1)
+	c.ObserveScan("sqli", 2*time.Second, 0)
+	c.ObserveScan("xss-reflected", time.Second, 2)
+	c.Finish()
+
+	summary := c.Summary()
+	require.Len(t, summary.Phases, 2)
+	assert.Equal(t, "crawl", summary.Phases[0].Name)
+	assert.Equal(t, int64(1), summary.Phases[0].Requests, "requests before the first phase are in none")
+	assert.Equal(t, int64(3), summary.Phases[1].Requests)
+
+	require.Len(t, summary.Scanners, 2)
+	assert.Equal(t, ScannerStats{Name: "sqli", Requests: 3, Errors: 1, ResponseTimeSeconds: 5.2, Tests: 2, TimeSeconds: 8, Findings: 1, AverageResponseMilli: 2600, responses: 2}, summary.Scanners[0], "the longest scan time first")
+	assert.Equal(t, "xss-reflected", summary.Scanners[1].Name)
+
+	require.Len(t, summary.Hosts, 2)
+	assert.Equal(t, HostStats{Host: "a.example", Requests: 4, ResponseTimeSeconds: 5.6, AverageResponseMilli: 1400, responses: 4}, summary.Hosts[0])
+	assert.Equal(t, int64(1), summary.Hosts[1].Errors)
+
+	require.Len(t, summary.Slowest, 3, "failed requests and the query are left out")
+	assert.Equal(t, "https://a.example/item", summary.Slowest[0].URL)
+	assert.Equal(t, 5000.0, summary.Slowest[0].ResponseMilli)
+	assert.Equal(t, "sqli", summary.Slowest[0].Scanner)
+	assert.Equal(t, "https://a.example/about", summary.Slowest[1].URL)
+	assert.Equal(t, "https://a.example/", summary.Slowest[2].URL)
+}
+
+func TestCollectorKeepsTheSlowestURLs(t *testing.T) {
+	c := NewCollector()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				c.ObserveRequest(httpclient.RequestMetric{Method: "GET", URL: fmt.Sprintf("https://a.example/%d", j), Host: "a.example", Status: 200, Duration: time.Duration(i*j) * time.Millisecond})
+				c.ObserveScan("sqli", time.Millisecond, 0)
+			}
+		}()
+	}
+	wg.Wait()
+
+	summary := c.Summary()
+	assert.Equal(t, int64(1000), summary.Hosts[0].Requests)
+	assert.Equal(t, int64(1000), summary.Scanners[0].Tests)
+	require.Len(t, summary.Slowest, SlowestURLs)
+	for i, slow := range summary.Slowest {
+		assert.Equal(t, fmt.Sprintf("https://a.example/%d", 19-i), slow.URL, "each URL once, with its slowest response")
+		assert.Equal(t, float64(49*(19-i)), slow.ResponseMilli)
+	}
+}
+
+func TestServePrometheus(t *testing.T) {
+	c := NewCollector()
+	c.StartPhase("active")
+	c.ObserveRequest(httpclient.RequestMetric{Source: httpclient.Source{Phase: "scan", Scanner: "sqli"}, Method: "GET", URL: "https://a.example/", Host: `a"example`, Status: 200, Duration: 250 * time.Millisecond})
+	c.ObserveScan("sqli", time.Second, 2)
+
+	_, err := Serve(c, "0.0.0.0:0")
+	assert.ErrorContains(t, err, "only loopback addresses are allowed")
+	server, err := Serve(c, "127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close()
+
+	resp, err := http.Get("http://" + server.Addr() + "/metrics")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, resp.Header.Get("Content-Type"), "version=0.0.4")
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	text := string(body)
+	assert.Contains(t, text, "# TYPE dursgo_scanner_requests_total counter\ndursgo_scanner_requests_total{scanner=\"sqli\"} 1\n")
+	assert.Contains(t, text, "dursgo_scanner_findings_total{scanner=\"sqli\"} 2\n")
+	assert.Contains(t, text, "dursgo_phase_running{phase=\"active\"} 1\n")
+	assert.Contains(t, text, "dursgo_host_response_time_seconds_total{host=\"a\\\"example\"} 0.25\n")
+}
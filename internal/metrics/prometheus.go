@@ -0,0 +1,144 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// WritePrometheus writes the statistics recorded so far in the Prometheus text exposition
+// format.
+func (c *Collector) WritePrometheus(w io.Writer) error {
+	summary := c.Summary()
+	var b strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	sample := func(name, label, value string, v float64) {
+		fmt.Fprintf(&b, "%s{%s=\"%s\"} %g\n", name, label, escapeLabel(value), v)
+	}
+
+	running := ""
+	if c != nil {
+		c.mu.Lock()
+		running = c.phase
+		c.mu.Unlock()
+	}
+	metric("dursgo_phase_duration_seconds", "gauge", "Wall-clock time of each phase of the scan, so far for the running one.")
+	for _, p := range summary.Phases {
+		sample("dursgo_phase_duration_seconds", "phase", p.Name, p.DurationSeconds)
+	}
+	metric("dursgo_phase_requests_total", "counter", "HTTP requests sent during each phase of the scan.")
+	for _, p := range summary.Phases {
+		sample("dursgo_phase_requests_total", "phase", p.Name, float64(p.Requests))
+	}
+	metric("dursgo_phase_running", "gauge", "1 for the running phase of the scan.")
+	for i, p := range summary.Phases {
+		value := 0.0
+		if i == len(summary.Phases)-1 && p.Name == running {
+			value = 1
+		}
+		sample("dursgo_phase_running", "phase", p.Name, value)
+	}
+
+	metric("dursgo_scanner_requests_total", "counter", "HTTP requests sent by each scanner, including redirects and retries.")
+	for _, s := range summary.Scanners {
+		sample("dursgo_scanner_requests_total", "scanner", s.Name, float64(s.Requests))
+	}
+	metric("dursgo_scanner_request_errors_total", "counter", "HTTP requests of each scanner that got no response.")
+	for _, s := range summary.Scanners {
+		sample("dursgo_scanner_request_errors_total", "scanner", s.Name, float64(s.Errors))
+	}
+	metric("dursgo_scanner_tests_total", "counter", "Requests tested by each scanner.")
+	for _, s := range summary.Scanners {
+		sample("dursgo_scanner_tests_total", "scanner", s.Name, float64(s.Tests))
+	}
+	metric("dursgo_scanner_time_seconds_total", "counter", "Time spent in the tests of each scanner.")
+	for _, s := range summary.Scanners {
+		sample("dursgo_scanner_time_seconds_total", "scanner", s.Name, s.TimeSeconds)
+	}
+	metric("dursgo_scanner_findings_total", "counter", "Findings reported by each scanner.")
+	for _, s := range summary.Scanners {
+		sample("dursgo_scanner_findings_total", "scanner", s.Name, float64(s.Findings))
+	}
+
+	metric("dursgo_host_requests_total", "counter", "HTTP requests sent to each host.")
+	for _, h := range summary.Hosts {
+		sample("dursgo_host_requests_total", "host", h.Host, float64(h.Requests))
+	}
+	metric("dursgo_host_request_errors_total", "counter", "HTTP requests to each host that got no response.")
+	for _, h := range summary.Hosts {
+		sample("dursgo_host_request_errors_total", "host", h.Host, float64(h.Errors))
+	}
+	metric("dursgo_host_response_time_seconds_total", "counter", "Time spent waiting for the responses of each host.")
+	for _, h := range summary.Hosts {
+		sample("dursgo_host_response_time_seconds_total", "host", h.Host, h.ResponseTimeSeconds)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// escapeLabel escapes a label value of the Prometheus text format.
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// Server serves the statistics of a scan at /metrics, in the Prometheus text format.
+type Server struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+// CheckAddr checks that addr is a host:port on the loopback interface. Other hosts are refused,
+// as the metrics endpoint has no authentication.
+func CheckAddr(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("metrics address %q: %v", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("metrics address %q: only loopback addresses are allowed", addr)
+	}
+	return nil
+}
+
+// Serve starts serving the statistics of c at /metrics on addr (see CheckAddr) in the
+// background.
+func Serve(c *Collector, addr string) (*Server, error) {
+	if err := CheckAddr(addr); err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.WritePrometheus(w)
+	})
+	s := &Server{server: &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}, listener: listener}
+	go s.server.Serve(listener)
+	return s, nil
+}
+
+// Addr returns the host:port the server listens on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server.
+func (s *Server) Close() error {
+	if s == nil {
+		return nil
+	}
+	return s.server.Close()
+}
@@ -0,0 +1,295 @@
+// Package notify posts findings to webhooks (generic JSON and Slack incoming webhooks) while the
+// scan is running.
+package notify
+
+import (
+	"Dursgo/internal/logger"
+	"Dursgo/internal/reporter"
+	"Dursgo/internal/scanner"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Environment variables holding the webhook URLs when the configuration does not name others.
+// The URLs are secrets (Slack webhook URLs grant posting rights), so they are never read from
+// config.yaml or logged.
+const (
+	DefaultWebhookURLEnv      = "DURSGO_WEBHOOK_URL"
+	DefaultSlackWebhookURLEnv = "DURSGO_SLACK_WEBHOOK_URL"
+)
+
+// DefaultMinSeverity is the lowest severity notified when Options.MinSeverity is empty.
+const DefaultMinSeverity = "High"
+
+// DefaultDigestInterval is how often digests are sent when Options.DigestInterval is not positive.
+const DefaultDigestInterval = 5 * time.Minute
+
+// evidenceSnippetBytes is the size evidence is truncated to in notifications.
+const evidenceSnippetBytes = 300
+
+// Options configures a Notifier.
+type Options struct {
+	WebhookURL      string        // Generic webhook receiving findings as JSON; empty disables it.
+	SlackWebhookURL string        // Slack incoming webhook; empty disables it.
+	Target          string        // Target URL of the scan, included in the messages.
+	MinSeverity     string        // Lowest severity notified (DefaultMinSeverity when empty).
+	Digest          bool          // Send batched digests instead of one message per finding.
+	DigestInterval  time.Duration // Time between digests (DefaultDigestInterval when not positive).
+}
+
+// endpoint is a webhook findings are posted to.
+type endpoint struct {
+	name   string // Shown in logs instead of the secret URL.
+	url    string
+	encode func(target string, findings []reporter.Finding) ([]byte, error)
+}
+
+// Notifier posts the findings emitted during a scan to webhooks in the background. It implements
+// scanner.FindingSink; findings below the severity threshold or with a fingerprint already
+// notified are skipped. Delivery failures are logged and never stop the scan. The methods of a
+// nil Notifier do nothing.
+type Notifier struct {
+	opts        Options
+	endpoints   []endpoint
+	client      *http.Client
+	log         *logger.Logger
+	retryDelays []time.Duration // Waits before the retries of a failed delivery.
+
+	mu      sync.Mutex // Protects pending and sent.
+	pending []reporter.Finding
+	sent    map[string]bool
+	wake    chan struct{}
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewNotifier creates a Notifier posting to the webhooks of opts and starts its delivery loop.
+// It returns nil when no webhook URL is set.
+func NewNotifier(opts Options, log *logger.Logger) (*Notifier, error) {
+	if opts.MinSeverity == "" {
+		opts.MinSeverity = DefaultMinSeverity
+	}
+	minSeverity, err := reporter.ParseSeverity(opts.MinSeverity)
+	if err != nil {
+		return nil, err
+	}
+	opts.MinSeverity = minSeverity
+	if opts.DigestInterval <= 0 {
+		opts.DigestInterval = DefaultDigestInterval
+	}
+
+	n := &Notifier{
+		opts:        opts,
+		client:      &http.Client{Timeout: 15 * time.Second},
+		log:         log,
+		retryDelays: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second},
+		sent:        make(map[string]bool),
+		wake:        make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	if opts.WebhookURL != "" {
+		n.endpoints = append(n.endpoints, endpoint{name: "webhook", url: opts.WebhookURL, encode: encodeWebhook})
+	}
+	if opts.SlackWebhookURL != "" {
+		n.endpoints = append(n.endpoints, endpoint{name: "Slack", url: opts.SlackWebhookURL, encode: encodeSlack})
+	}
+	if len(n.endpoints) == 0 {
+		return nil, nil
+	}
+	go n.run()
+	return n, nil
+}
+
+// URLFromEnv returns the value of the environment variable name, or of fallback when name is
+// empty.
+func URLFromEnv(name, fallback string) string {
+	if name == "" {
+		name = fallback
+	}
+	return strings.TrimSpace(os.Getenv(name))
+}
+
+// Endpoints returns the names of the webhooks notified ("webhook", "Slack").
+func (n *Notifier) Endpoints() []string {
+	if n == nil {
+		return nil
+	}
+	names := make([]string, 0, len(n.endpoints))
+	for _, e := range n.endpoints {
+		names = append(names, e.name)
+	}
+	return names
+}
+
+// Emit queues the findings at or above the severity threshold that were not notified yet.
+func (n *Notifier) Emit(findings []scanner.VulnerabilityResult) {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	queued := false
+	for _, v := range findings {
+		if v.SuppressedBy != "" || !reporter.SeverityAtLeast(v.Severity, n.opts.MinSeverity) {
+			continue
+		}
+		f := reporter.NewFinding(v, reporter.FindingOptions{MaxEvidenceBytes: evidenceSnippetBytes, ExcludeRaw: true})
+		if n.sent[f.Fingerprint] {
+			continue
+		}
+		n.sent[f.Fingerprint] = true
+		n.pending = append(n.pending, f)
+		queued = true
+	}
+	n.mu.Unlock()
+	if queued && !n.opts.Digest {
+		select {
+		case n.wake <- struct{}{}:
+		default: // A delivery is already due.
+		}
+	}
+}
+
+// Close delivers the findings still queued and stops the delivery loop.
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+	close(n.stop)
+	<-n.done
+}
+
+// run delivers the queued findings as they arrive, or every DigestInterval in digest mode,
+// until Close.
+func (n *Notifier) run() {
+	defer close(n.done)
+	var tick <-chan time.Time
+	if n.opts.Digest {
+		ticker := time.NewTicker(n.opts.DigestInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		select {
+		case <-n.wake:
+		case <-tick:
+		case <-n.stop:
+			n.flush()
+			return
+		}
+		n.flush()
+	}
+}
+
+// flush delivers the queued findings: one message each, or one digest of all of them.
+func (n *Notifier) flush() {
+	n.mu.Lock()
+	findings := n.pending
+	n.pending = nil
+	n.mu.Unlock()
+	if len(findings) == 0 {
+		return
+	}
+	if n.opts.Digest {
+		n.deliver(findings)
+		return
+	}
+	for _, f := range findings {
+		n.deliver([]reporter.Finding{f})
+	}
+}
+
+// deliver posts findings to every endpoint.
+func (n *Notifier) deliver(findings []reporter.Finding) {
+	for _, e := range n.endpoints {
+		body, err := e.encode(n.opts.Target, findings)
+		if err != nil {
+			n.log.Warn("Failed to encode %s notification: %v", e.name, err)
+			continue
+		}
+		if err := n.post(e.url, body); err != nil {
+			n.log.Warn("Failed to send %d finding(s) to %s: %v", len(findings), e.name, err)
+		}
+	}
+}
+
+// post sends body to webhookURL, retrying with backoff on network errors and 5xx responses.
+func (n *Notifier) post(webhookURL string, body []byte) error {
+	var lastErr error
+	for attempt := 0; ; attempt++ {
+		resp, err := n.client.Post(webhookURL, "application/json", bytes.NewReader(body))
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode < 300 {
+				return nil
+			}
+			err = fmt.Errorf("HTTP %d", resp.StatusCode)
+			if resp.StatusCode < 500 {
+				return err // Client errors (bad URL, revoked webhook) do not go away on retry.
+			}
+		} else {
+			err = redactURL(err)
+		}
+		lastErr = err
+		if attempt >= len(n.retryDelays) {
+			return fmt.Errorf("%v (after %d attempts)", lastErr, attempt+1)
+		}
+		time.Sleep(n.retryDelays[attempt])
+	}
+}
+
+// redactURL strips the URL, which holds the webhook secret, from client errors.
+func redactURL(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+// webhookPayload is the JSON body posted to generic webhooks.
+type webhookPayload struct {
+	Event    string             `json:"event"` // Always "findings".
+	Tool     string             `json:"tool"`  // Always "dursgo".
+	Target   string             `json:"target"`
+	SentAt   time.Time          `json:"sent_at"`
+	Findings []reporter.Finding `json:"findings"` // In the findings file schema, without raw dumps.
+}
+
+// encodeWebhook encodes findings for a generic webhook.
+func encodeWebhook(target string, findings []reporter.Finding) ([]byte, error) {
+	return json.Marshal(webhookPayload{Event: "findings", Tool: "dursgo", Target: target, SentAt: time.Now().UTC(), Findings: findings})
+}
+
+// encodeSlack encodes findings as a Slack incoming webhook message.
+func encodeSlack(target string, findings []reporter.Finding) ([]byte, error) {
+	var text strings.Builder
+	if len(findings) == 1 {
+		fmt.Fprintf(&text, "*Dursgo found a vulnerability on %s*\n", slackEscape(target))
+	} else {
+		fmt.Fprintf(&text, "*Dursgo found %d vulnerabilities on %s*\n", len(findings), slackEscape(target))
+	}
+	for _, f := range findings {
+		fmt.Fprintf(&text, "\n*[%s] %s*\nURL: `%s`\n", reporter.NormalizeSeverity(f.Severity), slackEscape(f.Type), slackEscape(f.URL))
+		if f.Parameter != "" {
+			fmt.Fprintf(&text, "Parameter: `%s`\n", slackEscape(f.Parameter))
+		}
+		if f.Evidence != "" {
+			fmt.Fprintf(&text, "Evidence: ```%s```\n", slackEscape(strings.ReplaceAll(f.Evidence, "```", "'''")))
+		}
+	}
+	return json.Marshal(map[string]string{"text": text.String()})
+}
+
+// slackEscape escapes the characters Slack interprets as markup in message text.
+func slackEscape(s string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(s)
+}
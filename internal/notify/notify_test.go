@@ -0,0 +1,132 @@
+package notify
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a webhook that fails the first failures requests with status and records the
+// bodies of the others.
+type recorder struct {
+	mu       sync.Mutex
+	failures int
+	status   int
+	attempts int
+	bodies   [][]byte
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.attempts++
+	if r.failures > 0 {
+		r.failures--
+		w.WriteHeader(r.status)
+		return
+	}
+	r.bodies = append(r.bodies, body)
+}
+
+func (r *recorder) received() ([][]byte, int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.bodies, r.attempts
+}
+
+func findings() []scanner.VulnerabilityResult {
+	return []scanner.VulnerabilityResult{
+		{VulnerabilityType: "SQL Injection", URL: "https://example.com/item/1?id=1", Parameter: "id", Severity: "High", Evidence: "You have an error in your SQL syntax <near>"},
+		{VulnerabilityType: "SQL Injection", URL: "https://example.com/item/2?id=1", Parameter: "id", Severity: "High"}, // Same fingerprint.
+		{VulnerabilityType: "Missing Security Header", URL: "https://example.com/", Parameter: "CSP", Severity: "Low"},
+		{VulnerabilityType: "Command Injection", URL: "https://example.com/ping?host=x", Parameter: "host", Severity: "Critical"},
+	}
+}
+
+func TestNotifierPostsFindingsAndRetries(t *testing.T) {
+	webhook := &recorder{failures: 2, status: http.StatusServiceUnavailable}
+	slack := &recorder{}
+	webhookServer, slackServer := httptest.NewServer(webhook), httptest.NewServer(slack)
+	defer webhookServer.Close()
+	defer slackServer.Close()
+
+	n, err := NewNotifier(Options{WebhookURL: webhookServer.URL, SlackWebhookURL: slackServer.URL, Target: "https://example.com"}, logger.NewLogger(logger.ERROR))
+	require.NoError(t, err)
+	n.retryDelays = []time.Duration{time.Millisecond, time.Millisecond, time.Millisecond}
+	assert.Equal(t, []string{"webhook", "Slack"}, n.Endpoints())
+
+	n.Emit(findings())
+	n.Close()
+
+	bodies, attempts := webhook.received()
+	require.Len(t, bodies, 2, "one message per finding at or above High, duplicates skipped")
+	assert.Equal(t, 4, attempts, "5xx responses are retried")
+	var payload webhookPayload
+	require.NoError(t, json.Unmarshal(bodies[0], &payload))
+	assert.Equal(t, "https://example.com", payload.Target)
+	require.Len(t, payload.Findings, 1)
+	assert.Equal(t, "SQL Injection", payload.Findings[0].Type)
+	assert.Empty(t, payload.Findings[0].RawRequest)
+
+	slackBodies, _ := slack.received()
+	require.Len(t, slackBodies, 2)
+	var message map[string]string
+	require.NoError(t, json.Unmarshal(slackBodies[0], &message))
+	assert.Contains(t, message["text"], "*[High] SQL Injection*")
+	assert.Contains(t, message["text"], "Parameter: `id`")
+	assert.Contains(t, message["text"], "syntax &lt;near&gt;")
+}
+
+func TestNotifierDigestAndClientErrors(t *testing.T) {
+	webhook := &recorder{failures: 1, status: http.StatusNotFound}
+	server := httptest.NewServer(webhook)
+	defer server.Close()
+
+	n, err := NewNotifier(Options{WebhookURL: server.URL, MinSeverity: "low", Digest: true, DigestInterval: time.Hour}, logger.NewLogger(logger.ERROR))
+	require.NoError(t, err)
+	n.Emit(findings())
+	n.Close()
+	bodies, attempts := webhook.received()
+	assert.Empty(t, bodies, "4xx responses are not retried")
+	assert.Equal(t, 1, attempts)
+
+	webhook = &recorder{}
+	server = httptest.NewServer(webhook)
+	defer server.Close()
+	n, err = NewNotifier(Options{WebhookURL: server.URL, MinSeverity: "low", Digest: true, DigestInterval: time.Hour}, logger.NewLogger(logger.ERROR))
+	require.NoError(t, err)
+	n.Emit(findings())
+	n.Close()
+	bodies, _ = webhook.received()
+	require.Len(t, bodies, 1, "pending findings are sent as one digest on Close")
+	var payload webhookPayload
+	require.NoError(t, json.Unmarshal(bodies[0], &payload))
+	assert.Len(t, payload.Findings, 3)
+}
+
+func TestNewNotifier(t *testing.T) {
+	n, err := NewNotifier(Options{}, logger.NewLogger(logger.ERROR))
+	require.NoError(t, err)
+	assert.Nil(t, n, "no webhook configured")
+	n.Emit(findings())
+	n.Close()
+
+	_, err = NewNotifier(Options{WebhookURL: "http://127.0.0.1:1", MinSeverity: "urgent"}, logger.NewLogger(logger.ERROR))
+	assert.Error(t, err)
+
+	t.Setenv("CUSTOM_HOOK", " https://hooks.example.com/x ")
+	assert.Equal(t, "https://hooks.example.com/x", URLFromEnv("CUSTOM_HOOK", DefaultWebhookURLEnv))
+	t.Setenv(DefaultWebhookURLEnv, "https://hooks.example.com/default")
+	assert.Equal(t, "https://hooks.example.com/default", URLFromEnv("", DefaultWebhookURLEnv))
+}
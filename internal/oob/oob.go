@@ -0,0 +1,196 @@
+// Package oob provides the out-of-band (OOB) collaborator used to confirm blind vulnerabilities.
+// A collaborator is either a public Interactsh-compatible server or a local HTTP listener;
+// scanners embed unique hosts derived from its URL in their payloads, and any DNS lookup or
+// HTTP request the target makes to those hosts is recorded as an Interaction.
+package oob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/interactsh/pkg/client"
+	"github.com/projectdiscovery/interactsh/pkg/server"
+)
+
+// maxCorrelationLabel keeps generated correlation IDs well below the 63-byte DNS label limit.
+const maxCorrelationLabel = 48
+
+// Interaction is a single out-of-band callback received by the collaborator.
+type Interaction struct {
+	Protocol      string    // "dns", "http", "smtp", ...
+	FullID        string    // Host name (or host and path) that carried the correlation ID.
+	RemoteAddress string    // Source IP of the callback.
+	Timestamp     time.Time // When the collaborator received the callback.
+}
+
+// Collaborator hands out the callback URL for a scan and records the interactions it receives.
+type Collaborator struct {
+	baseURL      string
+	mu           sync.Mutex
+	interactions []Interaction
+	stop         func()
+}
+
+// NewInteractshCollaborator registers with the default public Interactsh server and polls it
+// for interactions every pollInterval for the lifetime of the collaborator.
+func NewInteractshCollaborator(pollInterval time.Duration) (*Collaborator, error) {
+	interactshClient, err := client.New(client.DefaultOptions)
+	if err != nil {
+		return nil, err
+	}
+	c := &Collaborator{baseURL: "http://" + interactshClient.URL()}
+	interactshClient.StartPolling(pollInterval, func(interaction *server.Interaction) {
+		c.record(Interaction{
+			Protocol:      interaction.Protocol,
+			FullID:        interaction.FullId,
+			RemoteAddress: interaction.RemoteAddress,
+			Timestamp:     interaction.Timestamp,
+		})
+	})
+	c.stop = func() {
+		interactshClient.StopPolling()
+		interactshClient.Close()
+	}
+	return c, nil
+}
+
+// NewLocalCollaborator starts an HTTP listener on listenAddr (e.g., ":8880"). publicURL is the
+// address targets use to reach the listener (e.g., "http://oob.example.com:8880"); DNS-based
+// payloads only work when it is a host name with a wildcard record pointing at the listener.
+// Requests are correlated by their Host header and path, so plain IP addresses still catch
+// HTTP callbacks.
+func NewLocalCollaborator(listenAddr, publicURL string) (*Collaborator, error) {
+	if publicURL == "" {
+		return nil, errors.New("a public URL for the local OOB listener is required")
+	}
+	if !strings.Contains(publicURL, "://") {
+		publicURL = "http://" + publicURL
+	}
+	if _, err := url.Parse(publicURL); err != nil {
+		return nil, fmt.Errorf("invalid OOB public URL: %w", err)
+	}
+
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, err
+	}
+	c := &Collaborator{baseURL: strings.TrimSuffix(publicURL, "/")}
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			remote, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				remote = r.RemoteAddr
+			}
+			c.record(Interaction{
+				Protocol:      "http",
+				FullID:        strings.ToLower(r.Host + r.URL.Path),
+				RemoteAddress: remote,
+				Timestamp:     time.Now(),
+			})
+			w.WriteHeader(http.StatusOK)
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go srv.Serve(listener)
+	c.stop = func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}
+	return c, nil
+}
+
+// URL returns the collaborator's base URL, e.g. "http://abc123.oast.fun".
+func (c *Collaborator) URL() string {
+	return c.baseURL
+}
+
+// Domain returns the collaborator's host name, used by scanners that build DNS payloads.
+func (c *Collaborator) Domain() string {
+	return collaboratorHost(c.baseURL)
+}
+
+// Interactions returns a snapshot of the interactions received so far.
+func (c *Collaborator) Interactions() []Interaction {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]Interaction(nil), c.interactions...)
+}
+
+// Find returns the first interaction whose identifier contains correlationID.
+func (c *Collaborator) Find(correlationID string) (Interaction, bool) {
+	for _, interaction := range c.Interactions() {
+		if strings.Contains(interaction.FullID, correlationID) {
+			return interaction, true
+		}
+	}
+	return Interaction{}, false
+}
+
+// Close stops polling or shuts down the local listener.
+func (c *Collaborator) Close() {
+	if c.stop != nil {
+		c.stop()
+		c.stop = nil
+	}
+}
+
+func (c *Collaborator) record(interaction Interaction) {
+	c.mu.Lock()
+	c.interactions = append(c.interactions, interaction)
+	c.mu.Unlock()
+}
+
+// NewCorrelationID returns a unique, DNS-safe correlation ID for a scanner and parameter,
+// e.g. "sqli-user-id-482913".
+func NewCorrelationID(prefix, paramName string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(prefix + "-" + paramName) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	label := strings.Trim(b.String(), "-")
+	if len(label) > maxCorrelationLabel {
+		label = strings.Trim(label[:maxCorrelationLabel], "-")
+	}
+	return fmt.Sprintf("%s-%d", label, rand.Intn(1e9))
+}
+
+// PayloadHost returns the unique host name for correlationID under collaboratorURL.
+func PayloadHost(collaboratorURL, correlationID string) string {
+	return correlationID + "." + collaboratorHost(collaboratorURL)
+}
+
+// PayloadURL returns a unique HTTP URL for correlationID under collaboratorURL. The ID is
+// repeated in the path so HTTP callbacks correlate even when the host is an IP address.
+func PayloadURL(collaboratorURL, correlationID string) string {
+	u, err := url.Parse(collaboratorURL)
+	if err != nil || u.Host == "" {
+		return "http://" + PayloadHost(collaboratorURL, correlationID) + "/" + correlationID
+	}
+	host := u.Host
+	if net.ParseIP(u.Hostname()) == nil {
+		host = correlationID + "." + u.Host
+	}
+	return fmt.Sprintf("%s://%s/%s", u.Scheme, host, correlationID)
+}
+
+// collaboratorHost returns the host name (without scheme or port) of collaboratorURL.
+func collaboratorHost(collaboratorURL string) string {
+	if u, err := url.Parse(collaboratorURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return strings.TrimSuffix(collaboratorURL, "/")
+}
@@ -0,0 +1,83 @@
+package payloads
+
+import "regexp"
+
+// BackupSuffixes are appended to the name of a discovered file to guess the copies editors,
+// deployment tools and administrators leave next to it (login.php -> login.php~, login.php.bak).
+var BackupSuffixes = []string{"~", ".bak", ".old", ".orig", ".save", ".swp", ".tmp", ".copy", ".1"}
+
+// BackupExtensions replace the extension of a discovered file (login.php -> login.bak).
+var BackupExtensions = []string{".bak", ".old", ".txt"}
+
+// SwapFileFormats are the names editors give the swap file of name; %s is the file name.
+var SwapFileFormats = []string{".%s.swp", ".%s.swo", "#%s#"}
+
+// DirArchiveExtensions are appended to the name of a discovered directory (and of the host for
+// the root directory) to guess archives of it (/app/ -> /app.zip).
+var DirArchiveExtensions = []string{".zip", ".tar.gz", ".tgz", ".tar", ".rar", ".7z", ".bak"}
+
+// BackupGenericFiles are probed in every discovered directory, regardless of the files found there.
+var BackupGenericFiles = []string{
+	"web.config.bak", "web.config.old", "web.config~",
+	".env.bak", ".env.old", ".env.save",
+	"config.php.bak", "config.php~", "wp-config.php.bak", "wp-config.php~", "wp-config.php.save",
+	"settings.py.bak", "appsettings.json.bak", "database.yml.bak",
+	"backup.zip", "backup.tar.gz", "site.zip", "www.zip", "htdocs.zip", "backup.sql", "dump.sql.gz",
+}
+
+// ArchiveSignature is the magic number a backup archive or editor file starts with.
+type ArchiveSignature struct {
+	Kind  string // Kind of file reported, e.g. "ZIP archive".
+	Magic string // Leading bytes of the file.
+}
+
+// ArchiveSignatures recognize binary backup artifacts by their first bytes.
+var ArchiveSignatures = []ArchiveSignature{
+	{Kind: "ZIP archive", Magic: "PK\x03\x04"},
+	{Kind: "gzip archive", Magic: "\x1f\x8b"},
+	{Kind: "RAR archive", Magic: "Rar!\x1a\x07"},
+	{Kind: "7-Zip archive", Magic: "7z\xbc\xaf\x27\x1c"},
+	{Kind: "Vim swap file", Magic: "b0VIM "},
+}
+
+// SourceSignature recognizes server-side source code or configuration in a backup artifact.
+type SourceSignature struct {
+	Kind  string         // Kind of content reported, e.g. "PHP source".
+	Regex *regexp.Regexp // Matches content a server would execute or keep private.
+	// Raw is set for content no rendered page contains, so it counts in HTML responses too.
+	Raw bool
+}
+
+// SourceSignatures recognize text backup artifacts worth reporting. Served by the original file's
+// handler, this content would have been executed; served as a file, it reveals the application.
+var SourceSignatures = []SourceSignature{
+	{Kind: "PHP source", Regex: regexp.MustCompile(`<\?php`), Raw: true},
+	{Kind: "ASP.NET/JSP source", Regex: regexp.MustCompile(`<%(@\s*(Page|page|Control|taglib|include))?[\s=]`), Raw: true},
+	{Kind: "connection string", Regex: regexp.MustCompile(`(?i)(connectionString\s*=|Data Source=[^;"]+;|(mysql|postgres(ql)?|mongodb(\+srv)?|redis)://[^\s"']+@)`)},
+	{Kind: "database credentials", Regex: regexp.MustCompile(`(?i)\b(DB_(PASS(WORD)?|USER(NAME)?|HOST)|DATABASE_URL)\b\s*['"]?\s*[=,:]`)},
+	{Kind: "ASP.NET configuration", Regex: regexp.MustCompile(`<(configuration|appSettings|connectionStrings)>`)},
+	{Kind: "Python source", Regex: regexp.MustCompile(`(?m)^(from [\w.]+ import |import [\w.]+$|def \w+\(.*\):$)`)},
+	{Kind: "SQL dump", Regex: regexp.MustCompile(`(?i)(CREATE TABLE|INSERT INTO) [\x60"']?\w+`)},
+	{Kind: "credentials", Regex: regexp.MustCompile(`(?im)^\s*['"]?\w*(password|passwd|secret)\w*['"]?\s*[=:]\s*\S+`)},
+}
+
+// DirListingPattern recognizes a directory listing page generated by a web server.
+type DirListingPattern struct {
+	Server string         // Server or module generating the listing.
+	Regex  *regexp.Regexp // Matches the listing markup.
+}
+
+// DirListingPatterns recognize the directory listings of common web servers, most specific first.
+// They match markup specific to generated listings, not the words ("Index of", "Size") pages
+// merely contain.
+var DirListingPatterns = []DirListingPattern{
+	{Server: "nginx", Regex: regexp.MustCompile(`(?is)<title>Index of /[^<]*</title>.*<h1>Index of /[^<]*</h1><hr><pre><a href="\.\./">`)},
+	{Server: "Apache", Regex: regexp.MustCompile(`(?is)<title>Index of /[^<]*</title>.*<h1>Index of /`)},
+	{Server: "IIS", Regex: regexp.MustCompile(`(?is)<title>[^<]*- /[^<]*</title>.*\[To Parent Directory\]`)},
+	{Server: "lighttpd", Regex: regexp.MustCompile(`(?is)<h2>Index of /[^<]*</h2>.*<div class="list">`)},
+	{Server: "Python http.server", Regex: regexp.MustCompile(`(?is)<title>Directory listing for /[^<]*</title>`)},
+	{Server: "Jetty/Tomcat", Regex: regexp.MustCompile(`(?is)<title>Directory: /[^<]*</title>`)},
+}
+
+// DirListingEntryRegex extracts the entries of a directory listing from its links.
+var DirListingEntryRegex = regexp.MustCompile(`(?i)<a href="([^"?/][^"?]*)"`)
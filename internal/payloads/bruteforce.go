@@ -0,0 +1,35 @@
+package payloads
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// LoginUserParams are the names (lowercase) of the user fields of login forms.
+var LoginUserParams = map[string]bool{"username": true, "user": true, "email": true, "login": true}
+
+// LoginPasswordParams are the names (lowercase) of the password fields of login forms.
+var LoginPasswordParams = map[string]bool{"password": true, "pass": true, "passwd": true, "pwd": true, "user_pass": true}
+
+// BruteForceCaptchaMarkers are lowercase strings of the pages that ask for a CAPTCHA.
+var BruteForceCaptchaMarkers = []string{
+	"captcha", "g-recaptcha", "h-captcha", "cf-turnstile", "are you a robot", "not a robot",
+}
+
+// BruteForceLockoutMarkers are lowercase strings of the pages refusing further login attempts.
+var BruteForceLockoutMarkers = []string{
+	"too many", "locked", "lockout", "temporarily blocked", "temporarily disabled", "try again later",
+	"try again in", "rate limit", "slow down", "exceeded the maximum", "suspended",
+}
+
+// GenerateBruteForceUsername returns the account name of a brute-force protection test. It is
+// shaped like an e-mail address on a reserved domain, so that forms asking for an e-mail accept
+// it and no real account can be locked out.
+func GenerateBruteForceUsername() string {
+	return fmt.Sprintf("dursgo-bf-%08d@example.invalid", rand.Intn(100000000))
+}
+
+// GenerateBruteForcePassword returns a wrong password for a brute-force protection test.
+func GenerateBruteForcePassword() string {
+	return fmt.Sprintf("DursgoWrong-%08d", rand.Intn(100000000))
+}
@@ -0,0 +1,53 @@
+package payloads
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// CacheBusterParam is the query parameter holding the cache buster of each cache poisoning
+// probe, so the probes only poison cache entries no real user requests.
+const CacheBusterParam = "dursgocb"
+
+// CachePoisoningProbe is an input that caches commonly leave out of the cache key.
+type CachePoisoningProbe struct {
+	// Header carrying the probe value; empty for a query parameter.
+	Header string
+	// Param is the query parameter carrying the probe value when Header is empty.
+	Param string
+	// Value returns the value sent for a unique marker.
+	Value func(marker string) string
+	// Redirect marks inputs whose effect is a redirect, not a reflection of the marker.
+	Redirect bool
+	// Description explains the technique for the finding details.
+	Description string
+}
+
+// Name returns the header or parameter of the probe.
+func (p CachePoisoningProbe) Name() string {
+	if p.Header != "" {
+		return p.Header
+	}
+	return p.Param
+}
+
+// CachePoisoningProbes contains the unkeyed inputs Dursgo will probe, in order.
+var CachePoisoningProbes = []CachePoisoningProbe{
+	{Header: "X-Forwarded-Host", Value: func(marker string) string { return marker + ".invalid" }, Description: "X-Forwarded-Host header set to a foreign host"},
+	{Header: "X-Forwarded-Scheme", Value: func(string) string { return "http" }, Redirect: true, Description: "X-Forwarded-Scheme header set to http"},
+	{Header: "X-Original-URL", Value: func(marker string) string { return "/" + marker }, Description: "X-Original-URL header set to another path"},
+	{Header: "X-Rewrite-URL", Value: func(marker string) string { return "/" + marker }, Description: "X-Rewrite-URL header set to another path"},
+	{Param: "utm_content", Value: func(marker string) string { return marker }, Description: "utm_content query parameter added"},
+	{Param: "fbclid", Value: func(marker string) string { return marker }, Description: "fbclid query parameter added"},
+}
+
+// GenerateCachePoisoningMarker returns a unique marker for one probe, so a cached response can
+// be traced back to the request that poisoned it.
+func GenerateCachePoisoningMarker() string {
+	return fmt.Sprintf("dursgocp%08d", rand.Intn(100000000))
+}
+
+// GenerateCacheBuster returns a unique value of CacheBusterParam.
+func GenerateCacheBuster() string {
+	return fmt.Sprintf("%010d", rand.Int63n(10000000000))
+}
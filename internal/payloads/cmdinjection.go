@@ -6,12 +6,12 @@ import "regexp"
 // CommandInjectionTest represents a single command injection test case.
 type CommandInjectionTest struct {
 	Type            string // "output-based", "time-based"
-	PayloadToInject string
+	PayloadToInject string // Time-based payloads use {SLEEP_TIME} and {SLEEP_TIME_PLUS_ONE}, filled in per delay
 	Separators      []string
 	DetectionRegex  *regexp.Regexp
 	Description     string
 	OS              string // "unix", "windows", or "any"
-	SleepSeconds    int    // Only for time-based tests
+	EchoesToken     bool   // Output-based only: the payload echoes {TOKEN_LEFT}{TOKEN_RIGHT}, detected instead of DetectionRegex
 }
 
 // OASTCommandInjectionTest contains the payload template for OAST-based tests.
@@ -33,7 +33,6 @@ func init() {
 			Separators:      []string{";", "&&", "|", "`", "\n"},
 			Description:     "Time delay using 'sleep'",
 			OS:              "unix",
-			SleepSeconds:    5,
 		},
 		{
 			Type:            "time-based",
@@ -41,10 +40,48 @@ func init() {
 			Separators:      []string{"&", "&&", "|"},
 			Description:     "Time delay using 'ping' (Windows)",
 			OS:              "windows",
-			SleepSeconds:    5,
+		},
+		{
+			Type:            "time-based",
+			PayloadToInject: "$(sleep {SLEEP_TIME})",
+			Separators:      []string{""},
+			Description:     "Time delay using 'sleep' in command substitution",
+			OS:              "unix",
+		},
+		{
+			Type:            "time-based",
+			PayloadToInject: "`sleep {SLEEP_TIME}`",
+			Separators:      []string{""},
+			Description:     "Time delay using 'sleep' in backtick substitution",
+			OS:              "unix",
 		},
 
 		// --- Output-Based Payloads ---
+		// Echo tests split the token with shell quoting, so only an executed echo prints it joined.
+		{
+			Type:            "output-based",
+			PayloadToInject: `echo {TOKEN_LEFT}""{TOKEN_RIGHT}`,
+			Separators:      []string{";", "&&", "|", "\n"},
+			Description:     "Echoes a unique token",
+			OS:              "unix",
+			EchoesToken:     true,
+		},
+		{
+			Type:            "output-based",
+			PayloadToInject: `$(echo {TOKEN_LEFT}""{TOKEN_RIGHT})`,
+			Separators:      []string{""},
+			Description:     "Echoes a unique token in command substitution",
+			OS:              "unix",
+			EchoesToken:     true,
+		},
+		{
+			Type:            "output-based",
+			PayloadToInject: "echo {TOKEN_LEFT}^{TOKEN_RIGHT}",
+			Separators:      []string{"&", "&&", "|"},
+			Description:     "Echoes a unique token (Windows)",
+			OS:              "windows",
+			EchoesToken:     true,
+		},
 		{
 			Type:            "output-based",
 			PayloadToInject: "cat /etc/passwd",
@@ -0,0 +1,174 @@
+package payloads
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// CMS platforms and the component types of each, as used in CMSVulnerability.
+var cmsComponentTypes = map[string][]string{
+	"wordpress": {"plugin", "theme"},
+	"drupal":    {"core", "module"},
+	"joomla":    {"core", "component"},
+}
+
+// cveRegex matches a CVE ID.
+var cveRegex = regexp.MustCompile(`^CVE-\d{4}-\d{4,}$`)
+
+// CMSVulnerability is a known vulnerability of a range of versions of a CMS component, checked
+// by the cms scanner against the versions it detects. Payload set files add entries (or replace
+// the dataset) under the cms_vulnerabilities category.
+type CMSVulnerability struct {
+	Platform string   `yaml:"platform"` // "wordpress", "drupal" or "joomla".
+	Type     string   `yaml:"type"`     // "plugin" or "theme" (WordPress), "module" (Drupal), "component" (Joomla) or "core".
+	Slug     string   `yaml:"slug"`     // Directory name of the component (e.g., "contact-form-7", "com_content"); empty for core.
+	Name     string   `yaml:"name"`     // Display name of the component.
+	CVEs     []string `yaml:"cves"`
+	Title    string   `yaml:"title"`    // Short description of the vulnerability.
+	Severity string   `yaml:"severity"` // "Critical", "High", "Medium" or "Low".
+	// Introduced is the first vulnerable version, empty when every earlier version is affected.
+	Introduced string `yaml:"introduced"`
+	// FixedIn is the first version with the fix, empty when there is none.
+	FixedIn string `yaml:"fixed_in"`
+}
+
+// Affects reports whether version lies in the vulnerable range.
+func (v CMSVulnerability) Affects(version string) bool {
+	if version == "" {
+		return false
+	}
+	if v.Introduced != "" && CompareVersions(version, v.Introduced) < 0 {
+		return false
+	}
+	return v.FixedIn == "" || CompareVersions(version, v.FixedIn) < 0
+}
+
+// CMSVulnerabilities is the bundled dataset of vulnerable CMS component versions. Branches
+// fixed separately have one entry each.
+var CMSVulnerabilities = []CMSVulnerability{
+	// --- WordPress plugins ---
+	{Platform: "wordpress", Type: "plugin", Slug: "contact-form-7", Name: "Contact Form 7", CVEs: []string{"CVE-2020-35489"}, Title: "Unrestricted file upload through special characters in file names", Severity: "Critical", FixedIn: "5.3.2"},
+	{Platform: "wordpress", Type: "plugin", Slug: "wp-file-manager", Name: "File Manager", CVEs: []string{"CVE-2020-25213"}, Title: "Unauthenticated remote code execution through the elFinder connector", Severity: "Critical", Introduced: "6.0", FixedIn: "6.9"},
+	{Platform: "wordpress", Type: "plugin", Slug: "duplicator", Name: "Duplicator", CVEs: []string{"CVE-2020-11738"}, Title: "Unauthenticated arbitrary file download", Severity: "High", Introduced: "1.3.24", FixedIn: "1.3.28"},
+	{Platform: "wordpress", Type: "plugin", Slug: "social-warfare", Name: "Social Warfare", CVEs: []string{"CVE-2019-9978"}, Title: "Unauthenticated remote code execution and stored XSS", Severity: "Critical", FixedIn: "3.5.3"},
+	{Platform: "wordpress", Type: "plugin", Slug: "wp-gdpr-compliance", Name: "WP GDPR Compliance", CVEs: []string{"CVE-2018-19207"}, Title: "Unauthenticated update of arbitrary WordPress options (privilege escalation)", Severity: "Critical", FixedIn: "1.4.3"},
+	{Platform: "wordpress", Type: "plugin", Slug: "ultimate-member", Name: "Ultimate Member", CVEs: []string{"CVE-2023-3460"}, Title: "Unauthenticated privilege escalation to administrator at registration", Severity: "Critical", FixedIn: "2.6.7"},
+	{Platform: "wordpress", Type: "plugin", Slug: "wp-fastest-cache", Name: "WP Fastest Cache", CVEs: []string{"CVE-2023-6063"}, Title: "Unauthenticated SQL injection", Severity: "High", FixedIn: "1.2.2"},
+	{Platform: "wordpress", Type: "plugin", Slug: "forminator", Name: "Forminator", CVEs: []string{"CVE-2023-4596"}, Title: "Unauthenticated arbitrary file upload", Severity: "Critical", FixedIn: "1.25.0"},
+	{Platform: "wordpress", Type: "plugin", Slug: "litespeed-cache", Name: "LiteSpeed Cache", CVEs: []string{"CVE-2024-28000"}, Title: "Unauthenticated privilege escalation through a weak role simulation hash", Severity: "Critical", Introduced: "1.9", FixedIn: "6.4"},
+	{Platform: "wordpress", Type: "plugin", Slug: "really-simple-ssl", Name: "Really Simple Security", CVEs: []string{"CVE-2024-10924"}, Title: "Authentication bypass in two-factor authentication", Severity: "Critical", Introduced: "9.0.0", FixedIn: "9.1.2"},
+	{Platform: "wordpress", Type: "plugin", Slug: "woocommerce-payments", Name: "WooCommerce Payments", CVEs: []string{"CVE-2023-28121"}, Title: "Unauthenticated impersonation of any user, including administrators", Severity: "Critical", Introduced: "4.8.0", FixedIn: "5.6.2"},
+	{Platform: "wordpress", Type: "plugin", Slug: "revslider", Name: "Slider Revolution", CVEs: []string{"CVE-2014-9734"}, Title: "Unauthenticated arbitrary file download (wp-config.php)", Severity: "High", FixedIn: "4.2"},
+	{Platform: "wordpress", Type: "plugin", Slug: "easy-wp-smtp", Name: "Easy WP SMTP", CVEs: []string{"CVE-2020-35234"}, Title: "Publicly readable debug log exposing password reset links", Severity: "High", FixedIn: "1.4.4"},
+
+	// --- WordPress themes ---
+	{Platform: "wordpress", Type: "theme", Slug: "twentyfifteen", Name: "Twenty Fifteen", CVEs: []string{"CVE-2015-3429"}, Title: "DOM-based XSS in the bundled Genericons example.html", Severity: "Medium", FixedIn: "1.2"},
+
+	// --- Drupal core ---
+	{Platform: "drupal", Type: "core", Name: "Drupal core", CVEs: []string{"CVE-2014-3704"}, Title: "Unauthenticated SQL injection in the database abstraction API (Drupalgeddon)", Severity: "Critical", Introduced: "7.0", FixedIn: "7.32"},
+	{Platform: "drupal", Type: "core", Name: "Drupal core", CVEs: []string{"CVE-2018-7600"}, Title: "Unauthenticated remote code execution through the Form API (Drupalgeddon2)", Severity: "Critical", Introduced: "7.0", FixedIn: "7.58"},
+	{Platform: "drupal", Type: "core", Name: "Drupal core", CVEs: []string{"CVE-2018-7600"}, Title: "Unauthenticated remote code execution through the Form API (Drupalgeddon2)", Severity: "Critical", Introduced: "8.0.0", FixedIn: "8.3.9"},
+	{Platform: "drupal", Type: "core", Name: "Drupal core", CVEs: []string{"CVE-2018-7600"}, Title: "Unauthenticated remote code execution through the Form API (Drupalgeddon2)", Severity: "Critical", Introduced: "8.4.0", FixedIn: "8.4.6"},
+	{Platform: "drupal", Type: "core", Name: "Drupal core", CVEs: []string{"CVE-2018-7600"}, Title: "Unauthenticated remote code execution through the Form API (Drupalgeddon2)", Severity: "Critical", Introduced: "8.5.0", FixedIn: "8.5.1"},
+	{Platform: "drupal", Type: "core", Name: "Drupal core", CVEs: []string{"CVE-2019-6340"}, Title: "Remote code execution through REST field deserialization", Severity: "High", Introduced: "8.5.0", FixedIn: "8.5.11"},
+	{Platform: "drupal", Type: "core", Name: "Drupal core", CVEs: []string{"CVE-2019-6340"}, Title: "Remote code execution through REST field deserialization", Severity: "High", Introduced: "8.6.0", FixedIn: "8.6.10"},
+
+	// --- Joomla core ---
+	{Platform: "joomla", Type: "core", Name: "Joomla core", CVEs: []string{"CVE-2015-8562"}, Title: "Remote code execution through PHP object injection in session data", Severity: "Critical", Introduced: "1.5.0", FixedIn: "3.4.6"},
+	{Platform: "joomla", Type: "core", Name: "Joomla core", CVEs: []string{"CVE-2017-8917"}, Title: "Unauthenticated SQL injection in com_fields", Severity: "Critical", Introduced: "3.7.0", FixedIn: "3.7.1"},
+	{Platform: "joomla", Type: "core", Name: "Joomla core", CVEs: []string{"CVE-2023-23752"}, Title: "Improper access check in the web services API exposing configuration", Severity: "Medium", Introduced: "4.0.0", FixedIn: "4.2.8"},
+}
+
+func checkCMSVulnerability(v CMSVulnerability) error {
+	types, ok := cmsComponentTypes[v.Platform]
+	switch {
+	case !ok:
+		return fmt.Errorf("unknown platform %q; use drupal, joomla or wordpress", v.Platform)
+	case !slices.Contains(types, v.Type):
+		return fmt.Errorf("unknown %s component type %q; use %s", v.Platform, v.Type, strings.Join(types, ", "))
+	case v.Type != "core" && v.Slug == "":
+		return errors.New("slug is required")
+	case len(v.CVEs) == 0:
+		return errors.New("cves is required")
+	case v.Introduced == "" && v.FixedIn == "":
+		return errors.New("introduced or fixed_in is required")
+	}
+	for _, cve := range v.CVEs {
+		if !cveRegex.MatchString(cve) {
+			return fmt.Errorf("invalid CVE ID %q", cve)
+		}
+	}
+	for _, version := range []string{v.Introduced, v.FixedIn} {
+		if version != "" && !versionRegex.MatchString(version) {
+			return fmt.Errorf("invalid version %q", version)
+		}
+	}
+	return nil
+}
+
+// versionRegex matches the versions CompareVersions understands.
+var versionRegex = regexp.MustCompile(`^\d+(\.\d+)*([-.+]?[0-9A-Za-z.-]*)?$`)
+
+// CompareVersions compares two dotted version numbers ("5.3.2", "4.2", "8.x-1.10") and returns
+// -1, 0 or 1. Drupal's core prefix ("8.x-") is ignored, missing components count as zero and a
+// pre-release suffix ("6.0-beta1", "3.7.0-rc") sorts before the release.
+func CompareVersions(a, b string) int {
+	an, as := splitVersion(a)
+	bn, bs := splitVersion(b)
+	for i := 0; i < len(an) || i < len(bn); i++ {
+		var x, y int
+		if i < len(an) {
+			x = an[i]
+		}
+		if i < len(bn) {
+			y = bn[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case as == bs:
+		return 0
+	case as == "":
+		return 1
+	case bs == "":
+		return -1
+	case as < bs:
+		return -1
+	}
+	return 1
+}
+
+// drupalCoreRegex matches the core compatibility prefix of Drupal module versions.
+var drupalCoreRegex = regexp.MustCompile(`^\d+\.x-`)
+
+// splitVersion returns the numeric components of a version and its pre-release suffix.
+func splitVersion(version string) ([]int, string) {
+	version = drupalCoreRegex.ReplaceAllString(strings.TrimPrefix(strings.TrimSpace(version), "v"), "")
+	var numbers []int
+	for version != "" {
+		end := 0
+		for end < len(version) && version[end] >= '0' && version[end] <= '9' {
+			end++
+		}
+		if end == 0 {
+			break
+		}
+		n, _ := strconv.Atoi(version[:end])
+		numbers = append(numbers, n)
+		version = version[end:]
+		if !strings.HasPrefix(version, ".") || len(version) < 2 || version[1] < '0' || version[1] > '9' {
+			break
+		}
+		version = version[1:]
+	}
+	return numbers, strings.ToLower(strings.TrimLeft(version, ".-+"))
+}
@@ -0,0 +1,60 @@
+package payloads
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"5.3.2", "5.3.2", 0},
+		{"5.3", "5.3.0", 0},
+		{"5.3.1", "5.3.2", -1},
+		{"5.10", "5.9", 1},
+		{"v2.6.7", "2.6.6", 1},
+		{"8.x-1.10", "1.9", 1},
+		{"6.0-beta1", "6.0", -1},
+		{"3.7.0-rc", "3.7.0-beta", 1},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, CompareVersions(tt.a, tt.b), "%s vs %s", tt.a, tt.b)
+	}
+}
+
+func TestCMSVulnerabilityAffects(t *testing.T) {
+	v := CMSVulnerability{Introduced: "6.0", FixedIn: "6.9"}
+	assert.False(t, v.Affects("5.9"))
+	assert.True(t, v.Affects("6.0"))
+	assert.True(t, v.Affects("6.8.1"))
+	assert.False(t, v.Affects("6.9"))
+	assert.False(t, v.Affects(""))
+	assert.True(t, CMSVulnerability{FixedIn: "1.2"}.Affects("1.1"))
+}
+
+func TestLoadPayloadSetCMSVulnerabilities(t *testing.T) {
+	restorePayloads(t)
+	path := writeFile(t, "cms.yaml", `
+cms_vulnerabilities:
+  payloads:
+    - platform: wordpress
+      type: plugin
+      slug: example-forms
+      name: Example Forms
+      cves: [CVE-2024-12345]
+      title: Unauthenticated file upload
+      severity: High
+      fixed_in: "2.4.1"
+`)
+
+	_, err := LoadPayloadSet(path)
+	require.NoError(t, err)
+	vulns := GetCMSVulnerabilities()
+	require.Len(t, vulns, len(CMSVulnerabilities))
+	assert.Equal(t, "example-forms", vulns[len(vulns)-1].Slug)
+	assert.True(t, vulns[len(vulns)-1].Affects("2.4.0"))
+}
@@ -0,0 +1,53 @@
+package payloads
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// ContentDiscoveryPaths is the wordlist probed under every discovered directory.
+// Entries ending in "/" are probed as directories.
+var ContentDiscoveryPaths = []string{
+	// Administration and application areas
+	"admin", "admin/", "administrator/", "admin.php", "login", "dashboard", "console", "manage", "panel",
+	"user", "users", "account", "register", "search", "upload", "uploads/", "download", "export", "import",
+	"debug", "test", "dev", "old", "staging", "beta", "internal", "private/",
+
+	// APIs and their documentation
+	"api", "api/", "api/v1", "api/v2", "api/swagger.json", "api/openapi.json", "api-docs", "swagger.json",
+	"swagger-ui.html", "openapi.json", "v1", "v2", "graphql", "rest", "status", "health", "metrics",
+
+	// Backups and archives
+	"backup", "backup/", "backup.zip", "backup.tar.gz", "backup.sql", "site.zip", "www.zip", "db.zip",
+	"dump.sql", "database.sql",
+
+	// Source control, environment and server files
+	".git/config", ".git/HEAD", ".svn/entries", ".hg/", ".env", ".DS_Store", ".htaccess",
+	"web.config", "server-status", "phpinfo.php", "crossdomain.xml", "robots.txt", "sitemap.xml",
+}
+
+// ContentDiscoveryBaseNames are file names fuzzed with the extensions of the detected technologies.
+var ContentDiscoveryBaseNames = []string{
+	"index", "default", "admin", "login", "config", "test", "upload", "search", "info", "api",
+}
+
+// ContentDiscoveryExtensions maps a technology keyword (matched against the fingerprint) to the
+// file extensions worth fuzzing for it.
+var ContentDiscoveryExtensions = map[string][]string{
+	"php":        {".php", ".php.bak", ".phps"},
+	"wordpress":  {".php"},
+	"laravel":    {".php"},
+	"asp.net":    {".aspx", ".ashx", ".asmx"},
+	"iis":        {".asp", ".aspx"},
+	"java":       {".jsp", ".do", ".action"},
+	"tomcat":     {".jsp", ".do"},
+	"jsp":        {".jsp"},
+	"coldfusion": {".cfm"},
+	"perl":       {".pl", ".cgi"},
+}
+
+// GenerateContentDiscoveryCanary returns a random path segment that should not exist on any
+// target; its response is the baseline used to recognize soft-404 pages.
+func GenerateContentDiscoveryCanary() string {
+	return fmt.Sprintf("dursgo-%08d", rand.Intn(100000000))
+}
@@ -0,0 +1,37 @@
+package payloads
+
+import "strings"
+
+// SessionCookieNames are well-known session cookie names of common frameworks.
+var SessionCookieNames = []string{
+	"PHPSESSID", "JSESSIONID", "ASP.NET_SessionId", "ASPSESSIONID", "connect.sid", "session",
+	"sessionid", "sid", "laravel_session", "_session_id", "CFID", "CFTOKEN",
+}
+
+// SessionCookieMarkers are name fragments of cookies that likely carry a session or credentials.
+var SessionCookieMarkers = []string{"sess", "sid", "auth", "jwt", "login", "remember", "token"}
+
+// AuthCookieMaxLifetimeDays is the longest lifetime, in days, considered reasonable for a
+// session or authentication cookie.
+const AuthCookieMaxLifetimeDays = 30
+
+// IsSessionCookieName reports whether a cookie name looks like it carries a session: a
+// well-known session cookie name (case-insensitive) or a name containing a session marker.
+// CSRF token cookies (e.g., "csrftoken", "XSRF-TOKEN") are not sessions.
+func IsSessionCookieName(name string) bool {
+	for _, known := range SessionCookieNames {
+		if strings.EqualFold(name, known) {
+			return true
+		}
+	}
+	lower := strings.ToLower(name)
+	if strings.Contains(lower, "csrf") || strings.Contains(lower, "xsrf") {
+		return false
+	}
+	for _, marker := range SessionCookieMarkers {
+		if strings.Contains(lower, marker) {
+			return true
+		}
+	}
+	return false
+}
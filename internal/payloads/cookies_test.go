@@ -0,0 +1,16 @@
+package payloads
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSessionCookieName(t *testing.T) {
+	for _, name := range []string{"PHPSESSID", "jsessionid", "connect.sid", "session", "auth_token", "remember_me"} {
+		assert.True(t, IsSessionCookieName(name), name)
+	}
+	for _, name := range []string{"theme", "csrftoken", "XSRF-TOKEN", "_ga"} {
+		assert.False(t, IsSessionCookieName(name), name)
+	}
+}
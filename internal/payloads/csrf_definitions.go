@@ -1,5 +1,7 @@
 package payloads
 
+import "strings"
+
 // CommonCSRFTokenNames contains a list of common names for CSRF tokens in forms and headers.
 var CommonCSRFTokenNames = []string{
 	// Standard & Framework-specific
@@ -36,6 +38,17 @@ var CommonCSRFTokenNames = []string{
 	"X-CSRF-Header",
 }
 
+// IsCSRFTokenName reports whether name exactly matches (case-insensitively) one of
+// CommonCSRFTokenNames. Scanners use it to leave anti-CSRF tokens untouched.
+func IsCSRFTokenName(name string) bool {
+	for _, tokenName := range CommonCSRFTokenNames {
+		if strings.EqualFold(name, tokenName) {
+			return true
+		}
+	}
+	return false
+}
+
 // CSRFTokenValidationFailedKeywords contains common substrings found in responses
 // when a CSRF token validation fails.
 var CSRFTokenValidationFailedKeywords = []string{
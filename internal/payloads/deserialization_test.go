@@ -0,0 +1,230 @@
+package payloads
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// javaStreamReader walks a Java serialization stream the way ObjectInputStream reads it,
+// checking its structure: every reference points to an earlier handle and every object
+// carries the values its class descriptor declares.
+type javaStreamReader struct {
+	data    *bytes.Reader
+	handles []interface{} // *javaClass for descriptors, the class name for other objects.
+	classes []string      // Names of the classes read, in order.
+}
+
+func (r *javaStreamReader) u8() byte {
+	b, err := r.data.ReadByte()
+	if err != nil {
+		panic("unexpected end of stream")
+	}
+	return b
+}
+
+func (r *javaStreamReader) read(v interface{}) {
+	if err := binary.Read(r.data, binary.BigEndian, v); err != nil {
+		panic("unexpected end of stream")
+	}
+}
+
+func (r *javaStreamReader) utf() string {
+	var n uint16
+	r.read(&n)
+	b := make([]byte, n)
+	r.read(b)
+	return string(b)
+}
+
+func (r *javaStreamReader) reference() interface{} {
+	var h uint32
+	r.read(&h)
+	i := int(h) - javaBaseWireHandle
+	if i < 0 || i >= len(r.handles) {
+		panic(fmt.Sprintf("reference to unknown handle %#x", h))
+	}
+	return r.handles[i]
+}
+
+// classDesc reads a class descriptor, new or referenced, or null.
+func (r *javaStreamReader) classDesc() *javaClass {
+	switch tc := r.u8(); tc {
+	case tcNull:
+		return nil
+	case tcReference:
+		c, ok := r.reference().(*javaClass)
+		if !ok {
+			panic("reference to a class descriptor points to another object")
+		}
+		return c
+	case tcClassDesc:
+		c := &javaClass{}
+		r.handles = append(r.handles, c)
+		c.Name = r.utf()
+		r.classes = append(r.classes, c.Name)
+		r.read(&c.SUID)
+		c.Flags = r.u8()
+		var count uint16
+		r.read(&count)
+		for i := 0; i < int(count); i++ {
+			f := javaField{Type: r.u8(), Name: r.utf()}
+			if f.Type == 'L' || f.Type == '[' {
+				f.Signature, _ = r.object().(string)
+			}
+			c.Fields = append(c.Fields, f)
+		}
+		if end := r.u8(); end != tcEndBlockData {
+			panic(fmt.Sprintf("class annotation of %s not terminated", c.Name))
+		}
+		c.Super = r.classDesc()
+		return c
+	default:
+		panic(fmt.Sprintf("unexpected type code %#x for a class descriptor", tc))
+	}
+}
+
+// object reads any object and returns the string it is, or the name of its class.
+func (r *javaStreamReader) object() interface{} {
+	switch tc := r.u8(); tc {
+	case tcNull:
+		return nil
+	case tcReference:
+		return r.reference()
+	case tcString:
+		s := r.utf()
+		r.handles = append(r.handles, s)
+		return s
+	case tcClass:
+		c := r.classDesc()
+		r.handles = append(r.handles, "java.lang.Class")
+		return c.Name
+	case tcArray:
+		c := r.classDesc()
+		r.handles = append(r.handles, c.Name)
+		var n uint32
+		r.read(&n)
+		for i := 0; i < int(n); i++ {
+			r.object()
+		}
+		return c.Name
+	case tcObject:
+		c := r.classDesc()
+		r.handles = append(r.handles, c.Name)
+		var hierarchy []*javaClass
+		for k := c; k != nil; k = k.Super {
+			hierarchy = append([]*javaClass{k}, hierarchy...)
+		}
+		for _, k := range hierarchy {
+			for _, f := range k.Fields {
+				switch f.Type {
+				case 'L', '[':
+					r.object()
+				case 'J', 'D':
+					r.read(new(uint64))
+				case 'I', 'F':
+					r.read(new(uint32))
+				default:
+					panic(fmt.Sprintf("unsupported field type %c", f.Type))
+				}
+			}
+			if k.Flags&scWriteMethod != 0 {
+				r.writeObjectData()
+			}
+		}
+		return c.Name
+	default:
+		panic(fmt.Sprintf("unexpected type code %#x for an object", tc))
+	}
+}
+
+// writeObjectData reads the data written by a writeObject method, up to its end marker.
+func (r *javaStreamReader) writeObjectData() {
+	for {
+		b, err := r.data.ReadByte()
+		if err != nil {
+			panic("writeObject data not terminated")
+		}
+		switch b {
+		case tcEndBlockData:
+			return
+		case tcBlockData:
+			n := r.u8()
+			r.read(make([]byte, n))
+		default:
+			r.data.UnreadByte()
+			r.object()
+		}
+	}
+}
+
+// readJavaStream checks the structure of a stream holding one object and returns the classes
+// it declares.
+func readJavaStream(t *testing.T, stream []byte) (classes []string) {
+	t.Helper()
+	require.True(t, bytes.HasPrefix(stream, JavaSerializationMagic))
+	r := &javaStreamReader{data: bytes.NewReader(stream[len(JavaSerializationMagic):])}
+	defer func() {
+		if err := recover(); err != nil {
+			t.Fatalf("malformed stream: %v", err)
+		}
+	}()
+	r.object()
+	assert.Zero(t, r.data.Len(), "trailing bytes after the object")
+	return r.classes
+}
+
+func TestJavaGadgetsAreWellFormed(t *testing.T) {
+	classes := readJavaStream(t, JavaURLDNSGadget("abc.oast.example.com"))
+	assert.Equal(t, []string{"java.util.HashMap", "java.net.URL"}, classes)
+	assert.Contains(t, string(JavaURLDNSGadget("abc.oast.example.com")), "abc.oast.example.com")
+
+	classes = readJavaStream(t, JavaCommonsCollectionsSleepGadget(5))
+	assert.Equal(t, []string{
+		"java.util.HashMap",
+		"org.apache.commons.collections.keyvalue.TiedMapEntry",
+		"org.apache.commons.collections.map.LazyMap",
+		"org.apache.commons.collections.functors.ChainedTransformer",
+		"[Lorg.apache.commons.collections.Transformer;",
+		"org.apache.commons.collections.functors.ConstantTransformer",
+		"java.lang.Thread",
+		"org.apache.commons.collections.functors.InvokerTransformer",
+		"[Ljava.lang.Object;",
+		"[Ljava.lang.Class;",
+		"long",
+		"java.lang.String",
+		"java.lang.Long",
+		"java.lang.Number",
+		"java.lang.Object",
+	}, classes, "each class is described once, then referenced")
+	assert.True(t, bytes.Contains(JavaCommonsCollectionsSleepGadget(5), binary.BigEndian.AppendUint64(nil, 5000)), "the sleep is in milliseconds")
+
+	assert.Equal(t, []string{DeserializationUnknownClass}, readJavaStream(t, JavaUnknownClassObject()))
+}
+
+// phpStringRegex matches the strings of a serialized PHP value.
+var phpStringRegex = regexp.MustCompile(`s:(\d+):"`)
+
+func TestPHPGadgetsAreWellFormed(t *testing.T) {
+	for _, gadget := range DeserializationSleepGadgets {
+		if gadget.Format != FormatPHP {
+			continue
+		}
+		value := string(gadget.Build(7))
+		assert.Regexp(t, PHPSerializedRegex, value, gadget.Name)
+		assert.Contains(t, value, `s:7:"sleep 7";`, gadget.Name)
+		// Every string is as long as its declared length and followed by its closing quote.
+		for _, match := range phpStringRegex.FindAllStringSubmatchIndex(value, -1) {
+			n, _ := strconv.Atoi(value[match[2]:match[3]])
+			require.Less(t, match[1]+n+1, len(value), gadget.Name)
+			assert.Equal(t, `";`, value[match[1]+n:match[1]+n+2], gadget.Name)
+		}
+	}
+	assert.Equal(t, `O:22:"DursgoNonexistentClass":0:{}`, PHPUnknownClassObject)
+}
@@ -47,3 +47,20 @@ func init() {
 		},
 	}
 }
+
+// DOMXSSHookPayloads confirm the source-to-sink flows found by static analysis: they call the
+// hook function DURSGO_DOM_XSS_HOOK, defined in the page before its own scripts run, with the
+// numeric DURSGO_DOM_XSS_MARKER. Payloads for HTML sinks (innerHTML, document.write) are
+// listed under "html", those for code sinks (eval, setTimeout with a string) under "script".
+var DOMXSSHookPayloads = map[string][]DOMXSSTest{
+	"html": {
+		{Payload: `<img src=x onerror=DURSGO_DOM_XSS_HOOK(DURSGO_DOM_XSS_MARKER)>`, Description: "Image error handler calling the hook."},
+		{Payload: `"><img src=x onerror=DURSGO_DOM_XSS_HOOK(DURSGO_DOM_XSS_MARKER)>`, Description: "Break out of an attribute, then an image error handler calling the hook."},
+		{Payload: `<svg onload=DURSGO_DOM_XSS_HOOK(DURSGO_DOM_XSS_MARKER)>`, Description: "SVG load handler calling the hook."},
+	},
+	"script": {
+		{Payload: `DURSGO_DOM_XSS_HOOK(DURSGO_DOM_XSS_MARKER)`, Description: "Hook call evaluated as code."},
+		{Payload: `'-DURSGO_DOM_XSS_HOOK(DURSGO_DOM_XSS_MARKER)-'`, Description: "Break out of a single-quoted string in evaluated code."},
+		{Payload: `"-DURSGO_DOM_XSS_HOOK(DURSGO_DOM_XSS_MARKER)-"`, Description: "Break out of a double-quoted string in evaluated code."},
+	},
+}
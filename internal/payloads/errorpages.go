@@ -0,0 +1,72 @@
+package payloads
+
+import (
+	"errors"
+	"regexp"
+)
+
+// ErrorPageSignature recognizes the debug or error page of a framework, or a stack trace or
+// database error format, in response bodies. The errorpages analyzer matches them against every
+// response of a scan; payload set files add signatures (or replace them) under the error_pages
+// category.
+type ErrorPageSignature struct {
+	// Framework names what the page reveals in findings (e.g., "Django", "Java").
+	Framework string `yaml:"framework"`
+	// Pattern is the regular expression matching the page.
+	Pattern string `yaml:"pattern"`
+	// Regex is the compiled Pattern.
+	Regex *regexp.Regexp `yaml:"-"`
+}
+
+// ErrorPageSignatures are the built-in signatures, framework debug pages first, then the stack
+// trace and database error formats of their languages.
+var ErrorPageSignatures = []ErrorPageSignature{
+	// --- Framework debug pages ---
+	{Framework: "Django", Pattern: `You're seeing this error because you have <code>DEBUG = True</code>`},
+	{Framework: "Django", Pattern: `<th>Django Version:</th>`},
+	{Framework: "Laravel", Pattern: `(?:facade|spatie)/(?:laravel-)?ignition|window\.ignite\(`},
+	{Framework: "Laravel", Pattern: `Illuminate\\(?:Database|Routing|Foundation|Http|View)\\[\w\\]*Exception`},
+	{Framework: "Symfony", Pattern: `Symfony\\Component\\[\w\\]+Exception|<div id="sfwdt[0-9a-f]+"`},
+	{Framework: "ASP.NET", Pattern: `Server Error in '[^']*' Application\.`},
+	{Framework: "ASP.NET", Pattern: `<b>\s*Version Information:\s*</b>(?:&nbsp;|\s)*Microsoft \.NET Framework Version`},
+	{Framework: "ASP.NET Core", Pattern: `An unhandled exception occurred while processing the request\.`},
+	{Framework: "Ruby on Rails", Pattern: `<title>Action Controller: Exception caught</title>`},
+	{Framework: "Flask", Pattern: `The debugger caught an exception in your WSGI application|<title>[^<]*// Werkzeug Debugger</title>`},
+	{Framework: "Express", Pattern: `at Layer\.handle \[as handle_request\]`},
+	{Framework: "Apache Tomcat", Pattern: `(?i)<(?:b|h2)>root cause</(?:b|h2)>`},
+	{Framework: "Spring Boot", Pattern: `Whitelabel Error Page[\s\S]{0,500}?\bat [\w$.]+\([\w$]+\.java:\d+\)`},
+
+	// --- Stack traces ---
+	{Framework: "Java", Pattern: `(?m)^\s*at [\w$.]+\([\w$]+\.java:\d+\)`},
+	{Framework: "Python", Pattern: `Traceback \(most recent call last\):`},
+	{Framework: "PHP", Pattern: `(?:Fatal error|Warning|Notice|Parse error)(?:</b>)?: .{1,200}? in (?:<b>)?(?:/|[A-Z]:\\)\S+\.php(?:</b>)? on line (?:<b>)?\d+`},
+	{Framework: ".NET", Pattern: `at [\w.<>]+\(.*\) in (?:/|[A-Z]:\\)\S+:line \d+`},
+	{Framework: "Node.js", Pattern: `at (?:[\w.<>]+ )?\((?:/|[A-Z]:\\)\S+\.js:\d+:\d+\)`},
+	{Framework: "Go", Pattern: `goroutine \d+ \[running\]:`},
+
+	// --- Database errors and SQL fragments ---
+	{Framework: "SQL", Pattern: `SQLSTATE\[[0-9A-Z]{5}\]`},
+	{Framework: "SQL", Pattern: `(?i)you have an error in your sql syntax|unclosed quotation mark after the character string|\bORA-\d{5}: |PG::\w+Error|org\.postgresql\.util\.PSQLException|sqlite3\.OperationalError`},
+}
+
+func init() {
+	compileErrorPageSignatures()
+}
+
+func checkErrorPageSignature(s ErrorPageSignature) error {
+	if s.Framework == "" {
+		return errors.New("framework is required")
+	}
+	return checkErrorPattern(s.Pattern)
+}
+
+// compileErrorPageSignatures sets the Regex of ErrorPageSignatures, whose patterns have all been
+// checked before. A new list is assigned, as the current one may still be read.
+func compileErrorPageSignatures() {
+	signatures := make([]ErrorPageSignature, len(ErrorPageSignatures))
+	for i, s := range ErrorPageSignatures {
+		s.Regex = regexp.MustCompile(s.Pattern)
+		signatures[i] = s
+	}
+	ErrorPageSignatures = signatures
+}
@@ -0,0 +1,150 @@
+package payloads
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// PayloadKind is the language of a payload list, which decides the transformations that keep
+// its payloads working.
+type PayloadKind string
+
+// Payload kinds consuming evasion transformations.
+const (
+	PayloadSQL     PayloadKind = "sql"
+	PayloadXSS     PayloadKind = "xss"
+	PayloadCommand PayloadKind = "command"
+)
+
+// Transformation rewrites payloads so that they get past filters and WAFs matching their
+// literal form, e.g. ' OR 1=1-- as '/**/OR/**/1=1--. It is applied to the parameter value,
+// before the request encodes it for transport. Placeholders such as {DELAY} are kept as they
+// are, so templates can be transformed before they are filled in.
+type Transformation struct {
+	Name        string
+	Description string
+	Kinds       []PayloadKind // Payload languages the transformation keeps working.
+	transform   func(string) string
+}
+
+// AppliesTo reports whether the transformation keeps payloads of kind working.
+func (t Transformation) AppliesTo(kind PayloadKind) bool {
+	for _, k := range t.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// placeholder matches the placeholders of payload templates, which transformations skip.
+var placeholder = regexp.MustCompile(`\{[A-Z_]+\}`)
+
+// Apply returns payload transformed, with its placeholders unchanged. The zero Transformation
+// returns payload as it is.
+func (t Transformation) Apply(payload string) string {
+	if t.transform == nil {
+		return payload
+	}
+	var b strings.Builder
+	last := 0
+	for _, loc := range placeholder.FindAllStringIndex(payload, -1) {
+		b.WriteString(t.transform(payload[last:loc[0]]))
+		b.WriteString(payload[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	b.WriteString(t.transform(payload[last:]))
+	return b.String()
+}
+
+// Transformations are the evasion transformations, in the order scanners try them.
+var Transformations = []Transformation{
+	{Name: "url", Description: "URL-encode every character but letters, digits and -_.~", Kinds: []PayloadKind{PayloadSQL, PayloadXSS, PayloadCommand}, transform: urlEncode},
+	{Name: "sql-comment", Description: "Replace spaces with inline comments (/**/)", Kinds: []PayloadKind{PayloadSQL}, transform: sqlComments},
+	{Name: "case", Description: "Randomize the case of SQL keywords and identifiers", Kinds: []PayloadKind{PayloadSQL}, transform: randomCase},
+	{Name: "double-url", Description: "URL-encode twice", Kinds: []PayloadKind{PayloadSQL, PayloadXSS, PayloadCommand}, transform: func(s string) string { return urlEncode(urlEncode(s)) }},
+	{Name: "unicode", Description: `Escape every character but letters, digits and -_.~ as \uXXXX`, Kinds: []PayloadKind{PayloadSQL, PayloadXSS}, transform: unicodeEscape},
+	{Name: "null-byte", Description: "Append a null byte", Kinds: []PayloadKind{PayloadSQL, PayloadXSS, PayloadCommand}, transform: func(s string) string { return s + "\x00" }},
+}
+
+// TransformationByName returns the evasion transformation called name.
+func TransformationByName(name string) (Transformation, bool) {
+	for _, t := range Transformations {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return Transformation{}, false
+}
+
+// unreserved reports whether c is left as it is by the encoding transformations.
+func unreserved(c rune) bool {
+	return c < 0x80 && (unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("-_.~", c))
+}
+
+// urlEncode percent-encodes every byte of s but the unreserved characters.
+func urlEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; unreserved(rune(c)) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// unicodeEscape escapes every rune of s but the unreserved characters as \uXXXX.
+func unicodeEscape(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if unreserved(c) || c > 0xFFFF { // \uXXXX cannot hold runes outside the BMP.
+			b.WriteRune(c)
+		} else {
+			fmt.Fprintf(&b, `\u%04x`, c)
+		}
+	}
+	return b.String()
+}
+
+// sqlComments replaces the spaces of s with /**/, except after "--", which starts a comment
+// only when followed by a space.
+func sqlComments(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == ' ' && !strings.HasSuffix(s[:i], "--") {
+			b.WriteString("/**/")
+			continue
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// sqlWord matches the keywords and identifiers randomCase changes; words starting with a digit
+// (e.g., the hex literal 0x7e) are left alone.
+var sqlWord = regexp.MustCompile(`\b[A-Za-z_][A-Za-z0-9_]{2,}\b`)
+
+// randomCase randomizes the case of the letters of the words of s. The case only depends on s,
+// so that a finding reproduces with the same payload.
+func randomCase(s string) string {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	r := rand.New(rand.NewSource(int64(h.Sum64())))
+	return sqlWord.ReplaceAllStringFunc(s, func(word string) string {
+		runes := []rune(word)
+		for i, c := range runes {
+			if r.Intn(2) == 0 {
+				runes[i] = unicode.ToUpper(c)
+			} else {
+				runes[i] = unicode.ToLower(c)
+			}
+		}
+		return string(runes)
+	})
+}
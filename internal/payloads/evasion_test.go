@@ -0,0 +1,45 @@
+package payloads
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransformations(t *testing.T) {
+	tests := map[string]string{
+		"url":         `%27%20OR%201%3D1--%20-`,
+		"sql-comment": `'/**/OR/**/1=1-- -`,
+		"double-url":  `%2527%2520OR%25201%253D1--%2520-`,
+		"unicode":     `\u0027\u0020OR\u00201\u003d1--\u0020-`,
+		"null-byte":   "' OR 1=1-- -\x00",
+	}
+	for name, want := range tests {
+		transformation, ok := TransformationByName(name)
+		require.True(t, ok, name)
+		assert.Equal(t, want, transformation.Apply("' OR 1=1-- -"), name)
+	}
+	_, ok := TransformationByName("rot13")
+	assert.False(t, ok)
+	assert.Equal(t, "' OR 1=1", Transformation{}.Apply("' OR 1=1"), "the zero Transformation is the identity")
+}
+
+func TestTransformationPlaceholders(t *testing.T) {
+	url, _ := TransformationByName("url")
+	assert.Equal(t, `%27%20AND%20SLEEP%28{DELAY}%29`, url.Apply("' AND SLEEP({DELAY})"))
+	comment, _ := TransformationByName("sql-comment")
+	assert.Equal(t, `;/**/sleep/**/{DELAY}`, comment.Apply("; sleep {DELAY}"))
+}
+
+func TestRandomCase(t *testing.T) {
+	random, _ := TransformationByName("case")
+	payload := "' AND extractvalue(1,concat(0x7e,version()))-- -"
+	got := random.Apply(payload)
+	assert.Equal(t, strings.ToLower(payload), strings.ToLower(got))
+	assert.Equal(t, got, random.Apply(payload), "the case only depends on the payload")
+	assert.Contains(t, got, "0x7e")
+	assert.True(t, random.AppliesTo(PayloadSQL))
+	assert.False(t, random.AppliesTo(PayloadXSS))
+}
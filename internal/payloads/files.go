@@ -0,0 +1,551 @@
+package payloads
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Modes of a payload category in a payload set file.
+const (
+	ModeMerge   = "merge"   // The payloads of the file are added to the current ones.
+	ModeReplace = "replace" // The payloads of the file replace the current ones.
+)
+
+// PayloadFile describes a payload file loaded for a scan, as recorded in reports.
+type PayloadFile struct {
+	Path       string                `json:"path"`
+	SHA256     string                `json:"sha256"`
+	Categories []PayloadFileCategory `json:"categories"`
+}
+
+// PayloadFileCategory is a payload category set by a payload file.
+type PayloadFileCategory struct {
+	Name  string `json:"name"`
+	Mode  string `json:"mode"`
+	Count int    `json:"count"` // Payloads of the file.
+}
+
+// payloadsMu guards the payload lists that payload files can change, so that they can be swapped
+// while scanners read them through the accessors below. The lists are never modified in place:
+// loading a file assigns new slices, so slices handed out earlier stay valid.
+var payloadsMu sync.RWMutex
+
+// loadedFiles are the payload files loaded so far, in load order. Guarded by payloadsMu.
+var loadedFiles []PayloadFile
+
+// knownDBMS are the database systems the SQLi payloads can target.
+var knownDBMS = []string{"MSSQL", "MySQL", "Oracle", "PostgreSQL", "SQLite"}
+
+// payloadCategories are the payload lists payload files can extend or replace, by category name.
+var payloadCategories = map[string]payloadCategory{
+	"sqli":                newCategory(&SQLiPayloads, checkPayload),
+	"sqli_error_patterns": newCategory(&SQLiErrorPatterns, checkErrorPattern).then(compileSQLiErrorPatterns),
+	"sqli_boolean":        newCategory(&BooleanSQLiTests, checkBooleanTest),
+	"sqli_time":           newCategory(&TimeBasedSQLiTests, checkTimeTest),
+	"sqli_stacked":        newCategory(&StackedQueriesSQLiTests, checkTimeTest),
+	"sqli_union":          newCategory(&UnionSQLiPayloadTemplates, checkUnionTemplate),
+	"lfi":                 newCategory(&LFIPathTraversalPayloads, checkPayload),
+	"openredirect":        newCategory(&OpenRedirectPayloads, checkPayload),
+	"content_discovery":   newCategory(&ContentDiscoveryPaths, checkPayload),
+	"exposed":             newCategory(&ExposedGenericPaths, checkPayload),
+	"xss_blind":           newCategory(&BlindXSSPayloads, checkBlindXSSTemplate),
+	"jwt_secrets":         newCategory(&JWTWeakSecrets, checkPayload),
+	"parameters":          newCategory(&ParameterNames, checkParameterName),
+	"cms_vulnerabilities": newCategory(&CMSVulnerabilities, checkCMSVulnerability),
+	"error_pages":         newCategory(&ErrorPageSignatures, checkErrorPageSignature).then(compileErrorPageSignatures),
+	"default_endpoints":   newCategory(&DefaultEndpoints, checkDefaultEndpoint).then(compileDefaultEndpoints),
+}
+
+// payloadCategory is a payload list that payload files can set.
+type payloadCategory struct {
+	// decode checks the payload list of a payload set file. where prefixes error messages.
+	decode func(items *yaml.Node, where string) (payloadUpdate, error)
+	// fromLines checks the payloads of a plain text file, read from the given line numbers. It is
+	// nil for categories of structured payloads, which only payload set files can hold.
+	fromLines func(lines []string, lineNumbers []int, where string) (payloadUpdate, error)
+	// applied, if set, is called after the list was changed, with payloadsMu held for writing.
+	applied func()
+}
+
+// payloadUpdate is a checked payload list that has not been applied yet.
+type payloadUpdate struct {
+	count int
+	apply func(replace bool) // Called with payloadsMu held for writing.
+}
+
+// newCategory returns the category of list, whose payloads are checked with check.
+func newCategory[T any](list *[]T, check func(T) error) payloadCategory {
+	build := func(items []T, lineNumbers []int, where string) (payloadUpdate, error) {
+		if len(items) == 0 {
+			return payloadUpdate{}, fmt.Errorf("%s: no payloads", where)
+		}
+		var errs []error
+		for i, item := range items {
+			if err := check(item); err != nil {
+				errs = append(errs, fmt.Errorf("%s: line %d: %w", where, lineNumbers[i], err))
+			}
+		}
+		if len(errs) > 0 {
+			return payloadUpdate{}, errors.Join(errs...)
+		}
+		return payloadUpdate{count: len(items), apply: func(replace bool) {
+			if replace {
+				*list = items
+				return
+			}
+			*list = append(slices.Clip(*list), items...)
+		}}, nil
+	}
+
+	c := payloadCategory{decode: func(node *yaml.Node, where string) (payloadUpdate, error) {
+		if err := checkFields(node, reflect.TypeFor[T](), where); err != nil {
+			return payloadUpdate{}, err
+		}
+		var items []T
+		if err := node.Decode(&items); err != nil {
+			return payloadUpdate{}, fmt.Errorf("%s: %w", where, err)
+		}
+		lineNumbers := make([]int, len(node.Content))
+		for i, item := range node.Content {
+			lineNumbers[i] = item.Line
+		}
+		return build(items, lineNumbers, where)
+	}}
+	// Lists of strings can also be read from plain text files.
+	if fromLines, ok := any(build).(func([]string, []int, string) (payloadUpdate, error)); ok {
+		c.fromLines = fromLines
+	}
+	return c
+}
+
+// then returns c calling applied after its list was changed.
+func (c payloadCategory) then(applied func()) payloadCategory {
+	c.applied = applied
+	return c
+}
+
+// checkFields reports the keys of the items of a payload list that are not fields of the payload
+// type t. Items of other types are left to decoding.
+func checkFields(items *yaml.Node, t reflect.Type, where string) error {
+	if t.Kind() != reflect.Struct || items.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+		fields = append(fields, name)
+	}
+	var errs []error
+	for _, item := range items.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			if key := item.Content[i]; !slices.Contains(fields, key.Value) {
+				errs = append(errs, fmt.Errorf("%s: line %d: unknown field %q; use %s", where, key.Line, key.Value, strings.Join(fields, ", ")))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func checkPayload(payload string) error {
+	if payload == "" {
+		return errors.New("empty payload")
+	}
+	return nil
+}
+
+func checkParameterName(name string) error {
+	if name == "" || strings.ContainsAny(name, " \t&=#") {
+		return fmt.Errorf("invalid parameter name %q", name)
+	}
+	return nil
+}
+
+func checkErrorPattern(pattern string) error {
+	if _, err := regexp.Compile(pattern); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return nil
+}
+
+func checkUnionTemplate(template string) error {
+	if !strings.Contains(template, "{NULLS}") {
+		return fmt.Errorf("template %q has no {NULLS} placeholder", template)
+	}
+	return nil
+}
+
+func checkBlindXSSTemplate(template string) error {
+	if !strings.Contains(template, "{URL}") {
+		return fmt.Errorf("template %q has no {URL} placeholder", template)
+	}
+	return nil
+}
+
+func checkBooleanTest(test BooleanSQLiTest) error {
+	switch {
+	case test.TruePayload == "" || test.FalsePayload == "":
+		return errors.New("true_payload and false_payload are required")
+	case test.TruePayload == test.FalsePayload:
+		return errors.New("true_payload and false_payload are the same")
+	case test.DBMS != "" && !slices.Contains(knownDBMS, test.DBMS):
+		return fmt.Errorf("unknown dbms %q; use %s or leave it out", test.DBMS, strings.Join(knownDBMS, ", "))
+	}
+	return nil
+}
+
+func checkTimeTest(test TimeBasedSQLiTest) error {
+	switch {
+	case !strings.Contains(test.PayloadTemplate, "{DELAY}"):
+		return fmt.Errorf("template %q has no {DELAY} placeholder", test.PayloadTemplate)
+	case !slices.Contains(knownDBMS, test.DBMS):
+		return fmt.Errorf("unknown dbms %q; use %s", test.DBMS, strings.Join(knownDBMS, ", "))
+	}
+	return nil
+}
+
+// compileSQLiErrorPatterns sets SQLiErrorRegexes and SQLiErrorSignatures to the compiled
+// SQLiErrorPatterns, which have all been checked before. Patterns of SQLiErrorPatternsByDBMS
+// keep their database family, also when a payload file lists them.
+func compileSQLiErrorPatterns() {
+	dbmsOf := make(map[string]string)
+	for dbms, patterns := range SQLiErrorPatternsByDBMS {
+		for _, pattern := range patterns {
+			dbmsOf[pattern] = dbms
+		}
+	}
+	regexes := make([]*regexp.Regexp, 0, len(SQLiErrorPatterns))
+	signatures := make([]SQLiErrorSignature, 0, len(SQLiErrorPatterns))
+	for _, pattern := range SQLiErrorPatterns {
+		re := regexp.MustCompile(pattern)
+		regexes = append(regexes, re)
+		signatures = append(signatures, SQLiErrorSignature{Pattern: pattern, Regex: re, DBMS: dbmsOf[pattern]})
+	}
+	SQLiErrorRegexes, SQLiErrorSignatures = regexes, signatures
+}
+
+// PayloadCategories returns the names of the payload categories payload files can set.
+func PayloadCategories() []string {
+	names := make([]string, 0, len(payloadCategories))
+	for name := range payloadCategories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pendingFile is a checked payload file that has not been applied yet.
+type pendingFile struct {
+	info    PayloadFile
+	updates []pendingUpdate
+}
+
+type pendingUpdate struct {
+	category payloadCategory
+	update   payloadUpdate
+	replace  bool
+}
+
+// apply applies the payloads of f and records it as loaded.
+func (f *pendingFile) apply() PayloadFile {
+	payloadsMu.Lock()
+	defer payloadsMu.Unlock()
+	for _, u := range f.updates {
+		u.update.apply(u.replace)
+		if u.category.applied != nil {
+			u.category.applied()
+		}
+	}
+	loadedFiles = append(loadedFiles, f.info)
+	return f.info
+}
+
+// readPayloadFile reads a plain text payload file for the payload category name: one payload per
+// line, skipping empty lines and lines starting with "#". Payloads are used verbatim, without
+// trimming, so that leading and trailing spaces can be part of a payload.
+func readPayloadFile(name, path string) (*pendingFile, error) {
+	category, ok := payloadCategories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown payload category %q; use %s", name, strings.Join(PayloadCategories(), ", "))
+	}
+	if category.fromLines == nil {
+		return nil, fmt.Errorf("payload category %q has structured payloads; load it from a payload set file", name)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	var lineNumbers []int
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+		lineNumbers = append(lineNumbers, number)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%s has no payloads", path)
+	}
+	update, err := category.fromLines(lines, lineNumbers, path)
+	if err != nil {
+		return nil, err
+	}
+	return &pendingFile{
+		info:    PayloadFile{Path: path, SHA256: digest(data), Categories: []PayloadFileCategory{{Name: name, Mode: ModeReplace, Count: update.count}}},
+		updates: []pendingUpdate{{category: category, update: update, replace: true}},
+	}, nil
+}
+
+// readPayloadSet reads a payload set file: a YAML or JSON mapping of payload categories to their
+// mode (merge, the default, or replace) and payloads, e.g.
+//
+//	sqli_time:
+//	  mode: replace
+//	  payloads:
+//	    - template: "' AND SLEEP({DELAY})-- "
+//	      dbms: MySQL
+//	      description: MySQL SLEEP
+//	lfi:
+//	  payloads: ["../../../../etc/hosts"]
+//
+// All categories are checked before any is applied.
+func readPayloadSet(path string) (*pendingFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, fmt.Errorf("%s has no payloads", path)
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: line %d: expected a mapping of payload categories", path, root.Line)
+	}
+
+	pending := &pendingFile{info: PayloadFile{Path: path, SHA256: digest(data)}}
+	var errs []error
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		where := path + ": " + key.Value
+		category, ok := payloadCategories[key.Value]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: line %d: unknown payload category %q; use %s", path, key.Line, key.Value, strings.Join(PayloadCategories(), ", ")))
+			continue
+		}
+		mode, items, err := readPayloadSection(value, where)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		update, err := category.decode(items, where)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		pending.info.Categories = append(pending.info.Categories, PayloadFileCategory{Name: key.Value, Mode: mode, Count: update.count})
+		pending.updates = append(pending.updates, pendingUpdate{category: category, update: update, replace: mode == ModeReplace})
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	if len(pending.updates) == 0 {
+		return nil, fmt.Errorf("%s has no payloads", path)
+	}
+	return pending, nil
+}
+
+// readPayloadSection returns the mode and the payload list of a category of a payload set file.
+func readPayloadSection(node *yaml.Node, where string) (string, *yaml.Node, error) {
+	if node.Kind != yaml.MappingNode {
+		return "", nil, fmt.Errorf("%s: line %d: expected mode and payloads", where, node.Line)
+	}
+	mode, items := ModeMerge, (*yaml.Node)(nil)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		switch key.Value {
+		case "mode":
+			mode = strings.ToLower(value.Value)
+			if mode != ModeMerge && mode != ModeReplace {
+				return "", nil, fmt.Errorf("%s: line %d: invalid mode %q; use %s or %s", where, value.Line, value.Value, ModeMerge, ModeReplace)
+			}
+		case "payloads":
+			items = value
+		default:
+			return "", nil, fmt.Errorf("%s: line %d: unknown key %q; use mode, payloads", where, key.Line, key.Value)
+		}
+	}
+	switch {
+	case items == nil:
+		return "", nil, fmt.Errorf("%s: line %d: no payloads", where, node.Line)
+	case items.Kind != yaml.SequenceNode:
+		return "", nil, fmt.Errorf("%s: line %d: payloads must be a list", where, items.Line)
+	}
+	return mode, items, nil
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// CheckPayloadFile checks a plain text payload file for the payload category name without
+// loading it (see LoadPayloadFile).
+func CheckPayloadFile(name, path string) error {
+	_, err := readPayloadFile(name, path)
+	return err
+}
+
+// CheckPayloadSet checks a payload set file without loading it (see LoadPayloadSet).
+func CheckPayloadSet(path string) error {
+	_, err := readPayloadSet(path)
+	return err
+}
+
+// LoadPayloadFile replaces the payloads of the category name with those of a plain text file
+// holding one payload per line. Empty lines and lines starting with "#" are skipped; payloads are
+// used verbatim. Only categories of plain string payloads can be loaded this way.
+func LoadPayloadFile(name, path string) (PayloadFile, error) {
+	pending, err := readPayloadFile(name, path)
+	if err != nil {
+		return PayloadFile{}, err
+	}
+	return pending.apply(), nil
+}
+
+// LoadPayloadSet merges the payloads of a YAML or JSON payload set file with the current ones or
+// replaces them, per category. Nothing is changed if any category of the file is invalid.
+func LoadPayloadSet(path string) (PayloadFile, error) {
+	pending, err := readPayloadSet(path)
+	if err != nil {
+		return PayloadFile{}, err
+	}
+	return pending.apply(), nil
+}
+
+// LoadedPayloadFiles returns the payload files loaded so far, in load order.
+func LoadedPayloadFiles() []PayloadFile {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return slices.Clone(loadedFiles)
+}
+
+// The accessors below return the current payload lists. Scanners use them instead of the
+// variables, which payload files may replace while a scan runs.
+
+// GetSQLiErrorRegexes returns SQLiErrorRegexes.
+func GetSQLiErrorRegexes() []*regexp.Regexp {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return SQLiErrorRegexes
+}
+
+// GetSQLiErrorSignatures returns SQLiErrorSignatures.
+func GetSQLiErrorSignatures() []SQLiErrorSignature {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return SQLiErrorSignatures
+}
+
+// GetBooleanSQLiTests returns BooleanSQLiTests.
+func GetBooleanSQLiTests() []BooleanSQLiTest {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return BooleanSQLiTests
+}
+
+// GetUnionSQLiPayloadTemplates returns UnionSQLiPayloadTemplates.
+func GetUnionSQLiPayloadTemplates() []string {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return UnionSQLiPayloadTemplates
+}
+
+// GetLFIPathTraversalPayloads returns LFIPathTraversalPayloads.
+func GetLFIPathTraversalPayloads() []string {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return LFIPathTraversalPayloads
+}
+
+// GetOpenRedirectPayloads returns OpenRedirectPayloads.
+func GetOpenRedirectPayloads() []string {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return OpenRedirectPayloads
+}
+
+// GetContentDiscoveryPaths returns ContentDiscoveryPaths.
+func GetContentDiscoveryPaths() []string {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return ContentDiscoveryPaths
+}
+
+// GetParameterNames returns ParameterNames.
+func GetParameterNames() []string {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return ParameterNames
+}
+
+// GetCMSVulnerabilities returns CMSVulnerabilities.
+func GetCMSVulnerabilities() []CMSVulnerability {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return CMSVulnerabilities
+}
+
+// GetDefaultEndpoints returns DefaultEndpoints.
+func GetDefaultEndpoints() []DefaultEndpoint {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return DefaultEndpoints
+}
+
+// GetErrorPageSignatures returns ErrorPageSignatures.
+func GetErrorPageSignatures() []ErrorPageSignature {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return ErrorPageSignatures
+}
+
+// GetBlindXSSPayloads returns BlindXSSPayloads.
+func GetBlindXSSPayloads() []string {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return BlindXSSPayloads
+}
+
+// GetExposedGenericPaths returns ExposedGenericPaths.
+func GetExposedGenericPaths() []string {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return ExposedGenericPaths
+}
@@ -0,0 +1,200 @@
+package payloads
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// restorePayloads restores the payload lists and loaded files after a test that loads payloads.
+func restorePayloads(t *testing.T) {
+	lfi, redirects, boolean, timeBased := LFIPathTraversalPayloads, OpenRedirectPayloads, BooleanSQLiTests, TimeBasedSQLiTests
+	patterns, regexes, signatures, loaded, cms := SQLiErrorPatterns, SQLiErrorRegexes, SQLiErrorSignatures, loadedFiles, CMSVulnerabilities
+	errorPages, endpoints := ErrorPageSignatures, DefaultEndpoints
+	t.Cleanup(func() {
+		LFIPathTraversalPayloads, OpenRedirectPayloads, BooleanSQLiTests, TimeBasedSQLiTests = lfi, redirects, boolean, timeBased
+		SQLiErrorPatterns, SQLiErrorRegexes, SQLiErrorSignatures, loadedFiles, CMSVulnerabilities = patterns, regexes, signatures, loaded, cms
+		ErrorPageSignatures, DefaultEndpoints = errorPages, endpoints
+	})
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	return path
+}
+
+func TestLoadPayloadFile(t *testing.T) {
+	restorePayloads(t)
+	path := writeFile(t, "lfi.txt", "# Custom traversal\n../../secret.txt\r\n\n ../app.ini\n")
+
+	file, err := LoadPayloadFile("lfi", path)
+	require.NoError(t, err)
+	assert.Equal(t, []PayloadFileCategory{{Name: "lfi", Mode: ModeReplace, Count: 2}}, file.Categories)
+	assert.Len(t, file.SHA256, 64)
+	assert.Equal(t, []string{"../../secret.txt", " ../app.ini"}, GetLFIPathTraversalPayloads())
+	assert.Equal(t, []PayloadFile{file}, LoadedPayloadFiles())
+
+	_, err = LoadPayloadFile("xsss", path)
+	assert.ErrorContains(t, err, `unknown payload category "xsss"`)
+	_, err = LoadPayloadFile("sqli_boolean", path)
+	assert.ErrorContains(t, err, "structured payloads")
+	_, err = LoadPayloadFile("lfi", writeFile(t, "empty.txt", "# nothing\n"))
+	assert.ErrorContains(t, err, "has no payloads")
+	err = CheckPayloadFile("sqli_error_patterns", writeFile(t, "patterns.txt", "ok\n[broken\n"))
+	assert.ErrorContains(t, err, "line 2: invalid pattern")
+}
+
+func TestLoadPayloadSet(t *testing.T) {
+	restorePayloads(t)
+	builtinRedirects := len(OpenRedirectPayloads)
+	path := writeFile(t, "payloads.yaml", `
+sqli_boolean:
+  mode: replace
+  payloads:
+    - true_payload: "' AND 'a'='a"
+      false_payload: "' AND 'a'='b"
+      description: Quoted string comparison
+sqli_time:
+  payloads:
+    - template: "' AND SLEEP({DELAY})-- "
+      dbms: MySQL
+sqli_error_patterns:
+  payloads: ["(?i)custom orm failure"]
+openredirect:
+  payloads: ["//evil.example"]
+`)
+
+	file, err := LoadPayloadSet(path)
+	require.NoError(t, err)
+	assert.Equal(t, []PayloadFileCategory{
+		{Name: "sqli_boolean", Mode: ModeReplace, Count: 1},
+		{Name: "sqli_time", Mode: ModeMerge, Count: 1},
+		{Name: "sqli_error_patterns", Mode: ModeMerge, Count: 1},
+		{Name: "openredirect", Mode: ModeMerge, Count: 1},
+	}, file.Categories)
+
+	assert.Equal(t, []BooleanSQLiTest{{TruePayload: "' AND 'a'='a", FalsePayload: "' AND 'a'='b", Description: "Quoted string comparison"}}, GetBooleanSQLiTests())
+	assert.Contains(t, TimeBasedSQLiTestsForDBMS("MySQL"), TimeBasedSQLiTest{PayloadTemplate: "' AND SLEEP({DELAY})-- ", DBMS: "MySQL"})
+	assert.Len(t, GetOpenRedirectPayloads(), builtinRedirects+1)
+	regexes := GetSQLiErrorRegexes()
+	require.Len(t, regexes, len(SQLiErrorPatterns))
+	assert.True(t, regexes[len(regexes)-1].MatchString("Custom ORM failure"))
+}
+
+func TestLoadPayloadSetJSON(t *testing.T) {
+	restorePayloads(t)
+	path := writeFile(t, "payloads.json", `{"lfi": {"mode": "replace", "payloads": ["../../etc/hosts"]}}`)
+
+	_, err := LoadPayloadSet(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"../../etc/hosts"}, GetLFIPathTraversalPayloads())
+}
+
+func TestLoadPayloadSetRejectsInvalidFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr []string
+	}{
+		{
+			name:    "Unknown category",
+			content: "xsss:\n  payloads: [a]\n",
+			wantErr: []string{`line 1: unknown payload category "xsss"`},
+		},
+		{
+			name:    "Invalid mode",
+			content: "lfi:\n  mode: append\n  payloads: [a]\n",
+			wantErr: []string{`line 2: invalid mode "append"`},
+		},
+		{
+			name:    "Malformed regex",
+			content: "sqli_error_patterns:\n  payloads:\n    - ok\n    - \"[broken\"\n",
+			wantErr: []string{"sqli_error_patterns: line 4: invalid pattern"},
+		},
+		{
+			name:    "Time template without placeholder",
+			content: "sqli_time:\n  payloads:\n    - template: \"' AND SLEEP(5)--\"\n      dbms: MySQL\n",
+			wantErr: []string{"line 3: template \"' AND SLEEP(5)--\" has no {DELAY} placeholder"},
+		},
+		{
+			name:    "Unknown DBMS and field",
+			content: "sqli_stacked:\n  payloads:\n    - template: \"; SELECT pg_sleep({DELAY})--\"\n      dbms: postgres\n      desc: typo\n",
+			wantErr: []string{`line 5: unknown field "desc"`},
+		},
+		{
+			name:    "Incomplete boolean pair",
+			content: "sqli_boolean:\n  payloads:\n    - true_payload: \" AND 1=1\"\n",
+			wantErr: []string{"true_payload and false_payload are required"},
+		},
+		{
+			name:    "Union template without placeholder",
+			content: "sqli_union:\n  payloads: [\" UNION SELECT 1--\"]\n",
+			wantErr: []string{"has no {NULLS} placeholder"},
+		},
+		{
+			name:    "CMS vulnerability without a fixed range",
+			content: "cms_vulnerabilities:\n  payloads:\n    - platform: wordpress\n      type: plugin\n      slug: example\n      cves: [CVE-2024-0001]\n",
+			wantErr: []string{"introduced or fixed_in is required"},
+		},
+		{
+			name:    "Error page signature without a framework",
+			content: "error_pages:\n  payloads:\n    - pattern: \"Whoops\"\n",
+			wantErr: []string{"error_pages: line 3: framework is required"},
+		},
+		{
+			name:    "Default endpoint with a relative path",
+			content: "default_endpoints:\n  payloads:\n    - path: admin/status\n      name: Status\n      severity: Low\n      match: \"Uptime\"\n",
+			wantErr: []string{`default_endpoints: line 3: path "admin/status" does not start with /`},
+		},
+		{
+			name:    "Several errors",
+			content: "lfi:\n  payloads: [\"\"]\nsqli_union:\n  payloads: []\n",
+			wantErr: []string{"lfi: line 2: empty payload", "sqli_union: no payloads"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restorePayloads(t)
+			lfi := LFIPathTraversalPayloads
+			_, err := LoadPayloadSet(writeFile(t, "payloads.yaml", tt.content))
+			require.Error(t, err)
+			for _, want := range tt.wantErr {
+				assert.ErrorContains(t, err, want)
+			}
+			// Nothing of an invalid file is applied.
+			assert.Equal(t, lfi, LFIPathTraversalPayloads)
+			assert.Empty(t, LoadedPayloadFiles())
+		})
+	}
+}
+
+func TestLoadPayloadSetKeepsEarlierSlices(t *testing.T) {
+	restorePayloads(t)
+	before := GetLFIPathTraversalPayloads()
+	snapshot := append([]string(nil), before...)
+
+	_, err := LoadPayloadSet(writeFile(t, "payloads.yaml", "lfi:\n  payloads: [\"../x\"]\n"))
+	require.NoError(t, err)
+	// Merging assigns a new slice; a scanner still iterating the old one sees it unchanged.
+	assert.Equal(t, snapshot, before)
+	assert.Len(t, GetLFIPathTraversalPayloads(), len(before)+1)
+}
+
+func TestLoadPayloadSetCompilesErrorPages(t *testing.T) {
+	restorePayloads(t)
+	builtin := len(GetErrorPageSignatures())
+
+	_, err := LoadPayloadSet(writeFile(t, "payloads.yaml", "error_pages:\n  payloads:\n    - framework: Acme\n      pattern: \"AcmeKernel panic in [\\\\w/]+\\\\.acme\"\n"))
+	require.NoError(t, err)
+	signatures := GetErrorPageSignatures()
+	require.Len(t, signatures, builtin+1)
+	for _, s := range signatures {
+		require.NotNil(t, s.Regex, s.Framework)
+	}
+	assert.True(t, signatures[builtin].Regex.MatchString("AcmeKernel panic in /srv/app/main.acme"))
+}
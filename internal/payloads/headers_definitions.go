@@ -9,8 +9,9 @@ type HeaderCheck struct {
 	// RecommendedValue is the recommended value. If empty, only the presence is checked.
 	// Can also contain a simple pattern (e.g., "max-age=" for HSTS).
 	RecommendedValue string
-	// Severity is the level of severity if the header is missing or misconfigured.
-	Severity string // Example: "High", "Medium", "Low", "Informational"
+	// Severity is the level of severity if the header is missing or misconfigured. Header
+	// weaknesses only enable or worsen other attacks, so they are "Low" or "Informational".
+	Severity string
 	// CheckOnHTTPSOnly indicates whether the header is only relevant for HTTPS connections.
 	CheckOnHTTPSOnly bool
 	// Remediation is the suggested fix.
@@ -27,7 +28,7 @@ func init() {
 			Name:             "Content-Security-Policy",
 			Description:      "Helps prevent XSS and other injection attacks by restricting the content sources allowed to load in the browser.",
 			RecommendedValue: "", // Only check presence, as the policy can be highly complex.
-			Severity:         "High",
+			Severity:         "Low",
 			CheckOnHTTPSOnly: false,
 			Remediation:      "Implement a strict Content Security Policy (CSP) to restrict sources for scripts, styles, images, and other content.",
 		},
@@ -35,7 +36,7 @@ func init() {
 			Name:             "Strict-Transport-Security",
 			Description:      "Forces the browser to always communicate with the server using HTTPS.",
 			RecommendedValue: "max-age=", // We only check if the max-age directive exists.
-			Severity:         "Medium",
+			Severity:         "Low",
 			CheckOnHTTPSOnly: true,
 			Remediation:      "Apply HSTS by setting the header 'Strict-Transport-Security: max-age=31536000; includeSubDomains' on all HTTPS responses.",
 		},
@@ -43,7 +44,7 @@ func init() {
 			Name:             "X-Frame-Options",
 			Description:      "Protects against clickjacking attacks by controlling whether a page can be displayed within an iframe. Note: The 'frame-ancestors' directive in a CSP is the modern replacement for this header.",
 			RecommendedValue: "DENY", // Or SAMEORIGIN
-			Severity:         "Medium",
+			Severity:         "Low",
 			CheckOnHTTPSOnly: false,
 			Remediation:      "Set the header 'X-Frame-Options: DENY' or 'SAMEORIGIN' to prevent clickjacking. For more granular control, use CSP's 'frame-ancestors' directive.",
 		},
@@ -60,7 +61,7 @@ func init() {
 			Name:             "Cross-Origin-Opener-Policy",
 			Description:      "Protects against cross-origin attacks by isolating the top-level Browse context from other documents.",
 			RecommendedValue: "same-origin", // or same-origin-allow-popups
-			Severity:         "Low",
+			Severity:         "Informational",
 			CheckOnHTTPSOnly: true,
 			Remediation:      "Set 'Cross-Origin-Opener-Policy: same-origin' to enable process isolation and mitigate attacks like XS-Leaks.",
 		},
@@ -77,7 +78,7 @@ func init() {
 			Name:             "Permissions-Policy",
 			Description:      "Controls which browser features and APIs can be used by the page (e.g., geolocation, microphone, camera). Successor to Feature-Policy.",
 			RecommendedValue: "", // Only check presence, as the policy can be highly complex.
-			Severity:         "Low",
+			Severity:         "Informational",
 			CheckOnHTTPSOnly: false,
 			Remediation:      "Apply a strict Permissions-Policy to disable unnecessary browser features. Example: 'Permissions-Policy: geolocation=(), microphone=()'.",
 		},
@@ -94,7 +95,7 @@ func init() {
 			Name:             "Cache-Control",
 			Description:      "Controls caching policies. For sensitive data, it should prevent storing.",
 			RecommendedValue: "no-store", // Or must contain "no-cache"
-			Severity:         "Medium",
+			Severity:         "Low",
 			CheckOnHTTPSOnly: false,
 			Remediation:      "For pages with sensitive information, set the header 'Cache-Control: no-store, no-cache, must-revalidate' to prevent caching.",
 		},
@@ -111,7 +112,7 @@ func init() {
 			Name:             "X-XSS-Protection",
 			Description:      "Legacy header to enable the browser's built-in XSS filter. Deprecated in modern browsers but provides defense-in-depth for older browser users.",
 			RecommendedValue: "1; mode=block",
-			Severity:         "Low",
+			Severity:         "Informational",
 			CheckOnHTTPSOnly: false,
 			Remediation:      "Set 'X-XSS-Protection: 1; mode=block' for older browsers, but rely on a strong CSP as the primary defense against XSS.",
 		},
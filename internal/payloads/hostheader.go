@@ -0,0 +1,32 @@
+package payloads
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// HostHeaderTest describes one way of injecting a foreign host into a request.
+type HostHeaderTest struct {
+	// Header carrying the injected host. "Host" replaces the Host header itself; any other
+	// header is added while the original Host is kept.
+	Header string
+	// Description explains the technique for the finding details.
+	Description string
+}
+
+// HostHeaderTests contains the host injection techniques Dursgo will execute, in order.
+var HostHeaderTests = []HostHeaderTest{
+	{Header: "Host", Description: "Host header replaced"},
+	{Header: "X-Forwarded-Host", Description: "X-Forwarded-Host header added"},
+	{Header: "X-Host", Description: "X-Host header added"},
+}
+
+// HostHeaderResetPathKeywords mark endpoints of password reset flows, where an injected host
+// may end up in the reset link sent by email.
+var HostHeaderResetPathKeywords = []string{"reset", "forgot", "recover", "lost-password", "lostpassword"}
+
+// GenerateHostHeaderCanary returns a unique host name under the reserved .invalid TLD, so it
+// cannot be confused with content already present on the page.
+func GenerateHostHeaderCanary() string {
+	return fmt.Sprintf("dursgo%06d.invalid", rand.Intn(1000000))
+}
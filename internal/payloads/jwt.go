@@ -0,0 +1,29 @@
+package payloads
+
+import "regexp"
+
+// JWTRegex matches a JSON Web Token in JWS compact serialization: a base64url JSON header, a
+// payload and a signature, which is empty for unsigned tokens.
+var JWTRegex = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+
+// JWTNoneAlgorithms are the spellings of the "none" algorithm sent in unsigned tokens; libraries
+// comparing the algorithm case-sensitively against a deny list accept some of them.
+var JWTNoneAlgorithms = []string{"none", "None", "NONE", "nOnE"}
+
+// JWTWeakSecrets are HMAC secrets found in tutorials, framework defaults and leaked
+// configurations. Tokens signed with HS256, HS384 or HS512 are checked against them offline.
+var JWTWeakSecrets = []string{
+	"secret", "your-256-bit-secret", "your-384-bit-secret", "your-512-bit-secret", "secretkey",
+	"secret_key", "secret-key", "mysecret", "my_secret", "mysecretkey", "my-secret-key",
+	"jwt_secret", "jwt-secret", "jwtsecret", "jwt", "JWT_SECRET", "your_jwt_secret",
+	"your-secret-key", "supersecret", "super-secret", "topsecret", "changeme", "changeit",
+	"password", "passw0rd", "123456", "12345678", "qwerty", "admin", "test", "default", "key",
+	"private", "s3cr3t", "secret123", "shhhhh", "keyboard cat", "hello", "token", "auth",
+}
+
+// GetJWTWeakSecrets returns JWTWeakSecrets.
+func GetJWTWeakSecrets() []string {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	return JWTWeakSecrets
+}
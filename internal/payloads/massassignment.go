@@ -1,5 +1,10 @@
 package payloads
 
+import (
+	"fmt"
+	"math/rand"
+)
+
 // MassAssignmentTest represents a single Mass Assignment test case.
 type MassAssignmentTest struct {
 	// Key is the name of the field to be injected.
@@ -7,8 +12,17 @@ type MassAssignmentTest struct {
 	// Value is the malicious value to be injected.
 	Value interface{}
 	// CheckType tells the scanner how to verify success.
-	// Example: "bool_true", "string_match", "int_match"
+	// Example: "bool_true", "bool_false", "string_match", "int_match"
 	CheckType string
+	// Severity is that of the field being assignable: "High" for privileges, ownership and
+	// balances, "Medium" for subscriptions and verification, "Low" otherwise.
+	Severity string
+}
+
+// MassAssignmentGarbageField returns the name of a field no application defines; the response to
+// a body carrying it is the baseline of the differential test.
+func MassAssignmentGarbageField() string {
+	return fmt.Sprintf("dursgo_%08d", rand.Intn(100000000))
 }
 
 // MassAssignmentPayloads is the list of payloads to be tested for Mass Assignment vulnerabilities.
@@ -17,49 +31,49 @@ var MassAssignmentPayloads []MassAssignmentTest
 func init() {
 	MassAssignmentPayloads = []MassAssignmentTest{
 		// --- Privilege Escalation ---
-		{Key: "is_admin", Value: true, CheckType: "bool_true"},
-		{Key: "isAdmin", Value: true, CheckType: "bool_true"},
-		{Key: "isadmin", Value: true, CheckType: "bool_true"},
-		{Key: "is_staff", Value: true, CheckType: "bool_true"},
-		{Key: "isStaff", Value: true, CheckType: "bool_true"},
-		{Key: "role", Value: "admin", CheckType: "string_match"},
-		{Key: "Role", Value: "administrator", CheckType: "string_match"},
-		{Key: "user_role", Value: "admin", CheckType: "string_match"},
-		{Key: "userRole", Value: "superuser", CheckType: "string_match"},
-		{Key: "account_type", Value: "admin", CheckType: "string_match"},
-		{Key: "permissions", Value: "all", CheckType: "string_match"},
-		{Key: "access_level", Value: 999, CheckType: "int_match"},
-		{Key: "auth_level", Value: 100, CheckType: "int_match"},
-		{Key: "admin", Value: 1, CheckType: "int_match"},
-		{Key: "administrator", Value: true, CheckType: "bool_true"},
+		{Key: "is_admin", Value: true, CheckType: "bool_true", Severity: "High"},
+		{Key: "isAdmin", Value: true, CheckType: "bool_true", Severity: "High"},
+		{Key: "isadmin", Value: true, CheckType: "bool_true", Severity: "High"},
+		{Key: "is_staff", Value: true, CheckType: "bool_true", Severity: "High"},
+		{Key: "isStaff", Value: true, CheckType: "bool_true", Severity: "High"},
+		{Key: "role", Value: "admin", CheckType: "string_match", Severity: "High"},
+		{Key: "Role", Value: "administrator", CheckType: "string_match", Severity: "High"},
+		{Key: "user_role", Value: "admin", CheckType: "string_match", Severity: "High"},
+		{Key: "userRole", Value: "superuser", CheckType: "string_match", Severity: "High"},
+		{Key: "account_type", Value: "admin", CheckType: "string_match", Severity: "High"},
+		{Key: "permissions", Value: "all", CheckType: "string_match", Severity: "High"},
+		{Key: "access_level", Value: 999, CheckType: "int_match", Severity: "High"},
+		{Key: "auth_level", Value: 100, CheckType: "int_match", Severity: "High"},
+		{Key: "admin", Value: 1, CheckType: "int_match", Severity: "High"},
+		{Key: "administrator", Value: true, CheckType: "bool_true", Severity: "High"},
 
 		// --- Ownership/Account Takeover ---
-		{Key: "user_id", Value: 1, CheckType: "int_match"},
-		{Key: "userId", Value: 1, CheckType: "int_match"},
-		{Key: "owner_id", Value: 1, CheckType: "int_match"},
-		{Key: "author_id", Value: 1, CheckType: "int_match"},
+		{Key: "user_id", Value: 1, CheckType: "int_match", Severity: "High"},
+		{Key: "userId", Value: 1, CheckType: "int_match", Severity: "High"},
+		{Key: "owner_id", Value: 1, CheckType: "int_match", Severity: "High"},
+		{Key: "author_id", Value: 1, CheckType: "int_match", Severity: "High"},
 
 		// --- Subscription / Status Bypass ---
-		{Key: "is_premium", Value: true, CheckType: "bool_true"},
-		{Key: "is_pro", Value: true, CheckType: "bool_true"},
-		{Key: "has_subscription", Value: true, CheckType: "bool_true"},
-		{Key: "plan", Value: "premium", CheckType: "string_match"},
-		{Key: "subscription_level", Value: "gold", CheckType: "string_match"},
-		{Key: "paid_status", Value: "paid", CheckType: "string_match"},
+		{Key: "is_premium", Value: true, CheckType: "bool_true", Severity: "Medium"},
+		{Key: "is_pro", Value: true, CheckType: "bool_true", Severity: "Medium"},
+		{Key: "has_subscription", Value: true, CheckType: "bool_true", Severity: "Medium"},
+		{Key: "plan", Value: "premium", CheckType: "string_match", Severity: "Medium"},
+		{Key: "subscription_level", Value: "gold", CheckType: "string_match", Severity: "Medium"},
+		{Key: "paid_status", Value: "paid", CheckType: "string_match", Severity: "Medium"},
 
 		// --- Sensitive Data Overwriting (Verification, Balance, etc.) ---
-		{Key: "verified", Value: true, CheckType: "bool_true"},
-		{Key: "is_verified", Value: true, CheckType: "bool_true"},
-		{Key: "email_verified", Value: true, CheckType: "bool_true"},
-		{Key: "credit", Value: 999999, CheckType: "int_match"},
-		{Key: "balance", Value: 999999, CheckType: "int_match"},
-		{Key: "points", Value: 999999, CheckType: "int_match"},
-		{Key: "locked", Value: false, CheckType: "bool_false"}, // Note the inverse check
+		{Key: "verified", Value: true, CheckType: "bool_true", Severity: "Medium"},
+		{Key: "is_verified", Value: true, CheckType: "bool_true", Severity: "Medium"},
+		{Key: "email_verified", Value: true, CheckType: "bool_true", Severity: "Medium"},
+		{Key: "credit", Value: 999999, CheckType: "int_match", Severity: "High"},
+		{Key: "balance", Value: 999999, CheckType: "int_match", Severity: "High"},
+		{Key: "points", Value: 999999, CheckType: "int_match", Severity: "Medium"},
+		{Key: "locked", Value: false, CheckType: "bool_false", Severity: "Low"}, // Note the inverse check
 
 		// --- Nested Parameter Syntax (Common in frameworks like Rails, Node.js) ---
-		{Key: "user[is_admin]", Value: true, CheckType: "bool_true"},
-		{Key: "user[role]", Value: "admin", CheckType: "string_match"},
-		{Key: "profile[isAdmin]", Value: true, CheckType: "bool_true"},
-		{Key: "account[type]", Value: "premium", CheckType: "string_match"},
+		{Key: "user[is_admin]", Value: true, CheckType: "bool_true", Severity: "High"},
+		{Key: "user[role]", Value: "admin", CheckType: "string_match", Severity: "High"},
+		{Key: "profile[isAdmin]", Value: true, CheckType: "bool_true", Severity: "High"},
+		{Key: "account[type]", Value: "premium", CheckType: "string_match", Severity: "Medium"},
 	}
 }
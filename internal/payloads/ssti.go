@@ -11,6 +11,9 @@ type SSTIPayloadTest struct {
 	PayloadTemplate string // The template string to be injected into a parameter, e.g., "{{{CALC_RESULT}}}".
 	ExpectedPattern string // The regex pattern to search for in the response, e.g., "CALC_RESULT".
 	EngineName      string // The name of the template engine(s) targeted by this payload.
+	// Stacks are the technologies (fingerprint names) the engines run on; on a fingerprinted
+	// stack, its test cases are tried first.
+	Stacks []string
 }
 
 // SSTIPayloads contains a list of SSTI test cases for various template engines.
@@ -28,36 +31,43 @@ func init() {
 			PayloadTemplate: "{{%d*%d}}",
 			ExpectedPattern: "%d",
 			EngineName:      "Jinja2 / Twig / Nunjucks / Pebble",
+			Stacks:          []string{"Python", "PHP", "Node.js", "Java"},
 		},
 		{
 			PayloadTemplate: "${%d*%d}",
 			ExpectedPattern: "%d",
 			EngineName:      "FreeMarker / Velocity / Mako",
+			Stacks:          []string{"Java", "Python"},
 		},
 		{
 			PayloadTemplate: "<%%= %d*%d %%>",
 			ExpectedPattern: "%d",
 			EngineName:      "ERB (Ruby) / EJS (JavaScript)",
+			Stacks:          []string{"Ruby", "Node.js"},
 		},
 		{
 			PayloadTemplate: "#{%d*%d}",
 			ExpectedPattern: "%d",
 			EngineName:      "JavaServer Faces (JSF) / Pug (Jade)",
+			Stacks:          []string{"Java", "Node.js"},
 		},
 		{
 			PayloadTemplate: "*{%d*%d}",
 			ExpectedPattern: "%d",
 			EngineName:      "Thymeleaf",
+			Stacks:          []string{"Java"},
 		},
 		{
 			PayloadTemplate: "[[%d*%d]]",
 			ExpectedPattern: "%d",
 			EngineName:      "Thymeleaf (inline)",
+			Stacks:          []string{"Java"},
 		},
 		{
 			PayloadTemplate: "${(function(){return %d*%d})()}",
 			ExpectedPattern: "%d",
 			EngineName:      "JavaScript Template Literal",
+			Stacks:          []string{"Node.js"},
 		},
 
 		// --- Less Common / More Specific Engines ---
@@ -65,16 +75,19 @@ func init() {
 			PayloadTemplate: "@(%d*%d)",
 			ExpectedPattern: "%d",
 			EngineName:      "ASP.NET Razor",
+			Stacks:          []string{".NET"},
 		},
 		{
 			PayloadTemplate: "{math equation=\"%d*%d\"}",
 			ExpectedPattern: "%d",
 			EngineName:      "Smarty (PHP)",
+			Stacks:          []string{"PHP"},
 		},
 		{
 			PayloadTemplate: "<%%= %d*%d %%>", // Generic PHP, using different marker to avoid collision
 			ExpectedPattern: "%d",
 			EngineName:      "Generic PHP",
+			Stacks:          []string{"PHP"},
 		},
 		{
 			PayloadTemplate: "DURSGO${{%d*%d}}<%%={%d*%d}%%>[[(%d*%d)]]",
@@ -112,3 +125,51 @@ func GenerateSSTIPayload(testCase SSTIPayloadTest) (string, string) {
 
 	return payload, expectedOutput
 }
+
+// SSTIEngineProbe is an engine-specific follow-up payload used to fingerprint the template engine
+// once an arithmetic probe was evaluated. PayloadTemplate and ExpectedTemplate use the
+// placeholders {A} and {B} (random numbers), {TOKEN} (a random lowercase token) and, in
+// ExpectedTemplate only, {PRODUCT} (A*B), {REPEAT} (B repeated A times) and {TOKEN_UPPER}.
+type SSTIEngineProbe struct {
+	Engine           string
+	PayloadTemplate  string
+	ExpectedTemplate string
+}
+
+// SSTIEngineProbes fingerprints individual engines. Engines sharing a syntax are told apart by
+// engine-specific behavior, e.g., {{7*'7'}} renders 7777777 in Jinja2 but 49 in Twig.
+var SSTIEngineProbes = []SSTIEngineProbe{
+	{Engine: "Jinja2", PayloadTemplate: "{{{A}*'{B}'}}{TOKEN}", ExpectedTemplate: "{REPEAT}{TOKEN}"},
+	{Engine: "Twig", PayloadTemplate: "{{{A}*'{B}'}}{TOKEN}", ExpectedTemplate: "{PRODUCT}{TOKEN}"},
+	{Engine: "FreeMarker", PayloadTemplate: `${"{TOKEN}"?upper_case}`, ExpectedTemplate: "{TOKEN_UPPER}"},
+	{Engine: "Velocity", PayloadTemplate: "#set($d={A}*{B})${d}{TOKEN}", ExpectedTemplate: "{PRODUCT}{TOKEN}"},
+	{Engine: "Mako", PayloadTemplate: `${"{TOKEN}".upper()}`, ExpectedTemplate: "{TOKEN_UPPER}"},
+	{Engine: "ERB (Ruby)", PayloadTemplate: `<%= "{TOKEN}".upcase %>`, ExpectedTemplate: "{TOKEN_UPPER}"},
+	{Engine: "EJS (JavaScript)", PayloadTemplate: `<%= "{TOKEN}".toUpperCase() %>`, ExpectedTemplate: "{TOKEN_UPPER}"},
+	{Engine: "Smarty (PHP)", PayloadTemplate: `{"{TOKEN}"|upper}`, ExpectedTemplate: "{TOKEN_UPPER}"},
+	{Engine: "Thymeleaf", PayloadTemplate: "[[${'{TOKEN}'.toUpperCase()}]]", ExpectedTemplate: "{TOKEN_UPPER}"},
+}
+
+// GenerateSSTIEngineProbe fills in a probe's placeholders with fresh random values and returns
+// the payload and the output expected when the engine evaluates it.
+func GenerateSSTIEngineProbe(probe SSTIEngineProbe) (string, string) {
+	a := 11 + rand.Intn(9) // Number between 11 and 19
+	b := 2 + rand.Intn(8)  // Digit between 2 and 9
+	token := make([]byte, 8)
+	for i := range token {
+		token[i] = byte('a' + rand.Intn(26))
+	}
+
+	payload := strings.NewReplacer(
+		"{A}", fmt.Sprint(a),
+		"{B}", fmt.Sprint(b),
+		"{TOKEN}", string(token),
+	).Replace(probe.PayloadTemplate)
+	expected := strings.NewReplacer(
+		"{PRODUCT}", fmt.Sprint(a*b),
+		"{REPEAT}", strings.Repeat(fmt.Sprint(b), a),
+		"{TOKEN_UPPER}", strings.ToUpper(string(token)),
+		"{TOKEN}", string(token),
+	).Replace(probe.ExpectedTemplate)
+	return payload, expected
+}
@@ -0,0 +1,91 @@
+package payloads
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TakeoverFingerprint recognizes a hosting service whose unclaimed resources can be registered by
+// anyone, taking over every domain that still points to them.
+type TakeoverFingerprint struct {
+	// Service names the hosting service in findings (e.g., "GitHub Pages").
+	Service string
+	// CNAMEs are domain suffixes of the service's host names (e.g., "github.io"); a subdomain
+	// matches when any name of its CNAME chain ends with one of them.
+	CNAMEs []string
+	// Pattern is the regular expression matching the service's response for an unclaimed
+	// resource. Empty when NXDomain identifies it instead.
+	Pattern string
+	// NXDomain marks services whose unclaimed resources do not resolve at all (e.g., Azure), so
+	// a CNAME target that does not exist is the signature.
+	NXDomain bool
+	// Regex is the compiled Pattern.
+	Regex *regexp.Regexp
+}
+
+// MatchesCNAME reports whether name belongs to the service.
+func (f TakeoverFingerprint) MatchesCNAME(name string) bool {
+	name = strings.TrimSuffix(strings.ToLower(name), ".")
+	for _, suffix := range f.CNAMEs {
+		suffix = strings.TrimPrefix(strings.ToLower(suffix), ".")
+		if name == suffix || strings.HasSuffix(name, "."+suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// TakeoverFingerprints contains the services checked for subdomain takeover. Users can add their
+// own with AddTakeoverFingerprints.
+var TakeoverFingerprints []TakeoverFingerprint
+
+func init() {
+	builtin := []TakeoverFingerprint{
+		{Service: "GitHub Pages", CNAMEs: []string{"github.io"}, Pattern: `There isn't a GitHub Pages site here\.`},
+		{Service: "AWS S3", CNAMEs: []string{"amazonaws.com"}, Pattern: `<Code>NoSuchBucket</Code>`},
+		{Service: "Heroku", CNAMEs: []string{"herokuapp.com", "herokudns.com", "herokussl.com"}, Pattern: `(?i)<title>No such app</title>|herokucdn\.com/error-pages/no-such-app\.html`},
+		{Service: "Shopify", CNAMEs: []string{"myshopify.com"}, Pattern: `Sorry, this shop is currently unavailable\.`},
+		{Service: "Fastly", CNAMEs: []string{"fastly.net"}, Pattern: `Fastly error: unknown domain`},
+		{Service: "Pantheon", CNAMEs: []string{"pantheonsite.io"}, Pattern: `The gods are wise, but do not know of the site which you seek\.`},
+		{Service: "Bitbucket", CNAMEs: []string{"bitbucket.io"}, Pattern: `Repository not found`},
+		{Service: "Surge.sh", CNAMEs: []string{"surge.sh"}, Pattern: `project not found`},
+		{Service: "Tumblr", CNAMEs: []string{"domains.tumblr.com"}, Pattern: `Whatever you were looking for doesn't currently exist at this address\.`},
+		{Service: "Zendesk", CNAMEs: []string{"zendesk.com"}, Pattern: `Help Center Closed`},
+		{Service: "Microsoft Azure", CNAMEs: []string{"azurewebsites.net", "cloudapp.net", "cloudapp.azure.com", "trafficmanager.net", "blob.core.windows.net", "azureedge.net"}, NXDomain: true},
+	}
+	if err := AddTakeoverFingerprints(builtin); err != nil {
+		panic(err)
+	}
+}
+
+// AddTakeoverFingerprints compiles fingerprints and appends them to TakeoverFingerprints. Invalid
+// fingerprints are skipped and reported in the returned error; the valid ones are still added. It
+// must be called before scanning starts.
+func AddTakeoverFingerprints(fingerprints []TakeoverFingerprint) error {
+	var errs []error
+	for _, f := range fingerprints {
+		switch {
+		case f.Service == "":
+			errs = append(errs, fmt.Errorf("takeover fingerprint for %v has no service name", f.CNAMEs))
+			continue
+		case len(f.CNAMEs) == 0:
+			errs = append(errs, fmt.Errorf("takeover fingerprint %q has no CNAME suffixes", f.Service))
+			continue
+		case f.Pattern == "" && !f.NXDomain:
+			errs = append(errs, fmt.Errorf("takeover fingerprint %q needs a pattern or nxdomain", f.Service))
+			continue
+		}
+		if f.Pattern != "" {
+			re, err := regexp.Compile(f.Pattern)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid takeover fingerprint %q (%s): %w", f.Service, f.Pattern, err))
+				continue
+			}
+			f.Regex = re
+		}
+		TakeoverFingerprints = append(TakeoverFingerprints, f)
+	}
+	return errors.Join(errs...)
+}
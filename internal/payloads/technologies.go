@@ -0,0 +1,137 @@
+package payloads
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// TechnologyRule identifies a technology of the target stack from the first response of the
+// target and its favicon. The rule matches when any of its conditions does; the first capture
+// group of the matching regular expression, if any, is the version.
+type TechnologyRule struct {
+	// Name names the technology in the scan metadata and reports (e.g., "WordPress").
+	Name string
+	// Category groups technologies, e.g. "Web server", "Language", "Framework" or "CMS".
+	Category string
+	// Headers maps header names to regular expressions matching their value.
+	Headers map[string]string
+	// Cookies are regular expressions matching the names of cookies set by the target.
+	Cookies []string
+	// Body are regular expressions matching the HTML of the target's page.
+	Body []string
+	// Favicons are Shodan-style favicon hashes (MurmurHash3 of the base64-encoded icon).
+	Favicons []int32
+	// Implies names the technologies the technology runs on (WordPress implies PHP). A rule
+	// without conditions only gives the category of a technology other rules imply.
+	Implies []string
+
+	// HeaderRegexes, CookieRegexes and BodyRegexes are the compiled conditions; header names
+	// are canonicalized.
+	HeaderRegexes map[string]*regexp.Regexp
+	CookieRegexes []*regexp.Regexp
+	BodyRegexes   []*regexp.Regexp
+}
+
+// TechnologyRules contains the rules applied by technology fingerprinting. Users can add their
+// own with AddTechnologyRules.
+var TechnologyRules []TechnologyRule
+
+func init() {
+	builtin := []TechnologyRule{
+		// --- Web servers and CDNs ---
+		{Name: "nginx", Category: "Web server", Headers: map[string]string{"Server": `(?i)^nginx(?:/([\d.]+))?`}},
+		{Name: "Apache HTTP Server", Category: "Web server", Headers: map[string]string{"Server": `(?i)^apache(?:/([\d.]+))?(?:$|[\s(])`}},
+		{Name: "Microsoft IIS", Category: "Web server", Headers: map[string]string{"Server": `(?i)^Microsoft-IIS(?:/([\d.]+))?`}},
+		{Name: "LiteSpeed", Category: "Web server", Headers: map[string]string{"Server": `(?i)^litespeed`}},
+		{Name: "Caddy", Category: "Web server", Headers: map[string]string{"Server": `(?i)^caddy`}},
+		{Name: "Apache Tomcat", Category: "Web server", Headers: map[string]string{"Server": `(?i)^Apache-Coyote`}, Body: []string{`Apache Tomcat/([\d.]+)`}, Implies: []string{"Java"}},
+		{Name: "Jetty", Category: "Web server", Headers: map[string]string{"Server": `(?i)^jetty(?:\(([\w.-]+)\))?`}, Implies: []string{"Java"}},
+		{Name: "Gunicorn", Category: "Web server", Headers: map[string]string{"Server": `(?i)^gunicorn(?:/([\d.]+))?`}, Implies: []string{"Python"}},
+		{Name: "Uvicorn", Category: "Web server", Headers: map[string]string{"Server": `(?i)^uvicorn`}, Implies: []string{"Python"}},
+		{Name: "Werkzeug", Category: "Web server", Headers: map[string]string{"Server": `(?i)^werkzeug(?:/([\d.]+))?`}, Implies: []string{"Python"}},
+		{Name: "Cloudflare", Category: "CDN", Headers: map[string]string{"Server": `(?i)^cloudflare`, "CF-Ray": `.`}},
+
+		// --- Languages ---
+		{Name: "PHP", Category: "Language", Headers: map[string]string{"X-Powered-By": `(?i)\bphp(?:/([\d.]+))?`}, Cookies: []string{`^PHPSESSID$`}},
+		{Name: "Java", Category: "Language", Headers: map[string]string{"X-Powered-By": `(?i)\b(?:servlet|jsp)\b`}, Cookies: []string{`^JSESSIONID$`}},
+		{Name: "Python", Category: "Language", Headers: map[string]string{"Server": `(?i)\bpython(?:/([\d.]+))?`}},
+		{Name: "Ruby", Category: "Language", Headers: map[string]string{"X-Powered-By": `(?i)phusion passenger`}},
+		{Name: "Node.js", Category: "Language"},
+		{Name: ".NET", Category: "Language"},
+
+		// --- Frameworks ---
+		{Name: "ASP.NET", Category: "Framework", Headers: map[string]string{"X-Powered-By": `(?i)asp\.net`, "X-AspNet-Version": `([\d.]+)`}, Cookies: []string{`^ASP\.NET_SessionId$`, `^\.AspNetCore\.`}, Body: []string{`name="__VIEWSTATE"`}, Implies: []string{".NET"}},
+		{Name: "Laravel", Category: "Framework", Cookies: []string{`^laravel_session$`}, Implies: []string{"PHP"}},
+		{Name: "Symfony", Category: "Framework", Headers: map[string]string{"X-Debug-Token": `.`}, Cookies: []string{`^sf_redirect$`}, Implies: []string{"PHP"}},
+		{Name: "Django", Category: "Framework", Body: []string{`name=["']csrfmiddlewaretoken["']`}, Cookies: []string{`^django_language$`}, Implies: []string{"Python"}},
+		{Name: "Flask", Category: "Framework", Headers: map[string]string{"Server": `(?i)^werkzeug`}, Implies: []string{"Python"}},
+		{Name: "Ruby on Rails", Category: "Framework", Body: []string{`<meta name="csrf-param" content="authenticity_token"`}, Headers: map[string]string{"X-Runtime": `^[\d.]+$`}, Implies: []string{"Ruby"}},
+		{Name: "Express", Category: "Framework", Headers: map[string]string{"X-Powered-By": `(?i)^express`}, Cookies: []string{`^connect\.sid$`}, Implies: []string{"Node.js"}},
+		{Name: "Next.js", Category: "Framework", Headers: map[string]string{"X-Powered-By": `(?i)next\.js(?: ([\d.]+))?`}, Body: []string{`<script id="__NEXT_DATA__"`}, Implies: []string{"Node.js", "React"}},
+		{Name: "Spring Boot", Category: "Framework", Body: []string{`<h1>Whitelabel Error Page</h1>`}, Favicons: []int32{116323821}, Implies: []string{"Java"}},
+
+		// --- CMS and applications ---
+		{Name: "WordPress", Category: "CMS", Cookies: []string{`^wordpress_`, `^wp-settings-`}, Body: []string{`<meta name="generator" content="WordPress ?([\d.]+)?`, `/wp-content/`, `/wp-includes/`, `wp-emoji`}, Implies: []string{"PHP"}},
+		{Name: "Drupal", Category: "CMS", Headers: map[string]string{"X-Generator": `(?i)drupal(?: ([\d.]+))?`, "X-Drupal-Cache": `.`}, Body: []string{`<meta name="Generator" content="Drupal ?([\d.]+)?`, `Drupal\.settings`, `data-drupal-selector=`, `/sites/default/files/`}, Implies: []string{"PHP"}},
+		{Name: "Joomla", Category: "CMS", Body: []string{`<meta name="generator" content="Joomla!? ?([\d.]+)?`, `/media/jui/`, `/media/system/js/core\.js`}, Implies: []string{"PHP"}},
+		{Name: "Jenkins", Category: "CI/CD", Headers: map[string]string{"X-Jenkins": `([\d.]+)`}, Favicons: []int32{81586312}, Implies: []string{"Java"}},
+
+		// --- JavaScript libraries ---
+		{Name: "React", Category: "JavaScript framework", Body: []string{`data-reactroot`}},
+		{Name: "Angular", Category: "JavaScript framework", Body: []string{`ng-version="([\d.]+)"`}},
+		{Name: "Vue.js", Category: "JavaScript framework", Body: []string{`data-v-[0-9a-f]{8}`, `<div id="app" data-v-app`}},
+		{Name: "jQuery", Category: "JavaScript library", Body: []string{`jquery[.-]([\d.]+)(?:\.min)?\.js`}},
+	}
+	if err := AddTechnologyRules(builtin); err != nil {
+		panic(err)
+	}
+}
+
+// AddTechnologyRules compiles rules and appends them to TechnologyRules. Invalid rules are
+// skipped and reported in the returned error; the valid ones are still added. It must be called
+// before fingerprinting starts.
+func AddTechnologyRules(rules []TechnologyRule) error {
+	var errs []error
+RuleLoop:
+	for _, r := range rules {
+		if r.Name == "" {
+			errs = append(errs, errors.New("technology rule has no name"))
+			continue
+		}
+		r.HeaderRegexes = make(map[string]*regexp.Regexp, len(r.Headers))
+		for name, pattern := range r.Headers {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("invalid technology rule %q (header %s: %s): %w", r.Name, name, pattern, err))
+				continue RuleLoop
+			}
+			r.HeaderRegexes[http.CanonicalHeaderKey(name)] = re
+		}
+		var err error
+		if r.CookieRegexes, err = compileTechnologyPatterns(r.Cookies); err != nil {
+			errs = append(errs, fmt.Errorf("invalid technology rule %q (cookie): %w", r.Name, err))
+			continue
+		}
+		if r.BodyRegexes, err = compileTechnologyPatterns(r.Body); err != nil {
+			errs = append(errs, fmt.Errorf("invalid technology rule %q (body): %w", r.Name, err))
+			continue
+		}
+		TechnologyRules = append(TechnologyRules, r)
+	}
+	return errors.Join(errs...)
+}
+
+// compileTechnologyPatterns compiles the regular expressions of one kind of condition.
+func compileTechnologyPatterns(patterns []string) ([]*regexp.Regexp, error) {
+	regexes := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pattern, err)
+		}
+		regexes = append(regexes, re)
+	}
+	return regexes, nil
+}
@@ -0,0 +1,124 @@
+package payloads
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+)
+
+// WAFFingerprint recognizes the block or challenge page a web application firewall or bot
+// protection serves instead of the application's response.
+type WAFFingerprint struct {
+	// Name identifies the WAF in warnings and reports (e.g., "Cloudflare").
+	Name string
+	// Statuses are the status codes of the block page; empty matches any error status (4xx, 5xx).
+	Statuses []int
+	// Pattern is the regular expression matching the body of the block page.
+	Pattern string
+	// Headers maps header names to regular expressions matching their values (e.g.,
+	// "Cf-Mitigated": "challenge"). Every header must match.
+	Headers map[string]string
+	// Regex is the compiled Pattern; HeaderRegexes the compiled Headers.
+	Regex         *regexp.Regexp
+	HeaderRegexes map[string]*regexp.Regexp
+}
+
+// Matches reports whether a response with status, header and body is the WAF's block page.
+func (f WAFFingerprint) Matches(status int, header http.Header, body []byte) bool {
+	if len(f.Statuses) > 0 && !slices.Contains(f.Statuses, status) || len(f.Statuses) == 0 && status < 400 {
+		return false
+	}
+	for name, re := range f.HeaderRegexes {
+		if !re.MatchString(header.Get(name)) {
+			return false
+		}
+	}
+	return f.Regex == nil || f.Regex.Match(body)
+}
+
+// WAFFingerprints contains the block pages the HTTP client recognizes. Users can add their own
+// with AddWAFFingerprints.
+var WAFFingerprints []WAFFingerprint
+
+func init() {
+	blocked := []int{403, 406, 429, 503}
+	builtin := []WAFFingerprint{
+		{Name: "Cloudflare", Statuses: blocked, Pattern: `(?i)<title>Attention Required! \| Cloudflare</title>|Sorry, you have been blocked|cf-error-details|<title>Just a moment\.\.\.</title>`},
+		{Name: "Cloudflare", Statuses: blocked, Headers: map[string]string{"Cf-Mitigated": `(?i)challenge`}},
+		{Name: "Akamai", Statuses: []int{403}, Pattern: `(?is)<title>Access Denied</title>.*Reference&#32;&#35;|errors\.edgesuite\.net`},
+		{Name: "AWS WAF", Statuses: []int{403}, Pattern: `(?s)The request could not be satisfied\..*Request blocked`},
+		{Name: "ModSecurity", Statuses: []int{403, 406, 501}, Pattern: `(?i)This error was generated by Mod_Security|<title>ModSecurity Action</title>|Not Acceptable!.*mod_security`},
+		{Name: "Imperva Incapsula", Statuses: blocked, Pattern: `Incapsula incident ID|_Incapsula_Resource`},
+		{Name: "Sucuri", Statuses: blocked, Pattern: `Sucuri WebSite Firewall - Access Denied|sucuri\.net/privacy-policy`},
+		{Name: "F5 BIG-IP ASM", Statuses: []int{200, 403}, Pattern: `The requested URL was rejected\. Please consult with your administrator\.`},
+		{Name: "CAPTCHA challenge", Statuses: blocked, Pattern: `(?i)class="g-recaptcha"|class="h-captcha"|challenges\.cloudflare\.com/turnstile`},
+	}
+	if err := AddWAFFingerprints(builtin); err != nil {
+		panic(err)
+	}
+}
+
+// AddWAFFingerprints compiles fingerprints and appends them to WAFFingerprints. Invalid
+// fingerprints are skipped and reported in the returned error; the valid ones are still added. It
+// must be called before the first request is sent.
+func AddWAFFingerprints(fingerprints []WAFFingerprint) error {
+	var errs []error
+	for _, f := range fingerprints {
+		switch {
+		case f.Name == "":
+			errs = append(errs, fmt.Errorf("WAF fingerprint %q has no name", f.Pattern))
+			continue
+		case f.Pattern == "" && len(f.Headers) == 0:
+			errs = append(errs, fmt.Errorf("WAF fingerprint %q needs a pattern or headers", f.Name))
+			continue
+		}
+		var err error
+		if f.Pattern != "" {
+			if f.Regex, err = regexp.Compile(f.Pattern); err != nil {
+				errs = append(errs, fmt.Errorf("invalid WAF fingerprint %q (%s): %w", f.Name, f.Pattern, err))
+				continue
+			}
+		}
+		f.HeaderRegexes = make(map[string]*regexp.Regexp, len(f.Headers))
+		for name, pattern := range f.Headers {
+			re, headerErr := regexp.Compile(pattern)
+			if headerErr != nil {
+				err = fmt.Errorf("invalid WAF fingerprint %q header %s (%s): %w", f.Name, name, pattern, headerErr)
+				break
+			}
+			f.HeaderRegexes[name] = re
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		WAFFingerprints = append(WAFFingerprints, f)
+	}
+	return errors.Join(errs...)
+}
+
+// WAFStatus reports whether responses with status may be block pages, so that their bodies are
+// worth matching against WAFFingerprints.
+func WAFStatus(status int) bool {
+	if status >= 400 {
+		return true
+	}
+	for _, f := range WAFFingerprints {
+		if slices.Contains(f.Statuses, status) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchWAF returns the name of the WAF whose block page a response is, if any.
+func MatchWAF(status int, header http.Header, body []byte) (string, bool) {
+	for _, f := range WAFFingerprints {
+		if f.Matches(status, header, body) {
+			return f.Name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,52 @@
+package payloads
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchWAF(t *testing.T) {
+	name, ok := MatchWAF(403, http.Header{}, []byte("<html><title>Attention Required! | Cloudflare</title>"))
+	assert.True(t, ok)
+	assert.Equal(t, "Cloudflare", name)
+
+	name, ok = MatchWAF(403, http.Header{"Cf-Mitigated": {"challenge"}}, nil)
+	assert.True(t, ok)
+	assert.Equal(t, "Cloudflare", name)
+
+	_, ok = MatchWAF(200, http.Header{}, []byte("Sorry, you have been blocked"))
+	assert.False(t, ok, "Cloudflare block pages are not served with 200")
+	_, ok = MatchWAF(403, http.Header{}, []byte("<h1>Forbidden</h1>"))
+	assert.False(t, ok, "plain 403 pages are left to streak detection")
+
+	assert.True(t, WAFStatus(403))
+	assert.True(t, WAFStatus(200), "F5 BIG-IP ASM serves block pages with 200")
+	assert.False(t, WAFStatus(302))
+}
+
+func TestAddWAFFingerprints(t *testing.T) {
+	original := WAFFingerprints
+	t.Cleanup(func() { WAFFingerprints = original })
+
+	err := AddWAFFingerprints([]WAFFingerprint{
+		{Name: "Corporate WAF", Pattern: `Request ID: [0-9a-f]{8}`, Headers: map[string]string{"X-Waf": `^block$`}},
+		{Name: "Broken", Pattern: `(`},
+		{Name: "Broken header", Headers: map[string]string{"X-Waf": `(`}},
+		{Name: "Empty"},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Broken")
+	assert.Contains(t, err.Error(), "Empty")
+	require.Len(t, WAFFingerprints, len(original)+1)
+
+	name, ok := MatchWAF(406, http.Header{"X-Waf": {"block"}}, []byte("Request ID: deadbeef"))
+	assert.True(t, ok)
+	assert.Equal(t, "Corporate WAF", name)
+	_, ok = MatchWAF(406, http.Header{}, []byte("Request ID: deadbeef"))
+	assert.False(t, ok, "every header must match")
+	_, ok = MatchWAF(200, http.Header{"X-Waf": {"block"}}, []byte("Request ID: deadbeef"))
+	assert.False(t, ok, "without statuses only error responses match")
+}
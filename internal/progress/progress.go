@@ -0,0 +1,237 @@
+// Package progress renders the progress of a scan as a single status line on the terminal.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// refreshInterval is how often the status line is redrawn.
+const refreshInterval = 500 * time.Millisecond
+
+// Reporter tracks the work items of a scan, (scanner, request, parameter) triples, and renders
+// completed and total items, requests sent, findings, the request rate and the estimated time
+// left as a status line that is redrawn in place. Its methods may be called from several
+// goroutines; those of a nil Reporter do nothing.
+type Reporter struct {
+	mu       sync.Mutex // Guards out and everything written to the terminal.
+	out      io.Writer
+	requests func() int64 // Requests sent so far; nil shows none.
+
+	total     atomic.Int64
+	completed atomic.Int64
+	findings  atomic.Int64
+
+	started      time.Time
+	lastRequests int64
+	lastTime     time.Time
+	rate         float64 // Requests per second over the last refresh interval.
+	line         string  // The status line currently drawn; empty if none.
+	stop         chan struct{}
+	stopped      chan struct{}
+}
+
+// New returns a reporter drawing its status line on out. requests returns the number of requests
+// sent so far, e.g. httpclient.Client.RequestsSent.
+func New(out io.Writer, requests func() int64) *Reporter {
+	return &Reporter{out: out, requests: requests}
+}
+
+// IsTerminal reports whether f is a terminal, where a status line can be redrawn in place.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// AddWork adds items to the total number of work items.
+func (r *Reporter) AddWork(items int) {
+	if r != nil {
+		r.total.Add(int64(items))
+	}
+}
+
+// CompleteWork marks items as done.
+func (r *Reporter) CompleteWork(items int) {
+	if r != nil {
+		r.completed.Add(int64(items))
+	}
+}
+
+// AddFindings adds n to the number of findings so far.
+func (r *Reporter) AddFindings(n int) {
+	if r != nil {
+		r.findings.Add(int64(n))
+	}
+}
+
+// Start draws the status line and redraws it until Stop is called.
+func (r *Reporter) Start() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	if r.stop != nil {
+		r.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	r.started, r.lastTime, r.lastRequests = now, now, r.requestsSent()
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	r.stop, r.stopped = stop, stopped
+	r.mu.Unlock()
+
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				r.refresh(now)
+			}
+		}
+	}()
+}
+
+// Stop stops redrawing the status line and clears it.
+func (r *Reporter) Stop() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	stop, stopped := r.stop, r.stopped
+	r.stop = nil
+	r.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-stopped
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clear()
+}
+
+// refresh updates the request rate and redraws the status line.
+func (r *Reporter) refresh(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	requests := r.requestsSent()
+	if elapsed := now.Sub(r.lastTime).Seconds(); elapsed > 0 {
+		r.rate = float64(requests-r.lastRequests) / elapsed
+	}
+	r.lastRequests, r.lastTime = requests, now
+	r.clear()
+	r.line = r.status(now)
+	io.WriteString(r.out, r.line)
+}
+
+// clear erases the status line, leaving the cursor at the start of the line.
+func (r *Reporter) clear() {
+	if r.line != "" {
+		fmt.Fprintf(r.out, "\r%s\r", strings.Repeat(" ", len(r.line)))
+		r.line = ""
+	}
+}
+
+// Snapshot is the state of a scan shown on the status line.
+type Snapshot struct {
+	Completed int64   `json:"completed"`           // Work items done.
+	Total     int64   `json:"total"`               // Work items queued.
+	Percent   int     `json:"percent"`             // Completed as a percentage of Total.
+	Requests  int64   `json:"requests"`            // Requests sent so far.
+	Rate      float64 `json:"requests_per_second"` // Over the last refresh interval; 0 until started.
+	Findings  int64   `json:"findings"`
+	ETA       string  `json:"eta"` // Estimated time left, "--" if unknown.
+}
+
+// Snapshot returns the state of the scan as last drawn on the status line.
+func (r *Reporter) Snapshot() Snapshot {
+	if r == nil {
+		return Snapshot{ETA: "--"}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.snapshot(time.Now())
+}
+
+// snapshot returns the state of the scan at now. r.mu must be held.
+func (r *Reporter) snapshot(now time.Time) Snapshot {
+	s := Snapshot{
+		Completed: r.completed.Load(),
+		Total:     r.total.Load(),
+		Requests:  r.requestsSent(),
+		Rate:      r.rate,
+		Findings:  r.findings.Load(),
+	}
+	if s.Total > 0 {
+		s.Percent = int(s.Completed * 100 / s.Total)
+	}
+	s.ETA = "--"
+	if !r.started.IsZero() {
+		s.ETA = eta(now.Sub(r.started), s.Completed, s.Total)
+	}
+	return s
+}
+
+// status formats the status line at now, e.g.
+// "Scanning... 42% (1218/2900) | 15320 requests | 87.3 req/s | 3 findings | ETA 4m12s".
+func (r *Reporter) status(now time.Time) string {
+	s := r.snapshot(now)
+	line := fmt.Sprintf("Scanning... %d%% (%d/%d)", s.Percent, s.Completed, s.Total)
+	if r.requests != nil {
+		line += fmt.Sprintf(" | %d requests | %.1f req/s", s.Requests, s.Rate)
+	}
+	line += fmt.Sprintf(" | %d findings | ETA %s", s.Findings, s.ETA)
+	return line
+}
+
+// eta estimates the time left from the time elapsed for completed of total items.
+func eta(elapsed time.Duration, completed, total int64) string {
+	if completed <= 0 || completed >= total {
+		return "--"
+	}
+	left := time.Duration(float64(elapsed) / float64(completed) * float64(total-completed))
+	return left.Round(time.Second).String()
+}
+
+func (r *Reporter) requestsSent() int64 {
+	if r.requests == nil {
+		return 0
+	}
+	return r.requests()
+}
+
+// Writer returns a writer that writes to w without garbling the status line: the line is
+// cleared before each write and redrawn after it. Log output is routed through it while the
+// status line is shown (logger.SetOutput).
+func (r *Reporter) Writer(w io.Writer) io.Writer {
+	return &lineWriter{r: r, w: w}
+}
+
+// lineWriter is a writer returned by Reporter.Writer.
+type lineWriter struct {
+	r *Reporter
+	w io.Writer
+}
+
+func (lw *lineWriter) Write(p []byte) (int, error) {
+	lw.r.mu.Lock()
+	defer lw.r.mu.Unlock()
+	line := lw.r.line
+	lw.r.clear()
+	n, err := lw.w.Write(p)
+	if line != "" {
+		lw.r.line = line
+		io.WriteString(lw.r.out, line)
+	}
+	return n, err
+}
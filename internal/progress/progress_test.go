@@ -0,0 +1,61 @@
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatusLine(t *testing.T) {
+	var requests int64 = 100
+	r := New(&bytes.Buffer{}, func() int64 { return requests })
+	start := time.Now()
+	r.started, r.lastTime, r.lastRequests = start, start, requests
+	r.AddWork(200)
+	r.CompleteWork(50)
+	r.AddFindings(3)
+
+	requests = 150
+	r.refresh(start.Add(10 * time.Second))
+	// 50 of 200 items took 10s, so 150 items are left for 30s.
+	assert.Equal(t, "Scanning... 25% (50/200) | 150 requests | 5.0 req/s | 3 findings | ETA 30s", r.line)
+	assert.Equal(t, Snapshot{Completed: 50, Total: 200, Percent: 25, Requests: 150, Rate: 5, Findings: 3, ETA: "30s"}, r.snapshot(start.Add(10*time.Second)))
+
+	r.CompleteWork(150)
+	assert.Contains(t, r.status(start.Add(time.Minute)), "100% (200/200)")
+	assert.Contains(t, r.status(start.Add(time.Minute)), "ETA --")
+}
+
+func TestWriterKeepsStatusLineBelowOutput(t *testing.T) {
+	var out bytes.Buffer
+	r := New(&out, nil)
+	r.started = time.Now()
+	r.AddWork(2)
+	r.refresh(time.Now())
+	line := r.line
+
+	w := r.Writer(&out)
+	w.Write([]byte("[INFO] log line\n"))
+	// The status line is erased, the log line written and the status line drawn again.
+	clear := "\r" + strings.Repeat(" ", len(line)) + "\r"
+	assert.Equal(t, line+clear+"[INFO] log line\n"+line, out.String())
+
+	out.Reset()
+	r.Start()
+	r.Stop()
+	assert.True(t, strings.HasSuffix(out.String(), clear), "Stop clears the status line")
+	assert.Empty(t, r.line)
+}
+
+func TestNilReporter(t *testing.T) {
+	var r *Reporter
+	r.AddWork(1)
+	r.CompleteWork(1)
+	r.AddFindings(1)
+	r.Start()
+	r.Stop()
+	assert.Equal(t, Snapshot{ETA: "--"}, r.Snapshot())
+}
@@ -3,22 +3,64 @@ package renderer
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/chromedp/cdproto/network"
 	"github.com/chromedp/chromedp"
 )
 
+// ErrNoBrowser is returned by New when no Chrome or Chromium binary is installed.
+var ErrNoBrowser = errors.New("no Chrome or Chromium binary found")
+
+const (
+	// networkIdleTime is how long no request may be in flight for the network to count as idle.
+	networkIdleTime = 500 * time.Millisecond
+	// maxNetworkIdleWait bounds the wait for network idle after the load event, for pages that
+	// poll or keep connections open.
+	maxNetworkIdleWait = 10 * time.Second
+)
+
 // Renderer is a component that manages interactions with a headless browser (Chromedp).
 type Renderer struct {
 	allocCtx context.Context    // Context for the browser allocator.
 	cancel   context.CancelFunc // Function to cancel the allocator context and close the browser.
 }
 
-// New creates a new renderer instance and initializes the browser allocator.
+// Page is a page loaded in the browser.
+type Page struct {
+	HTML     string            // Outer HTML of the document after rendering.
+	Requests []CapturedRequest // XHR and fetch requests the page sent while loading.
+}
+
+// CapturedRequest is an XHR or fetch request sent by a page.
+type CapturedRequest struct {
+	Method      string
+	URL         string
+	ContentType string
+	Body        string
+}
+
+// New creates a new renderer instance and initializes the browser allocator. It returns
+// ErrNoBrowser when no browser binary is installed, so callers can fall back to static crawling.
 func New() (*Renderer, error) {
+	execPath := findBrowser()
+	if execPath == "" {
+		return nil, ErrNoBrowser
+	}
+
 	// Options to run Chrome/Chromium in an optimized headless mode.
 	// "no-sandbox" and "disable-dev-shm-usage" are important for stability in server/Docker environments.
 	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.ExecPath(execPath),
 		chromedp.Flag("headless", true),              // Run in headless mode (no UI).
 		chromedp.Flag("disable-gpu", true),           // Disable GPU hardware acceleration.
 		chromedp.Flag("no-sandbox", true),            // Disable sandbox for better compatibility.
@@ -36,6 +78,16 @@ func New() (*Renderer, error) {
 
 // GetRenderedHTML navigates to a URL, waits for JavaScript to execute, and returns the final HTML.
 func (r *Renderer) GetRenderedHTML(urlStr string, timeout time.Duration) (string, error) {
+	page, err := r.Render(urlStr, timeout)
+	if err != nil {
+		return "", err
+	}
+	return page.HTML, nil
+}
+
+// Render loads a URL, waits until the network is idle, and returns the rendered HTML together
+// with the XHR and fetch requests the page sent meanwhile.
+func (r *Renderer) Render(urlStr string, timeout time.Duration) (Page, error) {
 	// Create a new context with a timeout for each rendering task.
 	taskCtx, cancelTask := context.WithTimeout(r.allocCtx, timeout)
 	defer cancelTask() // Ensure the task context is cancelled.
@@ -44,18 +96,22 @@ func (r *Renderer) GetRenderedHTML(urlStr string, timeout time.Duration) (string
 	taskCtx, cancelTask = chromedp.NewContext(taskCtx)
 	defer cancelTask() // Ensure the tab context is cancelled.
 
-	var htmlContent string
+	tracker := newNetworkTracker()
+	chromedp.ListenTarget(taskCtx, tracker.handle)
+
+	var page Page
 	// Execute a sequence of actions within the browser.
 	err := chromedp.Run(taskCtx,
-		chromedp.Navigate(urlStr),     // 1. Navigate to the given URL.
-		chromedp.Sleep(2*time.Second), // 2. Wait for 2 seconds to allow SPAs to load and render.
-		chromedp.OuterHTML("html", &htmlContent), // 3. Get the outer HTML of the <html> element.
+		network.Enable(),                       // 1. Report network events to the tracker.
+		chromedp.Navigate(urlStr),              // 2. Navigate to the given URL and wait for the load event.
+		chromedp.ActionFunc(tracker.waitIdle),  // 3. Wait for requests sent by scripts to finish.
+		chromedp.OuterHTML("html", &page.HTML), // 4. Get the outer HTML of the <html> element.
 	)
-
 	if err != nil {
-		return "", err // Return error if any action fails.
+		return Page{}, err // Return error if any action fails.
 	}
-	return htmlContent, nil // Return the rendered HTML content.
+	page.Requests = tracker.captured()
+	return page, nil
 }
 
 // GetAllocatorContext returns the allocator context of the renderer.
@@ -67,3 +123,130 @@ func (r *Renderer) GetAllocatorContext() context.Context {
 func (r *Renderer) Close() {
 	r.cancel() // Call the cancel function to shut down the allocator.
 }
+
+// networkTracker follows the requests of a tab to detect network idle and capture XHR and fetch
+// requests.
+type networkTracker struct {
+	mu           sync.Mutex
+	inFlight     map[network.RequestID]bool
+	lastActivity time.Time
+	requests     []CapturedRequest
+}
+
+func newNetworkTracker() *networkTracker {
+	return &networkTracker{inFlight: make(map[network.RequestID]bool), lastActivity: time.Now()}
+}
+
+// handle is the chromedp.ListenTarget callback.
+func (t *networkTracker) handle(ev interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch ev := ev.(type) {
+	case *network.EventRequestWillBeSent:
+		t.inFlight[ev.RequestID] = true
+		t.lastActivity = time.Now()
+		if (ev.Type == network.ResourceTypeXHR || ev.Type == network.ResourceTypeFetch) && ev.Request != nil {
+			t.requests = append(t.requests, captureRequest(ev.Request))
+		}
+	case *network.EventLoadingFinished:
+		delete(t.inFlight, ev.RequestID)
+		t.lastActivity = time.Now()
+	case *network.EventLoadingFailed:
+		delete(t.inFlight, ev.RequestID)
+		t.lastActivity = time.Now()
+	}
+}
+
+// waitIdle returns once no request has been in flight for networkIdleTime, or after
+// maxNetworkIdleWait.
+func (t *networkTracker) waitIdle(ctx context.Context) error {
+	deadline := time.Now().Add(maxNetworkIdleWait)
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		t.mu.Lock()
+		idle := len(t.inFlight) == 0 && time.Since(t.lastActivity) >= networkIdleTime
+		t.mu.Unlock()
+		if idle || time.Now().After(deadline) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *networkTracker) captured() []CapturedRequest {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]CapturedRequest(nil), t.requests...)
+}
+
+// captureRequest copies the parts of a request needed to replay it.
+func captureRequest(req *network.Request) CapturedRequest {
+	captured := CapturedRequest{Method: req.Method, URL: req.URL}
+	for name, value := range req.Headers {
+		if strings.EqualFold(name, "Content-Type") {
+			captured.ContentType = fmt.Sprint(value)
+		}
+	}
+	var body strings.Builder
+	for _, entry := range req.PostDataEntries {
+		if entry == nil {
+			continue
+		}
+		// Entries are base64-encoded bytes.
+		if decoded, err := base64.StdEncoding.DecodeString(entry.Bytes); err == nil {
+			body.Write(decoded)
+		} else {
+			body.WriteString(entry.Bytes)
+		}
+	}
+	captured.Body = body.String()
+	return captured
+}
+
+// findBrowser returns the path of an installed Chrome or Chromium binary, or "" if there is none.
+// The locations are the ones chromedp searches by default.
+func findBrowser() string {
+	var locations []string
+	switch runtime.GOOS {
+	case "darwin":
+		locations = []string{
+			"/Applications/Chromium.app/Contents/MacOS/Chromium",
+			"/Applications/Google Chrome.app/Contents/MacOS/Google Chrome",
+		}
+	case "windows":
+		locations = []string{
+			"chrome",
+			"chrome.exe",
+			`C:\Program Files (x86)\Google\Chrome\Application\chrome.exe`,
+			`C:\Program Files\Google\Chrome\Application\chrome.exe`,
+			filepath.Join(os.Getenv("USERPROFILE"), `AppData\Local\Google\Chrome\Application\chrome.exe`),
+			filepath.Join(os.Getenv("USERPROFILE"), `AppData\Local\Chromium\Application\chrome.exe`),
+		}
+	default:
+		locations = []string{
+			"headless_shell",
+			"headless-shell",
+			"chromium",
+			"chromium-browser",
+			"google-chrome",
+			"google-chrome-stable",
+			"google-chrome-beta",
+			"google-chrome-unstable",
+			"/usr/bin/google-chrome",
+			"/usr/local/bin/chrome",
+			"/snap/bin/chromium",
+			"chrome",
+		}
+	}
+	for _, location := range locations {
+		if path, err := exec.LookPath(location); err == nil {
+			return path
+		}
+	}
+	return ""
+}
@@ -0,0 +1,176 @@
+package reporter
+
+import (
+	"Dursgo/internal/scanner"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Diff statuses of findings compared with a baseline scan (-baseline).
+const (
+	DiffNew      = "new"      // Not in the baseline.
+	DiffExisting = "existing" // In the baseline and in this scan.
+	DiffResolved = "resolved" // In the baseline but not in this scan.
+)
+
+// DiffSummary counts the findings of a scan by diff status.
+type DiffSummary struct {
+	Baseline string `json:"baseline"` // Path of the baseline report.
+	New      int    `json:"new"`
+	Existing int    `json:"existing"`
+	Resolved int    `json:"resolved"`
+}
+
+// Baseline holds the findings of a previous scan, keyed by fingerprint.
+type Baseline struct {
+	path     string
+	findings []Finding // One per fingerprint, in report order.
+}
+
+// LoadFindings reads the findings of a findings document (-output-format json) or a JSON
+// report (-output-json). Findings of reports written before fingerprints existed are
+// fingerprinted on load.
+func LoadFindings(path string) ([]Finding, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report struct {
+		SchemaVersion   string                        `json:"schema_version"`
+		Findings        []Finding                     `json:"findings"`
+		Vulnerabilities []scanner.VulnerabilityResult `json:"vulnerabilities"`
+	}
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	var findings []Finding
+	switch {
+	case report.SchemaVersion != "":
+		findings = report.Findings
+	case report.Vulnerabilities != nil:
+		for _, v := range report.Vulnerabilities {
+			findings = append(findings, NewFinding(v, FindingOptions{MaxEvidenceBytes: -1}))
+		}
+	default:
+		return nil, fmt.Errorf("%s is neither a findings document nor a JSON report", path)
+	}
+	for i, f := range findings {
+		if f.Fingerprint == "" {
+			findings[i].Fingerprint = Fingerprint(scanner.VulnerabilityResult{VulnerabilityType: f.Type, URL: f.URL, Parameter: f.Parameter, Location: f.Location})
+		}
+	}
+	return findings, nil
+}
+
+// LoadBaseline reads the findings of a previous scan with LoadFindings.
+func LoadBaseline(path string) (*Baseline, error) {
+	findings, err := LoadFindings(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Baseline{path: path}
+	seen := make(map[string]bool)
+	for _, f := range findings {
+		if !seen[f.Fingerprint] {
+			seen[f.Fingerprint] = true
+			b.findings = append(b.findings, f)
+		}
+	}
+	return b, nil
+}
+
+// ForTarget returns the findings of b that belong to target, for the scan of one target of a
+// scan of several targets. Findings not tagged with a target belong to every target.
+func (b *Baseline) ForTarget(target string) *Baseline {
+	scoped := &Baseline{path: b.path}
+	for _, f := range b.findings {
+		if f.Target == "" || f.Target == target {
+			scoped.findings = append(scoped.findings, f)
+		}
+	}
+	return scoped
+}
+
+// Len returns the number of distinct findings in the baseline.
+func (b *Baseline) Len() int {
+	return len(b.findings)
+}
+
+// Diff sets the DiffStatus of vulns (DiffNew or DiffExisting) by matching fingerprints with the
+// baseline, and returns the baseline findings absent from vulns, marked DiffResolved.
+func (b *Baseline) Diff(vulns []scanner.VulnerabilityResult) ([]Finding, DiffSummary) {
+	summary := DiffSummary{Baseline: b.path}
+	inBaseline := make(map[string]bool, len(b.findings))
+	for _, f := range b.findings {
+		inBaseline[f.Fingerprint] = true
+	}
+
+	current := make(map[string]bool, len(vulns))
+	for i := range vulns {
+		v := &vulns[i]
+		if v.Fingerprint == "" {
+			v.Fingerprint = Fingerprint(*v)
+		}
+		current[v.Fingerprint] = true
+		if inBaseline[v.Fingerprint] {
+			v.DiffStatus = DiffExisting
+			summary.Existing++
+		} else {
+			v.DiffStatus = DiffNew
+			summary.New++
+		}
+	}
+
+	var resolved []Finding
+	for _, f := range b.findings {
+		if !current[f.Fingerprint] {
+			f.DiffStatus = DiffResolved
+			resolved = append(resolved, f)
+		}
+	}
+	summary.Resolved = len(resolved)
+	return resolved, summary
+}
+
+// ParseSeverity validates a severity threshold ("critical", "High", ...) and returns it
+// normalized as by NormalizeSeverity.
+func ParseSeverity(severity string) (string, error) {
+	normalized := NormalizeSeverity(severity)
+	if severityClass(normalized) == "other" || strings.TrimSpace(severity) == "" {
+		return "", fmt.Errorf("unknown severity %q; use critical, high, medium, low or info", severity)
+	}
+	return normalized, nil
+}
+
+// SeverityAtLeast reports whether severity is at least as severe as threshold. Unknown
+// severities rank below Info.
+func SeverityAtLeast(severity, threshold string) bool {
+	return severityRank(NormalizeSeverity(severity)) <= severityRank(NormalizeSeverity(threshold))
+}
+
+// severityRank returns the position of a normalized severity in severityOrder, or
+// len(severityOrder) for unknown severities.
+func severityRank(severity string) int {
+	for i, s := range severityOrder {
+		if s == severity {
+			return i
+		}
+	}
+	return len(severityOrder)
+}
+
+// NewFindingsAtLeast returns the findings that are not DiffExisting (new, or not compared with a
+// baseline) and at least as severe as threshold.
+func NewFindingsAtLeast(vulns []scanner.VulnerabilityResult, threshold string) []scanner.VulnerabilityResult {
+	var matched []scanner.VulnerabilityResult
+	for _, v := range vulns {
+		if v.DiffStatus != DiffExisting && SeverityAtLeast(v.Severity, threshold) {
+			matched = append(matched, v)
+		}
+	}
+	return matched
+}
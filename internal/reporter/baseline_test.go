@@ -0,0 +1,74 @@
+package reporter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaselineDiff(t *testing.T) {
+	previous := []scanner.VulnerabilityResult{
+		{VulnerabilityType: "SQL Injection", URL: "https://example.com/item/1?id=1", Parameter: "id", Location: "query", Severity: "High"},
+		{VulnerabilityType: "Open Redirect", URL: "https://example.com/go?to=x", Parameter: "to", Location: "query", Severity: "Medium"},
+	}
+	path := filepath.Join(t.TempDir(), "previous.json")
+	require.NoError(t, WriteDocument(NewDocument(Metadata{}, Aggregate(previous, true), FindingOptions{}), path))
+
+	baseline, err := LoadBaseline(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, baseline.Len())
+
+	current := Aggregate([]scanner.VulnerabilityResult{
+		{VulnerabilityType: "SQL Injection", URL: "https://example.com/item/7?id=1", Parameter: "id", Location: "query", Severity: "High"},
+		{VulnerabilityType: "Reflected XSS", URL: "https://example.com/search?q=x", Parameter: "q", Location: "query", Severity: "High"},
+	}, true)
+	resolved, summary := baseline.Diff(current)
+
+	assert.Equal(t, DiffExisting, current[0].DiffStatus, "matched by fingerprint across path IDs")
+	assert.Equal(t, DiffNew, current[1].DiffStatus)
+	require.Len(t, resolved, 1)
+	assert.Equal(t, "Open Redirect", resolved[0].Type)
+	assert.Equal(t, DiffResolved, resolved[0].DiffStatus)
+	assert.Equal(t, DiffSummary{Baseline: path, New: 1, Existing: 1, Resolved: 1}, summary)
+
+	assert.Len(t, NewFindingsAtLeast(current, "High"), 1)
+	assert.Empty(t, NewFindingsAtLeast(current, "Critical"))
+}
+
+func TestLoadBaselineFromJSONReport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json")
+	report := NewReport("https://example.com", time.Now())
+	report.Vulnerabilities = []scanner.VulnerabilityResult{{VulnerabilityType: "XSS", URL: "https://example.com/?q=1", Parameter: "q"}}
+	require.NoError(t, WriteJSONReport(report, path))
+
+	baseline, err := LoadBaseline(path)
+	require.NoError(t, err)
+	_, summary := baseline.Diff([]scanner.VulnerabilityResult{{VulnerabilityType: "XSS", URL: "https://example.com/?q=2", Parameter: "q"}})
+	assert.Equal(t, 1, summary.Existing)
+
+	other := filepath.Join(t.TempDir(), "other.json")
+	require.NoError(t, os.WriteFile(other, []byte(`{"name": "not a report"}`), 0644))
+	_, err = LoadBaseline(other)
+	assert.ErrorContains(t, err, "neither a findings document nor a JSON report")
+}
+
+func TestSeverityThreshold(t *testing.T) {
+	threshold, err := ParseSeverity(" high")
+	require.NoError(t, err)
+	assert.Equal(t, "High", threshold)
+	_, err = ParseSeverity("severe")
+	assert.Error(t, err)
+	_, err = ParseSeverity("")
+	assert.Error(t, err)
+
+	assert.True(t, SeverityAtLeast("critical", threshold))
+	assert.True(t, SeverityAtLeast("High", threshold))
+	assert.False(t, SeverityAtLeast("Medium", threshold))
+	assert.False(t, SeverityAtLeast("Unrated", "Info"))
+}
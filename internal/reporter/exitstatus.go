@@ -0,0 +1,113 @@
+package reporter
+
+import (
+	"Dursgo/internal/scanner"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Exit statuses of dursgo, for CI pipelines. When several conditions apply, findings win over
+// scan errors, and scan errors over a partial scan.
+const (
+	ExitOK        = 0 // The scan completed and no finding met -fail-on or -fail-on-new.
+	ExitUsage     = 1 // Invalid flags or configuration; nothing was scanned.
+	ExitFindings  = 3 // Findings met the -fail-on or -fail-on-new threshold.
+	ExitScanError = 4 // The target was unreachable, the login or its verification failed or scanners failed.
+	ExitPartial   = 5 // The scan was interrupted, a host blocked it, request budgets ran out or the session was lost.
+)
+
+// FailOnNone is the -fail-on threshold that never fails on findings.
+const FailOnNone = "none"
+
+// ParseFailOn validates a -fail-on threshold: a severity as accepted by ParseSeverity, optionally
+// followed by a minimum confidence as accepted by scanner.ParseConfidence ("high:firm"), or
+// "none". It returns the normalized severity and confidence, both "" for none.
+func ParseFailOn(threshold string) (severity, confidence string, err error) {
+	if t := strings.ToLower(strings.TrimSpace(threshold)); t == "" || t == FailOnNone {
+		return "", "", nil
+	}
+	severityPart, confidencePart, hasConfidence := strings.Cut(threshold, ":")
+	if severity, err = ParseSeverity(severityPart); err != nil {
+		return "", "", fmt.Errorf("unknown severity %q; use none, critical, high, medium, low or info, optionally followed by :certain, :firm or :tentative", severityPart)
+	}
+	if hasConfidence {
+		if confidence, err = scanner.ParseConfidence(confidencePart); err != nil {
+			return "", "", err
+		}
+	}
+	return severity, confidence, nil
+}
+
+// ScanOutcome is what the exit status of a scan depends on.
+type ScanOutcome struct {
+	Findings         []scanner.VulnerabilityResult // Reported findings, with their diff status if compared.
+	FailOn           string                        // Normalized -fail-on severity; "" for none.
+	FailOnConfidence string                        // Minimum confidence of the findings counted by FailOn; "" for any.
+	FailOnNew        string                        // Normalized -fail-on-new severity; "" for none.
+	ScanErrors       map[string]int64              // Failed scanner/request pairs per scanner.
+	Interrupted      bool                          // The scan was stopped before completion (Ctrl-C).
+	// AbortedHosts are the hosts given up after blocking the scan.
+	AbortedHosts []string
+	// BudgetSkipped counts the requests refused because a request budget ran out.
+	BudgetSkipped int64
+	// SessionLost is why the authenticated session was lost and given up; "" if it was not.
+	SessionLost string
+}
+
+// ExitStatus returns the exit status of the scan and the condition that produced it.
+func (o ScanOutcome) ExitStatus() (int, string) {
+	if o.FailOn != "" {
+		if n := countAtLeast(o.Findings, o.FailOn, o.FailOnConfidence); n > 0 {
+			if o.FailOnConfidence != "" {
+				return ExitFindings, fmt.Sprintf("%d finding(s) of severity %s or higher with %s or higher confidence (-fail-on %s:%s)", n, o.FailOn, o.FailOnConfidence, strings.ToLower(o.FailOn), strings.ToLower(o.FailOnConfidence))
+			}
+			return ExitFindings, fmt.Sprintf("%d finding(s) of severity %s or higher (-fail-on %s)", n, o.FailOn, strings.ToLower(o.FailOn))
+		}
+	}
+	if o.FailOnNew != "" {
+		if n := len(NewFindingsAtLeast(o.Findings, o.FailOnNew)); n > 0 {
+			return ExitFindings, fmt.Sprintf("%d new finding(s) of severity %s or higher (-fail-on-new %s)", n, o.FailOnNew, strings.ToLower(o.FailOnNew))
+		}
+	}
+	if len(o.ScanErrors) > 0 {
+		names := make([]string, 0, len(o.ScanErrors))
+		for name, n := range o.ScanErrors {
+			names = append(names, fmt.Sprintf("%s (%d)", name, n))
+		}
+		sort.Strings(names)
+		return ExitScanError, "scanner errors: " + strings.Join(names, ", ")
+	}
+	var partial []string
+	if o.Interrupted {
+		partial = append(partial, "the scan was interrupted")
+	}
+	if len(o.AbortedHosts) > 0 {
+		partial = append(partial, "blocked by "+strings.Join(o.AbortedHosts, ", "))
+	}
+	if o.BudgetSkipped > 0 {
+		partial = append(partial, fmt.Sprintf("%d request(s) skipped by request budgets", o.BudgetSkipped))
+	}
+	if o.SessionLost != "" {
+		partial = append(partial, "authenticated session lost ("+o.SessionLost+")")
+	}
+	if len(partial) > 0 {
+		return ExitPartial, "partial scan: " + strings.Join(partial, "; ")
+	}
+	if o.FailOn == "" && o.FailOnNew == "" {
+		return ExitOK, "scan completed"
+	}
+	return ExitOK, "scan completed; no finding met the failure threshold"
+}
+
+// countAtLeast returns the number of findings at least as severe as threshold and at least as
+// certain as minConfidence.
+func countAtLeast(vulns []scanner.VulnerabilityResult, threshold, minConfidence string) int {
+	n := 0
+	for _, v := range vulns {
+		if SeverityAtLeast(v.Severity, threshold) && scanner.ConfidenceAtLeast(v.Confidence, minConfidence) {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,109 @@
+package reporter
+
+import (
+	"testing"
+
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFailOn(t *testing.T) {
+	for input, want := range map[string]string{"": "", "none": "", " NONE ": "", "high": "High", "Critical": "Critical"} {
+		got, confidence, err := ParseFailOn(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+		assert.Empty(t, confidence, input)
+	}
+	severity, confidence, err := ParseFailOn("high:Firm")
+	require.NoError(t, err)
+	assert.Equal(t, "High", severity)
+	assert.Equal(t, scanner.ConfidenceFirm, confidence)
+	_, _, err = ParseFailOn("severe")
+	assert.ErrorContains(t, err, "use none, critical")
+	_, _, err = ParseFailOn("high:sure")
+	assert.ErrorContains(t, err, "use certain, firm or tentative")
+}
+
+func TestExitStatus(t *testing.T) {
+	findings := []scanner.VulnerabilityResult{
+		{Severity: "Medium"},
+		{Severity: "High", DiffStatus: DiffExisting, Confidence: scanner.ConfidenceTentative},
+	}
+	tests := []struct {
+		name       string
+		outcome    ScanOutcome
+		wantStatus int
+		wantReason string
+	}{
+		{
+			name:       "No threshold",
+			outcome:    ScanOutcome{Findings: findings},
+			wantStatus: ExitOK,
+			wantReason: "scan completed",
+		},
+		{
+			name:       "Findings below the threshold",
+			outcome:    ScanOutcome{Findings: findings, FailOn: "Critical"},
+			wantStatus: ExitOK,
+			wantReason: "scan completed; no finding met the failure threshold",
+		},
+		{
+			name:       "Findings at the threshold",
+			outcome:    ScanOutcome{Findings: findings, FailOn: "Medium"},
+			wantStatus: ExitFindings,
+			wantReason: "2 finding(s) of severity Medium or higher (-fail-on medium)",
+		},
+		{
+			name:       "Findings at the threshold with the minimum confidence",
+			outcome:    ScanOutcome{Findings: findings, FailOn: "Medium", FailOnConfidence: scanner.ConfidenceFirm},
+			wantStatus: ExitFindings,
+			wantReason: "1 finding(s) of severity Medium or higher with Firm or higher confidence (-fail-on medium:firm)",
+		},
+		{
+			name:       "Only tentative findings at the threshold",
+			outcome:    ScanOutcome{Findings: findings, FailOn: "High", FailOnConfidence: scanner.ConfidenceFirm},
+			wantStatus: ExitOK,
+			wantReason: "scan completed; no finding met the failure threshold",
+		},
+		{
+			name:       "Only existing findings at the new threshold",
+			outcome:    ScanOutcome{Findings: findings, FailOnNew: "High"},
+			wantStatus: ExitOK,
+			wantReason: "scan completed; no finding met the failure threshold",
+		},
+		{
+			name:       "Findings win over errors and a partial scan",
+			outcome:    ScanOutcome{Findings: findings, FailOnNew: "Medium", ScanErrors: map[string]int64{"sqli": 1}, Interrupted: true},
+			wantStatus: ExitFindings,
+			wantReason: "1 new finding(s) of severity Medium or higher (-fail-on-new medium)",
+		},
+		{
+			name:       "Scanner errors win over a partial scan",
+			outcome:    ScanOutcome{ScanErrors: map[string]int64{"xss": 2, "sqli": 1}, BudgetSkipped: 4},
+			wantStatus: ExitScanError,
+			wantReason: "scanner errors: sqli (1), xss (2)",
+		},
+		{
+			name:       "Partial scan",
+			outcome:    ScanOutcome{Interrupted: true, AbortedHosts: []string{"example.com"}, BudgetSkipped: 4},
+			wantStatus: ExitPartial,
+			wantReason: "partial scan: the scan was interrupted; blocked by example.com; 4 request(s) skipped by request budgets",
+		},
+		{
+			name:       "Lost session",
+			outcome:    ScanOutcome{SessionLost: "/account answered 302 Found; re-login failed"},
+			wantStatus: ExitPartial,
+			wantReason: "partial scan: authenticated session lost (/account answered 302 Found; re-login failed)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, reason := tt.outcome.ExitStatus()
+			assert.Equal(t, tt.wantStatus, status)
+			assert.Equal(t, tt.wantReason, reason)
+		})
+	}
+}
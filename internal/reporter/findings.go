@@ -0,0 +1,482 @@
+package reporter
+
+import (
+	"Dursgo/internal/control"
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/fingerprint"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/metrics"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// SchemaVersion is the version of the findings schema (Document and Finding). It changes only
+// when fields are renamed or removed; new fields may be added within a version.
+const SchemaVersion = "1.0"
+
+// DefaultMaxEvidenceBytes is the size Finding.Evidence is truncated to when
+// FindingOptions.MaxEvidenceBytes is zero.
+const DefaultMaxEvidenceBytes = 4096
+
+// Output formats of the findings file (-output-format).
+const (
+	FormatText  = "text"  // Log lines only; no findings file.
+	FormatJSON  = "json"  // One Document written when the scan ends.
+	FormatJSONL = "jsonl" // One Finding per line, written as findings are found.
+	FormatHTML  = "html"  // Self-contained HTML report written when the scan ends.
+)
+
+// Document is the findings file written with -output-format json.
+type Document struct {
+	SchemaVersion string    `json:"schema_version"` // SchemaVersion of this build.
+	Metadata      Metadata  `json:"metadata"`
+	Findings      []Finding `json:"findings"` // Deduplicated findings, in the order they were reported.
+	// Resolved are the findings of the baseline scan absent from this one (-baseline), or the
+	// re-tested findings found remediated or gone (-retest).
+	Resolved []Finding `json:"resolved,omitempty"`
+	// Suppressed are the findings matched by a suppression rule (-suppressions), each with the
+	// rule in SuppressedBy. They are not counted in FindingsTotal.
+	Suppressed []Finding `json:"suppressed,omitempty"`
+}
+
+// Metadata describes the scan a Document reports on.
+type Metadata struct {
+	Tool              string           `json:"tool"`                // Always "dursgo".
+	ToolVersion       string           `json:"tool_version"`        // Version of the Dursgo build.
+	Target            string           `json:"target"`              // Target URL of the scan.
+	StartTime         time.Time        `json:"start_time"`          // RFC 3339.
+	EndTime           time.Time        `json:"end_time"`            // RFC 3339.
+	DurationSeconds   float64          `json:"duration_seconds"`    // EndTime - StartTime.
+	Interrupted       bool             `json:"interrupted"`         // The scan was stopped before completion (Ctrl-C).
+	Scanners          []ScannerInfo    `json:"scanners"`            // Scanners that ran, in scan order.
+	Scope             *ScopeInfo       `json:"scope,omitempty"`     // Scope of the crawl and the scanners.
+	Policy            *PolicyInfo      `json:"policy,omitempty"`    // Effective scan policy (-policy) after flag overrides.
+	URLsDiscovered    int              `json:"urls_discovered"`     // Unique URLs found while crawling.
+	RequestsScanned   int              `json:"requests_scanned"`    // Parameterized requests handed to the scanners.
+	RequestsSent      int64            `json:"requests_sent"`       // HTTP requests sent by all scanners (sum of RequestsByScanner).
+	RequestsByScanner map[string]int64 `json:"requests_by_scanner"` // HTTP requests sent per scanner, keyed by scanner display name.
+	FindingsTotal     int              `json:"findings_total"`      // len(Document.Findings).
+	Baseline          *DiffSummary     `json:"baseline,omitempty"`  // Comparison with the baseline scan (-baseline).
+	Retest            *RetestSummary   `json:"retest,omitempty"`    // Outcome of the re-tested findings (-retest).
+	// Retries counts the retries of transient failures (timeouts, connection resets, 429, 502,
+	// 503, 504); failed requests were skipped.
+	Retries httpclient.RetryStats `json:"retries"`
+	// Authentication tells whether the scan ran authenticated and how its session held up:
+	// whether it was verified, and how often it was re-established or given up.
+	Authentication AuthInfo `json:"authentication"`
+	// BlockedHosts are the hosts that blocked the scan with a WAF or rate limiting; their
+	// results are incomplete.
+	BlockedHosts []httpclient.BlockedHost `json:"blocked_hosts,omitempty"`
+	// PayloadFiles are the payload files that replaced or extended built-in payloads
+	// (payload_files and payload_sets in config.yaml).
+	PayloadFiles []payloads.PayloadFile `json:"payload_files,omitempty"`
+	// Targets are the results per target of a scan of several targets; Target is empty then.
+	Targets []TargetInfo `json:"targets,omitempty"`
+	// StoredContent is the content the scan created on the target by submitting markers and
+	// payloads to writable parameters (stored XSS), with the pages showing it, for its owners
+	// to remove.
+	StoredContent []scanner.StoredContent `json:"stored_content,omitempty"`
+	// Technologies is the target stack identified by fingerprinting.
+	Technologies fingerprint.Technologies `json:"technologies,omitempty"`
+	// SkipRules are the effective skip rules (built-in and skip_rules in config.yaml), with the
+	// number of tests each one skipped, so audits can tell what was not tested.
+	SkipRules []scanner.SkipRule `json:"skip_rules,omitempty"`
+	// Suppressions are the rules of the suppression file (-suppressions), with the number of
+	// findings each one suppressed.
+	Suppressions []SuppressionRule `json:"suppressions,omitempty"`
+	// ControlActions are the actions taken on the scan through its control interface
+	// (-control), e.g. pauses and skipped hosts, with their time.
+	ControlActions []control.Action `json:"control_actions,omitempty"`
+	// InertParams are the parameters the pre-flight of -skip-inert-params found to have no
+	// effect on their response, and the parameter tests skipped because of them.
+	InertParams *scanner.InertSummary `json:"inert_params,omitempty"`
+	// Coverage counts the scanner/parameter tests that ran, were skipped (per reason) and
+	// failed; Entries lists every test with -coverage.
+	Coverage *scanner.CoverageSummary `json:"coverage,omitempty"`
+	// Statistics are the wall-clock time of each phase of the scan, the requests, response
+	// times, test time and findings of each scanner, the response times of each host and the
+	// slowest URLs.
+	Statistics *metrics.Summary `json:"statistics,omitempty"`
+}
+
+// ScannerInfo identifies a scanner that ran and the options it ran with.
+type ScannerInfo struct {
+	Name    string                 `json:"name"`              // Module name, as selected with -s.
+	Version string                 `json:"version"`           // Version of the module's checks.
+	Options map[string]interface{} `json:"options,omitempty"` // Resolved options (scanners.<name> in config.yaml).
+}
+
+// ScopeInfo describes the scope of a scan (scope in config.yaml).
+type ScopeInfo struct {
+	Subdomains      string   `json:"subdomains"`                 // "same-host", "same-domain" or "allowlist".
+	AllowedHosts    []string `json:"allowed_hosts,omitempty"`    // Extra hosts of "allowlist".
+	IncludePatterns []string `json:"include_patterns,omitempty"` // URL regexes one of which must match.
+	ExcludePatterns []string `json:"exclude_patterns,omitempty"` // URL regexes that are never requested.
+	ExcludedURLs    int      `json:"excluded_urls"`              // URLs skipped as out of scope.
+}
+
+// AuthInfo describes the authentication of a scan (authentication in config.yaml) and the
+// verification of its session.
+type AuthInfo struct {
+	Authenticated bool   `json:"authenticated"`    // Requests carried credentials: a login session, a static cookie or headers.
+	Method        string `json:"method,omitempty"` // "login" or "static".
+	httpclient.SessionStats
+}
+
+// PolicyInfo is the effective scan policy: the policy selected with -policy (or policy in
+// config.yaml) and the values the scan actually ran with, after flags overrode it.
+type PolicyInfo struct {
+	Name                string   `json:"name"`                   // "quick", "balanced", "thorough" or "custom" (no policy).
+	Scanners            []string `json:"scanners"`               // Scanners that ran, in scan order.
+	PayloadTier         string   `json:"payload_tier"`           // "minimal", "standard" or "full".
+	Evasion             string   `json:"evasion,omitempty"`      // Evasion level or transformations (-evasion); empty for none.
+	TimeConfirmations   int      `json:"time_confirmations"`     // Delays a time-based finding is confirmed with.
+	TimeDelay           int      `json:"time_delay"`             // Base delay of time-based payloads in seconds (0 = no time-based scanner).
+	MaxRequestsPerParam int      `json:"max_requests_per_param"` // Request budget per parameter (0 = unlimited).
+	MaxDepth            int      `json:"max_depth"`              // Crawl depth.
+	OAST                bool     `json:"oast"`                   // Out-of-band tests enabled.
+	InjectHeaders       bool     `json:"inject_headers"`         // Header and cookie injection points tested.
+	PoCExtraction       bool     `json:"poc_extraction"`         // Confirmed blind SQL injections exploited to read a proof value.
+	Overrides           []string `json:"overrides,omitempty"`    // Flags that overrode values of the policy.
+}
+
+// Finding is one vulnerability in the findings schema.
+type Finding struct {
+	ID                   string     `json:"id"`                               // Hash of Fingerprint and URL, unique within the document.
+	Fingerprint          string     `json:"fingerprint"`                      // Hash of type, host, path template, parameter and location; equal across URLs and scans.
+	Type                 string     `json:"type"`                             // Vulnerability type, e.g. "SQL Injection".
+	Severity             string     `json:"severity,omitempty"`               // "critical", "high", "medium", "low" or "info".
+	Confidence           string     `json:"confidence,omitempty"`             // "certain", "firm" or "tentative".
+	URL                  string     `json:"url"`                              // URL the vulnerability was found at (the first one, when collapsed).
+	AffectedURLs         []string   `json:"affected_urls,omitempty"`          // Every URL with the same fingerprint (json and html formats).
+	Occurrences          int        `json:"occurrences,omitempty"`            // len(AffectedURLs).
+	Parameter            string     `json:"parameter,omitempty"`              // Vulnerable parameter, header or cookie.
+	Location             string     `json:"location,omitempty"`               // Where Parameter is sent, e.g. "query", "body", "header".
+	Payload              string     `json:"payload,omitempty"`                // Payload or probe that triggered the finding.
+	Details              string     `json:"details"`                          // Human-readable description.
+	Evidence             string     `json:"evidence,omitempty"`               // Supporting evidence, truncated to the configured size.
+	EvidenceTruncated    bool       `json:"evidence_truncated,omitempty"`     // Evidence was truncated.
+	Remediation          string     `json:"remediation,omitempty"`            // Suggested fix.
+	Scanner              string     `json:"scanner,omitempty"`                // Display name of the scanner that found it.
+	CVE                  string     `json:"cve,omitempty"`                    // Related CVE ID.
+	CWE                  string     `json:"cwe,omitempty"`                    // CWE ID of the weakness, e.g. "CWE-89".
+	CVSSVector           string     `json:"cvss_vector,omitempty"`            // CVSS v3.1 base vector.
+	CVSSScore            float64    `json:"cvss_score"`                       // CVSS v3.1 base score (0.0-10.0).
+	RawRequest           string     `json:"raw_request,omitempty"`            // Exact request sent, unless raw dumps are excluded.
+	RawResponse          string     `json:"raw_response,omitempty"`           // Response received, unless raw dumps are excluded.
+	RawResponseBase64    bool       `json:"raw_response_base64,omitempty"`    // RawResponse is base64-encoded (binary body).
+	RawResponseTruncated bool       `json:"raw_response_truncated,omitempty"` // RawResponse was truncated.
+	FoundAt              *time.Time `json:"found_at,omitempty"`               // When the finding was streamed (jsonl format only).
+	DiffStatus           string     `json:"diff_status,omitempty"`            // "new", "existing" or "resolved" compared with the baseline scan.
+	Target               string     `json:"target,omitempty"`                 // Target the finding belongs to, in a scan of several targets.
+	Technologies         []string   `json:"technologies,omitempty"`           // Technologies of the target (jsonl format only, which has no metadata).
+	SuppressedBy         string     `json:"suppressed_by,omitempty"`          // Suppression rule that matched the finding (-suppressions).
+	OverriddenBy         string     `json:"overridden_by,omitempty"`          // Severity override applied to the finding (severity_overrides).
+	OriginalSeverity     string     `json:"original_severity,omitempty"`      // Severity reported by the scanner, when overridden.
+	ActiveExploitation   string     `json:"active_exploitation,omitempty"`    // How the finding was exploited to prove it (-poc-extraction).
+	Transformation       string     `json:"transformation,omitempty"`         // Evasion transformation of the payload (-evasion).
+	RetestStatus         string     `json:"retest_status,omitempty"`          // "still_vulnerable", "remediated", "endpoint_gone" or "not_retested" (-retest).
+	RetestReason         string     `json:"retest_reason,omitempty"`          // Why the finding was not re-tested.
+	// Reproduction holds the requests and the detection check "dursgo verify" re-runs. Its
+	// requests carry no session credentials, so it is kept when raw dumps are excluded.
+	Reproduction *scanner.Reproduction `json:"reproduction,omitempty"`
+}
+
+// FindingOptions controls how findings are serialized.
+type FindingOptions struct {
+	// MaxEvidenceBytes is the size Evidence is truncated to. Zero uses DefaultMaxEvidenceBytes;
+	// a negative value keeps it whole.
+	MaxEvidenceBytes int
+	// ExcludeRaw drops the raw request and response dumps.
+	ExcludeRaw bool
+	// MinCVSSScore leaves findings with a lower CVSS score out of the findings file.
+	MinCVSSScore float64
+	// MinConfidence leaves less certain findings out of the findings file (see
+	// scanner.ConfidenceAtLeast); empty keeps every finding.
+	MinConfidence string
+	// NoCollapse keeps findings with the same fingerprint at different URLs apart in the
+	// JSONLWriter; by default only the first URL is written.
+	NoCollapse bool
+	// Technologies are the names of the technologies of the target, repeated on each finding of
+	// the JSONLWriter since the jsonl format has no metadata.
+	Technologies []string
+}
+
+// Fingerprint identifies a vulnerability across URLs and scans: a hash of its type, host, path
+// template (identifier segments replaced as in crawler.PathTemplate, so /product/1 and
+// /product/2 share one), parameter and parameter location.
+func Fingerprint(v scanner.VulnerabilityResult) string {
+	host, path := "", v.URL
+	if parsedURL, err := url.Parse(v.URL); err == nil {
+		host, path = strings.ToLower(parsedURL.Host), crawler.PathTemplate(parsedURL.Path)
+	}
+	return shortHash(strings.Join([]string{v.VulnerabilityType, host, path, v.Parameter, v.Location}, "|"))
+}
+
+// shortHash returns the hex encoding of the first 8 bytes of the SHA-256 of s.
+func shortHash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:8])
+}
+
+// findingKey is the deduplication key of a finding: its fingerprint, plus its URL unless
+// findings are collapsed across URLs.
+func findingKey(v scanner.VulnerabilityResult, collapse bool) string {
+	fingerprint := v.Fingerprint
+	if fingerprint == "" {
+		fingerprint = Fingerprint(v)
+	}
+	if collapse {
+		return fingerprint
+	}
+	return fingerprint + "|" + v.URL
+}
+
+// Aggregate sets the fingerprint of the findings and removes duplicates. With collapse, the
+// findings sharing a fingerprint become the first of them, listing every URL it was found at in
+// AffectedURLs; without it, only findings at the same URL are merged. The order of first
+// occurrence is kept.
+func Aggregate(vulns []scanner.VulnerabilityResult, collapse bool) []scanner.VulnerabilityResult {
+	var aggregated []scanner.VulnerabilityResult
+	index := make(map[string]int)
+	for _, v := range vulns {
+		v.Fingerprint = Fingerprint(v)
+		key := findingKey(v, collapse)
+		i, seen := index[key]
+		if !seen {
+			index[key] = len(aggregated)
+			v.AffectedURLs = []string{v.URL}
+			v.Occurrences = 1
+			aggregated = append(aggregated, v)
+			continue
+		}
+		first := &aggregated[i]
+		if !containsString(first.AffectedURLs, v.URL) {
+			first.AffectedURLs = append(first.AffectedURLs, v.URL)
+			first.Occurrences = len(first.AffectedURLs)
+		}
+	}
+	return aggregated
+}
+
+// containsString reports whether list contains s.
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// NewFinding converts a scanner result to the findings schema.
+func NewFinding(v scanner.VulnerabilityResult, opts FindingOptions) Finding {
+	fingerprint := v.Fingerprint
+	if fingerprint == "" {
+		fingerprint = Fingerprint(v)
+	}
+	f := Finding{
+		ID:                   shortHash(fingerprint + "|" + v.URL),
+		Fingerprint:          fingerprint,
+		Type:                 v.VulnerabilityType,
+		Severity:             v.Severity,
+		Confidence:           strings.ToLower(v.Confidence),
+		URL:                  v.URL,
+		AffectedURLs:         v.AffectedURLs,
+		Occurrences:          v.Occurrences,
+		Parameter:            v.Parameter,
+		Location:             v.Location,
+		Payload:              v.Payload,
+		Details:              v.Details,
+		Remediation:          v.Remediation,
+		Scanner:              v.ScannerName,
+		CVE:                  v.CVE,
+		CWE:                  v.CWE,
+		CVSSVector:           v.CVSSVector,
+		CVSSScore:            v.CVSSScore,
+		DiffStatus:           v.DiffStatus,
+		SuppressedBy:         v.SuppressedBy,
+		OverriddenBy:         v.OverriddenBy,
+		OriginalSeverity:     v.OriginalSeverity,
+		ActiveExploitation:   v.ActiveExploitation,
+		Transformation:       v.Transformation,
+		RawRequest:           v.RawRequest,
+		RawResponse:          v.RawResponse,
+		RawResponseBase64:    v.RawResponseBase64,
+		RawResponseTruncated: v.RawResponseTruncated,
+		Reproduction:         v.Reproduction,
+	}
+	f.Evidence, f.EvidenceTruncated = truncate(v.Evidence, opts.MaxEvidenceBytes)
+	if opts.ExcludeRaw {
+		f.RawRequest, f.RawResponse = "", ""
+		f.RawResponseBase64, f.RawResponseTruncated = false, false
+	}
+	return f
+}
+
+// truncate cuts s to maxBytes (DefaultMaxEvidenceBytes when zero, unlimited when negative)
+// without splitting a UTF-8 character.
+func truncate(s string, maxBytes int) (string, bool) {
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxEvidenceBytes
+	}
+	if maxBytes < 0 || len(s) <= maxBytes {
+		return s, false
+	}
+	cut := maxBytes
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut], true
+}
+
+// FilterByCVSS returns the findings whose CVSS score is at least minScore, in their order.
+func FilterByCVSS(vulns []scanner.VulnerabilityResult, minScore float64) []scanner.VulnerabilityResult {
+	if minScore <= 0 {
+		return vulns
+	}
+	var kept []scanner.VulnerabilityResult
+	for _, v := range vulns {
+		if v.CVSSScore >= minScore {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// FilterByConfidence returns the findings at least as certain as minConfidence, in their order.
+// An empty minConfidence keeps every finding.
+func FilterByConfidence(vulns []scanner.VulnerabilityResult, minConfidence string) []scanner.VulnerabilityResult {
+	if minConfidence == "" {
+		return vulns
+	}
+	var kept []scanner.VulnerabilityResult
+	for _, v := range vulns {
+		if scanner.ConfidenceAtLeast(v.Confidence, minConfidence) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// SortByCVSS orders findings by CVSS score, highest first. Findings with equal scores keep
+// their order.
+func SortByCVSS(vulns []scanner.VulnerabilityResult) {
+	sort.SliceStable(vulns, func(i, j int) bool { return vulns[i].CVSSScore > vulns[j].CVSSScore })
+}
+
+// NewDocument builds the findings document of a scan from its deduplicated results. The Tool,
+// DurationSeconds, RequestsSent and FindingsTotal fields of metadata are computed.
+func NewDocument(metadata Metadata, vulns []scanner.VulnerabilityResult, opts FindingOptions) *Document {
+	vulns = FilterByConfidence(FilterByCVSS(vulns, opts.MinCVSSScore), opts.MinConfidence)
+	doc := &Document{SchemaVersion: SchemaVersion, Metadata: metadata, Findings: make([]Finding, 0, len(vulns))}
+	for _, v := range vulns {
+		doc.Findings = append(doc.Findings, NewFinding(v, opts))
+	}
+	doc.Metadata.Tool = "dursgo"
+	doc.Metadata.DurationSeconds = metadata.EndTime.Sub(metadata.StartTime).Round(time.Millisecond).Seconds()
+	doc.Metadata.FindingsTotal = len(doc.Findings)
+	if doc.Metadata.RequestsByScanner == nil {
+		doc.Metadata.RequestsByScanner = make(map[string]int64)
+	}
+	for _, count := range doc.Metadata.RequestsByScanner {
+		doc.Metadata.RequestsSent += count
+	}
+	return doc
+}
+
+// WriteDocument writes doc as indented JSON to path.
+func WriteDocument(doc *Document, path string) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// JSONLWriter streams findings to a file, one JSON-encoded Finding per line, as soon as they are
+// emitted. Findings with a fingerprint already written (or, with FindingOptions.NoCollapse, the
+// same fingerprint and URL), scored below FindingOptions.MinCVSSScore or less certain than
+// FindingOptions.MinConfidence are skipped. It implements
+// scanner.FindingSink and is safe for concurrent use. The methods of a nil JSONLWriter do
+// nothing.
+type JSONLWriter struct {
+	mu      sync.Mutex
+	file    *os.File
+	opts    FindingOptions
+	written map[string]bool
+	err     error // First write error; later findings are dropped.
+	now     func() time.Time
+}
+
+// NewJSONLWriter creates (or truncates) the file at path for streaming findings.
+func NewJSONLWriter(path string, opts FindingOptions) (*JSONLWriter, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &JSONLWriter{file: file, opts: opts, written: make(map[string]bool), now: time.Now}, nil
+}
+
+// Emit writes the findings not written yet. Each line is written with a single write, so
+// readers tailing the file never see a partial finding.
+func (w *JSONLWriter) Emit(findings []scanner.VulnerabilityResult) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, v := range findings {
+		key := findingKey(v, !w.opts.NoCollapse)
+		if w.err != nil || w.written[key] || v.CVSSScore < w.opts.MinCVSSScore || !scanner.ConfidenceAtLeast(v.Confidence, w.opts.MinConfidence) {
+			continue
+		}
+		f := NewFinding(v, w.opts)
+		foundAt := w.now().UTC()
+		f.FoundAt = &foundAt
+		f.Technologies = w.opts.Technologies
+		line, err := json.Marshal(f)
+		if err != nil {
+			w.err = err
+			continue
+		}
+		if _, err := w.file.Write(append(line, '\n')); err != nil {
+			w.err = err
+			continue
+		}
+		w.written[key] = true
+	}
+}
+
+// Count returns the number of findings written.
+func (w *JSONLWriter) Count() int {
+	if w == nil {
+		return 0
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return len(w.written)
+}
+
+// Close closes the file and returns the first write error, if any.
+func (w *JSONLWriter) Close() error {
+	if w == nil {
+		return nil
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := w.file.Close(); err != nil && w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
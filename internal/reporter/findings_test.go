@@ -0,0 +1,189 @@
+package reporter
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFindingTruncatesEvidenceAndExcludesRaw(t *testing.T) {
+	v := scanner.VulnerabilityResult{
+		VulnerabilityType: "SQL Injection",
+		URL:               "https://example.com/product/42?id=1",
+		Parameter:         "id",
+		Evidence:          strings.Repeat("a", 9) + "é",
+		RawRequest:        "GET /product/42?id=1' HTTP/1.1",
+		RawResponse:       "HTTP/1.1 500 Internal Server Error",
+	}
+
+	f := NewFinding(v, FindingOptions{MaxEvidenceBytes: 10, ExcludeRaw: true})
+	assert.Equal(t, strings.Repeat("a", 9), f.Evidence, "multi-byte character split")
+	assert.True(t, f.EvidenceTruncated)
+	assert.Empty(t, f.RawRequest)
+	assert.Empty(t, f.RawResponse)
+
+	whole := NewFinding(v, FindingOptions{MaxEvidenceBytes: -1})
+	assert.Equal(t, v.Evidence, whole.Evidence)
+	assert.False(t, whole.EvidenceTruncated)
+	assert.Equal(t, v.RawRequest, whole.RawRequest)
+
+	other := v
+	other.URL = "https://example.com/product/7?id=2"
+	otherFinding := NewFinding(other, FindingOptions{})
+	assert.Equal(t, f.Fingerprint, otherFinding.Fingerprint, "fingerprints ignore path IDs")
+	assert.NotEqual(t, f.ID, otherFinding.ID)
+}
+
+func TestAggregate(t *testing.T) {
+	vulns := []scanner.VulnerabilityResult{
+		{VulnerabilityType: "SQL Injection", URL: "https://example.com/page/1?id=1", Parameter: "id", Location: "query"},
+		{VulnerabilityType: "XSS", URL: "https://example.com/search?q=a", Parameter: "q", Location: "query"},
+		{VulnerabilityType: "SQL Injection", URL: "https://example.com/page/2?id=1", Parameter: "id", Location: "query"},
+		{VulnerabilityType: "SQL Injection", URL: "https://example.com/page/2?id=1", Parameter: "id", Location: "query"},
+		{VulnerabilityType: "SQL Injection", URL: "https://example.com/page/3", Parameter: "id", Location: "body"},
+		{VulnerabilityType: "SQL Injection", URL: "https://other.example.com/page/4?id=1", Parameter: "id", Location: "query"},
+	}
+
+	collapsed := Aggregate(vulns, true)
+	require.Len(t, collapsed, 4)
+	assert.Equal(t, []string{"https://example.com/page/1?id=1", "https://example.com/page/2?id=1"}, collapsed[0].AffectedURLs)
+	assert.Equal(t, 2, collapsed[0].Occurrences)
+	assert.Equal(t, Fingerprint(vulns[2]), collapsed[0].Fingerprint)
+	assert.Equal(t, 1, collapsed[1].Occurrences)
+	assert.NotEqual(t, collapsed[0].Fingerprint, collapsed[2].Fingerprint, "the location is part of the fingerprint")
+	assert.NotEqual(t, collapsed[0].Fingerprint, collapsed[3].Fingerprint, "the host is part of the fingerprint")
+
+	raw := Aggregate(vulns, false)
+	require.Len(t, raw, 5, "only findings at the same URL are merged")
+	assert.Equal(t, raw[0].Fingerprint, raw[2].Fingerprint)
+	assert.Equal(t, "https://example.com/page/2?id=1", raw[2].URL)
+}
+
+func TestWriteDocument(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	doc := NewDocument(Metadata{
+		ToolVersion:       "1.2.3",
+		Target:            "https://example.com",
+		StartTime:         start,
+		EndTime:           start.Add(90 * time.Second),
+		Scanners:          []ScannerInfo{{Name: "sqli", Version: "1.0", Options: map[string]interface{}{"time_delay": 5}}},
+		RequestsByScanner: map[string]int64{"SQLi": 40, "XSS": 2},
+	}, []scanner.VulnerabilityResult{{VulnerabilityType: "SQL Injection", URL: "https://example.com/?id=1", Parameter: "id", Details: "Error-based"}}, FindingOptions{})
+	path := filepath.Join(t.TempDir(), "findings.json")
+	require.NoError(t, WriteDocument(doc, path))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, SchemaVersion, got["schema_version"])
+	metadata := got["metadata"].(map[string]interface{})
+	assert.Equal(t, "dursgo", metadata["tool"])
+	assert.Equal(t, 90.0, metadata["duration_seconds"])
+	assert.Equal(t, 42.0, metadata["requests_sent"])
+	assert.Equal(t, 1.0, metadata["findings_total"])
+	assert.Equal(t, "2024-05-01T12:00:00Z", metadata["start_time"])
+	findings := got["findings"].([]interface{})
+	require.Len(t, findings, 1)
+	finding := findings[0].(map[string]interface{})
+	assert.Equal(t, "SQL Injection", finding["type"])
+	assert.Equal(t, "id", finding["parameter"])
+	assert.NotContains(t, finding, "found_at")
+}
+
+func TestJSONLWriterStreamsUniqueFindings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "findings.jsonl")
+	w, err := NewJSONLWriter(path, FindingOptions{Technologies: []string{"nginx 1.25.3", "PHP"}})
+	require.NoError(t, err)
+
+	w.Emit([]scanner.VulnerabilityResult{{VulnerabilityType: "XSS", URL: "https://example.com/item/1", Parameter: "q"}})
+	// Lines are written as findings arrive, before Close.
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "\n"))
+
+	w.Emit([]scanner.VulnerabilityResult{
+		{VulnerabilityType: "XSS", URL: "https://example.com/item/2", Parameter: "q"}, // Same finding, other ID.
+		{VulnerabilityType: "SQL Injection", URL: "https://example.com/item/2", Parameter: "q"},
+	})
+	assert.Equal(t, 2, w.Count())
+	require.NoError(t, w.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var types []string
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		var f Finding
+		require.NoError(t, json.Unmarshal(lines.Bytes(), &f))
+		require.NotNil(t, f.FoundAt)
+		assert.Equal(t, []string{"nginx 1.25.3", "PHP"}, f.Technologies)
+		types = append(types, f.Type)
+	}
+	assert.Equal(t, []string{"XSS", "SQL Injection"}, types)
+
+	var nilWriter *JSONLWriter
+	nilWriter.Emit([]scanner.VulnerabilityResult{{VulnerabilityType: "XSS"}})
+	assert.NoError(t, nilWriter.Close())
+}
+
+func TestFilterByConfidence(t *testing.T) {
+	vulns := []scanner.VulnerabilityResult{
+		{VulnerabilityType: "SQL Injection (Error-Based)", Confidence: scanner.ConfidenceCertain},
+		{VulnerabilityType: "SQL Injection (Content-Based)", Confidence: scanner.ConfidenceTentative},
+		{VulnerabilityType: "Reflected XSS"}, // Unrated findings count as Firm.
+	}
+
+	assert.Len(t, FilterByConfidence(vulns, ""), 3)
+	assert.Len(t, FilterByConfidence(vulns, scanner.ConfidenceTentative), 3)
+	kept := FilterByConfidence(vulns, scanner.ConfidenceFirm)
+	require.Len(t, kept, 2)
+	assert.Equal(t, "Reflected XSS", kept[1].VulnerabilityType)
+
+	doc := NewDocument(Metadata{}, vulns, FindingOptions{MinConfidence: scanner.ConfidenceCertain})
+	require.Len(t, doc.Findings, 1)
+	assert.Equal(t, "certain", doc.Findings[0].Confidence)
+}
+
+func TestFilterAndSortByCVSS(t *testing.T) {
+	vulns := []scanner.VulnerabilityResult{
+		{VulnerabilityType: "Missing Security Header", CVSSScore: 3.1},
+		{VulnerabilityType: "Reflected XSS", CVSSScore: 6.1},
+		{VulnerabilityType: "SQL Injection", CVSSScore: 9.8, CWE: "CWE-89", CVSSVector: "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+		{VulnerabilityType: "Open Redirect", CVSSScore: 6.1},
+	}
+
+	assert.Len(t, FilterByCVSS(vulns, 0), 4)
+	kept := FilterByCVSS(vulns, 6.1)
+	require.Len(t, kept, 3)
+	assert.Equal(t, "Reflected XSS", kept[0].VulnerabilityType)
+
+	SortByCVSS(kept)
+	var types []string
+	for _, v := range kept {
+		types = append(types, v.VulnerabilityType)
+	}
+	assert.Equal(t, []string{"SQL Injection", "Reflected XSS", "Open Redirect"}, types, "equal scores keep their order")
+
+	doc := NewDocument(Metadata{}, vulns, FindingOptions{MinCVSSScore: 7})
+	require.Len(t, doc.Findings, 1)
+	assert.Equal(t, "CWE-89", doc.Findings[0].CWE)
+	assert.Equal(t, 9.8, doc.Findings[0].CVSSScore)
+
+	path := filepath.Join(t.TempDir(), "findings.jsonl")
+	w, err := NewJSONLWriter(path, FindingOptions{MinCVSSScore: 7})
+	require.NoError(t, err)
+	w.Emit(vulns)
+	assert.Equal(t, 1, w.Count())
+	require.NoError(t, w.Close())
+}
@@ -0,0 +1,365 @@
+package reporter
+
+import (
+	"html/template"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// severityOrder lists the severities of the HTML report from most to least severe. Findings
+// with another severity are grouped after them.
+var severityOrder = []string{"Critical", "High", "Medium", "Low", "Info"}
+
+// NormalizeSeverity maps the severities set by scanners ("high", "High", "Informational", ...)
+// to the names used in reports: Critical, High, Medium, Low or Info.
+func NormalizeSeverity(severity string) string {
+	s := strings.ToLower(strings.TrimSpace(severity))
+	switch {
+	case s == "", s == "info", s == "informational", s == "information":
+		return "Info"
+	case s == "critical", s == "high", s == "medium", s == "low":
+		return strings.ToUpper(s[:1]) + s[1:]
+	}
+	return severity
+}
+
+// htmlView is the data of the HTML report template.
+type htmlView struct {
+	Doc        *Document
+	Duration   string
+	MaxCVSS    float64     // Highest CVSS score of the findings.
+	Severities []htmlBar   // Findings per severity, in severityOrder.
+	Scanners   []htmlBar   // Findings per scanner, most first.
+	Groups     []htmlGroup // Findings grouped by severity, then type.
+}
+
+// htmlBar is a row of a bar chart.
+type htmlBar struct {
+	Label   string
+	Class   string // CSS class of the severity, for severity charts.
+	Count   int
+	Percent int // Width of the bar relative to the largest row.
+}
+
+// htmlGroup holds the findings of one severity.
+type htmlGroup struct {
+	Severity string
+	Class    string
+	Count    int
+	Types    []htmlTypeGroup
+}
+
+// htmlTypeGroup holds the findings of one vulnerability type within a severity.
+type htmlTypeGroup struct {
+	Type     string
+	Findings []Finding
+}
+
+// WriteHTML writes doc as a self-contained HTML report to path.
+func WriteHTML(doc *Document, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := RenderHTML(file, doc); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// RenderHTML renders doc as a self-contained HTML report (inline CSS, no scripts or external
+// resources). Every value from the scan is escaped by html/template, so payloads and response
+// evidence cannot inject markup into the report.
+func RenderHTML(w io.Writer, doc *Document) error {
+	return htmlReport.Execute(w, newHTMLView(doc))
+}
+
+// newHTMLView groups the findings of doc for the HTML report.
+func newHTMLView(doc *Document) htmlView {
+	view := htmlView{
+		Doc:      doc,
+		Duration: (time.Duration(doc.Metadata.DurationSeconds * float64(time.Second))).Round(time.Second).String(),
+	}
+
+	bySeverity := make(map[string][]Finding)
+	byScanner := make(map[string]int)
+	for _, f := range doc.Findings {
+		severity := NormalizeSeverity(f.Severity)
+		bySeverity[severity] = append(bySeverity[severity], f)
+		if f.CVSSScore > view.MaxCVSS {
+			view.MaxCVSS = f.CVSSScore
+		}
+		scannerName := f.Scanner
+		if scannerName == "" {
+			scannerName = "Unknown"
+		}
+		byScanner[scannerName]++
+	}
+
+	severities := append([]string(nil), severityOrder...)
+	var others []string
+	for severity := range bySeverity {
+		if severityClass(severity) == "other" {
+			others = append(others, severity)
+		}
+	}
+	sort.Strings(others)
+	severities = append(severities, others...)
+
+	maxCount := 0
+	for _, severity := range severities {
+		if n := len(bySeverity[severity]); n > maxCount {
+			maxCount = n
+		}
+	}
+	for _, severity := range severities {
+		findings := bySeverity[severity]
+		view.Severities = append(view.Severities, htmlBar{Label: severity, Class: severityClass(severity), Count: len(findings), Percent: percent(len(findings), maxCount)})
+		if len(findings) == 0 {
+			continue
+		}
+		group := htmlGroup{Severity: severity, Class: severityClass(severity), Count: len(findings)}
+		byType := make(map[string][]Finding)
+		var types []string
+		for _, f := range findings {
+			if _, seen := byType[f.Type]; !seen {
+				types = append(types, f.Type)
+			}
+			byType[f.Type] = append(byType[f.Type], f)
+		}
+		sort.Strings(types)
+		for _, t := range types {
+			typeFindings := byType[t]
+			sort.SliceStable(typeFindings, func(i, j int) bool { return typeFindings[i].CVSSScore > typeFindings[j].CVSSScore })
+			group.Types = append(group.Types, htmlTypeGroup{Type: t, Findings: typeFindings})
+		}
+		view.Groups = append(view.Groups, group)
+	}
+
+	maxCount = 0
+	for name, n := range byScanner {
+		view.Scanners = append(view.Scanners, htmlBar{Label: name, Count: n})
+		if n > maxCount {
+			maxCount = n
+		}
+	}
+	sort.Slice(view.Scanners, func(i, j int) bool {
+		if view.Scanners[i].Count != view.Scanners[j].Count {
+			return view.Scanners[i].Count > view.Scanners[j].Count
+		}
+		return view.Scanners[i].Label < view.Scanners[j].Label
+	})
+	for i := range view.Scanners {
+		view.Scanners[i].Percent = percent(view.Scanners[i].Count, maxCount)
+	}
+	return view
+}
+
+// severityClass returns the CSS class of a normalized severity.
+func severityClass(severity string) string {
+	for _, s := range severityOrder {
+		if s == severity {
+			return strings.ToLower(s)
+		}
+	}
+	return "other"
+}
+
+// percent returns n as a percentage of max, for bar widths.
+func percent(n, max int) int {
+	if max == 0 {
+		return 0
+	}
+	return n * 100 / max
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"join": strings.Join,
+	"time": func(t time.Time) string { return t.Format(time.RFC1123) },
+}).Parse(htmlTemplate))
+
+const htmlTemplate = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Dursgo report: {{with .Doc.Metadata.Target}}{{.}}{{else}}{{len .Doc.Metadata.Targets}} targets{{end}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; color: #1f2328; background: #f6f8fa; }
+header { background: #24292f; color: #fff; padding: 24px 40px; }
+header h1 { margin: 0 0 4px; font-size: 24px; }
+header p { margin: 0; color: #c9d1d9; word-break: break-all; }
+main { padding: 24px 40px; max-width: 1200px; }
+section { background: #fff; border: 1px solid #d0d7de; border-radius: 6px; padding: 16px 24px; margin-bottom: 24px; }
+h2 { font-size: 20px; margin-top: 0; }
+h3 { font-size: 16px; margin: 20px 0 8px; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 6px 8px; border-bottom: 1px solid #d0d7de; vertical-align: top; }
+th { width: 200px; color: #57606a; font-weight: 600; }
+.charts { display: flex; flex-wrap: wrap; gap: 24px; }
+.chart { flex: 1 1 320px; }
+.bar-row { display: flex; align-items: center; margin: 4px 0; }
+.bar-label { width: 160px; font-size: 13px; overflow: hidden; text-overflow: ellipsis; white-space: nowrap; }
+.bar-track { flex: 1; background: #eaeef2; border-radius: 3px; height: 16px; }
+.bar { height: 16px; border-radius: 3px; background: #0969da; }
+.bar-count { width: 40px; text-align: right; font-size: 13px; }
+.badge { display: inline-block; padding: 2px 8px; border-radius: 12px; color: #fff; font-size: 12px; font-weight: 600; }
+.critical { background: #8b0000; } .high { background: #cf222e; } .medium { background: #bc4c00; }
+.low { background: #9a6700; } .info { background: #0969da; } .other { background: #6e7781; }
+.new { background: #8250df; } .existing { background: #6e7781; } .resolved { background: #1a7f37; } .suppressed { background: #8c959f; }
+.still_vulnerable { background: #cf222e; } .remediated { background: #1a7f37; } .endpoint_gone { background: #6e7781; } .not_retested { background: #8c959f; }
+.finding { border: 1px solid #d0d7de; border-radius: 6px; padding: 8px 16px; margin: 8px 0; }
+.finding th { width: 120px; }
+pre { background: #f6f8fa; padding: 8px; overflow-x: auto; white-space: pre-wrap; word-break: break-all; font-size: 12px; margin: 0; }
+code { word-break: break-all; }
+summary { cursor: pointer; color: #0969da; }
+.empty { color: #57606a; }
+.warning { background: #fff8c5; border: 1px solid #d4a72c; padding: 8px 12px; }
+</style>
+</head>
+<body>
+<header>
+<h1>Dursgo Security Report</h1>
+<p>{{with .Doc.Metadata.Target}}{{.}}{{else}}{{range $i, $t := .Doc.Metadata.Targets}}{{if $i}}, {{end}}{{$t.Target}}{{end}}{{end}}</p>
+</header>
+<main>
+{{with .Doc.Metadata.BlockedHosts}}<section class="warning">
+<h2>Results Are Incomplete</h2>
+<p>These hosts blocked the scan with a WAF or rate limiting. Blocked responses were discarded, so vulnerabilities may have been missed.</p>
+<ul>
+{{range .}}<li><code>{{.Host}}</code>{{if .WAF}} ({{.WAF}}){{end}}: {{.Reason}}; blocked {{.Strikes}} time(s), {{.BlockedResponses}} response(s) discarded{{if .Aborted}}, <strong>given up</strong>{{end}}</li>
+{{end}}</ul>
+</section>
+{{end}}{{if .Doc.Metadata.Authentication.Lost}}<section class="warning">
+<h2>Authenticated Session Lost</h2>
+<p>The session was lost and could not be re-established ({{.Doc.Metadata.Authentication.Reason}}). The requests after it were not sent, so vulnerabilities may have been missed.</p>
+</section>
+{{end}}<section>
+<h2>Summary</h2>
+<table>
+<tr><th>Findings</th><td>{{.Doc.Metadata.FindingsTotal}}</td></tr>
+<tr><th>Highest CVSS score</th><td>{{printf "%.1f" .MaxCVSS}}</td></tr>
+{{with .Doc.Metadata.Baseline}}<tr><th>Compared with</th><td><code>{{.Baseline}}</code>: {{.New}} new, {{.Existing}} existing, {{.Resolved}} resolved</td></tr>
+{{end}}{{with .Doc.Metadata.Retest}}<tr><th>Retest of</th><td><code>{{.Source}}</code>: {{.StillVulnerable}} still vulnerable, {{.Remediated}} remediated, {{.EndpointGone}} endpoint gone, {{.NotRetested}} not re-tested</td></tr>
+{{end}}{{range .Severities}}<tr><th>{{.Label}}</th><td><span class="badge {{.Class}}">{{.Count}}</span></td></tr>
+{{end}}</table>
+<div class="charts">
+<div class="chart">
+<h3>Findings per severity</h3>
+{{range .Severities}}<div class="bar-row"><span class="bar-label">{{.Label}}</span><span class="bar-track"><div class="bar {{.Class}}" style="width: {{.Percent}}%"></div></span><span class="bar-count">{{.Count}}</span></div>
+{{end}}</div>
+<div class="chart">
+<h3>Findings per scanner</h3>
+{{range .Scanners}}<div class="bar-row"><span class="bar-label" title="{{.Label}}">{{.Label}}</span><span class="bar-track"><div class="bar" style="width: {{.Percent}}%"></div></span><span class="bar-count">{{.Count}}</span></div>
+{{else}}<p class="empty">No findings.</p>
+{{end}}</div>
+</div>
+</section>
+{{with .Doc.Metadata.Targets}}
+<section>
+<h2>Targets</h2>
+<table>
+{{range .}}<tr><th><code>{{.Target}}</code></th><td>{{.Findings}} finding(s); exit status {{.ExitStatus}}: {{.Status}}{{with .Technologies}}<br>Technologies: {{join . ", "}}{{end}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}
+{{with .Doc.Metadata.Coverage}}
+<section>
+<h2>Coverage</h2>
+<table>
+<tr><th>Parameter tests run</th><td>{{.Tested}}</td></tr>
+<tr><th>Failed</th><td>{{.Errored}}</td></tr>
+<tr><th>Skipped</th><td>{{.SkippedTotal}}</td></tr>
+{{range .Reasons}}<tr><th>Skipped: {{.Reason}}</th><td>{{.Count}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}
+
+<section>
+<h2>Scan Configuration</h2>
+<table>
+{{with .Doc.Metadata.Target}}<tr><th>Target</th><td><code>{{.}}</code></td></tr>
+{{end}}
+<tr><th>Started</th><td>{{time .Doc.Metadata.StartTime}}</td></tr>
+<tr><th>Finished</th><td>{{time .Doc.Metadata.EndTime}}{{if .Doc.Metadata.Interrupted}} (interrupted){{end}}</td></tr>
+<tr><th>Duration</th><td>{{.Duration}}</td></tr>
+{{with .Doc.Metadata.Scope}}<tr><th>Scope</th><td>{{.Subdomains}}{{if .AllowedHosts}}: {{join .AllowedHosts ", "}}{{end}}
+{{if .IncludePatterns}}<br>Include: <code>{{join .IncludePatterns "  "}}</code>{{end}}
+{{if .ExcludePatterns}}<br>Exclude: <code>{{join .ExcludePatterns "  "}}</code>{{end}}
+<br>{{.ExcludedURLs}} URL(s) excluded</td></tr>
+{{end}}{{with .Doc.Metadata.Technologies}}<tr><th>Technologies</th><td>{{range $i, $t := .}}{{if $i}}, {{end}}<span title="{{$t.Evidence}}">{{$t.Name}}{{with $t.Version}} {{.}}{{end}}</span>{{end}}</td></tr>
+{{end}}{{with .Doc.Metadata.Policy}}<tr><th>Policy</th><td>{{.Name}}: {{.PayloadTier}} payloads, {{.TimeConfirmations}} time-based confirmation(s){{if .TimeDelay}} of {{.TimeDelay}}s{{end}}, {{if .MaxRequestsPerParam}}{{.MaxRequestsPerParam}}{{else}}unlimited{{end}} request(s) per parameter, depth {{.MaxDepth}}{{if .OAST}}, OAST{{end}}{{if .InjectHeaders}}, header injection{{end}}{{if .Evasion}}, {{.Evasion}} evasion{{end}}{{if .PoCExtraction}}, <strong>PoC extraction (active exploitation)</strong>{{end}}
+{{if .Overrides}}<br>Overridden by: <code>{{range $i, $o := .Overrides}}{{if $i}} {{end}}-{{$o}}{{end}}</code>{{end}}</td></tr>
+{{end}}{{with .Doc.Metadata.Authentication}}<tr><th>Authentication</th><td>{{if .Authenticated}}{{.Method}}{{if .Verified}}, verified{{end}}, {{.Checks}} check(s), {{.Reauthentications}} re-authentication(s){{if .Lost}}, <strong>session lost</strong>{{end}}{{else}}None (unauthenticated scan){{end}}</td></tr>
+{{end}}<tr><th>Scanners</th><td>{{range $i, $s := .Doc.Metadata.Scanners}}{{if $i}}, {{end}}{{$s.Name}} {{$s.Version}}{{if $s.Options}} <code>{{range $k, $v := $s.Options}}{{$k}}={{$v}} {{end}}</code>{{end}}{{else}}None{{end}}</td></tr>
+<tr><th>URLs discovered</th><td>{{.Doc.Metadata.URLsDiscovered}}</td></tr>
+<tr><th>Requests scanned</th><td>{{.Doc.Metadata.RequestsScanned}}</td></tr>
+<tr><th>Requests sent</th><td>{{.Doc.Metadata.RequestsSent}}</td></tr>
+<tr><th>Retries</th><td>{{.Doc.Metadata.Retries.Retries}} ({{.Doc.Metadata.Retries.Recovered}} recovered, {{.Doc.Metadata.Retries.Failed}} failed)</td></tr>
+{{with .Doc.Metadata.PayloadFiles}}<tr><th>Payload files</th><td>{{range .}}<code>{{.Path}}</code>:{{range $i, $c := .Categories}}{{if $i}},{{end}} {{$c.Name}} ({{$c.Count}}, {{$c.Mode}}){{end}}<br>{{end}}</td></tr>
+{{end}}<tr><th>Dursgo version</th><td>{{.Doc.Metadata.ToolVersion}} (schema {{.Doc.SchemaVersion}})</td></tr>
+</table>
+</section>
+
+<section>
+<h2>Findings</h2>
+{{range .Groups}}<h3><span class="badge {{.Class}}">{{.Severity}}</span> {{.Count}} finding(s)</h3>
+{{range .Types}}<h3>{{.Type}}</h3>
+{{range .Findings}}<div class="finding" id="finding-{{.ID}}">
+<table>
+{{if .Target}}<tr><th>Target</th><td><code>{{.Target}}</code></td></tr>
+{{end}}<tr><th>URL</th><td><code>{{.URL}}</code>{{if .DiffStatus}} <span class="badge {{.DiffStatus}}">{{.DiffStatus}}</span>{{end}}{{if .RetestStatus}} <span class="badge {{.RetestStatus}}">{{.RetestStatus}}</span>{{end}}</td></tr>
+{{if gt .Occurrences 1}}<tr><th>Affected URLs</th><td><details><summary>{{.Occurrences}} URLs</summary>{{range .AffectedURLs}}<code>{{.}}</code><br>{{end}}</details></td></tr>
+{{end}}{{if .Parameter}}<tr><th>Parameter</th><td><code>{{.Parameter}}</code>{{if .Location}} ({{.Location}}){{end}}</td></tr>
+{{end}}{{if .Payload}}<tr><th>Payload</th><td><pre>{{.Payload}}</pre></td></tr>
+{{end}}<tr><th>Details</th><td>{{.Details}}</td></tr>
+{{if .RetestReason}}<tr><th>Not re-tested</th><td>{{.RetestReason}}</td></tr>
+{{end}}{{if .Confidence}}<tr><th>Confidence</th><td>{{.Confidence}}</td></tr>
+{{end}}{{if .Evidence}}<tr><th>Evidence</th><td><pre>{{.Evidence}}</pre>{{if .EvidenceTruncated}}<em>Truncated.</em>{{end}}</td></tr>
+{{end}}{{if .Transformation}}<tr><th>Evasion</th><td>Payload transformed with {{.Transformation}}</td></tr>
+{{end}}{{if .ActiveExploitation}}<tr><th>Active exploitation</th><td><strong>{{.ActiveExploitation}}</strong></td></tr>
+{{end}}{{if .Remediation}}<tr><th>Remediation</th><td>{{.Remediation}}</td></tr>
+{{end}}{{if .OverriddenBy}}<tr><th>Override</th><td>{{.OverriddenBy}}{{if .OriginalSeverity}}; severity was {{.OriginalSeverity}}{{end}}</td></tr>
+{{end}}{{if .CVSSVector}}<tr><th>CVSS</th><td><strong>{{printf "%.1f" .CVSSScore}}</strong> <code>{{.CVSSVector}}</code></td></tr>
+{{end}}{{if .CWE}}<tr><th>CWE</th><td>{{.CWE}}</td></tr>
+{{end}}{{if .CVE}}<tr><th>CVE</th><td>{{.CVE}}</td></tr>
+{{end}}<tr><th>Scanner</th><td>{{.Scanner}}</td></tr>
+<tr><th>Fingerprint</th><td><code>{{.Fingerprint}}</code></td></tr>
+{{if or .RawRequest .RawResponse}}<tr><th>Exchange</th><td><details><summary>Raw request and response</summary>
+{{if .RawRequest}}<pre>{{.RawRequest}}</pre>{{end}}
+{{if .RawResponse}}<pre>{{if .RawResponseBase64}}(base64) {{end}}{{.RawResponse}}</pre>{{if .RawResponseTruncated}}<em>Truncated.</em>{{end}}{{end}}
+</details></td></tr>
+{{end}}</table>
+</div>
+{{end}}{{end}}{{else}}<p class="empty">No vulnerabilities found.</p>
+{{end}}</section>
+{{if .Doc.Resolved}}
+<section>
+<h2>{{if .Doc.Metadata.Retest}}Resolved on Retest{{else}}Resolved Since the Baseline{{end}}</h2>
+<table>
+{{range .Doc.Resolved}}<tr><th>{{with .RetestStatus}}<span class="badge {{.}}">{{.}}</span>{{else}}<span class="badge resolved">resolved</span>{{end}}</th><td>{{.Type}} ({{.Severity}}) at <code>{{.URL}}</code>{{if .Parameter}}, parameter <code>{{.Parameter}}</code>{{end}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{with .Doc.Metadata.StoredContent}}
+<section>
+<h2>Content Created on the Target</h2>
+<p>The scan submitted these values to writable parameters. Remove the content they created.</p>
+<table>
+{{range .}}<tr><th>{{.Method}} <code>{{.URL}}</code></th><td>Parameter <code>{{.Param}}</code>: <code>{{.Value}}</code>{{with .ShownAt}}<br>Shown at: {{range $i, $u := .}}{{if $i}}, {{end}}<code>{{$u}}</code>{{end}}{{end}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}{{if .Doc.Suppressed}}
+<section>
+<h2>Suppressed Findings</h2>
+<table>
+{{range .Doc.Suppressed}}<tr><th><span class="badge suppressed">suppressed</span></th><td>{{.Type}} ({{.Severity}}) at <code>{{.URL}}</code>{{if .Parameter}}, parameter <code>{{.Parameter}}</code>{{end}}<br>Rule: {{.SuppressedBy}}</td></tr>
+{{end}}</table>
+</section>
+{{end}}</main>
+</body>
+</html>
+`
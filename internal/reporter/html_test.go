@@ -0,0 +1,86 @@
+package reporter
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderHTMLEscapesFindings(t *testing.T) {
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	doc := NewDocument(Metadata{
+		Target:    "https://example.com/",
+		StartTime: start,
+		EndTime:   start.Add(90 * time.Second),
+		Scanners:  []ScannerInfo{{Name: "xss-reflected", Version: "1.0"}},
+		Scope:     &ScopeInfo{Subdomains: "same-host", ExcludePatterns: []string{"/logout"}},
+		Policy:    &PolicyInfo{Name: "quick", PayloadTier: "minimal", TimeConfirmations: 2, TimeDelay: 3, MaxRequestsPerParam: 30, MaxDepth: 2, PoCExtraction: true, Overrides: []string{"d"}},
+		PayloadFiles: []payloads.PayloadFile{{Path: "payloads/custom.yaml", Categories: []payloads.PayloadFileCategory{
+			{Name: "sqli_time", Mode: payloads.ModeReplace, Count: 4},
+		}}},
+		Coverage: &scanner.CoverageSummary{Tested: 12, Errored: 1, Skipped: map[scanner.SkipReason]int{scanner.SkipReasonInert: 3, scanner.SkipReasonRule: 2}},
+	}, []scanner.VulnerabilityResult{{
+		VulnerabilityType: "Reflected XSS",
+		Severity:          "high",
+		URL:               "https://example.com/search?q=x",
+		Parameter:         "q",
+		Payload:           `"><script>alert(1)</script>`,
+		Evidence:          `<img src=x onerror=alert(2)>`,
+		ScannerName:       "Reflected XSS Scanner",
+		OverriddenBy:      "type \"Reflected XSS\"",
+		OriginalSeverity:  "medium",
+	}}, FindingOptions{})
+
+	var buf bytes.Buffer
+	require.NoError(t, RenderHTML(&buf, doc))
+	html := buf.String()
+
+	assert.NotContains(t, html, "<script>alert(1)</script>")
+	assert.NotContains(t, html, "<img src=x")
+	assert.Contains(t, html, "&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.Contains(t, html, "&lt;img src=x onerror=alert(2)&gt;")
+	assert.Contains(t, html, "1m30s")
+	assert.Contains(t, html, "/logout")
+	assert.Contains(t, html, "quick: minimal payloads, 2 time-based confirmation(s) of 3s, 30 request(s) per parameter, depth 2")
+	assert.Contains(t, html, "depth 2, <strong>PoC extraction (active exploitation)</strong>")
+	assert.Contains(t, html, "Overridden by: <code>-d</code>")
+	assert.Contains(t, html, "<code>payloads/custom.yaml</code>: sqli_time (4, replace)")
+	assert.Contains(t, html, "<tr><th>Skipped</th><td>5</td></tr>\n<tr><th>Skipped: skip_rule</th><td>2</td></tr>\n<tr><th>Skipped: inert</th><td>3</td></tr>")
+	assert.Contains(t, html, "<tr><th>Override</th><td>type &#34;Reflected XSS&#34;; severity was medium</td></tr>")
+	assert.NotContains(t, html, "<script", "the report has no scripts")
+}
+
+func TestNewHTMLViewGroupsFindings(t *testing.T) {
+	doc := NewDocument(Metadata{}, []scanner.VulnerabilityResult{
+		{VulnerabilityType: "SQL Injection", Severity: "Critical", URL: "https://example.com/a", Parameter: "id", ScannerName: "SQLi"},
+		{VulnerabilityType: "Missing Security Header", Severity: "low", URL: "https://example.com/a", Parameter: "CSP", ScannerName: "Headers"},
+		{VulnerabilityType: "Cookie Without Secure Flag", Severity: "Low", URL: "https://example.com/a", Parameter: "sid", ScannerName: "Headers"},
+		{VulnerabilityType: "Server Banner", Severity: "Informational", URL: "https://example.com/", ScannerName: "Headers"},
+		{VulnerabilityType: "Odd", Severity: "Unrated", URL: "https://example.com/"},
+	}, FindingOptions{})
+
+	view := newHTMLView(doc)
+
+	var severities []string
+	for _, g := range view.Groups {
+		severities = append(severities, g.Severity)
+	}
+	assert.Equal(t, []string{"Critical", "Low", "Info", "Unrated"}, severities)
+	require.Len(t, view.Groups[1].Types, 2)
+	assert.Equal(t, "Cookie Without Secure Flag", view.Groups[1].Types[0].Type)
+	assert.Equal(t, "other", view.Groups[3].Class)
+
+	require.Len(t, view.Severities, 6)
+	assert.Equal(t, htmlBar{Label: "Low", Class: "low", Count: 2, Percent: 100}, view.Severities[3])
+	assert.Equal(t, 0, view.Severities[1].Count, "empty severities are charted")
+
+	assert.Equal(t, htmlBar{Label: "Headers", Count: 3, Percent: 100}, view.Scanners[0])
+	assert.Equal(t, "SQLi", view.Scanners[1].Label)
+	assert.Equal(t, "Unknown", view.Scanners[2].Label)
+}
@@ -1,7 +1,12 @@
 package reporter
 
 import (
+	"Dursgo/internal/control"
 	"Dursgo/internal/crawler" // Required to access the ParameterizedRequest struct
+	"Dursgo/internal/fingerprint"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/metrics"
+	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
 	"time"
 )
@@ -21,21 +26,69 @@ type Report struct {
 	ScanSummary         ScanSummary                   `json:"scan_summary"`
 	DiscoveredEndpoints []DiscoveredEndpoint          `json:"discovered_endpoints,omitempty"` // New field added for discovered endpoints
 	Vulnerabilities     []scanner.VulnerabilityResult `json:"vulnerabilities"`
+	// SuppressedVulnerabilities are the findings matched by a suppression rule (-suppressions).
+	SuppressedVulnerabilities []scanner.VulnerabilityResult `json:"suppressed_vulnerabilities,omitempty"`
 }
 
 // ScanSummary contains metadata and a summary of the scan.
 // It provides an overview of the scan's execution, including timing,
 // scope, and high-level results.
 type ScanSummary struct {
-	TargetURL                  string            `json:"target_url"`
-	ScanStartTime              string            `json:"scan_start_time"`
-	ScanEndTime                string            `json:"scan_end_time"`
-	TotalDuration              string            `json:"total_duration"`
-	ScannersRun                []string          `json:"scanners_run"`
-	TechnologiesDetected       map[string]string `json:"technologies_detected"`
-	TotalURLsDiscovered        int               `json:"total_urls_discovered"`
-	TotalParameterizedRequests int               `json:"total_parameterized_requests"` // New field for summary
-	TotalVulnsFound            int               `json:"total_vulnerabilities_found"`
+	TargetURL                  string                     `json:"target_url"`
+	ScanStartTime              string                     `json:"scan_start_time"`
+	ScanEndTime                string                     `json:"scan_end_time"`
+	TotalDuration              string                     `json:"total_duration"`
+	ScannersRun                []string                   `json:"scanners_run"`
+	TechnologiesDetected       map[string]string          `json:"technologies_detected"`
+	Technologies               fingerprint.Technologies   `json:"technologies,omitempty"` // Stack identified by the technology rules
+	TotalURLsDiscovered        int                        `json:"total_urls_discovered"`
+	TotalParameterizedRequests int                        `json:"total_parameterized_requests"` // New field for summary
+	TotalVulnsFound            int                        `json:"total_vulnerabilities_found"`
+	RequestsByScanner          map[string]int64           `json:"requests_by_scanner,omitempty"`     // HTTP requests sent by each scanner
+	ExcludedByScope            map[string]int             `json:"excluded_by_scope,omitempty"`       // URLs excluded by the scope, per reason
+	DroppedByCrawlLimit        map[string]int             `json:"dropped_by_crawl_limit,omitempty"`  // URLs not crawled, per crawl limit
+	PrunedPagination           []crawler.PaginationBranch `json:"pruned_pagination,omitempty"`       // Paginated listings whose pages were sampled
+	CollapsedDuplicates        int                        `json:"collapsed_duplicates,omitempty"`    // Structurally identical requests not scanned
+	RepresentativeCoverage     []crawler.DuplicateGroup   `json:"representative_coverage,omitempty"` // Groups scanned through representatives
+	// ResumedFrom is the start time of the original run when the scan was resumed from a state
+	// file; ResumedFindings is the number of findings carried over from the earlier run(s).
+	ResumedFrom     string `json:"resumed_from,omitempty"`
+	ResumedFindings int    `json:"resumed_findings,omitempty"`
+	// ScannerOptions holds the options each scanner in ScannersRun ran with, for scanners that
+	// take options.
+	ScannerOptions map[string]map[string]interface{} `json:"scanner_options,omitempty"`
+	// Baseline counts the new, existing and resolved findings compared with the baseline scan
+	// (-baseline); each vulnerability carries its diff_status.
+	Baseline *DiffSummary `json:"baseline,omitempty"`
+	// Retries counts the retries of transient failures (timeouts, connection resets, 429, 502,
+	// 503, 504); failed requests were skipped.
+	Retries *httpclient.RetryStats `json:"retries,omitempty"`
+	// Authentication tells whether the scan ran authenticated, whether its session was verified
+	// and how often it was re-established.
+	Authentication *AuthInfo `json:"authentication,omitempty"`
+	// BlockedHosts are the hosts that blocked the scan with a WAF or rate limiting; their
+	// results are incomplete.
+	BlockedHosts []httpclient.BlockedHost `json:"blocked_hosts,omitempty"`
+	// PayloadFiles are the payload files that replaced or extended built-in payloads.
+	PayloadFiles []payloads.PayloadFile `json:"payload_files,omitempty"`
+	// StoredContent is the content the scan created on the target by submitting markers and
+	// payloads to writable parameters (stored XSS), for its owners to remove.
+	StoredContent []scanner.StoredContent `json:"stored_content,omitempty"`
+	// Suppressions are the rules of the suppression file, with the number of findings each one
+	// suppressed.
+	Suppressions []SuppressionRule `json:"suppressions,omitempty"`
+	// ControlActions are the actions taken on the scan through its control interface, with
+	// their time.
+	ControlActions []control.Action `json:"control_actions,omitempty"`
+	// InertParams are the parameters found to have no effect on their response and the
+	// parameter tests skipped because of them (-skip-inert-params).
+	InertParams *scanner.InertSummary `json:"inert_params,omitempty"`
+	// Coverage counts the scanner/parameter tests that ran, were skipped and failed, and lists
+	// each of them with -coverage.
+	Coverage *scanner.CoverageSummary `json:"coverage,omitempty"`
+	// Statistics are the timings and request statistics of the scan per phase, scanner and host,
+	// and its slowest URLs.
+	Statistics *metrics.Summary `json:"statistics,omitempty"`
 }
 
 // NewReport creates a new report instance.
@@ -0,0 +1,32 @@
+package reporter
+
+// Retest statuses of the findings of a report re-tested with -retest.
+const (
+	RetestStillVulnerable = "still_vulnerable" // The scanner found the vulnerability again.
+	RetestRemediated      = "remediated"       // The scanner ran and no longer finds it.
+	RetestEndpointGone    = "endpoint_gone"    // The endpoint answers 404 or 410.
+	RetestNotRetested     = "not_retested"     // The finding could not be re-tested; see RetestReason.
+)
+
+// RetestSummary counts the findings of a retest by status.
+type RetestSummary struct {
+	Source          string `json:"source"` // Path of the re-tested report.
+	StillVulnerable int    `json:"still_vulnerable"`
+	Remediated      int    `json:"remediated"`
+	EndpointGone    int    `json:"endpoint_gone"`
+	NotRetested     int    `json:"not_retested"`
+}
+
+// Add counts a finding with the retest status.
+func (s *RetestSummary) Add(status string) {
+	switch status {
+	case RetestStillVulnerable:
+		s.StillVulnerable++
+	case RetestRemediated:
+		s.Remediated++
+	case RetestEndpointGone:
+		s.EndpointGone++
+	case RetestNotRetested:
+		s.NotRetested++
+	}
+}
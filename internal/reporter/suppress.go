@@ -0,0 +1,212 @@
+package reporter
+
+import (
+	"Dursgo/internal/scanner"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SuppressionRule silences a known-accepted finding: either the findings with Fingerprint, or
+// those matching every field set among Type, URL and Parameter.
+type SuppressionRule struct {
+	Fingerprint string `yaml:"fingerprint" json:"fingerprint,omitempty"`
+	Type        string `yaml:"type" json:"type,omitempty"`           // Vulnerability type, matched case-insensitively.
+	URL         string `yaml:"url" json:"url,omitempty"`             // Regular expression matching the URL of the finding.
+	Parameter   string `yaml:"parameter" json:"parameter,omitempty"` // Parameter name, matched case-insensitively.
+	Reason      string `yaml:"reason" json:"reason,omitempty"`       // Why the finding is accepted, e.g. a ticket.
+	Matched     int    `yaml:"-" json:"matched"`                     // Findings suppressed by the rule.
+
+	url *regexp.Regexp
+}
+
+// String describes the rule for log lines and the suppressed_by field of findings, e.g.
+// `type "Missing Security Headers", url ^https://cdn\.` or "fingerprint 3f2a9c (accepted risk)".
+func (r SuppressionRule) String() string {
+	var parts []string
+	if r.Fingerprint != "" {
+		parts = append(parts, "fingerprint "+r.Fingerprint)
+	}
+	if r.Type != "" {
+		parts = append(parts, fmt.Sprintf("type %q", r.Type))
+	}
+	if r.URL != "" {
+		parts = append(parts, "url "+r.URL)
+	}
+	if r.Parameter != "" {
+		parts = append(parts, "parameter "+r.Parameter)
+	}
+	s := strings.Join(parts, ", ")
+	if r.Reason != "" {
+		s += " (" + r.Reason + ")"
+	}
+	return s
+}
+
+// matches reports whether the rule suppresses v.
+func (r *SuppressionRule) matches(v scanner.VulnerabilityResult) bool {
+	if r.Fingerprint != "" {
+		fingerprint := v.Fingerprint
+		if fingerprint == "" {
+			fingerprint = Fingerprint(v)
+		}
+		return fingerprint == r.Fingerprint
+	}
+	return (r.Type == "" || strings.EqualFold(r.Type, v.VulnerabilityType)) &&
+		(r.url == nil || r.url.MatchString(v.URL)) &&
+		(r.Parameter == "" || strings.EqualFold(r.Parameter, v.Parameter))
+}
+
+// Suppressions holds the rules of a suppression file (-suppressions). A nil *Suppressions
+// suppresses nothing.
+type Suppressions struct {
+	path  string
+	rules []SuppressionRule
+}
+
+// LoadSuppressions reads a suppression file: a YAML document listing rules under suppressions,
+// e.g.
+//
+//	suppressions:
+//	  - fingerprint: 3f2a9c0d1e4b5a67
+//	    reason: accepted risk, SEC-142
+//	  - type: Missing Security Headers
+//	    url: ^https://cdn\.example\.com/
+//	  - type: Reflected XSS
+//	    parameter: callback
+func LoadSuppressions(path string) (*Suppressions, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Suppressions []SuppressionRule `yaml:"suppressions"`
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var errs []error
+	for i := range file.Suppressions {
+		rule := &file.Suppressions[i]
+		switch {
+		case rule.Fingerprint != "" && (rule.Type != "" || rule.URL != "" || rule.Parameter != ""):
+			errs = append(errs, fmt.Errorf("%s: suppression %d: a fingerprint cannot be combined with type, url or parameter", path, i+1))
+		case rule.Fingerprint == "" && rule.Type == "" && rule.URL == "" && rule.Parameter == "":
+			errs = append(errs, fmt.Errorf("%s: suppression %d: set a fingerprint, or a type, url or parameter to match", path, i+1))
+		case rule.URL != "":
+			if rule.url, err = regexp.Compile(rule.URL); err != nil {
+				errs = append(errs, fmt.Errorf("%s: suppression %d: invalid url pattern: %v", path, i+1, err))
+			}
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &Suppressions{path: path, rules: file.Suppressions}, nil
+}
+
+// Path returns the path of the suppression file.
+func (s *Suppressions) Path() string {
+	if s == nil {
+		return ""
+	}
+	return s.path
+}
+
+// Len returns the number of rules.
+func (s *Suppressions) Len() int {
+	if s == nil {
+		return 0
+	}
+	return len(s.rules)
+}
+
+// match returns the first rule suppressing v, or nil.
+func (s *Suppressions) match(v scanner.VulnerabilityResult) *SuppressionRule {
+	if s == nil {
+		return nil
+	}
+	for i := range s.rules {
+		if s.rules[i].matches(v) {
+			return &s.rules[i]
+		}
+	}
+	return nil
+}
+
+// Apply splits the aggregated findings of a scan into those reported and those suppressed, the
+// latter with SuppressedBy set to the rule that matched. Each rule counts the findings it
+// suppressed. Apply is called once per scan; it is not safe for concurrent use.
+func (s *Suppressions) Apply(vulns []scanner.VulnerabilityResult) (kept, suppressed []scanner.VulnerabilityResult) {
+	if s == nil {
+		return vulns, nil
+	}
+	kept = make([]scanner.VulnerabilityResult, 0, len(vulns))
+	for _, v := range vulns {
+		rule := s.match(v)
+		if rule == nil {
+			kept = append(kept, v)
+			continue
+		}
+		rule.Matched++
+		v.SuppressedBy = rule.String()
+		suppressed = append(suppressed, v)
+	}
+	return kept, suppressed
+}
+
+// Rules returns the rules with the number of findings each one suppressed.
+func (s *Suppressions) Rules() []SuppressionRule {
+	if s == nil {
+		return nil
+	}
+	return append([]SuppressionRule(nil), s.rules...)
+}
+
+// Unused returns the rules that suppressed nothing, so stale entries can be removed.
+func (s *Suppressions) Unused() []SuppressionRule {
+	var unused []SuppressionRule
+	for _, rule := range s.Rules() {
+		if rule.Matched == 0 {
+			unused = append(unused, rule)
+		}
+	}
+	return unused
+}
+
+// Sink returns a FindingSink that marks the findings emitted during the scan as Apply would
+// before passing them to next, so streamed findings carry their suppression too.
+func (s *Suppressions) Sink(next scanner.FindingSink) scanner.FindingSink {
+	if s == nil {
+		return next
+	}
+	return suppressingSink{s: s, next: next}
+}
+
+// suppressingSink is the FindingSink returned by Suppressions.Sink.
+type suppressingSink struct {
+	s    *Suppressions
+	next scanner.FindingSink
+}
+
+// Emit marks the suppressed findings and passes all of them on. It does not count matches,
+// which Apply does once the findings are aggregated.
+func (k suppressingSink) Emit(findings []scanner.VulnerabilityResult) {
+	marked := make([]scanner.VulnerabilityResult, len(findings))
+	for i, v := range findings {
+		if rule := k.s.match(v); rule != nil {
+			v.SuppressedBy = rule.String()
+		}
+		marked[i] = v
+	}
+	k.next.Emit(marked)
+}
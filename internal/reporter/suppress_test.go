@@ -0,0 +1,98 @@
+package reporter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSink records the findings emitted to it.
+type recordingSink struct {
+	findings []scanner.VulnerabilityResult
+}
+
+func (r *recordingSink) Emit(findings []scanner.VulnerabilityResult) {
+	r.findings = append(r.findings, findings...)
+}
+
+func writeSuppressions(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "suppressions.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+func TestSuppressionsApply(t *testing.T) {
+	sqli := scanner.VulnerabilityResult{VulnerabilityType: "SQL Injection", URL: "https://example.com/item?id=1", Parameter: "id", Location: "query", Severity: "High"}
+	vulns := Aggregate([]scanner.VulnerabilityResult{
+		sqli,
+		{VulnerabilityType: "Missing Security Headers", URL: "https://cdn.example.com/app.js", Severity: "Low"},
+		{VulnerabilityType: "Missing Security Headers", URL: "https://example.com/", Severity: "Low"},
+		{VulnerabilityType: "Reflected XSS", URL: "https://example.com/search?q=x", Parameter: "q", Severity: "High"},
+	}, true)
+	path := writeSuppressions(t, `
+suppressions:
+  - fingerprint: `+Fingerprint(sqli)+`
+    reason: accepted risk, SEC-142
+  - type: missing security headers
+    url: ^https://cdn\.example\.com/
+  - type: Reflected XSS
+    parameter: callback
+`)
+
+	suppressions, err := LoadSuppressions(path)
+	require.NoError(t, err)
+	assert.Equal(t, 3, suppressions.Len())
+
+	kept, suppressed := suppressions.Apply(vulns)
+	require.Len(t, kept, 2)
+	assert.Equal(t, "https://example.com/", kept[0].URL)
+	assert.Equal(t, "Reflected XSS", kept[1].VulnerabilityType)
+	require.Len(t, suppressed, 2)
+	assert.Equal(t, "fingerprint "+Fingerprint(sqli)+" (accepted risk, SEC-142)", suppressed[0].SuppressedBy)
+	assert.Equal(t, `type "missing security headers", url ^https://cdn\.example\.com/`, suppressed[1].SuppressedBy)
+	assert.Equal(t, suppressed[0].SuppressedBy, NewFinding(suppressed[0], FindingOptions{}).SuppressedBy)
+
+	rules := suppressions.Rules()
+	assert.Equal(t, []int{1, 1, 0}, []int{rules[0].Matched, rules[1].Matched, rules[2].Matched})
+	unused := suppressions.Unused()
+	require.Len(t, unused, 1)
+	assert.Equal(t, "callback", unused[0].Parameter)
+
+	sink := &recordingSink{}
+	suppressions.Sink(sink).Emit([]scanner.VulnerabilityResult{sqli, vulns[3]})
+	require.Len(t, sink.findings, 2, "streamed findings are passed on")
+	assert.NotEmpty(t, sink.findings[0].SuppressedBy)
+	assert.Empty(t, sink.findings[1].SuppressedBy)
+	assert.Equal(t, 1, suppressions.Rules()[0].Matched, "the sink does not count matches")
+
+	var none *Suppressions
+	kept, suppressed = none.Apply(vulns)
+	assert.Len(t, kept, 4, "nil suppresses nothing")
+	assert.Empty(t, suppressed)
+}
+
+func TestLoadSuppressionsRejectsInvalidRules(t *testing.T) {
+	_, err := LoadSuppressions(writeSuppressions(t, `
+suppressions:
+  - fingerprint: abc
+    type: XSS
+  - reason: nothing to match
+  - url: "("
+`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "suppression 1: a fingerprint cannot be combined")
+	assert.Contains(t, err.Error(), "suppression 2: set a fingerprint")
+	assert.Contains(t, err.Error(), "suppression 3: invalid url pattern")
+
+	_, err = LoadSuppressions(writeSuppressions(t, "suppressions:\n  - fingerprnt: abc\n"))
+	assert.ErrorContains(t, err, "field fingerprnt not found")
+
+	suppressions, err := LoadSuppressions(writeSuppressions(t, ""))
+	require.NoError(t, err, "an empty file has no rules")
+	assert.Equal(t, 0, suppressions.Len())
+}
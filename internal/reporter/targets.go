@@ -0,0 +1,193 @@
+package reporter
+
+import (
+	"Dursgo/internal/scanner"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TargetInfo is the result of one target of a scan of several targets (-targets-file).
+type TargetInfo struct {
+	Target       string `json:"target"`                  // Target URL.
+	FindingsFile string `json:"findings_file,omitempty"` // Findings document of the target alone.
+	Findings     int    `json:"findings"`                // Findings of the target.
+	ExitStatus   int    `json:"exit_status"`             // Exit status of the scan of the target.
+	Status       string `json:"status"`                  // The condition that produced ExitStatus.
+	// Technologies are the names of the technologies identified on the target.
+	Technologies []string `json:"technologies,omitempty"`
+}
+
+// ReadDocument reads a findings document written with WriteDocument.
+func ReadDocument(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if doc.SchemaVersion == "" {
+		return nil, fmt.Errorf("%s is not a findings document", path)
+	}
+	return &doc, nil
+}
+
+// CombineDocuments combines the findings documents of the targets of a scan of several targets
+// into one: findings are tagged with their target, counters are summed and targets lists the
+// result of each target. docs[i] is the document of targets[i], or nil if its scan wrote none.
+func CombineDocuments(targets []TargetInfo, docs []*Document) *Document {
+	combined := &Document{SchemaVersion: SchemaVersion, Findings: []Finding{}}
+	m := &combined.Metadata
+	m.Tool = "dursgo"
+	m.RequestsByScanner = make(map[string]int64)
+	for i, doc := range docs {
+		if doc == nil {
+			continue
+		}
+		d := doc.Metadata
+		if m.ToolVersion == "" {
+			m.ToolVersion, m.Scanners, m.PayloadFiles = d.ToolVersion, d.Scanners, d.PayloadFiles
+		}
+		if m.StartTime.IsZero() || d.StartTime.Before(m.StartTime) {
+			m.StartTime = d.StartTime
+		}
+		if d.EndTime.After(m.EndTime) {
+			m.EndTime = d.EndTime
+		}
+		m.Interrupted = m.Interrupted || d.Interrupted
+		m.URLsDiscovered += d.URLsDiscovered
+		m.RequestsScanned += d.RequestsScanned
+		m.RequestsSent += d.RequestsSent
+		for name, n := range d.RequestsByScanner {
+			m.RequestsByScanner[name] += n
+		}
+		m.Retries.Retries += d.Retries.Retries
+		m.Retries.Recovered += d.Retries.Recovered
+		m.Retries.Failed += d.Retries.Failed
+		m.BlockedHosts = append(m.BlockedHosts, d.BlockedHosts...)
+		m.StoredContent = append(m.StoredContent, d.StoredContent...)
+		if d.Authentication.Authenticated {
+			a := &m.Authentication
+			if !a.Authenticated {
+				a.Authenticated, a.Method, a.Verified = true, d.Authentication.Method, d.Authentication.Verified
+			}
+			a.Verified = a.Verified && d.Authentication.Verified
+			a.Checks += d.Authentication.Checks
+			a.Reauthentications += d.Authentication.Reauthentications
+			if d.Authentication.Lost {
+				a.Lost, a.Reason = true, targets[i].Target+": "+d.Authentication.Reason
+			}
+		}
+		m.ControlActions = append(m.ControlActions, d.ControlActions...)
+		if d.InertParams != nil {
+			if m.InertParams == nil {
+				m.InertParams = &scanner.InertSummary{}
+			}
+			m.InertParams.Checked += d.InertParams.Checked
+			m.InertParams.Inert = append(m.InertParams.Inert, d.InertParams.Inert...)
+			m.InertParams.Skipped += d.InertParams.Skipped
+			for _, module := range d.InertParams.TestedBy {
+				if !slices.Contains(m.InertParams.TestedBy, module) {
+					m.InertParams.TestedBy = append(m.InertParams.TestedBy, module)
+				}
+			}
+		}
+		if d.Coverage != nil {
+			if m.Coverage == nil {
+				m.Coverage = &scanner.CoverageSummary{Skipped: make(map[scanner.SkipReason]int)}
+			}
+			m.Coverage.Tested += d.Coverage.Tested
+			m.Coverage.Errored += d.Coverage.Errored
+			for reason, n := range d.Coverage.Skipped {
+				m.Coverage.Skipped[reason] += n
+			}
+			m.Coverage.Entries = append(m.Coverage.Entries, d.Coverage.Entries...)
+		}
+		if d.Baseline != nil {
+			if m.Baseline == nil {
+				m.Baseline = &DiffSummary{Baseline: d.Baseline.Baseline}
+			}
+			m.Baseline.New += d.Baseline.New
+			m.Baseline.Existing += d.Baseline.Existing
+			m.Baseline.Resolved += d.Baseline.Resolved
+		}
+
+		target := targets[i].Target
+		targets[i].Findings = len(doc.Findings)
+		if len(d.Technologies) > 0 {
+			targets[i].Technologies = d.Technologies.Names()
+		}
+		for _, f := range doc.Findings {
+			f.Target = target
+			combined.Findings = append(combined.Findings, f)
+		}
+		for _, f := range doc.Resolved {
+			f.Target = target
+			combined.Resolved = append(combined.Resolved, f)
+		}
+		for _, f := range doc.Suppressed {
+			f.Target = target
+			combined.Suppressed = append(combined.Suppressed, f)
+		}
+	}
+	m.Targets = targets
+	m.DurationSeconds = m.EndTime.Sub(m.StartTime).Round(time.Millisecond).Seconds()
+	m.FindingsTotal = len(combined.Findings)
+	return combined
+}
+
+// TargetsExitStatus returns the exit status of a scan of several targets and the condition that
+// produced it. As for one target, findings win over scan errors and scan errors over partial
+// scans; targets that could not be scanned at all (e.g., invalid options) count as scan errors.
+func TargetsExitStatus(targets []TargetInfo) (int, string) {
+	byStatus := make(map[int][]string)
+	for _, t := range targets {
+		status := t.ExitStatus
+		if status != ExitOK && status != ExitFindings && status != ExitPartial {
+			status = ExitScanError
+		}
+		byStatus[status] = append(byStatus[status], t.Target)
+	}
+	for _, s := range []struct {
+		status    int
+		condition string
+	}{
+		{ExitFindings, "findings met the failure threshold on"},
+		{ExitScanError, "scan errors on"},
+		{ExitPartial, "partial scans of"},
+	} {
+		if failed := byStatus[s.status]; len(failed) > 0 {
+			return s.status, fmt.Sprintf("%s %d of %d target(s): %s", s.condition, len(failed), len(targets), strings.Join(failed, ", "))
+		}
+	}
+	return ExitOK, fmt.Sprintf("%d target(s) scanned", len(targets))
+}
+
+// WriteJSONL writes the findings of doc to path, one JSON-encoded Finding per line.
+func WriteJSONL(doc *Document, path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	technologies := make(map[string][]string, len(doc.Metadata.Targets))
+	for _, t := range doc.Metadata.Targets {
+		technologies[t.Target] = t.Technologies
+	}
+	encoder := json.NewEncoder(file)
+	for _, f := range doc.Findings {
+		// As for one target, each line carries the technologies of its target.
+		if f.Technologies == nil {
+			f.Technologies = technologies[f.Target]
+		}
+		if err := encoder.Encode(f); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	return file.Close()
+}
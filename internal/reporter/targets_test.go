@@ -0,0 +1,89 @@
+package reporter
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombineDocuments(t *testing.T) {
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	docs := []*Document{
+		{
+			SchemaVersion: SchemaVersion,
+			Metadata: Metadata{
+				ToolVersion: "1.2.3", Target: "http://a.example", StartTime: start, EndTime: start.Add(time.Minute),
+				RequestsSent: 10, RequestsByScanner: map[string]int64{"XSS": 10},
+			},
+			Findings: []Finding{{ID: "1", Type: "XSS", URL: "http://a.example/?q=1"}},
+		},
+		nil, // The scan of the second target failed before writing its document.
+		{
+			SchemaVersion: SchemaVersion,
+			Metadata: Metadata{
+				ToolVersion: "1.2.3", Target: "http://c.example", StartTime: start.Add(time.Second), EndTime: start.Add(2 * time.Minute),
+				RequestsSent: 5, RequestsByScanner: map[string]int64{"XSS": 2, "SQLi": 3}, Interrupted: true,
+			},
+			Findings: []Finding{{ID: "2", Type: "SQLi", URL: "http://c.example/?id=1"}, {ID: "3", Type: "XSS", URL: "http://c.example/?q=1"}},
+		},
+	}
+	targets := []TargetInfo{
+		{Target: "http://a.example", ExitStatus: ExitOK},
+		{Target: "http://b.example", ExitStatus: ExitScanError},
+		{Target: "http://c.example", ExitStatus: ExitPartial},
+	}
+
+	combined := CombineDocuments(targets, docs)
+	m := combined.Metadata
+	assert.Empty(t, m.Target)
+	assert.Equal(t, "1.2.3", m.ToolVersion)
+	assert.Equal(t, start, m.StartTime)
+	assert.Equal(t, start.Add(2*time.Minute), m.EndTime)
+	assert.Equal(t, 120.0, m.DurationSeconds)
+	assert.True(t, m.Interrupted)
+	assert.Equal(t, int64(15), m.RequestsSent)
+	assert.Equal(t, map[string]int64{"XSS": 12, "SQLi": 3}, m.RequestsByScanner)
+	assert.Equal(t, 3, m.FindingsTotal)
+	require.Len(t, combined.Findings, 3)
+	assert.Equal(t, "http://a.example", combined.Findings[0].Target)
+	assert.Equal(t, "http://c.example", combined.Findings[2].Target)
+	require.Len(t, m.Targets, 3)
+	assert.Equal(t, []int{1, 0, 2}, []int{m.Targets[0].Findings, m.Targets[1].Findings, m.Targets[2].Findings})
+	assert.Empty(t, docs[0].Findings[0].Target, "the documents of the targets are not modified")
+}
+
+func TestTargetsExitStatus(t *testing.T) {
+	status, reason := TargetsExitStatus([]TargetInfo{{Target: "a", ExitStatus: ExitOK}, {Target: "b", ExitStatus: ExitOK}})
+	assert.Equal(t, ExitOK, status)
+	assert.Equal(t, "2 target(s) scanned", reason)
+
+	status, reason = TargetsExitStatus([]TargetInfo{
+		{Target: "a", ExitStatus: ExitPartial},
+		{Target: "b", ExitStatus: ExitUsage},
+		{Target: "c", ExitStatus: ExitScanError},
+	})
+	assert.Equal(t, ExitScanError, status)
+	assert.Equal(t, "scan errors on 2 of 3 target(s): b, c", reason)
+
+	status, _ = TargetsExitStatus([]TargetInfo{{Target: "a", ExitStatus: ExitScanError}, {Target: "b", ExitStatus: ExitFindings}})
+	assert.Equal(t, ExitFindings, status)
+}
+
+func TestBaselineForTarget(t *testing.T) {
+	doc := &Document{SchemaVersion: SchemaVersion, Findings: []Finding{
+		{Type: "XSS", URL: "http://a.example/?q=1", Fingerprint: "a", Target: "http://a.example"},
+		{Type: "XSS", URL: "http://b.example/?q=1", Fingerprint: "b", Target: "http://b.example"},
+		{Type: "SQLi", URL: "http://a.example/?id=1", Fingerprint: "c"},
+	}}
+	path := filepath.Join(t.TempDir(), "baseline.json")
+	require.NoError(t, WriteDocument(doc, path))
+	baseline, err := LoadBaseline(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, baseline.Len())
+	assert.Equal(t, 2, baseline.ForTarget("http://a.example").Len())
+	assert.Equal(t, 1, baseline.ForTarget("http://c.example").Len())
+}
@@ -0,0 +1,296 @@
+// Package retest re-tests the findings of a report ("dursgo -retest") without crawling: the
+// request each finding was found at is rebuilt from the report, and only the scanner that
+// reported it is run, against only the finding's parameter. A finding is then still vulnerable,
+// remediated, on an endpoint that is gone, or could not be re-tested.
+package retest
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/reporter"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/requtil"
+	"Dursgo/internal/verify"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Result is the outcome of re-testing a finding.
+type Result struct {
+	Status string                       // reporter.RetestStillVulnerable, RetestRemediated, ...
+	Reason string                       // Why the finding was not re-tested.
+	Fresh  *scanner.VulnerabilityResult // The vulnerability as found again, when still vulnerable.
+}
+
+// Retester re-tests findings with the modules of the scanner registry, using a client
+// configured like the scan (authentication, rate limit, proxy, TLS).
+type Retester struct {
+	client  *httpclient.Client
+	log     *logger.Logger
+	opts    scanner.ScannerOptions
+	env     scanner.Env
+	modules map[string]scanner.SelectedModule // Keyed by module name and scanner display name.
+}
+
+// NewRetester creates a Retester running the scanners of modules. Findings are matched to their
+// module by the scanner name they record, a module name or a scanner display name. If
+// opts.RequestsPerSecond is set, the rate limit is applied to client.
+func NewRetester(client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, modules []scanner.SelectedModule, env scanner.Env) *Retester {
+	if opts.RequestsPerSecond > 0 {
+		client.SetRateLimit(opts.RequestsPerSecond)
+	}
+	r := &Retester{client: client, log: log, opts: opts, env: env, modules: make(map[string]scanner.SelectedModule)}
+	for _, module := range modules {
+		r.modules[module.Name] = module
+		switch {
+		case module.New != nil:
+			r.modules[module.New(env).Name()] = module
+		case module.NewPassive != nil:
+			r.modules[module.NewPassive(env).Name()] = module
+		}
+	}
+	return r
+}
+
+// Retest rebuilds the request of f, checks that its endpoint still exists and runs the scanner
+// that reported f on it. Scanners are instantiated for each finding, so that those checking a
+// host only once check it again.
+func (r *Retester) Retest(ctx context.Context, f reporter.Finding) Result {
+	module, ok := r.modules[f.Scanner]
+	switch {
+	case f.Scanner == "":
+		return notRetested("the finding does not record the scanner that reported it")
+	case !ok:
+		return notRetested(fmt.Sprintf("no scanner named %q is registered", f.Scanner))
+	case module.New == nil:
+		return notRetested(fmt.Sprintf("%s is a passive scanner, which only analyzes crawled responses", module.Name))
+	case (module.Requires == scanner.RequiresOAST && !r.env.OAST) || (module.Requires == scanner.RequiresRenderer && !r.env.Renderer):
+		return notRetested(fmt.Sprintf("%s requires %s", module.Name, module.Requires))
+	}
+	req, err := Request(f)
+	if err != nil {
+		return notRetested(err.Error())
+	}
+
+	params, err := requtil.Params(req)
+	if err != nil {
+		return notRetested(fmt.Sprintf("the request cannot be built: %v", err))
+	}
+	client := r.client.WithSource("scan", module.Name).WithContext(ctx)
+	status, _, err := requtil.Send(ctx, req, client, params)
+	switch {
+	case err != nil && status == 0:
+		return notRetested(fmt.Sprintf("the request failed: %v", err))
+	case status == http.StatusNotFound || status == http.StatusGone:
+		return Result{Status: reporter.RetestEndpointGone, Reason: fmt.Sprintf("%s %s answers %d", req.Method, req.URL, status)}
+	}
+
+	opts := r.opts
+	opts.PoCExtraction = false // Detection is enough to tell whether the finding is remediated.
+	if len(req.Headers) > 0 || len(req.Cookies) > 0 {
+		opts.InjectHeaders = true
+	}
+	findings, err := module.New(r.env).Scan(ctx, req, client, r.log, opts)
+	scanner.Classify(findings)
+	opts.Overrides.Apply(findings)
+	if fresh := match(f, findings); fresh != nil {
+		return Result{Status: reporter.RetestStillVulnerable, Fresh: fresh}
+	}
+	if err != nil {
+		return notRetested(fmt.Sprintf("the scan failed: %v", err))
+	}
+	return Result{Status: reporter.RetestRemediated}
+}
+
+// notRetested is the Result of a finding that could not be re-tested.
+func notRetested(reason string) Result {
+	return Result{Status: reporter.RetestNotRetested, Reason: reason}
+}
+
+// match returns the finding of findings that reports f again: the same vulnerability type on the
+// same parameter, or, for a finding on a parameter, any type on that parameter (e.g., a SQL
+// injection found error-based instead of time-based).
+func match(f reporter.Finding, findings []scanner.VulnerabilityResult) *scanner.VulnerabilityResult {
+	for i, v := range findings {
+		if v.Parameter == f.Parameter && v.VulnerabilityType == f.Type {
+			return &findings[i]
+		}
+	}
+	if f.Parameter == "" {
+		return nil
+	}
+	for i, v := range findings {
+		if v.Parameter == f.Parameter {
+			return &findings[i]
+		}
+	}
+	return nil
+}
+
+// Request rebuilds the request f was found at, with f's parameter as its only parameter and the
+// payload taken out of its value. It is built from the request without the payload of f's
+// reproduction when there is one, else from the request carrying the payload (reproduction or
+// raw request), else from f's URL.
+func Request(f reporter.Finding) (crawler.ParameterizedRequest, error) {
+	source, err := sourceRequest(f)
+	if err != nil {
+		return crawler.ParameterizedRequest{}, err
+	}
+	u, err := url.Parse(source.URL)
+	if err != nil || !u.IsAbs() {
+		return crawler.ParameterizedRequest{}, fmt.Errorf("invalid URL %q", source.URL)
+	}
+	req := crawler.ParameterizedRequest{Method: strings.ToUpper(source.Method), ContentType: header(source.Headers, "Content-Type")}
+	if req.Method == "" {
+		req.Method = http.MethodGet
+	}
+	strip := func(value string) string {
+		return withoutPayload(value, f.Payload)
+	}
+
+	name := f.Parameter
+	switch location := strings.ToLower(f.Location); {
+	case name == "":
+		// Findings on a URL or a host are re-tested on the request as recorded.
+		if req.IsJSON() {
+			req.RawBody = source.Body
+		} else {
+			req.FormPostData = source.Body
+		}
+	case location == "header":
+		req.Headers = map[string]string{name: strip(header(source.Headers, name))}
+	case location == "cookie":
+		req.Cookies = map[string]string{name: strip(cookieValue(header(source.Headers, "Cookie"), name))}
+	case location == "path":
+		// The path of the finding's URL holds the original segment; that of a request carrying
+		// the payload does not.
+		if found, err := url.Parse(f.URL); err == nil && found.Path != "" {
+			u.Path, u.RawPath = found.Path, found.RawPath
+		}
+		for _, param := range crawler.PathParamsOf(u.Path) {
+			if param.Name == name {
+				req.PathParams = []crawler.PathParam{param}
+			}
+		}
+		if len(req.PathParams) == 0 {
+			return crawler.ParameterizedRequest{}, fmt.Errorf("path parameter %q not found in %s", name, u.Path)
+		}
+	case req.Method == http.MethodGet:
+		query := u.Query()
+		if !query.Has(name) {
+			return crawler.ParameterizedRequest{}, fmt.Errorf("parameter %q not found in the query of %s", name, u.Redacted())
+		}
+		query.Set(name, strip(query.Get(name)))
+		u.RawQuery = query.Encode()
+		req.ParamNames, req.ParamLocations = []string{name}, []string{"query"}
+	case req.IsMultipart():
+		return crawler.ParameterizedRequest{}, errors.New("multipart requests cannot be rebuilt from a report")
+	case req.IsJSON():
+		req.RawBody = strings.Replace(source.Body, jsonEscape(f.Payload), "", 1)
+		params, err := requtil.Params(req)
+		if err != nil || !params.Has(name) {
+			return crawler.ParameterizedRequest{}, fmt.Errorf("parameter %q not found in the JSON body", name)
+		}
+		req.ParamNames, req.ParamLocations = []string{name}, []string{"json"}
+	default:
+		form, err := url.ParseQuery(source.Body)
+		if err != nil || !form.Has(name) {
+			return crawler.ParameterizedRequest{}, fmt.Errorf("parameter %q not found in the request body", name)
+		}
+		form.Set(name, strip(form.Get(name)))
+		req.FormPostData = form.Encode()
+		req.ParamNames, req.ParamLocations = []string{name}, []string{"body"}
+	}
+	req.URL, req.Path = u.String(), u.Path
+	return req, nil
+}
+
+// sourceRequest returns the recorded request Request starts from.
+func sourceRequest(f reporter.Finding) (scanner.ReplayRequest, error) {
+	if repro := f.Reproduction; repro != nil {
+		if repro.Baseline != nil {
+			return *repro.Baseline, nil
+		}
+		return repro.Request, nil
+	}
+	if request, err := verify.ReplayFromRaw(f); err == nil {
+		return request, nil
+	}
+	if f.URL == "" {
+		return scanner.ReplayRequest{}, errors.New("the finding has no URL")
+	}
+	return scanner.ReplayRequest{Method: http.MethodGet, URL: f.URL}, nil
+}
+
+// withoutPayload takes payload out of value. A value that was all payload is replaced with a
+// neutral "1".
+func withoutPayload(value, payload string) string {
+	if payload != "" {
+		value = strings.Replace(value, payload, "", 1)
+	}
+	if value == "" {
+		return "1"
+	}
+	return value
+}
+
+// jsonEscape returns s as it appears inside a JSON string.
+func jsonEscape(s string) string {
+	data, _ := json.Marshal(s)
+	return strings.TrimSuffix(strings.TrimPrefix(string(data), `"`), `"`)
+}
+
+// header returns the value of the header name in headers, whatever its case.
+func header(headers map[string]string, name string) string {
+	for key, value := range headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	return ""
+}
+
+// cookieValue returns the value of the cookie name in a Cookie header.
+func cookieValue(cookieHeader, name string) string {
+	for _, pair := range strings.Split(cookieHeader, ";") {
+		if value, ok := strings.CutPrefix(strings.TrimSpace(pair), name+"="); ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// Document builds the report of a retest of the findings of the report source, results[i]
+// being the outcome of findings[i]. Findings still vulnerable are reported with their fresh
+// evidence under their original ID and fingerprint, and those not re-tested as they were; both
+// are in Findings. Remediated findings and findings on endpoints that are gone are in Resolved.
+func Document(metadata reporter.Metadata, source string, findings []reporter.Finding, results []Result, opts reporter.FindingOptions) *reporter.Document {
+	summary := &reporter.RetestSummary{Source: source}
+	metadata.Retest = summary
+	doc := reporter.NewDocument(metadata, nil, opts)
+	for i, f := range findings {
+		result := results[i]
+		summary.Add(result.Status)
+		switch result.Status {
+		case reporter.RetestStillVulnerable:
+			fresh := reporter.NewFinding(*result.Fresh, opts)
+			fresh.ID, fresh.Fingerprint, fresh.Target = f.ID, f.Fingerprint, f.Target
+			fresh.RetestStatus = result.Status
+			doc.Findings = append(doc.Findings, fresh)
+		case reporter.RetestNotRetested:
+			f.RetestStatus, f.RetestReason = result.Status, result.Reason
+			doc.Findings = append(doc.Findings, f)
+		default:
+			f.RetestStatus = result.Status
+			doc.Resolved = append(doc.Resolved, f)
+		}
+	}
+	doc.Metadata.FindingsTotal = len(doc.Findings)
+	return doc
+}
@@ -0,0 +1,213 @@
+package retest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/reporter"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/requtil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestRebuildsTheInjectionPoint(t *testing.T) {
+	tests := []struct {
+		name    string
+		finding reporter.Finding
+		want    crawler.ParameterizedRequest
+		wantErr string
+	}{
+		{
+			name: "query parameter of a raw request",
+			finding: reporter.Finding{
+				URL: "http://example.com/item?id=1", Parameter: "id", Location: "query", Payload: "' OR '1'='1",
+				RawRequest: "GET /item?id=1%27+OR+%271%27%3D%271&sort=asc HTTP/1.1\r\nHost: example.com\r\nCookie: session=old\r\n\r\n",
+			},
+			want: crawler.ParameterizedRequest{Method: "GET", URL: "http://example.com/item?id=1&sort=asc", Path: "/item", ParamNames: []string{"id"}, ParamLocations: []string{"query"}},
+		},
+		{
+			name: "form parameter of a reproduction baseline",
+			finding: reporter.Finding{
+				URL: "http://example.com/login", Parameter: "user", Location: "body", Payload: "' AND SLEEP(5)-- ",
+				Reproduction: &scanner.Reproduction{
+					Check:    scanner.CheckTiming,
+					Request:  scanner.ReplayRequest{Method: "POST", URL: "http://example.com/login", Body: "user=admin%27+AND+SLEEP%285%29--+&pass=x"},
+					Baseline: &scanner.ReplayRequest{Method: "POST", URL: "http://example.com/login", Body: "user=admin&pass=x"},
+				},
+			},
+			want: crawler.ParameterizedRequest{Method: "POST", URL: "http://example.com/login", Path: "/login", FormPostData: "pass=x&user=admin", ParamNames: []string{"user"}, ParamLocations: []string{"body"}},
+		},
+		{
+			name: "JSON parameter",
+			finding: reporter.Finding{
+				URL: "http://example.com/api/search", Parameter: "filter.name", Location: "json", Payload: `"||1=1`,
+				Reproduction: &scanner.Reproduction{Request: scanner.ReplayRequest{
+					Method: "POST", URL: "http://example.com/api/search", Headers: map[string]string{"Content-Type": "application/json"},
+					Body: `{"filter":{"name":"bob\"||1=1"}}`,
+				}},
+			},
+			want: crawler.ParameterizedRequest{Method: "POST", URL: "http://example.com/api/search", Path: "/api/search", ContentType: "application/json", RawBody: `{"filter":{"name":"bob"}}`, ParamNames: []string{"filter.name"}, ParamLocations: []string{"json"}},
+		},
+		{
+			name:    "path parameter",
+			finding: reporter.Finding{URL: "http://example.com/users/42/orders", Parameter: "id", Location: "path", Payload: "42'"},
+			want:    crawler.ParameterizedRequest{Method: "GET", URL: "http://example.com/users/42/orders", Path: "/users/42/orders", PathParams: []crawler.PathParam{{Name: "id", Index: 1}}},
+		},
+		{
+			name: "header",
+			finding: reporter.Finding{URL: "http://example.com/", Parameter: "X-Forwarded-For", Location: "header", Payload: "' OR 1=1-- ",
+				Reproduction: &scanner.Reproduction{Request: scanner.ReplayRequest{Method: "GET", URL: "http://example.com/", Headers: map[string]string{"X-Forwarded-For": "' OR 1=1-- "}}}},
+			want: crawler.ParameterizedRequest{Method: "GET", URL: "http://example.com/", Path: "/", Headers: map[string]string{"X-Forwarded-For": "1"}},
+		},
+		{
+			name:    "parameter missing from the request",
+			finding: reporter.Finding{URL: "http://example.com/item?id=1", Parameter: "q", Location: "query"},
+			wantErr: `parameter "q" not found in the query`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := Request(tt.finding)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, req)
+		})
+	}
+}
+
+// echoScanner reports a SQL injection in every parameter whose value the server echoes as
+// "vulnerable".
+type echoScanner struct {
+	name string
+}
+
+func (s echoScanner) Name() string { return s.name }
+
+func (s echoScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, _ *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	params, err := requtil.Params(req)
+	if err != nil {
+		return nil, err
+	}
+	var findings []scanner.VulnerabilityResult
+	for _, name := range req.ParamNames {
+		_, body, err := requtil.Send(ctx, req, client, params)
+		if err != nil {
+			return findings, err
+		}
+		if body == "vulnerable" {
+			findings = append(findings, scanner.VulnerabilityResult{VulnerabilityType: "SQL Injection", URL: req.URL, Parameter: name, Location: "query", Evidence: body, ScannerName: s.name})
+		}
+	}
+	return findings, nil
+}
+
+// passiveScanner is a passive module, which cannot be re-tested.
+type passiveScanner struct{}
+
+func (passiveScanner) Name() string { return "Passive Scanner" }
+
+func (passiveScanner) ScanResponses([]crawler.CrawledResponse, *logger.Logger) []scanner.VulnerabilityResult {
+	return nil
+}
+
+func TestRetestStatuses(t *testing.T) {
+	var cookies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookies = append(cookies, r.Header.Get("Cookie"))
+		switch r.URL.Path {
+		case "/gone":
+			http.NotFound(w, r)
+		case "/fixed":
+			fmt.Fprint(w, "ok")
+		default:
+			fmt.Fprint(w, "vulnerable")
+		}
+	}))
+	defer server.Close()
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, AuthCookie: "session=abc"})
+	modules := []scanner.SelectedModule{
+		{Registration: scanner.Registration{Name: "echo", New: func(scanner.Env) scanner.Scanner { return echoScanner{"Echo Scanner"} }}},
+		{Registration: scanner.Registration{Name: "passive", NewPassive: func(scanner.Env) scanner.PassiveScanner { return passiveScanner{} }}},
+		{Registration: scanner.Registration{Name: "oast", Requires: scanner.RequiresOAST, New: func(scanner.Env) scanner.Scanner { return echoScanner{"OAST Scanner"} }}},
+	}
+	r := NewRetester(client, log, scanner.ScannerOptions{RequestsPerSecond: 100}, modules, scanner.Env{})
+
+	finding := func(path, scannerName string) reporter.Finding {
+		return reporter.Finding{Type: "SQL Injection", URL: server.URL + path + "?id=1", Parameter: "id", Location: "query", Payload: "'", Scanner: scannerName}
+	}
+	tests := []struct {
+		name       string
+		finding    reporter.Finding
+		wantStatus string
+		wantReason string
+	}{
+		{name: "still vulnerable", finding: finding("/item", "Echo Scanner"), wantStatus: reporter.RetestStillVulnerable},
+		{name: "matched by module name", finding: finding("/item", "echo"), wantStatus: reporter.RetestStillVulnerable},
+		{name: "remediated", finding: finding("/fixed", "Echo Scanner"), wantStatus: reporter.RetestRemediated},
+		{name: "endpoint gone", finding: finding("/gone", "Echo Scanner"), wantStatus: reporter.RetestEndpointGone, wantReason: "answers 404"},
+		{name: "passive scanner", finding: finding("/item", "Passive Scanner"), wantStatus: reporter.RetestNotRetested, wantReason: "passive scanner"},
+		{name: "missing requirement", finding: finding("/item", "oast"), wantStatus: reporter.RetestNotRetested, wantReason: "requires -oast"},
+		{name: "unknown scanner", finding: finding("/item", "Gone Scanner"), wantStatus: reporter.RetestNotRetested, wantReason: `no scanner named "Gone Scanner"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := r.Retest(context.Background(), tt.finding)
+			assert.Equal(t, tt.wantStatus, result.Status)
+			assert.Contains(t, result.Reason, tt.wantReason)
+			if tt.wantStatus == reporter.RetestStillVulnerable {
+				require.NotNil(t, result.Fresh)
+				assert.Equal(t, "vulnerable", result.Fresh.Evidence)
+				assert.Equal(t, server.URL+"/item?id=1", result.Fresh.URL, "the payload is taken out of the request")
+			}
+		})
+	}
+	for _, cookie := range cookies {
+		assert.Contains(t, cookie, "session=abc", "the configured session is sent")
+	}
+}
+
+func TestDocument(t *testing.T) {
+	findings := []reporter.Finding{
+		{ID: "a1", Fingerprint: "fa", Type: "SQL Injection", URL: "http://example.com/item?id=1", Parameter: "id", Evidence: "old"},
+		{ID: "b2", Fingerprint: "fb", Type: "Reflected XSS", URL: "http://example.com/search?q=x", Parameter: "q"},
+		{ID: "c3", Fingerprint: "fc", Type: "Exposed Sensitive Endpoint (Go pprof)", URL: "http://example.com/debug/pprof/"},
+		{ID: "d4", Fingerprint: "fd", Type: "Missing Security Header", URL: "http://example.com/"},
+	}
+	fresh := scanner.VulnerabilityResult{VulnerabilityType: "SQL Injection", URL: "http://example.com/item?id=1", Parameter: "id", Evidence: "new", ScannerName: "SQL Injection Scanner"}
+	results := []Result{
+		{Status: reporter.RetestStillVulnerable, Fresh: &fresh},
+		{Status: reporter.RetestRemediated},
+		{Status: reporter.RetestEndpointGone, Reason: "GET http://example.com/debug/pprof/ answers 404"},
+		{Status: reporter.RetestNotRetested, Reason: "headers is a passive scanner"},
+	}
+
+	doc := Document(reporter.Metadata{Target: "http://example.com"}, "report.json", findings, results, reporter.FindingOptions{})
+
+	assert.Equal(t, &reporter.RetestSummary{Source: "report.json", StillVulnerable: 1, Remediated: 1, EndpointGone: 1, NotRetested: 1}, doc.Metadata.Retest)
+	require.Len(t, doc.Findings, 2)
+	assert.Equal(t, 2, doc.Metadata.FindingsTotal)
+	assert.Equal(t, "a1", doc.Findings[0].ID, "a finding found again keeps its ID")
+	assert.Equal(t, "fa", doc.Findings[0].Fingerprint)
+	assert.Equal(t, "new", doc.Findings[0].Evidence, "with fresh evidence")
+	assert.Equal(t, reporter.RetestStillVulnerable, doc.Findings[0].RetestStatus)
+	assert.Equal(t, reporter.RetestNotRetested, doc.Findings[1].RetestStatus)
+	assert.Equal(t, "headers is a passive scanner", doc.Findings[1].RetestReason)
+	require.Len(t, doc.Resolved, 2)
+	assert.Equal(t, reporter.RetestRemediated, doc.Resolved[0].RetestStatus)
+	assert.Equal(t, reporter.RetestEndpointGone, doc.Resolved[1].RetestStatus)
+}
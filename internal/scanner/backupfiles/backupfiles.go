@@ -0,0 +1,370 @@
+package backupfiles
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"Dursgo/internal/scanner/secrets"
+	"Dursgo/internal/soft404"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ModuleName is the name of the backup file module in the scanner registry.
+const ModuleName = "backupfiles"
+
+const (
+	// defaultConfirmBytes is how much of a candidate artifact is read to confirm its content.
+	defaultConfirmBytes = 8192
+	// defaultMaxProbesPerHost bounds the candidate requests sent to one host.
+	defaultMaxProbesPerHost = 1000
+	// snippetRadius is the number of characters shown around the matched content.
+	snippetRadius = 60
+	// htmlSniffBytes is how much of a body is searched for the markup of a rendered page.
+	htmlSniffBytes = 512
+)
+
+// htmlPageRegex recognizes the start of a rendered HTML page.
+var htmlPageRegex = regexp.MustCompile(`(?i)<(!doctype html|html|head|body)[\s>]`)
+
+// artifactResponse is the start of a candidate artifact as fetched for confirmation.
+type artifactResponse struct {
+	status int
+	header http.Header
+	body   string // At most the confirm_bytes first bytes.
+	// compared is the body with the requested path replaced by a placeholder, so that soft-404
+	// pages reflecting it compare equal.
+	compared string
+	exchange scanner.Exchange
+}
+
+// candidate is a URL a backup copy of discovered content may be served at.
+type candidate struct {
+	url    *url.URL
+	origin string // The file or directory the candidate is a copy of.
+}
+
+// BackupFilesScanner implements the Scanner interface for backup, temporary and swap copies of
+// discovered files and archives of discovered directories (login.php~, login.php.bak,
+// .login.php.swp, /app.zip, web.config.bak). Candidates answering differently from the soft-404
+// baseline of their directory are fetched partially and reported only when they contain source
+// code, configuration or an archive; secrets inside them are reported as well.
+type BackupFilesScanner struct {
+	mu     sync.Mutex
+	probed map[string]bool // Candidate URLs already requested.
+	dirs   map[string]bool // Directories whose archives and generic files were queued.
+	// baselines are the soft-404 baselines of the directories; they also cap the requests per
+	// host. Created on the first scan, which brings the max_probes_per_host option.
+	baselines *soft404.Baselines[*artifactResponse]
+}
+
+// NewBackupFilesScanner creates a new instance of BackupFilesScanner.
+func NewBackupFilesScanner() *BackupFilesScanner {
+	return &BackupFilesScanner{
+		probed: make(map[string]bool),
+		dirs:   make(map[string]bool),
+	}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           ModuleName,
+		Order:          290,
+		DefaultEnabled: true,
+		Options: []scanner.OptionSpec{
+			{Name: "confirm_bytes", Type: scanner.OptionInt, Default: defaultConfirmBytes, Description: "Bytes of a candidate backup file fetched (range request) to confirm it holds source code, configuration or an archive"},
+			{Name: "max_probes_per_host", Type: scanner.OptionInt, Default: defaultMaxProbesPerHost, Description: "Cap on backup file candidates requested per host (0 = unlimited)"},
+		},
+		New: func(scanner.Env) scanner.Scanner { return NewBackupFilesScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *BackupFilesScanner) Name() string {
+	return "Backup File Scanner"
+}
+
+// Scan probes the backup variants of the requested file and, once per directory, the archives
+// of the directories leading to it and the generic backup files.
+func (s *BackupFilesScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	target, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, err
+	}
+	if target.Host == "" {
+		return nil, nil
+	}
+	candidates := s.candidatesFor(target)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	confirmBytes := opts.IntOption(ModuleName, "confirm_bytes", defaultConfirmBytes)
+	if confirmBytes <= 0 {
+		confirmBytes = defaultConfirmBytes
+	}
+	baselines := s.baselinesFor(log, opts.IntOption(ModuleName, "max_probes_per_host", defaultMaxProbesPerHost))
+	cmp := compare.New(opts, log, "Backup Files")
+	cmp.Mode = compare.Words
+	log.Debug("Backup Files: Testing %d candidates for %s", len(candidates), req.URL)
+
+	// A redirect of a candidate (to the login or home page) is never the artifact itself.
+	client = client.WithoutRedirects()
+
+	var findings []scanner.VulnerabilityResult
+	for _, c := range candidates {
+		if ctx.Err() != nil {
+			return findings, ctx.Err()
+		}
+		dir := c.url.ResolveReference(&url.URL{Path: "./"})
+		ext := extensionOf(path.Base(c.url.Path))
+		base := baselines.For(dir, ext, func(target string) (*artifactResponse, error) {
+			return fetchPartial(ctx, client, target, confirmBytes)
+		})
+		if !baselines.Take(c.url.Host) {
+			break
+		}
+		resp, err := fetchPartial(ctx, client, c.url.String(), confirmBytes)
+		if err != nil {
+			if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+				return findings, nil
+			}
+			log.Debug("Backup Files: Request for %s failed: %v", c.url, err)
+			continue
+		}
+		if resp.status != http.StatusOK && resp.status != http.StatusPartialContent {
+			continue
+		}
+		if base != nil && base.status == resp.status && !cmp.IsDifferent(base.compared, resp.compared) {
+			log.Debug("Backup Files: %s matches the soft-404 baseline of %s.", c.url, dir)
+			continue
+		}
+		if opts.Soft404.Match(ctx, c.url.String(), resp.status, resp.header.Get("Location"), resp.body) {
+			log.Debug("Backup Files: %s is the soft-404 page of its host.", c.url)
+			continue
+		}
+		kind, snippet, ok := confirmArtifact(resp.body)
+		if !ok {
+			log.Debug("Backup Files: %s exists but holds no source code, configuration or archive.", c.url)
+			continue
+		}
+		log.Success("Backup Files: Found %s (%s), a copy of %s", c.url, kind, c.origin)
+
+		leaked := secrets.NewSecretsScanner().ScanResponses([]crawler.CrawledResponse{{
+			URL: c.url.String(), StatusCode: resp.status, Header: resp.header, Body: resp.body,
+		}}, log)
+		findings = append(findings, newFinding(c, kind, snippet, resp, leaked))
+		findings = append(findings, leaked...)
+	}
+	return findings, nil
+}
+
+// candidatesFor returns the candidates of target not requested yet: the backup variants of its
+// file, then the archives and generic backup files of each directory leading to it.
+func (s *BackupFilesScanner) candidatesFor(target *url.URL) []candidate {
+	root := &url.URL{Scheme: target.Scheme, Host: target.Host, Path: "/"}
+	var urls []candidate
+	add := func(p, origin string) {
+		u := root.ResolveReference(&url.URL{Path: p})
+		urls = append(urls, candidate{url: u, origin: origin})
+	}
+
+	dir, name := path.Split(target.Path)
+	if dir == "" {
+		dir = "/"
+	}
+	// Only files with an extension: extensionless paths are mostly routes, not files on disk.
+	if ext := path.Ext(name); ext != "" && ext != name {
+		origin := root.ResolveReference(&url.URL{Path: dir + name}).String()
+		for _, suffix := range payloads.BackupSuffixes {
+			add(dir+name+suffix, origin)
+		}
+		stem := strings.TrimSuffix(name, ext)
+		for _, replacement := range payloads.BackupExtensions {
+			if replacement != ext {
+				add(dir+stem+replacement, origin)
+			}
+		}
+		for _, format := range payloads.SwapFileFormats {
+			add(dir+fmt.Sprintf(format, name), origin)
+		}
+	}
+
+	for d := dir; ; d = path.Dir(strings.TrimSuffix(d, "/")) {
+		d = strings.TrimSuffix(d, "/") + "/"
+		key := target.Scheme + "://" + target.Host + d
+		s.mu.Lock()
+		seen := s.dirs[key]
+		s.dirs[key] = true
+		s.mu.Unlock()
+		if !seen {
+			origin := root.ResolveReference(&url.URL{Path: d}).String()
+			// The archive of a directory sits next to it; the root's is named after the host.
+			archive := strings.TrimSuffix(d, "/")
+			if d == "/" {
+				archive = "/" + target.Hostname()
+			}
+			for _, ext := range payloads.DirArchiveExtensions {
+				add(archive+ext, origin)
+			}
+			for _, file := range payloads.BackupGenericFiles {
+				add(d+file, origin)
+			}
+		}
+		if d == "/" {
+			break
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fresh := urls[:0]
+	for _, c := range urls {
+		if key := c.url.String(); !s.probed[key] {
+			s.probed[key] = true
+			fresh = append(fresh, c)
+		}
+	}
+	return fresh
+}
+
+// baselinesFor returns the soft-404 baselines of the scanner, creating them with the probe cap
+// maxProbes on first use.
+func (s *BackupFilesScanner) baselinesFor(log *logger.Logger, maxProbes int) *soft404.Baselines[*artifactResponse] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.baselines == nil {
+		s.baselines = soft404.NewBaselines[*artifactResponse](log, "Backup Files", maxProbes)
+	}
+	return s.baselines
+}
+
+// fetchPartial requests the first limit bytes of target with a range request; servers ignoring
+// the range are cut off after limit bytes as well.
+func fetchPartial(ctx context.Context, client *httpclient.Client, target string, limit int) (*artifactResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=0-%d", limit-1))
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(limit)))
+	if err != nil && len(body) == 0 {
+		return nil, err
+	}
+	replacer := strings.NewReplacer(req.URL.EscapedPath(), "{path}", req.URL.Path, "{path}")
+	return &artifactResponse{
+		status:   resp.StatusCode,
+		header:   resp.Header,
+		body:     string(body),
+		compared: replacer.Replace(string(body)),
+		exchange: scanner.CaptureExchange(req, resp, body),
+	}, nil
+}
+
+// confirmArtifact reports whether body is the start of an archive, editor swap file, source
+// code or configuration, returning its kind and a snippet of the content that gave it away.
+// Apart from raw server-side code, matches in rendered HTML pages do not count: an error or
+// documentation page mentioning "password =" is not a backup file.
+func confirmArtifact(body string) (kind, snippet string, ok bool) {
+	for _, sig := range payloads.ArchiveSignatures {
+		if strings.HasPrefix(body, sig.Magic) {
+			return sig.Kind, fmt.Sprintf("%d bytes read, starting with %q", len(body), truncate(body, 16)), true
+		}
+	}
+	head := body
+	if len(head) > htmlSniffBytes {
+		head = head[:htmlSniffBytes]
+	}
+	isHTML := htmlPageRegex.MatchString(head)
+	for _, sig := range payloads.SourceSignatures {
+		if isHTML && !sig.Raw {
+			continue
+		}
+		if loc := sig.Regex.FindStringIndex(body); loc != nil {
+			return sig.Kind, snippetAround(body, loc[0], loc[1]), true
+		}
+	}
+	return "", "", false
+}
+
+// snippetAround returns the match with up to snippetRadius characters of context on its line.
+func snippetAround(body string, start, end int) string {
+	from := start - snippetRadius
+	if from < 0 {
+		from = 0
+	}
+	if nl := strings.LastIndexByte(body[from:start], '\n'); nl >= 0 {
+		from += nl + 1
+	}
+	to := end + snippetRadius
+	if to > len(body) {
+		to = len(body)
+	}
+	if nl := strings.IndexByte(body[end:to], '\n'); nl >= 0 {
+		to = end + nl
+	}
+	return strings.TrimSpace(body[from:to])
+}
+
+// truncate returns the first n bytes of s.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+// extensionOf returns the part of name that decides how a server handles it, so that each gets
+// its own baseline: "~" and "#" endings, or the extension.
+func extensionOf(name string) string {
+	switch {
+	case strings.HasSuffix(name, "~"):
+		return "~"
+	case strings.HasSuffix(name, "#"):
+		return "#"
+	}
+	return path.Ext(name)
+}
+
+// newFinding builds the finding of an exposed artifact; leaked are the secrets found inside it.
+func newFinding(c candidate, kind, snippet string, resp *artifactResponse, leaked []scanner.VulnerabilityResult) scanner.VulnerabilityResult {
+	details := fmt.Sprintf("%s is served as a file and contains %s. It is a backup, temporary or archive copy of %s, readable by anyone who guesses its name.", c.url, kind, c.origin)
+	if len(leaked) > 0 {
+		types := make([]string, 0, len(leaked))
+		for _, l := range leaked {
+			types = append(types, strings.TrimSuffix(strings.TrimPrefix(l.VulnerabilityType, "Sensitive Data Exposure ("), ")"))
+		}
+		details += fmt.Sprintf(" It leaks %d secret(s): %s.", len(leaked), strings.Join(types, ", "))
+	}
+	severity := "Medium"
+	if len(leaked) > 0 || strings.Contains(kind, "archive") || strings.Contains(kind, "credentials") || strings.Contains(kind, "connection string") {
+		severity = "High"
+	}
+	finding := scanner.VulnerabilityResult{
+		VulnerabilityType: "Exposed Backup File",
+		URL:               c.url.String(),
+		Details:           details,
+		Severity:          severity,
+		Evidence:          fmt.Sprintf("%s (status %d): %s", kind, resp.status, snippet),
+		Remediation:       "Remove backup, temporary and swap files and archives from the web root, deny access to such extensions in the server configuration, and rotate any credentials they contained.",
+		ScannerName:       ModuleName,
+	}
+	finding.SetExchange(resp.exchange)
+	return finding
+}
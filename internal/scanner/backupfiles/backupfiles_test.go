@@ -0,0 +1,107 @@
+package backupfiles
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupFilesScanner(t *testing.T) {
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/app/login.php":
+			fmt.Fprint(w, "<html><body><form>login</form></body></html>")
+		case "/app/login.php.bak":
+			ranges = append(ranges, r.Header.Get("Range"))
+			fmt.Fprint(w, "<?php\n$db = mysqli_connect('localhost', 'app', 'S3cr3t!');\n?>")
+		case "/app.zip":
+			fmt.Fprint(w, "PK\x03\x04\x14\x00\x00\x00\x08\x00")
+		case "/app/login.old":
+			// Exists, but only as a rendered page mentioning a password: not a backup.
+			fmt.Fprint(w, "<!DOCTYPE html><html><body>Forgot your password = click here</body></html>")
+		default:
+			// Soft-404: every other path answers 200 with a page reflecting the path.
+			fmt.Fprintf(w, "<html><body>Sorry, the page %s could not be found on this site.</body></html>", r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, FollowRedirects: true})
+	s := NewBackupFilesScanner()
+	req := crawler.ParameterizedRequest{URL: server.URL + "/app/login.php", Method: "GET"}
+
+	findings, err := s.Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+	require.NoError(t, err)
+
+	byURL := make(map[string]scanner.VulnerabilityResult)
+	for _, f := range findings {
+		if f.VulnerabilityType == "Exposed Backup File" {
+			byURL[f.URL] = f
+		}
+	}
+	require.Len(t, byURL, 2, "only the PHP backup and the archive are reported")
+	php := byURL[server.URL+"/app/login.php.bak"]
+	assert.Contains(t, php.Evidence, "PHP source")
+	assert.Contains(t, php.Evidence, "<?php")
+	assert.Contains(t, php.Details, server.URL+"/app/login.php")
+	assert.NotEmpty(t, php.RawRequest)
+	assert.Contains(t, byURL[server.URL+"/app.zip"].Evidence, "ZIP archive")
+	assert.Equal(t, "High", byURL[server.URL+"/app.zip"].Severity)
+	assert.Equal(t, []string{fmt.Sprintf("bytes=0-%d", defaultConfirmBytes-1)}, ranges)
+
+	// Candidates are requested once, however many requests lead to them.
+	findings, err = s.Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestConfirmArtifact(t *testing.T) {
+	kind, snippet, ok := confirmArtifact("# settings\nDB_PASSWORD = 'hunter2'\nDEBUG = False\n")
+	require.True(t, ok)
+	assert.Equal(t, "database credentials", kind)
+	assert.Equal(t, "DB_PASSWORD = 'hunter2'", snippet)
+
+	_, _, ok = confirmArtifact("<html><head></head><body>Set DB_PASSWORD = in your environment.</body></html>")
+	assert.False(t, ok, "credentials mentioned on a rendered page")
+
+	kind, _, ok = confirmArtifact("<html><body><% Response.Write(\"x\") %></body></html>")
+	require.True(t, ok)
+	assert.Equal(t, "ASP.NET/JSP source", kind)
+
+	_, _, ok = confirmArtifact("just some text")
+	assert.False(t, ok)
+}
+
+func TestDirListingScanner(t *testing.T) {
+	apache := `<html><head><title>Index of /uploads</title></head><body><h1>Index of /uploads</h1>
+<table><tr><th><a href="?C=N;O=D">Name</a></th></tr>
+<tr><td><a href="/">Parent Directory</a></td></tr>
+<tr><td><a href="report.pdf">report.pdf</a></td></tr>
+<tr><td><a href="db-2024.sql.gz">db-2024.sql.gz</a></td></tr>
+<tr><td><a href="images/">images/</a></td></tr></table></body></html>`
+	responses := []crawler.CrawledResponse{
+		{URL: "http://example.com/uploads/", StatusCode: 200, Body: apache},
+		{URL: "http://example.com/uploads/?C=N;O=D", StatusCode: 200, Body: apache},
+		{URL: "http://example.com/about", StatusCode: 200, Body: "<html><title>About</title><p>Index of /products is on the left.</p></html>"},
+	}
+
+	findings := NewDirListingScanner().ScanResponses(responses, logger.NewLogger(logger.ERROR))
+	require.Len(t, findings, 1)
+	f := findings[0]
+	assert.Equal(t, "Directory Listing", f.VulnerabilityType)
+	assert.Equal(t, "http://example.com/uploads/", f.URL)
+	assert.Equal(t, "Medium", f.Severity)
+	assert.Equal(t, "Apache directory listing with 3 entries: report.pdf, db-2024.sql.gz, images/", f.Evidence)
+	assert.Contains(t, f.Details, "db-2024.sql.gz")
+}
@@ -0,0 +1,143 @@
+package backupfiles
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"fmt"
+	"html"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// maxListedEntries bounds the entries of a directory listing quoted in a finding.
+const maxListedEntries = 15
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "dirlisting",
+		Order:          300,
+		DefaultEnabled: true,
+		NewPassive:     func(scanner.Env) scanner.PassiveScanner { return NewDirListingScanner() },
+	})
+}
+
+// DirListingScanner implements the PassiveScanner interface for directory listings generated by
+// the web server (Apache, nginx, IIS, ...) among crawled responses. Each listed directory is
+// reported once, with its entries; listings containing backup files or archives rank higher.
+type DirListingScanner struct{}
+
+// NewDirListingScanner creates a new instance of DirListingScanner.
+func NewDirListingScanner() *DirListingScanner {
+	return &DirListingScanner{}
+}
+
+// Name returns the scanner's name.
+func (s *DirListingScanner) Name() string {
+	return "Directory Listing Scanner"
+}
+
+// ScanResponses searches the crawled responses for directory listing pages.
+func (s *DirListingScanner) ScanResponses(responses []crawler.CrawledResponse, log *logger.Logger) []scanner.VulnerabilityResult {
+	reported := make(map[string]bool)
+	var findings []scanner.VulnerabilityResult
+	for _, resp := range responses {
+		if resp.StatusCode != 200 {
+			continue
+		}
+		server := listingServer(resp.Body)
+		if server == "" {
+			continue
+		}
+		// Sorting links (Apache's ?C=N;O=D) list the same directory again.
+		dir := resp.URL
+		if u, err := url.Parse(resp.URL); err == nil {
+			u.RawQuery, u.Fragment = "", ""
+			dir = u.String()
+		}
+		if reported[dir] {
+			continue
+		}
+		reported[dir] = true
+
+		entries := listingEntries(resp.Body)
+		log.Success("Directory Listing: %s lists %d entries (%s)", dir, len(entries), server)
+		findings = append(findings, newListingFinding(dir, server, entries))
+	}
+	return findings
+}
+
+// listingServer returns the server whose directory listing body is, or "" if it is none.
+func listingServer(body string) string {
+	for _, p := range payloads.DirListingPatterns {
+		if p.Regex.MatchString(body) {
+			return p.Server
+		}
+	}
+	return ""
+}
+
+// listingEntries returns the names listed in a directory listing, without the parent directory.
+func listingEntries(body string) []string {
+	seen := make(map[string]bool)
+	var entries []string
+	for _, m := range payloads.DirListingEntryRegex.FindAllStringSubmatch(body, -1) {
+		name := html.UnescapeString(m[1])
+		if unescaped, err := url.PathUnescape(name); err == nil {
+			name = unescaped
+		}
+		if name == "" || strings.HasPrefix(name, "..") || seen[name] {
+			continue
+		}
+		seen[name] = true
+		entries = append(entries, name)
+	}
+	return entries
+}
+
+// isBackupName reports whether a listed name looks like a backup copy or an archive.
+func isBackupName(name string) bool {
+	lower := strings.ToLower(name)
+	for _, suffix := range append(append([]string{}, payloads.BackupSuffixes...), payloads.DirArchiveExtensions...) {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return strings.HasSuffix(lower, ".sql") || strings.HasSuffix(lower, ".sql.gz")
+}
+
+// newListingFinding builds the finding of a directory listing.
+func newListingFinding(dir, server string, entries []string) scanner.VulnerabilityResult {
+	var backups []string
+	for _, e := range entries {
+		if isBackupName(e) {
+			backups = append(backups, e)
+		}
+	}
+	sort.Strings(backups)
+	details := fmt.Sprintf("The web server (%s) generates a listing of %s, revealing %d file(s) and directories that may not be linked anywhere.", server, dir, len(entries))
+	severity := "Low"
+	if len(backups) > 0 {
+		details += fmt.Sprintf(" The listing includes backup files or archives: %s.", strings.Join(backups, ", "))
+		severity = "Medium"
+	}
+	listed := entries
+	if len(listed) > maxListedEntries {
+		listed = listed[:maxListedEntries]
+	}
+	evidence := fmt.Sprintf("%s directory listing with %d entries: %s", server, len(entries), strings.Join(listed, ", "))
+	if len(entries) > len(listed) {
+		evidence += ", ..."
+	}
+	return scanner.VulnerabilityResult{
+		VulnerabilityType: "Directory Listing",
+		URL:               dir,
+		Details:           details,
+		Severity:          severity,
+		Evidence:          evidence,
+		Remediation:       "Disable automatic directory indexes in the web server (Options -Indexes, autoindex off, directoryBrowse enabled=\"false\") and remove files that are not meant to be served.",
+		ScannerName:       "dirlisting",
+	}
+}
@@ -5,11 +5,11 @@ import (
 	"Dursgo/internal/httpclient"
 	"Dursgo/internal/logger"
 	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/requtil"
+	"context"
 	"fmt"
-	"io"
 	"math/rand"
 	"net/http"
-	"net/url"
 	"regexp"
 	"strings"
 	"sync"
@@ -24,6 +24,18 @@ func NewBlindSSRFScanner() *BlindSSRFScanner {
 	return &BlindSSRFScanner{}
 }
 
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "blindssrf",
+		Order:          170,
+		DefaultEnabled: true,
+		Requires:       scanner.RequiresOAST,
+		// Callbacks come from fetches that leave the response unchanged.
+		TestsInertParams: true,
+		New:              func(scanner.Env) scanner.Scanner { return NewBlindSSRFScanner() },
+	})
+}
+
 // Name returns the scanner's name.
 func (s *BlindSSRFScanner) Name() string {
 	return "Blind SSRF Scanner (OAST)"
@@ -58,7 +70,8 @@ var commonSSRFHeaders = []string{
 }
 
 // Scan injects OAST payloads for out-of-band detection.
-func (s *BlindSSRFScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+func (s *BlindSSRFScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
 	if opts.OASTDomain == "" || opts.OASTCorrelationMap == nil {
 		return nil, nil
 	}
@@ -69,6 +82,9 @@ func (s *BlindSSRFScanner) Scan(req crawler.ParameterizedRequest, client *httpcl
 
 	// --- Test Parameters (Query and Body) ---
 	for _, paramName := range req.ParamNames {
+		if opts.SkipParam("blindssrf", paramName) {
+			continue
+		}
 		if !isPotentialSSRFParam(paramName) {
 			continue
 		}
@@ -77,14 +93,14 @@ func (s *BlindSSRFScanner) Scan(req crawler.ParameterizedRequest, client *httpcl
 				continue
 			}
 			wg.Add(1)
-			go s.testParameterInjection(&wg, req, client, log, opts, paramName, paramLoc)
+			go s.testParameterInjection(ctx, &wg, req, client, log, opts, paramName, paramLoc)
 		}
 	}
 
 	// --- Test Headers ---
 	for _, headerName := range commonSSRFHeaders {
 		wg.Add(1)
-		go s.testHeaderInjection(&wg, req, client, log, opts, headerName)
+		go s.testHeaderInjection(ctx, &wg, req, client, log, opts, headerName)
 	}
 
 	wg.Wait()
@@ -92,7 +108,7 @@ func (s *BlindSSRFScanner) Scan(req crawler.ParameterizedRequest, client *httpcl
 }
 
 // testParameterInjection handles the logic for testing a single parameter.
-func (s *BlindSSRFScanner) testParameterInjection(wg *sync.WaitGroup, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, paramName, paramLoc string) {
+func (s *BlindSSRFScanner) testParameterInjection(ctx context.Context, wg *sync.WaitGroup, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, paramName, paramLoc string) {
 	defer wg.Done()
 	oastPayloads := generateOASTPayloads(opts.OASTDomain, fmt.Sprintf("ssrf-param-%s", paramName))
 
@@ -111,20 +127,22 @@ func (s *BlindSSRFScanner) testParameterInjection(wg *sync.WaitGroup, req crawle
 		}
 		opts.OASTCorrelationMap.Store(extractCorrelationID(payload), potentialVuln)
 
-		testURL, reqBody := buildRequestComponents(req, paramName, payload)
-		httpRequest, _ := http.NewRequest(req.Method, testURL, reqBody)
-		addCommonHeaders(httpRequest) // Make the request look legitimate
-		if req.Method == "POST" {
-			httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		httpRequest, err := requtil.Override(ctx, req, paramName, payload)
+		if err != nil {
+			log.Debug("BlindSSRF: Could not build request for param '%s': %v", paramName, err)
+			return
 		}
+		addCommonHeaders(httpRequest) // Make the request look legitimate
 
 		log.Debug("BlindSSRF: Injecting OAST payload '%s' into param '%s'", payload, paramName)
-		client.Do(httpRequest)
+		if resp, err := client.Do(httpRequest); err == nil {
+			resp.Body.Close() // Also frees the host slot of the per-host concurrency cap.
+		}
 	}
 }
 
 // testHeaderInjection handles the logic for testing a single header.
-func (s *BlindSSRFScanner) testHeaderInjection(wg *sync.WaitGroup, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, headerName string) {
+func (s *BlindSSRFScanner) testHeaderInjection(ctx context.Context, wg *sync.WaitGroup, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, headerName string) {
 	defer wg.Done()
 	oastPayloads := generateOASTPayloads(opts.OASTDomain, fmt.Sprintf("ssrf-header-%s", strings.ToLower(headerName)))
 
@@ -144,48 +162,28 @@ func (s *BlindSSRFScanner) testHeaderInjection(wg *sync.WaitGroup, req crawler.P
 		opts.OASTCorrelationMap.Store(extractCorrelationID(payload), potentialVuln)
 
 		// Send a single, well-formed attack request.
-		attackURL, attackBody := buildRequestComponents(req, "", "")
-		attackReq, _ := http.NewRequest(req.Method, attackURL, attackBody)
-		addCommonHeaders(attackReq) // Make the request look legitimate
-		if req.Method == "POST" {
-			attackReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		params, err := requtil.Params(req)
+		if err != nil {
+			log.Debug("BlindSSRF: Could not build request for header '%s': %v", headerName, err)
+			return
 		}
+		attackReq, err := requtil.New(ctx, req, params)
+		if err != nil {
+			log.Debug("BlindSSRF: Could not build request for header '%s': %v", headerName, err)
+			return
+		}
+		addCommonHeaders(attackReq) // Make the request look legitimate
 		attackReq.Header.Set(headerName, payload)
 
 		log.Debug("BlindSSRF: Injecting OAST payload '%s' into header '%s'", payload, headerName)
-		client.Do(attackReq)
+		if resp, err := client.Do(attackReq); err == nil {
+			resp.Body.Close() // Also frees the host slot of the per-host concurrency cap.
+		}
 		// A small delay is still useful to avoid overwhelming the server and the OAST service.
 		time.Sleep(200 * time.Millisecond)
 	}
 }
 
-// buildRequestComponents is a helper to build the request with the payload.
-func buildRequestComponents(req crawler.ParameterizedRequest, paramToInject, valueToInject string) (string, io.Reader) {
-	var originalParams url.Values
-	if req.Method == "GET" {
-		p, _ := url.Parse(req.URL)
-		originalParams = p.Query()
-	} else {
-		originalParams, _ = url.ParseQuery(req.FormPostData)
-	}
-
-	testParams := url.Values{}
-	for k, v := range originalParams {
-		testParams[k] = v
-	}
-
-	if paramToInject != "" {
-		testParams.Set(paramToInject, valueToInject)
-	}
-
-	if req.Method == "GET" {
-		baseURL, _ := url.Parse(req.URL)
-		baseURL.RawQuery = testParams.Encode()
-		return baseURL.String(), nil
-	}
-	return req.URL, strings.NewReader(testParams.Encode())
-}
-
 // generateOASTPayloads creates a slice of various OAST payloads for a given base domain and prefix.
 func generateOASTPayloads(oastDomain, prefix string) []string {
 	correlationID := fmt.Sprintf("%s-%d", prefix, rand.Intn(1e9))
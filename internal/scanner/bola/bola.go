@@ -6,8 +6,9 @@ import (
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/requtil"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"regexp"
@@ -43,6 +44,15 @@ func NewBOLAScanner() *BOLAScanner {
 	}
 }
 
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "bola",
+		Order:          150,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewBOLAScanner() },
+	})
+}
+
 // Name returns the scanner's name.
 func (s *BOLAScanner) Name() string {
 	return "Broken Object Level Authorization (BOLA) Scanner"
@@ -52,7 +62,8 @@ func (s *BOLAScanner) Name() string {
 // This function identifies potential BOLA vulnerabilities by manipulating numeric IDs
 // in URL paths and observing the application's response. It skips public API paths
 // and non-sensitive resources to focus on relevant targets.
-func (s *BOLAScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+func (s *BOLAScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
 	// --- IMPROVEMENT 1: Extract Path and run more flexible Regex ---
 	parsedURL, err := url.Parse(req.URL)
 	if err != nil {
@@ -97,7 +108,7 @@ func (s *BOLAScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.
 	log.Debug("BOLA: Testing endpoint: %s (Baseline ID: %d)", genericPath, originalID)
 
 	// Get baseline with the original URL provided by the crawler.
-	baselineBody, err := sendRequestAndGetBody(req.URL, client)
+	baselineBody, err := sendRequestAndGetBody(ctx, req.URL, client)
 	if err != nil || containsNegativeKeyword(baselineBody) {
 		log.Debug("BOLA: Could not get a valid baseline response for ID %d.", originalID)
 		return nil, nil
@@ -122,7 +133,7 @@ func (s *BOLAScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.
 		testURL := parsedURL.Scheme + "://" + parsedURL.Host + testPath
 
 		log.Debug("BOLA: Testing with URL: %s", testURL)
-		testBody, err := sendRequestAndGetBody(testURL, client)
+		testBody, err := sendRequestAndGetBody(ctx, testURL, client)
 		if err != nil {
 			continue
 		}
@@ -173,30 +184,20 @@ func isSensitiveResource(p string) bool {
 	return false
 }
 
-// sendRequest sends an HTTP GET request to the target URL.
-// It's a helper function to encapsulate the HTTP request creation and execution.
-func sendRequest(client *httpclient.Client, targetURL string) (*http.Response, error) {
-	httpReq, err := http.NewRequest("GET", targetURL, nil)
-	if err != nil {
-		return nil, err
-	}
-	return client.Do(httpReq)
-}
-
 // sendRequestAndGetBody sends an HTTP GET request and returns the response body as a string.
-// It handles error checking for the request and response, and reads the entire response body.
-func sendRequestAndGetBody(targetURL string, client *httpclient.Client) (string, error) {
-	resp, err := sendRequest(client, targetURL)
+// A response other than 200 or a truncated body is an error, since the bodies are compared.
+func sendRequestAndGetBody(ctx context.Context, targetURL string, client *httpclient.Client) (string, error) {
+	req := crawler.ParameterizedRequest{Method: "GET", URL: targetURL}
+	params, err := requtil.Params(req)
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("non-200 status code: %d", resp.StatusCode)
-	}
-	bodyBytes, err := io.ReadAll(resp.Body)
+	status, body, err := requtil.Send(ctx, req, client, params)
 	if err != nil {
 		return "", err
 	}
-	return string(bodyBytes), nil
+	if status != http.StatusOK {
+		return "", fmt.Errorf("non-200 status code: %d", status)
+	}
+	return body, nil
 }
@@ -0,0 +1,291 @@
+// Package bruteforce audits login forms for protection against password guessing: rate
+// limiting, CAPTCHAs, account lockout or growing delays after repeated failed logins.
+package bruteforce
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"Dursgo/internal/scanner/requtil"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModuleName selects the scanner (-s) and holds its options in config.yaml.
+const ModuleName = "bruteforce"
+
+// Option defaults.
+const (
+	defaultAttempts         = 20
+	defaultMaxTotalAttempts = 100
+)
+
+// A response slower than delayFactor times the first responses, and by at least minDelay, means
+// the application delays failed logins.
+const (
+	delayFactor = 3
+	minDelay    = time.Second
+)
+
+// referenceAttempts is the number of first attempts whose response times are the reference of
+// the delay check.
+const referenceAttempts = 3
+
+// BruteForceScanner implements the Scanner interface for missing brute-force protection on
+// login forms.
+type BruteForceScanner struct {
+	mu        sync.Mutex
+	forms     map[string]bool // Login forms tested, by method and URL without query.
+	sent      int             // Failed logins sent by all bursts, capped by max_total_attempts.
+	burstMu   sync.Mutex      // One burst at a time, so that two forms do not trip the same limit.
+	protected map[string]bool // Lowercase user names of the configured logins.
+}
+
+// NewBruteForceScanner creates a new instance of BruteForceScanner. The bursts never log in as
+// one of loginUsernames, the accounts configured for the scan.
+func NewBruteForceScanner(loginUsernames []string) *BruteForceScanner {
+	s := &BruteForceScanner{forms: make(map[string]bool), protected: make(map[string]bool)}
+	for _, name := range loginUsernames {
+		s.protected[strings.ToLower(name)] = true
+	}
+	return s
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name: ModuleName,
+		// Last, so that a host throttling the scanner's address after a burst does not hold up
+		// the other modules.
+		Order:          330,
+		DefaultEnabled: true,
+		Options: []scanner.OptionSpec{
+			{Name: "attempts", Type: scanner.OptionInt, Default: defaultAttempts, Description: "Failed logins sent to each login form; a form answering all of them alike has no protection"},
+			{Name: "max_total_attempts", Type: scanner.OptionInt, Default: defaultMaxTotalAttempts, Description: "Failed logins sent to all login forms together; forms found once it is reached are not tested"},
+		},
+		New: func(env scanner.Env) scanner.Scanner { return NewBruteForceScanner(env.LoginUsernames) },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *BruteForceScanner) Name() string {
+	return "Brute-Force Protection Scanner"
+}
+
+// attempt is a failed login of a burst.
+type attempt struct {
+	status  int
+	body    string
+	elapsed time.Duration
+	header  http.Header
+}
+
+// String describes the attempt in the attempt log of a finding.
+func (a attempt) String() string {
+	return fmt.Sprintf("HTTP %d, %d bytes, %v", a.status, len(a.body), a.elapsed.Round(time.Millisecond))
+}
+
+// Scan sends a burst of failed logins for a made-up account to each login form, once per form,
+// and reports the forms that answer every attempt alike: no 429, CAPTCHA, lockout message,
+// changed response or growing delay.
+func (s *BruteForceScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	if req.Method == "GET" {
+		return nil, nil
+	}
+	params, err := requtil.Params(req)
+	if err != nil {
+		return nil, nil
+	}
+	userField, passwordFields := loginFields(params)
+	if userField == "" || len(passwordFields) == 0 {
+		return nil, nil
+	}
+
+	key := req.Method + " " + formURL(req.URL)
+	attempts := max(opts.IntOption(ModuleName, "attempts", defaultAttempts), referenceAttempts+1)
+	maxTotal := opts.IntOption(ModuleName, "max_total_attempts", defaultMaxTotalAttempts)
+	s.mu.Lock()
+	if s.forms[key] {
+		s.mu.Unlock()
+		return nil, nil
+	}
+	s.forms[key] = true
+	if s.sent+attempts > maxTotal {
+		s.mu.Unlock()
+		log.Debug("Brute force: Skipping %s, the cap of %d failed logins (max_total_attempts) is reached", key, maxTotal)
+		opts.Coverage.SkipRequest(ModuleName, req, scanner.SkipReasonBudget)
+		return nil, nil
+	}
+	s.sent += attempts
+	s.mu.Unlock()
+
+	username := payloads.GenerateBruteForceUsername()
+	for s.protected[strings.ToLower(username)] {
+		username = payloads.GenerateBruteForceUsername()
+	}
+	params.Set(userField, username)
+	password := payloads.GenerateBruteForcePassword()
+	for _, field := range passwordFields {
+		params.Set(field, password)
+	}
+
+	s.burstMu.Lock()
+	defer s.burstMu.Unlock()
+	// Applications may end the session on a failed login; the scan goes on with its own.
+	savedSession := client.SnapshotSession()
+	defer client.RestoreSession(savedSession)
+	burst := client.WithContext(ctx).WithRateLimitExemption()
+	cmp := compare.New(opts, log, "Brute force")
+
+	log.Debug("Brute force: Sending %d failed logins for '%s' to %s", attempts, username, key)
+	var sent []attempt
+	var exchange scanner.Exchange
+	for i := 0; i < attempts; i++ {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		start := time.Now()
+		httpReq, resp, body, err := requtil.Do(ctx, req, burst, params)
+		elapsed := time.Since(start)
+		if resp == nil {
+			if httpclient.IsTransient(err) && len(sent) > 0 {
+				log.Debug("Brute force: %s refused attempt %d (%v); the host protects the form", key, i+1, err)
+			}
+			return nil, nil
+		}
+		if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
+			return nil, nil
+		}
+		a := attempt{status: resp.StatusCode, body: string(body), elapsed: elapsed, header: resp.Header}
+		if i == 0 && !isCredentialCheck(a) {
+			log.Debug("Brute force: %s answered the first failed login with %s, skipping", key, a)
+			return nil, nil
+		}
+		if len(sent) > 0 {
+			if signal := protectionSignal(sent, a, cmp); signal != "" {
+				log.Debug("Brute force: %s is protected (%s after %d attempts)", key, signal, i+1)
+				return nil, nil
+			}
+		}
+		sent = append(sent, a)
+		exchange = scanner.CaptureExchange(httpReq, resp, body)
+	}
+
+	log.Success("Brute force: %s accepted %d failed logins without any protection", key, attempts)
+	var attemptLog []string
+	for i, a := range sent {
+		attemptLog = append(attemptLog, fmt.Sprintf("Attempt %d: %s", i+1, a))
+	}
+	vuln := scanner.VulnerabilityResult{
+		VulnerabilityType: "Missing Brute-Force Protection",
+		URL:               req.URL,
+		Parameter:         requtil.DisplayName(userField),
+		Payload:           fmt.Sprintf("%d failed logins for %s", attempts, username),
+		Location:          requtil.Location(req, userField),
+		Details: fmt.Sprintf("The login form accepted %d consecutive failed logins for the made-up account '%s' and answered every attempt alike: "+
+			"no HTTP 429, no CAPTCHA, no lockout message, no change of the response and no growing delay. Attackers can guess passwords at the rate the server answers.", attempts, username),
+		Severity:    "Medium",
+		Evidence:    strings.Join(attemptLog, "\n"),
+		Remediation: "Limit failed logins per account and per client address (e.g., answer 429 or require a CAPTCHA after a few failures, with growing delays or a temporary lockout), and alert on credential stuffing.",
+		ScannerName: s.Name(),
+	}
+	vuln.SetExchange(exchange)
+	return []scanner.VulnerabilityResult{vuln}, nil
+}
+
+// loginFields returns the user field and the password fields of a login form, or "" and nil if
+// params is not a login form.
+func loginFields(params url.Values) (string, []string) {
+	var userField string
+	var passwordFields []string
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lower := strings.ToLower(name)
+		switch {
+		case payloads.LoginUserParams[lower] && userField == "":
+			userField = name
+		case payloads.LoginPasswordParams[lower] || strings.Contains(lower, "password"):
+			passwordFields = append(passwordFields, name)
+		}
+	}
+	return userField, passwordFields
+}
+
+// formURL returns rawURL without its query, which login forms rarely depend on.
+func formURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.RawQuery, u.Fragment = "", ""
+	return u.String()
+}
+
+// isCredentialCheck reports whether the first failed login of a burst reached the credential
+// check: a stale anti-CSRF token or a malformed request is refused with the same response
+// every time, which would pass for an unprotected form. A form asking for a CAPTCHA from the
+// start is protected.
+func isCredentialCheck(first attempt) bool {
+	switch {
+	case first.status == http.StatusBadRequest, first.status == http.StatusForbidden, first.status == http.StatusNotFound,
+		first.status == http.StatusMethodNotAllowed, first.status == 419, first.status == http.StatusTooManyRequests,
+		first.status >= 500:
+		return false
+	}
+	_, captcha := findMarker(first.body, payloads.BruteForceCaptchaMarkers)
+	return !captcha
+}
+
+// protectionSignal returns how the response to a failed login shows a protection, compared with
+// the responses to the attempts sent before, or "" if it shows none.
+func protectionSignal(before []attempt, a attempt, cmp compare.Comparator) string {
+	first := before[0]
+	switch {
+	case a.status == http.StatusTooManyRequests:
+		return "HTTP 429 Too Many Requests"
+	case a.header.Get("Retry-After") != "":
+		return "Retry-After: " + a.header.Get("Retry-After")
+	case a.status != first.status:
+		return fmt.Sprintf("HTTP %d instead of %d", a.status, first.status)
+	}
+	for _, markers := range [][]string{payloads.BruteForceCaptchaMarkers, payloads.BruteForceLockoutMarkers} {
+		if marker, ok := findMarker(a.body, markers); ok && !strings.Contains(strings.ToLower(first.body), marker) {
+			return fmt.Sprintf("'%s' in the response", marker)
+		}
+	}
+	if cmp.IsDifferent(first.body, a.body) {
+		return "the response changed"
+	}
+	var reference time.Duration
+	for _, r := range before[:min(len(before), referenceAttempts)] {
+		reference = max(reference, r.elapsed)
+	}
+	if a.elapsed > delayFactor*reference && a.elapsed-reference >= minDelay {
+		return fmt.Sprintf("the response was delayed to %v", a.elapsed.Round(time.Millisecond))
+	}
+	return ""
+}
+
+// findMarker returns the first of markers (lowercase) found in body.
+func findMarker(body string, markers []string) (string, bool) {
+	lower := strings.ToLower(body)
+	for _, marker := range markers {
+		if strings.Contains(lower, marker) {
+			return marker, true
+		}
+	}
+	return "", false
+}
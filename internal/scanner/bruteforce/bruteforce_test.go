@@ -0,0 +1,122 @@
+package bruteforce
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loginApp is a login form that rejects every login. After limit failures for a user name it
+// answers with protected instead, unless limit is zero.
+type loginApp struct {
+	mu        sync.Mutex
+	failures  map[string]int
+	usernames map[string]bool
+	limit     int
+	protected func(w http.ResponseWriter)
+	refuse    bool // Every login is refused before the credentials are checked (stale CSRF token).
+}
+
+func (a *loginApp) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	user := r.PostForm.Get("username")
+	a.mu.Lock()
+	a.failures[user]++
+	failures := a.failures[user]
+	a.usernames[user] = true
+	a.mu.Unlock()
+	switch {
+	case a.refuse:
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("<p>CSRF token mismatch</p>"))
+	case a.limit > 0 && failures > a.limit:
+		a.protected(w)
+	default:
+		w.Write([]byte(`<h1>Sign in</h1><p class="error">Invalid username or password.</p><form method="post"><input name="username"><input type="password" name="password"></form>`))
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name      string
+		app       *loginApp
+		wantFound bool
+	}{
+		{name: "No protection", app: &loginApp{}, wantFound: true},
+		{name: "Rate limited", app: &loginApp{limit: 5, protected: func(w http.ResponseWriter) { w.WriteHeader(http.StatusTooManyRequests) }}},
+		{name: "Account locked", app: &loginApp{limit: 5, protected: func(w http.ResponseWriter) {
+			w.Write([]byte(`<h1>Sign in</h1><p class="error">Your account is temporarily locked.</p><form method="post"><input name="username"><input type="password" name="password"></form>`))
+		}}},
+		{name: "CAPTCHA required", app: &loginApp{limit: 3, protected: func(w http.ResponseWriter) {
+			w.Write([]byte(`<h1>Sign in</h1><p class="error">Invalid username or password.</p><form method="post"><input name="username"><input type="password" name="password"><div class="g-recaptcha"></div></form>`))
+		}}},
+		{name: "Refused before the credential check", app: &loginApp{refuse: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.app.failures, tt.app.usernames = make(map[string]int), make(map[string]bool)
+			server := httptest.NewServer(tt.app)
+			defer server.Close()
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{})
+			client.SetRateLimit(2)
+			req := crawler.ParameterizedRequest{Method: "POST", URL: server.URL + "/login", FormPostData: "username=alice&password=Passw0rd%21", ParamNames: []string{"username", "password"}}
+
+			s := NewBruteForceScanner([]string{"alice"})
+			start := time.Now()
+			findings, err := s.Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			assert.Less(t, time.Since(start), 5*time.Second, "the burst is exempt from the rate limit")
+			assert.False(t, tt.app.usernames["alice"], "the configured account is never sent failing logins")
+
+			if !tt.wantFound {
+				assert.Empty(t, findings)
+				return
+			}
+			require.Len(t, findings, 1)
+			assert.Equal(t, "Missing Brute-Force Protection", findings[0].VulnerabilityType)
+			assert.Equal(t, "Medium", findings[0].Severity)
+			assert.Equal(t, "username", findings[0].Parameter)
+			assert.Len(t, strings.Split(findings[0].Evidence, "\n"), defaultAttempts)
+			assert.Contains(t, findings[0].Evidence, "Attempt 20: HTTP 200")
+
+			findings, err = s.Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			assert.Empty(t, findings, "a form is tested once")
+		})
+	}
+}
+
+func TestScanHonorsTotalCap(t *testing.T) {
+	app := &loginApp{failures: make(map[string]int), usernames: make(map[string]bool)}
+	server := httptest.NewServer(app)
+	defer server.Close()
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{})
+	opts := scanner.ScannerOptions{ModuleOptions: map[string]map[string]interface{}{ModuleName: {"attempts": 10, "max_total_attempts": 15}}}
+
+	s := NewBruteForceScanner(nil)
+	for _, path := range []string{"/login", "/admin/login"} {
+		req := crawler.ParameterizedRequest{Method: "POST", URL: server.URL + path, FormPostData: "username=x&password=y", ParamNames: []string{"username", "password"}}
+		_, err := s.Scan(context.Background(), req, client, log, opts)
+		require.NoError(t, err)
+	}
+	total := 0
+	for _, failures := range app.failures {
+		total += failures
+	}
+	assert.Equal(t, 10, total, "the second form would exceed the cap and is not tested")
+}
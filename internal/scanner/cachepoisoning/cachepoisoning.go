@@ -0,0 +1,264 @@
+// Package cachepoisoning detects web cache poisoning through inputs that caches leave out of the
+// cache key (unkeyed headers and query parameters).
+package cachepoisoning
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/hostheader"
+	"Dursgo/internal/scanner/requtil"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ModuleName selects the scanner (-s).
+const ModuleName = "cachepoisoning"
+
+// CachePoisoningScanner implements the Scanner interface for web cache poisoning.
+type CachePoisoningScanner struct {
+	mu          sync.Mutex
+	urlsScanned map[string]bool
+}
+
+// NewCachePoisoningScanner creates a new instance of CachePoisoningScanner.
+func NewCachePoisoningScanner() *CachePoisoningScanner {
+	return &CachePoisoningScanner{urlsScanned: make(map[string]bool)}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           ModuleName,
+		Order:          215,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewCachePoisoningScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *CachePoisoningScanner) Name() string {
+	return "Web Cache Poisoning Scanner"
+}
+
+// response is a response received by the scanner, with its body.
+type response struct {
+	req  *http.Request
+	resp *http.Response
+	body string
+}
+
+// Scan probes the unkeyed inputs of crawled GET endpoints whose responses are cacheable. Each
+// probe carries a unique marker and its own cache buster; when the response reflects the marker,
+// a clean request with the same cache buster tells whether the cache stored the poisoned
+// response and serves it to other users.
+func (s *CachePoisoningScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	if req.Method != "GET" {
+		return nil, nil
+	}
+	// The cache key of an endpoint rarely depends on which parameters are tested, so the query is
+	// ignored.
+	parsedURL, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, nil
+	}
+	endpoint := parsedURL.Scheme + "://" + parsedURL.Host + parsedURL.Path
+	s.mu.Lock()
+	if s.urlsScanned[endpoint] {
+		s.mu.Unlock()
+		return nil, nil
+	}
+	s.urlsScanned[endpoint] = true
+	s.mu.Unlock()
+
+	// Caches may key responses on the User-Agent, so every request of the probes sends the same.
+	// Redirects are compared, not followed.
+	client = client.WithContext(ctx).WithFixedUserAgent().WithoutRedirects()
+
+	baseline, err := fetch(ctx, client, withQuery(parsedURL, payloads.GenerateCacheBuster(), "", ""))
+	if err != nil {
+		return nil, nil
+	}
+	if !hostheader.IsCacheable(baseline.resp) {
+		log.Debug("Cache poisoning: %s is not cacheable, skipping", endpoint)
+		return nil, nil
+	}
+	log.Debug("Starting cache poisoning scan for %s", endpoint)
+
+	var findings []scanner.VulnerabilityResult
+	for _, probe := range payloads.CachePoisoningProbes {
+		if ctx.Err() != nil {
+			return findings, ctx.Err()
+		}
+		if probe.Header != "" && isVaryingOn(baseline.resp, probe.Header) {
+			continue
+		}
+		if probe.Redirect && isRedirect(baseline.resp) {
+			continue
+		}
+		vuln, found, err := s.testProbe(ctx, req, client, log, parsedURL, probe, baseline)
+		if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			break
+		}
+		if found {
+			findings = append(findings, vuln)
+		}
+	}
+	return findings, nil
+}
+
+// testProbe sends probe with a new marker and cache buster and, when the response shows its
+// effect, the clean request with the same cache buster.
+func (s *CachePoisoningScanner) testProbe(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, target *url.URL, probe payloads.CachePoisoningProbe, baseline response) (scanner.VulnerabilityResult, bool, error) {
+	marker, buster := payloads.GenerateCachePoisoningMarker(), payloads.GenerateCacheBuster()
+	value := probe.Value(marker)
+	probeClient, probeURL := client, withQuery(target, buster, probe.Param, value)
+	if probe.Header != "" {
+		probeClient = client.WithHeaders(map[string]string{probe.Header: value})
+	}
+	poisoned, err := fetch(ctx, probeClient, probeURL)
+	if err != nil {
+		return scanner.VulnerabilityResult{}, false, err
+	}
+	evidence, ok := effect(probe, poisoned, baseline, marker)
+	if !ok {
+		return scanner.VulnerabilityResult{}, false, nil
+	}
+
+	// The clean request has the cache key of the probe: same path and cache buster, neither the
+	// header nor the parameter.
+	clean, err := fetch(ctx, client, withQuery(target, buster, "", ""))
+	if err != nil {
+		return scanner.VulnerabilityResult{}, false, err
+	}
+	cachedEvidence, cached := effect(probe, clean, baseline, marker)
+	if cached {
+		// An application that stores the input would show it under any cache buster; only a
+		// cached response is limited to the poisoned cache key.
+		control, err := fetch(ctx, client, withQuery(target, payloads.GenerateCacheBuster(), "", ""))
+		if err != nil {
+			return scanner.VulnerabilityResult{}, false, err
+		}
+		if _, persisted := effect(probe, control, baseline, marker); persisted {
+			log.Debug("Cache poisoning: %s of %s shows up under every cache buster, not cached", probe.Name(), target)
+			cached = false
+		}
+	}
+
+	location := "header"
+	if probe.Header == "" {
+		location = "query"
+	}
+	vuln := scanner.VulnerabilityResult{
+		URL:         req.URL,
+		Parameter:   probe.Name(),
+		Payload:     fmt.Sprintf("%s: %s", probe.Name(), value),
+		Location:    location,
+		Remediation: "Include every input that changes the response in the cache key, or stop the application from using it: ignore X-Forwarded-* and URL override headers from untrusted clients, and drop tracking parameters before they reach the application.",
+		ScannerName: s.Name(),
+	}
+	if probe.Param != "" {
+		vuln.Payload = fmt.Sprintf("%s=%s", probe.Param, value)
+	}
+	if cached {
+		log.Success("Cache poisoning: response to %s of %s was cached and served to a clean request", probe.Name(), req.URL)
+		vuln.VulnerabilityType = "Web Cache Poisoning"
+		vuln.Severity = "High"
+		vuln.Details = fmt.Sprintf("The response to a request with the %s changed (%s), and a following request without it, with the same cache key (%s=%s), received the same changed response: the cache does not key on %s and serves poisoned responses to other users.",
+			probe.Description, describe(probe), payloads.CacheBusterParam, buster, probe.Name())
+		vuln.Evidence = "Clean request: " + cachedEvidence
+		vuln.SetExchange(scanner.CaptureExchange(clean.req, clean.resp, []byte(clean.body)))
+		return vuln, true, nil
+	}
+	log.Info("Cache poisoning: %s of %s is reflected but was not cached", probe.Name(), req.URL)
+	vuln.VulnerabilityType = "Unkeyed Input Reflection"
+	vuln.Severity = "Low"
+	vuln.Details = fmt.Sprintf("The response to a request with the %s changed (%s), but a following request without it, with the same cache key (%s=%s), did not receive the changed response, so the cache did not store it. The response is cacheable (Cache-Control: %q); another cache or another path may still store it.",
+		probe.Description, describe(probe), payloads.CacheBusterParam, buster, baseline.resp.Header.Get("Cache-Control"))
+	vuln.Evidence = evidence
+	vuln.SetExchange(scanner.CaptureExchange(poisoned.req, poisoned.resp, []byte(poisoned.body)))
+	return vuln, true, nil
+}
+
+// describe returns how the response to probe changed, for finding details.
+func describe(probe payloads.CachePoisoningProbe) string {
+	if probe.Redirect {
+		return "it redirects"
+	}
+	return "it reflects the injected value"
+}
+
+// effect reports whether r shows the effect of probe with marker: the marker in the Location
+// header or the body, or, for redirect probes, a redirect the baseline does not have. It returns
+// the evidence.
+func effect(probe payloads.CachePoisoningProbe, r, baseline response, marker string) (string, bool) {
+	if probe.Redirect {
+		location := r.resp.Header.Get("Location")
+		if isRedirect(r.resp) && location != baseline.resp.Header.Get("Location") {
+			return fmt.Sprintf("%d redirect, Location: %s", r.resp.StatusCode, location), true
+		}
+		return "", false
+	}
+	if location := r.resp.Header.Get("Location"); strings.Contains(location, marker) {
+		return "Location: " + location, true
+	}
+	if strings.Contains(r.body, marker) {
+		return scanner.Snippet(r.body, marker), true
+	}
+	return "", false
+}
+
+// fetch sends a GET request for target.
+func fetch(ctx context.Context, client *httpclient.Client, target string) (response, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return response{}, err
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return response{}, err
+	}
+	defer resp.Body.Close()
+	body, err := requtil.ReadBody(client, resp)
+	if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
+		return response{}, err
+	}
+	return response{req: httpReq, resp: resp, body: string(body)}, nil
+}
+
+// withQuery returns target with the cache buster and, if name is not empty, the parameter name
+// set to value.
+func withQuery(target *url.URL, buster, name, value string) string {
+	u := *target
+	query := u.Query()
+	query.Set(payloads.CacheBusterParam, buster)
+	if name != "" {
+		query.Set(name, value)
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// isVaryingOn reports whether the Vary header of resp names header, which puts it in the cache
+// key.
+func isVaryingOn(resp *http.Response, header string) bool {
+	for _, value := range resp.Header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name == "*" || strings.EqualFold(name, header) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isRedirect reports whether resp is a redirect.
+func isRedirect(resp *http.Response) bool {
+	return resp.StatusCode >= 300 && resp.StatusCode < 400 && resp.Header.Get("Location") != ""
+}
@@ -0,0 +1,197 @@
+package cachepoisoning
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cachedResponse is a response stored by sharedCache.
+type cachedResponse struct {
+	header http.Header
+	status int
+	body   []byte
+}
+
+// sharedCache is a caching proxy in front of an application: GET responses are stored under the
+// path and the query without its utm_* parameters, ignoring every header.
+func sharedCache(app http.HandlerFunc) http.HandlerFunc {
+	var mu sync.Mutex
+	entries := make(map[string]cachedResponse)
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		for name := range query {
+			if strings.HasPrefix(name, "utm_") {
+				query.Del(name)
+			}
+		}
+		key := r.URL.Path + "?" + query.Encode()
+		mu.Lock()
+		entry, hit := entries[key]
+		mu.Unlock()
+		if !hit {
+			recorder := httptest.NewRecorder()
+			app(recorder, r)
+			entry = cachedResponse{header: recorder.Header(), status: recorder.Code, body: recorder.Body.Bytes()}
+			mu.Lock()
+			entries[key] = entry
+			mu.Unlock()
+		}
+		for name, values := range entry.header {
+			w.Header()[name] = values
+		}
+		w.Header().Set("X-Cache", map[bool]string{true: "HIT", false: "MISS"}[hit])
+		w.WriteHeader(entry.status)
+		w.Write(entry.body)
+	}
+}
+
+// forwardedHostApp builds the URL of its scripts from X-Forwarded-Host.
+func forwardedHostApp(w http.ResponseWriter, r *http.Request) {
+	host := r.Host
+	if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+		host = forwarded
+	}
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	w.Write([]byte(`<script src="//` + host + `/static/app.js"></script>`))
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		wantTypes    []string
+		wantParams   []string
+		wantSeverity []string
+		maxRequests  int32
+	}{
+		{
+			name:         "X-Forwarded-Host reflected and cached",
+			handler:      sharedCache(forwardedHostApp),
+			wantTypes:    []string{"Web Cache Poisoning"},
+			wantParams:   []string{"X-Forwarded-Host"},
+			wantSeverity: []string{"High"},
+		},
+		{
+			name:         "X-Forwarded-Host reflected without a cache",
+			handler:      forwardedHostApp,
+			wantTypes:    []string{"Unkeyed Input Reflection"},
+			wantParams:   []string{"X-Forwarded-Host"},
+			wantSeverity: []string{"Low"},
+		},
+		{
+			name: "Tracking parameter left out of the cache key",
+			handler: sharedCache(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "public, max-age=60")
+				w.Write([]byte(`<a href="/signup?ref=` + url.QueryEscape(r.URL.Query().Get("utm_content")) + `">Sign up</a>`))
+			}),
+			wantTypes:    []string{"Web Cache Poisoning"},
+			wantParams:   []string{"utm_content"},
+			wantSeverity: []string{"High"},
+		},
+		{
+			name: "X-Forwarded-Scheme redirect cached",
+			handler: sharedCache(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "max-age=60")
+				if r.Header.Get("X-Forwarded-Scheme") == "http" {
+					http.Redirect(w, r, "https://"+r.Host+r.URL.RequestURI(), http.StatusMovedPermanently)
+					return
+				}
+				w.Write([]byte("<p>Home</p>"))
+			}),
+			wantTypes:    []string{"Web Cache Poisoning"},
+			wantParams:   []string{"X-Forwarded-Scheme"},
+			wantSeverity: []string{"High"},
+		},
+		{
+			name: "Header in Vary",
+			handler: sharedCache(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Vary", "Accept-Encoding, X-Forwarded-Host")
+				forwardedHostApp(w, r)
+			}),
+		},
+		{
+			name: "Not cacheable",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Cache-Control", "no-store")
+				w.Write([]byte(`<script src="//` + r.Header.Get("X-Forwarded-Host") + `/app.js"></script>`))
+			},
+			maxRequests: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				assert.NotEmpty(t, r.URL.Query().Get(payloads.CacheBusterParam), "every request carries a cache buster")
+				tt.handler(w, r)
+			}))
+			defer server.Close()
+
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, FollowRedirects: true})
+			req := crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/home?lang=en"}
+
+			s := NewCachePoisoningScanner()
+			findings, err := s.Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			require.Len(t, findings, len(tt.wantTypes))
+			for i, finding := range findings {
+				assert.Equal(t, tt.wantTypes[i], finding.VulnerabilityType)
+				assert.Equal(t, tt.wantParams[i], finding.Parameter)
+				assert.Equal(t, tt.wantSeverity[i], finding.Severity)
+				assert.NotEmpty(t, finding.Evidence)
+			}
+			if tt.maxRequests > 0 {
+				assert.LessOrEqual(t, atomic.LoadInt32(&requests), tt.maxRequests)
+			}
+
+			sent := atomic.LoadInt32(&requests)
+			findings, err = s.Scan(context.Background(), crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/home?lang=de"}, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			assert.Empty(t, findings, "an endpoint is probed once")
+			assert.Equal(t, sent, atomic.LoadInt32(&requests))
+		})
+	}
+}
+
+func TestScanIgnoresStoredInput(t *testing.T) {
+	// The application remembers the last X-Forwarded-Host it saw and shows it to everyone:
+	// reflected, but not by a cache.
+	var mu sync.Mutex
+	last := "example.com"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+			last = forwarded
+		}
+		w.Header().Set("Cache-Control", "public, max-age=60")
+		w.Write([]byte(`<link rel="canonical" href="https://` + last + `/">`))
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	findings, err := NewCachePoisoningScanner().Scan(context.Background(), crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/"}, client, log, scanner.ScannerOptions{})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Unkeyed Input Reflection", findings[0].VulnerabilityType)
+	assert.Equal(t, "Low", findings[0].Severity)
+}
@@ -6,11 +6,15 @@ import (
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/nested"
+	"Dursgo/internal/scanner/requtil"
+	"Dursgo/internal/scanner/timing"
+	"context"
 	"fmt"
 	"io"
 	"math/rand"
-	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -23,6 +27,21 @@ func NewCommandInjectionScanner() *CommandInjectionScanner {
 	return &CommandInjectionScanner{}
 }
 
+// ModuleName selects the scanner (-s) and holds its options in config.yaml (scanners.cmdinjection).
+const ModuleName = "cmdinjection"
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           ModuleName,
+		Order:          90,
+		DefaultEnabled: true,
+		Options: []scanner.OptionSpec{
+			{Name: "time_delay", Type: scanner.OptionInt, Default: 5, Description: "Sleep in seconds injected by time-based payloads; findings are confirmed with this delay and its multiples, see time_confirmations"},
+		},
+		New: func(scanner.Env) scanner.Scanner { return NewCommandInjectionScanner() },
+	})
+}
+
 // Name returns the scanner's name.
 func (s *CommandInjectionScanner) Name() string {
 	return "Context-Aware Command Injection Scanner"
@@ -30,114 +49,150 @@ func (s *CommandInjectionScanner) Name() string {
 
 // Scan performs a command injection scan on the given parameterized request.
 // It prioritizes output-based detection, then falls back to time-based, and finally OAST-based detection.
-func (s *CommandInjectionScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+func (s *CommandInjectionScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
 	var findings []scanner.VulnerabilityResult
 	rand.Seed(time.Now().UnixNano())
 
 	for _, paramName := range req.ParamNames {
-		originalParams, err := getOriginalParams(req)
+		if opts.SkipParam(ModuleName, paramName) {
+			continue
+		}
+		originalParams, err := requtil.Params(req)
 		if err != nil {
 			continue
 		}
-		originalValue := originalParams.Get(paramName)
-		var vulnerabilityFoundForParam bool
-
-		// --- Phase 1: Prioritize Output-Based Detection ---
-		for _, testCase := range payloads.CommandInjectionTests {
-			if testCase.Type != "output-based" {
-				continue
+		// The value itself, then the strings nested in a JSON or base64 value.
+		for _, target := range nested.Targets(paramName, originalParams.Get(paramName)) {
+			if ctx.Err() != nil {
+				return findings, ctx.Err()
 			}
-			found, vuln := s.executeTest(req, client, log, paramName, originalValue, originalParams, testCase)
-			if found {
+			if vuln, found := s.testTarget(ctx, req, client, log, opts, originalParams, target); found {
 				findings = append(findings, vuln)
-				vulnerabilityFoundForParam = true
-				break // Found the best evidence, stop output-based tests for this param
+				break // Move to the next parameter
 			}
 		}
-		if vulnerabilityFoundForParam {
-			continue // Move to the next parameter
-		}
+	}
+	return findings, nil
+}
 
-		// --- Phase 2: Fallback to Time-Based Detection ---
-		for _, testCase := range payloads.CommandInjectionTests {
-			if testCase.Type != "time-based" {
-				continue
-			}
-			found, vuln := s.executeTest(req, client, log, paramName, originalValue, originalParams, testCase)
-			if found {
-				findings = append(findings, vuln)
-				vulnerabilityFoundForParam = true
-				break // Found time-based, good enough, stop time-based tests for this param
+// testTarget runs the output-based, time-based and OAST tests against one injection point of a
+// parameter and returns the first finding. The output-based and time-based payloads are sent as
+// they are, then with each evasion transformation (opts.Evasion).
+func (s *CommandInjectionScanner) testTarget(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, originalParams url.Values, target nested.Point) (scanner.VulnerabilityResult, bool) {
+	transformations := opts.Evasion.Transformations(payloads.PayloadCommand)
+
+	// --- Phase 1: Prioritize Output-Based Detection ---
+	for _, transformation := range transformations {
+		for _, testCase := range testsOfType("output-based", opts.PayloadTier) {
+			if found, vuln := s.testOutputBased(ctx, req, client, target, originalParams, testCase, transformation); found {
+				return vuln, true // Found the best evidence, stop output-based tests for this param
 			}
 		}
-		if vulnerabilityFoundForParam {
-			continue // Move to the next parameter
-		}
+	}
 
-		// --- Phase 3: Always run OAST if enabled, as it's a separate detection method ---
-		if opts.OASTDomain != "" {
-			s.testOASTBased(req, client, opts, paramName, "")
+	// --- Phase 2: Fallback to Time-Based Detection ---
+	// The baseline is sampled once per parameter and shared by all time-based payloads.
+	baseline, ok := timing.MeasureBaseline(opts.TimeBasedBaselineSamples, func() (time.Duration, error) {
+		duration, _, err := requtil.Measure(ctx, req, client, originalParams)
+		return duration, err
+	})
+	if ok {
+		log.Debug("CMDi (Time-Based): Baseline for '%s' over %d samples: mean %s, stddev %s", target.Param, len(baseline.Samples), baseline.Mean, baseline.StdDev)
+		for _, transformation := range transformations {
+			for _, testCase := range testsOfType("time-based", opts.PayloadTier) {
+				if found, vuln := s.testTimeBased(ctx, req, client, target, originalParams, testCase, transformation, baseline, timing.Delays(opts.IntOption(ModuleName, "time_delay", 0), opts.TimeConfirmations)); found {
+					return vuln, true // Found time-based, good enough, stop time-based tests for this param
+				}
+			}
 		}
 	}
-	return findings, nil
+
+	// --- Phase 3: Always run OAST if enabled, as it's a separate detection method ---
+	if opts.OASTDomain != "" {
+		s.testOASTBased(req, client, opts, target, "")
+	}
+	return scanner.VulnerabilityResult{}, false
 }
 
-// executeTest is a new helper function to run a single test case and check for vulnerabilities.
-// It constructs and sends requests with various payloads and checks for signs of command injection.
-func (s *CommandInjectionScanner) executeTest(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName, originalValue string, originalParams url.Values, testCase payloads.CommandInjectionTest) (bool, scanner.VulnerabilityResult) {
+// testOutputBased injects a command whose output is recognizable and looks for that output in
+// the response. Echo tests print a random token split by shell quoting (e.g., echo a""b), so a
+// mere reflection of the payload cannot match. The separator and command are rewritten by
+// transformation.
+func (s *CommandInjectionScanner) testOutputBased(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, target nested.Point, originalParams url.Values, testCase payloads.CommandInjectionTest, transformation payloads.Transformation) (bool, scanner.VulnerabilityResult) {
 	for _, separator := range testCase.Separators {
 		// Smart Injection Strategy: Try appending and replacing with '1'
-		injectionBases := []string{originalValue, "1"}
+		injectionBases := []string{target.Value, "1"}
 		for _, base := range injectionBases {
-			payload := strings.Replace(testCase.PayloadToInject, "{SLEEP_TIME}", fmt.Sprintf("%d", testCase.SleepSeconds), -1)
-			payload = strings.Replace(payload, "{SLEEP_TIME_PLUS_ONE}", fmt.Sprintf("%d", testCase.SleepSeconds+1), -1)
-			maliciousValue := base + separator + payload
-
-			switch testCase.Type {
-			case "output-based":
-				testURL, reqBody := buildRequest(req, originalParams, paramName, maliciousValue)
-				responseBody, err := sendRequestAndGetBody(client, req.Method, testURL, reqBody, log)
-				if err != nil {
-					continue
-				}
-				if testCase.DetectionRegex != nil && testCase.DetectionRegex.MatchString(responseBody) {
-					return true, scanner.VulnerabilityResult{
-						VulnerabilityType: "Command Injection (Output-Based)",
-						URL:               testURL,
-						Parameter:         paramName,
-						Payload:           separator + payload,
-						Location:          getParamLocation(req),
-						Details:           fmt.Sprintf("Command output detected for OS '%s'.", testCase.OS),
-						Evidence:          testCase.DetectionRegex.FindString(responseBody),
-						Severity:          "high",
-						Remediation:       "Do not use user input directly in command execution. Use safe APIs and strict validation.",
-						ScannerName:       s.Name(),
-					}
-				}
-			case "time-based":
-				baselineDuration := measureRequestDuration(req, client, originalParams)
-				if baselineDuration < 0 {
-					continue
+			payload := transformation.Apply(separator + testCase.PayloadToInject)
+			detectionRegex := testCase.DetectionRegex
+			if testCase.EchoesToken {
+				left, right := fmt.Sprintf("dursgo%d", rand.Intn(1e6)), fmt.Sprintf("cmdi%d", rand.Intn(1e6))
+				payload = strings.NewReplacer("{TOKEN_LEFT}", left, "{TOKEN_RIGHT}", right).Replace(payload)
+				detectionRegex = regexp.MustCompile(regexp.QuoteMeta(left + right))
+			}
+			testParams := withPayload(originalParams, target.Param, target.Inject(base+payload))
+			status, responseBody, err := requtil.Send(ctx, req, client, testParams)
+			if err != nil || status >= 400 {
+				continue
+			}
+			testURL, _, _ := requtil.Components(req, testParams)
+			if detectionRegex != nil && detectionRegex.MatchString(responseBody) {
+				return true, scanner.VulnerabilityResult{
+					VulnerabilityType: "Command Injection (Output-Based)",
+					URL:               testURL,
+					Parameter:         target.Param,
+					Payload:           payload,
+					Location:          getParamLocation(req),
+					Details:           withNote(fmt.Sprintf("Command output detected for OS '%s' (%s). The output is returned in the response, so arbitrary commands can be run and read directly.", testCase.OS, testCase.Description), target),
+					Evidence:          detectionRegex.FindString(responseBody),
+					Severity:          "high",
+					Remediation:       "Do not use user input directly in command execution. Use safe APIs and strict validation.",
+					ScannerName:       s.Name(),
+					Transformation:    transformation.Name,
 				}
-				testParams := copyParams(originalParams)
-				testParams.Set(paramName, maliciousValue)
-				testDuration := measureRequestDuration(req, client, testParams)
-				delayThreshold := time.Duration(testCase.SleepSeconds-1) * time.Second
+			}
+		}
+	}
+	return false, scanner.VulnerabilityResult{}
+}
 
-				if testDuration > (baselineDuration + delayThreshold) {
-					testURL, _ := buildRequest(req, originalParams, paramName, maliciousValue)
-					return true, scanner.VulnerabilityResult{
-						VulnerabilityType: "Blind Command Injection (Time-Based)",
-						URL:               testURL,
-						Parameter:         paramName,
-						Payload:           separator + payload,
-						Location:          getParamLocation(req),
-						Severity:          "high",
-						Details:           fmt.Sprintf("OS detected as '%s'. Request delayed by ~%d seconds.", testCase.OS, testCase.SleepSeconds),
-						Remediation:       "Use allowlists or proper input validation. Avoid using input directly in shell commands.",
-						ScannerName:       s.Name(),
-					}
-				}
+// testTimeBased injects a sleeping command and confirms every delay in delays (in seconds)
+// against the baseline with timing.ConfirmDelay, the same verification used by the SQLi
+// time-based test. The separator and command are rewritten by transformation.
+func (s *CommandInjectionScanner) testTimeBased(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, target nested.Point, originalParams url.Values, testCase payloads.CommandInjectionTest, transformation payloads.Transformation, baseline timing.Baseline, delays []int) (bool, scanner.VulnerabilityResult) {
+	for _, separator := range testCase.Separators {
+		injectionBases := []string{target.Value, "1"}
+		for _, base := range injectionBases {
+			var maliciousValue, payload string
+			confirmations, confirmed := timing.ConfirmDelay(baseline, delays, func(delay int) (time.Duration, error) {
+				payload = strings.NewReplacer(
+					"{SLEEP_TIME}", fmt.Sprintf("%d", delay),
+					"{SLEEP_TIME_PLUS_ONE}", fmt.Sprintf("%d", delay+1),
+				).Replace(transformation.Apply(separator + testCase.PayloadToInject))
+				maliciousValue = target.Inject(base + payload)
+				testParams := requtil.Copy(originalParams)
+				testParams.Set(target.Param, maliciousValue)
+				duration, _, err := requtil.Measure(ctx, req, client, testParams)
+				return duration, err
+			})
+			if !confirmed {
+				continue
+			}
+
+			testURL, _, _ := requtil.Components(req, withPayload(originalParams, target.Param, maliciousValue))
+			return true, scanner.VulnerabilityResult{
+				VulnerabilityType: "Blind Command Injection (Time-Based)",
+				URL:               testURL,
+				Parameter:         target.Param,
+				Payload:           payload,
+				Location:          getParamLocation(req),
+				Severity:          "high",
+				Details:           withNote(fmt.Sprintf("OS detected as '%s' (%s). The command output is not returned, but injected delays were reproduced across %d confirmations and scaled with the requested sleep.", testCase.OS, testCase.Description, len(confirmations)), target),
+				Evidence:          fmt.Sprintf("Baseline samples: %s; Injected: %s", timing.FormatDurations(baseline.Samples), strings.Join(confirmations, ", ")),
+				Remediation:       "Use allowlists or proper input validation. Avoid using input directly in shell commands.",
+				ScannerName:       s.Name(),
+				Transformation:    transformation.Name,
 			}
 		}
 	}
@@ -146,10 +201,14 @@ func (s *CommandInjectionScanner) executeTest(req crawler.ParameterizedRequest,
 
 // testOASTBased performs OAST-based command injection tests.
 // It generates unique OAST payloads and stores correlation data for out-of-band detection.
-func (s *CommandInjectionScanner) testOASTBased(req crawler.ParameterizedRequest, client *httpclient.Client, opts scanner.ScannerOptions, paramName, detectedOS string) {
-	originalParams, _ := getOriginalParams(req)
+func (s *CommandInjectionScanner) testOASTBased(req crawler.ParameterizedRequest, client *httpclient.Client, opts scanner.ScannerOptions, target nested.Point, detectedOS string) {
+	originalParams, err := requtil.Params(req)
+	if err != nil {
+		return
+	}
+	paramName := target.Param
 
-	for _, testCase := range payloads.OASTCommandInjectionTests {
+	for _, testCase := range scanner.TrimPayloads(opts.PayloadTier, payloads.OASTCommandInjectionTests) {
 		if testCase.OS != "any" && testCase.OS != "" && testCase.OS != detectedOS {
 			continue
 		}
@@ -158,7 +217,7 @@ func (s *CommandInjectionScanner) testOASTBased(req crawler.ParameterizedRequest
 			oastPayloadDomain := fmt.Sprintf("%s.%s", correlationID, opts.OASTDomain)
 			payloadToInject := strings.Replace(testCase.PayloadTemplate, "DURSGO_OAST_DOMAIN", oastPayloadDomain, -1)
 			// For blind injection, we don't prepend the original value as it can break the command.
-			maliciousValue := separator + " " + payloadToInject
+			maliciousValue := target.Inject(separator + " " + payloadToInject)
 
 			opts.OASTCorrelationMap.Store(correlationID, scanner.VulnerabilityResult{
 				VulnerabilityType: fmt.Sprintf("Blind Command Injection (OAST: %s)", testCase.Description),
@@ -167,39 +226,36 @@ func (s *CommandInjectionScanner) testOASTBased(req crawler.ParameterizedRequest
 				Payload:           separator + " " + payloadToInject,
 				Location:          getParamLocation(req),
 				Severity:          "high",
+				Details:           target.Note(),
 				Evidence:          fmt.Sprintf("Payload sent to %s", oastPayloadDomain),
 				Remediation:       "Avoid using untrusted input in OS commands. Use whitelisting and secure APIs.",
 				ScannerName:       s.Name(),
 			})
 
-			testURL, reqBody := buildRequest(req, originalParams, paramName, maliciousValue)
 			// Send the request synchronously to ensure it completes before the scan finishes.
-			sendAndForget(client, req.Method, testURL, reqBody)
+			sendAndForget(client, req, withPayload(originalParams, paramName, maliciousValue))
 		}
 	}
 }
 
 // ---- Helper Functions (Improved for Stability) ----
 
-// getOriginalParams extracts original parameters from the request based on its method.
-func getOriginalParams(req crawler.ParameterizedRequest) (url.Values, error) {
-	if req.Method == "GET" {
-		p, e := url.Parse(req.URL)
-		if e != nil {
-			return nil, e
+// testsOfType returns the command injection tests of a detection type ("output-based" or
+// "time-based") that the payload tier keeps.
+func testsOfType(typ string, tier scanner.PayloadTier) []payloads.CommandInjectionTest {
+	var tests []payloads.CommandInjectionTest
+	for _, testCase := range payloads.CommandInjectionTests {
+		if testCase.Type == typ {
+			tests = append(tests, testCase)
 		}
-		return p.Query(), nil
 	}
-	return url.ParseQuery(req.FormPostData)
+	return scanner.TrimPayloads(tier, tests)
 }
 
-// buildRequest builds an HTTP request with the injected payload.
-// This is a key improvement function.
-func buildRequest(req crawler.ParameterizedRequest, oP url.Values, pti, vti string) (string, io.Reader) {
-	testParams := url.Values{}
-	for k, v := range oP {
-		testParams[k] = v
-	}
+// withPayload returns a copy of the original parameters with the payload value vti set on the
+// parameter under test (pti).
+func withPayload(oP url.Values, pti, vti string) url.Values {
+	testParams := requtil.Copy(oP)
 
 	// Context-Aware Fix: When testing a parameter (pti), check if other parameters
 	// look like they expect a URL. If so, and their current value is invalid,
@@ -228,16 +284,15 @@ func buildRequest(req crawler.ParameterizedRequest, oP url.Values, pti, vti stri
 	}
 
 	testParams.Set(pti, vti) // Set the actual payload for the parameter under test
+	return testParams
+}
 
-	if req.Method == "GET" {
-		baseURL, err := url.Parse(req.URL)
-		if err != nil {
-			return "", nil // Return empty string if URL is invalid
-		}
-		baseURL.RawQuery = testParams.Encode()
-		return baseURL.String(), nil
+// withNote appends the note of a nested injection point to the details of a finding.
+func withNote(details string, target nested.Point) string {
+	if note := target.Note(); note != "" {
+		return details + " " + note
 	}
-	return req.URL, strings.NewReader(testParams.Encode())
+	return details
 }
 
 // getParamLocation returns a comma-separated string of parameter locations.
@@ -245,26 +300,10 @@ func getParamLocation(req crawler.ParameterizedRequest) string {
 	return strings.Join(req.ParamLocations, ",")
 }
 
-// sendRequest sends an HTTP request and returns the response.
-// Added error checking for extra security.
-func sendRequest(c *httpclient.Client, m, t string, b io.Reader) (*http.Response, error) {
-	h, e := http.NewRequest(m, t, b)
-	if e != nil {
-		return nil, e
-	}
-	if m == "POST" {
-		h.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	}
-	return c.Do(h)
-}
-
-// sendAndForget sends an HTTP request and waits for it to complete.
-func sendAndForget(c *httpclient.Client, m, t string, b io.Reader) {
-	h, e := http.NewRequest(m, t, b)
+// sendAndForget sends the request with params applied and waits for it to complete.
+func sendAndForget(c *httpclient.Client, req crawler.ParameterizedRequest, params url.Values) {
+	h, e := requtil.New(context.Background(), req, params)
 	if e == nil { // Only proceed if the request was successfully created
-		if m == "POST" {
-			h.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-		}
 		resp, _ := c.Do(h)
 		if resp != nil && resp.Body != nil {
 			// We don't need to read the body, but we must close it.
@@ -273,70 +312,3 @@ func sendAndForget(c *httpclient.Client, m, t string, b io.Reader) {
 		}
 	}
 }
-
-// sendRequestAndGetBody sends an HTTP request and returns the response body as a string.
-func sendRequestAndGetBody(c *httpclient.Client, m, t string, b io.Reader, l *logger.Logger) (string, error) {
-	r, e := sendRequest(c, m, t, b)
-	if e != nil {
-		return "", e
-	}
-	defer r.Body.Close()
-	by, re := io.ReadAll(r.Body)
-	if re != nil {
-		return "", re
-	}
-	if r.StatusCode >= 400 {
-		return string(by), fmt.Errorf("error status code: %d", r.StatusCode)
-	}
-	return string(by), nil
-}
-
-// measureRequestDuration measures the duration of an HTTP request.
-func measureRequestDuration(req crawler.ParameterizedRequest, client *httpclient.Client, params url.Values) time.Duration {
-	var testURL string
-	var reqBody io.Reader
-
-	if req.Method == "GET" {
-		u, err := url.Parse(req.URL)
-		if err != nil {
-			return -1
-		} // Error checking
-		u.RawQuery = params.Encode()
-		testURL = u.String()
-		reqBody = nil
-	} else {
-		testURL = req.URL
-		reqBody = strings.NewReader(params.Encode())
-	}
-
-	startTime := time.Now()
-	httpRequest, err := http.NewRequest(req.Method, testURL, reqBody)
-	if err != nil {
-		return -1 // Error checking
-	}
-	if req.Method == "POST" {
-		httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	}
-
-	res, err := client.Do(httpRequest)
-	if err != nil {
-		if res != nil {
-			res.Body.Close()
-		}
-		return -1
-	}
-	if res.Body != nil {
-		io.Copy(io.Discard, res.Body)
-		res.Body.Close()
-	}
-	return time.Since(startTime)
-}
-
-// copyParams creates a deep copy of url.Values.
-func copyParams(original url.Values) url.Values {
-	nP := url.Values{}
-	for k, v := range original {
-		nP[k] = v
-	}
-	return nP
-}
@@ -0,0 +1,471 @@
+package cms
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/fingerprint"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ModuleName is the name of the CMS component module in the scanner registry.
+const ModuleName = "cms"
+
+const (
+	// defaultMaxRequests bounds the enumeration requests sent to one site.
+	defaultMaxRequests = 300
+	// maxManifestBytes bounds how much of a readme, stylesheet or manifest is read.
+	maxManifestBytes = 64 * 1024
+)
+
+// severityRank orders severities for picking the most severe vulnerability of a component.
+var severityRank = map[string]int{"critical": 4, "high": 3, "medium": 2, "low": 1}
+
+// platforms maps the technologies identified by fingerprinting to the CMS platforms of
+// payloads.CMSVulnerabilities.
+var platforms = []struct {
+	tech     string
+	platform string
+	marker   *regexp.Regexp // Path of the platform's files in a URL; what precedes it is the site root.
+}{
+	{"WordPress", "wordpress", regexp.MustCompile(`/wp-(?:content|includes|admin)/`)},
+	{"Drupal", "drupal", regexp.MustCompile(`/(?:sites/[^/]+|core|misc)/`)},
+	{"Joomla", "joomla", regexp.MustCompile(`/(?:administrator|components|media/jui)/`)},
+}
+
+// versionProbe reads the version of one type of component from the files it ships with.
+type versionProbe struct {
+	paths   []string       // Relative to the site root; {slug} is the component, {short} the slug without "com_".
+	marker  *regexp.Regexp // Matches the file only: soft-404 pages and other files do not count.
+	version *regexp.Regexp // Its first group is the version.
+}
+
+// versionProbes are keyed by platform and component type.
+var versionProbes = map[string]versionProbe{
+	"wordpress/plugin": {
+		paths:   []string{"wp-content/plugins/{slug}/readme.txt", "wp-content/plugins/{slug}/README.txt"},
+		marker:  regexp.MustCompile(`(?im)^\s*(=== .+ ===|Contributors:|Stable tag:)`),
+		version: regexp.MustCompile(`(?im)^\s*Stable tag:\s*v?(\d+(?:\.\d+)+)`),
+	},
+	"wordpress/theme": {
+		paths:   []string{"wp-content/themes/{slug}/style.css"},
+		marker:  regexp.MustCompile(`(?im)^\s*Theme Name:`),
+		version: regexp.MustCompile(`(?im)^\s*Version:\s*v?(\d+(?:\.\d+)+)`),
+	},
+	"drupal/core": {
+		paths:   []string{"CHANGELOG.txt", "core/CHANGELOG.txt"},
+		marker:  regexp.MustCompile(`(?m)^Drupal \d+\.\d+`),
+		version: regexp.MustCompile(`(?m)^Drupal (\d+\.\d+(?:\.\d+)?),`),
+	},
+	"drupal/module": {
+		paths: []string{
+			"modules/contrib/{slug}/{slug}.info.yml", "modules/{slug}/{slug}.info.yml",
+			"sites/all/modules/contrib/{slug}/{slug}.info", "sites/all/modules/{slug}/{slug}.info",
+		},
+		marker:  regexp.MustCompile(`(?m)^\s*(name|core|core_version_requirement)\s*[:=]`),
+		version: regexp.MustCompile(`(?m)^\s*version\s*[:=]\s*['"]?([^'"\s]+)`),
+	},
+	"joomla/core": {
+		paths:   []string{"administrator/manifests/files/joomla.xml"},
+		marker:  regexp.MustCompile(`<extension[\s>]`),
+		version: regexp.MustCompile(`<version>\s*([^<\s]+)\s*</version>`),
+	},
+	"joomla/component": {
+		paths:   []string{"administrator/components/{slug}/{short}.xml", "components/{slug}/{short}.xml"},
+		marker:  regexp.MustCompile(`<extension[\s>]`),
+		version: regexp.MustCompile(`<version>\s*([^<\s]+)\s*</version>`),
+	},
+}
+
+// referenceRegexes find the components a URL or page references, keyed by platform. The first
+// group is the component type (or empty for the platform's only one), the second the slug and
+// the third, if any, the version of an asset URL (?ver=).
+var referenceRegexes = map[string]*regexp.Regexp{
+	"wordpress": regexp.MustCompile(`/wp-content/(plugins|themes)/([A-Za-z0-9_.-]+)/(?:[^"'\s?<>]*\?ver=(\d+(?:\.\d+)+))?`),
+	"drupal":    regexp.MustCompile(`/(?:sites/[^/"']+/)?()modules/(?:contrib/)?([a-z][a-z0-9_]*)/`),
+	"joomla":    regexp.MustCompile(`/()components/(com_[a-z0-9_]+)/`),
+}
+
+// component is a CMS component to look up, referenced by a page or listed in the dataset.
+type component struct {
+	platform     string
+	typ          string
+	slug         string
+	assetVersion string // Version of an asset URL referencing the component, if any.
+}
+
+func (c component) key() string {
+	return c.typ + "/" + c.slug
+}
+
+// site is the enumeration state of one CMS installation.
+type site struct {
+	once        sync.Once
+	mu          sync.Mutex
+	probed      map[string]bool // Components looked up, by key.
+	requests    int             // Requests sent.
+	capReported bool
+}
+
+// CMSScanner implements the Scanner interface for known vulnerable WordPress plugins and
+// themes, Drupal core and modules and Joomla core and components. Once fingerprinting (or the
+// crawled URLs) identify the CMS, it reads the versions of the components referenced by the
+// site and of those in payloads.CMSVulnerabilities from their readme, stylesheet or manifest
+// files, and reports the components whose version is in a vulnerable range.
+type CMSScanner struct {
+	mu    sync.Mutex
+	sites map[string]*site
+}
+
+// NewCMSScanner creates a new instance of CMSScanner.
+func NewCMSScanner() *CMSScanner {
+	return &CMSScanner{sites: make(map[string]*site)}
+}
+
+func init() {
+	// Enumeration sends requests of its own for every component of the dataset, so it is not
+	// part of "all"; select it with -s cms or -enable-scanners cms.
+	scanner.Register(scanner.Registration{
+		Name:           ModuleName,
+		Order:          310,
+		DefaultEnabled: false,
+		Options: []scanner.OptionSpec{
+			{Name: "max_requests", Type: scanner.OptionInt, Default: defaultMaxRequests, Description: "Enumeration requests sent per CMS installation (0 = unlimited)"},
+			{Name: "enumerate_dataset", Type: scanner.OptionBool, Default: true, Description: "Also look up the components of the vulnerability dataset that no crawled page references"},
+		},
+		New: func(scanner.Env) scanner.Scanner { return NewCMSScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *CMSScanner) Name() string {
+	return "CMS Component Scanner"
+}
+
+// Scan looks up the components referenced by the request URL and, on the first request of a
+// CMS installation, its core, the components its home page references and those of the dataset.
+func (s *CMSScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	target, err := url.Parse(req.URL)
+	if err != nil || target.Host == "" {
+		return nil, err
+	}
+	platform, root := detectPlatform(opts.Technologies, target)
+	if platform == "" {
+		return nil, nil
+	}
+	st := s.site(platform + " " + root.String())
+	maxRequests := opts.IntOption(ModuleName, "max_requests", defaultMaxRequests)
+
+	var components []component
+	st.once.Do(func() {
+		log.Info("CMS: Enumerating %s components of %s", platform, root)
+		if _, ok := versionProbes[platform+"/core"]; ok {
+			components = append(components, component{platform: platform, typ: "core"})
+		}
+		if st.take(log, root, maxRequests) {
+			if body, _, err := fetch(ctx, client, root.String()); err == nil {
+				components = append(components, references(platform, body)...)
+			}
+		}
+		if opts.BoolOption(ModuleName, "enumerate_dataset", true) {
+			components = append(components, datasetComponents(platform)...)
+		}
+	})
+	components = append(components, references(platform, target.Path+"?"+target.RawQuery)...)
+
+	var findings []scanner.VulnerabilityResult
+	for _, c := range components {
+		if ctx.Err() != nil {
+			return findings, ctx.Err()
+		}
+		if !st.claim(c) {
+			continue
+		}
+		d, ok, err := s.detect(ctx, client, log, st, root, c, maxRequests)
+		if err != nil {
+			if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+				return findings, nil
+			}
+			continue
+		}
+		if !ok {
+			continue
+		}
+		vulns := affecting(d)
+		if len(vulns) == 0 {
+			log.Info("CMS: %s %s version %s, no known vulnerabilities", d.name, d.typ, d.version)
+			continue
+		}
+		log.Success("CMS: %s %s version %s is affected by %d known vulnerabilit(ies)", d.name, d.typ, d.version, len(vulns))
+		findings = append(findings, newFinding(d, vulns))
+	}
+	return findings, nil
+}
+
+// site returns the enumeration state of the installation with the given key.
+func (s *CMSScanner) site(key string) *site {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st, ok := s.sites[key]
+	if !ok {
+		st = &site{probed: make(map[string]bool)}
+		s.sites[key] = st
+	}
+	return st
+}
+
+// claim reports whether c has not been looked up yet, and marks it as looked up.
+func (st *site) claim(c component) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.probed[c.key()] {
+		return false
+	}
+	st.probed[c.key()] = true
+	return true
+}
+
+// take counts a request against the site's budget and reports whether it may be sent.
+func (st *site) take(log *logger.Logger, root *url.URL, maxRequests int) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if maxRequests > 0 && st.requests >= maxRequests {
+		if !st.capReported {
+			st.capReported = true
+			log.Warn("CMS: Request budget of %d reached for %s; remaining components are skipped.", maxRequests, root)
+		}
+		return false
+	}
+	st.requests++
+	return true
+}
+
+// detection is a component whose version was read.
+type detection struct {
+	component
+	name     string
+	version  string
+	url      string // File the version was read from (or the referencing asset URL).
+	evidence string
+	exchange scanner.Exchange
+}
+
+// detect reads the version of c from the first of its files that exists. Without one, a
+// plugin referenced by a versioned asset URL is reported with the asset's version.
+func (s *CMSScanner) detect(ctx context.Context, client *httpclient.Client, log *logger.Logger, st *site, root *url.URL, c component, maxRequests int) (detection, bool, error) {
+	d := detection{component: c, name: componentName(c)}
+	probe, ok := versionProbes[c.platform+"/"+c.typ]
+	if !ok {
+		return d, false, nil
+	}
+	replacer := strings.NewReplacer("{slug}", c.slug, "{short}", strings.TrimPrefix(c.slug, "com_"))
+	for _, p := range probe.paths {
+		if !st.take(log, root, maxRequests) {
+			return d, false, nil
+		}
+		fileURL := root.ResolveReference(&url.URL{Path: replacer.Replace(p)}).String()
+		body, exchange, err := fetch(ctx, client, fileURL)
+		if err != nil {
+			if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+				return d, false, err
+			}
+			log.Debug("CMS: Request for %s failed: %v", fileURL, err)
+			continue
+		}
+		if !probe.marker.MatchString(body) {
+			continue
+		}
+		m := probe.version.FindStringSubmatch(body)
+		if m == nil {
+			log.Debug("CMS: %s exists but states no version", fileURL)
+			continue
+		}
+		d.version, d.url, d.exchange = m[1], fileURL, exchange
+		d.evidence = fmt.Sprintf("%s: %q", fileURL, strings.TrimSpace(m[0]))
+		return d, true, nil
+	}
+	if c.assetVersion != "" {
+		d.version = c.assetVersion
+		d.url = root.ResolveReference(&url.URL{Path: "wp-content/" + c.typ + "s/" + c.slug + "/"}).String()
+		d.evidence = fmt.Sprintf("version %s of an asset URL (?ver=) referencing %s", c.assetVersion, d.url)
+		return d, true, nil
+	}
+	return d, false, nil
+}
+
+// fetch requests target and returns the start of its body if it answered 200.
+func fetch(ctx context.Context, client *httpclient.Client, target string) (string, scanner.Exchange, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return "", scanner.Exchange{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", scanner.Exchange{}, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestBytes))
+	if err != nil {
+		return "", scanner.Exchange{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", scanner.Exchange{}, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return string(body), scanner.CaptureExchange(req, resp, body), nil
+}
+
+// detectPlatform returns the CMS platform of target, from the fingerprint or from the path of
+// the URL, and the root of the installation: the part of the path before the platform's files,
+// or "/".
+func detectPlatform(techs fingerprint.Technologies, target *url.URL) (string, *url.URL) {
+	for _, p := range platforms {
+		loc := p.marker.FindStringIndex(target.Path)
+		if !techs.Has(p.tech) && loc == nil {
+			continue
+		}
+		root := &url.URL{Scheme: target.Scheme, Host: target.Host, Path: "/"}
+		if loc != nil {
+			root.Path = target.Path[:loc[0]+1]
+		}
+		return p.platform, root
+	}
+	return "", nil
+}
+
+// references returns the components of platform referenced in text (a page or a URL path).
+func references(platform, text string) []component {
+	re, ok := referenceRegexes[platform]
+	if !ok {
+		return nil
+	}
+	var components []component
+	for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+		// Drupal core's own modules live under /core/modules/.
+		if platform == "drupal" && strings.HasSuffix(text[:m[0]], "/core") {
+			continue
+		}
+		c := component{platform: platform, slug: text[m[4]:m[5]]}
+		switch platform {
+		case "wordpress":
+			c.typ = strings.TrimSuffix(text[m[2]:m[3]], "s")
+			if m[6] >= 0 {
+				c.assetVersion = text[m[6]:m[7]]
+			}
+		case "drupal":
+			c.typ = "module"
+		case "joomla":
+			c.typ = "component"
+		}
+		components = append(components, c)
+	}
+	return components
+}
+
+// datasetComponents returns the components of platform in payloads.CMSVulnerabilities.
+func datasetComponents(platform string) []component {
+	seen := make(map[string]bool)
+	var components []component
+	for _, v := range payloads.GetCMSVulnerabilities() {
+		c := component{platform: v.Platform, typ: v.Type, slug: v.Slug}
+		if v.Platform != platform || v.Type == "core" || seen[c.key()] {
+			continue
+		}
+		seen[c.key()] = true
+		components = append(components, c)
+	}
+	return components
+}
+
+// componentName returns the display name of c from the dataset, or its slug.
+func componentName(c component) string {
+	for _, v := range payloads.GetCMSVulnerabilities() {
+		if v.Platform == c.platform && v.Type == c.typ && v.Slug == c.slug && v.Name != "" {
+			return v.Name
+		}
+	}
+	if c.typ == "core" {
+		return strings.ToUpper(c.platform[:1]) + c.platform[1:] + " core"
+	}
+	return c.slug
+}
+
+// affecting returns the vulnerabilities of the dataset affecting the detected version, most
+// severe first.
+func affecting(d detection) []payloads.CMSVulnerability {
+	var vulns []payloads.CMSVulnerability
+	for _, v := range payloads.GetCMSVulnerabilities() {
+		if v.Platform == d.platform && v.Type == d.typ && v.Slug == d.slug && v.Affects(d.version) {
+			vulns = append(vulns, v)
+		}
+	}
+	sort.SliceStable(vulns, func(i, j int) bool {
+		return severityRank[strings.ToLower(vulns[i].Severity)] > severityRank[strings.ToLower(vulns[j].Severity)]
+	})
+	return vulns
+}
+
+// newFinding builds the finding of a component affected by vulns.
+func newFinding(d detection, vulns []payloads.CMSVulnerability) scanner.VulnerabilityResult {
+	var cves, listed []string
+	fixedIn := ""
+	for _, v := range vulns {
+		for _, cve := range v.CVEs {
+			if !containsString(cves, cve) {
+				cves = append(cves, cve)
+			}
+		}
+		entry := strings.Join(v.CVEs, "/") + " " + v.Title
+		if v.FixedIn != "" {
+			entry += " (fixed in " + v.FixedIn + ")"
+			if fixedIn == "" || payloads.CompareVersions(v.FixedIn, fixedIn) > 0 {
+				fixedIn = v.FixedIn
+			}
+		}
+		listed = append(listed, entry)
+	}
+	what := d.name
+	if d.typ != "core" {
+		what = fmt.Sprintf("%s %s %q", d.name, d.typ, d.slug)
+	}
+	remediation := fmt.Sprintf("Update %s to the latest release", d.name)
+	if fixedIn != "" {
+		remediation += " (at least " + fixedIn + ")"
+	}
+	if d.typ != "core" {
+		remediation += ", or remove it if it is not used"
+	}
+	finding := scanner.VulnerabilityResult{
+		VulnerabilityType: "Known Vulnerable Component",
+		URL:               d.url,
+		Details:           fmt.Sprintf("%s is installed at version %s, which is affected by %s: %s.", what, d.version, strings.Join(cves, ", "), strings.Join(listed, "; ")),
+		Severity:          vulns[0].Severity,
+		Evidence:          d.evidence,
+		Remediation:       remediation + ".",
+		ScannerName:       ModuleName,
+		CVE:               vulns[0].CVEs[0],
+		Enrichment:        map[string]interface{}{"cves": cves, "component": d.slug, "version": d.version},
+	}
+	finding.SetExchange(d.exchange)
+	return finding
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
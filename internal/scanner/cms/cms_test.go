@@ -0,0 +1,114 @@
+package cms
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/fingerprint"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newWordPressSite(t *testing.T, requests *int32) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><head>
+<script src="/wp-content/plugins/contact-form-7/includes/js/index.js?ver=5.3.1"></script>
+<link rel="stylesheet" href="/wp-content/plugins/akismet/_inc/akismet.css?ver=5.0">
+<link rel="stylesheet" href="/wp-content/themes/twentytwentyfour/style.css?ver=1.0">
+</head><body>Blog</body></html>`)
+		case "/wp-content/plugins/contact-form-7/readme.txt":
+			fmt.Fprint(w, "=== Contact Form 7 ===\nContributors: takayukister\nStable tag: 5.3.1\nLicense: GPLv2\n")
+		case "/wp-content/plugins/akismet/readme.txt":
+			fmt.Fprint(w, "=== Akismet Anti-spam ===\nStable tag: 5.0\n")
+		case "/wp-content/themes/twentytwentyfour/style.css":
+			fmt.Fprint(w, "/*\nTheme Name: Twenty Twenty-Four\nVersion: 1.0\n*/")
+		default:
+			// Soft-404: every other path answers 200 with the blog's not found page.
+			fmt.Fprintf(w, "<html><body>Nothing found for %s</body></html>", r.URL.Path)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCMSScanner(t *testing.T) {
+	var requests int32
+	server := newWordPressSite(t, &requests)
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, FollowRedirects: true})
+	opts := scanner.ScannerOptions{Technologies: fingerprint.Technologies{{Name: "WordPress"}}}
+	s := NewCMSScanner()
+
+	findings, err := s.Scan(context.Background(), crawler.ParameterizedRequest{URL: server.URL + "/", Method: "GET"}, client, log, opts)
+	require.NoError(t, err)
+	require.Len(t, findings, 1, "only Contact Form 7 has a vulnerable version")
+	f := findings[0]
+	assert.Equal(t, "Known Vulnerable Component", f.VulnerabilityType)
+	assert.Equal(t, server.URL+"/wp-content/plugins/contact-form-7/readme.txt", f.URL)
+	assert.Equal(t, "CVE-2020-35489", f.CVE)
+	assert.Equal(t, "Critical", f.Severity)
+	assert.Contains(t, f.Details, "version 5.3.1")
+	assert.Contains(t, f.Evidence, `"Stable tag: 5.3.1"`)
+	assert.Contains(t, f.Remediation, "5.3.2")
+	assert.Equal(t, []string{"CVE-2020-35489"}, f.Enrichment["cves"])
+	assert.NotEmpty(t, f.RawRequest)
+
+	// Components are looked up once per site, and sites are only enumerated once.
+	sent := atomic.LoadInt32(&requests)
+	findings, err = s.Scan(context.Background(), crawler.ParameterizedRequest{URL: server.URL + "/?p=1", Method: "GET"}, client, log, opts)
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+	assert.Equal(t, sent, atomic.LoadInt32(&requests))
+}
+
+func TestCMSScannerRequestBudget(t *testing.T) {
+	var requests int32
+	server := newWordPressSite(t, &requests)
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, FollowRedirects: true})
+	opts := scanner.ScannerOptions{
+		Technologies:  fingerprint.Technologies{{Name: "WordPress"}},
+		ModuleOptions: map[string]map[string]interface{}{ModuleName: {"max_requests": 3}},
+	}
+
+	_, err := NewCMSScanner().Scan(context.Background(), crawler.ParameterizedRequest{URL: server.URL + "/", Method: "GET"}, client, log, opts)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestCMSScannerSkipsOtherTargets(t *testing.T) {
+	var requests int32
+	server := newWordPressSite(t, &requests)
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, FollowRedirects: true})
+
+	findings, err := NewCMSScanner().Scan(context.Background(), crawler.ParameterizedRequest{URL: server.URL + "/about", Method: "GET"}, client, log, scanner.ScannerOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+	assert.Zero(t, atomic.LoadInt32(&requests))
+}
+
+func TestReferences(t *testing.T) {
+	refs := references("wordpress", `<img src="/blog/wp-content/themes/astra/logo.png"><script src="/wp-content/plugins/forminator/build/front.js?ver=1.24.6">`)
+	assert.Equal(t, []component{
+		{platform: "wordpress", typ: "theme", slug: "astra"},
+		{platform: "wordpress", typ: "plugin", slug: "forminator", assetVersion: "1.24.6"},
+	}, refs)
+
+	refs = references("drupal", `/core/modules/system/css/a.css /modules/contrib/webform/js/b.js /sites/all/modules/views/c.js`)
+	assert.Equal(t, []component{
+		{platform: "drupal", typ: "module", slug: "webform"},
+		{platform: "drupal", typ: "module", slug: "views"},
+	}, refs)
+}
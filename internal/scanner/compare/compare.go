@@ -0,0 +1,171 @@
+// Package compare decides whether two response bodies differ after normalizing dynamic content.
+// It is shared by scanners that infer behavior from response differences (boolean-based SQLi,
+// CSRF replays).
+package compare
+
+import (
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+	"regexp"
+	"strings"
+
+	"github.com/agext/levenshtein"
+)
+
+// Response comparison modes, selected per scan with ScannerOptions.SimilarityMode.
+const (
+	// Levenshtein compares the normalized bodies character by character (default).
+	Levenshtein = "levenshtein"
+	// Structure compares only the sequence of HTML tags, ignoring all text.
+	Structure = "structure"
+	// Words compares the sets of words in the bodies (Jaccard index).
+	Words = "words"
+)
+
+// DefaultThreshold is the similarity below which two responses count as different.
+const DefaultThreshold = 0.95
+
+// hiddenStatePatterns match hidden form fields and CSRF meta tags (view state, nonces);
+// the tag is kept and only its value is blanked.
+var hiddenStatePatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)(<input[^>]*type=["']?hidden["']?[^>]*value=)["'][^"']*["']`),
+	regexp.MustCompile(`(?i)(<meta[^>]*name=["']?csrf[^>]*content=)["'][^"']*["']`),
+}
+
+// dynamicContentPatterns match content that changes between identical requests and would
+// otherwise dominate the comparison: dates, times, timestamps, UUIDs and long token-like blobs.
+var dynamicContentPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}(:\d{2})?(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`),
+	regexp.MustCompile(`\d{4}-\d{2}-\d{2}|\d{1,2}/\d{1,2}/\d{2,4}`),
+	regexp.MustCompile(`(?i)(mon|tue|wed|thu|fri|sat|sun), \d{1,2} \w{3} \d{4} \d{2}:\d{2}:\d{2}( \w+)?`),
+	regexp.MustCompile(`\b\d{1,2}:\d{2}(:\d{2})?\b`),
+	regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`),
+	regexp.MustCompile(`\b1[0-9]{9}([0-9]{3})?\b`),
+	regexp.MustCompile(`[A-Za-z0-9+/_=-]{32,}`),
+}
+
+var (
+	htmlTagPattern = regexp.MustCompile(`<\s*(/?[a-zA-Z][a-zA-Z0-9-]*)`)
+	wordPattern    = regexp.MustCompile(`[\p{L}\p{N}_]+`)
+)
+
+// Comparator decides whether two response bodies differ, using the threshold and
+// comparison mode configured for the scan.
+type Comparator struct {
+	Threshold float64
+	Mode      string
+	log       *logger.Logger
+	label     string // Scanner label prefixed to debug messages (e.g., "SQLi").
+}
+
+// New returns a comparator configured from opts, falling back to the Levenshtein mode and the
+// default threshold. label prefixes the comparator's debug messages.
+func New(opts scanner.ScannerOptions, log *logger.Logger, label string) Comparator {
+	cmp := Comparator{Threshold: opts.SimilarityThreshold, Mode: strings.ToLower(opts.SimilarityMode), log: log, label: label}
+	if cmp.Threshold <= 0 || cmp.Threshold > 1 {
+		cmp.Threshold = DefaultThreshold
+	}
+	switch cmp.Mode {
+	case Structure, Words:
+	default:
+		cmp.Mode = Levenshtein
+	}
+	return cmp
+}
+
+// IsDifferent reports whether modified is sufficiently different from original.
+// The score is logged at debug level so the threshold can be tuned per target.
+func (c Comparator) IsDifferent(original, modified string) bool {
+	score := c.Similarity(original, modified)
+	if c.log != nil {
+		c.log.Debug("%s: Response similarity %.3f (%s mode, threshold %.2f)", c.label, score, c.Mode, c.Threshold)
+	}
+	return score < c.Threshold
+}
+
+// Similarity returns a score between 0 (unrelated) and 1 (identical) for two normalized bodies.
+func (c Comparator) Similarity(original, modified string) float64 {
+	original, modified = Normalize(original), Normalize(modified)
+	switch c.Mode {
+	case Structure:
+		return sequenceSimilarity(htmlTagPattern.FindAllString(original, -1), htmlTagPattern.FindAllString(modified, -1))
+	case Words:
+		return wordSetSimilarity(original, modified)
+	}
+	return levenshteinSimilarity(original, modified)
+}
+
+// Normalize replaces dynamic content with fixed placeholders.
+func Normalize(body string) string {
+	for _, re := range hiddenStatePatterns {
+		body = re.ReplaceAllString(body, `${1}""`)
+	}
+	for _, re := range dynamicContentPatterns {
+		body = re.ReplaceAllString(body, "~")
+	}
+	return body
+}
+
+// levenshteinSimilarity returns 1 - distance/maxLen for two strings.
+func levenshteinSimilarity(a, b string) float64 {
+	maxLen := len(a)
+	if len(b) > maxLen {
+		maxLen = len(b)
+	}
+	if maxLen == 0 {
+		return 1
+	}
+	return 1.0 - float64(levenshtein.Distance(a, b, nil))/float64(maxLen)
+}
+
+// sequenceSimilarity is levenshteinSimilarity over token sequences (e.g., HTML tags).
+func sequenceSimilarity(a, b []string) float64 {
+	maxLen := len(a)
+	if len(b) > maxLen {
+		maxLen = len(b)
+	}
+	if maxLen == 0 {
+		return 1
+	}
+	// Classic two-row edit distance over tokens.
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if strings.EqualFold(a[i-1], b[j-1]) {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return 1.0 - float64(prev[len(b)])/float64(maxLen)
+}
+
+// wordSetSimilarity returns the Jaccard index of the word sets of two bodies.
+func wordSetSimilarity(a, b string) float64 {
+	setA, setB := wordSet(a), wordSet(b)
+	if len(setA) == 0 && len(setB) == 0 {
+		return 1
+	}
+	intersection := 0
+	for word := range setA {
+		if setB[word] {
+			intersection++
+		}
+	}
+	return float64(intersection) / float64(len(setA)+len(setB)-intersection)
+}
+
+func wordSet(body string) map[string]bool {
+	set := make(map[string]bool)
+	for _, word := range wordPattern.FindAllString(strings.ToLower(body), -1) {
+		set[word] = true
+	}
+	return set
+}
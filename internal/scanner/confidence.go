@@ -0,0 +1,57 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Confidence levels of a finding: how sure the scanner is that it is real, independently of its
+// severity.
+const (
+	// ConfidenceCertain is proof of the vulnerability, e.g. a database-specific error message,
+	// data read back or a hijacked session.
+	ConfidenceCertain = "Certain"
+	// ConfidenceFirm is strong, reproduced indirect evidence, e.g. a normalized boolean
+	// differential or a delay confirmed with several sleeps. Findings whose scanner does not rate
+	// them are Firm.
+	ConfidenceFirm = "Firm"
+	// ConfidenceTentative is a single signal that may have another cause, e.g. a response length
+	// change or a delay measured once.
+	ConfidenceTentative = "Tentative"
+)
+
+// confidenceOrder lists the confidence levels from the most to the least certain.
+var confidenceOrder = []string{ConfidenceCertain, ConfidenceFirm, ConfidenceTentative}
+
+// ParseConfidence validates a confidence level (case-insensitive) and returns it normalized.
+func ParseConfidence(confidence string) (string, error) {
+	for _, c := range confidenceOrder {
+		if strings.EqualFold(c, strings.TrimSpace(confidence)) {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown confidence %q; use certain, firm or tentative", confidence)
+}
+
+// ConfidenceAtLeast reports whether confidence is at least as certain as threshold. An empty
+// confidence counts as ConfidenceFirm; an empty threshold accepts every finding.
+func ConfidenceAtLeast(confidence, threshold string) bool {
+	if threshold == "" {
+		return true
+	}
+	if confidence == "" {
+		confidence = ConfidenceFirm
+	}
+	return confidenceRank(confidence) <= confidenceRank(threshold)
+}
+
+// confidenceRank returns the position of a confidence in confidenceOrder, or len(confidenceOrder)
+// for unknown ones.
+func confidenceRank(confidence string) int {
+	for i, c := range confidenceOrder {
+		if strings.EqualFold(c, confidence) {
+			return i
+		}
+	}
+	return len(confidenceOrder)
+}
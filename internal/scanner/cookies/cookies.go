@@ -0,0 +1,168 @@
+package cookies
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// CookieScanner implements the PassiveScanner interface for insecure cookie attributes. It
+// collects the Set-Cookie headers seen while crawling and reports each cookie once per host.
+type CookieScanner struct{}
+
+// NewCookieScanner creates a new instance of CookieScanner.
+func NewCookieScanner() *CookieScanner {
+	return &CookieScanner{}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "cookies",
+		Order:          240,
+		DefaultEnabled: true,
+		NewPassive:     func(scanner.Env) scanner.PassiveScanner { return NewCookieScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *CookieScanner) Name() string {
+	return "Cookie Attributes Scanner"
+}
+
+// cookieIssues collects what is wrong with one cookie on one host.
+type cookieIssues struct {
+	hostKey string
+	name    string
+	session bool
+	raw     string   // First Set-Cookie line with issues.
+	issues  []string // Distinct issues, in order of discovery.
+	urls    []string // URLs whose response set the cookie with issues.
+}
+
+// ScanResponses checks every Set-Cookie header of the crawled responses.
+func (s *CookieScanner) ScanResponses(responses []crawler.CrawledResponse, log *logger.Logger) []scanner.VulnerabilityResult {
+	cookies := make(map[string]*cookieIssues)
+	var order []string
+
+	for _, resp := range responses {
+		parsedURL, err := url.Parse(resp.URL)
+		if err != nil {
+			continue
+		}
+		hostKey := parsedURL.Scheme + "://" + parsedURL.Host
+		for _, line := range resp.Header.Values("Set-Cookie") {
+			cookie := parseSetCookie(line)
+			if cookie == nil || isDeletion(cookie) {
+				continue
+			}
+			issues := checkCookie(cookie, parsedURL)
+			if len(issues) == 0 {
+				continue
+			}
+			log.Debug("Cookies: '%s' set by %s has issues: %s", cookie.Name, resp.URL, strings.Join(issues, "; "))
+
+			key := hostKey + " " + cookie.Name
+			c, ok := cookies[key]
+			if !ok {
+				c = &cookieIssues{hostKey: hostKey, name: cookie.Name, session: payloads.IsSessionCookieName(cookie.Name), raw: line}
+				cookies[key] = c
+				order = append(order, key)
+			}
+			for _, issue := range issues {
+				if !slices.Contains(c.issues, issue) {
+					c.issues = append(c.issues, issue)
+				}
+			}
+			if !slices.Contains(c.urls, resp.URL) {
+				c.urls = append(c.urls, resp.URL)
+			}
+		}
+	}
+
+	findings := make([]scanner.VulnerabilityResult, 0, len(order))
+	for _, key := range order {
+		findings = append(findings, s.newResult(cookies[key]))
+	}
+	return findings
+}
+
+// newResult builds the finding for one cookie on a host.
+func (s *CookieScanner) newResult(c *cookieIssues) scanner.VulnerabilityResult {
+	kind, severity := "Cookie", "Low"
+	if c.session {
+		kind, severity = "Session cookie", "Medium"
+	}
+	return scanner.VulnerabilityResult{
+		VulnerabilityType: "Insecure Cookie Attributes",
+		URL:               c.hostKey + "/",
+		Parameter:         c.name,
+		Location:          "cookie",
+		Details:           fmt.Sprintf("%s '%s' is set with insecure attributes: %s. Set by: %s", kind, c.name, strings.Join(c.issues, "; "), scanner.ListURLs(c.urls)),
+		Severity:          severity,
+		Evidence:          "Set-Cookie: " + c.raw,
+		Remediation:       "Set Secure on every cookie of an HTTPS site and HttpOnly on session cookies, use SameSite=Lax or Strict (SameSite=None only together with Secure), omit the Domain attribute so cookies stay host-only, and keep session cookie lifetimes short.",
+		ScannerName:       "cookies",
+	}
+}
+
+// checkCookie returns the issues of a cookie set by a response from pageURL.
+func checkCookie(cookie *http.Cookie, pageURL *url.URL) []string {
+	session := payloads.IsSessionCookieName(cookie.Name)
+	var issues []string
+	if pageURL.Scheme == "https" && !cookie.Secure {
+		issues = append(issues, "missing Secure on an HTTPS site, so it is also sent over plain HTTP")
+	}
+	if session && !cookie.HttpOnly {
+		issues = append(issues, "missing HttpOnly, so scripts (e.g., an XSS payload) can read it")
+	}
+	if cookie.SameSite == http.SameSiteNoneMode && !cookie.Secure {
+		issues = append(issues, "SameSite=None without Secure, which browsers reject or send cross-site over HTTP")
+	}
+	if domain := strings.TrimPrefix(strings.ToLower(cookie.Domain), "."); domain != "" && isParentDomain(domain, strings.ToLower(pageURL.Hostname())) {
+		issues = append(issues, fmt.Sprintf("Domain=%s shares it with every subdomain of %s", cookie.Domain, domain))
+	}
+	if days := lifetimeDays(cookie); session && days > payloads.AuthCookieMaxLifetimeDays {
+		issues = append(issues, fmt.Sprintf("expires after %d days (more than %d for an authentication cookie)", days, payloads.AuthCookieMaxLifetimeDays))
+	}
+	return issues
+}
+
+// parseSetCookie parses a single Set-Cookie header line, returning nil if it is malformed.
+func parseSetCookie(line string) *http.Cookie {
+	resp := http.Response{Header: http.Header{"Set-Cookie": {line}}}
+	parsed := resp.Cookies()
+	if len(parsed) == 0 {
+		return nil
+	}
+	return parsed[0]
+}
+
+// isDeletion reports whether the Set-Cookie line removes the cookie instead of setting it.
+func isDeletion(cookie *http.Cookie) bool {
+	return cookie.MaxAge < 0 || (!cookie.Expires.IsZero() && cookie.Expires.Before(time.Now()))
+}
+
+// lifetimeDays returns the lifetime of a persistent cookie in whole days (0 for session cookies).
+// Max-Age takes precedence over Expires, as in browsers.
+func lifetimeDays(cookie *http.Cookie) int {
+	switch {
+	case cookie.MaxAge > 0:
+		return cookie.MaxAge / 86400
+	case !cookie.Expires.IsZero():
+		return int(time.Until(cookie.Expires).Hours() / 24)
+	}
+	return 0
+}
+
+// isParentDomain reports whether domain is a parent of host, i.e. the cookie is also sent to
+// sibling subdomains.
+func isParentDomain(domain, host string) bool {
+	return host != domain && strings.HasSuffix(host, "."+domain)
+}
@@ -0,0 +1,99 @@
+package cookies
+
+import (
+	"net/http"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanResponses(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		setCookie    string
+		wantSeverity string
+		wantIssues   []string
+	}{
+		{
+			name:         "Session cookie without Secure and HttpOnly",
+			url:          "https://shop.example.com/login",
+			setCookie:    "PHPSESSID=abc123; Path=/",
+			wantSeverity: "Medium",
+			wantIssues:   []string{"missing Secure", "missing HttpOnly"},
+		},
+		{
+			name:         "SameSite=None without Secure",
+			url:          "http://shop.example.com/",
+			setCookie:    "prefs=dark; Path=/; SameSite=None",
+			wantSeverity: "Low",
+			wantIssues:   []string{"SameSite=None without Secure"},
+		},
+		{
+			name:         "Domain shared with subdomains",
+			url:          "https://shop.example.com/",
+			setCookie:    "connect.sid=s%3Aabc; Domain=.example.com; Path=/; Secure; HttpOnly",
+			wantSeverity: "Medium",
+			wantIssues:   []string{"Domain=.example.com shares it with every subdomain of example.com"},
+		},
+		{
+			name:         "Long-lived authentication cookie",
+			url:          "https://shop.example.com/",
+			setCookie:    "remember_token=xyz; Max-Age=31536000; Secure; HttpOnly",
+			wantSeverity: "Medium",
+			wantIssues:   []string{"expires after 365 days"},
+		},
+		{
+			name:      "Secure session cookie",
+			url:       "https://shop.example.com/",
+			setCookie: "JSESSIONID=abc; Path=/; Secure; HttpOnly; SameSite=Lax",
+		},
+		{
+			name:      "Cookie deletion",
+			url:       "https://shop.example.com/logout",
+			setCookie: "session=; Max-Age=0",
+		},
+		{
+			name:      "Non-session cookie without HttpOnly",
+			url:       "https://shop.example.com/",
+			setCookie: "theme=dark; Secure",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := []crawler.CrawledResponse{{URL: tt.url, StatusCode: http.StatusOK, Header: http.Header{"Set-Cookie": {tt.setCookie}}}}
+
+			findings := NewCookieScanner().ScanResponses(responses, logger.NewLogger(logger.ERROR))
+			if tt.wantSeverity == "" {
+				assert.Empty(t, findings)
+				return
+			}
+			require.Len(t, findings, 1)
+			assert.Equal(t, tt.wantSeverity, findings[0].Severity)
+			assert.Equal(t, "Set-Cookie: "+tt.setCookie, findings[0].Evidence)
+			for _, issue := range tt.wantIssues {
+				assert.Contains(t, findings[0].Details, issue)
+			}
+		})
+	}
+}
+
+func TestScanResponsesGroupsPerCookieAndHost(t *testing.T) {
+	header := http.Header{"Set-Cookie": {"session=abc; Path=/"}}
+	responses := []crawler.CrawledResponse{
+		{URL: "https://a.example.com/", StatusCode: http.StatusOK, Header: header},
+		{URL: "https://a.example.com/cart", StatusCode: http.StatusOK, Header: header},
+		{URL: "https://b.example.com/", StatusCode: http.StatusOK, Header: header},
+	}
+
+	findings := NewCookieScanner().ScanResponses(responses, logger.NewLogger(logger.ERROR))
+	require.Len(t, findings, 2)
+	assert.Equal(t, "session", findings[0].Parameter)
+	assert.Contains(t, findings[0].Details, "Set by: https://a.example.com/, https://a.example.com/cart")
+	assert.Equal(t, "https://b.example.com/", findings[1].URL)
+}
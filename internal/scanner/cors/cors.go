@@ -6,6 +6,7 @@ import (
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
+	"context"
 	"fmt"
 	"net/http"
 	"strings"
@@ -24,11 +25,21 @@ func NewCORSScanner() *CORSScanner {
 	return &CORSScanner{urlsScanned: make(map[string]bool)}
 }
 
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "cors",
+		Order:          130,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewCORSScanner() },
+	})
+}
+
 func (s *CORSScanner) Name() string {
 	return "CORS Misconfiguration Scanner"
 }
 
-func (s *CORSScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+func (s *CORSScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
 	var findings []scanner.VulnerabilityResult
 	targetURL := req.URL
 
@@ -43,7 +54,7 @@ func (s *CORSScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.
 	log.Debug("Running CORS check on: %s", targetURL)
 
 	for _, testCase := range payloads.CORSTests {
-		httpRequest, _ := http.NewRequest("GET", targetURL, nil)
+		httpRequest, _ := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
 		httpRequest.Header.Set("Origin", testCase.OriginHeader)
 
 		resp, err := client.Do(httpRequest)
@@ -84,8 +95,8 @@ func (s *CORSScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.
 		}
 
 		// --- Reduce false positives on public stateless endpoints ---
+		credsInvolved := setCookie || hasRealCookie || authHeader
 		if vulnerable {
-			credsInvolved := setCookie || hasRealCookie || authHeader
 			isPublic := false
 			for _, p := range publicPathsCORS {
 				if strings.Contains(targetURL, p) {
@@ -110,7 +121,7 @@ func (s *CORSScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.
 			evidence := fmt.Sprintf("Access-Control-Allow-Origin: %s, Access-Control-Allow-Credentials: %s", acaoHeader, acacHeader)
 			remediation := "Do not reflect arbitrary Origin headers or use wildcards. Validate and explicitly whitelist trusted origins. Avoid using 'Access-Control-Allow-Credentials: true' with wildcards or dynamic origins."
 
-			findings = append(findings, scanner.VulnerabilityResult{
+			vuln := scanner.VulnerabilityResult{
 				VulnerabilityType: "CORS Misconfiguration",
 				URL:               targetURL,
 				Payload:           fmt.Sprintf("Origin: %s", testCase.OriginHeader),
@@ -119,7 +130,12 @@ func (s *CORSScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.
 				Evidence:          evidence,
 				Remediation:       remediation,
 				ScannerName:       s.Name(),
-			})
+			}
+			if !credsInvolved {
+				// Without credentials, other origins can only read what they could fetch themselves.
+				vuln.SetCVSSMetrics("C:L", "I:N")
+			}
+			findings = append(findings, vuln)
 			return findings, nil
 		}
 	}
@@ -0,0 +1,200 @@
+package scanner
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"errors"
+	"sort"
+	"sync"
+)
+
+// CoverageStatus is the outcome of the test of one parameter by one module.
+type CoverageStatus string
+
+const (
+	CoverageTested  CoverageStatus = "tested"
+	CoverageSkipped CoverageStatus = "skipped"
+	CoverageErrored CoverageStatus = "errored"
+)
+
+// SkipReason tells why a module left a parameter untested.
+type SkipReason string
+
+const (
+	SkipReasonRule      SkipReason = "skip_rule"          // A skip rule excludes the parameter or the path.
+	SkipReasonScope     SkipReason = "out_of_scope"       // The request is outside the scan scope.
+	SkipReasonBudget    SkipReason = "budget_exhausted"   // The request budget of the parameter ran out.
+	SkipReasonDuplicate SkipReason = "duplicate"          // The request was collapsed with structurally identical ones.
+	SkipReasonInert     SkipReason = "inert"              // The pre-flight found the parameter inert.
+	SkipReasonMethod    SkipReason = "method_unsupported" // The module does not test requests of this method.
+)
+
+// skipReasons are the skip reasons in the order they are summarized.
+var skipReasons = []SkipReason{SkipReasonRule, SkipReasonScope, SkipReasonBudget, SkipReasonDuplicate, SkipReasonInert, SkipReasonMethod}
+
+// CoverageEntry is the outcome of the test of one parameter of a request by one module.
+type CoverageEntry struct {
+	Module string         `json:"module"`
+	Method string         `json:"method"`
+	URL    string         `json:"url"`
+	Param  string         `json:"param,omitempty"` // Empty for a request without parameters.
+	Status CoverageStatus `json:"status"`
+	Reason SkipReason     `json:"reason,omitempty"` // Why a skipped parameter was skipped.
+	Error  string         `json:"error,omitempty"`  // Last error of an errored test.
+}
+
+// CoverageSummary counts the parameter tests of a scan by outcome. Entries lists each of them
+// when the scan records them (-coverage).
+type CoverageSummary struct {
+	Tested  int                `json:"tested"`
+	Skipped map[SkipReason]int `json:"skipped,omitempty"` // Skipped tests per reason.
+	Errored int                `json:"errored"`
+	Entries []CoverageEntry    `json:"entries,omitempty"`
+}
+
+// SkippedTotal returns the number of skipped tests.
+func (s CoverageSummary) SkippedTotal() int {
+	total := 0
+	for _, n := range s.Skipped {
+		total += n
+	}
+	return total
+}
+
+// Reasons returns the reasons of Skipped with their counts, in a fixed order.
+func (s CoverageSummary) Reasons() []SkipCount {
+	var counts []SkipCount
+	for _, reason := range skipReasons {
+		if n := s.Skipped[reason]; n > 0 {
+			counts = append(counts, SkipCount{Reason: reason, Count: n})
+		}
+	}
+	return counts
+}
+
+// SkipCount is the number of tests skipped for one reason.
+type SkipCount struct {
+	Reason SkipReason
+	Count  int
+}
+
+// Coverage records, for every parameter of every request and every module, whether the module
+// tested it, skipped it and why, or failed. Manager.RunScans records the requests it does not
+// hand to a module and the outcome of the others; modules record the parameters they skip
+// themselves with Skip. A nil *Coverage records nothing.
+type Coverage struct {
+	mu      sync.Mutex
+	entries map[coverageKey]*CoverageEntry
+}
+
+// coverageKey identifies the test of a parameter of a request by a module.
+type coverageKey struct {
+	module, method, url, param string
+}
+
+// NewCoverage creates an empty Coverage.
+func NewCoverage() *Coverage {
+	return &Coverage{entries: make(map[coverageKey]*CoverageEntry)}
+}
+
+// coverageParams returns the parameters of req a coverage entry is recorded for: one per
+// parameter, or the empty name for a request without parameters.
+func coverageParams(req crawler.ParameterizedRequest) []string {
+	if len(req.ParamNames) == 0 {
+		return []string{""}
+	}
+	return req.ParamNames
+}
+
+// Skip records that module left the parameter name of req untested for reason.
+func (c *Coverage) Skip(module string, req crawler.ParameterizedRequest, name string, reason SkipReason) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[coverageKey{module, req.Method, req.URL, name}] = &CoverageEntry{Module: module, Method: req.Method, URL: req.URL, Param: name, Status: CoverageSkipped, Reason: reason}
+}
+
+// SkipRequest records that module left every parameter of req untested for reason.
+func (c *Coverage) SkipRequest(module string, req crawler.ParameterizedRequest, reason SkipReason) {
+	for _, name := range coverageParams(req) {
+		c.Skip(module, req, name, reason)
+	}
+}
+
+// complete records the outcome of the test of req by module: the parameters the module did not
+// skip are tested, or errored when err is not nil. An exhausted request budget skips them.
+func (c *Coverage) complete(module string, req crawler.ParameterizedRequest, err error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, name := range coverageParams(req) {
+		key := coverageKey{module, req.Method, req.URL, name}
+		if entry, ok := c.entries[key]; ok && entry.Status == CoverageSkipped {
+			continue
+		}
+		entry := &CoverageEntry{Module: module, Method: req.Method, URL: req.URL, Param: name, Status: CoverageTested}
+		switch {
+		case errors.Is(err, httpclient.ErrRequestBudgetExhausted):
+			entry.Status, entry.Reason = CoverageSkipped, SkipReasonBudget
+		case err != nil:
+			entry.Status, entry.Error = CoverageErrored, err.Error()
+		}
+		c.entries[key] = entry
+	}
+}
+
+// Summary returns the counts of the outcomes recorded so far, with every entry, sorted by URL,
+// method, parameter and module, when withEntries is set.
+func (c *Coverage) Summary(withEntries bool) CoverageSummary {
+	if c == nil {
+		return CoverageSummary{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	summary := CoverageSummary{Skipped: make(map[SkipReason]int)}
+	for _, entry := range c.entries {
+		switch entry.Status {
+		case CoverageTested:
+			summary.Tested++
+		case CoverageSkipped:
+			summary.Skipped[entry.Reason]++
+		case CoverageErrored:
+			summary.Errored++
+		}
+		if withEntries {
+			summary.Entries = append(summary.Entries, *entry)
+		}
+	}
+	sort.Slice(summary.Entries, func(i, j int) bool {
+		a, b := summary.Entries[i], summary.Entries[j]
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		if a.Param != b.Param {
+			return a.Param < b.Param
+		}
+		return a.Module < b.Module
+	})
+	return summary
+}
+
+// LogSummary logs the number of parameter tests run, failed and skipped, with a line per skip
+// reason.
+func (c *Coverage) LogSummary(log *logger.Logger) {
+	summary := c.Summary(false)
+	if summary.Tested+summary.SkippedTotal()+summary.Errored == 0 {
+		return
+	}
+	log.Info("ScannerManager: Coverage: %d parameter test(s) run, %d failed, %d skipped.", summary.Tested, summary.Errored, summary.SkippedTotal())
+	for _, count := range summary.Reasons() {
+		log.Info("ScannerManager:   skipped (%s): %d", count.Reason, count.Count)
+	}
+}
@@ -0,0 +1,213 @@
+package crlf
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/requtil"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// CRLFScanner implements the Scanner interface for CRLF injection (HTTP response splitting).
+type CRLFScanner struct{}
+
+// NewCRLFScanner creates a new instance of CRLFScanner.
+func NewCRLFScanner() *CRLFScanner {
+	return &CRLFScanner{}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "crlf",
+		Order:          220,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewCRLFScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *CRLFScanner) Name() string {
+	return "CRLF Injection Scanner"
+}
+
+// Scan injects encoded line breaks followed by a header into each query and body parameter.
+// A finding is High when the injected header appears in the response header block, and Medium
+// when the decoded line break only reaches the body (log-injection style).
+func (s *CRLFScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	if len(req.ParamNames) == 0 {
+		return nil, nil
+	}
+	log.Debug("Starting CRLF injection scan for %s %s", req.Method, req.URL)
+
+	var findings []scanner.VulnerabilityResult
+	for _, paramName := range req.ParamNames {
+		if opts.SkipParam("crlf", paramName) {
+			continue
+		}
+		var partial *scanner.VulnerabilityResult
+
+	PayloadLoop:
+		for _, test := range payloads.CRLFTests {
+			for _, header := range payloads.CRLFInjectedHeaders {
+				if ctx.Err() != nil {
+					return findings, ctx.Err()
+				}
+				payload := test.Prefix + header.Raw
+				respHeader, body, exchange, err := sendCRLFRequest(ctx, req, client, paramName, payload)
+				if err != nil {
+					if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+						return findings, nil
+					}
+					continue
+				}
+
+				if injectedHeaderLanded(respHeader, header) {
+					log.Success("CRLF Injection: Header '%s' injected via '%s' (%s) at %s", header.Name, paramName, test.Technique, req.URL)
+					vuln := s.newResult(req, paramName, payload, "High",
+						fmt.Sprintf("The parameter is written into the response headers without neutralizing line breaks (%s), so arbitrary headers can be injected (HTTP response splitting). This enables session fixation via Set-Cookie, cache poisoning and XSS.", test.Technique),
+						fmt.Sprintf("%s: %s", header.Name, respHeader.Get(header.Name)))
+					vuln.SetExchange(exchange)
+					findings = append(findings, vuln)
+					partial = nil
+					break PayloadLoop
+				}
+
+				if partial == nil {
+					if evidence, ok := partialInjection(body, header); ok {
+						vuln := s.newResult(req, paramName, payload, "Medium",
+							fmt.Sprintf("The decoded line break (%s) reaches the response, but did not split the header block. Output built from this value, such as log files or text exports, can be forged (log injection).", test.Technique),
+							evidence)
+						vuln.SetExchange(exchange)
+						partial = &vuln
+					}
+				}
+			}
+		}
+
+		if partial != nil {
+			log.Success("CRLF Injection: Line break reflected via '%s' at %s (partial)", paramName, req.URL)
+			findings = append(findings, *partial)
+		}
+	}
+	return findings, nil
+}
+
+// newResult builds a CRLF finding for paramName.
+func (s *CRLFScanner) newResult(req crawler.ParameterizedRequest, paramName, payload, severity, details, evidence string) scanner.VulnerabilityResult {
+	return scanner.VulnerabilityResult{
+		VulnerabilityType: "CRLF Injection",
+		URL:               req.URL,
+		Parameter:         paramName,
+		Payload:           payload,
+		Location:          getParamLocation(req),
+		Details:           details,
+		Severity:          severity,
+		Evidence:          evidence,
+		Remediation:       "Reject or strip CR and LF characters (including encoded and unicode variants) from user input before placing it in response headers, and use the framework's header APIs, which validate values.",
+		ScannerName:       s.Name(),
+	}
+}
+
+// injectedHeaderLanded reports whether the injected header is present in the response headers.
+func injectedHeaderLanded(respHeader http.Header, header payloads.CRLFInjectedHeader) bool {
+	for _, value := range respHeader.Values(header.Name) {
+		if strings.HasPrefix(strings.TrimSpace(value), header.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// partialInjection looks for the injected header text after a decoded line break in the body
+// and returns the line it forged. Header values are not checked: an injected text without its
+// line break there only shows that the server strips CR and LF.
+func partialInjection(body string, header payloads.CRLFInjectedHeader) (string, bool) {
+	injected := header.Name + ": " + header.Value
+	for _, lineBreak := range []string{"\r\n", "\n", "\r"} {
+		if index := strings.Index(body, lineBreak+injected); index >= 0 {
+			start := strings.LastIndexAny(body[:index], "\r\n") + 1
+			return fmt.Sprintf("Body: %q", body[start:index+len(lineBreak)+len(injected)]), true
+		}
+	}
+	return "", false
+}
+
+// sendCRLFRequest sends the request with paramName set to payload and returns the response
+// headers and body along with the raw exchange for evidence.
+func sendCRLFRequest(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, paramName, payload string) (http.Header, string, scanner.Exchange, error) {
+	httpReq, err := newRequest(ctx, req, paramName, payload)
+	if err != nil {
+		return nil, "", scanner.Exchange{}, err
+	}
+
+	// Redirects are the most common sink, and the injected header lands on the redirect itself.
+	client = client.WithoutRedirects()
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, "", scanner.Exchange{}, err
+	}
+	defer resp.Body.Close()
+	body, err := requtil.ReadBody(client, resp)
+	if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
+		return nil, "", scanner.Exchange{}, err
+	}
+	return resp.Header, string(body), scanner.CaptureExchange(httpReq, resp, body), nil
+}
+
+// rawMarker stands in for the payload while requtil encodes the parameters; it needs no encoding.
+const rawMarker = "dursgocrlfpayload"
+
+// newRequest builds the request with the already URL-encoded payload placed verbatim in the
+// query (GET) or form body, so the encoding under test reaches the target unchanged. JSON bodies
+// get the decoded payload.
+func newRequest(ctx context.Context, req crawler.ParameterizedRequest, paramName, payload string) (*http.Request, error) {
+	if req.IsJSON() {
+		decoded, err := url.PathUnescape(payload)
+		if err != nil {
+			decoded = payload
+		}
+		return requtil.Override(ctx, req, paramName, decoded)
+	}
+
+	params, err := requtil.Params(req)
+	if err != nil {
+		return nil, err
+	}
+	params = requtil.Copy(params)
+	params.Set(paramName, rawMarker)
+	testURL, reqBody, err := requtil.Components(req, params)
+	if err != nil {
+		return nil, err
+	}
+	var body io.Reader
+	if reqBody != nil {
+		raw, err := io.ReadAll(reqBody)
+		if err != nil {
+			return nil, err
+		}
+		body = strings.NewReader(strings.Replace(string(raw), rawMarker, payload, 1))
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, strings.Replace(testURL, rawMarker, payload, 1), body)
+	if err != nil {
+		return nil, err
+	}
+	requtil.SetContentType(httpReq, req)
+	return httpReq, nil
+}
+
+func getParamLocation(req crawler.ParameterizedRequest) string {
+	if req.Method == "GET" {
+		return "query"
+	}
+	return "body"
+}
@@ -0,0 +1,112 @@
+package crlf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rawRedirect writes a redirect with the lang parameter copied verbatim into the Location header,
+// bypassing net/http's header sanitization the way a vulnerable server would.
+func rawRedirect(decode func(string) string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lang := decode(r.URL.Query().Get("lang"))
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		fmt.Fprintf(buf, "HTTP/1.1 302 Found\r\nLocation: /home?lang=%s\r\nContent-Length: 0\r\nConnection: close\r\n\r\n", lang)
+		buf.Flush()
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name          string
+		handler       http.HandlerFunc
+		wantSeverity  string
+		wantEvidence  string
+		wantTechnique string
+	}{
+		{
+			name:          "Header splitting",
+			handler:       rawRedirect(func(v string) string { return v }),
+			wantSeverity:  "High",
+			wantEvidence:  "Set-Cookie: dursgo=1",
+			wantTechnique: "URL-encoded CRLF",
+		},
+		{
+			name: "Double encoding bypasses a CRLF filter",
+			handler: rawRedirect(func(v string) string {
+				if strings.ContainsAny(v, "\r\n") {
+					return "en"
+				}
+				return strings.NewReplacer("%0d", "\r", "%0a", "\n").Replace(v)
+			}),
+			wantSeverity:  "High",
+			wantTechnique: "double URL-encoded CRLF",
+		},
+		{
+			name: "Line break only reaches the body",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("Language set to " + r.URL.Query().Get("lang")))
+			},
+			wantSeverity: "Medium",
+			wantEvidence: `Body: "Language set to \r\nSet-Cookie: dursgo=1"`,
+		},
+		{
+			name: "Line breaks stripped",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/home?lang="+stripLineBreaks(r.URL.Query().Get("lang")), http.StatusFound)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, FollowRedirects: true})
+			req := crawler.ParameterizedRequest{
+				Method:     "GET",
+				URL:        server.URL + "/language?lang=en",
+				ParamNames: []string{"lang"},
+			}
+
+			findings, err := NewCRLFScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			if tt.wantSeverity == "" {
+				assert.Empty(t, findings)
+				return
+			}
+			require.Len(t, findings, 1)
+			assert.Equal(t, tt.wantSeverity, findings[0].Severity)
+			assert.Equal(t, "lang", findings[0].Parameter)
+			if tt.wantEvidence != "" {
+				assert.Equal(t, tt.wantEvidence, findings[0].Evidence)
+			}
+			if tt.wantTechnique != "" {
+				assert.Contains(t, findings[0].Details, "("+tt.wantTechnique+")")
+			}
+		})
+	}
+}
+
+// stripLineBreaks removes line breaks, as safe header APIs do.
+func stripLineBreaks(v string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(v)
+}
@@ -6,7 +6,9 @@ import (
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
-	"crypto/sha256"
+	"Dursgo/internal/scanner/compare"
+	"Dursgo/internal/scanner/requtil"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,6 +18,13 @@ import (
 	"golang.org/x/net/html"
 )
 
+const (
+	// bogusTokenValue replaces the anti-CSRF token in the invalid-token replay.
+	bogusTokenValue = "dursgo_bad_csrf_token_test"
+	// crossSiteOrigin is sent as Origin/Referer on replays, as a forged cross-site request would be.
+	crossSiteOrigin = "https://dursgo-csrf.invalid"
+)
+
 // CSRFScanner implements the Scanner interface for Cross-Site Request Forgery.
 type CSRFScanner struct{}
 
@@ -24,15 +33,47 @@ func NewCSRFScanner() *CSRFScanner {
 	return &CSRFScanner{}
 }
 
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "csrf",
+		Order:          80,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewCSRFScanner() },
+	})
+}
+
 // Name returns the scanner's name.
 func (s *CSRFScanner) Name() string {
 	return "Cross-Site Request Forgery (CSRF) Scanner"
 }
 
+// respSnapshot captures the parts of a form submission that are compared between replays.
+type respSnapshot struct {
+	status   int
+	body     string
+	location string
+	cookies  []*http.Cookie
+	exchange scanner.Exchange
+}
+
+// succeeded reports whether the submission was accepted (2xx, or a 3xx redirect after processing).
+func (r respSnapshot) succeeded() bool {
+	return r.status >= 200 && r.status < 400
+}
+
+// replay is a forged variant of the original form submission.
+type replay struct {
+	description string
+	data        url.Values
+}
+
 // Scan performs a CSRF scan on the given parameterized request.
-// It checks if a POST form is vulnerable to CSRF by testing for the absence
-// or weak validation of CSRF tokens. It skips login/registration and file upload forms.
-func (s *CSRFScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+// It submits the POST form once as the browser would, then replays it cross-site without the
+// anti-CSRF token and with a bogus token. A finding is only raised when a replay produces the
+// same successful result as the original submission. SameSite attributes on session cookies
+// are used to grade the severity. Login/registration and file upload forms are skipped.
+func (s *CSRFScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
 	if req.Method != "POST" || !contains(req.ParamLocations, "body") {
 		return nil, nil
 	}
@@ -57,94 +98,131 @@ func (s *CSRFScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.
 		}
 	}
 
-	// --- LOGIC IMPROVEMENT ---
-	// If the form does not have a token field at all, THIS IS A VULNERABILITY.
-	if csrfTokenField == "" {
-		log.Success("CSRF: Found a form at %s without any CSRF token.", req.URL)
-		return []scanner.VulnerabilityResult{{
-			VulnerabilityType: "Cross-Site Request Forgery (CSRF)",
-			URL:               req.URL,
-			Location:          "body",
-			Details:           "The form does not contain any CSRF token, making it vulnerable to CSRF attacks.",
-			Severity:          "High",
-			Evidence:          "No CSRF token parameter found in the form.",
-			Remediation:       "Implement CSRF protection using synchronizer tokens, SameSite cookies, or double submit tokens.",
-			ScannerName:       "csrf",
-		}}, nil
+	// The token recorded by the crawler may have expired; fetch a fresh one together with the
+	// session cookies the source page sets.
+	sourceURL := req.SourceURL
+	if sourceURL == "" {
+		sourceURL = guessSourcePage(req.URL)
+	}
+	freshToken, cookies := fetchSourcePage(ctx, sourceURL, client, log)
+	if csrfTokenField != "" && freshToken != "" {
+		originalFormData.Set(csrfTokenField, freshToken)
 	}
 
-	// If a token IS FOUND, proceed with testing for weak token validation.
-	log.Debug("CSRF: Found potential token '%s'. Testing for weak validation.", csrfTokenField)
-
-	// ---- Step 1: Get baseline response with original data (only to ensure the form can be processed normally) ----
-	_, errBase := submitForm(req, client, log, originalFormData)
-	if errBase != nil {
+	// ---- Step 1: Submit the form as the application expects it ----
+	baseline, err := submitForm(ctx, req, client, originalFormData, false)
+	if err != nil {
+		return nil, nil
+	}
+	if !baseline.succeeded() {
+		log.Debug("CSRF: Original submission to %s was not accepted (status %d). Skipping.", req.URL, baseline.status)
 		return nil, nil
 	}
 
-	// ---- Step 2: Send request WITHOUT token ----
-	manipNoToken := cloneValues(originalFormData)
-	manipNoToken.Del(csrfTokenField)
-	noTokenSnap, _ := submitForm(req, client, log, manipNoToken)
-	if noTokenSnap.status == http.StatusOK || noTokenSnap.status == http.StatusFound {
-		details := fmt.Sprintf("Request without CSRF token was accepted (status %d).", noTokenSnap.status)
-		return []scanner.VulnerabilityResult{{
-			VulnerabilityType: "Cross-Site Request Forgery (CSRF)",
-			URL:               req.URL,
-			Location:          "body",
-			Details:           details,
-			Severity:          "medium",
-			Evidence:          "Token omitted and server response matched baseline.",
-			Remediation:       "Implement CSRF protection using synchronizer tokens, SameSite cookies, or double submit tokens.",
-			ScannerName:       "csrf",
-		}}, nil
-	}
-
-	// ---- Step 3: Send request with a bad token ----
-	badTokenValue := "dursgo_bad_csrf_token_test"
-	manipBad := cloneValues(originalFormData)
-	manipBad.Set(csrfTokenField, badTokenValue)
-	badTokenSnap, _ := submitForm(req, client, log, manipBad)
-	if badTokenSnap.status == http.StatusOK || badTokenSnap.status == http.StatusFound {
-		details := fmt.Sprintf("Request with invalid CSRF token was accepted (status %d).", badTokenSnap.status)
-		return []scanner.VulnerabilityResult{{
+	// ---- Step 2: Replay the submission cross-site without a valid token ----
+	var replays []replay
+	if csrfTokenField == "" {
+		replays = append(replays, replay{description: "Replayed cross-site (the form has no anti-CSRF token)", data: cloneValues(originalFormData)})
+	} else {
+		noToken := cloneValues(originalFormData)
+		noToken.Del(csrfTokenField)
+		badToken := cloneValues(originalFormData)
+		badToken.Set(csrfTokenField, bogusTokenValue)
+		replays = append(replays,
+			replay{description: fmt.Sprintf("Replayed cross-site without token '%s'", csrfTokenField), data: noToken},
+			replay{description: fmt.Sprintf("Replayed cross-site with bogus token '%s=%s'", csrfTokenField, bogusTokenValue), data: badToken},
+		)
+	}
+
+	cmp := compare.New(opts, log, "CSRF")
+	for _, r := range replays {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		forged, err := submitForm(ctx, req, client, r.data, true)
+		if err != nil {
+			continue
+		}
+		if !sameOutcome(baseline, forged, cmp) {
+			log.Debug("CSRF: %s was rejected or differed from the original (status %d vs %d).", r.description, forged.status, baseline.status)
+			continue
+		}
+
+		details := "The form does not contain any CSRF token, and a cross-site replay produced the same result as the original submission."
+		severity := "High"
+		if csrfTokenField != "" {
+			details = fmt.Sprintf("The anti-CSRF token '%s' is not validated: a cross-site replay with the token removed or replaced produced the same result as the original submission.", csrfTokenField)
+			severity = "Medium"
+		}
+		if note, protected := sameSiteProtection(cookies, baseline, forged); protected {
+			severity = "Low"
+			details += " " + note
+		} else if note != "" {
+			details += " " + note
+		}
+
+		log.Success("CSRF: %s was accepted at %s", r.description, req.URL)
+		vuln := scanner.VulnerabilityResult{
 			VulnerabilityType: "Cross-Site Request Forgery (CSRF)",
 			URL:               req.URL,
+			Parameter:         csrfTokenField,
+			Payload:           r.data.Encode(),
 			Location:          "body",
 			Details:           details,
-			Severity:          "medium",
-			Evidence:          "Invalid token accepted.",
-			Remediation:       "Implement CSRF protection using synchronizer tokens, SameSite cookies, or double submit tokens.",
-			ScannerName:       "csrf",
-		}}, nil
+			Severity:          severity,
+			Evidence: fmt.Sprintf("%s: status %d, %d bytes; original submission: status %d, %d bytes (similarity %.3f).",
+				r.description, forged.status, len(forged.body), baseline.status, len(baseline.body), cmp.Similarity(baseline.body, forged.body)),
+			Remediation: "Implement CSRF protection using synchronizer tokens, SameSite cookies, or double submit tokens.",
+			ScannerName: s.Name(),
+		}
+		vuln.SetExchange(forged.exchange)
+		return []scanner.VulnerabilityResult{vuln}, nil
 	}
 
-	log.Debug("CSRF: Baseline differed from manipulated requests. Form appears protected.")
+	log.Debug("CSRF: Forged submissions to %s were rejected. Form appears protected.", req.URL)
 	return nil, nil
 }
 
-// ---- Helper functions ----
-// respSnapshot captures key aspects of an HTTP response for comparison.
-type respSnapshot struct {
-	status   int
-	bodyHash string
-	location string
-}
-
-// hashBody generates a SHA256 hash of the response body.
-func hashBody(b string) string {
-	h := sha256.Sum256([]byte(b))
-	return fmt.Sprintf("%x", h[:])
-}
-
-// statusAndLocationMatch checks if the status code and location header of two snapshots match.
-func statusAndLocationMatch(a, b respSnapshot) bool {
-	return a.status == b.status && a.location == b.location
+// sameOutcome reports whether the forged submission was accepted exactly like the original:
+// same status class and redirect target, a similar body, and no new token validation error.
+func sameOutcome(original, forged respSnapshot, cmp compare.Comparator) bool {
+	if !forged.succeeded() || forged.status/100 != original.status/100 || forged.location != original.location {
+		return false
+	}
+	if responseIndicatesTokenFailure(forged.body) && !responseIndicatesTokenFailure(original.body) {
+		return false
+	}
+	return !cmp.IsDifferent(original.body, forged.body)
 }
 
-// snapshotsEqual checks if two response snapshots are identical (status, body hash, and location).
-func snapshotsEqual(a, b respSnapshot) bool {
-	return a.status == b.status && a.location == b.location
+// sameSiteProtection inspects the SameSite attribute of session-like cookies set by the source
+// page or the form submissions. It reports true when every such cookie is Lax or Strict, which
+// keeps browsers from attaching it to cross-site POST requests, and returns a note for the details.
+func sameSiteProtection(cookies []*http.Cookie, snapshots ...respSnapshot) (string, bool) {
+	var unprotected, protected []string
+	for _, snap := range snapshots {
+		cookies = append(cookies, snap.cookies...)
+	}
+	seen := make(map[string]bool)
+	for _, c := range cookies {
+		if seen[c.Name] || !payloads.IsSessionCookieName(c.Name) {
+			continue
+		}
+		seen[c.Name] = true
+		switch c.SameSite {
+		case http.SameSiteLaxMode, http.SameSiteStrictMode:
+			protected = append(protected, c.Name)
+		default:
+			unprotected = append(unprotected, c.Name)
+		}
+	}
+	switch {
+	case len(unprotected) > 0:
+		return fmt.Sprintf("Session cookie(s) %s are sent cross-site (SameSite missing or None).", strings.Join(unprotected, ", ")), false
+	case len(protected) > 0:
+		return fmt.Sprintf("Exploitability is reduced: session cookie(s) %s are SameSite=Lax/Strict, so browsers do not send them with cross-site POST requests.", strings.Join(protected, ", ")), true
+	}
+	return "", false
 }
 
 // cloneValues creates a deep copy of url.Values.
@@ -160,12 +238,19 @@ func cloneValues(v url.Values) url.Values {
 
 // submitForm sends a POST request with the given data and returns a response snapshot.
 // It temporarily disables redirects to capture the immediate response status and location.
-func submitForm(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, data url.Values) (respSnapshot, error) {
-	httpReq, _ := http.NewRequest("POST", req.URL, strings.NewReader(data.Encode()))
-	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+// When crossSite is set, the request carries a foreign Origin and Referer like a forged request.
+func submitForm(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, data url.Values, crossSite bool) (respSnapshot, error) {
+	// The replays are form posts whatever the crawled encoding, since data comes from the form.
+	httpReq, err := requtil.New(ctx, crawler.ParameterizedRequest{Method: "POST", URL: req.URL}, data)
+	if err != nil {
+		return respSnapshot{}, err
+	}
+	if crossSite {
+		httpReq.Header.Set("Origin", crossSiteOrigin)
+		httpReq.Header.Set("Referer", crossSiteOrigin+"/")
+	}
 
-	originalCheckRedirect := client.TemporarilyDisableRedirects()
-	defer client.RestoreRedirects(originalCheckRedirect)
+	client = client.WithoutRedirects()
 
 	resp, err := client.Do(httpReq)
 	if err != nil && !strings.Contains(err.Error(), "use last response") {
@@ -180,35 +265,40 @@ func submitForm(req crawler.ParameterizedRequest, client *httpclient.Client, log
 	defer resp.Body.Close()
 
 	bodyBytes, _ := io.ReadAll(resp.Body)
-	bodyString := string(bodyBytes)
-	locationHeader := resp.Header.Get("Location")
-
 	return respSnapshot{
 		status:   resp.StatusCode,
-		bodyHash: hashBody(bodyString),
-		location: locationHeader,
+		body:     string(bodyBytes),
+		location: resp.Header.Get("Location"),
+		cookies:  resp.Cookies(),
+		exchange: scanner.CaptureExchange(httpReq, resp, bodyBytes),
 	}, nil
 }
 
-// getValidTokenFromPage fetches a page and attempts to extract a valid CSRF token from its HTML.
-func getValidTokenFromPage(sourceURL string, client *httpclient.Client, log *logger.Logger) string {
+// fetchSourcePage fetches the page containing the form and returns a fresh CSRF token extracted
+// from its HTML along with the cookies it sets.
+func fetchSourcePage(ctx context.Context, sourceURL string, client *httpclient.Client, log *logger.Logger) (string, []*http.Cookie) {
 	if sourceURL == "" {
-		return ""
+		return "", nil
 	}
 	log.Debug("CSRF: Fetching source page %s to get a valid token", sourceURL)
-	resp, err := client.Get(sourceURL)
-	if err != nil || resp.StatusCode != http.StatusOK {
-		if resp != nil {
-			resp.Body.Close()
-		}
-		return ""
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", sourceURL, nil)
+	if err != nil {
+		return "", nil
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", nil
 	}
 	defer resp.Body.Close()
+	cookies := resp.Cookies()
+	if resp.StatusCode != http.StatusOK {
+		return "", cookies
+	}
 
 	bodyBytes, _ := io.ReadAll(resp.Body)
 	doc, err := html.Parse(strings.NewReader(string(bodyBytes)))
 	if err != nil {
-		return ""
+		return "", cookies
 	}
 
 	var tokenValue string
@@ -238,7 +328,7 @@ func getValidTokenFromPage(sourceURL string, client *httpclient.Client, log *log
 		}
 	}
 	f(doc)
-	return tokenValue
+	return tokenValue, cookies
 }
 
 // guessSourcePage attempts to guess the source page URL for a given action URL.
@@ -285,11 +375,16 @@ func isPotentialUploadForm(req crawler.ParameterizedRequest) bool {
 	return false
 }
 
-// isCSRFTokenParam checks if a parameter name is likely a CSRF token field.
+// isCSRFTokenParam checks if a parameter name is likely a CSRF token field. Besides the exact
+// names shared with other scanners, names containing a known token name (e.g., "edit_csrf_token")
+// are accepted.
 func isCSRFTokenParam(p string) bool {
+	if payloads.IsCSRFTokenName(p) {
+		return true
+	}
 	l := strings.ToLower(p)
 	for _, n := range payloads.CommonCSRFTokenNames {
-		if strings.Contains(l, n) {
+		if strings.Contains(l, strings.ToLower(n)) {
 			return true
 		}
 	}
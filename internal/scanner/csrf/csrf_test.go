@@ -0,0 +1,82 @@
+package csrf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name          string
+		formData      string
+		validateToken bool
+		sameSite      http.SameSite
+		wantFindings  int
+		wantSeverity  string
+	}{
+		{name: "Token is validated", formData: "email=a%40b.c&csrf_token=stale", validateToken: true},
+		{name: "Token is ignored", formData: "email=a%40b.c&csrf_token=stale", wantFindings: 1, wantSeverity: "Medium"},
+		{name: "Token is ignored but session cookie is SameSite=Lax", formData: "email=a%40b.c&csrf_token=stale", sameSite: http.SameSiteLaxMode, wantFindings: 1, wantSeverity: "Low"},
+		{name: "Form has no token", formData: "email=a%40b.c", wantFindings: 1, wantSeverity: "High"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/settings", func(w http.ResponseWriter, r *http.Request) {
+				http.SetCookie(w, &http.Cookie{Name: "PHPSESSID", Value: "abc", SameSite: tt.sameSite})
+				w.Write([]byte(`<form method="POST" action="/settings/email"><input type="hidden" name="csrf_token" value="fresh-token"><input name="email"></form>`))
+			})
+			mux.HandleFunc("/settings/email", func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				if tt.validateToken && r.PostForm.Get("csrf_token") != "fresh-token" {
+					w.WriteHeader(http.StatusForbidden)
+					w.Write([]byte("Invalid CSRF token"))
+					return
+				}
+				w.Write([]byte("<p>Email updated to " + r.PostForm.Get("email") + "</p>"))
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+			req := crawler.ParameterizedRequest{
+				Method:         "POST",
+				URL:            server.URL + "/settings/email",
+				Path:           "/settings/email",
+				ParamLocations: []string{"body"},
+				FormPostData:   tt.formData,
+				SourceURL:      server.URL + "/settings",
+			}
+
+			findings, err := NewCSRFScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			require.Len(t, findings, tt.wantFindings)
+			if tt.wantFindings == 0 {
+				return
+			}
+			assert.Equal(t, tt.wantSeverity, findings[0].Severity)
+			assert.Contains(t, findings[0].Evidence, "status 200")
+			assert.Contains(t, findings[0].RawRequest, "Origin: "+crossSiteOrigin)
+			assert.NotContains(t, findings[0].RawRequest, "fresh-token")
+		})
+	}
+}
+
+func TestIsCSRFTokenParam(t *testing.T) {
+	assert.True(t, isCSRFTokenParam("csrfmiddlewaretoken"))
+	assert.True(t, isCSRFTokenParam("YII_CSRF_TOKEN"))
+	assert.True(t, isCSRFTokenParam("edit_csrf_token"))
+	assert.False(t, isCSRFTokenParam("email"))
+}
@@ -0,0 +1,192 @@
+package scanner
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/payloads"
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// frameworkTokenFields are the anti-CSRF fields of common web frameworks (Rails, Laravel,
+// Django, ASP.NET, Spring/Express), added to payloads.IgnoredParams by default.
+var frameworkTokenFields = []string{
+	"authenticity_token", "_token", "csrfmiddlewaretoken", "__requestverificationtoken",
+	"_csrf", "xsrf_token", "csrf-token",
+}
+
+// TokenRefresher keeps anti-CSRF tokens fresh in test requests. Applications that reject
+// submissions with a stale token would otherwise turn every other parameter of the form into a
+// dead end. Before each request that still carries the token captured by the crawler, the page
+// the form was found on is fetched again and the token fields are replaced with their current
+// values. Token fields that a scanner injected into are left alone.
+type TokenRefresher struct {
+	fields map[string]bool // Lower-case token field names.
+}
+
+// DefaultTokenFields returns the token field names refreshed when none are configured.
+func DefaultTokenFields() []string {
+	return append(append([]string(nil), payloads.IgnoredParams...), frameworkTokenFields...)
+}
+
+// NewTokenRefresher creates a TokenRefresher for the given field names (case-insensitive).
+// Without names, DefaultTokenFields is used.
+func NewTokenRefresher(fields []string) *TokenRefresher {
+	if len(fields) == 0 {
+		fields = DefaultTokenFields()
+	}
+	r := &TokenRefresher{fields: make(map[string]bool, len(fields))}
+	for _, field := range fields {
+		r.fields[strings.ToLower(field)] = true
+	}
+	return r
+}
+
+// IsTokenField reports whether name is a refreshed token field.
+func (r *TokenRefresher) IsTokenField(name string) bool {
+	return r != nil && r.fields[strings.ToLower(name)]
+}
+
+// Bind returns a copy of client that refreshes the token fields of req in every request it
+// sends. client is returned unchanged if r is nil, req has no token field or the page it was
+// found on is unknown.
+func (r *TokenRefresher) Bind(client *httpclient.Client, req crawler.ParameterizedRequest) *httpclient.Client {
+	if r == nil || req.SourceURL == "" {
+		return client
+	}
+	captured := r.capturedTokens(req)
+	if len(captured) == 0 {
+		return client
+	}
+	return client.WithRequestHook(func(httpReq *http.Request) error {
+		r.refresh(client, req.SourceURL, captured, httpReq)
+		return nil
+	})
+}
+
+// capturedTokens returns the token values recorded by the crawler in the query or form body of req.
+func (r *TokenRefresher) capturedTokens(req crawler.ParameterizedRequest) url.Values {
+	tokens := url.Values{}
+	var params []url.Values
+	if u, err := url.Parse(req.URL); err == nil {
+		params = append(params, u.Query())
+	}
+	if !req.IsJSON() && !req.IsXML() && req.FormPostData != "" {
+		if form, err := url.ParseQuery(req.FormPostData); err == nil {
+			params = append(params, form)
+		}
+	}
+	for _, values := range params {
+		for name := range values {
+			if r.IsTokenField(name) {
+				tokens.Set(name, values.Get(name))
+			}
+		}
+	}
+	return tokens
+}
+
+// refresh replaces the token fields of httpReq that still hold their captured value with the
+// values currently served on sourceURL. Failures leave the request unchanged.
+func (r *TokenRefresher) refresh(client *httpclient.Client, sourceURL string, captured url.Values, httpReq *http.Request) {
+	query := httpReq.URL.Query()
+	var form url.Values
+	var body []byte
+	if httpReq.Body != nil && strings.HasPrefix(httpReq.Header.Get("Content-Type"), "application/x-www-form-urlencoded") {
+		var err error
+		if body, err = io.ReadAll(httpReq.Body); err != nil {
+			return
+		}
+		httpReq.Body.Close()
+		httpReq.Body = io.NopCloser(bytes.NewReader(body))
+		form, _ = url.ParseQuery(string(body))
+	}
+
+	var stale []string
+	for name := range captured {
+		if (query.Has(name) && query.Get(name) == captured.Get(name)) || (form.Has(name) && form.Get(name) == captured.Get(name)) {
+			stale = append(stale, name)
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+	fresh := fetchTokens(client, httpReq, sourceURL, stale)
+	if len(fresh) == 0 {
+		return
+	}
+
+	queryChanged, formChanged := false, false
+	for name, value := range fresh {
+		if query.Has(name) && query.Get(name) == captured.Get(name) {
+			query.Set(name, value)
+			queryChanged = true
+		}
+		if form.Has(name) && form.Get(name) == captured.Get(name) {
+			form.Set(name, value)
+			formChanged = true
+		}
+	}
+	if queryChanged {
+		httpReq.URL.RawQuery = query.Encode()
+	}
+	if formChanged {
+		encoded := []byte(form.Encode())
+		httpReq.Body = io.NopCloser(bytes.NewReader(encoded))
+		httpReq.ContentLength = int64(len(encoded))
+		httpReq.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(encoded)), nil }
+	}
+}
+
+// fetchTokens fetches sourceURL and returns the values of the named fields: <input> elements and
+// <meta name="csrf-token" content="..."> tags.
+func fetchTokens(client *httpclient.Client, httpReq *http.Request, sourceURL string, names []string) map[string]string {
+	pageReq, err := http.NewRequestWithContext(httpReq.Context(), "GET", sourceURL, nil)
+	if err != nil {
+		return nil
+	}
+	resp, err := client.Do(pageReq)
+	if err != nil {
+		return nil
+	}
+	defer resp.Body.Close()
+	doc, err := html.Parse(io.LimitReader(resp.Body, 2<<20))
+	if err != nil {
+		return nil
+	}
+
+	wanted := make(map[string]string, len(names)) // Lower-case name to name as sent.
+	for _, name := range names {
+		wanted[strings.ToLower(name)] = name
+	}
+	fresh := make(map[string]string)
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && (n.Data == "input" || n.Data == "meta") {
+			var name, value string
+			for _, a := range n.Attr {
+				switch strings.ToLower(a.Key) {
+				case "name":
+					name = a.Val
+				case "value", "content":
+					value = a.Val
+				}
+			}
+			if sent, ok := wanted[strings.ToLower(name)]; ok && value != "" {
+				if _, found := fresh[sent]; !found {
+					fresh[sent] = value
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+	return fresh
+}
@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer issues a new single-use token with every page view and accepts a submission only
+// with the latest token.
+func tokenServer(t *testing.T) (*httptest.Server, *[]url.Values) {
+	var mu sync.Mutex
+	issued := 1 // The crawler saw tok-0; it has expired since.
+	var accepted []url.Values
+	mux := http.NewServeMux()
+	mux.HandleFunc("/profile", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		issued++
+		fmt.Fprintf(w, `<html><head><meta name="csrf-token" content="meta-%d"></head><body>
+<form method="post" action="/update"><input type="hidden" name="CSRF_Token" value="tok-%d"><input name="bio"></form></body></html>`, issued, issued)
+	})
+	mux.HandleFunc("/update", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		require.NoError(t, r.ParseForm())
+		if r.PostForm.Get("CSRF_Token") != fmt.Sprintf("tok-%d", issued) {
+			http.Error(w, "invalid token", http.StatusForbidden)
+			return
+		}
+		accepted = append(accepted, r.PostForm)
+		w.Write([]byte("saved"))
+	})
+	return httptest.NewServer(mux), &accepted
+}
+
+func TestTokenRefresher(t *testing.T) {
+	server, accepted := tokenServer(t)
+	defer server.Close()
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	req := crawler.ParameterizedRequest{
+		Method:       "POST",
+		URL:          server.URL + "/update",
+		FormPostData: "CSRF_Token=tok-0&bio=test",
+		SourceURL:    server.URL + "/profile",
+	}
+	send := func(c *httpclient.Client, body string) int {
+		httpReq, err := http.NewRequest("POST", req.URL, strings.NewReader(body))
+		require.NoError(t, err)
+		httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		resp, err := c.Do(httpReq)
+		require.NoError(t, err)
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusForbidden, send(client, "CSRF_Token=tok-0&bio=%27"), "stale token without refresher")
+
+	bound := NewTokenRefresher(nil).Bind(client, req)
+	assert.Equal(t, http.StatusOK, send(bound, "CSRF_Token=tok-0&bio=%27"))
+	assert.Equal(t, http.StatusOK, send(bound, "CSRF_Token=tok-0&bio=%3Cscript%3E"))
+	require.Len(t, *accepted, 2)
+	assert.Equal(t, "'", (*accepted)[0].Get("bio"))
+	assert.Equal(t, "<script>", (*accepted)[1].Get("bio"))
+
+	// A payload injected into the token field itself is sent as is.
+	assert.Equal(t, http.StatusForbidden, send(bound, "CSRF_Token=%27&bio=test"))
+}
+
+func TestTokenRefresherBind(t *testing.T) {
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{})
+	refresher := NewTokenRefresher([]string{"nonce"})
+	assert.True(t, refresher.IsTokenField("NONCE"))
+	assert.False(t, refresher.IsTokenField("csrf"))
+
+	withToken := crawler.ParameterizedRequest{Method: "GET", URL: "https://example.com/search?q=1&nonce=abc", SourceURL: "https://example.com/"}
+	assert.NotSame(t, client, refresher.Bind(client, withToken))
+	withoutSource := withToken
+	withoutSource.SourceURL = ""
+	assert.Same(t, client, refresher.Bind(client, withoutSource))
+	withoutToken := crawler.ParameterizedRequest{Method: "POST", URL: "https://example.com/login", FormPostData: "user=a", SourceURL: "https://example.com/"}
+	assert.Same(t, client, refresher.Bind(client, withoutToken))
+
+	var nilRefresher *TokenRefresher
+	assert.Same(t, client, nilRefresher.Bind(client, withToken))
+	assert.ElementsMatch(t, []string{"csrf", "csrf_token", "_csrf_token", "token", "session", "session_id", "__cfduid", "authenticity_token", "_token", "csrfmiddlewaretoken", "__requestverificationtoken", "_csrf", "xsrf_token", "csrf-token"}, DefaultTokenFields())
+}
@@ -0,0 +1,260 @@
+package scanner
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// cvssPrefix starts every CVSS v3.1 vector string.
+const cvssPrefix = "CVSS:3.1/"
+
+// cvssWeights holds the weight of each value of the CVSS v3.1 base metrics. The weights of
+// Privileges Required depend on Scope and are handled in CVSSScore.
+var cvssWeights = map[string]map[string]float64{
+	"AV": {"N": 0.85, "A": 0.62, "L": 0.55, "P": 0.2},
+	"AC": {"L": 0.77, "H": 0.44},
+	"PR": {"N": 0.85, "L": 0.62, "H": 0.27},
+	"UI": {"N": 0.85, "R": 0.62},
+	"S":  {"U": 0, "C": 0},
+	"C":  {"H": 0.56, "L": 0.22, "N": 0},
+	"I":  {"H": 0.56, "L": 0.22, "N": 0},
+	"A":  {"H": 0.56, "L": 0.22, "N": 0},
+}
+
+// cvssMetricOrder is the order of the base metrics in a vector string.
+var cvssMetricOrder = []string{"AV", "AC", "PR", "UI", "S", "C", "I", "A"}
+
+// parseCVSSVector returns the base metrics of a CVSS v3.1 vector string. Every base metric must
+// be present exactly once; temporal and environmental metrics are not supported.
+func parseCVSSVector(vector string) (map[string]string, error) {
+	if !strings.HasPrefix(vector, cvssPrefix) {
+		return nil, fmt.Errorf("CVSS vector %q does not start with %s", vector, cvssPrefix)
+	}
+	metrics := make(map[string]string, len(cvssMetricOrder))
+	for _, part := range strings.Split(strings.TrimPrefix(vector, cvssPrefix), "/") {
+		name, value, ok := strings.Cut(part, ":")
+		weights, known := cvssWeights[name]
+		if !ok || !known {
+			return nil, fmt.Errorf("CVSS vector %q has an unknown metric %q", vector, part)
+		}
+		if _, valid := weights[value]; !valid {
+			return nil, fmt.Errorf("CVSS vector %q has an invalid value %q", vector, part)
+		}
+		if _, seen := metrics[name]; seen {
+			return nil, fmt.Errorf("CVSS vector %q sets %s twice", vector, name)
+		}
+		metrics[name] = value
+	}
+	for _, name := range cvssMetricOrder {
+		if _, ok := metrics[name]; !ok {
+			return nil, fmt.Errorf("CVSS vector %q lacks the %s metric", vector, name)
+		}
+	}
+	return metrics, nil
+}
+
+// CVSSScore computes the CVSS v3.1 base score (0.0-10.0) of a vector string such as
+// "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H".
+func CVSSScore(vector string) (float64, error) {
+	m, err := parseCVSSVector(vector)
+	if err != nil {
+		return 0, err
+	}
+	changed := m["S"] == "C"
+
+	privileges := cvssWeights["PR"][m["PR"]]
+	if changed && m["PR"] == "L" {
+		privileges = 0.68
+	} else if changed && m["PR"] == "H" {
+		privileges = 0.5
+	}
+
+	iss := 1 - (1-cvssWeights["C"][m["C"]])*(1-cvssWeights["I"][m["I"]])*(1-cvssWeights["A"][m["A"]])
+	impact := 6.42 * iss
+	if changed {
+		impact = 7.52*(iss-0.029) - 3.25*math.Pow(iss-0.02, 15)
+	}
+	if impact <= 0 {
+		return 0, nil
+	}
+	exploitability := 8.22 * cvssWeights["AV"][m["AV"]] * cvssWeights["AC"][m["AC"]] * privileges * cvssWeights["UI"][m["UI"]]
+	if changed {
+		return cvssRoundUp(math.Min(1.08*(impact+exploitability), 10)), nil
+	}
+	return cvssRoundUp(math.Min(impact+exploitability, 10)), nil
+}
+
+// cvssRoundUp rounds up to one decimal as specified in CVSS v3.1 (Appendix A), avoiding
+// floating point artifacts such as 4.000000001 rounding to 4.1.
+func cvssRoundUp(x float64) float64 {
+	i := int64(math.Round(x * 100000))
+	if i%10000 == 0 {
+		return float64(i) / 100000
+	}
+	return float64(i/10000+1) / 10
+}
+
+// CVSSRating returns the qualitative severity of a CVSS v3.1 score: "Critical", "High",
+// "Medium", "Low" or "None".
+func CVSSRating(score float64) string {
+	switch {
+	case score >= 9:
+		return "Critical"
+	case score >= 7:
+		return "High"
+	case score >= 4:
+		return "Medium"
+	case score > 0:
+		return "Low"
+	}
+	return "None"
+}
+
+// WithCVSSMetrics returns vector with the given base metrics ("PR:L", "C:H", ...) replaced.
+// Invalid metrics are ignored.
+func WithCVSSMetrics(vector string, metrics ...string) string {
+	parsed, err := parseCVSSVector(vector)
+	if err != nil {
+		return vector
+	}
+	for _, metric := range metrics {
+		name, value, _ := strings.Cut(metric, ":")
+		if _, valid := cvssWeights[name][value]; valid {
+			parsed[name] = value
+		}
+	}
+	parts := make([]string, 0, len(cvssMetricOrder))
+	for _, name := range cvssMetricOrder {
+		parts = append(parts, name+":"+parsed[name])
+	}
+	return cvssPrefix + strings.Join(parts, "/")
+}
+
+// Classification is the CWE and default CVSS v3.1 vector of a vulnerability type.
+type Classification struct {
+	CWE        string // e.g. "CWE-89".
+	CVSSVector string
+}
+
+// defaultClassifications maps vulnerability types to their classification. Types are matched
+// by prefix and the longest prefix wins, so "SQL Injection (Auth Bypass)" can differ from the
+// other "SQL Injection (...)" types.
+var defaultClassifications = map[string]Classification{
+	"SQL Injection":                     {"CWE-89", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+	"SQL Injection (Auth Bypass)":       {"CWE-89", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+	"SQL Injection (Out-of-Band)":       {"CWE-89", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H"},
+	"NoSQL Injection":                   {"CWE-943", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:L/A:N"},
+	"NoSQL Injection (Auth Bypass)":     {"CWE-943", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N"},
+	"XSS":                               {"CWE-79", cvssPrefix + "AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N"},
+	"Reflected XSS":                     {"CWE-79", cvssPrefix + "AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N"},
+	"Stored XSS":                        {"CWE-79", cvssPrefix + "AV:N/AC:L/PR:L/UI:R/S:C/C:H/I:L/A:N"},
+	"DOM-Based Cross-Site Scripting":    {"CWE-79", cvssPrefix + "AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N"},
+	"Local File Inclusion":              {"CWE-22", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N"},
+	"Open Redirect":                     {"CWE-601", cvssPrefix + "AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N"},
+	"Server-Side Request Forgery":       {"CWE-918", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:C/C:L/I:L/A:N"},
+	"Blind SSRF":                        {"CWE-918", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:C/C:L/I:N/A:N"},
+	"Insecure Direct Object Reference":  {"CWE-639", cvssPrefix + "AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:N/A:N"},
+	"Broken Object Level Authorization": {"CWE-639", cvssPrefix + "AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:L/A:N"},
+	"Cross-Site Request Forgery":        {"CWE-352", cvssPrefix + "AV:N/AC:L/PR:N/UI:R/S:U/C:N/I:H/A:N"},
+	"Command Injection":                 {"CWE-78", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+	"Blind Command Injection":           {"CWE-78", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+	"Server-Side Template Injection":    {"CWE-1336", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+	"Unrestricted File Upload":          {"CWE-434", cvssPrefix + "AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H"},
+	"Missing Security Header":           {"CWE-693", cvssPrefix + "AV:N/AC:H/PR:N/UI:R/S:U/C:N/I:L/A:N"},
+	"Misconfigured Security Header":     {"CWE-693", cvssPrefix + "AV:N/AC:H/PR:N/UI:R/S:U/C:N/I:L/A:N"},
+	"Server Banner":                     {"CWE-200", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N"},
+	"CORS Misconfiguration":             {"CWE-942", cvssPrefix + "AV:N/AC:L/PR:N/UI:R/S:U/C:H/I:L/A:N"},
+	"Exposed Backup File":               {"CWE-530", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N"},
+	"Known Vulnerable Component":        {"CWE-1395", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:L/A:L"},
+	"Exposed Sensitive File":            {"CWE-538", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N"},
+	"Directory Listing":                 {"CWE-548", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N"},
+	"Mass Assignment":                   {"CWE-915", cvssPrefix + "AV:N/AC:L/PR:L/UI:N/S:U/C:N/I:H/A:N"},
+	"GraphQL Introspection Enabled":     {"CWE-200", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N"},
+	"GraphQL Batching Enabled":          {"CWE-770", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:L"},
+	"Missing Rate Limiting":             {"CWE-307", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N"},
+	"XML External Entity":               {"CWE-611", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:L"},
+	"Insecure Deserialization":          {"CWE-502", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"},
+	"Host Header Injection":             {"CWE-644", cvssPrefix + "AV:N/AC:H/PR:N/UI:R/S:C/C:L/I:L/A:N"},
+	"Web Cache Poisoning":               {"CWE-349", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:C/C:L/I:L/A:N"},
+	"Unkeyed Input Reflection":          {"CWE-349", cvssPrefix + "AV:N/AC:H/PR:N/UI:R/S:U/C:N/I:L/A:N"},
+	"JWT None Algorithm Accepted":       {"CWE-347", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N"},
+	"JWT Weak Signing Secret":           {"CWE-1391", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:N"},
+	"JWT Expired Token Accepted":        {"CWE-613", cvssPrefix + "AV:N/AC:H/PR:N/UI:N/S:U/C:L/I:L/A:N"},
+	"JWT Missing Claim":                 {"CWE-613", cvssPrefix + "AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:N/A:N"},
+	"Missing Brute-Force Protection":    {"CWE-307", cvssPrefix + "AV:N/AC:H/PR:N/UI:N/S:U/C:L/I:L/A:N"},
+	"CRLF Injection":                    {"CWE-93", cvssPrefix + "AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N"},
+	"Sensitive Data Exposure":           {"CWE-200", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N"},
+	"Verbose Error Page":                {"CWE-209", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:N/A:N"},
+	"Exposed Sensitive Endpoint":        {"CWE-200", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:N/A:N"},
+	"Cross-Site Tracing":                {"CWE-693", cvssPrefix + "AV:N/AC:H/PR:N/UI:R/S:U/C:L/I:N/A:N"},
+	"Insecure Cookie Attributes":        {"CWE-1004", cvssPrefix + "AV:N/AC:H/PR:N/UI:R/S:U/C:L/I:N/A:N"},
+	"Cookie Without Secure Flag":        {"CWE-614", cvssPrefix + "AV:N/AC:H/PR:N/UI:R/S:U/C:L/I:N/A:N"},
+	"Unsafe HTTP Methods Allowed":       {"CWE-650", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:L/A:N"},
+	"HTTP Method Tampering":             {"CWE-650", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:H/A:N"},
+	"Prototype Pollution":               {"CWE-1321", cvssPrefix + "AV:N/AC:L/PR:N/UI:N/S:U/C:L/I:L/A:L"},
+	"Subdomain Takeover":                {"CWE-350", cvssPrefix + "AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:H/A:N"},
+	"Dangling DNS Record":               {"CWE-350", cvssPrefix + "AV:N/AC:H/PR:N/UI:R/S:C/C:L/I:L/A:N"},
+}
+
+// DefaultClassification returns the classification of a vulnerability type, matching the
+// longest known prefix.
+func DefaultClassification(vulnType string) (Classification, bool) {
+	var best Classification
+	bestLen := -1
+	for prefix, c := range defaultClassifications {
+		if strings.HasPrefix(vulnType, prefix) && len(prefix) > bestLen {
+			best, bestLen = c, len(prefix)
+		}
+	}
+	return best, bestLen >= 0
+}
+
+// SetCVSSMetrics adjusts the CVSS vector of the finding to its context, e.g. "PR:L" when the
+// vulnerable request needs a session. A finding without a vector starts from the default
+// vector of its type; the score is recomputed by Classify.
+func (v *VulnerabilityResult) SetCVSSMetrics(metrics ...string) {
+	if v.CVSSVector == "" {
+		c, ok := DefaultClassification(v.VulnerabilityType)
+		if !ok {
+			return
+		}
+		v.CVSSVector = c.CVSSVector
+	}
+	v.CVSSVector = WithCVSSMetrics(v.CVSSVector, metrics...)
+}
+
+// Classify fills in the CWE and CVSS vector of findings whose scanner did not set them from the
+// default classification of their type, and computes CVSSScore from the vector. Findings
+// without a severity get the rating of their score, and findings without a confidence are
+// ConfidenceFirm. It can be applied more than once.
+func Classify(findings []VulnerabilityResult) {
+	for i := range findings {
+		v := &findings[i]
+		if v.Confidence == "" {
+			v.Confidence = ConfidenceFirm
+		}
+		if c, ok := DefaultClassification(v.VulnerabilityType); ok {
+			if v.CWE == "" {
+				v.CWE = c.CWE
+			}
+			if v.CVSSVector == "" {
+				v.CVSSVector = c.CVSSVector
+			}
+		}
+		if v.CVSSVector == "" {
+			continue
+		}
+		score, err := CVSSScore(v.CVSSVector)
+		if err != nil {
+			continue
+		}
+		v.CVSSScore = score
+		if v.Severity == "" {
+			v.Severity = CVSSRating(score)
+			if v.Severity == "None" {
+				v.Severity = "Info" // Reports rate findings without impact as informational.
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCVSSScore(t *testing.T) {
+	cases := map[string]float64{
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H": 9.8,
+		"CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:H": 8.8,
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:R/S:C/C:L/I:L/A:N": 6.1,
+		"CVSS:3.1/AV:N/AC:L/PR:L/UI:R/S:C/C:H/I:L/A:N": 7.6,
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:C/C:H/I:H/A:H": 10,
+		"CVSS:3.1/AV:P/AC:H/PR:H/UI:R/S:U/C:L/I:N/A:N": 1.6,
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:N/I:N/A:N": 0,
+	}
+	for vector, want := range cases {
+		score, err := CVSSScore(vector)
+		require.NoError(t, err, vector)
+		assert.Equal(t, want, score, vector)
+	}
+
+	for _, invalid := range []string{
+		"AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+		"CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H",
+		"CVSS:3.1/AV:X/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+		"CVSS:3.1/AV:N/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H",
+	} {
+		_, err := CVSSScore(invalid)
+		assert.Error(t, err, invalid)
+	}
+
+	assert.Equal(t, "Critical", CVSSRating(9.8))
+	assert.Equal(t, "Medium", CVSSRating(6.1))
+	assert.Equal(t, "None", CVSSRating(0))
+}
+
+func TestClassify(t *testing.T) {
+	findings := []VulnerabilityResult{
+		{VulnerabilityType: "SQL Injection (Error-Based)", Severity: "High"},
+		{VulnerabilityType: "SQL Injection (Error-Based)"},
+		{VulnerabilityType: "Stored XSS", CWE: "CWE-80"},
+		{VulnerabilityType: "Server Banner"},
+		{VulnerabilityType: "Something New"},
+	}
+	findings[1].SetCVSSMetrics("PR:L", "A:N", "bogus")
+	Classify(findings)
+
+	assert.Equal(t, "CWE-89", findings[0].CWE)
+	assert.Equal(t, 9.8, findings[0].CVSSScore)
+	assert.Equal(t, "High", findings[0].Severity, "the scanner's severity is kept")
+
+	assert.Equal(t, "CVSS:3.1/AV:N/AC:L/PR:L/UI:N/S:U/C:H/I:H/A:N", findings[1].CVSSVector)
+	assert.Equal(t, 8.1, findings[1].CVSSScore)
+	assert.Equal(t, "High", findings[1].Severity)
+
+	assert.Equal(t, "CWE-80", findings[2].CWE, "the scanner's CWE is kept")
+	assert.Equal(t, "Info", findings[3].Severity)
+	assert.Empty(t, findings[4].CVSSVector)
+
+	c, ok := DefaultClassification("SQL Injection (Auth Bypass)")
+	require.True(t, ok)
+	assert.Equal(t, "CWE-89", c.CWE)
+}
@@ -0,0 +1,400 @@
+// Package deserialization detects serialized objects (Java, PHP, .NET ViewState and
+// BinaryFormatter) in parameters and cookies and checks whether the server deserializes them.
+package deserialization
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/oob"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/requtil"
+	"Dursgo/internal/scanner/timing"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModuleName selects the scanner (-s) and holds its options in config.yaml.
+const ModuleName = "deserialization"
+
+// Evidence classes of the findings.
+const (
+	evidenceError    = "error"
+	evidenceDelay    = "delay"
+	evidenceCallback = "callback"
+)
+
+// evidenceLabels name the evidence classes in vulnerability types.
+var evidenceLabels = map[string]string{
+	evidenceError:    "Error-Based",
+	evidenceDelay:    "Time-Based",
+	evidenceCallback: "Out-of-Band",
+}
+
+// DeserializationScanner implements the Scanner interface for insecure deserialization.
+type DeserializationScanner struct {
+	cookies sync.Map // Host, name and value of the cookies already tested.
+}
+
+// NewDeserializationScanner creates a new instance of DeserializationScanner.
+func NewDeserializationScanner() *DeserializationScanner {
+	return &DeserializationScanner{}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           ModuleName,
+		Order:          205,
+		DefaultEnabled: true,
+		Options: []scanner.OptionSpec{
+			{Name: "gadgets", Type: scanner.OptionBool, Default: true, Description: "Send sleep and out-of-band gadget chains (ysoserial/phpggc style) to serialized values; false only sends malformed objects for error evidence"},
+			{Name: "time_delay", Type: scanner.OptionInt, Default: 5, Description: "Sleep in seconds of the sleep gadgets; findings are confirmed with this delay and its multiples, see time_confirmations"},
+		},
+		New: func(scanner.Env) scanner.Scanner { return NewDeserializationScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *DeserializationScanner) Name() string {
+	return "Insecure Deserialization Scanner"
+}
+
+// encoding is how a serialized object is written in a parameter value.
+type encoding struct {
+	name   string // e.g. "base64", for finding details.
+	encode func([]byte) string
+}
+
+// blob is a serialized object found in a parameter value.
+type blob struct {
+	format   string // One of the payloads.Format* constants.
+	data     []byte
+	encoding encoding
+}
+
+var (
+	rawEncoding = encoding{name: "raw", encode: func(b []byte) string { return string(b) }}
+	hexEncoding = encoding{name: "hex", encode: hex.EncodeToString}
+	// base64Encodings are tried in order when decoding a value.
+	base64Encodings = []encoding{
+		{name: "base64", encode: base64.StdEncoding.EncodeToString},
+		{name: "base64url", encode: base64.URLEncoding.EncodeToString},
+		{name: "base64", encode: base64.RawStdEncoding.EncodeToString},
+		{name: "base64url", encode: base64.RawURLEncoding.EncodeToString},
+	}
+	base64Decoders = []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding}
+)
+
+// detectBlob returns the serialized object held by the value of the parameter name: PHP
+// serialize() output, or a Java, ViewState or BinaryFormatter stream in base64 or hex. Values
+// that are still URL-encoded, as cookies often are, are decoded first.
+func detectBlob(name, value string) (blob, bool) {
+	value = strings.TrimSpace(value)
+	if strings.Contains(value, "%") {
+		if unescaped, err := url.QueryUnescape(value); err == nil && unescaped != value {
+			if b, ok := detectBlob(name, unescaped); ok {
+				inner := b.encoding
+				b.encoding = encoding{name: "URL-encoded " + inner.name, encode: func(data []byte) string { return url.QueryEscape(inner.encode(data)) }}
+				return b, true
+			}
+		}
+	}
+	if payloads.PHPSerializedRegex.MatchString(value) {
+		return blob{format: payloads.FormatPHP, data: []byte(value), encoding: rawEncoding}, true
+	}
+	if len(value) < 8 {
+		return blob{}, false
+	}
+	for i, decoder := range base64Decoders {
+		data, err := decoder.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		if format := formatOf(name, data); format != "" {
+			return blob{format: format, data: data, encoding: base64Encodings[i]}, true
+		}
+	}
+	if data, err := hex.DecodeString(value); err == nil && bytes.HasPrefix(data, payloads.JavaSerializationMagic) {
+		return blob{format: payloads.FormatJava, data: data, encoding: hexEncoding}, true
+	}
+	return blob{}, false
+}
+
+// formatOf returns the serialization format of data decoded from the value of the parameter
+// name, or "".
+func formatOf(name string, data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, payloads.JavaSerializationMagic):
+		return payloads.FormatJava
+	case bytes.HasPrefix(data, payloads.BinaryFormatterMagic):
+		return payloads.FormatBinaryFormatter
+	case bytes.HasPrefix(data, payloads.ViewStateMagic) && strings.EqualFold(name, "__VIEWSTATE"):
+		return payloads.FormatViewState
+	case payloads.PHPSerializedRegex.Match(data):
+		return payloads.FormatPHP
+	}
+	return ""
+}
+
+// tamperedObject is a malformed variant of a serialized object.
+type tamperedObject struct {
+	description string
+	data        []byte
+}
+
+// tamper returns the malformed variants of b that make its deserializer fail: an object of a
+// class that does not exist and a truncated object.
+func tamper(b blob) []tamperedObject {
+	switch b.format {
+	case payloads.FormatJava:
+		return []tamperedObject{
+			{description: "object of an unknown class", data: payloads.JavaUnknownClassObject()},
+			{description: "truncated stream", data: b.data[:max(len(b.data)/2, len(payloads.JavaSerializationMagic)+1)]},
+		}
+	case payloads.FormatPHP:
+		return []tamperedObject{
+			{description: "object of an unknown class", data: []byte(payloads.PHPUnknownClassObject)},
+			{description: "truncated value", data: b.data[:len(b.data)-1]},
+		}
+	case payloads.FormatViewState:
+		return []tamperedObject{
+			{description: "truncated ViewState", data: b.data[:max(len(b.data)/2, len(payloads.ViewStateMagic)+1)]},
+			{description: "ViewState with an unknown type token", data: append(append([]byte{}, payloads.ViewStateMagic...), 0xEE)},
+		}
+	}
+	return []tamperedObject{{description: "truncated stream", data: b.data[:max(len(b.data)/2, 1)]}}
+}
+
+// Scan looks for serialized objects in the parameters of req and in the cookies sent with it,
+// each cookie once per scan, and probes them: malformed objects for deserialization errors,
+// then sleep and out-of-band gadget chains of the format.
+func (s *DeserializationScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	paramNames := req.ParamNames
+	if req.IsJSON() && len(paramNames) == 0 {
+		paramNames = requtil.JSONParamNames(req.RawBody)
+	}
+	// Serialized objects are most often kept in cookies, and only values holding one are
+	// tested, so the cookies of the jar are examined even without -inject-headers.
+	req.Cookies = requtil.AddHeaderInjectionPoints(req, client).Cookies
+	var cookieNames []string
+	for name := range req.Cookies {
+		cookieNames = append(cookieNames, requtil.CookiePrefix+name)
+	}
+	sort.Strings(cookieNames)
+	paramNames = append(append([]string{}, paramNames...), cookieNames...)
+
+	originalParams, err := requtil.Params(req)
+	if err != nil {
+		return nil, nil
+	}
+	// A serialized PHP value in base64 is also text nested in its parameter; the nested
+	// pseudo-parameters would re-encode its original text over the objects sent.
+	for key := range originalParams {
+		if strings.HasPrefix(key, requtil.NestedPrefix) {
+			delete(originalParams, key)
+		}
+	}
+
+	var findings []scanner.VulnerabilityResult
+	for _, paramName := range paramNames {
+		if ctx.Err() != nil {
+			return findings, ctx.Err()
+		}
+		displayName := requtil.DisplayName(paramName)
+		b, ok := detectBlob(displayName, originalParams.Get(paramName))
+		if !ok {
+			continue
+		}
+		if opts.SkipParam(ModuleName, displayName) {
+			opts.Coverage.Skip(ModuleName, req, paramName, scanner.SkipReasonRule)
+			continue
+		}
+		if strings.HasPrefix(paramName, requtil.CookiePrefix) {
+			host := req.URL
+			if u, err := url.Parse(req.URL); err == nil {
+				host = u.Host
+			}
+			if _, tested := s.cookies.LoadOrStore(host+"\x00"+paramName+"\x00"+originalParams.Get(paramName), true); tested {
+				continue
+			}
+		}
+		log.Info("Deserialization: %s object (%s) found in '%s' of %s %s", b.format, b.encoding.name, displayName, req.Method, req.URL)
+		paramClient := client.WithRequestBudget(opts.MaxRequestsPerParam)
+		if vuln, found := s.testObject(ctx, req, paramClient, log, opts, originalParams, paramName, b); found {
+			findings = append(findings, vuln)
+		}
+	}
+	return findings, nil
+}
+
+// testObject probes the serialized object b held by paramName. A confirmed sleep gadget is
+// returned over error evidence; out-of-band gadgets are reported once their callback arrives.
+func (s *DeserializationScanner) testObject(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, originalParams url.Values, paramName string, b blob) (scanner.VulnerabilityResult, bool) {
+	_, baselineBody, err := requtil.Send(ctx, req, client, originalParams)
+	if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
+		return scanner.VulnerabilityResult{}, false
+	}
+
+	errorVuln, foundError := s.testErrors(ctx, req, client, log, originalParams, paramName, b, baselineBody)
+	if !opts.BoolOption(ModuleName, "gadgets", true) {
+		return errorVuln, foundError
+	}
+	if vuln, found := s.testSleepGadgets(ctx, req, client, log, opts, originalParams, paramName, b); found {
+		return vuln, true
+	}
+	s.testCallbackGadgets(ctx, req, client, log, opts, originalParams, paramName, b)
+	return errorVuln, foundError
+}
+
+// testErrors sends the malformed variants of b and looks for the error signatures of its format
+// that the original response does not show.
+func (s *DeserializationScanner) testErrors(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, originalParams url.Values, paramName string, b blob, baselineBody string) (scanner.VulnerabilityResult, bool) {
+	for _, tampered := range tamper(b) {
+		testParams := requtil.Copy(originalParams)
+		testParams.Set(paramName, b.encoding.encode(tampered.data))
+		_, body, exchange, err := requtil.SendCaptured(ctx, req, client, testParams)
+		if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			break
+		}
+		if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
+			continue
+		}
+		if b.format == payloads.FormatViewState {
+			if signature, found := newSignature(body, baselineBody, payloads.ViewStateMACSignatures); found {
+				log.Debug("Deserialization: ViewState of %s is signed (%s)", req.URL, signature)
+				return scanner.VulnerabilityResult{}, false
+			}
+		}
+		signature, found := newSignature(body, baselineBody, payloads.DeserializationErrorSignatures[b.format])
+		if !found {
+			continue
+		}
+		log.Success("Deserialization: %s error for a %s in '%s' of %s", b.format, tampered.description, requtil.DisplayName(paramName), req.URL)
+		details := fmt.Sprintf("Parameter '%s' holds a %s serialized object (%s). Sending a %s made the server fail with a %s deserialization error the original request does not show, so the value is deserialized by the server. Deserializing data the client controls lets an attacker instantiate classes of their choice; no gadget chain was confirmed.",
+			requtil.DisplayName(paramName), b.format, b.encoding.name, tampered.description, b.format)
+		if b.format == payloads.FormatViewState {
+			details += " The ViewState is not protected by a MAC (enableViewStateMac is off), so it can carry gadgets such as TypeConfuseDelegate."
+		}
+		vuln := s.newResult(req, paramName, b, evidenceError, details)
+		vuln.Payload = testParams.Get(paramName)
+		vuln.Evidence = signature
+		vuln.Severity = "High"
+		vuln.SetExchange(exchange)
+		return vuln, true
+	}
+	return scanner.VulnerabilityResult{}, false
+}
+
+// newSignature returns the first of signatures found in body and not in baselineBody.
+func newSignature(body, baselineBody string, signatures []string) (string, bool) {
+	for _, signature := range signatures {
+		if strings.Contains(body, signature) && !strings.Contains(baselineBody, signature) {
+			return signature, true
+		}
+	}
+	return "", false
+}
+
+// testSleepGadgets sends the sleep gadgets of the format of b and confirms each delay against
+// a baseline, with time_delay and its multiples, like the other time-based tests.
+func (s *DeserializationScanner) testSleepGadgets(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, originalParams url.Values, paramName string, b blob) (scanner.VulnerabilityResult, bool) {
+	var gadgets []payloads.DeserializationSleepGadget
+	for _, gadget := range payloads.DeserializationSleepGadgets {
+		if gadget.Format == b.format {
+			gadgets = append(gadgets, gadget)
+		}
+	}
+	if len(gadgets) == 0 {
+		return scanner.VulnerabilityResult{}, false
+	}
+	baseline, ok := timing.MeasureBaseline(opts.TimeBasedBaselineSamples, func() (time.Duration, error) {
+		duration, _, err := requtil.Measure(ctx, req, client, originalParams)
+		return duration, err
+	})
+	if !ok {
+		return scanner.VulnerabilityResult{}, false
+	}
+	plan := timing.Plan{Delays: timing.Delays(opts.IntOption(ModuleName, "time_delay", 0), opts.TimeConfirmations), Tolerance: timing.Tolerance}
+	for _, gadget := range gadgets {
+		if ctx.Err() != nil {
+			break
+		}
+		var payload string
+		var exchange scanner.Exchange
+		confirmations, confirmed := plan.Confirm(baseline, func(delay int) (time.Duration, error) {
+			testParams := requtil.Copy(originalParams)
+			payload = b.encoding.encode(gadget.Build(delay))
+			testParams.Set(paramName, payload)
+			duration, captured, err := requtil.Measure(ctx, req, client, testParams)
+			exchange = captured
+			return duration, err
+		})
+		if !confirmed {
+			continue
+		}
+		log.Success("Deserialization: %s gadget %s delayed %s by the requested sleeps", b.format, gadget.Name, req.URL)
+		vuln := s.newResult(req, paramName, b, evidenceDelay, fmt.Sprintf("Parameter '%s' holds a %s serialized object (%s). The %s gadget chain sent in its place paused the server for the requested time across %d confirmations (%s): the server deserializes the value and the gadget ran, which allows remote code execution.",
+			requtil.DisplayName(paramName), b.format, b.encoding.name, gadget.Name, len(confirmations), plan))
+		vuln.Payload = payload
+		vuln.Evidence = fmt.Sprintf("Baseline samples: %s; Injected: %s", timing.FormatDurations(baseline.Samples), strings.Join(confirmations, ", "))
+		vuln.Severity = "Critical"
+		vuln.SetExchange(exchange)
+		return vuln, true
+	}
+	return scanner.VulnerabilityResult{}, false
+}
+
+// testCallbackGadgets sends the out-of-band gadgets of the format of b. Potential findings are
+// stored in the OAST correlation map and only reported once the collaborator records a
+// callback.
+func (s *DeserializationScanner) testCallbackGadgets(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, originalParams url.Values, paramName string, b blob) {
+	if opts.OOBCollaboratorURL == "" || opts.OASTCorrelationMap == nil {
+		return
+	}
+	for _, gadget := range payloads.DeserializationCallbackGadgets {
+		if gadget.Format != b.format || ctx.Err() != nil {
+			continue
+		}
+		correlationID := oob.NewCorrelationID("deser", requtil.DisplayName(paramName))
+		host := oob.PayloadHost(opts.OOBCollaboratorURL, correlationID)
+		testParams := requtil.Copy(originalParams)
+		testParams.Set(paramName, b.encoding.encode(gadget.Build(host)))
+
+		vuln := s.newResult(req, paramName, b, evidenceCallback, fmt.Sprintf("Parameter '%s' holds a %s serialized object (%s). The %s gadget chain sent in its place made the server contact the collaborator host %s: the server deserializes the value and the gadget ran.",
+			requtil.DisplayName(paramName), b.format, b.encoding.name, gadget.Name, host))
+		vuln.Payload = testParams.Get(paramName)
+		vuln.Evidence = fmt.Sprintf("Correlation ID: %s.", correlationID)
+		vuln.Severity = "Critical"
+		opts.OASTCorrelationMap.Store(correlationID, vuln)
+
+		log.Debug("Deserialization (Out-of-Band): Sending %s gadget to '%s' of %s (correlation ID %s)", gadget.Name, requtil.DisplayName(paramName), req.URL, correlationID)
+		if _, _, err := requtil.Send(ctx, req, client, testParams); errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			opts.OASTCorrelationMap.Delete(correlationID) // Never sent, so it can never be confirmed.
+			return
+		}
+	}
+}
+
+// newResult returns the finding of an evidence class on the object b held by paramName.
+func (s *DeserializationScanner) newResult(req crawler.ParameterizedRequest, paramName string, b blob, evidence, details string) scanner.VulnerabilityResult {
+	return scanner.VulnerabilityResult{
+		VulnerabilityType: fmt.Sprintf("Insecure Deserialization (%s, %s)", b.format, evidenceLabels[evidence]),
+		URL:               req.URL,
+		Parameter:         requtil.DisplayName(paramName),
+		Location:          requtil.Location(req, paramName),
+		Details:           fmt.Sprintf("%s Evidence class: %s.", details, evidence),
+		Remediation:       "Do not deserialize data the client can modify. Keep state on the server or use a data-only format such as JSON, sign serialized values with a server-side key (e.g. enable the ViewState MAC), and restrict the classes the deserializer accepts (look-ahead deserialization, allowed_classes).",
+		ScannerName:       s.Name(),
+	}
+}
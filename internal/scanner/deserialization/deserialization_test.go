@@ -0,0 +1,131 @@
+package deserialization
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectBlob(t *testing.T) {
+	php := `O:4:"User":1:{s:4:"name";s:5:"alice";}`
+	java := append(append([]byte{}, payloads.JavaSerializationMagic...), 0x73, 0x72)
+	tests := []struct {
+		name, param, value string
+		wantFormat         string
+		wantEncoding       string
+	}{
+		{name: "Raw PHP", param: "data", value: php, wantFormat: payloads.FormatPHP, wantEncoding: "raw"},
+		{name: "Base64 PHP", param: "data", value: base64.StdEncoding.EncodeToString([]byte(php)), wantFormat: payloads.FormatPHP, wantEncoding: "base64"},
+		{name: "URL-encoded PHP cookie", param: "prefs", value: url.QueryEscape(php), wantFormat: payloads.FormatPHP, wantEncoding: "URL-encoded raw"},
+		{name: "Base64 Java", param: "state", value: base64.StdEncoding.EncodeToString(java), wantFormat: payloads.FormatJava, wantEncoding: "base64"},
+		{name: "Hex Java", param: "state", value: "aced00057372", wantFormat: payloads.FormatJava, wantEncoding: "hex"},
+		{name: "ViewState", param: "__VIEWSTATE", value: "/wEPDwUKLTEyMzQ1Njc4OWRk", wantFormat: payloads.FormatViewState, wantEncoding: "base64"},
+		{name: "BinaryFormatter", param: "obj", value: "AAEAAAD/////AQAAAAAAAAA=", wantFormat: payloads.FormatBinaryFormatter, wantEncoding: "base64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, ok := detectBlob(tt.param, tt.value)
+			require.True(t, ok)
+			assert.Equal(t, tt.wantFormat, b.format)
+			assert.Equal(t, tt.wantEncoding, b.encoding.name)
+			assert.Equal(t, tt.value, b.encoding.encode(b.data), "the object is written back the way it was found")
+		})
+	}
+
+	for _, value := range []string{"hello", "12345", "dGhpcyBpcyBiYXNlNjQ=", "/wEPDwUKLTEyMzQ1Njc4OWRk", `{"a":1}`} {
+		_, ok := detectBlob("q", value)
+		assert.False(t, ok, value)
+	}
+}
+
+// phpApp unserializes the base64 "prefs" cookie, printing the notices PHP would.
+func phpApp(requests *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		cookie, err := r.Cookie("prefs")
+		if err != nil {
+			w.Write([]byte("<p>Welcome</p>"))
+			return
+		}
+		value, _ := base64.StdEncoding.DecodeString(cookie.Value)
+		switch {
+		case !strings.HasSuffix(string(value), "}"):
+			w.Write([]byte("<b>Notice</b>: unserialize(): Error at offset 35 of 36 bytes in /var/www/prefs.php"))
+		default:
+			w.Write([]byte("<p>Welcome back</p>"))
+		}
+	}))
+}
+
+func TestScanReportsPHPErrorEvidenceInCookieOncePerScan(t *testing.T) {
+	var requests int32
+	server := phpApp(&requests)
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	prefs := base64.StdEncoding.EncodeToString([]byte(`a:1:{s:5:"theme";s:4:"dark";}`))
+	req := crawler.ParameterizedRequest{
+		Method:     "GET",
+		URL:        server.URL + "/account?tab=1",
+		ParamNames: []string{"tab"},
+		Cookies:    map[string]string{"prefs": prefs, "session": "abc123"},
+	}
+	opts := scanner.ScannerOptions{ModuleOptions: map[string]map[string]interface{}{ModuleName: {"gadgets": false}}}
+	s := NewDeserializationScanner()
+
+	findings, err := s.Scan(context.Background(), req, client, log, opts)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Insecure Deserialization (PHP, Error-Based)", findings[0].VulnerabilityType)
+	assert.Equal(t, "prefs", findings[0].Parameter)
+	assert.Equal(t, "cookie", findings[0].Location)
+	assert.Equal(t, "High", findings[0].Severity)
+	assert.Equal(t, "unserialize(): Error at offset", findings[0].Evidence)
+	assert.Contains(t, findings[0].Details, "Evidence class: error.")
+
+	sent := atomic.LoadInt32(&requests)
+	findings, err = s.Scan(context.Background(), crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/other", Cookies: req.Cookies}, client, log, opts)
+	require.NoError(t, err)
+	assert.Empty(t, findings, "a cookie is tested once per scan")
+	assert.Equal(t, sent, atomic.LoadInt32(&requests))
+}
+
+func TestScanIgnoresSignedViewState(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("__VIEWSTATE") != "/wEPDwUKLTEyMzQ1Njc4OWRk" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("Validation of viewstate MAC failed. at System.Web.UI.ObjectStateFormatter.Deserialize(String inputString)"))
+			return
+		}
+		w.Write([]byte("<form>...</form>"))
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	req := crawler.ParameterizedRequest{
+		Method:       "POST",
+		URL:          server.URL + "/default.aspx",
+		ParamNames:   []string{"__VIEWSTATE", "q"},
+		FormPostData: "__VIEWSTATE=%2FwEPDwUKLTEyMzQ1Njc4OWRk&q=x",
+	}
+
+	findings, err := NewDeserializationScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
@@ -11,19 +11,43 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/chromedp/cdproto/page"
 	"github.com/chromedp/chromedp"
+	"golang.org/x/net/html"
 )
 
+const (
+	// hookFunction is defined in the page before its own scripts run; the payloads confirming
+	// static flows call it with their marker.
+	hookFunction = "__dursgoDOMXSS"
+	// maxLinkedScripts bounds the scripts of a page fetched for static analysis.
+	maxLinkedScripts = 10
+)
+
+// hookScript records the markers the hook function is called with.
+var hookScript = fmt.Sprintf(`window.%[1]s = function (marker) { (window.%[1]sCalls = window.%[1]sCalls || []).push(String(marker)); };`, hookFunction)
+
 // DOMXSSScanner implements the Scanner interface for DOM-Based XSS.
 type DOMXSSScanner struct{}
 
 // NewDOMXSSScanner creates a new instance of DOMXSSScanner.
 func NewDOMXSSScanner() *DOMXSSScanner { return &DOMXSSScanner{} }
 
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "domxss",
+		Order:          180,
+		DefaultEnabled: true,
+		Requires:       scanner.RequiresRenderer,
+		New:            func(scanner.Env) scanner.Scanner { return NewDOMXSSScanner() },
+	})
+}
+
 // Name returns the scanner's name.
 func (s *DOMXSSScanner) Name() string { return "Intelligent DOM-Based XSS Scanner" }
 
@@ -170,6 +194,145 @@ func (s *DOMXSSScanner) testFragmentDOMXSS(allocatorContext context.Context, req
 	return nil
 }
 
+// pageFlows returns the source-to-sink flows of the inline scripts of body and of the
+// scripts it links to on the same host.
+func pageFlows(ctx context.Context, pageURL, body string, client *httpclient.Client) []flow {
+	var flows []flow
+	for _, script := range inlineScripts(body) {
+		flows = append(flows, analyzeScript(script)...)
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return flows
+	}
+	for _, src := range scriptSources(body) {
+		scriptURL, err := base.Parse(src)
+		if err != nil || scriptURL.Host != base.Host {
+			continue // Third-party libraries are out of scope.
+		}
+		scriptReq, err := http.NewRequestWithContext(ctx, "GET", scriptURL.String(), nil)
+		if err != nil {
+			continue
+		}
+		resp, err := client.Do(scriptReq)
+		if err != nil {
+			continue
+		}
+		js, _ := io.ReadAll(io.LimitReader(resp.Body, crawler.MaxRetainedBodyBytes))
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			flows = append(flows, analyzeScript(string(js))...)
+		}
+	}
+	return flows
+}
+
+// scriptSources returns the src of the first maxLinkedScripts <script> elements of body.
+func scriptSources(body string) []string {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	var sources []string
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "script" && len(sources) < maxLinkedScripts {
+			for _, a := range n.Attr {
+				if a.Key == "src" && a.Val != "" {
+					sources = append(sources, a.Val)
+				}
+			}
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			f(child)
+		}
+	}
+	f(doc)
+	return sources
+}
+
+// flowURL returns the URL of pageURL carrying payload where the source of f reads it: the
+// fragment, or a query parameter for location.search. Sources the page URL does not control
+// (referrer, window.name) return "".
+func flowURL(pageURL, payload string, f flow) string {
+	switch {
+	case strings.Contains(f.source, "location.search"):
+		u, err := url.Parse(pageURL)
+		if err != nil {
+			return ""
+		}
+		query := u.Query()
+		query.Set("dursgo", payload)
+		u.RawQuery = query.Encode()
+		return u.String()
+	case strings.Contains(f.source, "referrer"), strings.Contains(f.source, "window.name"):
+		return ""
+	default:
+		return pageURL + "#" + payload
+	}
+}
+
+// testStaticFlows confirms the flows found by static analysis in the browser: it loads the page
+// with payloads calling a hook function, defined before the page's scripts run, where the source
+// of each flow reads them, and reports the flows the hook was called from.
+func (s *DOMXSSScanner) testStaticFlows(allocatorContext context.Context, req crawler.ParameterizedRequest, flows []flow, log *logger.Logger) []scanner.VulnerabilityResult {
+	tried := make(map[string]bool)
+	for _, f := range flows {
+		kind := "html"
+		if f.kind == sinkScript {
+			kind = "script"
+		}
+		for _, testCase := range payloads.DOMXSSHookPayloads[kind] {
+			marker := strconv.Itoa(rand.Intn(1e9) + 1)
+			payload := strings.NewReplacer("DURSGO_DOM_XSS_HOOK", hookFunction, "DURSGO_DOM_XSS_MARKER", marker).Replace(testCase.Payload)
+			exploitURL := flowURL(req.URL, payload, f)
+			// Flows of the same kind read from the same place are confirmed by the same URLs.
+			key := kind + "\x00" + flowURL(req.URL, testCase.Payload, f)
+			if exploitURL == "" || tried[key] {
+				continue
+			}
+			tried[key] = true
+
+			exploitCtx, cancelExploit := chromedp.NewContext(allocatorContext)
+			runCtx, cancel := context.WithTimeout(exploitCtx, 20*time.Second)
+			var called bool
+			err := chromedp.Run(runCtx,
+				chromedp.ActionFunc(func(ctx context.Context) error {
+					_, err := page.AddScriptToEvaluateOnNewDocument(hookScript).Do(ctx)
+					return err
+				}),
+				chromedp.Navigate(exploitURL),
+				chromedp.Sleep(2*time.Second), // Let the page's scripts read the source and write the sink.
+				chromedp.Evaluate(fmt.Sprintf(`(window.%sCalls || []).indexOf(%q) >= 0`, hookFunction, marker), &called),
+			)
+			cancel()
+			cancelExploit()
+			if err != nil {
+				log.Debug("DOMXSS (static flow): Error loading %s: %v", exploitURL, err)
+				continue
+			}
+			if !called {
+				continue
+			}
+			log.Success("DOMXSS (static flow): %s reached %s and executed on %s", f.source, f.sink, req.URL)
+			return []scanner.VulnerabilityResult{{
+				VulnerabilityType: "DOM-Based Cross-Site Scripting",
+				URL:               exploitURL,
+				Parameter:         f.source,
+				Payload:           payload,
+				Location:          f.source,
+				Details:           fmt.Sprintf("Data from %s reaches the %s sink and was executed by the browser. Payload Description: %s", f.source, f.sink, testCase.Description),
+				Severity:          "High",
+				Confidence:        scanner.ConfidenceCertain,
+				Evidence:          fmt.Sprintf("The payload called the hook function %s(%s) defined before the page's scripts. Flow: %s", hookFunction, marker, f.snippet),
+				Remediation:       "Do not write data read from the URL into HTML or code sinks. Use textContent or setAttribute for HTML, pass functions rather than strings to setTimeout, and consider Trusted Types.",
+				ScannerName:       s.Name(),
+			}}
+		}
+	}
+	return nil
+}
+
 // testPostMessageDOMXSS contains logic for scanning web message vulnerabilities.
 // It first detects if a 'message' event listener is present and then attempts
 // to exploit it by sending crafted postMessages with XSS payloads.
@@ -261,20 +424,19 @@ func (s *DOMXSSScanner) testPostMessageDOMXSS(allocatorContext context.Context,
 
 // Scan now acts as an orchestrator that calls both scanning methods.
 // It checks if the headless browser is enabled and if the page is HTML before proceeding.
-func (s *DOMXSSScanner) Scan(req crawler.ParameterizedRequest, _ *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+func (s *DOMXSSScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, _ *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
 	if opts.Renderer == nil {
 		log.Debug("DOMXSS: Skipping scan because headless browser is not enabled (--render-js).")
 		return nil, nil
 	}
 
 	// Check Content-Type using GET for higher reliability.
-	getReq, _ := http.NewRequest("GET", req.URL, nil)
+	getReq, _ := http.NewRequestWithContext(ctx, "GET", req.URL, nil)
 	getResp, err := opts.Client.Do(getReq)
 	if err != nil {
 		return nil, nil
 	}
-	// Ensure body is closed to prevent resource leaks.
-	io.Copy(io.Discard, getResp.Body)
+	body, _ := io.ReadAll(io.LimitReader(getResp.Body, crawler.MaxRetainedBodyBytes))
 	getResp.Body.Close()
 
 	if !strings.Contains(getResp.Header.Get("Content-Type"), "text/html") {
@@ -286,7 +448,19 @@ func (s *DOMXSSScanner) Scan(req crawler.ParameterizedRequest, _ *httpclient.Cli
 	allocatorContext := opts.Renderer.GetAllocatorContext()
 	var findings []scanner.VulnerabilityResult
 
-	// 1. Run Fragment-based scan
+	// 1. Confirm the source-to-sink flows of the page's scripts (see the domxss-static module).
+	if flows := pageFlows(ctx, req.URL, string(body), opts.Client); len(flows) > 0 {
+		log.Debug("DOMXSS: %d source-to-sink flow(s) found statically on %s; confirming them in the browser.", len(flows), req.URL)
+		if flowFindings := s.testStaticFlows(allocatorContext, req, flows, log); flowFindings != nil {
+			return flowFindings, nil
+		}
+	}
+
+	if ctx.Err() != nil {
+		return findings, ctx.Err()
+	}
+
+	// 2. Run Fragment-based scan
 	fragmentFindings := s.testFragmentDOMXSS(allocatorContext, req, log)
 	if fragmentFindings != nil {
 		findings = append(findings, fragmentFindings...)
@@ -294,7 +468,11 @@ func (s *DOMXSSScanner) Scan(req crawler.ParameterizedRequest, _ *httpclient.Cli
 		return findings, nil
 	}
 
-	// 2. Run postMessage-based scan
+	if ctx.Err() != nil {
+		return findings, ctx.Err()
+	}
+
+	// 3. Run postMessage-based scan
 	postMessageFindings := s.testPostMessageDOMXSS(allocatorContext, req, log)
 	if postMessageFindings != nil {
 		findings = append(findings, postMessageFindings...)
@@ -0,0 +1,264 @@
+package domxss
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// StaticModuleName is the name of the passive module that looks for DOM XSS in the JavaScript
+// of the crawled pages.
+const StaticModuleName = "domxss-static"
+
+// maxSnippetLength bounds the code shown in the evidence of a flow.
+const maxSnippetLength = 200
+
+// sinkKind tells how a sink interprets the data it is given, and so which payloads can confirm it.
+type sinkKind int
+
+const (
+	sinkHTML   sinkKind = iota // The data is parsed as HTML (innerHTML, document.write, ...).
+	sinkScript                 // The data is evaluated as JavaScript (eval, setTimeout, ...).
+)
+
+// sink is a dangerous DOM API. The pattern ends where the argument written to the sink begins.
+type sink struct {
+	name    string
+	kind    sinkKind
+	pattern *regexp.Regexp
+}
+
+var sinks = []sink{
+	{name: "innerHTML", kind: sinkHTML, pattern: regexp.MustCompile(`\.innerHTML\s*\+?=`)},
+	{name: "outerHTML", kind: sinkHTML, pattern: regexp.MustCompile(`\.outerHTML\s*\+?=`)},
+	{name: "insertAdjacentHTML", kind: sinkHTML, pattern: regexp.MustCompile(`\.insertAdjacentHTML\s*\(`)},
+	{name: "document.write", kind: sinkHTML, pattern: regexp.MustCompile(`\bdocument\.write(?:ln)?\s*\(`)},
+	{name: "jQuery html()", kind: sinkHTML, pattern: regexp.MustCompile(`\.html\s*\(`)},
+	{name: "eval", kind: sinkScript, pattern: regexp.MustCompile(`\beval\s*\(`)},
+	{name: "setTimeout", kind: sinkScript, pattern: regexp.MustCompile(`\bsetTimeout\s*\(`)},
+	{name: "setInterval", kind: sinkScript, pattern: regexp.MustCompile(`\bsetInterval\s*\(`)},
+	{name: "Function constructor", kind: sinkScript, pattern: regexp.MustCompile(`\bFunction\s*\(`)},
+}
+
+var (
+	// sourcePattern matches the attacker-controllable values of the page URL and window.
+	sourcePattern = regexp.MustCompile(`\b(?:(?:document|window)\.)?location\.(?:hash|search|href|pathname)\b|\bdocument\.(?:URL|documentURI|baseURI|referrer)\b|\bwindow\.name\b|\b(?:document|window)\.location\b`)
+	// assignmentPattern matches a statement assigning a variable, with the assigned expression.
+	assignmentPattern = regexp.MustCompile(`^(?:(?:var|let|const)\s+)?([A-Za-z_$][\w$]*)\s*=([^=].*)$`)
+	// identifierPattern matches the identifiers of an expression.
+	identifierPattern = regexp.MustCompile(`[A-Za-z_$][\w$]*`)
+	// functionArgumentPattern matches a function expression passed to setTimeout or setInterval.
+	functionArgumentPattern = regexp.MustCompile(`^(?:async\s+)?(?:function\b|\(?[\w$,\s]*\)?\s*=>)`)
+	// statementSeparator splits a script into statements, roughly: strings are not parsed.
+	statementSeparator = regexp.MustCompile(`[;\r\n]+`)
+)
+
+// flow is a source of attacker-controllable data that reaches a sink in a script.
+type flow struct {
+	source  string // e.g. "location.hash", or "location.hash (through q)" when passed by a variable.
+	sink    string
+	kind    sinkKind
+	snippet string // The statement writing to the sink.
+}
+
+// analyzeScript returns the flows from a source to a sink in js. Values are followed through
+// the variables they are assigned to, in the order of the statements; the analysis is lexical,
+// so it misses flows through function calls and objects and may flag sanitized values.
+func analyzeScript(js string) []flow {
+	tainted := make(map[string]string) // Variable name -> source of its value.
+	seen := make(map[string]bool)
+	var flows []flow
+	for _, statement := range statementSeparator.Split(js, -1) {
+		statement = strings.TrimSpace(statement)
+		if statement == "" {
+			continue
+		}
+		for _, sk := range sinks {
+			index := sk.pattern.FindStringIndex(statement)
+			if index == nil {
+				continue
+			}
+			argument := strings.TrimSpace(statement[index[1]:])
+			if strings.HasPrefix(argument, "=") {
+				continue // A comparison, e.g. el.innerHTML == "".
+			}
+			if sk.kind == sinkScript && sk.name != "eval" && functionArgumentPattern.MatchString(argument) {
+				continue // setTimeout(function () { ... }) runs code, not a string.
+			}
+			source := taintOf(argument, tainted)
+			if source == "" || seen[sk.name+"\x00"+statement] {
+				continue
+			}
+			seen[sk.name+"\x00"+statement] = true
+			flows = append(flows, flow{source: source, sink: sk.name, kind: sk.kind, snippet: truncateSnippet(statement)})
+		}
+		if match := assignmentPattern.FindStringSubmatch(statement); match != nil {
+			if source := taintOf(match[2], tainted); source != "" {
+				tainted[match[1]] = strings.SplitN(source, " (through ", 2)[0]
+			} else {
+				delete(tainted, match[1]) // Reassigned a value the attacker does not control.
+			}
+		}
+	}
+	return flows
+}
+
+// taintOf returns the source expression carries data from, directly or through a tainted
+// variable, or "" when it carries none.
+func taintOf(expression string, tainted map[string]string) string {
+	if source := sourcePattern.FindString(expression); source != "" {
+		return source
+	}
+	if len(tainted) == 0 {
+		return ""
+	}
+	for _, index := range identifierPattern.FindAllStringIndex(expression, -1) {
+		if index[0] > 0 && expression[index[0]-1] == '.' {
+			continue // A property name, not a variable.
+		}
+		name := expression[index[0]:index[1]]
+		if source, ok := tainted[name]; ok {
+			return fmt.Sprintf("%s (through %s)", source, name)
+		}
+	}
+	return ""
+}
+
+// truncateSnippet shortens statement to maxSnippetLength characters.
+func truncateSnippet(statement string) string {
+	if len(statement) <= maxSnippetLength {
+		return statement
+	}
+	return statement[:maxSnippetLength] + "..."
+}
+
+// inlineScripts returns the code of the inline <script> elements of an HTML document. Scripts
+// with a src and data blocks (JSON, templates) are left out.
+func inlineScripts(body string) []string {
+	doc, err := html.Parse(strings.NewReader(body))
+	if err != nil {
+		return nil
+	}
+	var scripts []string
+	var f func(*html.Node)
+	f = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "script" && isJavaScript(n) && n.FirstChild != nil {
+			scripts = append(scripts, n.FirstChild.Data)
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			f(child)
+		}
+	}
+	f(doc)
+	return scripts
+}
+
+// isJavaScript reports whether the <script> element n holds inline JavaScript.
+func isJavaScript(n *html.Node) bool {
+	for _, a := range n.Attr {
+		switch strings.ToLower(a.Key) {
+		case "src":
+			return false
+		case "type":
+			t := strings.ToLower(strings.TrimSpace(a.Val))
+			if t != "" && t != "module" && !strings.Contains(t, "javascript") && !strings.Contains(t, "ecmascript") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isHTMLPage reports whether resp is a page whose scripts run in the browser.
+func isHTMLPage(resp crawler.CrawledResponse) bool {
+	if resp.StatusCode != 200 {
+		return false
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		return strings.Contains(contentType, "text/html")
+	}
+	return strings.Contains(strings.ToLower(resp.Body), "<script")
+}
+
+// --- Static DOM XSS Scanner ---
+
+// StaticScanner implements the PassiveScanner interface for DOM XSS found by static analysis:
+// it follows the sources of the page URL to dangerous sinks in the inline scripts of the
+// crawled pages and in the scripts they link to. Its findings are potential; the domxss module
+// confirms flows in the headless browser (-render-js).
+type StaticScanner struct{}
+
+// NewStaticScanner creates a new instance of StaticScanner.
+func NewStaticScanner() *StaticScanner { return &StaticScanner{} }
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           StaticModuleName,
+		Order:          185,
+		DefaultEnabled: true,
+		NewPassive:     func(scanner.Env) scanner.PassiveScanner { return NewStaticScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *StaticScanner) Name() string { return "Static DOM-Based XSS Scanner" }
+
+// ScanResponses analyzes the inline scripts of every crawled page and the linked scripts once
+// each. A flow in a linked script is reported at the first page loading it.
+func (s *StaticScanner) ScanResponses(responses []crawler.CrawledResponse, log *logger.Logger) []scanner.VulnerabilityResult {
+	bodies := make(map[string]string, len(responses))
+	for _, resp := range responses {
+		if resp.StatusCode == 200 {
+			bodies[resp.URL] = resp.Body
+		}
+	}
+
+	var findings []scanner.VulnerabilityResult
+	analyzed := make(map[string]bool) // Linked scripts already analyzed.
+	for _, resp := range responses {
+		if !isHTMLPage(resp) {
+			continue
+		}
+		for _, script := range inlineScripts(resp.Body) {
+			for _, f := range analyzeScript(script) {
+				findings = append(findings, s.newResult(resp.URL, "inline <script>", f))
+			}
+		}
+		for _, scriptURL := range resp.Scripts {
+			body, ok := bodies[scriptURL]
+			if !ok || analyzed[scriptURL] {
+				continue
+			}
+			analyzed[scriptURL] = true
+			for _, f := range analyzeScript(body) {
+				findings = append(findings, s.newResult(resp.URL, scriptURL, f))
+			}
+		}
+	}
+	if len(findings) > 0 {
+		log.Info("DOMXSS (static): %d potential source-to-sink flow(s) found in the crawled scripts.", len(findings))
+	}
+	return findings
+}
+
+// newResult builds the finding of a flow found in a script of page.
+func (s *StaticScanner) newResult(page, script string, f flow) scanner.VulnerabilityResult {
+	return scanner.VulnerabilityResult{
+		VulnerabilityType: "Potential DOM-Based XSS",
+		URL:               page,
+		Parameter:         f.source,
+		Location:          script,
+		Details: fmt.Sprintf("Data from %s reaches the %s sink without visible sanitization in %s. Found by static analysis only: the flow was not executed. Run the domxss scanner with -render-js to confirm it in a browser.",
+			f.source, f.sink, script),
+		Severity:    "Low",
+		Confidence:  scanner.ConfidenceTentative,
+		Evidence:    f.snippet,
+		Remediation: "Do not write data read from the URL, the referrer or window.name into HTML or code sinks. Use textContent or setAttribute for HTML, pass functions rather than strings to setTimeout, and consider Trusted Types.",
+		ScannerName: StaticModuleName,
+	}
+}
@@ -0,0 +1,82 @@
+package domxss
+
+import (
+	"net/http"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzeScript(t *testing.T) {
+	tests := []struct {
+		name       string
+		js         string
+		wantSource string
+		wantSink   string
+		wantKind   sinkKind
+	}{
+		{name: "Direct innerHTML", js: `document.getElementById("out").innerHTML = location.hash.slice(1);`, wantSource: "location.hash", wantSink: "innerHTML", wantKind: sinkHTML},
+		{name: "Through a variable", js: "var q = new URLSearchParams(location.search).get('q')\nel.innerHTML += '<b>' + q + '</b>'", wantSource: "location.search (through q)", wantSink: "innerHTML", wantKind: sinkHTML},
+		{name: "document.write", js: `document.write("<a href='" + document.URL + "'>share</a>")`, wantSource: "document.URL", wantSink: "document.write", wantKind: sinkHTML},
+		{name: "eval", js: `let cb = decodeURIComponent(window.location.hash.substr(1)); eval(cb);`, wantSource: "window.location.hash (through cb)", wantSink: "eval", wantKind: sinkScript},
+		{name: "setTimeout with a string", js: `setTimeout("go('" + location.href + "')", 100)`, wantSource: "location.href", wantSink: "setTimeout", wantKind: sinkScript},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flows := analyzeScript(tt.js)
+			require.Len(t, flows, 1)
+			assert.Equal(t, tt.wantSource, flows[0].source)
+			assert.Equal(t, tt.wantSink, flows[0].sink)
+			assert.Equal(t, tt.wantKind, flows[0].kind)
+			assert.NotEmpty(t, flows[0].snippet)
+		})
+	}
+}
+
+func TestAnalyzeScriptIgnoresSafeCode(t *testing.T) {
+	for _, js := range []string{
+		`el.textContent = location.hash`,
+		`el.innerHTML = "<b>Welcome</b>"`,
+		`if (el.innerHTML == location.hash) { reset() }`,
+		`setTimeout(function () { render(location.hash) }, 10)`,
+		`setTimeout(() => render(location.hash), 10)`,
+		"var q = location.hash\nq = 'home'\nel.innerHTML = q",
+	} {
+		assert.Empty(t, analyzeScript(js), js)
+	}
+}
+
+func TestStaticScannerScanResponses(t *testing.T) {
+	htmlHeader := http.Header{"Content-Type": []string{"text/html; charset=utf-8"}}
+	jsHeader := http.Header{"Content-Type": []string{"application/javascript"}}
+	responses := []crawler.CrawledResponse{
+		{URL: "http://example.com/", StatusCode: 200, Header: htmlHeader, Scripts: []string{"http://example.com/app.js"},
+			Body: `<html><script>document.write(location.search)</script><script type="application/json">{"x": "eval(location.hash)"}</script></html>`},
+		{URL: "http://example.com/about", StatusCode: 200, Header: htmlHeader, Scripts: []string{"http://example.com/app.js"}, Body: `<html>About</html>`},
+		{URL: "http://example.com/app.js", StatusCode: 200, Header: jsHeader, Body: "function show() {\n  $('#msg').html(decodeURIComponent(location.hash))\n}"},
+	}
+
+	findings := NewStaticScanner().ScanResponses(responses, logger.NewLogger(logger.ERROR))
+	require.Len(t, findings, 2, "the JSON data block is not code and the shared script is reported once")
+	assert.Equal(t, "inline <script>", findings[0].Location)
+	assert.Equal(t, "location.search", findings[0].Parameter)
+	assert.Equal(t, "http://example.com/app.js", findings[1].Location)
+	assert.Equal(t, "http://example.com/", findings[1].URL)
+	assert.Equal(t, "$('#msg').html(decodeURIComponent(location.hash))", findings[1].Evidence)
+	for _, finding := range findings {
+		assert.Equal(t, "Low", finding.Severity)
+		assert.Equal(t, scanner.ConfidenceTentative, finding.Confidence)
+		assert.Equal(t, StaticModuleName, finding.ScannerName)
+	}
+}
+
+func TestFlowURL(t *testing.T) {
+	assert.Equal(t, "http://example.com/p?a=1#<b>", flowURL("http://example.com/p?a=1", "<b>", flow{source: "location.hash"}))
+	assert.Equal(t, "http://example.com/p?a=1&dursgo=%3Cb%3E", flowURL("http://example.com/p?a=1", "<b>", flow{source: "location.search (through q)"}))
+	assert.Empty(t, flowURL("http://example.com/p", "<b>", flow{source: "document.referrer"}))
+}
@@ -0,0 +1,136 @@
+// Package errorpages recognizes verbose error pages in the responses of a scan: framework debug
+// pages (Django DEBUG, Laravel Ignition, ASP.NET error screens), stack traces and database
+// errors. It analyzes the responses the HTTP client receives anyway, so it sends no requests.
+package errorpages
+
+import (
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	// contextRadius is the number of characters shown around a match, on the same line.
+	contextRadius = 60
+	// maxSnippetBytes bounds the match shown in evidence.
+	maxSnippetBytes = 300
+)
+
+// binaryContentTypes are the media type prefixes of responses that are not searched.
+var binaryContentTypes = []string{"image/", "audio/", "video/", "font/", "application/octet-stream", "application/pdf", "application/zip"}
+
+// Analyzer matches payloads.ErrorPageSignatures against the responses passed to Observe, which
+// is an httpclient.ResponseObserver. Each framework is reported once per host, at the first
+// response it was seen in.
+type Analyzer struct {
+	log      *logger.Logger
+	mu       sync.Mutex
+	seen     map[string]bool // Host and framework of the findings.
+	findings []scanner.VulnerabilityResult
+}
+
+// NewAnalyzer creates an Analyzer.
+func NewAnalyzer(log *logger.Logger) *Analyzer {
+	return &Analyzer{log: log, seen: make(map[string]bool)}
+}
+
+// Observe looks for verbose error pages in a response and its body.
+func (a *Analyzer) Observe(resp *http.Response, body []byte) {
+	if len(body) == 0 || resp.Request == nil || binary(resp.Header.Get("Content-Type")) {
+		return
+	}
+	host := resp.Request.URL.Host
+	text := string(body)
+	for _, signature := range payloads.GetErrorPageSignatures() {
+		key := host + "\x00" + signature.Framework
+		if a.reported(key) {
+			continue
+		}
+		index := signature.Regex.FindStringIndex(text)
+		if index == nil {
+			continue
+		}
+		a.mu.Lock()
+		if !a.seen[key] {
+			a.seen[key] = true
+			a.findings = append(a.findings, newResult(signature, resp, body, index))
+			a.log.Success("Error pages: %s error output found at %s", signature.Framework, resp.Request.URL)
+		}
+		a.mu.Unlock()
+	}
+}
+
+// reported reports whether a finding of key was recorded.
+func (a *Analyzer) reported(key string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.seen[key]
+}
+
+// Findings returns the findings recorded so far.
+func (a *Analyzer) Findings() []scanner.VulnerabilityResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]scanner.VulnerabilityResult(nil), a.findings...)
+}
+
+// newResult builds the finding of a signature matched at index of body.
+func newResult(signature payloads.ErrorPageSignature, resp *http.Response, body []byte, index []int) scanner.VulnerabilityResult {
+	text := string(body)
+	v := scanner.VulnerabilityResult{
+		VulnerabilityType: fmt.Sprintf("Verbose Error Page (%s)", signature.Framework),
+		URL:               resp.Request.URL.String(),
+		Details: fmt.Sprintf("A response (status %d) shows %s error output. It reveals internal details such as source code paths, queries, configuration or library versions that help attackers plan further attacks.",
+			resp.StatusCode, signature.Framework),
+		Severity:    "Medium",
+		Confidence:  scanner.ConfidenceFirm,
+		Evidence:    fmt.Sprintf("Matched %q at byte offset %d of the response body", snippet(text, index[0], index[1]), index[0]),
+		Remediation: "Disable debug mode and detailed error output in production (e.g., DEBUG = False in Django, APP_DEBUG=false in Laravel, <customErrors mode=\"On\"> in ASP.NET), log errors on the server and serve generic error pages.",
+		ScannerName: "errorpages",
+	}
+	v.SetExchange(scanner.CaptureExchange(sentRequest(resp), resp, body))
+	return v
+}
+
+// sentRequest returns the request of resp with a fresh copy of its body, which the transport
+// has consumed, for the raw exchange.
+func sentRequest(resp *http.Response) *http.Request {
+	req := *resp.Request
+	req.Body = nil
+	if resp.Request.GetBody != nil {
+		if body, err := resp.Request.GetBody(); err == nil {
+			req.Body = body
+		}
+	}
+	return &req
+}
+
+// snippet returns the match, cut to maxSnippetBytes, with up to contextRadius characters of
+// context on its line.
+func snippet(text string, start, end int) string {
+	end = min(end, start+maxSnippetBytes)
+	from := max(start-contextRadius, 0)
+	if nl := strings.LastIndexAny(text[from:start], "\r\n"); nl >= 0 {
+		from += nl + 1
+	}
+	to := min(end+contextRadius, len(text))
+	if nl := strings.IndexAny(text[end:to], "\r\n"); nl >= 0 {
+		to = end + nl
+	}
+	return strings.TrimSpace(text[from:to])
+}
+
+// binary reports whether a response of contentType is not text.
+func binary(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	for _, prefix := range binaryContentTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
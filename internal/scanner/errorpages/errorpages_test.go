@@ -0,0 +1,114 @@
+package errorpages
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveSignatures(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		contentType   string
+		wantFramework string
+	}{
+		{
+			name:          "Django debug page",
+			body:          "<p>You're seeing this error because you have <code>DEBUG = True</code> in your Django settings file.</p>",
+			wantFramework: "Django",
+		},
+		{
+			name:          "Laravel Ignition",
+			body:          `<script src="/vendor/spatie/laravel-ignition/ignition.js"></script>`,
+			wantFramework: "Laravel",
+		},
+		{
+			name:          "ASP.NET yellow screen",
+			body:          "<h1>Server Error in '/' Application.</h1><h2><i>Object reference not set to an instance of an object.</i></h2>",
+			wantFramework: "ASP.NET",
+		},
+		{
+			name:          "Java stack trace",
+			body:          "java.lang.IllegalStateException\n\tat com.shop.CartService.add(CartService.java:88)",
+			wantFramework: "Java",
+		},
+		{
+			name:          "SQL error",
+			body:          `{"error":"SQLSTATE[42S22]: Column not found: 1054 Unknown column 'x' in 'where clause'"}`,
+			contentType:   "application/json",
+			wantFramework: "SQL",
+		},
+		{
+			name:        "Binary response",
+			body:        "Traceback (most recent call last):",
+			contentType: "image/png",
+		},
+		{
+			name: "Generic error page",
+			body: "<h1>Something went wrong</h1><p>Please try again later.</p>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com/orders?id=1", nil)
+			resp := &http.Response{StatusCode: http.StatusInternalServerError, Header: http.Header{}, Request: req}
+			if tt.contentType != "" {
+				resp.Header.Set("Content-Type", tt.contentType)
+			}
+
+			a := NewAnalyzer(logger.NewLogger(logger.ERROR))
+			a.Observe(resp, []byte(tt.body))
+			findings := a.Findings()
+			if tt.wantFramework == "" {
+				assert.Empty(t, findings)
+				return
+			}
+			require.Len(t, findings, 1)
+			assert.Equal(t, "Verbose Error Page ("+tt.wantFramework+")", findings[0].VulnerabilityType)
+			assert.Equal(t, "Medium", findings[0].Severity)
+			assert.Equal(t, "http://example.com/orders?id=1", findings[0].URL)
+		})
+	}
+}
+
+func TestObserveClientResponsesOncePerHostAndFramework(t *testing.T) {
+	var requests atomic.Int64
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/ok" {
+			w.Write([]byte("<p>Welcome</p>"))
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte("Traceback (most recent call last):\n  File \"/srv/app/views.py\", line 12, in show\n" + strings.Repeat("x", 64)))
+	}))
+	defer server.Close()
+
+	a := NewAnalyzer(logger.NewLogger(logger.ERROR))
+	client := httpclient.NewClient(logger.NewLogger(logger.ERROR), httpclient.ClientOptions{Observe: a.Observe})
+	for _, path := range []string{"/ok", "/a", "/b"} {
+		resp, err := client.Get(server.URL + path)
+		require.NoError(t, err)
+		_, _, err = client.ReadBody(resp)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	findings := a.Findings()
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Verbose Error Page (Python)", findings[0].VulnerabilityType)
+	assert.Equal(t, server.URL+"/a", findings[0].URL)
+	assert.Contains(t, findings[0].Evidence, "Traceback (most recent call last):")
+	assert.Contains(t, findings[0].RawResponse, "500 Internal Server Error")
+	assert.Equal(t, int64(3), requests.Load(), "the analysis sends no requests")
+}
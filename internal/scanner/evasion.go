@@ -0,0 +1,108 @@
+package scanner
+
+import (
+	"Dursgo/internal/payloads"
+	"fmt"
+	"strings"
+)
+
+// Evasion levels (ScannerOptions.Evasion, -evasion). Besides a level, an evasion setting can
+// list transformation names, e.g. "url,case".
+const (
+	EvasionNone = "none" // Payloads are sent as they are (default).
+	EvasionLow  = "low"  // Cheap transformations that get past naive filters.
+	EvasionHigh = "high" // Every transformation.
+)
+
+// lowEvasion are the transformations of EvasionLow.
+var lowEvasion = []string{"url", "sql-comment", "case"}
+
+// Evasion is the set of evasion transformations scanners expand their payload lists with. Every
+// transformation multiplies the requests of the scanners consuming it, so the zero Evasion, which
+// sends payloads as they are, is the default.
+type Evasion struct {
+	Level string // EvasionNone, EvasionLow, EvasionHigh or the list of transformation names.
+	names []string
+}
+
+// ParseEvasion parses an evasion level or a comma-separated list of transformation names; an
+// empty value is EvasionNone.
+func ParseEvasion(value string) (Evasion, error) {
+	switch level := strings.ToLower(strings.TrimSpace(value)); level {
+	case "", EvasionNone:
+		return Evasion{}, nil
+	case EvasionLow:
+		return Evasion{Level: level, names: lowEvasion}, nil
+	case EvasionHigh:
+		return Evasion{Level: level, names: transformationNames()}, nil
+	default:
+		var names []string
+		for _, name := range strings.Split(level, ",") {
+			name = strings.TrimSpace(name)
+			if _, ok := payloads.TransformationByName(name); !ok {
+				return Evasion{}, fmt.Errorf("invalid evasion level or transformation %q; use none, low, high or a list of %s", name, strings.Join(transformationNames(), ", "))
+			}
+			names = append(names, name)
+		}
+		return Evasion{Level: strings.Join(names, ","), names: names}, nil
+	}
+}
+
+// transformationNames returns the names of the available transformations.
+func transformationNames() []string {
+	var names []string
+	for _, t := range payloads.Transformations {
+		names = append(names, t.Name)
+	}
+	return names
+}
+
+// String returns the level of e, or EvasionNone.
+func (e Evasion) String() string {
+	if e.Level == "" {
+		return EvasionNone
+	}
+	return e.Level
+}
+
+// Enabled reports whether e transforms payloads at all.
+func (e Evasion) Enabled() bool {
+	return len(e.names) > 0
+}
+
+// Transformations returns the transformations of e that keep payloads of kind working, after the
+// identity (the zero Transformation), so that scanners try the original payloads first.
+func (e Evasion) Transformations(kind payloads.PayloadKind) []payloads.Transformation {
+	list := []payloads.Transformation{{}}
+	for _, name := range e.names {
+		if t, ok := payloads.TransformationByName(name); ok && t.AppliesTo(kind) {
+			list = append(list, t)
+		}
+	}
+	return list
+}
+
+// Variant is a payload of a list expanded by an Evasion, with the name of the transformation
+// that produced it; empty for the original payload.
+type Variant[T any] struct {
+	Payload        T
+	Transformation string
+}
+
+// Expand returns the payloads of list followed by their variants for each transformation of e
+// applying to kind. apply returns a copy of a payload with its payload strings transformed by
+// the given function. Scanners expand the list after trimming it to their payload tier, so the
+// level multiplies the requests of a tier instead of the full lists.
+func Expand[T any](e Evasion, kind payloads.PayloadKind, list []T, apply func(T, func(string) string) T) []Variant[T] {
+	var variants []Variant[T]
+	for _, t := range e.Transformations(kind) {
+		for _, p := range list {
+			if t.Name == "" {
+				variants = append(variants, Variant[T]{Payload: p})
+				continue
+			}
+			variants = append(variants, Variant[T]{Payload: apply(p, t.Apply), Transformation: t.Name})
+		}
+	}
+	return variants
+}
@@ -0,0 +1,48 @@
+package scanner
+
+import (
+	"testing"
+
+	"Dursgo/internal/payloads"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEvasion(t *testing.T) {
+	e, err := ParseEvasion("")
+	require.NoError(t, err)
+	assert.False(t, e.Enabled())
+	assert.Equal(t, EvasionNone, e.String())
+
+	e, err = ParseEvasion(" Low ")
+	require.NoError(t, err)
+	assert.Equal(t, EvasionLow, e.String())
+	assert.Len(t, e.Transformations(payloads.PayloadSQL), 1+len(lowEvasion))
+	assert.Len(t, e.Transformations(payloads.PayloadCommand), 2, "only url applies to commands")
+
+	e, err = ParseEvasion("high")
+	require.NoError(t, err)
+	assert.Len(t, e.Transformations(payloads.PayloadSQL), 1+len(payloads.Transformations))
+
+	e, err = ParseEvasion("url, null-byte")
+	require.NoError(t, err)
+	assert.Equal(t, "url,null-byte", e.String())
+	_, err = ParseEvasion("url,rot13")
+	assert.Error(t, err)
+}
+
+func TestExpand(t *testing.T) {
+	list := []string{"' OR 1=1-- -", "' AND 1=2-- -"}
+	identity := func(p string, transform func(string) string) string { return transform(p) }
+
+	assert.Equal(t, []Variant[string]{{Payload: list[0]}, {Payload: list[1]}}, Expand(Evasion{}, payloads.PayloadSQL, list, identity))
+
+	e, err := ParseEvasion("sql-comment")
+	require.NoError(t, err)
+	variants := Expand(e, payloads.PayloadSQL, list, identity)
+	require.Len(t, variants, 4)
+	assert.Equal(t, Variant[string]{Payload: list[0]}, variants[0], "original payloads first")
+	assert.Equal(t, Variant[string]{Payload: "'/**/OR/**/1=1-- -", Transformation: "sql-comment"}, variants[2])
+	assert.Len(t, Expand(e, payloads.PayloadXSS, list, identity), 2, "sql-comment does not apply to XSS")
+}
@@ -0,0 +1,109 @@
+package scanner
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxRawResponseBytes is the raw response size kept in findings when
+// ScannerOptions.MaxRawResponseBytes is unset.
+const DefaultMaxRawResponseBytes = 8192
+
+// MaxListedURLs bounds the affected URLs ListURLs lists in a finding.
+const MaxListedURLs = 10
+
+// SnippetRadius is the number of characters Snippet keeps on each side of the needle.
+const SnippetRadius = 40
+
+// Exchange is the raw HTTP request and response that produced a finding.
+type Exchange struct {
+	Request  string
+	Response string
+}
+
+// CaptureExchange dumps req as sent on the wire and resp with the already-read body.
+// It must be called after the request was sent: the client adds headers (User-Agent,
+// authentication) and leaves a rewound body on req. Dump errors yield empty strings.
+func CaptureExchange(req *http.Request, resp *http.Response, body []byte) Exchange {
+	var exchange Exchange
+	if req != nil {
+		if dump, err := httputil.DumpRequestOut(req, true); err == nil {
+			exchange.Request = string(dump)
+		}
+	}
+	if resp != nil {
+		if dump, err := httputil.DumpResponse(resp, false); err == nil {
+			exchange.Response = string(dump) + string(body)
+		}
+	}
+	return exchange
+}
+
+// SetExchange attaches the raw request/response pair to the finding. The response is
+// truncated and encoded later by PrepareRawEvidence.
+func (v *VulnerabilityResult) SetExchange(exchange Exchange) {
+	v.RawRequest = exchange.Request
+	v.RawResponse = exchange.Response
+}
+
+// PrepareRawEvidence truncates each finding's RawResponse to maxBytes (DefaultMaxRawResponseBytes
+// when zero; a negative value drops raw exchanges entirely) and base64-encodes responses that
+// are not valid UTF-8 text, setting RawResponseBase64 so reporters can decode them.
+func PrepareRawEvidence(findings []VulnerabilityResult, maxBytes int) {
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxRawResponseBytes
+	}
+	for i := range findings {
+		v := &findings[i]
+		if maxBytes < 0 {
+			v.RawRequest, v.RawResponse = "", ""
+			continue
+		}
+		if v.RawResponse == "" || v.RawResponseBase64 {
+			continue
+		}
+		raw := []byte(v.RawResponse)
+		if len(raw) > maxBytes {
+			cut := maxBytes
+			for cut > 0 && !utf8.RuneStart(raw[cut]) {
+				cut-- // Don't split a multi-byte character and misreport the body as binary.
+			}
+			raw = raw[:cut]
+			v.RawResponseTruncated = true
+		}
+		if !utf8.Valid(raw) || bytes.IndexByte(raw, 0) >= 0 {
+			v.RawResponse = base64.StdEncoding.EncodeToString(raw)
+			v.RawResponseBase64 = true
+			continue
+		}
+		v.RawResponse = string(raw)
+	}
+}
+
+// Snippet returns the text around the first occurrence of needle in body, for the Evidence of
+// a reflection; needle itself if body does not contain it.
+func Snippet(body, needle string) string {
+	index := strings.Index(body, needle)
+	if index < 0 {
+		return needle
+	}
+	start, end := max(index-SnippetRadius, 0), min(index+len(needle)+SnippetRadius, len(body))
+	return strings.TrimSpace(strings.ToValidUTF8(body[start:end], ""))
+}
+
+// ListURLs lists up to MaxListedURLs of the URLs affected by a finding, sorted, noting how many
+// were left out.
+func ListURLs(urls []string) string {
+	sorted := append([]string{}, urls...)
+	sort.Strings(sorted)
+	if len(sorted) <= MaxListedURLs {
+		return strings.Join(sorted, ", ")
+	}
+	return fmt.Sprintf("%s (and %d more)", strings.Join(sorted[:MaxListedURLs], ", "), len(sorted)-MaxListedURLs)
+}
@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCaptureExchange(t *testing.T) {
+	req := httptest.NewRequest("POST", "http://example.com/login", strings.NewReader("user=admin'--"))
+	req.RequestURI = "" // Client-side request, as sent by httpclient.
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp := &http.Response{StatusCode: 200, Status: "200 OK", ProtoMajor: 1, ProtoMinor: 1, Header: http.Header{"Content-Type": {"text/html"}}}
+
+	exchange := CaptureExchange(req, resp, []byte("<p>Welcome admin</p>"))
+
+	assert.Contains(t, exchange.Request, "POST /login HTTP/1.1")
+	assert.Contains(t, exchange.Request, "user=admin'--")
+	assert.Contains(t, exchange.Response, "HTTP/1.1 200 OK")
+	assert.True(t, strings.HasSuffix(exchange.Response, "<p>Welcome admin</p>"))
+}
+
+func TestPrepareRawEvidence(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      string
+		maxBytes      int
+		wantResponse  string
+		wantBase64    bool
+		wantTruncated bool
+	}{
+		{
+			name:         "Short text is kept",
+			response:     "HTTP/1.1 200 OK\r\n\r\nok",
+			maxBytes:     64,
+			wantResponse: "HTTP/1.1 200 OK\r\n\r\nok",
+		},
+		{
+			name:          "Long text is truncated",
+			response:      "HTTP/1.1 200 OK\r\n\r\n" + strings.Repeat("a", 100),
+			maxBytes:      20,
+			wantResponse:  "HTTP/1.1 200 OK\r\n\r\na",
+			wantTruncated: true,
+		},
+		{
+			name:          "Truncation does not split a multi-byte character",
+			response:      "ééé",
+			maxBytes:      3,
+			wantResponse:  "é",
+			wantTruncated: true,
+		},
+		{
+			name:         "Binary body is base64-encoded",
+			response:     "HTTP/1.1 200 OK\r\n\r\n\x89PNG\x00\x01",
+			maxBytes:     64,
+			wantResponse: base64.StdEncoding.EncodeToString([]byte("HTTP/1.1 200 OK\r\n\r\n\x89PNG\x00\x01")),
+			wantBase64:   true,
+		},
+		{
+			name:     "Negative limit drops the exchange",
+			response: "HTTP/1.1 200 OK\r\n\r\nok",
+			maxBytes: -1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := []VulnerabilityResult{{RawRequest: "GET / HTTP/1.1", RawResponse: tt.response}}
+			PrepareRawEvidence(findings, tt.maxBytes)
+
+			require.Len(t, findings, 1)
+			assert.Equal(t, tt.wantResponse, findings[0].RawResponse)
+			assert.Equal(t, tt.wantBase64, findings[0].RawResponseBase64)
+			assert.Equal(t, tt.wantTruncated, findings[0].RawResponseTruncated)
+		})
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	body := strings.Repeat("a", 100) + "canary" + strings.Repeat("b", 100)
+	assert.Equal(t, strings.Repeat("a", SnippetRadius)+"canary"+strings.Repeat("b", SnippetRadius), Snippet(body, "canary"))
+	assert.Equal(t, "x canary", Snippet("  x canary\n", "canary"))
+	assert.Equal(t, "missing", Snippet(body, "missing"))
+}
+
+func TestListURLs(t *testing.T) {
+	assert.Equal(t, "http://a/, http://b/", ListURLs([]string{"http://b/", "http://a/"}))
+
+	var urls []string
+	for i := 0; i < MaxListedURLs+2; i++ {
+		urls = append(urls, fmt.Sprintf("http://host/%02d", i))
+	}
+	listed := ListURLs(urls)
+	assert.True(t, strings.HasPrefix(listed, "http://host/00, "))
+	assert.True(t, strings.HasSuffix(listed, "http://host/09 (and 2 more)"))
+}
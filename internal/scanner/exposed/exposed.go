@@ -6,6 +6,7 @@ import (
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -25,6 +26,15 @@ func NewExposedScanner() *ExposedScanner {
 	return &ExposedScanner{dirsScanned: make(map[string]bool)}
 }
 
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "exposed",
+		Order:          140,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewExposedScanner() },
+	})
+}
+
 // Name returns the scanner's name.
 func (s *ExposedScanner) Name() string {
 	return "Directory Listing / Exposed Files Scanner"
@@ -32,7 +42,8 @@ func (s *ExposedScanner) Name() string {
 
 // Scan performs a scan for exposed files and directory listings.
 // It now scans on a per-directory basis and includes baseline content checking to reduce false positives.
-func (s *ExposedScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+func (s *ExposedScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
 	var findings []scanner.VulnerabilityResult
 	parsedURL, err := url.Parse(req.URL)
 	if err != nil {
@@ -81,7 +92,7 @@ func (s *ExposedScanner) Scan(req crawler.ParameterizedRequest, client *httpclie
 	pathsToTest := make(map[string]bool)
 
 	// 1. Add all generic paths
-	for _, path := range payloads.ExposedGenericPaths {
+	for _, path := range payloads.GetExposedGenericPaths() {
 		pathsToTest[path] = true
 	}
 
@@ -126,6 +137,10 @@ func (s *ExposedScanner) Scan(req crawler.ParameterizedRequest, client *httpclie
 				log.Debug("ExposedScanner: Skipping %s, content matches 'not found' baseline.", testURLStr)
 				continue
 			}
+			if opts.Soft404.IsSoft404(resp, responseBody) {
+				log.Debug("ExposedScanner: Skipping %s, it is the soft-404 page of the host.", testURLStr)
+				continue
+			}
 
 			isListing := false
 			if strings.HasSuffix(path, "/") {
@@ -6,13 +6,13 @@ import (
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/requtil"
 	"bytes"
+	"context"
 	"fmt"
 	"html"
 	"io"
-	"mime/multipart"
 	"net/http"
-	"net/textproto"
 	"net/url"
 	"regexp"
 	"strings"
@@ -27,6 +27,15 @@ func NewFileUploadScanner() *FileUploadScanner {
 	return &FileUploadScanner{}
 }
 
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "fileupload",
+		Order:          110,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewFileUploadScanner() },
+	})
+}
+
 // Name returns the scanner's name.
 func (s *FileUploadScanner) Name() string {
 	return "Unrestricted File Upload Scanner"
@@ -34,7 +43,8 @@ func (s *FileUploadScanner) Name() string {
 
 // Scan performs a scan for unrestricted file upload vulnerabilities.
 // It attempts to upload malicious files and verifies if they are accessible and executable.
-func (s *FileUploadScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+func (s *FileUploadScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
 	var findings []scanner.VulnerabilityResult
 	if req.Method != "POST" {
 		return nil, nil
@@ -60,7 +70,7 @@ func (s *FileUploadScanner) Scan(req crawler.ParameterizedRequest, client *httpc
 	// --- Generic Upload Test ---
 	shellFilename := fmt.Sprintf("dursgo_shell_%d.php", time.Now().UnixNano())
 	shellContent := "<?php echo 'DURSGO_UPLOAD_CONFIRMED'; ?>"
-	if found, vuln := s.testUpload(req, client, log, opts, fileParamName, shellFilename, []byte(shellContent), "image/jpeg", "DURSGO_UPLOAD_CONFIRMED"); found {
+	if found, vuln := s.testUpload(ctx, req, client, log, opts, fileParamName, shellFilename, []byte(shellContent), "image/jpeg", "DURSGO_UPLOAD_CONFIRMED"); found {
 		findings = append(findings, vuln)
 		return findings, nil
 	}
@@ -71,7 +81,7 @@ func (s *FileUploadScanner) Scan(req crawler.ParameterizedRequest, client *httpc
 		contentWithMarker := bytes.Replace(testCase.Content, []byte("dursgo_magic_header"), []byte(marker), -1)
 		contentWithMarker = bytes.Replace(contentWithMarker, []byte("dursgo_secret"), []byte(marker), -1)
 
-		if found, vuln := s.testUpload(req, client, log, opts, fileParamName, testCase.FileName, contentWithMarker, testCase.ContentType, marker); found {
+		if found, vuln := s.testUpload(ctx, req, client, log, opts, fileParamName, testCase.FileName, contentWithMarker, testCase.ContentType, marker); found {
 			findings = append(findings, vuln)
 			return findings, nil
 		}
@@ -82,8 +92,8 @@ func (s *FileUploadScanner) Scan(req crawler.ParameterizedRequest, client *httpc
 
 // testUpload performs a file upload test and verifies its success.
 // It attempts to upload a file with given content and type, then checks for its accessibility and a marker.
-func (s *FileUploadScanner) testUpload(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, paramName, fileName string, fileContent []byte, contentType, marker string) (bool, scanner.VulnerabilityResult) {
-	uploadResp, err := s.performUploadRequest(req, client, log, paramName, fileName, fileContent, contentType)
+func (s *FileUploadScanner) testUpload(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, paramName, fileName string, fileContent []byte, contentType, marker string) (bool, scanner.VulnerabilityResult) {
+	uploadResp, err := s.performUploadRequest(ctx, req, client, log, paramName, fileName, fileContent, contentType)
 	if err != nil {
 		log.Debug("Fileupload: Upload request for '%s' failed: %v", fileName, err)
 		return false, scanner.VulnerabilityResult{}
@@ -161,39 +171,29 @@ func (s *FileUploadScanner) testUpload(req crawler.ParameterizedRequest, client
 
 // performUploadRequest constructs and sends a multipart/form-data upload request.
 // It includes the specified file content and other original form parameters.
-func (s *FileUploadScanner) performUploadRequest(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName, fileName string, fileContent []byte, contentType string) (*http.Response, error) {
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-
-	h := make(textproto.MIMEHeader)
-	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, paramName, fileName))
-	h.Set("Content-Type", contentType)
-	part, err := writer.CreatePart(h)
-	if err != nil {
-		return nil, err
-	}
-	_, err = part.Write(fileContent)
-	if err != nil {
-		return nil, err
-	}
-
+func (s *FileUploadScanner) performUploadRequest(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName, fileName string, fileContent []byte, contentType string) (*http.Response, error) {
+	fields := []crawler.MultipartField{{Name: paramName, Value: fileName, IsFile: true, Content: fileContent, ContentType: contentType}}
 	originalFormData, _ := url.ParseQuery(req.FormPostData)
 	for key, values := range originalFormData {
 		if key != paramName {
 			for _, value := range values {
-				writer.WriteField(key, value)
+				fields = append(fields, crawler.MultipartField{Name: key, Value: value})
 			}
 		}
 	}
-
 	// Explicitly add common submit parameters, as many applications check for them.
-	writer.WriteField("submit", "Upload")
-	writer.Close()
-
-	uploadReq, _ := http.NewRequest("POST", req.URL, body)
-	uploadReq.Header.Set("Content-Type", writer.FormDataContentType())
+	fields = append(fields, crawler.MultipartField{Name: "submit", Value: "Upload"})
 
-	return client.Do(uploadReq)
+	uploadReq := crawler.ParameterizedRequest{Method: "POST", URL: req.URL, ContentType: crawler.MultipartContentType, MultipartFields: fields}
+	params, err := requtil.Params(uploadReq)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := requtil.New(ctx, uploadReq, params)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(httpReq)
 }
 
 // verifyUpload attempts to verify the successful upload and execution of a file.
@@ -2,6 +2,7 @@ package graphql
 
 import (
 	"Dursgo/internal/crawler"
+	"Dursgo/internal/discovery"
 	"Dursgo/internal/httpclient"
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
@@ -54,6 +55,24 @@ func NewGraphQLScanner() *GraphQLScanner {
 	return &GraphQLScanner{}
 }
 
+// ModuleName selects the scanner (-s) and holds its options in config.yaml (scanners.graphql).
+const ModuleName = "graphql"
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           ModuleName,
+		Order:          190,
+		DefaultEnabled: true,
+		Options: []scanner.OptionSpec{
+			{Name: "batch_testing", Type: scanner.OptionBool, Default: true, Description: "Test query batching"},
+			{Name: "batch_max_size", Type: scanner.OptionInt, Default: 10, Description: "Largest batch sent by the batching test"},
+			{Name: "batch_max_requests", Type: scanner.OptionInt, Default: 30, Description: "Request budget of the batching test"},
+			{Name: "batch_delay_ms", Type: scanner.OptionInt, Default: 100, Description: "Delay between batching requests in milliseconds"},
+		},
+		New: func(scanner.Env) scanner.Scanner { return NewGraphQLScanner() },
+	})
+}
+
 // Name returns the scanner's name.
 func (s *GraphQLScanner) Name() string {
 	return "GraphQL Scanner"
@@ -119,7 +138,7 @@ func (s *GraphQLScanner) logError(err error, message string) {
 // checkIntrospection checks if GraphQL introspection is enabled.
 // It sends various introspection queries to the GraphQL endpoint and
 // analyzes the response to determine if schema information is exposed.
-func (s *GraphQLScanner) checkIntrospection(endpoint string, client *httpclient.Client) (bool, string) {
+func (s *GraphQLScanner) checkIntrospection(ctx context.Context, endpoint string, client *httpclient.Client) (bool, string) {
 	// Basic introspection query
 	introspectionQuery := `{"query":"{__schema{types{name fields{name type{name kind ofType{name kind}}}}}"}`
 	queries := []string{
@@ -129,7 +148,7 @@ func (s *GraphQLScanner) checkIntrospection(endpoint string, client *httpclient.
 	}
 
 	for _, query := range queries {
-		req, err := http.NewRequest("POST", endpoint, bytes.NewBufferString(query))
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBufferString(query))
 		if err != nil {
 			continue
 		}
@@ -177,9 +196,9 @@ func (s *GraphQLScanner) checkIntrospection(endpoint string, client *httpclient.
 // checkSQLInjection checks for SQL Injection vulnerabilities in GraphQL and REST API endpoints.
 // It performs a multi-phase check, starting with basic payloads, then different payloads,
 // and finally checks vulnerable REST API endpoints and response differences.
-func (s *GraphQLScanner) checkSQLInjection(endpoint string, client *httpclient.Client) (bool, string, string) {
+func (s *GraphQLScanner) checkSQLInjection(ctx context.Context, endpoint string, client *httpclient.Client) (bool, string, string) {
 	// Phase 1: Check with basic payloads
-	basicVuln, basicEvidence := s.checkBasicSQLInjection(endpoint, client)
+	basicVuln, basicEvidence := s.checkBasicSQLInjection(ctx, endpoint, client)
 	if basicVuln {
 		// If vulnerability found, return true with evidence
 		return true, basicEvidence, "SQL Injection detected"
@@ -224,13 +243,13 @@ func (s *GraphQLScanner) checkSQLInjection(endpoint string, client *httpclient.C
 		var err error
 
 		if ep.method == "POST" {
-			req, err = http.NewRequest(ep.method, fullURL, bytes.NewBufferString(ep.payload))
+			req, err = http.NewRequestWithContext(ctx, ep.method, fullURL, bytes.NewBufferString(ep.payload))
 		} else {
 			url := fullURL
 			if ep.payload != "" {
 				url = fmt.Sprintf("%s?%s", fullURL, ep.payload)
 			}
-			req, err = http.NewRequest(ep.method, url, nil)
+			req, err = http.NewRequestWithContext(ctx, ep.method, url, nil)
 		}
 
 		if err != nil {
@@ -286,7 +305,7 @@ func (s *GraphQLScanner) checkSQLInjection(endpoint string, client *httpclient.C
 // checkBasicSQLInjection performs basic SQL Injection detection.
 // It tests various GraphQL and REST API endpoints with common SQL injection payloads
 // and looks for specific patterns or unusual status codes in the responses.
-func (s *GraphQLScanner) checkBasicSQLInjection(endpoint string, client *httpclient.Client) (bool, string) {
+func (s *GraphQLScanner) checkBasicSQLInjection(ctx context.Context, endpoint string, client *httpclient.Client) (bool, string) {
 	// Extract base URL from GraphQL endpoint
 	baseURL := strings.TrimSuffix(endpoint, "/graphql")
 	if baseURL == endpoint { // If not a GraphQL endpoint, use the original URL
@@ -342,14 +361,14 @@ func (s *GraphQLScanner) checkBasicSQLInjection(endpoint string, client *httpcli
 		var err error
 
 		if tc.method == "POST" {
-			req, err = http.NewRequest(tc.method, tc.endpoint, bytes.NewBufferString(tc.payload))
+			req, err = http.NewRequestWithContext(ctx, tc.method, tc.endpoint, bytes.NewBufferString(tc.payload))
 		} else {
 			// For GET, add parameters directly to the URL
 			url := tc.endpoint
 			if tc.payload != "" {
 				url = fmt.Sprintf("%s?%s", tc.endpoint, tc.payload)
 			}
-			req, err = http.NewRequest(tc.method, url, nil)
+			req, err = http.NewRequestWithContext(ctx, tc.method, url, nil)
 		}
 
 		if err != nil {
@@ -410,7 +429,7 @@ func (s *GraphQLScanner) checkSensitiveDataExposure(endpoint string, client *htt
 
 // checkNoSQLInjection checks for NoSQL Injection vulnerabilities.
 // It tests vulnerable endpoints with NoSQL injection payloads and looks for indicators in the response.
-func (s *GraphQLScanner) checkNoSQLInjection(endpoint string, client *httpclient.Client) (bool, string, string) {
+func (s *GraphQLScanner) checkNoSQLInjection(ctx context.Context, endpoint string, client *httpclient.Client) (bool, string, string) {
 	// Extract base URL
 	baseURL := strings.TrimSuffix(endpoint, "/graphql")
 	if baseURL == endpoint {
@@ -434,7 +453,7 @@ func (s *GraphQLScanner) checkNoSQLInjection(endpoint string, client *httpclient
 
 	for _, ep := range endpointsToTest {
 		url := fmt.Sprintf("%s%s?%s", baseURL, ep.path, ep.payload)
-		req, err := http.NewRequest(ep.method, url, nil)
+		req, err := http.NewRequestWithContext(ctx, ep.method, url, nil)
 		if err != nil {
 			continue
 		}
@@ -474,7 +493,7 @@ func (s *GraphQLScanner) checkNoSQLInjection(endpoint string, client *httpclient
 // checkRateLimit checks for rate limiting protection.
 // It sends multiple requests to an endpoint and analyzes the success rate and response times
 // to determine if rate limiting is properly implemented.
-func (s *GraphQLScanner) checkRateLimit(endpoint string, client *httpclient.Client) (bool, string, string) {
+func (s *GraphQLScanner) checkRateLimit(ctx context.Context, endpoint string, client *httpclient.Client) (bool, string, string) {
 	// Extract base URL
 	baseURL := strings.TrimSuffix(endpoint, "/graphql")
 	if baseURL == endpoint {
@@ -519,9 +538,9 @@ func (s *GraphQLScanner) checkRateLimit(endpoint string, client *httpclient.Clie
 			var err error
 			
 			if ep.body != "" {
-				req, err = http.NewRequest(ep.method, url, bytes.NewBufferString(ep.body))
+				req, err = http.NewRequestWithContext(ctx, ep.method, url, bytes.NewBufferString(ep.body))
 			} else {
-				req, err = http.NewRequest(ep.method, url, nil)
+				req, err = http.NewRequestWithContext(ctx, ep.method, url, nil)
 			}
 			
 			if err != nil {
@@ -534,8 +553,8 @@ func (s *GraphQLScanner) checkRateLimit(endpoint string, client *httpclient.Clie
 				req.Header.Set(k, v)
 			}
 
-			// Send request
-			resp, err := client.Do(req)
+			// Send request once: a retried 429 would hide the rate limit.
+			resp, err := client.DoNoRetry(req)
 			if err == nil {
 				if resp.StatusCode >= 200 && resp.StatusCode < 300 {
 					successCount++
@@ -571,7 +590,7 @@ func (s *GraphQLScanner) checkRateLimit(endpoint string, client *httpclient.Clie
 
 // isGraphQLEndpoint checks if the endpoint responds like a GraphQL service.
 // It sends simple GraphQL queries and checks for JSON responses containing 'data' or 'errors' fields.
-func (s *GraphQLScanner) isGraphQLEndpoint(endpoint string, client *httpclient.Client) bool {
+func (s *GraphQLScanner) isGraphQLEndpoint(ctx context.Context, endpoint string, client *httpclient.Client) bool {
 	// List of GraphQL queries to try
 	queries := []string{
 		`{"query":"{__typename}"}`,                           // Simplest query
@@ -581,7 +600,7 @@ func (s *GraphQLScanner) isGraphQLEndpoint(endpoint string, client *httpclient.C
 
 	// Try each query
 	for _, query := range queries {
-		req, err := http.NewRequest("POST", endpoint, bytes.NewBufferString(query))
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBufferString(query))
 		if err != nil {
 			continue
 		}
@@ -674,7 +693,7 @@ type BatchConfig struct {
 
 // checkBatchQueries checks for GraphQL batching vulnerabilities.
 // It sends batched queries and analyzes the response to determine if batching is enabled.
-func (s *GraphQLScanner) checkBatchQueries(endpoint string, client *httpclient.Client, config BatchConfig) (bool, string, string) {
+func (s *GraphQLScanner) checkBatchQueries(ctx context.Context, endpoint string, client *httpclient.Client, config BatchConfig) (bool, string, string) {
 	if !config.Enabled || len(config.TestQueries) == 0 {
 		return false, "", ""
 	}
@@ -703,7 +722,7 @@ func (s *GraphQLScanner) checkBatchQueries(endpoint string, client *httpclient.C
 		}
 
 		// Create HTTP request
-		req, err := http.NewRequest("POST", endpoint, bytes.NewBuffer(jsonData))
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewBuffer(jsonData))
 		if err != nil {
 			s.logError(fmt.Errorf("error creating request: %v", err), "")
 			continue
@@ -759,7 +778,8 @@ func min(a, b int) int {
 // Scan performs a security scan on the GraphQL endpoint.
 // It orchestrates various checks including SQL injection, NoSQL injection,
 // introspection, sensitive data exposure, batching, and rate limiting.
-func (s *GraphQLScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+func (s *GraphQLScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
 	if opts.GraphQLEndpoint == "" {
 		return nil, nil // Not a GraphQL scan target
 	}
@@ -783,7 +803,7 @@ func (s *GraphQLScanner) Scan(req crawler.ParameterizedRequest, client *httpclie
 	}
 
 	// Check if the endpoint responds like a GraphQL service
-	if !s.isGraphQLEndpoint(opts.GraphQLEndpoint, client) {
+	if !s.isGraphQLEndpoint(ctx, opts.GraphQLEndpoint, client) {
 		return nil, nil // Endpoint does not appear to be a GraphQL service.
 	}
 
@@ -799,35 +819,16 @@ func (s *GraphQLScanner) Scan(req crawler.ParameterizedRequest, client *httpclie
 		DelayMs:     100,
 	}
 
-	// Try to read batching configuration from options
-	if config, ok := opts.Config["batch_testing"].(map[string]interface{}); ok {
-		// Convert configuration from map to BatchConfig struct
-		if enabled, ok := config["enabled"].(bool); ok {
-			batchConfig.Enabled = enabled
-		}
-		if maxBatchSize, ok := config["max_batch_size"].(int); ok {
-			batchConfig.MaxBatchSize = maxBatchSize
-		}
-		if testQueries, ok := config["test_queries"].([]interface{}); ok {
-			batchConfig.TestQueries = nil
-			for _, q := range testQueries {
-				if query, ok := q.(string); ok {
-					batchConfig.TestQueries = append(batchConfig.TestQueries, query)
-				}
-			}
-		}
-		if maxRequests, ok := config["max_requests"].(int); ok {
-			batchConfig.MaxRequests = maxRequests
-		}
-		if delayMs, ok := config["delay_ms"].(int); ok {
-			batchConfig.DelayMs = delayMs
-		}
-	}
+	// Apply the batching options of config.yaml (scanners.graphql.*)
+	batchConfig.Enabled = opts.BoolOption(ModuleName, "batch_testing", batchConfig.Enabled)
+	batchConfig.MaxBatchSize = opts.IntOption(ModuleName, "batch_max_size", batchConfig.MaxBatchSize)
+	batchConfig.MaxRequests = opts.IntOption(ModuleName, "batch_max_requests", batchConfig.MaxRequests)
+	batchConfig.DelayMs = opts.IntOption(ModuleName, "batch_delay_ms", batchConfig.DelayMs)
 
 	var findings []scanner.VulnerabilityResult
 
 	// Run all security checks
-	if isVuln, evidence, details := s.checkSQLInjection(opts.GraphQLEndpoint, client); isVuln {
+	if isVuln, evidence, details := s.checkSQLInjection(ctx, opts.GraphQLEndpoint, client); isVuln {
 		paramName := "query"
 		if len(req.ParamNames) > 0 {
 			paramName = req.ParamNames[0]
@@ -877,7 +878,7 @@ func (s *GraphQLScanner) Scan(req crawler.ParameterizedRequest, client *httpclie
 		})
 	}
 
-	if isVuln, respBody := s.checkIntrospection(opts.GraphQLEndpoint, client); isVuln {
+	if isVuln, respBody := s.checkIntrospection(ctx, opts.GraphQLEndpoint, client); isVuln {
 		// Get parameter from request if available
 		paramName := "query"
 		if len(req.ParamNames) > 0 {
@@ -895,13 +896,18 @@ func (s *GraphQLScanner) Scan(req crawler.ParameterizedRequest, client *httpclie
 			evidence = evidence[:200] + "... [truncated]"
 		}
 
+		details := "GraphQL Introspection is enabled. This can leak sensitive information about the API schema, including all available queries, mutations, and types."
+		if schema, err := discovery.ParseGraphQLSchema([]byte(respBody)); err == nil {
+			details += " Schema summary: " + schema.Summary() + "."
+		}
+
 		findings = append(findings, scanner.VulnerabilityResult{
 			VulnerabilityType: "GraphQL Introspection Enabled",
 			URL:               opts.GraphQLEndpoint,
 			Parameter:         paramName,
 			Location:          location,
 			Payload:           "{__schema{types{name}}}",
-			Details:           "GraphQL Introspection is enabled. This can leak sensitive information about the API schema, including all available queries, mutations, and types.",
+			Details:           details,
 			Severity:          "Medium",
 			Evidence:          evidence,
 			Remediation:       "Disable introspection in production or restrict it to authenticated users. Consider using graphql-disable-introspection for Apollo Server or disable introspection in your GraphQL server configuration.",
@@ -912,7 +918,7 @@ func (s *GraphQLScanner) Scan(req crawler.ParameterizedRequest, client *httpclie
 		if strings.Contains(respBody, "postPassword") {
 			log.Success("Found potentially sensitive field 'postPassword' in schema. Attempting to exploit.")
 			exploitQuery := `{"query":"query getBlogPost($id: Int!) { getBlogPost(id: $id) { title, postPassword } }", "variables":{"id":3}}`
-			httpReq, err := http.NewRequest("POST", opts.GraphQLEndpoint, bytes.NewBufferString(exploitQuery))
+			httpReq, err := http.NewRequestWithContext(ctx, "POST", opts.GraphQLEndpoint, bytes.NewBufferString(exploitQuery))
 			if err == nil {
 				httpReq.Header.Set("Content-Type", "application/json")
 				resp, err := client.Do(httpReq)
@@ -942,7 +948,7 @@ func (s *GraphQLScanner) Scan(req crawler.ParameterizedRequest, client *httpclie
 										// SOLVE THE LAB: Submit the found password.
 										solutionURL := strings.TrimSuffix(opts.GraphQLEndpoint, "/graphql/v1") + "/solution"
 										solutionBody := fmt.Sprintf(`{"password":"%s"}`, password)
-										solutionReq, _ := http.NewRequest("POST", solutionURL, bytes.NewBufferString(solutionBody))
+										solutionReq, _ := http.NewRequestWithContext(ctx, "POST", solutionURL, bytes.NewBufferString(solutionBody))
 										solutionReq.Header.Set("Content-Type", "application/json")
 										solutionResp, solutionErr := client.Do(solutionReq)
 										if solutionErr == nil {
@@ -986,7 +992,7 @@ func (s *GraphQLScanner) Scan(req crawler.ParameterizedRequest, client *httpclie
 		})
 	}
 
-	if isVuln, evidence, details := s.checkBatchQueries(opts.GraphQLEndpoint, client, batchConfig); isVuln {
+	if isVuln, evidence, details := s.checkBatchQueries(ctx, opts.GraphQLEndpoint, client, batchConfig); isVuln {
 		paramName := "query"
 		if len(req.ParamNames) > 0 {
 			paramName = req.ParamNames[0]
@@ -1011,7 +1017,7 @@ func (s *GraphQLScanner) Scan(req crawler.ParameterizedRequest, client *httpclie
 		})
 	}
 
-	if isVuln, evidence, details := s.checkRateLimit(opts.GraphQLEndpoint, client); isVuln {
+	if isVuln, evidence, details := s.checkRateLimit(ctx, opts.GraphQLEndpoint, client); isVuln {
 		paramName := "query"
 		if len(req.ParamNames) > 0 {
 			paramName = req.ParamNames[0]
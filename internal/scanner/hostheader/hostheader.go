@@ -0,0 +1,203 @@
+package hostheader
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// maxAgeRegex extracts max-age and s-maxage directives from Cache-Control.
+var maxAgeRegex = regexp.MustCompile(`(?i)(?:s-)?max-age\s*=\s*(\d+)`)
+
+// HostHeaderScanner implements the Scanner interface for Host header injection.
+type HostHeaderScanner struct {
+	mu          sync.Mutex
+	urlsScanned map[string]bool
+}
+
+// NewHostHeaderScanner creates a new instance of HostHeaderScanner.
+func NewHostHeaderScanner() *HostHeaderScanner {
+	return &HostHeaderScanner{urlsScanned: make(map[string]bool)}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "hostheader",
+		Order:          210,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewHostHeaderScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *HostHeaderScanner) Name() string {
+	return "Host Header Injection Scanner"
+}
+
+// reflection describes where an injected host showed up in a response.
+type reflection struct {
+	context string // "redirect", "absolute link" or "response body".
+	snippet string
+}
+
+// Scan injects a unique foreign host through the Host, X-Forwarded-Host and X-Host headers of
+// crawled GET endpoints and reports responses that reflect it in redirects, absolute links or
+// the body. Reflections in cacheable responses are reported as cache poisoning.
+func (s *HostHeaderScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	if req.Method != "GET" {
+		return nil, nil
+	}
+
+	// The Host header is handled per endpoint, not per parameter, so the query is ignored.
+	parsedURL, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, nil
+	}
+	endpoint := parsedURL.Scheme + "://" + parsedURL.Host + parsedURL.Path
+	s.mu.Lock()
+	if s.urlsScanned[endpoint] {
+		s.mu.Unlock()
+		return nil, nil
+	}
+	s.urlsScanned[endpoint] = true
+	s.mu.Unlock()
+
+	log.Debug("Starting Host header injection scan for %s", req.URL)
+
+	for _, test := range payloads.HostHeaderTests {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		canary := payloads.GenerateHostHeaderCanary()
+		httpReq, resp, body, err := sendWithInjectedHost(ctx, client, req.URL, test, canary)
+		if err != nil {
+			if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+				return nil, nil
+			}
+			continue
+		}
+		found, ok := findReflection(resp, string(body), canary)
+		if !ok {
+			continue
+		}
+
+		vulnType := "Host Header Injection"
+		severity := "Medium"
+		details := fmt.Sprintf("The injected host (%s) was reflected in the %s. Applications that build URLs from the Host header can be abused to poison links, redirects and password reset emails.", test.Description, found.context)
+		switch {
+		case IsCacheable(resp):
+			vulnType = "Host Header Injection (Cache Poisoning)"
+			severity = "High"
+			details += fmt.Sprintf(" The response is cacheable (Cache-Control: %q), so a shared cache may serve the poisoned response to other users.", resp.Header.Get("Cache-Control"))
+		case isPasswordResetEndpoint(parsedURL.Path):
+			severity = "High"
+			details += " The endpoint belongs to a password reset flow, where the injected host may be used in the emailed reset link."
+		case found.context == "response body":
+			severity = "Low"
+		}
+
+		log.Success("Host Header Injection: %s reflected in the %s of %s", test.Header, found.context, req.URL)
+		vuln := scanner.VulnerabilityResult{
+			VulnerabilityType: vulnType,
+			URL:               req.URL,
+			Parameter:         test.Header,
+			Payload:           fmt.Sprintf("%s: %s", test.Header, canary),
+			Location:          "header",
+			Details:           details,
+			Severity:          severity,
+			Evidence:          found.snippet,
+			Remediation:       "Do not build absolute URLs from the Host or X-Forwarded-* headers; use a configured canonical host name, validate the Host header against an allow-list, and ignore forwarding headers from untrusted clients.",
+			ScannerName:       s.Name(),
+		}
+		vuln.SetExchange(scanner.CaptureExchange(httpReq, resp, body))
+		return []scanner.VulnerabilityResult{vuln}, nil
+	}
+	return nil, nil
+}
+
+// sendWithInjectedHost sends a GET request to targetURL with canary injected as described by test.
+// Redirects are not followed, so a redirect to the injected host is observed instead of fetched.
+func sendWithInjectedHost(ctx context.Context, client *httpclient.Client, targetURL string, test payloads.HostHeaderTest, canary string) (*http.Request, *http.Response, []byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", targetURL, nil)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	client = client.WithoutRedirects()
+
+	var resp *http.Response
+	if strings.EqualFold(test.Header, "Host") {
+		resp, err = client.DoWithHost(httpReq, canary)
+	} else {
+		httpReq.Header.Set(test.Header, canary)
+		resp, err = client.Do(httpReq)
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return httpReq, resp, body, nil
+}
+
+// findReflection looks for canary in the Location header, then in absolute links of the body,
+// then anywhere in the body.
+func findReflection(resp *http.Response, body, canary string) (reflection, bool) {
+	if location := resp.Header.Get("Location"); strings.Contains(location, canary) {
+		return reflection{context: "redirect", snippet: "Location: " + location}, true
+	}
+	for _, prefix := range []string{"://" + canary, "//" + canary} {
+		if strings.Contains(body, prefix) {
+			return reflection{context: "absolute link", snippet: scanner.Snippet(body, prefix)}, true
+		}
+	}
+	if strings.Contains(body, canary) {
+		return reflection{context: "response body", snippet: scanner.Snippet(body, canary)}, true
+	}
+	return reflection{}, false
+}
+
+// IsCacheable reports whether a shared cache may store the response, judging by Cache-Control
+// and by headers that caches add to the responses they serve.
+func IsCacheable(resp *http.Response) bool {
+	cacheControl := strings.ToLower(resp.Header.Get("Cache-Control"))
+	if strings.Contains(cacheControl, "no-store") || strings.Contains(cacheControl, "private") || strings.Contains(cacheControl, "no-cache") {
+		return false
+	}
+	for _, match := range maxAgeRegex.FindAllStringSubmatch(cacheControl, -1) {
+		if seconds, err := strconv.Atoi(match[1]); err == nil && seconds > 0 {
+			return true
+		}
+	}
+	if strings.Contains(cacheControl, "public") {
+		return true
+	}
+	return resp.Header.Get("Age") != "" || resp.Header.Get("X-Cache") != "" || resp.Header.Get("CF-Cache-Status") != ""
+}
+
+// isPasswordResetEndpoint reports whether path looks like part of a password reset flow.
+func isPasswordResetEndpoint(path string) bool {
+	lower := strings.ToLower(path)
+	for _, keyword := range payloads.HostHeaderResetPathKeywords {
+		if strings.Contains(lower, keyword) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package hostheader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		path         string
+		wantFindings int
+		wantType     string
+		wantSeverity string
+		wantHeader   string
+	}{
+		{
+			name: "Host reflected in absolute link",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`<a href="http://` + r.Host + `/home">Home</a>`))
+			},
+			path:         "/about",
+			wantFindings: 1,
+			wantType:     "Host Header Injection",
+			wantSeverity: "Medium",
+			wantHeader:   "Host",
+		},
+		{
+			name: "X-Forwarded-Host reflected in cacheable redirect",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				host := "example.com" // Virtual host is fixed; only the proxy header is trusted.
+				if forwarded := r.Header.Get("X-Forwarded-Host"); forwarded != "" {
+					host = forwarded
+				}
+				w.Header().Set("Cache-Control", "public, max-age=300")
+				http.Redirect(w, r, "https://"+host+"/login", http.StatusFound)
+			},
+			path:         "/account",
+			wantFindings: 1,
+			wantType:     "Host Header Injection (Cache Poisoning)",
+			wantSeverity: "High",
+			wantHeader:   "X-Forwarded-Host",
+		},
+		{
+			name: "Host ignored",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`<a href="https://example.com/home">Home</a>`))
+			},
+			path: "/about",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, FollowRedirects: true})
+			req := crawler.ParameterizedRequest{Method: "GET", URL: server.URL + tt.path}
+
+			findings, err := NewHostHeaderScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			require.Len(t, findings, tt.wantFindings)
+			if tt.wantFindings == 0 {
+				return
+			}
+			assert.Equal(t, tt.wantType, findings[0].VulnerabilityType)
+			assert.Equal(t, tt.wantSeverity, findings[0].Severity)
+			assert.Equal(t, tt.wantHeader, findings[0].Parameter)
+			assert.Contains(t, findings[0].Evidence, ".invalid")
+		})
+	}
+}
@@ -6,14 +6,26 @@ import (
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"Dursgo/internal/scanner/requtil"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 )
 
+// invalidID is an object ID assumed not to exist; its response is the known-404 baseline.
+const invalidID = 999999
+
+// uuidRegex matches UUID object references.
+var uuidRegex = regexp.MustCompile(`^(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
 // IDORScanner implements the Scanner interface for Insecure Direct Object Reference (IDOR).
 type IDORScanner struct{}
 
@@ -22,187 +34,248 @@ func NewIDORScanner() *IDORScanner {
 	return &IDORScanner{}
 }
 
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "idor",
+		Order:          70,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewIDORScanner() },
+	})
+}
+
 // Name returns the scanner's name.
 func (s *IDORScanner) Name() string {
 	return "Insecure Direct Object Reference (IDOR) Scanner"
 }
 
-// isUserResourcePath checks if a URL path likely points to a user-specific resource.
-func isUserResourcePath(path string) bool {
-	userKeywords := []string{"user", "profile", "account", "member", "customer"}
-	lowerPath := strings.ToLower(path)
-	for _, keyword := range userKeywords {
-		if strings.Contains(lowerPath, keyword) {
-			return true
+// objectRef is a request component holding an object reference.
+type objectRef struct {
+	name     string // Parameter name, or "URL Path Segment #N".
+	location string // "query", "body", "graphql" (a GraphQL variable) or "path".
+	value    string
+	segment  int // Index of the path segment, for location "path".
+}
+
+// response is the part of an HTTP exchange the access checks compare.
+type response struct {
+	status   int
+	body     string
+	exchange scanner.Exchange
+	noData   bool // GraphQL response without data (errors, or null for every field).
+}
+
+// replaySession is a session the original request is replayed with.
+type replaySession struct {
+	label  string // Who the session belongs to, for logs and finding details.
+	client *httpclient.Client
+}
+
+// accessible reports whether the response delivered an object rather than an error page.
+func (r response) accessible() bool {
+	if r.status < 200 || r.status >= 300 || r.noData {
+		return false
+	}
+	lowerBody := strings.ToLower(r.body)
+	for _, keyword := range payloads.IDORNegativeKeywords {
+		if strings.Contains(lowerBody, keyword) {
+			return false
 		}
 	}
-	return false
+	return true
 }
 
-// testPathForIDOR tests for IDOR vulnerabilities by manipulating numeric IDs in URL paths.
-func (s *IDORScanner) testPathForIDOR(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) []scanner.VulnerabilityResult {
-	var findings []scanner.VulnerabilityResult
-	parsedURL, err := url.Parse(req.URL)
-	if err != nil || !isUserResourcePath(parsedURL.Path) {
-		return nil
+// Scan performs the IDOR scan on requests that reference objects through numeric or UUID
+// values. The object is first fetched with the scan's session (user A), then the request is
+// replayed without credentials and with the second session (user B); either one receiving the
+// same object is reported. Numeric IDs are also incremented and decremented under user A and
+// compared against a known-404 baseline.
+func (s *IDORScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	refs := objectReferences(req, opts)
+	if len(refs) == 0 {
+		return nil, nil
 	}
+	log.Debug("Starting IDOR scan for %s %s (%d object reference(s))...", req.Method, req.URL, len(refs))
 
-	pathSegments := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
-	for i, segment := range pathSegments {
-		originalID, err := strconv.Atoi(segment)
-		if err != nil {
-			continue // Not a numeric segment.
-		}
-
-		log.Debug("IDOR (Path): Found numeric segment '%d' in path %s. Testing...", originalID, parsedURL.Path)
+	original, err := send(ctx, client, req, nil, "")
+	if err != nil || !original.accessible() {
+		log.Debug("IDOR: Original request to %s did not return an object, skipping.", req.URL)
+		return nil, nil
+	}
 
-		const invalidID = 999999
-		invalidPathSegments := make([]string, len(pathSegments))
-		copy(invalidPathSegments, pathSegments)
-		invalidPathSegments[i] = strconv.Itoa(invalidID)
-		invalidURL := *parsedURL
-		invalidURL.Path = "/" + strings.Join(invalidPathSegments, "/")
+	// The Levenshtein mode is used regardless of the configured mode: the same object rendered
+	// for another session must match character by character, not just structurally.
+	cmp := compare.New(opts, log, "IDOR")
+	cmp.Mode = compare.Levenshtein
 
-		_, baselineErrorBody, errBase := sendRequest(req.Method, &invalidURL, nil, client, log)
-		if errBase != nil {
-			continue
+	var findings []scanner.VulnerabilityResult
+	if finding, ok := s.testCrossSession(ctx, req, client, opts, cmp, original, refs, log); ok {
+		findings = append(findings, finding)
+	}
+	for _, ref := range refs {
+		if ctx.Err() != nil {
+			return findings, ctx.Err()
 		}
+		finding, ok, err := s.testAdjacentIDs(ctx, req, client, opts, cmp, original, ref, log)
+		if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			break
+		}
+		if ok {
+			findings = append(findings, finding)
+			break
+		}
+	}
+	return findings, nil
+}
 
-		idsToTest := []int{1, 2, 3}
-		for _, testID := range idsToTest {
-			if opts.UserID != 0 && (testID == opts.UserID || testID == originalID) {
-				continue
-			}
-
-			testPathSegments := make([]string, len(pathSegments))
-			copy(testPathSegments, pathSegments)
-			testPathSegments[i] = strconv.Itoa(testID)
-			testURL := *parsedURL
-			testURL.Path = "/" + strings.Join(testPathSegments, "/")
-
-			statusCodeTest, responseBodyTest, errTest := sendRequest(req.Method, &testURL, nil, client, log)
+// testCrossSession replays the original request without credentials and with user B's session.
+// An unauthenticated replay is only meaningful when the scan itself is authenticated.
+func (s *IDORScanner) testCrossSession(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, opts scanner.ScannerOptions, cmp compare.Comparator, original response, refs []objectRef, log *logger.Logger) (scanner.VulnerabilityResult, bool) {
+	if !client.Authenticated() {
+		return scanner.VulnerabilityResult{}, false
+	}
+	sessions := []replaySession{
+		{"an unauthenticated request (cookies and authentication headers stripped)", client.WithSession(req.URL, "", nil)},
+	}
+	if opts.SecondSessionCookie != "" || len(opts.SecondSessionHeaders) > 0 {
+		sessions = append(sessions, replaySession{"session B (the second user)", client.WithSession(req.URL, opts.SecondSessionCookie, opts.SecondSessionHeaders)})
+	}
 
-			if errTest == nil && statusCodeTest == http.StatusOK && responseBodyTest != baselineErrorBody {
-				var details string
-				if opts.UserID != 0 {
-					details = fmt.Sprintf("As authenticated user %d, accessed user-resource '%s'. The response was valid and different from the error page baseline, indicating access to another user's data.", opts.UserID, testURL.String())
-				} else {
-					details = fmt.Sprintf("As an unauthenticated user, accessed user-resource '%s'. The response was valid and different from the error page baseline, indicating access to sensitive data.", testURL.String())
-				}
-				findings = append(findings, scanner.VulnerabilityResult{
-					VulnerabilityType: "Insecure Direct Object Reference (IDOR)",
-					URL:               testURL.String(),
-					Parameter:         fmt.Sprintf("URL Path Segment #%d", i+1),
-					Payload:           strconv.Itoa(testID),
-					Location:          "path",
-					Details:           details,
-					Severity:          "High",
-					Evidence:          "Response for a valid ID was different from the response for a known-invalid ID.",
-					Remediation:       "Ensure that server-side authorization checks verify that the logged-in user has permission to access the requested resource ID.",
-					ScannerName:       s.Name(),
-				})
-				return findings
-			}
+	for _, session := range sessions {
+		replayed, err := send(ctx, session.client, req, nil, "")
+		if err != nil || !replayed.accessible() || cmp.IsDifferent(original.body, replayed.body) {
+			continue
+		}
+		similarity := cmp.Similarity(original.body, replayed.body)
+		log.Success("IDOR: %s retrieved user A's object at %s", session.label, req.URL)
+		vuln := scanner.VulnerabilityResult{
+			VulnerabilityType: "Insecure Direct Object Reference (IDOR)",
+			URL:               req.URL,
+			Parameter:         refNames(refs),
+			Location:          refs[0].location,
+			Details:           fmt.Sprintf("User A's object was retrieved by %s: replaying the request user A made returned the same object (similarity %.3f) instead of an access error.", session.label, similarity),
+			Severity:          "High",
+			Evidence:          fmt.Sprintf("User A: status %d, %d bytes; replay: status %d, %d bytes; similarity %.3f.", original.status, len(original.body), replayed.status, len(replayed.body), similarity),
+			Remediation:       "Ensure that server-side authorization checks verify that the logged-in user has permission to access the requested resource ID.",
+			ScannerName:       s.Name(),
 		}
+		vuln.SetExchange(replayed.exchange)
+		return vuln, true
 	}
-	return findings
+	return scanner.VulnerabilityResult{}, false
 }
 
-// testParamsForIDOR tests for IDOR vulnerabilities in URL and body parameters.
-func (s *IDORScanner) testParamsForIDOR(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) []scanner.VulnerabilityResult {
-	var findings []scanner.VulnerabilityResult
-	originalParams, err := getOriginalParams(req)
+// testAdjacentIDs requests the neighbouring IDs of a numeric reference under user A's session.
+// A neighbour is reported when it returns an object that differs both from the known-404
+// baseline and from user A's own object.
+func (s *IDORScanner) testAdjacentIDs(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, opts scanner.ScannerOptions, cmp compare.Comparator, original response, ref objectRef, log *logger.Logger) (scanner.VulnerabilityResult, bool, error) {
+	originalID, err := strconv.Atoi(ref.value)
 	if err != nil {
-		return nil
+		return scanner.VulnerabilityResult{}, false, nil // UUIDs cannot be enumerated.
 	}
 
-	for _, paramName := range req.ParamNames {
-		if !isCommonIDParam(paramName) {
+	notFound, err := send(ctx, client, req, &ref, strconv.Itoa(invalidID))
+	if err != nil {
+		return scanner.VulnerabilityResult{}, false, err
+	}
+
+	for _, testID := range []int{originalID + 1, originalID - 1} {
+		if testID <= 0 || (opts.UserID != 0 && testID == opts.UserID) {
 			continue
 		}
-
-		originalValueStr := originalParams.Get(paramName)
-		originalID, err := strconv.Atoi(originalValueStr)
+		tampered, err := send(ctx, client, req, &ref, strconv.Itoa(testID))
 		if err != nil {
-			originalID = 0
+			return scanner.VulnerabilityResult{}, false, err
+		}
+		if !tampered.accessible() || !cmp.IsDifferent(notFound.body, tampered.body) {
+			continue
+		}
+		if compare.Normalize(tampered.body) == compare.Normalize(original.body) {
+			log.Debug("IDOR: '%s=%d' returned user A's own object; the reference is ignored.", ref.name, testID)
+			continue
 		}
 
-		log.Debug("IDOR (Param): Found potential IDOR parameter '%s'. Testing...", paramName)
+		session := "the scan's session (user A)"
+		if opts.UserID != 0 {
+			session = fmt.Sprintf("the scan's session (user A, ID %d)", opts.UserID)
+		} else if !client.Authenticated() {
+			session = "an unauthenticated session"
+		}
+		log.Success("IDOR: %s retrieved object %d via '%s' at %s", session, testID, ref.name, req.URL)
+		vuln := scanner.VulnerabilityResult{
+			VulnerabilityType: "Insecure Direct Object Reference (IDOR)",
+			URL:               req.URL,
+			Parameter:         ref.name,
+			Payload:           strconv.Itoa(testID),
+			Location:          ref.location,
+			Details:           fmt.Sprintf("Using %s, changing '%s' from %d to %d retrieved another user's object: the response was valid and differed from both user A's object and the known-404 baseline (ID %d).", session, ref.name, originalID, testID, invalidID),
+			Severity:          "High",
+			Evidence:          fmt.Sprintf("ID %d: status %d, %d bytes; known-404 ID %d: status %d, %d bytes; similarity to baseline %.3f.", testID, tampered.status, len(tampered.body), invalidID, notFound.status, len(notFound.body), cmp.Similarity(notFound.body, tampered.body)),
+			Remediation:       "Ensure that server-side authorization checks verify that the logged-in user has permission to access the requested resource ID.",
+			ScannerName:       s.Name(),
+		}
+		vuln.SetExchange(tampered.exchange)
+		return vuln, true, nil
+	}
+	return scanner.VulnerabilityResult{}, false, nil
+}
 
-		const invalidID = 999999
-		invalidParams := copyParams(originalParams)
-		invalidParams.Set(paramName, strconv.Itoa(invalidID))
-		parsedURL, _ := url.Parse(req.URL)
-		_, baselineErrorBody, errBase := sendRequest(req.Method, parsedURL, invalidParams, client, log)
-		if errBase != nil {
-			continue
+// ---- Helper Functions ----
+
+// objectReferences returns the path segments, query parameters and form parameters whose values
+// are numeric IDs or UUIDs, plus parameters with well-known ID names. Parameters excluded by a
+// skip rule are left out.
+func objectReferences(req crawler.ParameterizedRequest, opts scanner.ScannerOptions) []objectRef {
+	parsedURL, err := url.Parse(req.URL)
+	if err != nil {
+		return nil
+	}
+	var refs []objectRef
+	for i, segment := range strings.Split(strings.Trim(parsedURL.Path, "/"), "/") {
+		if isObjectID(segment) {
+			refs = append(refs, objectRef{name: fmt.Sprintf("URL Path Segment #%d", i+1), location: "path", value: segment, segment: i})
 		}
+	}
 
-		idsToTest := []int{1, 2, 3}
-		for _, testID := range idsToTest {
-			if opts.UserID != 0 && (testID == opts.UserID || testID == originalID) {
+	addParams := func(values url.Values, location string) {
+		for name, vals := range values {
+			if len(vals) == 0 || opts.SkipParam("idor", name) {
 				continue
 			}
-
-			testParams := copyParams(originalParams)
-			testParams.Set(paramName, strconv.Itoa(testID))
-			statusCodeTest, responseBodyTest, errTest := sendRequest(req.Method, parsedURL, testParams, client, log)
-
-			if errTest == nil && statusCodeTest == http.StatusOK && responseBodyTest != baselineErrorBody {
-				// Final False Positive Check: Ensure the response doesn't contain common error messages.
-				isFalsePositive := false
-				for _, keyword := range payloads.IDORNegativeKeywords {
-					if strings.Contains(strings.ToLower(responseBodyTest), keyword) {
-						log.Debug("IDOR (Param): Skipping potential false positive for '%s=%d' due to negative keyword: %s", paramName, testID, keyword)
-						isFalsePositive = true
-						break
-					}
-				}
-				if isFalsePositive {
-					continue
-				}
-
-				var details string
-				if opts.UserID != 0 {
-					details = fmt.Sprintf("As authenticated user %d, accessed a resource via parameter '%s=%d'. The response was valid and different from the error page baseline, indicating access to another user's data.", opts.UserID, paramName, testID)
-				} else {
-					details = fmt.Sprintf("As an unauthenticated user, accessed a resource via parameter '%s=%d'. The response was valid and different from the error page baseline, indicating access to sensitive data.", paramName, testID)
+			if isObjectID(vals[0]) || (vals[0] != "" && isCommonIDParam(name)) {
+				refs = append(refs, objectRef{name: name, location: location, value: vals[0]})
+			}
+		}
+	}
+	addParams(parsedURL.Query(), "query")
+	if req.Method != "GET" && !req.IsJSON() && !req.IsXML() {
+		if form, err := url.ParseQuery(req.FormPostData); err == nil {
+			addParams(form, "body")
+		}
+	}
+	if req.IsGraphQL() {
+		if envelope, err := decodeGraphQLEnvelope(req.RawBody); err == nil {
+			variables := url.Values{}
+			for name, value := range graphQLVariables(envelope) {
+				switch value.(type) {
+				case string, json.Number:
+					variables.Set(name, fmt.Sprint(value))
 				}
-
-				findings = append(findings, scanner.VulnerabilityResult{
-					VulnerabilityType: "Insecure Direct Object Reference (IDOR)",
-					URL:               req.URL,
-					Parameter:         paramName,
-					Payload:           strconv.Itoa(testID),
-					Location:          getParamLocation(req),
-					Details:           details,
-					Severity:          "High",
-					Evidence:          "Response for a valid ID was different from the response for a known-invalid ID.",
-					Remediation:       "Ensure that server-side authorization checks verify that the logged-in user has permission to access the requested resource ID.",
-					ScannerName:       s.Name(),
-				})
-				return findings
 			}
+			addParams(variables, "graphql")
 		}
 	}
-	return findings
+	return refs
 }
 
-// Scan performs the IDOR scan.
-func (s *IDORScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
-	var findings []scanner.VulnerabilityResult
-	log.Debug("Starting IDOR scan for %s %s...", req.Method, req.URL)
-
-	pathFindings := s.testPathForIDOR(req, client, log, opts)
-	findings = append(findings, pathFindings...)
-
-	paramFindings := s.testParamsForIDOR(req, client, log, opts)
-	findings = append(findings, paramFindings...)
-
-	return findings, nil
+// isObjectID reports whether value looks like a numeric ID or a UUID.
+func isObjectID(value string) bool {
+	if _, err := strconv.Atoi(value); err == nil {
+		return true
+	}
+	return uuidRegex.MatchString(value)
 }
 
-// ---- Helper Functions ----
 func isCommonIDParam(p string) bool {
 	for _, name := range payloads.CommonIDParameterNames {
 		if strings.ToLower(p) == name {
@@ -212,66 +285,125 @@ func isCommonIDParam(p string) bool {
 	return false
 }
 
-func getOriginalParams(req crawler.ParameterizedRequest) (url.Values, error) {
-	if req.Method == "GET" {
-		parsedURL, err := url.Parse(req.URL)
+// refNames joins the names of the object references for the finding's Parameter field.
+func refNames(refs []objectRef) string {
+	names := make([]string, len(refs))
+	for i, ref := range refs {
+		names[i] = ref.name
+	}
+	return strings.Join(names, ", ")
+}
+
+// send issues the request with the object reference ref set to value; a nil ref sends the
+// original request.
+func send(ctx context.Context, client *httpclient.Client, req crawler.ParameterizedRequest, ref *objectRef, value string) (response, error) {
+	httpRequest, err := newRequest(ctx, req, ref, value)
+	if err != nil {
+		return response{}, err
+	}
+	resp, err := client.Do(httpRequest)
+	if err != nil {
+		return response{}, err
+	}
+	defer resp.Body.Close()
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return response{}, err
+	}
+	return response{
+		status:   resp.StatusCode,
+		body:     string(bodyBytes),
+		exchange: scanner.CaptureExchange(httpRequest, resp, bodyBytes),
+		noData:   req.IsGraphQL() && !hasGraphQLData(bodyBytes),
+	}, nil
+}
+
+// newRequest builds req with ref set to value. Query and path references are written into the
+// built URL, since requtil keeps the query of non-GET requests and knows no path segments.
+func newRequest(ctx context.Context, req crawler.ParameterizedRequest, ref *objectRef, value string) (*http.Request, error) {
+	if ref != nil && ref.location == "graphql" {
+		body, err := setGraphQLVariable(req.RawBody, ref.name, value)
 		if err != nil {
 			return nil, err
 		}
-		return parsedURL.Query(), nil
+		req.RawBody = body
+	}
+	params, err := requtil.Params(req)
+	if err != nil {
+		return nil, err
 	}
-	return url.ParseQuery(req.FormPostData)
+	if ref != nil && ref.location == "body" {
+		params = requtil.Copy(params)
+		params.Set(ref.name, value)
+	}
+	httpRequest, err := requtil.New(ctx, req, params)
+	if err != nil || ref == nil {
+		return httpRequest, err
+	}
+
+	switch ref.location {
+	case "path":
+		segments := strings.Split(strings.Trim(httpRequest.URL.Path, "/"), "/")
+		segments[ref.segment] = value
+		httpRequest.URL.Path = "/" + strings.Join(segments, "/")
+		httpRequest.URL.RawPath = ""
+	case "query":
+		query := httpRequest.URL.Query()
+		query.Set(ref.name, value)
+		httpRequest.URL.RawQuery = query.Encode()
+	}
+	return httpRequest, nil
 }
 
-func copyParams(original url.Values) url.Values {
-	newParams := url.Values{}
-	for k, v := range original {
-		newParams[k] = v
+// setGraphQLVariable returns the GraphQL body with the variable name set to value, as a number
+// if it was one to keep Int variables valid for the schema.
+func setGraphQLVariable(body, name, value string) (string, error) {
+	path := "variables." + name
+	values, err := requtil.JSONValues(body)
+	if err != nil {
+		return "", err
 	}
-	return newParams
+	var variable interface{} = value
+	if _, isNumber := values[path].(json.Number); isNumber {
+		variable = json.Number(value)
+	}
+	return requtil.SetJSONPaths(body, map[string]interface{}{path: variable})
 }
 
-func sendRequest(method string, baseURL *url.URL, params url.Values, client *httpclient.Client, log *logger.Logger) (int, string, error) {
-	var targetURL string
-	var reqBody io.Reader
-	if method == "GET" {
-		freshURL := *baseURL
-		if params != nil {
-			freshURL.RawQuery = params.Encode()
-		}
-		targetURL = freshURL.String()
-	} else {
-		targetURL = baseURL.String()
-		if params != nil {
-			reqBody = strings.NewReader(params.Encode())
-		}
+// decodeGraphQLEnvelope decodes the JSON body of a GraphQL request, keeping numbers as json.Number.
+func decodeGraphQLEnvelope(body string) (map[string]interface{}, error) {
+	var envelope map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&envelope); err != nil {
+		return nil, err
 	}
-	return fetchAndRead(client, method, targetURL, reqBody, log)
+	return envelope, nil
 }
 
-func getParamLocation(req crawler.ParameterizedRequest) string {
-	return strings.Join(req.ParamLocations, ",")
+// graphQLVariables returns the variables object of a GraphQL envelope, adding an empty one if needed.
+func graphQLVariables(envelope map[string]interface{}) map[string]interface{} {
+	variables, ok := envelope["variables"].(map[string]interface{})
+	if !ok {
+		variables = make(map[string]interface{})
+		envelope["variables"] = variables
+	}
+	return variables
 }
 
-func fetchAndRead(client *httpclient.Client, method, targetURL string, reqBody io.Reader, log *logger.Logger) (int, string, error) {
-	httpRequest, err := http.NewRequest(method, targetURL, reqBody)
-	if err != nil {
-		return 0, "", err
+// hasGraphQLData reports whether a GraphQL response returned a value for at least one field.
+// Servers answer 200 for missing or forbidden objects too, with null data and an error list.
+func hasGraphQLData(body []byte) bool {
+	var result struct {
+		Data map[string]interface{} `json:"data"`
 	}
-	if method == "POST" {
-		httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := json.Unmarshal(body, &result); err != nil {
+		return false
 	}
-	resp, err := client.Do(httpRequest)
-	if err != nil {
-		if resp != nil && resp.Body != nil {
-			resp.Body.Close()
+	for _, value := range result.Data {
+		if value != nil {
+			return true
 		}
-		return 0, "", err
 	}
-	defer resp.Body.Close()
-	bodyBytes, readErr := io.ReadAll(resp.Body)
-	if readErr != nil {
-		return resp.StatusCode, "", readErr
-	}
-	return resp.StatusCode, string(bodyBytes), nil
+	return false
 }
@@ -0,0 +1,144 @@
+package idor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// orderOwners maps order IDs to the user owning them; user A is alice, user B is bob.
+var orderOwners = map[int]string{4: "bob", 5: "alice", 6: "carol"}
+
+// orderServer serves /api/orders/{id}, optionally requiring a session and ownership.
+func orderServer(requireAuth, checkOwner bool) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var user string
+		if cookie, err := r.Cookie("session"); err == nil {
+			user = cookie.Value
+		}
+		if requireAuth && user == "" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte("Login required"))
+			return
+		}
+		id, _ := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/orders/"))
+		owner, ok := orderOwners[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("Order not found"))
+			return
+		}
+		if checkOwner && owner != user {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("Access denied"))
+			return
+		}
+		w.Write([]byte("<h1>Order #" + strconv.Itoa(id) + "</h1><p>Customer: " + owner + "</p><p>Shipping to " + owner + "'s home address</p>"))
+	}))
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name        string
+		requireAuth bool
+		checkOwner  bool
+		wantDetails []string
+	}{
+		{
+			name:        "Ownership not checked",
+			requireAuth: true,
+			wantDetails: []string{"retrieved by session B (the second user)", "changing 'URL Path Segment #3' from 5 to 6"},
+		},
+		{
+			name:        "No authentication required",
+			wantDetails: []string{"retrieved by an unauthenticated request", "changing 'URL Path Segment #3' from 5 to 6"},
+		},
+		{
+			name:        "Ownership checked",
+			requireAuth: true,
+			checkOwner:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := orderServer(tt.requireAuth, tt.checkOwner)
+			defer server.Close()
+
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, AuthCookie: "session=alice"})
+			req := crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/api/orders/5"}
+			opts := scanner.ScannerOptions{SecondSessionCookie: "session=bob"}
+
+			findings, err := NewIDORScanner().Scan(context.Background(), req, client, log, opts)
+			require.NoError(t, err)
+			require.Len(t, findings, len(tt.wantDetails))
+			for i, want := range tt.wantDetails {
+				assert.Contains(t, findings[i].Details, want)
+				assert.NotEmpty(t, findings[i].RawRequest)
+			}
+		})
+	}
+}
+
+func TestObjectReferences(t *testing.T) {
+	req := crawler.ParameterizedRequest{
+		Method:       "POST",
+		URL:          "http://example.com/users/42/docs?doc=3f2504e0-4f89-11d3-9a0c-0305e82c3301&lang=en",
+		FormPostData: "invoice_id=INV-7&csrf_token=123&note=hi",
+	}
+
+	var names []string
+	opts := scanner.ScannerOptions{SkipRules: scanner.NewSkipRules(scanner.DefaultSkipRules())}
+	for _, ref := range objectReferences(req, opts) {
+		names = append(names, ref.location+":"+ref.name)
+	}
+	assert.ElementsMatch(t, []string{"path:URL Path Segment #2", "query:doc", "body:invoice_id"}, names)
+}
+
+func TestScanGraphQLVariable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Variables struct {
+				ID int `json:"id"`
+			} `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.Write([]byte(`{"errors": [{"message": "Variable \"$id\" got invalid value"}]}`))
+			return
+		}
+		owner, ok := orderOwners[body.Variables.ID]
+		if !ok {
+			w.Write([]byte(`{"data": {"order": null}, "errors": [{"message": "No order with this ID"}]}`))
+			return
+		}
+		fmt.Fprintf(w, `{"data": {"order": {"id": %d, "customer": %q, "address": "%s's home address"}}}`, body.Variables.ID, owner, owner)
+	}))
+	defer server.Close()
+
+	req, err := crawler.NewGraphQLRequest(server.URL+"/graphql", "query DursgoLookup($id: Int!) { order(id: $id) { id customer address } }", map[string]interface{}{"id": 5})
+	require.NoError(t, err)
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+
+	findings, err := NewIDORScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "id", findings[0].Parameter)
+	assert.Equal(t, "graphql", findings[0].Location)
+	assert.Contains(t, findings[0].Details, "changing 'id' from 5 to 6")
+	assert.Contains(t, findings[0].RawRequest, `"variables":{"id":6}`)
+}
@@ -0,0 +1,168 @@
+package scanner
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/logger"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// InertParam is a parameter the inert-parameter pre-flight found to have no effect on the
+// response of its request: removing it and sending a random value both returned the baseline.
+type InertParam struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Param  string `json:"param"`
+}
+
+// InertSummary is the outcome of the inert-parameter pre-flight (-skip-inert-params).
+type InertSummary struct {
+	Checked int          `json:"checked"`         // Parameters probed.
+	Inert   []InertParam `json:"inert,omitempty"` // Parameters found inert, sorted.
+	// Skipped counts the parameter tests left out because their parameter is inert; modules
+	// with Registration.TestsInertParams test them anyway and are listed in TestedBy.
+	Skipped  int64    `json:"skipped"`
+	TestedBy []string `json:"tested_by,omitempty"`
+}
+
+// InertParams holds the parameters found inert by the pre-flight and counts the tests skipped
+// because of them. Manager.RunScans leaves inert parameters out of the requests it hands to
+// modules, except to modules whose sinks may be blind (Registration.TestsInertParams); those
+// check InertParams themselves. A nil *InertParams marks nothing inert.
+type InertParams struct {
+	mu       sync.Mutex
+	checked  int
+	inert    map[string]map[string]bool // Inert parameter names, keyed by inertKey.
+	skipped  atomic.Int64
+	testedBy map[string]bool
+}
+
+// NewInertParams creates an empty InertParams.
+func NewInertParams() *InertParams {
+	return &InertParams{inert: make(map[string]map[string]bool), testedBy: make(map[string]bool)}
+}
+
+// inertKey identifies the request a parameter belongs to.
+func inertKey(req crawler.ParameterizedRequest) string {
+	return req.Method + " " + req.URL
+}
+
+// AddChecked records that n more parameters were probed.
+func (p *InertParams) AddChecked(n int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.checked += n
+}
+
+// Mark records the parameter name of req as inert.
+func (p *InertParams) Mark(req crawler.ParameterizedRequest, name string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	key := inertKey(req)
+	if p.inert[key] == nil {
+		p.inert[key] = make(map[string]bool)
+	}
+	p.inert[key][name] = true
+}
+
+// IsInert reports whether the parameter name of req was found inert.
+func (p *InertParams) IsInert(req crawler.ParameterizedRequest, name string) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.inert[inertKey(req)][name]
+}
+
+// Skip reports whether the parameter name of req is inert, counting a skipped test if it is.
+// Modules testing inert parameters call it for the checks they leave out.
+func (p *InertParams) Skip(req crawler.ParameterizedRequest, name string) bool {
+	if !p.IsInert(req, name) {
+		return false
+	}
+	p.skipped.Add(1)
+	return true
+}
+
+// filter returns req without its inert parameters for a module that skips them, counting the
+// tests skipped. Modules testing inert parameters are recorded and get req unchanged.
+func (p *InertParams) filter(req crawler.ParameterizedRequest, module string, testsInert bool) crawler.ParameterizedRequest {
+	if p == nil || len(req.ParamNames) == 0 {
+		return req
+	}
+	p.mu.Lock()
+	inert := p.inert[inertKey(req)]
+	if testsInert && len(inert) > 0 {
+		p.testedBy[module] = true
+	}
+	p.mu.Unlock()
+	if testsInert || len(inert) == 0 {
+		return req
+	}
+
+	// ParamLocations either holds one location per parameter or one for all of them.
+	parallel := len(req.ParamLocations) == len(req.ParamNames)
+	names := make([]string, 0, len(req.ParamNames))
+	var locations []string
+	for i, name := range req.ParamNames {
+		if inert[name] {
+			p.skipped.Add(1)
+			continue
+		}
+		names = append(names, name)
+		if parallel {
+			locations = append(locations, req.ParamLocations[i])
+		}
+	}
+	req.ParamNames = names
+	if parallel {
+		req.ParamLocations = locations
+	}
+	return req
+}
+
+// Summary returns the parameters found inert and the tests skipped so far.
+func (p *InertParams) Summary() InertSummary {
+	if p == nil {
+		return InertSummary{}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	summary := InertSummary{Checked: p.checked, Skipped: p.skipped.Load()}
+	for key, names := range p.inert {
+		method, rawURL, _ := strings.Cut(key, " ")
+		for name := range names {
+			summary.Inert = append(summary.Inert, InertParam{Method: method, URL: rawURL, Param: name})
+		}
+	}
+	sort.Slice(summary.Inert, func(i, j int) bool {
+		a, b := summary.Inert[i], summary.Inert[j]
+		if a.URL != b.URL {
+			return a.URL < b.URL
+		}
+		if a.Method != b.Method {
+			return a.Method < b.Method
+		}
+		return a.Param < b.Param
+	})
+	for module := range p.testedBy {
+		summary.TestedBy = append(summary.TestedBy, module)
+	}
+	sort.Strings(summary.TestedBy)
+	return summary
+}
+
+// LogSummary logs the number of inert parameters and of the tests skipped because of them.
+func (p *InertParams) LogSummary(log *logger.Logger) {
+	summary := p.Summary()
+	if len(summary.Inert) == 0 {
+		return
+	}
+	log.Info("ScannerManager: Skipped %d parameter test(s) of %d inert parameter(s) (-skip-inert-params).", summary.Skipped, len(summary.Inert))
+	if len(summary.TestedBy) > 0 {
+		log.Info("ScannerManager: Inert parameters were still tested by modules with blind sinks: %v", summary.TestedBy)
+	}
+}
@@ -4,9 +4,75 @@ import (
 	"Dursgo/internal/crawler"
 	"Dursgo/internal/httpclient"
 	"Dursgo/internal/logger"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+	"time"
 )
 
+// Scanner is implemented by every vulnerability scanner.
+// Scan must stop sending requests once ctx is cancelled and return the findings collected so
+// far, together with ctx.Err().
 type Scanner interface {
 	Name() string
-	Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts ScannerOptions) ([]VulnerabilityResult, error)
-}
\ No newline at end of file
+	Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts ScannerOptions) ([]VulnerabilityResult, error)
+}
+
+// PassiveScanner is implemented by scanners that analyze the responses fetched while crawling
+// instead of sending requests of their own. It is registered with Manager.RegisterPassiveScanner.
+type PassiveScanner interface {
+	Name() string
+	ScanResponses(responses []crawler.CrawledResponse, log *logger.Logger) []VulnerabilityResult
+}
+
+// ProgressTracker records the scanner/request pairs a scan has completed, so that an interrupted
+// scan can be resumed without testing them again. Pairs are identified by TestKey.
+type ProgressTracker interface {
+	IsTested(key string) bool
+	MarkTested(key string, findings []VulnerabilityResult)
+}
+
+// FindingSink receives findings as soon as the scanner that found them returns, e.g. to stream
+// them to a file while the scan is running. Emit may be called from several goroutines.
+type FindingSink interface {
+	Emit(findings []VulnerabilityResult)
+}
+
+// StatusReporter follows the work of a scan, e.g. to render a progress line. Work is counted in
+// items: one per parameter of each scanner/request pair, or one for a pair without parameters.
+// Its methods may be called from several goroutines.
+type StatusReporter interface {
+	AddWork(items int)
+	CompleteWork(items int)
+	AddFindings(n int)
+}
+
+// ScanObserver is told about each test of a scanner module: a scanner/request pair, or a pass of
+// a passive scanner over the crawled responses. It may be called from several goroutines.
+type ScanObserver interface {
+	ObserveScan(module string, elapsed time.Duration, findings int)
+}
+
+// FindingSinks passes findings to each of several sinks.
+type FindingSinks []FindingSink
+
+// Emit passes findings to every sink, in order.
+func (s FindingSinks) Emit(findings []VulnerabilityResult) {
+	for _, sink := range s {
+		sink.Emit(findings)
+	}
+}
+
+// TestKey identifies the test of req by the named scanner for a ProgressTracker.
+func TestKey(scannerName string, req crawler.ParameterizedRequest) string {
+	names := append([]string(nil), req.ParamNames...)
+	sort.Strings(names)
+	hash := sha256.New()
+	for _, part := range []string{strings.Join(names, ","), strings.Join(req.ParamLocations, ","), req.ContentType, req.FormPostData, req.RawBody} {
+		hash.Write([]byte(part))
+		hash.Write([]byte{0})
+	}
+	return scannerName + " " + req.Method + " " + req.URL + " " + hex.EncodeToString(hash.Sum(nil))[:16]
+}
@@ -0,0 +1,483 @@
+// Package jwt analyzes the JSON Web Tokens the scan authenticates with, in the Authorization
+// header or in cookies, and tests whether the server verifies them.
+package jwt
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"Dursgo/internal/scanner/requtil"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ModuleName selects the scanner (-s) and holds its options in config.yaml.
+const ModuleName = "jwt"
+
+// Locations of the tokens.
+const (
+	locationHeader = "header"
+	locationCookie = "cookie"
+)
+
+// JWTScanner implements the Scanner interface for JSON Web Token weaknesses.
+type JWTScanner struct {
+	mu     sync.Mutex
+	tokens map[string]*tokenState // By token.
+}
+
+// tokenState is what the scanner knows of a token seen during the scan.
+type tokenState struct {
+	attempts int    // Crawled requests replayed to find one whose response depends on the token.
+	running  bool   // A request is being replayed; requests seen meanwhile are not.
+	tested   bool   // The active tests ran against such a request.
+	secret   string // HMAC secret found in the wordlist; "" if none.
+}
+
+// NewJWTScanner creates a new instance of JWTScanner.
+func NewJWTScanner() *JWTScanner {
+	return &JWTScanner{tokens: make(map[string]*tokenState)}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           ModuleName,
+		Order:          225,
+		DefaultEnabled: true,
+		Options: []scanner.OptionSpec{
+			{Name: "replay_attempts", Type: scanner.OptionInt, Default: 5, Description: "Crawled GET requests replayed per token to find one whose response depends on it, before the active tests give up"},
+		},
+		New: func(scanner.Env) scanner.Scanner { return NewJWTScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *JWTScanner) Name() string {
+	return "JWT Scanner"
+}
+
+// token is a JWT sent with the requests of the scan.
+type token struct {
+	raw       string
+	location  string // locationHeader or locationCookie.
+	name      string // Header or cookie name.
+	prefix    string // Text before the token in the header value, e.g. "Bearer ".
+	segments  []string
+	header    map[string]interface{}
+	claims    map[string]interface{}
+	signature []byte
+}
+
+// parseToken decodes the header and claims of a JWT.
+func parseToken(raw string) (token, bool) {
+	segments := strings.Split(raw, ".")
+	if len(segments) != 3 {
+		return token{}, false
+	}
+	tok := token{raw: raw, segments: segments}
+	if decodeSegment(segments[0], &tok.header) != nil || decodeSegment(segments[1], &tok.claims) != nil {
+		return token{}, false
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segments[2], "="))
+	if err != nil {
+		return token{}, false
+	}
+	tok.signature = signature
+	return tok, true
+}
+
+// decodeSegment decodes a base64url JSON object, keeping numbers as written.
+func decodeSegment(segment string, v *map[string]interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(segment, "="))
+	if err != nil {
+		return err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
+
+// encodeSegment encodes v as a base64url JSON object.
+func encodeSegment(v map[string]interface{}) string {
+	data, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// findTokens returns the JWTs the client sends with req: in its authentication headers and in
+// the cookies of its jar.
+func findTokens(req crawler.ParameterizedRequest, client *httpclient.Client) []token {
+	var tokens []token
+	authHeaders := client.AuthHeaders()
+	names := make([]string, 0, len(authHeaders))
+	for name := range authHeaders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := authHeaders[name]
+		match := payloads.JWTRegex.FindStringIndex(value)
+		if match == nil || match[1] != len(value) {
+			continue
+		}
+		if tok, ok := parseToken(value[match[0]:]); ok {
+			tok.location, tok.name, tok.prefix = locationHeader, name, value[:match[0]]
+			tokens = append(tokens, tok)
+		}
+	}
+
+	httpReq, err := http.NewRequest(req.Method, req.URL, nil)
+	if err != nil {
+		return tokens
+	}
+	for _, cookie := range client.GetClient().Jar.Cookies(httpReq.URL) {
+		if payloads.JWTRegex.FindString(cookie.Value) != cookie.Value {
+			continue
+		}
+		if tok, ok := parseToken(cookie.Value); ok {
+			tok.location, tok.name = locationCookie, cookie.Name
+			tokens = append(tokens, tok)
+		}
+	}
+	return tokens
+}
+
+// Scan analyzes the JWTs sent with req once each: claims missing from the token and an HMAC
+// secret found in the wordlist of weak secrets. The active tests replay a crawled GET request
+// that depends on the token, one whose response to a token with a broken signature differs,
+// with forged tokens: unsigned (alg=none), and expired when the token already is or the secret
+// is known.
+func (s *JWTScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	maxAttempts := opts.IntOption(ModuleName, "replay_attempts", 5)
+
+	var findings []scanner.VulnerabilityResult
+	for _, tok := range findTokens(req, client) {
+		if ctx.Err() != nil {
+			return findings, ctx.Err()
+		}
+		s.mu.Lock()
+		state, seen := s.tokens[tok.raw]
+		if !seen {
+			state = &tokenState{}
+			s.tokens[tok.raw] = state
+			log.Info("JWT: analyzing the token in %s '%s' (alg %v)", tok.location, tok.name, tok.header["alg"])
+			findings = append(findings, s.analyze(req, tok, state)...)
+		}
+		replay := req.Method == "GET" && !state.tested && !state.running && state.attempts < maxAttempts
+		if replay {
+			state.attempts++
+			state.running = true
+		}
+		secret := state.secret
+		s.mu.Unlock()
+		if !replay {
+			continue
+		}
+
+		vulns, dependent, err := s.testToken(ctx, req, client, log, opts, tok, secret)
+		findings = append(findings, vulns...)
+		s.mu.Lock()
+		state.running = false
+		state.tested = state.tested || dependent
+		s.mu.Unlock()
+		if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			break
+		}
+	}
+	return findings, nil
+}
+
+// analyze looks at tok without sending requests: its HMAC secret, stored in state when found in
+// the wordlist, and its missing claims.
+func (s *JWTScanner) analyze(req crawler.ParameterizedRequest, tok token, state *tokenState) []scanner.VulnerabilityResult {
+	var findings []scanner.VulnerabilityResult
+	if secret, ok := crack(tok, payloads.GetJWTWeakSecrets()); ok {
+		state.secret = secret
+		vuln := s.newResult(req, tok, "JWT Weak Signing Secret", fmt.Sprintf("The token is signed with %v and the secret %q, found in the wordlist of weak secrets: anyone can sign tokens with any claims, e.g. another user's subject or an administrator role.", tok.header["alg"], secret))
+		vuln.Severity = "Critical"
+		vuln.Evidence = fmt.Sprintf("Secret: %q; %s", secret, describe(tok))
+		findings = append(findings, vuln)
+	}
+
+	var missing []string
+	if _, ok := tok.claims["exp"]; !ok {
+		missing = append(missing, "exp (the token never expires)")
+	}
+	if _, ok := tok.claims["aud"]; !ok {
+		missing = append(missing, "aud (the token is not bound to an audience and may be accepted by other services trusting the same issuer)")
+	}
+	if len(missing) > 0 {
+		vuln := s.newResult(req, tok, "JWT Missing Claim", fmt.Sprintf("The token has no %s claim.", strings.Join(missing, " and no ")))
+		vuln.Severity = "Informational"
+		vuln.Evidence = describe(tok)
+		vuln.Remediation = "Issue short-lived tokens with an exp claim and an aud claim naming the service, and have every service check both."
+		findings = append(findings, vuln)
+	}
+	return findings
+}
+
+// crack returns the secret of secrets that tok is signed with, for the HMAC algorithms.
+func crack(tok token, secrets []string) (string, bool) {
+	newHash := hmacHash(tok)
+	if newHash == nil {
+		return "", false
+	}
+	signingInput := []byte(tok.segments[0] + "." + tok.segments[1])
+	for _, secret := range secrets {
+		mac := hmac.New(newHash, []byte(secret))
+		mac.Write(signingInput)
+		if hmac.Equal(mac.Sum(nil), tok.signature) {
+			return secret, true
+		}
+	}
+	return "", false
+}
+
+// hmacHash returns the hash function of the HMAC algorithm of tok, or nil.
+func hmacHash(tok token) func() hash.Hash {
+	switch tok.header["alg"] {
+	case "HS256":
+		return sha256.New
+	case "HS384":
+		return sha512.New384
+	case "HS512":
+		return sha512.New
+	}
+	return nil
+}
+
+// response is the answer to a replayed request.
+type response struct {
+	status   int
+	body     string
+	exchange scanner.Exchange
+}
+
+// testToken replays req with tokens derived from tok. It reports whether the response depends on
+// the token at all: it must differ from the response to a token with a broken signature.
+func (s *JWTScanner) testToken(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, tok token, secret string) ([]scanner.VulnerabilityResult, bool, error) {
+	cmp := compare.New(opts, log, "JWT")
+	differs := func(a, b response) bool { return a.status != b.status || cmp.IsDifferent(a.body, b.body) }
+
+	original, err := s.send(ctx, req, client, tok, tok.raw)
+	if err != nil {
+		return nil, false, err
+	}
+	invalid, err := s.send(ctx, req, client, tok, brokenSignature(tok))
+	if err != nil {
+		return nil, false, err
+	}
+	if !differs(original, invalid) {
+		log.Debug("JWT: %s does not depend on the token in %s '%s'", req.URL, tok.location, tok.name)
+		return nil, false, nil
+	}
+	// A forged token is accepted when the server answers it as it answers the original token,
+	// and not as it answers an invalid one.
+	accepted := func(r response) bool { return !differs(original, r) && differs(invalid, r) }
+	baseline := fmt.Sprintf("Original token: HTTP %d; token with a broken signature: HTTP %d", original.status, invalid.status)
+
+	var findings []scanner.VulnerabilityResult
+	for _, alg := range payloads.JWTNoneAlgorithms {
+		header := make(map[string]interface{}, len(tok.header))
+		for name, value := range tok.header {
+			header[name] = value
+		}
+		header["alg"] = alg
+		forged := encodeSegment(header) + "." + tok.segments[1] + "."
+		r, err := s.send(ctx, req, client, tok, forged)
+		if err != nil {
+			return findings, true, err
+		}
+		if !accepted(r) {
+			continue
+		}
+		log.Success("JWT: %s accepts an unsigned token (alg %q) in %s '%s'", req.URL, alg, tok.location, tok.name)
+		vuln := s.newResult(req, tok, "JWT None Algorithm Accepted", fmt.Sprintf("The server accepted the token with its signature removed and the algorithm set to %q, answering like it does to the original token and unlike to a token with a broken signature: it does not verify signatures, so anyone can forge tokens with any claims.", alg))
+		vuln.Severity = "Critical"
+		vuln.Payload = fmt.Sprintf(`{"alg":%q,...}.<original claims>.`, alg)
+		vuln.Evidence = fmt.Sprintf("%s; unsigned token: HTTP %d. %s", baseline, r.status, describe(tok))
+		vuln.SetExchange(redact(r.exchange, tok, forged))
+		findings = append(findings, vuln)
+		break
+	}
+
+	if exp, ok := claimTime(tok.claims, "exp"); ok && exp.Before(time.Now()) {
+		log.Success("JWT: %s accepts the expired token in %s '%s'", req.URL, tok.location, tok.name)
+		vuln := s.newResult(req, tok, "JWT Expired Token Accepted", fmt.Sprintf("The token expired at %s, yet the server still answers it like a valid token and unlike a token with a broken signature: expiration is not enforced, so stolen tokens stay usable.", exp.UTC().Format(time.RFC3339)))
+		vuln.Severity = "Medium"
+		vuln.Evidence = fmt.Sprintf("%s. %s", baseline, describe(tok))
+		vuln.SetExchange(redact(original.exchange, tok, tok.raw))
+		findings = append(findings, vuln)
+	} else if secret != "" {
+		claims := make(map[string]interface{}, len(tok.claims))
+		for name, value := range tok.claims {
+			claims[name] = value
+		}
+		expired := time.Now().Add(-time.Hour)
+		claims["exp"] = expired.Unix()
+		forged := sign(tok, claims, secret)
+		r, err := s.send(ctx, req, client, tok, forged)
+		if err != nil {
+			return findings, true, err
+		}
+		if accepted(r) {
+			log.Success("JWT: %s accepts an expired token in %s '%s'", req.URL, tok.location, tok.name)
+			vuln := s.newResult(req, tok, "JWT Expired Token Accepted", fmt.Sprintf("A copy of the token re-signed with the weak secret and expired at %s was answered like the original token and unlike a token with a broken signature: expiration is not enforced, so stolen tokens stay usable.", expired.UTC().Format(time.RFC3339)))
+			vuln.Severity = "Medium"
+			vuln.Payload = fmt.Sprintf(`{"exp":%d,...} signed with the weak secret`, expired.Unix())
+			vuln.Evidence = fmt.Sprintf("%s; expired token: HTTP %d. %s", baseline, r.status, describe(tok))
+			vuln.SetExchange(redact(r.exchange, tok, forged))
+			findings = append(findings, vuln)
+		}
+	}
+	return findings, true, nil
+}
+
+// send replays req with value in place of tok. The cookies of the client's jar are sent, and
+// those set by the response are discarded, so forged tokens never replace the scan's session.
+func (s *JWTScanner) send(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, tok token, value string) (response, error) {
+	req.Headers, req.Cookies = nil, nil // The token is the only value replaced.
+	params, err := requtil.Params(req)
+	if err != nil {
+		return response{}, err
+	}
+	httpReq, err := requtil.New(ctx, req, params)
+	if err != nil {
+		return response{}, err
+	}
+	session := client.SnapshotSession()
+	switch tok.location {
+	case locationHeader:
+		headers := client.AuthHeaders()
+		headers[tok.name] = tok.prefix + value
+		client = client.WithAuthHeaders(headers)
+	case locationCookie:
+		var pairs []string
+		for _, cookie := range session.Cookies(httpReq.URL) {
+			if cookie.Name == tok.name {
+				cookie.Value = value
+			}
+			pairs = append(pairs, cookie.Name+"="+cookie.Value)
+		}
+		httpReq.Header.Set("Cookie", strings.Join(pairs, "; "))
+		session = httpclient.NewSession()
+	}
+
+	resp, err := client.DoWithSession(httpReq, session)
+	if err != nil {
+		return response{}, err
+	}
+	defer resp.Body.Close()
+	body, err := requtil.ReadBody(client, resp)
+	if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
+		return response{}, err
+	}
+	return response{status: resp.StatusCode, body: string(body), exchange: scanner.CaptureExchange(httpReq, resp, body)}, nil
+}
+
+// brokenSignature returns tok with a signature that cannot be valid.
+func brokenSignature(tok token) string {
+	signature := tok.segments[2]
+	switch {
+	case signature == "":
+		signature = "ZHVyc2dv"
+	case signature[0] == 'A':
+		signature = "B" + signature[1:]
+	default:
+		signature = "A" + signature[1:]
+	}
+	return tok.segments[0] + "." + tok.segments[1] + "." + signature
+}
+
+// sign returns a token with the header of tok and claims, signed with secret.
+func sign(tok token, claims map[string]interface{}, secret string) string {
+	signingInput := tok.segments[0] + "." + encodeSegment(claims)
+	mac := hmac.New(hmacHash(tok), []byte(secret))
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// claimTime returns the time of a NumericDate claim (exp, nbf, iat).
+func claimTime(claims map[string]interface{}, name string) (time.Time, bool) {
+	number, ok := claims[name].(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, err := number.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(seconds), 0), true
+}
+
+// describe returns the header of tok and its claims with their values masked, for evidence: the
+// token itself is a credential and is never reported.
+func describe(tok token) string {
+	header, _ := json.Marshal(tok.header)
+	masked := make(map[string]interface{}, len(tok.claims))
+	for name, value := range tok.claims {
+		switch name {
+		case "exp", "nbf", "iat":
+			masked[name] = value
+		default:
+			masked[name] = maskValue(value)
+		}
+	}
+	claims, _ := json.Marshal(masked)
+	return fmt.Sprintf("Header: %s; Payload (masked): %s", header, claims)
+}
+
+// maskValue keeps the first characters of a string claim, enough to recognize it.
+func maskValue(value interface{}) string {
+	if s, ok := value.(string); ok && len(s) > 4 {
+		return s[:2] + "***"
+	}
+	return "***"
+}
+
+// redact replaces tok and the token sent in its place in a raw exchange with their header
+// segment, and their claims anywhere else with a placeholder: a token re-signed with a cracked
+// secret is as much a credential as tok.
+func redact(exchange scanner.Exchange, tok token, sent string) scanner.Exchange {
+	var pairs []string
+	for _, raw := range []string{sent, tok.raw} {
+		segments := strings.SplitN(raw, ".", 3)
+		pairs = append(pairs, raw, segments[0]+".[REDACTED]")
+		if len(segments) == 3 {
+			pairs = append(pairs, "."+segments[1]+".", ".[REDACTED].")
+		}
+	}
+	replacer := strings.NewReplacer(pairs...)
+	exchange.Request = replacer.Replace(exchange.Request)
+	exchange.Response = replacer.Replace(exchange.Response)
+	return exchange
+}
+
+// newResult returns a finding on tok.
+func (s *JWTScanner) newResult(req crawler.ParameterizedRequest, tok token, vulnType, details string) scanner.VulnerabilityResult {
+	return scanner.VulnerabilityResult{
+		VulnerabilityType: vulnType,
+		URL:               req.URL,
+		Parameter:         tok.name,
+		Location:          tok.location,
+		Details:           details,
+		Remediation:       "Verify the signature of every token with a fixed algorithm (never the one named in the token, and never none), sign HMAC tokens with a long random secret, and reject tokens whose exp is past.",
+		ScannerName:       s.Name(),
+	}
+}
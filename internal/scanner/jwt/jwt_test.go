@@ -0,0 +1,174 @@
+package jwt
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const strongSecret = "b1f6c0e2d9a84f7e9c3a5d2b8e6f4a1c"
+
+// signHS256 returns a token with claims signed with secret.
+func signHS256(claims map[string]interface{}, secret string) string {
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// verifier is how an application checks the tokens it is sent.
+type verifier struct {
+	secret      string
+	acceptNone  bool // Tokens with any spelling of alg "none" are not verified.
+	checkExpiry bool
+}
+
+// app serves the account page of the user named in a valid token, read from the Authorization
+// header or the "token" cookie.
+func (v verifier) app() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if cookie, err := r.Cookie("token"); err == nil {
+			raw = cookie.Value
+		}
+		claims, ok := v.verify(raw)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`<h1>Sign in</h1><form action="/login"><input name="user"></form>`))
+			return
+		}
+		w.Write([]byte(`<h1>Account of ` + claims["sub"].(string) + `</h1><ul><li>Order 1001: shipped</li><li>Order 1002: pending</li></ul>`))
+	}
+}
+
+func (v verifier) verify(raw string) (map[string]interface{}, bool) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, false
+	}
+	var header, claims map[string]interface{}
+	headerJSON, _ := base64.RawURLEncoding.DecodeString(parts[0])
+	claimsJSON, _ := base64.RawURLEncoding.DecodeString(parts[1])
+	if json.Unmarshal(headerJSON, &header) != nil || json.Unmarshal(claimsJSON, &claims) != nil {
+		return nil, false
+	}
+	alg, _ := header["alg"].(string)
+	switch {
+	case strings.EqualFold(alg, "none"):
+		if !v.acceptNone {
+			return nil, false
+		}
+	case alg == "HS256":
+		mac := hmac.New(sha256.New, []byte(v.secret))
+		mac.Write([]byte(parts[0] + "." + parts[1]))
+		if base64.RawURLEncoding.EncodeToString(mac.Sum(nil)) != parts[2] {
+			return nil, false
+		}
+	default:
+		return nil, false
+	}
+	if exp, ok := claims["exp"].(float64); v.checkExpiry && ok && time.Unix(int64(exp), 0).Before(time.Now()) {
+		return nil, false
+	}
+	_, ok := claims["sub"].(string)
+	return claims, ok
+}
+
+func TestScan(t *testing.T) {
+	future := time.Now().Add(time.Hour).Unix()
+	past := time.Now().Add(-time.Hour).Unix()
+	tests := []struct {
+		name      string
+		verifier  verifier
+		secret    string
+		claims    map[string]interface{}
+		inCookie  bool
+		wantTypes []string
+	}{
+		{
+			name:      "Weak secret and alg none",
+			verifier:  verifier{secret: "secret", acceptNone: true, checkExpiry: true},
+			secret:    "secret",
+			claims:    map[string]interface{}{"sub": "alice", "role": "customer"},
+			wantTypes: []string{"JWT Weak Signing Secret", "JWT Missing Claim", "JWT None Algorithm Accepted"},
+		},
+		{
+			name:      "Weak secret without expiration check",
+			verifier:  verifier{secret: "changeme"},
+			secret:    "changeme",
+			claims:    map[string]interface{}{"sub": "alice", "aud": "shop", "exp": future},
+			inCookie:  true,
+			wantTypes: []string{"JWT Weak Signing Secret", "JWT Expired Token Accepted"},
+		},
+		{
+			name:      "Expired token accepted",
+			verifier:  verifier{secret: strongSecret},
+			secret:    strongSecret,
+			claims:    map[string]interface{}{"sub": "alice", "aud": "shop", "exp": past},
+			wantTypes: []string{"JWT Expired Token Accepted"},
+		},
+		{
+			name:     "Verified token",
+			verifier: verifier{secret: strongSecret, checkExpiry: true},
+			secret:   strongSecret,
+			claims:   map[string]interface{}{"sub": "alice", "aud": "shop", "exp": future},
+			inCookie: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.verifier.app())
+			defer server.Close()
+
+			raw := signHS256(tt.claims, tt.secret)
+			clientOpts := httpclient.ClientOptions{TargetBaseURL: server.URL, AuthHeaders: map[string]string{"Authorization": "Bearer " + raw}}
+			if tt.inCookie {
+				clientOpts = httpclient.ClientOptions{TargetBaseURL: server.URL, AuthCookie: "lang=en; token=" + raw}
+			}
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, clientOpts)
+			s := NewJWTScanner()
+
+			findings, err := s.Scan(context.Background(), crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/account"}, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			var types []string
+			for _, finding := range findings {
+				types = append(types, finding.VulnerabilityType)
+				for _, text := range []string{finding.Details, finding.Evidence, finding.Payload, finding.RawRequest, finding.RawResponse} {
+					assert.NotContains(t, text, raw, "the token is never reported")
+					assert.NotContains(t, text, strings.Split(raw, ".")[1], "the claims are only reported masked")
+					assert.NotRegexp(t, `eyJ[\w-]*\.eyJ`, text, "neither are the tokens built by the scanner")
+				}
+			}
+			assert.ElementsMatch(t, tt.wantTypes, types)
+
+			findings, err = s.Scan(context.Background(), crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/orders"}, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			assert.Empty(t, findings, "a token is tested once")
+		})
+	}
+}
+
+func TestDescribeMasksClaims(t *testing.T) {
+	tok, ok := parseToken(signHS256(map[string]interface{}{"sub": "alice@example.com", "exp": 1700000000, "admin": true}, "x"))
+	require.True(t, ok)
+	assert.Equal(t, `Header: {"alg":"HS256","typ":"JWT"}; Payload (masked): {"admin":"***","exp":1700000000,"sub":"al***"}`, describe(tok))
+}
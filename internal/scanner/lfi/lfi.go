@@ -6,8 +6,8 @@ import (
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
+	"context"
 	"fmt"
-	"io"
 	"math/rand"
 	"net/http"
 	"net/url"
@@ -25,6 +25,15 @@ func NewLFIScanner() *LFIScanner {
 	return &LFIScanner{}
 }
 
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "lfi",
+		Order:          40,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewLFIScanner() },
+	})
+}
+
 // Name returns the name of the scanner.
 func (s *LFIScanner) Name() string {
 	return "Advanced Local File Inclusion Scanner"
@@ -32,7 +41,8 @@ func (s *LFIScanner) Name() string {
 
 // Scan performs a scan for Local File Inclusion (LFI) vulnerabilities.
 // It identifies potential LFI parameters and tests them with various path traversal payloads.
-func (s *LFIScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+func (s *LFIScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
 	var findings []scanner.VulnerabilityResult
 	rand.Seed(time.Now().UnixNano())
 
@@ -40,9 +50,21 @@ func (s *LFIScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.C
 		return nil, nil
 	}
 
+	parsedURL, err := url.Parse(req.URL)
+	if err != nil {
+		return nil, nil
+	}
+	originalQuery := parsedURL.Query()
+
 ParamLoop:
 	for _, paramName := range req.ParamNames {
-		if !isPotentialLFIParam(paramName) {
+		if opts.SkipParam("lfi", paramName) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return findings, ctx.Err()
+		}
+		if !isPotentialLFIParam(paramName) && !isPotentialLFIValue(originalQuery.Get(paramName)) {
 			continue
 		}
 
@@ -50,16 +72,21 @@ ParamLoop:
 
 		// Send a baseline request once per parameter
 		baselineValue := fmt.Sprintf("dursgoprobing%d", rand.Intn(1e9))
-		baselineResp, err := sendLFIRequest(req, client, paramName, baselineValue)
+		_, baselineResp, err := sendLFIRequest(ctx, req, client, paramName, baselineValue)
 		if err != nil {
 			continue
 		}
-		defer baselineResp.Body.Close()
-		baselineBodyBytes, _ := io.ReadAll(baselineResp.Body)
+		baselineBodyBytes, truncated, _ := client.ReadBody(baselineResp)
+		baselineResp.Body.Close()
+		if truncated {
+			// Responses cannot be told apart from a partial baseline.
+			log.Debug("LFI: Baseline response for '%s' in %s exceeds the response size limit; skipping.", paramName, req.URL)
+			continue
+		}
 		baselineBody := string(baselineBodyBytes)
 
-		for _, lfiPayload := range payloads.LFIPathTraversalPayloads {
-			vuln, found := s.executeTest(req, client, log, paramName, lfiPayload, baselineBody)
+		for _, lfiPayload := range payloads.GetLFIPathTraversalPayloads() {
+			vuln, found := s.executeTest(ctx, req, client, log, paramName, lfiPayload, baselineBody)
 			if found {
 				findings = append(findings, vuln)
 				continue ParamLoop // Found, continue to the next parameter
@@ -74,20 +101,20 @@ ParamLoop:
 // 1. The response must be different from the baseline.
 // 2. The response must contain a keyword indicating a successful LFI.
 // 3. The keyword must not be a reflection of the payload itself.
-func (s *LFIScanner) executeTest(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName, lfiPayload, baselineBody string) (scanner.VulnerabilityResult, bool) {
-	testResp, err := sendLFIRequest(req, client, paramName, lfiPayload)
+func (s *LFIScanner) executeTest(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName, lfiPayload, baselineBody string) (scanner.VulnerabilityResult, bool) {
+	testReq, testResp, err := sendLFIRequest(ctx, req, client, paramName, lfiPayload)
 	if err != nil {
 		return scanner.VulnerabilityResult{}, false
 	}
 	defer testResp.Body.Close()
 
 	if testResp.StatusCode == http.StatusOK {
-		bodyBytes, _ := io.ReadAll(testResp.Body)
+		bodyBytes, truncated, _ := client.ReadBody(testResp)
 		body := string(bodyBytes)
 
 		// Three-Step Detection Logic
-		// 1. Response must be different from the baseline
-		if !isDifferentResponse(baselineBody, body) {
+		// 1. Response must be different from the baseline, compared in full
+		if truncated || !isDifferentResponse(baselineBody, body) {
 			return scanner.VulnerabilityResult{}, false
 		}
 
@@ -96,26 +123,23 @@ func (s *LFIScanner) executeTest(req crawler.ParameterizedRequest, client *httpc
 			if strings.Contains(body, keyword) {
 				// 3. Keyword must not be a reflection of the payload
 				if !strings.Contains(lfiPayload, keyword) {
-					log.Success("LFI: Found keyword '%s' for payload '%s' in param '%s'", keyword, lfiPayload, paramName)
-					parsedURL, _ := url.Parse(req.URL)
-					query := parsedURL.Query()
-					query.Set(paramName, lfiPayload)
-					parsedURL.RawQuery = query.Encode()
-					testURL := parsedURL.String()
+					bypass := lfiBypassTechnique(lfiPayload)
+					log.Success("LFI: Found keyword '%s' for payload '%s' (bypass: %s) in param '%s'", keyword, lfiPayload, bypass, paramName)
 
 					details := fmt.Sprintf("LFI payload '%s' returned known file content matching keyword: '%s'", lfiPayload, keyword)
 					vuln := scanner.VulnerabilityResult{
 						VulnerabilityType: "Local File Inclusion/Path Traversal",
-						URL:               testURL,
+						URL:               testReq.URL.String(),
 						Parameter:         paramName,
 						Payload:           lfiPayload,
 						Location:          "query",
 						Details:           details,
 						Severity:          "High",
-						Evidence:          keyword,
+						Evidence:          fmt.Sprintf("Matched signature '%s'; encoding bypass: %s", keyword, bypass),
 						Remediation:       "Validate and sanitize all user input. Implement an allow-list of files that can be included and disallow path traversal characters.",
 						ScannerName:       s.Name(),
 					}
+					vuln.SetExchange(scanner.CaptureExchange(testReq, testResp, bodyBytes))
 					return vuln, true
 				}
 			}
@@ -124,18 +148,80 @@ func (s *LFIScanner) executeTest(req crawler.ParameterizedRequest, client *httpc
 	return scanner.VulnerabilityResult{}, false
 }
 
-// sendLFIRequest sends an HTTP GET request with the LFI payload.
-func sendLFIRequest(req crawler.ParameterizedRequest, client *httpclient.Client, paramName, value string) (*http.Response, error) {
-	parsedURL, err := url.Parse(req.URL)
+// sendLFIRequest sends an HTTP GET request with the LFI payload and returns the sent request
+// along with the response.
+func sendLFIRequest(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, paramName, value string) (*http.Request, *http.Response, error) {
+	testURL, err := buildLFIURL(req.URL, paramName, value)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", testURL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	return httpReq, resp, nil
+}
+
+// buildLFIURL sets paramName to value in rawURL. Values containing '%' are already URL-encoded
+// (e.g., ..%252f or %00) and are placed in the query verbatim, so the encoding under test reaches
+// the target unchanged instead of being escaped a second time.
+func buildLFIURL(rawURL, paramName, value string) (string, error) {
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
 	}
 	query := parsedURL.Query()
-	query.Set(paramName, value)
-	parsedURL.RawQuery = query.Encode()
-	testURL := parsedURL.String()
+	query.Del(paramName)
+	if !strings.Contains(value, "%") {
+		value = url.QueryEscape(value)
+	}
+	pair := url.QueryEscape(paramName) + "=" + value
+	if encoded := query.Encode(); encoded != "" {
+		pair = encoded + "&" + pair
+	}
+	parsedURL.RawQuery = pair
+	return parsedURL.String(), nil
+}
 
-	return client.Get(testURL)
+// lfiBypassTechnique describes the encoding or filter bypass a payload relies on, for the
+// finding's evidence.
+func lfiBypassTechnique(payload string) string {
+	lower := strings.ToLower(payload)
+	var techniques []string
+	switch {
+	case strings.Contains(lower, "%25"):
+		techniques = append(techniques, "double URL encoding")
+	case strings.Contains(lower, "%c0%ae"):
+		techniques = append(techniques, "overlong UTF-8 encoding")
+	case strings.Contains(strings.ReplaceAll(lower, "%00", ""), "%"):
+		techniques = append(techniques, "URL encoding")
+	}
+	if strings.Contains(lower, "%00") {
+		techniques = append(techniques, "null byte truncation")
+	}
+	if strings.Contains(lower, "....//") {
+		techniques = append(techniques, "nested traversal sequence (....//)")
+	}
+	if strings.Contains(payload, "\\") && strings.Contains(payload, "..") {
+		techniques = append(techniques, "backslash separators")
+	}
+	if scheme, _, found := strings.Cut(lower, "://"); found && !strings.Contains(scheme, "/") {
+		if scheme == "php" && strings.Contains(lower, "base64-encode") {
+			techniques = append(techniques, "php://filter base64 wrapper")
+		} else {
+			techniques = append(techniques, scheme+":// wrapper")
+		}
+	} else if strings.HasPrefix(lower, "data:") {
+		techniques = append(techniques, "data: wrapper")
+	}
+	if len(techniques) == 0 {
+		return "none (plain path)"
+	}
+	return strings.Join(techniques, " + ")
 }
 
 // isPotentialLFIParam checks if a parameter name is commonly associated with LFI vulnerabilities.
@@ -149,6 +235,18 @@ func isPotentialLFIParam(p string) bool {
 		strings.Contains(l, "country") // Added for lab
 }
 
+// isPotentialLFIValue checks if a parameter value looks like a file reference, such as a script
+// name (page.php, header.inc) or a relative path.
+func isPotentialLFIValue(v string) bool {
+	l := strings.ToLower(v)
+	for _, ext := range []string{".php", ".inc", ".html", ".htm", ".txt", ".tpl", ".jsp", ".asp", ".aspx", ".xml", ".ini", ".log"} {
+		if strings.HasSuffix(l, ext) {
+			return true
+		}
+	}
+	return strings.Contains(l, "/") || strings.Contains(l, "\\")
+}
+
 // isDifferentResponse checks if two responses are sufficiently different using Levenshtein distance.
 func isDifferentResponse(original, modified string) bool {
 	distance := levenshtein.Distance(original, modified, nil)
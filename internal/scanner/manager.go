@@ -4,44 +4,109 @@ import (
 	"Dursgo/internal/crawler"
 	"Dursgo/internal/httpclient"
 	"Dursgo/internal/logger"
-	"fmt"
-	"io"
-	"net/http"
-	"net/url"
-	"strings"
+	"context"
+	"errors"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Manager orchestrates the execution of multiple scanners.
 // It manages a collection of registered scanners and runs them against a set of requests.
 type Manager struct {
-	scanners   []Scanner
-	httpClient *httpclient.Client
-	logger     *logger.Logger
-	options    ScannerOptions
+	scanners        []Scanner
+	passiveScanners []PassiveScanner
+	httpClient      *httpclient.Client
+	logger          *logger.Logger
+	options         ScannerOptions
+	requestCounts   map[string]*atomic.Int64 // Requests sent per scanner, keyed by scanner name.
+	errorCounts     map[string]*atomic.Int64 // Failed scanner/request pairs per scanner, see ErrorCounts.
+	moduleNames     map[string]string        // Module names of the scanners, keyed by scanner name.
+	testsInert      map[string]bool          // Scanners testing inert parameters, keyed by scanner name.
 }
 
 // NewManager creates a new scanner manager.
+// If opts.RequestsPerSecond is set, the rate limit is applied to client and shared by all scanners.
 func NewManager(client *httpclient.Client, log *logger.Logger, opts ScannerOptions) *Manager {
+	if opts.RequestsPerSecond > 0 {
+		client.SetRateLimit(opts.RequestsPerSecond)
+		log.Info("ScannerManager: Limiting scan traffic to %.2f requests per second.", opts.RequestsPerSecond)
+	}
 	return &Manager{
-		httpClient: client,
-		logger:     log,
-		options:    opts,
-		scanners:   make([]Scanner, 0),
+		httpClient:    client,
+		logger:        log,
+		options:       opts,
+		scanners:      make([]Scanner, 0),
+		requestCounts: make(map[string]*atomic.Int64),
+		errorCounts:   make(map[string]*atomic.Int64),
+		moduleNames:   make(map[string]string),
+		testsInert:    make(map[string]bool),
 	}
 }
 
 // RegisterScanner adds a scanner to the manager.
 func (m *Manager) RegisterScanner(s Scanner) {
 	m.scanners = append(m.scanners, s)
+	if _, ok := m.requestCounts[s.Name()]; !ok {
+		m.requestCounts[s.Name()] = new(atomic.Int64)
+		m.errorCounts[s.Name()] = new(atomic.Int64)
+	}
 	m.logger.Debug("ScannerManager: Registered scanner: %s", s.Name())
 }
 
+// RegisterModule adds the scanner of the named module to the manager. Unlike RegisterScanner,
+// skip rules limited to the module apply to it.
+func (m *Manager) RegisterModule(name string, s Scanner) {
+	m.RegisterScanner(s)
+	m.moduleNames[s.Name()] = name
+	m.testsInert[s.Name()] = testsInertParams(name)
+}
+
+// RegisterPassiveScanner adds a scanner that works on crawled responses to the manager.
+func (m *Manager) RegisterPassiveScanner(s PassiveScanner) {
+	m.passiveScanners = append(m.passiveScanners, s)
+	m.logger.Debug("ScannerManager: Registered passive scanner: %s", s.Name())
+}
+
+// RegisterPassiveModule adds the passive scanner of the named module to the manager, recorded
+// under its module name.
+func (m *Manager) RegisterPassiveModule(name string, s PassiveScanner) {
+	m.RegisterPassiveScanner(s)
+	m.moduleNames[s.Name()] = name
+}
+
+// RunPassiveScans executes all registered passive scanners against the responses retained by
+// the crawler. No requests are sent.
+func (m *Manager) RunPassiveScans(responses []crawler.CrawledResponse) []VulnerabilityResult {
+	if len(m.passiveScanners) == 0 {
+		return nil
+	}
+	if len(responses) == 0 {
+		m.logger.Warn("ScannerManager: No crawled responses retained (pages rendered in a headless browser are not kept); skipping passive scanners.")
+		return nil
+	}
+
+	m.logger.Info("ScannerManager: Running %d passive scanner(s) on %d crawled responses...", len(m.passiveScanners), len(responses))
+	var allFindings []VulnerabilityResult
+	for _, s := range m.passiveScanners {
+		started := time.Now()
+		findings := s.ScanResponses(responses, m.logger)
+		m.observe(s, time.Since(started), findings)
+		PrepareRawEvidence(findings, m.options.MaxRawResponseBytes)
+		Classify(findings)
+		m.options.Overrides.Apply(findings)
+		m.emit(findings)
+		allFindings = append(allFindings, findings...)
+	}
+	return allFindings
+}
+
 // RunScans executes all registered scanners against a list of requests.
 // It implements a smart targeting logic to optimize scanning by identifying
 // representative parameters based on reflection signatures.
-func (m *Manager) RunScans(requests []crawler.ParameterizedRequest) []VulnerabilityResult {
+// When ctx is cancelled, no new requests are started and the findings collected so far are returned.
+func (m *Manager) RunScans(ctx context.Context, requests []crawler.ParameterizedRequest) []VulnerabilityResult {
 	if len(m.scanners) == 0 || len(requests) == 0 {
 		return nil
 	}
@@ -50,172 +115,261 @@ func (m *Manager) RunScans(requests []crawler.ParameterizedRequest) []Vulnerabil
 	var allFindings []VulnerabilityResult
 	var findingsMu sync.Mutex
 
-	// --- SMART TARGETING LOGIC (Temporarily Disabled for Debugging) ---
-	// The original logic is preserved below but commented out.
-	/*
-		optimizedRequests := make(map[string]crawler.ParameterizedRequest)
+	finalRequests := m.inScope(requests)
+	if len(finalRequests) == 0 {
+		return nil
+	}
 
-		for _, req := range requests {
-			// Only optimize if there are more than 2 parameters.
-			if len(req.ParamNames) <= 2 {
-				optimizedRequests[req.Method+req.URL] = req
+	// Every scanner/request pair is a job of its own, so a slow scanner does not hold back the
+	// other scanners of a request. Pairs tested by an interrupted earlier run are not repeated.
+	// Pairs excluded by a path skip rule are not tested either, and inert parameters are left
+	// out for the modules that skip them.
+	var pairs []scanJob
+	skipped := 0
+	for _, req := range finalRequests {
+		for _, s := range m.scanners {
+			module := m.moduleName(s)
+			if m.options.SkipRules.SkipPath(m.moduleNames[s.Name()], req.URL) {
+				m.logger.Debug("ScannerManager: Skip rule excludes %s %s from %s", req.Method, req.URL, s.Name())
+				m.options.Coverage.SkipRequest(module, req, SkipReasonRule)
 				continue
 			}
-
-			m.logger.Debug("SmartTargeting: Analyzing %d parameters for %s %s", len(req.ParamNames), req.Method, req.URL)
-
-			reflectionSignatures := make(map[string]string)
-			var representativeParams []string
-			rand.Seed(time.Now().UnixNano()) // Initialize random seed
-			probeMarker := fmt.Sprintf("dursgoprobe%d", rand.Intn(1e9))
-
-			for _, paramName := range req.ParamNames {
-				signature := m.getReflectionSignature(req, paramName, probeMarker)
-
-				if _, exists := reflectionSignatures[signature]; !exists {
-					reflectionSignatures[signature] = paramName
-					representativeParams = append(representativeParams, paramName)
-					m.logger.Debug("SmartTargeting: Parameter '%s' is a new representative for signature: %s", paramName, signature)
-				} else {
-					m.logger.Debug("SmartTargeting: Parameter '%s' has a duplicate signature. Skipping full scan.", paramName)
+			key := TestKey(s.Name(), req)
+			if m.options.Progress != nil && m.options.Progress.IsTested(key) {
+				skipped++
+				m.options.Coverage.complete(module, req, nil)
+				continue
+			}
+			filtered := m.options.InertParams.filter(req, m.moduleNames[s.Name()], m.testsInert[s.Name()])
+			if len(filtered.ParamNames) < len(req.ParamNames) {
+				for _, name := range req.ParamNames {
+					if !slices.Contains(filtered.ParamNames, name) {
+						m.options.Coverage.Skip(module, req, name, SkipReasonInert)
+					}
 				}
 			}
-
-			optimizedReq := req
-			optimizedReq.ParamNames = representativeParams
-			optimizedRequests[req.Method+req.URL] = optimizedReq
-			m.logger.Info("SmartTargeting: Optimized %s %s from %d to %d parameters.", req.Method, req.URL, len(req.ParamNames), len(representativeParams))
+			pairs = append(pairs, scanJob{scanner: s, req: filtered, crawled: req, key: key})
 		}
+	}
+	if skipped > 0 {
+		m.logger.Info("ScannerManager: Skipping %d scanner/request pair(s) completed by the resumed scan.", skipped)
+	}
+	if len(pairs) == 0 {
+		m.options.SkipRules.LogSummary(m.logger)
+		m.options.InertParams.LogSummary(m.logger)
+		m.options.Coverage.LogSummary(m.logger)
+		return nil
+	}
+	jobs := make(chan scanJob, len(pairs))
+	workItems := 0
+	for _, job := range pairs {
+		jobs <- job
+		workItems += job.workItems()
+	}
+	close(jobs)
+	if m.options.Status != nil {
+		m.options.Status.AddWork(workItems)
+	}
 
-		finalRequests := make([]crawler.ParameterizedRequest, 0, len(optimizedRequests))
-		for _, req := range optimizedRequests {
-			finalRequests = append(finalRequests, req)
-		}
-	*/
-	finalRequests := requests // Bypass optimization
-	// --- END SMART TARGETING LOGIC ---
-
-	jobs := make(chan crawler.ParameterizedRequest, len(finalRequests))
 	var wg sync.WaitGroup
 	numWorkers := m.options.Concurrency
-	if numWorkers > len(finalRequests) {
-		numWorkers = len(finalRequests)
+	if numWorkers > len(pairs) {
+		numWorkers = len(pairs)
 	}
-	if numWorkers == 0 && len(finalRequests) > 0 {
+	if numWorkers <= 0 {
 		numWorkers = 1
 	}
 
-	m.logger.Debug("ScannerManager: Initializing %d worker(s) for optimized scanning.", numWorkers)
-
-	// --- Start Spinner ---
-	done := make(chan bool)
-	go func() {
-		spinner := []string{"/", "-", "\\", "|"}
-		i := 0
-		for {
-			select {
-			case <-done:
-				fmt.Print("\r") // Clear the spinner line
-				return
-			default:
-				fmt.Printf("\rScanning... %s ", spinner[i])
-				i = (i + 1) % len(spinner)
-				time.Sleep(100 * time.Millisecond)
-			}
-		}
-	}()
-	// --- End Spinner ---
+	m.logger.Debug("ScannerManager: Initializing %d worker(s) for %d scanner/request pair(s).", numWorkers, len(pairs))
+
+	// Give each scanner a client that counts the requests it sends and tags them with its module
+	// in the HAR file.
+	scannerClients := make(map[string]*httpclient.Client, len(m.scanners))
+	for _, s := range m.scanners {
+		scannerClients[s.Name()] = m.httpClient.WithRequestCounter(m.requestCounts[s.Name()]).WithSource("scan", m.moduleName(s))
+	}
 
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for req := range jobs {
-				for _, s := range m.scanners {
-					findings, err := s.Scan(req, m.httpClient, m.logger, m.options)
-					if err != nil {
-						m.logger.Error("Scanner %s failed for %s: %v", s.Name(), req.URL, err)
-						continue
-					}
-					if len(findings) > 0 {
-						findingsMu.Lock()
-						allFindings = append(allFindings, findings...)
-						findingsMu.Unlock()
-					}
+			for job := range jobs {
+				// Pausing the scan from the control interface holds the workers here between
+				// tests; the client holds the requests of tests already running.
+				if m.httpClient.WaitResumed(ctx) != nil {
+					continue // Drain the queue without scanning.
+				}
+				findings := m.runScanJob(ctx, job, scannerClients[job.scanner.Name()])
+				if m.options.Status != nil {
+					m.options.Status.CompleteWork(job.workItems())
+				}
+				if len(findings) > 0 {
+					findingsMu.Lock()
+					allFindings = append(allFindings, findings...)
+					findingsMu.Unlock()
 				}
 			}
 		}()
 	}
-
-	for _, req := range finalRequests {
-		jobs <- req
-	}
-	close(jobs)
 	wg.Wait()
 
-	done <- true // Stop the spinner
-	// --- End Spinner Stop ---
+	if ctx.Err() != nil {
+		m.logger.Warn("ScannerManager: Scan interrupted (%v). Reporting partial results.", ctx.Err())
+	}
 
+	m.options.SkipRules.LogSummary(m.logger)
+	m.options.InertParams.LogSummary(m.logger)
+	m.options.Coverage.LogSummary(m.logger)
 	m.logger.Info("ScannerManager: All scanning workers finished. Found %d total potential vulnerabilities.", len(allFindings))
 	return allFindings
 }
 
-// getReflectionSignature is a new helper function to create a "fingerprint".
-// It sends a probe value in a parameter and analyzes how it's reflected in the response
-// to create a unique signature for reflection behavior.
-func (m *Manager) getReflectionSignature(req crawler.ParameterizedRequest, paramName, probeValue string) string {
-	var testURL string
-	var reqBody io.Reader
+// scanJob is the test of one request by one scanner.
+type scanJob struct {
+	scanner Scanner
+	req     crawler.ParameterizedRequest // Without the inert parameters the scanner skips.
+	crawled crawler.ParameterizedRequest // The request as crawled, for the Coverage of the pair.
+	key     string                       // TestKey of the pair, from the request as crawled.
+}
 
-	originalParams, _ := url.ParseQuery(req.FormPostData)
-	if req.Method == "GET" {
-		parsedURL, err := url.Parse(req.URL)
-		if err != nil {
-			m.logger.Debug("SmartTargeting: Failed to parse URL %s for probe. Skipping.", req.URL)
-			return "error_parsing_url"
-		}
-		queryParams := parsedURL.Query()
-		queryParams.Set(paramName, probeValue)
-		parsedURL.RawQuery = queryParams.Encode()
-		testURL = parsedURL.String()
-	} else { // POST
-		testURL = req.URL
-		formData := url.Values{}
-		for k, v := range originalParams {
-			formData[k] = v
-		}
-		formData.Set(paramName, probeValue)
-		reqBody = strings.NewReader(formData.Encode())
+// workItems returns the number of work items of the job reported to a StatusReporter: one per
+// parameter, at least one.
+func (j scanJob) workItems() int {
+	return max(len(j.req.ParamNames), 1)
+}
+
+// runScanJob runs one scanner against one request and returns its findings, recording the pair
+// with the ProgressTracker once it has completed.
+func (m *Manager) runScanJob(ctx context.Context, job scanJob, client *httpclient.Client) []VulnerabilityResult {
+	// Tests against a host given up after blocking the scan, or skipped from the control
+	// interface, and all tests once the authenticated session was lost, are skipped, and left
+	// untested for a resumed scan.
+	if client.HostAborted(job.req.URL) {
+		m.options.Coverage.complete(m.moduleName(job.scanner), job.crawled, httpclient.ErrBlocked)
+		return nil
+	}
+	if client.HostSkipped(job.req.URL) {
+		m.options.Coverage.complete(m.moduleName(job.scanner), job.crawled, httpclient.ErrHostSkipped)
+		return nil
+	}
+	if client.SessionLost() {
+		m.options.Coverage.complete(m.moduleName(job.scanner), job.crawled, httpclient.ErrSessionLost)
+		return nil
+	}
+	scanClient := m.options.CSRFTokens.Bind(client, job.req)
+	scanOpts := m.options
+	scanOpts.Client = scanClient
+	started := time.Now()
+	findings, err := job.scanner.Scan(ctx, job.req, scanClient, m.logger, scanOpts)
+	m.observe(job.scanner, time.Since(started), findings)
+	if errors.Is(err, httpclient.ErrBlocked) || errors.Is(err, httpclient.ErrHostSkipped) || errors.Is(err, httpclient.ErrSessionLost) {
+		m.logger.Debug("Scanner %s stopped for %s: %v", job.scanner.Name(), job.req.URL, err)
+	} else if err != nil && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		m.logger.Error("Scanner %s failed for %s: %v", job.scanner.Name(), job.req.URL, err)
+		m.errorCounts[job.scanner.Name()].Add(1)
+	}
+	// Pairs cut short by cancellation are left out of the coverage: they were not tested.
+	if ctx.Err() == nil {
+		m.options.Coverage.complete(m.moduleName(job.scanner), job.crawled, err)
 	}
+	// Findings are kept even on error: a cancelled scanner returns what it found so far.
+	PrepareRawEvidence(findings, m.options.MaxRawResponseBytes)
+	Classify(findings)
+	m.options.Overrides.Apply(findings)
+	m.emit(findings)
+	// A pair cut short by cancellation, by its host being given up or skipped or by the loss of
+	// the session is tested again when the scan is resumed.
+	if m.options.Progress != nil && ctx.Err() == nil && !client.HostAborted(job.req.URL) && !client.HostSkipped(job.req.URL) && !client.SessionLost() {
+		m.options.Progress.MarkTested(job.key, findings)
+	}
+	return findings
+}
 
-	// --- KEY FIX: Adding missing error handling ---
-	httpRequest, err := http.NewRequest(req.Method, testURL, reqBody)
-	if err != nil {
-		m.logger.Debug("SmartTargeting: Could not create probe request for %s: %v", testURL, err)
-		return "error_creating_request" // Return a unique error fingerprint
+// observe tells the ScanObserver of the scanner options, if any, about a test of s.
+func (m *Manager) observe(s interface{ Name() string }, elapsed time.Duration, findings []VulnerabilityResult) {
+	if m.options.Timings != nil {
+		m.options.Timings.ObserveScan(m.moduleName(s), elapsed, len(findings))
 	}
+}
 
-	if req.Method == "POST" {
-		httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+// emit passes findings to the FindingSink of the scanner options, if any.
+func (m *Manager) emit(findings []VulnerabilityResult) {
+	if len(findings) == 0 {
+		return
 	}
+	if m.options.Findings != nil {
+		m.options.Findings.Emit(findings)
+	}
+	if m.options.Status != nil {
+		m.options.Status.AddFindings(len(findings))
+	}
+}
 
-	resp, err := m.httpClient.Do(httpRequest)
-	if err != nil {
-		if resp != nil && resp.Body != nil {
-			resp.Body.Close()
+// inScope drops the requests whose URL is outside m.options.Scope. The crawler only records
+// in-scope requests, but requests built later (e.g., from a GraphQL schema) pass through here too.
+func (m *Manager) inScope(requests []crawler.ParameterizedRequest) []crawler.ParameterizedRequest {
+	if m.options.Scope == nil {
+		return requests
+	}
+	filtered := make([]crawler.ParameterizedRequest, 0, len(requests))
+	for _, req := range requests {
+		if !m.options.Scope.Allows(req.URL) {
+			m.logger.Debug("ScannerManager: Skipping out-of-scope request %s %s", req.Method, req.URL)
+			m.SkipRequests([]crawler.ParameterizedRequest{req}, SkipReasonScope)
+			continue
 		}
-		return "error_fetching"
+		filtered = append(filtered, req)
+	}
+	if skipped := len(requests) - len(filtered); skipped > 0 {
+		m.logger.Info("ScannerManager: Skipped %d out-of-scope request(s).", skipped)
 	}
-	defer resp.Body.Close()
+	return filtered
+}
 
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	responseBody := string(bodyBytes)
+// SkipRequests records with the Coverage of the scanner options that every registered scanner
+// left requests untested for reason, e.g. requests collapsed before the scan.
+func (m *Manager) SkipRequests(requests []crawler.ParameterizedRequest, reason SkipReason) {
+	for _, req := range requests {
+		for _, s := range m.scanners {
+			m.options.Coverage.SkipRequest(m.moduleName(s), req, reason)
+		}
+	}
+}
+
+// moduleName returns the module name of a registered scanner, or its name for a scanner
+// registered without one.
+func (m *Manager) moduleName(s interface{ Name() string }) string {
+	if module := m.moduleNames[s.Name()]; module != "" {
+		return module
+	}
+	return s.Name()
+}
+
+// RequestCounts returns the number of HTTP requests (including retries) each registered
+// scanner has sent, keyed by scanner name.
+func (m *Manager) RequestCounts() map[string]int64 {
+	counts := make(map[string]int64, len(m.requestCounts))
+	for name, counter := range m.requestCounts {
+		counts[name] = counter.Load()
+	}
+	return counts
+}
 
-	// Create a fingerprint based on how the probeValue is reflected.
-	reflectionCount := strings.Count(responseBody, probeValue)
-	isEncoded := strings.Contains(responseBody, url.QueryEscape(probeValue)) && !strings.Contains(responseBody, probeValue)
+// ErrorCounts returns how many scanner/request pairs failed with an error, per scanner that had
+// any. Requests stopped by cancellation or by a blocking host do not count.
+func (m *Manager) ErrorCounts() map[string]int64 {
+	counts := make(map[string]int64)
+	for name, counter := range m.errorCounts {
+		if n := counter.Load(); n > 0 {
+			counts[name] = n
+		}
+	}
+	return counts
+}
 
-	return fmt.Sprintf("count:%d-encoded:%t", reflectionCount, isEncoded)
+// GetPassiveScanners returns a slice of registered passive scanners.
+func (m *Manager) GetPassiveScanners() []PassiveScanner {
+	return m.passiveScanners
 }
 
 // GetRegisteredScanners returns a slice of registered scanners.
@@ -0,0 +1,305 @@
+package scanner
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingScanner records the URLs it was asked to scan.
+type recordingScanner struct {
+	mu      sync.Mutex
+	scanned []string
+}
+
+func (s *recordingScanner) Name() string { return "Recording Scanner" }
+
+func (s *recordingScanner) Scan(_ context.Context, req crawler.ParameterizedRequest, _ *httpclient.Client, _ *logger.Logger, _ ScannerOptions) ([]VulnerabilityResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scanned = append(s.scanned, req.URL)
+	return nil, nil
+}
+
+func TestRunScansSkipsOutOfScope(t *testing.T) {
+	scope, err := crawler.NewScope("https://example.com", crawler.ScopeOptions{ExcludePatterns: []string{`/admin/`}})
+	require.NoError(t, err)
+	log := logger.NewLogger(logger.ERROR)
+	m := NewManager(httpclient.NewClient(log, httpclient.ClientOptions{}), log, ScannerOptions{Concurrency: 2, Scope: scope})
+	s := &recordingScanner{}
+	m.RegisterScanner(s)
+
+	m.RunScans(context.Background(), []crawler.ParameterizedRequest{
+		{Method: "GET", URL: "https://example.com/search?q=1", ParamNames: []string{"q"}},
+		{Method: "GET", URL: "https://example.com/admin/users?id=1", ParamNames: []string{"id"}},
+		{Method: "GET", URL: "https://api.other.com/items?id=1", ParamNames: []string{"id"}},
+	})
+
+	assert.Equal(t, []string{"https://example.com/search?q=1"}, s.scanned)
+	assert.Equal(t, map[string]int{"exclude: /admin/": 1, "host": 1}, scope.ExcludedByReason())
+}
+
+// mapTracker is an in-memory ProgressTracker.
+type mapTracker struct {
+	mu     sync.Mutex
+	tested map[string][]VulnerabilityResult
+}
+
+func (p *mapTracker) IsTested(key string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	_, ok := p.tested[key]
+	return ok
+}
+
+func (p *mapTracker) MarkTested(key string, findings []VulnerabilityResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.tested[key] = findings
+}
+
+func TestRunScansSkipsTestedPairs(t *testing.T) {
+	requests := []crawler.ParameterizedRequest{
+		{Method: "GET", URL: "https://example.com/search?q=1", ParamNames: []string{"q"}},
+		{Method: "GET", URL: "https://example.com/item?id=1", ParamNames: []string{"id"}},
+	}
+	s := &recordingScanner{}
+	progress := &mapTracker{tested: map[string][]VulnerabilityResult{TestKey(s.Name(), requests[0]): nil}}
+	log := logger.NewLogger(logger.ERROR)
+	m := NewManager(httpclient.NewClient(log, httpclient.ClientOptions{}), log, ScannerOptions{Concurrency: 1, Progress: progress})
+	m.RegisterScanner(s)
+
+	m.RunScans(context.Background(), requests)
+
+	assert.Equal(t, []string{"https://example.com/item?id=1"}, s.scanned)
+	assert.True(t, progress.IsTested(TestKey(s.Name(), requests[1])))
+	assert.NotEqual(t, TestKey(s.Name(), requests[0]), TestKey("Other Scanner", requests[0]))
+}
+
+// rendezvousScanner finishes only once its partner has started, which requires the two to scan
+// the same request concurrently.
+type rendezvousScanner struct {
+	name    string
+	started chan struct{}
+	partner chan struct{}
+}
+
+func (s *rendezvousScanner) Name() string { return s.name }
+
+func (s *rendezvousScanner) Scan(_ context.Context, req crawler.ParameterizedRequest, _ *httpclient.Client, _ *logger.Logger, _ ScannerOptions) ([]VulnerabilityResult, error) {
+	close(s.started)
+	select {
+	case <-s.partner:
+		return []VulnerabilityResult{{VulnerabilityType: s.name, URL: req.URL}}, nil
+	case <-time.After(5 * time.Second):
+		return nil, nil
+	}
+}
+
+func TestRunScansRunsScannersOfARequestConcurrently(t *testing.T) {
+	a := &rendezvousScanner{name: "A", started: make(chan struct{})}
+	b := &rendezvousScanner{name: "B", started: make(chan struct{}), partner: a.started}
+	a.partner = b.started
+	log := logger.NewLogger(logger.ERROR)
+	m := NewManager(httpclient.NewClient(log, httpclient.ClientOptions{}), log, ScannerOptions{Concurrency: 2})
+	m.RegisterScanner(a)
+	m.RegisterScanner(b)
+
+	findings := m.RunScans(context.Background(), []crawler.ParameterizedRequest{{Method: "GET", URL: "https://example.com/search?q=1", ParamNames: []string{"q"}}})
+
+	assert.Len(t, findings, 2)
+}
+
+// sliceSink collects the findings emitted by a Manager.
+type sliceSink struct {
+	mu       sync.Mutex
+	findings []VulnerabilityResult
+}
+
+func (s *sliceSink) Emit(findings []VulnerabilityResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.findings = append(s.findings, findings...)
+}
+
+func TestRunScansEmitsFindings(t *testing.T) {
+	a := &rendezvousScanner{name: "A", started: make(chan struct{})}
+	b := &rendezvousScanner{name: "B", started: make(chan struct{}), partner: a.started}
+	a.partner = b.started
+	sink := &sliceSink{}
+	log := logger.NewLogger(logger.ERROR)
+	m := NewManager(httpclient.NewClient(log, httpclient.ClientOptions{}), log, ScannerOptions{Concurrency: 2, Findings: sink})
+	m.RegisterScanner(a)
+	m.RegisterScanner(b)
+
+	findings := m.RunScans(context.Background(), []crawler.ParameterizedRequest{{Method: "GET", URL: "https://example.com/search?q=1", ParamNames: []string{"q"}}})
+
+	assert.ElementsMatch(t, findings, sink.findings)
+	assert.Len(t, sink.findings, 2)
+}
+
+// countingStatus is a StatusReporter that counts what it is told.
+type countingStatus struct {
+	total, completed, findings atomic.Int64
+}
+
+func (s *countingStatus) AddWork(items int)      { s.total.Add(int64(items)) }
+func (s *countingStatus) CompleteWork(items int) { s.completed.Add(int64(items)) }
+func (s *countingStatus) AddFindings(n int)      { s.findings.Add(int64(n)) }
+
+func TestRunScansReportsStatus(t *testing.T) {
+	a := &rendezvousScanner{name: "A", started: make(chan struct{})}
+	b := &rendezvousScanner{name: "B", started: make(chan struct{}), partner: a.started}
+	a.partner = b.started
+	status := &countingStatus{}
+	log := logger.NewLogger(logger.ERROR)
+	m := NewManager(httpclient.NewClient(log, httpclient.ClientOptions{}), log, ScannerOptions{Concurrency: 2, Status: status})
+	m.RegisterScanner(a)
+	m.RegisterScanner(b)
+
+	m.RunScans(context.Background(), []crawler.ParameterizedRequest{{Method: "GET", URL: "https://example.com/search?q=1&page=2", ParamNames: []string{"q", "page"}}})
+
+	// One item per parameter of each scanner/request pair.
+	assert.EqualValues(t, 4, status.total.Load())
+	assert.EqualValues(t, 4, status.completed.Load())
+	assert.EqualValues(t, 2, status.findings.Load())
+}
+
+// failingScanner fails for the requests whose URL contains "fail" and is cancelled for those
+// containing "cancel".
+type failingScanner struct{}
+
+func (failingScanner) Name() string { return "Failing Scanner" }
+
+func (failingScanner) Scan(_ context.Context, req crawler.ParameterizedRequest, _ *httpclient.Client, _ *logger.Logger, _ ScannerOptions) ([]VulnerabilityResult, error) {
+	switch {
+	case strings.Contains(req.URL, "fail"):
+		return nil, errors.New("malformed response")
+	case strings.Contains(req.URL, "cancel"):
+		return nil, context.Canceled
+	}
+	return nil, nil
+}
+
+func TestRunScansCountsScannerErrors(t *testing.T) {
+	log := logger.NewLogger(logger.SUCCESS)
+	m := NewManager(httpclient.NewClient(log, httpclient.ClientOptions{}), log, ScannerOptions{Concurrency: 2})
+	m.RegisterScanner(failingScanner{})
+	m.RegisterScanner(&recordingScanner{})
+
+	m.RunScans(context.Background(), []crawler.ParameterizedRequest{
+		{Method: "GET", URL: "https://example.com/fail?a=1", ParamNames: []string{"a"}},
+		{Method: "GET", URL: "https://example.com/fail?b=1", ParamNames: []string{"b"}},
+		{Method: "GET", URL: "https://example.com/cancel?c=1", ParamNames: []string{"c"}},
+		{Method: "GET", URL: "https://example.com/ok?d=1", ParamNames: []string{"d"}},
+	})
+
+	assert.Equal(t, map[string]int64{"Failing Scanner": 2}, m.ErrorCounts())
+}
+
+// paramScanner records the parameters it was asked to test.
+type paramScanner struct {
+	name   string
+	mu     sync.Mutex
+	params []string
+}
+
+func (s *paramScanner) Name() string { return s.name }
+
+func (s *paramScanner) Scan(_ context.Context, req crawler.ParameterizedRequest, _ *httpclient.Client, _ *logger.Logger, _ ScannerOptions) ([]VulnerabilityResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.params = append(s.params, req.ParamNames...)
+	return nil, nil
+}
+
+func TestRunScansSkipsInertParams(t *testing.T) {
+	useTestRegistry(t)
+	req := crawler.ParameterizedRequest{Method: "GET", URL: "https://example.com/search?q=1&utm=x", ParamNames: []string{"q", "utm"}, ParamLocations: []string{"query", "query"}}
+	inert := NewInertParams()
+	inert.AddChecked(2)
+	inert.Mark(req, "utm")
+	progress := &mapTracker{tested: make(map[string][]VulnerabilityResult)}
+	log := logger.NewLogger(logger.ERROR)
+	m := NewManager(httpclient.NewClient(log, httpclient.ClientOptions{}), log, ScannerOptions{Concurrency: 1, InertParams: inert, Progress: progress})
+	xss := &paramScanner{name: "XSS"}
+	blind := &paramScanner{name: "Blind SSRF"}
+	m.RegisterModule("xss-reflected", xss)
+	m.RegisterModule("blindssrf", blind)
+
+	m.RunScans(context.Background(), []crawler.ParameterizedRequest{req})
+
+	assert.Equal(t, []string{"q"}, xss.params)
+	assert.Equal(t, []string{"q", "utm"}, blind.params, "modules with blind sinks test inert parameters")
+	assert.Contains(t, progress.tested, TestKey(xss.Name(), req), "pairs are recorded under the request as crawled")
+	summary := inert.Summary()
+	assert.Equal(t, 2, summary.Checked)
+	assert.Equal(t, []InertParam{{Method: "GET", URL: req.URL, Param: "utm"}}, summary.Inert)
+	assert.Equal(t, int64(1), summary.Skipped)
+	assert.Equal(t, []string{"blindssrf"}, summary.TestedBy)
+
+	assert.True(t, inert.Skip(req, "utm"))
+	assert.False(t, inert.Skip(req, "q"))
+	assert.Equal(t, int64(2), inert.Summary().Skipped)
+	var none *InertParams
+	assert.False(t, none.IsInert(req, "utm"))
+}
+
+// skippingScanner reports the parameter "token" as skipped by a skip rule and tests the others.
+type skippingScanner struct{}
+
+func (skippingScanner) Name() string { return "Skipping Scanner" }
+
+func (skippingScanner) Scan(_ context.Context, req crawler.ParameterizedRequest, _ *httpclient.Client, _ *logger.Logger, opts ScannerOptions) ([]VulnerabilityResult, error) {
+	if slices.Contains(req.ParamNames, "token") {
+		opts.Coverage.Skip("skipping", req, "token", SkipReasonRule)
+	}
+	return nil, nil
+}
+
+func TestRunScansRecordsCoverage(t *testing.T) {
+	useTestRegistry(t)
+	scope, err := crawler.NewScope("https://example.com", crawler.ScopeOptions{})
+	require.NoError(t, err)
+	search := crawler.ParameterizedRequest{Method: "GET", URL: "https://example.com/search?q=1&token=x&utm=y", ParamNames: []string{"q", "token", "utm"}}
+	inert := NewInertParams()
+	inert.Mark(search, "utm")
+	coverage := NewCoverage()
+	log := logger.NewLogger(logger.ERROR)
+	m := NewManager(httpclient.NewClient(log, httpclient.ClientOptions{}), log, ScannerOptions{Concurrency: 2, Scope: scope, InertParams: inert, Coverage: coverage})
+	m.RegisterModule("skipping", skippingScanner{})
+	m.RegisterScanner(failingScanner{})
+
+	m.SkipRequests([]crawler.ParameterizedRequest{{Method: "GET", URL: "https://example.com/item/2", ParamNames: []string{"ref"}}}, SkipReasonDuplicate)
+	m.RunScans(context.Background(), []crawler.ParameterizedRequest{
+		search,
+		{Method: "GET", URL: "https://example.com/fail"},
+		{Method: "GET", URL: "https://other.com/search?q=1", ParamNames: []string{"q"}},
+	})
+
+	summary := coverage.Summary(true)
+	assert.Equal(t, 4, summary.Tested)
+	assert.Equal(t, 1, summary.Errored)
+	assert.Equal(t, map[SkipReason]int{SkipReasonRule: 1, SkipReasonInert: 2, SkipReasonScope: 2, SkipReasonDuplicate: 2}, summary.Skipped)
+	assert.Contains(t, summary.Entries, CoverageEntry{Module: "Failing Scanner", Method: "GET", URL: "https://example.com/fail", Status: CoverageErrored, Error: "malformed response"})
+	assert.Contains(t, summary.Entries, CoverageEntry{Module: "skipping", Method: "GET", URL: search.URL, Param: "token", Status: CoverageSkipped, Reason: SkipReasonRule})
+	assert.Contains(t, summary.Entries, CoverageEntry{Module: "skipping", Method: "GET", URL: search.URL, Param: "q", Status: CoverageTested})
+	assert.Equal(t, []SkipCount{{SkipReasonRule, 1}, {SkipReasonScope, 2}, {SkipReasonDuplicate, 2}, {SkipReasonInert, 2}}, summary.Reasons())
+	assert.Empty(t, coverage.Summary(false).Entries)
+
+	var none *Coverage
+	none.Skip("sqli", search, "q", SkipReasonRule)
+	assert.Zero(t, none.Summary(true).Tested)
+}
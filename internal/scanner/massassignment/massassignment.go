@@ -6,8 +6,12 @@ import (
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"Dursgo/internal/scanner/requtil"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -16,6 +20,22 @@ import (
 	"time"
 )
 
+// Evidence classes of the findings on JSON requests, from the strongest.
+const (
+	evidencePersisted    = "persisted"
+	evidenceEchoed       = "echoed"
+	evidenceDifferential = "differential"
+)
+
+// evidenceRanks order the evidence classes, the strongest first.
+var evidenceRanks = map[string]int{evidencePersisted: 0, evidenceEchoed: 1, evidenceDifferential: 2}
+
+// severityRanks order the severities of the payloads, the highest first.
+var severityRanks = map[string]int{"High": 0, "Medium": 1, "Low": 2}
+
+// idFields are the fields of a response object identifying the resource created or updated.
+var idFields = []string{"id", "_id", "uuid", "ID", "Id"}
+
 // MassAssignmentScanner implements the Scanner interface for Mass Assignment vulnerabilities.
 type MassAssignmentScanner struct {
 	mu         sync.Mutex
@@ -29,6 +49,18 @@ func NewMassAssignmentScanner() *MassAssignmentScanner {
 	}
 }
 
+// ModuleName selects the scanner (-s) and holds its options in config.yaml.
+const ModuleName = "massassignment"
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           ModuleName,
+		Order:          160,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewMassAssignmentScanner() },
+	})
+}
+
 // Name returns the scanner's name.
 func (s *MassAssignmentScanner) Name() string {
 	return "Mass Assignment Scanner"
@@ -58,22 +90,45 @@ func isPotentialTarget(targetURL string, log *logger.Logger) bool {
 	return false
 }
 
+// isJSONWrite reports whether req is a crawled JSON request writing an object (POST, PUT or
+// PATCH), whose body the extra fields are added to.
+func isJSONWrite(req crawler.ParameterizedRequest) bool {
+	switch req.Method {
+	case "POST", "PUT", "PATCH":
+	default:
+		return false
+	}
+	return req.IsJSON() && !req.IsGraphQL() && strings.HasPrefix(strings.TrimSpace(req.RawBody), "{")
+}
+
 // Scan performs the Mass Assignment scan.
-// It identifies potential target endpoints and attempts to modify restricted fields
-// by sending crafted JSON payloads. It then verifies if the changes were successful.
-func (s *MassAssignmentScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
-	if !isPotentialTarget(req.URL, log) {
+// Crawled JSON POST, PUT and PATCH requests are replayed with privileged-looking fields added to
+// their legitimate body. Other endpoints that look like they modify data get crafted JSON
+// payloads sent blindly, and a GET of the same URL verifies whether the changes were made.
+func (s *MassAssignmentScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	jsonWrite := isJSONWrite(req)
+	if !jsonWrite && !isPotentialTarget(req.URL, log) {
 		return nil, nil
 	}
 
+	key := req.URL
+	if jsonWrite {
+		key = req.Method + " " + req.URL
+	}
 	s.mu.Lock()
-	if s.testedURLs[req.URL] {
+	if s.testedURLs[key] {
 		s.mu.Unlock()
 		return nil, nil
 	}
-	s.testedURLs[req.URL] = true
+	s.testedURLs[key] = true
 	s.mu.Unlock()
 
+	if jsonWrite {
+		log.Debug("MassAssignment: Testing extra fields in the JSON body of %s %s", req.Method, req.URL)
+		return s.scanJSON(ctx, req, client.WithRequestBudget(opts.MaxRequestsPerParam), log, opts)
+	}
+
 	log.Debug("MassAssignment: Found potential target: %s. Starting test...", req.URL)
 
 	for _, httpMethod := range []string{"POST", "PUT"} {
@@ -86,7 +141,7 @@ func (s *MassAssignmentScanner) Scan(req crawler.ParameterizedRequest, client *h
 				continue
 			}
 
-			injectReq, _ := http.NewRequest(httpMethod, req.URL, bytes.NewBuffer(jsonPayload))
+			injectReq, _ := http.NewRequestWithContext(ctx, httpMethod, req.URL, bytes.NewBuffer(jsonPayload))
 			injectReq.Header.Set("Content-Type", "application/json")
 
 			log.Debug("MassAssignment: Sending %s to %s with payload: %s", httpMethod, req.URL, string(jsonPayload))
@@ -101,7 +156,7 @@ func (s *MassAssignmentScanner) Scan(req crawler.ParameterizedRequest, client *h
 
 			time.Sleep(250 * time.Millisecond)
 
-			verifyReq, _ := http.NewRequest("GET", req.URL, nil)
+			verifyReq, _ := http.NewRequestWithContext(ctx, "GET", req.URL, nil)
 			verifyResp, err := client.Do(verifyReq)
 			if err != nil {
 				if verifyResp != nil && verifyResp.Body != nil {
@@ -117,42 +172,330 @@ func (s *MassAssignmentScanner) Scan(req crawler.ParameterizedRequest, client *h
 				continue
 			}
 
-			if val, ok := result[testCase.Key]; ok {
-				vulnerable := false
-				switch testCase.CheckType {
-				case "bool_true":
-					if bVal, ok := val.(bool); ok && bVal {
-						vulnerable = true
-					}
-				case "string_match":
-					if sVal, ok := val.(string); ok && sVal == testCase.Value.(string) {
-						vulnerable = true
-					}
-				case "int_match":
-					if fVal, ok := val.(float64); ok && int(fVal) == testCase.Value.(int) {
-						vulnerable = true
-					}
-				}
+			if val, ok := result[testCase.Key]; ok && matches(val, testCase) {
+				details := fmt.Sprintf("Successfully injected and modified the restricted field '%s' to value '%v' via a %s request.", testCase.Key, testCase.Value, httpMethod)
+				log.Success("Mass Assignment found at %s!", req.URL)
 
-				if vulnerable {
-					details := fmt.Sprintf("Successfully injected and modified the restricted field '%s' to value '%v' via a %s request.", testCase.Key, testCase.Value, httpMethod)
-					log.Success("Mass Assignment found at %s!", req.URL)
-
-					return []scanner.VulnerabilityResult{{
-						VulnerabilityType: "Mass Assignment",
-						URL:               req.URL,
-						Payload:           string(jsonPayload),
-						Location:          "JSON Body",
-						Details:           details,
-						ScannerName:       s.Name(),
-						Severity:          "High",
-						Evidence:          fmt.Sprintf("Key '%s' changed to '%v' and reflected in GET response", testCase.Key, testCase.Value),
-						Remediation:       "Implement whitelisting for mass assignable fields or use explicit field binding. Avoid binding user input directly to model objects.",
-					}}, nil
-				}
+				return []scanner.VulnerabilityResult{{
+					VulnerabilityType: "Mass Assignment",
+					URL:               req.URL,
+					Payload:           string(jsonPayload),
+					Location:          "JSON Body",
+					Details:           details,
+					ScannerName:       ModuleName,
+					Severity:          testCase.Severity,
+					Evidence:          fmt.Sprintf("Key '%s' changed to '%v' and reflected in GET response", testCase.Key, testCase.Value),
+					Remediation:       remediation,
+				}}, nil
 			}
 		}
 	}
 
 	return nil, nil
 }
+
+// remediation is the remediation of every Mass Assignment finding.
+const remediation = "Implement whitelisting for mass assignable fields or use explicit field binding. Avoid binding user input directly to model objects."
+
+// matches reports whether a field value read back from a response is the injected value of
+// testCase.
+func matches(val interface{}, testCase payloads.MassAssignmentTest) bool {
+	switch testCase.CheckType {
+	case "bool_true":
+		bVal, ok := val.(bool)
+		return ok && bVal
+	case "bool_false":
+		bVal, ok := val.(bool)
+		return ok && !bVal
+	case "string_match":
+		sVal, ok := val.(string)
+		return ok && sVal == testCase.Value.(string)
+	case "int_match":
+		fVal, ok := val.(float64)
+		return ok && int(fVal) == testCase.Value.(int)
+	}
+	return false
+}
+
+// reply is a response to a replayed JSON request.
+type reply struct {
+	status   int
+	body     string
+	object   map[string]interface{} // The decoded body, if it is a JSON object.
+	location string                 // Location header.
+	exchange scanner.Exchange
+}
+
+// acceptedField is a field the server accepted, with the evidence of it.
+type acceptedField struct {
+	testCase payloads.MassAssignmentTest
+	class    string // One of the evidence* constants.
+	evidence string
+	payload  string
+	exchange scanner.Exchange
+}
+
+// scanJSON replays req with each payload field added to its body and looks for the field being
+// accepted: its value echoed in the response object, shown by a GET of the created or updated
+// resource, or a response that differs from the one to a garbage field. The fields accepted
+// are reported in one finding, whose severity is that of the most sensitive field.
+func (s *MassAssignmentScanner) scanJSON(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	original, err := sendWithFields(ctx, req, client, nil)
+	if err != nil {
+		return nil, nil
+	}
+	garbageField := payloads.MassAssignmentGarbageField()
+	garbage, err := sendWithFields(ctx, req, client, map[string]interface{}{garbageField: "dursgo"})
+	if err != nil {
+		return nil, nil
+	}
+	cmp := compare.New(opts, log, "MassAssignment")
+	originalObject := originalBody(req)
+	// An endpoint echoing fields it does not know echoes the injected ones too; only a GET of
+	// the resource then tells whether they were bound.
+	_, echoesUnknown := findField(garbage.object, garbageField)
+	if echoesUnknown {
+		log.Debug("MassAssignment: %s %s echoes unknown fields; relying on follow-up GETs", req.Method, req.URL)
+	}
+
+	var accepted []acceptedField
+	for _, testCase := range payloads.MassAssignmentPayloads {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if strings.Contains(testCase.Key, "[") {
+			continue // Form-style nesting; JSON bodies nest objects instead.
+		}
+		if _, legitimate := originalObject[testCase.Key]; legitimate {
+			continue
+		}
+		if val, ok := findField(original.object, testCase.Key); ok && matches(val, testCase) {
+			continue // Already the value injected: nothing to tell apart.
+		}
+
+		fields := map[string]interface{}{testCase.Key: testCase.Value}
+		r, err := sendWithFields(ctx, req, client, fields)
+		if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			break
+		}
+		if err != nil {
+			continue
+		}
+		payload, _ := json.Marshal(fields)
+		field := acceptedField{testCase: testCase, payload: string(payload), exchange: r.exchange}
+
+		if resourceURL := locateResource(req, r); resourceURL != "" {
+			if val, ok, exchange := s.fetchField(ctx, client, resourceURL, testCase.Key); ok && matches(val, testCase) {
+				field.class, field.exchange = evidencePersisted, exchange
+				field.evidence = fmt.Sprintf("GET %s returned '%s': %v", resourceURL, testCase.Key, val)
+				accepted = append(accepted, field)
+				continue
+			}
+		}
+		if echoesUnknown {
+			continue
+		}
+		if val, ok := findField(r.object, testCase.Key); ok && matches(val, testCase) && isSuccess(r.status) {
+			field.class = evidenceEchoed
+			field.evidence = fmt.Sprintf("Response object echoed '%s': %v", testCase.Key, val)
+			accepted = append(accepted, field)
+			continue
+		}
+		// A well-built API treats a privileged field like any unknown one; answering it
+		// differently shows that the field is bound.
+		if isSuccess(r.status) && (r.status != garbage.status || cmp.IsDifferent(withoutIDs(garbage), withoutIDs(r))) {
+			field.class = evidenceDifferential
+			field.evidence = fmt.Sprintf("'%s': HTTP %d; garbage field '%s': HTTP %d, with a different response", testCase.Key, r.status, garbageField, garbage.status)
+			accepted = append(accepted, field)
+		}
+	}
+	if len(accepted) == 0 {
+		return nil, nil
+	}
+	return []scanner.VulnerabilityResult{s.newJSONResult(req, accepted)}, nil
+}
+
+// newJSONResult returns the finding on the fields accepted by req, led by the most sensitive
+// field with the strongest evidence.
+func (s *MassAssignmentScanner) newJSONResult(req crawler.ParameterizedRequest, accepted []acceptedField) scanner.VulnerabilityResult {
+	best := accepted[0]
+	for _, field := range accepted[1:] {
+		if severityRanks[field.testCase.Severity] < severityRanks[best.testCase.Severity] ||
+			(severityRanks[field.testCase.Severity] == severityRanks[best.testCase.Severity] && evidenceRanks[field.class] < evidenceRanks[best.class]) {
+			best = field
+		}
+	}
+	var names []string
+	for _, field := range accepted {
+		names = append(names, fmt.Sprintf("'%s' (%s)", field.testCase.Key, field.class))
+	}
+
+	vulnType, severity := "Mass Assignment", best.testCase.Severity
+	var how string
+	switch best.class {
+	case evidencePersisted:
+		how = "and a GET of the resource shows the injected value, so it was stored"
+	case evidenceEchoed:
+		vulnType += " (Reflected)"
+		how = "and the response object echoes the injected value; whether it was stored could not be checked"
+	case evidenceDifferential:
+		vulnType += " (Differential)"
+		how = "and the response differs from the response to an unknown garbage field, so the field is processed; its value was not read back"
+		if severity == "High" {
+			severity = "Medium"
+		} else {
+			severity = "Low"
+		}
+	}
+	details := fmt.Sprintf("The %s request to %s accepted the field '%s' added to its legitimate JSON body, %s. Fields accepted: %s.",
+		req.Method, req.URL, best.testCase.Key, how, strings.Join(names, ", "))
+
+	vuln := scanner.VulnerabilityResult{
+		VulnerabilityType: vulnType,
+		URL:               req.URL,
+		Parameter:         best.testCase.Key,
+		Payload:           best.payload,
+		Location:          "JSON Body",
+		Details:           details,
+		Severity:          severity,
+		Evidence:          best.evidence,
+		Remediation:       remediation,
+		ScannerName:       ModuleName,
+	}
+	vuln.SetExchange(best.exchange)
+	return vuln
+}
+
+// sendWithFields replays req with fields set in its JSON body; nil replays it unchanged.
+func sendWithFields(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, fields map[string]interface{}) (reply, error) {
+	if len(fields) > 0 {
+		body, err := requtil.SetJSONFields(req.RawBody, fields)
+		if err != nil {
+			return reply{}, err
+		}
+		req.RawBody = body
+	}
+	params, err := requtil.Params(req)
+	if err != nil {
+		return reply{}, err
+	}
+	httpReq, resp, body, err := requtil.Do(ctx, req, client, params)
+	if resp == nil {
+		return reply{}, err
+	}
+	if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
+		return reply{}, err
+	}
+	r := reply{status: resp.StatusCode, body: string(body), location: resp.Header.Get("Location"), exchange: scanner.CaptureExchange(httpReq, resp, body)}
+	json.Unmarshal(body, &r.object)
+	return r, nil
+}
+
+// withoutIDs returns the body of r without the ids of its object, which differ between the
+// resources created by identical requests.
+func withoutIDs(r reply) string {
+	if r.object == nil {
+		return r.body
+	}
+	object := make(map[string]interface{}, len(r.object))
+	for key, val := range r.object {
+		object[key] = val
+	}
+	for _, name := range idFields {
+		delete(object, name)
+	}
+	body, _ := json.Marshal(object)
+	return string(body)
+}
+
+// originalBody returns the top-level object of the legitimate body of req.
+func originalBody(req crawler.ParameterizedRequest) map[string]interface{} {
+	var object map[string]interface{}
+	json.Unmarshal([]byte(req.RawBody), &object)
+	return object
+}
+
+// findField returns the field key of a response object, at its top level or in an object it
+// wraps (e.g. {"data": {...}} or {"user": {...}}).
+func findField(object map[string]interface{}, key string) (interface{}, bool) {
+	if val, ok := object[key]; ok {
+		return val, true
+	}
+	for _, child := range object {
+		if nested, ok := child.(map[string]interface{}); ok {
+			if val, ok := nested[key]; ok {
+				return val, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// locateResource returns the URL of the resource created or updated by req: the Location of
+// the response, the URL of a PUT or PATCH request, or the URL of a POST request followed by the
+// id of the created object. It returns "" when the resource cannot be located.
+func locateResource(req crawler.ParameterizedRequest, r reply) string {
+	base, err := url.Parse(req.URL)
+	if err != nil {
+		return ""
+	}
+	if r.location != "" {
+		if location, err := base.Parse(r.location); err == nil {
+			return location.String()
+		}
+	}
+	if req.Method != "POST" {
+		return req.URL
+	}
+	for _, name := range idFields {
+		val, ok := findField(r.object, name)
+		if !ok {
+			continue
+		}
+		var id string
+		switch v := val.(type) {
+		case string:
+			id = v
+		case float64:
+			id = fmt.Sprintf("%.0f", v)
+		}
+		if id == "" {
+			continue
+		}
+		resource := *base
+		resource.RawQuery = ""
+		resource.Path = strings.TrimSuffix(base.Path, "/") + "/" + url.PathEscape(id)
+		resource.RawPath = ""
+		return resource.String()
+	}
+	return ""
+}
+
+// fetchField sends a GET request for resourceURL and returns the field key of the JSON object
+// answered.
+func (s *MassAssignmentScanner) fetchField(ctx context.Context, client *httpclient.Client, resourceURL, key string) (interface{}, bool, scanner.Exchange) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", resourceURL, nil)
+	if err != nil {
+		return nil, false, scanner.Exchange{}
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, false, scanner.Exchange{}
+	}
+	defer resp.Body.Close()
+	body, err := requtil.ReadBody(client, resp)
+	if err != nil || !isSuccess(resp.StatusCode) {
+		return nil, false, scanner.Exchange{}
+	}
+	var object map[string]interface{}
+	if json.Unmarshal(body, &object) != nil {
+		return nil, false, scanner.Exchange{}
+	}
+	val, ok := findField(object, key)
+	return val, ok, scanner.CaptureExchange(httpReq, resp, body)
+}
+
+// isSuccess reports whether status is a 2xx status.
+func isSuccess(status int) bool {
+	return status >= 200 && status < 300
+}
@@ -0,0 +1,112 @@
+package massassignment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// api is a user API. POST /api/users creates a user and answers its id, GET /api/users/{id}
+// shows it and PUT /api/profile updates the profile and answers it.
+type api struct {
+	mu    sync.Mutex
+	users []map[string]interface{}
+	// bind reports whether a field of a request body is stored in the model.
+	bind func(field string) bool
+}
+
+func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/api/users":
+		user := a.decode(r)
+		a.users = append(a.users, user)
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": len(a.users), "name": user["name"]})
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/api/users/"):
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/api/users/"))
+		if err != nil || id < 1 || id > len(a.users) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(a.users[id-1])
+	case r.Method == "PUT" && r.URL.Path == "/api/profile":
+		json.NewEncoder(w).Encode(a.decode(r))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// decode returns the user described by the body of r, with the defaults of the fields not set.
+func (a *api) decode(r *http.Request) map[string]interface{} {
+	var body map[string]interface{}
+	json.NewDecoder(r.Body).Decode(&body)
+	user := map[string]interface{}{"name": body["name"], "role": "user", "is_admin": false}
+	for field, value := range body {
+		if a.bind(field) {
+			user[field] = value
+		}
+	}
+	return user
+}
+
+func TestScanJSONBodies(t *testing.T) {
+	model := map[string]bool{"name": true, "role": true, "is_admin": true, "verified": true, "balance": true}
+	bindModel := func(field string) bool { return model[field] }
+	bindName := func(field string) bool { return field == "name" }
+	tests := []struct {
+		name         string
+		bind         func(string) bool
+		method, path string
+		wantType     string
+		wantSeverity string
+	}{
+		{name: "Created user shows the fields", bind: bindModel, method: "POST", path: "/api/users", wantType: "Mass Assignment", wantSeverity: "High"},
+		{name: "Updated profile echoes the fields", bind: bindModel, method: "PUT", path: "/api/profile", wantType: "Mass Assignment (Reflected)", wantSeverity: "High"},
+		{name: "Only the name is bound", bind: bindName, method: "POST", path: "/api/users"},
+		{name: "Only the name is bound on update", bind: bindName, method: "PUT", path: "/api/profile"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(&api{bind: tt.bind})
+			defer server.Close()
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{})
+			req := crawler.ParameterizedRequest{Method: tt.method, URL: server.URL + tt.path, ContentType: "application/json", RawBody: `{"name":"alice"}`}
+
+			s := NewMassAssignmentScanner()
+			findings, err := s.Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			if tt.wantType == "" {
+				assert.Empty(t, findings)
+				return
+			}
+			require.Len(t, findings, 1)
+			assert.Equal(t, tt.wantType, findings[0].VulnerabilityType)
+			assert.Equal(t, tt.wantSeverity, findings[0].Severity)
+			assert.Equal(t, "JSON Body", findings[0].Location)
+			assert.Contains(t, findings[0].Details, "'role'")
+			assert.NotEmpty(t, findings[0].RawResponse)
+
+			findings, err = s.Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			assert.Empty(t, findings, "a request is tested once")
+		})
+	}
+}
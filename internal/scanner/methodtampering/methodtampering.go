@@ -0,0 +1,349 @@
+package methodtampering
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+// MethodTamperingScanner implements the Scanner interface for HTTP method tampering: state-changing
+// verbs (PUT, DELETE, PATCH) that the server accepts although the application does not use them.
+type MethodTamperingScanner struct {
+	mu               sync.Mutex
+	endpointsScanned map[string]bool
+	dirsScanned      map[string]bool
+}
+
+// NewMethodTamperingScanner creates a new instance of MethodTamperingScanner.
+func NewMethodTamperingScanner() *MethodTamperingScanner {
+	return &MethodTamperingScanner{endpointsScanned: make(map[string]bool), dirsScanned: make(map[string]bool)}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "methodtampering",
+		Order:          250,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewMethodTamperingScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *MethodTamperingScanner) Name() string {
+	return "HTTP Method Tampering Scanner"
+}
+
+// probe is the outcome of one request, kept for the finding details.
+type probe struct {
+	request *http.Request
+	resp    *http.Response
+	body    []byte
+	summary string // e.g. "PUT https://example.com/a.txt -> 201".
+}
+
+// uploadResult is the outcome of the PUT test in one directory.
+type uploadResult struct {
+	resource string // URL of the test resource.
+	via      string // "PUT" or the method override header that created the resource.
+	created  *probe // The request that created the resource, nil if none did.
+	verified *probe // The GET that returned the marker.
+	removed  bool
+	probes   []string
+}
+
+// Scan sends OPTIONS to each crawled endpoint to list the allowed methods, then probes PUT, PATCH
+// and DELETE (directly and through method override headers) once per directory, using a uniquely
+// named test resource. A PUT whose content is returned by a follow-up GET is High; the resource
+// is deleted afterwards. The crawled pages themselves are never modified.
+func (s *MethodTamperingScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	parsedURL, err := url.Parse(req.URL)
+	if err != nil || parsedURL.Host == "" {
+		return nil, nil
+	}
+	endpoint := parsedURL.Scheme + "://" + parsedURL.Host + parsedURL.EscapedPath()
+	dir := parsedURL.Scheme + "://" + parsedURL.Host + directoryOf(parsedURL.EscapedPath())
+
+	s.mu.Lock()
+	newEndpoint := !s.endpointsScanned[endpoint]
+	s.endpointsScanned[endpoint] = true
+	newDir := !s.dirsScanned[dir]
+	s.dirsScanned[dir] = true
+	s.mu.Unlock()
+	if !newEndpoint {
+		return nil, nil
+	}
+
+	log.Debug("Starting HTTP method tampering scan for %s", endpoint)
+	options, err := send(ctx, client, "OPTIONS", endpoint, "", nil)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			return nil, nil
+		}
+	}
+
+	var upload *uploadResult
+	if newDir {
+		upload, err = s.testUpload(ctx, client, log, dir)
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			return nil, nil
+		}
+	}
+
+	var findings []scanner.VulnerabilityResult
+	if upload != nil && upload.created != nil {
+		log.Success("Method Tampering: %s created %s", upload.via, upload.resource)
+		findings = append(findings, s.newUploadResult(upload))
+	}
+	if options != nil {
+		if unsafe := unsafeMethods(options.resp.Header.Get("Allow")); len(unsafe) > 0 {
+			log.Success("Method Tampering: OPTIONS on %s allows %s", endpoint, strings.Join(unsafe, ", "))
+			findings = append(findings, s.newAllowResult(endpoint, options, unsafe, upload))
+		}
+	}
+	return findings, nil
+}
+
+// testUpload PUTs a uniquely named resource into dir and checks it with a GET. PATCH and DELETE
+// are then sent to the same resource, which also cleans it up. When the direct PUT fails, each
+// method override header is tried with a POST.
+func (s *MethodTamperingScanner) testUpload(ctx context.Context, client *httpclient.Client, log *logger.Logger, dir string) (*uploadResult, error) {
+	name, marker := payloads.GenerateMethodTamperingResource()
+	result := &uploadResult{resource: dir + name, via: "PUT"}
+
+	put, err := send(ctx, client, "PUT", result.resource, marker, nil)
+	if err != nil {
+		return result, err
+	}
+	result.probes = append(result.probes, put.summary)
+	if isSuccess(put.resp.StatusCode) {
+		verified, err := verify(ctx, client, result, marker)
+		if err != nil {
+			return result, err
+		}
+		if verified != nil {
+			result.created, result.verified = put, verified
+		}
+	}
+
+	patch, err := send(ctx, client, "PATCH", result.resource, marker, nil)
+	if err != nil {
+		return result, err
+	}
+	result.probes = append(result.probes, patch.summary)
+
+	if err := cleanup(ctx, client, log, result, marker, ""); err != nil {
+		return result, err
+	}
+	if result.created != nil {
+		return result, nil
+	}
+
+	for _, header := range payloads.MethodOverrideHeaders {
+		name, marker := payloads.GenerateMethodTamperingResource()
+		result.resource = dir + name
+		post, err := send(ctx, client, "POST", result.resource, marker, map[string]string{header: "PUT"})
+		if err != nil {
+			return result, err
+		}
+		result.probes = append(result.probes, post.summary)
+		if !isSuccess(post.resp.StatusCode) {
+			continue
+		}
+		verified, err := verify(ctx, client, result, marker)
+		if err != nil {
+			return result, err
+		}
+		if verified == nil {
+			continue
+		}
+		result.via, result.created, result.verified = header, post, verified
+		return result, cleanup(ctx, client, log, result, marker, header)
+	}
+	return result, nil
+}
+
+// verify fetches the test resource and returns the GET probe if it serves marker.
+func verify(ctx context.Context, client *httpclient.Client, result *uploadResult, marker string) (*probe, error) {
+	get, err := send(ctx, client, "GET", result.resource, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	found := isSuccess(get.resp.StatusCode) && strings.Contains(string(get.body), marker)
+	if found {
+		get.summary += " (test content returned)"
+	}
+	result.probes = append(result.probes, get.summary)
+	if !found {
+		return nil, nil
+	}
+	return get, nil
+}
+
+// cleanup deletes the test resource, falling back to a POST with overrideHeader when the direct
+// DELETE is rejected. When the resource was created, a final GET confirms it is gone.
+func cleanup(ctx context.Context, client *httpclient.Client, log *logger.Logger, result *uploadResult, marker, overrideHeader string) error {
+	del, err := send(ctx, client, "DELETE", result.resource, "", nil)
+	if err != nil {
+		return err
+	}
+	result.probes = append(result.probes, del.summary)
+	if !isSuccess(del.resp.StatusCode) && overrideHeader != "" && result.created != nil {
+		post, err := send(ctx, client, "POST", result.resource, "", map[string]string{overrideHeader: "DELETE"})
+		if err != nil {
+			return err
+		}
+		result.probes = append(result.probes, post.summary)
+	}
+	if result.created == nil {
+		return nil
+	}
+
+	get, err := send(ctx, client, "GET", result.resource, "", nil)
+	if err != nil {
+		return err
+	}
+	result.removed = !strings.Contains(string(get.body), marker)
+	if !result.removed {
+		log.Warn("Method Tampering: Could not delete test resource %s, remove it manually", result.resource)
+	}
+	return nil
+}
+
+// newUploadResult builds the High finding for a test resource created through PUT or a method
+// override header.
+func (s *MethodTamperingScanner) newUploadResult(upload *uploadResult) scanner.VulnerabilityResult {
+	details := fmt.Sprintf("The server stored a file sent with PUT and served it back on a follow-up GET, which is equivalent to an unrestricted file upload (defacement, malware hosting, or code execution if the directory runs scripts). Probes: %s.", strings.Join(upload.probes, "; "))
+	payload := fmt.Sprintf("PUT %s", upload.resource)
+	if upload.via != "PUT" {
+		details = fmt.Sprintf("The server honors the %s header: a POST with '%s: PUT' stored a file that was served back on a follow-up GET. Verb restrictions in front of the application are bypassed, and the upload is equivalent to an unrestricted file upload. Probes: %s.", upload.via, upload.via, strings.Join(upload.probes, "; "))
+		payload = fmt.Sprintf("POST %s with %s: PUT", upload.resource, upload.via)
+	}
+	if upload.removed {
+		details += " The test resource was deleted afterwards."
+	} else {
+		details += " The test resource could not be deleted; remove it manually."
+	}
+
+	vuln := scanner.VulnerabilityResult{
+		VulnerabilityType: "HTTP Method Tampering (PUT File Upload)",
+		URL:               upload.resource,
+		Parameter:         upload.via,
+		Payload:           payload,
+		Location:          "method",
+		Details:           details,
+		Severity:          "High",
+		Evidence:          fmt.Sprintf("%s; %s", upload.created.summary, upload.verified.summary),
+		Remediation:       "Disable PUT, DELETE and PATCH (including WebDAV) on the web server unless an authenticated API needs them, ignore X-HTTP-Method-Override and similar headers, and never let request bodies be written into the document root.",
+		ScannerName:       s.Name(),
+	}
+	vuln.SetExchange(scanner.CaptureExchange(upload.created.request, upload.created.resp, upload.created.body))
+	return vuln
+}
+
+// newAllowResult builds the Low finding for unsafe methods advertised by OPTIONS. The probes of
+// the directory's upload test, if run in this call, show whether the methods are really accepted.
+func (s *MethodTamperingScanner) newAllowResult(endpoint string, options *probe, unsafe []string, upload *uploadResult) scanner.VulnerabilityResult {
+	details := fmt.Sprintf("OPTIONS advertises state-changing methods (%s) that the crawled pages do not use. Make sure each of them requires authorization, or disable them.", strings.Join(unsafe, ", "))
+	if upload != nil && len(upload.probes) > 0 {
+		details += fmt.Sprintf(" Probes with a test resource in the same directory: %s.", strings.Join(upload.probes, "; "))
+	}
+	vuln := scanner.VulnerabilityResult{
+		VulnerabilityType: "Unsafe HTTP Methods Allowed",
+		URL:               endpoint,
+		Parameter:         "OPTIONS",
+		Location:          "method",
+		Details:           details,
+		Severity:          "Low",
+		Evidence:          fmt.Sprintf("%s; Allow: %s", options.summary, options.resp.Header.Get("Allow")),
+		Remediation:       "Restrict the allowed methods to those the application needs (usually GET, HEAD and POST) in the web server or framework configuration.",
+		ScannerName:       s.Name(),
+	}
+	vuln.SetExchange(scanner.CaptureExchange(options.request, options.resp, options.body))
+	return vuln
+}
+
+// send issues a request with an optional text body and extra headers, without following redirects.
+func send(ctx context.Context, client *httpclient.Client, method, target, body string, headers map[string]string) (*probe, error) {
+	var reqBody io.Reader
+	if body != "" {
+		reqBody = strings.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, target, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	if body != "" {
+		httpReq.Header.Set("Content-Type", "text/plain")
+	}
+	summary := method
+	for name, value := range headers {
+		httpReq.Header.Set(name, value)
+		summary = fmt.Sprintf("%s (%s: %s)", method, name, value)
+	}
+
+	client = client.WithoutRedirects()
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &probe{
+		request: httpReq,
+		resp:    resp,
+		body:    respBody,
+		summary: fmt.Sprintf("%s %s -> %d", summary, target, resp.StatusCode),
+	}, nil
+}
+
+// unsafeMethods returns the payloads.UnsafeHTTPMethods listed in an Allow header value.
+func unsafeMethods(allow string) []string {
+	var found []string
+	for _, method := range strings.Split(allow, ",") {
+		method = strings.ToUpper(strings.TrimSpace(method))
+		for _, unsafe := range payloads.UnsafeHTTPMethods {
+			if method == unsafe {
+				found = append(found, method)
+			}
+		}
+	}
+	return found
+}
+
+// directoryOf returns the directory of a URL path, with a trailing slash.
+func directoryOf(urlPath string) string {
+	if strings.HasSuffix(urlPath, "/") {
+		return urlPath
+	}
+	dir := path.Dir(urlPath)
+	if dir == "/" || dir == "." {
+		return "/"
+	}
+	return dir + "/"
+}
+
+func isSuccess(statusCode int) bool {
+	return statusCode >= 200 && statusCode < 300
+}
@@ -0,0 +1,145 @@
+package methodtampering
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fileServer stores files sent with PUT and serves them back, like a misconfigured WebDAV share.
+// With overrideOnly, PUT is rejected and only honored through X-HTTP-Method-Override.
+type fileServer struct {
+	mu           sync.Mutex
+	files        map[string]string
+	allow        string
+	overrideOnly bool
+}
+
+func (f *fileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	method := r.Method
+	if override := r.Header.Get("X-HTTP-Method-Override"); override != "" && method == "POST" {
+		method = override
+	} else if method == "PUT" && f.overrideOnly {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	switch method {
+	case "OPTIONS":
+		w.Header().Set("Allow", f.allow)
+	case "PUT":
+		body, _ := io.ReadAll(r.Body)
+		f.files[r.URL.Path] = string(body)
+		w.WriteHeader(http.StatusCreated)
+	case "DELETE":
+		delete(f.files, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	case "GET":
+		if content, ok := f.files[r.URL.Path]; ok {
+			w.Write([]byte(content))
+			return
+		}
+		w.Write([]byte("<html>home</html>"))
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name      string
+		handler   http.Handler
+		wantTypes []string
+		wantVia   string
+	}{
+		{
+			name:      "PUT stores files",
+			handler:   &fileServer{files: map[string]string{}, allow: "GET, HEAD, PUT, DELETE, OPTIONS"},
+			wantTypes: []string{"HTTP Method Tampering (PUT File Upload)", "Unsafe HTTP Methods Allowed"},
+			wantVia:   "PUT",
+		},
+		{
+			name:      "PUT only through method override",
+			handler:   &fileServer{files: map[string]string{}, allow: "GET, POST", overrideOnly: true},
+			wantTypes: []string{"HTTP Method Tampering (PUT File Upload)"},
+			wantVia:   "X-HTTP-Method-Override",
+		},
+		{
+			name: "Every method answers 200 without storing anything",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("<html>home</html>"))
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+			req := crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/docs/index.html?page=1"}
+
+			findings, err := NewMethodTamperingScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			var types []string
+			for _, f := range findings {
+				types = append(types, f.VulnerabilityType)
+			}
+			assert.Equal(t, tt.wantTypes, types)
+			if tt.wantVia == "" {
+				return
+			}
+			upload := findings[0]
+			assert.Equal(t, "High", upload.Severity)
+			assert.Equal(t, tt.wantVia, upload.Parameter)
+			assert.True(t, strings.HasPrefix(upload.URL, server.URL+"/docs/dursgo-put-test-"), upload.URL)
+			assert.Contains(t, upload.Details, "The test resource was deleted afterwards.")
+			if fs, ok := tt.handler.(*fileServer); ok {
+				assert.Empty(t, fs.files, "test resource must be cleaned up")
+			}
+		})
+	}
+}
+
+func TestScanOncePerDirectory(t *testing.T) {
+	var puts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			puts++
+		}
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	s := NewMethodTamperingScanner()
+	for _, page := range []string{"/shop/a", "/shop/b", "/shop/a?sort=asc"} {
+		_, err := s.Scan(context.Background(), crawler.ParameterizedRequest{Method: "GET", URL: server.URL + page}, client, log, scanner.ScannerOptions{})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, puts)
+}
+
+func TestDirectoryOf(t *testing.T) {
+	assert.Equal(t, "/", directoryOf(""))
+	assert.Equal(t, "/", directoryOf("/index.php"))
+	assert.Equal(t, "/api/", directoryOf("/api/"))
+	assert.Equal(t, "/api/v1/", directoryOf("/api/v1/users"))
+}
@@ -0,0 +1,217 @@
+package misconfig
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// ModuleName is the name of the misconfiguration module in the scanner registry.
+const ModuleName = "misconfig"
+
+const (
+	// bodyBytes is how much of a response is read to confirm an endpoint or a reflection.
+	bodyBytes = 65536
+	// traceHeader carries the marker of the TRACE and TRACK probes.
+	traceHeader = "X-Dursgo-Trace"
+	// maxSnippetBytes bounds the matched content shown in evidence.
+	maxSnippetBytes = 80
+)
+
+// traceMethods are the methods that echo the request back: TRACE, and TRACK on IIS.
+var traceMethods = []string{"TRACE", "TRACK"}
+
+// severityMetrics are the CVSS metrics of an exposed endpoint per severity, so that its score
+// agrees with the severity of the endpoint table.
+var severityMetrics = map[string][]string{
+	"Critical": {"S:C", "C:H", "I:L"},
+	"High":     {"C:H"},
+	"Medium":   {"C:L"},
+	"Low":      {"AC:H", "C:L"},
+}
+
+// MisconfigScanner implements the Scanner interface for server misconfigurations checked once
+// per host: the TRACE and TRACK methods, and the debug, monitoring and metadata endpoints of
+// payloads.DefaultEndpoints (Spring Boot Actuator, Go pprof, Apache server-status, phpinfo(),
+// .git). Endpoints are only reported when their response matches the signature of their
+// content, never on the status code alone.
+type MisconfigScanner struct {
+	mu    sync.Mutex
+	hosts map[string]bool // Origins already checked.
+}
+
+// NewMisconfigScanner creates a new instance of MisconfigScanner.
+func NewMisconfigScanner() *MisconfigScanner {
+	return &MisconfigScanner{hosts: make(map[string]bool)}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           ModuleName,
+		Order:          320,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewMisconfigScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *MisconfigScanner) Name() string {
+	return "Server Misconfiguration Scanner"
+}
+
+// response is the start of a response, as read for confirmation.
+type response struct {
+	status   int
+	body     string // At most bodyBytes bytes.
+	exchange scanner.Exchange
+}
+
+// Scan checks the host of req, the first time one of its requests is scanned.
+func (s *MisconfigScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	target, err := url.Parse(req.URL)
+	if err != nil || target.Host == "" {
+		return nil, nil
+	}
+	origin := target.Scheme + "://" + target.Host
+	s.mu.Lock()
+	seen := s.hosts[origin]
+	s.hosts[origin] = true
+	s.mu.Unlock()
+	if seen {
+		return nil, nil
+	}
+	log.Debug("Misconfig: Checking %s", origin)
+
+	// An endpoint redirecting to a login page is not exposed.
+	client = client.WithoutRedirects()
+
+	var findings []scanner.VulnerabilityResult
+	// record keeps the outcome of a probe and reports whether the checks of the host go on. A
+	// failed probe is skipped; a cancelled scan or an exhausted request budget ends them.
+	record := func(finding *scanner.VulnerabilityResult, err error) bool {
+		switch {
+		case ctx.Err() != nil || errors.Is(err, httpclient.ErrRequestBudgetExhausted):
+			return false
+		case err != nil:
+			log.Debug("Misconfig: %v", err)
+		case finding != nil:
+			findings = append(findings, *finding)
+		}
+		return true
+	}
+	for _, method := range traceMethods {
+		if !record(s.testTrace(ctx, client, log, origin+"/", method)) {
+			return findings, ctx.Err()
+		}
+	}
+	for _, endpoint := range payloads.GetDefaultEndpoints() {
+		if !record(s.testEndpoint(ctx, client, log, origin, endpoint)) {
+			return findings, ctx.Err()
+		}
+	}
+	return findings, nil
+}
+
+// testTrace sends method with a marker header and reports it enabled when the response echoes
+// the request, marker included.
+func (s *MisconfigScanner) testTrace(ctx context.Context, client *httpclient.Client, log *logger.Logger, target, method string) (*scanner.VulnerabilityResult, error) {
+	marker := fmt.Sprintf("dursgo-trace-%06d", rand.Intn(1000000))
+	resp, err := fetch(ctx, client, method, target, map[string]string{traceHeader: marker})
+	if err != nil {
+		return nil, err
+	}
+	if resp.status != http.StatusOK || !strings.Contains(resp.body, marker) || !strings.Contains(resp.body, method+" /") {
+		return nil, nil
+	}
+	log.Success("Misconfig: %s is enabled on %s", method, target)
+
+	vuln := scanner.VulnerabilityResult{
+		VulnerabilityType: fmt.Sprintf("Cross-Site Tracing (%s Enabled)", method),
+		URL:               target,
+		Parameter:         method,
+		Location:          "method",
+		Details: fmt.Sprintf("The server answers %s requests by echoing the request back, headers included. Together with an XSS or a proxy flaw, this exposes HttpOnly cookies and authentication headers to scripts (Cross-Site Tracing), and it reveals headers added by proxies in front of the server.",
+			method),
+		Severity:    "Low",
+		Confidence:  scanner.ConfidenceCertain,
+		Evidence:    fmt.Sprintf("%s %s -> %d; the %s: %s header sent was reflected in the response body.", method, target, resp.status, traceHeader, marker),
+		Remediation: "Disable the TRACE and TRACK methods on the web server and on every proxy or load balancer in front of it (e.g., TraceEnable off in Apache, request filtering of the verbs on IIS).",
+		ScannerName: ModuleName,
+	}
+	vuln.SetExchange(resp.exchange)
+	return &vuln, nil
+}
+
+// testEndpoint requests an endpoint and reports it when its response matches the endpoint's
+// signature.
+func (s *MisconfigScanner) testEndpoint(ctx context.Context, client *httpclient.Client, log *logger.Logger, origin string, endpoint payloads.DefaultEndpoint) (*scanner.VulnerabilityResult, error) {
+	target := origin + endpoint.Path
+	resp, err := fetch(ctx, client, "GET", target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.status != http.StatusOK {
+		return nil, nil
+	}
+	loc := endpoint.Regex.FindStringIndex(resp.body)
+	if loc == nil {
+		log.Debug("Misconfig: %s answered %d without the content of %s.", target, resp.status, endpoint.Name)
+		return nil, nil
+	}
+	log.Success("Misconfig: %s is exposed at %s", endpoint.Name, target)
+
+	details := fmt.Sprintf("%s is publicly accessible at %s.", endpoint.Name, target)
+	if endpoint.Exposes != "" {
+		details = fmt.Sprintf("%s is publicly accessible at %s. It exposes %s.", endpoint.Name, target, endpoint.Exposes)
+	}
+	vuln := scanner.VulnerabilityResult{
+		VulnerabilityType: fmt.Sprintf("Exposed Sensitive Endpoint (%s)", endpoint.Name),
+		URL:               target,
+		Details:           details,
+		Severity:          endpoint.Severity,
+		Confidence:        scanner.ConfidenceCertain,
+		Evidence:          fmt.Sprintf("GET %s -> %d; the response matched the %s signature with %q.", target, resp.status, endpoint.Name, resp.body[loc[0]:min(loc[1], loc[0]+maxSnippetBytes)]),
+		Remediation:       "Remove the endpoint from production or restrict it to administrators (e.g., management.endpoints.web.exposure in Spring Boot, Require ip for server-status in Apache, no net/http/pprof handlers on public listeners), and deny access to version control directories.",
+		ScannerName:       ModuleName,
+	}
+	vuln.SetCVSSMetrics(severityMetrics[endpoint.Severity]...)
+	vuln.SetExchange(resp.exchange)
+	return &vuln, nil
+}
+
+// fetch sends a request without a body and reads the start of the response.
+func fetch(ctx context.Context, client *httpclient.Client, method, target string, headers map[string]string) (*response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, bodyBytes))
+	if err != nil && len(body) == 0 {
+		return nil, err
+	}
+	return &response{
+		status:   resp.StatusCode,
+		body:     string(body),
+		exchange: scanner.CaptureExchange(req, resp, body),
+	}, nil
+}
@@ -0,0 +1,77 @@
+package misconfig
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// misconfiguredServer echoes TRACE requests, serves a heap dump and Apache's server-status, and
+// answers every other path with a 200 soft-404 page.
+func misconfiguredServer(requests *atomic.Int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		switch {
+		case r.Method == "TRACE":
+			w.Header().Set("Content-Type", "message/http")
+			fmt.Fprintf(w, "TRACE %s HTTP/1.1\r\n", r.URL.RequestURI())
+			r.Header.Write(w)
+		case r.Method != "GET":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		case r.URL.Path == "/actuator/heapdump":
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write([]byte("JAVA PROFILE 1.0.2\x00\x00\x00\x00\x08\x00\x00\x01\x8f"))
+		case r.URL.Path == "/server-status":
+			w.Write([]byte("<html><head><title>Apache Status</title></head><body><h1>Apache Server Status for app.example.com</h1></body></html>"))
+		default:
+			w.Write([]byte("<html><body><h1>Page not found</h1><p>/actuator/env /debug/pprof/ ref: refs/heads/main</p></body></html>"))
+		}
+	}))
+}
+
+func TestScanReportsConfirmedEndpointsOncePerHost(t *testing.T) {
+	var requests atomic.Int64
+	server := misconfiguredServer(&requests)
+	defer server.Close()
+	client := httpclient.NewClient(logger.NewLogger(logger.ERROR), httpclient.ClientOptions{})
+	s := NewMisconfigScanner()
+
+	findings, err := s.Scan(context.Background(), crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/shop?id=1"}, client, logger.NewLogger(logger.ERROR), scanner.ScannerOptions{})
+	require.NoError(t, err)
+
+	byType := make(map[string]scanner.VulnerabilityResult)
+	for _, f := range findings {
+		byType[f.VulnerabilityType] = f
+	}
+	require.Len(t, byType, 3, "TRACE, heapdump and server-status; the soft-404 pages are not reported")
+	trace := byType["Cross-Site Tracing (TRACE Enabled)"]
+	assert.Equal(t, "Low", trace.Severity)
+	assert.Contains(t, trace.Evidence, traceHeader)
+	heapdump := byType["Exposed Sensitive Endpoint (Spring Boot Actuator heapdump)"]
+	assert.Equal(t, "Critical", heapdump.Severity)
+	assert.Equal(t, server.URL+"/actuator/heapdump", heapdump.URL)
+	assert.Equal(t, "Medium", byType["Exposed Sensitive Endpoint (Apache server-status)"].Severity)
+
+	// The scores agree with the severities of the endpoint table.
+	scanner.Classify(findings)
+	for _, f := range findings {
+		assert.Equal(t, f.Severity, scanner.CVSSRating(f.CVSSScore), f.VulnerabilityType)
+	}
+
+	sent := requests.Load()
+	findings, err = s.Scan(context.Background(), crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/cart"}, client, logger.NewLogger(logger.ERROR), scanner.ScannerOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+	assert.Equal(t, sent, requests.Load(), "a host is only checked once")
+}
@@ -0,0 +1,261 @@
+// Package nested finds injection points inside encoded parameter values: the string fields of a
+// JSON document passed as a parameter (filter={"name":"x"}) and base64-encoded text, itself
+// possibly JSON (data=eyJuYW1lIjoieCJ9). A payload appended to the whole value never reaches
+// the sinks behind them; Point.Inject places it in the decoded field and re-encodes the value.
+// It is shared by the injection scanners (SQLi, XSS, command injection).
+package nested
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Encoding is a layer of encoding around an injection point.
+type Encoding string
+
+// Encodings recognized in parameter values.
+const (
+	JSON   Encoding = "JSON"
+	Base64 Encoding = "base64"
+)
+
+// Values shorter than minBase64Length are not decoded as base64: short words decode to
+// printable text too often.
+const minBase64Length = 8
+
+// maxPoints caps the string fields returned for one parameter, as each is tested separately.
+const maxPoints = 10
+
+// base64Encodings are tried in order when decoding a value; the one that decodes it is used
+// to re-encode the injected value.
+var base64Encodings = []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding}
+
+// Point is an injection point of a parameter: a string inside its encoded value or, with an
+// empty Chain, the value itself.
+type Point struct {
+	Param string     // Parameter holding the value.
+	Field string     // Path of the JSON string field (e.g. "user.name", "tags[0]"); empty if not in JSON.
+	Chain []Encoding // Encodings around the point, outermost first, e.g. [base64 JSON].
+	Value string     // Original value at the point.
+
+	raw    string           // Original value of the parameter.
+	base64 *base64.Encoding // Encoding of a base64 layer.
+}
+
+// Targets returns the injection points of a parameter: its value itself, followed by the points
+// found inside it by Points.
+func Targets(param, value string) []Point {
+	return append([]Point{{Param: param, Value: value, raw: value}}, Points(param, value)...)
+}
+
+// Points returns the injection points inside the value of a parameter: the string fields of a
+// JSON object or array, or the decoded text of a base64 value (the string fields, if that text
+// is JSON). Values that are neither return no points. At most maxPoints fields are returned,
+// in path order.
+func Points(param, value string) []Point {
+	base := Point{Param: param, raw: value}
+	text := value
+	if enc, decoded, ok := decodeBase64(value); ok {
+		base.Chain, base.base64 = []Encoding{Base64}, enc
+		text = decoded
+	}
+
+	doc, ok := decodeJSON(text)
+	if !ok {
+		if base.base64 == nil {
+			return nil
+		}
+		base.Value = text
+		return []Point{base}
+	}
+	var points []Point
+	walkStrings(doc, "", func(path, s string) {
+		point := base
+		point.Chain = append(append([]Encoding(nil), base.Chain...), JSON)
+		point.Field, point.Value = path, s
+		points = append(points, point)
+	})
+	sort.Slice(points, func(i, j int) bool { return points[i].Field < points[j].Field })
+	if len(points) > maxPoints {
+		points = points[:maxPoints]
+	}
+	return points
+}
+
+// Nested reports whether the point is inside the value rather than the value itself.
+func (p Point) Nested() bool {
+	return len(p.Chain) > 0
+}
+
+// Inject returns the parameter value with the point set to value, re-encoded through the chain.
+func (p Point) Inject(value string) string {
+	if !p.Nested() {
+		return value
+	}
+	text := value
+	if p.Field != "" {
+		inner := p.raw
+		if p.base64 != nil {
+			decoded, err := p.base64.DecodeString(p.raw)
+			if err != nil {
+				return p.raw
+			}
+			inner = string(decoded)
+		}
+		doc, ok := decodeJSON(inner)
+		if !ok {
+			return p.raw
+		}
+		encoded, err := encodeJSON(setString(doc, "", p.Field, value))
+		if err != nil {
+			return p.raw
+		}
+		text = encoded
+	}
+	if p.base64 != nil {
+		return p.base64.EncodeToString([]byte(text))
+	}
+	return text
+}
+
+// String describes the point for findings, e.g. "JSON field 'name' inside base64-encoded
+// parameter 'data'".
+func (p Point) String() string {
+	param := fmt.Sprintf("parameter '%s'", p.Param)
+	if p.base64 != nil {
+		param = "base64-encoded " + param
+	}
+	switch {
+	case p.Field != "":
+		return fmt.Sprintf("JSON field '%s' inside %s", p.Field, param)
+	case p.Nested():
+		return "decoded value of " + param
+	}
+	return param
+}
+
+// Note returns the sentence added to the details of a finding on the point, or "" if the
+// point is the parameter value itself.
+func (p Point) Note() string {
+	if !p.Nested() {
+		return ""
+	}
+	return fmt.Sprintf("The payload was injected into the %s.", p)
+}
+
+// decodeBase64 decodes value if it is base64-encoded printable text.
+func decodeBase64(value string) (*base64.Encoding, string, bool) {
+	if len(value) < minBase64Length {
+		return nil, "", false
+	}
+	for _, enc := range base64Encodings {
+		decoded, err := enc.DecodeString(value)
+		if err != nil {
+			continue
+		}
+		if text := string(decoded); printable(text) {
+			return enc, text, true
+		}
+		return nil, "", false
+	}
+	return nil, "", false
+}
+
+// printable reports whether s is valid UTF-8 text without control characters other than
+// whitespace, and contains at least one letter.
+func printable(s string) bool {
+	if !utf8.ValidString(s) {
+		return false
+	}
+	letter := false
+	for _, r := range s {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case r == '\t' || r == '\n' || r == '\r':
+		case !unicode.IsPrint(r):
+			return false
+		}
+	}
+	return letter
+}
+
+// decodeJSON decodes s if it is a JSON object or array, preserving number precision.
+func decodeJSON(s string) (interface{}, bool) {
+	trimmed := strings.TrimSpace(s)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return nil, false
+	}
+	dec := json.NewDecoder(strings.NewReader(trimmed))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, false
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, false // Trailing data: not a single JSON document.
+	}
+	return doc, true
+}
+
+// encodeJSON serializes doc without escaping HTML characters, so payloads reach the
+// application as written.
+func encodeJSON(doc interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(doc); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// walkStrings visits every string of a decoded JSON document with its path.
+func walkStrings(node interface{}, path string, visit func(path, s string)) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			walkStrings(child, joinPath(path, key), visit)
+		}
+	case []interface{}:
+		for i, child := range v {
+			walkStrings(child, fmt.Sprintf("%s[%d]", path, i), visit)
+		}
+	case string:
+		visit(path, v)
+	}
+}
+
+// setString returns node with the string at field replaced by value.
+func setString(node interface{}, path, field, value string) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = setString(child, joinPath(path, key), field, value)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = setString(child, fmt.Sprintf("%s[%d]", path, i), field, value)
+		}
+	case string:
+		if path == field {
+			return value
+		}
+	}
+	return node
+}
+
+// joinPath appends an object key to a JSON path.
+func joinPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
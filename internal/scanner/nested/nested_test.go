@@ -0,0 +1,53 @@
+package nested
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPointsInJSONParameter(t *testing.T) {
+	points := Points("filter", `{"name":"x","page":2,"tags":["a"]}`)
+	require.Len(t, points, 2)
+	assert.Equal(t, "name", points[0].Field)
+	assert.Equal(t, "x", points[0].Value)
+	assert.Equal(t, []Encoding{JSON}, points[0].Chain)
+	assert.Equal(t, "tags[0]", points[1].Field)
+
+	assert.Equal(t, `{"name":"x'<b>","page":2,"tags":["a"]}`, points[0].Inject("x'<b>"))
+	assert.Equal(t, "The payload was injected into the JSON field 'name' inside parameter 'filter'.", points[0].Note())
+}
+
+func TestPointsInBase64Parameter(t *testing.T) {
+	value := base64.StdEncoding.EncodeToString([]byte(`{"name":"x"}`))
+	points := Points("data", value)
+	require.Len(t, points, 1)
+	assert.Equal(t, []Encoding{Base64, JSON}, points[0].Chain)
+	assert.Equal(t, "JSON field 'name' inside base64-encoded parameter 'data'", points[0].String())
+
+	decoded, err := base64.StdEncoding.DecodeString(points[0].Inject("x'"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"x'"}`, string(decoded))
+
+	// Base64 text that is not JSON is injected as a whole; the URL-safe alphabet is kept.
+	value = base64.RawURLEncoding.EncodeToString([]byte("user=admin&role=1>?"))
+	points = Points("token", value)
+	require.Len(t, points, 1)
+	assert.Equal(t, "user=admin&role=1>?", points[0].Value)
+	assert.Equal(t, "decoded value of base64-encoded parameter 'token'", points[0].String())
+	assert.Equal(t, base64.RawURLEncoding.EncodeToString([]byte("user=admin&role=1>?'")), points[0].Inject(points[0].Value+"'"))
+}
+
+func TestPointsIgnorePlainValues(t *testing.T) {
+	for _, value := range []string{"", "1", "admin", "password", "1234567890", "{not json", `{"id":1}`, `"quoted"`} {
+		assert.Empty(t, Points("p", value), value)
+	}
+
+	targets := Targets("q", "admin")
+	require.Len(t, targets, 1)
+	assert.False(t, targets[0].Nested())
+	assert.Equal(t, "admin'", targets[0].Inject("admin'"))
+	assert.Empty(t, targets[0].Note())
+}
@@ -0,0 +1,133 @@
+package nosqli
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner/requtil"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// injection maps parameter names to the value sent in their place: a plain string or an operator
+// object such as {"$ne": nil}.
+type injection map[string]interface{}
+
+// originalValues returns the parameters of req with their original values. JSON leaves keep their
+// type (string or json.Number) and are keyed by path, e.g. "user.name" or "items[0].id".
+func originalValues(req crawler.ParameterizedRequest) (map[string]interface{}, error) {
+	if req.IsJSON() {
+		return requtil.JSONValues(req.RawBody)
+	}
+	params, err := requtil.Params(req)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	for name := range params {
+		values[name] = params.Get(name)
+	}
+	return values, nil
+}
+
+// resolve replaces the payloads.NoSQLi* placeholders of an operand.
+func resolve(operand, original interface{}, canary string) interface{} {
+	switch v := operand.(type) {
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			resolved[key] = resolve(child, original, canary)
+		}
+		return resolved
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, child := range v {
+			resolved[i] = resolve(child, original, canary)
+		}
+		return resolved
+	case string:
+		if v == payloads.NoSQLiOriginal {
+			return original
+		}
+		v = strings.ReplaceAll(v, payloads.NoSQLiOriginalRegex, "^"+regexp.QuoteMeta(fmt.Sprint(original))+"$")
+		return strings.ReplaceAll(v, payloads.NoSQLiCanary, canary)
+	}
+	return operand
+}
+
+// newRequest builds the request for req with the parameters in inj replaced. Operator objects
+// become nested objects in JSON bodies, and use the array syntax (name[$op]=value) understood by
+// PHP, Express (qs) and Rails in query strings and form bodies.
+func newRequest(ctx context.Context, req crawler.ParameterizedRequest, inj injection) (*http.Request, error) {
+	if req.IsJSON() {
+		body, err := requtil.SetJSONPaths(req.RawBody, inj)
+		if err != nil {
+			return nil, err
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		requtil.SetContentType(httpReq, req)
+		return httpReq, nil
+	}
+
+	params, err := requtil.Params(req)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range inj {
+		params.Del(name)
+		addFormValue(params, name, value)
+	}
+	return requtil.New(ctx, req, params)
+}
+
+// describe renders inj the way it is sent, for the finding's payload.
+func describe(req crawler.ParameterizedRequest, inj injection) string {
+	names := make([]string, 0, len(inj))
+	for name := range inj {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		if req.IsJSON() {
+			encoded, _ := json.Marshal(inj[name])
+			parts = append(parts, fmt.Sprintf("%q: %s", name, encoded))
+			continue
+		}
+		params := url.Values{}
+		addFormValue(params, name, inj[name])
+		decoded, _ := url.QueryUnescape(params.Encode())
+		parts = append(parts, decoded)
+	}
+	if req.IsJSON() {
+		return strings.Join(parts, ", ")
+	}
+	return strings.Join(parts, "&")
+}
+
+// addFormValue adds value under name, expanding operator objects into array syntax.
+func addFormValue(params url.Values, name string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			addFormValue(params, name+"["+key+"]", child)
+		}
+	case []interface{}:
+		for _, child := range v {
+			addFormValue(params, name+"[]", child)
+		}
+	case nil:
+		params.Add(name, "")
+	default:
+		params.Add(name, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,298 @@
+package nosqli
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"Dursgo/internal/scanner/requtil"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// NoSQLiScanner implements the Scanner interface for NoSQL injection through MongoDB-style query
+// operators.
+type NoSQLiScanner struct{}
+
+// NewNoSQLiScanner creates a new instance of NoSQLiScanner.
+func NewNoSQLiScanner() *NoSQLiScanner {
+	return &NoSQLiScanner{}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "nosqli",
+		Order:          260,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewNoSQLiScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *NoSQLiScanner) Name() string {
+	return "NoSQL Injection Scanner"
+}
+
+// Scan replaces query, form and JSON parameters with MongoDB operator objects. Login forms are
+// first tested for an authentication bypass; then each parameter gets a boolean-differential test,
+// where an operator matching the original value must return the original response and one
+// matching nothing a different response.
+func (s *NoSQLiScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	if req.Method != "GET" && req.Method != "POST" && !req.IsJSON() || req.IsMultipart() {
+		return nil, nil // Multipart fields cannot carry operator objects.
+	}
+	paramNames := req.ParamNames
+	if req.IsJSON() && !req.IsGraphQL() {
+		paramNames = requtil.JSONParamNames(req.RawBody) // Operators replace leaves, addressed by path.
+	}
+	if len(paramNames) == 0 {
+		return nil, nil
+	}
+	originals, err := originalValues(req)
+	if err != nil {
+		return nil, nil
+	}
+
+	log.Debug("Starting NoSQL injection scan for %s %s", req.Method, req.URL)
+	cmp := compare.New(opts, log, "NoSQLi")
+
+	if vuln, ok := s.testAuthBypass(ctx, req, client, log, paramNames, cmp); ok {
+		return []scanner.VulnerabilityResult{vuln}, nil
+	}
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	baseline, err := newRequest(ctx, req, injection{})
+	if err != nil {
+		return nil, nil
+	}
+	_, baselineBody, err := send(client, baseline, true)
+	if err != nil {
+		return nil, ctx.Err()
+	}
+
+	var findings []scanner.VulnerabilityResult
+	for _, paramName := range paramNames {
+		if ctx.Err() != nil {
+			return findings, ctx.Err()
+		}
+		original, ok := originals[paramName]
+		if !ok || opts.SkipParam("nosqli", paramName) {
+			continue
+		}
+		paramClient := client.WithRequestBudget(opts.MaxRequestsPerParam)
+		if vuln, ok := s.testFilterBypass(ctx, req, paramClient, log, paramName, original, string(baselineBody), cmp); ok {
+			findings = append(findings, vuln)
+		}
+	}
+	return findings, ctx.Err()
+}
+
+// testFilterBypass runs payloads.NoSQLiBooleanTests against paramName.
+func (s *NoSQLiScanner) testFilterBypass(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string, original interface{}, baselineBody string, cmp compare.Comparator) (scanner.VulnerabilityResult, bool) {
+	for _, test := range payloads.NoSQLiBooleanTests {
+		canary := payloads.GenerateNoSQLiCanary()
+		trueInj := injection{paramName: resolve(test.True, original, canary)}
+		trueReq, err := newRequest(ctx, req, trueInj)
+		if err != nil {
+			return scanner.VulnerabilityResult{}, false
+		}
+		trueResp, trueBody, err := send(client, trueReq, true)
+		if err != nil {
+			return scanner.VulnerabilityResult{}, false
+		}
+		if cmp.IsDifferent(baselineBody, string(trueBody)) {
+			continue // The operator object is rejected or not passed to the query as an object.
+		}
+
+		falseReq, err := newRequest(ctx, req, injection{paramName: resolve(test.False, original, canary)})
+		if err != nil {
+			return scanner.VulnerabilityResult{}, false
+		}
+		_, falseBody, err := send(client, falseReq, true)
+		if err != nil {
+			return scanner.VulnerabilityResult{}, false
+		}
+		if !cmp.IsDifferent(baselineBody, string(falseBody)) {
+			continue // The parameter does not influence the response.
+		}
+
+		log.Success("NoSQLi (Filter Bypass): Operator %s is evaluated in param '%s' at %s", test.Operator, paramName, req.URL)
+		vuln := scanner.VulnerabilityResult{
+			VulnerabilityType: "NoSQL Injection (Filter Bypass)",
+			URL:               trueReq.URL.String(),
+			Parameter:         paramName,
+			Payload:           describe(req, trueInj),
+			Location:          getParamLocation(req),
+			Details:           fmt.Sprintf("The parameter is passed to the database query as an object, so the %s operator is evaluated: an operator matching the original value returned the original response, while one matching nothing changed it. Attackers can use operators such as {\"$ne\": null} or {\"$regex\": \".*\"} to widen the query filter and read other records.", test.Operator),
+			Severity:          "High",
+			Evidence:          fmt.Sprintf("Response for the matching %s operator was similar to the original (similarity %.3f), while the non-matching one was different (similarity %.3f; %s mode, threshold %.2f).", test.Operator, cmp.Similarity(baselineBody, string(trueBody)), cmp.Similarity(baselineBody, string(falseBody)), cmp.Mode, cmp.Threshold),
+			Remediation:       "Cast user input to the expected primitive type before building queries (or validate it against a schema), reject keys starting with '$', and sanitize request objects (e.g., express-mongo-sanitize).",
+			ScannerName:       s.Name(),
+		}
+		vuln.SetExchange(scanner.CaptureExchange(trueReq, trueResp, trueBody))
+		return vuln, true
+	}
+	return scanner.VulnerabilityResult{}, false
+}
+
+// testAuthBypass injects payloads.NoSQLiAuthBypassTests into the user name and password fields of
+// a login form. A login counts as successful when the response (or the page it redirects to with
+// the issued session) contains a logged-in keyword that a failed login does not.
+func (s *NoSQLiScanner) testAuthBypass(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramNames []string, cmp compare.Comparator) (scanner.VulnerabilityResult, bool) {
+	userParam, passwordParams := loginFields(paramNames)
+	if userParam == "" || len(passwordParams) == 0 {
+		return scanner.VulnerabilityResult{}, false
+	}
+	// A successful bypass stores the hijacked session in the jar; the scan goes on with its own.
+	savedSession := client.SnapshotSession()
+	defer client.RestoreSession(savedSession)
+
+	// 1. Establish a "failure" baseline with known-bad credentials.
+	failure := injection{userParam: "dursgo-test-user"}
+	for _, name := range passwordParams {
+		failure[name] = "dursgo-test-pass"
+	}
+	failureReq, err := newRequest(ctx, req, failure)
+	if err != nil {
+		return scanner.VulnerabilityResult{}, false
+	}
+	failureResp, failureBody, err := send(client, failureReq, false)
+	if err != nil {
+		return scanner.VulnerabilityResult{}, false
+	}
+	failurePage := loggedInPage(client, failureResp, string(failureBody))
+
+	for _, test := range payloads.NoSQLiAuthBypassTests {
+		inj := injection{userParam: test.Value}
+		for _, name := range passwordParams {
+			inj[name] = test.Value
+		}
+		httpReq, err := newRequest(ctx, req, inj)
+		if err != nil {
+			return scanner.VulnerabilityResult{}, false
+		}
+		resp, body, err := send(client, httpReq, false)
+		if err != nil {
+			return scanner.VulnerabilityResult{}, false
+		}
+
+		page := loggedInPage(client, resp, string(body))
+		keyword := successKeyword(page)
+		if keyword == "" || successKeyword(failurePage) != "" || !cmp.IsDifferent(failurePage, page) {
+			continue
+		}
+
+		log.Success("NoSQLi (Auth Bypass): Logged in with operator %s at %s", test.Operator, req.URL)
+		vuln := scanner.VulnerabilityResult{
+			VulnerabilityType: "NoSQL Injection (Auth Bypass)",
+			URL:               req.URL,
+			Parameter:         userParam,
+			Payload:           describe(req, inj),
+			Location:          getParamLocation(req),
+			Details:           fmt.Sprintf("The login accepted the %s operator in place of the credentials, so the query matched a stored user without knowing the password. The resulting page contained the keyword '%s', which a failed login does not.", test.Operator, keyword),
+			Severity:          "High",
+			Evidence:          fmt.Sprintf("Failed login: HTTP %d; login with %s operators: HTTP %d and keyword '%s'.", failureResp.StatusCode, test.Operator, resp.StatusCode, keyword),
+			Remediation:       "Cast credentials to strings before querying, reject keys starting with '$', and compare password hashes in application code instead of inside the query.",
+			ScannerName:       s.Name(),
+		}
+		vuln.SetExchange(scanner.CaptureExchange(httpReq, resp, body))
+		return vuln, true
+	}
+	return scanner.VulnerabilityResult{}, false
+}
+
+// loggedInPage returns the page shown after a login response: the body, or for a redirect that
+// issued cookies, the body of its target fetched with those cookies.
+func loggedInPage(client *httpclient.Client, resp *http.Response, body string) string {
+	if resp.StatusCode < 300 || resp.StatusCode >= 400 || len(resp.Cookies()) == 0 {
+		return body
+	}
+	location, err := resp.Location()
+	if err != nil {
+		return body
+	}
+	session := httpclient.NewSession()
+	session.SetCookies(resp.Request.URL, resp.Cookies())
+	followReq, err := http.NewRequestWithContext(resp.Request.Context(), "GET", location.String(), nil)
+	if err != nil {
+		return body
+	}
+	finalResp, err := client.DoWithSession(followReq, session)
+	if err != nil {
+		return body
+	}
+	defer finalResp.Body.Close()
+	finalBody, err := io.ReadAll(finalResp.Body)
+	if err != nil {
+		return body
+	}
+	return string(finalBody)
+}
+
+// successKeyword returns the first payloads.NoSQLiLoginSuccessKeywords found in page.
+func successKeyword(page string) string {
+	page = strings.ToLower(page)
+	for _, keyword := range payloads.NoSQLiLoginSuccessKeywords {
+		if strings.Contains(page, keyword) {
+			return keyword
+		}
+	}
+	return ""
+}
+
+// loginFields returns the user name parameter and the password parameters of a login form.
+func loginFields(paramNames []string) (string, []string) {
+	var userParam string
+	var passwordParams []string
+	for _, name := range paramNames {
+		lower := strings.ToLower(name)
+		if strings.Contains(lower, "pass") {
+			passwordParams = append(passwordParams, name)
+			continue
+		}
+		for _, candidate := range payloads.NoSQLiLoginUserParams {
+			if userParam == "" && lower == candidate {
+				userParam = name
+			}
+		}
+	}
+	return userParam, passwordParams
+}
+
+// send performs httpReq and reads the response body. Redirects are followed only if follow is set.
+// Bodies are compared, so a truncated body is an error.
+func send(client *httpclient.Client, httpReq *http.Request, follow bool) (*http.Response, []byte, error) {
+	if !follow {
+		client = client.WithoutRedirects()
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, truncated, err := client.ReadBody(resp)
+	if err == nil && truncated {
+		err = httpclient.ErrBodyTruncated
+	}
+	return resp, body, err
+}
+
+func getParamLocation(req crawler.ParameterizedRequest) string {
+	switch {
+	case req.Method == "GET":
+		return "query"
+	case req.IsGraphQL():
+		return "graphql"
+	case req.IsJSON():
+		return "json"
+	}
+	return "body"
+}
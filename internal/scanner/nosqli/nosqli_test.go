@@ -0,0 +1,178 @@
+package nosqli
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// matches evaluates a MongoDB-style condition (a plain value or an operator object) against value.
+func matches(cond interface{}, value string) bool {
+	ops, ok := cond.(map[string]interface{})
+	if !ok {
+		return cond == value
+	}
+	for op, operand := range ops {
+		switch op {
+		case "$eq":
+			return operand == value
+		case "$ne":
+			return operand != value
+		case "$gt":
+			s, _ := operand.(string)
+			return value > s
+		case "$in":
+			for _, v := range operand.([]interface{}) {
+				if v == value {
+					return true
+				}
+			}
+			return false
+		case "$regex":
+			return regexp.MustCompile(operand.(string)).MatchString(value)
+		}
+	}
+	return false
+}
+
+// formCondition rebuilds the object the qs parser makes of name[$op]=value form fields.
+func formCondition(form map[string][]string, name string) interface{} {
+	if values, ok := form[name]; ok {
+		return values[0]
+	}
+	ops := map[string]interface{}{}
+	for key, values := range form {
+		if op, ok := strings.CutPrefix(key, name+"["); ok {
+			op = strings.TrimSuffix(strings.TrimSuffix(op, "[]"), "]")
+			if strings.HasSuffix(key, "[]") {
+				list := make([]interface{}, len(values))
+				for i, v := range values {
+					list[i] = v
+				}
+				ops[op] = list
+			} else {
+				ops[op] = values[0]
+			}
+		}
+	}
+	return ops
+}
+
+var products = map[string]string{"Go in Action": "books", "Dune": "books", "Espresso Machine": "kitchen"}
+
+func productList(cond interface{}) string {
+	var found []string
+	for name, category := range products {
+		if matches(cond, category) {
+			found = append(found, name)
+		}
+	}
+	if len(found) == 0 {
+		return "<html><body>No products found.</body></html>"
+	}
+	return "<html><body>" + strings.Repeat("<li>product</li>", len(found)) + "</body></html>"
+}
+
+func TestScanFilterBypass(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    bool
+	}{
+		{
+			name: "Query parsed into operator objects",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				r.ParseForm()
+				w.Write([]byte(productList(formCondition(r.Form, "category"))))
+			},
+			want: true,
+		},
+		{
+			name: "Query read as a string",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(productList(r.URL.Query().Get("category"))))
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+			req := crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/products?category=books", ParamNames: []string{"category"}}
+
+			findings, err := NewNoSQLiScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			if !tt.want {
+				assert.Empty(t, findings)
+				return
+			}
+			require.Len(t, findings, 1)
+			assert.Equal(t, "NoSQL Injection (Filter Bypass)", findings[0].VulnerabilityType)
+			assert.Equal(t, "category", findings[0].Parameter)
+			assert.Equal(t, "category[$eq]=books", findings[0].Payload)
+			assert.Contains(t, findings[0].Details, "$eq operator")
+		})
+	}
+}
+
+func TestScanAuthBypass(t *testing.T) {
+	login := func(w http.ResponseWriter, r *http.Request) {
+		var creds map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&creds); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if matches(creds["username"], "admin") && matches(creds["password"], "s3cr3t-passw0rd") {
+			w.Write([]byte(`{"message": "Welcome back, admin", "links": ["/logout"]}`))
+			return
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"error": "Invalid credentials"}`))
+	}
+	server := httptest.NewServer(http.HandlerFunc(login))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	req := crawler.ParameterizedRequest{
+		Method:      "POST",
+		URL:         server.URL + "/api/login",
+		ContentType: "application/json",
+		RawBody:     `{"username": "guest", "password": "guest"}`,
+	}
+
+	findings, err := NewNoSQLiScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "NoSQL Injection (Auth Bypass)", findings[0].VulnerabilityType)
+	assert.Equal(t, "username", findings[0].Parameter)
+	assert.Equal(t, "json", findings[0].Location)
+	assert.Equal(t, `"password": {"$ne":null}, "username": {"$ne":null}`, findings[0].Payload)
+}
+
+func TestNewRequestFormArraySyntax(t *testing.T) {
+	req := crawler.ParameterizedRequest{Method: "POST", URL: "http://example.com/login", FormPostData: "username=guest&password=guest&remember=1"}
+	httpReq, err := newRequest(context.Background(), req, injection{"username": map[string]interface{}{"$in": []interface{}{"admin", "root"}}, "password": map[string]interface{}{"$ne": nil}})
+	require.NoError(t, err)
+	require.NoError(t, httpReq.ParseForm())
+	assert.Equal(t, []string{"admin", "root"}, httpReq.PostForm["username[$in][]"])
+	assert.Equal(t, []string{""}, httpReq.PostForm["password[$ne]"])
+	assert.Equal(t, "1", httpReq.PostForm.Get("remember"))
+	assert.NotContains(t, httpReq.PostForm, "username")
+}
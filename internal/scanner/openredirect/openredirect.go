@@ -6,8 +6,9 @@ import (
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/requtil"
+	"context"
 	"fmt"
-	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -21,6 +22,15 @@ func NewOpenRedirectScanner() *OpenRedirectScanner {
 	return &OpenRedirectScanner{}
 }
 
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "openredirect",
+		Order:          50,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewOpenRedirectScanner() },
+	})
+}
+
 // Name returns the scanner's name.
 func (s *OpenRedirectScanner) Name() string {
 	return "Open Redirect Scanner"
@@ -29,7 +39,10 @@ func (s *OpenRedirectScanner) Name() string {
 // Scan performs a scan for Open Redirect vulnerabilities.
 // It injects various redirect payloads into parameters and checks if the server
 // responds with a redirect to an external domain.
-func (s *OpenRedirectScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+func (s *OpenRedirectScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	// The redirect responses themselves are inspected, so they must not be followed.
+	client = client.WithoutRedirects()
 	var findings []scanner.VulnerabilityResult
 	log.Debug("Starting Open Redirect scan for %s %s...", req.Method, req.URL)
 
@@ -40,7 +53,7 @@ func (s *OpenRedirectScanner) Scan(req crawler.ParameterizedRequest, client *htt
 	originalHost := originalRequestParsedURL.Host
 
 	// --- Path-Based Open Redirect Scan ---
-	for _, orPayload := range payloads.OpenRedirectPayloads {
+	for _, orPayload := range payloads.GetOpenRedirectPayloads() {
 		// We only test for payloads that start with // or \\, as these can manipulate the host
 		if strings.HasPrefix(orPayload, "//") || strings.HasPrefix(orPayload, "\\\\") {
 			parsedURL, err := url.Parse(req.URL)
@@ -53,14 +66,12 @@ func (s *OpenRedirectScanner) Scan(req crawler.ParameterizedRequest, client *htt
 			// This specifically tests for path-based redirects at the root.
 			testURL := fmt.Sprintf("%s://%s%s", parsedURL.Scheme, parsedURL.Host, orPayload)
 
-			httpRequest, reqErr := http.NewRequest("GET", testURL, nil)
+			httpRequest, reqErr := http.NewRequestWithContext(ctx, "GET", testURL, nil)
 			if reqErr != nil {
 				continue
 			}
 
-			originalCheckRedirectFunc := client.TemporarilyDisableRedirects()
 			resp, err := client.Do(httpRequest)
-			client.RestoreRedirects(originalCheckRedirectFunc)
 
 			if resp != nil {
 				defer resp.Body.Close()
@@ -122,20 +133,17 @@ func (s *OpenRedirectScanner) Scan(req crawler.ParameterizedRequest, client *htt
 
 	if contains(req.ParamLocations, "query") || contains(req.ParamLocations, "body") {
 		for _, paramName := range req.ParamNames {
-			for _, orPayload := range payloads.OpenRedirectPayloads {
-				testURL, reqBody, httpMethod := buildRequest(req, paramName, orPayload)
-
-				httpRequest, reqErr := http.NewRequest(httpMethod, testURL, reqBody)
+			if opts.SkipParam("openredirect", paramName) {
+				continue
+			}
+			for _, orPayload := range payloads.GetOpenRedirectPayloads() {
+				httpRequest, reqErr := requtil.Override(ctx, req, paramName, orPayload)
 				if reqErr != nil {
 					continue
 				}
-				if httpMethod == "POST" {
-					httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-				}
+				testURL := httpRequest.URL.String()
 
-				originalCheckRedirectFunc := client.TemporarilyDisableRedirects()
 				resp, err := client.Do(httpRequest)
-				client.RestoreRedirects(originalCheckRedirectFunc)
 
 				if resp != nil {
 					defer resp.Body.Close()
@@ -174,17 +182,12 @@ func (s *OpenRedirectScanner) Scan(req crawler.ParameterizedRequest, client *htt
 								details := fmt.Sprintf("Redirected to external URL '%s' (Host: %s) which matches payload host '%s'. Original host: '%s'. Status: %d.",
 									locationHeader, redirectURL.Host, payloadTargetURL.Host, originalHost, resp.StatusCode)
 
-								locationType := "query"
-								if httpMethod == "POST" {
-									locationType = "body"
-								}
-
 								findings = append(findings, scanner.VulnerabilityResult{
 									VulnerabilityType: "Open Redirect",
 									URL:               req.URL,
 									Parameter:         paramName,
 									Payload:           orPayload,
-									Location:          locationType,
+									Location:          requtil.Location(req, paramName),
 									Details:           details,
 									Severity:          "medium",
 									Evidence:          locationHeader,
@@ -203,32 +206,6 @@ func (s *OpenRedirectScanner) Scan(req crawler.ParameterizedRequest, client *htt
 	return findings, nil
 }
 
-// buildRequest constructs an HTTP request with the open redirect payload.
-// It handles both GET and POST requests, injecting the payload into the appropriate parameter.
-func buildRequest(req crawler.ParameterizedRequest, paramName, payload string) (string, io.Reader, string) {
-	if req.Method == "GET" {
-		// --- FIX STARTS HERE ---
-		parsedURL, err := url.Parse(req.URL)
-		if err != nil {
-			// If the original URL from the crawler is broken, return an empty string.
-			// This will cause http.NewRequest to fail safely in the Scan function,
-			// and this test will be skipped.
-			return "", nil, "GET"
-		}
-		// --- END FIX ---
-
-		queryParams := parsedURL.Query()
-		queryParams.Set(paramName, payload)
-		parsedURL.RawQuery = queryParams.Encode()
-		return parsedURL.String(), nil, "GET"
-	}
-
-	// The POST part does not have a URL parsing risk, so no changes are needed.
-	formData, _ := url.ParseQuery(req.FormPostData)
-	formData.Set(paramName, payload)
-	return req.URL, strings.NewReader(formData.Encode()), "POST"
-}
-
 // contains checks if a string is present in a slice of strings.
 func contains(s []string, str string) bool {
 	for _, v := range s {
@@ -0,0 +1,125 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// severities are the severities of findings, from the most to the least severe.
+var severities = []string{"Critical", "High", "Medium", "Low", "Info"}
+
+// parseSeverity returns severity ("high", "HIGH", ...) as written in findings ("High").
+func parseSeverity(severity string) (string, error) {
+	for _, s := range severities {
+		if strings.EqualFold(strings.TrimSpace(severity), s) {
+			return s, nil
+		}
+	}
+	return "", fmt.Errorf("invalid severity %q; use %s", severity, strings.Join(severities, ", "))
+}
+
+// Override adapts the findings of a vulnerability type, optionally only at the URLs matching a
+// pattern, to the organization's rating and guidance: it replaces their severity and appends
+// remediation text and a link to the scanner's remediation.
+type Override struct {
+	Type           string // Vulnerability type, matched case-insensitively.
+	URL            string // Regular expression matching the URL of the finding; empty matches all.
+	Severity       string // Severity the findings are given; empty keeps theirs.
+	Remediation    string // Text appended to the remediation.
+	RemediationURL string // Link appended to the remediation, e.g. an internal wiki page.
+
+	url *regexp.Regexp
+}
+
+// String describes the override for the overridden_by field of findings, e.g.
+// `type "Reflected XSS", url ^https://www\.`.
+func (o Override) String() string {
+	s := fmt.Sprintf("type %q", o.Type)
+	if o.URL != "" {
+		s += ", url " + o.URL
+	}
+	return s
+}
+
+// matches reports whether the override applies to v.
+func (o *Override) matches(v VulnerabilityResult) bool {
+	return strings.EqualFold(o.Type, v.VulnerabilityType) && (o.url == nil || o.url.MatchString(v.URL))
+}
+
+// Overrides holds the severity and remediation overrides of a scan (severity_overrides in
+// config.yaml). The first override matching a finding applies. A nil *Overrides changes
+// nothing.
+type Overrides struct {
+	overrides []Override
+}
+
+// NewOverrides validates overrides and returns them ready to apply: each needs a type, a valid
+// URL pattern and a known severity, and must change the severity or the remediation.
+func NewOverrides(overrides []Override) (*Overrides, error) {
+	var errs []error
+	checked := make([]Override, len(overrides))
+	for i, o := range overrides {
+		var err error
+		if strings.TrimSpace(o.Type) == "" {
+			errs = append(errs, fmt.Errorf("severity override %d: type is required", i+1))
+		}
+		if o.Severity != "" {
+			if o.Severity, err = parseSeverity(o.Severity); err != nil {
+				errs = append(errs, fmt.Errorf("severity override %d: %w", i+1, err))
+			}
+		}
+		if o.Severity == "" && o.Remediation == "" && o.RemediationURL == "" {
+			errs = append(errs, fmt.Errorf("severity override %d: set a severity, remediation or remediation_url", i+1))
+		}
+		if o.URL != "" {
+			if o.url, err = regexp.Compile(o.URL); err != nil {
+				errs = append(errs, fmt.Errorf("severity override %d: invalid url pattern: %v", i+1, err))
+			}
+		}
+		checked[i] = o
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return &Overrides{overrides: checked}, nil
+}
+
+// Len returns the number of overrides.
+func (o *Overrides) Len() int {
+	if o == nil {
+		return 0
+	}
+	return len(o.overrides)
+}
+
+// Apply applies the first matching override to each of findings, after Classify, recording the
+// override in OverriddenBy and the severity the scanner reported in OriginalSeverity. Findings
+// already overridden are left as they are, so findings can be passed again.
+func (o *Overrides) Apply(findings []VulnerabilityResult) {
+	if o == nil {
+		return
+	}
+	for i := range findings {
+		v := &findings[i]
+		if v.OverriddenBy != "" {
+			continue
+		}
+		idx := slices.IndexFunc(o.overrides, func(override Override) bool { return override.matches(*v) })
+		if idx < 0 {
+			continue
+		}
+		override := o.overrides[idx]
+		v.OverriddenBy = override.String()
+		if override.Severity != "" && override.Severity != v.Severity {
+			v.OriginalSeverity, v.Severity = v.Severity, override.Severity
+		}
+		remediation := []string{v.Remediation, override.Remediation}
+		if override.RemediationURL != "" {
+			remediation = append(remediation, "See "+override.RemediationURL)
+		}
+		v.Remediation = strings.Join(slices.DeleteFunc(remediation, func(s string) bool { return strings.TrimSpace(s) == "" }), " ")
+	}
+}
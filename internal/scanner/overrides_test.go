@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverridesApply(t *testing.T) {
+	overrides, err := NewOverrides([]Override{
+		{Type: "reflected xss", URL: `^https://www\.example\.com/`, Severity: "MEDIUM"},
+		{Type: "Missing Security Header", Severity: "info", RemediationURL: "https://wiki.example.com/headers"},
+		{Type: "SQL Injection", Remediation: "Use the query builder of the platform."},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, overrides.Len())
+
+	findings := []VulnerabilityResult{
+		{VulnerabilityType: "Reflected XSS", URL: "https://www.example.com/search?q=x", Severity: "High"},
+		{VulnerabilityType: "Reflected XSS", URL: "https://app.example.com/search?q=x", Severity: "High"},
+		{VulnerabilityType: "Missing Security Header", URL: "https://app.example.com/", Severity: "Low", Remediation: "Send Strict-Transport-Security."},
+		{VulnerabilityType: "SQL Injection", URL: "https://app.example.com/item?id=1", Severity: "Critical"},
+	}
+	overrides.Apply(findings)
+
+	assert.Equal(t, "Medium", findings[0].Severity)
+	assert.Equal(t, "High", findings[0].OriginalSeverity)
+	assert.Equal(t, `type "reflected xss", url ^https://www\.example\.com/`, findings[0].OverriddenBy)
+	assert.Equal(t, VulnerabilityResult{VulnerabilityType: "Reflected XSS", URL: "https://app.example.com/search?q=x", Severity: "High"}, findings[1], "the URL pattern does not match")
+	assert.Equal(t, "Info", findings[2].Severity)
+	assert.Equal(t, "Send Strict-Transport-Security. See https://wiki.example.com/headers", findings[2].Remediation)
+	assert.Equal(t, "Critical", findings[3].Severity)
+	assert.Empty(t, findings[3].OriginalSeverity, "the severity is kept")
+	assert.Equal(t, "Use the query builder of the platform.", findings[3].Remediation)
+	assert.Equal(t, `type "SQL Injection"`, findings[3].OverriddenBy)
+
+	overrides.Apply(findings)
+	assert.Equal(t, "High", findings[0].OriginalSeverity, "findings are overridden once")
+	assert.Equal(t, "Send Strict-Transport-Security. See https://wiki.example.com/headers", findings[2].Remediation)
+
+	var none *Overrides
+	none.Apply(findings[1:2])
+	assert.Empty(t, findings[1].OverriddenBy)
+}
+
+func TestNewOverridesValidates(t *testing.T) {
+	_, err := NewOverrides([]Override{
+		{Type: "Reflected XSS", Severity: "informational"},
+		{URL: "(", Severity: "low"},
+		{Type: "SQL Injection"},
+	})
+	require.Error(t, err)
+	for _, msg := range []string{
+		`severity override 1: invalid severity "informational"; use Critical, High, Medium, Low, Info`,
+		"severity override 2: type is required",
+		"severity override 2: invalid url pattern",
+		"severity override 3: set a severity, remediation or remediation_url",
+	} {
+		assert.Contains(t, err.Error(), msg)
+	}
+}
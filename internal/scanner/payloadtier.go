@@ -0,0 +1,57 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PayloadTier is how much of each payload list scanners send (ScannerOptions.PayloadTier,
+// -payload-tier). Payload lists are ordered with the most broadly effective payloads first, so a
+// reduced tier keeps those.
+type PayloadTier string
+
+// Payload tiers.
+const (
+	PayloadsMinimal  PayloadTier = "minimal"  // The first few payloads of each list, for smoke tests.
+	PayloadsStandard PayloadTier = "standard" // The common payloads of each list.
+	PayloadsFull     PayloadTier = "full"     // Every payload (default).
+)
+
+// Payloads kept per list by the reduced tiers.
+const (
+	minimalPayloads  = 3
+	standardPayloads = 10
+)
+
+// ParsePayloadTier parses a tier name; an empty name is PayloadsFull.
+func ParsePayloadTier(name string) (PayloadTier, error) {
+	switch tier := PayloadTier(strings.ToLower(strings.TrimSpace(name))); tier {
+	case "":
+		return PayloadsFull, nil
+	case PayloadsMinimal, PayloadsStandard, PayloadsFull:
+		return tier, nil
+	}
+	return "", fmt.Errorf("invalid payload tier %q; use minimal, standard or full", name)
+}
+
+// Limit returns the number of payloads kept from a list of n, or n for the full tier.
+func (t PayloadTier) Limit(n int) int {
+	switch t {
+	case PayloadsMinimal:
+		return min(n, minimalPayloads)
+	case PayloadsStandard:
+		return min(n, standardPayloads)
+	}
+	return n
+}
+
+// Keeps reports whether the tier keeps the payload at index i (0-based) of a list, for lists
+// whose payloads are filtered or grouped before being sent.
+func (t PayloadTier) Keeps(i int) bool {
+	return i < t.Limit(i+1)
+}
+
+// TrimPayloads returns the payloads of list the tier keeps.
+func TrimPayloads[T any](tier PayloadTier, list []T) []T {
+	return list[:tier.Limit(len(list))]
+}
@@ -0,0 +1,28 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPayloadTier(t *testing.T) {
+	tier, err := ParsePayloadTier("")
+	require.NoError(t, err)
+	assert.Equal(t, PayloadsFull, tier)
+	tier, err = ParsePayloadTier(" Minimal ")
+	require.NoError(t, err)
+	assert.Equal(t, PayloadsMinimal, tier)
+	_, err = ParsePayloadTier("huge")
+	assert.Error(t, err)
+
+	list := make([]int, 25)
+	assert.Len(t, TrimPayloads(PayloadsMinimal, list), minimalPayloads)
+	assert.Len(t, TrimPayloads(PayloadsStandard, list), standardPayloads)
+	assert.Len(t, TrimPayloads(PayloadsFull, list), 25)
+	assert.Len(t, TrimPayloads(PayloadsStandard, list[:4]), 4, "short lists are kept whole")
+	assert.True(t, PayloadsMinimal.Keeps(minimalPayloads-1))
+	assert.False(t, PayloadsMinimal.Keeps(minimalPayloads))
+	assert.True(t, PayloadsFull.Keeps(1000))
+}
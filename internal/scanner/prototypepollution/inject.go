@@ -0,0 +1,181 @@
+package prototypepollution
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner/requtil"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// injectionPoint places a pollution vector at the root of the request's parameters or nested in
+// one of them, e.g. a[__proto__][canary]=value or {"a": {"__proto__": {"canary": "value"}}}.
+type injectionPoint struct {
+	parent string // Parameter or top-level JSON key the vector is nested in; empty for the root.
+	vector payloads.PrototypePollutionVector
+}
+
+// label names the injected key path the way it is sent.
+func (p injectionPoint) label(req crawler.ParameterizedRequest) string {
+	keys := p.vector.Keys
+	if p.parent != "" {
+		keys = append([]string{p.parent}, keys...)
+	}
+	if req.IsJSON() {
+		return strings.Join(keys, ".")
+	}
+	label := keys[0]
+	for _, key := range keys[1:] {
+		label += "[" + key + "]"
+	}
+	return label
+}
+
+// injectionPoints returns every vector at the root and nested in each parameter (query and form
+// requests) or each top-level object (JSON requests).
+func injectionPoints(req crawler.ParameterizedRequest) []injectionPoint {
+	parents := []string{""}
+	if req.IsJSON() {
+		doc, err := decodeJSONObject(req.RawBody)
+		if err != nil {
+			return nil
+		}
+		var keys []string
+		for key, value := range doc {
+			if _, ok := value.(map[string]interface{}); ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		parents = append(parents, keys...)
+	} else {
+		parents = append(parents, req.ParamNames...)
+	}
+
+	var points []injectionPoint
+	for _, parent := range parents {
+		for _, vector := range payloads.PrototypePollutionVectors {
+			points = append(points, injectionPoint{parent: parent, vector: vector})
+		}
+	}
+	return points
+}
+
+// newRequest builds the request for req with the canary property injected at point. A nil point
+// builds the original request.
+func newRequest(ctx context.Context, req crawler.ParameterizedRequest, point *injectionPoint, name, value string) (*http.Request, error) {
+	if req.IsJSON() {
+		body := req.RawBody
+		if point != nil {
+			doc, err := decodeJSONObject(req.RawBody)
+			if err != nil {
+				return nil, err
+			}
+			target := doc
+			if point.parent != "" {
+				child, ok := doc[point.parent].(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("JSON key %q is not an object", point.parent)
+				}
+				target = child
+			}
+			var polluted interface{} = map[string]interface{}{name: value}
+			for i := len(point.vector.Keys) - 1; i > 0; i-- {
+				polluted = map[string]interface{}{point.vector.Keys[i]: polluted}
+			}
+			target[point.vector.Keys[0]] = polluted
+
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			if err := enc.Encode(doc); err != nil {
+				return nil, err
+			}
+			body = buf.String()
+		}
+		httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, strings.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header.Set("Content-Type", req.ContentType)
+		return httpReq, nil
+	}
+
+	params, err := requtil.Params(req)
+	if err != nil {
+		return nil, err
+	}
+	if point != nil {
+		params.Del(point.parent)
+		params.Set(point.label(req)+"["+name+"]", value)
+	}
+	return requtil.New(ctx, req, params)
+}
+
+// describe renders the injected canary the way it is sent, for the finding's payload.
+func describe(req crawler.ParameterizedRequest, point injectionPoint, name, value string) string {
+	if !req.IsJSON() {
+		return fmt.Sprintf("%s[%s]=%s", point.label(req), name, value)
+	}
+	encoded := fmt.Sprintf("{%q: %q}", name, value)
+	for i := len(point.vector.Keys) - 1; i >= 0; i-- {
+		encoded = fmt.Sprintf("{%q: %s}", point.vector.Keys[i], encoded)
+	}
+	if point.parent != "" {
+		encoded = fmt.Sprintf("{%q: %s}", point.parent, encoded)
+	}
+	return encoded
+}
+
+// decodeJSONObject decodes a JSON object while preserving number precision.
+func decodeJSONObject(raw string) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return nil, fmt.Errorf("JSON body is not an object")
+	}
+	return doc, nil
+}
+
+// hasPollutedProperty reports whether a JSON response contains the canary property outside of the
+// injected key paths, i.e. as a property the server copied onto or inherited into its own objects.
+func hasPollutedProperty(body []byte, name, value string) bool {
+	var doc interface{}
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return false
+	}
+	return findProperty(doc, name, value)
+}
+
+func findProperty(node interface{}, name, value string) bool {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if v[name] == value {
+			return true
+		}
+		for key, child := range v {
+			if key == "__proto__" || key == "constructor" || key == "prototype" {
+				continue // The injected object echoed back as sent.
+			}
+			if findProperty(child, name, value) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if findProperty(child, name, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
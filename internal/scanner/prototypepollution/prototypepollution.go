@@ -0,0 +1,182 @@
+package prototypepollution
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// PrototypePollutionScanner implements the Scanner interface for server-side prototype pollution in
+// Node.js applications.
+type PrototypePollutionScanner struct{}
+
+// NewPrototypePollutionScanner creates a new instance of PrototypePollutionScanner.
+func NewPrototypePollutionScanner() *PrototypePollutionScanner {
+	return &PrototypePollutionScanner{}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "prototypepollution",
+		Order:          270,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewPrototypePollutionScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *PrototypePollutionScanner) Name() string {
+	return "Prototype Pollution Scanner"
+}
+
+// Scan injects __proto__ and constructor.prototype keys carrying a canary property into JSON
+// bodies, query strings and form bodies. A finding is reported when the injection crashes the
+// server with a Node.js stack trace, or when the canary property shows up as a property of the
+// objects in the response or in the response to a following clean request. Targets are only
+// tested when fingerprinted as Node.js (from the Server and X-Powered-By headers), unless
+// ScannerOptions.ForcePrototypePollution is set.
+func (s *PrototypePollutionScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	if req.IsGraphQL() || req.IsMultipart() || (req.Method != "GET" && req.Method != "POST" && !req.IsJSON()) {
+		return nil, nil
+	}
+	points := injectionPoints(req)
+	if len(points) == 0 {
+		return nil, nil
+	}
+
+	baselineReq, err := newRequest(ctx, req, nil, "", "")
+	if err != nil {
+		return nil, nil
+	}
+	baselineResp, baselineBody, err := send(client, baselineReq)
+	if err != nil {
+		return nil, ctx.Err()
+	}
+	if !opts.ForcePrototypePollution && !opts.Technologies.Has("Node.js") && !isNodeTarget(opts.Fingerprint, baselineResp.Header) {
+		log.Debug("Prototype Pollution: Skipping %s, target is not fingerprinted as Node.js", req.URL)
+		return nil, nil
+	}
+	// A page that already shows a stack trace cannot tell whether the injection caused one.
+	baselineCrashes := baselineResp.StatusCode >= 500 || payloads.MatchNodeStackTrace(string(baselineBody)) != ""
+
+	log.Debug("Starting prototype pollution scan for %s %s", req.Method, req.URL)
+	for _, point := range points {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		pointClient := client.WithRequestBudget(opts.MaxRequestsPerParam)
+		vuln, ok, err := s.testPoint(ctx, req, pointClient, log, point, baselineCrashes)
+		if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			log.Debug("Prototype Pollution: Request budget exhausted for %s at %s", point.label(req), req.URL)
+			continue
+		}
+		if ok {
+			// One polluting key path is enough to prove the merge is unsafe.
+			return []scanner.VulnerabilityResult{vuln}, nil
+		}
+	}
+	return nil, ctx.Err()
+}
+
+// testPoint sends a canary property at point, then the original request, and checks both
+// responses for the effects of the pollution.
+func (s *PrototypePollutionScanner) testPoint(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, point injectionPoint, baselineCrashes bool) (scanner.VulnerabilityResult, bool, error) {
+	name, value := payloads.GeneratePrototypePollutionCanary()
+	httpReq, err := newRequest(ctx, req, &point, name, value)
+	if err != nil {
+		return scanner.VulnerabilityResult{}, false, nil
+	}
+	resp, body, err := send(client, httpReq)
+	if err != nil {
+		return scanner.VulnerabilityResult{}, false, err
+	}
+
+	vuln := scanner.VulnerabilityResult{
+		URL:         httpReq.URL.String(),
+		Parameter:   point.label(req),
+		Payload:     describe(req, point, name, value),
+		Location:    getParamLocation(req),
+		Remediation: "Do not merge, clone or assign user-controlled objects recursively without filtering the keys __proto__, constructor and prototype. Parse input into objects created with Object.create(null) or Map, validate request bodies against a schema, and keep merge libraries (lodash, qs, deepmerge) up to date. Object.freeze(Object.prototype) prevents global pollution.",
+		ScannerName: s.Name(),
+	}
+
+	if !baselineCrashes && resp.StatusCode >= 500 {
+		if trace := payloads.MatchNodeStackTrace(string(body)); trace != "" {
+			log.Success("Prototype Pollution (Server Error): Key path '%s' crashed %s", point.label(req), req.URL)
+			vuln.VulnerabilityType = "Prototype Pollution (Server Error)"
+			vuln.Severity = "Medium"
+			vuln.Details = fmt.Sprintf("Sending the key path %s made the server fail with a Node.js stack trace, while the original request succeeded. The input reaches an object merge or property assignment that walks into the prototype, which is often exploitable to pollute Object.prototype.", point.label(req))
+			vuln.Evidence = fmt.Sprintf("HTTP %d with stack trace: %s", resp.StatusCode, trace)
+			vuln.SetExchange(scanner.CaptureExchange(httpReq, resp, body))
+			return vuln, true, nil
+		}
+	}
+
+	if hasPollutedProperty(body, name, value) {
+		log.Success("Prototype Pollution (Reflected Property): Key path '%s' added property '%s' at %s", point.label(req), name, req.URL)
+		vuln.VulnerabilityType = "Prototype Pollution (Reflected Property)"
+		vuln.Severity = "Medium"
+		vuln.Details = fmt.Sprintf("The property '%s', sent only inside %s, came back as a property of the response objects. The server assigns user-controlled keys into the prototype of its objects, which lets attackers inject properties the application did not expect (e.g., isAdmin).", name, point.label(req))
+		vuln.Evidence = fmt.Sprintf("Response contains \"%s\": \"%s\" outside of the injected key path.", name, value)
+		vuln.SetExchange(scanner.CaptureExchange(httpReq, resp, body))
+	}
+
+	// A polluted Object.prototype outlives the request: the property is inherited by objects
+	// built while answering a request that does not carry it.
+	cleanReq, err := newRequest(ctx, req, nil, "", "")
+	if err != nil {
+		return vuln, vuln.VulnerabilityType != "", nil
+	}
+	cleanResp, cleanBody, err := send(client, cleanReq)
+	if err != nil {
+		return vuln, vuln.VulnerabilityType != "", err
+	}
+	if hasPollutedProperty(cleanBody, name, value) {
+		log.Success("Prototype Pollution (Persistent): Key path '%s' polluted Object.prototype at %s", point.label(req), req.URL)
+		vuln.VulnerabilityType = "Prototype Pollution (Persistent)"
+		vuln.Severity = "High"
+		vuln.Details = fmt.Sprintf("After sending the property '%s' inside %s, a following request without it returned the property too. The injection polluted Object.prototype for the whole server process, so attackers can change application logic for every user and often escalate to denial of service or remote code execution through gadgets.", name, point.label(req))
+		vuln.Evidence = fmt.Sprintf("Clean request after the injection returned HTTP %d containing \"%s\": \"%s\". The canary property stays on the prototype until the server restarts.", cleanResp.StatusCode, name, value)
+		vuln.SetExchange(scanner.CaptureExchange(cleanReq, cleanResp, cleanBody))
+	}
+	return vuln, vuln.VulnerabilityType != "", nil
+}
+
+// isNodeTarget reports whether the fingerprint or the response headers identify a Node.js backend.
+func isNodeTarget(fingerprint map[string]string, header http.Header) bool {
+	for tech, value := range fingerprint {
+		if payloads.IsNodeTechnology(tech) || payloads.IsNodeTechnology(value) {
+			return true
+		}
+	}
+	return payloads.IsNodeTechnology(header.Get("X-Powered-By")) || payloads.IsNodeTechnology(header.Get("Server"))
+}
+
+// send performs httpReq and reads the response body.
+func send(client *httpclient.Client, httpReq *http.Request) (*http.Response, []byte, error) {
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return resp, body, err
+}
+
+func getParamLocation(req crawler.ParameterizedRequest) string {
+	switch {
+	case req.Method == "GET":
+		return "query"
+	case req.IsJSON():
+		return "json"
+	}
+	return "body"
+}
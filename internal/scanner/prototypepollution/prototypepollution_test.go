@@ -0,0 +1,164 @@
+package prototypepollution
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// nodeStackTrace is the error page Express renders for an uncaught exception in development mode.
+const nodeStackTrace = `TypeError: Cannot convert object to primitive value
+    at String (<anonymous>)
+    at merge (/app/node_modules/merge/index.js:31:18)
+    at Layer.handle [as handle_request] (/app/node_modules/express/lib/router/layer.js:95:5)`
+
+// pollutingServer mimics an Express API that deep-merges the request body into a profile. Merging
+// __proto__ reaches the shared prototype, whose properties every later profile inherits.
+func pollutingServer(poweredBy string) *httptest.Server {
+	var mu sync.Mutex
+	prototype := map[string]interface{}{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		mu.Lock()
+		defer mu.Unlock()
+		profile := map[string]interface{}{"id": 7}
+		for key, value := range body {
+			if key == "__proto__" {
+				for k, v := range value.(map[string]interface{}) {
+					prototype[k] = v
+				}
+				continue
+			}
+			profile[key] = value
+		}
+		for k, v := range prototype {
+			profile[k] = v
+		}
+		if poweredBy != "" {
+			w.Header().Set("X-Powered-By", poweredBy)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(profile)
+	}))
+}
+
+func jsonRequest(serverURL string) crawler.ParameterizedRequest {
+	return crawler.ParameterizedRequest{
+		Method:      "POST",
+		URL:         serverURL + "/api/profile",
+		ParamNames:  []string{"name"},
+		ContentType: "application/json",
+		RawBody:     `{"name": "alice"}`,
+	}
+}
+
+func TestScanPersistentPollution(t *testing.T) {
+	server := pollutingServer("Express")
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+
+	findings, err := NewPrototypePollutionScanner().Scan(context.Background(), jsonRequest(server.URL), client, log, scanner.ScannerOptions{})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Prototype Pollution (Persistent)", findings[0].VulnerabilityType)
+	assert.Equal(t, "High", findings[0].Severity)
+	assert.Equal(t, "__proto__", findings[0].Parameter)
+	assert.Equal(t, "json", findings[0].Location)
+	assert.Contains(t, findings[0].Payload, `{"__proto__": {"dursgo_pp_`)
+}
+
+func TestScanServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Powered-By", "Express")
+		if strings.Contains(r.URL.RawQuery, "__proto__") {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("<pre>" + nodeStackTrace + "</pre>"))
+			return
+		}
+		w.Write([]byte("<html><body>Results for " + r.URL.Query().Get("q") + "</body></html>"))
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	req := crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/search?q=shoes", ParamNames: []string{"q"}}
+
+	findings, err := NewPrototypePollutionScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Prototype Pollution (Server Error)", findings[0].VulnerabilityType)
+	assert.Equal(t, "query", findings[0].Location)
+	assert.Contains(t, findings[0].Evidence, "at merge (/app/node_modules/merge/index.js:31:18)")
+	assert.Regexp(t, `^__proto__\[dursgo_pp_\d{6}\]=dursgo-polluted-\d{6}$`, findings[0].Payload)
+}
+
+func TestScanRequiresNodeFingerprint(t *testing.T) {
+	server := pollutingServer("")
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	s := NewPrototypePollutionScanner()
+
+	findings, err := s.Scan(context.Background(), jsonRequest(server.URL), client, log, scanner.ScannerOptions{Fingerprint: map[string]string{"WebServer": "nginx"}})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+
+	findings, err = s.Scan(context.Background(), jsonRequest(server.URL), client, log, scanner.ScannerOptions{Fingerprint: map[string]string{"X-Powered-By": "Next.js"}})
+	require.NoError(t, err)
+	assert.Len(t, findings, 1)
+
+	findings, err = s.Scan(context.Background(), jsonRequest(server.URL), client, log, scanner.ScannerOptions{ForcePrototypePollution: true})
+	require.NoError(t, err)
+	assert.Len(t, findings, 1)
+}
+
+func TestScanEchoedInputIsNotReported(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Stores and returns the body as sent, without merging it into anything.
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("X-Powered-By", "Express")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"saved": body})
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+
+	findings, err := NewPrototypePollutionScanner().Scan(context.Background(), jsonRequest(server.URL), client, log, scanner.ScannerOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
+
+func TestNewRequestNestedQueryKey(t *testing.T) {
+	req := crawler.ParameterizedRequest{Method: "GET", URL: "http://example.com/items?a=1&b=2", ParamNames: []string{"a", "b"}}
+	points := injectionPoints(req)
+	require.Len(t, points, 6)
+
+	point := points[3] // constructor.prototype nested in a.
+	httpReq, err := newRequest(context.Background(), req, &point, "polluted", "1")
+	require.NoError(t, err)
+	query := httpReq.URL.Query()
+	assert.Equal(t, "1", query.Get("a[constructor][prototype][polluted]"))
+	assert.Equal(t, "2", query.Get("b"))
+	assert.NotContains(t, query, "a")
+	assert.Equal(t, "a[constructor][prototype][polluted]=1", describe(req, point, "polluted", "1"))
+}
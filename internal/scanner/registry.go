@@ -0,0 +1,375 @@
+package scanner
+
+import (
+	"Dursgo/internal/httpclient"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// OptionType is the type of a scanner module option.
+type OptionType string
+
+// Option types.
+const (
+	OptionInt    OptionType = "int"
+	OptionFloat  OptionType = "float"
+	OptionBool   OptionType = "bool"
+	OptionString OptionType = "string"
+)
+
+// OptionSpec describes an option a scanner module accepts under scanners.<name> in config.yaml.
+type OptionSpec struct {
+	Name        string
+	Type        OptionType
+	Default     interface{}
+	Description string
+	// Validate checks a configured value after its conversion to Type; nil accepts any value.
+	Validate func(value interface{}) error
+}
+
+// Requirement is a capability of the scan that a scanner module needs in order to run.
+type Requirement int
+
+const (
+	RequiresNothing  Requirement = iota // Runs in every scan.
+	RequiresOAST                        // Needs an out-of-band collaborator (-oast).
+	RequiresRenderer                    // Needs the headless browser (-render-js).
+)
+
+// String returns the flag that satisfies the requirement.
+func (r Requirement) String() string {
+	switch r {
+	case RequiresOAST:
+		return "-oast"
+	case RequiresRenderer:
+		return "-render-js"
+	}
+	return "nothing"
+}
+
+// Env is the scan environment passed to the factories of scanner modules.
+type Env struct {
+	TargetBaseURL   string             // Scheme and host of the target.
+	AnonymousClient *httpclient.Client // Client without the scan's credentials, for third-party hosts.
+	OAST            bool               // An out-of-band collaborator is available.
+	Renderer        bool               // The headless browser is available.
+	LoginUsernames  []string           // User names of the configured logins, never sent failing logins.
+}
+
+// Registration describes a scanner module. Modules register themselves from an init function,
+// so importing a scanner package makes it available.
+type Registration struct {
+	Name           string                   // Name used to select the module, e.g. "sqli".
+	Version        string                   // Version of the module's checks, recorded in reports.
+	Order          int                      // Position in the scan order; lower runs first.
+	DefaultEnabled bool                     // Whether "all" selects the module.
+	Requires       Requirement              // Capability the module needs; without it, the module is skipped.
+	Options        []OptionSpec             // Options accepted under scanners.<name> in config.yaml.
+	New            func(Env) Scanner        // Factory of active modules.
+	NewPassive     func(Env) PassiveScanner // Factory of passive modules.
+	// TestsInertParams marks modules whose sinks may be blind (out-of-band callbacks, second
+	// order): they get the parameters the inert-parameter pre-flight found to have no visible
+	// effect, and check ScannerOptions.InertParams themselves.
+	TestsInertParams bool
+}
+
+// DefaultModuleVersion is the version of modules that do not set Registration.Version.
+const DefaultModuleVersion = "1.0"
+
+// Reserved keys of scanners.<name> in config.yaml, accepted by every module.
+const (
+	settingEnabled = "enabled" // true selects the module, false deselects it.
+	settingOrder   = "order"   // Overrides Registration.Order.
+)
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]Registration)
+	aliases    = make(map[string][]string)
+)
+
+// Register adds a scanner module to the registry. It panics if the name is taken or the module
+// has no factory, as registration happens at init time.
+func Register(r Registration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, taken := registry[r.Name]; taken || aliases[r.Name] != nil {
+		panic("scanner: duplicate registration of " + r.Name)
+	}
+	if (r.New == nil) == (r.NewPassive == nil) {
+		panic("scanner: " + r.Name + " must have exactly one of New and NewPassive")
+	}
+	if r.Version == "" {
+		r.Version = DefaultModuleVersion
+	}
+	registry[r.Name] = r
+}
+
+// RegisterAlias makes name select several modules, e.g. "xss" for the reflected and stored XSS
+// scanners.
+func RegisterAlias(name string, modules ...string) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, taken := registry[name]; taken || aliases[name] != nil {
+		panic("scanner: duplicate registration of " + name)
+	}
+	aliases[name] = modules
+}
+
+// AvailableScanners returns the names that can be selected: modules and aliases, sorted.
+func AvailableScanners() []string {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	names := make([]string, 0, len(registry)+len(aliases))
+	for name := range registry {
+		names = append(names, name)
+	}
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Selection is the scanner selection of the command line and config.yaml. Later sources take
+// precedence: Base, then the "enabled" settings, then Enable, then Disable.
+type Selection struct {
+	Base     string                            // "all", "none" or comma-separated names (-s, scanners_to_run).
+	Enable   []string                          // Names added to the selection (-enable-scanners).
+	Disable  []string                          // Names removed from the selection (-disable-scanners).
+	Settings map[string]map[string]interface{} // Options per module (scanners.<name>.<option>).
+}
+
+// SelectedModule is a module chosen to run, with its resolved options.
+type SelectedModule struct {
+	Registration
+	Options map[string]interface{} // Every option of the module, defaults filled in.
+}
+
+// Resolution is the outcome of Resolve.
+type Resolution struct {
+	Modules []SelectedModule  // Modules to run, in scan order.
+	Skipped map[string]string // Selected modules that cannot run in this scan, with the reason.
+}
+
+// ModuleOptions returns the options of the selected modules, keyed by module name, as stored in
+// ScannerOptions.ModuleOptions.
+func (r Resolution) ModuleOptions() map[string]map[string]interface{} {
+	options := make(map[string]map[string]interface{}, len(r.Modules))
+	for _, module := range r.Modules {
+		options[module.Name] = module.Options
+	}
+	return options
+}
+
+// Names returns the names of the selected modules in scan order.
+func (r Resolution) Names() []string {
+	names := make([]string, 0, len(r.Modules))
+	for _, module := range r.Modules {
+		names = append(names, module.Name)
+	}
+	return names
+}
+
+// Resolve selects the modules to run and resolves their options. Unknown scanner or option
+// names and options of the wrong type are errors; modules whose requirement env does not meet
+// are reported in Resolution.Skipped.
+func Resolve(sel Selection, env Env) (Resolution, error) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	selected := make(map[string]bool)
+	switch base := strings.ToLower(strings.TrimSpace(sel.Base)); base {
+	case "all":
+		for name, r := range registry {
+			if r.DefaultEnabled {
+				selected[name] = true
+			}
+		}
+	case "", "none":
+	default:
+		if err := applyNames(selected, strings.Split(base, ","), true); err != nil {
+			return Resolution{}, err
+		}
+	}
+
+	// Settings are validated whether or not their module is selected.
+	options := make(map[string]map[string]interface{}, len(registry))
+	orders := make(map[string]int, len(registry))
+	for name, r := range registry {
+		options[name] = make(map[string]interface{}, len(r.Options))
+		for _, spec := range r.Options {
+			options[name][spec.Name] = spec.Default
+		}
+		orders[name] = r.Order
+	}
+	settingNames := make([]string, 0, len(sel.Settings))
+	for name := range sel.Settings {
+		settingNames = append(settingNames, name)
+	}
+	sort.Strings(settingNames)
+	for _, name := range settingNames {
+		modules, err := expandName(strings.ToLower(name))
+		if err != nil {
+			return Resolution{}, err
+		}
+		for _, module := range modules {
+			for key, value := range sel.Settings[name] {
+				switch key {
+				case settingEnabled:
+					enabled, ok := value.(bool)
+					if !ok {
+						return Resolution{}, fmt.Errorf("scanners.%s.%s must be true or false", name, key)
+					}
+					selected[module] = enabled
+				case settingOrder:
+					order, err := convertOption(value, OptionInt)
+					if err != nil {
+						return Resolution{}, fmt.Errorf("scanners.%s.%s: %v", name, key, err)
+					}
+					orders[module] = order.(int)
+				default:
+					spec, ok := findOption(registry[module], key)
+					if !ok {
+						return Resolution{}, fmt.Errorf("unknown option scanners.%s.%s; available options: %s", name, key, optionNames(registry[module]))
+					}
+					converted, err := convertOption(value, spec.Type)
+					if err == nil && spec.Validate != nil {
+						err = spec.Validate(converted)
+					}
+					if err != nil {
+						return Resolution{}, fmt.Errorf("scanners.%s.%s: %v", name, key, err)
+					}
+					options[module][key] = converted
+				}
+			}
+		}
+	}
+
+	if err := applyNames(selected, sel.Enable, true); err != nil {
+		return Resolution{}, err
+	}
+	if err := applyNames(selected, sel.Disable, false); err != nil {
+		return Resolution{}, err
+	}
+
+	resolution := Resolution{Skipped: make(map[string]string)}
+	for name, isSelected := range selected {
+		if !isSelected {
+			continue
+		}
+		r := registry[name]
+		if (r.Requires == RequiresOAST && !env.OAST) || (r.Requires == RequiresRenderer && !env.Renderer) {
+			resolution.Skipped[name] = "requires " + r.Requires.String()
+			continue
+		}
+		r.Order = orders[name]
+		resolution.Modules = append(resolution.Modules, SelectedModule{Registration: r, Options: options[name]})
+	}
+	sort.Slice(resolution.Modules, func(i, j int) bool {
+		a, b := resolution.Modules[i], resolution.Modules[j]
+		if a.Order != b.Order {
+			return a.Order < b.Order
+		}
+		return a.Name < b.Name
+	})
+	return resolution, nil
+}
+
+// applyNames sets the selection state of the modules named in names (aliases expanded).
+// registryMu must be held.
+func applyNames(selected map[string]bool, names []string, state bool) error {
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		modules, err := expandName(name)
+		if err != nil {
+			return err
+		}
+		for _, module := range modules {
+			selected[module] = state
+		}
+	}
+	return nil
+}
+
+// expandName returns the modules a name selects. registryMu must be held.
+func expandName(name string) ([]string, error) {
+	if _, ok := registry[name]; ok {
+		return []string{name}, nil
+	}
+	if modules, ok := aliases[name]; ok {
+		return modules, nil
+	}
+	available := make([]string, 0, len(registry)+len(aliases))
+	for n := range registry {
+		available = append(available, n)
+	}
+	for n := range aliases {
+		available = append(available, n)
+	}
+	sort.Strings(available)
+	return nil, fmt.Errorf("unknown scanner %q; available scanners: %s", name, strings.Join(available, ", "))
+}
+
+// findOption returns the spec of the named option of a module.
+func findOption(r Registration, name string) (OptionSpec, bool) {
+	for _, spec := range r.Options {
+		if spec.Name == name {
+			return spec, true
+		}
+	}
+	return OptionSpec{}, false
+}
+
+// testsInertParams reports whether the named module tests inert parameters.
+func testsInertParams(module string) bool {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return registry[module].TestsInertParams
+}
+
+// optionNames lists the options of a module for error messages.
+func optionNames(r Registration) string {
+	names := []string{settingEnabled, settingOrder}
+	for _, spec := range r.Options {
+		names = append(names, spec.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// convertOption converts a value decoded from YAML to the Go type of an option type.
+func convertOption(value interface{}, typ OptionType) (interface{}, error) {
+	switch typ {
+	case OptionInt:
+		switch v := value.(type) {
+		case int:
+			return v, nil
+		case float64:
+			if v == math.Trunc(v) {
+				return int(v), nil
+			}
+		}
+	case OptionFloat:
+		switch v := value.(type) {
+		case int:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		}
+	case OptionBool:
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case OptionString:
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("expected a value of type %s, got %v", typ, value)
+}
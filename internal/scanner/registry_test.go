@@ -0,0 +1,83 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// useTestRegistry replaces the registry with a few modules for the duration of the test.
+func useTestRegistry(t *testing.T) {
+	savedRegistry, savedAliases := registry, aliases
+	registry, aliases = make(map[string]Registration), make(map[string][]string)
+	t.Cleanup(func() { registry, aliases = savedRegistry, savedAliases })
+
+	newActive := func(Env) Scanner { return &recordingScanner{} }
+	Register(Registration{Name: "sqli", Order: 30, DefaultEnabled: true, New: newActive, Options: []OptionSpec{
+		{Name: "time_delay", Type: OptionInt, Default: 5},
+	}})
+	Register(Registration{Name: "xss-reflected", Order: 10, DefaultEnabled: true, New: newActive})
+	Register(Registration{Name: "xss-stored", Order: 20, DefaultEnabled: true, New: newActive})
+	Register(Registration{Name: "blindssrf", Order: 40, DefaultEnabled: true, Requires: RequiresOAST, New: newActive, TestsInertParams: true})
+	Register(Registration{Name: "fuzz", Order: 50, New: newActive})
+	RegisterAlias("xss", "xss-reflected", "xss-stored")
+}
+
+func TestResolveSelection(t *testing.T) {
+	useTestRegistry(t)
+
+	all, err := Resolve(Selection{Base: "all"}, Env{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"xss-reflected", "xss-stored", "sqli"}, all.Names(), "'all' runs default modules in order")
+	assert.Equal(t, "requires -oast", all.Skipped["blindssrf"])
+	assert.Equal(t, map[string]interface{}{"time_delay": 5}, all.ModuleOptions()["sqli"])
+
+	withOAST, err := Resolve(Selection{Base: "all", Enable: []string{"fuzz"}, Disable: []string{"xss"}}, Env{OAST: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sqli", "blindssrf", "fuzz"}, withOAST.Names())
+
+	listed, err := Resolve(Selection{Base: " XSS-Stored , sqli"}, Env{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"xss-stored", "sqli"}, listed.Names())
+
+	none, err := Resolve(Selection{Base: "none"}, Env{})
+	require.NoError(t, err)
+	assert.Empty(t, none.Modules)
+}
+
+func TestResolveSettings(t *testing.T) {
+	useTestRegistry(t)
+
+	res, err := Resolve(Selection{Base: "sqli", Settings: map[string]map[string]interface{}{
+		"sqli": {"time_delay": 8, "order": 1},
+		"xss":  {"enabled": true},
+		"fuzz": {"enabled": true, "order": 15},
+	}}, Env{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"sqli", "xss-reflected", "fuzz", "xss-stored"}, res.Names())
+	assert.Equal(t, 8, res.ModuleOptions()["sqli"]["time_delay"])
+
+	opts := ScannerOptions{ModuleOptions: res.ModuleOptions()}
+	assert.Equal(t, 8, opts.IntOption("sqli", "time_delay", 0))
+	assert.Equal(t, 3, opts.IntOption("sqli", "retries", 3))
+	assert.True(t, ScannerOptions{}.BoolOption("graphql", "batch_testing", true))
+}
+
+func TestResolveRejectsUnknownNames(t *testing.T) {
+	useTestRegistry(t)
+
+	_, err := Resolve(Selection{Base: "sqli,sqlx"}, Env{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown scanner "sqlx"`)
+	assert.Contains(t, err.Error(), "available scanners: blindssrf, fuzz, sqli, xss, xss-reflected, xss-stored")
+
+	_, err = Resolve(Selection{Base: "all", Disable: []string{"csrf"}}, Env{})
+	assert.ErrorContains(t, err, `unknown scanner "csrf"`)
+
+	_, err = Resolve(Selection{Base: "all", Settings: map[string]map[string]interface{}{"sqli": {"time_dealy": 8}}}, Env{})
+	assert.ErrorContains(t, err, "unknown option scanners.sqli.time_dealy; available options: enabled, order, time_delay")
+
+	_, err = Resolve(Selection{Base: "all", Settings: map[string]map[string]interface{}{"sqli": {"time_delay": "8s"}}}, Env{})
+	assert.ErrorContains(t, err, "scanners.sqli.time_delay: expected a value of type int")
+}
@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Checks of a Reproduction, re-run by "dursgo verify".
+const (
+	CheckPattern = "pattern" // The response to Request matches Pattern.
+	CheckTiming  = "timing"  // Request is delayed by Delay seconds beyond a fresh baseline of Baseline.
+	CheckDiff    = "diff"    // The response to Request is similar to that of Baseline, the response to Control is not.
+)
+
+// Reproduction is what a finding needs to be replayed and re-checked with the detection logic
+// that reported it.
+type Reproduction struct {
+	Check    string         `json:"check"`              // CheckPattern, CheckTiming or CheckDiff.
+	Request  ReplayRequest  `json:"request"`            // Request carrying the payload.
+	Baseline *ReplayRequest `json:"baseline,omitempty"` // Request without the payload (timing, diff).
+	Control  *ReplayRequest `json:"control,omitempty"`  // Request whose response must differ from Baseline's (diff).
+	Pattern  string         `json:"pattern,omitempty"`  // Regular expression the response must match (pattern).
+	Delay    int            `json:"delay,omitempty"`    // Sleep in seconds injected by Request (timing).
+	// Threshold and Mode configure the response comparison of CheckDiff, as in
+	// ScannerOptions.SimilarityThreshold and SimilarityMode.
+	Threshold float64 `json:"threshold,omitempty"`
+	Mode      string  `json:"mode,omitempty"`
+}
+
+// ReplayRequest is a request as a scanner built it, before the client added the session and
+// its default headers; a replay gets those from its own configuration.
+type ReplayRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// NewReplayRequest records req. Its body is read through GetBody, so req can still be sent.
+func NewReplayRequest(req *http.Request) ReplayRequest {
+	r := ReplayRequest{Method: req.Method, URL: req.URL.String()}
+	if len(req.Header) > 0 {
+		r.Headers = make(map[string]string, len(req.Header))
+		for name, values := range req.Header {
+			r.Headers[name] = strings.Join(values, ", ")
+		}
+	}
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			body.Close()
+			r.Body = string(data)
+		}
+	}
+	return r
+}
+
+// NewRequest builds the HTTP request to replay r.
+func (r ReplayRequest) NewRequest(ctx context.Context) (*http.Request, error) {
+	var body io.Reader
+	if r.Body != "" {
+		body = strings.NewReader(r.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.Method, r.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(r.Headers))
+	for name := range r.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		req.Header.Set(name, r.Headers[name])
+	}
+	return req, nil
+}
@@ -0,0 +1,173 @@
+package requtil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// flattenJSONBody decodes a JSON document and returns its injectable leaves (strings and numbers)
+// keyed by their path, e.g. "user.name" or "items[0].id".
+func flattenJSONBody(raw string) (url.Values, error) {
+	doc, err := decodeJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	walkJSON(doc, "", func(path string, value interface{}) {
+		params.Set(path, fmt.Sprint(value))
+	})
+	return params, nil
+}
+
+// JSONValues returns the injectable leaves of a JSON body keyed by their path, like
+// JSONParamNames, with their JSON type: strings as string and numbers as json.Number.
+func JSONValues(raw string) (map[string]interface{}, error) {
+	doc, err := decodeJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	walkJSON(doc, "", func(path string, value interface{}) {
+		values[path] = value
+	})
+	return values, nil
+}
+
+// JSONParamNames returns the sorted list of injectable paths found in a JSON body.
+func JSONParamNames(raw string) []string {
+	params, err := flattenJSONBody(raw)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// buildJSONBody re-serializes the original JSON body, replacing every leaf whose path
+// appears in params with the corresponding (possibly injected) value.
+func buildJSONBody(raw string, params url.Values) (string, error) {
+	doc, err := decodeJSON(raw)
+	if err != nil {
+		return "", err
+	}
+	doc = replaceJSON(doc, "", func(path string, leaf interface{}) (interface{}, bool) {
+		if _, ok := params[path]; !ok {
+			return nil, false
+		}
+		// Numbers are only replaced (as strings) when their value was actually modified.
+		if number, ok := leaf.(json.Number); ok && params.Get(path) == number.String() {
+			return nil, false
+		}
+		return params.Get(path), true
+	})
+	return encodeJSON(doc)
+}
+
+// SetJSONPaths returns the JSON document raw with the leaves at the paths of values (see
+// JSONParamNames) replaced by those values, which may be of any JSON type, e.g. objects.
+func SetJSONPaths(raw string, values map[string]interface{}) (string, error) {
+	doc, err := decodeJSON(raw)
+	if err != nil {
+		return "", err
+	}
+	doc = replaceJSON(doc, "", func(path string, _ interface{}) (interface{}, bool) {
+		value, ok := values[path]
+		return value, ok
+	})
+	return encodeJSON(doc)
+}
+
+// SetJSONFields returns the JSON object raw with the top-level fields set to their values,
+// added or replaced, and every other field kept (e.g., to send extra fields along with a
+// legitimate body). raw must hold an object.
+func SetJSONFields(raw string, fields map[string]interface{}) (string, error) {
+	doc, err := decodeJSON(raw)
+	if err != nil {
+		return "", err
+	}
+	object, ok := doc.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("JSON body is not an object")
+	}
+	for key, value := range fields {
+		object[key] = value
+	}
+	return encodeJSON(object)
+}
+
+// encodeJSON serializes a JSON document.
+func encodeJSON(doc interface{}) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false) // Keep payload characters such as '<' and '&' intact.
+	if err := enc.Encode(doc); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// decodeJSON decodes a JSON document while preserving number precision.
+func decodeJSON(raw string) (interface{}, error) {
+	var doc interface{}
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// walkJSON visits every string and number leaf of a decoded JSON document, as a string or a
+// json.Number.
+func walkJSON(node interface{}, path string, visit func(path string, value interface{})) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			walkJSON(child, joinJSONPath(path, key), visit)
+		}
+	case []interface{}:
+		for i, child := range v {
+			walkJSON(child, fmt.Sprintf("%s[%d]", path, i), visit)
+		}
+	case string, json.Number:
+		visit(path, v)
+	}
+}
+
+// replaceJSON returns node with every string and number leaf for which replace returns true
+// replaced by the value it returns.
+func replaceJSON(node interface{}, path string, replace func(path string, leaf interface{}) (interface{}, bool)) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			v[key] = replaceJSON(child, joinJSONPath(path, key), replace)
+		}
+		return v
+	case []interface{}:
+		for i, child := range v {
+			v[i] = replaceJSON(child, fmt.Sprintf("%s[%d]", path, i), replace)
+		}
+		return v
+	case string, json.Number:
+		if value, ok := replace(path, v); ok {
+			return value
+		}
+	}
+	return node
+}
+
+// joinJSONPath appends an object key to a JSON path.
+func joinJSONPath(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
@@ -0,0 +1,66 @@
+package requtil
+
+import (
+	"Dursgo/internal/crawler"
+	"bytes"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// MultipartBoundary separates the parts of multipart test requests. It is fixed so that the
+// Content-Type header can be set independently of the body.
+const MultipartBoundary = "DursgoFormBoundary7MA4YWxkTrZu0gW"
+
+// fileFieldContent is the content of the files sent in file fields without a Content of their
+// own; payloads go into the file name, which applications commonly store in the database.
+const fileFieldContent = "dursgo"
+
+// buildMultipartBody encodes fields in their original order with the values of params. Values
+// of a field appearing several times are taken in order.
+func buildMultipartBody(fields []crawler.MultipartField, params url.Values) (io.Reader, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	if err := writer.SetBoundary(MultipartBoundary); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]int)
+	for _, field := range fields {
+		value := field.Value
+		if values := params[field.Name]; seen[field.Name] < len(values) {
+			value = values[seen[field.Name]]
+		}
+		seen[field.Name]++
+
+		if !field.IsFile {
+			if err := writer.WriteField(field.Name, value); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		h := make(textproto.MIMEHeader)
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(field.Name), escapeQuotes(value)))
+		content, contentType := []byte(fileFieldContent), "text/plain"
+		if field.Content != nil {
+			content, contentType = field.Content, field.ContentType
+		}
+		h.Set("Content-Type", contentType)
+		part, err := writer.CreatePart(h)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// escapeQuotes escapes a Content-Disposition parameter value as mime/multipart does.
+var escapeQuotes = strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace
@@ -0,0 +1,247 @@
+package requtil
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/scanner/nested"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Header, cookie and path injection points are carried alongside the regular parameters using
+// these prefixes (e.g., "header:User-Agent", "cookie:session", "path:id"), so every test can
+// mutate them the same way. Strings inside JSON or base64 parameter values are carried as
+// "nested:<param>:<field>" (see nested.Points) and re-encoded into their parameter when sent.
+const (
+	HeaderPrefix = "header:"
+	CookiePrefix = "cookie:"
+	PathPrefix   = "path:"
+	NestedPrefix = "nested:"
+)
+
+// injectableHeaders are request headers that applications commonly interpolate into queries
+// (access logs, analytics, geo-IP lookups).
+var injectableHeaders = []string{"User-Agent", "Referer", "X-Forwarded-For"}
+
+// AddHeaderInjectionPoints fills in the request's header and cookie injection points when the
+// crawler did not provide any. Cookie values are taken from the client's cookie jar.
+func AddHeaderInjectionPoints(req crawler.ParameterizedRequest, client *httpclient.Client) crawler.ParameterizedRequest {
+	if len(req.Headers) == 0 {
+		req.Headers = make(map[string]string)
+		for _, name := range injectableHeaders {
+			req.Headers[name] = defaultHeaderValue(name, req.URL)
+		}
+	}
+	if len(req.Cookies) == 0 {
+		if u, err := url.Parse(req.URL); err == nil && client.GetClient().Jar != nil {
+			req.Cookies = make(map[string]string)
+			for _, cookie := range client.GetClient().Jar.Cookies(u) {
+				req.Cookies[cookie.Name] = cookie.Value
+			}
+		}
+	}
+	return req
+}
+
+// defaultHeaderValue returns a plausible original value for an injectable header.
+func defaultHeaderValue(name, requestURL string) string {
+	switch name {
+	case "Referer":
+		return requestURL
+	case "X-Forwarded-For":
+		return "127.0.0.1"
+	}
+	return "Mozilla/5.0"
+}
+
+// InjectionPointNames returns the pseudo-parameter names for the request's headers and cookies.
+func InjectionPointNames(req crawler.ParameterizedRequest) []string {
+	var names []string
+	for name := range req.Headers {
+		names = append(names, HeaderPrefix+name)
+	}
+	for name := range req.Cookies {
+		names = append(names, CookiePrefix+name)
+	}
+	return names
+}
+
+// PathParamNames returns the pseudo-parameter names for the request's path parameters.
+func PathParamNames(req crawler.ParameterizedRequest) []string {
+	names := make([]string, 0, len(req.PathParams))
+	for _, param := range req.PathParams {
+		names = append(names, PathPrefix+param.Name)
+	}
+	return names
+}
+
+// addInjectionPointValues adds the original header, cookie and path segment values to params,
+// and the strings nested in its JSON or base64 values.
+func addInjectionPointValues(req crawler.ParameterizedRequest, params url.Values) {
+	var points []nested.Point
+	for name := range params {
+		points = append(points, nested.Points(name, params.Get(name))...)
+	}
+	for _, point := range points {
+		params.Set(nestedParamName(point), point.Value)
+	}
+	for name, value := range req.Headers {
+		params.Set(HeaderPrefix+name, value)
+	}
+	for name, value := range req.Cookies {
+		params.Set(CookiePrefix+name, value)
+	}
+	if len(req.PathParams) == 0 {
+		return
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return
+	}
+	segments := strings.Split(strings.TrimPrefix(u.Path, "/"), "/")
+	for _, param := range req.PathParams {
+		if param.Index < len(segments) {
+			params.Set(PathPrefix+param.Name, segments[param.Index])
+		}
+	}
+}
+
+// withoutInjectionPoints returns params without the header, cookie, path and nested
+// pseudo-parameters.
+func withoutInjectionPoints(params url.Values) url.Values {
+	regular := url.Values{}
+	for key, values := range params {
+		if isInjectionPoint(key) {
+			continue
+		}
+		regular[key] = values
+	}
+	return regular
+}
+
+// isInjectionPoint reports whether key is a header, cookie, path or nested pseudo-parameter.
+func isInjectionPoint(key string) bool {
+	for _, prefix := range []string{HeaderPrefix, CookiePrefix, PathPrefix, NestedPrefix} {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// nestedParamName returns the pseudo-parameter name of a string nested in a parameter value.
+func nestedParamName(point nested.Point) string {
+	return NestedPrefix + point.Param + ":" + point.Field
+}
+
+// NestedParamNames returns the pseudo-parameter names of the strings nested in the values of
+// the parameters in names.
+func NestedParamNames(params url.Values, names []string) []string {
+	var nestedNames []string
+	for _, name := range names {
+		for _, point := range nested.Points(name, params.Get(name)) {
+			nestedNames = append(nestedNames, nestedParamName(point))
+		}
+	}
+	return nestedNames
+}
+
+// nestedPoint returns the nested injection point named paramName among params.
+func nestedPoint(params url.Values, paramName string) (nested.Point, bool) {
+	rest, ok := strings.CutPrefix(paramName, NestedPrefix)
+	if !ok {
+		return nested.Point{}, false
+	}
+	param, _, _ := strings.Cut(rest, ":")
+	for _, point := range nested.Points(param, params.Get(param)) {
+		if nestedParamName(point) == paramName {
+			return point, true
+		}
+	}
+	return nested.Point{}, false
+}
+
+// applyNestedPoints returns params with every modified nested pseudo-parameter re-encoded into
+// the value of its parameter.
+func applyNestedPoints(params url.Values) url.Values {
+	var applied url.Values
+	for key := range params {
+		point, ok := nestedPoint(params, key)
+		if !ok || params.Get(key) == point.Value {
+			continue
+		}
+		if applied == nil {
+			applied = Copy(params)
+		}
+		applied.Set(point.Param, point.Inject(params.Get(key)))
+	}
+	if applied == nil {
+		return params
+	}
+	return applied
+}
+
+// NestedNote returns the note added to the details of a finding on paramName when it is nested
+// in a parameter value, e.g. "The payload was injected into the JSON field 'name' inside
+// base64-encoded parameter 'data'.", else "".
+func NestedNote(req crawler.ParameterizedRequest, paramName string) string {
+	params, err := Params(req)
+	if err != nil {
+		return ""
+	}
+	if point, ok := nestedPoint(params, paramName); ok {
+		return point.Note()
+	}
+	return ""
+}
+
+// applyInjectionPoints sets the header and cookie pseudo-parameters on an outgoing request.
+// Cookies are written to the Cookie header verbatim (http.Cookie would strip quotes and
+// semicolons from payloads) and precede the client's jar cookies, so servers that read the
+// first occurrence of a cookie see the payload.
+func applyInjectionPoints(httpReq *http.Request, params url.Values) {
+	for key := range params {
+		switch {
+		case strings.HasPrefix(key, HeaderPrefix):
+			httpReq.Header.Set(strings.TrimPrefix(key, HeaderPrefix), params.Get(key))
+		case strings.HasPrefix(key, CookiePrefix):
+			pair := strings.TrimPrefix(key, CookiePrefix) + "=" + params.Get(key)
+			if existing := httpReq.Header.Get("Cookie"); existing != "" {
+				pair = existing + "; " + pair
+			}
+			httpReq.Header.Set("Cookie", pair)
+		}
+	}
+}
+
+// injectPath returns u with the path pseudo-parameters of params written into their segments.
+// Injected values are escaped, so a payload containing "/" or "#" stays in its segment.
+func injectPath(u *url.URL, req crawler.ParameterizedRequest, params url.Values) {
+	if len(req.PathParams) == 0 {
+		return
+	}
+	segments := strings.Split(strings.TrimPrefix(u.EscapedPath(), "/"), "/")
+	for _, param := range req.PathParams {
+		if value, ok := params[PathPrefix+param.Name]; ok && len(value) > 0 && param.Index < len(segments) {
+			segments[param.Index] = url.PathEscape(value[0])
+		}
+	}
+	rawPath := "/" + strings.Join(segments, "/")
+	if path, err := url.PathUnescape(rawPath); err == nil {
+		u.Path, u.RawPath = path, rawPath
+	}
+}
+
+// DisplayName strips the header/cookie/path prefix for display in findings. Nested
+// pseudo-parameters are shown as the parameter holding them.
+func DisplayName(paramName string) string {
+	if rest, ok := strings.CutPrefix(paramName, NestedPrefix); ok {
+		param, _, _ := strings.Cut(rest, ":")
+		return param
+	}
+	for _, prefix := range []string{HeaderPrefix, CookiePrefix, PathPrefix} {
+		paramName = strings.TrimPrefix(paramName, prefix)
+	}
+	return paramName
+}
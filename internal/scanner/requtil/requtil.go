@@ -0,0 +1,263 @@
+// Package requtil builds and sends the test requests of the injection scanners. A crawled
+// crawler.ParameterizedRequest is turned into its parameters (Params), one or more of them is
+// replaced by a payload, and the request is rebuilt with every parameter in its original
+// location: query string, form, JSON or multipart body, URL path, header, cookie, or a string
+// nested in a JSON or base64 parameter value (see points.go). Send, SendCaptured and Measure
+// execute the request and read its size-limited body.
+package requtil
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/timing"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Params extracts original parameters from the request based on its method.
+// JSON bodies are flattened into path-keyed values (e.g., "user.name"), and any header, cookie
+// or path injection points are included as prefixed pseudo-parameters.
+func Params(req crawler.ParameterizedRequest) (url.Values, error) {
+	var params url.Values
+	var err error
+	switch {
+	case req.IsJSON():
+		params, err = flattenJSONBody(req.RawBody)
+	case req.IsMultipart():
+		params = url.Values{}
+		for _, field := range req.MultipartFields {
+			params.Add(field.Name, field.Value)
+		}
+	case req.Method == "GET":
+		var u *url.URL
+		u, err = url.Parse(req.URL)
+		if err == nil {
+			params = u.Query()
+		}
+	default:
+		params, err = url.ParseQuery(req.FormPostData)
+	}
+	if err != nil {
+		return nil, err
+	}
+	addInjectionPointValues(req, params)
+	return params, nil
+}
+
+// Copy creates a copy of url.Values that can be modified without changing the original.
+func Copy(original url.Values) url.Values {
+	newParams := url.Values{}
+	for k, v := range original {
+		newParams[k] = v
+	}
+	return newParams
+}
+
+// Override builds the request of req with the parameter (or pseudo-parameter) paramName set to
+// value and every other parameter left at its original value.
+func Override(ctx context.Context, req crawler.ParameterizedRequest, paramName, value string) (*http.Request, error) {
+	params, err := Params(req)
+	if err != nil {
+		return nil, err
+	}
+	params = Copy(params)
+	params.Set(paramName, value)
+	return New(ctx, req, params)
+}
+
+// Components constructs the URL and request body for a test request.
+// Path pseudo-parameters are written into the URL path; header and cookie pseudo-parameters
+// are left out, see applyInjectionPoints.
+func Components(req crawler.ParameterizedRequest, params url.Values) (string, io.Reader, error) {
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return "", nil, err
+	}
+	injectPath(u, req, params)
+	params = withoutInjectionPoints(applyNestedPoints(params))
+	if req.Method == "GET" {
+		u.RawQuery = params.Encode()
+		return u.String(), nil, nil
+	}
+	if req.IsJSON() {
+		body, err := buildJSONBody(req.RawBody, params)
+		if err != nil {
+			return "", nil, err
+		}
+		return u.String(), strings.NewReader(body), nil
+	}
+	if req.IsMultipart() {
+		body, err := buildMultipartBody(req.MultipartFields, params)
+		if err != nil {
+			return "", nil, err
+		}
+		return u.String(), body, nil
+	}
+	if isRawBody(req) {
+		return u.String(), strings.NewReader(req.RawBody), nil
+	}
+	return u.String(), strings.NewReader(params.Encode()), nil
+}
+
+// isRawBody reports whether req carries a body of another type than form, JSON or multipart,
+// e.g. XML. It has no parameters to set and is sent unchanged.
+func isRawBody(req crawler.ParameterizedRequest) bool {
+	return req.FormPostData == "" && req.RawBody != "" && !req.IsJSON() && !req.IsMultipart()
+}
+
+// SetContentType sets the Content-Type header matching the request's body encoding.
+func SetContentType(httpReq *http.Request, req crawler.ParameterizedRequest) {
+	if req.Method == "GET" {
+		return
+	}
+	if req.IsJSON() {
+		httpReq.Header.Set("Content-Type", req.ContentType)
+		return
+	}
+	if req.IsMultipart() {
+		httpReq.Header.Set("Content-Type", "multipart/form-data; boundary="+MultipartBoundary)
+		return
+	}
+	if isRawBody(req) && req.ContentType != "" {
+		httpReq.Header.Set("Content-Type", req.ContentType)
+		return
+	}
+	httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+}
+
+// New builds the HTTP request for req with params applied.
+func New(ctx context.Context, req crawler.ParameterizedRequest, params url.Values) (*http.Request, error) {
+	testURL, reqBody, err := Components(req, params)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, testURL, reqBody)
+	if err != nil {
+		return nil, err
+	}
+	SetContentType(httpReq, req)
+	applyInjectionPoints(httpReq, params)
+	return httpReq, nil
+}
+
+// Replay records the test request of params for the reproduction of a finding.
+func Replay(req crawler.ParameterizedRequest, params url.Values) scanner.ReplayRequest {
+	httpReq, err := New(context.Background(), req, params)
+	if err != nil {
+		return scanner.ReplayRequest{Method: req.Method, URL: req.URL}
+	}
+	return scanner.NewReplayRequest(httpReq)
+}
+
+// ReadBody reads the body of resp up to the client's size limit; httpclient.ErrBodyTruncated
+// is returned along with the body if it was cut off.
+func ReadBody(client *httpclient.Client, resp *http.Response) ([]byte, error) {
+	body, truncated, err := client.ReadBody(resp)
+	if err == nil && truncated {
+		err = httpclient.ErrBodyTruncated
+	}
+	return body, err
+}
+
+// Do sends the request with params applied and returns the request as sent, the response and
+// its body; httpclient.ErrBodyTruncated if the body was truncated.
+func Do(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, params url.Values) (*http.Request, *http.Response, []byte, error) {
+	httpReq, err := New(ctx, req, params)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return httpReq, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := ReadBody(client, resp)
+	return httpReq, resp, bodyBytes, err
+}
+
+// Send sends an HTTP request and returns the status code, body, and any error. Its callers
+// compare bodies, so a truncated body is an error.
+func Send(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, params url.Values) (int, string, error) {
+	_, resp, body, err := Do(ctx, req, client, params)
+	if resp == nil {
+		return 0, "", err
+	}
+	if err != nil {
+		return resp.StatusCode, "", err
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// SendCaptured is Send that also returns the raw exchange, for requests whose response may
+// become a finding's evidence. A truncated body is returned along with
+// httpclient.ErrBodyTruncated, for callers that only look for patterns in it.
+func SendCaptured(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, params url.Values) (int, string, scanner.Exchange, error) {
+	httpReq, resp, body, err := Do(ctx, req, client, params)
+	if resp == nil {
+		return 0, "", scanner.Exchange{}, err
+	}
+	if errors.Is(err, httpclient.ErrBodyTruncated) {
+		return resp.StatusCode, string(body), scanner.CaptureExchange(httpReq, resp, body), err
+	}
+	if err != nil {
+		return resp.StatusCode, "", scanner.Exchange{}, err
+	}
+	return resp.StatusCode, string(body), scanner.CaptureExchange(httpReq, resp, body), nil
+}
+
+// Measure measures the duration of an HTTP request, including reading the body. A nil params
+// sends the original request.
+func Measure(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, params url.Values) (time.Duration, scanner.Exchange, error) {
+	if params == nil {
+		var err error
+		params, err = Params(req)
+		if err != nil {
+			return 0, scanner.Exchange{}, err
+		}
+	}
+
+	httpReq, err := New(ctx, req, params)
+	if err != nil {
+		return 0, scanner.Exchange{}, err
+	}
+	elapsed, resp, body, err := timing.MeasureRequest(client, httpReq)
+	if err != nil {
+		return 0, scanner.Exchange{}, err
+	}
+	return elapsed, scanner.CaptureExchange(httpReq, resp, body), nil
+}
+
+// Location returns the location of the parameter (query, body, json, graphql, multipart, path,
+// header or cookie).
+func Location(req crawler.ParameterizedRequest, paramName string) string {
+	if strings.HasPrefix(paramName, HeaderPrefix) {
+		return "header"
+	}
+	if strings.HasPrefix(paramName, CookiePrefix) {
+		return "cookie"
+	}
+	if strings.HasPrefix(paramName, PathPrefix) {
+		return "path"
+	}
+	if req.Method == "GET" {
+		return "query"
+	}
+	if req.IsGraphQL() {
+		return "graphql"
+	}
+	if req.IsJSON() {
+		return "json"
+	}
+	if req.IsMultipart() {
+		return "multipart"
+	}
+	return "body"
+}
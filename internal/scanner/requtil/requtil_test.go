@@ -0,0 +1,220 @@
+package requtil
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sent is the part of a test request compared by the golden tests.
+type sent struct {
+	Method      string
+	URL         string
+	ContentType string
+	Cookie      string
+	Header      string // Value of the header named in the test case, if any.
+	Body        string
+}
+
+func TestOverrideGoldenRequests(t *testing.T) {
+	data := base64.StdEncoding.EncodeToString([]byte(`{"name":"x"}`))
+	tests := []struct {
+		name   string
+		req    crawler.ParameterizedRequest
+		param  string
+		header string
+		want   sent
+	}{
+		{
+			name:  "query",
+			req:   crawler.ParameterizedRequest{Method: "GET", URL: "http://example.com/items?id=1&sort=asc", ParamNames: []string{"id", "sort"}},
+			param: "id",
+			want:  sent{Method: "GET", URL: "http://example.com/items?id=%27+OR+%271%27%3D%271&sort=asc"},
+		},
+		{
+			name:  "form body",
+			req:   crawler.ParameterizedRequest{Method: "POST", URL: "http://example.com/login", FormPostData: "user=admin&pass=secret", ParamNames: []string{"user", "pass"}},
+			param: "user",
+			want: sent{Method: "POST", URL: "http://example.com/login", ContentType: "application/x-www-form-urlencoded",
+				Body: "pass=secret&user=%27+OR+%271%27%3D%271"},
+		},
+		{
+			name:  "JSON body",
+			req:   crawler.ParameterizedRequest{Method: "PUT", URL: "http://example.com/api/users", ContentType: "application/json; charset=utf-8", RawBody: `{"user":{"name":"bob","age":30},"tags":["a"]}`},
+			param: "user.name",
+			want: sent{Method: "PUT", URL: "http://example.com/api/users", ContentType: "application/json; charset=utf-8",
+				Body: `{"tags":["a"],"user":{"age":30,"name":"' OR '1'='1"}}`},
+		},
+		{
+			name:  "path segment",
+			req:   crawler.ParameterizedRequest{Method: "GET", URL: "http://example.com/api/items/42?sort=asc", ParamNames: []string{"sort"}, PathParams: crawler.PathParamsOf("/api/items/42")},
+			param: "path:id",
+			want:  sent{Method: "GET", URL: "http://example.com/api/items/%27%20OR%20%271%27=%271?sort=asc"},
+		},
+		{
+			name:   "header",
+			req:    crawler.ParameterizedRequest{Method: "GET", URL: "http://example.com/?q=1", ParamNames: []string{"q"}, Headers: map[string]string{"X-Forwarded-For": "127.0.0.1"}},
+			param:  "header:X-Forwarded-For",
+			header: "X-Forwarded-For",
+			want:   sent{Method: "GET", URL: "http://example.com/?q=1", Header: "' OR '1'='1"},
+		},
+		{
+			name:  "cookie",
+			req:   crawler.ParameterizedRequest{Method: "GET", URL: "http://example.com/", Cookies: map[string]string{"session": "abc"}},
+			param: "cookie:session",
+			want:  sent{Method: "GET", URL: "http://example.com/", Cookie: "session=' OR '1'='1"},
+		},
+		{
+			name:  "nested in base64 JSON",
+			req:   crawler.ParameterizedRequest{Method: "GET", URL: "http://example.com/?data=" + data, ParamNames: []string{"data"}},
+			param: "nested:data:name",
+			want:  sent{Method: "GET", URL: "http://example.com/?data=" + strings.ReplaceAll(base64.StdEncoding.EncodeToString([]byte(`{"name":"' OR '1'='1"}`)), "=", "%3D")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpReq, err := Override(context.Background(), tt.req, tt.param, "' OR '1'='1")
+			require.NoError(t, err)
+			got := sent{
+				Method:      httpReq.Method,
+				URL:         httpReq.URL.String(),
+				ContentType: httpReq.Header.Get("Content-Type"),
+				Cookie:      httpReq.Header.Get("Cookie"),
+			}
+			if tt.header != "" {
+				got.Header = httpReq.Header.Get(tt.header)
+			}
+			if httpReq.Body != nil {
+				body, err := io.ReadAll(httpReq.Body)
+				require.NoError(t, err)
+				got.Body = string(body)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestComponentsPathAndMultipart(t *testing.T) {
+	req := crawler.ParameterizedRequest{
+		Method:     "GET",
+		URL:        "http://example.com/api/items/42?sort=asc",
+		ParamNames: []string{"sort"},
+		PathParams: crawler.PathParamsOf("/api/items/42"),
+	}
+	params, err := Params(req)
+	require.NoError(t, err)
+	assert.Equal(t, "42", params.Get("path:id"))
+
+	params.Set("path:id", "42 AND SLEEP(5)-- /x")
+	testURL, _, err := Components(req, params)
+	require.NoError(t, err)
+	assert.Equal(t, "http://example.com/api/items/42%20AND%20SLEEP%285%29--%20%2Fx?sort=asc", testURL)
+
+	req = crawler.ParameterizedRequest{
+		Method:      "POST",
+		URL:         "http://example.com/upload",
+		ContentType: crawler.MultipartContentType,
+		ParamNames:  []string{"title", "file"},
+		MultipartFields: []crawler.MultipartField{
+			{Name: "title", Value: "report"},
+			{Name: "file", Value: "dursgo.txt", IsFile: true},
+		},
+	}
+	assert.Equal(t, "multipart", Location(req, "title"))
+	params, err = Params(req)
+	require.NoError(t, err)
+	params.Set("file", `x.txt'"`)
+
+	httpReq, err := New(context.Background(), req, params)
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/form-data; boundary="+MultipartBoundary, httpReq.Header.Get("Content-Type"))
+	require.NoError(t, httpReq.ParseMultipartForm(1<<20))
+	assert.Equal(t, "report", httpReq.FormValue("title"))
+	require.Len(t, httpReq.MultipartForm.File["file"], 1)
+	assert.Equal(t, `x.txt'"`, httpReq.MultipartForm.File["file"][0].Filename)
+
+	req = crawler.ParameterizedRequest{
+		Method:      "POST",
+		URL:         "http://example.com/soap",
+		ContentType: "text/xml",
+		RawBody:     "<id>42</id>",
+	}
+	params, err = Params(req)
+	require.NoError(t, err)
+	httpReq, err = New(context.Background(), req, params)
+	require.NoError(t, err)
+	assert.Equal(t, "text/xml", httpReq.Header.Get("Content-Type"))
+	body, err := io.ReadAll(httpReq.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "<id>42</id>", string(body))
+}
+
+func TestSetJSONFields(t *testing.T) {
+	body, err := SetJSONFields(`{"name":"alice","id":12345678901234567890,"role":"user"}`, map[string]interface{}{"role": "admin", "is_admin": true, "note": "<b>&"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name":"alice","id":12345678901234567890,"role":"admin","is_admin":true,"note":"<b>&"}`, body)
+	assert.Contains(t, body, "12345678901234567890", "numbers keep their precision")
+
+	_, err = SetJSONFields(`[{"name":"alice"}]`, map[string]interface{}{"role": "admin"})
+	assert.Error(t, err)
+}
+
+func TestJSONValuesAndSetJSONPaths(t *testing.T) {
+	raw := `{"user":{"name":"alice","id":7},"items":[{"sku":"a"}]}`
+	values, err := JSONValues(raw)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"user.name": "alice", "user.id": json.Number("7"), "items[0].sku": "a"}, values)
+
+	body, err := SetJSONPaths(raw, map[string]interface{}{"user.name": map[string]interface{}{"$ne": nil}, "items[0].sku": "<b>"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"user":{"name":{"$ne":null},"id":7},"items":[{"sku":"<b>"}]}`, body)
+}
+
+func TestSendAndMeasure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("big") != "" {
+			w.Write([]byte(strings.Repeat("x", 64)))
+			return
+		}
+		w.Write([]byte("q=" + r.URL.Query().Get("q")))
+	}))
+	defer server.Close()
+	client := httpclient.NewClient(logger.NewLogger(logger.ERROR), httpclient.ClientOptions{MaxResponseBytes: 32})
+	req := crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/?q=1&big=", ParamNames: []string{"q"}}
+	params, err := Params(req)
+	require.NoError(t, err)
+
+	testParams := Copy(params)
+	testParams.Set("q", "2")
+	status, body, err := Send(context.Background(), req, client, testParams)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, "q=2", body)
+	assert.Equal(t, "1", params.Get("q"), "Copy leaves the original parameters unchanged")
+
+	elapsed, exchange, err := Measure(context.Background(), req, client, nil)
+	require.NoError(t, err)
+	assert.Positive(t, elapsed)
+	assert.Contains(t, exchange.Response, "q=1")
+
+	// A body cut off at the size limit cannot be compared, but can still be searched.
+	testParams.Set("big", "1")
+	_, body, err = Send(context.Background(), req, client, testParams)
+	assert.ErrorIs(t, err, httpclient.ErrBodyTruncated)
+	assert.Empty(t, body)
+	_, body, _, err = SendCaptured(context.Background(), req, client, testParams)
+	assert.ErrorIs(t, err, httpclient.ErrBodyTruncated)
+	assert.Len(t, body, 32)
+}
@@ -0,0 +1,163 @@
+package secrets
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"fmt"
+	"strings"
+)
+
+const (
+	// maxMatchesPerResponse bounds the matches of one pattern taken from a single response.
+	maxMatchesPerResponse = 50
+	// contextRadius is the number of characters shown around a match, on the same line.
+	contextRadius = 40
+	// maxListedLocations bounds the locations listed in a finding.
+	maxListedLocations = 10
+)
+
+// SecretsScanner implements the PassiveScanner interface for secrets and sensitive data leaked
+// in crawled pages and JavaScript files. Each distinct match is reported once, listing every
+// URL it was found on.
+type SecretsScanner struct{}
+
+// NewSecretsScanner creates a new instance of SecretsScanner.
+func NewSecretsScanner() *SecretsScanner {
+	return &SecretsScanner{}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "secrets",
+		Order:          230,
+		DefaultEnabled: true,
+		NewPassive:     func(scanner.Env) scanner.PassiveScanner { return NewSecretsScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *SecretsScanner) Name() string {
+	return "Secrets Scanner"
+}
+
+// location is a place a secret was found.
+type location struct {
+	url    string
+	offset int // Byte offset of the match in the response body.
+}
+
+// leak is one distinct match of a pattern, with every location it was found at.
+type leak struct {
+	pattern   payloads.SecretPattern
+	snippet   string // Masked match with its context, from the first location.
+	locations []location
+}
+
+// ScanResponses matches payloads.SecretPatterns against the body of every crawled response.
+func (s *SecretsScanner) ScanResponses(responses []crawler.CrawledResponse, log *logger.Logger) []scanner.VulnerabilityResult {
+	leaks := make(map[string]*leak)
+	var order []string
+
+	for _, resp := range responses {
+		if resp.Body == "" {
+			continue
+		}
+		log.Debug("Secrets: Checking %s", resp.URL)
+		for _, pattern := range payloads.SecretPatterns {
+			// Disclosures such as stack traces match on many lines of one page; the first is enough.
+			limit := maxMatchesPerResponse
+			if pattern.Verbatim {
+				limit = 1
+			}
+			for _, index := range pattern.Regex.FindAllStringIndex(resp.Body, limit) {
+				match := resp.Body[index[0]:index[1]]
+				key := pattern.Name + "\x00" + match
+				l, ok := leaks[key]
+				if !ok {
+					l = &leak{pattern: pattern, snippet: snippet(resp.Body, index[0], index[1], pattern.Verbatim)}
+					leaks[key] = l
+					order = append(order, key)
+				}
+				l.locations = append(l.locations, location{url: resp.URL, offset: index[0]})
+			}
+		}
+	}
+
+	findings := make([]scanner.VulnerabilityResult, 0, len(order))
+	for _, key := range order {
+		l := leaks[key]
+		log.Success("Secrets: %s found on %d URL(s), first at %s", l.pattern.Name, len(l.locations), l.locations[0].url)
+		findings = append(findings, s.newResult(l))
+	}
+	return findings
+}
+
+// newResult builds the finding for one distinct leak.
+func (s *SecretsScanner) newResult(l *leak) scanner.VulnerabilityResult {
+	first := l.locations[0]
+	listed := make([]string, 0, maxListedLocations)
+	for i, loc := range l.locations {
+		if i == maxListedLocations {
+			listed = append(listed, fmt.Sprintf("(and %d more)", len(l.locations)-maxListedLocations))
+			break
+		}
+		listed = append(listed, fmt.Sprintf("%s (offset %d)", loc.url, loc.offset))
+	}
+
+	details := fmt.Sprintf("%s found in content served to clients. Anyone who loads it can retrieve and use the secret.", l.pattern.Name)
+	remediation := "Remove the secret from client-side code and responses, revoke and rotate it, and keep credentials on the server (e.g., behind a backend proxy or in a secrets manager)."
+	if l.pattern.Verbatim {
+		details = fmt.Sprintf("%s found in content served to clients. It reveals internal details (code paths, infrastructure) that help attackers plan further attacks.", l.pattern.Name)
+		remediation = "Disable verbose error output in production, serve generic error pages and avoid exposing internal addresses or paths in responses."
+	}
+
+	return scanner.VulnerabilityResult{
+		VulnerabilityType: fmt.Sprintf("Sensitive Data Exposure (%s)", l.pattern.Name),
+		URL:               first.url,
+		Details:           fmt.Sprintf("%s Found at %d location(s): %s", details, len(l.locations), strings.Join(listed, ", ")),
+		Severity:          l.pattern.Severity,
+		Evidence:          fmt.Sprintf("Matched %q at byte offset %d of %s", l.snippet, first.offset, first.url),
+		Remediation:       remediation,
+		ScannerName:       "secrets",
+	}
+}
+
+// snippet returns the match with up to contextRadius characters of context on its line. The
+// match is masked unless verbatim is set.
+func snippet(body string, start, end int, verbatim bool) string {
+	from := start - contextRadius
+	if from < 0 {
+		from = 0
+	}
+	if nl := strings.LastIndexAny(body[from:start], "\r\n"); nl >= 0 {
+		from += nl + 1
+	}
+	to := end + contextRadius
+	if to > len(body) {
+		to = len(body)
+	}
+	if nl := strings.IndexAny(body[end:to], "\r\n"); nl >= 0 {
+		to = end + nl
+	}
+
+	match := body[start:end]
+	if !verbatim {
+		match = mask(match)
+	}
+	return strings.TrimLeft(body[from:start], " \t") + match + body[end:to]
+}
+
+// mask keeps the first and last characters of a secret, enough to identify it, and replaces the
+// rest with asterisks.
+func mask(secret string) string {
+	keep := 4
+	if len(secret) < 16 {
+		keep = 2
+	}
+	if len(secret) <= 2*keep {
+		return strings.Repeat("*", len(secret))
+	}
+	return secret[:keep] + strings.Repeat("*", len(secret)-2*keep) + secret[len(secret)-keep:]
+}
@@ -2,29 +2,34 @@ package securityheaders
 
 import (
 	"Dursgo/internal/crawler"
-	"Dursgo/internal/httpclient"
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
-	"bytes"
 	"fmt"
-	"io"
-	"mime"
 	"net/http"
 	"net/url"
+	"path"
+	"slices"
 	"strings"
-	"sync"
 )
 
-// SecurityHeadersScanner implements the Scanner interface for checking security headers.
-type SecurityHeadersScanner struct {
-	mu           sync.Mutex
-	hostsScanned map[string]bool
-}
+// SecurityHeadersScanner implements the PassiveScanner interface for auditing security headers.
+// It inspects the responses fetched while crawling and reports each missing or weak header once
+// per host, listing the affected URLs and the values observed.
+type SecurityHeadersScanner struct{}
 
 // NewSecurityHeadersScanner creates a new instance of SecurityHeadersScanner.
 func NewSecurityHeadersScanner() *SecurityHeadersScanner {
-	return &SecurityHeadersScanner{hostsScanned: make(map[string]bool)}
+	return &SecurityHeadersScanner{}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "securityheaders",
+		Order:          120,
+		DefaultEnabled: true,
+		NewPassive:     func(scanner.Env) scanner.PassiveScanner { return NewSecurityHeadersScanner() },
+	})
 }
 
 // Name returns the scanner's name.
@@ -32,278 +37,172 @@ func (s *SecurityHeadersScanner) Name() string {
 	return "Security Headers Scanner"
 }
 
-// isWordPressSite checks if the response indicates a WordPress site.
-// It checks for common WordPress headers and body content.
-func isWordPressSite(resp *http.Response) bool {
-	// Check generator header
-	if generator := resp.Header.Get("X-Generator"); generator != "" && strings.Contains(strings.ToLower(generator), "wordpress") {
-		return true
-	}
-
-	// Check X-Powered-By header
-	if poweredBy := resp.Header.Get("X-Powered-By"); poweredBy != "" && strings.Contains(strings.ToLower(poweredBy), "wordpress") {
-		return true
-	}
-
-	// Check meta generator in body
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return false
-	}
-	// Reset body for further reading
-	resp.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-	
-	bodyStr := strings.ToLower(string(bodyBytes))
-	
-	// Check for several WordPress indicators
-	wordPressIndicators := []string{
-		"generator name=\"wordpress\"",
-		"wp-content",
-		"wp-includes",
-		"wp-json",
-		"wp-admin",
-		"wp-embed",
-	}
-	
-	for _, indicator := range wordPressIndicators {
-		if strings.Contains(bodyStr, indicator) {
-			return true
-		}
-	}
-	
-	return false
+// issue collects the responses of one host sharing a missing or weak header.
+type issue struct {
+	check   payloads.HeaderCheck
+	missing bool
+	urls    []string
+	values  []string // Distinct header values observed (weak headers only), in order of appearance.
 }
 
-// isHTMLResponse checks if the response is likely HTML content
-func isHTMLResponse(resp *http.Response, log *logger.Logger) bool {
-	// Check Content-Type header first
-	contentType := resp.Header.Get("Content-Type")
-	path := strings.ToLower(resp.Request.URL.Path)
-	
-	// Debug log
-	log.Debug("Checking if HTML - Path: %s, Content-Type: %s", path, contentType)
+// hostIssues tracks the issues found on one host.
+type hostIssues struct {
+	analyzed int               // Responses analyzed for the host.
+	issues   map[string]*issue // Keyed by header name and kind.
+	order    []string          // Issue keys in order of discovery.
+}
 
-	// Always check HTML content directly first
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	resp.Body = io.NopCloser(bytes.NewBuffer(bodyBytes)) // Reset body for actual reading
-	
-	// Check for common HTML indicators in the first few KB
-	bodyStart := strings.ToLower(string(bodyBytes[:min(4096, len(bodyBytes))]))
-	_ = strings.Contains(bodyStart, "<!doctype html>") || 
-			  strings.Contains(bodyStart, "<html") ||
-			  strings.Contains(bodyStart, "<head>") ||
-			  strings.Contains(bodyStart, "<body>")
+// ScanResponses checks the successful crawled responses against payloads.SecurityHeaderChecks.
+// Error pages are skipped, as they are often served by a different layer than the application.
+func (s *SecurityHeadersScanner) ScanResponses(responses []crawler.CrawledResponse, log *logger.Logger) []scanner.VulnerabilityResult {
+	hosts := make(map[string]*hostIssues)
+	var hostOrder []string
 
-	// Check for common WordPress paths or if it's a WordPress site
-	if path == "/" || strings.HasSuffix(path, ".php") || strings.Contains(path, "wp-") || isWordPressSite(resp) {
-		log.Debug("WordPress path or site detected: %s", path)
-		return true
-	}
-
-	// If no content type, check URL extension
-	if contentType == "" {
-		u, err := url.Parse(resp.Request.URL.String())
-		if err != nil {
-			log.Error("Error parsing URL: %v", err)
-			return false
-		}
-		ext := ""
-		if dot := strings.LastIndex(u.Path, "."); dot != -1 && dot < len(u.Path)-1 {
-			ext = strings.ToLower(u.Path[dot+1:])
+	for _, resp := range responses {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			continue
 		}
-		// Common web extensions
-		htmlExtensions := map[string]bool{
-			"": true, "html": true, "htm": true, "php": true, 
-			"aspx": true, "jsp": true, "do": true, "action": true,
+		parsedURL, err := url.Parse(resp.URL)
+		if err != nil {
+			continue
 		}
-		if htmlExtensions[ext] {
-			log.Debug("HTML extension detected: %s", ext)
-			return true
+		hostKey := parsedURL.Scheme + "://" + parsedURL.Host
+		h, ok := hosts[hostKey]
+		if !ok {
+			h = &hostIssues{issues: make(map[string]*issue)}
+			hosts[hostKey] = h
+			hostOrder = append(hostOrder, hostKey)
 		}
-		// If no extension, assume it's HTML for the root or common paths
-		isRootPath := ext == "" && (u.Path == "" || u.Path == "/" || !strings.Contains(u.Path, "."))
-		if isRootPath {
-			log.Debug("Root path detected: %s", u.Path)
+		h.analyzed++
+		log.Debug("Security Headers: Checking %s", resp.URL)
+
+		isHTML := isHTMLResponse(resp.Header, parsedURL.Path)
+		for _, check := range payloads.SecurityHeaderChecks {
+			if !applies(check, resp.Header, parsedURL, isHTML) {
+				continue
+			}
+			value, present := headerValue(resp.Header, check.Name)
+			switch {
+			case !present:
+				h.add(check, true, resp.URL, "")
+			case isInsecureHeaderValue(check.Name, value):
+				h.add(check, false, resp.URL, value)
+			}
 		}
-		return isRootPath
 	}
 
-	// Handle Cloudflare and other content types
-	contentType = strings.ToLower(contentType)
-	if strings.Contains(contentType, "x-httpd-php") || 
-	   strings.Contains(contentType, "x-httpd-fastphp") ||
-	   strings.Contains(contentType, "text/html") ||
-	   strings.Contains(contentType, "xhtml+xml") {
-		log.Debug("HTML content type detected: %s", contentType)
-		return true
+	var findings []scanner.VulnerabilityResult
+	for _, hostKey := range hostOrder {
+		h := hosts[hostKey]
+		for _, key := range h.order {
+			findings = append(findings, s.newResult(hostKey, h.analyzed, h.issues[key]))
+		}
 	}
+	return findings
+}
 
-	// If we get here, try to parse the media type
-	mediaType, _, err := mime.ParseMediaType(contentType)
-	if err != nil {
-		log.Error("Error parsing media type: %v", err)
-		// Be permissive if we can't parse the content type
-		return true
+// add records that rawURL misses the checked header, or carries the weak value.
+func (h *hostIssues) add(check payloads.HeaderCheck, missing bool, rawURL, value string) {
+	key := fmt.Sprintf("%s missing=%t", check.Name, missing)
+	is, ok := h.issues[key]
+	if !ok {
+		is = &issue{check: check, missing: missing}
+		h.issues[key] = is
+		h.order = append(h.order, key)
 	}
-
-	// Common HTML content types
-	htmlTypes := map[string]bool{
-		"text/html":               true,
-		"application/xhtml+xml":   true,
-		"application/x-httpd-php": true,
+	is.urls = append(is.urls, rawURL)
+	if !missing && !slices.Contains(is.values, value) {
+		is.values = append(is.values, value)
 	}
-
-	isHTML := htmlTypes[mediaType]
-	log.Debug("Media type check - Type: %s, IsHTML: %v", mediaType, isHTML)
-	return isHTML
 }
 
-// Scan performs a security headers scan on the given request's host.
-// It checks for the presence and configuration of various security headers,
-// considering the context of the response (e.g., HTML, sensitive content).
-func (s *SecurityHeadersScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
-	var findings []scanner.VulnerabilityResult
-
-	parsedURL, _ := url.Parse(req.URL)
-	hostKey := parsedURL.Scheme + "://" + parsedURL.Host
-
-	s.mu.Lock()
-	if s.hostsScanned[hostKey] {
-		s.mu.Unlock()
-		return nil, nil
-	}
-	s.hostsScanned[hostKey] = true
-	s.mu.Unlock()
-
-	log.Debug("Running Security Headers check on: %s", req.URL)
+// newResult builds the finding for one issue on a host.
+func (s *SecurityHeadersScanner) newResult(hostKey string, analyzed int, is *issue) scanner.VulnerabilityResult {
+	vuln := scanner.VulnerabilityResult{
+		URL:         hostKey + "/",
+		Payload:     is.check.Remediation,
+		Severity:    is.check.Severity,
+		Remediation: is.check.Remediation,
+		ScannerName: "securityheaders",
+	}
+	affected := fmt.Sprintf("Affected URLs (%d of %d analyzed on this host): %s", len(is.urls), analyzed, scanner.ListURLs(is.urls))
+	if is.missing {
+		vuln.VulnerabilityType = "Missing Security Header"
+		vuln.Details = fmt.Sprintf("Header '%s' not found. %s %s", is.check.Name, is.check.Description, affected)
+		vuln.Evidence = fmt.Sprintf("Header '%s' is missing in the response headers of %d URL(s).", is.check.Name, len(is.urls))
+		return vuln
+	}
+
+	observed := make([]string, len(is.values))
+	for i, value := range is.values {
+		observed[i] = fmt.Sprintf("'%s: %s'", is.check.Name, value)
+	}
+	vuln.VulnerabilityType = "Misconfigured Security Header"
+	vuln.Details = fmt.Sprintf("Header '%s' found with a weak value. %s %s", is.check.Name, is.check.Description, affected)
+	vuln.Evidence = "Observed " + strings.Join(observed, ", ") + "."
+	return vuln
+}
 
-	resp, err := client.Get(req.URL)
-	if err != nil {
-		if resp != nil && resp.Body != nil {
-			resp.Body.Close()
-		}
-		return nil, nil
+// applies reports whether check is relevant for a response, considering the scheme, the content
+// type and headers that make the check redundant.
+func applies(check payloads.HeaderCheck, header http.Header, parsedURL *url.URL, isHTML bool) bool {
+	if check.CheckOnHTTPSOnly && parsedURL.Scheme != "https" {
+		return false
 	}
-	defer resp.Body.Close()
+	switch strings.ToLower(check.Name) {
+	case "content-security-policy":
+		// CSP only governs documents.
+		return isHTML
+	case "x-frame-options":
+		// Only documents can be framed; CSP frame-ancestors is the modern replacement.
+		csp, _ := headerValue(header, "Content-Security-Policy")
+		return isHTML && !strings.Contains(strings.ToLower(csp), "frame-ancestors")
+	case "cache-control":
+		// Only require Cache-Control for sensitive content.
+		return isSensitivePath(parsedURL.Path)
+	}
+	return true
+}
 
-	// Only analyze successful responses (2xx status codes).
-	// This prevents false positives on 404 pages or server errors.
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		log.Debug("Skipping security headers check for non-successful status code %d on %s", resp.StatusCode, req.URL)
-		return nil, nil
+// headerValue returns all values of name joined by ", ". A report-only CSP counts as present.
+func headerValue(header http.Header, name string) (string, bool) {
+	values := header.Values(name)
+	if len(values) == 0 && strings.EqualFold(name, "Content-Security-Policy") {
+		values = header.Values("Content-Security-Policy-Report-Only")
 	}
+	return strings.Join(values, ", "), len(values) > 0
+}
 
-	// Reset response body for further processing
-	bodyBytes, _ := io.ReadAll(resp.Body)
-	resp.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-
-	// Check if this is an HTML response
-	isHTML := isHTMLResponse(resp, log)
-	isSensitive := isSensitiveContent(resp)
-
-	// Get all response headers in lowercase for case-insensitive checks
-	headers := make(map[string]string)
-	for k, v := range resp.Header {
-		headers[strings.ToLower(k)] = v[0] // Take the first value if multiple
+// isHTMLResponse reports whether a response is a document, from its Content-Type or, when
+// that is absent, from the extension of its path.
+func isHTMLResponse(header http.Header, urlPath string) bool {
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if contentType != "" {
+		return strings.Contains(contentType, "text/html") || strings.Contains(contentType, "xhtml+xml")
 	}
-
-	for _, headerCheck := range payloads.SecurityHeaderChecks {
-		headerName := strings.ToLower(headerCheck.Name)
-		headerValue, headerExists := headers[headerName]
-
-		// Special handling for CSP: accept 'report-only' header as present.
-		if headerName == "content-security-policy" {
-			if _, reportOnlyExists := headers["content-security-policy-report-only"]; reportOnlyExists {
-				headerExists = true
-			}
-		}
-
-		// Skip if header is only for HTTPS and we're not on HTTPS
-		if headerCheck.CheckOnHTTPSOnly && parsedURL.Scheme != "https" {
-			continue
-		}
-
-		// Context-aware checks for specific headers
-		switch headerName {
-		case "content-security-policy":
-			// Skip CSP for non-HTML content
-			if !isHTML {
-				continue
-			}
-		case "x-frame-options":
-			// Skip if CSP with frame-ancestors is present (modern approach)
-			if csp, hasCSP := headers["content-security-policy"]; hasCSP && strings.Contains(strings.ToLower(csp), "frame-ancestors") {
-				continue
-			}
-		case "cache-control":
-			// Only require Cache-Control for sensitive content
-			if !isSensitive {
-				continue
-			}
-		}
-
-		if !headerExists {
-			findings = append(findings, scanner.VulnerabilityResult{
-				VulnerabilityType: "Missing Security Header",
-				URL:               req.URL,
-				Details: fmt.Sprintf(
-					"Header '%s' not found. %s",
-					headerCheck.Name, headerCheck.Description,
-				),
-				Payload:     headerCheck.Remediation,
-				Severity:    headerCheck.Severity,
-				Evidence:    fmt.Sprintf("Header '%s' is missing in response headers.", headerCheck.Name),
-				Remediation: headerCheck.Remediation,
-				ScannerName: "securityheaders",
-			})
-		} else if headerCheck.RecommendedValue != "" && !strings.Contains(strings.ToLower(headerValue), strings.ToLower(headerCheck.RecommendedValue)) {
-			// For misconfigured headers, only report if the value is clearly insecure
-			if isInsecureHeaderValue(headerName, headerValue) {
-				findings = append(findings, scanner.VulnerabilityResult{
-					VulnerabilityType: "Misconfigured Security Header",
-					URL:               req.URL,
-					Details: fmt.Sprintf(
-						"Header '%s' found with potentially insecure value '%s'. %s",
-						headerCheck.Name, headerValue, headerCheck.Description,
-					),
-					Payload:     headerCheck.Remediation,
-					Severity:    headerCheck.Severity,
-					Evidence:    fmt.Sprintf("Found header '%s: %s', which may be misconfigured.", headerCheck.Name, headerValue),
-					Remediation: headerCheck.Remediation,
-					ScannerName: "securityheaders",
-				})
-			}
-		}
+	switch strings.ToLower(path.Ext(urlPath)) {
+	case "", ".html", ".htm", ".php", ".aspx", ".jsp", ".do", ".action":
+		return true
 	}
-
-	return findings, nil
+	return false
 }
 
-// isSensitiveContent checks if the response appears to contain sensitive content.
-// It checks for common sensitive paths and error response codes.
-func isSensitiveContent(resp *http.Response) bool {
-	// Check common sensitive paths
+// isSensitivePath checks if the path appears to serve sensitive content.
+func isSensitivePath(urlPath string) bool {
 	sensitivePaths := []string{
-		"/login", 
-		"/admin", 
+		"/login",
+		"/admin",
 		"/dashboard",
 		"/account",
 		"/settings",
 		"/profile",
 	}
-
-	path := strings.ToLower(resp.Request.URL.Path)
+	urlPath = strings.ToLower(urlPath)
 	for _, p := range sensitivePaths {
-		if strings.HasPrefix(path, p) {
+		if strings.HasPrefix(urlPath, p) {
 			return true
 		}
 	}
-
-	// Check response code
-	if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-		return true
-	}
-
 	return false
 }
 
@@ -314,7 +213,7 @@ func isInsecureHeaderValue(headerName, value string) bool {
 	case "x-frame-options":
 		return value == "" || !strings.EqualFold(value, "DENY") && !strings.EqualFold(value, "SAMEORIGIN")
 	case "x-content-type-options":
-		return !strings.EqualFold(value, "nosniff")
+		return !strings.EqualFold(strings.TrimSpace(value), "nosniff")
 	case "x-xss-protection":
 		// "0" is a valid way to disable the header, especially with a strong CSP.
 		// "1; mode=block" is the strongest setting. Other values are less secure.
@@ -324,25 +223,25 @@ func isInsecureHeaderValue(headerName, value string) bool {
 		}
 		return true
 	case "strict-transport-security":
-		return value == "" || !strings.Contains(strings.ToLower(value), "max-age=")
+		val := strings.ToLower(value)
+		return !strings.Contains(val, "max-age=") || strings.Contains(strings.ReplaceAll(val, " ", ""), "max-age=0")
+	case "referrer-policy":
+		// With a list of policies the browser applies the last one it supports.
+		policies := strings.Split(strings.ToLower(value), ",")
+		last := strings.TrimSpace(policies[len(policies)-1])
+		return last == "unsafe-url" || last == "no-referrer-when-downgrade"
 	case "content-security-policy":
-		// Check for obviously insecure CSP
+		// Check for obviously insecure CSP. 'unsafe-inline' is ignored by browsers when a nonce,
+		// hash or 'strict-dynamic' is present.
 		value = strings.ToLower(value)
-		if strings.Contains(value, "unsafe-inline") || 
-		   strings.Contains(value, "unsafe-eval") ||
-		   strings.Contains(value, "*.") ||
-		   strings.Contains(value, "http:") ||
-		   strings.Contains(value, "https://*") {
+		inlineNeutralized := strings.Contains(value, "'nonce-") || strings.Contains(value, "'sha") || strings.Contains(value, "'strict-dynamic'")
+		if (strings.Contains(value, "unsafe-inline") && !inlineNeutralized) ||
+			strings.Contains(value, "unsafe-eval") ||
+			strings.Contains(value, "*.") ||
+			strings.Contains(value, "http:") ||
+			strings.Contains(value, "https://*") {
 			return true
 		}
 	}
 	return false
 }
-
-// min returns the smaller of two integers.
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
@@ -0,0 +1,157 @@
+package securityheaders
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// secureHeaders returns a header set passing every check, with overrides applied
+// (an empty override value deletes the header).
+func secureHeaders(overrides map[string]string) http.Header {
+	header := http.Header{}
+	header.Set("Content-Type", "text/html; charset=utf-8")
+	header.Set("Content-Security-Policy", "default-src 'self'")
+	header.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+	header.Set("X-Frame-Options", "DENY")
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("Cross-Origin-Opener-Policy", "same-origin")
+	header.Set("Cross-Origin-Embedder-Policy", "require-corp")
+	header.Set("Permissions-Policy", "geolocation=()")
+	header.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+	header.Set("Cache-Control", "no-store")
+	header.Set("Clear-Site-Data", `"cache", "cookies", "storage"`)
+	header.Set("X-XSS-Protection", "0")
+	for name, value := range overrides {
+		if value == "" {
+			header.Del(name)
+		} else {
+			header.Set(name, value)
+		}
+	}
+	return header
+}
+
+// findingFor returns the finding about the named header, if any.
+func findingFor(findings []scanner.VulnerabilityResult, header string) (scanner.VulnerabilityResult, bool) {
+	for _, f := range findings {
+		if strings.HasPrefix(f.Details, "Header '"+header+"'") {
+			return f, true
+		}
+	}
+	return scanner.VulnerabilityResult{}, false
+}
+
+func TestScanResponses(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		overrides    map[string]string
+		wantHeader   string
+		wantType     string
+		wantEvidence string
+	}{
+		{
+			name:       "Missing CSP",
+			url:        "https://example.com/",
+			overrides:  map[string]string{"Content-Security-Policy": ""},
+			wantHeader: "Content-Security-Policy",
+			wantType:   "Missing Security Header",
+		},
+		{
+			name:         "CSP with unsafe-inline",
+			url:          "https://example.com/",
+			overrides:    map[string]string{"Content-Security-Policy": "script-src 'self' 'unsafe-inline'"},
+			wantHeader:   "Content-Security-Policy",
+			wantType:     "Misconfigured Security Header",
+			wantEvidence: "Observed 'Content-Security-Policy: script-src 'self' 'unsafe-inline''.",
+		},
+		{
+			name:      "CSP with unsafe-inline neutralized by a nonce",
+			url:       "https://example.com/",
+			overrides: map[string]string{"Content-Security-Policy": "script-src 'nonce-abc' 'unsafe-inline'"},
+		},
+		{
+			name:       "Missing HSTS on HTTPS",
+			url:        "https://example.com/",
+			overrides:  map[string]string{"Strict-Transport-Security": ""},
+			wantHeader: "Strict-Transport-Security",
+			wantType:   "Missing Security Header",
+		},
+		{
+			name:      "Missing HSTS on HTTP",
+			url:       "http://example.com/",
+			overrides: map[string]string{"Strict-Transport-Security": ""},
+		},
+		{
+			name:       "Missing X-Frame-Options",
+			url:        "https://example.com/",
+			overrides:  map[string]string{"X-Frame-Options": ""},
+			wantHeader: "X-Frame-Options",
+			wantType:   "Missing Security Header",
+		},
+		{
+			name:      "Missing X-Frame-Options with frame-ancestors",
+			url:       "https://example.com/",
+			overrides: map[string]string{"X-Frame-Options": "", "Content-Security-Policy": "frame-ancestors 'none'"},
+		},
+		{
+			name:         "Permissive Referrer-Policy",
+			url:          "https://example.com/",
+			overrides:    map[string]string{"Referrer-Policy": "unsafe-url"},
+			wantHeader:   "Referrer-Policy",
+			wantType:     "Misconfigured Security Header",
+			wantEvidence: "Observed 'Referrer-Policy: unsafe-url'.",
+		},
+		{
+			name:       "Missing X-Content-Type-Options",
+			url:        "https://example.com/app.js",
+			overrides:  map[string]string{"Content-Type": "application/javascript", "X-Content-Type-Options": "", "Content-Security-Policy": ""},
+			wantHeader: "X-Content-Type-Options",
+			wantType:   "Missing Security Header",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responses := []crawler.CrawledResponse{{URL: tt.url, StatusCode: http.StatusOK, Header: secureHeaders(tt.overrides)}}
+
+			findings := NewSecurityHeadersScanner().ScanResponses(responses, logger.NewLogger(logger.ERROR))
+			if tt.wantHeader == "" {
+				assert.Empty(t, findings)
+				return
+			}
+			require.Len(t, findings, 1)
+			f, ok := findingFor(findings, tt.wantHeader)
+			require.True(t, ok, "no finding for %s: %+v", tt.wantHeader, findings)
+			assert.Equal(t, tt.wantType, f.VulnerabilityType)
+			assert.Contains(t, []string{"Low", "Informational"}, f.Severity)
+			if tt.wantEvidence != "" {
+				assert.Equal(t, tt.wantEvidence, f.Evidence)
+			}
+		})
+	}
+}
+
+func TestScanResponsesGroupsPerHost(t *testing.T) {
+	weak := secureHeaders(map[string]string{"X-Frame-Options": "", "Referrer-Policy": "no-referrer-when-downgrade"})
+	responses := []crawler.CrawledResponse{
+		{URL: "https://example.com/", StatusCode: http.StatusOK, Header: weak},
+		{URL: "https://example.com/about", StatusCode: http.StatusOK, Header: weak},
+		{URL: "https://example.com/missing", StatusCode: http.StatusNotFound, Header: http.Header{}},
+		{URL: "https://other.example.com/", StatusCode: http.StatusOK, Header: weak},
+	}
+
+	findings := NewSecurityHeadersScanner().ScanResponses(responses, logger.NewLogger(logger.ERROR))
+	require.Len(t, findings, 4)
+	assert.Equal(t, "https://example.com/", findings[0].URL)
+	assert.Contains(t, findings[0].Details, "Affected URLs (2 of 2 analyzed on this host): https://example.com/, https://example.com/about")
+	assert.Equal(t, "https://other.example.com/", findings[2].URL)
+}
@@ -0,0 +1,174 @@
+package scanner
+
+import (
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// SkipRule excludes a parameter or the requests to a path from the tests of all scanners or of
+// some of them. Exactly one of Param and Path is set.
+type SkipRule struct {
+	Param    string   `json:"param,omitempty"`    // Parameter name, matched case-insensitively.
+	Path     string   `json:"path,omitempty"`     // Matches the requests whose URL path contains it.
+	Scanners []string `json:"scanners,omitempty"` // Modules the rule applies to; empty applies to all.
+	Default  bool     `json:"default,omitempty"`  // Built-in rule (see DefaultSkipRules).
+	Skipped  int64    `json:"skipped"`            // Parameter tests or scanner/request pairs skipped.
+}
+
+// String describes the rule for log lines, e.g. "path /register (sqli)".
+func (r SkipRule) String() string {
+	s := "param " + r.Param
+	if r.Path != "" {
+		s = "path " + r.Path
+	}
+	if len(r.Scanners) > 0 {
+		s += " (" + strings.Join(r.Scanners, ", ") + ")"
+	}
+	return s
+}
+
+// appliesTo reports whether the rule applies to the named module.
+func (r SkipRule) appliesTo(module string) bool {
+	return len(r.Scanners) == 0 || slices.Contains(r.Scanners, module)
+}
+
+// defaultSkipParamScanners are the modules that leave anti-CSRF tokens alone by default:
+// injecting into a token mostly gets the request rejected before the other parameters are read.
+var defaultSkipParamScanners = []string{"crlf", "idor", "nosqli", "sqli"}
+
+// defaultSkipPaths are paths the sqli module skips by default, as submitting their forms
+// (comments, sign-ups) with every payload floods the application with records.
+var defaultSkipPaths = []string{"/comment", "/register"}
+
+// DefaultSkipRules returns the built-in skip rules: anti-CSRF token parameters for the injection
+// scanners and comment and registration pages for SQL injection.
+func DefaultSkipRules() []SkipRule {
+	var rules []SkipRule
+	for _, name := range payloads.CommonCSRFTokenNames {
+		rules = append(rules, SkipRule{Param: name, Scanners: defaultSkipParamScanners, Default: true})
+	}
+	for _, path := range defaultSkipPaths {
+		rules = append(rules, SkipRule{Path: path, Scanners: []string{"sqli"}, Default: true})
+	}
+	return rules
+}
+
+// BuildSkipRules returns the effective skip rules of a scan: the built-in rules (none with
+// noDefaults) without those whose parameter or path is listed in remove, followed by extra.
+// Scanner names of extra are checked against the registry and aliases expanded to their
+// modules.
+func BuildSkipRules(noDefaults bool, remove []string, extra []SkipRule) ([]SkipRule, error) {
+	var rules []SkipRule
+	if !noDefaults {
+		rules = DefaultSkipRules()
+	}
+	for _, pattern := range remove {
+		n := len(rules)
+		rules = slices.DeleteFunc(rules, func(r SkipRule) bool {
+			return strings.EqualFold(r.Param, pattern) || (r.Path != "" && r.Path == pattern)
+		})
+		if len(rules) == n && !noDefaults {
+			return nil, fmt.Errorf("skip rule %q to remove is not a built-in rule", pattern)
+		}
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, rule := range extra {
+		if (rule.Param == "") == (rule.Path == "") {
+			return nil, fmt.Errorf("skip rule %s: set exactly one of param and path", rule)
+		}
+		var scanners []string
+		for _, name := range rule.Scanners {
+			if modules, ok := aliases[name]; ok {
+				scanners = append(scanners, modules...)
+				continue
+			}
+			if _, ok := registry[name]; !ok {
+				return nil, fmt.Errorf("skip rule %s: unknown scanner %q", rule, name)
+			}
+			scanners = append(scanners, name)
+		}
+		rule.Scanners = scanners
+		rule.Default = false
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// SkipRules applies skip rules and counts what each one skipped. Manager.RunScans skips the
+// scanner/request pairs matching path rules; scanners check their parameters with
+// ScannerOptions.SkipParam. A nil *SkipRules skips nothing.
+type SkipRules struct {
+	rules   []SkipRule
+	skipped []atomic.Int64
+}
+
+// NewSkipRules creates a SkipRules applying rules.
+func NewSkipRules(rules []SkipRule) *SkipRules {
+	return &SkipRules{rules: rules, skipped: make([]atomic.Int64, len(rules))}
+}
+
+// SkipParam reports whether a rule excludes the parameter name from the tests of module.
+func (s *SkipRules) SkipParam(module, name string) bool {
+	if s == nil {
+		return false
+	}
+	for i, rule := range s.rules {
+		if rule.Param != "" && strings.EqualFold(rule.Param, name) && rule.appliesTo(module) {
+			s.skipped[i].Add(1)
+			return true
+		}
+	}
+	return false
+}
+
+// SkipPath reports whether a rule excludes the request to rawURL from the tests of module.
+func (s *SkipRules) SkipPath(module, rawURL string) bool {
+	if s == nil {
+		return false
+	}
+	path := rawURL
+	if u, err := url.Parse(rawURL); err == nil {
+		path = u.Path
+	}
+	for i, rule := range s.rules {
+		if rule.Path != "" && strings.Contains(path, rule.Path) && rule.appliesTo(module) {
+			s.skipped[i].Add(1)
+			return true
+		}
+	}
+	return false
+}
+
+// Rules returns the rules with the number of tests each one skipped so far.
+func (s *SkipRules) Rules() []SkipRule {
+	if s == nil {
+		return nil
+	}
+	rules := make([]SkipRule, len(s.rules))
+	for i, rule := range s.rules {
+		rule.Skipped = s.skipped[i].Load()
+		rules[i] = rule
+	}
+	return rules
+}
+
+// LogSummary logs each rule that skipped something once, with the number of tests it skipped.
+func (s *SkipRules) LogSummary(log *logger.Logger) {
+	for _, rule := range s.Rules() {
+		if rule.Skipped == 0 {
+			continue
+		}
+		what := "parameter test(s)"
+		if rule.Path != "" {
+			what = "scanner/request pair(s)"
+		}
+		log.Info("ScannerManager: Skip rule %s skipped %d %s; see skip_rules in config.yaml.", rule, rule.Skipped, what)
+	}
+}
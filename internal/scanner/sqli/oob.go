@@ -0,0 +1,63 @@
+package sqli
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/oob"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/requtil"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// testOutOfBand injects payloads that make the database resolve or fetch a unique collaborator
+// host. Nothing is reported directly: each potential finding is stored in the OAST correlation
+// map under its correlation ID and only reported once the collaborator records a matching
+// interaction (the interaction's timestamp and source IP are then added to the Evidence).
+func (s *SQLiScanner) testOutOfBand(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string, fingerprint dbmsFingerprint, opts scanner.ScannerOptions) {
+	if opts.OOBCollaboratorURL == "" || opts.OASTCorrelationMap == nil {
+		return
+	}
+	originalParams, err := requtil.Params(req)
+	if err != nil {
+		return
+	}
+	originalValue := originalParams.Get(paramName)
+
+	for _, test := range scanner.TrimPayloads(opts.PayloadTier, payloads.OOBSQLiPayloadsForDBMS(fingerprint.DBMS)) {
+		correlationID := oob.NewCorrelationID("sqli", requtil.DisplayName(paramName))
+		payload := strings.NewReplacer(
+			"{HOST}", oob.PayloadHost(opts.OOBCollaboratorURL, correlationID),
+			"{URL}", oob.PayloadURL(opts.OOBCollaboratorURL, correlationID),
+		).Replace(test.Payload)
+
+		testParams := requtil.Copy(originalParams)
+		testParams.Set(paramName, originalValue+payload)
+		testURL, _, _ := requtil.Components(req, testParams)
+
+		opts.OASTCorrelationMap.Store(correlationID, scanner.VulnerabilityResult{
+			VulnerabilityType: "SQL Injection (Out-of-Band)",
+			URL:               testURL,
+			Parameter:         requtil.DisplayName(paramName),
+			Payload:           originalValue + payload,
+			Details:           strings.TrimSpace(fingerprint.annotate(fmt.Sprintf("The database contacted an attacker-controlled host (%s), allowing data exfiltration over DNS/HTTP.", test.Description)) + " " + requtil.NestedNote(req, paramName)),
+			Severity:          "High",
+			Confidence:        scanner.ConfidenceCertain, // Reported only once the interaction arrives.
+			Evidence:          fmt.Sprintf("Correlation ID: %s.", correlationID),
+			Location:          requtil.Location(req, paramName),
+			Remediation:       "Use parameterized queries (prepared statements) and restrict outbound network access from the database server.",
+			ScannerName:       s.Name(),
+		})
+
+		log.Debug("SQLi (Out-of-Band): Sending %s payload to '%s' (correlation ID %s)", test.DBMS, paramName, correlationID)
+		if _, _, err := requtil.Send(ctx, req, client, testParams); errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			opts.OASTCorrelationMap.Delete(correlationID) // Never sent, so it can never be confirmed.
+		} else if err != nil {
+			log.Debug("SQLi (Out-of-Band): Request failed for '%s': %v", paramName, err)
+		}
+	}
+}
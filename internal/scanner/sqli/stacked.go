@@ -0,0 +1,49 @@
+package sqli
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/requtil"
+	"Dursgo/internal/scanner/timing"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// testStackedQueries terminates the original statement and appends a sleeping one (e.g.,
+// "; WAITFOR DELAY"). Unlike inline time-based payloads, a confirmed delay proves the backend
+// executes stacked statements, which allows data modification and, on MSSQL, command execution.
+// The delay is verified with the same baseline logic as testTimeBased.
+func (s *SQLiScanner) testStackedQueries(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string, fingerprint dbmsFingerprint, tier scanner.PayloadTier, baseline timing.Baseline, plan timing.Plan) (scanner.VulnerabilityResult, bool) {
+	for _, test := range scanner.TrimPayloads(tier, payloads.StackedQueriesSQLiTestsForDBMS(fingerprint.DBMS)) {
+		payloadStr, testParams, exchange, confirmations, confirmed := confirmTimeDelay(ctx, req, client, log, paramName, test.PayloadTemplate, baseline, plan)
+		if !confirmed {
+			continue
+		}
+
+		log.Success("SQLi (Stacked Queries): %s delayed the response for param '%s'", test.Description, paramName)
+		testURL, _, _ := requtil.Components(req, testParams)
+		if fingerprint.DBMS == "" {
+			fingerprint = dbmsFingerprint{DBMS: test.DBMS, Method: "stacked sleep statement"}
+		}
+		return scanner.VulnerabilityResult{
+			VulnerabilityType: "SQL Injection (Stacked Queries)",
+			URL:               testURL,
+			Parameter:         requtil.DisplayName(paramName),
+			Payload:           payloadStr,
+			Details:           fingerprint.annotate(fmt.Sprintf("A sleep appended as a separate statement (%s) delayed the response across %d confirmations, so the backend likely supports query stacking. Arbitrary statements (INSERT, UPDATE, DROP or, on MSSQL, xp_cmdshell) can probably be executed. Confirmed with %s.", test.Description, len(confirmations), plan)),
+			Severity:          "Critical",
+			Confidence:        timingConfidence(confirmations),
+			Evidence:          fmt.Sprintf("Baseline samples: %s; Injected: %s", timing.FormatDurations(baseline.Samples), strings.Join(confirmations, ", ")),
+			Location:          requtil.Location(req, paramName),
+			Remediation:       "Use parameterized queries (prepared statements), disable multi-statement execution in the database driver and run the application with a least-privileged database account.",
+			ScannerName:       s.Name(),
+			RawRequest:        exchange.Request,
+			RawResponse:       exchange.Response,
+		}, true
+	}
+	return scanner.VulnerabilityResult{}, false
+}
@@ -0,0 +1,133 @@
+package sqli
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"Dursgo/internal/scanner/requtil"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// UNION-based column enumeration limits.
+const (
+	maxUnionColumns       = 20  // Highest column count probed.
+	unionOutOfRangeColumn = 100 // ORDER BY index that no realistic query supports.
+)
+
+// testUnionBased performs a UNION-based SQL injection test.
+// For each UNION template it first derives the column count with ORDER BY probing (ORDER BY n
+// keeps the original response for every n up to the real column count), then injects a marker
+// built by string concatenation into each column in turn and looks for the concatenated result
+// in the response. Because the marker only exists after the database evaluates it, a plain
+// reflection of the input cannot trigger a finding.
+func (s *SQLiScanner) testUnionBased(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName string, fingerprint dbmsFingerprint, tier scanner.PayloadTier, cmp compare.Comparator) (scanner.VulnerabilityResult, bool) {
+	originalParams, err := requtil.Params(req)
+	if err != nil {
+		return scanner.VulnerabilityResult{}, false
+	}
+	_, originalBody, err := requtil.Send(ctx, req, client, originalParams)
+	if err != nil {
+		return scanner.VulnerabilityResult{}, false
+	}
+	originalValue := originalParams.Get(paramName)
+
+	inject := func(value string) (string, error) {
+		testParams := requtil.Copy(originalParams)
+		testParams.Set(paramName, value)
+		_, body, err := requtil.Send(ctx, req, client, testParams)
+		return body, err
+	}
+
+	for _, template := range scanner.TrimPayloads(tier, payloads.GetUnionSQLiPayloadTemplates()) {
+		orderByTemplate := strings.Replace(template, " UNION SELECT {NULLS}", " ORDER BY {N}", 1)
+		orderBy := func(n int) string {
+			return originalValue + strings.Replace(orderByTemplate, "{N}", fmt.Sprintf("%d", n), 1)
+		}
+
+		// Cheap pre-check: ORDER BY 1 must behave like the original query and an absurd
+		// column index must break it. Otherwise this context is not injectable.
+		firstBody, err := inject(orderBy(1))
+		if err != nil || cmp.IsDifferent(originalBody, firstBody) {
+			continue
+		}
+		outOfRangeBody, err := inject(orderBy(unionOutOfRangeColumn))
+		if err != nil || !cmp.IsDifferent(originalBody, outOfRangeBody) {
+			continue
+		}
+
+		columnCount := 1
+		for n := 2; n <= maxUnionColumns; n++ {
+			body, err := inject(orderBy(n))
+			if err != nil || cmp.IsDifferent(originalBody, body) {
+				break
+			}
+			columnCount = n
+		}
+		log.Debug("SQLi (UNION-Based): ORDER BY probing suggests %d column(s) for '%s' with template %q", columnCount, paramName, template)
+
+		for column := 0; column < columnCount; column++ {
+			left := "dursgo"
+			right := fmt.Sprintf("u%d", rand.Intn(1e9))
+			marker := left + right
+			for _, expression := range unionMarkerExpressions(fingerprint.DBMS, left, right) {
+				columns := make([]string, columnCount)
+				for i := range columns {
+					columns[i] = "NULL"
+				}
+				columns[column] = expression
+				payload := strings.Replace(template, "{NULLS}", strings.Join(columns, ","), 1)
+
+				// A value that matches no rows makes the UNION row the only one returned.
+				testParams := requtil.Copy(originalParams)
+				testParams.Set(paramName, "-1"+payload)
+				_, body, exchange, err := requtil.SendCaptured(ctx, req, client, testParams)
+				if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) || !strings.Contains(body, marker) {
+					continue
+				}
+
+				log.Success("SQLi (UNION-Based): Marker reflected from column %d of %d for param '%s'", column+1, columnCount, paramName)
+				testURL, _, _ := requtil.Components(req, testParams)
+				return scanner.VulnerabilityResult{
+					VulnerabilityType: "SQL Injection (UNION-Based)",
+					URL:               testURL,
+					Parameter:         requtil.DisplayName(paramName),
+					Payload:           "-1" + payload,
+					Details:           fingerprint.annotate(fmt.Sprintf("A UNION SELECT with %d column(s) was accepted and the value computed in column %d was rendered in the response, allowing arbitrary data to be read from the database.", columnCount, column+1)),
+					Severity:          "High",
+					Confidence:        scanner.ConfidenceCertain, // A value computed by the database was read back.
+					Evidence:          fmt.Sprintf("Column count: %d (ORDER BY probing), reflecting column: %d, marker: %s", columnCount, column+1, marker),
+					Location:          requtil.Location(req, paramName),
+					Remediation:       "Use parameterized queries (prepared statements).",
+					ScannerName:       s.Name(),
+					RawRequest:        exchange.Request,
+					RawResponse:       exchange.Response,
+				}, true
+			}
+		}
+	}
+	return scanner.VulnerabilityResult{}, false
+}
+
+// unionMarkerExpressions returns SQL expressions that concatenate left and right, using the
+// fingerprinted DBMS's syntax when known and the most common syntaxes otherwise.
+func unionMarkerExpressions(dbms, left, right string) []string {
+	concat := fmt.Sprintf("CONCAT('%s','%s')", left, right)
+	pipes := fmt.Sprintf("'%s'||'%s'", left, right)
+	plus := fmt.Sprintf("'%s'+'%s'", left, right)
+	switch dbms {
+	case "MySQL":
+		return []string{concat}
+	case "PostgreSQL", "Oracle", "SQLite":
+		return []string{pipes}
+	case "MSSQL":
+		return []string{plus}
+	}
+	return []string{concat, pipes, plus}
+}
@@ -4,15 +4,34 @@ import (
 	"Dursgo/internal/crawler"
 	"Dursgo/internal/httpclient"
 	"Dursgo/internal/logger"
+	"Dursgo/internal/oob"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/requtil"
+	"Dursgo/internal/scanner/timing"
+	"context"
+	"errors"
 	"fmt"
-	"io"
+	"math/rand"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
+	"time"
 )
 
+// Inference thresholds for targets that return no recognizable content.
+const (
+	minSizeDifferential = 100             // Bytes a response must differ from the control by.
+	timeDifferential    = 2 * time.Second // Extra time a response must take over the slowest control.
+)
+
+// hostLikeValueRegex matches values such as "api.example.com" or "10.0.0.5:8080/status".
+var hostLikeValueRegex = regexp.MustCompile(`(?i)^(([a-z0-9-]+\.)+[a-z]{2,}|\d{1,3}(\.\d{1,3}){3})(:\d+)?(/\S*)?$`)
+
+// fileExtensionRegex matches file names that hostLikeValueRegex would mistake for host names.
+var fileExtensionRegex = regexp.MustCompile(`(?i)\.(php|html?|jsp|aspx?|txt|png|jpe?g|gif|svg|css|js|json|xml|pdf|csv|zip)$`)
+
 // SSRFScanner implements the Scanner interface for Server-Side Request Forgery.
 type SSRFScanner struct{}
 
@@ -21,103 +40,285 @@ func NewSSRFScanner() *SSRFScanner {
 	return &SSRFScanner{}
 }
 
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "ssrf",
+		Order:          60,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewSSRFScanner() },
+	})
+}
+
 // Name returns the scanner's name.
 func (s *SSRFScanner) Name() string {
 	return "Server-Side Request Forgery (SSRF) Scanner"
 }
 
+// ssrfResponse is a sent SSRF probe with its response.
+type ssrfResponse struct {
+	request  *http.Request
+	response *http.Response
+	body     string
+	duration time.Duration
+}
+
 // Scan performs a scan for Server-Side Request Forgery (SSRF) vulnerabilities.
-// It iterates through parameters in GET and POST requests, injecting SSRF payloads
-// and checking for keywords in the response that indicate a successful SSRF attack.
-func (s *SSRFScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+// For each parameter whose name or value looks like a URL or host, it injects a callback URL
+// on the OOB collaborator (confirmed asynchronously), then internal, cloud metadata and
+// protocol-smuggling payloads. Those are confirmed by response signatures or, for filtered
+// targets, inferred from response size/time differentials against an unresolvable control host.
+func (s *SSRFScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
 	var findings []scanner.VulnerabilityResult
 	log.Debug("Starting SSRF scan for %s %s...", req.Method, req.URL)
 
-	if contains(req.ParamLocations, "query") || contains(req.ParamLocations, "body") {
-		for _, paramName := range req.ParamNames {
-			for _, ssrfPayload := range payloads.SSRFPayloads {
-				testURL, reqBody, httpMethod := buildRequest(req, paramName, ssrfPayload)
+	if !contains(req.ParamLocations, "query") && !contains(req.ParamLocations, "body") {
+		return nil, nil
+	}
+	originalParams, err := requtil.Params(req)
+	if err != nil {
+		return nil, nil
+	}
 
-				httpRequest, reqErr := http.NewRequest(httpMethod, testURL, reqBody)
-				if reqErr != nil {
-					continue
-				}
-				if httpMethod == "POST" {
-					httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-				}
-				if strings.Contains(ssrfPayload, "metadata.google.internal") {
-					httpRequest.Header.Set("Metadata-Flavor", "Google")
-				}
+	for _, paramName := range req.ParamNames {
+		if opts.SkipParam("ssrf", paramName) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return findings, ctx.Err()
+		}
+		originalValue := originalParams.Get(paramName)
+		if !isPotentialSSRFParam(paramName) && !isPotentialSSRFValue(originalValue) {
+			continue
+		}
+		log.Debug("SSRF: Testing parameter '%s' in %s", paramName, req.URL)
+
+		s.testCallback(ctx, req, client, log, opts, paramName)
 
-				originalCheckRedirectFunc := client.TemporarilyDisableRedirects()
-				resp, err := client.Do(httpRequest)
-				client.RestoreRedirects(originalCheckRedirectFunc)
+		baseline, err := sendSSRFRequest(ctx, req, client, paramName, originalValue, nil)
+		if err != nil {
+			continue
+		}
+		control, controlStable := s.measureControl(ctx, req, client, paramName)
 
-				if resp != nil {
-					defer resp.Body.Close()
+		var inferred *scanner.VulnerabilityResult
+		for _, test := range payloads.SSRFTests {
+			probe, err := sendSSRFRequest(ctx, req, client, paramName, test.Payload, test.Headers)
+			if err != nil {
+				if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+					break
 				}
-				if err != nil {
-					if resp == nil {
+				continue
+			}
+
+			if signature, ok := matchSignature(probe.body, baseline.body, test); ok {
+				log.Success("SSRF: Signature '%s' found for %s payload '%s' in param '%s'", signature, test.Class, test.Payload, paramName)
+				vuln := s.newFinding(req, probe, paramName, test)
+				vuln.Details = fmt.Sprintf("Payload class: %s (%s). Confirmed via response content: the response (Status: %d) contained '%s', which is absent from the original response.", test.Class, test.Description, probe.response.StatusCode, signature)
+				vuln.Evidence = signature
+				findings = append(findings, vuln)
+				inferred = nil
+				break
+			}
+
+			if inferred == nil && controlStable && test.Class != payloads.SSRFClassExternal {
+				if reason, ok := differential(probe, control, test.Payload); ok {
+					// Inference alone is weak evidence, so the differential must reproduce.
+					retry, err := sendSSRFRequest(ctx, req, client, paramName, test.Payload, test.Headers)
+					if err != nil {
 						continue
 					}
-				}
-				if resp == nil {
-					continue
-				}
-
-				bodyBytes, _ := io.ReadAll(resp.Body)
-				responseBody := string(bodyBytes)
-
-				for _, keyword := range payloads.SSRFResponseKeywords {
-					if strings.Contains(strings.ToLower(responseBody), strings.ToLower(keyword)) {
-						details := fmt.Sprintf("Response from target URL (%s, Status: %d) contained keyword '%s' when testing SSRF payload '%s'.",
-							resp.Request.URL.String(), resp.StatusCode, keyword, ssrfPayload)
-						findings = append(findings, scanner.VulnerabilityResult{
-							VulnerabilityType: "Server-Side Request Forgery (SSRF)",
-							URL:               testURL,
-							Parameter:         paramName,
-							Payload:           ssrfPayload,
-							Location:          "body", // optional: can be replaced with getParamLocation(req) for more accuracy
-							Details:           details,
-							Severity:          "high",
-							Evidence:          ssrfPayload,
-							Remediation:       "Whitelist allowed URLs, avoid user-controlled input in server-side requests, and implement SSRF protection libraries or firewalls.",
-							ScannerName:       "ssrf",
-						})
-
-						goto nextSSRFParam
+					if _, again := differential(retry, control, test.Payload); !again {
+						continue
 					}
+					vuln := s.newFinding(req, probe, paramName, test)
+					vuln.VulnerabilityType = "Server-Side Request Forgery (SSRF, Inferred)"
+					vuln.Severity = "Medium"
+					vuln.Details = fmt.Sprintf("Payload class: %s (%s). Confirmed via inference only: the target returned no recognizable content, but the response differs from an unresolvable control host, suggesting the server attempted the request. Verify manually.", test.Class, test.Description)
+					vuln.Evidence = reason
+					inferred = &vuln
 				}
 			}
-		nextSSRFParam:
+		}
+		if inferred != nil {
+			log.Success("SSRF: Inferred SSRF for payload '%s' in param '%s' (%s)", inferred.Payload, paramName, inferred.Evidence)
+			findings = append(findings, *inferred)
 		}
 	}
 	return findings, nil
 }
 
-// buildRequest constructs an HTTP request with the SSRF payload.
-// It handles both GET and POST requests, injecting the payload into the appropriate parameter.
-func buildRequest(req crawler.ParameterizedRequest, paramName, payload string) (string, io.Reader, string) {
-	if req.Method == "GET" {
-		// --- FIX STARTS HERE ---
-		parsedURL, err := url.Parse(req.URL)
+// testCallback injects a unique URL on the OOB collaborator. The potential finding is stored in
+// the OAST correlation map and only reported once the collaborator records the callback.
+func (s *SSRFScanner) testCallback(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, paramName string) {
+	if opts.OOBCollaboratorURL == "" || opts.OASTCorrelationMap == nil {
+		return
+	}
+	correlationID := oob.NewCorrelationID("ssrf", paramName)
+	payload := oob.PayloadURL(opts.OOBCollaboratorURL, correlationID)
+	testURL := req.URL
+	if httpRequest, err := requtil.Override(ctx, req, paramName, payload); err == nil {
+		testURL = httpRequest.URL.String()
+	}
+
+	opts.OASTCorrelationMap.Store(correlationID, scanner.VulnerabilityResult{
+		VulnerabilityType: "Server-Side Request Forgery (SSRF)",
+		URL:               testURL,
+		Parameter:         paramName,
+		Payload:           payload,
+		Location:          getParamLocation(req),
+		Details:           fmt.Sprintf("Payload class: %s. Confirmed via callback: the server requested the collaborator URL injected into '%s'.", payloads.SSRFClassOOBCallback, paramName),
+		Severity:          "High",
+		Evidence:          fmt.Sprintf("Correlation ID: %s.", correlationID),
+		Remediation:       "Whitelist allowed URLs, avoid user-controlled input in server-side requests, and implement SSRF protection libraries or firewalls.",
+		ScannerName:       s.Name(),
+	})
+
+	log.Debug("SSRF: Injecting callback URL '%s' into param '%s'", payload, paramName)
+	if _, err := sendSSRFRequest(ctx, req, client, paramName, payload, nil); errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+		opts.OASTCorrelationMap.Delete(correlationID) // Never sent, so it can never be confirmed.
+	}
+}
+
+// measureControl sends two requests pointing the parameter at an unresolvable host. The control
+// is stable when both responses have a similar size, so size differentials are meaningful.
+func (s *SSRFScanner) measureControl(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, paramName string) ([]ssrfResponse, bool) {
+	var control []ssrfResponse
+	for i := 0; i < 2; i++ {
+		controlURL := fmt.Sprintf("http://dursgo-%d.invalid/", rand.Intn(1e9))
+		resp, err := sendSSRFRequest(ctx, req, client, paramName, controlURL, nil)
 		if err != nil {
-			// If the original URL from the crawler is broken, return an empty string.
-			// This will cause http.NewRequest to fail safely in the Scan function, and this test will be skipped.
-			return "", nil, "GET"
+			return nil, false
 		}
-		// --- END FIX ---
+		resp.body = stripReflection(resp.body, controlURL)
+		control = append(control, resp)
+	}
+	sizeDiff := len(control[0].body) - len(control[1].body)
+	if sizeDiff < 0 {
+		sizeDiff = -sizeDiff
+	}
+	return control, control[0].response.StatusCode == control[1].response.StatusCode && sizeDiff < minSizeDifferential
+}
+
+// newFinding returns an SSRF finding for a probe, with its raw exchange attached.
+func (s *SSRFScanner) newFinding(req crawler.ParameterizedRequest, probe ssrfResponse, paramName string, test payloads.SSRFTest) scanner.VulnerabilityResult {
+	vuln := scanner.VulnerabilityResult{
+		VulnerabilityType: "Server-Side Request Forgery (SSRF)",
+		URL:               probe.request.URL.String(),
+		Parameter:         paramName,
+		Payload:           test.Payload,
+		Location:          getParamLocation(req),
+		Severity:          "high",
+		Remediation:       "Whitelist allowed URLs, avoid user-controlled input in server-side requests, and implement SSRF protection libraries or firewalls.",
+		ScannerName:       s.Name(),
+	}
+	if test.Class == payloads.SSRFClassMetadata || test.Class == payloads.SSRFClassProtocol {
+		vuln.Severity = "Critical" // Cloud credentials or local files are readable.
+	}
+	vuln.SetExchange(scanner.CaptureExchange(probe.request, probe.response, []byte(probe.body)))
+	return vuln
+}
 
-		queryParams := parsedURL.Query()
-		queryParams.Set(paramName, payload)
-		parsedURL.RawQuery = queryParams.Encode()
-		return parsedURL.String(), nil, "GET"
+// matchSignature returns the first test signature or generic SSRF keyword found in body. Keywords
+// already in the baseline response or in the payload itself (reflection) are ignored.
+func matchSignature(body, baselineBody string, test payloads.SSRFTest) (string, bool) {
+	lowerBody, lowerBaseline := strings.ToLower(body), strings.ToLower(baselineBody)
+	lowerPayload := strings.ToLower(test.Payload)
+	candidates := append(append([]string{}, test.Signatures...), payloads.SSRFResponseKeywords...)
+	for _, keyword := range candidates {
+		lowerKeyword := strings.ToLower(keyword)
+		if strings.Contains(lowerBody, lowerKeyword) && !strings.Contains(lowerBaseline, lowerKeyword) && !strings.Contains(lowerPayload, lowerKeyword) {
+			return keyword, true
+		}
 	}
+	return "", false
+}
+
+// differential compares a probe with the control responses, ignoring reflections of the payload.
+// It reports a size or status differential, or a delay beyond the slowest control response.
+func differential(probe ssrfResponse, control []ssrfResponse, payload string) (string, bool) {
+	body := stripReflection(probe.body, payload)
 
-	// The POST part does not have a URL parsing risk, so no changes are needed.
-	formData, _ := url.ParseQuery(req.FormPostData)
-	formData.Set(paramName, payload)
-	return req.URL, strings.NewReader(formData.Encode()), "POST"
+	var slowest time.Duration
+	controlDurations := make([]time.Duration, len(control))
+	for i, c := range control {
+		controlDurations[i] = c.duration
+		if c.duration > slowest {
+			slowest = c.duration
+		}
+	}
+	if probe.duration > slowest+timeDifferential {
+		return fmt.Sprintf("Response took %s versus %s for the control host.", probe.duration.Round(time.Millisecond), timing.FormatDurations(controlDurations)), true
+	}
+
+	sizeDiff := len(body) - len(control[0].body)
+	if sizeDiff < 0 {
+		sizeDiff = -sizeDiff
+	}
+	threshold := len(control[0].body) / 10
+	if threshold < minSizeDifferential {
+		threshold = minSizeDifferential
+	}
+	if sizeDiff > threshold {
+		return fmt.Sprintf("Response size %d bytes (status %d) versus %d bytes (status %d) for the control host.", len(body), probe.response.StatusCode, len(control[0].body), control[0].response.StatusCode), true
+	}
+	if probe.response.StatusCode != control[0].response.StatusCode {
+		return fmt.Sprintf("Response status %d versus %d for the control host.", probe.response.StatusCode, control[0].response.StatusCode), true
+	}
+	return "", false
+}
+
+// stripReflection removes reflections of value (raw or URL-encoded) from body, so responses
+// echoing payloads of different lengths stay comparable.
+func stripReflection(body, value string) string {
+	body = strings.ReplaceAll(body, value, "")
+	return strings.ReplaceAll(body, url.QueryEscape(value), "")
+}
+
+// sendSSRFRequest sends the request with paramName set to value and measures it.
+func sendSSRFRequest(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, paramName, value string, headers map[string]string) (ssrfResponse, error) {
+	httpRequest, err := requtil.Override(ctx, req, paramName, value)
+	if err != nil {
+		return ssrfResponse{}, err
+	}
+	for name, headerValue := range headers {
+		httpRequest.Header.Set(name, headerValue)
+	}
+
+	duration, resp, body, err := timing.MeasureRequest(client.WithoutRedirects(), httpRequest)
+	if err != nil {
+		return ssrfResponse{}, err
+	}
+	return ssrfResponse{request: httpRequest, response: resp, body: string(body), duration: duration}, nil
+}
+
+// getParamLocation returns where parameters are injected for the request method.
+func getParamLocation(req crawler.ParameterizedRequest) string {
+	if req.Method == "GET" {
+		return "query"
+	}
+	return "body"
+}
+
+// isPotentialSSRFParam checks if a parameter name is commonly used to pass URLs or hosts.
+func isPotentialSSRFParam(paramName string) bool {
+	lowerParam := strings.ToLower(paramName)
+	commonNames := []string{"url", "uri", "link", "href", "src", "dest", "redirect", "host", "domain", "site", "feed", "image", "callback", "webhook", "proxy", "endpoint", "fetch", "load"}
+	for _, name := range commonNames {
+		if strings.Contains(lowerParam, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPotentialSSRFValue checks if a parameter value is a URL or looks like a host name.
+func isPotentialSSRFValue(value string) bool {
+	lower := strings.ToLower(strings.TrimSpace(value))
+	if strings.Contains(lower, "://") || strings.HasPrefix(lower, "//") {
+		return true
+	}
+	return hostLikeValueRegex.MatchString(lower) && !fileExtensionRegex.MatchString(lower)
 }
 
 // contains checks if a string is present in a slice of strings.
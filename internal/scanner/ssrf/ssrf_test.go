@@ -0,0 +1,86 @@
+package ssrf
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScanReportsPayloadClassAndConfirmation(t *testing.T) {
+	tests := []struct {
+		name        string
+		respond     func(target string) string
+		wantType    string
+		wantDetails []string
+	}{
+		{
+			name: "Cloud metadata content",
+			respond: func(target string) string {
+				if target == "http://169.254.169.254/latest/meta-data/" {
+					return "ami-id\ninstance-id\nlocal-ipv4"
+				}
+				return "<p>Preview unavailable</p>"
+			},
+			wantType:    "Server-Side Request Forgery (SSRF)",
+			wantDetails: []string{"cloud-metadata", "Confirmed via response content"},
+		},
+		{
+			name: "Size differential on an internal port",
+			respond: func(target string) string {
+				if target == "http://127.0.0.1:8080" {
+					return "<p>Preview:</p>" + strings.Repeat("<div>internal dashboard</div>", 20)
+				}
+				return "<p>Preview unavailable</p>"
+			},
+			wantType:    "Server-Side Request Forgery (SSRF, Inferred)",
+			wantDetails: []string{"internal-loopback", "Confirmed via inference"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(tt.respond(r.URL.Query().Get("url"))))
+			}))
+			defer server.Close()
+
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+			req := crawler.ParameterizedRequest{
+				Method:         "GET",
+				URL:            server.URL + "/preview?url=https://example.org/&id=7",
+				ParamNames:     []string{"url", "id"},
+				ParamLocations: []string{"query"},
+			}
+
+			findings, err := NewSSRFScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			require.Len(t, findings, 1)
+			assert.Equal(t, tt.wantType, findings[0].VulnerabilityType)
+			assert.Equal(t, "url", findings[0].Parameter)
+			for _, want := range tt.wantDetails {
+				assert.Contains(t, findings[0].Details, want)
+			}
+		})
+	}
+}
+
+func TestIsPotentialSSRFValue(t *testing.T) {
+	assert.True(t, isPotentialSSRFValue("https://cdn.example.com/a.png"))
+	assert.True(t, isPotentialSSRFValue("//example.com/feed"))
+	assert.True(t, isPotentialSSRFValue("api.internal.example.com"))
+	assert.True(t, isPotentialSSRFValue("10.0.0.5:8080/status"))
+	assert.False(t, isPotentialSSRFValue("index.php"))
+	assert.False(t, isPotentialSSRFValue("3.14"))
+	assert.False(t, isPotentialSSRFValue("john"))
+}
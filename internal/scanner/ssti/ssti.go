@@ -2,14 +2,15 @@ package ssti
 
 import (
 	"Dursgo/internal/crawler"
+	"Dursgo/internal/fingerprint"
 	"Dursgo/internal/httpclient"
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
-	"bytes"
-	"encoding/json"
+	"Dursgo/internal/scanner/requtil"
+	"context"
+	"errors"
 	"fmt"
-	"io"
 	"math/rand"
 	"net/http"
 	"net/url"
@@ -25,13 +26,28 @@ func NewSSTIScanner() *SSTIScanner {
 	return &SSTIScanner{}
 }
 
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "ssti",
+		Order:          100,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewSSTIScanner() },
+	})
+}
+
 // Name returns the scanner's name.
 func (s *SSTIScanner) Name() string {
 	return "Server-Side Template Injection (SSTI) Scanner"
 }
 
+// snippetRadius is the number of characters kept on each side of evaluated output in evidence.
+const snippetRadius = 40
+
 // Scan performs the SSTI scan by injecting payloads and analyzing responses.
-func (s *SSTIScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+// Arithmetic probes detect evaluation; engine-specific probes from payloads.SSTIEngineProbes
+// then fingerprint and confirm the template engine.
+func (s *SSTIScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
 	var findings []scanner.VulnerabilityResult
 	// Seed the random number generator for unique baseline values.
 	rand.Seed(time.Now().UnixNano())
@@ -40,70 +56,109 @@ func (s *SSTIScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.
 
 	// Iterate through each parameter found in the request.
 	for _, paramName := range req.ParamNames {
-		vulnerabilityFoundForParam := false // Flag to stop testing a parameter once a vulnerability is found.
+		if opts.SkipParam("ssti", paramName) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return findings, ctx.Err()
+		}
 
 		// Generate a unique baseline value for comparison.
 		baselineValue := fmt.Sprintf("dursgoprobe%d", rand.Intn(1e9))
-		baselineResp, err := sendSSTIRequest(req, client, paramName, baselineValue)
+		baselineStatus, baselineBody, err := sendSSTIRequest(ctx, req, client, paramName, baselineValue)
 		if err != nil {
 			log.Debug("SSTI: Baseline request failed for param '%s': %v", paramName, err)
 			continue // Cannot proceed without a valid baseline.
 		}
-		defer baselineResp.Body.Close() // Ensure response body is closed.
-		baselineBodyBytes, _ := io.ReadAll(baselineResp.Body)
-		baselineBody := string(baselineBodyBytes)
-
-		// Iterate through each SSTI test case (payload template).
-		for _, testCase := range payloads.SSTIPayloads {
-			if vulnerabilityFoundForParam {
-				break // Stop if a vulnerability has already been found for this parameter.
-			}
 
+		// Iterate through each SSTI test case (payload template), those of the detected stack first.
+		for _, testCase := range testCasesFor(opts.Technologies) {
 			// Generate a unique payload and its expected output for the current test case.
 			payload, expectedOutput := payloads.GenerateSSTIPayload(testCase)
 
-			testResp, err := sendSSTIRequest(req, client, paramName, payload)
+			testStatus, testBody, err := sendSSTIRequest(ctx, req, client, paramName, payload)
 			if err != nil {
 				log.Debug("SSTI: Test request failed for param '%s' with payload '%s': %v", paramName, payload, err)
 				continue
 			}
-			defer testResp.Body.Close() // Ensure response body is closed.
-			testBodyBytes, _ := io.ReadAll(testResp.Body)
-			testBody := string(testBodyBytes)
 
 			// --- Detection Logic ---
-			// 1. Reflection-Based Detection (High Confidence):
+			// 1. Evaluation-Based Detection (High Confidence):
 			//    - Expected output is found in the test response.
 			//    - Expected output is NOT found in the baseline response (prevents false positives from static content).
+			//    - The raw payload is NOT reflected, i.e., the expression was replaced by its result.
 			//    - HTTP status code is OK (200).
-			isReflected := strings.Contains(testBody, expectedOutput) && !strings.Contains(baselineBody, expectedOutput)
-			if isReflected && testResp.StatusCode == http.StatusOK {
-				vulnerabilityFoundForParam = true
-				findings = append(findings, s.createVulnerability(req, testCase, paramName, payload,
-					fmt.Sprintf("Template expression '%s' was evaluated to '%s'.", payload, expectedOutput)))
-				continue
+			isEvaluated := strings.Contains(testBody, expectedOutput) && !strings.Contains(baselineBody, expectedOutput) && !strings.Contains(testBody, payload)
+			if isEvaluated && testStatus == http.StatusOK {
+				vuln := s.createVulnerability(req, paramName, payload,
+					fmt.Sprintf("Template expression '%s' was evaluated to '%s'. Candidate engines: %s.", payload, expectedOutput, testCase.EngineName),
+					fmt.Sprintf("Injected: %s | Rendered: %s", payload, evaluationSnippet(testBody, expectedOutput)))
+				if confirmed, ok := s.fingerprintEngine(ctx, req, client, log, paramName, baselineBody); ok {
+					vuln = confirmed
+				}
+				findings = append(findings, vuln)
+				break
 			}
 
 			// 2. Error-Based Detection (Medium-High Confidence):
 			//    - Baseline request was successful (HTTP 200 OK).
 			//    - Test request resulted in an Internal Server Error (HTTP 500).
 			//    This indicates the server attempted to process the template but failed.
-			isErrorBased := baselineResp.StatusCode == http.StatusOK && testResp.StatusCode == http.StatusInternalServerError
+			isErrorBased := baselineStatus == http.StatusOK && testStatus == http.StatusInternalServerError
 			if isErrorBased {
-				vulnerabilityFoundForParam = true
-				findings = append(findings, s.createVulnerability(req, testCase, paramName, payload,
-					fmt.Sprintf("Payload '%s' caused a 500 Internal Server Error, while a baseline request was successful. This strongly indicates the server tried to process the template.", payload)))
-				continue
+				findings = append(findings, s.createVulnerability(req, paramName, payload,
+					fmt.Sprintf("Payload '%s' caused a 500 Internal Server Error, while a baseline request was successful. This strongly indicates the server tried to process the template.", payload),
+					fmt.Sprintf("Engine: %s, Expected Output: %s", testCase.EngineName, testCase.ExpectedPattern)))
+				break
 			}
 		}
 	}
 	return findings, nil
 }
 
+// testCasesFor returns payloads.SSTIPayloads with the test cases of the engines running on the
+// fingerprinted stack first (Jinja2 and Mako on Python, ERB on Ruby), each group in its order.
+func testCasesFor(techs fingerprint.Technologies) []payloads.SSTIPayloadTest {
+	var preferred, others []payloads.SSTIPayloadTest
+	for _, testCase := range payloads.SSTIPayloads {
+		if techs.HasAny(testCase.Stacks...) {
+			preferred = append(preferred, testCase)
+		} else {
+			others = append(others, testCase)
+		}
+	}
+	return append(preferred, others...)
+}
+
+// fingerprintEngine sends the engine-specific probes and returns a Critical finding for the
+// first engine whose expected output is rendered.
+func (s *SSTIScanner) fingerprintEngine(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, paramName, baselineBody string) (scanner.VulnerabilityResult, bool) {
+	for _, probe := range payloads.SSTIEngineProbes {
+		payload, expectedOutput := payloads.GenerateSSTIEngineProbe(probe)
+		status, body, err := sendSSTIRequest(ctx, req, client, paramName, payload)
+		if err != nil || status != http.StatusOK {
+			continue
+		}
+		if !strings.Contains(body, expectedOutput) || strings.Contains(baselineBody, expectedOutput) {
+			continue
+		}
+		log.Success("SSTI: Confirmed %s template engine via param '%s'", probe.Engine, paramName)
+		vuln := s.createVulnerability(req, paramName, payload,
+			fmt.Sprintf("Template engine confirmed as %s: the engine-specific expression '%s' was evaluated to '%s'.", probe.Engine, payload, expectedOutput),
+			fmt.Sprintf("Injected: %s | Rendered: %s", payload, evaluationSnippet(body, expectedOutput)))
+		vuln.Severity = "Critical"
+		return vuln, true
+	}
+	return scanner.VulnerabilityResult{}, false
+}
+
 // createVulnerability constructs a scanner.VulnerabilityResult based on the detected SSTI.
-func (s *SSTIScanner) createVulnerability(req crawler.ParameterizedRequest, testCase payloads.SSTIPayloadTest, paramName, payload, details string) scanner.VulnerabilityResult {
+func (s *SSTIScanner) createVulnerability(req crawler.ParameterizedRequest, paramName, payload, details, evidence string) scanner.VulnerabilityResult {
 	// Build the vulnerable URL using the original request and the successful payload.
-	vulnerableURL, _, _, _ := buildRequestComponents(req, paramName, payload)
+	vulnerableURL := req.URL
+	if params, err := testParams(req, paramName, payload); err == nil {
+		vulnerableURL, _, _ = requtil.Components(req, params)
+	}
 	return scanner.VulnerabilityResult{
 		VulnerabilityType: "Server-Side Template Injection (SSTI)",
 		URL:               vulnerableURL,
@@ -112,64 +167,60 @@ func (s *SSTIScanner) createVulnerability(req crawler.ParameterizedRequest, test
 		Location:          getParamLocation(req),
 		Details:           details,
 		Severity:          "High",
-		Evidence:          fmt.Sprintf("Engine: %s, Expected Output: %s", testCase.EngineName, testCase.ExpectedPattern),
+		Evidence:          evidence,
 		Remediation:       "Avoid using user-supplied input in template structures. Use sandboxed template engines and explicitly pass variables.",
 		ScannerName:       s.Name(),
 	}
 }
 
-// --- Helper Functions ---
-
-func sendSSTIRequest(req crawler.ParameterizedRequest, client *httpclient.Client, paramName, value string) (*http.Response, error) {
-	testURL, reqBody, contentType, err := buildRequestComponents(req, paramName, value)
-	if err != nil {
-		return nil, err
+// evaluationSnippet returns the evaluated output with up to snippetRadius characters of
+// surrounding response content.
+func evaluationSnippet(body, output string) string {
+	index := strings.Index(body, output)
+	if index < 0 {
+		return output
 	}
-	httpRequest, err := http.NewRequest(req.Method, testURL, reqBody)
-	if err != nil {
-		return nil, err
+	start, end := index-snippetRadius, index+len(output)+snippetRadius
+	if start < 0 {
+		start = 0
 	}
-	if req.Method == "POST" && contentType != "" {
-		httpRequest.Header.Set("Content-Type", contentType)
+	if end > len(body) {
+		end = len(body)
 	}
-	return client.Do(httpRequest)
+	return strings.TrimSpace(strings.ToValidUTF8(body[start:end], ""))
 }
 
-func buildRequestComponents(req crawler.ParameterizedRequest, paramName, value string) (string, io.Reader, string, error) {
-	if req.Method == "GET" {
-		p, err := url.Parse(req.URL)
-		if err != nil {
-			return "", nil, "", err
-		}
-		q := p.Query()
-		q.Set(paramName, value)
-		p.RawQuery = q.Encode()
-		return p.String(), nil, "", nil
-	}
+// --- Helper Functions ---
 
-	trimmedData := strings.TrimSpace(req.FormPostData)
-	if strings.HasPrefix(trimmedData, "{") && strings.HasSuffix(trimmedData, "}") {
-		var jsonData map[string]interface{}
-		if err := json.Unmarshal([]byte(req.FormPostData), &jsonData); err == nil {
-			jsonData[paramName] = value
-			newBody, err := json.Marshal(jsonData)
-			if err != nil {
-				return "", nil, "", err
-			}
-			return req.URL, bytes.NewReader(newBody), "application/json", nil
-		}
+// sendSSTIRequest sends the request with paramName set to value and returns the status code and body.
+func sendSSTIRequest(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, paramName, value string) (int, string, error) {
+	params, err := testParams(req, paramName, value)
+	if err != nil {
+		return 0, "", err
 	}
-	return buildFormRequest(req, paramName, value)
+	_, resp, body, err := requtil.Do(ctx, req, client, params)
+	if resp == nil {
+		return 0, "", err
+	}
+	if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
+		return 0, "", err
+	}
+	return resp.StatusCode, string(body), nil
 }
 
-func buildFormRequest(req crawler.ParameterizedRequest, paramName, value string) (string, io.Reader, string, error) {
-	params, err := url.ParseQuery(req.FormPostData)
+// testParams returns the parameters of req with paramName set to value. Form bodies also get
+// submit=Submit, which some form handlers need to process the request.
+func testParams(req crawler.ParameterizedRequest, paramName, value string) (url.Values, error) {
+	params, err := requtil.Params(req)
 	if err != nil {
-		return "", nil, "", err
+		return nil, err
 	}
+	params = requtil.Copy(params)
 	params.Set(paramName, value)
-	params.Set("submit", "Submit")
-	return req.URL, strings.NewReader(params.Encode()), "application/x-www-form-urlencoded", nil
+	if req.Method != "GET" && !req.IsJSON() && !req.IsMultipart() {
+		params.Set("submit", "Submit")
+	}
+	return params, nil
 }
 
 func getParamLocation(req crawler.ParameterizedRequest) string {
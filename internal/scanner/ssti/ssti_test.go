@@ -0,0 +1,115 @@
+package ssti
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/fingerprint"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	jinjaMultiply       = regexp.MustCompile(`\{\{(\d+)\*(\d+)\}\}`)
+	jinjaStringMultiply = regexp.MustCompile(`\{\{(\d+)\*'(\d)'\}\}`)
+)
+
+// renderJinja evaluates the subset of Jinja2 used by the probes: integer and string multiplication.
+func renderJinja(input string) string {
+	output := jinjaMultiply.ReplaceAllStringFunc(input, func(m string) string {
+		parts := jinjaMultiply.FindStringSubmatch(m)
+		a, _ := strconv.Atoi(parts[1])
+		b, _ := strconv.Atoi(parts[2])
+		return strconv.Itoa(a * b)
+	})
+	return jinjaStringMultiply.ReplaceAllStringFunc(output, func(m string) string {
+		parts := jinjaStringMultiply.FindStringSubmatch(m)
+		count, _ := strconv.Atoi(parts[1])
+		return strings.Repeat(parts[2], count)
+	})
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name         string
+		render       func(string) string
+		wantFindings int
+		wantSeverity string
+		wantEngine   string
+	}{
+		{
+			name:         "Jinja2 is confirmed",
+			render:       renderJinja,
+			wantFindings: 1,
+			wantSeverity: "Critical",
+			wantEngine:   "Jinja2",
+		},
+		{
+			name:         "Plain reflection is not reported",
+			render:       func(input string) string { return input },
+			wantFindings: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("<p>Hello " + tt.render(r.URL.Query().Get("name")) + "!</p>"))
+			}))
+			defer server.Close()
+
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+			req := crawler.ParameterizedRequest{
+				Method:     "GET",
+				URL:        server.URL + "/greet?name=guest",
+				ParamNames: []string{"name"},
+			}
+
+			findings, err := NewSSTIScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+			require.NoError(t, err)
+			require.Len(t, findings, tt.wantFindings)
+			if tt.wantFindings == 0 {
+				return
+			}
+			assert.Equal(t, tt.wantSeverity, findings[0].Severity)
+			assert.Contains(t, findings[0].Details, tt.wantEngine)
+			assert.Contains(t, findings[0].Evidence, "Injected: "+findings[0].Payload)
+			assert.Contains(t, findings[0].Evidence, "Rendered: ")
+		})
+	}
+}
+
+func TestGenerateSSTIEngineProbe(t *testing.T) {
+	payload, expected := payloads.GenerateSSTIEngineProbe(payloads.SSTIEngineProbe{
+		PayloadTemplate:  "{{{A}*'{B}'}}{TOKEN}",
+		ExpectedTemplate: "{REPEAT}{TOKEN}",
+	})
+
+	assert.Regexp(t, `^\{\{\d+\*'\d'\}\}[a-z]{8}$`, payload)
+	assert.Equal(t, renderJinja(payload), expected)
+}
+
+func TestTestCasesFor(t *testing.T) {
+	assert.Equal(t, payloads.SSTIPayloads, testCasesFor(nil), "without a fingerprint the order is unchanged")
+
+	ruby := testCasesFor(fingerprint.Technologies{{Name: "Ruby on Rails"}, {Name: "Ruby"}})
+	require.Len(t, ruby, len(payloads.SSTIPayloads))
+	assert.Equal(t, "ERB (Ruby) / EJS (JavaScript)", ruby[0].EngineName)
+	assert.Equal(t, "Jinja2 / Twig / Nunjucks / Pebble", ruby[1].EngineName)
+
+	python := testCasesFor(fingerprint.Technologies{{Name: "Python"}})
+	assert.Equal(t, "Jinja2 / Twig / Nunjucks / Pebble", python[0].EngineName)
+	assert.Equal(t, "FreeMarker / Velocity / Mako", python[1].EngineName)
+}
@@ -0,0 +1,148 @@
+package scanner
+
+import (
+	"Dursgo/internal/crawler"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StoredMarker is a benign marker submitted to a writable parameter during the active phase.
+// Once the active phase is over, the pages of the target are fetched again and the pages
+// displaying the marker are the read points of the stored input.
+type StoredMarker struct {
+	Marker      string                       `json:"marker"`
+	Request     crawler.ParameterizedRequest `json:"request"` // The write point.
+	Param       string                       `json:"param"`
+	SubmittedAt time.Time                    `json:"submitted_at"`
+}
+
+// StoredContent is content a scan created on the target by submitting a value to a writable
+// parameter, listed in the report so that the owners of the target can remove it.
+type StoredContent struct {
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Param  string `json:"param"`
+	Value  string `json:"value"` // Marker or payload submitted.
+	// ShownAt are the pages the value was found on, when it was looked for.
+	ShownAt     []string  `json:"shown_at,omitempty"`
+	SubmittedAt time.Time `json:"submitted_at"`
+}
+
+// StoredMarkers records the markers submitted by the stored XSS module and the content the
+// scan created on the target. Its methods may be called from several goroutines; those of a
+// nil *StoredMarkers do nothing.
+type StoredMarkers struct {
+	mu      sync.Mutex
+	markers []StoredMarker
+	index   map[string]int      // Marker -> position in markers.
+	shownAt map[string][]string // Marker -> pages it was found on.
+	content []StoredContent     // Submissions other than markers, e.g. confirmation payloads.
+}
+
+// NewStoredMarkers creates an empty StoredMarkers.
+func NewStoredMarkers() *StoredMarkers {
+	return &StoredMarkers{index: make(map[string]int), shownAt: make(map[string][]string)}
+}
+
+// Add records a submitted marker.
+func (m *StoredMarkers) Add(marker StoredMarker) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.index[marker.Marker]; ok {
+		return
+	}
+	m.index[marker.Marker] = len(m.markers)
+	m.markers = append(m.markers, marker)
+}
+
+// Restore records the markers submitted by an interrupted run.
+func (m *StoredMarkers) Restore(markers []StoredMarker) {
+	for _, marker := range markers {
+		m.Add(marker)
+	}
+}
+
+// Markers returns the submitted markers, in the order they were submitted.
+func (m *StoredMarkers) Markers() []StoredMarker {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]StoredMarker(nil), m.markers...)
+}
+
+// Lookup returns the submission of marker.
+func (m *StoredMarkers) Lookup(marker string) (StoredMarker, bool) {
+	if m == nil {
+		return StoredMarker{}, false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i, ok := m.index[marker]
+	if !ok {
+		return StoredMarker{}, false
+	}
+	return m.markers[i], true
+}
+
+// MarkShown records that marker was found on page.
+func (m *StoredMarkers) MarkShown(marker, page string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, shown := range m.shownAt[marker] {
+		if shown == page {
+			return
+		}
+	}
+	m.shownAt[marker] = append(m.shownAt[marker], page)
+}
+
+// AddContent records a submission other than a marker.
+func (m *StoredMarkers) AddContent(content StoredContent) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.content = append(m.content, content)
+}
+
+// Content returns every submission the scan made to a writable parameter, markers included,
+// sorted by URL, parameter and time.
+func (m *StoredMarkers) Content() []StoredContent {
+	if m == nil {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	content := make([]StoredContent, 0, len(m.markers)+len(m.content))
+	for _, marker := range m.markers {
+		content = append(content, StoredContent{
+			Method:      marker.Request.Method,
+			URL:         marker.Request.URL,
+			Param:       marker.Param,
+			Value:       marker.Marker,
+			ShownAt:     append([]string(nil), m.shownAt[marker.Marker]...),
+			SubmittedAt: marker.SubmittedAt,
+		})
+	}
+	content = append(content, m.content...)
+	sort.SliceStable(content, func(i, j int) bool {
+		if content[i].URL != content[j].URL {
+			return content[i].URL < content[j].URL
+		}
+		if content[i].Param != content[j].Param {
+			return content[i].Param < content[j].Param
+		}
+		return content[i].SubmittedAt.Before(content[j].SubmittedAt)
+	})
+	return content
+}
@@ -0,0 +1,225 @@
+package takeover
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/dns"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// maxHosts bounds the subdomains resolved in one scan.
+const maxHosts = 200
+
+// hostRegex matches the host of absolute and protocol-relative URLs in response bodies.
+var hostRegex = regexp.MustCompile(`(?i)(https?:)?//([a-z0-9](?:[a-z0-9-]*[a-z0-9])?(?:\.[a-z0-9](?:[a-z0-9-]*[a-z0-9])?)+)`)
+
+// Resolver resolves the CNAME chain of a host. It is implemented by dns.Resolver.
+type Resolver interface {
+	Resolve(ctx context.Context, host string) (dns.Resolution, error)
+}
+
+// TakeoverScanner implements the PassiveScanner interface for subdomain takeover. It collects the
+// subdomains of the target referenced in crawled responses and resolves their CNAME chains. Unlike
+// other passive scanners it sends requests: one DNS query per subdomain, and one HTTP request per
+// subdomain pointing to a known hosting service, to confirm the service's "unclaimed" page.
+type TakeoverScanner struct {
+	client     *httpclient.Client
+	resolver   Resolver
+	baseDomain string // Registrable domain of the target; its subdomains are in scope.
+	// urlFor returns the URL fetched to confirm a candidate.
+	urlFor func(scheme, host string) string
+}
+
+// NewTakeoverScanner creates a new instance of TakeoverScanner for the subdomains of targetURL.
+// client should not carry the scan's credentials, since candidates are served by third parties.
+func NewTakeoverScanner(client *httpclient.Client, resolver Resolver, targetURL string) *TakeoverScanner {
+	host := targetURL
+	if parsedURL, err := url.Parse(targetURL); err == nil && parsedURL.Hostname() != "" {
+		host = parsedURL.Hostname()
+	}
+	host = strings.ToLower(host)
+	baseDomain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		baseDomain = host
+	}
+	return &TakeoverScanner{
+		client:     client,
+		resolver:   resolver,
+		baseDomain: baseDomain,
+		urlFor: func(scheme, host string) string {
+			return scheme + "://" + host + "/"
+		},
+	}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "takeover",
+		Order:          280,
+		DefaultEnabled: true,
+		// Candidates may be served by third parties, so the scan's credentials are not sent.
+		NewPassive: func(env scanner.Env) scanner.PassiveScanner {
+			return NewTakeoverScanner(env.AnonymousClient, dns.NewResolver(""), env.TargetBaseURL)
+		},
+	})
+}
+
+// Name returns the scanner's name.
+func (s *TakeoverScanner) Name() string {
+	return "Subdomain Takeover Scanner"
+}
+
+// ScanResponses checks every subdomain referenced in responses against payloads.TakeoverFingerprints.
+// A CNAME to a known service is reported as High when the service's response for an unclaimed
+// resource is confirmed; a CNAME whose target does not exist is reported as a dangling record.
+func (s *TakeoverScanner) ScanResponses(responses []crawler.CrawledResponse, log *logger.Logger) []scanner.VulnerabilityResult {
+	hosts := s.collectHosts(responses)
+	log.Debug("Takeover: Checking %d subdomain(s) of %s", len(hosts), s.baseDomain)
+
+	var findings []scanner.VulnerabilityResult
+	for _, host := range sortedKeys(hosts) {
+		resolution, err := s.resolver.Resolve(context.Background(), host)
+		if err != nil {
+			log.Debug("Takeover: Could not resolve %s: %v", host, err)
+			continue
+		}
+		if len(resolution.CNAMEs) == 0 {
+			continue // Only CNAME records point to resources another account can claim.
+		}
+		log.Debug("Takeover: %s (NXDOMAIN: %v)", resolution.Chain(), resolution.NXDomain)
+		if vuln, ok := s.check(resolution, hosts[host], log); ok {
+			findings = append(findings, vuln)
+		}
+	}
+	return findings
+}
+
+// check matches a resolved subdomain against the fingerprints, fetching it once if needed.
+func (s *TakeoverScanner) check(resolution dns.Resolution, scheme string, log *logger.Logger) (scanner.VulnerabilityResult, bool) {
+	fingerprint, known := matchFingerprint(resolution)
+	candidateURL := s.urlFor(scheme, resolution.Host)
+	vuln := scanner.VulnerabilityResult{
+		URL:         candidateURL,
+		Parameter:   resolution.Host,
+		Location:    "dns",
+		Remediation: "Remove DNS records that point to deprovisioned resources, or claim the resource again. Remove CNAME records before deleting the cloud resource they point to, and audit DNS zones regularly for dangling records.",
+		ScannerName: s.Name(),
+	}
+
+	switch {
+	case known && fingerprint.NXDomain:
+		if !resolution.NXDomain {
+			return vuln, false
+		}
+		log.Success("Subdomain Takeover: %s points to an unclaimed %s resource (%s)", resolution.Host, fingerprint.Service, resolution.Target())
+		vuln.VulnerabilityType = "Subdomain Takeover"
+		vuln.Severity = "High"
+		vuln.Details = fmt.Sprintf("%s has a CNAME record pointing to %s, a %s name that does not exist. Anyone can create a %s resource with this name and serve content from %s.", resolution.Host, resolution.Target(), fingerprint.Service, fingerprint.Service, resolution.Host)
+		vuln.Evidence = fmt.Sprintf("CNAME chain: %s. Signature: %s (NXDOMAIN).", resolution.Chain(), resolution.Target())
+		return vuln, true
+
+	case known && !resolution.NXDomain:
+		resp, body, err := s.fetch(candidateURL)
+		if err != nil {
+			log.Debug("Takeover: Could not fetch %s: %v", candidateURL, err)
+			return vuln, false
+		}
+		match := fingerprint.Regex.FindString(string(body))
+		if match == "" {
+			return vuln, false
+		}
+		log.Success("Subdomain Takeover: %s points to an unclaimed %s resource", resolution.Host, fingerprint.Service)
+		vuln.VulnerabilityType = "Subdomain Takeover"
+		vuln.Severity = "High"
+		vuln.Details = fmt.Sprintf("%s points to %s, which answers with the %s page for an unclaimed resource. Anyone can claim it on %s and serve content from %s, e.g. to steal cookies scoped to %s or host phishing pages.", resolution.Host, resolution.Target(), fingerprint.Service, fingerprint.Service, resolution.Host, s.baseDomain)
+		vuln.Evidence = fmt.Sprintf("CNAME chain: %s. Response (HTTP %d) matched signature: %s", resolution.Chain(), resp.StatusCode, match)
+		vuln.SetExchange(scanner.CaptureExchange(resp.Request, resp, body))
+		return vuln, true
+
+	case resolution.NXDomain:
+		log.Success("Dangling DNS Record: %s points to %s, which does not exist", resolution.Host, resolution.Target())
+		vuln.VulnerabilityType = "Dangling DNS Record"
+		vuln.Severity = "Medium"
+		vuln.Details = fmt.Sprintf("%s has a CNAME record pointing to %s, which does not exist. If the name (or its domain) can be registered by someone else, %s can be taken over.", resolution.Host, resolution.Target(), resolution.Host)
+		vuln.Evidence = fmt.Sprintf("CNAME chain: %s (NXDOMAIN).", resolution.Chain())
+		return vuln, true
+	}
+	return vuln, false
+}
+
+// collectHosts returns the in-scope hosts of the crawled URLs and of the URLs referenced in
+// response bodies and redirects, with the scheme they were first seen with.
+func (s *TakeoverScanner) collectHosts(responses []crawler.CrawledResponse) map[string]string {
+	hosts := make(map[string]string)
+	add := func(scheme, host string) {
+		host = strings.TrimSuffix(strings.ToLower(host), ".")
+		if _, seen := hosts[host]; seen || len(hosts) >= maxHosts || !s.inScope(host) {
+			return
+		}
+		if scheme != "http" {
+			scheme = "https"
+		}
+		hosts[host] = scheme
+	}
+	for _, resp := range responses {
+		if parsedURL, err := url.Parse(resp.URL); err == nil {
+			add(parsedURL.Scheme, parsedURL.Hostname())
+		}
+		texts := []string{resp.Body, resp.Header.Get("Location"), resp.Header.Get("Content-Security-Policy")}
+		for _, text := range texts {
+			for _, match := range hostRegex.FindAllStringSubmatch(text, -1) {
+				add(strings.TrimSuffix(strings.ToLower(match[1]), ":"), match[2])
+			}
+		}
+	}
+	return hosts
+}
+
+// inScope reports whether host is the target's registrable domain or one of its subdomains.
+func (s *TakeoverScanner) inScope(host string) bool {
+	return host == s.baseDomain || strings.HasSuffix(host, "."+s.baseDomain)
+}
+
+// fetch performs a GET request to rawURL and reads the response body.
+func (s *TakeoverScanner) fetch(rawURL string) (*http.Response, []byte, error) {
+	resp, err := s.client.Get(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, int64(crawler.MaxRetainedBodyBytes)))
+	return resp, body, err
+}
+
+// matchFingerprint returns the fingerprint of the service any name of the CNAME chain belongs to.
+func matchFingerprint(resolution dns.Resolution) (payloads.TakeoverFingerprint, bool) {
+	for _, fingerprint := range payloads.TakeoverFingerprints {
+		for _, name := range resolution.CNAMEs {
+			if fingerprint.MatchesCNAME(name) {
+				return fingerprint, true
+			}
+		}
+	}
+	return payloads.TakeoverFingerprint{}, false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,89 @@
+package takeover
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/dns"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver answers from a fixed table and records the hosts it was asked for.
+type fakeResolver struct {
+	records map[string]dns.Resolution
+	asked   []string
+}
+
+func (r *fakeResolver) Resolve(_ context.Context, host string) (dns.Resolution, error) {
+	r.asked = append(r.asked, host)
+	res, ok := r.records[host]
+	if !ok {
+		return dns.Resolution{Host: host, Addresses: []string{"203.0.113.10"}}, nil
+	}
+	res.Host = host
+	return res, nil
+}
+
+func TestScanResponses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/docs.example.com/" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("<h1>404</h1><p><strong>There isn't a GitHub Pages site here.</strong></p>"))
+			return
+		}
+		w.Write([]byte("<html><body>Developer blog</body></html>"))
+	}))
+	defer server.Close()
+
+	resolver := &fakeResolver{records: map[string]dns.Resolution{
+		"docs.example.com":   {CNAMEs: []string{"example.github.io"}, Addresses: []string{"185.199.108.153"}},
+		"blog.example.com":   {CNAMEs: []string{"example-blog.github.io"}, Addresses: []string{"185.199.108.153"}},
+		"legacy.example.com": {CNAMEs: []string{"legacy-app.azurewebsites.net"}, NXDomain: true},
+		"promo.example.com":  {CNAMEs: []string{"promo.expired-agency.com"}, NXDomain: true},
+	}}
+	log := logger.NewLogger(logger.ERROR)
+	s := NewTakeoverScanner(httpclient.NewClient(log, httpclient.ClientOptions{}), resolver, "https://www.example.com/")
+	s.urlFor = func(_, host string) string { return server.URL + "/" + host + "/" }
+
+	responses := []crawler.CrawledResponse{
+		{
+			URL:    "https://www.example.com/",
+			Header: http.Header{"Content-Security-Policy": {"script-src 'self' https://static.example.com"}},
+			Body: `<a href="https://docs.example.com/api">Docs</a> <a href="http://blog.example.com/">Blog</a>
+				<script src="//legacy.example.com/app.js"></script> <img src="https://cdn.other-site.com/logo.png">
+				<a href="https://promo.example.com/sale">Sale</a>`,
+		},
+	}
+
+	findings := s.ScanResponses(responses, log)
+	require.Len(t, findings, 3)
+
+	assert.Equal(t, "Subdomain Takeover", findings[0].VulnerabilityType)
+	assert.Equal(t, "docs.example.com", findings[0].Parameter)
+	assert.Equal(t, "High", findings[0].Severity)
+	assert.Contains(t, findings[0].Details, "GitHub Pages")
+	assert.Equal(t, "CNAME chain: docs.example.com -> example.github.io. Response (HTTP 404) matched signature: There isn't a GitHub Pages site here.", findings[0].Evidence)
+	assert.Contains(t, findings[0].RawResponse, "There isn't a GitHub Pages site here.")
+
+	assert.Equal(t, "Subdomain Takeover", findings[1].VulnerabilityType)
+	assert.Equal(t, "legacy.example.com", findings[1].Parameter)
+	assert.Contains(t, findings[1].Details, "Microsoft Azure")
+	assert.Contains(t, findings[1].Evidence, "legacy.example.com -> legacy-app.azurewebsites.net")
+
+	assert.Equal(t, "Dangling DNS Record", findings[2].VulnerabilityType)
+	assert.Equal(t, "promo.example.com", findings[2].Parameter)
+	assert.Equal(t, "Medium", findings[2].Severity)
+
+	assert.ElementsMatch(t, []string{"www.example.com", "static.example.com", "docs.example.com", "blog.example.com", "legacy.example.com", "promo.example.com"}, resolver.asked)
+	for _, host := range resolver.asked {
+		assert.False(t, strings.HasSuffix(host, "other-site.com"))
+	}
+}
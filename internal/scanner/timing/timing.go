@@ -0,0 +1,240 @@
+// Package timing implements the baseline-vs-injected response time verification shared by
+// time-based scanners (blind SQL injection, blind command injection).
+package timing
+
+import (
+	"Dursgo/internal/httpclient"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Verification settings.
+const (
+	DefaultBaselineSamples = 5               // Baseline samples when the scanner options leave it unset.
+	StdDevFactor           = 3.0             // Injected responses must exceed the baseline mean by this many stddevs.
+	Tolerance              = 1 * time.Second // Allowed shortfall between the injected sleep and the measured delay.
+	DefaultAdaptiveFactor  = 3.0             // Adaptive delays are this many times the p95 latency of a host.
+	MaxAdaptiveDelay       = 30              // Cap of adaptive delays in seconds, so confirmations stay within timeouts.
+)
+
+// DefaultDelays are the sleep durations (in seconds) every time-based finding must be confirmed with.
+var DefaultDelays = []int{5, 10}
+
+// DefaultConfirmations is the number of sleeps of DefaultDelays.
+const DefaultConfirmations = 2
+
+// Delays returns the sleep durations to confirm time-based findings with: count multiples of
+// base seconds (base, twice base, ...). A base that is not positive is DefaultDelays[0] and a
+// count that is not positive DefaultConfirmations, so Delays(0, 0) is DefaultDelays.
+func Delays(base, count int) []int {
+	if base <= 0 {
+		base = DefaultDelays[0]
+	}
+	if count <= 0 {
+		count = DefaultConfirmations
+	}
+	delays := make([]int, count)
+	for i := range delays {
+		delays[i] = (i + 1) * base
+	}
+	return delays
+}
+
+// Baseline models the normal response time of a request before time-based tests.
+type Baseline struct {
+	Samples   []time.Duration
+	Mean      time.Duration
+	StdDev    time.Duration
+	Threshold time.Duration // Mean + StdDevFactor*StdDev.
+}
+
+// MeasureBaseline calls measure sampleCount times (DefaultBaselineSamples when zero or
+// negative). It reports false when fewer than two samples succeed, which is too few for a
+// meaningful baseline.
+func MeasureBaseline(sampleCount int, measure func() (time.Duration, error)) (Baseline, bool) {
+	if sampleCount <= 0 {
+		sampleCount = DefaultBaselineSamples
+	}
+	var samples []time.Duration
+	for i := 0; i < sampleCount; i++ {
+		sample, err := measure()
+		if err != nil {
+			continue
+		}
+		samples = append(samples, sample)
+	}
+	if len(samples) < 2 {
+		return Baseline{}, false
+	}
+	mean, stddev := Stats(samples)
+	return Baseline{
+		Samples:   samples,
+		Mean:      mean,
+		StdDev:    stddev,
+		Threshold: mean + time.Duration(StdDevFactor*float64(stddev)),
+	}, true
+}
+
+// ConfirmDelay calls measure with every delay in delays (in seconds) and requires each response
+// to exceed the baseline threshold, roughly match the injected sleep (within Tolerance) and grow
+// with it. This filters out one-off slow responses. It returns one confirmation per delay, for
+// evidence.
+func ConfirmDelay(baseline Baseline, delays []int, measure func(delay int) (time.Duration, error)) ([]string, bool) {
+	return Plan{Delays: delays, Tolerance: Tolerance}.Confirm(baseline, measure)
+}
+
+// Plan is how time-based findings are confirmed: the sleeps injected and how far the measured
+// delay may fall short of them.
+type Plan struct {
+	Delays    []int         // Sleeps in seconds, each of which must be reproduced.
+	Tolerance time.Duration // Allowed shortfall between the injected sleep and the measured delay.
+	// Adaptive describes how Delays were derived from the latency of the host; nil when they
+	// were configured.
+	Adaptive *Adaptation
+}
+
+// Adaptation records how an adaptive delay was chosen for a host.
+type Adaptation struct {
+	Factor  float64       // The delay is at least Factor times P95.
+	P95     time.Duration // 95th percentile of the warm-up response times.
+	Samples int           // Warm-up requests measured.
+}
+
+// String describes the plan for finding details and log lines, e.g. "10s and 20s sleeps, 1s
+// tolerance (adaptive: 3.0 × p95 latency 3.2s over 5 requests)".
+func (p Plan) String() string {
+	sleeps := make([]string, len(p.Delays))
+	for i, delay := range p.Delays {
+		sleeps[i] = fmt.Sprintf("%ds", delay)
+	}
+	s := fmt.Sprintf("%s sleeps, %s tolerance", strings.Join(sleeps, " and "), p.Tolerance)
+	if a := p.Adaptive; a != nil {
+		s += fmt.Sprintf(" (adaptive: %.1f × p95 latency %s over %d requests)", a.Factor, a.P95.Round(time.Millisecond), a.Samples)
+	}
+	return s
+}
+
+// Confirm calls measure with every delay of the plan and requires each response to exceed the
+// baseline threshold, to fall at most Tolerance short of the injected sleep and to grow with
+// it. It returns one confirmation per delay, for evidence.
+func (p Plan) Confirm(baseline Baseline, measure func(delay int) (time.Duration, error)) ([]string, bool) {
+	delays := p.Delays
+	var confirmations []string
+	previousDelta := time.Duration(0)
+	for _, delay := range delays {
+		duration, err := measure(delay)
+		if err != nil {
+			return nil, false
+		}
+		delta := duration - baseline.Mean
+		expected := time.Duration(delay)*time.Second - p.Tolerance
+		if duration <= baseline.Threshold || delta < expected || delta <= previousDelta {
+			return nil, false
+		}
+		previousDelta = delta
+		confirmations = append(confirmations, fmt.Sprintf("%ds sleep -> %s", delay, duration.Round(time.Millisecond)))
+	}
+	return confirmations, len(confirmations) > 0
+}
+
+// AdaptiveDelay returns the sleep in seconds for a host whose p95 latency is p95: factor times
+// p95, rounded up, and at least minDelay, capped at MaxAdaptiveDelay (or minDelay if larger).
+// Slow, jittery hosts get a sleep that stands out from their noise; fast ones keep minDelay.
+func AdaptiveDelay(minDelay int, factor float64, p95 time.Duration) int {
+	delay := int(math.Ceil(factor * p95.Seconds()))
+	return max(minDelay, min(delay, MaxAdaptiveDelay))
+}
+
+// Percentile returns the p-th percentile (0-100) of samples by the nearest-rank method.
+func Percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[min(max(rank, 1), len(sorted))-1]
+}
+
+// HostPlans caches the adaptive Plan of each host, so the warm-up requests are sent once per
+// host and scan. The zero value is ready to use; it is safe for concurrent use.
+type HostPlans struct {
+	mu    sync.Mutex
+	hosts map[string]*hostPlan
+}
+
+// hostPlan is the plan of a host, computed once.
+type hostPlan struct {
+	once sync.Once
+	plan Plan
+	ok   bool
+}
+
+// Get returns the plan of host, calling compute the first time the host is asked for; callers
+// asking meanwhile wait for it. It reports false if compute failed, e.g. when the warm-up
+// requests got no response; compute is not called again for the host.
+func (h *HostPlans) Get(host string, compute func() (Plan, bool)) (Plan, bool) {
+	h.mu.Lock()
+	if h.hosts == nil {
+		h.hosts = make(map[string]*hostPlan)
+	}
+	hp, ok := h.hosts[host]
+	if !ok {
+		hp = &hostPlan{}
+		h.hosts[host] = hp
+	}
+	h.mu.Unlock()
+	hp.once.Do(func() { hp.plan, hp.ok = compute() })
+	return hp.plan, hp.ok
+}
+
+// MeasureRequest sends req and returns how long it took to receive the full response, along
+// with the response and its body. The time starts once the client sent the request, so waits for
+// the rate limit or a per-host slot do not count. The request is never retried, which would
+// distort the time.
+func MeasureRequest(client *httpclient.Client, req *http.Request) (time.Duration, *http.Response, []byte, error) {
+	resp, startTime, err := client.DoTimed(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	elapsed := time.Since(startTime)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return elapsed, resp, body, nil
+}
+
+// Stats returns the mean and population standard deviation of a set of durations.
+func Stats(samples []time.Duration) (time.Duration, time.Duration) {
+	if len(samples) == 0 {
+		return 0, 0
+	}
+	var sum float64
+	for _, sample := range samples {
+		sum += float64(sample)
+	}
+	mean := sum / float64(len(samples))
+	var variance float64
+	for _, sample := range samples {
+		variance += (float64(sample) - mean) * (float64(sample) - mean)
+	}
+	variance /= float64(len(samples))
+	return time.Duration(mean), time.Duration(math.Sqrt(variance))
+}
+
+// FormatDurations renders durations as a compact list for evidence strings.
+func FormatDurations(samples []time.Duration) string {
+	parts := make([]string, len(samples))
+	for i, sample := range samples {
+		parts[i] = sample.Round(time.Millisecond).String()
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
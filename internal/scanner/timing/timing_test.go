@@ -0,0 +1,94 @@
+package timing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlanConfirmTolerance(t *testing.T) {
+	baseline := Baseline{Mean: 200 * time.Millisecond, Threshold: 300 * time.Millisecond}
+	// Sleeps measured 1.5s short of the injected 5s and 10s.
+	measure := func(delay int) (time.Duration, error) {
+		return baseline.Mean + time.Duration(delay)*time.Second - 1500*time.Millisecond, nil
+	}
+
+	_, confirmed := ConfirmDelay(baseline, []int{5, 10}, measure)
+	assert.False(t, confirmed, "the default tolerance is 1s")
+
+	confirmations, confirmed := Plan{Delays: []int{5, 10}, Tolerance: 2 * time.Second}.Confirm(baseline, measure)
+	assert.True(t, confirmed)
+	assert.Equal(t, []string{"5s sleep -> 3.7s", "10s sleep -> 8.7s"}, confirmations)
+}
+
+func TestAdaptiveDelay(t *testing.T) {
+	assert.Equal(t, 5, AdaptiveDelay(5, 3, 200*time.Millisecond), "fast hosts keep the minimum")
+	assert.Equal(t, 16, AdaptiveDelay(5, 3, 5100*time.Millisecond))
+	assert.Equal(t, MaxAdaptiveDelay, AdaptiveDelay(5, 3, time.Minute))
+	assert.Equal(t, 40, AdaptiveDelay(40, 3, time.Minute), "the cap never lowers the minimum")
+}
+
+func TestDelays(t *testing.T) {
+	assert.Equal(t, []int{5, 10}, Delays(5, 0))
+	assert.Equal(t, []int{3, 6, 9}, Delays(3, 3))
+	assert.Equal(t, []int{DefaultDelays[0]}, Delays(0, 1))
+}
+
+func TestPercentile(t *testing.T) {
+	samples := []time.Duration{5, 1, 4, 2, 3, 10, 9, 8, 7, 6}
+	assert.Equal(t, time.Duration(10), Percentile(samples, 95))
+	assert.Equal(t, time.Duration(5), Percentile(samples, 50))
+	assert.Equal(t, time.Duration(1), Percentile(samples, 0))
+	assert.Zero(t, Percentile(nil, 95))
+}
+
+func TestHostPlansComputeOncePerHost(t *testing.T) {
+	var plans HostPlans
+	calls := 0
+	compute := func() (Plan, bool) {
+		calls++
+		return Plan{Delays: []int{calls}}, calls == 1
+	}
+
+	plan, ok := plans.Get("a.example", compute)
+	assert.True(t, ok)
+	assert.Equal(t, []int{1}, plan.Delays)
+	plan, _ = plans.Get("a.example", compute)
+	assert.Equal(t, []int{1}, plan.Delays)
+
+	_, ok = plans.Get("b.example", compute)
+	assert.False(t, ok)
+	_, ok = plans.Get("b.example", compute)
+	assert.False(t, ok, "a failed host is not computed again")
+	assert.Equal(t, 2, calls)
+}
+
+func TestMeasureRequestExcludesRateLimitWait(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	client := httpclient.NewClient(logger.NewLogger(logger.ERROR), httpclient.ClientOptions{Timeout: 5 * time.Second})
+	client.SetRateLimit(2)
+	for i := 0; i < 2; i++ { // Use up the burst.
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	req, err := http.NewRequest("GET", server.URL, nil)
+	require.NoError(t, err)
+	start := time.Now()
+	elapsed, _, body, err := MeasureRequest(client, req)
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.GreaterOrEqual(t, time.Since(start), 300*time.Millisecond, "the request waited for the rate limit")
+	assert.Less(t, elapsed, 200*time.Millisecond, "the wait is not counted as server time")
+}
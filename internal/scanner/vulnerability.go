@@ -1,8 +1,11 @@
 package scanner
 
 import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/fingerprint"
 	"Dursgo/internal/httpclient"
 	"Dursgo/internal/renderer"
+	"Dursgo/internal/soft404"
 	"sync"
 )
 
@@ -14,12 +17,43 @@ type VulnerabilityResult struct {
 	Location          string                 `json:"Location,omitempty"`
 	Details           string                 `json:"Details"`
 	Severity          string                 `json:"severity,omitempty"`
+	Confidence        string                 `json:"confidence,omitempty"` // Certain, Firm or Tentative; Classify sets Firm unless the scanner set it.
 	Evidence          string                 `json:"evidence,omitempty"`
 	Remediation       string                 `json:"remediation,omitempty"`
 	ScannerName       string                 `json:"scanner_name,omitempty"`
 	CVE               string                 `json:"cve,omitempty"`
+	CWE               string                 `json:"cwe,omitempty"`         // Set by Classify unless the scanner set it.
+	CVSSVector        string                 `json:"cvss_vector,omitempty"` // CVSS v3.1 base vector; scanners adjust it with SetCVSSMetrics.
+	CVSSScore         float64                `json:"cvss_score,omitempty"`  // Base score of CVSSVector, computed by Classify.
+	Fingerprint       string                 `json:"fingerprint,omitempty"` // Set when findings are aggregated for the report.
+	AffectedURLs      []string               `json:"affected_urls,omitempty"`
+	Occurrences       int                    `json:"occurrences,omitempty"` // len(AffectedURLs).
+	DiffStatus        string                 `json:"diff_status,omitempty"` // "new" or "existing" when compared with a baseline scan.
 	Enrichment        map[string]interface{} `json:"enrichment,omitempty"`
 	AIAnalysis        string                 `json:"ai_analysis,omitempty"`
+	// RawRequest and RawResponse hold the exact exchange that produced the finding, when the
+	// scanner captured it. RawResponse is truncated to ScannerOptions.MaxRawResponseBytes and
+	// base64-encoded (RawResponseBase64) when the body is binary.
+	RawRequest           string `json:"raw_request,omitempty"`
+	RawResponse          string `json:"raw_response,omitempty"`
+	RawResponseBase64    bool   `json:"raw_response_base64,omitempty"`
+	RawResponseTruncated bool   `json:"raw_response_truncated,omitempty"`
+	// Reproduction lets "dursgo verify" replay the finding and re-run its detection check, for
+	// scanners that record one.
+	Reproduction *Reproduction `json:"reproduction,omitempty"`
+	// SuppressedBy is the suppression rule (-suppressions) that matched the finding; suppressed
+	// findings are reported apart and do not affect the exit status.
+	SuppressedBy string `json:"suppressed_by,omitempty"`
+	// OverriddenBy is the severity override (severity_overrides in config.yaml) applied to the
+	// finding, and OriginalSeverity the severity the scanner reported when it was changed.
+	OverriddenBy     string `json:"overridden_by,omitempty"`
+	OriginalSeverity string `json:"original_severity,omitempty"`
+	// Transformation is the evasion transformation (-evasion) of the payload that produced the
+	// finding; empty for an original payload.
+	Transformation string `json:"transformation,omitempty"`
+	// ActiveExploitation describes how the finding was exploited beyond detection to prove it
+	// (-poc-extraction), e.g. the value read from the database is in Evidence.
+	ActiveExploitation string `json:"active_exploitation,omitempty"`
 }
 
 type ScannerOptions struct {
@@ -27,9 +61,132 @@ type ScannerOptions struct {
 	OASTDomain         string
 	OASTCorrelationMap *sync.Map
 	Fingerprint        map[string]string
-	UserID             int
-	Renderer           *renderer.Renderer
-	Client             *httpclient.Client
-	GraphQLEndpoint    string
-	Config             map[string]interface{} `json:"config,omitempty"`
+	// Technologies is the target stack identified by fingerprinting; scanners use it to try the
+	// payloads of the detected stack first or to skip checks that cannot apply.
+	Technologies    fingerprint.Technologies
+	UserID          int
+	Renderer        *renderer.Renderer
+	Client          *httpclient.Client
+	GraphQLEndpoint string
+	// TimeBasedBaselineSamples is the number of baseline requests used to model normal response
+	// times before time-based tests. Zero uses the scanner's default.
+	TimeBasedBaselineSamples int
+	// InjectHeaders enables header and cookie injection points (User-Agent, Referer,
+	// X-Forwarded-For and session cookies) in scanners that support them.
+	InjectHeaders bool
+	// PoCExtraction lets scanners exploit confirmed blind injections to read a short, harmless
+	// proof value (the database version or user) into the evidence. It is active exploitation.
+	PoCExtraction bool
+	// OOBCollaboratorURL is the base URL of the out-of-band collaborator (e.g.,
+	// "http://abc123.oast.fun"). Scanners derive unique per-parameter hosts from it and store
+	// potential findings in OASTCorrelationMap. Empty disables out-of-band tests.
+	OOBCollaboratorURL string
+	// SimilarityThreshold is the similarity (0-1) below which two responses count as different
+	// in differential tests. Zero uses the scanner's default (0.95).
+	SimilarityThreshold float64
+	// SimilarityMode selects how responses are compared: "levenshtein" (default), "structure"
+	// (HTML tag sequence) or "words" (word-set overlap).
+	SimilarityMode string
+	// MaxRequestsPerParam caps the requests a scanner may send while testing one parameter;
+	// remaining payloads are skipped once it is reached. Zero means unlimited.
+	MaxRequestsPerParam int
+	// PayloadTier trims the payload lists of scanners that support it (see TrimPayloads). Empty
+	// sends every payload.
+	PayloadTier PayloadTier
+	// Evasion expands the payload lists of the SQL injection, XSS and command injection
+	// scanners with encoded variants (see Expand). The zero Evasion sends payloads as they are.
+	Evasion Evasion
+	// TimeConfirmations is the number of growing sleeps every time-based finding is confirmed
+	// with (see timing.Delays). Zero uses timing.DefaultConfirmations.
+	TimeConfirmations int
+	// RequestsPerSecond limits the request rate shared by all scanners. Zero means unlimited.
+	RequestsPerSecond float64
+	// MaxRawResponseBytes is the size raw responses in findings are truncated to. Zero uses
+	// DefaultMaxRawResponseBytes; a negative value disables raw request/response capture.
+	MaxRawResponseBytes int
+	// ForcePrototypePollution runs the prototype pollution scanner against targets that are not
+	// fingerprinted as Node.js.
+	ForcePrototypePollution bool
+	// CSRFTokens refreshes anti-CSRF tokens before each request a scanner sends for a form that
+	// carries one. Nil sends the tokens captured by the crawler.
+	CSRFTokens *TokenRefresher
+	// Progress skips the scanner/request pairs completed by an earlier run and records the pairs
+	// completed by this one. Nil tests every pair.
+	Progress ProgressTracker
+	// Findings receives the findings of each scanner/request pair and passive scanner as soon as
+	// they are available. Nil only collects them for the final report.
+	Findings FindingSink
+	// Status is told about the work items of Manager.RunScans and the findings of all scanners
+	// as they complete. Nil reports no progress.
+	Status StatusReporter
+	// Timings is told how long each test of the scan took and what it found, e.g. for the scan
+	// statistics. Nil records nothing.
+	Timings ScanObserver
+	// Scope restricts the requests scanned by Manager.RunScans. Requests whose URL is out of
+	// scope are skipped. Nil scans every request.
+	Scope *crawler.Scope
+	// SkipRules are the parameters and paths excluded from testing. Nil skips nothing.
+	SkipRules *SkipRules
+	// InertParams are the parameters the inert-parameter pre-flight found to have no effect on
+	// their response; Manager.RunScans leaves them out for most modules. Nil tests every
+	// parameter.
+	InertParams *InertParams
+	// Soft404 recognizes the "not found" pages hosts answer unknown paths with (200 or a
+	// redirect), which scanners must not take for content. Nil recognizes none.
+	Soft404 *soft404.Detector
+	// Coverage records whether each parameter was tested by each module, or why it was skipped.
+	// Modules report the parameters they skip with Coverage.Skip. Nil records nothing.
+	Coverage *Coverage
+	// Overrides re-rate the findings of the scan and append remediation guidance (severity_overrides
+	// in config.yaml); Manager applies them after Classify. Nil changes nothing.
+	Overrides *Overrides
+	// SecondSessionCookie and SecondSessionHeaders authenticate a second user (user B) for
+	// cross-session access control checks; the scan's own session is user A. Both empty
+	// disables the cross-session replay.
+	SecondSessionCookie  string
+	SecondSessionHeaders map[string]string
+	// StoredMarkers records the markers the stored XSS module submits to writable parameters,
+	// which are looked for on every page once the active phase is over, and the content the scan
+	// created on the target. Nil submits no markers.
+	StoredMarkers *StoredMarkers
+	// ModuleOptions holds the options of each scanner module, keyed by module name and option
+	// name, as resolved by Resolve from the defaults and the scanners section of config.yaml.
+	ModuleOptions map[string]map[string]interface{}
+}
+
+// IntOption returns an int option of a scanner module, or fallback when it is not set.
+func (o ScannerOptions) IntOption(module, name string, fallback int) int {
+	if v, ok := o.ModuleOptions[module][name].(int); ok {
+		return v
+	}
+	return fallback
+}
+
+// FloatOption returns a float option of a scanner module, or fallback when it is not set.
+func (o ScannerOptions) FloatOption(module, name string, fallback float64) float64 {
+	if v, ok := o.ModuleOptions[module][name].(float64); ok {
+		return v
+	}
+	return fallback
+}
+
+// StringOption returns a string option of a scanner module, or fallback when it is not set.
+func (o ScannerOptions) StringOption(module, name, fallback string) string {
+	if v, ok := o.ModuleOptions[module][name].(string); ok {
+		return v
+	}
+	return fallback
+}
+
+// SkipParam reports whether a skip rule excludes the parameter name from the tests of module.
+func (o ScannerOptions) SkipParam(module, name string) bool {
+	return o.SkipRules.SkipParam(module, name)
+}
+
+// BoolOption returns a bool option of a scanner module, or fallback when it is not set.
+func (o ScannerOptions) BoolOption(module, name string, fallback bool) bool {
+	if v, ok := o.ModuleOptions[module][name].(bool); ok {
+		return v
+	}
+	return fallback
 }
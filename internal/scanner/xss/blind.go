@@ -0,0 +1,137 @@
+package xss
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/oob"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/requtil"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BlindModuleName selects the scanner (-s) and holds its options in config.yaml.
+const BlindModuleName = "xss-blind"
+
+// BlindScannerName is the name of the blind XSS scanner. The OAST correlation map entries of its
+// potential findings carry it as ScannerName.
+const BlindScannerName = "Blind XSS Scanner"
+
+// --- Blind XSS Scanner ---
+
+// BlindXSSScanner injects payloads that make a browser request the OOB collaborator, for input
+// that is stored and rendered elsewhere (an administration panel, a support ticket view, a log
+// viewer), possibly long after the scan. Like the other out-of-band tests, a potential finding
+// is stored in the OAST correlation map and only reported once its callback arrives.
+type BlindXSSScanner struct{}
+
+// NewBlindXSSScanner creates a new instance of BlindXSSScanner.
+func NewBlindXSSScanner() scanner.Scanner { return &BlindXSSScanner{} }
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           BlindModuleName,
+		Order:          25,
+		DefaultEnabled: true,
+		Requires:       scanner.RequiresOAST,
+		// Stored input usually leaves the response of the request that submitted it unchanged.
+		TestsInertParams: true,
+		Options: []scanner.OptionSpec{
+			{Name: "save_pending", Type: scanner.OptionBool, Default: true, Description: "Save the injections whose callback has not arrived to the state file, so that a run with -resume keeps waiting for them"},
+		},
+		New: func(scanner.Env) scanner.Scanner { return NewBlindXSSScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *BlindXSSScanner) Name() string { return BlindScannerName }
+
+// Scan injects the blind XSS payloads into every parameter of req. All payloads of a parameter
+// share one correlation ID, so a callback identifies the URL and parameter it was injected into.
+func (s *BlindXSSScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	if opts.OOBCollaboratorURL == "" || opts.OASTCorrelationMap == nil {
+		return nil, nil
+	}
+	originalParams, err := requtil.Params(req)
+	if err != nil {
+		return nil, nil
+	}
+
+	paramNames := req.ParamNames
+	if req.IsJSON() && len(paramNames) == 0 {
+		paramNames = requtil.JSONParamNames(req.RawBody)
+	}
+	if opts.InjectHeaders {
+		// User-Agent and Referer end up in access logs and analytics dashboards.
+		req = requtil.AddHeaderInjectionPoints(req, client)
+		originalParams, _ = requtil.Params(req)
+		paramNames = append(append([]string{}, paramNames...), requtil.InjectionPointNames(req)...)
+	}
+	templates := scanner.TrimPayloads(opts.PayloadTier, payloads.GetBlindXSSPayloads())
+
+	for _, paramName := range paramNames {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		displayName := requtil.DisplayName(paramName)
+		if opts.SkipParam(BlindModuleName, displayName) {
+			opts.Coverage.Skip(BlindModuleName, req, paramName, scanner.SkipReasonRule)
+			continue
+		}
+
+		correlationID := oob.NewCorrelationID("bxss", displayName)
+		callbackURL := oob.PayloadURL(opts.OOBCollaboratorURL, correlationID)
+		injected := make([]string, 0, len(templates))
+		for _, template := range templates {
+			injected = append(injected, strings.ReplaceAll(template, "{URL}", callbackURL))
+		}
+		injectedAt := time.Now().UTC()
+
+		// Stored before sending: the callback may arrive while the request is still running.
+		opts.OASTCorrelationMap.Store(correlationID, scanner.VulnerabilityResult{
+			VulnerabilityType: "Blind XSS",
+			URL:               req.URL,
+			Parameter:         displayName,
+			Payload:           strings.Join(injected, "\n"),
+			Location:          requtil.Location(req, paramName),
+			Details: fmt.Sprintf("A payload injected into '%s' of %s %s at %s was rendered by a browser, which requested the collaborator. The input is stored and displayed unencoded to another user, typically in an administration or back-office page.",
+				displayName, req.Method, req.URL, injectedAt.Format(time.RFC3339)),
+			Severity:    "Critical",
+			Confidence:  scanner.ConfidenceCertain, // Reported only once the interaction arrives.
+			Evidence:    fmt.Sprintf("Correlation ID: %s.", correlationID),
+			Remediation: "Encode stored input for the context it is rendered in on every page that displays it, including internal and administration pages, and set a restrictive Content-Security-Policy on them.",
+			ScannerName: s.Name(),
+		})
+
+		sent, exhausted := false, false
+		for _, payload := range injected {
+			testParams := requtil.Copy(originalParams)
+			testParams.Set(paramName, payload)
+			_, _, err := requtil.Send(ctx, req, client, testParams)
+			if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+				exhausted = true
+				break
+			}
+			if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
+				log.Debug("[%s] Request failed for '%s': %v", s.Name(), paramName, err)
+				continue
+			}
+			sent = true
+		}
+		if !sent {
+			opts.OASTCorrelationMap.Delete(correlationID) // Never sent, so it can never be confirmed.
+		} else {
+			log.Debug("[%s] Injected %d payload(s) into '%s' of %s (correlation ID %s)", s.Name(), len(injected), displayName, req.URL, correlationID)
+		}
+		if exhausted {
+			return nil, nil
+		}
+	}
+	return nil, nil
+}
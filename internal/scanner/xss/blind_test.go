@@ -0,0 +1,72 @@
+package xss
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlindXSSScanStoresOneCorrelationPerParameter(t *testing.T) {
+	var mu sync.Mutex
+	var received []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		received = append(received, r.URL.Query().Get("comment"))
+		mu.Unlock()
+		w.Write([]byte("Thanks, your comment awaits moderation."))
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	req := crawler.ParameterizedRequest{
+		Method:     "GET",
+		URL:        server.URL + "/feedback?comment=hi",
+		ParamNames: []string{"comment"},
+	}
+	correlationMap := &sync.Map{}
+	opts := scanner.ScannerOptions{OOBCollaboratorURL: "http://oob.example.com", OASTCorrelationMap: correlationMap}
+
+	findings, err := NewBlindXSSScanner().Scan(context.Background(), req, client, log, opts)
+	require.NoError(t, err)
+	assert.Empty(t, findings, "blind XSS is only reported once the callback arrives")
+
+	var ids []string
+	correlationMap.Range(func(key, value any) bool {
+		ids = append(ids, key.(string))
+		finding := value.(scanner.VulnerabilityResult)
+		assert.Equal(t, "Blind XSS", finding.VulnerabilityType)
+		assert.Equal(t, "comment", finding.Parameter)
+		assert.Equal(t, BlindScannerName, finding.ScannerName)
+		return true
+	})
+	require.Len(t, ids, 1)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.NotEmpty(t, received)
+	for _, value := range received {
+		assert.True(t, strings.Contains(value, ids[0]), "every payload carries the correlation ID: %s", value)
+	}
+}
+
+func TestBlindXSSScanWithoutCollaborator(t *testing.T) {
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{})
+	req := crawler.ParameterizedRequest{Method: "GET", URL: "http://127.0.0.1:1/?q=1", ParamNames: []string{"q"}}
+
+	findings, err := NewBlindXSSScanner().Scan(context.Background(), req, client, log, scanner.ScannerOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, findings)
+}
@@ -0,0 +1,294 @@
+package xss
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/requtil"
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"math/rand"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// StoredModuleName is the name of the stored XSS module, which submits the markers looked for
+// by RetrieveStored.
+const StoredModuleName = "xss-stored"
+
+// defaultMaxStoredPages is the default of the max_pages option of the stored XSS module.
+const defaultMaxStoredPages = 300
+
+// storedMarkerRegex matches the markers submitted by submitMarkers.
+var storedMarkerRegex = regexp.MustCompile(regexp.QuoteMeta(payloads.StoredXSSMarker) + `[0-9a-f]{10}`)
+
+// newStoredMarker returns a unique marker for a writable parameter.
+func newStoredMarker() string {
+	return fmt.Sprintf("%s%010x", payloads.StoredXSSMarker, rand.Int63n(1<<40))
+}
+
+// isWritable reports whether req may store what it submits: a form or API request other than GET.
+func isWritable(req crawler.ParameterizedRequest) bool {
+	switch req.Method {
+	case "POST", "PUT", "PATCH":
+		return true
+	}
+	return false
+}
+
+// acceptsText reports whether the parameter name of value may store a marker. Numbers (ids,
+// quantities), anti-CSRF tokens and file fields are left alone.
+func acceptsText(req crawler.ParameterizedRequest, name, value string, opts scanner.ScannerOptions) bool {
+	if opts.CSRFTokens.IsTokenField(name) {
+		return false
+	}
+	for _, ignored := range payloads.IgnoredParams {
+		if strings.EqualFold(name, ignored) {
+			return false
+		}
+	}
+	for _, field := range req.MultipartFields {
+		if field.Name == name && field.IsFile {
+			return false
+		}
+	}
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return false
+	}
+	return true
+}
+
+// submitMarkers submits a unique marker to every text parameter of a writable request, one
+// parameter at a time, and records it in opts.StoredMarkers. It returns ctx.Err() when the scan
+// is cancelled.
+func (s *StoredXSSScanner) submitMarkers(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) error {
+	if !isWritable(req) {
+		return nil
+	}
+	originalParams, err := requtil.Params(req)
+	if err != nil {
+		return nil
+	}
+	paramNames := req.ParamNames
+	if req.IsJSON() && len(paramNames) == 0 {
+		paramNames = requtil.JSONParamNames(req.RawBody)
+	}
+
+	for _, paramName := range paramNames {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if opts.SkipParam(StoredModuleName, paramName) {
+			opts.Coverage.Skip(StoredModuleName, req, paramName, scanner.SkipReasonRule)
+			continue
+		}
+		original := originalParams.Get(paramName)
+		if !acceptsText(req, paramName, original, opts) {
+			continue
+		}
+		marker := newStoredMarker()
+		value := marker
+		if strings.Contains(original, "@") || strings.Contains(strings.ToLower(paramName), "email") {
+			value = marker + "@example.com" // Keep e-mail validation happy.
+		}
+		testParams := requtil.Copy(originalParams)
+		testParams.Set(paramName, value)
+		_, _, err := requtil.Send(ctx, req, client, testParams)
+		if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			return nil
+		}
+		if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
+			log.Debug("[%s] Marker submission failed for '%s': %v", StoredModuleName, paramName, err)
+			continue
+		}
+		opts.StoredMarkers.Add(scanner.StoredMarker{Marker: marker, Request: req, Param: paramName, SubmittedAt: time.Now().UTC()})
+		log.Debug("[%s] Submitted marker %s to '%s' of %s %s", StoredModuleName, marker, paramName, req.Method, req.URL)
+	}
+	return nil
+}
+
+// storedHit is a page displaying a marker.
+type storedHit struct {
+	page string
+	body string
+}
+
+// RetrieveStored is the stored XSS phase, run once the active phase is over. It fetches the
+// pages the write points were found on and the crawled HTML pages again, up to the max_pages
+// option of the stored XSS module, and looks for the markers of opts.StoredMarkers. For each
+// marker found, the XSS payloads of the contexts it is displayed in are submitted at its write
+// point, and a payload rendered unencoded on the page it was found on is reported as Stored XSS
+// with both the write request and the read URL.
+func RetrieveStored(ctx context.Context, responses []crawler.CrawledResponse, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) []scanner.VulnerabilityResult {
+	client = client.WithContext(ctx)
+	markers := opts.StoredMarkers.Markers()
+	if len(markers) == 0 {
+		return nil
+	}
+	maxPages := opts.IntOption(StoredModuleName, "max_pages", defaultMaxStoredPages)
+	// The page a form was found on often lists what it submits, so it comes first.
+	var pages []string
+	for _, marker := range markers {
+		if marker.Request.SourceURL != "" {
+			pages = append(pages, marker.Request.SourceURL)
+		}
+	}
+	for _, resp := range responses {
+		if resp.StatusCode == http.StatusOK && strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+			pages = append(pages, resp.URL)
+		}
+	}
+	seen := make(map[string]bool)
+	hits := make(map[string][]storedHit) // Marker -> pages displaying it.
+	fetched := 0
+	for _, page := range pages {
+		if ctx.Err() != nil || fetched >= maxPages {
+			break
+		}
+		if seen[page] {
+			continue
+		}
+		seen[page] = true
+		fetched++
+		body, ok := fetchPage(ctx, client, page)
+		if !ok {
+			continue
+		}
+		for _, marker := range storedMarkerRegex.FindAllString(body, -1) {
+			if _, known := opts.StoredMarkers.Lookup(marker); !known {
+				continue // Submitted by another scan.
+			}
+			opts.StoredMarkers.MarkShown(marker, page)
+			if n := len(hits[marker]); n == 0 || hits[marker][n-1].page != page {
+				hits[marker] = append(hits[marker], storedHit{page: page, body: body})
+			}
+		}
+	}
+	log.Info("Stored XSS: %d of %d marker(s) found on %d page(s) fetched again.", len(hits), len(markers), fetched)
+
+	var findings []scanner.VulnerabilityResult
+	for _, marker := range markers {
+		for _, hit := range hits[marker.Marker] {
+			if ctx.Err() != nil {
+				return findings
+			}
+			if vuln, found := confirmStored(ctx, marker, hit, client, log, opts); found {
+				findings = append(findings, vuln)
+				break // One finding per write point.
+			}
+		}
+	}
+	return findings
+}
+
+// fetchPage returns the body of page.
+func fetchPage(ctx context.Context, client *httpclient.Client, page string) (string, bool) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", page, nil)
+	if err != nil {
+		return "", false
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return "", false
+	}
+	defer resp.Body.Close()
+	body, err := requtil.ReadBody(client, resp)
+	if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
+		return "", false
+	}
+	return string(body), true
+}
+
+// confirmStored submits the XSS payloads of the contexts marker is displayed in on hit.page at
+// the write point of marker, and returns the finding of the first payload rendered unencoded.
+func confirmStored(ctx context.Context, marker scanner.StoredMarker, hit storedHit, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) (scanner.VulnerabilityResult, bool) {
+	contexts := make(map[string]bool)
+	for offset := 0; ; {
+		i := strings.Index(hit.body[offset:], marker.Marker)
+		if i == -1 {
+			break
+		}
+		contexts[classifyReflection(hit.body, offset+i, len(marker.Marker))] = true
+		offset += i + len(marker.Marker)
+	}
+	log.Info("Stored XSS: marker of '%s' (%s %s) displayed at %s. Submitting payloads...", marker.Param, marker.Request.Method, marker.Request.URL, hit.page)
+
+	originalParams, err := requtil.Params(marker.Request)
+	if err != nil {
+		return scanner.VulnerabilityResult{}, false
+	}
+	writeClient := opts.CSRFTokens.Bind(client, marker.Request)
+	tried := make(map[string]int) // Payloads sent per context, limited by the payload tier.
+	for _, testCase := range payloads.XSSTests {
+		if !contexts[testCase.Context] || !opts.PayloadTier.Keeps(tried[testCase.Context]) {
+			continue
+		}
+		tried[testCase.Context]++
+
+		uniqueMarker := fmt.Sprintf("%s%d", payloads.XSSMarker, rand.Intn(1e9))
+		payload := strings.ReplaceAll(testCase.PayloadTemplate, "DURSGO_MARKER", uniqueMarker)
+		detectionRegex, err := regexp.Compile(strings.ReplaceAll(testCase.DetectionRegex, "DURSGO_MARKER", uniqueMarker))
+		if err != nil {
+			continue
+		}
+		testParams := requtil.Copy(originalParams)
+		testParams.Set(marker.Param, payload)
+		writeReq, writeResp, writeBody, err := requtil.Do(ctx, marker.Request, writeClient, testParams)
+		if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			break
+		}
+		if writeResp == nil {
+			continue
+		}
+		opts.StoredMarkers.AddContent(scanner.StoredContent{Method: marker.Request.Method, URL: marker.Request.URL, Param: marker.Param, Value: payload, SubmittedAt: time.Now().UTC()})
+
+		readReq, err := http.NewRequestWithContext(ctx, "GET", hit.page, nil)
+		if err != nil {
+			continue
+		}
+		readResp, err := client.Do(readReq)
+		if err != nil {
+			continue
+		}
+		readBody, err := requtil.ReadBody(client, readResp)
+		readResp.Body.Close()
+		if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
+			continue
+		}
+		found, evidence := verifyXSS(readBody, detectionRegex, testCase.PayloadTemplate)
+		if !found {
+			continue
+		}
+		if snippet := reflectionSnippet(html.UnescapeString(string(readBody)), evidence); snippet != "" {
+			evidence = snippet
+		}
+		log.Success("Stored XSS: payload submitted to '%s' of %s rendered unencoded at %s", marker.Param, marker.Request.URL, hit.page)
+
+		vuln := scanner.VulnerabilityResult{
+			VulnerabilityType: "Stored XSS",
+			URL:               hit.page,
+			Parameter:         marker.Param,
+			Payload:           payload,
+			Location:          requtil.Location(marker.Request, marker.Param),
+			Details: fmt.Sprintf("Write request: %s %s, parameter '%s'. Read URL: %s. A marker submitted to the parameter during the scan was displayed on the read URL, and a payload submitted the same way was rendered there unencoded in a '%s' context, so it runs in the browser of every user viewing the page. The raw request is the submission, the raw response the read URL. Description: %s",
+				marker.Request.Method, marker.Request.URL, marker.Param, hit.page, testCase.Context, testCase.Description),
+			Severity:    "High",
+			Evidence:    evidence,
+			Remediation: "Encode stored input for the context it is rendered in on every page that displays it, and validate it when it is submitted. Remove the content submitted by the scan (see stored_content in the report).",
+			ScannerName: StoredModuleName,
+		}
+		vuln.SetExchange(scanner.Exchange{
+			Request:  scanner.CaptureExchange(writeReq, writeResp, writeBody).Request,
+			Response: scanner.CaptureExchange(readReq, readResp, readBody).Response,
+		})
+		return vuln, true
+	}
+	return scanner.VulnerabilityResult{}, false
+}
@@ -0,0 +1,86 @@
+package xss
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// guestbook stores the comments posted to /sign and lists them unencoded on /.
+func guestbook() *httptest.Server {
+	var mu sync.Mutex
+	var comments []string
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "text/html")
+		if r.Method == "POST" {
+			comments = append(comments, r.FormValue("comment"))
+			w.Write([]byte("Thanks!"))
+			return
+		}
+		w.Write([]byte("<html><body><ul><li>" + strings.Join(comments, "</li><li>") + "</li></ul></body></html>"))
+	}))
+}
+
+func TestStoredXSSCorrelatesWriteAndReadPoints(t *testing.T) {
+	server := guestbook()
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	req := crawler.ParameterizedRequest{
+		Method:       "POST",
+		URL:          server.URL + "/sign",
+		ParamNames:   []string{"comment", "rating"},
+		FormPostData: "comment=hello&rating=5",
+		SourceURL:    server.URL + "/",
+	}
+	opts := scanner.ScannerOptions{StoredMarkers: scanner.NewStoredMarkers()}
+
+	require.NoError(t, (&StoredXSSScanner{}).submitMarkers(context.Background(), req, client, log, opts))
+	markers := opts.StoredMarkers.Markers()
+	require.Len(t, markers, 1, "numeric parameters are left alone")
+	assert.Equal(t, "comment", markers[0].Param)
+
+	findings := RetrieveStored(context.Background(), nil, client, log, opts)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "Stored XSS", findings[0].VulnerabilityType)
+	assert.Equal(t, server.URL+"/", findings[0].URL, "the finding is reported at the read URL")
+	assert.Contains(t, findings[0].Details, "Write request: POST "+server.URL+"/sign, parameter 'comment'")
+	assert.Equal(t, StoredModuleName, findings[0].ScannerName)
+
+	content := opts.StoredMarkers.Content()
+	require.GreaterOrEqual(t, len(content), 2, "the marker and the confirmation payload are listed for cleanup")
+	assert.Equal(t, []string{server.URL + "/"}, content[0].ShownAt)
+}
+
+func TestRetrieveStoredIgnoresUnknownMarkers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<p>dursgostored00000000ff</p>"))
+	}))
+	defer server.Close()
+
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+	opts := scanner.ScannerOptions{StoredMarkers: scanner.NewStoredMarkers()}
+	opts.StoredMarkers.Add(scanner.StoredMarker{
+		Marker:  "dursgostored0000000001",
+		Request: crawler.ParameterizedRequest{Method: "POST", URL: server.URL + "/sign", SourceURL: server.URL + "/"},
+		Param:   "comment",
+	})
+
+	assert.Empty(t, RetrieveStored(context.Background(), nil, client, log, opts))
+	assert.Empty(t, opts.StoredMarkers.Content()[0].ShownAt)
+}
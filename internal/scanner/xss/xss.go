@@ -6,6 +6,10 @@ import (
 	"Dursgo/internal/logger"
 	"Dursgo/internal/payloads"
 	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/nested"
+	"Dursgo/internal/scanner/requtil"
+	"context"
+	"errors"
 	"fmt"
 	"html"
 	"io"
@@ -23,11 +27,20 @@ type ReflectedXSSScanner struct{}
 
 func NewReflectedXSSScanner() scanner.Scanner { return &ReflectedXSSScanner{} }
 
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "xss-reflected",
+		Order:          10,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewReflectedXSSScanner() },
+	})
+}
+
 func (s *ReflectedXSSScanner) Name() string { return "xss-reflected" }
 
 // verifyXSS checks for XSS vulnerabilities with improved false positive detection.
 // It returns true if a vulnerability is found, along with the evidence.
-func (s *ReflectedXSSScanner) verifyXSS(body []byte, detectionRegex *regexp.Regexp, payloadTemplate string) (bool, string) {
+func verifyXSS(body []byte, detectionRegex *regexp.Regexp, payloadTemplate string) (bool, string) {
 	rawBody := string(body)
 	decodedBody := html.UnescapeString(rawBody)
 
@@ -54,91 +67,154 @@ func (s *ReflectedXSSScanner) verifyXSS(body []byte, detectionRegex *regexp.Rege
 	return true, evidence
 }
 
-func (s *ReflectedXSSScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+// contextSeverity rates a confirmed reflection by its context. Inside a script block the
+// payload executes as soon as the page loads without injecting any markup; in a text node,
+// attribute or URL it depends on new markup, an event or a click surviving other defenses.
+var contextSeverity = map[string]string{
+	"JS":        "High",
+	"HTML":      "Medium",
+	"Attribute": "Medium",
+	"URL":       "Medium",
+}
+
+// snippetRadius is the number of characters kept around a reflection in Evidence.
+const snippetRadius = 60
+
+func (s *ReflectedXSSScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
 	var findings []scanner.VulnerabilityResult
 	rand.Seed(time.Now().UnixNano())
 
 	log.Debug("[%s] Processing request: %s %s", s.Name(), req.Method, req.URL)
 
 	for _, paramName := range req.ParamNames {
+		if opts.SkipParam("xss-reflected", paramName) {
+			continue
+		}
 		for _, paramLoc := range req.ParamLocations {
 			if !((req.Method == "GET" && paramLoc == "query") || (req.Method == "POST" && paramLoc == "form")) {
 				continue
 			}
+			// The value itself, then the strings nested in a JSON or base64 value.
+			for _, target := range injectionTargets(req, paramName) {
+				if ctx.Err() != nil {
+					return findings, ctx.Err()
+				}
+				if vuln, found := s.testTarget(ctx, req, client, log, target, paramLoc, opts.PayloadTier, opts.Evasion); found {
+					findings = append(findings, vuln)
+					break
+				}
+			}
+		}
+	}
+	return findings, nil
+}
+
+// xssPayload is an XSS test with the template injected for it, which evasion transformations
+// rewrite while the test keeps the original template for verification.
+type xssPayload struct {
+	test     payloads.XSSTest
+	template string
+}
+
+// testTarget injects the XSS payloads matching the reflection contexts of target, and their
+// evasion variants, and returns the first verified finding.
+func (s *ReflectedXSSScanner) testTarget(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, target nested.Point, paramLoc string, tier scanner.PayloadTier, evasion scanner.Evasion) (scanner.VulnerabilityResult, bool) {
+	detectedContexts := detectReflectionContexts(ctx, req, target, client, log)
+	if len(detectedContexts) == 0 {
+		return scanner.VulnerabilityResult{}, false
+	}
+
+	tests := make([]xssPayload, 0, len(payloads.XSSTests))
+	for _, testCase := range payloads.XSSTests {
+		tests = append(tests, xssPayload{test: testCase, template: testCase.PayloadTemplate})
+	}
+	variants := scanner.Expand(evasion, payloads.PayloadXSS, tests, func(p xssPayload, transform func(string) string) xssPayload {
+		p.template = transform(p.template)
+		return p
+	})
 
-			detectedContexts := detectReflectionContexts(req, paramName, client, log)
-			if len(detectedContexts) == 0 {
+	tried := make(map[string]int) // Payloads sent per context and transformation, limited by the payload tier.
+	for _, variant := range variants {
+		testCase := variant.Payload.test
+		if _, contextMatch := detectedContexts[testCase.Context]; !contextMatch {
+			continue
+		}
+		key := testCase.Context + "/" + variant.Transformation
+		if !tier.Keeps(tried[key]) {
+			continue
+		}
+		tried[key]++
+
+		uniqueMarker := fmt.Sprintf("%s%d", payloads.XSSMarker, rand.Intn(1e9))
+		payload := strings.Replace(variant.Payload.template, "DURSGO_MARKER", uniqueMarker, -1)
+		detectionRegexStr := strings.Replace(testCase.DetectionRegex, "DURSGO_MARKER", uniqueMarker, -1)
+		detectionRegex, _ := regexp.Compile(detectionRegexStr)
+
+		httpRequest, resp, bodyBytes, err := sendRequest(ctx, req, target.Param, target.Inject(payload), client)
+		if err != nil {
+			continue
+		}
+
+		// Pass the payload template to the verification function for more accurate checking.
+		if found, evidence := verifyXSS(bodyBytes, detectionRegex, testCase.PayloadTemplate); found {
+			contentType := resp.Header.Get("Content-Type")
+			if !strings.Contains(strings.ToLower(contentType), "text/html") {
 				continue
 			}
 
-		PayloadLoop:
-			for _, testCase := range payloads.XSSTests {
-				if _, contextMatch := detectedContexts[testCase.Context]; !contextMatch {
-					continue
-				}
-
-				uniqueMarker := fmt.Sprintf("%s%d", payloads.XSSMarker, rand.Intn(1e9))
-				payload := strings.Replace(testCase.PayloadTemplate, "DURSGO_MARKER", uniqueMarker, -1)
-				detectionRegexStr := strings.Replace(testCase.DetectionRegex, "DURSGO_MARKER", uniqueMarker, -1)
-				detectionRegex, _ := regexp.Compile(detectionRegexStr)
+			finalEvidence := evidence
+			if finalEvidence == "" {
+				finalEvidence = payload
+			}
+			// Show where the payload landed, not just the payload itself.
+			if snippet := reflectionSnippet(html.UnescapeString(string(bodyBytes)), finalEvidence); snippet != "" {
+				finalEvidence = snippet
+			}
 
-				testURL, reqBody := buildRequestComponents(req, paramName, payload)
-				httpRequest, _ := http.NewRequest(req.Method, testURL, reqBody)
-				if req.Method == "POST" {
-					httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-				}
+			severity, ok := contextSeverity[testCase.Context]
+			if !ok {
+				severity = "Medium"
+			}
 
-				resp, err := client.Do(httpRequest)
-				if err != nil {
-					if resp != nil {
-						resp.Body.Close()
-					}
-					continue
-				}
-				if resp.Body == nil {
-					continue
-				}
+			details := fmt.Sprintf(
+				"Injected payload was reflected unencoded in a '%s' context. Description: %s",
+				testCase.Context, testCase.Description,
+			)
+			if note := target.Note(); note != "" {
+				details += " " + note
+			}
 
-				bodyBytes, _ := io.ReadAll(resp.Body)
-				resp.Body.Close()
-
-				// Pass the payload template to the verification function for more accurate checking.
-				if found, evidence := s.verifyXSS(bodyBytes, detectionRegex, testCase.PayloadTemplate); found {
-					contentType := resp.Header.Get("Content-Type")
-					if !strings.Contains(strings.ToLower(contentType), "text/html") {
-						continue
-					}
-
-					finalEvidence := evidence
-					if finalEvidence == "" {
-						finalEvidence = payload
-					}
-
-					details := fmt.Sprintf(
-						"Injected payload was executed in a '%s' context. Description: %s",
-						testCase.Context, testCase.Description,
-					)
-
-					vuln := scanner.VulnerabilityResult{
-						VulnerabilityType: "Reflected XSS",
-						URL:               testURL,
-						Parameter:         paramName,
-						Payload:           payload,
-						Location:          paramLoc,
-						Details:           details,
-						Severity:          "high",
-						Evidence:          finalEvidence,
-						Remediation:       "Sanitize user input and implement proper output encoding based on context.",
-						ScannerName:       s.Name(),
-					}
-					findings = append(findings, vuln)
-					// [REVERT] Restore original logic to stop after the first valid finding for efficiency.
-					break PayloadLoop
-				}
+			vuln := scanner.VulnerabilityResult{
+				VulnerabilityType: "Reflected XSS",
+				URL:               httpRequest.URL.String(),
+				Parameter:         target.Param,
+				Payload:           payload,
+				Location:          paramLoc,
+				Details:           details,
+				Severity:          severity,
+				Evidence:          finalEvidence,
+				Remediation:       "Sanitize user input and implement proper output encoding based on context.",
+				ScannerName:       s.Name(),
+				Transformation:    variant.Transformation,
 			}
+			vuln.SetExchange(scanner.CaptureExchange(httpRequest, resp, bodyBytes))
+			// [REVERT] Restore original logic to stop after the first valid finding for efficiency.
+			return vuln, true
 		}
 	}
-	return findings, nil
+	return scanner.VulnerabilityResult{}, false
+}
+
+// reflectionSnippet returns match with up to snippetRadius characters of surrounding response
+// body on each side, or "" if match does not occur in body.
+func reflectionSnippet(body, match string) string {
+	index := strings.Index(body, match)
+	if index == -1 {
+		return ""
+	}
+	snippet := safeSubstring(body, index-snippetRadius, index+len(match)+snippetRadius)
+	return strings.Join(strings.Fields(snippet), " ") // Collapse newlines and indentation.
 }
 
 // --- Stored XSS Scanner ---
@@ -147,16 +223,40 @@ type StoredXSSScanner struct{}
 
 func NewStoredXSSScanner() scanner.Scanner { return &StoredXSSScanner{} }
 
-func (s *StoredXSSScanner) Name() string { return "xss-stored" }
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           StoredModuleName,
+		Order:          20,
+		DefaultEnabled: true,
+		// Stored input usually leaves the response of the request that submitted it unchanged.
+		TestsInertParams: true,
+		Options: []scanner.OptionSpec{
+			{Name: "markers", Type: scanner.OptionBool, Default: true, Description: "Submit a marker to every writable parameter and look for it on the crawled pages once the active phase is over"},
+			{Name: "max_pages", Type: scanner.OptionInt, Default: defaultMaxStoredPages, Description: "Pages fetched again to look for the markers"},
+		},
+		New: func(scanner.Env) scanner.Scanner { return NewStoredXSSScanner() },
+	})
+}
+
+func init() { scanner.RegisterAlias("xss", "xss-reflected", "xss-stored", BlindModuleName) }
+
+func (s *StoredXSSScanner) Name() string { return StoredModuleName }
 
-func (s *StoredXSSScanner) Scan(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, _ scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+func (s *StoredXSSScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	// Markers of input displayed on another page are looked for by RetrieveStored.
+	if opts.StoredMarkers != nil {
+		if err := s.submitMarkers(ctx, req, client, log, opts); err != nil {
+			return nil, err
+		}
+	}
 	if !(req.Method == "POST" && isStoredXSSForm(req)) {
 		return nil, nil
 	}
 
 	// [PERBAIKAN] Coba logika baru yang lebih canggih terlebih dahulu.
 	if req.SourceURL != "" {
-		findings, err := s.scanWithSourceURL(req, client, log)
+		findings, err := s.scanWithSourceURL(ctx, req, client, log)
 		// Jika ada temuan atau error (selain error "tidak ditemukan"), kembalikan.
 		if err != nil || len(findings) > 0 {
 			return findings, err
@@ -165,11 +265,11 @@ func (s *StoredXSSScanner) Scan(req crawler.ParameterizedRequest, client *httpcl
 	
 	// Jika logika baru tidak menemukan apa-apa, jalankan logika lama sebagai fallback.
 	log.Debug("[%s] Falling back to legacy Stored XSS check for: %s", s.Name(), req.URL)
-	return submitAndVerifyStoredXSS(req, client, log)
+	return submitAndVerifyStoredXSS(ctx, req, client, log, opts.PayloadTier)
 }
 
 // [FUNGSI BARU] Logika baru yang menggunakan SourceURL
-func (s *StoredXSSScanner) scanWithSourceURL(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger) ([]scanner.VulnerabilityResult, error) {
+func (s *StoredXSSScanner) scanWithSourceURL(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger) ([]scanner.VulnerabilityResult, error) {
 	verificationURL := req.SourceURL
 	log.Debug("StoredXSS-Advanced: Verifying on SourceURL: %s", verificationURL)
 
@@ -197,7 +297,7 @@ func (s *StoredXSSScanner) scanWithSourceURL(req crawler.ParameterizedRequest, c
 	formData := buildCommentFormData(req, payload, csrfToken)
 
 	// 3. Kirim komentar
-	postReq, _ := http.NewRequest("POST", req.URL, strings.NewReader(formData.Encode()))
+	postReq, _ := http.NewRequestWithContext(ctx, "POST", req.URL, strings.NewReader(formData.Encode()))
 	postReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	postReq.Header.Set("Referer", verificationURL)
 	
@@ -252,7 +352,7 @@ func isStoredXSSForm(req crawler.ParameterizedRequest) bool {
 	return false
 }
 
-func submitAndVerifyStoredXSS(req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger) ([]scanner.VulnerabilityResult, error) {
+func submitAndVerifyStoredXSS(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, tier scanner.PayloadTier) ([]scanner.VulnerabilityResult, error) {
 	var findings []scanner.VulnerabilityResult
 
 	// Find the injectable parameter (e.g., 'comment' or 'content')
@@ -307,7 +407,7 @@ func submitAndVerifyStoredXSS(req crawler.ParameterizedRequest, client *httpclie
 		}
 	}
 
-	probePostReq, _ := http.NewRequest("POST", req.URL, strings.NewReader(probeFormData.Encode()))
+	probePostReq, _ := http.NewRequestWithContext(ctx, "POST", req.URL, strings.NewReader(probeFormData.Encode()))
 	probePostReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	probePostReq.Header.Set("Referer", req.URL)
 	probeResp, err := client.Do(probePostReq)
@@ -334,7 +434,7 @@ func submitAndVerifyStoredXSS(req crawler.ParameterizedRequest, client *httpclie
 	log.Debug("[%s] Probe marker FOUND. Proceeding with full XSS payload testing.", "xss-stored")
 
 	// --- Full Payload Testing (only if probe was successful) ---
-	for _, testCase := range payloads.XSSTests {
+	for _, testCase := range scanner.TrimPayloads(tier, payloads.XSSTests) {
 		uniqueMarker := fmt.Sprintf("%s%d", payloads.XSSMarker, rand.Intn(1e9))
 		payload := strings.Replace(testCase.PayloadTemplate, "DURSGO_MARKER", uniqueMarker, -1)
 		detectionRegexStr := strings.Replace(testCase.DetectionRegex, "DURSGO_MARKER", uniqueMarker, -1)
@@ -353,7 +453,7 @@ func submitAndVerifyStoredXSS(req crawler.ParameterizedRequest, client *httpclie
 			}
 		}
 
-		postReq, _ := http.NewRequest("POST", req.URL, strings.NewReader(formData.Encode()))
+		postReq, _ := http.NewRequestWithContext(ctx, "POST", req.URL, strings.NewReader(formData.Encode()))
 		postReq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 		postReq.Header.Set("Referer", req.URL)
 
@@ -418,45 +518,55 @@ func buildCommentFormData(req crawler.ParameterizedRequest, payload, csrfToken s
 	return formData
 }
 
-func detectReflectionContexts(req crawler.ParameterizedRequest, paramName string, client *httpclient.Client, log *logger.Logger) map[string]bool {
-	probeMarker := fmt.Sprintf("dursgoprobe%d", rand.Intn(1e9))
-	testURL, reqBody := buildRequestComponents(req, paramName, probeMarker)
-
-	httpRequest, err := http.NewRequest(req.Method, testURL, reqBody)
+// sendRequest injects value into paramName and returns the request as sent, the response and
+// its body, which may be truncated to the client's size limit.
+func sendRequest(ctx context.Context, req crawler.ParameterizedRequest, paramName, value string, client *httpclient.Client) (*http.Request, *http.Response, []byte, error) {
+	params, err := requtil.Params(req)
 	if err != nil {
-		log.Debug("Error creating request for context detection: %v", err)
-		return nil
+		return nil, nil, nil, err
 	}
-
-	
-	if req.Method == "POST" {
-		httpRequest.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	params = requtil.Copy(params)
+	params.Set(paramName, value)
+	httpRequest, resp, bodyBytes, err := requtil.Do(ctx, req, client, params)
+	if errors.Is(err, httpclient.ErrBodyTruncated) {
+		err = nil
 	}
+	return httpRequest, resp, bodyBytes, err
+}
 
-	resp, err := client.Do(httpRequest)
-	if err != nil {
-		if resp != nil {
-			resp.Body.Close()
-		}
-		log.Debug("Error during context detection request for param '%s': %v", paramName, err)
-		return nil
-	}
-	defer resp.Body.Close()
+var (
+	// urlAttributeRegex matches text ending inside a URL-valued attribute (href="..., src=...).
+	urlAttributeRegex = regexp.MustCompile(`(?i)\b(?:href|src|action|formaction|data)\s*=\s*["']?$`)
+	// scriptOpenRegex and scriptCloseRegex locate script blocks preceding a reflection.
+	scriptOpenRegex  = regexp.MustCompile(`(?i)<script\b[^>]*>`)
+	scriptCloseRegex = regexp.MustCompile(`(?i)</script\s*>`)
+)
 
-	bodyBytes, err := io.ReadAll(resp.Body)
+// detectReflectionContexts injects a unique probe into target and classifies every place it
+// is reflected: inside a script block ("JS"), at the start of a URL attribute ("URL"), inside a
+// quoted attribute ("Attribute") or in markup/text ("HTML").
+func detectReflectionContexts(ctx context.Context, req crawler.ParameterizedRequest, target nested.Point, client *httpclient.Client, log *logger.Logger) map[string]bool {
+	paramName := target.Param
+	if target.Nested() {
+		paramName = target.String()
+	}
+	probeMarker := fmt.Sprintf("dursgoprobe%d", rand.Intn(1e9))
+	_, _, bodyBytes, err := sendRequest(ctx, req, target.Param, target.Inject(probeMarker), client)
 	if err != nil {
-		log.Debug("Error reading response body for context detection: %v", err)
+		log.Debug("Error during context detection request for param '%s': %v", paramName, err)
 		return nil
 	}
 	responseBody := string(bodyBytes)
 
 	contexts := make(map[string]bool)
-	indices := []int{}
-	for i := strings.Index(responseBody, probeMarker); i != -1; i = strings.Index(responseBody[i+1:], probeMarker) {
-		if len(indices) > 10 {
+	var indices []int
+	for offset := 0; len(indices) <= 10; {
+		i := strings.Index(responseBody[offset:], probeMarker)
+		if i == -1 {
 			break
 		}
-		indices = append(indices, i)
+		indices = append(indices, offset+i)
+		offset += i + len(probeMarker)
 	}
 
 	if len(indices) == 0 {
@@ -467,68 +577,49 @@ func detectReflectionContexts(req crawler.ParameterizedRequest, paramName string
 	log.Info("Parameter '%s' is reflected in %d locations. Analyzing contexts...", paramName, len(indices))
 
 	for _, index := range indices {
-		precedingText := safeSubstring(responseBody, index-15, index)
-		followingText := safeSubstring(responseBody, index+len(probeMarker), index+len(probeMarker)+15)
-
-		if strings.Contains(precedingText, "<script>") && strings.Contains(followingText, "</script>") {
-			log.Debug("Context for '%s' at index %d: JS", paramName, index)
-			contexts["JS"] = true
-			continue
-		}
-
-		lastQuote := strings.LastIndexAny(precedingText, `"'`)
-		if lastQuote != -1 {
-			quoteChar := string(precedingText[lastQuote])
-			if strings.HasPrefix(followingText, quoteChar) {
-				log.Debug("Context for '%s' at index %d: Attribute", paramName, index)
-				contexts["Attribute"] = true
-				continue
-			}
-		}
-
-		if strings.Contains(precedingText, "<") && strings.Contains(followingText, ">") {
-			log.Debug("Context for '%s' at index %d: HTML (in-tag)", paramName, index)
-			contexts["HTML"] = true
-			continue
-		}
-
-		log.Debug("Context for '%s' at index %d: HTML (default)", paramName, index)
-		contexts["HTML"] = true
+		reflectionContext := classifyReflection(responseBody, index, len(probeMarker))
+		log.Debug("Context for '%s' at index %d: %s", paramName, index, reflectionContext)
+		contexts[reflectionContext] = true
 	}
 
 	return contexts
 }
 
-func buildRequestComponents(req crawler.ParameterizedRequest, paramToInject, valueToInject string) (string, io.Reader) {
-	parsedURL, _ := url.Parse(req.URL)
-	params := parsedURL.Query()
-	var body io.Reader
+// classifyReflection returns the context of the reflection of length markerLen at index.
+func classifyReflection(body string, index, markerLen int) string {
+	preceding := body[:index]
 
-	if req.Method == "POST" && req.FormPostData != "" {
-		params, _ = url.ParseQuery(req.FormPostData)
+	// Inside a script block: the last <script> opens after the last </script>.
+	if opens := scriptOpenRegex.FindAllStringIndex(preceding, -1); len(opens) > 0 {
+		lastOpen := opens[len(opens)-1][0]
+		closes := scriptCloseRegex.FindAllStringIndex(preceding, -1)
+		if len(closes) == 0 || closes[len(closes)-1][0] < lastOpen {
+			return "JS"
+		}
 	}
 
-	params.Set(paramToInject, valueToInject)
-
-	if req.Method == "POST" {
-		bodyParams := url.Values{}
-		urlParams := url.Values{}
-		originalPostParams, _ := url.ParseQuery(req.FormPostData)
+	precedingText := safeSubstring(body, index-40, index)
+	followingText := safeSubstring(body, index+markerLen, index+markerLen+15)
 
-		for key := range params {
-			if _, isBodyParam := originalPostParams[key]; isBodyParam || key == paramToInject {
-				bodyParams.Set(key, params.Get(key))
-			} else {
-				urlParams.Set(key, params.Get(key))
-			}
+	// Inside a tag: the last '<' comes after the last '>'.
+	if strings.LastIndex(preceding, "<") > strings.LastIndex(preceding, ">") {
+		if urlAttributeRegex.MatchString(precedingText) {
+			return "URL"
+		}
+		lastQuote := strings.LastIndexAny(precedingText, `"'`)
+		if lastQuote != -1 && strings.HasPrefix(followingText, string(precedingText[lastQuote])) {
+			return "Attribute"
 		}
-		body = strings.NewReader(bodyParams.Encode())
-		parsedURL.RawQuery = urlParams.Encode()
-	} else {
-		parsedURL.RawQuery = params.Encode()
 	}
 
-	return parsedURL.String(), body
+	return "HTML"
+}
+
+// injectionTargets returns the injection points of paramName: its value, followed by the strings
+// nested in it when the value is JSON or base64 (see nested.Targets).
+func injectionTargets(req crawler.ParameterizedRequest, paramName string) []nested.Point {
+	params, _ := requtil.Params(req)
+	return nested.Targets(paramName, params.Get(paramName))
 }
 
 func safeSubstring(s string, start, end int) string {
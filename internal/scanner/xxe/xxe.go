@@ -0,0 +1,220 @@
+package xxe
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/oob"
+	"Dursgo/internal/payloads"
+	"Dursgo/internal/scanner"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// maxTextNodes limits how many text nodes of a document are tested individually.
+const maxTextNodes = 10
+
+var (
+	// xmlDeclarationRegex matches the XML declaration, which must stay before the DOCTYPE.
+	xmlDeclarationRegex = regexp.MustCompile(`^\s*<\?xml[^>]*\?>`)
+	// doctypeRegex matches an existing DOCTYPE (including an internal subset), which is replaced.
+	doctypeRegex = regexp.MustCompile(`(?s)<!DOCTYPE[^\[>]*(\[.*?\])?\s*>`)
+	// rootElementRegex captures the name of the first element.
+	rootElementRegex = regexp.MustCompile(`<([A-Za-z_][\w:.-]*)`)
+	// textNodeRegex matches non-blank text between two tags.
+	textNodeRegex = regexp.MustCompile(`>([^<>]*[^<>\s][^<>]*)<`)
+)
+
+// XXEScanner implements the Scanner interface for XML External Entity injection.
+type XXEScanner struct{}
+
+// NewXXEScanner creates a new instance of XXEScanner.
+func NewXXEScanner() *XXEScanner {
+	return &XXEScanner{}
+}
+
+func init() {
+	scanner.Register(scanner.Registration{
+		Name:           "xxe",
+		Order:          200,
+		DefaultEnabled: true,
+		New:            func(scanner.Env) scanner.Scanner { return NewXXEScanner() },
+	})
+}
+
+// Name returns the scanner's name.
+func (s *XXEScanner) Name() string {
+	return "XML External Entity (XXE) Scanner"
+}
+
+// xmlDocument is a request body split around the position where a DOCTYPE is inserted.
+type xmlDocument struct {
+	declaration string  // XML declaration, if any.
+	body        string  // Document without declaration and DOCTYPE.
+	root        string  // Root element name.
+	textNodes   [][]int // Start/end offsets of text nodes in body.
+}
+
+// Scan tests requests with XML bodies. It adds a DOCTYPE declaring an external entity to the
+// original document and references the entity from one existing text node at a time, first
+// reading local files in-band and, if that fails, triggering out-of-band callbacks.
+func (s *XXEScanner) Scan(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions) ([]scanner.VulnerabilityResult, error) {
+	client = client.WithContext(ctx)
+	if !req.IsXML() || strings.TrimSpace(req.RawBody) == "" {
+		return nil, nil
+	}
+	doc, ok := parseXMLDocument(req.RawBody)
+	if !ok {
+		log.Debug("XXE: Could not find a root element in the body of %s", req.URL)
+		return nil, nil
+	}
+	log.Debug("Starting XXE scan for %s %s (%d text nodes)...", req.Method, req.URL, len(doc.textNodes))
+
+	_, _, baselineBody, err := sendXMLRequest(ctx, req, client, req.RawBody)
+	if err != nil {
+		return nil, nil
+	}
+
+	for node := range doc.textNodes {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		for _, test := range payloads.XXEInBandTests {
+			body := doc.build(strings.ReplaceAll(test.DTDTemplate, "{ROOT}", doc.root), node, true)
+			httpReq, resp, respBody, err := sendXMLRequest(ctx, req, client, body)
+			if err != nil {
+				if errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+					return nil, nil
+				}
+				continue
+			}
+			for _, signature := range test.Signatures {
+				if strings.Contains(respBody, signature) && !strings.Contains(baselineBody, signature) {
+					log.Success("XXE: In-band file read (%s) via text node %d of %s", test.Description, node+1, req.URL)
+					vuln := scanner.VulnerabilityResult{
+						VulnerabilityType: "XML External Entity (XXE)",
+						URL:               req.URL,
+						Parameter:         fmt.Sprintf("text node %d", node+1),
+						Payload:           body,
+						Location:          "body",
+						Details:           fmt.Sprintf("The XML parser resolved external entities in-band: the entity pointing to %s was expanded and its content returned in the response.", test.Description),
+						Severity:          "High",
+						Evidence:          signature,
+						Remediation:       "Disable DTD processing and external entity resolution in the XML parser (e.g., FEATURE_SECURE_PROCESSING, disallow-doctype-decl), or use a data format without entities.",
+						ScannerName:       s.Name(),
+					}
+					vuln.SetExchange(scanner.CaptureExchange(httpReq, resp, []byte(respBody)))
+					return []scanner.VulnerabilityResult{vuln}, nil
+				}
+			}
+		}
+	}
+
+	s.testOutOfBand(ctx, req, client, log, opts, doc)
+	return nil, nil
+}
+
+// testOutOfBand sends external entities pointing at the OOB collaborator. Potential findings are
+// stored in the OAST correlation map and only reported once the collaborator records a callback.
+func (s *XXEScanner) testOutOfBand(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, log *logger.Logger, opts scanner.ScannerOptions, doc xmlDocument) {
+	if opts.OOBCollaboratorURL == "" || opts.OASTCorrelationMap == nil {
+		return
+	}
+	for _, test := range payloads.XXEOOBTests {
+		correlationID := oob.NewCorrelationID("xxe", strings.ReplaceAll(test.Description, " ", "-"))
+		dtd := strings.NewReplacer(
+			"{ROOT}", doc.root,
+			"{URL}", oob.PayloadURL(opts.OOBCollaboratorURL, correlationID),
+		).Replace(test.DTDTemplate)
+		body := doc.build(dtd, 0, test.ReferencesEntity)
+
+		opts.OASTCorrelationMap.Store(correlationID, scanner.VulnerabilityResult{
+			VulnerabilityType: "XML External Entity (XXE, Out-of-Band)",
+			URL:               req.URL,
+			Parameter:         "XML body",
+			Payload:           body,
+			Location:          "body",
+			Details:           fmt.Sprintf("The XML parser resolved an %s out-of-band: it fetched the collaborator URL, but file contents were not returned in-band. Data can still be exfiltrated with an attacker-hosted DTD.", test.Description),
+			Severity:          "High",
+			Evidence:          fmt.Sprintf("Correlation ID: %s.", correlationID),
+			Remediation:       "Disable DTD processing and external entity resolution in the XML parser, and restrict outbound network access from the server.",
+			ScannerName:       s.Name(),
+		})
+
+		log.Debug("XXE (Out-of-Band): Sending %s payload to %s (correlation ID %s)", test.Description, req.URL, correlationID)
+		if _, _, _, err := sendXMLRequest(ctx, req, client, body); errors.Is(err, httpclient.ErrRequestBudgetExhausted) {
+			opts.OASTCorrelationMap.Delete(correlationID) // Never sent, so it can never be confirmed.
+			return
+		}
+	}
+}
+
+// parseXMLDocument separates the XML declaration and any existing DOCTYPE from the document and
+// locates its root element and text nodes.
+func parseXMLDocument(raw string) (xmlDocument, bool) {
+	var doc xmlDocument
+	body := raw
+	if loc := xmlDeclarationRegex.FindStringIndex(body); loc != nil {
+		doc.declaration = strings.TrimSpace(body[:loc[1]])
+		body = body[loc[1]:]
+	}
+	body = doctypeRegex.ReplaceAllString(body, "")
+
+	root := rootElementRegex.FindStringSubmatchIndex(body)
+	if root == nil {
+		return doc, false
+	}
+	doc.body = body
+	doc.root = body[root[2]:root[3]]
+	for _, match := range textNodeRegex.FindAllStringSubmatchIndex(body, maxTextNodes) {
+		doc.textNodes = append(doc.textNodes, []int{match[2], match[3]})
+	}
+	if len(doc.textNodes) == 0 {
+		// Without text nodes, the entity is referenced inside the root element.
+		end := strings.Index(body[root[0]:], ">")
+		if end < 0 || strings.HasSuffix(body[:root[0]+end], "/") {
+			return doc, false
+		}
+		end += root[0]
+		doc.textNodes = append(doc.textNodes, []int{end + 1, end + 1})
+	}
+	return doc, true
+}
+
+// build returns the document with dtd inserted after the XML declaration and, when
+// referenceEntity is set, "&xxe;" appended to the given text node.
+func (d xmlDocument) build(dtd string, node int, referenceEntity bool) string {
+	body := d.body
+	if referenceEntity {
+		end := d.textNodes[node][1]
+		body = body[:end] + "&xxe;" + body[end:]
+	}
+	if d.declaration != "" {
+		return d.declaration + "\n" + dtd + "\n" + strings.TrimLeft(body, " \t\r\n")
+	}
+	return dtd + "\n" + strings.TrimLeft(body, " \t\r\n")
+}
+
+// sendXMLRequest sends body with the request's method, URL and XML content type.
+func sendXMLRequest(ctx context.Context, req crawler.ParameterizedRequest, client *httpclient.Client, body string) (*http.Request, *http.Response, string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL, strings.NewReader(body))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	httpReq.Header.Set("Content-Type", req.ContentType)
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return httpReq, resp, string(respBody), nil
+}
@@ -0,0 +1,96 @@
+package xxe
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orderBody = `<?xml version="1.0" encoding="UTF-8"?>
+<order><id>42</id><note>gift wrap</note></order>`
+
+func TestParseXMLDocumentPreservesStructure(t *testing.T) {
+	doc, ok := parseXMLDocument(orderBody)
+	require.True(t, ok)
+	assert.Equal(t, "order", doc.root)
+	require.Len(t, doc.textNodes, 2)
+
+	got := doc.build(`<!DOCTYPE order [<!ENTITY xxe SYSTEM "file:///etc/passwd">]>`, 1, true)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE order [<!ENTITY xxe SYSTEM "file:///etc/passwd">]>
+<order><id>42</id><note>gift wrap&xxe;</note></order>`, got)
+}
+
+func TestParseXMLDocumentReplacesExistingDoctype(t *testing.T) {
+	doc, ok := parseXMLDocument(`<!DOCTYPE note SYSTEM "note.dtd"><note/>`)
+	assert.False(t, ok, "an empty root element has no place for the entity reference")
+
+	doc, ok = parseXMLDocument(`<!DOCTYPE note [<!ELEMENT note (#PCDATA)>]><note>hi</note>`)
+	require.True(t, ok)
+	assert.Equal(t, "<note>hi</note>", doc.body)
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name         string
+		resolve      bool
+		wantFindings int
+		wantOOBStore bool
+	}{
+		{name: "In-band file read", resolve: true, wantFindings: 1},
+		{name: "Falls back to out-of-band", resolve: false, wantOOBStore: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				// Emulate a parser that expands &xxe; into /etc/passwd when the entity is declared.
+				if tt.resolve && strings.Contains(string(body), `SYSTEM "file:///etc/passwd"`) && strings.Contains(string(body), "&xxe;") {
+					w.Write([]byte("<result>root:x:0:0:root:/root:/bin/bash</result>"))
+					return
+				}
+				w.Write([]byte("<result>ok</result>"))
+			}))
+			defer server.Close()
+
+			log := logger.NewLogger(logger.ERROR)
+			client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: server.URL})
+			req := crawler.ParameterizedRequest{
+				Method:      "POST",
+				URL:         server.URL + "/api/orders",
+				ContentType: "application/xml",
+				RawBody:     orderBody,
+			}
+			correlationMap := &sync.Map{}
+			opts := scanner.ScannerOptions{OOBCollaboratorURL: "http://oob.example.com", OASTCorrelationMap: correlationMap}
+
+			findings, err := NewXXEScanner().Scan(context.Background(), req, client, log, opts)
+			require.NoError(t, err)
+			require.Len(t, findings, tt.wantFindings)
+			if tt.wantFindings > 0 {
+				assert.Contains(t, findings[0].Details, "in-band")
+				assert.Equal(t, "root:x:0:0:", findings[0].Evidence)
+			}
+
+			stored := false
+			correlationMap.Range(func(_, _ any) bool {
+				stored = true
+				return false
+			})
+			assert.Equal(t, tt.wantOOBStore, stored)
+		})
+	}
+}
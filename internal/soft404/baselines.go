@@ -0,0 +1,86 @@
+package soft404
+
+import (
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"net/url"
+	"sync"
+)
+
+// baseline holds the response to a random path, fetched once per directory and extension.
+type baseline[R any] struct {
+	once sync.Once
+	resp R // The zero R when the baseline request failed.
+}
+
+// Baselines serves the brute-forcing modules (content discovery, backup files) that tell a
+// requested path from the not-found answer of its directory: it fetches the response to a random
+// path once per directory and extension, and caps the requests sent to each host. Directories can
+// answer differently from the host-wide page a Detector fingerprints, e.g. behind a framework
+// route. R is the module's representation of a response. Its methods are safe for concurrent use.
+type Baselines[R any] struct {
+	log       *logger.Logger
+	name      string // Module name prefixed to the log messages.
+	maxProbes int    // Cap on requests sent per host (0 = unlimited).
+
+	mu        sync.Mutex
+	probes    map[string]int          // Requests sent per host.
+	capLogged map[string]bool         // Hosts whose probe cap was reported.
+	baselines map[string]*baseline[R] // Keyed by directory and extension.
+}
+
+// NewBaselines creates the baselines of the module name, which sends at most maxProbes requests
+// to each host (0 = unlimited).
+func NewBaselines[R any](log *logger.Logger, name string, maxProbes int) *Baselines[R] {
+	return &Baselines[R]{
+		log:       log,
+		name:      name,
+		maxProbes: maxProbes,
+		probes:    make(map[string]int),
+		capLogged: make(map[string]bool),
+		baselines: make(map[string]*baseline[R]),
+	}
+}
+
+// For returns the baseline of dir for paths with the extension ext, requesting a random path
+// with fetch on first use. It returns the zero R when the baseline could not be fetched.
+func (b *Baselines[R]) For(dir *url.URL, ext string, fetch func(target string) (R, error)) R {
+	key := dir.String() + " " + ext
+	b.mu.Lock()
+	entry, ok := b.baselines[key]
+	if !ok {
+		entry = &baseline[R]{}
+		b.baselines[key] = entry
+	}
+	b.mu.Unlock()
+
+	entry.once.Do(func() {
+		if !b.Take(dir.Host) {
+			return
+		}
+		canary := payloads.GenerateContentDiscoveryCanary() + ext
+		resp, err := fetch(dir.ResolveReference(&url.URL{Path: canary}).String())
+		if err != nil {
+			b.log.Debug("%s: Baseline request for %s failed: %v", b.name, dir, err)
+			return
+		}
+		b.log.Debug("%s: Baseline for %s (%q) fetched", b.name, dir, ext)
+		entry.resp = resp
+	})
+	return entry.resp
+}
+
+// Take counts a request against the probe cap of host and reports whether it may be sent.
+func (b *Baselines[R]) Take(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.maxProbes > 0 && b.probes[host] >= b.maxProbes {
+		if !b.capLogged[host] {
+			b.capLogged[host] = true
+			b.log.Warn("%s: Probe limit of %d reached for %s; remaining probes are skipped.", b.name, b.maxProbes, host)
+		}
+		return false
+	}
+	b.probes[host]++
+	return true
+}
@@ -0,0 +1,332 @@
+// Package soft404 recognizes the "not found" pages of hosts that answer unknown paths with 200
+// (or a redirect) instead of 404. A Detector requests a few paths that cannot exist on each
+// host, fingerprints the answer (status, length band, title and not-found phrases) and tells
+// scanners whether a response is that page, so that they do not take it for content. Hosts are
+// re-probed periodically, as an application can change its not-found handling mid-scan (e.g.,
+// a WAF starting to answer every request with a block page).
+package soft404
+
+import (
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/payloads"
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of Detector.ReprobeInterval and Detector.ReprobeChecks.
+const (
+	DefaultReprobeInterval = 5 * time.Minute
+	DefaultReprobeChecks   = 500
+)
+
+// maxBodyBytes bounds how much of a response is fingerprinted.
+const maxBodyBytes = 64 * 1024
+
+// pathPlaceholder replaces the requested path in bodies and Location headers, so that pages
+// reflecting the path compare equal.
+const pathPlaceholder = "{path}"
+
+// notFoundPhrases are the phrases of not-found pages kept in a fingerprint when every probe
+// contains them.
+var notFoundPhrases = []string{
+	"not found", "404", "could not be found", "cannot be found", "can't be found", "does not exist",
+	"doesn't exist", "no longer available", "page you requested", "page you are looking for",
+	"nothing here", "no such page", "oops",
+}
+
+var titlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// Fingerprint is how a host answers paths that do not exist.
+type Fingerprint struct {
+	Host   string // Scheme and host, e.g. "https://example.com".
+	Soft   bool   // Unknown paths get a non-404 answer; false for hosts answering 404 or 410.
+	Status int
+	// Location is the redirect target of 3xx answers, with the requested path replaced by
+	// {path}.
+	Location string
+	// MinLength and MaxLength bound the body lengths of the probes, with the path replaced.
+	MinLength int
+	MaxLength int
+	Title     string   // Title shared by the probes; empty when they have none or differ.
+	Phrases   []string // notFoundPhrases present in every probe.
+}
+
+// String describes f for the log.
+func (f *Fingerprint) String() string {
+	s := fmt.Sprintf("%s answers unknown paths with status %d", f.Host, f.Status)
+	if !f.Soft {
+		return s
+	}
+	if f.Location != "" {
+		return s + " to " + f.Location
+	}
+	s += fmt.Sprintf(", %d-%d bytes", f.MinLength, f.MaxLength)
+	if f.Title != "" {
+		s += fmt.Sprintf(", title %q", f.Title)
+	}
+	if len(f.Phrases) > 0 {
+		s += fmt.Sprintf(", phrases %q", f.Phrases)
+	}
+	return s
+}
+
+// equal reports whether f and g describe the same behavior.
+func (f *Fingerprint) equal(g *Fingerprint) bool {
+	return f.Soft == g.Soft && f.Status == g.Status && f.Location == g.Location && f.Title == g.Title &&
+		strings.Join(f.Phrases, "\x00") == strings.Join(g.Phrases, "\x00") &&
+		f.MinLength <= g.MaxLength+slack(g.MaxLength) && g.MinLength <= f.MaxLength+slack(f.MaxLength)
+}
+
+// slack is how far the length of a soft-404 page may fall outside the band of the probes: the
+// not-found page often reflects the path or a timestamp.
+func slack(length int) int {
+	if s := length / 10; s > 64 {
+		return s
+	}
+	return 64
+}
+
+// hostState is the fingerprint of a host and when it was taken.
+type hostState struct {
+	mu       sync.Mutex
+	probed   bool
+	fp       *Fingerprint // nil when the probes failed.
+	probedAt time.Time
+	checks   int  // Matches since the last probe.
+	probing  bool // A re-probe is running; other callers keep using fp.
+}
+
+// Detector fingerprints the not-found pages of the hosts it is asked about, on first use, and
+// re-probes a host once ReprobeInterval has passed or ReprobeChecks responses were matched
+// against its fingerprint. Its methods are safe for concurrent use; a nil *Detector reports no
+// soft 404s.
+type Detector struct {
+	client *httpclient.Client
+	log    *logger.Logger
+
+	ReprobeInterval time.Duration
+	ReprobeChecks   int
+
+	mu    sync.Mutex
+	hosts map[string]*hostState
+}
+
+// New creates a Detector sending its probes with client.
+func New(client *httpclient.Client, log *logger.Logger) *Detector {
+	return &Detector{
+		client:          client,
+		log:             log,
+		ReprobeInterval: DefaultReprobeInterval,
+		ReprobeChecks:   DefaultReprobeChecks,
+		hosts:           make(map[string]*hostState),
+	}
+}
+
+// Probe fingerprints the host of target now, e.g. at the start of a scan, and returns its
+// fingerprint; nil when the probes failed.
+func (d *Detector) Probe(ctx context.Context, target string) *Fingerprint {
+	if d == nil {
+		return nil
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	h := d.host(u)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fp, h.probedAt, h.checks, h.probed = d.probe(ctx, u), time.Now(), 0, true
+	d.report(h.fp)
+	return h.fp
+}
+
+// IsSoft404 reports whether resp, whose body was read into body, is the not-found page of its
+// host.
+func (d *Detector) IsSoft404(resp *http.Response, body string) bool {
+	if d == nil || resp == nil || resp.Request == nil {
+		return false
+	}
+	return d.Match(resp.Request.Context(), resp.Request.URL.String(), resp.StatusCode, resp.Header.Get("Location"), body)
+}
+
+// Match reports whether a response to target with the given status, Location header and body
+// is the not-found page of the host of target, for callers that only kept these parts of the
+// response. The requested path may already be replaced by "{path}" in location and body.
+func (d *Detector) Match(ctx context.Context, target string, status int, location, body string) bool {
+	if d == nil {
+		return false
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	fp := d.fingerprint(ctx, u)
+	if fp == nil || !fp.Soft || status != fp.Status {
+		return false
+	}
+	location, body = stripPath(u, location), stripPath(u, body)
+	if status >= 300 && status < 400 {
+		return location == fp.Location
+	}
+	if len(body) < fp.MinLength-slack(fp.MinLength) || len(body) > fp.MaxLength+slack(fp.MaxLength) {
+		return false
+	}
+	if fp.Title != "" && title(body) != fp.Title {
+		return false
+	}
+	lower := strings.ToLower(body)
+	for _, phrase := range fp.Phrases {
+		if !strings.Contains(lower, phrase) {
+			return false
+		}
+	}
+	return true
+}
+
+// host returns the state of the host of u, adding it if needed.
+func (d *Detector) host(u *url.URL) *hostState {
+	key := u.Scheme + "://" + u.Host
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	h := d.hosts[key]
+	if h == nil {
+		h = &hostState{}
+		d.hosts[key] = h
+	}
+	return h
+}
+
+// fingerprint returns the fingerprint of the host of u, probing it on first use and when a
+// re-probe is due. Concurrent callers wait for the first probe, but keep using the previous
+// fingerprint during a re-probe.
+func (d *Detector) fingerprint(ctx context.Context, u *url.URL) *Fingerprint {
+	h := d.host(u)
+	h.mu.Lock()
+	if !h.probed {
+		h.fp, h.probedAt, h.probed = d.probe(ctx, u), time.Now(), true
+		fp := h.fp
+		h.mu.Unlock()
+		d.report(fp)
+		return fp
+	}
+	h.checks++
+	fp := h.fp
+	due := !h.probing && (h.checks >= d.ReprobeChecks || time.Since(h.probedAt) >= d.ReprobeInterval)
+	h.probing = h.probing || due
+	h.mu.Unlock()
+	if !due {
+		return fp
+	}
+
+	fresh := d.probe(ctx, u)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.probing, h.checks, h.probedAt = false, 0, time.Now()
+	if fresh == nil {
+		return h.fp // Keep the previous fingerprint when the host did not answer.
+	}
+	if h.fp != nil && !h.fp.equal(fresh) {
+		d.log.Warn("Soft-404: The not-found page of %s changed: %s (was: %s).", fresh.Host, fresh, h.fp)
+	}
+	h.fp = fresh
+	return fresh
+}
+
+// probe requests paths that cannot exist on the host of u and fingerprints the answers. It
+// returns nil when a probe failed; a host answering differently to each is not soft.
+func (d *Detector) probe(ctx context.Context, u *url.URL) *Fingerprint {
+	origin := &url.URL{Scheme: u.Scheme, Host: u.Host}
+	fp := &Fingerprint{Host: origin.String()}
+	canary := payloads.GenerateContentDiscoveryCanary()
+	paths := []string{"/" + canary, "/" + canary + ".php", "/" + canary + "/" + canary + ".html"}
+	var bodies []string
+	for i, p := range paths {
+		status, location, body, err := d.fetch(ctx, origin.ResolveReference(&url.URL{Path: p}))
+		if err != nil {
+			d.log.Debug("Soft-404: Probe of %s failed: %v", fp.Host, err)
+			return nil
+		}
+		if i == 0 {
+			fp.Status, fp.Location, fp.MinLength, fp.MaxLength = status, location, len(body), len(body)
+		} else if status != fp.Status || location != fp.Location {
+			// No stable not-found page to recognize.
+			d.log.Debug("Soft-404: %s answers unknown paths inconsistently (status %d, then %d).", fp.Host, fp.Status, status)
+			return &Fingerprint{Host: fp.Host, Status: status}
+		}
+		fp.MinLength, fp.MaxLength = min(fp.MinLength, len(body)), max(fp.MaxLength, len(body))
+		bodies = append(bodies, body)
+	}
+	fp.Soft = fp.Status != http.StatusNotFound && fp.Status != http.StatusGone
+
+	fp.Title = title(bodies[0])
+	for _, body := range bodies[1:] {
+		if title(body) != fp.Title {
+			fp.Title = ""
+		}
+	}
+	for _, phrase := range notFoundPhrases {
+		shared := true
+		for _, body := range bodies {
+			shared = shared && strings.Contains(strings.ToLower(body), phrase)
+		}
+		if shared {
+			fp.Phrases = append(fp.Phrases, phrase)
+		}
+	}
+	return fp
+}
+
+// report logs the first fingerprint of a host.
+func (d *Detector) report(fp *Fingerprint) {
+	switch {
+	case fp == nil:
+	case fp.Soft:
+		d.log.Info("Soft-404: %s; scanners will not take this page for content.", fp)
+	default:
+		d.log.Debug("Soft-404: %s.", fp)
+	}
+}
+
+// fetch requests u and returns its status, Location header and body, with the path replaced.
+func (d *Detector) fetch(ctx context.Context, u *url.URL) (int, string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return 0, "", "", err
+	}
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodyBytes))
+	if err != nil {
+		return 0, "", "", err
+	}
+	return resp.StatusCode, stripPath(u, resp.Header.Get("Location")), stripPath(u, string(body)), nil
+}
+
+// stripPath replaces the path of u in s, escaped or not, with pathPlaceholder.
+func stripPath(u *url.URL, s string) string {
+	if u.Path == "" || u.Path == "/" {
+		return s
+	}
+	return strings.NewReplacer(u.EscapedPath(), pathPlaceholder, u.Path, pathPlaceholder).Replace(s)
+}
+
+// title returns the title of an HTML page, unescaped and trimmed.
+func title(body string) string {
+	m := titlePattern.FindStringSubmatch(body)
+	if m == nil {
+		return ""
+	}
+	return strings.TrimSpace(html.UnescapeString(m[1]))
+}
@@ -0,0 +1,113 @@
+package soft404
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/testutil"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, client *httpclient.Client, target string) (*http.Response, string) {
+	t.Helper()
+	resp, err := client.Get(target)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestDetector(t *testing.T) {
+	log := logger.NewLogger(logger.ERROR)
+
+	t.Run("Soft 404", func(t *testing.T) {
+		app := testutil.NewApp(t, testutil.Safe("/item", "id"), testutil.Soft404())
+		client := httpclient.NewClient(log, httpclient.ClientOptions{})
+		d := New(client, log)
+
+		fp := d.Probe(context.Background(), app.URL("/"))
+		require.NotNil(t, fp)
+		assert.True(t, fp.Soft)
+		assert.Equal(t, http.StatusOK, fp.Status)
+		assert.Equal(t, "Oops", fp.Title)
+		assert.Contains(t, fp.Phrases, "could not be found")
+
+		resp, body := get(t, client, app.URL("/some/other-missing-page.aspx"))
+		assert.True(t, d.IsSoft404(resp, body))
+		resp, body = get(t, client, app.URL("/item?id=1"))
+		assert.False(t, d.IsSoft404(resp, body))
+		assert.False(t, d.Match(context.Background(), app.URL("/missing"), http.StatusNotFound, "", body))
+	})
+
+	t.Run("Real 404", func(t *testing.T) {
+		app := testutil.NewApp(t, testutil.Safe("/item", "id"))
+		client := httpclient.NewClient(log, httpclient.ClientOptions{})
+		d := New(client, log)
+
+		resp, body := get(t, client, app.URL("/missing"))
+		assert.False(t, d.IsSoft404(resp, body))
+		fp := d.Probe(context.Background(), app.URL("/"))
+		require.NotNil(t, fp)
+		assert.False(t, fp.Soft)
+		assert.Equal(t, http.StatusNotFound, fp.Status)
+	})
+
+	t.Run("Behavior shift", func(t *testing.T) {
+		var hard atomic.Bool
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if hard.Load() {
+				http.NotFound(w, r)
+				return
+			}
+			io.WriteString(w, "<html><head><title>Not Found</title></head><body>The page you requested was not found.</body></html>")
+		}))
+		defer server.Close()
+		client := httpclient.NewClient(log, httpclient.ClientOptions{})
+		d := New(client, log)
+		d.ReprobeChecks = 2
+
+		page := "<html><head><title>Not Found</title></head><body>The page you requested was not found.</body></html>"
+		assert.True(t, d.Match(context.Background(), server.URL+"/a", http.StatusOK, "", page))
+		hard.Store(true)
+		assert.True(t, d.Match(context.Background(), server.URL+"/b", http.StatusOK, "", page), "no re-probe before ReprobeChecks matches")
+		assert.False(t, d.Match(context.Background(), server.URL+"/c", http.StatusOK, "", page), "re-probed after the shift")
+	})
+
+	var nilDetector *Detector
+	assert.False(t, nilDetector.Match(context.Background(), "http://example.com/", http.StatusOK, "", ""))
+}
+
+func TestBaselines(t *testing.T) {
+	b := NewBaselines[*string](logger.NewLogger(logger.ERROR), "Test", 3)
+	dir, err := url.Parse("http://example.com/app/")
+	require.NoError(t, err)
+
+	var fetched []string
+	fetch := func(target string) (*string, error) {
+		fetched = append(fetched, target)
+		return &target, nil
+	}
+	base := b.For(dir, ".php", fetch)
+	require.NotNil(t, base)
+	assert.Equal(t, base, b.For(dir, ".php", fetch), "the baseline is fetched once per directory and extension")
+	assert.Len(t, fetched, 1)
+	assert.Contains(t, fetched[0], "http://example.com/app/")
+	assert.True(t, strings.HasSuffix(fetched[0], ".php"))
+
+	assert.True(t, b.Take("example.com"))
+	assert.True(t, b.Take("example.com"))
+	assert.False(t, b.Take("example.com"), "the baseline request counts against the probe cap")
+	assert.Nil(t, b.For(dir, ".bak", fetch), "no baseline once the cap is reached")
+	assert.True(t, b.Take("other.example"))
+}
@@ -0,0 +1,298 @@
+// Package state saves the progress of a scan to a file so that an interrupted scan can be resumed.
+package state
+
+import (
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/scanner"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Version is the format version of the state files written and read by this build.
+const Version = 1
+
+// DefaultFlushInterval is how often a Store saves the state of a running scan.
+const DefaultFlushInterval = 30 * time.Second
+
+var (
+	// ErrCorrupted is returned by Load for state files that are truncated or were modified.
+	ErrCorrupted = errors.New("state file is corrupted")
+	// ErrVersionMismatch is returned by Load for state files written in another format version.
+	ErrVersionMismatch = errors.New("state file version mismatch")
+)
+
+// State is the progress of a scan.
+type State struct {
+	Target    string                `json:"target"`     // Target URL of the scan.
+	StartedAt time.Time             `json:"started_at"` // Start of the original run.
+	Crawl     crawler.CrawlSnapshot `json:"crawl"`
+	// CrawlComplete is set once crawling, content discovery and parameter discovery have
+	// finished. ScanRequests then holds the requests to scan.
+	CrawlComplete   bool                           `json:"crawl_complete"`
+	ScanRequests    []crawler.ParameterizedRequest `json:"scan_requests,omitempty"`
+	PassiveComplete bool                           `json:"passive_complete"`
+	PassiveFindings []scanner.VulnerabilityResult  `json:"passive_findings,omitempty"`
+	Tested          []string                       `json:"tested,omitempty"`   // scanner.TestKey of the completed scanner/request pairs.
+	Findings        []scanner.VulnerabilityResult  `json:"findings,omitempty"` // Findings of the completed pairs.
+	// PendingCallbacks are the out-of-band injections whose callback had not arrived when the
+	// scan ended, e.g. blind XSS payloads no one has viewed yet; a resumed scan keeps waiting for
+	// them.
+	PendingCallbacks []PendingCallback `json:"pending_callbacks,omitempty"`
+	// StoredMarkers are the markers submitted to writable parameters, looked for on the pages of
+	// the target once the active phase is over.
+	StoredMarkers []scanner.StoredMarker `json:"stored_markers,omitempty"`
+}
+
+// PendingCallback is an out-of-band injection waiting for its callback. It can only be
+// confirmed by a collaborator with the same URL, i.e. the local listener (-oob-listen) reached at
+// the same -oob-url; Interactsh hands out a new URL to every run.
+type PendingCallback struct {
+	CollaboratorURL string                      `json:"collaborator_url"`
+	CorrelationID   string                      `json:"correlation_id"`
+	Finding         scanner.VulnerabilityResult `json:"finding"` // Reported once the callback arrives.
+}
+
+// envelope is the content of a state file. The checksum detects truncated or edited files.
+type envelope struct {
+	Version  int             `json:"version"`
+	SavedAt  time.Time       `json:"saved_at"`
+	Checksum string          `json:"checksum"` // Hex SHA-256 of State.
+	State    json.RawMessage `json:"state"`
+}
+
+// Load reads a state file. Files that are corrupted or were written in another format version
+// are refused with ErrCorrupted or ErrVersionMismatch.
+func Load(path string) (*State, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrCorrupted, path, err)
+	}
+	if env.Version != Version {
+		return nil, fmt.Errorf("%w: %s has version %d, this version of Dursgo reads version %d", ErrVersionMismatch, path, env.Version, Version)
+	}
+	if sum := sha256.Sum256(env.State); len(env.State) == 0 || hex.EncodeToString(sum[:]) != env.Checksum {
+		return nil, fmt.Errorf("%w: %s: checksum mismatch", ErrCorrupted, path)
+	}
+	var st State
+	if err := json.Unmarshal(env.State, &st); err != nil {
+		return nil, fmt.Errorf("%w: %s: %v", ErrCorrupted, path, err)
+	}
+	return &st, nil
+}
+
+// Save writes st to path. The file is replaced atomically, so an interruption while saving
+// leaves the previous state intact.
+func Save(path string, st *State) error {
+	data, err := json.Marshal(st)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, data)
+}
+
+// writeFile writes the marshaled state data to path through a temporary file.
+func writeFile(path string, data []byte) error {
+	sum := sha256.Sum256(data)
+	content, err := json.Marshal(envelope{Version: Version, SavedAt: time.Now().UTC(), Checksum: hex.EncodeToString(sum[:]), State: data})
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // No-op once renamed.
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Store holds the state of a running scan and saves it to its file periodically and on Close.
+// It implements scanner.ProgressTracker. The methods of a nil Store do nothing.
+type Store struct {
+	path    string
+	mu      sync.Mutex // Protects state, tested, crawler and markers.
+	writeMu sync.Mutex // Serializes file writes.
+	state   *State
+	tested  map[string]bool
+	crawler *crawler.Crawler // Snapshotted on every save until the crawl is complete.
+	markers *scanner.StoredMarkers
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewStore creates a Store saving st to path.
+func NewStore(path string, st *State) *Store {
+	s := &Store{path: path, state: st, tested: make(map[string]bool, len(st.Tested))}
+	for _, key := range st.Tested {
+		s.tested[key] = true
+	}
+	return s
+}
+
+// TrackCrawler makes every save include a snapshot of the crawl of c.
+func (s *Store) TrackCrawler(c *crawler.Crawler) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.crawler = c
+}
+
+// TrackStoredMarkers makes every save include the markers recorded in m.
+func (s *Store) TrackStoredMarkers(m *scanner.StoredMarkers) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.markers = m
+}
+
+// CompleteCrawl records the final crawl snapshot and the requests to scan, and saves the state.
+func (s *Store) CompleteCrawl(requests []crawler.ParameterizedRequest) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if s.crawler != nil {
+		s.state.Crawl = s.crawler.Snapshot()
+		s.crawler = nil
+	}
+	s.state.CrawlComplete = true
+	s.state.ScanRequests = requests
+	s.mu.Unlock()
+	return s.Flush()
+}
+
+// CompletePassive records the findings of the passive scanners.
+func (s *Store) CompletePassive(findings []scanner.VulnerabilityResult) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.PassiveComplete = true
+	s.state.PassiveFindings = findings
+}
+
+// SetPendingCallbacks records the out-of-band injections still waiting for their callback,
+// replacing those recorded before.
+func (s *Store) SetPendingCallbacks(pending []PendingCallback) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.PendingCallbacks = pending
+}
+
+// IsTested reports whether the scanner/request pair identified by key has been tested.
+func (s *Store) IsTested(key string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tested[key]
+}
+
+// MarkTested records a tested scanner/request pair and its findings.
+func (s *Store) MarkTested(key string, findings []scanner.VulnerabilityResult) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tested[key] {
+		return
+	}
+	s.tested[key] = true
+	s.state.Tested = append(s.state.Tested, key)
+	s.state.Findings = append(s.state.Findings, findings...)
+}
+
+// Flush saves the state to the file.
+func (s *Store) Flush() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if s.crawler != nil {
+		s.state.Crawl = s.crawler.Snapshot()
+	}
+	if s.markers != nil {
+		s.state.StoredMarkers = s.markers.Markers()
+	}
+	data, err := json.Marshal(s.state)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	return writeFile(s.path, data)
+}
+
+// Start saves the state every interval (DefaultFlushInterval if interval is not positive) until
+// Close. Failed saves are logged.
+func (s *Store) Start(interval time.Duration, log *logger.Logger) {
+	if s == nil {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultFlushInterval
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				if err := s.Flush(); err != nil {
+					log.Warn("Failed to save scan state to %s: %v", s.path, err)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops the periodic saves and saves the state a last time.
+func (s *Store) Close() error {
+	if s == nil {
+		return nil
+	}
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+		s.stop = nil
+	}
+	return s.Flush()
+}
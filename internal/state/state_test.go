@@ -0,0 +1,93 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"Dursgo/internal/crawler"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStoreSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scan.state")
+	store := NewStore(path, &State{Target: "https://example.com", StartedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)})
+	require.NoError(t, store.CompleteCrawl([]crawler.ParameterizedRequest{{Method: "GET", URL: "https://example.com/search?q=<x>", ParamNames: []string{"q"}}}))
+	store.MarkTested("SQLi GET https://example.com/search?q=<x> 0a1b", []scanner.VulnerabilityResult{{VulnerabilityType: "SQL Injection", URL: "https://example.com/search", Parameter: "q"}})
+	store.MarkTested("XSS GET https://example.com/search?q=<x> 0a1b", nil)
+	store.SetPendingCallbacks([]PendingCallback{{
+		CollaboratorURL: "http://oob.example.net:8880",
+		CorrelationID:   "bxss-comment-123456",
+		Finding:         scanner.VulnerabilityResult{VulnerabilityType: "Blind XSS", URL: "https://example.com/contact", Parameter: "comment"},
+	}})
+	markers := scanner.NewStoredMarkers()
+	markers.Add(scanner.StoredMarker{Marker: "dursgostored0123456789", Request: crawler.ParameterizedRequest{Method: "POST", URL: "https://example.com/comments"}, Param: "body"})
+	store.TrackStoredMarkers(markers)
+	require.NoError(t, store.Close())
+
+	st, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com", st.Target)
+	assert.True(t, st.CrawlComplete)
+	assert.Len(t, st.ScanRequests, 1)
+	assert.Len(t, st.Tested, 2)
+	require.Len(t, st.Findings, 1)
+	assert.Equal(t, "q", st.Findings[0].Parameter)
+	require.Len(t, st.PendingCallbacks, 1)
+	assert.Equal(t, "bxss-comment-123456", st.PendingCallbacks[0].CorrelationID)
+	assert.Equal(t, "comment", st.PendingCallbacks[0].Finding.Parameter)
+	require.Len(t, st.StoredMarkers, 1)
+	assert.Equal(t, "https://example.com/comments", st.StoredMarkers[0].Request.URL)
+
+	resumed := NewStore(path, st)
+	assert.True(t, resumed.IsTested("XSS GET https://example.com/search?q=<x> 0a1b"))
+	assert.False(t, resumed.IsTested("LFI GET https://example.com/search?q=<x> 0a1b"))
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary files left behind")
+}
+
+func TestLoadRefusesBadFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "scan.state")
+	require.NoError(t, Save(path, &State{Target: "https://example.com", Tested: []string{"a", "b"}}))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	truncated := filepath.Join(dir, "truncated.state")
+	require.NoError(t, os.WriteFile(truncated, data[:len(data)/2], 0644))
+	_, err = Load(truncated)
+	assert.ErrorIs(t, err, ErrCorrupted)
+
+	edited := filepath.Join(dir, "edited.state")
+	require.NoError(t, os.WriteFile(edited, []byte(strings.Replace(string(data), `"b"`, `"c"`, 1)), 0644))
+	_, err = Load(edited)
+	assert.ErrorIs(t, err, ErrCorrupted)
+
+	var env map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &env))
+	env["version"] = Version + 1
+	newer, err := json.Marshal(env)
+	require.NoError(t, err)
+	future := filepath.Join(dir, "future.state")
+	require.NoError(t, os.WriteFile(future, newer, 0644))
+	_, err = Load(future)
+	assert.ErrorIs(t, err, ErrVersionMismatch)
+
+	_, err = Load(filepath.Join(dir, "missing.state"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func TestNilStore(t *testing.T) {
+	var store *Store
+	assert.False(t, store.IsTested("key"))
+	store.MarkTested("key", nil)
+	assert.NoError(t, store.Close())
+}
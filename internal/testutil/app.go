@@ -0,0 +1,276 @@
+// Package testutil serves a vulnerable web application for the tests of scanner modules. An App
+// is an httptest.Server whose endpoints simulate the behaviors scanners detect: SQL error pages,
+// boolean-differential and sleeping queries, queries listing extra rows, reflected input, an
+// injectable login form and soft 404s, next to safe endpoints for the negative cases.
+//
+// A scanner package tests its detection end-to-end by scanning an endpoint that has the flaw and
+// one that does not:
+//
+//	app := testutil.NewApp(t, testutil.SQLError("/item", "id"), testutil.Safe("/safe", "id"))
+//	log := logger.NewLogger(logger.ERROR)
+//	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: app.URL("/")})
+//	findings, err := NewScanner().Scan(ctx, app.Request("GET", "/item?id=1"), client, log, opts)
+//	// ... and expect no findings for app.Request("GET", "/safe?id=1").
+//
+// Endpoints read their parameter from the query string or a form body. Injectable ones append
+// it to a simulated MySQL query (see Query), so payloads behave as on a real database: a quote
+// that is not closed is a syntax error, "' AND '1'='2" matches no row and "' OR 1=1-- -" every
+// row. Scanners needing another behavior register it with App.Handle.
+package testutil
+
+import (
+	"Dursgo/internal/crawler"
+	"fmt"
+	"html"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// App is a vulnerable web application served for a test.
+type App struct {
+	server   *httptest.Server
+	mux      *http.ServeMux
+	mu       sync.Mutex
+	requests map[string]int // Requests per path.
+	notFound http.HandlerFunc
+	sessions map[string]string // Session cookie values of the logged-in users.
+}
+
+// Option adds an endpoint or a behavior to an App.
+type Option func(*App)
+
+// NewApp starts an App with the given endpoints. It is closed when the test ends. Paths
+// without an endpoint answer 404 Not Found, or a soft 404 with Soft404.
+func NewApp(t testing.TB, options ...Option) *App {
+	t.Helper()
+	a := &App{
+		mux:      http.NewServeMux(),
+		requests: make(map[string]int),
+		notFound: http.NotFound,
+		sessions: make(map[string]string),
+	}
+	a.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) { a.notFound(w, r) })
+	for _, option := range options {
+		option(a)
+	}
+	a.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		a.mu.Lock()
+		a.requests[r.URL.Path]++
+		a.mu.Unlock()
+		a.mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(a.server.Close)
+	return a
+}
+
+// Handle registers a custom endpoint, for behaviors the options do not cover. It must be called
+// from an Option.
+func (a *App) Handle(path string, handler http.HandlerFunc) {
+	a.mux.HandleFunc(path, handler)
+}
+
+// URL returns the absolute URL of path (with its query, if any) on the App.
+func (a *App) URL(path string) string {
+	return a.server.URL + path
+}
+
+// Request returns the crawled request scanners test for method and path: the parameters of
+// the query string of path, sent in a form body for POST.
+func (a *App) Request(method, path string) crawler.ParameterizedRequest {
+	u, err := url.Parse(a.URL(path))
+	if err != nil {
+		panic(fmt.Sprintf("testutil: invalid path %q: %v", path, err))
+	}
+	req := crawler.ParameterizedRequest{Method: method, URL: u.String()}
+	for name := range u.Query() {
+		req.ParamNames = append(req.ParamNames, name)
+	}
+	sort.Strings(req.ParamNames)
+	if method == http.MethodPost {
+		req.FormPostData = u.RawQuery
+		u.RawQuery = ""
+		req.URL = u.String()
+	}
+	return req
+}
+
+// Requests returns the number of requests the App received for path.
+func (a *App) Requests(path string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.requests[path]
+}
+
+// page writes an HTML page with body.
+func page(w http.ResponseWriter, status int, title, body string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<html><head><title>%s</title></head><body><h1>%s</h1>%s</body></html>", title, title, body)
+}
+
+// productList renders the rows of a product query.
+func productList(rows []Product) string {
+	if len(rows) == 0 {
+		return "<p>No products found.</p>"
+	}
+	var b strings.Builder
+	b.WriteString("<table>")
+	for _, p := range rows {
+		fmt.Fprintf(&b, "<tr><td>%s</td><td>%s</td><td>%s</td></tr>", p.ID, html.EscapeString(p.Name), html.EscapeString(p.Description))
+	}
+	b.WriteString("</table>")
+	return b.String()
+}
+
+// SQLError serves an error-based SQL injection: param is appended to a product query, and a
+// syntax error shows the MySQL error message in a 500 response.
+func SQLError(path, param string) Option {
+	return func(a *App) {
+		a.Handle(path, func(w http.ResponseWriter, r *http.Request) {
+			result := Query(r.FormValue(param))
+			if result.Err != nil {
+				page(w, http.StatusInternalServerError, "Error", "<pre>"+html.EscapeString(result.Err.Error())+"</pre>")
+				return
+			}
+			page(w, http.StatusOK, "Products", productList(result.Rows))
+		})
+	}
+}
+
+// BooleanSQLi serves a boolean-based blind SQL injection: param is appended to a query showing
+// the first matching product, so a false condition empties the page while a true one leaves it
+// as it is. Errors are not shown.
+func BooleanSQLi(path, param string) Option {
+	return func(a *App) {
+		a.Handle(path, func(w http.ResponseWriter, r *http.Request) {
+			rows := Query(r.FormValue(param)).Rows
+			if len(rows) > 1 {
+				rows = rows[:1] // LIMIT 1
+			}
+			page(w, http.StatusOK, "Product", productList(rows))
+		})
+	}
+}
+
+// TimeSQLi serves a time-based blind SQL injection: param is appended to a query whose result
+// is not shown, and SLEEP(n) delays the response by n seconds.
+func TimeSQLi(path, param string) Option {
+	return func(a *App) {
+		a.Handle(path, func(w http.ResponseWriter, r *http.Request) {
+			result := Query(r.FormValue(param))
+			if result.Sleep > 0 {
+				select {
+				case <-time.After(result.Sleep):
+				case <-r.Context().Done():
+				}
+			}
+			page(w, http.StatusOK, "Thank you", "<p>Your request was recorded.</p>")
+		})
+	}
+}
+
+// ContentSQLi serves an SQL injection visible in the amount of content: param is appended to a
+// query listing every matching product, so a true OR condition lists the whole table. Errors
+// are not shown.
+func ContentSQLi(path, param string) Option {
+	return func(a *App) {
+		a.Handle(path, func(w http.ResponseWriter, r *http.Request) {
+			page(w, http.StatusOK, "Products", productList(Query(r.FormValue(param)).Rows))
+		})
+	}
+}
+
+// Safe serves the negative case of the SQL injection endpoints: the product whose ID is param,
+// looked up with a parameterized query. Other values show no product.
+func Safe(path, param string) Option {
+	return func(a *App) {
+		a.Handle(path, func(w http.ResponseWriter, r *http.Request) {
+			page(w, http.StatusOK, "Product", productList(Lookup(r.FormValue(param))))
+		})
+	}
+}
+
+// Reflect serves a reflected XSS: param is written into the page without escaping.
+func Reflect(path, param string) Option {
+	return func(a *App) {
+		a.Handle(path, func(w http.ResponseWriter, r *http.Request) {
+			page(w, http.StatusOK, "Search", "<p>You searched for: "+r.FormValue(param)+"</p>")
+		})
+	}
+}
+
+// Escaped serves the negative case of Reflect: param is HTML-escaped.
+func Escaped(path, param string) Option {
+	return func(a *App) {
+		a.Handle(path, func(w http.ResponseWriter, r *http.Request) {
+			page(w, http.StatusOK, "Search", "<p>You searched for: "+html.EscapeString(r.FormValue(param))+"</p>")
+		})
+	}
+}
+
+// Login serves a login form at path checking the username and password fields against the
+// Users table. A valid login sets a session cookie and redirects to path + "/account", which
+// greets the user with a logout link, or redirects back to path without a session. With injectable, the
+// username is appended to the query (see LoginQuery), so "admin'-- -" logs in as admin without
+// the password.
+func Login(path string, injectable bool) Option {
+	return func(a *App) {
+		a.Handle(path, func(w http.ResponseWriter, r *http.Request) {
+			form := `<form method="POST"><input name="username"><input type="password" name="password"><button>Sign in</button></form>`
+			if r.Method != http.MethodPost {
+				page(w, http.StatusOK, "Sign in", form)
+				return
+			}
+			username, password := r.FormValue("username"), r.FormValue("password")
+			var user string
+			if injectable {
+				user = LoginQuery(username, password)
+			} else {
+				for _, u := range Users {
+					if u.Username == username && u.Password == password {
+						user = username
+					}
+				}
+			}
+			if user == "" {
+				page(w, http.StatusOK, "Sign in", "<p>Invalid username or password.</p>"+form)
+				return
+			}
+			session := fmt.Sprintf("s%d", time.Now().UnixNano())
+			a.mu.Lock()
+			a.sessions[session] = user
+			a.mu.Unlock()
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: session, Path: "/", HttpOnly: true})
+			http.Redirect(w, r, path+"/account", http.StatusFound)
+		})
+		a.Handle(path+"/account", func(w http.ResponseWriter, r *http.Request) {
+			var user string
+			if cookie, err := r.Cookie("session"); err == nil {
+				a.mu.Lock()
+				user = a.sessions[cookie.Value]
+				a.mu.Unlock()
+			}
+			if user == "" {
+				http.Redirect(w, r, path, http.StatusFound)
+				return
+			}
+			page(w, http.StatusOK, "My account", "<p>Welcome "+html.EscapeString(user)+`.</p><a href="/logout">Logout</a>`)
+		})
+	}
+}
+
+// Soft404 answers paths without an endpoint with a 200 "not found" page naming the path,
+// as applications with a catch-all route do.
+func Soft404() Option {
+	return func(a *App) {
+		a.notFound = func(w http.ResponseWriter, r *http.Request) {
+			page(w, http.StatusOK, "Oops", "<p>Sorry, "+html.EscapeString(r.URL.Path)+" could not be found. Try the search.</p>")
+		}
+	}
+}
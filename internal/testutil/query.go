@@ -0,0 +1,199 @@
+package testutil
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Product is a row of the Products table.
+type Product struct {
+	ID          string
+	Name        string
+	Description string
+}
+
+// Products is the table the product queries of an App read.
+var Products = []Product{
+	{"1", "Widget", "A small widget for everyday use."},
+	{"2", "Gadget", "A gadget with two buttons and a light."},
+	{"3", "Gizmo", "A gizmo that does a bit of everything."},
+	{"4", "Doohickey", "A doohickey, sold by the dozen."},
+	{"5", "Thingamajig", "The thingamajig you did not know you needed."},
+}
+
+// User is an account of the login form.
+type User struct {
+	Username string
+	Password string
+}
+
+// Users are the accounts of the login form; a login bypass selects the first.
+var Users = []User{{"admin", "s3cret-Passw0rd"}, {"alice", "correct-horse"}}
+
+// QueryResult is the outcome of a simulated query.
+type QueryResult struct {
+	Rows  []Product
+	Err   error         // Syntax error, with a MySQL error message.
+	Sleep time.Duration // Time the query slept (SLEEP(n) in the injection).
+}
+
+// mysqlFunctions are the functions the simulated database knows; calling another one is an
+// error, as DBMS fingerprinting probes expect.
+var mysqlFunctions = map[string]bool{
+	"SLEEP": true, "BENCHMARK": true, "IF": true, "ASCII": true, "SUBSTRING": true, "LENGTH": true, "CONCAT": true,
+	"VERSION": true, "USER": true, "CURRENT_USER": true, "DATABASE": true, "CONNECTION_ID": true, "MD5": true, "SELECT": true,
+}
+
+var (
+	functionCall = regexp.MustCompile(`\b(\w+)\(`)
+	comparison   = regexp.MustCompile(`(?i)\b(AND|OR)\s*\(?\s*(['"]?)(\w+)['"]?\s*=\s*(['"]?)(\w+)`)
+	sleepCall    = regexp.MustCompile(`(?i)\bSLEEP\((\d+)\)`)
+)
+
+// injection is the SQL a value injects into a query after the string literal it breaks out
+// of with a single quote.
+type injection struct {
+	literal   string // Value before the quote.
+	sql       string // SQL after it, with the closing quote the query adds unless commented.
+	commented bool   // A "--" or "#" comment cuts the rest of the query.
+}
+
+// parseInjection splits value at its first single quote; it reports false for values that stay
+// within the string literal.
+func parseInjection(value string) (injection, bool) {
+	literal, sql, broken := strings.Cut(value, "'")
+	if !broken {
+		return injection{}, false
+	}
+	i := injection{literal: literal, sql: sql}
+	for _, comment := range []string{"--", "#"} {
+		if at := strings.Index(i.sql, comment); at >= 0 {
+			i.sql, i.commented = i.sql[:at], true
+		}
+	}
+	if !i.commented {
+		i.sql += "'"
+	}
+	return i, true
+}
+
+// check reports the MySQL error the injected SQL causes: unbalanced quotes or parentheses, or
+// an unknown function.
+func (i injection) check() error {
+	syntaxError := errors.New("You have an error in your SQL syntax; check the manual that corresponds to your MySQL server version for the right syntax to use near '" + i.sql + "' at line 1")
+	if strings.Count(i.sql, "'")%2 != 0 || strings.Count(i.sql, `"`)%2 != 0 {
+		return syntaxError
+	}
+	depth := 0
+	for _, c := range i.sql {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth--; depth < 0 {
+				return syntaxError
+			}
+		}
+	}
+	if depth != 0 {
+		return syntaxError
+	}
+	for _, m := range functionCall.FindAllStringSubmatch(i.sql, -1) {
+		if !mysqlFunctions[strings.ToUpper(m[1])] {
+			return errors.New("FUNCTION shop." + m[1] + " does not exist")
+		}
+	}
+	return nil
+}
+
+// conditions evaluates the injected comparisons: none reports a false "AND x=y", which matches
+// no row, and all a true "OR x=y", which matches every row.
+func (i injection) conditions() (none, all bool) {
+	for _, m := range comparison.FindAllStringSubmatch(i.sql, -1) {
+		equal := m[3] == m[5]
+		switch strings.ToUpper(m[1]) {
+		case "AND":
+			none = none || !equal
+		case "OR":
+			all = all || equal
+		}
+	}
+	return none, all
+}
+
+// Query runs value through the simulated query SELECT * FROM products WHERE id='<value>' of a
+// MySQL database. The value is a plain ID until a single quote ends the string; what follows is
+// SQL: the quotes and parentheses must balance (the query adds a closing quote unless a "--"
+// or "#" comment cuts it), functions must exist, a false "AND x=y" matches no row, a true
+// "OR x=y" every row and SLEEP(n) sleeps n seconds.
+func Query(value string) QueryResult {
+	i, broken := parseInjection(value)
+	if !broken {
+		return QueryResult{Rows: Lookup(value)}
+	}
+	if err := i.check(); err != nil {
+		return QueryResult{Err: err}
+	}
+	var result QueryResult
+	switch none, all := i.conditions(); {
+	case none:
+	case all:
+		result.Rows = Products
+	default:
+		result.Rows = Lookup(i.literal)
+	}
+	if m := sleepCall.FindStringSubmatch(i.sql); m != nil {
+		seconds, _ := strconv.Atoi(m[1])
+		result.Sleep = time.Duration(seconds) * time.Second
+	}
+	return result
+}
+
+// Lookup returns the products whose ID is id, as a parameterized query does.
+func Lookup(id string) []Product {
+	for _, p := range Products {
+		if p.ID == id {
+			return []Product{p}
+		}
+	}
+	return nil
+}
+
+// LoginQuery runs the simulated query SELECT username FROM users WHERE username='<username>'
+// AND password='<password>' and returns the username it selects, or "". Only the username is
+// injectable: commenting out the password check ("admin'-- -") or adding a true OR condition
+// ("' OR 1=1-- -") logs in without the password.
+func LoginQuery(username, password string) string {
+	user := func(name string) (User, bool) {
+		for _, u := range Users {
+			if u.Username == name {
+				return u, true
+			}
+		}
+		return User{}, false
+	}
+	i, broken := parseInjection(username)
+	if !broken {
+		if u, ok := user(username); ok && u.Password == password {
+			return u.Username
+		}
+		return ""
+	}
+	if i.check() != nil {
+		return ""
+	}
+	none, all := i.conditions()
+	u, ok := user(i.literal)
+	switch {
+	case all: // OR binds looser than the AND of the password check.
+		return Users[0].Username
+	case none || !ok:
+		return ""
+	case i.commented || u.Password == password:
+		return u.Username
+	}
+	return ""
+}
@@ -0,0 +1,64 @@
+package testutil
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		value string
+		rows  int
+		err   bool
+		sleep time.Duration
+	}{
+		{value: "1", rows: 1},
+		{value: "1 OR 1=1", rows: 0}, // Inside the string literal.
+		{value: "1'", err: true},
+		{value: "1')", err: true},
+		{value: "1' AND NOPE(1) AND '1'='1", err: true},
+		{value: "1' AND '1'='1", rows: 1},
+		{value: "1' AND '1'='2", rows: 0},
+		{value: "1' OR '1'='1", rows: len(Products)},
+		{value: "1' OR 1=1-- -", rows: len(Products)},
+		{value: "1' OR 1=2#", rows: 1},
+		{value: "1' AND SLEEP(2) AND '1'='1", rows: 1, sleep: 2 * time.Second},
+	}
+	for _, tt := range tests {
+		result := Query(tt.value)
+		assert.Equal(t, tt.err, result.Err != nil, tt.value)
+		assert.Len(t, result.Rows, tt.rows, tt.value)
+		assert.Equal(t, tt.sleep, result.Sleep, tt.value)
+	}
+}
+
+func TestLoginQuery(t *testing.T) {
+	assert.Equal(t, "alice", LoginQuery("alice", "correct-horse"))
+	assert.Empty(t, LoginQuery("alice", "wrong"))
+	assert.Equal(t, "alice", LoginQuery("alice'-- -", "wrong"))
+	assert.Equal(t, "admin", LoginQuery("' OR 1=1-- -", "wrong"))
+	assert.Empty(t, LoginQuery("alice' AND 1=2-- -", "wrong"))
+	assert.Empty(t, LoginQuery("alice'", "correct-horse"))
+}
+
+func TestApp(t *testing.T) {
+	app := NewApp(t, Safe("/item", "id"), Soft404())
+
+	req := app.Request("POST", "/item?id=1&b=2")
+	assert.Equal(t, app.URL("/item"), req.URL)
+	assert.Equal(t, "id=1&b=2", req.FormPostData)
+	assert.Equal(t, []string{"b", "id"}, req.ParamNames)
+
+	resp, err := http.Get(app.URL("/missing"))
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), "/missing could not be found")
+	assert.Equal(t, 1, app.Requests("/missing"))
+}
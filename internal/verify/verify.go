@@ -0,0 +1,242 @@
+// Package verify replays the findings of a report and re-runs the checks that detected them
+// ("dursgo verify"), so a finding can be triaged or confirmed as fixed without rebuilding its
+// requests by hand.
+package verify
+
+import (
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/reporter"
+	"Dursgo/internal/scanner"
+	"Dursgo/internal/scanner/compare"
+	"Dursgo/internal/scanner/timing"
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Statuses of a Result.
+const (
+	Pass = "PASS" // The check succeeded again: the vulnerability is still there.
+	Fail = "FAIL" // The check failed or its requests could not be sent.
+	Skip = "SKIP" // The finding holds neither a reproduction nor a raw request to replay.
+)
+
+// checkEvidence is the check of findings without a Reproduction: their raw request is replayed
+// and the response must contain their evidence.
+const checkEvidence = "evidence"
+
+// Result is the outcome of verifying a finding.
+type Result struct {
+	Status   string // Pass, Fail or Skip.
+	Check    string // Check that was run, e.g. scanner.CheckTiming.
+	Evidence string // Fresh evidence when the check passed, the reason otherwise.
+}
+
+// Verifier replays findings with a client configured like the scan (authentication, proxy, TLS).
+type Verifier struct {
+	client *httpclient.Client
+	log    *logger.Logger
+	// BaselineSamples is the number of requests timing checks measure the normal response time
+	// with. Zero uses timing.DefaultBaselineSamples.
+	BaselineSamples int
+}
+
+// NewVerifier creates a Verifier sending its requests with client.
+func NewVerifier(client *httpclient.Client, log *logger.Logger) *Verifier {
+	return &Verifier{client: client, log: log}
+}
+
+// Verify replays f and re-runs the check of its Reproduction. Findings of scanners that record
+// none are checked by replaying their raw request and looking for their evidence.
+func (v *Verifier) Verify(ctx context.Context, f reporter.Finding) Result {
+	repro := f.Reproduction
+	if repro == nil {
+		request, err := ReplayFromRaw(f)
+		if err != nil {
+			return Result{Status: Skip, Check: checkEvidence, Evidence: err.Error()}
+		}
+		if strings.TrimSpace(f.Evidence) == "" {
+			return Result{Status: Skip, Check: checkEvidence, Evidence: "the finding has no evidence to look for"}
+		}
+		repro = &scanner.Reproduction{Check: checkEvidence, Request: request, Pattern: regexp.QuoteMeta(f.Evidence)}
+	}
+
+	var result Result
+	switch repro.Check {
+	case scanner.CheckPattern, checkEvidence:
+		result = v.checkPattern(ctx, repro)
+	case scanner.CheckTiming:
+		result = v.checkTiming(ctx, repro)
+	case scanner.CheckDiff:
+		result = v.checkDiff(ctx, repro)
+	default:
+		result = Result{Status: Skip, Evidence: fmt.Sprintf("unknown check %q", repro.Check)}
+	}
+	result.Check = repro.Check
+	return result
+}
+
+// checkPattern sends the payload request and matches the pattern against the response.
+func (v *Verifier) checkPattern(ctx context.Context, repro *scanner.Reproduction) Result {
+	re, err := regexp.Compile(repro.Pattern)
+	if err != nil {
+		return Result{Status: Skip, Evidence: fmt.Sprintf("invalid pattern %q: %v", repro.Pattern, err)}
+	}
+	status, body, err := v.send(ctx, repro.Request)
+	if err != nil {
+		return failed(err)
+	}
+	if match := re.FindString(body); match != "" {
+		return Result{Status: Pass, Evidence: fmt.Sprintf("HTTP %d, matched %q", status, match)}
+	}
+	return Result{Status: Fail, Evidence: fmt.Sprintf("HTTP %d, no match for %s", status, repro.Pattern)}
+}
+
+// checkTiming measures a fresh baseline with the request without the payload, then requires the
+// payload request to be delayed by the injected sleep, as the scan did.
+func (v *Verifier) checkTiming(ctx context.Context, repro *scanner.Reproduction) Result {
+	if repro.Baseline == nil || repro.Delay <= 0 {
+		return Result{Status: Skip, Evidence: "the reproduction has no baseline request or delay"}
+	}
+	baseline, ok := timing.MeasureBaseline(v.BaselineSamples, func() (time.Duration, error) {
+		return v.measure(ctx, *repro.Baseline)
+	})
+	if !ok {
+		return Result{Status: Fail, Evidence: "the baseline request failed"}
+	}
+	confirmations, confirmed := timing.ConfirmDelay(baseline, []int{repro.Delay}, func(int) (time.Duration, error) {
+		return v.measure(ctx, repro.Request)
+	})
+	if !confirmed {
+		return Result{Status: Fail, Evidence: fmt.Sprintf("Baseline samples: %s; the %ds sleep was not reproduced", timing.FormatDurations(baseline.Samples), repro.Delay)}
+	}
+	return Result{Status: Pass, Evidence: fmt.Sprintf("Baseline samples: %s; Injected: %s", timing.FormatDurations(baseline.Samples), strings.Join(confirmations, ", "))}
+}
+
+// checkDiff requires the payload response to be similar to the baseline response and the
+// control (e.g., FALSE condition) response to differ from it.
+func (v *Verifier) checkDiff(ctx context.Context, repro *scanner.Reproduction) Result {
+	if repro.Baseline == nil || repro.Control == nil {
+		return Result{Status: Skip, Evidence: "the reproduction has no baseline or control request"}
+	}
+	cmp := compare.New(scanner.ScannerOptions{SimilarityThreshold: repro.Threshold, SimilarityMode: repro.Mode}, v.log, "Verify")
+	bodies := make([]string, 3)
+	for i, request := range []scanner.ReplayRequest{*repro.Baseline, repro.Request, *repro.Control} {
+		_, body, err := v.send(ctx, request)
+		if err != nil {
+			return failed(err)
+		}
+		bodies[i] = body
+	}
+	similar, control := cmp.Similarity(bodies[0], bodies[1]), cmp.Similarity(bodies[0], bodies[2])
+	evidence := fmt.Sprintf("payload response similarity %.3f, control response similarity %.3f (%s mode, threshold %.2f)", similar, control, cmp.Mode, cmp.Threshold)
+	if similar >= cmp.Threshold && control < cmp.Threshold {
+		return Result{Status: Pass, Evidence: evidence}
+	}
+	return Result{Status: Fail, Evidence: evidence}
+}
+
+// send replays request and returns the status code and body of the response. A truncated body
+// is returned as far as it was read.
+func (v *Verifier) send(ctx context.Context, request scanner.ReplayRequest) (int, string, error) {
+	req, err := request.NewRequest(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	body, _, err := v.client.ReadBody(resp)
+	if err != nil && !errors.Is(err, httpclient.ErrBodyTruncated) {
+		return 0, "", err
+	}
+	return resp.StatusCode, string(body), nil
+}
+
+// measure replays request and returns how long the full response took.
+func (v *Verifier) measure(ctx context.Context, request scanner.ReplayRequest) (time.Duration, error) {
+	req, err := request.NewRequest(ctx)
+	if err != nil {
+		return 0, err
+	}
+	duration, _, _, err := timing.MeasureRequest(v.client, req)
+	return duration, err
+}
+
+// failed is the result of a check whose requests could not be sent.
+func failed(err error) Result {
+	return Result{Status: Fail, Evidence: fmt.Sprintf("request failed: %v", err)}
+}
+
+// rawOnlyHeaders are the headers of raw requests that the replaying client sets itself: the
+// session of the scan is replaced with the one configured for the verification.
+var rawOnlyHeaders = []string{"Authorization", "Cookie", "Content-Length", "Accept-Encoding", "User-Agent", "Connection"}
+
+// ReplayFromRaw rebuilds the request of a finding from its raw request dump, without the
+// session headers of the scan (see rawOnlyHeaders).
+func ReplayFromRaw(f reporter.Finding) (scanner.ReplayRequest, error) {
+	if strings.TrimSpace(f.RawRequest) == "" {
+		return scanner.ReplayRequest{}, errors.New("the finding has no reproduction data or raw request (raw dumps excluded, or not recorded by its scanner)")
+	}
+	raw := f.RawRequest
+	if !strings.Contains(raw, "\r\n") {
+		raw = strings.ReplaceAll(raw, "\n", "\r\n")
+	}
+	req, err := http.ReadRequest(bufio.NewReader(strings.NewReader(raw)))
+	if err != nil {
+		return scanner.ReplayRequest{}, fmt.Errorf("parse raw request: %w", err)
+	}
+	body, _ := io.ReadAll(req.Body)
+
+	scheme := "https"
+	if u, err := url.Parse(f.URL); err == nil && u.Scheme != "" {
+		scheme = u.Scheme
+	}
+	request := scanner.ReplayRequest{Method: req.Method, URL: scheme + "://" + req.Host + req.RequestURI, Body: string(body)}
+	for _, name := range rawOnlyHeaders {
+		req.Header.Del(name)
+	}
+	if len(req.Header) > 0 {
+		request.Headers = make(map[string]string, len(req.Header))
+		for name, values := range req.Header {
+			request.Headers[name] = strings.Join(values, ", ")
+		}
+	}
+	return request, nil
+}
+
+// Select returns the findings matching key: a finding ID, a fingerprint (every finding having
+// it) or the 1-based index of a finding in the report. An empty key selects every finding.
+func Select(findings []reporter.Finding, key string) ([]reporter.Finding, error) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return findings, nil
+	}
+	var selected []reporter.Finding
+	for _, f := range findings {
+		if f.ID == key || f.Fingerprint == key {
+			selected = append(selected, f)
+		}
+	}
+	if len(selected) > 0 {
+		return selected, nil
+	}
+	if index, err := strconv.Atoi(key); err == nil {
+		if index < 1 || index > len(findings) {
+			return nil, fmt.Errorf("finding index %d out of range; the report has %d finding(s)", index, len(findings))
+		}
+		return findings[index-1 : index], nil
+	}
+	return nil, fmt.Errorf("no finding with ID or fingerprint %q", key)
+}
@@ -0,0 +1,131 @@
+package verify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Dursgo/internal/httpclient"
+	"Dursgo/internal/logger"
+	"Dursgo/internal/reporter"
+	"Dursgo/internal/scanner"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestVerifier returns a Verifier for a server answering with handler.
+func newTestVerifier(t *testing.T, handler http.HandlerFunc) (*Verifier, string) {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	log := logger.NewLogger(logger.ERROR)
+	client := httpclient.NewClient(log, httpclient.ClientOptions{TargetBaseURL: srv.URL, AuthCookie: "session=abc"})
+	return NewVerifier(client, log), srv.URL
+}
+
+func TestVerifyPattern(t *testing.T) {
+	fixed := false
+	v, base := newTestVerifier(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "session=abc", r.Header.Get("Cookie"), "the configured session is sent")
+		if !fixed && strings.Contains(r.URL.Query().Get("id"), "'") {
+			fmt.Fprint(w, "You have an error in your SQL syntax near ''1''")
+			return
+		}
+		fmt.Fprint(w, "item 1")
+	})
+	f := reporter.Finding{Reproduction: &scanner.Reproduction{
+		Check:   scanner.CheckPattern,
+		Request: scanner.ReplayRequest{Method: "GET", URL: base + "/item?id=1'"},
+		Pattern: `You have an error in your SQL syntax`,
+	}}
+
+	result := v.Verify(context.Background(), f)
+	assert.Equal(t, Pass, result.Status)
+	assert.Equal(t, scanner.CheckPattern, result.Check)
+	assert.Contains(t, result.Evidence, "HTTP 200")
+
+	fixed = true
+	assert.Equal(t, Fail, v.Verify(context.Background(), f).Status)
+}
+
+func TestVerifyDiff(t *testing.T) {
+	v, base := newTestVerifier(t, func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.RawQuery, "1%3D2") {
+			fmt.Fprint(w, "<html><body>No results.</body></html>")
+			return
+		}
+		fmt.Fprint(w, "<html><body><h1>Item</h1><p>A long description of the first item in the catalogue.</p></body></html>")
+	})
+	request := func(query string) scanner.ReplayRequest {
+		return scanner.ReplayRequest{Method: "GET", URL: base + "/item?" + query}
+	}
+	baseline, control := request("id=1"), request("id=1%27+AND+1%3D2--")
+	f := reporter.Finding{Reproduction: &scanner.Reproduction{
+		Check:    scanner.CheckDiff,
+		Request:  request("id=1%27+AND+1%3D1--"),
+		Baseline: &baseline,
+		Control:  &control,
+	}}
+
+	result := v.Verify(context.Background(), f)
+	assert.Equal(t, Pass, result.Status, result.Evidence)
+
+	f.Reproduction.Control = &baseline
+	assert.Equal(t, Fail, v.Verify(context.Background(), f).Status, "a control response like the baseline disproves the finding")
+
+	f.Reproduction.Baseline = nil
+	assert.Equal(t, Skip, v.Verify(context.Background(), f).Status)
+}
+
+func TestVerifyReplaysRawRequest(t *testing.T) {
+	v, base := newTestVerifier(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "session=abc", r.Header.Get("Cookie"), "the recorded session is replaced")
+		assert.Equal(t, "yes", r.Header.Get("X-Test"))
+		fmt.Fprintf(w, "<p>%s</p>", r.URL.Query().Get("q"))
+	})
+	host := strings.TrimPrefix(base, "http://")
+	f := reporter.Finding{
+		URL:        base + "/search",
+		Evidence:   "<script>alert(1)</script>",
+		RawRequest: "GET /search?q=%3Cscript%3Ealert(1)%3C%2Fscript%3E HTTP/1.1\nHost: " + host + "\nCookie: session=old\nX-Test: yes\n\n",
+	}
+
+	result := v.Verify(context.Background(), f)
+	assert.Equal(t, Pass, result.Status, result.Evidence)
+	assert.Equal(t, checkEvidence, result.Check)
+
+	f.RawRequest = ""
+	assert.Equal(t, Skip, v.Verify(context.Background(), f).Status)
+}
+
+func TestSelect(t *testing.T) {
+	findings := []reporter.Finding{
+		{ID: "a1", Fingerprint: "fp1"},
+		{ID: "b2", Fingerprint: "fp2"},
+		{ID: "c3", Fingerprint: "fp1"},
+	}
+
+	selected, err := Select(findings, "")
+	require.NoError(t, err)
+	assert.Len(t, selected, 3)
+
+	selected, err = Select(findings, "b2")
+	require.NoError(t, err)
+	assert.Equal(t, findings[1:2], selected)
+
+	selected, err = Select(findings, "fp1")
+	require.NoError(t, err)
+	assert.Equal(t, []reporter.Finding{findings[0], findings[2]}, selected)
+
+	selected, err = Select(findings, "3")
+	require.NoError(t, err)
+	assert.Equal(t, findings[2:], selected)
+
+	_, err = Select(findings, "4")
+	assert.EqualError(t, err, "finding index 4 out of range; the report has 3 finding(s)")
+	_, err = Select(findings, "zz")
+	assert.Error(t, err)
+}